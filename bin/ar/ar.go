@@ -0,0 +1,190 @@
+// Package ar provides access to static libraries in ar archive format, as used
+// by Unix (*.a) and Microsoft (*.lib) toolchains.
+package ar
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/bin/coff"
+	"github.com/decomp/exp/bin/elf"
+	"github.com/pkg/errors"
+)
+
+// Magic prefix of ar archives.
+//
+//    21 3C 61 72 63 68 3E 0A  |!<arch>.|
+const magic = "!<arch>\n"
+
+// A Member is an object file member of an archive.
+type Member struct {
+	// Name of the member (e.g. "foo.o" or "d:\build\obj\foo.obj").
+	Name string
+	// Contents of the member.
+	Data []byte
+}
+
+// IsArchive reports whether the given file is an ar archive.
+func IsArchive(r io.ReaderAt) bool {
+	buf := make([]byte, len(magic))
+	if _, err := r.ReadAt(buf, 0); err != nil {
+		return false
+	}
+	return string(buf) == magic
+}
+
+// ParseFile parses the given archive, reading from path.
+func ParseFile(path string) ([]*Member, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse parses the object file members of the given archive, reading from r.
+// Symbol tables, long name tables and import library members are skipped.
+//
+// Users are responsible for closing r.
+func Parse(r io.ReaderAt) ([]*Member, error) {
+	if !IsArchive(r) {
+		return nil, errors.New("invalid ar archive; missing magic prefix")
+	}
+	// Size in bytes of archive member headers.
+	const hdrSize = 60
+	var (
+		members []*Member
+		// Long name table of GNU and MSVC archives.
+		longNames []byte
+	)
+	off := int64(len(magic))
+	for {
+		var hdr [hdrSize]byte
+		n, err := r.ReadAt(hdr[:], off)
+		if n == 0 && err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read member header at offset 0x%X", off)
+		}
+		if string(hdr[58:60]) != "`\n" {
+			return nil, errors.Errorf("invalid member header at offset 0x%X; missing terminator", off)
+		}
+		rawName := strings.TrimRight(string(hdr[0:16]), " ")
+		size, err := strconv.ParseInt(strings.TrimSpace(string(hdr[48:58])), 10, 64)
+		if err != nil || size < 0 {
+			return nil, errors.Errorf("invalid size of member at offset 0x%X", off)
+		}
+		// Read member data incrementally, as the size may exceed the archive.
+		data, err := ioutil.ReadAll(io.NewSectionReader(r, off+hdrSize, size))
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read member %q at offset 0x%X", rawName, off)
+		}
+		if int64(len(data)) != size {
+			return nil, errors.Errorf("unable to read member %q at offset 0x%X; size 0x%X exceeds archive", rawName, off, size)
+		}
+		// Member data is 2-byte aligned.
+		off += hdrSize + size + size&1
+		var name string
+		switch {
+		case rawName == "/", rawName == "/SYM64/", rawName == "__.SYMDEF", rawName == "__.SYMDEF SORTED", rawName == "/<ECSYMBOLS>/":
+			// skip symbol tables.
+			continue
+		case rawName == "//":
+			longNames = data
+			continue
+		case strings.HasPrefix(rawName, "#1/"):
+			// BSD long name, stored in front of member data.
+			n, err := strconv.Atoi(rawName[len("#1/"):])
+			if err != nil || n > len(data) {
+				return nil, errors.Errorf("invalid BSD long name %q of member", rawName)
+			}
+			name = strings.TrimRight(string(data[:n]), "\x00")
+			data = data[n:]
+			if strings.HasPrefix(name, "__.SYMDEF") {
+				// skip symbol tables with BSD long names (e.g. as written by
+				// llvm-ar).
+				continue
+			}
+		case strings.HasPrefix(rawName, "/"):
+			// GNU or MSVC long name, stored in long name table.
+			i, err := strconv.Atoi(rawName[len("/"):])
+			if err != nil || i >= len(longNames) {
+				return nil, errors.Errorf("invalid long name reference %q of member", rawName)
+			}
+			name = parseLongName(longNames[i:])
+		default:
+			name = strings.TrimSuffix(rawName, "/")
+		}
+		if isShortImport(data) {
+			// skip short import members of import libraries.
+			continue
+		}
+		member := &Member{
+			Name: name,
+			Data: data,
+		}
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+// BaseName returns the base name of the member, with directory and extension
+// removed.
+func (m *Member) BaseName() string {
+	name := path.Base(strings.Replace(m.Name, `\`, "/", -1))
+	return strings.TrimSuffix(name, path.Ext(name))
+}
+
+// Parse parses the object file of the member, placing its first section at the
+// given base address.
+func (m *Member) Parse(base bin.Address) (*bin.File, error) {
+	r := bytes.NewReader(m.Data)
+	switch {
+	case bytes.HasPrefix(m.Data, []byte("\x7FELF")):
+		return elf.ParseAt(r, base)
+	case bytes.HasPrefix(m.Data, []byte("\x4C\x01")), bytes.HasPrefix(m.Data, []byte("\x64\x86")):
+		return coff.ParseAt(r, base)
+	default:
+		return nil, errors.Errorf("unknown object file format of member %q", m.Name)
+	}
+}
+
+// End returns the end address of the given file, i.e. the address following
+// its last section; used to place archive members consecutively in memory.
+func End(file *bin.File) bin.Address {
+	var end bin.Address
+	for _, sect := range file.Sections {
+		if e := sect.Addr + bin.Address(sect.MemSize); e > end {
+			end = e
+		}
+	}
+	return end
+}
+
+// ### [ Helper functions ] ####################################################
+
+// parseLongName parses the long name at the start of the given long name table
+// entry; terminated by "/\n" in GNU archives and by NULL in MSVC archives.
+func parseLongName(data []byte) string {
+	end := bytes.IndexAny(data, "\x00\n")
+	if end == -1 {
+		end = len(data)
+	}
+	return strings.TrimSuffix(string(data[:end]), "/")
+}
+
+// isShortImport reports whether the given member data is a short import object
+// of an MSVC import library.
+//
+//    00 00 FF FF  |....|
+func isShortImport(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("\x00\x00\xFF\xFF"))
+}
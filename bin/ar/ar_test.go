@@ -0,0 +1,151 @@
+package ar_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/bin/ar"
+)
+
+// member specifies the expected contents of an archive member.
+type member struct {
+	name     string
+	baseName string
+	// Size of member data.
+	size int
+	// Machine architecture and exports of the object file.
+	arch    bin.Arch
+	exports map[bin.Address]string
+}
+
+func TestParse(t *testing.T) {
+	foo := member{name: "foo.o", baseName: "foo", size: 672, arch: bin.ArchX86_32, exports: map[bin.Address]string{0x10000: "main"}}
+	long := member{name: "a_very_long_member_name.o", baseName: "a_very_long_member_name", size: 1032, arch: bin.ArchX86_64, exports: map[bin.Address]string{0x10000: "main"}}
+	bar := member{name: "bar.obj", baseName: "bar", size: 424, arch: bin.ArchX86_32, exports: map[bin.Address]string{0x10000: "_main"}}
+	// Members of import library, holding import descriptors.
+	kernel32 := func(size int) member {
+		return member{name: "kernel32.dll", baseName: "kernel32", size: size, arch: bin.ArchX86_32, exports: map[bin.Address]string{}}
+	}
+	golden := []struct {
+		path    string
+		members []member
+	}{
+		// GNU archive with symbol table and long name table.
+		{path: "testdata/gnu.a", members: []member{foo, long}},
+		// BSD archive with long names in front of member data.
+		{path: "testdata/bsd.a", members: []member{foo, long}},
+		// MSVC library; the short import member of the import library is skipped.
+		{
+			path: "testdata/msvc.lib",
+			members: []member{
+				bar,
+				{name: "a_very_long_object_name.obj", baseName: "a_very_long_object_name", size: 424, arch: bin.ArchX86_32, exports: map[bin.Address]string{0x10000: "_main"}},
+				kernel32(373),
+				kernel32(127),
+				kernel32(156),
+			},
+		},
+	}
+	for _, g := range golden {
+		members, err := ar.ParseFile(g.path)
+		if err != nil {
+			t.Errorf("%q: unable to parse archive; %+v", g.path, err)
+			continue
+		}
+		if len(members) != len(g.members) {
+			t.Errorf("%q: number of members mismatch; expected %d, got %d", g.path, len(g.members), len(members))
+			continue
+		}
+		for i, want := range g.members {
+			m := members[i]
+			if m.Name != want.name {
+				t.Errorf("%q: member %d: name mismatch; expected %q, got %q", g.path, i, want.name, m.Name)
+			}
+			if got := m.BaseName(); got != want.baseName {
+				t.Errorf("%q: member %q: base name mismatch; expected %q, got %q", g.path, want.name, want.baseName, got)
+			}
+			if len(m.Data) != want.size {
+				t.Errorf("%q: member %q: size mismatch; expected %d, got %d", g.path, want.name, want.size, len(m.Data))
+			}
+			file, err := m.Parse(0x10000)
+			if err != nil {
+				t.Errorf("%q: member %q: unable to parse object file; %+v", g.path, want.name, err)
+				continue
+			}
+			if file.Arch != want.arch {
+				t.Errorf("%q: member %q: machine architecture mismatch; expected %v, got %v", g.path, want.name, want.arch, file.Arch)
+			}
+			if len(file.Exports) != len(want.exports) {
+				t.Errorf("%q: member %q: number of exports mismatch; expected %d, got %d", g.path, want.name, len(want.exports), len(file.Exports))
+			}
+			for addr, name := range want.exports {
+				if file.Exports[addr] != name {
+					t.Errorf("%q: member %q: export at %v mismatch; expected %q, got %q", g.path, want.name, addr, name, file.Exports[addr])
+				}
+			}
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	golden := []struct {
+		path string
+		desc string
+	}{
+		{path: "testdata/invalid/magic.a", desc: "missing magic prefix"},
+		{path: "testdata/invalid/size.a", desc: "invalid size"},
+		{path: "testdata/invalid/oversized.a", desc: "size exceeding archive"},
+		{path: "testdata/invalid/longnames.a", desc: "long name without long name table"},
+		{path: "testdata/invalid/bsdname.a", desc: "BSD long name exceeding member"},
+	}
+	for _, g := range golden {
+		if _, err := ar.ParseFile(g.path); err == nil {
+			t.Errorf("%q: %s; expected error, got nil", g.path, g.desc)
+		}
+	}
+}
+
+func TestParseTruncated(t *testing.T) {
+	buf, err := ioutil.ReadFile("testdata/gnu.a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := []struct {
+		size int
+		desc string
+	}{
+		{size: 4, desc: "magic prefix"},
+		{size: 0x28, desc: "member header of symbol table"},
+		{size: 0x80, desc: "member header of long name table"},
+		{size: 0x200, desc: "member data of foo.o"},
+		{size: len(buf) - 1, desc: "member data of a_very_long_member_name.o"},
+	}
+	for _, g := range golden {
+		if _, err := ar.Parse(bytes.NewReader(buf[:g.size])); err == nil {
+			t.Errorf("truncated within %s (0x%X bytes); expected error, got nil", g.desc, g.size)
+		}
+	}
+}
+
+func TestMember(t *testing.T) {
+	golden := []struct {
+		name     string
+		baseName string
+	}{
+		{name: "foo.o", baseName: "foo"},
+		{name: "obj/foo.o", baseName: "foo"},
+		// Path of MSVC library member.
+		{name: `d:\build\obj\bar.obj`, baseName: "bar"},
+	}
+	for _, g := range golden {
+		m := &ar.Member{Name: g.name, Data: []byte("FOO")}
+		if got := m.BaseName(); got != g.baseName {
+			t.Errorf("%q: base name mismatch; expected %q, got %q", g.name, g.baseName, got)
+		}
+		if _, err := m.Parse(0x10000); err == nil {
+			t.Errorf("%q: expected error for member of unknown object file format, got nil", g.name)
+		}
+	}
+}
@@ -4,9 +4,9 @@ package bin
 
 import "strconv"
 
-const _Arch_name = "x86_32x86_64MIPS_32PowerPC_32"
+const _Arch_name = "x86_32x86_64MIPS_32PowerPC_32x86_16"
 
-var _Arch_index = [...]uint8{0, 6, 12, 19, 29}
+var _Arch_index = [...]uint8{0, 6, 12, 19, 29, 35}
 
 func (i Arch) String() string {
 	i -= 1
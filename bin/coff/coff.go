@@ -0,0 +1,402 @@
+// Package coff provides access to COFF object files (.obj), as produced by
+// Microsoft compilers.
+//
+// The sections of COFF object files are laid out consecutively in memory,
+// starting at objBase, and relocations are applied against the symbols of the
+// symbol table. Function symbols are recorded as exports, providing exact
+// function boundaries to the disassembler. External symbols are mapped to slots
+// of an artificial import section following the last section of the object
+// file.
+package coff
+
+import (
+	"debug/pe"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/mewkiz/pkg/term"
+	"github.com/pkg/errors"
+)
+
+var (
+	// warn is a logger which logs warning messages with "warning:" prefix to
+	// standard error.
+	warn = log.New(os.Stderr, term.RedBold("warning:")+" ", 0)
+)
+
+// Register COFF format.
+func init() {
+	// Common Object File Format (COFF) of the Intel 386 machine architecture.
+	//
+	//    4C 01  |L.|
+	const magic386 = "\x4C\x01"
+	bin.RegisterFormatIdent("coff", magic386, isObj, Parse)
+	// Common Object File Format (COFF) of the AMD64 machine architecture.
+	//
+	//    64 86  |d.|
+	const magicAMD64 = "\x64\x86"
+	bin.RegisterFormatIdent("coff", magicAMD64, isObj, Parse)
+}
+
+// isObj reports whether the given COFF file is an object file, i.e. has no
+// optional header.
+func isObj(r io.ReaderAt) bool {
+	// Offset of SizeOfOptionalHeader in COFF file header.
+	const optHdrSizeOffset = 16
+	var buf [2]byte
+	if _, err := r.ReadAt(buf[:], optHdrSizeOffset); err != nil {
+		return false
+	}
+	return binary.LittleEndian.Uint16(buf[:]) == 0
+}
+
+// Base address of the first section of object files.
+const objBase = 0x10000
+
+// Size in bytes of slots of external symbols in the artificial import section.
+const importSlotSize = 8
+
+// ParseFile parses the given COFF object file, reading from path.
+func ParseFile(path string) (*bin.File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse parses the given COFF object file, reading from r.
+//
+// Users are responsible for closing r.
+func Parse(r io.ReaderAt) (*bin.File, error) {
+	return ParseAt(r, objBase)
+}
+
+// ParseAt parses the given COFF object file, reading from r, and places its
+// first section at the specified base address.
+//
+// Users are responsible for closing r.
+func ParseAt(r io.ReaderAt, base bin.Address) (*bin.File, error) {
+	// Open COFF file.
+	f, err := pe.NewFile(r)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Parse machine architecture.
+	file := &bin.File{
+		Imports: make(map[bin.Address]string),
+		Exports: make(map[bin.Address]string),
+	}
+	switch f.Machine {
+	case pe.IMAGE_FILE_MACHINE_I386:
+		file.Arch = bin.ArchX86_32
+	case pe.IMAGE_FILE_MACHINE_AMD64:
+		file.Arch = bin.ArchX86_64
+	default:
+		panic(fmt.Errorf("support for machine architecture %v not yet implemented", f.Machine))
+	}
+
+	// Lay out sections; indexed by section number - 1.
+	sects := make([]*bin.Section, len(f.Sections))
+	addr := base
+	for i, s := range f.Sections {
+		if s.Characteristics&(lnkRemove|lnkInfo) != 0 {
+			// skip linker directives (e.g. .drectve) and discarded sections.
+			continue
+		}
+		addr = alignAddr(addr, sectAlign(s.Characteristics))
+		var data []byte
+		if s.Characteristics&cntUninitializedData == 0 {
+			buf, err := s.Data()
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			data = buf
+		}
+		sect := &bin.Section{
+			Name:     s.Name,
+			Addr:     addr,
+			Offset:   uint64(s.Offset),
+			Data:     data,
+			FileSize: len(data),
+			MemSize:  int(s.Size),
+			Perm:     parsePerm(s.Characteristics),
+			Flags:    parseSectFlags(s.Characteristics),
+			Align:    int(sectAlign(s.Characteristics)),
+		}
+		sects[i] = sect
+		file.Sections = append(file.Sections, sect)
+		addr += bin.Address(s.Size)
+	}
+
+	// Resolve symbols; indexed by symbol table index (including auxiliary
+	// symbol records).
+	importBase := alignAddr(addr, 16)
+	importAddr := importBase
+	symAddrs := make([]bin.Address, len(f.COFFSymbols))
+	var funcs bin.Addresses
+	for i := 0; i < len(f.COFFSymbols); i++ {
+		sym := &f.COFFSymbols[i]
+		name, err := sym.FullName(f.StringTable)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		switch {
+		case sym.SectionNumber == 0 && sym.Value == 0:
+			// external symbol.
+			file.Imports[importAddr] = name
+			symAddrs[i] = importAddr
+			importAddr += importSlotSize
+		case sym.SectionNumber == 0:
+			// common symbol, with size specified by value; allocated in the
+			// artificial import section.
+			importAddr = alignAddr(importAddr, importSlotSize)
+			symAddrs[i] = importAddr
+			importAddr += bin.Address(sym.Value)
+		case sym.SectionNumber == symAbsolute:
+			symAddrs[i] = bin.Address(sym.Value)
+		case sym.SectionNumber > 0 && int(sym.SectionNumber) <= len(sects):
+			sect := sects[sym.SectionNumber-1]
+			if sect == nil {
+				break
+			}
+			symAddrs[i] = sect.Addr + bin.Address(sym.Value)
+			if sym.Type == typeFunc && (sym.StorageClass == classExternal || sym.StorageClass == classStatic) {
+				file.Exports[symAddrs[i]] = name
+				funcs = append(funcs, symAddrs[i])
+			}
+		}
+		// Skip auxiliary symbol records.
+		i += int(sym.NumberOfAuxSymbols)
+	}
+	if importAddr > importBase {
+		size := int(importAddr - importBase)
+		sect := &bin.Section{
+			Name:    ".imports",
+			Addr:    importBase,
+			Data:    make([]byte, size),
+			MemSize: size,
+			Perm:    bin.PermR | bin.PermW,
+		}
+		file.Sections = append(file.Sections, sect)
+	}
+
+	// Apply relocations.
+	for i, s := range f.Sections {
+		sect := sects[i]
+		if sect == nil || sect.Data == nil {
+			continue
+		}
+		for _, reloc := range s.Relocs {
+			if int(reloc.SymbolTableIndex) >= len(symAddrs) {
+				return nil, errors.Errorf("invalid symbol index %d of relocation at offset 0x%X of section %q", reloc.SymbolTableIndex, reloc.VirtualAddress, s.Name)
+			}
+			sym := symAddrs[reloc.SymbolTableIndex]
+			if err := applyReloc(file.Arch, reloc, sect, sym, base); err != nil {
+				return nil, errors.Wrapf(err, "unable to apply relocation of section %q", s.Name)
+			}
+		}
+	}
+
+	// Use main function (or first function) as entry point.
+	sort.Sort(funcs)
+	for addr, name := range file.Exports {
+		if name == "main" || name == "_main" {
+			file.Entry = addr
+		}
+	}
+	if file.Entry == 0 && len(funcs) > 0 {
+		file.Entry = funcs[0]
+	}
+	return file, nil
+}
+
+// Section characteristics.
+const (
+	// Uninitialized data.
+	cntUninitializedData = 0x00000080
+	// Comments or other information (e.g. linker directives).
+	lnkInfo = 0x00000200
+	// Not part of the image.
+	lnkRemove = 0x00000800
+	// Alignment mask.
+	alignMask = 0x00F00000
+	// Can be discarded as needed.
+	memDiscardable = 0x02000000
+	// permR specifies that the memory is readable.
+	permR = 0x40000000
+	// permW specifies that the memory is writeable.
+	permW = 0x80000000
+	// permX specifies that the memory is executable.
+	permX = 0x20000000
+)
+
+// Symbol table constants.
+const (
+	// Section number of absolute symbols.
+	symAbsolute = -1
+	// Symbol type of functions.
+	typeFunc = 0x20
+	// Storage class of external symbols.
+	classExternal = 2
+	// Storage class of static symbols.
+	classStatic = 3
+)
+
+// Relocation types of the Intel 386 machine architecture.
+const (
+	// 32-bit virtual address of symbol.
+	rel386Dir32 = 0x0006
+	// 32-bit relative virtual address of symbol.
+	rel386Dir32NB = 0x0007
+	// 16-bit section index of symbol (debug information).
+	rel386Section = 0x000A
+	// 32-bit offset of symbol from the beginning of its section (debug
+	// information).
+	rel386SecRel = 0x000B
+	// 32-bit relative displacement of symbol.
+	rel386Rel32 = 0x0014
+)
+
+// Relocation types of the AMD64 machine architecture.
+const (
+	// 64-bit virtual address of symbol.
+	relAMD64Addr64 = 0x0001
+	// 32-bit virtual address of symbol.
+	relAMD64Addr32 = 0x0002
+	// 32-bit relative virtual address of symbol.
+	relAMD64Addr32NB = 0x0003
+	// 32-bit relative displacement of symbol, from the byte following the
+	// relocated location.
+	relAMD64Rel32 = 0x0004
+	// 32-bit relative displacement of symbol, from 5 bytes past the relocated
+	// location.
+	relAMD64Rel32_5 = 0x0009
+	// 16-bit section index of symbol (debug information).
+	relAMD64Section = 0x000A
+	// 32-bit offset of symbol from the beginning of its section (debug
+	// information).
+	relAMD64SecRel = 0x000B
+)
+
+// applyReloc applies the given relocation to the section, where sym is the
+// address of the referenced symbol and base the image base of relative virtual
+// addresses. The addend of COFF relocations is stored at the relocated location.
+func applyReloc(arch bin.Arch, reloc pe.Reloc, sect *bin.Section, sym, base bin.Address) error {
+	off := uint64(reloc.VirtualAddress)
+	p := uint64(sect.Addr) + off
+	s := uint64(sym)
+	// Size in bytes of relocated location.
+	size := uint64(4)
+	// Value relative to s, as added to the addend; nil if unmodified.
+	var v func(addend uint64) uint64
+	switch arch {
+	case bin.ArchX86_32:
+		switch reloc.Type {
+		case rel386Dir32:
+			v = func(addend uint64) uint64 { return s + addend }
+		case rel386Dir32NB:
+			v = func(addend uint64) uint64 { return s - uint64(base) + addend }
+		case rel386Rel32:
+			v = func(addend uint64) uint64 { return s + addend - (p + 4) }
+		case rel386Section, rel386SecRel:
+			// debug information.
+			return nil
+		default:
+			warn.Printf("support for relocation type 0x%04X not yet implemented", reloc.Type)
+			return nil
+		}
+	case bin.ArchX86_64:
+		switch t := reloc.Type; {
+		case t == relAMD64Addr64:
+			size = 8
+			v = func(addend uint64) uint64 { return s + addend }
+		case t == relAMD64Addr32:
+			v = func(addend uint64) uint64 { return s + addend }
+		case t == relAMD64Addr32NB:
+			v = func(addend uint64) uint64 { return s - uint64(base) + addend }
+		case t >= relAMD64Rel32 && t <= relAMD64Rel32_5:
+			k := uint64(t - relAMD64Rel32)
+			v = func(addend uint64) uint64 { return s + addend - (p + 4 + k) }
+		case t == relAMD64Section, t == relAMD64SecRel:
+			// debug information.
+			return nil
+		default:
+			warn.Printf("support for relocation type 0x%04X not yet implemented", reloc.Type)
+			return nil
+		}
+	}
+	data := sect.Data
+	if off+size > uint64(len(data)) {
+		return errors.Errorf("relocation offset 0x%X out of bounds", off)
+	}
+	switch size {
+	case 4:
+		addend := uint64(int32(binary.LittleEndian.Uint32(data[off:])))
+		binary.LittleEndian.PutUint32(data[off:], uint32(v(addend)))
+	case 8:
+		addend := binary.LittleEndian.Uint64(data[off:])
+		binary.LittleEndian.PutUint64(data[off:], v(addend))
+	default:
+		panic(fmt.Errorf("support for relocation size %d not yet implemented", size))
+	}
+	return nil
+}
+
+// parsePerm returns the memory access permissions represented by the given
+// section characteristics.
+func parsePerm(char uint32) bin.Perm {
+	var perm bin.Perm
+	if char&permR != 0 {
+		perm |= bin.PermR
+	}
+	if char&permW != 0 {
+		perm |= bin.PermW
+	}
+	if char&permX != 0 {
+		perm |= bin.PermX
+	}
+	return perm
+}
+
+// parseSectFlags returns the section characteristics, other than access
+// permissions, represented by the given COFF section characteristics.
+func parseSectFlags(char uint32) bin.SectFlag {
+	var flags bin.SectFlag
+	if char&cntUninitializedData != 0 {
+		flags |= bin.SectUninit
+	}
+	if char&memDiscardable != 0 {
+		flags |= bin.SectDiscardable
+	}
+	return flags
+}
+
+// ### [ Helper functions ] ####################################################
+
+// sectAlign returns the alignment in bytes represented by the given section
+// characteristics.
+func sectAlign(char uint32) uint64 {
+	n := (char & alignMask) >> 20
+	if n == 0 {
+		// default alignment of 16 bytes.
+		return 16
+	}
+	return 1 << (n - 1)
+}
+
+// alignAddr returns the given address rounded up to the specified alignment.
+func alignAddr(addr bin.Address, align uint64) bin.Address {
+	if align <= 1 {
+		return addr
+	}
+	a := bin.Address(align)
+	return (addr + a - 1) / a * a
+}
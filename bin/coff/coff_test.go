@@ -0,0 +1,151 @@
+package coff_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/bin/coff"
+)
+
+// section specifies the expected contents of a section.
+type section struct {
+	name string
+	addr bin.Address
+	data []byte
+	perm bin.Perm
+}
+
+func TestParse(t *testing.T) {
+	golden := []struct {
+		path     string
+		arch     bin.Arch
+		entry    bin.Address
+		sections []section
+		exports  map[bin.Address]string
+		imports  map[bin.Address]string
+	}{
+		{
+			path:  "testdata/obj_x86_32.obj",
+			arch:  bin.ArchX86_32,
+			entry: 0x10000,
+			sections: []section{
+				// Relocated push of data address (IMAGE_REL_I386_DIR32) and calls to
+				// external functions (IMAGE_REL_I386_REL32).
+				{name: ".text", addr: 0x10000, data: []byte{0x55, 0x89, 0xE5, 0x68, 0x14, 0x00, 0x01, 0x00, 0xE8, 0x13, 0x00, 0x00, 0x00, 0x6A, 0x00, 0xE8, 0x14, 0x00, 0x00, 0x00}, perm: bin.PermR | bin.PermX},
+				// Relocated 32-bit address of function.
+				{name: ".data", addr: 0x10014, data: []byte("hello\x00\x00\x00\x00\x00\x01\x00"), perm: bin.PermR | bin.PermW},
+				{name: ".bss", addr: 0x10020, data: []byte{}, perm: bin.PermR | bin.PermW},
+				{name: ".imports", addr: 0x10020, data: make([]byte, 16), perm: bin.PermR | bin.PermW},
+			},
+			exports: map[bin.Address]string{0x10000: "_main"},
+			imports: map[bin.Address]string{0x10020: "_puts", 0x10028: "_exit"},
+		},
+		{
+			path:  "testdata/obj_x86_64.obj",
+			arch:  bin.ArchX86_64,
+			entry: 0x10000,
+			sections: []section{
+				// Relocated RIP-relative data address and calls to external functions
+				// (IMAGE_REL_AMD64_REL32).
+				{name: ".text", addr: 0x10000, data: []byte{0x55, 0x48, 0x89, 0xE5, 0x48, 0x83, 0xEC, 0x20, 0x48, 0x8D, 0x0D, 0x11, 0x00, 0x00, 0x00, 0xE8, 0x1C, 0x00, 0x00, 0x00, 0x31, 0xC9, 0xE8, 0x1D, 0x00, 0x00, 0x00}, perm: bin.PermR | bin.PermX},
+				// Relocated 64-bit address of function (IMAGE_REL_AMD64_ADDR64).
+				{name: ".data", addr: 0x10020, data: []byte("hello\x00\x00\x00\x00\x00\x01\x00\x00\x00\x00\x00"), perm: bin.PermR | bin.PermW},
+				{name: ".bss", addr: 0x10030, data: []byte{}, perm: bin.PermR | bin.PermW},
+				{name: ".imports", addr: 0x10030, data: make([]byte, 16), perm: bin.PermR | bin.PermW},
+			},
+			exports: map[bin.Address]string{0x10000: "main"},
+			imports: map[bin.Address]string{0x10030: "puts", 0x10038: "exit"},
+		},
+	}
+	for _, g := range golden {
+		file, err := coff.ParseFile(g.path)
+		if err != nil {
+			t.Errorf("%q: unable to parse COFF object file; %+v", g.path, err)
+			continue
+		}
+		if file.Arch != g.arch {
+			t.Errorf("%q: machine architecture mismatch; expected %v, got %v", g.path, g.arch, file.Arch)
+		}
+		if file.Entry != g.entry {
+			t.Errorf("%q: entry point mismatch; expected %v, got %v", g.path, g.entry, file.Entry)
+		}
+		if len(file.Sections) != len(g.sections) {
+			t.Errorf("%q: number of sections mismatch; expected %d, got %d", g.path, len(g.sections), len(file.Sections))
+			continue
+		}
+		for i, want := range g.sections {
+			sect := file.Sections[i]
+			if sect.Name != want.name {
+				t.Errorf("%q: section %d: name mismatch; expected %q, got %q", g.path, i, want.name, sect.Name)
+			}
+			if sect.Addr != want.addr {
+				t.Errorf("%q: section %q: address mismatch; expected %v, got %v", g.path, want.name, want.addr, sect.Addr)
+			}
+			if !bytes.Equal(sect.Data, want.data) {
+				t.Errorf("%q: section %q: contents mismatch; expected % X, got % X", g.path, want.name, want.data, sect.Data)
+			}
+			if sect.Perm != want.perm {
+				t.Errorf("%q: section %q: permissions mismatch; expected %v, got %v", g.path, want.name, want.perm, sect.Perm)
+			}
+		}
+		checkSymbols(t, g.path, "export", file.Exports, g.exports)
+		checkSymbols(t, g.path, "import", file.Imports, g.imports)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	golden := []struct {
+		path string
+		desc string
+	}{
+		{path: "testdata/invalid/sections.obj", desc: "number of sections exceeding file"},
+		{path: "testdata/invalid/symbols.obj", desc: "number of symbols exceeding file"},
+		{path: "testdata/invalid/strtab.obj", desc: "size of string table exceeding file"},
+		{path: "testdata/invalid/sectsize.obj", desc: "size of .text exceeding file"},
+		{path: "testdata/invalid/sectoff.obj", desc: "contents of .text outside of file"},
+		{path: "testdata/invalid/relocs.obj", desc: "number of relocations exceeding file"},
+		{path: "testdata/invalid/relsym.obj", desc: "relocation with invalid symbol index"},
+	}
+	for _, g := range golden {
+		if _, err := coff.ParseFile(g.path); err == nil {
+			t.Errorf("%q: %s; expected error, got nil", g.path, g.desc)
+		}
+	}
+}
+
+func TestParseTruncated(t *testing.T) {
+	buf, err := ioutil.ReadFile("testdata/obj_x86_32.obj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := []struct {
+		size int
+		desc string
+	}{
+		{size: 0x10, desc: "file header"},
+		{size: 0x40, desc: "section headers"},
+		{size: 0x90, desc: ".text"},
+		{size: 0xA5, desc: "relocations of .text"},
+		{size: 0x100, desc: "symbol table"},
+		{size: len(buf) - 1, desc: "string table"},
+	}
+	for _, g := range golden {
+		if _, err := coff.Parse(bytes.NewReader(buf[:g.size])); err == nil {
+			t.Errorf("truncated within %s (0x%X bytes); expected error, got nil", g.desc, g.size)
+		}
+	}
+}
+
+// checkSymbols reports mismatches of the given symbols (imports or exports).
+func checkSymbols(t *testing.T, path, kind string, got, want map[bin.Address]string) {
+	if len(got) != len(want) {
+		t.Errorf("%q: number of %ss mismatch; expected %d, got %d", path, kind, len(want), len(got))
+	}
+	for addr, name := range want {
+		if got[addr] != name {
+			t.Errorf("%q: %s at %v mismatch; expected %q, got %q", path, kind, addr, name, got[addr])
+		}
+	}
+}
@@ -0,0 +1,18 @@
+	.text
+	.globl	_main
+	.def	_main; .scl 2; .type 32; .endef
+_main:
+	pushl	%ebp
+	movl	%esp, %ebp
+	pushl	$_msg
+	calll	_puts
+	pushl	$0
+	calll	_exit
+
+	.data
+_msg:
+	.asciz	"hello"
+	.p2align	2
+	.globl	_main_ptr
+_main_ptr:
+	.long	_main
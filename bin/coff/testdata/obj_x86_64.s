@@ -0,0 +1,19 @@
+	.text
+	.globl	main
+	.def	main; .scl 2; .type 32; .endef
+main:
+	pushq	%rbp
+	movq	%rsp, %rbp
+	subq	$32, %rsp
+	leaq	msg(%rip), %rcx
+	callq	puts
+	xorl	%ecx, %ecx
+	callq	exit
+
+	.data
+msg:
+	.asciz	"hello"
+	.p2align	3
+	.globl	main_ptr
+main_ptr:
+	.quad	main
@@ -0,0 +1,185 @@
+// Package demangle implements demangling of C++ symbol names, as mangled by
+// MSVC (e.g. "?foo@@YAXH@Z") and by compilers following the Itanium C++ ABI
+// (e.g. "_Z3fooi").
+package demangle
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A Symbol is a demangled C++ symbol.
+type Symbol struct {
+	// Qualified name (e.g. "std::vector<int>::push_back").
+	Name string
+	// Return type; or empty if not encoded (e.g. constructors, and non-template
+	// functions mangled according to the Itanium C++ ABI).
+	RetType string
+	// Parameter types.
+	Params []string
+	// Variadic function.
+	Variadic bool
+	// Calling convention (e.g. "__stdcall"); or empty if not encoded.
+	CallConv string
+	// Qualifiers of member functions (e.g. "const").
+	Quals string
+	// Function symbol; data symbol otherwise (e.g. global variables and
+	// virtual function tables).
+	Func bool
+	// Non-static member function; which receives an implicit this parameter.
+	Member bool
+}
+
+// String returns the demangled name of the symbol, including the parameter
+// types of functions (e.g. "foo::bar(int, char const*)"). The string
+// representation follows the output of c++filt, and is unique for overloaded
+// functions.
+func (sym *Symbol) String() string {
+	if !sym.Func {
+		return sym.Name
+	}
+	params := append([]string{}, sym.Params...)
+	if sym.Variadic {
+		params = append(params, "...")
+	}
+	s := fmt.Sprintf("%s(%s)", sym.Name, strings.Join(params, ", "))
+	if len(sym.Quals) > 0 {
+		s += " " + sym.Quals
+	}
+	return s
+}
+
+// Proto returns the function prototype of the symbol, including the return
+// type and calling convention if encoded (e.g. "void __stdcall foo(int)").
+func (sym *Symbol) Proto() string {
+	s := sym.String()
+	if len(sym.CallConv) > 0 {
+		s = sym.CallConv + " " + s
+	}
+	if len(sym.RetType) > 0 {
+		s = sym.RetType + " " + s
+	}
+	return s
+}
+
+// IsMangled reports whether the given symbol name is a mangled C++ name.
+func IsMangled(name string) bool {
+	name = trimImport(name)
+	return isMSVC(name) || isItanium(name)
+}
+
+// Demangle demangles the given C++ symbol name. Import prefixes (e.g.
+// "__imp_") are ignored.
+func Demangle(name string) (*Symbol, error) {
+	mangled := trimImport(name)
+	var sym *Symbol
+	var err error
+	switch {
+	case isMSVC(mangled):
+		sym, err = demangleMSVC(mangled)
+	case isItanium(mangled):
+		sym, err = demangleItanium(mangled)
+	default:
+		return nil, errors.Errorf("unable to demangle %q; not a mangled C++ name", name)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to demangle %q", name)
+	}
+	return sym, nil
+}
+
+// Name returns the demangled name of the given symbol name (see
+// Symbol.String). The boolean return value indicates success.
+func Name(name string) (string, bool) {
+	sym, err := Demangle(name)
+	if err != nil {
+		return "", false
+	}
+	return sym.String(), true
+}
+
+// ### [ Helper functions ] ####################################################
+
+// trimImport trims the import prefix of the given symbol name.
+func trimImport(name string) string {
+	for _, prefix := range []string{"__imp_", "_imp_"} {
+		if strings.HasPrefix(name, prefix) {
+			return name[len(prefix):]
+		}
+	}
+	return name
+}
+
+// isMSVC reports whether the given symbol name is mangled by MSVC.
+func isMSVC(name string) bool {
+	return len(name) > 1 && name[0] == '?'
+}
+
+// isItanium reports whether the given symbol name is mangled according to the
+// Itanium C++ ABI; optionally with an extra leading underscore (e.g. Mach-O
+// and 32-bit MinGW).
+func isItanium(name string) bool {
+	return strings.HasPrefix(name, "_Z") || strings.HasPrefix(name, "__Z")
+}
+
+// A syntaxError is a syntax error of a mangled name, raised by panic and
+// recovered at the top-level of the parsers.
+type syntaxError struct {
+	err error
+}
+
+// fail raises a syntax error with the given format string and arguments.
+func fail(format string, args ...interface{}) {
+	panic(syntaxError{err: errors.Errorf(format, args...)})
+}
+
+// recoverError recovers syntax errors, storing them in *err.
+func recoverError(err *error) {
+	if e := recover(); e != nil {
+		if e, ok := e.(syntaxError); ok {
+			*err = e.err
+			return
+		}
+		panic(e)
+	}
+}
+
+// pointerTo returns the string representation of a pointer or reference
+// (specified by op; e.g. "*" or "&") to the given type. Pointers to function
+// types (e.g. "void (int)") are formatted as function pointers (e.g.
+// "void (*)(int)").
+func pointerTo(typ, op string) string {
+	if pos := strings.Index(typ, " ["); pos != -1 && strings.HasSuffix(typ, "]") {
+		// Pointer to array (e.g. "int [10]" -> "int (*) [10]").
+		return fmt.Sprintf("%s (%s)%s", typ[:pos], op, typ[pos:])
+	}
+	pos := strings.Index(typ, " (")
+	if pos == -1 || !strings.HasSuffix(typ, ")") {
+		return typ + op
+	}
+	ret, rest := typ[:pos], typ[pos+1:]
+	// Locate end of first parenthesized group.
+	depth, end := 0, -1
+	for i, r := range rest {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+		if depth == 0 {
+			end = i
+			break
+		}
+	}
+	if end+1 < len(rest) && rest[end+1] == '(' {
+		// Pointer to function pointer (e.g. "void (*)(int)" -> "void (**)(int)");
+		// or function type with calling convention (e.g.
+		// "void (__cdecl)(int)" -> "void (__cdecl*)(int)").
+		return fmt.Sprintf("%s (%s%s)%s", ret, rest[1:end], op, rest[end+1:])
+	}
+	// Function type (e.g. "void (int)" -> "void (*)(int)").
+	return fmt.Sprintf("%s (%s)%s", ret, op, rest)
+}
@@ -0,0 +1,664 @@
+package demangle
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Itanium C++ ABI name mangling.
+//
+// References:
+//    https://itanium-cxx-abi.github.io/cxx-abi/abi.html#mangling
+//
+//    _ZN3foo3barEPKc -> foo::bar(char const*)
+//
+//    _Z        mangled name
+//    N ... E   nested name
+//    3foo      source name (foo)
+//    3bar      source name (bar)
+//    PKc       parameter types (char const*)
+
+// demangleItanium demangles the given mangled symbol name, following the
+// Itanium C++ ABI.
+func demangleItanium(name string) (sym *Symbol, err error) {
+	defer recoverError(&err)
+	mangled := name[strings.Index(name, "_Z")+len("_Z"):]
+	// Ignore clone suffixes of optimized functions (e.g. "_Z3foov.cold").
+	if pos := strings.IndexByte(mangled, '.'); pos != -1 {
+		mangled = mangled[:pos]
+	}
+	p := &itaniumParser{s: mangled}
+	sym = p.encoding()
+	if p.pos < len(p.s) {
+		fail("trailing characters %q", p.s[p.pos:])
+	}
+	return sym, nil
+}
+
+// itaniumParser is a parser of mangled names following the Itanium C++ ABI.
+type itaniumParser struct {
+	// Mangled name.
+	s string
+	// Current position.
+	pos int
+	// Substitution candidates.
+	subs []string
+	// Template arguments of the function; referenced by template parameters.
+	tmplArgs []string
+}
+
+// encoding parses an encoding, following the initial "_Z".
+//
+//	<encoding> ::= <name> <bare-function-type>
+//	           ::= <name>
+//	           ::= <special-name>
+func (p *itaniumParser) encoding() *Symbol {
+	sym := &Symbol{}
+	if p.peek() == 'T' {
+		// Special names of virtual tables and RTTI.
+		p.pos++
+		var prefix string
+		switch c := p.next(); c {
+		case 'V':
+			prefix = "vtable for "
+		case 'T':
+			prefix = "VTT for "
+		case 'I':
+			prefix = "typeinfo for "
+		case 'S':
+			prefix = "typeinfo name for "
+		default:
+			fail("support for special name \"T%c\" not yet implemented", c)
+		}
+		sym.Name = prefix + p.typ()
+		return sym
+	}
+	n := p.name()
+	sym.Name = n.name
+	sym.Quals = n.quals
+	if p.pos >= len(p.s) {
+		// Data symbol.
+		return sym
+	}
+	sym.Func = true
+	// Template functions, except constructors, destructors and conversion
+	// operators, have their return type encoded.
+	if n.tmpl && !n.ctorDtor {
+		sym.RetType = p.typ()
+	}
+	if p.peek() == 'v' && p.pos+1 == len(p.s) {
+		p.pos++
+		return sym
+	}
+	for p.pos < len(p.s) {
+		if p.peek() == 'z' {
+			p.pos++
+			sym.Variadic = true
+			continue
+		}
+		sym.Params = append(sym.Params, p.typ())
+	}
+	return sym
+}
+
+// itaniumName is a parsed name.
+type itaniumName struct {
+	// Qualified name.
+	name string
+	// CV-qualifiers of member functions (e.g. "const").
+	quals string
+	// The name ends with template arguments.
+	tmpl bool
+	// The name is a constructor or destructor.
+	ctorDtor bool
+}
+
+// name parses a name.
+//
+//	<name> ::= <nested-name>
+//	       ::= <unscoped-name>
+//	       ::= <unscoped-template-name> <template-args>
+func (p *itaniumParser) name() itaniumName {
+	switch p.peek() {
+	case 'N':
+		return p.nestedName()
+	case 'Z':
+		fail("support for local names not yet implemented")
+	}
+	var n itaniumName
+	subst := false
+	if p.peek() == 'S' && p.peekAt(1) != 't' {
+		n.name = p.substitution()
+		subst = true
+	} else {
+		if strings.HasPrefix(p.s[p.pos:], "St") {
+			p.pos += 2
+			n.name = "std::"
+		}
+		name, _ := p.unqualifiedName("")
+		n.name += name
+	}
+	if p.peek() == 'I' {
+		// Unscoped template name.
+		if !subst {
+			p.subs = append(p.subs, n.name)
+		}
+		n.name += p.templateArgs(true)
+		n.tmpl = true
+	}
+	return n
+}
+
+// nestedName parses a nested name.
+//
+//	<nested-name> ::= N [<CV-qualifiers>] [<ref-qualifier>] <prefix> <unqualified-name> E
+//	              ::= N [<CV-qualifiers>] [<ref-qualifier>] <template-prefix> <template-args> E
+func (p *itaniumParser) nestedName() itaniumName {
+	p.pos++ // 'N'
+	var n itaniumName
+	n.quals = p.cvQuals()
+	switch p.peek() {
+	case 'R':
+		p.pos++
+		n.quals = strings.TrimSpace(n.quals + " &")
+	case 'O':
+		p.pos++
+		n.quals = strings.TrimSpace(n.quals + " &&")
+	}
+	var names []string
+	last := ""
+	for p.peek() != 'E' {
+		if p.pos >= len(p.s) {
+			fail("unexpected end of nested name")
+		}
+		subst := false
+		n.tmpl = false
+		n.ctorDtor = false
+		switch c := p.peek(); {
+		case c == 'I':
+			if len(names) == 0 {
+				fail("template arguments without template name")
+			}
+			names[len(names)-1] += p.templateArgs(true)
+			n.tmpl = true
+		case c == 'S' && p.peekAt(1) != 't':
+			names = append(names, p.substitution())
+			subst = true
+		case c == 'S':
+			p.pos += 2
+			names = append(names, "std")
+		case c == 'T':
+			names = append(names, p.templateParam())
+		case c == 'C' || c == 'D' && p.peekAt(1) != 't' && p.peekAt(1) != 'T':
+			// Constructor or destructor.
+			p.pos += 2
+			name := unqualified(last)
+			if c == 'D' {
+				name = "~" + name
+			}
+			names = append(names, name)
+			n.ctorDtor = true
+		default:
+			name, conv := p.unqualifiedName(strings.Join(names, "::"))
+			names = append(names, name)
+			n.ctorDtor = conv
+		}
+		last = names[len(names)-1]
+		if p.peek() != 'E' && !subst {
+			p.subs = append(p.subs, strings.Join(names, "::"))
+		}
+	}
+	p.pos++ // 'E'
+	n.name = strings.Join(names, "::")
+	return n
+}
+
+// unqualifiedName parses an unqualified name within the given scope. The
+// boolean return value indicates whether the name is a conversion operator.
+//
+//	<unqualified-name> ::= <operator-name>
+//	                   ::= <source-name>
+func (p *itaniumParser) unqualifiedName(scope string) (string, bool) {
+	c := p.peek()
+	switch {
+	case '0' <= c && c <= '9':
+		return p.sourceName(), false
+	case 'a' <= c && c <= 'z':
+		code := p.s[p.pos:min(p.pos+2, len(p.s))]
+		p.pos += 2
+		if code == "cv" {
+			// Conversion operator.
+			return "operator " + p.typ(), true
+		}
+		if name, ok := itaniumOperators[code]; ok {
+			return "operator" + name, false
+		}
+		fail("support for operator %q not yet implemented", code)
+	case c == 'L':
+		// Internal linkage (e.g. static functions).
+		p.pos++
+		return p.sourceName(), false
+	}
+	fail("invalid unqualified name at offset %d in scope %q", p.pos, scope)
+	panic("unreachable")
+}
+
+// itaniumOperators maps from operator code to operator name.
+var itaniumOperators = map[string]string{
+	"nw": " new",
+	"na": " new[]",
+	"dl": " delete",
+	"da": " delete[]",
+	"ps": "+",
+	"ng": "-",
+	"ad": "&",
+	"de": "*",
+	"co": "~",
+	"pl": "+",
+	"mi": "-",
+	"ml": "*",
+	"dv": "/",
+	"rm": "%",
+	"an": "&",
+	"or": "|",
+	"eo": "^",
+	"aS": "=",
+	"pL": "+=",
+	"mI": "-=",
+	"mL": "*=",
+	"dV": "/=",
+	"rM": "%=",
+	"aN": "&=",
+	"oR": "|=",
+	"eO": "^=",
+	"ls": "<<",
+	"rs": ">>",
+	"lS": "<<=",
+	"rS": ">>=",
+	"eq": "==",
+	"ne": "!=",
+	"lt": "<",
+	"gt": ">",
+	"le": "<=",
+	"ge": ">=",
+	"ss": "<=>",
+	"nt": "!",
+	"aa": "&&",
+	"oo": "||",
+	"pp": "++",
+	"mm": "--",
+	"cm": ",",
+	"pm": "->*",
+	"pt": "->",
+	"cl": "()",
+	"ix": "[]",
+}
+
+// sourceName parses a source name; an identifier prefixed by its length.
+//
+//	<source-name> ::= <positive length number> <identifier>
+func (p *itaniumParser) sourceName() string {
+	start := p.pos
+	for p.pos < len(p.s) && '0' <= p.s[p.pos] && p.s[p.pos] <= '9' {
+		p.pos++
+	}
+	n, err := strconv.Atoi(p.s[start:p.pos])
+	if err != nil || n <= 0 || p.pos+n > len(p.s) {
+		fail("invalid source name length at offset %d", start)
+	}
+	name := p.s[p.pos : p.pos+n]
+	p.pos += n
+	if strings.HasPrefix(name, "_GLOBAL__N") {
+		return "(anonymous namespace)"
+	}
+	return name
+}
+
+// templateArgs parses template arguments, and returns their string
+// representation (e.g. "<int, char>"). Template arguments of the outermost
+// name are recorded for template parameter references if outer is set.
+//
+//	<template-args> ::= I <template-arg>+ E
+func (p *itaniumParser) templateArgs(outer bool) string {
+	p.pos++ // 'I'
+	var args []string
+	for p.peek() != 'E' {
+		if p.pos >= len(p.s) {
+			fail("unexpected end of template arguments")
+		}
+		switch p.peek() {
+		case 'L':
+			// Literal (e.g. "Li5E" -> 5).
+			p.pos++
+			typ := p.typ()
+			end := strings.IndexByte(p.s[p.pos:], 'E')
+			if end == -1 {
+				fail("unterminated literal")
+			}
+			val := p.s[p.pos : p.pos+end]
+			p.pos += end + 1
+			if strings.HasPrefix(val, "n") {
+				val = "-" + val[1:]
+			}
+			switch typ {
+			case "bool":
+				if val == "0" {
+					val = "false"
+				} else {
+					val = "true"
+				}
+			case "int":
+			default:
+				val = fmt.Sprintf("(%s)%s", typ, val)
+			}
+			args = append(args, val)
+		case 'J':
+			// Argument pack; parsed as template arguments, as 'J' is skipped
+			// like 'I'.
+			pack := p.templateArgs(false)
+			args = append(args, strings.TrimSuffix(strings.TrimPrefix(pack, "<"), ">"))
+		case 'X':
+			fail("support for template argument expressions not yet implemented")
+		default:
+			args = append(args, p.typ())
+		}
+	}
+	p.pos++ // 'E'
+	if outer {
+		p.tmplArgs = args
+	}
+	list := strings.Join(args, ", ")
+	if strings.HasSuffix(list, ">") {
+		list += " "
+	}
+	return "<" + list + ">"
+}
+
+// templateParam parses a template parameter reference.
+//
+//	<template-param> ::= T_
+//	                 ::= T <parameter-2 non-negative number> _
+func (p *itaniumParser) templateParam() string {
+	p.pos++ // 'T'
+	i := p.seqID()
+	if i >= len(p.tmplArgs) {
+		fail("invalid template parameter reference %d", i)
+	}
+	return p.tmplArgs[i]
+}
+
+// substitution parses a substitution.
+//
+//	<substitution> ::= S_
+//	               ::= S <seq-id> _
+//	               ::= Sa | Sb | Ss | Si | So | Sd
+func (p *itaniumParser) substitution() string {
+	p.pos++ // 'S'
+	switch p.peek() {
+	case 'a':
+		p.pos++
+		return "std::allocator"
+	case 'b':
+		p.pos++
+		return "std::basic_string"
+	case 's':
+		p.pos++
+		return "std::string"
+	case 'i':
+		p.pos++
+		return "std::istream"
+	case 'o':
+		p.pos++
+		return "std::ostream"
+	case 'd':
+		p.pos++
+		return "std::iostream"
+	}
+	i := p.seqID()
+	if i >= len(p.subs) {
+		fail("invalid substitution reference %d", i)
+	}
+	return p.subs[i]
+}
+
+// seqID parses a sequence ID terminated by '_'; "_" denotes 0, and base 36
+// numbers denote the number plus one.
+func (p *itaniumParser) seqID() int {
+	if p.peek() == '_' {
+		p.pos++
+		return 0
+	}
+	n := 0
+	for {
+		c := p.next()
+		switch {
+		case c == '_':
+			return n + 1
+		case '0' <= c && c <= '9':
+			n = n*36 + int(c-'0')
+		case 'A' <= c && c <= 'Z':
+			n = n*36 + int(c-'A') + 10
+		default:
+			fail("invalid sequence ID digit %q", c)
+		}
+	}
+}
+
+// cvQuals parses optional CV-qualifiers, and returns their string
+// representation (e.g. "const").
+func (p *itaniumParser) cvQuals() string {
+	var quals []string
+	for {
+		switch p.peek() {
+		case 'r':
+			quals = append(quals, "restrict")
+		case 'V':
+			quals = append(quals, "volatile")
+		case 'K':
+			quals = append(quals, "const")
+		default:
+			// Qualifiers are mangled in reverse order (r, V, K).
+			for i, j := 0, len(quals)-1; i < j; i, j = i+1, j-1 {
+				quals[i], quals[j] = quals[j], quals[i]
+			}
+			return strings.Join(quals, " ")
+		}
+		p.pos++
+	}
+}
+
+// typ parses a type.
+func (p *itaniumParser) typ() string {
+	c := p.peek()
+	if name, ok := itaniumBuiltins[c]; ok {
+		p.pos++
+		return name
+	}
+	var typ string
+	switch c {
+	case 'D':
+		p.pos++
+		switch c2 := p.next(); c2 {
+		case 'n':
+			return "decltype(nullptr)"
+		case 'i':
+			return "char32_t"
+		case 's':
+			return "char16_t"
+		case 'u':
+			return "char8_t"
+		case 'f':
+			return "decimal32"
+		case 'd':
+			return "decimal64"
+		case 'h':
+			return "half"
+		case 'p':
+			// Pack expansion; expanded template parameters are separated by
+			// commas.
+			typ = p.typ()
+		default:
+			fail("support for type \"D%c\" not yet implemented", c2)
+		}
+	case 'P':
+		p.pos++
+		typ = pointerTo(p.typ(), "*")
+	case 'R':
+		p.pos++
+		typ = pointerTo(p.typ(), "&")
+	case 'O':
+		p.pos++
+		typ = pointerTo(p.typ(), "&&")
+	case 'r', 'V', 'K':
+		quals := p.cvQuals()
+		typ = p.typ() + " " + quals
+	case 'F':
+		// Function type.
+		p.pos++
+		if p.peek() == 'Y' {
+			// extern "C".
+			p.pos++
+		}
+		ret := p.typ()
+		var params []string
+		for p.peek() != 'E' {
+			switch p.peek() {
+			case 0:
+				fail("unexpected end of function type")
+			case 'v':
+				p.pos++
+				continue
+			case 'z':
+				p.pos++
+				params = append(params, "...")
+				continue
+			case 'R', 'O':
+				if p.peekAt(1) == 'E' {
+					// Reference qualifier of member function type.
+					p.pos++
+					continue
+				}
+			}
+			params = append(params, p.typ())
+		}
+		p.pos++ // 'E'
+		typ = fmt.Sprintf("%s (%s)", ret, strings.Join(params, ", "))
+	case 'A':
+		// Array type.
+		p.pos++
+		end := strings.IndexByte(p.s[p.pos:], '_')
+		if end == -1 {
+			fail("unterminated array dimension")
+		}
+		dim := p.s[p.pos : p.pos+end]
+		p.pos += end + 1
+		typ = fmt.Sprintf("%s [%s]", p.typ(), dim)
+	case 'M':
+		// Pointer to member.
+		p.pos++
+		class := p.typ()
+		member := p.typ()
+		typ = pointerTo(member, class+"::*")
+	case 'T':
+		typ = p.templateParam()
+		if p.peek() == 'I' {
+			p.subs = append(p.subs, typ)
+			typ += p.templateArgs(false)
+		}
+	case 'S':
+		if p.peekAt(1) == 't' {
+			// Class type in std namespace.
+			p.pos += 2
+			name, _ := p.unqualifiedName("std")
+			typ = "std::" + name
+			if p.peek() == 'I' {
+				p.subs = append(p.subs, typ)
+				typ += p.templateArgs(false)
+			}
+			break
+		}
+		typ = p.substitution()
+		if p.peek() != 'I' {
+			// Substitutions are not substitution candidates.
+			return typ
+		}
+		typ += p.templateArgs(false)
+	case 'N':
+		n := p.nestedName()
+		typ = n.name
+	case 'u':
+		// Vendor extended type.
+		p.pos++
+		typ = p.sourceName()
+	default:
+		if '0' <= c && c <= '9' {
+			typ = p.sourceName()
+			if p.peek() == 'I' {
+				p.subs = append(p.subs, typ)
+				typ += p.templateArgs(false)
+			}
+			break
+		}
+		fail("support for type %q not yet implemented", c)
+	}
+	p.subs = append(p.subs, typ)
+	return typ
+}
+
+// itaniumBuiltins maps from builtin type code to type name.
+var itaniumBuiltins = map[byte]string{
+	'v': "void",
+	'w': "wchar_t",
+	'b': "bool",
+	'c': "char",
+	'a': "signed char",
+	'h': "unsigned char",
+	's': "short",
+	't': "unsigned short",
+	'i': "int",
+	'j': "unsigned int",
+	'l': "long",
+	'm': "unsigned long",
+	'x': "long long",
+	'y': "unsigned long long",
+	'n': "__int128",
+	'o': "unsigned __int128",
+	'f': "float",
+	'd': "double",
+	'e': "long double",
+	'g': "__float128",
+	'z': "...",
+}
+
+// peek returns the current character, or 0 at end of input.
+func (p *itaniumParser) peek() byte {
+	return p.peekAt(0)
+}
+
+// peekAt returns the character at the given offset from the current position,
+// or 0 at end of input.
+func (p *itaniumParser) peekAt(off int) byte {
+	if p.pos+off >= len(p.s) {
+		return 0
+	}
+	return p.s[p.pos+off]
+}
+
+// next returns the current character and advances the position.
+func (p *itaniumParser) next() byte {
+	if p.pos >= len(p.s) {
+		fail("unexpected end of mangled name")
+	}
+	c := p.s[p.pos]
+	p.pos++
+	return c
+}
+
+// min returns the minimum of x and y.
+func min(x, y int) int {
+	if x < y {
+		return x
+	}
+	return y
+}
@@ -0,0 +1,674 @@
+package demangle
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MSVC name mangling.
+//
+// References:
+//    https://en.wikiversity.org/wiki/Visual_C%2B%2B_name_mangling
+//    http://www.agner.org/optimize/calling_conventions.pdf (section 8.4)
+//
+//    ?foo@bar@@QAEHPBD@Z -> public: int __thiscall bar::foo(char const *)
+//
+//    ?      mangled name
+//    foo@   name
+//    bar@   scope
+//    @      end of qualified name
+//    Q      public member function
+//    A      this pointer qualifiers (none)
+//    E      calling convention (__thiscall)
+//    H      return type (int)
+//    PBD    parameter types (char const *)
+//    @      end of parameter list
+//    Z      throw specification (none)
+
+// demangleMSVC demangles the given MSVC mangled symbol name.
+func demangleMSVC(name string) (sym *Symbol, err error) {
+	defer recoverError(&err)
+	p := &msvcParser{s: name, pos: 1}
+	sym = p.symbol()
+	if p.pos < len(p.s) {
+		fail("trailing characters %q", p.s[p.pos:])
+	}
+	return sym, nil
+}
+
+// msvcParser is a parser of MSVC mangled names.
+type msvcParser struct {
+	// Mangled name.
+	s string
+	// Current position.
+	pos int
+	// Name back-references (at most 10).
+	names []string
+	// Parameter type back-references (at most 10).
+	types []string
+}
+
+// symbol parses a symbol, following the initial '?'.
+func (p *msvcParser) symbol() *Symbol {
+	sym := &Symbol{}
+	// Special names are resolved after the enclosing scope is known (e.g.
+	// constructors are named after their class).
+	special := ""
+	if p.peek() == '?' && p.peekAt(1) != '$' {
+		p.pos++
+		special = p.specialName()
+	}
+	scope := p.qualifiedName(special == "")
+	if len(special) > 0 {
+		parent := ""
+		if len(scope) > 0 {
+			parent = unqualified(scope[len(scope)-1])
+		}
+		switch special {
+		case "ctor":
+			special = parent
+		case "dtor":
+			special = "~" + parent
+		}
+		scope = append(scope, special)
+	}
+	sym.Name = strings.Join(scope, "::")
+	c := p.next()
+	switch {
+	case '0' <= c && c <= '4':
+		// Static member variable, global variable or local static variable.
+		p.dataType()
+	case c == '6' || c == '7':
+		// Virtual function table or virtual base table.
+		p.storageClass()
+		if p.peek() != '@' {
+			p.qualifiedName(true)
+		} else {
+			p.pos++
+		}
+	case 'A' <= c && c <= 'Z':
+		sym.Func = true
+		p.function(sym, c)
+		if special == msvcConversion {
+			// Conversion operators are named after their return type.
+			sym.Name = strings.TrimSuffix(sym.Name, msvcConversion) + "operator " + sym.RetType
+		}
+	default:
+		fail("support for symbol type %q not yet implemented", c)
+	}
+	return sym
+}
+
+// function parses the encoding of a function with the given access kind.
+func (p *msvcParser) function(sym *Symbol, kind byte) {
+	switch kind {
+	// Member functions (private, protected and public; non-virtual and
+	// virtual).
+	case 'A', 'B', 'E', 'F', 'I', 'J', 'M', 'N', 'Q', 'R', 'U', 'V':
+		sym.Member = true
+		// Pointer modifiers of this pointer (__ptr64, __restrict, __unaligned).
+		for p.peek() == 'E' || p.peek() == 'I' || p.peek() == 'F' {
+			p.pos++
+		}
+		switch p.next() {
+		case 'A':
+			// no qualifiers.
+		case 'B':
+			sym.Quals = "const"
+		case 'C':
+			sym.Quals = "volatile"
+		case 'D':
+			sym.Quals = "const volatile"
+		default:
+			fail("invalid this pointer qualifier %q", p.s[p.pos-1])
+		}
+	// Static member functions (private, protected and public).
+	case 'C', 'D', 'K', 'L', 'S', 'T':
+	// Global functions.
+	case 'Y', 'Z':
+	default:
+		fail("support for function kind %q not yet implemented", kind)
+	}
+	sym.CallConv = p.callConv()
+	sym.RetType = p.retType()
+	sym.Params, sym.Variadic = p.params()
+	// Throw specification.
+	if p.peek() == 'Z' {
+		p.pos++
+	}
+}
+
+// callConv parses a calling convention.
+func (p *msvcParser) callConv() string {
+	switch c := p.next(); c {
+	case 'A', 'B':
+		return "__cdecl"
+	case 'C', 'D':
+		return "__pascal"
+	case 'E', 'F':
+		return "__thiscall"
+	case 'G', 'H':
+		return "__stdcall"
+	case 'I', 'J':
+		return "__fastcall"
+	case 'Q':
+		return "__vectorcall"
+	default:
+		fail("invalid calling convention %q", c)
+	}
+	panic("unreachable")
+}
+
+// retType parses a return type; or '@' if not present (e.g. constructors).
+func (p *msvcParser) retType() string {
+	if p.peek() == '@' {
+		p.pos++
+		return ""
+	}
+	if p.peek() == '?' {
+		// Storage class of return type.
+		p.pos++
+		cv := p.storageClass()
+		return p.typ() + cv
+	}
+	return p.typ()
+}
+
+// params parses a parameter list; terminated by '@' or 'Z' (variadic), or the
+// single parameter type 'X' (void).
+func (p *msvcParser) params() (params []string, variadic bool) {
+	if p.peek() == 'X' {
+		p.pos++
+		return nil, false
+	}
+	for {
+		switch p.peek() {
+		case '@':
+			p.pos++
+			return params, false
+		case 'Z':
+			p.pos++
+			return params, true
+		case 0:
+			fail("unexpected end of parameter list")
+		}
+		start := p.pos
+		typ := p.typ()
+		if p.pos-start > 1 && len(p.types) < 10 {
+			p.types = append(p.types, typ)
+		}
+		params = append(params, typ)
+	}
+}
+
+// typ parses a type.
+func (p *msvcParser) typ() string {
+	c := p.next()
+	switch c {
+	case 'C':
+		return "signed char"
+	case 'D':
+		return "char"
+	case 'E':
+		return "unsigned char"
+	case 'F':
+		return "short"
+	case 'G':
+		return "unsigned short"
+	case 'H':
+		return "int"
+	case 'I':
+		return "unsigned int"
+	case 'J':
+		return "long"
+	case 'K':
+		return "unsigned long"
+	case 'M':
+		return "float"
+	case 'N':
+		return "double"
+	case 'O':
+		return "long double"
+	case 'X':
+		return "void"
+	case '_':
+		return p.extType()
+	case 'P':
+		return p.pointer("*", "")
+	case 'Q':
+		return p.pointer("*", " const")
+	case 'R':
+		return p.pointer("*", " volatile")
+	case 'S':
+		return p.pointer("*", " const volatile")
+	case 'A':
+		return p.pointer("&", "")
+	case 'B':
+		return p.pointer("&", " volatile")
+	case 'T', 'U', 'V':
+		// Union, struct or class.
+		return strings.Join(p.qualifiedName(true), "::")
+	case 'W':
+		// Enum; with underlying type.
+		p.pos++
+		return strings.Join(p.qualifiedName(true), "::")
+	case '?':
+		// Storage class of template argument or return type.
+		cv := p.storageClass()
+		return p.typ() + cv
+	case '$':
+		switch {
+		case strings.HasPrefix(p.s[p.pos:], "$Q"):
+			// Rvalue reference.
+			p.pos += 2
+			return p.pointer("&&", "")
+		case strings.HasPrefix(p.s[p.pos:], "$T"):
+			p.pos += 2
+			return "std::nullptr_t"
+		case strings.HasPrefix(p.s[p.pos:], "$C"):
+			// Qualified type (e.g. template arguments).
+			p.pos += 2
+			cv := p.storageClass()
+			return p.typ() + cv
+		}
+	}
+	if '0' <= c && c <= '9' {
+		i := int(c - '0')
+		if i >= len(p.types) {
+			fail("invalid type back-reference %d", i)
+		}
+		return p.types[i]
+	}
+	fail("support for type %q not yet implemented", c)
+	panic("unreachable")
+}
+
+// extType parses an extended type, following the initial '_'.
+func (p *msvcParser) extType() string {
+	switch c := p.next(); c {
+	case 'D':
+		return "__int8"
+	case 'E':
+		return "unsigned __int8"
+	case 'F':
+		return "__int16"
+	case 'G':
+		return "unsigned __int16"
+	case 'H':
+		return "__int32"
+	case 'I':
+		return "unsigned __int32"
+	case 'J':
+		return "__int64"
+	case 'K':
+		return "unsigned __int64"
+	case 'L':
+		return "__int128"
+	case 'M':
+		return "unsigned __int128"
+	case 'N':
+		return "bool"
+	case 'Q':
+		return "char8_t"
+	case 'S':
+		return "char16_t"
+	case 'U':
+		return "char32_t"
+	case 'W':
+		return "wchar_t"
+	default:
+		fail("support for extended type %q not yet implemented", c)
+	}
+	panic("unreachable")
+}
+
+// pointer parses the pointee type of a pointer or reference (specified by op),
+// with the given qualifiers of the pointer itself.
+func (p *msvcParser) pointer(op, quals string) string {
+	if p.peek() == '6' {
+		// Pointer to function.
+		p.pos++
+		callConv := p.callConv()
+		ret := p.retType()
+		params, variadic := p.params()
+		if p.peek() == 'Z' {
+			p.pos++
+		}
+		if variadic {
+			params = append(params, "...")
+		}
+		return fmt.Sprintf("%s (%s%s)(%s)%s", ret, callConv, op, strings.Join(params, ", "), quals)
+	}
+	// Pointer modifiers (__ptr64, __restrict, __unaligned).
+	for p.peek() == 'E' || p.peek() == 'I' || p.peek() == 'F' {
+		p.pos++
+	}
+	cv := p.storageClass()
+	if p.peek() == 'Y' {
+		// Pointer to array.
+		p.pos++
+		ndims := p.number()
+		var dims string
+		for i := int64(0); i < ndims; i++ {
+			dims += fmt.Sprintf("[%d]", p.number())
+		}
+		return fmt.Sprintf("%s%s (%s)%s%s", p.typ(), cv, op, dims, quals)
+	}
+	return pointerTo(p.typ()+cv, op) + quals
+}
+
+// storageClass parses a storage class (const and volatile qualifiers).
+func (p *msvcParser) storageClass() string {
+	switch c := p.next(); c {
+	case 'A':
+		return ""
+	case 'B':
+		return " const"
+	case 'C':
+		return " volatile"
+	case 'D':
+		return " const volatile"
+	default:
+		fail("invalid storage class %q", c)
+	}
+	panic("unreachable")
+}
+
+// dataType parses the type and storage class of a variable.
+func (p *msvcParser) dataType() string {
+	typ := p.typ()
+	cv := p.storageClass()
+	return typ + cv
+}
+
+// qualifiedName parses a qualified name terminated by '@', and returns its
+// components ordered from outermost to innermost scope. The first component is
+// a plain or template name if first is set; otherwise, only scope components
+// follow (e.g. after a special name).
+func (p *msvcParser) qualifiedName(first bool) []string {
+	var names []string
+	if !first && p.peek() == '@' {
+		// Special name without scope (e.g. "??2@YAPAXI@Z").
+		p.pos++
+		return nil
+	}
+	for {
+		if p.peek() == '@' {
+			p.pos++
+			break
+		}
+		if p.pos >= len(p.s) {
+			fail("unexpected end of qualified name")
+		}
+		names = append(names, p.nameFragment())
+	}
+	// Reverse order; innermost scope is mangled first.
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	return names
+}
+
+// nameFragment parses a component of a qualified name.
+func (p *msvcParser) nameFragment() string {
+	c := p.peek()
+	switch {
+	case '0' <= c && c <= '9':
+		p.pos++
+		i := int(c - '0')
+		if i >= len(p.names) {
+			fail("invalid name back-reference %d", i)
+		}
+		return p.names[i]
+	case strings.HasPrefix(p.s[p.pos:], "?$"):
+		p.pos += 2
+		name := p.templateName()
+		p.addName(name)
+		return name
+	case strings.HasPrefix(p.s[p.pos:], "?A"):
+		// Anonymous namespace (e.g. "?A0x1234abcd@").
+		end := strings.IndexByte(p.s[p.pos:], '@')
+		if end == -1 {
+			fail("unterminated anonymous namespace")
+		}
+		p.pos += end + 1
+		return "`anonymous namespace'"
+	case c == '?':
+		// Nested scope of local names (e.g. "?1??foo@@YAXXZ@").
+		fail("support for local names not yet implemented")
+	}
+	return p.ident()
+}
+
+// templateName parses a template name and its template arguments, following
+// the initial "?$". Template arguments have separate back-reference tables.
+func (p *msvcParser) templateName() string {
+	names, types := p.names, p.types
+	p.names, p.types = nil, nil
+	defer func() {
+		p.names, p.types = names, types
+	}()
+	name := p.ident()
+	var args []string
+	for p.peek() != '@' {
+		if p.pos >= len(p.s) {
+			fail("unexpected end of template argument list")
+		}
+		start := p.pos
+		var arg string
+		switch {
+		case strings.HasPrefix(p.s[p.pos:], "$0"):
+			// Integer constant.
+			p.pos += 2
+			arg = fmt.Sprint(p.number())
+		case strings.HasPrefix(p.s[p.pos:], "$1"):
+			// Address of symbol.
+			p.pos += 2
+			sym := p.symbol()
+			arg = "&" + sym.Name
+		default:
+			arg = p.typ()
+			if p.pos-start > 1 && len(p.types) < 10 {
+				p.types = append(p.types, arg)
+			}
+		}
+		args = append(args, arg)
+	}
+	p.pos++
+	list := strings.Join(args, ", ")
+	if strings.HasSuffix(list, ">") {
+		list += " "
+	}
+	return fmt.Sprintf("%s<%s>", name, list)
+}
+
+// specialName parses a special name (e.g. constructors and operators),
+// following the initial '?'. The special names "ctor" and "dtor" are resolved
+// by the caller.
+func (p *msvcParser) specialName() string {
+	c := p.next()
+	if c == '_' {
+		c2 := p.next()
+		if name, ok := msvcSpecialNames2[c2]; ok {
+			return name
+		}
+		fail("support for special name \"?_%c\" not yet implemented", c2)
+	}
+	switch c {
+	case '0':
+		return "ctor"
+	case '1':
+		return "dtor"
+	case 'B':
+		return msvcConversion
+	}
+	if name, ok := msvcSpecialNames[c]; ok {
+		return name
+	}
+	fail("support for special name \"?%c\" not yet implemented", c)
+	panic("unreachable")
+}
+
+// msvcConversion is the placeholder name of conversion operators, which are
+// named after their return type.
+const msvcConversion = "operator <conversion>"
+
+// msvcSpecialNames maps from special name code to operator name.
+var msvcSpecialNames = map[byte]string{
+	'2': "operator new",
+	'3': "operator delete",
+	'4': "operator=",
+	'5': "operator>>",
+	'6': "operator<<",
+	'7': "operator!",
+	'8': "operator==",
+	'9': "operator!=",
+	'A': "operator[]",
+	'C': "operator->",
+	'D': "operator*",
+	'E': "operator++",
+	'F': "operator--",
+	'G': "operator-",
+	'H': "operator+",
+	'I': "operator&",
+	'J': "operator->*",
+	'K': "operator/",
+	'L': "operator%",
+	'M': "operator<",
+	'N': "operator<=",
+	'O': "operator>",
+	'P': "operator>=",
+	'Q': "operator,",
+	'R': "operator()",
+	'S': "operator~",
+	'T': "operator^",
+	'U': "operator|",
+	'V': "operator&&",
+	'W': "operator||",
+	'X': "operator*=",
+	'Y': "operator+=",
+	'Z': "operator-=",
+}
+
+// msvcSpecialNames2 maps from special name code (following "?_") to operator
+// or compiler-generated name.
+var msvcSpecialNames2 = map[byte]string{
+	'0': "operator/=",
+	'1': "operator%=",
+	'2': "operator>>=",
+	'3': "operator<<=",
+	'4': "operator&=",
+	'5': "operator|=",
+	'6': "operator^=",
+	'7': "`vftable'",
+	'8': "`vbtable'",
+	'9': "`vcall'",
+	'A': "`typeof'",
+	'B': "`local static guard'",
+	'D': "`vbase destructor'",
+	'E': "`vector deleting destructor'",
+	'F': "`default constructor closure'",
+	'G': "`scalar deleting destructor'",
+	'H': "`vector constructor iterator'",
+	'I': "`vector destructor iterator'",
+	'J': "`vector vbase constructor iterator'",
+	'K': "`virtual displacement map'",
+	'L': "`eh vector constructor iterator'",
+	'M': "`eh vector destructor iterator'",
+	'N': "`eh vector vbase constructor iterator'",
+	'O': "`copy constructor closure'",
+	'S': "`local vftable'",
+	'T': "`local vftable constructor closure'",
+	'U': "operator new[]",
+	'V': "operator delete[]",
+	'X': "`placement delete closure'",
+	'Y': "`placement delete[] closure'",
+}
+
+// ident parses an identifier terminated by '@', and records it as a name
+// back-reference.
+func (p *msvcParser) ident() string {
+	end := strings.IndexByte(p.s[p.pos:], '@')
+	if end <= 0 {
+		fail("invalid identifier at offset %d", p.pos)
+	}
+	name := p.s[p.pos : p.pos+end]
+	p.pos += end + 1
+	p.addName(name)
+	return name
+}
+
+// addName records the given name as a name back-reference.
+func (p *msvcParser) addName(name string) {
+	for _, n := range p.names {
+		if n == name {
+			return
+		}
+	}
+	if len(p.names) < 10 {
+		p.names = append(p.names, name)
+	}
+}
+
+// number parses an encoded number; '0'-'9' encodes 1-10, and hexadecimal
+// digits 'A'-'P' terminated by '@' encode other numbers. Negative numbers are
+// prefixed by '?'.
+func (p *msvcParser) number() int64 {
+	neg := false
+	if p.peek() == '?' {
+		neg = true
+		p.pos++
+	}
+	var n int64
+	c := p.next()
+	switch {
+	case '0' <= c && c <= '9':
+		n = int64(c-'0') + 1
+	case 'A' <= c && c <= 'P' || c == '@':
+		for ; c != '@'; c = p.next() {
+			if c < 'A' || 'P' < c {
+				fail("invalid encoded number digit %q", c)
+			}
+			n = n*16 + int64(c-'A')
+		}
+	default:
+		fail("invalid encoded number %q", c)
+	}
+	if neg {
+		return -n
+	}
+	return n
+}
+
+// peek returns the current character, or 0 at end of input.
+func (p *msvcParser) peek() byte {
+	return p.peekAt(0)
+}
+
+// peekAt returns the character at the given offset from the current position,
+// or 0 at end of input.
+func (p *msvcParser) peekAt(off int) byte {
+	if p.pos+off >= len(p.s) {
+		return 0
+	}
+	return p.s[p.pos+off]
+}
+
+// next returns the current character and advances the position.
+func (p *msvcParser) next() byte {
+	if p.pos >= len(p.s) {
+		fail("unexpected end of mangled name")
+	}
+	c := p.s[p.pos]
+	p.pos++
+	return c
+}
+
+// unqualified returns the name without template arguments (e.g. constructors
+// of class templates are named after the template).
+func unqualified(name string) string {
+	if pos := strings.IndexByte(name, '<'); pos != -1 {
+		return name[:pos]
+	}
+	return name
+}
@@ -0,0 +1,670 @@
+package elf
+
+import (
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// parseEHFrame parses the call frame information of the .eh_frame section of the
+// given ELF binary executable, recording the unwinding information of each
+// function in file.Unwind. The .eh_frame section is located through the
+// PT_GNU_EH_FRAME segment (.eh_frame_hdr) if section headers are missing.
+//
+// The .eh_frame section consists of Common Information Entries (CIE), holding
+// information shared by functions (e.g. pointer encodings and initial call frame
+// instructions), and Frame Description Entries (FDE), each specifying the
+// address range of a function and the call frame instructions of its body.
+//
+// ref: https://refspecs.linuxfoundation.org/LSB_5.0.0/LSB-Core-generic/LSB-Core-generic/ehframechpt.html
+func parseEHFrame(f *elf.File, file *bin.File) error {
+	var ehFrameAddr bin.Address
+	if sect := f.Section(".eh_frame"); sect != nil && sect.Size == 0 {
+		// Empty .eh_frame section, as emitted by linkers for input without call
+		// frame information.
+		return nil
+	} else if sect != nil && sect.Addr != 0 {
+		ehFrameAddr = bin.Address(sect.Addr)
+	} else {
+		for _, prog := range f.Progs {
+			if prog.Type != elf.PT_GNU_EH_FRAME {
+				continue
+			}
+			addr, err := parseEHFrameHdr(file, bin.Address(prog.Vaddr))
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			ehFrameAddr = addr
+		}
+	}
+	if ehFrameAddr == 0 {
+		// no call frame information.
+		return nil
+	}
+	data, ok := file.AddressSpace().Bytes(ehFrameAddr, 0)
+	if !ok {
+		return errors.Errorf("unable to locate .eh_frame at %v", ehFrameAddr)
+	}
+	if sect := f.Section(".eh_frame"); sect != nil && int(sect.Size) <= len(data) {
+		data = data[:sect.Size]
+	}
+	p := &ehParser{
+		data:    data,
+		addr:    ehFrameAddr,
+		order:   f.ByteOrder,
+		ptrSize: file.Arch.BitSize() / 8,
+		cies:    make(map[int]*cie),
+	}
+	for off := 0; off < len(data); {
+		next, err := p.parseEntry(file, off)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if next == 0 {
+			// terminator.
+			break
+		}
+		off = next
+	}
+	file.SortUnwind()
+	return nil
+}
+
+// parseEHFrameHdr parses the .eh_frame_hdr section at the given address, and
+// returns the address of the .eh_frame section.
+//
+//    uint8  version;            // 1
+//    uint8  eh_frame_ptr_enc;
+//    uint8  fde_count_enc;
+//    uint8  table_enc;
+//    encoded eh_frame_ptr;
+//    encoded fde_count;
+//    // binary search table of (initial location, FDE address) pairs.
+func parseEHFrameHdr(file *bin.File, hdrAddr bin.Address) (bin.Address, error) {
+	data, ok := file.AddressSpace().Bytes(hdrAddr, 0)
+	if !ok || len(data) < 4 {
+		return 0, errors.Errorf("unable to locate .eh_frame_hdr at %v", hdrAddr)
+	}
+	if data[0] != 1 {
+		return 0, errors.Errorf("unsupported .eh_frame_hdr version %d at %v", data[0], hdrAddr)
+	}
+	r := &ehReader{
+		data:    data,
+		addr:    hdrAddr,
+		off:     4,
+		order:   file.ByteOrder(),
+		ptrSize: file.Arch.BitSize() / 8,
+	}
+	addr := r.ptr(data[1])
+	if r.err != nil {
+		return 0, errors.Wrapf(r.err, "invalid .eh_frame_hdr at %v", hdrAddr)
+	}
+	return addr, nil
+}
+
+// --- [ Call frame information entries ] --------------------------------------
+
+// ehParser is a parser of .eh_frame entries.
+type ehParser struct {
+	// Contents of .eh_frame.
+	data []byte
+	// Address of .eh_frame.
+	addr bin.Address
+	// Byte order and pointer size of the executable.
+	order   binary.ByteOrder
+	ptrSize int
+	// Parsed CIEs, by offset.
+	cies map[int]*cie
+}
+
+// A cie is a Common Information Entry.
+type cie struct {
+	// Code and data alignment factors.
+	codeAlign uint64
+	dataAlign int64
+	// Return address register.
+	raReg uint64
+	// Pointer encoding of FDE addresses.
+	fdeEnc uint8
+	// Augmentation string contains 'z'; FDEs have augmentation data.
+	hasAugData bool
+	// Initial call frame instructions.
+	insts []byte
+}
+
+// parseEntry parses the CIE or FDE at the given offset, and returns the offset
+// of the next entry; or 0 if the entry is a terminator.
+func (p *ehParser) parseEntry(file *bin.File, off int) (int, error) {
+	r := &ehReader{data: p.data, addr: p.addr, off: off, order: p.order, ptrSize: p.ptrSize}
+	length := uint64(r.u32())
+	if r.err != nil || length == 0 {
+		return 0, nil
+	}
+	if length == 0xFFFFFFFF {
+		// 64-bit DWARF format.
+		length = r.u64()
+	}
+	start := r.off
+	end := start + int(length)
+	if r.err != nil || end > len(p.data) || end < start {
+		return 0, errors.Errorf("truncated .eh_frame entry at %v", p.addr+bin.Address(off))
+	}
+	r.data = p.data[:end]
+	idOff := r.off
+	id := r.u32()
+	if id == 0 {
+		c, err := p.parseCIE(r)
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+		p.cies[off] = c
+		return end, nil
+	}
+	// The CIE pointer is relative to the CIE pointer field.
+	cieOff := idOff - int(id)
+	c, ok := p.cies[cieOff]
+	if !ok {
+		if cieOff < 0 || cieOff >= len(p.data) {
+			return 0, errors.Errorf("invalid CIE pointer of FDE at %v", p.addr+bin.Address(off))
+		}
+		cr := &ehReader{data: p.data, addr: p.addr, off: cieOff, order: p.order, ptrSize: p.ptrSize}
+		cieLen := int(cr.u32())
+		if cr.err != nil || cr.off+cieLen > len(p.data) {
+			return 0, errors.Errorf("invalid CIE of FDE at %v", p.addr+bin.Address(off))
+		}
+		cr.data = p.data[:cr.off+cieLen]
+		cr.u32()
+		var err error
+		if c, err = p.parseCIE(cr); err != nil {
+			return 0, errors.WithStack(err)
+		}
+		p.cies[cieOff] = c
+	}
+	u, err := p.parseFDE(r, c)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if u != nil {
+		file.Unwind = append(file.Unwind, u)
+	}
+	return end, nil
+}
+
+// parseCIE parses the body of a CIE, following the CIE ID.
+func (p *ehParser) parseCIE(r *ehReader) (*cie, error) {
+	cieAddr := r.addr + bin.Address(r.off)
+	c := &cie{fdeEnc: dwEhPeAbsPtr}
+	version := r.u8()
+	aug := r.cstring()
+	if strings.Contains(aug, "eh") {
+		// GNU eh_data pointer.
+		r.skip(p.ptrSize)
+	}
+	c.codeAlign = r.uleb()
+	c.dataAlign = r.sleb()
+	if version == 1 {
+		c.raReg = uint64(r.u8())
+	} else {
+		c.raReg = r.uleb()
+	}
+	if strings.HasPrefix(aug, "z") {
+		c.hasAugData = true
+		augLen := int(r.uleb())
+		augEnd := r.off + augLen
+		for _, a := range aug[1:] {
+			switch a {
+			case 'L':
+				// LSDA encoding.
+				r.u8()
+			case 'P':
+				// Personality routine.
+				enc := r.u8()
+				r.ptr(enc)
+			case 'R':
+				c.fdeEnc = r.u8()
+			case 'S', 'B':
+				// signal frame; no data.
+			default:
+				// unknown augmentation; skip remaining data.
+			}
+		}
+		r.off = augEnd
+	}
+	if r.err != nil || r.off > len(r.data) {
+		return nil, errors.Errorf("invalid CIE at %v", cieAddr)
+	}
+	c.insts = r.data[r.off:]
+	return c, nil
+}
+
+// parseFDE parses the body of an FDE, following the CIE pointer, and returns the
+// unwinding information of the function.
+func (p *ehParser) parseFDE(r *ehReader, c *cie) (*bin.Unwind, error) {
+	fdeAddr := r.addr + bin.Address(r.off)
+	start := r.ptr(c.fdeEnc)
+	// The address range uses the format of the FDE encoding, without
+	// application (e.g. pc-relative).
+	size := r.ptr(c.fdeEnc & 0x0F)
+	if c.hasAugData {
+		augLen := int(r.uleb())
+		r.skip(augLen)
+	}
+	if r.err != nil || r.off > len(r.data) {
+		return nil, errors.Errorf("invalid FDE at %v", fdeAddr)
+	}
+	if start == 0 {
+		// skip FDE of discarded function.
+		return nil, nil
+	}
+	u := &bin.Unwind{
+		Start: start,
+		End:   start + size,
+	}
+	regs := x86DwarfRegs64
+	if p.ptrSize == 4 {
+		regs = x86DwarfRegs32
+	}
+	s := &cfaState{cie: c, unwind: u, regs: regs, ptrSize: p.ptrSize, cfaReg: -1}
+	if err := s.exec(r, c.insts, false); err != nil {
+		return nil, errors.Wrapf(err, "invalid initial instructions of CIE of FDE at %v", fdeAddr)
+	}
+	if err := s.exec(r, r.data[r.off:], true); err != nil {
+		return nil, errors.Wrapf(err, "invalid instructions of FDE at %v", fdeAddr)
+	}
+	s.finish()
+	return u, nil
+}
+
+// --- [ Call frame instructions ] ---------------------------------------------
+
+// DWARF register names of x86 and x86-64.
+var (
+	x86DwarfRegs32 = []string{"eax", "ecx", "edx", "ebx", "esp", "ebp", "esi", "edi"}
+	x86DwarfRegs64 = []string{"rax", "rdx", "rcx", "rbx", "rsi", "rdi", "rbp", "rsp", "r8", "r9", "r10", "r11", "r12", "r13", "r14", "r15"}
+)
+
+// cfaState tracks the call frame rules of the prologue of a function.
+type cfaState struct {
+	cie    *cie
+	unwind *bin.Unwind
+	// DWARF register names.
+	regs    []string
+	ptrSize int
+	// Current location, relative to the start of the function.
+	loc uint64
+	// CFA register and offset; cfaReg is -1 if unknown.
+	cfaReg    int
+	cfaOffset int64
+	// Register holding the CFA on entry (i.e. the stack pointer).
+	spReg int
+	// Saved registers, by DWARF register number, and their CFA offset.
+	saved map[uint64]int64
+	// Order in which registers were saved.
+	order []uint64
+	// End of prologue reached.
+	done bool
+}
+
+// exec interprets the given call frame instructions until the end of the
+// prologue; i.e. the first instruction which restores state (epilogue or
+// remember_state). If fde is set, the location of each rule is tracked to
+// determine the size of the prologue.
+func (s *cfaState) exec(r *ehReader, insts []byte, fde bool) error {
+	ir := &ehReader{data: insts, addr: r.addr, order: r.order, ptrSize: r.ptrSize}
+	if s.saved == nil {
+		s.saved = make(map[uint64]int64)
+	}
+	// mark records the end of the prologue at the current location.
+	mark := func() {
+		if fde && s.loc > 0 {
+			s.unwind.PrologueSize = int(s.loc)
+		}
+	}
+	for ir.off < len(insts) && !s.done && ir.err == nil {
+		op := ir.u8()
+		switch op & 0xC0 {
+		case dwCFAAdvanceLoc:
+			s.loc += uint64(op&0x3F) * s.cie.codeAlign
+			continue
+		case dwCFAOffset:
+			s.save(uint64(op&0x3F), int64(ir.uleb())*s.cie.dataAlign)
+			mark()
+			continue
+		case dwCFARestore:
+			s.done = true
+			continue
+		}
+		switch op {
+		case dwCFANop:
+		case dwCFASetLoc:
+			loc := ir.ptr(dwEhPeAbsPtr)
+			s.loc = uint64(loc - s.unwind.Start)
+		case dwCFAAdvanceLoc1:
+			s.loc += uint64(ir.u8()) * s.cie.codeAlign
+		case dwCFAAdvanceLoc2:
+			s.loc += uint64(ir.u16()) * s.cie.codeAlign
+		case dwCFAAdvanceLoc4:
+			s.loc += uint64(ir.u32()) * s.cie.codeAlign
+		case dwCFAOffsetExtended:
+			reg := ir.uleb()
+			s.save(reg, int64(ir.uleb())*s.cie.dataAlign)
+			mark()
+		case dwCFAOffsetExtendedSf:
+			reg := ir.uleb()
+			s.save(reg, ir.sleb()*s.cie.dataAlign)
+			mark()
+		case dwCFARestoreExtended, dwCFARememberState, dwCFARestoreState:
+			s.done = true
+		case dwCFAUndefined, dwCFASameValue, dwCFADefCFARegister:
+			reg := int(ir.uleb())
+			if op == dwCFADefCFARegister {
+				if !s.defCFA(reg, s.cfaOffset) {
+					break
+				}
+				mark()
+			}
+		case dwCFARegister, dwCFAValOffset, dwCFAGNUNegativeOffsetExtended:
+			ir.uleb()
+			ir.uleb()
+		case dwCFAValOffsetSf:
+			ir.uleb()
+			ir.sleb()
+		case dwCFADefCFA:
+			reg := int(ir.uleb())
+			off := int64(ir.uleb())
+			if s.defCFA(reg, off) {
+				mark()
+			}
+		case dwCFADefCFASf:
+			reg := int(ir.uleb())
+			off := ir.sleb() * s.cie.dataAlign
+			if s.defCFA(reg, off) {
+				mark()
+			}
+		case dwCFADefCFAOffset:
+			if s.defCFA(s.cfaReg, int64(ir.uleb())) {
+				mark()
+			}
+		case dwCFADefCFAOffsetSf:
+			if s.defCFA(s.cfaReg, ir.sleb()*s.cie.dataAlign) {
+				mark()
+			}
+		case dwCFADefCFAExpression:
+			ir.skip(int(ir.uleb()))
+			s.done = true
+		case dwCFAExpression, dwCFAValExpression:
+			ir.uleb()
+			ir.skip(int(ir.uleb()))
+		case dwCFAGNUArgsSize:
+			ir.uleb()
+		default:
+			return errors.Errorf("unknown call frame instruction 0x%02X", op)
+		}
+	}
+	return ir.err
+}
+
+// defCFA defines the CFA as the given register and offset. The boolean return
+// value indicates whether the rule is part of the prologue; the prologue ends
+// when the CFA offset decreases (e.g. pop in the epilogue) or when the CFA
+// reverts to the stack pointer.
+func (s *cfaState) defCFA(reg int, off int64) bool {
+	if s.cfaReg == -1 {
+		// Initial CFA rule of CIE.
+		s.cfaReg, s.cfaOffset, s.spReg = reg, off, reg
+		return false
+	}
+	if reg == s.cfaReg && off < s.cfaOffset || reg == s.spReg && s.cfaReg != s.spReg {
+		s.done = true
+		return false
+	}
+	s.cfaReg, s.cfaOffset = reg, off
+	return true
+}
+
+// save records that the given register is saved at the given CFA offset.
+func (s *cfaState) save(reg uint64, off int64) {
+	if reg == s.cie.raReg {
+		// return address.
+		return
+	}
+	if _, ok := s.saved[reg]; !ok {
+		s.order = append(s.order, reg)
+	}
+	s.saved[reg] = off
+}
+
+// finish records the frame layout of the prologue in the unwinding information.
+func (s *cfaState) finish() {
+	u := s.unwind
+	u.FrameSize = s.cfaOffset - int64(s.ptrSize)
+	if s.cfaReg != s.spReg && 0 <= s.cfaReg && s.cfaReg < len(s.regs) {
+		u.FrameReg = s.regs[s.cfaReg]
+	}
+	for _, reg := range s.order {
+		name := fmt.Sprintf("r%d", reg)
+		if reg < uint64(len(s.regs)) {
+			name = s.regs[reg]
+		}
+		u.SavedRegs = append(u.SavedRegs, bin.SavedReg{Reg: name, Offset: s.saved[reg]})
+	}
+}
+
+// Call frame instructions.
+const (
+	// Primary opcodes, encoded in the high two bits.
+	dwCFAAdvanceLoc = 0x40
+	dwCFAOffset     = 0x80
+	dwCFARestore    = 0xC0
+	// Extended opcodes.
+	dwCFANop                       = 0x00
+	dwCFASetLoc                    = 0x01
+	dwCFAAdvanceLoc1               = 0x02
+	dwCFAAdvanceLoc2               = 0x03
+	dwCFAAdvanceLoc4               = 0x04
+	dwCFAOffsetExtended            = 0x05
+	dwCFARestoreExtended           = 0x06
+	dwCFAUndefined                 = 0x07
+	dwCFASameValue                 = 0x08
+	dwCFARegister                  = 0x09
+	dwCFARememberState             = 0x0A
+	dwCFARestoreState              = 0x0B
+	dwCFADefCFA                    = 0x0C
+	dwCFADefCFARegister            = 0x0D
+	dwCFADefCFAOffset              = 0x0E
+	dwCFADefCFAExpression          = 0x0F
+	dwCFAExpression                = 0x10
+	dwCFAOffsetExtendedSf          = 0x11
+	dwCFADefCFASf                  = 0x12
+	dwCFADefCFAOffsetSf            = 0x13
+	dwCFAValOffset                 = 0x14
+	dwCFAValOffsetSf               = 0x15
+	dwCFAValExpression             = 0x16
+	dwCFAGNUArgsSize               = 0x2E
+	dwCFAGNUNegativeOffsetExtended = 0x2F
+)
+
+// --- [ Reader ] --------------------------------------------------------------
+
+// Pointer encodings of .eh_frame.
+const (
+	// Format, encoded in the low four bits.
+	dwEhPeAbsPtr  = 0x00
+	dwEhPeULEB128 = 0x01
+	dwEhPeUData2  = 0x02
+	dwEhPeUData4  = 0x03
+	dwEhPeUData8  = 0x04
+	dwEhPeSLEB128 = 0x09
+	dwEhPeSData2  = 0x0A
+	dwEhPeSData4  = 0x0B
+	dwEhPeSData8  = 0x0C
+	// Application, encoded in bits 4 to 6.
+	dwEhPePCRel = 0x10
+	// Pointer is indirect.
+	dwEhPeIndirect = 0x80
+	// No value present.
+	dwEhPeOmit = 0xFF
+)
+
+// ehReader is a reader of .eh_frame and .eh_frame_hdr data. Reading past the
+// end of data records an error in err, after which reads return zero.
+type ehReader struct {
+	// Data, and address of data[0].
+	data []byte
+	addr bin.Address
+	// Current offset into data.
+	off int
+	// Byte order and pointer size of the executable.
+	order   binary.ByteOrder
+	ptrSize int
+	// First error encountered.
+	err error
+}
+
+// next returns the next n bytes, advancing the offset.
+func (r *ehReader) next(n int) []byte {
+	if r.err != nil {
+		return make([]byte, n)
+	}
+	if n < 0 || r.off+n > len(r.data) {
+		r.err = errors.Errorf("unexpected end of data at %v", r.addr+bin.Address(r.off))
+		return make([]byte, n)
+	}
+	buf := r.data[r.off : r.off+n]
+	r.off += n
+	return buf
+}
+
+// skip skips n bytes.
+func (r *ehReader) skip(n int) {
+	r.next(n)
+}
+
+// u8 reads an unsigned 8-bit integer.
+func (r *ehReader) u8() uint8 {
+	return r.next(1)[0]
+}
+
+// u16 reads an unsigned 16-bit integer.
+func (r *ehReader) u16() uint16 {
+	return r.order.Uint16(r.next(2))
+}
+
+// u32 reads an unsigned 32-bit integer.
+func (r *ehReader) u32() uint32 {
+	return r.order.Uint32(r.next(4))
+}
+
+// u64 reads an unsigned 64-bit integer.
+func (r *ehReader) u64() uint64 {
+	return r.order.Uint64(r.next(8))
+}
+
+// uleb reads an unsigned LEB128 integer.
+func (r *ehReader) uleb() uint64 {
+	var x uint64
+	for shift := uint(0); r.err == nil; shift += 7 {
+		b := r.u8()
+		if shift < 64 {
+			x |= uint64(b&0x7F) << shift
+		}
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	return x
+}
+
+// sleb reads a signed LEB128 integer.
+func (r *ehReader) sleb() int64 {
+	var x int64
+	shift := uint(0)
+	for r.err == nil {
+		b := r.u8()
+		if shift < 64 {
+			x |= int64(b&0x7F) << shift
+		}
+		shift += 7
+		if b&0x80 == 0 {
+			if shift < 64 && b&0x40 != 0 {
+				// sign extend.
+				x |= -1 << shift
+			}
+			break
+		}
+	}
+	return x
+}
+
+// cstring reads a NULL-terminated string.
+func (r *ehReader) cstring() string {
+	var buf []byte
+	for r.err == nil {
+		b := r.u8()
+		if b == 0 {
+			break
+		}
+		buf = append(buf, b)
+	}
+	return string(buf)
+}
+
+// ptr reads a pointer of the given encoding.
+func (r *ehReader) ptr(enc uint8) bin.Address {
+	if enc == dwEhPeOmit {
+		return 0
+	}
+	pc := r.addr + bin.Address(r.off)
+	var x uint64
+	switch enc & 0x0F {
+	case dwEhPeAbsPtr:
+		if r.ptrSize == 4 {
+			x = uint64(r.u32())
+		} else {
+			x = r.u64()
+		}
+	case dwEhPeULEB128:
+		x = r.uleb()
+	case dwEhPeUData2:
+		x = uint64(r.u16())
+	case dwEhPeUData4:
+		x = uint64(r.u32())
+	case dwEhPeUData8:
+		x = r.u64()
+	case dwEhPeSLEB128:
+		x = uint64(r.sleb())
+	case dwEhPeSData2:
+		x = uint64(int64(int16(r.u16())))
+	case dwEhPeSData4:
+		x = uint64(int64(int32(r.u32())))
+	case dwEhPeSData8:
+		x = r.u64()
+	default:
+		r.err = errors.Errorf("unsupported pointer encoding 0x%02X at %v", enc, pc)
+		return 0
+	}
+	switch enc & 0x70 {
+	case 0:
+		// absolute.
+	case dwEhPePCRel:
+		x += uint64(pc)
+	default:
+		// text-, data- and function-relative pointers are not used by x86
+		// executables.
+		r.err = errors.Errorf("unsupported pointer application 0x%02X at %v", enc&0x70, pc)
+		return 0
+	}
+	if r.ptrSize == 4 {
+		x = uint64(uint32(x))
+	}
+	if enc&dwEhPeIndirect != 0 {
+		// The pointer is resolved at runtime; personality routines are not
+		// needed to unwind.
+		return 0
+	}
+	return bin.Address(x)
+}
@@ -0,0 +1,107 @@
+package elf_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/bin/elf"
+)
+
+func TestParseEHFrame(t *testing.T) {
+	// Function which saves rbp and rbx, and establishes rbp as frame pointer,
+	// calling a leaf function without frame pointer.
+	//
+	//    _start:
+	//       push rbp
+	//       mov  rbp, rsp
+	//       push rbx
+	//       ...
+	//    f:
+	//       sub  rsp, 24
+	//       ...
+	unwind64 := []*bin.Unwind{
+		{
+			Start:        0x4000B0,
+			End:          0x4000C6,
+			PrologueSize: 5,
+			FrameSize:    8,
+			FrameReg:     "rbp",
+			SavedRegs: []bin.SavedReg{
+				{Reg: "rbp", Offset: -16},
+				{Reg: "rbx", Offset: -24},
+			},
+		},
+		{
+			Start:        0x4000C6,
+			End:          0x4000D4,
+			PrologueSize: 4,
+			FrameSize:    24,
+		},
+	}
+	golden := []struct {
+		path string
+		want []*bin.Unwind
+	}{
+		{
+			path: "testdata/eh_x86_32",
+			want: []*bin.Unwind{
+				{
+					Start:        0x8048074,
+					End:          0x8048089,
+					PrologueSize: 4,
+					FrameSize:    4,
+					FrameReg:     "ebp",
+					SavedRegs: []bin.SavedReg{
+						{Reg: "ebp", Offset: -8},
+						{Reg: "ebx", Offset: -12},
+					},
+				},
+				{
+					Start:        0x8048089,
+					End:          0x8048095,
+					PrologueSize: 3,
+					FrameSize:    12,
+				},
+			},
+		},
+		// .eh_frame section.
+		{path: "testdata/eh_x86_64", want: unwind64},
+		// .eh_frame located through the PT_GNU_EH_FRAME segment (.eh_frame_hdr).
+		{path: "testdata/eh_x86_64_nosect", want: unwind64},
+	}
+	for _, g := range golden {
+		file, err := elf.ParseFile(g.path)
+		if err != nil {
+			t.Errorf("%q: unable to parse ELF executable; %+v", g.path, err)
+			continue
+		}
+		if len(file.Unwind) != len(g.want) {
+			t.Errorf("%q: number of unwind info entries mismatch; expected %d, got %d", g.path, len(g.want), len(file.Unwind))
+			continue
+		}
+		for i, u := range file.Unwind {
+			if !reflect.DeepEqual(u, g.want[i]) {
+				t.Errorf("%q: unwind info %d: mismatch; expected %+v, got %+v", g.path, i, g.want[i], u)
+			}
+		}
+	}
+}
+
+func TestParseEHFrameInvalid(t *testing.T) {
+	golden := []struct {
+		path string
+		desc string
+	}{
+		{path: "testdata/invalid/eh_cie_len", desc: "extended length of CIE exceeding .eh_frame"},
+		{path: "testdata/invalid/eh_fde_len", desc: "length of FDE exceeding .eh_frame"},
+		{path: "testdata/invalid/eh_cie_ptr", desc: "CIE pointer of FDE outside of .eh_frame"},
+		{path: "testdata/invalid/eh_cfa_inst", desc: "unknown call frame instruction"},
+		{path: "testdata/invalid/eh_hdr_ptr", desc: ".eh_frame_hdr referring to .eh_frame outside of executable"},
+	}
+	for _, g := range golden {
+		if _, err := elf.ParseFile(g.path); err == nil {
+			t.Errorf("%q: %s; expected error, got nil", g.path, g.desc)
+		}
+	}
+}
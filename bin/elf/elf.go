@@ -7,8 +7,6 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
-	"io/ioutil"
-	"os"
 	"sort"
 
 	"github.com/decomp/exp/bin"
@@ -24,20 +22,31 @@ func init() {
 	bin.RegisterFormat("elf", magic, Parse)
 }
 
-// ParseFile parses the given ELF binary executable, reading from path.
+// ParseFile parses the given ELF binary executable, reading from path. The
+// file is memory-mapped, and section data is loaded lazily on first access.
+//
+// Section data refers to the memory-mapped file, which is therefore kept open
+// until the returned file is closed.
 func ParseFile(path string) (*bin.File, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, errors.WithStack(err)
+	parse := func(m *bin.Mapping) (*bin.File, error) {
+		return Parse(m)
 	}
-	defer f.Close()
-	return Parse(f)
+	return bin.ParseMapped(path, parse)
 }
 
 // Parse parses the given ELF binary executable, reading from r.
 //
 // Users are responsible for closing r.
 func Parse(r io.ReaderAt) (*bin.File, error) {
+	return ParseAt(r, objBase)
+}
+
+// ParseAt parses the given ELF binary executable, reading from r. The first
+// section of relocatable object files is placed at the specified base address;
+// executables and shared objects are placed at their linked addresses.
+//
+// Users are responsible for closing r.
+func ParseAt(r io.ReaderAt, base bin.Address) (*bin.File, error) {
 	// Open ELF file.
 	f, err := elf.NewFile(r)
 	if err != nil {
@@ -46,8 +55,9 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 
 	// Parse machine architecture.
 	file := &bin.File{
-		Imports: make(map[bin.Address]string),
-		Exports: make(map[bin.Address]string),
+		Imports:   make(map[bin.Address]string),
+		Exports:   make(map[bin.Address]string),
+		BigEndian: f.ByteOrder == binary.BigEndian,
 	}
 	switch f.Machine {
 	case elf.EM_386:
@@ -58,15 +68,54 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 		file.Arch = bin.ArchPowerPC_32
 	}
 
+	// Parse relocatable object file.
+	if f.Type == elf.ET_REL {
+		return parseRel(f, file, base)
+	}
+
+	// Parse required shared libraries.
+	libs, err := f.ImportedLibraries()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	file.Libs = libs
+
 	// Parse entry address.
 	file.Entry = bin.Address(f.Entry)
 
+	// Shared objects have no program interpreter, as opposed to position
+	// independent executables.
+	if f.Type == elf.ET_DYN {
+		file.Shared = true
+		for _, prog := range f.Progs {
+			if prog.Type == elf.PT_INTERP {
+				file.Shared = false
+			}
+		}
+	}
+
+	// Parse exploit mitigations. Shared objects and position independent
+	// executables may be loaded at randomized base addresses, and the stack is
+	// non-executable unless requested by PT_GNU_STACK.
+	if f.Type == elf.ET_DYN {
+		file.Flags |= bin.ImageASLR
+	}
+	for _, prog := range f.Progs {
+		if prog.Type == elf.PT_GNU_STACK && prog.Flags&elf.PF_X == 0 {
+			file.Flags |= bin.ImageNX
+		}
+	}
+
 	// Parse sections.
 	for _, s := range f.Sections {
 		perm := parseSectFlags(s.Flags)
 		var data []byte
 		if s.Type != elf.SHT_NOBITS {
-			data, err = s.Data()
+			if s.Flags&elf.SHF_COMPRESSED != 0 {
+				data, err = s.Data()
+			} else {
+				data, err = bin.ReadData(r, int64(s.Offset), int(s.FileSize))
+			}
 			if err != nil {
 				return nil, errors.WithStack(err)
 			}
@@ -82,6 +131,10 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 			MemSize:  int(s.Size),
 			Data:     data,
 			Perm:     perm,
+			Align:    int(s.Addralign),
+		}
+		if s.Type == elf.SHT_NOBITS {
+			sect.Flags |= bin.SectUninit
 		}
 		file.Sections = append(file.Sections, sect)
 	}
@@ -105,8 +158,7 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 		if prog.Type != elf.PT_LOAD {
 			continue
 		}
-		r := prog.Open()
-		data, err := ioutil.ReadAll(r)
+		data, err := bin.ReadData(r, int64(prog.Off), int(prog.Filesz))
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
@@ -118,6 +170,7 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 			FileSize: int(prog.Filesz),
 			MemSize:  int(prog.Memsz),
 			Perm:     perm,
+			Align:    int(prog.Align),
 		}
 		segments = append(segments, seg)
 	}
@@ -190,7 +243,7 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 			r := bytes.NewReader(gotpltData[4+4+4:])
 			for _, dynSym := range dynSyms {
 				var v uint32
-				if err := binary.Read(r, binary.LittleEndian, &v); err != nil {
+				if err := binary.Read(r, f.ByteOrder, &v); err != nil {
 					if errors.Cause(err) == io.EOF {
 						break
 					}
@@ -209,7 +262,7 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 			r := bytes.NewReader(gotpltData[8+8+8:])
 			for _, dynSym := range dynSyms {
 				var v uint64
-				if err := binary.Read(r, binary.LittleEndian, &v); err != nil {
+				if err := binary.Read(r, f.ByteOrder, &v); err != nil {
 					if errors.Cause(err) == io.EOF {
 						break
 					}
@@ -262,7 +315,7 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 			}
 			for {
 				var sym Sym32
-				if err := binary.Read(r, binary.LittleEndian, &sym); err != nil {
+				if err := binary.Read(r, f.ByteOrder, &sym); err != nil {
 					if errors.Cause(err) == io.EOF {
 						break
 					}
@@ -304,7 +357,7 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 			}
 			for {
 				var sym Sym64
-				if err := binary.Read(r, binary.LittleEndian, &sym); err != nil {
+				if err := binary.Read(r, f.ByteOrder, &sym); err != nil {
 					if errors.Cause(err) == io.EOF {
 						break
 					}
@@ -332,6 +385,11 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 		}
 	}
 
+	// Parse call frame information.
+	if err := parseEHFrame(f, file); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
 	return file, nil
 }
 
@@ -0,0 +1,275 @@
+package elf
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// Relocatable object files.
+//
+// The allocated sections of relocatable object files (ET_REL) are laid out
+// consecutively in memory, starting at objBase, and relocations are applied
+// against the symbols of the symbol table. Function symbols are recorded as
+// exports, providing exact function boundaries to the disassembler. Undefined
+// symbols are mapped to slots of an artificial import section following the
+// last section of the object file.
+
+// Base address of the first section of relocatable object files.
+const objBase = 0x10000
+
+// Size in bytes of slots of undefined symbols in the artificial import section.
+const importSlotSize = 8
+
+// parseRel parses the given relocatable ELF object file into file, placing its
+// first section at the specified base address.
+func parseRel(f *elf.File, file *bin.File, base bin.Address) (*bin.File, error) {
+	// Lay out allocated sections.
+	sectAddrs := make(map[int]bin.Address)
+	sects := make(map[int]*bin.Section)
+	addr := base
+	for i, s := range f.Sections {
+		if s.Flags&elf.SHF_ALLOC == 0 || s.Size == 0 {
+			continue
+		}
+		addr = alignAddr(addr, s.Addralign)
+		var data []byte
+		if s.Type != elf.SHT_NOBITS {
+			buf, err := s.Data()
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			data = buf
+		}
+		sect := &bin.Section{
+			Name:     s.Name,
+			Addr:     addr,
+			Offset:   s.Offset,
+			Data:     data,
+			FileSize: len(data),
+			MemSize:  int(s.Size),
+			Perm:     parseSectFlags(s.Flags) | bin.PermR,
+			Align:    int(s.Addralign),
+		}
+		if s.Type == elf.SHT_NOBITS {
+			sect.Flags |= bin.SectUninit
+		}
+		sectAddrs[i] = addr
+		sects[i] = sect
+		file.Sections = append(file.Sections, sect)
+		addr += bin.Address(s.Size)
+	}
+
+	// Resolve symbols.
+	syms, err := f.Symbols()
+	if err != nil && err != elf.ErrNoSymbols {
+		return nil, errors.WithStack(err)
+	}
+	importBase := alignAddr(addr, 16)
+	importAddr := importBase
+	// Symbol addresses, indexed by symbol table index - 1.
+	symAddrs := make([]bin.Address, len(syms))
+	var funcs bin.Addresses
+	for i, sym := range syms {
+		switch {
+		case sym.Section == elf.SHN_UNDEF:
+			if sym.Name == "" {
+				continue
+			}
+			file.Imports[importAddr] = sym.Name
+			symAddrs[i] = importAddr
+			importAddr += importSlotSize
+		case sym.Section == elf.SHN_ABS:
+			symAddrs[i] = bin.Address(sym.Value)
+		case sym.Section == elf.SHN_COMMON:
+			// Common symbols are allocated in the artificial import section.
+			importAddr = alignAddr(importAddr, sym.Value)
+			symAddrs[i] = importAddr
+			importAddr += alignAddr(bin.Address(sym.Size), importSlotSize)
+		default:
+			base, ok := sectAddrs[int(sym.Section)]
+			if !ok {
+				// symbol of non-allocated section (e.g. debug information).
+				continue
+			}
+			symAddrs[i] = base + bin.Address(sym.Value)
+			if elf.ST_TYPE(sym.Info) == elf.STT_FUNC {
+				file.Exports[symAddrs[i]] = sym.Name
+				funcs = append(funcs, symAddrs[i])
+			}
+		}
+	}
+	if importAddr > importBase {
+		size := int(importAddr - importBase)
+		sect := &bin.Section{
+			Name:    ".imports",
+			Addr:    importBase,
+			Data:    make([]byte, size),
+			MemSize: size,
+			Perm:    bin.PermR | bin.PermW,
+		}
+		file.Sections = append(file.Sections, sect)
+	}
+
+	// Apply relocations.
+	for _, s := range f.Sections {
+		if s.Type != elf.SHT_REL && s.Type != elf.SHT_RELA {
+			continue
+		}
+		target, ok := sects[int(s.Info)]
+		if !ok || target.Data == nil {
+			// relocations of non-allocated section (e.g. debug information).
+			continue
+		}
+		data, err := s.Data()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if err := applyRelocs(file.Arch, s.Type == elf.SHT_RELA, data, target, symAddrs); err != nil {
+			return nil, errors.Wrapf(err, "unable to apply relocations of section %q", s.Name)
+		}
+	}
+
+	// Use main function (or first function) as entry point.
+	sort.Sort(funcs)
+	for addr, name := range file.Exports {
+		if name == "main" {
+			file.Entry = addr
+		}
+	}
+	if file.Entry == 0 && len(funcs) > 0 {
+		file.Entry = funcs[0]
+	}
+	return file, nil
+}
+
+// applyRelocs applies the given relocation entries (REL or RELA) to the target
+// section, based on the addresses of the symbols of the symbol table.
+func applyRelocs(arch bin.Arch, rela bool, data []byte, target *bin.Section, symAddrs []bin.Address) error {
+	r := bytes.NewReader(data)
+	for r.Len() > 0 {
+		var (
+			off    uint64
+			symIdx uint32
+			typ    uint32
+			addend int64
+		)
+		switch arch {
+		case bin.ArchX86_32:
+			var rel elf.Rela32
+			if rela {
+				if err := binary.Read(r, binary.LittleEndian, &rel); err != nil {
+					return errors.WithStack(err)
+				}
+			} else {
+				if err := binary.Read(r, binary.LittleEndian, &rel.Off); err != nil {
+					return errors.WithStack(err)
+				}
+				if err := binary.Read(r, binary.LittleEndian, &rel.Info); err != nil {
+					return errors.WithStack(err)
+				}
+			}
+			off, symIdx, typ, addend = uint64(rel.Off), elf.R_SYM32(rel.Info), elf.R_TYPE32(rel.Info), int64(rel.Addend)
+		case bin.ArchX86_64:
+			var rel elf.Rela64
+			if rela {
+				if err := binary.Read(r, binary.LittleEndian, &rel); err != nil {
+					return errors.WithStack(err)
+				}
+			} else {
+				if err := binary.Read(r, binary.LittleEndian, &rel.Off); err != nil {
+					return errors.WithStack(err)
+				}
+				if err := binary.Read(r, binary.LittleEndian, &rel.Info); err != nil {
+					return errors.WithStack(err)
+				}
+			}
+			off, symIdx, typ, addend = rel.Off, elf.R_SYM64(rel.Info), elf.R_TYPE64(rel.Info), rel.Addend
+		default:
+			return errors.Errorf("support for relocations of machine architecture %v not yet implemented", arch)
+		}
+		if symIdx == 0 || int(symIdx) > len(symAddrs) {
+			return errors.Errorf("invalid symbol index %d of relocation at offset 0x%X", symIdx, off)
+		}
+		s := uint64(symAddrs[symIdx-1])
+		p := uint64(target.Addr) + off
+		if err := applyReloc(arch, typ, target.Data, off, s, p, addend, rela); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// applyReloc applies the relocation of the given type at the specified offset
+// of the section data, where s is the symbol address, p the address of the
+// relocated location and addend the explicit addend (RELA) of the relocation.
+// The implicit addend of REL relocations is stored at the relocated location.
+func applyReloc(arch bin.Arch, typ uint32, data []byte, off, s, p uint64, addend int64, rela bool) error {
+	// Size in bytes of relocated location.
+	size := uint64(4)
+	var (
+		// Relative to the relocated location.
+		pcrel bool
+	)
+	switch arch {
+	case bin.ArchX86_32:
+		switch elf.R_386(typ) {
+		case elf.R_386_NONE:
+			return nil
+		case elf.R_386_32:
+		case elf.R_386_PC32, elf.R_386_PLT32:
+			pcrel = true
+		default:
+			return errors.Errorf("support for relocation type %v not yet implemented", elf.R_386(typ))
+		}
+	case bin.ArchX86_64:
+		switch elf.R_X86_64(typ) {
+		case elf.R_X86_64_NONE:
+			return nil
+		case elf.R_X86_64_64:
+			size = 8
+		case elf.R_X86_64_32, elf.R_X86_64_32S:
+		case elf.R_X86_64_PC32, elf.R_X86_64_PLT32:
+			pcrel = true
+		default:
+			return errors.Errorf("support for relocation type %v not yet implemented", elf.R_X86_64(typ))
+		}
+	}
+	if off+size > uint64(len(data)) {
+		return errors.Errorf("relocation offset 0x%X out of bounds", off)
+	}
+	if !rela {
+		if size == 8 {
+			addend = int64(binary.LittleEndian.Uint64(data[off:]))
+		} else {
+			addend = int64(int32(binary.LittleEndian.Uint32(data[off:])))
+		}
+	}
+	v := s + uint64(addend)
+	if pcrel {
+		v -= p
+	}
+	switch size {
+	case 4:
+		binary.LittleEndian.PutUint32(data[off:], uint32(v))
+	case 8:
+		binary.LittleEndian.PutUint64(data[off:], v)
+	default:
+		panic(fmt.Errorf("support for relocation size %d not yet implemented", size))
+	}
+	return nil
+}
+
+// alignAddr returns the given address rounded up to the specified alignment.
+func alignAddr(addr bin.Address, align uint64) bin.Address {
+	if align <= 1 {
+		return addr
+	}
+	a := bin.Address(align)
+	return (addr + a - 1) / a * a
+}
@@ -0,0 +1,34 @@
+	.text
+	.globl	_start
+	.type	_start, @function
+_start:
+	.cfi_startproc
+	pushl	%ebp
+	.cfi_def_cfa_offset 8
+	.cfi_offset %ebp, -8
+	movl	%esp, %ebp
+	.cfi_def_cfa_register %ebp
+	pushl	%ebx
+	.cfi_offset %ebx, -12
+	call	f
+	movl	%eax, %ebx
+	movl	$1, %eax
+	int	$0x80
+	popl	%ebx
+	popl	%ebp
+	.cfi_def_cfa %esp, 4
+	ret
+	.cfi_endproc
+	.size	_start, .-_start
+
+	.type	f, @function
+f:
+	.cfi_startproc
+	subl	$12, %esp
+	.cfi_def_cfa_offset 16
+	movl	$42, %eax
+	addl	$12, %esp
+	.cfi_def_cfa_offset 4
+	ret
+	.cfi_endproc
+	.size	f, .-f
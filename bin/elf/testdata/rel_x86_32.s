@@ -0,0 +1,19 @@
+	.text
+	.globl	main
+	.type	main, @function
+main:
+	pushl	%ebp
+	movl	%esp, %ebp
+	pushl	$msg
+	call	puts
+	pushl	$0
+	call	exit
+	.size	main, .-main
+
+	.data
+msg:
+	.asciz	"hello"
+	.align	4
+	.globl	main_ptr
+main_ptr:
+	.long	main
@@ -0,0 +1,20 @@
+	.text
+	.globl	main
+	.type	main, @function
+main:
+	pushq	%rbp
+	movq	%rsp, %rbp
+	leaq	msg(%rip), %rdi
+	call	puts@PLT
+	xorl	%edi, %edi
+	call	exit@PLT
+	.size	main, .-main
+
+	.data
+	.align	8
+msg:
+	.asciz	"hello"
+	.align	8
+	.globl	main_ptr
+main_ptr:
+	.quad	main
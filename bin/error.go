@@ -0,0 +1,109 @@
+package bin
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxErrorBytes specifies the maximum number of raw bytes recorded by position
+// errors; the maximum length of an x86 instruction.
+const maxErrorBytes = 15
+
+// A PosError is an error at a given address of a binary executable, carrying
+// the context required to triage the error without rerunning the tool (e.g. in
+// batch runs over many executables).
+//
+// Example error message:
+//
+//    decode error at 0x401000 (.text) [0F FF 41 00]: invalid instruction
+type PosError struct {
+	// Address of the error.
+	Addr Address
+	// Raw bytes at the address; at most 15 bytes.
+	Bytes []byte
+	// Name of the section containing the address; or empty if not located.
+	Section string
+	// Context of the error (e.g. "decode" or "lift").
+	Context string
+	// Underlying error.
+	Err error
+}
+
+// WrapAt returns an error annotating err with the given address of the binary
+// executable and the raw bytes and section name at the address. WrapAt returns
+// nil if err is nil, and err if err is already a position error.
+func (file *File) WrapAt(addr Address, context string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := AsPosError(err); ok {
+		return err
+	}
+	e := &PosError{
+		Addr:    addr,
+		Context: context,
+		Err:     err,
+	}
+	if file != nil {
+		as := file.AddressSpace()
+		if sect, ok := as.Section(addr, 0); ok {
+			e.Section = sect.Name
+		}
+		if data, ok := as.Bytes(addr, 0); ok {
+			if len(data) > maxErrorBytes {
+				data = data[:maxErrorBytes]
+			}
+			e.Bytes = append([]byte(nil), data...)
+		}
+	}
+	return e
+}
+
+// AsPosError returns the first position error in the chain of err, as unwrapped
+// by the Cause method of github.com/pkg/errors or by the Unwrap method. The
+// boolean return value indicates success.
+func AsPosError(err error) (*PosError, bool) {
+	for err != nil {
+		switch e := err.(type) {
+		case *PosError:
+			return e, true
+		case interface{ Cause() error }:
+			err = e.Cause()
+		case interface{ Unwrap() error }:
+			err = e.Unwrap()
+		default:
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// Error returns the error message of the position error.
+func (e *PosError) Error() string {
+	buf := &strings.Builder{}
+	if len(e.Context) > 0 {
+		fmt.Fprintf(buf, "%s error", e.Context)
+	} else {
+		buf.WriteString("error")
+	}
+	fmt.Fprintf(buf, " at %v", e.Addr)
+	if len(e.Section) > 0 {
+		fmt.Fprintf(buf, " (%s)", e.Section)
+	}
+	if len(e.Bytes) > 0 {
+		fmt.Fprintf(buf, " [% X]", e.Bytes)
+	}
+	fmt.Fprintf(buf, ": %v", e.Err)
+	return buf.String()
+}
+
+// Unwrap returns the underlying error of the position error.
+func (e *PosError) Unwrap() error {
+	return e.Err
+}
+
+// Cause returns the underlying error of the position error; for use with
+// errors.Cause of github.com/pkg/errors.
+func (e *PosError) Cause() error {
+	return e.Err
+}
@@ -0,0 +1,50 @@
+package bin_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+func TestAsPosError(t *testing.T) {
+	t.Parallel()
+	file := &bin.File{
+		Sections: []*bin.Section{
+			{Name: ".text", Addr: 0x401000, Data: []byte{0x0F, 0xFF}, MemSize: 2, Perm: bin.PermR | bin.PermX},
+		},
+	}
+	posErr := file.WrapAt(0x401000, "decode", io.ErrUnexpectedEOF)
+	golden := []struct {
+		err  error
+		want bool
+	}{
+		{err: nil, want: false},
+		{err: io.ErrUnexpectedEOF, want: false},
+		{err: posErr, want: true},
+		{err: errors.WithStack(posErr), want: true},
+		{err: errors.Wrap(errors.WithStack(posErr), "unable to disassemble"), want: true},
+	}
+	for i, g := range golden {
+		e, ok := bin.AsPosError(g.err)
+		if ok != g.want {
+			t.Errorf("error %d: position error mismatch; expected %v, got %v", i, g.want, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if e.Addr != 0x401000 || e.Section != ".text" {
+			t.Errorf("error %d: position mismatch; expected 0x401000 (.text), got %v (%s)", i, e.Addr, e.Section)
+		}
+		if got, want := e.Error(), "decode error at 0x401000 (.text) [0F FF]: unexpected EOF"; got != want {
+			t.Errorf("error %d: error message mismatch; expected %q, got %q", i, want, got)
+		}
+	}
+	// Position errors are not wrapped twice.
+	err := file.WrapAt(0x401001, "lift", errors.WithStack(posErr))
+	if e, ok := bin.AsPosError(err); !ok || e.Addr != 0x401000 {
+		t.Errorf("position error of rewrapped error mismatch; expected 0x401000, got %v", err)
+	}
+}
@@ -5,92 +5,102 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"sync/atomic"
 )
 
 // A File is a binary exectuable.
 type File struct {
 	// Machine architecture specifying the assembly instruction set.
 	Arch Arch
-	// Entry point of the executable.
+	// Name of the binary executable format (e.g. "pe" or "elf"), as registered
+	// with RegisterFormat; or empty if unknown (e.g. raw binary executables).
+	Format string
+	// Entry point of the executable; or 0 if none (e.g. DLLs without an
+	// initialization routine).
 	Entry Address
+	// Shared library (e.g. DLL or shared object); the exported functions and
+	// initialization functions, rather than the entry point, are the roots of
+	// analysis.
+	Shared bool
+	// Base address of the image; relative virtual addresses (RVAs) are relative
+	// to the base address.
+	Base Address
+	// Segment model of 16-bit segment:offset addresses.
+	Segments SegmentModel
+	// Byte order of the executable is big-endian; little-endian otherwise.
+	BigEndian bool
 	// Sections (and segments) of the exectuable.
 	Sections []*Section
 	// Function imports.
 	Imports map[Address]string
 	// Function exports.
 	Exports map[Address]string
+	// Libraries (e.g. DLLs and shared objects) required by the executable.
+	Libs []string
+	// Library of each function import; if known.
+	ImportLibs map[Address]string
+	// Initialization functions invoked by the loader prior to the entry point
+	// (e.g. TLS callbacks of PE executables), in order of invocation.
+	InitFuncs []Address
+	// Stack unwinding information of functions (e.g. .pdata of x64 PE
+	// executables and .eh_frame of ELF executables), sorted by start address.
+	Unwind []*Unwind
+	// Exploit mitigations supported by the image (e.g. ASLR and NX).
+	Flags ImageFlag
+	// Memory-mapped file referred to by section data; or nil if section data
+	// is not memory-mapped.
+	mapping *Mapping
+	// Cached address space (*AddressSpace) of the sections; rebuilt when the
+	// sections are replaced.
+	space atomic.Value
 }
 
 // Code returns the code starting at the specified address of the binary
 // executable.
 func (file *File) Code(addr Address) []byte {
-	if len(file.Sections) > 0 {
-		code, ok := locateCode(addr, file.Sections)
-		if ok {
-			return code
-		}
+	if code, ok := file.AddressSpace().Bytes(addr, PermX); ok {
+		return code
 	}
 	panic(fmt.Errorf("unable to locate code at address %v", addr))
 }
 
-// locateCode tries to locate the code starting at the specified address by
-// searching through the given sections. The boolean return value indicates
-// success.
-//
-// pre-condition: sects must be sorted in ascending order.
-func locateCode(addr Address, sects []*Section) ([]byte, bool) {
-	// Find the first section who's end address is greater than addr.
-	less := func(i int) bool {
-		sect := sects[i]
-		return addr < sect.Addr+Address(len(sect.Data))
-	}
-	index := sort.Search(len(sects), less)
-	for i := index; index < len(sects); i++ {
-		sect := sects[i]
-		if sect.Perm&PermX == 0 {
-			// skip non-executable section.
-			continue
-		}
-		if sect.Addr <= addr && addr < sect.Addr+Address(len(sect.Data)) {
-			offset := addr - sect.Addr
-			return sect.Data[offset:], true
-		}
-	}
-	return nil, false
-}
-
 // Data returns the data starting at the specified address of the binary
 // executable.
 func (file *File) Data(addr Address) []byte {
-	if len(file.Sections) > 0 {
-		data, ok := locateData(addr, file.Sections)
-		if ok {
-			return data
-		}
+	if data, ok := file.AddressSpace().Bytes(addr, 0); ok {
+		return data
 	}
 	panic(fmt.Errorf("unable to locate data at address %v", addr))
 }
 
-// locateData tries to locate the data starting at the specified address by
-// searching through the given sections. The boolean return value indicates
-// success.
-//
-// pre-condition: sects must be sorted in ascending order.
-func locateData(addr Address, sects []*Section) ([]byte, bool) {
-	// Find the first section who's end address is greater than addr.
-	less := func(i int) bool {
-		sect := sects[i]
-		return addr < sect.Addr+Address(len(sect.Data))
-	}
-	index := sort.Search(len(sects), less)
-	if 0 <= index && index < len(sects) {
-		sect := sects[index]
-		if sect.Addr <= addr && addr < sect.Addr+Address(len(sect.Data)) {
-			offset := addr - sect.Addr
-			return sect.Data[offset:], true
+// Patch overwrites the contents of the binary executable starting at the
+// specified address with the given data (e.g. a memory dump of a region
+// decrypted at runtime). The contents of every section overlapping the patched
+// region are updated.
+func (file *File) Patch(addr Address, data []byte) error {
+	end := addr + Address(len(data))
+	patched := false
+	for _, sect := range file.Sections {
+		sectEnd := sect.End()
+		if end <= sect.Addr || sectEnd <= addr {
+			// skip non-overlapping section.
+			continue
+		}
+		start := addr
+		if start < sect.Addr {
+			start = sect.Addr
 		}
+		stop := end
+		if stop > sectEnd {
+			stop = sectEnd
+		}
+		copy(sect.Data[start-sect.Addr:stop-sect.Addr], data[start-addr:stop-addr])
+		patched = true
+	}
+	if !patched {
+		return fmt.Errorf("unable to locate section of patched region at address %v", addr)
 	}
-	return nil, false
+	return nil
 }
 
 //go:generate stringer -linecomment -type Arch
@@ -110,11 +120,16 @@ const (
 	ArchMIPS_32 // MIPS_32
 	// ArchPowerPC_32 represents the 32-bit PowerPC machine architecture.
 	ArchPowerPC_32 // PowerPC_32
+	// ArchX86_16 represents the 16-bit x86 machine architecture, as used by
+	// Intel and AMD.
+	ArchX86_16 // x86_16
 )
 
 // BitSize returns the bit size of the machine architecture.
 func (arch Arch) BitSize() int {
 	m := map[Arch]int{
+		// 16-bit architectures.
+		ArchX86_16: 16,
 		// 32-bit architectures.
 		ArchX86_32:     32,
 		ArchMIPS_32:    32,
@@ -131,6 +146,7 @@ func (arch Arch) BitSize() int {
 // Set sets arch to the machine architecture represented by s.
 func (arch *Arch) Set(s string) error {
 	m := map[string]Arch{
+		"x86_16":     ArchX86_16,
 		"x86_32":     ArchX86_32,
 		"x86_64":     ArchX86_64,
 		"MIPS_32":    ArchMIPS_32,
@@ -171,6 +187,10 @@ type Section struct {
 	MemSize int
 	// Access permissions of the section in memory.
 	Perm Perm
+	// Characteristics of the section other than its access permissions.
+	Flags SectFlag
+	// Alignment in bytes of the section in memory; or 0 if unspecified.
+	Align int
 }
 
 // Perm specifies the access permissions of a segment or section in memory.
@@ -202,3 +222,56 @@ func (perm Perm) String() string {
 	}
 	return r + w + x
 }
+
+// SectFlag specifies the characteristics of a section, other than its access
+// permissions.
+type SectFlag uint8
+
+// Section characteristics.
+const (
+	// SectUninit specifies that the section contains only uninitialized data
+	// (e.g. .bss).
+	SectUninit SectFlag = 0x1
+	// SectDiscardable specifies that the section may be discarded once the
+	// image is loaded (e.g. relocations of PE executables).
+	SectDiscardable SectFlag = 0x2
+)
+
+// String returns the string representation of the section characteristics.
+func (flags SectFlag) String() string {
+	var ss []string
+	if flags&SectUninit != 0 {
+		ss = append(ss, "uninit")
+	}
+	if flags&SectDiscardable != 0 {
+		ss = append(ss, "discardable")
+	}
+	return strings.Join(ss, ",")
+}
+
+// ImageFlag specifies the exploit mitigations supported by a binary executable.
+type ImageFlag uint8
+
+// Exploit mitigations.
+const (
+	// ImageASLR specifies that the image may be loaded at a randomized base
+	// address (e.g. PE executables with a dynamic base and position independent
+	// ELF executables).
+	ImageASLR ImageFlag = 0x1
+	// ImageNX specifies that the image is compatible with non-executable data
+	// and stack memory (e.g. PE executables compatible with DEP and ELF
+	// executables with a non-executable GNU stack).
+	ImageNX ImageFlag = 0x2
+)
+
+// String returns the string representation of the exploit mitigations.
+func (flags ImageFlag) String() string {
+	var ss []string
+	if flags&ImageASLR != 0 {
+		ss = append(ss, "aslr")
+	}
+	if flags&ImageNX != 0 {
+		ss = append(ss, "nx")
+	}
+	return strings.Join(ss, ",")
+}
@@ -0,0 +1,126 @@
+// Package fingerprint computes fingerprints of binary executables; import
+// hashes, section hashes and fuzzy hashes, which may be used to cluster related
+// binary executables of a corpus prior to lifting.
+package fingerprint
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+)
+
+// A Fingerprint is the fingerprint of a binary executable.
+type Fingerprint struct {
+	// MD5 hash of the file contents.
+	MD5 string `json:"md5"`
+	// SHA-256 hash of the file contents.
+	SHA256 string `json:"sha256"`
+	// Import hash; or empty if the executable has no imports.
+	ImpHash string `json:"imphash,omitempty"`
+	// ssdeep fuzzy hash of the file contents.
+	SSDeep string `json:"ssdeep"`
+	// TLSH locality sensitive hash of the file contents; or empty if the file
+	// contents are too small or lack variation.
+	TLSH string `json:"tlsh,omitempty"`
+	// Section hashes.
+	Sections []*Section `json:"sections"`
+}
+
+// A Section is the fingerprint of a section of a binary executable.
+type Section struct {
+	// Section name.
+	Name string `json:"name"`
+	// Start address of the section.
+	Addr bin.Address `json:"addr"`
+	// Size in bytes of the section contents.
+	Size int `json:"size"`
+	// MD5 hash of the section contents.
+	MD5 string `json:"md5"`
+	// Shannon entropy of the section contents, in bits per byte; high entropy
+	// (above ~7.2) indicates compressed or encrypted data.
+	Entropy float64 `json:"entropy"`
+}
+
+// Compute computes the fingerprint of the given binary executable, based on its
+// file contents.
+func Compute(data []byte, file *bin.File) *Fingerprint {
+	fp := &Fingerprint{
+		MD5:     fmt.Sprintf("%x", md5.Sum(data)),
+		SHA256:  fmt.Sprintf("%x", sha256.Sum256(data)),
+		ImpHash: ImpHash(file),
+		SSDeep:  SSDeep(data),
+	}
+	if h, ok := TLSH(data); ok {
+		fp.TLSH = h
+	}
+	for _, sect := range file.Sections {
+		s := &Section{
+			Name:    sect.Name,
+			Addr:    sect.Addr,
+			Size:    len(sect.Data),
+			MD5:     fmt.Sprintf("%x", md5.Sum(sect.Data)),
+			Entropy: Entropy(sect.Data),
+		}
+		fp.Sections = append(fp.Sections, s)
+	}
+	return fp
+}
+
+// ImpHash returns the import hash (imphash) of the given binary executable; the
+// MD5 hash of the comma-separated list of imports in import address table
+// order, each formatted as "lib.func" in lower case, with the file extension
+// of the library name (.dll, .ocx or .sys) omitted. Imports by ordinal are
+// formatted as "lib.ordN".
+//
+// ImpHash returns an empty string if the executable has no imports.
+func ImpHash(file *bin.File) string {
+	var addrs []bin.Address
+	for addr := range file.Imports {
+		addrs = append(addrs, addr)
+	}
+	if len(addrs) == 0 {
+		return ""
+	}
+	sort.Sort(bin.Addresses(addrs))
+	var imps []string
+	for _, addr := range addrs {
+		lib := strings.ToLower(file.ImportLibs[addr])
+		switch path.Ext(lib) {
+		case ".dll", ".ocx", ".sys":
+			lib = strings.TrimSuffix(lib, path.Ext(lib))
+		}
+		name := strings.ToLower(file.Imports[addr])
+		// Imports by ordinal are named LIB_ordinal_N by the PE parser.
+		if pos := strings.LastIndex(name, "_ordinal_"); pos != -1 {
+			name = "ord" + name[pos+len("_ordinal_"):]
+		}
+		imps = append(imps, lib+"."+name)
+	}
+	return fmt.Sprintf("%x", md5.Sum([]byte(strings.Join(imps, ","))))
+}
+
+// Entropy returns the Shannon entropy of the given data, in bits per byte.
+func Entropy(data []byte) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	var freqs [256]int
+	for _, b := range data {
+		freqs[b]++
+	}
+	entropy := 0.0
+	for _, freq := range freqs {
+		if freq == 0 {
+			continue
+		}
+		p := float64(freq) / float64(len(data))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
@@ -0,0 +1,113 @@
+package fingerprint
+
+import (
+	"fmt"
+)
+
+// ssdeep parameters.
+const (
+	// Size in bytes of the rolling hash window.
+	rollingWindow = 7
+	// Minimum block size.
+	minBlockSize = 3
+	// Maximum length of the first signature; the second signature is half as
+	// long.
+	spamSumLen = 64
+	// Initial value of the FNV hash.
+	hashInit = 0x28021967
+	// Prime of the FNV hash.
+	hashPrime = 0x01000193
+)
+
+// b64 is the alphabet of ssdeep signatures.
+const b64 = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// SSDeep returns the ssdeep context triggered piecewise hash (CTPH) of the given
+// data (e.g. "3:hMCE:hMCE"), in the format BLOCKSIZE:SIG1:SIG2.
+//
+// The data is split into pieces at positions where a rolling hash over the
+// last 7 bytes triggers for the block size, and each piece contributes one
+// character to the signature. The block size is chosen so that the signature
+// has between 32 and 64 characters.
+func SSDeep(data []byte) string {
+	bs := uint32(minBlockSize)
+	for bs*spamSumLen < uint32(len(data)) {
+		bs *= 2
+	}
+	for {
+		sig1, sig2 := spamSum(data, bs)
+		if bs > minBlockSize && len(sig1) < spamSumLen/2 {
+			// Too few pieces; retry with smaller block size.
+			bs /= 2
+			continue
+		}
+		return fmt.Sprintf("%d:%s:%s", bs, sig1, sig2)
+	}
+}
+
+// spamSum returns the signatures of the given data for the block size bs and
+// twice the block size.
+func spamSum(data []byte, bs uint32) (sig1, sig2 string) {
+	var (
+		r      roller
+		buf1   [spamSumLen]byte
+		buf2   [spamSumLen / 2]byte
+		j, k   int
+		h      uint32
+		h2, h3 uint32 = hashInit, hashInit
+	)
+	for _, c := range data {
+		h = r.roll(c)
+		h2 = h2*hashPrime ^ uint32(c)
+		h3 = h3*hashPrime ^ uint32(c)
+		if h%bs == bs-1 {
+			buf1[j] = b64[h2%64]
+			if j < len(buf1)-1 {
+				h2 = hashInit
+				j++
+			}
+		}
+		if h%(2*bs) == 2*bs-1 {
+			buf2[k] = b64[h3%64]
+			if k < len(buf2)-1 {
+				h3 = hashInit
+				k++
+			}
+		}
+	}
+	// Remaining data after the last trigger.
+	if h != 0 {
+		buf1[j] = b64[h2%64]
+		buf2[k] = b64[h3%64]
+	}
+	if buf1[j] != 0 {
+		j++
+	}
+	if buf2[k] != 0 {
+		k++
+	}
+	return string(buf1[:j]), string(buf2[:k])
+}
+
+// roller is the rolling hash of ssdeep; a hash of the last 7 bytes.
+type roller struct {
+	// Window of the last 7 bytes.
+	window [rollingWindow]byte
+	// Hash state.
+	h1, h2, h3 uint32
+	// Number of bytes hashed.
+	n int
+}
+
+// roll adds the given byte to the rolling hash and returns the updated hash.
+func (r *roller) roll(c byte) uint32 {
+	r.h2 -= r.h1
+	r.h2 += rollingWindow * uint32(c)
+	r.h1 += uint32(c)
+	r.h1 -= uint32(r.window[r.n%rollingWindow])
+	r.window[r.n%rollingWindow] = c
+	r.n++
+	r.h3 <<= 5
+	r.h3 ^= uint32(c)
+	return r.h1 + r.h2 + r.h3
+}
@@ -0,0 +1,139 @@
+package fingerprint
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// TLSH parameters.
+const (
+	// Minimum length in bytes of data.
+	tlshMinLen = 50
+	// Size in bytes of the sliding window.
+	tlshWindow = 5
+	// Number of buckets; i.e. 128 bucket hashes (TLSH 128/1).
+	tlshBuckets = 128
+	// Size in bytes of the code part of the hash.
+	tlshCodeSize = tlshBuckets / 4
+)
+
+// TLSH returns the TLSH locality sensitive hash of the given data (in the
+// 128-bucket format with 1-byte checksum, and "T1" version prefix). The boolean
+// return value indicates success; data shorter than 50 bytes or with too little
+// variation has no TLSH hash.
+//
+// Each 5-byte window of the data increments six buckets, selected by Pearson
+// hashes of byte triplets of the window. The hash body encodes the quartile of
+// each bucket count, and the header the checksum, the logarithm of the data
+// length and the ratios between the quartiles.
+func TLSH(data []byte) (string, bool) {
+	if len(data) < tlshMinLen {
+		return "", false
+	}
+	var buckets [256]uint32
+	var checksum byte
+	for i := tlshWindow - 1; i < len(data); i++ {
+		a, b, c, d, e := data[i], data[i-1], data[i-2], data[i-3], data[i-4]
+		checksum = pearson(0, a, b, checksum)
+		buckets[pearson(2, a, b, c)]++
+		buckets[pearson(3, a, b, d)]++
+		buckets[pearson(5, a, c, d)]++
+		buckets[pearson(7, a, c, e)]++
+		buckets[pearson(11, a, b, e)]++
+		buckets[pearson(13, a, d, e)]++
+	}
+	// Quartiles of bucket counts.
+	var sorted [tlshBuckets]uint32
+	copy(sorted[:], buckets[:tlshBuckets])
+	sort.Slice(sorted[:], func(i, j int) bool { return sorted[i] < sorted[j] })
+	q1, q2, q3 := sorted[tlshBuckets/4-1], sorted[tlshBuckets/2-1], sorted[tlshBuckets-tlshBuckets/4-1]
+	nonzero := 0
+	for _, count := range buckets[:tlshBuckets] {
+		if count > 0 {
+			nonzero++
+		}
+	}
+	if q3 == 0 || nonzero <= 4*tlshCodeSize/2 {
+		// too little variation.
+		return "", false
+	}
+	// Body; two bits per bucket, encoding the quartile of the bucket count.
+	var code [tlshCodeSize]byte
+	for i := range code {
+		var h byte
+		for j := 0; j < 4; j++ {
+			count := buckets[4*i+j]
+			switch {
+			case q3 < count:
+				h += 3 << (uint(j) * 2)
+			case q2 < count:
+				h += 2 << (uint(j) * 2)
+			case q1 < count:
+				h += 1 << (uint(j) * 2)
+			}
+		}
+		code[i] = h
+	}
+	// Header.
+	lvalue := tlshLength(len(data))
+	q1ratio := byte((q1 * 100 / q3) % 16)
+	q2ratio := byte((q2 * 100 / q3) % 16)
+	buf := &strings.Builder{}
+	buf.WriteString("T1")
+	fmt.Fprintf(buf, "%02X", swapNibbles(checksum))
+	fmt.Fprintf(buf, "%02X", swapNibbles(lvalue))
+	fmt.Fprintf(buf, "%02X", q2ratio<<4|q1ratio)
+	for i := len(code) - 1; i >= 0; i-- {
+		fmt.Fprintf(buf, "%02X", code[i])
+	}
+	return buf.String(), true
+}
+
+// tlshLength returns the logarithmic encoding of the given data length.
+func tlshLength(n int) byte {
+	var l float64
+	switch {
+	case n <= 656:
+		l = math.Floor(math.Log(float64(n)) / math.Log(1.5))
+	case n <= 3199:
+		l = math.Floor(math.Log(float64(n))/math.Log(1.3) - 8.72777)
+	default:
+		l = math.Floor(math.Log(float64(n))/math.Log(1.1) - 62.5472)
+	}
+	return byte(int(l) & 0xFF)
+}
+
+// pearson returns the Pearson hash of the given salt and byte triplet.
+func pearson(salt, i, j, k byte) byte {
+	h := pearsonTable[salt]
+	h = pearsonTable[h^i]
+	h = pearsonTable[h^j]
+	return pearsonTable[h^k]
+}
+
+// swapNibbles returns the given byte with its high and low nibbles swapped.
+func swapNibbles(b byte) byte {
+	return b<<4 | b>>4
+}
+
+// pearsonTable is the permutation table of the Pearson hash used by TLSH.
+var pearsonTable = [256]byte{
+	1, 87, 49, 12, 176, 178, 102, 166, 121, 193, 6, 84, 249, 230, 44, 163,
+	14, 197, 213, 181, 161, 85, 218, 80, 64, 239, 24, 226, 236, 142, 38, 200,
+	110, 177, 104, 103, 141, 253, 255, 50, 77, 101, 81, 18, 45, 96, 31, 222,
+	25, 107, 190, 70, 86, 237, 240, 34, 72, 242, 20, 214, 244, 227, 149, 235,
+	97, 234, 57, 22, 60, 250, 82, 175, 208, 5, 127, 199, 111, 62, 135, 248,
+	174, 169, 211, 58, 66, 154, 106, 195, 245, 171, 17, 187, 182, 179, 0, 243,
+	132, 56, 148, 75, 128, 133, 158, 100, 130, 126, 91, 13, 153, 246, 216, 219,
+	119, 68, 223, 78, 83, 88, 201, 99, 122, 11, 92, 32, 136, 114, 52, 10,
+	138, 30, 48, 183, 156, 35, 61, 26, 143, 74, 251, 94, 129, 162, 63, 152,
+	170, 7, 115, 167, 241, 206, 3, 150, 55, 59, 151, 220, 90, 53, 23, 131,
+	125, 173, 15, 238, 79, 95, 89, 16, 105, 137, 225, 224, 217, 160, 37, 123,
+	118, 73, 2, 157, 46, 116, 9, 145, 134, 228, 207, 212, 202, 215, 69, 229,
+	27, 188, 67, 124, 168, 252, 42, 4, 29, 108, 21, 247, 19, 205, 39, 203,
+	233, 40, 186, 147, 198, 192, 155, 33, 164, 191, 98, 204, 165, 180, 117, 76,
+	140, 36, 210, 172, 41, 54, 159, 8, 185, 232, 113, 196, 231, 47, 146, 120,
+	51, 65, 28, 144, 254, 221, 93, 189, 194, 139, 112, 43, 71, 109, 184, 209,
+}
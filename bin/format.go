@@ -28,6 +28,14 @@ func RegisterFormat(name, magic string, parse func(io.ReaderAt) (*File, error))
 	formats = append(formats, format{name: name, magic: magic, parse: parse})
 }
 
+// RegisterFormatIdent registers a binary executable format for use by Parse,
+// which shares its magic prefix with other formats (e.g. the "MZ" prefix of PE,
+// NE and LE executables). Ident reports whether a file with matching magic
+// prefix is of the given format.
+func RegisterFormatIdent(name, magic string, ident func(io.ReaderAt) bool, parse func(io.ReaderAt) (*File, error)) {
+	formats = append(formats, format{name: name, magic: magic, ident: ident, parse: parse})
+}
+
 // formats is the list of registered formats.
 var formats []format
 
@@ -39,18 +47,23 @@ type format struct {
 	// Magic prefix that identifies the format's encoding. The magic string can
 	// contain "?" wildcards that each match any one byte.
 	magic string
+	// ident reports whether the given binary executable, with matching magic
+	// prefix, is of the format; or nil if identified by magic prefix alone.
+	ident func(r io.ReaderAt) bool
 	// parse parses the given binary executable, reading from r.
 	parse func(r io.ReaderAt) (*File, error)
 }
 
-// ParseFile parses the given binary executable, reading from path.
+// ParseFile parses the given binary executable, reading from path. The file is
+// memory-mapped, and section data is loaded lazily on first access.
+//
+// Section data refers to the memory-mapped file, which is therefore kept open
+// until the returned file is closed.
 func ParseFile(path string) (*File, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, errors.WithStack(err)
+	parse := func(m *Mapping) (*File, error) {
+		return Parse(m)
 	}
-	defer f.Close()
-	return Parse(f)
+	return ParseMapped(path, parse)
 }
 
 // Parse parses the given binary executable, reading from r.
@@ -66,8 +79,13 @@ func Parse(r io.ReaderAt) (*File, error) {
 			}
 			return nil, errors.WithStack(err)
 		}
-		if match(format.magic, buf) {
-			return format.parse(r)
+		if match(format.magic, buf) && (format.ident == nil || format.ident(r)) {
+			file, err := format.parse(r)
+			if err != nil {
+				return nil, err
+			}
+			file.Format = format.name
+			return file, nil
 		}
 	}
 	return nil, errors.New("unknown binary executable format;\n\ttip: try loading as raw binary executable")
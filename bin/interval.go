@@ -0,0 +1,136 @@
+package bin
+
+import (
+	"sort"
+)
+
+// An Interval is a half-open address range [Start, End) with an associated
+// value (e.g. a section or a basic block).
+type Interval struct {
+	// Start address of the interval.
+	Start Address
+	// End address of the interval (exclusive).
+	End Address
+	// Value associated with the interval.
+	Value interface{}
+}
+
+// Contains reports whether the given address is contained within the interval.
+func (iv Interval) Contains(addr Address) bool {
+	return iv.Start <= addr && addr < iv.End
+}
+
+// An IntervalTree provides fast containment and overlap queries of a static
+// set of possibly overlapping intervals.
+//
+// The tree is represented as an array of intervals sorted by start address,
+// augmented with the maximum end address of each prefix of the array. Queries
+// binary search for the last interval starting at or before the query address,
+// and walk backwards until no preceding interval may reach the query address;
+// O(log n + k) for k intervals reaching past the query address.
+type IntervalTree struct {
+	// Intervals sorted by start address; intervals with identical start
+	// addresses are kept in insertion order.
+	ivs []Interval
+	// maxEnds[i] is the maximum end address of ivs[:i+1].
+	maxEnds []Address
+}
+
+// NewIntervalTree returns a new interval tree of the given intervals. Empty
+// intervals are ignored.
+func NewIntervalTree(ivs []Interval) *IntervalTree {
+	t := &IntervalTree{}
+	for _, iv := range ivs {
+		if iv.Start < iv.End {
+			t.ivs = append(t.ivs, iv)
+		}
+	}
+	less := func(i, j int) bool {
+		return t.ivs[i].Start < t.ivs[j].Start
+	}
+	if !sort.SliceIsSorted(t.ivs, less) {
+		sort.SliceStable(t.ivs, less)
+	}
+	t.maxEnds = make([]Address, len(t.ivs))
+	var maxEnd Address
+	for i, iv := range t.ivs {
+		if maxEnd < iv.End {
+			maxEnd = iv.End
+		}
+		t.maxEnds[i] = maxEnd
+	}
+	return t
+}
+
+// Len returns the number of intervals of the tree.
+func (t *IntervalTree) Len() int {
+	return len(t.ivs)
+}
+
+// Containing returns the intervals containing the given address, in ascending
+// order of start address.
+func (t *IntervalTree) Containing(addr Address) []Interval {
+	return t.Overlapping(addr, addr+1)
+}
+
+// Overlapping returns the intervals overlapping the half-open address range
+// [start, end), in ascending order of start address.
+func (t *IntervalTree) Overlapping(start, end Address) []Interval {
+	var ivs []Interval
+	t.walk(start, end, func(iv Interval) bool {
+		ivs = append(ivs, iv)
+		return false
+	})
+	// walk visits intervals in descending order.
+	for i, j := 0, len(ivs)-1; i < j; i, j = i+1, j-1 {
+		ivs[i], ivs[j] = ivs[j], ivs[i]
+	}
+	return ivs
+}
+
+// Find returns the first interval, in ascending order of start address, which
+// contains the given address and satisfies the given predicate. The boolean
+// return value indicates success.
+func (t *IntervalTree) Find(addr Address, pred func(iv Interval) bool) (Interval, bool) {
+	var found Interval
+	ok := false
+	t.walk(addr, addr+1, func(iv Interval) bool {
+		if pred(iv) {
+			// keep searching for preceding intervals.
+			found, ok = iv, true
+		}
+		return false
+	})
+	return found, ok
+}
+
+// Last returns the interval with the highest start address which contains the
+// given address. The boolean return value indicates success.
+func (t *IntervalTree) Last(addr Address) (Interval, bool) {
+	var found Interval
+	ok := false
+	t.walk(addr, addr+1, func(iv Interval) bool {
+		found, ok = iv, true
+		return true
+	})
+	return found, ok
+}
+
+// walk invokes visit for each interval overlapping [start, end), in descending
+// order of start address, until visit returns true.
+func (t *IntervalTree) walk(start, end Address, visit func(iv Interval) bool) {
+	less := func(i int) bool {
+		return end <= t.ivs[i].Start
+	}
+	for i := sort.Search(len(t.ivs), less) - 1; i >= 0; i-- {
+		if t.maxEnds[i] <= start {
+			// no preceding interval reaches start.
+			break
+		}
+		if iv := t.ivs[i]; start < iv.End {
+			if visit(iv) {
+				break
+			}
+		}
+	}
+}
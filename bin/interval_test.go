@@ -0,0 +1,71 @@
+package bin_test
+
+import (
+	"testing"
+
+	"github.com/decomp/exp/bin"
+)
+
+func TestIntervalTree(t *testing.T) {
+	t.Parallel()
+	ivs := []bin.Interval{
+		{Start: 0x1000, End: 0x1010, Value: "a"},
+		{Start: 0x1000, End: 0x2000, Value: "b"},
+		{Start: 0x1008, End: 0x1020, Value: "c"},
+		{Start: 0x1800, End: 0x1800, Value: "empty"},
+		{Start: 0x3000, End: 0x3004, Value: "d"},
+	}
+	tree := bin.NewIntervalTree(ivs)
+	if got, want := tree.Len(), 4; got != want {
+		t.Fatalf("number of intervals mismatch; expected %d, got %d", want, got)
+	}
+	golden := []struct {
+		addr bin.Address
+		want []string
+	}{
+		{addr: 0x0FFF, want: nil},
+		{addr: 0x1000, want: []string{"a", "b"}},
+		{addr: 0x100C, want: []string{"a", "b", "c"}},
+		{addr: 0x1010, want: []string{"b", "c"}},
+		{addr: 0x1800, want: []string{"b"}},
+		{addr: 0x2000, want: nil},
+		{addr: 0x3003, want: []string{"d"}},
+		{addr: 0x3004, want: nil},
+	}
+	for _, g := range golden {
+		var got []string
+		for _, iv := range tree.Containing(g.addr) {
+			got = append(got, iv.Value.(string))
+		}
+		if !equalStrings(got, g.want) {
+			t.Errorf("intervals containing %v mismatch; expected %q, got %q", g.addr, g.want, got)
+		}
+	}
+	// Find returns the first match in ascending order.
+	notA := func(iv bin.Interval) bool {
+		return iv.Value != "a"
+	}
+	if iv, ok := tree.Find(0x100C, notA); !ok || iv.Value != "b" {
+		t.Errorf("find at 0x100C mismatch; expected %q, got %v (ok=%v)", "b", iv.Value, ok)
+	}
+	// Last returns the interval with the highest start address.
+	if iv, ok := tree.Last(0x100C); !ok || iv.Value != "c" {
+		t.Errorf("last at 0x100C mismatch; expected %q, got %v (ok=%v)", "c", iv.Value, ok)
+	}
+	if got := len(tree.Overlapping(0x1F00, 0x3001)); got != 2 {
+		t.Errorf("number of overlapping intervals mismatch; expected 2, got %d", got)
+	}
+}
+
+// equalStrings reports whether the given string slices are equal.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,951 @@
+// Package le provides access to LE (Linear Executable) and LX (Linear
+// eXecutable) files, as used by OS/2, Windows VxD drivers and DOS extenders.
+package le
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/mewkiz/pkg/term"
+	"github.com/pkg/errors"
+)
+
+var (
+	// dbg is a logger which logs debug messages with "le:" prefix to standard
+	// error.
+	dbg = log.New(os.Stderr, term.MagentaBold("le:")+" ", 0)
+	// warn is a logger which logs warning messages with "warning:" prefix to
+	// standard error.
+	warn = log.New(os.Stderr, term.RedBold("warning:")+" ", 0)
+)
+
+// Register LE and LX formats.
+func init() {
+	// Linear Executable (LE) and Linear eXecutable (LX) formats.
+	//
+	//    4D 5A  |MZ|
+	const magic = "MZ"
+	bin.RegisterFormatIdent("le", magic, isLE, Parse)
+}
+
+// isLE reports whether the given MZ executable is an LE or LX executable.
+func isLE(r io.ReaderAt) bool {
+	sig, ok := bin.MZSignature(r, 2)
+	return ok && (string(sig) == "LE" || string(sig) == "LX")
+}
+
+// Segmented address translation.
+//
+// Objects are mapped to the linear address space at their relocation base
+// addresses. The segment selectors of relocated far pointers (16:16 and 16:32)
+// hold 1-based object numbers, and the offsets of far pointers are relative to
+// the start of the object.
+//
+// Imported functions are mapped to an artificial object following the last
+// object of the executable.
+
+// ParseFile parses the given LE or LX binary executable, reading from path.
+func ParseFile(path string) (*bin.File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse parses the given LE or LX binary executable, reading from r.
+//
+// Users are responsible for closing r.
+func Parse(r io.ReaderAt) (*bin.File, error) {
+	// Parse LE header.
+	leOff, err := bin.MZHeaderOffset(r)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var hdr header
+	if err := read(r, leOff, &hdr); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	magic := string(hdr.Magic[:])
+	if magic != "LE" && magic != "LX" {
+		return nil, errors.Errorf("invalid LE signature; expected %q or %q, got %q", "LE", "LX", magic)
+	}
+	if hdr.ByteOrder != 0 || hdr.WordOrder != 0 {
+		return nil, errors.Errorf("support for big-endian %s executables not yet implemented", magic)
+	}
+	file := &bin.File{
+		Imports: make(map[bin.Address]string),
+		Exports: make(map[bin.Address]string),
+	}
+	p := &parser{
+		r:       r,
+		size:    fileSize(r),
+		leOff:   leOff,
+		hdr:     hdr,
+		lx:      magic == "LX",
+		file:    file,
+		imports: make(map[string]uint32),
+	}
+	if err := p.checkHeader(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Parse objects.
+	if err := p.parseObjects(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	file.Arch = bin.ArchX86_16
+	for _, obj := range p.objs {
+		if obj.Flags&objBig != 0 {
+			file.Arch = bin.ArchX86_32
+			break
+		}
+	}
+	if hdr.EIPObject != 0 {
+		if int(hdr.EIPObject) > len(p.objs) {
+			return nil, errors.Errorf("invalid object number %d of entry point", hdr.EIPObject)
+		}
+		file.Entry = bin.Address(p.objs[hdr.EIPObject-1].RelocBase + hdr.EIP)
+	}
+
+	// Parse entry table and exports.
+	if err := p.parseEntries(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := p.parseNames(leOff+int64(hdr.ResNamesOff), -1); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if hdr.NonresNamesSize != 0 {
+		if err := p.parseNames(int64(hdr.NonresNamesOff), int64(hdr.NonresNamesSize)); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	// Apply fixups.
+	if err := p.applyFixups(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Add artificial object of imported functions.
+	if p.nimports > 0 {
+		size := int(p.nimports) * importSlotSize
+		sect := &bin.Section{
+			Name:    "imports",
+			Addr:    bin.Address(p.importBase()),
+			Data:    make([]byte, size),
+			MemSize: size,
+			Perm:    bin.PermR,
+		}
+		file.Sections = append(file.Sections, sect)
+	}
+	return file, nil
+}
+
+// ref: http://www.textfiles.com/programming/FORMATS/lxexe.txt
+
+// header is the LE and LX header.
+type header struct {
+	// Signature ("LE" or "LX").
+	Magic [2]byte
+	// Byte and word order; 0 for little-endian.
+	ByteOrder uint8
+	WordOrder uint8
+	// Format level.
+	FormatLevel uint32
+	// CPU type.
+	CPUType uint16
+	// Target operating system.
+	OSType uint16
+	// Module version.
+	ModuleVersion uint32
+	// Module flags.
+	ModuleFlags uint32
+	// Number of pages of module.
+	NPages uint32
+	// Entry point; object number and offset.
+	EIPObject uint32
+	EIP       uint32
+	// Initial stack pointer; object number and offset.
+	ESPObject uint32
+	ESP       uint32
+	// Page size in bytes.
+	PageSize uint32
+	// Page offset shift (LX) or number of bytes of the last page (LE).
+	PageShift uint32
+	// Size and checksum of fixup and loader sections.
+	FixupSectionSize  uint32
+	FixupChecksum     uint32
+	LoaderSectionSize uint32
+	LoaderChecksum    uint32
+	// Object table offset, relative to the LE header, and number of objects.
+	ObjTableOff uint32
+	NObjects    uint32
+	// Offsets of tables, relative to the LE header.
+	ObjPageTableOff uint32
+	ObjIterPagesOff uint32
+	ResTableOff     uint32
+	NResources      uint32
+	ResNamesOff     uint32
+	EntryTableOff   uint32
+	DirectivesOff   uint32
+	NDirectives     uint32
+	// Offsets of fixup page table and fixup record table, relative to the LE
+	// header.
+	FixupPageTableOff   uint32
+	FixupRecordTableOff uint32
+	// Import module name table offset, relative to the LE header, and number
+	// of imported modules.
+	ImpModTableOff uint32
+	NImpMods       uint32
+	// Import procedure name table offset, relative to the LE header.
+	ImpProcTableOff uint32
+	// Per-page checksum table offset, relative to the LE header.
+	PageChecksumOff uint32
+	// File offset of data pages.
+	DataPagesOff uint32
+	// Number of preload pages.
+	NPreloadPages uint32
+	// File offset and size in bytes of non-resident names table.
+	NonresNamesOff  uint32
+	NonresNamesSize uint32
+}
+
+// objEntry is an entry of the object table.
+type objEntry struct {
+	// Size in bytes of object in memory.
+	VirtualSize uint32
+	// Relocation base address of object.
+	RelocBase uint32
+	// Object flags.
+	Flags uint32
+	// 1-based index of first page of object in object page table.
+	PageTableIndex uint32
+	// Number of pages of object.
+	NPages uint32
+	// Reserved.
+	Reserved uint32
+}
+
+// Object flags.
+const (
+	// Readable object.
+	objR = 0x0001
+	// Writable object.
+	objW = 0x0002
+	// Executable object.
+	objX = 0x0004
+	// Discardable object.
+	objDiscardable = 0x0010
+	// Object uses 32-bit default operand size.
+	objBig = 0x2000
+)
+
+// LX page flags.
+const (
+	// Legal physical page.
+	pageValid = 0
+	// Iterated data page (EXEPACK).
+	pageIterated = 1
+	// Invalid page.
+	pageInvalid = 2
+	// Zero-filled page.
+	pageZeroed = 3
+)
+
+// Size in bytes of slots of imported functions in the artificial import
+// object.
+const importSlotSize = 4
+
+// maxPageSize is the maximum page size in bytes supported by the parser.
+const maxPageSize = 0x10000
+
+// parser tracks the state of the LE and LX parser.
+type parser struct {
+	// Input reader.
+	r io.ReaderAt
+	// Size in bytes of input file.
+	size int64
+	// File offset of LE header.
+	leOff int64
+	// LE header.
+	hdr header
+	// LX format.
+	lx bool
+	// Output file.
+	file *bin.File
+	// Object table entries.
+	objs []objEntry
+	// Object sections, indexed by object number - 1.
+	sects []*bin.Section
+	// Map from entry ordinal to address of entry point.
+	entries map[uint32]bin.Address
+	// Map from import name to offset within import object.
+	imports map[string]uint32
+	// Number of imported functions.
+	nimports uint32
+}
+
+// checkHeader validates the header fields which determine the size of
+// allocations (e.g. the number of pages) against the size of the input file.
+func (p *parser) checkHeader() error {
+	if p.hdr.PageSize == 0 || p.hdr.PageSize > maxPageSize {
+		return errors.Errorf("invalid page size %d; expected 1 to %d bytes", p.hdr.PageSize, maxPageSize)
+	}
+	if !p.lx && p.hdr.PageShift > p.hdr.PageSize {
+		return errors.Errorf("invalid size %d of last page; exceeds page size (%d bytes)", p.hdr.PageShift, p.hdr.PageSize)
+	}
+	// Each page has an entry in the object page table.
+	entrySize := int64(4)
+	if p.lx {
+		entrySize = 8
+	}
+	if !p.fits(p.leOff+int64(p.hdr.ObjPageTableOff), int64(p.hdr.NPages)*entrySize) {
+		return errors.Errorf("invalid number of pages %d; object page table exceeds file size (%d bytes)", p.hdr.NPages, p.size)
+	}
+	if !p.fits(p.leOff+int64(p.hdr.ObjTableOff), int64(p.hdr.NObjects)*24) {
+		return errors.Errorf("invalid number of objects %d; object table exceeds file size (%d bytes)", p.hdr.NObjects, p.size)
+	}
+	return nil
+}
+
+// fits reports whether the n bytes at the given file offset are within the
+// input file.
+func (p *parser) fits(off, n int64) bool {
+	return off >= 0 && n >= 0 && off <= p.size && n <= p.size-off
+}
+
+// parseObjects parses the object table and object pages.
+func (p *parser) parseObjects() error {
+	for i := 0; i < int(p.hdr.NObjects); i++ {
+		var obj objEntry
+		if err := read(p.r, p.leOff+int64(p.hdr.ObjTableOff)+int64(i)*24, &obj); err != nil {
+			return errors.WithStack(err)
+		}
+		p.objs = append(p.objs, obj)
+		objNum := i + 1
+		if obj.NPages > 0 && (obj.PageTableIndex == 0 || int64(obj.PageTableIndex)+int64(obj.NPages)-1 > int64(p.hdr.NPages)) {
+			return errors.Errorf("invalid pages %d-%d of object %d; exceeds number of pages (%d)", obj.PageTableIndex, int64(obj.PageTableIndex)+int64(obj.NPages)-1, objNum, p.hdr.NPages)
+		}
+		var data []byte
+		fileSize := 0
+		for j := uint32(0); j < obj.NPages; j++ {
+			page, err := p.readPage(obj.PageTableIndex + j)
+			if err != nil {
+				return errors.Wrapf(err, "unable to read page %d of object %d", j, objNum)
+			}
+			fileSize += len(page)
+			// Zero-fill remainder of page.
+			padded := make([]byte, p.hdr.PageSize)
+			copy(padded, page)
+			data = append(data, padded...)
+		}
+		memSize := int(obj.VirtualSize)
+		if len(data) > memSize {
+			data = data[:memSize]
+		}
+		var perm bin.Perm
+		if obj.Flags&objR != 0 {
+			perm |= bin.PermR
+		}
+		if obj.Flags&objW != 0 {
+			perm |= bin.PermW
+		}
+		if obj.Flags&objX != 0 {
+			perm |= bin.PermX
+		}
+		dbg.Printf("object %d at 0x%08X (%d pages, %d bytes in memory)", objNum, obj.RelocBase, obj.NPages, memSize)
+		sect := &bin.Section{
+			Name:     fmt.Sprintf("obj%d", objNum),
+			Addr:     bin.Address(obj.RelocBase),
+			Data:     data,
+			FileSize: fileSize,
+			MemSize:  memSize,
+			Perm:     perm,
+			Align:    int(p.hdr.PageSize),
+		}
+		if obj.Flags&objDiscardable != 0 {
+			sect.Flags |= bin.SectDiscardable
+		}
+		p.sects = append(p.sects, sect)
+		p.file.Sections = append(p.file.Sections, sect)
+	}
+	return nil
+}
+
+// readPage returns the contents of the given 1-based page, as stored in the
+// executable file.
+func (p *parser) readPage(pageNum uint32) ([]byte, error) {
+	entryOff := p.leOff + int64(p.hdr.ObjPageTableOff)
+	if !p.lx {
+		// LE object page table entry; 24-bit page number (high byte first) and
+		// flags.
+		var e [4]byte
+		if _, err := p.r.ReadAt(e[:], entryOff+int64(pageNum-1)*4); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		n := uint32(e[0])<<16 | uint32(e[1])<<8 | uint32(e[2])
+		if n == 0 {
+			return nil, nil
+		}
+		size := p.hdr.PageSize
+		if n == p.hdr.NPages {
+			// Number of bytes of the last page.
+			size = p.hdr.PageShift
+		}
+		buf := make([]byte, size)
+		off := int64(p.hdr.DataPagesOff) + int64(n-1)*int64(p.hdr.PageSize)
+		if _, err := p.r.ReadAt(buf, off); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return buf, nil
+	}
+	// LX object page table entry; page data offset, data size and flags.
+	var e struct {
+		Offset uint32
+		Size   uint16
+		Flags  uint16
+	}
+	if err := read(p.r, entryOff+int64(pageNum-1)*8, &e); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	switch e.Flags {
+	case pageValid, pageIterated:
+		buf := make([]byte, e.Size)
+		base := int64(p.hdr.DataPagesOff)
+		if e.Flags == pageIterated {
+			base = int64(p.hdr.ObjIterPagesOff)
+		}
+		off := base + int64(e.Offset)<<p.hdr.PageShift
+		if _, err := p.r.ReadAt(buf, off); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if e.Flags == pageIterated {
+			return expandIterated(buf, int(p.hdr.PageSize))
+		}
+		return buf, nil
+	case pageInvalid, pageZeroed:
+		return nil, nil
+	default:
+		warn.Printf("support for LX page flags 0x%04X of page %d not yet implemented; zero-filling page", e.Flags, pageNum)
+		return nil, nil
+	}
+}
+
+// parseEntries parses the entry table.
+func (p *parser) parseEntries() error {
+	p.entries = make(map[uint32]bin.Address)
+	off := p.leOff + int64(p.hdr.EntryTableOff)
+	ordinal := uint32(1)
+	for {
+		var bundle [2]byte
+		if _, err := p.r.ReadAt(bundle[:], off); err != nil {
+			return errors.WithStack(err)
+		}
+		off += 2
+		count, typ := bundle[0], bundle[1]&0x7F
+		if count == 0 {
+			break
+		}
+		if typ == 0 {
+			// unused entries.
+			ordinal += uint32(count)
+			continue
+		}
+		var objNum uint16
+		if err := read(p.r, off, &objNum); err != nil {
+			return errors.WithStack(err)
+		}
+		off += 2
+		// Size in bytes of each entry of the bundle.
+		var size int64
+		switch typ {
+		case 1:
+			// 16-bit entry; flags and 16-bit offset.
+			size = 3
+		case 2:
+			// 286 call gate entry; flags, 16-bit offset and call gate selector.
+			size = 5
+		case 3:
+			// 32-bit entry; flags and 32-bit offset.
+			size = 5
+		case 4:
+			// forwarder entry; flags, module ordinal and procedure name offset or
+			// ordinal.
+			size = 7
+		default:
+			return errors.Errorf("support for entry bundle type %d not yet implemented", typ)
+		}
+		for i := 0; i < int(count); i++ {
+			buf := make([]byte, size)
+			if _, err := p.r.ReadAt(buf, off); err != nil {
+				return errors.WithStack(err)
+			}
+			off += size
+			if typ != 4 {
+				if objNum == 0 || int(objNum) > len(p.objs) {
+					return errors.Errorf("invalid object number %d of entry ordinal %d", objNum, ordinal)
+				}
+				var entryOff uint32
+				if typ == 3 {
+					entryOff = binary.LittleEndian.Uint32(buf[1:])
+				} else {
+					entryOff = uint32(binary.LittleEndian.Uint16(buf[1:]))
+				}
+				p.entries[ordinal] = bin.Address(p.objs[objNum-1].RelocBase + entryOff)
+			}
+			ordinal++
+		}
+	}
+	return nil
+}
+
+// parseNames parses the resident or non-resident names table located at the
+// given file offset, and records exports of the named entries. A negative size
+// indicates that the names table is terminated by a zero-length name.
+func (p *parser) parseNames(off, size int64) error {
+	end := off + size
+	for first := true; size < 0 || off < end; first = false {
+		name, err := readPascalString(p.r, off)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if len(name) == 0 {
+			break
+		}
+		var ordinal uint16
+		if err := read(p.r, off+1+int64(len(name)), &ordinal); err != nil {
+			return errors.WithStack(err)
+		}
+		off += 1 + int64(len(name)) + 2
+		if first {
+			// module name or description.
+			continue
+		}
+		if addr, ok := p.entries[uint32(ordinal)]; ok {
+			dbg.Printf("export %q (ordinal %d) at %v", name, ordinal, addr)
+			p.file.Exports[addr] = name
+		}
+	}
+	return nil
+}
+
+// Fixup source types.
+const (
+	// 8-bit byte.
+	fixupByte = 0x00
+	// 16-bit selector.
+	fixupSelector = 0x02
+	// 16:16 far pointer.
+	fixupPtr16 = 0x03
+	// 16-bit offset.
+	fixupOffset16 = 0x05
+	// 16:32 far pointer.
+	fixupPtr32 = 0x06
+	// 32-bit offset.
+	fixupOffset32 = 0x07
+	// 32-bit self-relative offset.
+	fixupRel32 = 0x08
+	// Source list flag.
+	fixupSourceList = 0x20
+)
+
+// Fixup target flags.
+const (
+	// Internal reference.
+	fixupInternal = 0x00
+	// Imported reference by ordinal.
+	fixupImportOrdinal = 0x01
+	// Imported reference by name.
+	fixupImportName = 0x02
+	// Internal reference through entry table.
+	fixupEntry = 0x03
+	// Additive fixup.
+	fixupAdditive = 0x04
+	// 32-bit target offset.
+	fixupTarget32 = 0x10
+	// 32-bit additive value.
+	fixupAdditive32 = 0x20
+	// 16-bit object number or module ordinal.
+	fixupObject16 = 0x40
+	// 8-bit import ordinal.
+	fixupOrdinal8 = 0x80
+)
+
+// applyFixups applies the fixups of each page of the objects.
+func (p *parser) applyFixups() error {
+	// Parse fixup page table.
+	fptOff := p.leOff + int64(p.hdr.FixupPageTableOff)
+	n := int64(p.hdr.NPages) + 1
+	if !p.fits(fptOff, n*4) {
+		return errors.Errorf("invalid fixup page table of %d pages; exceeds file size (%d bytes)", p.hdr.NPages, p.size)
+	}
+	fpt := make([]uint32, n)
+	if err := read(p.r, fptOff, fpt); err != nil {
+		return errors.WithStack(err)
+	}
+	recsOff := p.leOff + int64(p.hdr.FixupRecordTableOff)
+	recsSize := int64(fpt[p.hdr.NPages])
+	if !p.fits(recsOff, recsSize) {
+		return errors.Errorf("invalid fixup record table size %d; exceeds file size (%d bytes)", recsSize, p.size)
+	}
+	recs := make([]byte, recsSize)
+	if _, err := p.r.ReadAt(recs, recsOff); err != nil {
+		return errors.WithStack(err)
+	}
+	for i, obj := range p.objs {
+		for j := uint32(0); j < obj.NPages; j++ {
+			pageNum := obj.PageTableIndex + j
+			if pageNum == 0 || pageNum > p.hdr.NPages {
+				return errors.Errorf("invalid page number %d of object %d", pageNum, i+1)
+			}
+			start, end := fpt[pageNum-1], fpt[pageNum]
+			if start > end || int(end) > len(recs) {
+				return errors.Errorf("invalid fixup records of page %d", pageNum)
+			}
+			pageOff := int64(j) * int64(p.hdr.PageSize)
+			if err := p.applyPageFixups(i, pageOff, recs[start:end]); err != nil {
+				return errors.Wrapf(err, "unable to apply fixups of page %d", pageNum)
+			}
+		}
+	}
+	return nil
+}
+
+// applyPageFixups applies the given fixup records of the page at the specified
+// offset of the object with the given 0-based index.
+func (p *parser) applyPageFixups(objIndex int, pageOff int64, recs []byte) error {
+	fr := &fixupReader{buf: recs}
+	for len(fr.buf) > 0 {
+		src, flags := fr.u8(), fr.u8()
+		var srcOffs []int64
+		count := uint32(1)
+		if src&fixupSourceList != 0 {
+			count = fr.u8()
+		} else {
+			srcOffs = append(srcOffs, int64(int16(fr.u16())))
+		}
+		// Parse fixup target.
+		objOrMod := func() uint32 {
+			if flags&fixupObject16 != 0 {
+				return fr.u16()
+			}
+			return fr.u8()
+		}
+		var (
+			// Linear target address.
+			target bin.Address
+			// Target far address; object number and offset.
+			sel uint32
+			off uint32
+		)
+		switch flags & 0x03 {
+		case fixupInternal:
+			sel = objOrMod()
+			if src&0x0F != fixupSelector {
+				if flags&fixupTarget32 != 0 {
+					off = fr.u32()
+				} else {
+					off = fr.u16()
+				}
+			}
+			if sel == 0 || int(sel) > len(p.objs) {
+				return errors.Errorf("invalid target object number %d", sel)
+			}
+			target = bin.Address(p.objs[sel-1].RelocBase + off)
+		case fixupImportOrdinal:
+			mod := objOrMod()
+			var ordinal uint32
+			switch {
+			case flags&fixupOrdinal8 != 0:
+				ordinal = fr.u8()
+			case flags&fixupTarget32 != 0:
+				ordinal = fr.u32()
+			default:
+				ordinal = fr.u16()
+			}
+			modName, err := p.importModule(mod)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			name := fmt.Sprintf("%s_ordinal_%d", strings.ToLower(modName), ordinal)
+			target, sel, off = p.importAddr(name)
+		case fixupImportName:
+			mod := objOrMod()
+			var nameOff uint32
+			if flags&fixupTarget32 != 0 {
+				nameOff = fr.u32()
+			} else {
+				nameOff = fr.u16()
+			}
+			if _, err := p.importModule(mod); err != nil {
+				return errors.WithStack(err)
+			}
+			name, err := readPascalString(p.r, p.leOff+int64(p.hdr.ImpProcTableOff)+int64(nameOff))
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			target, sel, off = p.importAddr(name)
+		case fixupEntry:
+			ordinal := objOrMod()
+			addr, ok := p.entries[ordinal]
+			if !ok {
+				return errors.Errorf("unable to locate entry ordinal %d", ordinal)
+			}
+			target = addr
+			sel, off = p.farAddr(addr)
+		}
+		if flags&fixupAdditive != 0 {
+			var add uint32
+			if flags&fixupAdditive32 != 0 {
+				add = fr.u32()
+			} else {
+				add = fr.u16()
+			}
+			target += bin.Address(add)
+			off += add
+		}
+		for i := uint32(0); i < count && src&fixupSourceList != 0; i++ {
+			srcOffs = append(srcOffs, int64(int16(fr.u16())))
+		}
+		if fr.err != nil {
+			return errors.WithStack(fr.err)
+		}
+		for _, srcOff := range srcOffs {
+			if err := p.applyFixup(objIndex, pageOff+srcOff, src&0x0F, target, sel, off); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+	return nil
+}
+
+// applyFixup applies the fixup of the given source type at the specified offset
+// of the object with the given 0-based index.
+func (p *parser) applyFixup(objIndex int, srcOff int64, srcType uint32, target bin.Address, sel, off uint32) error {
+	obj, data := p.objs[objIndex], p.sects[objIndex].Data
+	size := map[uint32]int64{
+		fixupByte:     1,
+		fixupSelector: 2,
+		fixupPtr16:    4,
+		fixupOffset16: 2,
+		fixupPtr32:    6,
+		fixupOffset32: 4,
+		fixupRel32:    4,
+	}
+	n, ok := size[srcType]
+	if !ok {
+		return errors.Errorf("support for fixup source type 0x%02X not yet implemented", srcType)
+	}
+	if srcOff < 0 || srcOff+n > int64(len(data)) {
+		// skip fixups of uninitialized data outside of the object data.
+		return nil
+	}
+	buf := data[srcOff:]
+	switch srcType {
+	case fixupByte:
+		buf[0] = byte(target)
+	case fixupSelector:
+		binary.LittleEndian.PutUint16(buf, uint16(sel))
+	case fixupPtr16:
+		binary.LittleEndian.PutUint16(buf, uint16(off))
+		binary.LittleEndian.PutUint16(buf[2:], uint16(sel))
+	case fixupOffset16:
+		binary.LittleEndian.PutUint16(buf, uint16(off))
+	case fixupPtr32:
+		binary.LittleEndian.PutUint32(buf, off)
+		binary.LittleEndian.PutUint16(buf[4:], uint16(sel))
+	case fixupOffset32:
+		binary.LittleEndian.PutUint32(buf, uint32(target))
+	case fixupRel32:
+		next := bin.Address(obj.RelocBase) + bin.Address(srcOff+4)
+		binary.LittleEndian.PutUint32(buf, uint32(target-next))
+	}
+	return nil
+}
+
+// farAddr returns the far address (object number and offset) of the given
+// linear address.
+func (p *parser) farAddr(addr bin.Address) (sel, off uint32) {
+	for i, obj := range p.objs {
+		base := bin.Address(obj.RelocBase)
+		if base <= addr && addr < base+bin.Address(obj.VirtualSize) {
+			return uint32(i + 1), uint32(addr - base)
+		}
+	}
+	return 0, uint32(addr)
+}
+
+// importModule returns the name of the imported module with the given 1-based
+// module ordinal.
+func (p *parser) importModule(mod uint32) (string, error) {
+	if mod == 0 || mod > p.hdr.NImpMods {
+		return "", errors.Errorf("invalid import module ordinal %d", mod)
+	}
+	off := p.leOff + int64(p.hdr.ImpModTableOff)
+	for i := uint32(1); ; i++ {
+		name, err := readPascalString(p.r, off)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		if i == mod {
+			return name, nil
+		}
+		off += 1 + int64(len(name))
+	}
+}
+
+// importBase returns the base address of the artificial import object.
+func (p *parser) importBase() uint32 {
+	var end uint32
+	for _, obj := range p.objs {
+		if e := obj.RelocBase + obj.VirtualSize; e > end {
+			end = e
+		}
+	}
+	// Align to page boundary.
+	const pageSize = 0x1000
+	return (end + pageSize - 1) &^ (pageSize - 1)
+}
+
+// importAddr returns the linear and far address of the slot of the given
+// imported function in the artificial import object.
+func (p *parser) importAddr(name string) (target bin.Address, sel, off uint32) {
+	sel = uint32(len(p.objs) + 1)
+	off, ok := p.imports[name]
+	if !ok {
+		off = p.nimports * importSlotSize
+		p.imports[name] = off
+		p.nimports++
+		addr := bin.Address(p.importBase() + off)
+		dbg.Printf("import %q at %v", name, addr)
+		p.file.Imports[addr] = name
+	}
+	return bin.Address(p.importBase() + off), sel, off
+}
+
+// ### [ Helper functions ] ####################################################
+
+// fixupReader reads little-endian values from fixup records.
+type fixupReader struct {
+	// Remaining fixup records.
+	buf []byte
+	// First error encountered.
+	err error
+}
+
+// u8 reads an 8-bit value from the fixup records.
+func (fr *fixupReader) u8() uint32 {
+	return uint32(fr.next(1)[0])
+}
+
+// u16 reads a 16-bit value from the fixup records.
+func (fr *fixupReader) u16() uint32 {
+	return uint32(binary.LittleEndian.Uint16(fr.next(2)))
+}
+
+// u32 reads a 32-bit value from the fixup records.
+func (fr *fixupReader) u32() uint32 {
+	return binary.LittleEndian.Uint32(fr.next(4))
+}
+
+// next returns the next n bytes of the fixup records; or n zero bytes if
+// truncated.
+func (fr *fixupReader) next(n int) []byte {
+	if len(fr.buf) < n {
+		if fr.err == nil {
+			fr.err = errors.New("truncated fixup record")
+		}
+		fr.buf = nil
+		return make([]byte, n)
+	}
+	buf := fr.buf[:n]
+	fr.buf = fr.buf[n:]
+	return buf
+}
+
+// expandIterated expands the given iterated data page (EXEPACK), consisting of
+// repeated data records; number of iterations, data length and data.
+func expandIterated(buf []byte, pageSize int) ([]byte, error) {
+	var page []byte
+	for len(buf) >= 4 && len(page) < pageSize {
+		n := int(binary.LittleEndian.Uint16(buf))
+		size := int(binary.LittleEndian.Uint16(buf[2:]))
+		buf = buf[4:]
+		if size > len(buf) {
+			return nil, errors.Errorf("invalid iterated data record; data length %d exceeds remaining page data (%d bytes)", size, len(buf))
+		}
+		for i := 0; i < n && len(page) < pageSize; i++ {
+			page = append(page, buf[:size]...)
+		}
+		buf = buf[size:]
+	}
+	if len(page) > pageSize {
+		page = page[:pageSize]
+	}
+	return page, nil
+}
+
+// fileSize returns the size in bytes of the given input file.
+func fileSize(r io.ReaderAt) int64 {
+	switch r := r.(type) {
+	case interface{ Size() int64 }:
+		// e.g. *bytes.Reader and *io.SectionReader.
+		return r.Size()
+	case interface{ Stat() (os.FileInfo, error) }:
+		// e.g. *os.File.
+		if fi, err := r.Stat(); err == nil {
+			return fi.Size()
+		}
+	}
+	// Locate the end of file by binary search.
+	readable := func(off int64) bool {
+		var b [1]byte
+		_, err := r.ReadAt(b[:], off)
+		return err == nil
+	}
+	if !readable(0) {
+		return 0
+	}
+	lo, hi := int64(0), int64(1)
+	for readable(hi) {
+		lo, hi = hi, hi*2
+	}
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		if readable(mid) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo + 1
+}
+
+// read reads structured binary data from r at the given file offset into v.
+func read(r io.ReaderAt, off int64, v interface{}) error {
+	sr := io.NewSectionReader(r, off, int64(binary.Size(v)))
+	if err := binary.Read(sr, binary.LittleEndian, v); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// readPascalString reads a length-prefixed string from r at the given file
+// offset.
+func readPascalString(r io.ReaderAt, off int64) (string, error) {
+	var n [1]byte
+	if _, err := r.ReadAt(n[:], off); err != nil {
+		return "", errors.WithStack(err)
+	}
+	buf := make([]byte, n[0])
+	if _, err := r.ReadAt(buf, off+1); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return string(buf), nil
+}
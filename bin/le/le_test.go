@@ -0,0 +1,141 @@
+package le_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/bin/le"
+)
+
+func TestParse(t *testing.T) {
+	type section struct {
+		name     string
+		addr     bin.Address
+		data     []byte
+		fileSize int
+		memSize  int
+		perm     bin.Perm
+	}
+	golden := []struct {
+		path     string
+		entry    bin.Address
+		sections []section
+		exports  map[bin.Address]string
+		imports  map[bin.Address]string
+	}{
+		{
+			path:  "testdata/hello.exe",
+			entry: 0x10000,
+			sections: []section{
+				// Fixup of 32-bit offset to imported function; the object is padded
+				// to the page size.
+				{name: "obj1", addr: 0x10000, data: append([]byte{0xB8, 0x00, 0x20, 0x01, 0x00, 0xC3, 0x90, 0x90}, make([]byte, 0x1000-8)...), fileSize: 8, memSize: 0x2000, perm: bin.PermR | bin.PermX},
+				{name: "imports", addr: 0x12000, data: make([]byte, 4), memSize: 4, perm: bin.PermR},
+			},
+			exports: map[bin.Address]string{0x10000: "start"},
+			imports: map[bin.Address]string{0x12000: "Beep"},
+		},
+	}
+	for _, g := range golden {
+		file, err := le.ParseFile(g.path)
+		if err != nil {
+			t.Errorf("%q: unable to parse LE executable; %+v", g.path, err)
+			continue
+		}
+		if file.Arch != bin.ArchX86_32 {
+			t.Errorf("%q: machine architecture mismatch; expected %v, got %v", g.path, bin.ArchX86_32, file.Arch)
+		}
+		if file.Entry != g.entry {
+			t.Errorf("%q: entry point mismatch; expected %v, got %v", g.path, g.entry, file.Entry)
+		}
+		if len(file.Sections) != len(g.sections) {
+			t.Errorf("%q: number of sections mismatch; expected %d, got %d", g.path, len(g.sections), len(file.Sections))
+			continue
+		}
+		for i, want := range g.sections {
+			sect := file.Sections[i]
+			if sect.Name != want.name {
+				t.Errorf("%q: section %d: name mismatch; expected %q, got %q", g.path, i, want.name, sect.Name)
+			}
+			if sect.Addr != want.addr {
+				t.Errorf("%q: section %q: address mismatch; expected %v, got %v", g.path, want.name, want.addr, sect.Addr)
+			}
+			if !bytes.Equal(sect.Data, want.data) {
+				t.Errorf("%q: section %q: contents mismatch; expected % X, got % X", g.path, want.name, want.data, sect.Data)
+			}
+			if sect.FileSize != want.fileSize || sect.MemSize != want.memSize {
+				t.Errorf("%q: section %q: size mismatch; expected %d bytes in file and 0x%X bytes in memory, got %d and 0x%X", g.path, want.name, want.fileSize, want.memSize, sect.FileSize, sect.MemSize)
+			}
+			if sect.Perm != want.perm {
+				t.Errorf("%q: section %q: permissions mismatch; expected %v, got %v", g.path, want.name, want.perm, sect.Perm)
+			}
+		}
+		for addr, name := range g.exports {
+			if got := file.Exports[addr]; got != name {
+				t.Errorf("%q: export at %v mismatch; expected %q, got %q", g.path, addr, name, got)
+			}
+		}
+		for addr, name := range g.imports {
+			if got := file.Imports[addr]; got != name {
+				t.Errorf("%q: import at %v mismatch; expected %q, got %q", g.path, addr, name, got)
+			}
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	golden := []struct {
+		path string
+		desc string
+	}{
+		{path: "testdata/invalid/pages.exe", desc: "number of pages exceeding the object page table of the file"},
+		{path: "testdata/invalid/pages_64k.exe", desc: "number of pages (65536) exceeding the object page table of the file"},
+		{path: "testdata/invalid/pagesize_0.exe", desc: "page size of zero"},
+		{path: "testdata/invalid/pagesize.exe", desc: "page size exceeding 64 KB"},
+		{path: "testdata/invalid/lastpage.exe", desc: "size of last page exceeding page size"},
+		{path: "testdata/invalid/objects.exe", desc: "number of objects exceeding the object table of the file"},
+		{path: "testdata/invalid/fixpages.exe", desc: "fixup page table exceeding file"},
+		{path: "testdata/invalid/fixrecs.exe", desc: "fixup record table exceeding file"},
+	}
+	for _, g := range golden {
+		buf, err := ioutil.ReadFile(g.path)
+		if err != nil {
+			t.Errorf("%q: %+v", g.path, err)
+			continue
+		}
+		if _, err := le.Parse(bytes.NewReader(buf)); err == nil {
+			t.Errorf("%q: %s; expected error, got nil", g.path, g.desc)
+		}
+		// Reader of unknown size.
+		r := struct{ io.ReaderAt }{bytes.NewReader(buf)}
+		if _, err := le.Parse(r); err == nil {
+			t.Errorf("%q: %s; expected error for reader of unknown size, got nil", g.path, g.desc)
+		}
+	}
+}
+
+func TestParseTruncated(t *testing.T) {
+	buf, err := ioutil.ReadFile("testdata/hello.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := []struct {
+		size int
+		desc string
+	}{
+		{size: 0x20, desc: "MZ header"},
+		{size: 0x80, desc: "LE header"},
+		{size: 0xF8, desc: "object table"},
+		{size: 0x110, desc: "resident names"},
+		{size: 0x134, desc: "fixup record table"},
+		{size: 0x204, desc: "data pages"},
+	}
+	for _, g := range golden {
+		if _, err := le.Parse(bytes.NewReader(buf[:g.size])); err == nil {
+			t.Errorf("truncated within %s (0x%X bytes); expected error, got nil", g.desc, g.size)
+		}
+	}
+}
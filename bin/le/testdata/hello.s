@@ -0,0 +1,93 @@
+# LE executable (32-bit OS/2) with a code object referencing an imported
+# function (KERNEL.Beep) through a 32-bit offset fixup.
+
+	.text
+mz:
+	.ascii	"MZ"
+	.org	0x3C
+	.long	le - mz                # offset of LE header
+
+	.org	0x40
+le:
+	.ascii	"LE"
+	.byte	0, 0                   # byte and word order (little-endian)
+	.long	0                      # format level
+	.word	2                      # CPU type (386)
+	.word	1                      # OS type (OS/2)
+	.long	0                      # module version
+	.long	0                      # module flags
+	.long	1                      # pages
+	.long	1, start - code        # EIP object and offset
+	.long	0, 0                   # ESP object and offset
+	.long	0x1000                 # page size
+	.long	code_end - code        # bytes of last page
+	.long	0, 0                   # fixup section size and checksum
+	.long	0, 0                   # loader section size and checksum
+	.long	objs - le              # object table
+	.long	1                      # objects
+	.long	objpages - le          # object page table
+	.long	0                      # object iterated pages
+	.long	0, 0                   # resource table and entries
+	.long	resnames - le          # resident names
+	.long	entries - le           # entry table
+	.long	0, 0                   # module directives and entries
+	.long	fixpages - le          # fixup page table
+	.long	fixrecs - le           # fixup record table
+	.long	impmods - le           # imported module names
+	.long	1                      # imported modules
+	.long	impprocs - le          # imported procedure names
+	.long	0                      # per-page checksums
+	.long	code - mz              # data pages
+
+	.org	le + 0xB0
+objs:
+	# 32-bit readable and executable object.
+	.long	0x2000, 0x10000, 0x2005, 1, 1, 0
+objpages:
+	.byte	0, 0, 1, 0
+resnames:
+	# Module name and exported entry point.
+	.byte	4
+	.ascii	"TEST"
+	.word	0
+	.byte	5
+	.ascii	"start"
+	.word	1
+	.byte	0
+	.org	le + 0xDC
+entries:
+	# 32-bit bundle of one entry in object 1 at offset 0.
+	.byte	1, 3
+	.word	1
+	.byte	0x01
+	.long	start - code
+	.byte	0, 0
+	.org	le + 0xE8
+fixpages:
+	.long	0, fixrecs_end - fixrecs
+fixrecs:
+	# 32-bit offset at offset 1 of page 1 to imported procedure name.
+	.byte	0x07, 0x02
+	.word	1
+	.byte	1
+	.word	beep - impprocs
+fixrecs_end:
+	.byte	0
+impmods:
+	.byte	6
+	.ascii	"KERNEL"
+	.org	le + 0x100
+impprocs:
+	.byte	0
+beep:
+	.byte	4
+	.ascii	"Beep"
+
+	.org	0x200
+code:
+start:
+	movl	$0, %eax               # address of KERNEL.Beep
+	ret
+	nop
+	nop
+code_end:
@@ -0,0 +1,292 @@
+// Package memdump provides access to process memory dumps; Windows minidump
+// files and raw process memory dumps with an associated module map.
+//
+// Memory dumps capture the process image exactly as loaded (e.g. unpacked or
+// decrypted in memory), including the import address table (IAT) resolved by
+// the loader. Imports are named by resolving the IAT entries against the
+// export tables of the loaded modules.
+package memdump
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/mewkiz/pkg/pathutil"
+	"github.com/mewkiz/pkg/term"
+)
+
+var (
+	// dbg is a logger which logs debug messages with "memdump:" prefix to
+	// standard error.
+	dbg = log.New(os.Stderr, term.MagentaBold("memdump:")+" ", 0)
+)
+
+// A Module is a module (executable or shared library) loaded into the address
+// space of the process.
+type Module struct {
+	// Module name.
+	Name string `json:"name"`
+	// Base address of the module image.
+	Addr bin.Address `json:"addr"`
+	// Size in bytes of the module image.
+	Size int `json:"size"`
+}
+
+// contains reports whether the given address is within the module image.
+func (m *Module) contains(addr bin.Address) bool {
+	return m.Addr <= addr && addr < m.Addr+bin.Address(m.Size)
+}
+
+// loadModules records the entry point, imports and exports of the given
+// process memory, based on the in-memory PE images of the modules. The first
+// module is the main executable.
+func loadModules(file *bin.File, modules []*Module) {
+	sortSections(file)
+	if file.Imports == nil {
+		file.Imports = make(map[bin.Address]string)
+	}
+	if file.Exports == nil {
+		file.Exports = make(map[bin.Address]string)
+	}
+	if len(modules) == 0 {
+		return
+	}
+	// Map from resolved address to name of exported function of each module.
+	resolved := make(map[bin.Address]string)
+	for _, m := range modules[1:] {
+		for addr, name := range parseExports(file, m.Addr) {
+			resolved[addr] = name
+		}
+	}
+	main := modules[0]
+	dbg.Printf("main module %q at %v", main.Name, main.Addr)
+	if file.Entry == 0 {
+		if h, ok := parsePEHeader(file, main.Addr); ok && h.entryRVA != 0 {
+			file.Entry = main.Addr + bin.Address(h.entryRVA)
+		}
+	}
+	for addr, name := range parseExports(file, main.Addr) {
+		file.Exports[addr] = name
+	}
+	for addr, name := range parseImports(file, main.Addr, resolved) {
+		file.Imports[addr] = name
+	}
+}
+
+// sortSections sorts the sections of the given file based on address.
+func sortSections(file *bin.File) {
+	less := func(i, j int) bool {
+		return file.Sections[i].Addr < file.Sections[j].Addr
+	}
+	sort.Slice(file.Sections, less)
+}
+
+// --- [ PE image ] ------------------------------------------------------------
+
+// peHeader holds the fields of an in-memory PE image header relevant to memory
+// dumps.
+type peHeader struct {
+	// Entry point RVA.
+	entryRVA uint32
+	// Export table RVA and size.
+	etRVA  uint32
+	etSize uint32
+	// Import table RVA and size.
+	itRVA  uint32
+	itSize uint32
+}
+
+// parsePEHeader parses the PE image header at the given base address. The
+// boolean return value indicates success.
+func parsePEHeader(file *bin.File, base bin.Address) (*peHeader, bool) {
+	dos, ok := read(file, base, 0x40)
+	if !ok || dos[0] != 'M' || dos[1] != 'Z' {
+		return nil, false
+	}
+	peAddr := base + bin.Address(binary.LittleEndian.Uint32(dos[0x3C:]))
+	// Signature (4 bytes), file header (20 bytes) and optional header.
+	const (
+		fileHeaderSize  = 20
+		optHeaderOffset = 4 + fileHeaderSize
+	)
+	buf, ok := read(file, peAddr, optHeaderOffset+2)
+	if !ok || !bytes.Equal(buf[:4], []byte("PE\x00\x00")) {
+		return nil, false
+	}
+	optAddr := peAddr + optHeaderOffset
+	opt, ok := read(file, optAddr, 0x70+16)
+	if !ok {
+		return nil, false
+	}
+	// Offset of data directories within optional header.
+	var ddOffset int
+	switch magic := binary.LittleEndian.Uint16(opt); magic {
+	case 0x10B: // PE32
+		ddOffset = 0x60
+	case 0x20B: // PE32+
+		ddOffset = 0x70
+	default:
+		return nil, false
+	}
+	dd, ok := read(file, optAddr+bin.Address(ddOffset), 2*8)
+	if !ok {
+		return nil, false
+	}
+	h := &peHeader{
+		entryRVA: binary.LittleEndian.Uint32(opt[0x10:]),
+		etRVA:    binary.LittleEndian.Uint32(dd[0:]),
+		etSize:   binary.LittleEndian.Uint32(dd[4:]),
+		itRVA:    binary.LittleEndian.Uint32(dd[8:]),
+		itSize:   binary.LittleEndian.Uint32(dd[12:]),
+	}
+	return h, true
+}
+
+// parseExports returns a map from address to name of the functions exported by
+// the PE image at the given base address.
+func parseExports(file *bin.File, base bin.Address) map[bin.Address]string {
+	exports := make(map[bin.Address]string)
+	h, ok := parsePEHeader(file, base)
+	if !ok || h.etSize == 0 {
+		return exports
+	}
+	// Export directory.
+	ed, ok := read(file, base+bin.Address(h.etRVA), 40)
+	if !ok {
+		return exports
+	}
+	dllName := readString(file, base+bin.Address(binary.LittleEndian.Uint32(ed[12:])))
+	nfuncs := binary.LittleEndian.Uint32(ed[20:])
+	nnames := binary.LittleEndian.Uint32(ed[24:])
+	funcsAddr := base + bin.Address(binary.LittleEndian.Uint32(ed[28:]))
+	namesAddr := base + bin.Address(binary.LittleEndian.Uint32(ed[32:]))
+	ordsAddr := base + bin.Address(binary.LittleEndian.Uint32(ed[36:]))
+	funcs, ok1 := read(file, funcsAddr, 4*int(nfuncs))
+	names, ok2 := read(file, namesAddr, 4*int(nnames))
+	ords, ok3 := read(file, ordsAddr, 2*int(nnames))
+	if !ok1 || !ok2 || !ok3 {
+		return exports
+	}
+	for i := 0; i < int(nnames); i++ {
+		nameAddr := base + bin.Address(binary.LittleEndian.Uint32(names[4*i:]))
+		ord := int(binary.LittleEndian.Uint16(ords[2*i:]))
+		if ord >= int(nfuncs) {
+			continue
+		}
+		rva := binary.LittleEndian.Uint32(funcs[4*ord:])
+		if h.etRVA <= rva && rva < h.etRVA+h.etSize {
+			// skip forwarded export.
+			continue
+		}
+		name := readString(file, nameAddr)
+		dbg.Printf("export %s!%s at %v", dllName, name, base+bin.Address(rva))
+		exports[base+bin.Address(rva)] = name
+	}
+	return exports
+}
+
+// parseImports returns a map from import address table (IAT) entry address to
+// name of the functions imported by the PE image at the given base address.
+// The IAT entries are resolved against the given map from address to name of
+// exported functions, and named by the import name table if unresolved.
+func parseImports(file *bin.File, base bin.Address, resolved map[bin.Address]string) map[bin.Address]string {
+	imports := make(map[bin.Address]string)
+	h, ok := parsePEHeader(file, base)
+	if !ok || h.itSize == 0 {
+		return imports
+	}
+	ptrSize := file.Arch.BitSize() / 8
+	// Size in bytes of import descriptors.
+	const impDescSize = 20
+	for descAddr := base + bin.Address(h.itRVA); ; descAddr += impDescSize {
+		desc, ok := read(file, descAddr, impDescSize)
+		if !ok || bytes.Equal(desc, make([]byte, impDescSize)) {
+			break
+		}
+		intRVA := binary.LittleEndian.Uint32(desc[0:])
+		dllName := readString(file, base+bin.Address(binary.LittleEndian.Uint32(desc[12:])))
+		iatAddr := base + bin.Address(binary.LittleEndian.Uint32(desc[16:]))
+		for i := 0; ; i++ {
+			entryAddr := iatAddr + bin.Address(i*ptrSize)
+			target, ok := readUintptr(file, entryAddr)
+			if !ok || target == 0 {
+				break
+			}
+			// Resolve IAT entry as loaded.
+			if name, ok := resolved[bin.Address(target)]; ok {
+				imports[entryAddr] = name
+				continue
+			}
+			// Fall back to import name table.
+			if intRVA == 0 {
+				continue
+			}
+			nameRVA, ok := readUintptr(file, base+bin.Address(intRVA)+bin.Address(i*ptrSize))
+			if !ok {
+				continue
+			}
+			ordinalFlag := uint64(1) << uint(ptrSize*8-1)
+			if nameRVA&ordinalFlag != 0 {
+				imports[entryAddr] = fmt.Sprintf("%s_ordinal_%d", pathutil.TrimExt(dllName), nameRVA&0xFFFF)
+				continue
+			}
+			// Skip hint.
+			imports[entryAddr] = readString(file, base+bin.Address(nameRVA)+2)
+		}
+	}
+	return imports
+}
+
+// ### [ Helper functions ] ####################################################
+
+// read returns n bytes of process memory at the given address. The boolean
+// return value indicates success.
+func read(file *bin.File, addr bin.Address, n int) ([]byte, bool) {
+	for _, sect := range file.Sections {
+		if sect.Addr <= addr && addr+bin.Address(n) <= sect.Addr+bin.Address(len(sect.Data)) {
+			offset := addr - sect.Addr
+			return sect.Data[offset : offset+bin.Address(n)], true
+		}
+	}
+	return nil, false
+}
+
+// readUintptr reads a little-endian encoded value of pointer size based on the
+// CPU architecture. The boolean return value indicates success.
+func readUintptr(file *bin.File, addr bin.Address) (uint64, bool) {
+	switch bits := file.Arch.BitSize(); bits {
+	case 32:
+		buf, ok := read(file, addr, 4)
+		if !ok {
+			return 0, false
+		}
+		return uint64(binary.LittleEndian.Uint32(buf)), true
+	case 64:
+		buf, ok := read(file, addr, 8)
+		if !ok {
+			return 0, false
+		}
+		return binary.LittleEndian.Uint64(buf), true
+	default:
+		panic(fmt.Errorf("support for machine architecture with bit size %d not yet implemented", bits))
+	}
+}
+
+// readString reads the NULL-terminated string at the given address.
+func readString(file *bin.File, addr bin.Address) string {
+	for _, sect := range file.Sections {
+		if sect.Addr <= addr && addr < sect.Addr+bin.Address(len(sect.Data)) {
+			data := sect.Data[addr-sect.Addr:]
+			if pos := bytes.IndexByte(data, '\x00'); pos != -1 {
+				return string(data[:pos])
+			}
+			return string(data)
+		}
+	}
+	return ""
+}
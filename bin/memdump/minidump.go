@@ -0,0 +1,405 @@
+package memdump
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+	"unicode/utf16"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// Register minidump format.
+func init() {
+	// Windows minidump format.
+	//
+	//    4D 44 4D 50  |MDMP|
+	const magic = "MDMP"
+	bin.RegisterFormat("minidump", magic, ParseMinidump)
+}
+
+// ParseMinidumpFile parses the given Windows minidump file, reading from path.
+func ParseMinidumpFile(path string) (*bin.File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	return ParseMinidump(f)
+}
+
+// ParseMinidump parses the given Windows minidump file, reading from r.
+//
+// Users are responsible for closing r.
+func ParseMinidump(r io.ReaderAt) (*bin.File, error) {
+	var hdr minidumpHeader
+	if err := readStruct(r, 0, &hdr); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if hdr.Signature != minidumpSignature {
+		return nil, errors.Errorf("invalid minidump signature 0x%08X; expected 0x%08X", hdr.Signature, minidumpSignature)
+	}
+	file := &bin.File{}
+	var (
+		modules []*Module
+		regions []*minidumpRegion
+		infos   []minidumpMemoryInfo
+	)
+	for i := uint32(0); i < hdr.NStreams; i++ {
+		var dir minidumpDirectory
+		if err := readStruct(r, int64(hdr.StreamDirRVA)+int64(i)*12, &dir); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		var err error
+		switch dir.StreamType {
+		case streamSystemInfo:
+			file.Arch, err = parseSystemInfo(r, dir.RVA)
+		case streamModuleList:
+			modules, err = parseModuleList(r, dir.RVA)
+		case streamMemoryList:
+			var rs []*minidumpRegion
+			rs, err = parseMemoryList(r, dir.RVA)
+			regions = append(regions, rs...)
+		case streamMemory64List:
+			var rs []*minidumpRegion
+			rs, err = parseMemory64List(r, dir.RVA)
+			regions = append(regions, rs...)
+		case streamMemoryInfoList:
+			infos, err = parseMemoryInfoList(r, dir.RVA)
+		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	if file.Arch == 0 {
+		return nil, errors.New("unable to locate system information stream of minidump")
+	}
+	// Parse memory regions.
+	for _, region := range regions {
+		if !readable(r, region.offset, region.size) {
+			return nil, errors.Errorf("memory region at %v out of bounds; %d bytes at offset %d exceeds minidump", region.addr, region.size, region.offset)
+		}
+		data := make([]byte, region.size)
+		if _, err := r.ReadAt(data, region.offset); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		sect := &bin.Section{
+			Name:     regionName(region.addr, modules),
+			Addr:     region.addr,
+			Offset:   uint64(region.offset),
+			Data:     data,
+			FileSize: len(data),
+			MemSize:  len(data),
+			Perm:     regionPerm(region.addr, modules, infos),
+		}
+		file.Sections = append(file.Sections, sect)
+	}
+	loadModules(file, modules)
+	return file, nil
+}
+
+// Minidump stream types.
+const (
+	streamModuleList     = 4
+	streamMemoryList     = 5
+	streamSystemInfo     = 7
+	streamMemory64List   = 9
+	streamMemoryInfoList = 16
+)
+
+// minidumpSignature is the signature of minidump files ("MDMP").
+const minidumpSignature = 0x504D444D
+
+// minidumpHeader is the header of a minidump file.
+type minidumpHeader struct {
+	// Signature ("MDMP").
+	Signature uint32
+	// Version.
+	Version uint32
+	// Number of streams.
+	NStreams uint32
+	// Stream directory RVA.
+	StreamDirRVA uint32
+	// Checksum.
+	Checksum uint32
+	// Time stamp.
+	Date uint32
+	// Flags.
+	Flags uint64
+}
+
+// minidumpDirectory is a stream directory entry of a minidump file.
+type minidumpDirectory struct {
+	// Stream type.
+	StreamType uint32
+	// Size in bytes of stream.
+	Size uint32
+	// Stream RVA.
+	RVA uint32
+}
+
+// minidumpRegion is a memory region of a minidump file.
+type minidumpRegion struct {
+	// Start address of memory region.
+	addr bin.Address
+	// File offset of memory region contents.
+	offset int64
+	// Size in bytes of memory region.
+	size uint64
+}
+
+// minidumpMemoryInfo holds the access permissions of a memory region.
+type minidumpMemoryInfo struct {
+	// Start address of memory region.
+	Addr uint64
+	// Allocation base address.
+	AllocBase uint64
+	// Allocation protection.
+	AllocProtect uint32
+	_            uint32
+	// Size in bytes of memory region.
+	Size uint64
+	// Memory state.
+	State uint32
+	// Memory protection.
+	Protect uint32
+	// Memory type.
+	Type uint32
+	_    uint32
+}
+
+// parseSystemInfo parses the system information stream at the given offset,
+// and returns the machine architecture of the process.
+func parseSystemInfo(r io.ReaderAt, offset uint32) (bin.Arch, error) {
+	var procArch uint16
+	if err := readStruct(r, int64(offset), &procArch); err != nil {
+		return 0, errors.WithStack(err)
+	}
+	// Processor architectures.
+	const (
+		procArchX86   = 0
+		procArchAMD64 = 9
+	)
+	switch procArch {
+	case procArchX86:
+		return bin.ArchX86_32, nil
+	case procArchAMD64:
+		return bin.ArchX86_64, nil
+	default:
+		return 0, errors.Errorf("support for processor architecture %d not yet implemented", procArch)
+	}
+}
+
+// parseModuleList parses the module list stream at the given offset.
+func parseModuleList(r io.ReaderAt, offset uint32) ([]*Module, error) {
+	var n uint32
+	if err := readStruct(r, int64(offset), &n); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// Size in bytes of module entries.
+	const moduleSize = 108
+	var modules []*Module
+	for i := uint32(0); i < n; i++ {
+		var entry struct {
+			Base    uint64
+			Size    uint32
+			_       uint32 // checksum
+			_       uint32 // time stamp
+			NameRVA uint32
+		}
+		if err := readStruct(r, int64(offset)+4+int64(i)*moduleSize, &entry); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		name, err := readMinidumpString(r, entry.NameRVA)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		m := &Module{
+			Name: name,
+			Addr: bin.Address(entry.Base),
+			Size: int(entry.Size),
+		}
+		dbg.Printf("module %q at %v", m.Name, m.Addr)
+		modules = append(modules, m)
+	}
+	return modules, nil
+}
+
+// parseMemoryList parses the memory list stream at the given offset.
+func parseMemoryList(r io.ReaderAt, offset uint32) ([]*minidumpRegion, error) {
+	var n uint32
+	if err := readStruct(r, int64(offset), &n); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var regions []*minidumpRegion
+	for i := uint32(0); i < n; i++ {
+		var desc struct {
+			Addr uint64
+			Size uint32
+			RVA  uint32
+		}
+		if err := readStruct(r, int64(offset)+4+int64(i)*16, &desc); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		region := &minidumpRegion{
+			addr:   bin.Address(desc.Addr),
+			offset: int64(desc.RVA),
+			size:   uint64(desc.Size),
+		}
+		regions = append(regions, region)
+	}
+	return regions, nil
+}
+
+// parseMemory64List parses the 64-bit memory list stream at the given offset.
+// The contents of the memory regions are stored contiguously.
+func parseMemory64List(r io.ReaderAt, offset uint32) ([]*minidumpRegion, error) {
+	var hdr struct {
+		N       uint64
+		BaseRVA uint64
+	}
+	if err := readStruct(r, int64(offset), &hdr); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var regions []*minidumpRegion
+	dataOffset := int64(hdr.BaseRVA)
+	for i := uint64(0); i < hdr.N; i++ {
+		var desc struct {
+			Addr uint64
+			Size uint64
+		}
+		if err := readStruct(r, int64(offset)+16+int64(i)*16, &desc); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		region := &minidumpRegion{
+			addr:   bin.Address(desc.Addr),
+			offset: dataOffset,
+			size:   desc.Size,
+		}
+		regions = append(regions, region)
+		dataOffset += int64(desc.Size)
+	}
+	return regions, nil
+}
+
+// parseMemoryInfoList parses the memory information list stream at the given
+// offset.
+func parseMemoryInfoList(r io.ReaderAt, offset uint32) ([]minidumpMemoryInfo, error) {
+	var hdr struct {
+		HeaderSize uint32
+		EntrySize  uint32
+		N          uint64
+	}
+	if err := readStruct(r, int64(offset), &hdr); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if hdr.EntrySize < uint32(binary.Size(minidumpMemoryInfo{})) {
+		return nil, errors.Errorf("invalid memory information entry size %d; expected at least %d", hdr.EntrySize, binary.Size(minidumpMemoryInfo{}))
+	}
+	var infos []minidumpMemoryInfo
+	for i := uint64(0); i < hdr.N; i++ {
+		var info minidumpMemoryInfo
+		entryOffset := int64(offset) + int64(hdr.HeaderSize) + int64(i)*int64(hdr.EntrySize)
+		if err := readStruct(r, entryOffset, &info); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// regionName returns the name of the module containing the given address; or
+// empty if not part of a module image.
+func regionName(addr bin.Address, modules []*Module) string {
+	for _, m := range modules {
+		if m.contains(addr) {
+			return m.Name
+		}
+	}
+	return ""
+}
+
+// regionPerm returns the access permissions of the memory region at the given
+// address. Memory regions of module images are considered executable if no
+// memory information is present.
+func regionPerm(addr bin.Address, modules []*Module, infos []minidumpMemoryInfo) bin.Perm {
+	for _, info := range infos {
+		if bin.Address(info.Addr) <= addr && addr < bin.Address(info.Addr+info.Size) {
+			return parseProtect(info.Protect)
+		}
+	}
+	if len(regionName(addr, modules)) > 0 {
+		return bin.PermR | bin.PermW | bin.PermX
+	}
+	return bin.PermR | bin.PermW
+}
+
+// parseProtect returns the access permissions represented by the given Windows
+// memory protection constant.
+func parseProtect(protect uint32) bin.Perm {
+	// Memory protection constants.
+	const (
+		pageReadOnly         = 0x02
+		pageReadWrite        = 0x04
+		pageWriteCopy        = 0x08
+		pageExecute          = 0x10
+		pageExecuteRead      = 0x20
+		pageExecuteReadWrite = 0x40
+		pageExecuteWriteCopy = 0x80
+	)
+	switch protect & 0xFF {
+	case pageReadOnly:
+		return bin.PermR
+	case pageReadWrite, pageWriteCopy:
+		return bin.PermR | bin.PermW
+	case pageExecute:
+		return bin.PermX
+	case pageExecuteRead:
+		return bin.PermR | bin.PermX
+	case pageExecuteReadWrite, pageExecuteWriteCopy:
+		return bin.PermR | bin.PermW | bin.PermX
+	}
+	return 0
+}
+
+// readStruct reads the little-endian encoded value v at the given offset.
+func readStruct(r io.ReaderAt, offset int64, v interface{}) error {
+	sr := io.NewSectionReader(r, offset, int64(binary.Size(v)))
+	if err := binary.Read(sr, binary.LittleEndian, v); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// readMinidumpString reads the UTF-16 encoded minidump string at the given
+// offset.
+func readMinidumpString(r io.ReaderAt, offset uint32) (string, error) {
+	var size uint32
+	if err := readStruct(r, int64(offset), &size); err != nil {
+		return "", errors.WithStack(err)
+	}
+	if !readable(r, int64(offset)+4, uint64(size)) {
+		return "", errors.Errorf("minidump string at offset %d out of bounds; %d bytes exceeds minidump", offset, size)
+	}
+	buf := make([]uint16, size/2)
+	if err := readStruct(r, int64(offset)+4, buf); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return string(utf16.Decode(buf)), nil
+}
+
+// readable reports whether the n bytes at the given offset are within the
+// bounds of r. The size of r need not be known; the last byte is read.
+func readable(r io.ReaderAt, offset int64, n uint64) bool {
+	if offset < 0 || n > math.MaxInt64-uint64(offset) {
+		return false
+	}
+	if n == 0 {
+		return true
+	}
+	var b [1]byte
+	_, err := r.ReadAt(b[:], offset+int64(n)-1)
+	return err == nil
+}
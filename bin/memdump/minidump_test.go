@@ -0,0 +1,143 @@
+package memdump_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/bin/memdump"
+)
+
+func TestParseMinidump(t *testing.T) {
+	type section struct {
+		name string
+		addr bin.Address
+		size int
+		perm bin.Perm
+	}
+	golden := []struct {
+		path     string
+		arch     bin.Arch
+		entry    bin.Address
+		sections []section
+		exports  map[bin.Address]string
+		imports  map[bin.Address]string
+	}{
+		{
+			path: "testdata/minidump.dmp",
+			arch: bin.ArchX86_32,
+			// Located from the PE image header of the main module.
+			entry: 0x401000,
+			sections: []section{
+				{name: "hello.exe", addr: 0x400000, size: 0x138, perm: bin.PermR},
+				{name: "hello.exe", addr: 0x401000, size: 14, perm: bin.PermR | bin.PermX},
+				{name: "hello.exe", addr: 0x402000, size: 100, perm: bin.PermR | bin.PermW},
+				{name: "kernel32.dll", addr: 0x7C800000, size: 0x18C, perm: bin.PermR},
+				// Module image without memory information.
+				{name: "kernel32.dll", addr: 0x7C801000, size: 11, perm: bin.PermR | bin.PermW | bin.PermX},
+			},
+			exports: map[bin.Address]string{},
+			imports: map[bin.Address]string{
+				// Resolved against the exports of kernel32.dll.
+				0x402028: "Beep",
+				// Named by the import name table.
+				0x40202C: "ExitProcess",
+			},
+		},
+	}
+	for _, g := range golden {
+		file, err := memdump.ParseMinidumpFile(g.path)
+		if err != nil {
+			t.Errorf("%q: unable to parse minidump; %+v", g.path, err)
+			continue
+		}
+		if file.Arch != g.arch {
+			t.Errorf("%q: machine architecture mismatch; expected %v, got %v", g.path, g.arch, file.Arch)
+		}
+		if file.Entry != g.entry {
+			t.Errorf("%q: entry point mismatch; expected %v, got %v", g.path, g.entry, file.Entry)
+		}
+		if len(file.Sections) != len(g.sections) {
+			t.Errorf("%q: number of sections mismatch; expected %d, got %d", g.path, len(g.sections), len(file.Sections))
+			continue
+		}
+		for i, want := range g.sections {
+			sect := file.Sections[i]
+			if sect.Name != want.name {
+				t.Errorf("%q: section %d: name mismatch; expected %q, got %q", g.path, i, want.name, sect.Name)
+			}
+			if sect.Addr != want.addr {
+				t.Errorf("%q: section %d: address mismatch; expected %v, got %v", g.path, i, want.addr, sect.Addr)
+			}
+			if len(sect.Data) != want.size {
+				t.Errorf("%q: section %d: size mismatch; expected %d, got %d", g.path, i, want.size, len(sect.Data))
+			}
+			if sect.Perm != want.perm {
+				t.Errorf("%q: section %d: permissions mismatch; expected %v, got %v", g.path, i, want.perm, sect.Perm)
+			}
+		}
+		checkSymbols(t, g.path, "export", file.Exports, g.exports)
+		checkSymbols(t, g.path, "import", file.Imports, g.imports)
+	}
+}
+
+func TestParseMinidumpInvalid(t *testing.T) {
+	golden := []struct {
+		path string
+		desc string
+	}{
+		{path: "testdata/invalid/magic.dmp", desc: "invalid signature"},
+		{path: "testdata/invalid/streams.dmp", desc: "number of streams exceeding file"},
+		{path: "testdata/invalid/sysinfo.dmp", desc: "missing system information stream"},
+		{path: "testdata/invalid/arch.dmp", desc: "unsupported processor architecture"},
+		{path: "testdata/invalid/modname.dmp", desc: "size of module name exceeding file"},
+		{path: "testdata/invalid/region.dmp", desc: "size of memory region exceeding file"},
+		{path: "testdata/invalid/region64.dmp", desc: "size of memory region exceeding 64-bit file offsets"},
+		{path: "testdata/invalid/meminfo.dmp", desc: "size of memory information entries of zero"},
+	}
+	for _, g := range golden {
+		if _, err := memdump.ParseMinidumpFile(g.path); err == nil {
+			t.Errorf("%q: %s; expected error, got nil", g.path, g.desc)
+		}
+	}
+}
+
+func TestParseMinidumpTruncated(t *testing.T) {
+	buf, err := ioutil.ReadFile("testdata/minidump.dmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := []struct {
+		size int
+		desc string
+	}{
+		{size: 0x10, desc: "header"},
+		{size: 0x40, desc: "stream directory"},
+		{size: 0x80, desc: "system information"},
+		{size: 0x100, desc: "module list"},
+		{size: 0x180, desc: "module name"},
+		{size: 0x1C0, desc: "memory list"},
+		{size: 0x1F0, desc: "64-bit memory list"},
+		{size: 0x250, desc: "memory information list"},
+		{size: 0x300, desc: "memory region of hello.exe"},
+		{size: len(buf) - 1, desc: "memory region of kernel32.dll"},
+	}
+	for _, g := range golden {
+		if _, err := memdump.ParseMinidump(bytes.NewReader(buf[:g.size])); err == nil {
+			t.Errorf("truncated within %s (0x%X bytes); expected error, got nil", g.desc, g.size)
+		}
+	}
+}
+
+// checkSymbols reports mismatches of the given symbols (imports or exports).
+func checkSymbols(t *testing.T, path, kind string, got, want map[bin.Address]string) {
+	if len(got) != len(want) {
+		t.Errorf("%q: number of %ss mismatch; expected %d, got %d", path, kind, len(want), len(got))
+	}
+	for addr, name := range want {
+		if got[addr] != name {
+			t.Errorf("%q: %s at %v mismatch; expected %q, got %q", path, kind, addr, name, got[addr])
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package memdump
+
+import (
+	"io/ioutil"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/mewkiz/pkg/jsonutil"
+	"github.com/pkg/errors"
+)
+
+// A ModuleMap describes the layout of a raw process memory dump, which has the
+// following structure, where addresses are hexadecimal strings.
+//
+//    {
+//       "arch": "x86_32",
+//       "entry": "0x401000",
+//       "regions": [
+//          {"addr": "0x400000", "offset": 0, "size": 4096, "perm": "r--"},
+//          {"addr": "0x401000", "offset": 4096, "size": 8192, "perm": "r-x"}
+//       ],
+//       "modules": [
+//          {"name": "foo.exe", "addr": "0x400000", "size": 12288},
+//          {"name": "kernel32.dll", "addr": "0x7C800000", "size": 1024000}
+//       ]
+//    }
+//
+// The entry point is optional, and located from the PE image header of the
+// main module (i.e. the first module) if omitted.
+type ModuleMap struct {
+	// Machine architecture of the process (x86_32 or x86_64).
+	Arch string `json:"arch"`
+	// Entry point of the process; or 0 if unspecified.
+	Entry bin.Address `json:"entry"`
+	// Memory regions of the process memory dump.
+	Regions []*Region `json:"regions"`
+	// Modules loaded into the address space of the process; the first module is
+	// the main executable.
+	Modules []*Module `json:"modules"`
+}
+
+// A Region is a memory region of a raw process memory dump.
+type Region struct {
+	// Start address of the memory region.
+	Addr bin.Address `json:"addr"`
+	// File offset of the memory region contents within the memory dump.
+	Offset int64 `json:"offset"`
+	// Size in bytes of the memory region.
+	Size int `json:"size"`
+	// Access permissions of the memory region in "rwx" notation.
+	Perm string `json:"perm"`
+}
+
+// ParseRaw parses the given raw process memory dump, reading from path, based
+// on the module map read from mapPath.
+func ParseRaw(path, mapPath string) (*bin.File, error) {
+	var mmap ModuleMap
+	if err := jsonutil.ParseFile(mapPath, &mmap); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	file := &bin.File{
+		Entry: mmap.Entry,
+	}
+	if err := file.Arch.Set(mmap.Arch); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, region := range mmap.Regions {
+		end := region.Offset + int64(region.Size)
+		if region.Offset < 0 || end > int64(len(buf)) {
+			return nil, errors.Errorf("memory region at %v out of bounds; offset range [%d, %d) exceeds memory dump size %d", region.Addr, region.Offset, end, len(buf))
+		}
+		sect := &bin.Section{
+			Name:     regionName(region.Addr, mmap.Modules),
+			Addr:     region.Addr,
+			Offset:   uint64(region.Offset),
+			Data:     buf[region.Offset:end],
+			FileSize: region.Size,
+			MemSize:  region.Size,
+			Perm:     parsePerm(region.Perm),
+		}
+		file.Sections = append(file.Sections, sect)
+	}
+	loadModules(file, mmap.Modules)
+	return file, nil
+}
+
+// parsePerm returns the access permissions represented by the given string in
+// "rwx" notation.
+func parsePerm(s string) bin.Perm {
+	var perm bin.Perm
+	if strings.Contains(s, "r") {
+		perm |= bin.PermR
+	}
+	if strings.Contains(s, "w") {
+		perm |= bin.PermW
+	}
+	if strings.Contains(s, "x") {
+		perm |= bin.PermX
+	}
+	return perm
+}
@@ -0,0 +1,206 @@
+# Windows minidump of an x86 process, with the main module hello.exe importing
+# Beep and ExitProcess from kernel32.dll. Assembled with GNU as.
+
+	.set	HELLO_BASE, 0x400000
+	.set	KERNEL32_BASE, 0x7C800000
+
+# pe_header emits the in-memory PE image header of an i386 module, with the
+# given entry point RVA and export and import table RVAs and sizes.
+	.macro	pe_header entry, et_rva, et_size, it_rva, it_size
+	.ascii	"MZ"
+	.fill	0x3A
+	.long	0x40			# e_lfanew
+	.ascii	"PE\0\0"
+	.short	0x14C, 0		# machine and number of sections
+	.long	0, 0, 0
+	.short	0xE0, 0x102		# size of optional header and characteristics
+	# Optional header.
+	.short	0x10B			# PE32
+	.fill	0x0E
+	.long	\entry
+	.fill	0x60 - 0x14
+	# Data directories.
+	.long	\et_rva, \et_size
+	.long	\it_rva, \it_size
+	.fill	14 * 8
+	.endm
+
+	.text
+	.ascii	"MDMP"
+	.long	0xA793			# version
+	.long	5			# number of streams
+	.long	streams			# stream directory RVA
+	.long	0			# checksum
+	.long	0			# time stamp
+	.quad	0			# flags
+
+# --- [ Stream directory ] ----------------------------------------------------
+
+streams:
+	.long	7, sysinfo_end - sysinfo, sysinfo		# SystemInfoStream
+	.long	4, modules_end - modules, modules		# ModuleListStream
+	.long	5, memory_end - memory, memory			# MemoryListStream
+	.long	9, memory64_end - memory64, memory64		# Memory64ListStream
+	.long	16, meminfo_end - meminfo, meminfo		# MemoryInfoListStream
+
+# --- [ System information ] --------------------------------------------------
+
+sysinfo:
+	.short	0			# PROCESSOR_ARCHITECTURE_INTEL
+	.short	6, 0x3A09		# processor level and revision
+	.byte	1, 1			# number of processors and product type
+	.long	6, 1, 7601, 2		# OS version (major, minor, build) and platform
+	.long	0			# service pack string RVA
+	.short	0, 0			# suite mask
+	.fill	24			# CPU information
+sysinfo_end:
+
+# --- [ Modules ] -------------------------------------------------------------
+
+modules:
+	.long	2
+	# hello.exe
+	.quad	HELLO_BASE
+	.long	0x3000			# size of image
+	.long	0, 0			# checksum and time stamp
+	.long	hello_name
+	.fill	52 + 4 * 8		# version information, CodeView and misc records
+	# kernel32.dll
+	.quad	KERNEL32_BASE
+	.long	0x2000
+	.long	0, 0
+	.long	kernel32_name
+	.fill	52 + 4 * 8
+modules_end:
+
+hello_name:
+	.long	18
+	.string16 "hello.exe"
+kernel32_name:
+	.long	24
+	.string16 "kernel32.dll"
+
+# --- [ Memory regions ] ------------------------------------------------------
+
+# Memory regions of hello.exe.
+memory:
+	.long	3
+	.quad	HELLO_BASE
+	.long	hello_hdr_end - hello_hdr, hello_hdr
+	.quad	HELLO_BASE + 0x1000
+	.long	hello_text_end - hello_text, hello_text
+	.quad	HELLO_BASE + 0x2000
+	.long	hello_idata_end - hello_idata, hello_idata
+memory_end:
+
+# Memory regions of kernel32.dll, stored contiguously.
+memory64:
+	.quad	2
+	.long	kernel32_hdr, 0		# base RVA
+	.quad	KERNEL32_BASE, kernel32_hdr_end - kernel32_hdr
+	.quad	KERNEL32_BASE + 0x1000, kernel32_text_end - kernel32_text
+memory64_end:
+
+# Access permissions of memory regions; the code of kernel32.dll has no memory
+# information.
+meminfo:
+	.long	16, 48			# size of header and entries
+	.quad	4
+	.quad	HELLO_BASE, HELLO_BASE
+	.long	0x80, 0			# PAGE_EXECUTE_WRITECOPY
+	.quad	0x1000
+	.long	0x1000, 0x02, 0x1000000, 0	# MEM_COMMIT, PAGE_READONLY, MEM_IMAGE
+	.quad	HELLO_BASE + 0x1000, HELLO_BASE
+	.long	0x80, 0
+	.quad	0x1000
+	.long	0x1000, 0x20, 0x1000000, 0	# PAGE_EXECUTE_READ
+	.quad	HELLO_BASE + 0x2000, HELLO_BASE
+	.long	0x80, 0
+	.quad	0x1000
+	.long	0x1000, 0x04, 0x1000000, 0	# PAGE_READWRITE
+	.quad	KERNEL32_BASE, KERNEL32_BASE
+	.long	0x80, 0
+	.quad	0x1000
+	.long	0x1000, 0x02, 0x1000000, 0	# PAGE_READONLY
+meminfo_end:
+
+# --- [ hello.exe ] -----------------------------------------------------------
+
+hello_hdr:
+	pe_header 0x1000, 0, 0, 0x2000, 40
+hello_hdr_end:
+
+hello_text:
+	call	*(HELLO_BASE + 0x2000 + hello_iat - hello_idata)
+	pushl	$0
+	call	*(HELLO_BASE + 0x2000 + hello_iat + 4 - hello_idata)
+hello_text_end:
+
+	.set	HELLO_IDATA_RVA, 0x2000
+hello_idata:
+	# Import descriptor of kernel32.dll, followed by NULL descriptor.
+	.long	HELLO_IDATA_RVA + hello_int - hello_idata, 0, 0
+	.long	HELLO_IDATA_RVA + hello_dll - hello_idata
+	.long	HELLO_IDATA_RVA + hello_iat - hello_idata
+	.fill	20
+	# Import address table, as resolved by the loader; ExitProcess is not
+	# exported by the kernel32.dll of the dump.
+hello_iat:
+	.long	KERNEL32_BASE + 0x1000	# Beep
+	.long	KERNEL32_BASE + 0x1010	# ExitProcess
+	.long	0
+	# Import name table.
+hello_int:
+	.long	HELLO_IDATA_RVA + hello_beep - hello_idata
+	.long	HELLO_IDATA_RVA + hello_exit - hello_idata
+	.long	0
+hello_dll:
+	.asciz	"kernel32.dll"
+	.balign	2
+hello_beep:
+	.short	0
+	.asciz	"Beep"
+	.balign	2
+hello_exit:
+	.short	0
+	.asciz	"ExitProcess"
+hello_idata_end:
+
+# --- [ kernel32.dll ] --------------------------------------------------------
+
+	.set	KERNEL32_ET_RVA, kernel32_edata - kernel32_hdr
+	.set	KERNEL32_ET_SIZE, kernel32_edata_end - kernel32_edata
+kernel32_hdr:
+	pe_header 0, KERNEL32_ET_RVA, KERNEL32_ET_SIZE, 0, 0
+	# Export directory.
+kernel32_edata:
+	.long	0, 0, 0
+	.long	kernel32_dll - kernel32_hdr	# name RVA
+	.long	1			# ordinal base
+	.long	2, 2			# number of functions and names
+	.long	kernel32_funcs - kernel32_hdr
+	.long	kernel32_names - kernel32_hdr
+	.long	kernel32_ords - kernel32_hdr
+kernel32_funcs:
+	.long	0x1000, 0x1008
+kernel32_names:
+	.long	kernel32_beep - kernel32_hdr
+	.long	kernel32_sleep - kernel32_hdr
+kernel32_ords:
+	.short	0, 1
+kernel32_dll:
+	.asciz	"KERNEL32.dll"
+kernel32_beep:
+	.asciz	"Beep"
+kernel32_sleep:
+	.asciz	"Sleep"
+kernel32_edata_end:
+kernel32_hdr_end:
+
+kernel32_text:
+	# Beep
+	movl	$1, %eax
+	ret	$8
+	# Sleep
+	ret	$4
+kernel32_text_end:
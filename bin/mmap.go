@@ -0,0 +1,136 @@
+package bin
+
+import (
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// A Mapping is a memory-mapped file. The contents of the file are loaded
+// lazily by the operating system on first access, which enables analysis of
+// very large binary executables (e.g. firmware images) without reading them
+// into memory in full.
+//
+// The mapping is private; writes to the mapped memory (e.g. by File.Patch) are
+// not carried through to the underlying file.
+type Mapping struct {
+	// Mapped file contents.
+	data []byte
+	// Unmaps the file contents; or nil if not memory-mapped (e.g. on platforms
+	// lacking support for memory-mapped files).
+	unmap func() error
+}
+
+// Map maps the given file into memory.
+func Map(path string) (*Mapping, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	size := fi.Size()
+	if int64(int(size)) != size {
+		return nil, errors.Errorf("unable to map %q; file size (%d bytes) exceeds address space", path, size)
+	}
+	if size == 0 {
+		return &Mapping{}, nil
+	}
+	m, err := mmap(f, int(size))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to map %q", path)
+	}
+	return m, nil
+}
+
+// Bytes returns the contents of the mapped file.
+func (m *Mapping) Bytes() []byte {
+	return m.data
+}
+
+// Len returns the length in bytes of the mapped file.
+func (m *Mapping) Len() int {
+	return len(m.data)
+}
+
+// ReadAt implements the io.ReaderAt interface.
+func (m *Mapping) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.Errorf("invalid offset %d; negative offset", off)
+	}
+	if off >= int64(len(m.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, m.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Close unmaps the file. Section data referring to the mapped file must not be
+// accessed after the mapping is closed.
+func (m *Mapping) Close() error {
+	if m.unmap == nil {
+		return nil
+	}
+	unmap := m.unmap
+	m.data, m.unmap = nil, nil
+	if err := unmap(); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// ParseMapped parses the given binary executable using parse, reading from the
+// memory-mapped file at path. Section data of the returned file may refer to
+// the mapped file, which is unmapped by File.Close, or if parsing fails.
+func ParseMapped(path string, parse func(m *Mapping) (*File, error)) (*File, error) {
+	m, err := Map(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	file, err := parse(m)
+	if err != nil {
+		m.Close()
+		return nil, errors.WithStack(err)
+	}
+	file.mapping = m
+	return file, nil
+}
+
+// Close unmaps the memory-mapped file of the binary executable, as parsed by
+// ParseMapped. Section data must not be accessed after the file is closed.
+func (file *File) Close() error {
+	if file.mapping == nil {
+		return nil
+	}
+	m := file.mapping
+	file.mapping = nil
+	return m.Close()
+}
+
+// ReadData returns n bytes at the given offset of r. If r is a memory-mapped
+// file, the returned data refers to the mapped memory rather than being
+// copied, and is loaded lazily on first access.
+func ReadData(r io.ReaderAt, off int64, n int) ([]byte, error) {
+	if n < 0 || off < 0 {
+		return nil, errors.Errorf("invalid data range at offset %d of length %d", off, n)
+	}
+	if m, ok := r.(*Mapping); ok {
+		if off+int64(n) > int64(len(m.data)) {
+			return nil, errors.Errorf("unable to read %d bytes at offset %d; exceeds file end by %d bytes", n, off, off+int64(n)-int64(len(m.data)))
+		}
+		// Limit capacity to prevent appends from overwriting succeeding data.
+		return m.data[off : off+int64(n) : off+int64(n)], nil
+	}
+	buf := make([]byte, n)
+	if _, err := r.ReadAt(buf, off); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return buf, nil
+}
@@ -0,0 +1,20 @@
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package bin
+
+import (
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// mmap reads the first size bytes of the given file into memory, on platforms
+// lacking support for memory-mapped files.
+func mmap(f *os.File, size int) (*Mapping, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(f, data); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &Mapping{data: data}, nil
+}
@@ -0,0 +1,52 @@
+package bin_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+func TestParseMapped(t *testing.T) {
+	t.Parallel()
+	f, err := ioutil.TempFile("", "mmap_test_")
+	if err != nil {
+		t.Fatalf("unable to create temporary file; %+v", err)
+	}
+	defer os.Remove(f.Name())
+	want := []byte{0x55, 0x8B, 0xEC, 0xC3}
+	if _, err := f.Write(want); err != nil {
+		t.Fatalf("unable to write temporary file; %+v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("unable to close temporary file; %+v", err)
+	}
+	parse := func(m *bin.Mapping) (*bin.File, error) {
+		sect := &bin.Section{Addr: 0x401000, Data: m.Bytes(), MemSize: len(m.Bytes()), Perm: bin.PermR | bin.PermX}
+		return &bin.File{Sections: []*bin.Section{sect}}, nil
+	}
+	file, err := bin.ParseMapped(f.Name(), parse)
+	if err != nil {
+		t.Fatalf("unable to parse file; %+v", err)
+	}
+	if got := file.Sections[0].Data; !bytes.Equal(got, want) {
+		t.Errorf("section data mismatch; expected % X, got % X", want, got)
+	}
+	if err := file.Close(); err != nil {
+		t.Errorf("unable to close file; %+v", err)
+	}
+	// Closing twice is a no-op.
+	if err := file.Close(); err != nil {
+		t.Errorf("unable to close file twice; %+v", err)
+	}
+	// Parse errors are reported.
+	invalid := func(m *bin.Mapping) (*bin.File, error) {
+		return nil, errors.New("invalid file")
+	}
+	if _, err := bin.ParseMapped(f.Name(), invalid); err == nil {
+		t.Errorf("expected parse error, got nil")
+	}
+}
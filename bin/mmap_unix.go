@@ -0,0 +1,26 @@
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package bin
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// mmap maps the first size bytes of the given file into memory. The mapping is
+// private and copy-on-write, to allow in-memory patching of section data.
+func mmap(f *os.File, size int) (*Mapping, error) {
+	data, err := syscall.Mmap(int(f.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	m := &Mapping{
+		data: data,
+		unmap: func() error {
+			return syscall.Munmap(data)
+		},
+	}
+	return m, nil
+}
@@ -0,0 +1,35 @@
+package bin
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// MZHeaderOffset returns the file offset of the new executable header of the
+// given MZ executable, as specified by the e_lfanew field of the DOS header.
+func MZHeaderOffset(r io.ReaderAt) (int64, error) {
+	// Offset of e_lfanew in DOS header.
+	const lfanewOffset = 0x3C
+	var buf [4]byte
+	if _, err := r.ReadAt(buf[:], lfanewOffset); err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return int64(binary.LittleEndian.Uint32(buf[:])), nil
+}
+
+// MZSignature returns the signature of length n of the new executable header
+// (e.g. "PE\x00\x00", "NE", "LE" or "LX") of the given MZ executable. The
+// boolean return value indicates success.
+func MZSignature(r io.ReaderAt, n int) ([]byte, bool) {
+	off, err := MZHeaderOffset(r)
+	if err != nil {
+		return nil, false
+	}
+	sig := make([]byte, n)
+	if _, err := r.ReadAt(sig, off); err != nil {
+		return nil, false
+	}
+	return sig, true
+}
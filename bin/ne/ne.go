@@ -0,0 +1,576 @@
+// Package ne provides access to NE (New Executable) files, as used by 16-bit
+// Windows and OS/2.
+package ne
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/mewkiz/pkg/term"
+	"github.com/pkg/errors"
+)
+
+var (
+	// dbg is a logger which logs debug messages with "ne:" prefix to standard
+	// error.
+	dbg = log.New(os.Stderr, term.MagentaBold("ne:")+" ", 0)
+)
+
+// Register NE format.
+func init() {
+	// New Executable (NE) format.
+	//
+	//    4D 5A  |MZ|
+	const magic = "MZ"
+	bin.RegisterFormatIdent("ne", magic, isNE, Parse)
+}
+
+// isNE reports whether the given MZ executable is an NE executable.
+func isNE(r io.ReaderAt) bool {
+	sig, ok := bin.MZSignature(r, 2)
+	return ok && string(sig) == "NE"
+}
+
+// Segmented address translation.
+//
+// Each segment is mapped to a distinct 64 KB region of the linear address
+// space, based on its 1-based segment number; i.e. the far address seg:off is
+// located at the linear address seg*0x10000 + off. The segment selectors of
+// relocated far pointers hold segment numbers.
+//
+// Imported functions are mapped to an artificial segment following the last
+// segment of the executable.
+
+// Addr returns the linear address of the given far address.
+func Addr(seg, off uint16) bin.Address {
+	as := &bin.AddressSpace{Segments: bin.SegmentNumbered}
+	return as.Linear(bin.FarAddr{Seg: seg, Off: off})
+}
+
+// FarAddr returns the far address of the given linear address.
+func FarAddr(addr bin.Address) (seg, off uint16) {
+	as := &bin.AddressSpace{Segments: bin.SegmentNumbered}
+	a, _ := as.Far(addr, uint16(addr>>16))
+	return a.Seg, a.Off
+}
+
+// ParseFile parses the given NE binary executable, reading from path.
+func ParseFile(path string) (*bin.File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse parses the given NE binary executable, reading from r.
+//
+// Users are responsible for closing r.
+func Parse(r io.ReaderAt) (*bin.File, error) {
+	// Parse NE header.
+	neOff, err := bin.MZHeaderOffset(r)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var hdr header
+	if err := read(r, neOff, &hdr); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if string(hdr.Magic[:]) != "NE" {
+		return nil, errors.Errorf("invalid NE signature; expected %q, got %q", "NE", hdr.Magic[:])
+	}
+	// Segment offsets are 16-bit sector numbers; shifted file offsets are 32-bit.
+	if hdr.AlignShift > 16 {
+		return nil, errors.Errorf("invalid alignment shift count %d; expected at most 16", hdr.AlignShift)
+	}
+	file := &bin.File{
+		Arch:     bin.ArchX86_16,
+		Segments: bin.SegmentNumbered,
+		Imports:  make(map[bin.Address]string),
+		Exports:  make(map[bin.Address]string),
+	}
+	if hdr.CS != 0 {
+		file.Entry = Addr(hdr.CS, hdr.IP)
+	}
+
+	// Parse segments.
+	p := &parser{
+		r:       r,
+		neOff:   neOff,
+		hdr:     hdr,
+		file:    file,
+		imports: make(map[string]uint16),
+	}
+	if err := p.parseSegments(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Parse entry table and exports.
+	if err := p.parseEntries(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := p.parseNames(neOff+int64(hdr.ResNamesOff), -1); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if hdr.NonresNamesSize != 0 {
+		if err := p.parseNames(int64(hdr.NonresNamesOff), int64(hdr.NonresNamesSize)); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	// Apply relocations.
+	for i := range p.segs {
+		if err := p.relocate(i); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	// Add artificial segment of imported functions.
+	if p.nimports > 0 {
+		size := int(p.nimports) * importSlotSize
+		sect := &bin.Section{
+			Name:    "imports",
+			Addr:    Addr(uint16(len(p.segs)+1), 0),
+			Data:    make([]byte, size),
+			MemSize: size,
+			Perm:    bin.PermR,
+		}
+		file.Sections = append(file.Sections, sect)
+	}
+	return file, nil
+}
+
+// ref: http://www.fileformat.info/format/exe/corion-ne.htm
+
+// header is the NE header.
+type header struct {
+	// Signature ("NE").
+	Magic [2]byte
+	// Linker version and revision.
+	LinkerVer uint8
+	LinkerRev uint8
+	// Offset of entry table, relative to the NE header.
+	EntryTableOff uint16
+	// Size in bytes of entry table.
+	EntryTableSize uint16
+	// Checksum of file.
+	CRC uint32
+	// Module flags.
+	Flags uint16
+	// Segment number of automatic data segment.
+	AutoDataSeg uint16
+	// Initial size of local heap and stack.
+	HeapSize  uint16
+	StackSize uint16
+	// Entry point (CS:IP); CS holds a segment number.
+	IP uint16
+	CS uint16
+	// Initial stack pointer (SS:SP); SS holds a segment number.
+	SP uint16
+	SS uint16
+	// Number of entries in segment table.
+	NSegs uint16
+	// Number of entries in module reference table.
+	NModRefs uint16
+	// Size in bytes of non-resident names table.
+	NonresNamesSize uint16
+	// Offsets of tables, relative to the NE header.
+	SegTableOff    uint16
+	ResTableOff    uint16
+	ResNamesOff    uint16
+	ModRefTableOff uint16
+	ImpNamesOff    uint16
+	// File offset of non-resident names table.
+	NonresNamesOff uint32
+	// Number of movable entries.
+	NMovableEntries uint16
+	// Logical sector alignment shift count.
+	AlignShift uint16
+	// Number of resource segments.
+	NResSegs uint16
+	// Target operating system.
+	TargetOS uint8
+}
+
+// segEntry is an entry of the segment table.
+type segEntry struct {
+	// Logical sector offset of segment contents; or 0 if no contents.
+	Offset uint16
+	// Size in bytes of segment contents in file; 0 represents 64 KB.
+	Size uint16
+	// Segment flags.
+	Flags uint16
+	// Minimum allocation size in bytes of segment; 0 represents 64 KB.
+	MinAlloc uint16
+}
+
+// Segment flags.
+const (
+	// Data segment (code segment if not set).
+	segData = 0x0001
+	// Read-only data segment or execute-only code segment.
+	segReadOnly = 0x0080
+	// Segment has relocations.
+	segReloc = 0x0100
+	// Segment may be discarded from memory.
+	segDiscardable = 0x1000
+)
+
+// Size in bytes of slots of imported functions in the artificial import
+// segment.
+const importSlotSize = 4
+
+// parser tracks the state of the NE parser.
+type parser struct {
+	// Input reader.
+	r io.ReaderAt
+	// File offset of NE header.
+	neOff int64
+	// NE header.
+	hdr header
+	// Output file.
+	file *bin.File
+	// Segment table entries.
+	segs []segEntry
+	// Segment sections, indexed by segment number - 1.
+	sects []*bin.Section
+	// Map from entry ordinal to far address of entry point.
+	entries map[uint16][2]uint16
+	// Map from import name to offset within import segment.
+	imports map[string]uint16
+	// Number of imported functions.
+	nimports uint16
+}
+
+// parseSegments parses the segment table and segment contents.
+func (p *parser) parseSegments() error {
+	for i := 0; i < int(p.hdr.NSegs); i++ {
+		var entry segEntry
+		if err := read(p.r, p.neOff+int64(p.hdr.SegTableOff)+int64(i)*8, &entry); err != nil {
+			return errors.WithStack(err)
+		}
+		p.segs = append(p.segs, entry)
+		segNum := uint16(i + 1)
+		fileOff := int64(entry.Offset) << p.hdr.AlignShift
+		fileSize := 0
+		if entry.Offset != 0 {
+			fileSize = int(entry.Size)
+			if fileSize == 0 {
+				fileSize = 0x10000
+			}
+		}
+		memSize := int(entry.MinAlloc)
+		if memSize == 0 {
+			memSize = 0x10000
+		}
+		if memSize < fileSize {
+			memSize = fileSize
+		}
+		data := make([]byte, fileSize)
+		if _, err := p.r.ReadAt(data, fileOff); err != nil {
+			return errors.WithStack(err)
+		}
+		perm := bin.PermR
+		name := fmt.Sprintf("code%d", segNum)
+		if entry.Flags&segData != 0 {
+			name = fmt.Sprintf("data%d", segNum)
+			if entry.Flags&segReadOnly == 0 {
+				perm |= bin.PermW
+			}
+		} else {
+			perm |= bin.PermX
+		}
+		dbg.Printf("segment %d at file offset 0x%X (%d bytes in file, %d bytes in memory)", segNum, fileOff, fileSize, memSize)
+		sect := &bin.Section{
+			Name:     name,
+			Addr:     Addr(segNum, 0),
+			Offset:   uint64(fileOff),
+			Data:     data,
+			FileSize: fileSize,
+			MemSize:  memSize,
+			Perm:     perm,
+		}
+		if entry.Flags&segDiscardable != 0 {
+			sect.Flags |= bin.SectDiscardable
+		}
+		p.sects = append(p.sects, sect)
+		p.file.Sections = append(p.file.Sections, sect)
+	}
+	return nil
+}
+
+// parseEntries parses the entry table.
+func (p *parser) parseEntries() error {
+	p.entries = make(map[uint16][2]uint16)
+	buf := make([]byte, p.hdr.EntryTableSize)
+	if _, err := p.r.ReadAt(buf, p.neOff+int64(p.hdr.EntryTableOff)); err != nil {
+		return errors.WithStack(err)
+	}
+	ordinal := uint16(1)
+	for len(buf) >= 2 {
+		count, typ := buf[0], buf[1]
+		buf = buf[2:]
+		if count == 0 {
+			break
+		}
+		for i := 0; i < int(count); i++ {
+			switch typ {
+			case 0x00:
+				// unused entries.
+			case 0xFF:
+				// movable segment; flags, int 3Fh, segment number and offset.
+				if len(buf) < 6 {
+					return errors.Errorf("invalid movable entry of ordinal %d; truncated entry table", ordinal)
+				}
+				p.entries[ordinal] = [2]uint16{uint16(buf[3]), binary.LittleEndian.Uint16(buf[4:])}
+				buf = buf[6:]
+			case 0xFE:
+				// constant entry; flags and value.
+				if len(buf) < 3 {
+					return errors.Errorf("invalid constant entry of ordinal %d; truncated entry table", ordinal)
+				}
+				buf = buf[3:]
+			default:
+				// fixed segment; flags and offset.
+				if len(buf) < 3 {
+					return errors.Errorf("invalid fixed entry of ordinal %d; truncated entry table", ordinal)
+				}
+				p.entries[ordinal] = [2]uint16{uint16(typ), binary.LittleEndian.Uint16(buf[1:])}
+				buf = buf[3:]
+			}
+			ordinal++
+		}
+	}
+	return nil
+}
+
+// parseNames parses the resident or non-resident names table located at the
+// given file offset, and records exports of the named entries. A negative size
+// indicates that the names table is terminated by a zero-length name.
+func (p *parser) parseNames(off, size int64) error {
+	end := off + size
+	for first := true; size < 0 || off < end; first = false {
+		name, err := readPascalString(p.r, off)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if len(name) == 0 {
+			break
+		}
+		var buf [2]byte
+		if _, err := p.r.ReadAt(buf[:], off+1+int64(len(name))); err != nil {
+			return errors.WithStack(err)
+		}
+		off += 1 + int64(len(name)) + 2
+		if first {
+			// module name or description.
+			continue
+		}
+		ordinal := binary.LittleEndian.Uint16(buf[:])
+		if entry, ok := p.entries[ordinal]; ok {
+			addr := Addr(entry[0], entry[1])
+			dbg.Printf("export %q (ordinal %d) at %v", name, ordinal, addr)
+			p.file.Exports[addr] = name
+		}
+	}
+	return nil
+}
+
+// Relocation source types.
+const (
+	// Low byte of offset.
+	relocLoByte = 0
+	// 16-bit segment selector.
+	relocSegment = 2
+	// 32-bit far pointer (offset followed by segment selector).
+	relocFarAddr = 3
+	// 16-bit offset.
+	relocOffset = 5
+)
+
+// Relocation target types.
+const (
+	// Internal reference.
+	relocInternal = 0
+	// Imported ordinal.
+	relocImportOrdinal = 1
+	// Imported name.
+	relocImportName = 2
+	// Operating system fixup (e.g. floating-point emulation).
+	relocOSFixup = 3
+	// Additive relocation.
+	relocAdditive = 0x4
+)
+
+// relocate applies the relocations of the given segment.
+func (p *parser) relocate(i int) error {
+	entry, sect := p.segs[i], p.sects[i]
+	if entry.Flags&segReloc == 0 || entry.Offset == 0 {
+		return nil
+	}
+	off := int64(sect.Offset) + int64(sect.FileSize)
+	var n uint16
+	if err := read(p.r, off, &n); err != nil {
+		return errors.WithStack(err)
+	}
+	off += 2
+	for j := 0; j < int(n); j++ {
+		var rec [8]byte
+		if _, err := p.r.ReadAt(rec[:], off+int64(j)*8); err != nil {
+			return errors.WithStack(err)
+		}
+		srcType, flags := rec[0], rec[1]
+		srcOff := binary.LittleEndian.Uint16(rec[2:])
+		var seg, targetOff uint16
+		switch flags & 0x3 {
+		case relocInternal:
+			if rec[4] == 0xFF {
+				// movable segment; entry ordinal.
+				ordinal := binary.LittleEndian.Uint16(rec[6:])
+				e, ok := p.entries[ordinal]
+				if !ok {
+					return errors.Errorf("unable to locate entry ordinal %d referenced from relocation of segment %d", ordinal, i+1)
+				}
+				seg, targetOff = e[0], e[1]
+			} else {
+				seg, targetOff = uint16(rec[4]), binary.LittleEndian.Uint16(rec[6:])
+			}
+		case relocImportOrdinal, relocImportName:
+			name, err := p.importName(flags&0x3, binary.LittleEndian.Uint16(rec[4:]), binary.LittleEndian.Uint16(rec[6:]))
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			seg, targetOff = p.importAddr(name)
+		case relocOSFixup:
+			// Floating-point emulation fixups are left as is.
+			continue
+		}
+		if err := p.apply(sect, srcType, srcOff, flags&relocAdditive != 0, seg, targetOff); err != nil {
+			return errors.Wrapf(err, "unable to apply relocation %d of segment %d", j, i+1)
+		}
+	}
+	return nil
+}
+
+// apply applies the relocation of the given source type at the specified
+// offset of the segment, targeting the far address seg:off. Non-additive
+// relocations are applied to each location of the relocation chain.
+func (p *parser) apply(sect *bin.Section, srcType uint8, srcOff uint16, additive bool, seg, off uint16) error {
+	data := sect.Data
+	for visited := make(map[uint16]bool); !visited[srcOff]; {
+		visited[srcOff] = true
+		size := 2
+		switch srcType {
+		case relocLoByte:
+			size = 1
+		case relocFarAddr:
+			size = 4
+		}
+		if int(srcOff)+size > len(data) {
+			return errors.Errorf("relocation source offset 0x%04X out of bounds", srcOff)
+		}
+		// Offset of next location in relocation chain.
+		next := uint16(0xFFFF)
+		if srcType != relocLoByte {
+			next = binary.LittleEndian.Uint16(data[srcOff:])
+		}
+		switch srcType {
+		case relocLoByte:
+			if additive {
+				data[srcOff] += byte(off)
+			} else {
+				data[srcOff] = byte(off)
+			}
+		case relocSegment:
+			putUint16(data[srcOff:], seg, additive)
+		case relocFarAddr:
+			putUint16(data[srcOff:], off, additive)
+			putUint16(data[srcOff+2:], seg, false)
+		case relocOffset:
+			putUint16(data[srcOff:], off, additive)
+		default:
+			return errors.Errorf("support for relocation source type %d not yet implemented", srcType)
+		}
+		if additive || next == 0xFFFF {
+			break
+		}
+		srcOff = next
+	}
+	return nil
+}
+
+// importName returns the name of the imported function referenced by the given
+// relocation target.
+func (p *parser) importName(typ uint8, modIndex, x uint16) (string, error) {
+	if modIndex == 0 || modIndex > p.hdr.NModRefs {
+		return "", errors.Errorf("invalid module reference index %d", modIndex)
+	}
+	var nameOff uint16
+	if err := read(p.r, p.neOff+int64(p.hdr.ModRefTableOff)+int64(modIndex-1)*2, &nameOff); err != nil {
+		return "", errors.WithStack(err)
+	}
+	impNamesOff := p.neOff + int64(p.hdr.ImpNamesOff)
+	modName, err := readPascalString(p.r, impNamesOff+int64(nameOff))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	if typ == relocImportOrdinal {
+		return fmt.Sprintf("%s_ordinal_%d", strings.ToLower(modName), x), nil
+	}
+	return readPascalString(p.r, impNamesOff+int64(x))
+}
+
+// importAddr returns the far address of the slot of the given imported function
+// in the artificial import segment.
+func (p *parser) importAddr(name string) (seg, off uint16) {
+	seg = uint16(len(p.segs) + 1)
+	if off, ok := p.imports[name]; ok {
+		return seg, off
+	}
+	off = p.nimports * importSlotSize
+	p.imports[name] = off
+	p.nimports++
+	addr := Addr(seg, off)
+	dbg.Printf("import %q at %v", name, addr)
+	p.file.Imports[addr] = name
+	return seg, off
+}
+
+// ### [ Helper functions ] ####################################################
+
+// read reads structured binary data from r at the given file offset into v.
+func read(r io.ReaderAt, off int64, v interface{}) error {
+	sr := io.NewSectionReader(r, off, int64(binary.Size(v)))
+	if err := binary.Read(sr, binary.LittleEndian, v); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// readPascalString reads a length-prefixed string from r at the given file
+// offset.
+func readPascalString(r io.ReaderAt, off int64) (string, error) {
+	var n [1]byte
+	if _, err := r.ReadAt(n[:], off); err != nil {
+		return "", errors.WithStack(err)
+	}
+	buf := make([]byte, n[0])
+	if _, err := r.ReadAt(buf, off+1); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return string(buf), nil
+}
+
+// putUint16 stores v to buf; or adds v to the value stored in buf if additive.
+func putUint16(buf []byte, v uint16, additive bool) {
+	if additive {
+		v += binary.LittleEndian.Uint16(buf)
+	}
+	binary.LittleEndian.PutUint16(buf, v)
+}
@@ -0,0 +1,131 @@
+package ne_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/bin/ne"
+)
+
+func TestParse(t *testing.T) {
+	type section struct {
+		name    string
+		addr    bin.Address
+		data    []byte
+		memSize int
+		perm    bin.Perm
+	}
+	golden := []struct {
+		path     string
+		entry    bin.Address
+		sections []section
+		exports  map[bin.Address]string
+		imports  map[bin.Address]string
+	}{
+		{
+			path:  "testdata/hello.exe",
+			entry: ne.Addr(1, 0),
+			sections: []section{
+				// Relocated far call to imported function (segment 3, offset 0).
+				{name: "code1", addr: ne.Addr(1, 0), data: []byte{0x9A, 0x00, 0x00, 0x03, 0x00, 0xCB}, memSize: 0x10000, perm: bin.PermR | bin.PermX},
+				{name: "data2", addr: ne.Addr(2, 0), data: []byte("DATA"), memSize: 0x100, perm: bin.PermR | bin.PermW},
+				{name: "imports", addr: ne.Addr(3, 0), data: make([]byte, 4), memSize: 4, perm: bin.PermR},
+			},
+			exports: map[bin.Address]string{ne.Addr(1, 0): "MAIN"},
+			imports: map[bin.Address]string{ne.Addr(3, 0): "BEEP"},
+		},
+	}
+	for _, g := range golden {
+		file, err := ne.ParseFile(g.path)
+		if err != nil {
+			t.Errorf("%q: unable to parse NE executable; %+v", g.path, err)
+			continue
+		}
+		if file.Arch != bin.ArchX86_16 {
+			t.Errorf("%q: machine architecture mismatch; expected %v, got %v", g.path, bin.ArchX86_16, file.Arch)
+		}
+		if file.Entry != g.entry {
+			t.Errorf("%q: entry point mismatch; expected %v, got %v", g.path, g.entry, file.Entry)
+		}
+		if len(file.Sections) != len(g.sections) {
+			t.Errorf("%q: number of sections mismatch; expected %d, got %d", g.path, len(g.sections), len(file.Sections))
+			continue
+		}
+		for i, want := range g.sections {
+			sect := file.Sections[i]
+			if sect.Name != want.name {
+				t.Errorf("%q: section %d: name mismatch; expected %q, got %q", g.path, i, want.name, sect.Name)
+			}
+			if sect.Addr != want.addr {
+				t.Errorf("%q: section %q: address mismatch; expected %v, got %v", g.path, want.name, want.addr, sect.Addr)
+			}
+			if !bytes.Equal(sect.Data, want.data) {
+				t.Errorf("%q: section %q: contents mismatch; expected % X, got % X", g.path, want.name, want.data, sect.Data)
+			}
+			if sect.MemSize != want.memSize {
+				t.Errorf("%q: section %q: memory size mismatch; expected %d, got %d", g.path, want.name, want.memSize, sect.MemSize)
+			}
+			if sect.Perm != want.perm {
+				t.Errorf("%q: section %q: permissions mismatch; expected %v, got %v", g.path, want.name, want.perm, sect.Perm)
+			}
+		}
+		for addr, name := range g.exports {
+			if got := file.Exports[addr]; got != name {
+				t.Errorf("%q: export at %v mismatch; expected %q, got %q", g.path, addr, name, got)
+			}
+		}
+		for addr, name := range g.imports {
+			if got := file.Imports[addr]; got != name {
+				t.Errorf("%q: import at %v mismatch; expected %q, got %q", g.path, addr, name, got)
+			}
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	golden := []struct {
+		path string
+		desc string
+	}{
+		{path: "testdata/invalid/neoff.exe", desc: "offset of NE header outside of file"},
+		{path: "testdata/invalid/segments.exe", desc: "number of segments exceeding file"},
+		{path: "testdata/invalid/segoff.exe", desc: "contents of code segment outside of file"},
+		{path: "testdata/invalid/segsize.exe", desc: "size of code segment exceeding file"},
+		{path: "testdata/invalid/align.exe", desc: "alignment shift count exceeding 32-bit file offsets"},
+		{path: "testdata/invalid/entries.exe", desc: "size of entry table exceeding file"},
+		{path: "testdata/invalid/impnames.exe", desc: "imported names outside of file"},
+		{path: "testdata/invalid/relocs.exe", desc: "number of relocations exceeding file"},
+	}
+	for _, g := range golden {
+		if _, err := ne.ParseFile(g.path); err == nil {
+			t.Errorf("%q: %s; expected error, got nil", g.path, g.desc)
+		}
+	}
+}
+
+func TestParseTruncated(t *testing.T) {
+	buf, err := ioutil.ReadFile("testdata/hello.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := []struct {
+		size int
+		desc string
+	}{
+		{size: 0x20, desc: "MZ header"},
+		{size: 0x50, desc: "NE header"},
+		{size: 0x88, desc: "segment table"},
+		{size: 0x98, desc: "resident names"},
+		{size: 0xB4, desc: "entry table"},
+		{size: 0x103, desc: "code segment"},
+		{size: 0x10C, desc: "relocations of code segment"},
+		{size: 0x122, desc: "data segment"},
+	}
+	for _, g := range golden {
+		if _, err := ne.Parse(bytes.NewReader(buf[:g.size])); err == nil {
+			t.Errorf("truncated within %s (0x%X bytes); expected error, got nil", g.desc, g.size)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+# NE executable (16-bit Windows) with a code segment calling an imported
+# function (KERNEL.BEEP) through a relocated far pointer, and a data segment.
+
+	.text
+mz:
+	.ascii	"MZ"
+	.org	0x3C
+	.long	ne - mz                # offset of NE header
+
+	.org	0x40
+ne:
+	.ascii	"NE"
+	.byte	5, 10                  # linker version
+	.word	entries - ne           # entry table
+	.word	entries_end - entries
+	.long	0                      # CRC
+	.word	0                      # flags
+	.word	0                      # automatic data segment
+	.word	0, 0                   # heap and stack size
+	.word	0, 1                   # CS:IP
+	.word	0, 0                   # SS:SP
+	.word	2                      # segments
+	.word	1                      # module references
+	.word	0                      # size of non-resident names
+	.word	segs - ne              # segment table
+	.word	resnames - ne          # resource table
+	.word	resnames - ne          # resident names
+	.word	modrefs - ne           # module reference table
+	.word	impnames - ne          # imported names
+	.long	0                      # non-resident names
+	.word	0                      # movable entry points
+	.word	4                      # alignment shift
+	.word	0                      # resource segments
+	.byte	2                      # target OS (Windows)
+
+	.org	ne + 0x40
+segs:
+	# Code segment with relocations.
+	.word	(code - mz) >> 4, code_end - code, 0x0100, 0
+	# Data segment.
+	.word	(data - mz) >> 4, data_end - data, 0x0001, 0x100
+resnames:
+	# Module name and exported entry point.
+	.byte	4
+	.ascii	"TEST"
+	.word	0
+	.byte	4
+	.ascii	"MAIN"
+	.word	1
+	.byte	0
+	.org	ne + 0x60
+modrefs:
+	.word	kernel - impnames
+impnames:
+	.byte	0
+kernel:
+	.byte	6
+	.ascii	"KERNEL"
+beep:
+	.byte	4
+	.ascii	"BEEP"
+	.org	ne + 0x70
+entries:
+	# Bundle of one fixed entry in segment 1 at offset 0.
+	.byte	1, 1
+	.byte	0x01
+	.word	0
+	.byte	0, 0
+entries_end:
+
+	.org	0x100
+	.code16
+code:
+	lcall	$0, $0xFFFF            # far call to KERNEL.BEEP
+	lret
+code_end:
+	# Relocations; far pointer at offset 1 to imported name.
+	.word	1
+	.byte	3, 2
+	.word	1, 1, beep - impnames
+
+	.org	0x120
+data:
+	.ascii	"DATA"
+data_end:
+	.org	0x130
@@ -0,0 +1,91 @@
+package pe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/decomp/exp/bin"
+	"github.com/mewkiz/pkg/pathutil"
+	"github.com/pkg/errors"
+)
+
+// exportDir is an export directory.
+type exportDir struct {
+	// Export flags; reserved.
+	Characteristics uint32
+	// Time stamp.
+	Date uint32
+	// Major version number.
+	MajorVersion uint16
+	// Minor version number.
+	MinorVersion uint16
+	// DLL name RVA.
+	DLLNameRVA uint32
+	// Starting ordinal number of the export address table.
+	OrdinalBase uint32
+	// Number of entries in the export address table.
+	NFuncs uint32
+	// Number of entries in the name pointer and ordinal tables.
+	NNames uint32
+	// Export address table RVA.
+	FuncsRVA uint32
+	// Name pointer table RVA.
+	NamesRVA uint32
+	// Ordinal table RVA.
+	OrdinalsRVA uint32
+}
+
+// parseExports parses the export table of the given PE binary executable,
+// recording function exports in file.Exports. Exports without names are named
+// DLL_ordinal_N, matching the names of imports by ordinal. Forwarded exports
+// (i.e. exports implemented by other DLLs) are ignored.
+func parseExports(file *bin.File, etRVA, etSize uint64) error {
+	as := file.AddressSpace()
+	etAddr := as.VA(etRVA)
+	var dir exportDir
+	if err := binary.Read(bytes.NewReader(file.Data(etAddr)), binary.LittleEndian, &dir); err != nil {
+		return errors.WithStack(err)
+	}
+	dllName := parseString(file.Data(as.VA(uint64(dir.DLLNameRVA))))
+	dbg.Println("export dll name:", dllName)
+	// Names of exports, indexed by export address table index.
+	names := make(map[uint32]string)
+	for i := uint32(0); i < dir.NNames; i++ {
+		nameRVA, err := file.ReadUint32(as.VA(uint64(dir.NamesRVA) + uint64(4*i)))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		ordinal, err := file.ReadUint16(as.VA(uint64(dir.OrdinalsRVA) + uint64(2*i)))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		index := uint32(ordinal)
+		if index >= dir.NFuncs {
+			return errors.Errorf("invalid ordinal table index %d of export %d; exceeds number of exported functions (%d)", index, i, dir.NFuncs)
+		}
+		names[index] = parseString(file.Data(as.VA(uint64(nameRVA))))
+	}
+	for index := uint32(0); index < dir.NFuncs; index++ {
+		funcRVA, err := file.ReadUint32(as.VA(uint64(dir.FuncsRVA) + uint64(4*index)))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if funcRVA == 0 {
+			// unused entry.
+			continue
+		}
+		if etRVA <= uint64(funcRVA) && uint64(funcRVA) < etRVA+etSize {
+			// forwarded export.
+			continue
+		}
+		name, ok := names[index]
+		if !ok {
+			name = fmt.Sprintf("%s_ordinal_%d", pathutil.TrimExt(dllName), dir.OrdinalBase+index)
+		}
+		addr := as.VA(uint64(funcRVA))
+		dbg.Printf("export at %v: %v", addr, name)
+		file.Exports[addr] = name
+	}
+	return nil
+}
@@ -31,17 +31,25 @@ func init() {
 	//
 	//    4D 5A  |MZ|
 	const magic = "MZ"
-	bin.RegisterFormat("pe", magic, Parse)
+	bin.RegisterFormatIdent("pe", magic, isPE, Parse)
 }
 
-// ParseFile parses the given PE binary executable, reading from path.
+// isPE reports whether the given MZ executable is a PE executable.
+func isPE(r io.ReaderAt) bool {
+	sig, ok := bin.MZSignature(r, 4)
+	return ok && string(sig) == "PE\x00\x00"
+}
+
+// ParseFile parses the given PE binary executable, reading from path. The
+// file is memory-mapped, and section data is loaded lazily on first access.
+//
+// Section data refers to the memory-mapped file, which is therefore kept open
+// until the returned file is closed.
 func ParseFile(path string) (*bin.File, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, errors.WithStack(err)
+	parse := func(m *bin.Mapping) (*bin.File, error) {
+		return Parse(m)
 	}
-	defer f.Close()
-	return Parse(f)
+	return bin.ParseMapped(path, parse)
 }
 
 // Parse parses the given PE binary executable, reading from r.
@@ -56,7 +64,9 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 
 	// Parse machine architecture.
 	file := &bin.File{
-		Imports: make(map[bin.Address]string),
+		Imports:    make(map[bin.Address]string),
+		Exports:    make(map[bin.Address]string),
+		ImportLibs: make(map[bin.Address]string),
 	}
 	switch f.FileHeader.Machine {
 	case pe.IMAGE_FILE_MACHINE_I386:
@@ -73,41 +83,82 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 	var (
 		// Image base address.
 		imageBase uint64
+		// Entry point RVA.
+		entryRVA uint64
+		// Export table RVA and size.
+		etRVA  uint64
+		etSize uint64
 		// Import table RVA and size.
 		itRVA  uint64
 		itSize uint64
 		// Import address table (IAT) RVA and size.
 		iatRVA  uint64
 		iatSize uint64
+		// TLS table RVA and size.
+		tlsRVA  uint64
+		tlsSize uint64
+		// Exception table RVA and size.
+		exRVA  uint64
+		exSize uint64
+		// Section alignment in memory.
+		sectAlign uint32
+		// DLL characteristics.
+		dllChar uint16
 	)
 	// Data directory indices.
 	const (
+		ExportTableIndex        = 0
 		ImportTableIndex        = 1
+		ExceptionTableIndex     = 3
+		TLSTableIndex           = 9
 		ImportAddressTableIndex = 12
 	)
 	switch opt := f.OptionalHeader.(type) {
 	case *pe.OptionalHeader32:
-		file.Entry = bin.Address(opt.ImageBase + opt.AddressOfEntryPoint)
 		imageBase = uint64(opt.ImageBase)
+		entryRVA = uint64(opt.AddressOfEntryPoint)
+		etRVA = uint64(opt.DataDirectory[ExportTableIndex].VirtualAddress)
+		etSize = uint64(opt.DataDirectory[ExportTableIndex].Size)
 		itRVA = uint64(opt.DataDirectory[ImportTableIndex].VirtualAddress)
 		itSize = uint64(opt.DataDirectory[ImportTableIndex].Size)
 		iatRVA = uint64(opt.DataDirectory[ImportAddressTableIndex].VirtualAddress)
 		iatSize = uint64(opt.DataDirectory[ImportAddressTableIndex].Size)
+		tlsRVA = uint64(opt.DataDirectory[TLSTableIndex].VirtualAddress)
+		tlsSize = uint64(opt.DataDirectory[TLSTableIndex].Size)
+		sectAlign = opt.SectionAlignment
+		dllChar = opt.DllCharacteristics
 	case *pe.OptionalHeader64:
-		file.Entry = bin.Address(opt.ImageBase) + bin.Address(opt.AddressOfEntryPoint)
-		imageBase = uint64(opt.ImageBase)
+		imageBase = opt.ImageBase
+		entryRVA = uint64(opt.AddressOfEntryPoint)
+		etRVA = uint64(opt.DataDirectory[ExportTableIndex].VirtualAddress)
+		etSize = uint64(opt.DataDirectory[ExportTableIndex].Size)
 		itRVA = uint64(opt.DataDirectory[ImportTableIndex].VirtualAddress)
 		itSize = uint64(opt.DataDirectory[ImportTableIndex].Size)
 		iatRVA = uint64(opt.DataDirectory[ImportAddressTableIndex].VirtualAddress)
 		iatSize = uint64(opt.DataDirectory[ImportAddressTableIndex].Size)
+		tlsRVA = uint64(opt.DataDirectory[TLSTableIndex].VirtualAddress)
+		tlsSize = uint64(opt.DataDirectory[TLSTableIndex].Size)
+		exRVA = uint64(opt.DataDirectory[ExceptionTableIndex].VirtualAddress)
+		exSize = uint64(opt.DataDirectory[ExceptionTableIndex].Size)
+		sectAlign = opt.SectionAlignment
+		dllChar = opt.DllCharacteristics
 	default:
 		panic(fmt.Errorf("support for optional header type %T not yet implemented", opt))
 	}
+	file.Base = bin.Address(imageBase)
+	as := file.AddressSpace()
+	if entryRVA != 0 {
+		file.Entry = as.VA(entryRVA)
+	}
+	// File characteristic specifying that the image is a DLL.
+	const imageFileDLL = 0x2000
+	file.Shared = f.FileHeader.Characteristics&imageFileDLL != 0
+	file.Flags = parseDLLChar(dllChar)
 
 	// Parse sections.
 	for _, s := range f.Sections {
-		addr := bin.Address(imageBase) + bin.Address(s.VirtualAddress)
-		raw, err := s.Data()
+		addr := as.VA(uint64(s.VirtualAddress))
+		raw, err := bin.ReadData(r, int64(s.Offset), int(s.Size))
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
@@ -127,6 +178,8 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 			FileSize: fileSize,
 			MemSize:  memSize,
 			Perm:     perm,
+			Flags:    parseSectFlags(s.Characteristics),
+			Align:    int(sectAlign),
 		}
 		file.Sections = append(file.Sections, sect)
 	}
@@ -142,10 +195,32 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 	}
 	sort.Slice(file.Sections, less)
 
+	// Parse export table.
+	if etSize != 0 {
+		if err := parseExports(file, etRVA, etSize); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	// Parse TLS table.
+	if tlsSize != 0 {
+		if err := parseTLS(file, tlsRVA); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	// Parse exception table; only x64 executables use the table format of
+	// unwinding information.
+	if exSize != 0 && file.Arch == bin.ArchX86_64 {
+		if err := parseUnwind(file, exRVA, exSize); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
 	// Parse import address table (IAT).
 	dbg.Println("iat")
 	if iatSize != 0 {
-		iatAddr := bin.Address(imageBase + iatRVA)
+		iatAddr := as.VA(iatRVA)
 		dbg.Println("iat addr:", iatAddr)
 		data := file.Data(iatAddr)
 		data = data[:iatSize]
@@ -159,7 +234,7 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 
 	// Parse import table.
 	dbg.Println("it")
-	itAddr := bin.Address(imageBase + itRVA)
+	itAddr := as.VA(itRVA)
 	dbg.Println("it addr:", itAddr)
 	data := file.Data(itAddr)
 	data = data[:itSize]
@@ -179,13 +254,14 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 	}
 	for _, impDesc := range impDescs {
 		dbg.Printf("impDesc: %#v\n", pretty.Formatter(impDesc))
-		dllNameAddr := bin.Address(imageBase) + bin.Address(impDesc.DLLNameRVA)
+		dllNameAddr := as.VA(uint64(impDesc.DLLNameRVA))
 		data := file.Data(dllNameAddr)
 		dllName := parseString(data)
 		dbg.Println("dll name:", dllName)
+		file.Libs = append(file.Libs, dllName)
 		// Parse import name table and import address table.
-		impNameTableAddr := bin.Address(imageBase) + bin.Address(impDesc.ImportNameTableRVA)
-		impAddrTableAddr := bin.Address(imageBase) + bin.Address(impDesc.ImportAddressTableRVA)
+		impNameTableAddr := as.VA(uint64(impDesc.ImportNameTableRVA))
+		impAddrTableAddr := as.VA(uint64(impDesc.ImportAddressTableRVA))
 		inAddr := impNameTableAddr
 		iaAddr := impAddrTableAddr
 		for {
@@ -203,9 +279,10 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 				dbg.Println("===> ordinal", ordinal)
 				impName := fmt.Sprintf("%s_ordinal_%d", pathutil.TrimExt(dllName), ordinal)
 				file.Imports[impAddr] = impName
+				file.ImportLibs[impAddr] = dllName
 				continue
 			}
-			impNameAddr := bin.Address(imageBase + impNameRVA)
+			impNameAddr := as.VA(impNameRVA)
 			data := file.Data(impNameAddr)
 			ordinal := binary.LittleEndian.Uint16(data)
 			data = data[2:]
@@ -213,6 +290,7 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 			dbg.Println("ordinal:", ordinal)
 			dbg.Println("impName:", impName)
 			file.Imports[impAddr] = impName
+			file.ImportLibs[impAddr] = dllName
 		}
 		dbg.Println()
 	}
@@ -270,6 +348,47 @@ func parsePerm(char uint32) bin.Perm {
 	return perm
 }
 
+// parseSectFlags returns the section characteristics, other than access
+// permissions, represented by the given PE image characteristics.
+func parseSectFlags(char uint32) bin.SectFlag {
+	// Characteristics.
+	const (
+		// cntUninitializedData specifies that the section contains uninitialized
+		// data.
+		cntUninitializedData = 0x00000080
+		// memDiscardable specifies that the section can be discarded as needed.
+		memDiscardable = 0x02000000
+	)
+	var flags bin.SectFlag
+	if char&cntUninitializedData != 0 {
+		flags |= bin.SectUninit
+	}
+	if char&memDiscardable != 0 {
+		flags |= bin.SectDiscardable
+	}
+	return flags
+}
+
+// parseDLLChar returns the exploit mitigations represented by the given PE DLL
+// characteristics of the optional header.
+func parseDLLChar(char uint16) bin.ImageFlag {
+	// DLL characteristics.
+	const (
+		// dynamicBase specifies that the image can be relocated at load time.
+		dynamicBase = 0x0040
+		// nxCompat specifies that the image is compatible with DEP.
+		nxCompat = 0x0100
+	)
+	var flags bin.ImageFlag
+	if char&dynamicBase != 0 {
+		flags |= bin.ImageASLR
+	}
+	if char&nxCompat != 0 {
+		flags |= bin.ImageNX
+	}
+	return flags
+}
+
 // ### [ Helper functions ] ####################################################
 
 // parseString parses the NULL-terminated string in the given data.
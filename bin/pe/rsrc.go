@@ -0,0 +1,644 @@
+package pe
+
+import (
+	"debug/pe"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"unicode/utf16"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// Resource types.
+const (
+	RTCursor       = 1
+	RTBitmap       = 2
+	RTIcon         = 3
+	RTMenu         = 4
+	RTDialog       = 5
+	RTString       = 6
+	RTFontDir      = 7
+	RTFont         = 8
+	RTAccelerator  = 9
+	RTRCData       = 10
+	RTMessageTable = 11
+	RTGroupCursor  = 12
+	RTGroupIcon    = 14
+	RTVersion      = 16
+	RTDlgInclude   = 17
+	RTPlugPlay     = 19
+	RTVXD          = 20
+	RTAniCursor    = 21
+	RTAniIcon      = 22
+	RTHTML         = 23
+	RTManifest     = 24
+)
+
+// rtNames maps from resource type to name.
+var rtNames = map[uint16]string{
+	RTCursor:       "RT_CURSOR",
+	RTBitmap:       "RT_BITMAP",
+	RTIcon:         "RT_ICON",
+	RTMenu:         "RT_MENU",
+	RTDialog:       "RT_DIALOG",
+	RTString:       "RT_STRING",
+	RTFontDir:      "RT_FONTDIR",
+	RTFont:         "RT_FONT",
+	RTAccelerator:  "RT_ACCELERATOR",
+	RTRCData:       "RT_RCDATA",
+	RTMessageTable: "RT_MESSAGETABLE",
+	RTGroupCursor:  "RT_GROUP_CURSOR",
+	RTGroupIcon:    "RT_GROUP_ICON",
+	RTVersion:      "RT_VERSION",
+	RTDlgInclude:   "RT_DLGINCLUDE",
+	RTPlugPlay:     "RT_PLUGPLAY",
+	RTVXD:          "RT_VXD",
+	RTAniCursor:    "RT_ANICURSOR",
+	RTAniIcon:      "RT_ANIICON",
+	RTHTML:         "RT_HTML",
+	RTManifest:     "RT_MANIFEST",
+}
+
+// A Resource is a resource of a PE executable (e.g. dialog, string table, icon
+// or version information).
+type Resource struct {
+	// Resource type.
+	Type ResourceID
+	// Resource name.
+	Name ResourceID
+	// Language ID.
+	Lang uint16
+	// Address of the resource data.
+	Addr bin.Address
+	// Resource data.
+	Data []byte
+	// Code page of the resource data.
+	CodePage uint32
+}
+
+// A ResourceID identifies a resource type or name, either by integer ID or by
+// name.
+type ResourceID struct {
+	// Integer ID; valid if Name is empty.
+	ID uint16
+	// Name; or empty if identified by integer ID.
+	Name string
+}
+
+// String returns the string representation of the resource ID.
+func (id ResourceID) String() string {
+	if len(id.Name) > 0 {
+		return id.Name
+	}
+	return fmt.Sprintf("#%d", id.ID)
+}
+
+// TypeName returns the name of the resource type (e.g. "RT_DIALOG").
+func (res *Resource) TypeName() string {
+	if len(res.Type.Name) == 0 {
+		if name, ok := rtNames[res.Type.ID]; ok {
+			return name
+		}
+	}
+	return res.Type.String()
+}
+
+// ParseResourcesFile parses the resources of the given PE binary executable,
+// reading from path.
+func ParseResourcesFile(path string) ([]*Resource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	return ParseResources(f)
+}
+
+// ParseResources parses the resources of the given PE binary executable,
+// reading from r. Resources are sorted by type, name and language.
+//
+// Users are responsible for closing r.
+func ParseResources(r io.ReaderAt) ([]*Resource, error) {
+	f, err := pe.NewFile(r)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	file, err := Parse(r)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// Data directory index.
+	const ResourceTableIndex = 2
+	var dir pe.DataDirectory
+	switch opt := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		if opt.NumberOfRvaAndSizes > ResourceTableIndex {
+			dir = opt.DataDirectory[ResourceTableIndex]
+		}
+	case *pe.OptionalHeader64:
+		if opt.NumberOfRvaAndSizes > ResourceTableIndex {
+			dir = opt.DataDirectory[ResourceTableIndex]
+		}
+	default:
+		return nil, errors.Errorf("support for optional header type %T not yet implemented", opt)
+	}
+	if dir.Size == 0 {
+		return nil, nil
+	}
+	return parseResources(file, uint64(dir.VirtualAddress))
+}
+
+// parseResources parses the resource tree at the given RVA of the PE binary
+// executable.
+func parseResources(file *bin.File, rsrcRVA uint64) ([]*Resource, error) {
+	as := file.AddressSpace()
+	rsrcAddr := as.VA(rsrcRVA)
+	data, ok := as.Bytes(rsrcAddr, 0)
+	if !ok {
+		return nil, errors.Errorf("unable to locate resource directory at %v", rsrcAddr)
+	}
+	var rs []*Resource
+	// The resource tree has three levels: type, name and language.
+	var walk func(offset uint32, level int, path []ResourceID) error
+	walk = func(offset uint32, level int, path []ResourceID) error {
+		if level > 2 {
+			return errors.Errorf("invalid resource directory at offset 0x%X; exceeds maximum depth", offset)
+		}
+		rr := &rsrcReader{data: data, off: int(offset)}
+		rr.skip(12) // Characteristics, TimeDateStamp, MajorVersion, MinorVersion
+		nnamed := rr.u16()
+		nids := rr.u16()
+		for i := 0; i < int(nnamed)+int(nids); i++ {
+			nameField := rr.u32()
+			offsetField := rr.u32()
+			if rr.err != nil {
+				return errors.WithStack(rr.err)
+			}
+			var id ResourceID
+			if nameField&0x80000000 != 0 {
+				nr := &rsrcReader{data: data, off: int(nameField &^ 0x80000000)}
+				n := nr.u16()
+				id.Name = nr.utf16(int(n))
+				if nr.err != nil {
+					return errors.WithStack(nr.err)
+				}
+			} else {
+				id.ID = uint16(nameField)
+			}
+			p := append(path[:level:level], id)
+			if offsetField&0x80000000 != 0 {
+				if err := walk(offsetField&^0x80000000, level+1, p); err != nil {
+					return errors.WithStack(err)
+				}
+				continue
+			}
+			// Data entry.
+			er := &rsrcReader{data: data, off: int(offsetField)}
+			dataRVA := er.u32()
+			size := er.u32()
+			codePage := er.u32()
+			if er.err != nil {
+				return errors.WithStack(er.err)
+			}
+			res := &Resource{
+				Addr:     as.VA(uint64(dataRVA)),
+				CodePage: codePage,
+			}
+			if len(p) > 0 {
+				res.Type = p[0]
+			}
+			if len(p) > 1 {
+				res.Name = p[1]
+			}
+			if len(p) > 2 {
+				res.Lang = p[2].ID
+			}
+			resData, ok := as.Bytes(res.Addr, 0)
+			if !ok {
+				return errors.Errorf("unable to locate data of resource %v/%v at %v", res.Type, res.Name, res.Addr)
+			}
+			if uint64(size) > uint64(len(resData)) {
+				return errors.Errorf("invalid size of resource %v/%v at %v; exceeds section (%d > %d)", res.Type, res.Name, res.Addr, size, len(resData))
+			}
+			res.Data = resData[:size]
+			rs = append(rs, res)
+		}
+		return nil
+	}
+	if err := walk(0, 0, nil); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	less := func(i, j int) bool {
+		a, b := rs[i], rs[j]
+		if a.Type != b.Type {
+			return lessID(a.Type, b.Type)
+		}
+		if a.Name != b.Name {
+			return lessID(a.Name, b.Name)
+		}
+		return a.Lang < b.Lang
+	}
+	sort.SliceStable(rs, less)
+	return rs, nil
+}
+
+// --- [ String tables ] -------------------------------------------------------
+
+// Strings returns the strings of the given string table resource (RT_STRING),
+// by string ID.
+func (res *Resource) Strings() (map[uint32]string, error) {
+	if res.Type != (ResourceID{ID: RTString}) || len(res.Name.Name) > 0 || res.Name.ID == 0 {
+		return nil, errors.Errorf("invalid string table resource %v/%v", res.TypeName(), res.Name)
+	}
+	// Each string table holds 16 strings; string table n holds the strings
+	// with IDs [16*(n-1), 16*n).
+	strs := make(map[uint32]string)
+	rr := &rsrcReader{data: res.Data}
+	base := 16 * (uint32(res.Name.ID) - 1)
+	for i := uint32(0); i < 16; i++ {
+		n := rr.u16()
+		s := rr.utf16(int(n))
+		if rr.err != nil {
+			return nil, errors.Wrapf(rr.err, "invalid string table resource %v", res.Name)
+		}
+		if n > 0 {
+			strs[base+i] = s
+		}
+	}
+	return strs, nil
+}
+
+// StringTable returns the strings of the string table resources, by string ID.
+// The first language of each string table is used.
+func StringTable(rs []*Resource) map[uint32]string {
+	strs := make(map[uint32]string)
+	for _, res := range rs {
+		if res.Type != (ResourceID{ID: RTString}) {
+			continue
+		}
+		ss, err := res.Strings()
+		if err != nil {
+			dbg.Printf("unable to parse string table; %v", err)
+			continue
+		}
+		for id, s := range ss {
+			if _, ok := strs[id]; !ok {
+				strs[id] = s
+			}
+		}
+	}
+	return strs
+}
+
+// --- [ Dialogs ] -------------------------------------------------------------
+
+// A Dialog is a dialog box template.
+type Dialog struct {
+	// Window style.
+	Style uint32
+	// Extended window style.
+	ExStyle uint32
+	// Position and dimensions in dialog units.
+	X, Y, CX, CY int16
+	// Menu resource; or empty if none.
+	Menu ResourceID
+	// Window class; or empty if the predefined dialog box class.
+	Class ResourceID
+	// Title.
+	Title string
+	// Font point size; valid if Font is non-empty.
+	PointSize uint16
+	// Font typeface; or empty if not specified.
+	Font string
+	// Controls of the dialog box.
+	Controls []*DialogControl
+}
+
+// A DialogControl is a control of a dialog box template.
+type DialogControl struct {
+	// Control ID.
+	ID uint32
+	// Window style.
+	Style uint32
+	// Extended window style.
+	ExStyle uint32
+	// Position and dimensions in dialog units.
+	X, Y, CX, CY int16
+	// Window class (e.g. "BUTTON", or #128 for predefined button class).
+	Class ResourceID
+	// Text or resource (e.g. icon) of the control.
+	Text ResourceID
+}
+
+// Dialog styles.
+const (
+	dsSetFont   = 0x40
+	dsShellFont = 0x48
+)
+
+// Dialog parses the given dialog resource (RT_DIALOG), in DLGTEMPLATE or
+// DLGTEMPLATEEX format.
+func (res *Resource) Dialog() (*Dialog, error) {
+	if res.Type != (ResourceID{ID: RTDialog}) {
+		return nil, errors.Errorf("invalid dialog resource %v/%v", res.TypeName(), res.Name)
+	}
+	rr := &rsrcReader{data: res.Data}
+	dlg := &Dialog{}
+	ex := len(res.Data) >= 4 && binary.LittleEndian.Uint16(res.Data[0:]) == 1 && binary.LittleEndian.Uint16(res.Data[2:]) == 0xFFFF
+	var n uint16
+	if ex {
+		rr.skip(8) // dlgVer, signature, helpID
+		dlg.ExStyle = rr.u32()
+		dlg.Style = rr.u32()
+	} else {
+		dlg.Style = rr.u32()
+		dlg.ExStyle = rr.u32()
+	}
+	n = rr.u16()
+	dlg.X, dlg.Y, dlg.CX, dlg.CY = int16(rr.u16()), int16(rr.u16()), int16(rr.u16()), int16(rr.u16())
+	dlg.Menu = rr.szOrOrd()
+	dlg.Class = rr.szOrOrd()
+	dlg.Title = rr.sz()
+	if (ex && dlg.Style&dsShellFont != 0) || (!ex && dlg.Style&dsSetFont != 0) {
+		dlg.PointSize = rr.u16()
+		if ex {
+			rr.skip(4) // weight, italic, charset
+		}
+		dlg.Font = rr.sz()
+	}
+	for i := 0; i < int(n); i++ {
+		rr.align4()
+		ctrl := &DialogControl{}
+		if ex {
+			rr.skip(4) // helpID
+			ctrl.ExStyle = rr.u32()
+			ctrl.Style = rr.u32()
+		} else {
+			ctrl.Style = rr.u32()
+			ctrl.ExStyle = rr.u32()
+		}
+		ctrl.X, ctrl.Y, ctrl.CX, ctrl.CY = int16(rr.u16()), int16(rr.u16()), int16(rr.u16()), int16(rr.u16())
+		if ex {
+			ctrl.ID = rr.u32()
+		} else {
+			ctrl.ID = uint32(rr.u16())
+		}
+		ctrl.Class = rr.szOrOrd()
+		ctrl.Text = rr.szOrOrd()
+		extra := rr.u16()
+		rr.skip(int(extra))
+		dlg.Controls = append(dlg.Controls, ctrl)
+	}
+	if rr.err != nil {
+		return nil, errors.Wrapf(rr.err, "invalid dialog resource %v", res.Name)
+	}
+	return dlg, nil
+}
+
+// --- [ Icons ] ---------------------------------------------------------------
+
+// Icon returns the contents of an icon file (.ico) assembled from the given
+// icon group resource (RT_GROUP_ICON) and the icon resources (RT_ICON) it
+// references.
+func Icon(rs []*Resource, group *Resource) ([]byte, error) {
+	if group.Type != (ResourceID{ID: RTGroupIcon}) {
+		return nil, errors.Errorf("invalid icon group resource %v/%v", group.TypeName(), group.Name)
+	}
+	icons := make(map[uint16]*Resource)
+	for _, res := range rs {
+		if res.Type == (ResourceID{ID: RTIcon}) && len(res.Name.Name) == 0 {
+			if _, ok := icons[res.Name.ID]; !ok {
+				icons[res.Name.ID] = res
+			}
+		}
+	}
+	rr := &rsrcReader{data: group.Data}
+	rr.skip(4) // reserved, type
+	n := rr.u16()
+	// The group icon directory entries (GRPICONDIRENTRY) are 14 bytes, and the
+	// icon directory entries (ICONDIRENTRY) of icon files are 16 bytes; the
+	// trailing resource ID is replaced by the file offset of the image.
+	const headerSize, entrySize = 6, 16
+	header := make([]byte, headerSize, headerSize+entrySize*int(n))
+	binary.LittleEndian.PutUint16(header[2:], 1) // icon type
+	binary.LittleEndian.PutUint16(header[4:], n)
+	var images []byte
+	offset := headerSize + entrySize*int(n)
+	for i := 0; i < int(n); i++ {
+		entry := rr.bytes(12)
+		id := rr.u16()
+		if rr.err != nil {
+			return nil, errors.Wrapf(rr.err, "invalid icon group resource %v", group.Name)
+		}
+		icon, ok := icons[id]
+		if !ok {
+			return nil, errors.Errorf("unable to locate icon resource #%d of icon group %v", id, group.Name)
+		}
+		e := make([]byte, entrySize)
+		copy(e, entry[:8])
+		binary.LittleEndian.PutUint32(e[8:], uint32(len(icon.Data)))
+		binary.LittleEndian.PutUint32(e[12:], uint32(offset))
+		header = append(header, e...)
+		images = append(images, icon.Data...)
+		offset += len(icon.Data)
+	}
+	return append(header, images...), nil
+}
+
+// --- [ Version information ] -------------------------------------------------
+
+// VersionInfo is the version information of a PE executable.
+type VersionInfo struct {
+	// File version (e.g. "1.2.3.4").
+	FileVersion string
+	// Product version (e.g. "1.2.3.4").
+	ProductVersion string
+	// Version information strings (e.g. "CompanyName", "FileDescription"), of
+	// the first string table.
+	Strings map[string]string
+}
+
+// Version parses the given version information resource (RT_VERSION).
+func (res *Resource) Version() (*VersionInfo, error) {
+	if res.Type != (ResourceID{ID: RTVersion}) {
+		return nil, errors.Errorf("invalid version information resource %v/%v", res.TypeName(), res.Name)
+	}
+	info := &VersionInfo{
+		Strings: make(map[string]string),
+	}
+	// VS_VERSIONINFO.
+	rr := &rsrcReader{data: res.Data}
+	length, valueLength, _, key := rr.versionHeader()
+	if key != "VS_VERSION_INFO" {
+		return nil, errors.Errorf("invalid version information resource; expected VS_VERSION_INFO key, got %q", key)
+	}
+	end := int(length)
+	if valueLength >= 52 {
+		// VS_FIXEDFILEINFO.
+		value := rr.bytes(int(valueLength))
+		if sig := binary.LittleEndian.Uint32(value[0:]); sig != 0xFEEF04BD {
+			return nil, errors.Errorf("invalid VS_FIXEDFILEINFO signature 0x%08X", sig)
+		}
+		version := func(ms, ls uint32) string {
+			return fmt.Sprintf("%d.%d.%d.%d", ms>>16, ms&0xFFFF, ls>>16, ls&0xFFFF)
+		}
+		info.FileVersion = version(binary.LittleEndian.Uint32(value[8:]), binary.LittleEndian.Uint32(value[12:]))
+		info.ProductVersion = version(binary.LittleEndian.Uint32(value[16:]), binary.LittleEndian.Uint32(value[20:]))
+	} else {
+		rr.skip(int(valueLength))
+	}
+	// StringFileInfo and VarFileInfo.
+	for rr.align4(); rr.err == nil && rr.off < end && rr.off < len(rr.data); rr.align4() {
+		start := rr.off
+		length, _, _, key := rr.versionHeader()
+		if length == 0 {
+			break
+		}
+		childEnd := start + int(length)
+		if key == "StringFileInfo" {
+			// First StringTable.
+			rr.align4()
+			tableStart := rr.off
+			tableLength, _, _, _ := rr.versionHeader()
+			tableEnd := tableStart + int(tableLength)
+			for rr.align4(); rr.err == nil && rr.off < tableEnd; rr.align4() {
+				strStart := rr.off
+				strLength, strValueLength, _, strKey := rr.versionHeader()
+				if strLength == 0 {
+					break
+				}
+				// Value length is specified in words, including NULL terminator.
+				value := rr.utf16(int(strValueLength))
+				if n := len(value); n > 0 && value[n-1] == 0 {
+					value = value[:n-1]
+				}
+				info.Strings[strKey] = value
+				rr.off = strStart + int(strLength)
+			}
+		}
+		rr.off = childEnd
+	}
+	if rr.err != nil {
+		return nil, errors.Wrap(rr.err, "invalid version information resource")
+	}
+	return info, nil
+}
+
+// ### [ Helper functions ] ####################################################
+
+// rsrcReader reads little-endian encoded resource data. Reads past the end of
+// data record an error and return zero values.
+type rsrcReader struct {
+	// Resource data.
+	data []byte
+	// Current offset into data.
+	off int
+	// First error encountered; or nil.
+	err error
+}
+
+// bytes reads n bytes.
+func (rr *rsrcReader) bytes(n int) []byte {
+	if rr.err != nil || n < 0 || rr.off+n > len(rr.data) {
+		if rr.err == nil {
+			rr.err = errors.Errorf("unexpected end of resource data at offset 0x%X", rr.off)
+		}
+		return make([]byte, n)
+	}
+	b := rr.data[rr.off : rr.off+n]
+	rr.off += n
+	return b
+}
+
+// skip skips n bytes.
+func (rr *rsrcReader) skip(n int) {
+	rr.bytes(n)
+}
+
+// u16 reads a 16-bit unsigned integer.
+func (rr *rsrcReader) u16() uint16 {
+	return binary.LittleEndian.Uint16(rr.bytes(2))
+}
+
+// u32 reads a 32-bit unsigned integer.
+func (rr *rsrcReader) u32() uint32 {
+	return binary.LittleEndian.Uint32(rr.bytes(4))
+}
+
+// align4 aligns the offset to a 32-bit boundary.
+func (rr *rsrcReader) align4() {
+	if rem := rr.off % 4; rem != 0 && rr.off+4-rem <= len(rr.data) {
+		rr.off += 4 - rem
+	}
+}
+
+// utf16 reads a UTF-16 encoded string of n code units.
+func (rr *rsrcReader) utf16(n int) string {
+	buf := rr.bytes(2 * n)
+	units := make([]uint16, n)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(buf[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
+
+// sz reads a NULL-terminated UTF-16 encoded string.
+func (rr *rsrcReader) sz() string {
+	var units []uint16
+	for rr.err == nil {
+		u := rr.u16()
+		if u == 0 {
+			break
+		}
+		units = append(units, u)
+	}
+	return string(utf16.Decode(units))
+}
+
+// szOrOrd reads a string or ordinal (sz_Or_Ord) of a dialog template; either
+// 0x0000 (none), 0xFFFF followed by an ordinal, or a NULL-terminated UTF-16
+// encoded string.
+func (rr *rsrcReader) szOrOrd() ResourceID {
+	if rr.err != nil || rr.off+2 > len(rr.data) {
+		rr.u16()
+		return ResourceID{}
+	}
+	switch binary.LittleEndian.Uint16(rr.data[rr.off:]) {
+	case 0x0000:
+		rr.u16()
+		return ResourceID{}
+	case 0xFFFF:
+		rr.u16()
+		return ResourceID{ID: rr.u16()}
+	}
+	return ResourceID{Name: rr.sz()}
+}
+
+// versionHeader reads the header of a version information structure; the
+// length, value length, type and key, followed by padding to a 32-bit boundary.
+func (rr *rsrcReader) versionHeader() (length, valueLength, typ uint16, key string) {
+	length = rr.u16()
+	valueLength = rr.u16()
+	typ = rr.u16()
+	key = rr.sz()
+	rr.align4()
+	return length, valueLength, typ, key
+}
+
+// lessID reports whether the resource ID a sorts before b; named IDs before
+// integer IDs, as in the resource directory.
+func lessID(a, b ResourceID) bool {
+	switch {
+	case len(a.Name) > 0 && len(b.Name) > 0:
+		return a.Name < b.Name
+	case len(a.Name) > 0:
+		return true
+	case len(b.Name) > 0:
+		return false
+	}
+	return a.ID < b.ID
+}
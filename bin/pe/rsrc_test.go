@@ -0,0 +1,226 @@
+package pe_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/bin/pe"
+)
+
+func TestParseResources(t *testing.T) {
+	type resource struct {
+		typeName string
+		name     pe.ResourceID
+		lang     uint16
+		addr     bin.Address
+		size     int
+	}
+	golden := []struct {
+		path      string
+		resources []resource
+		strings   map[uint32]string
+	}{
+		{
+			path: "testdata/rsrc.exe",
+			resources: []resource{
+				// Named resource types sort before integer IDs.
+				{typeName: "CUSTOM", name: pe.ResourceID{ID: 1}, lang: 0x409, addr: 0x403278, size: 4},
+				{typeName: "RT_ICON", name: pe.ResourceID{ID: 1}, lang: 0x409, addr: 0x403200, size: 40},
+				{typeName: "RT_ICON", name: pe.ResourceID{ID: 2}, lang: 0x409, addr: 0x403228, size: 40},
+				{typeName: "RT_DIALOG", name: pe.ResourceID{ID: 100}, lang: 0x409, addr: 0x403280, size: 90},
+				{typeName: "RT_STRING", name: pe.ResourceID{ID: 1}, lang: 0x409, addr: 0x403420, size: 42},
+				{typeName: "RT_GROUP_ICON", name: pe.ResourceID{ID: 1}, lang: 0x409, addr: 0x403250, size: 34},
+				{typeName: "RT_VERSION", name: pe.ResourceID{ID: 1}, lang: 0x409, addr: 0x4032E0, size: 320},
+			},
+			strings: map[uint32]string{1: "Hello"},
+		},
+		// PE executable without resources.
+		{path: "testdata/start.exe", strings: map[uint32]string{}},
+	}
+	for _, g := range golden {
+		rs, err := pe.ParseResourcesFile(g.path)
+		if err != nil {
+			t.Errorf("%q: unable to parse resources; %+v", g.path, err)
+			continue
+		}
+		if len(rs) != len(g.resources) {
+			t.Errorf("%q: number of resources mismatch; expected %d, got %d", g.path, len(g.resources), len(rs))
+			continue
+		}
+		for i, want := range g.resources {
+			res := rs[i]
+			if got := res.TypeName(); got != want.typeName {
+				t.Errorf("%q: resource %d: type mismatch; expected %q, got %q", g.path, i, want.typeName, got)
+			}
+			if res.Name != want.name {
+				t.Errorf("%q: resource %d: name mismatch; expected %v, got %v", g.path, i, want.name, res.Name)
+			}
+			if res.Lang != want.lang {
+				t.Errorf("%q: resource %d: language mismatch; expected 0x%X, got 0x%X", g.path, i, want.lang, res.Lang)
+			}
+			if res.Addr != want.addr {
+				t.Errorf("%q: resource %d: address mismatch; expected %v, got %v", g.path, i, want.addr, res.Addr)
+			}
+			if len(res.Data) != want.size {
+				t.Errorf("%q: resource %d: size mismatch; expected %d, got %d", g.path, i, want.size, len(res.Data))
+			}
+		}
+		if got := pe.StringTable(rs); !reflect.DeepEqual(got, g.strings) {
+			t.Errorf("%q: string table mismatch; expected %v, got %v", g.path, g.strings, got)
+		}
+	}
+}
+
+func TestParseResourcesInvalid(t *testing.T) {
+	golden := []struct {
+		path string
+		desc string
+	}{
+		{path: "testdata/invalid/rsrc_cycle.exe", desc: "cyclic resource directory"},
+		{path: "testdata/invalid/rsrc_size.exe", desc: "resource size exceeding section"},
+		{path: "testdata/invalid/rsrc_data.exe", desc: "resource data outside of section"},
+		{path: "testdata/invalid/rsrc_name.exe", desc: "resource name outside of section"},
+	}
+	for _, g := range golden {
+		if _, err := pe.ParseResourcesFile(g.path); err == nil {
+			t.Errorf("%q: %s; expected error, got nil", g.path, g.desc)
+		}
+	}
+}
+
+func TestCustom(t *testing.T) {
+	res := findResource(t, pe.ResourceID{Name: "CUSTOM"}, 1)
+	if want := []byte("DATA"); !bytes.Equal(res.Data, want) {
+		t.Errorf("contents mismatch; expected % X, got % X", want, res.Data)
+	}
+	if _, err := res.Strings(); err == nil {
+		t.Errorf("expected error for string table of custom resource, got nil")
+	}
+}
+
+func TestDialog(t *testing.T) {
+	res := findResource(t, pe.ResourceID{ID: pe.RTDialog}, 100)
+	dlg, err := res.Dialog()
+	if err != nil {
+		t.Fatalf("unable to parse dialog; %+v", err)
+	}
+	want := &pe.Dialog{
+		// WS_POPUP | WS_CAPTION | DS_SETFONT; set by the resource compiler from
+		// CAPTION and FONT.
+		Style:     0x80C00040,
+		X:         10,
+		Y:         20,
+		CX:        200,
+		CY:        100,
+		Title:     "Test",
+		PointSize: 8,
+		Font:      "MS Shell Dlg",
+		Controls: []*pe.DialogControl{
+			{ID: 1, Style: 0x50010000, X: 5, Y: 6, CX: 50, CY: 14, Class: pe.ResourceID{ID: 0x80}, Text: pe.ResourceID{Name: "OK"}},
+		},
+	}
+	if !reflect.DeepEqual(dlg, want) {
+		t.Errorf("dialog mismatch; expected %+v, got %+v", want, dlg)
+	}
+	// Truncated dialog template.
+	res.Data = res.Data[:len(res.Data)-6]
+	if _, err := res.Dialog(); err == nil {
+		t.Errorf("expected error for truncated dialog, got nil")
+	}
+}
+
+func TestIcon(t *testing.T) {
+	rs, err := pe.ParseResourcesFile("testdata/rsrc.exe")
+	if err != nil {
+		t.Fatalf("unable to parse resources; %+v", err)
+	}
+	var icons []*pe.Resource
+	for _, res := range rs {
+		if res.Type == (pe.ResourceID{ID: pe.RTIcon}) {
+			icons = append(icons, res)
+		}
+	}
+	group := findResource(t, pe.ResourceID{ID: pe.RTGroupIcon}, 1)
+	got, err := pe.Icon(icons, group)
+	if err != nil {
+		t.Fatalf("unable to assemble icon; %+v", err)
+	}
+	// The icon file is identical to the one compiled into the resources.
+	want, err := ioutil.ReadFile("testdata/icon.ico")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("icon mismatch; expected % X, got % X", want, got)
+	}
+	// Icon group referring to missing icon.
+	if _, err := pe.Icon(icons[:1], group); err == nil {
+		t.Errorf("expected error for missing icon, got nil")
+	}
+}
+
+func TestVersion(t *testing.T) {
+	res := findResource(t, pe.ResourceID{ID: pe.RTVersion}, 1)
+	info, err := res.Version()
+	if err != nil {
+		t.Fatalf("unable to parse version information; %+v", err)
+	}
+	want := &pe.VersionInfo{
+		FileVersion:    "1.2.3.4",
+		ProductVersion: "5.6.7.8",
+		Strings: map[string]string{
+			"CompanyName":     "Decomp",
+			"FileDescription": "Test",
+		},
+	}
+	if !reflect.DeepEqual(info, want) {
+		t.Errorf("version information mismatch; expected %+v, got %+v", want, info)
+	}
+	// Invalid signature of VS_FIXEDFILEINFO, following the VS_VERSION_INFO key.
+	binary.LittleEndian.PutUint32(res.Data[40:], 0)
+	if _, err := res.Version(); err == nil {
+		t.Errorf("expected error for invalid VS_FIXEDFILEINFO signature, got nil")
+	}
+}
+
+// findResource returns the resource of the given type and integer name in
+// testdata/rsrc.exe.
+func findResource(t *testing.T, typ pe.ResourceID, name uint16) *pe.Resource {
+	rs, err := pe.ParseResourcesFile("testdata/rsrc.exe")
+	if err != nil {
+		t.Fatalf("unable to parse resources; %+v", err)
+	}
+	for _, res := range rs {
+		if res.Type == typ && res.Name == (pe.ResourceID{ID: name}) {
+			return res
+		}
+	}
+	t.Fatalf("unable to locate resource %v/#%d", typ, name)
+	return nil
+}
+
+func TestParseResourcesTruncated(t *testing.T) {
+	buf, err := ioutil.ReadFile("testdata/rsrc.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := []struct {
+		size int
+		desc string
+	}{
+		{size: 0x20, desc: "MZ header"},
+		{size: 0x100, desc: "optional header"},
+		{size: 0x1A0, desc: "section headers"},
+		{size: 0x900, desc: "resource directory"},
+		{size: 0xA60, desc: "resource data"},
+	}
+	for _, g := range golden {
+		if _, err := pe.ParseResources(bytes.NewReader(buf[:g.size])); err == nil {
+			t.Errorf("truncated within %s (0x%X bytes); expected error, got nil", g.desc, g.size)
+		}
+	}
+}
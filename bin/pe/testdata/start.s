@@ -0,0 +1,5 @@
+	.text
+	.globl	_start
+_start:
+	xorl	%eax, %eax
+	ret
@@ -0,0 +1,38 @@
+# Functions with x64 unwind information, assembled with llvm-mc.
+
+	.text
+	.globl	_start
+	.seh_proc _start
+_start:
+	pushq	%rbp
+	.seh_pushreg %rbp
+	pushq	%rbx
+	.seh_pushreg %rbx
+	subq	$0x28, %rsp
+	.seh_stackalloc 0x28
+	leaq	(%rsp), %rbp
+	.seh_setframe %rbp, 0
+	movq	%rsi, 0x40(%rsp)
+	.seh_savereg %rsi, 0x40
+	.seh_endprologue
+	call	f
+	# Function chunk, with unwind information chained to the parent function.
+	.seh_startchained
+	.seh_endprologue
+	movq	0x40(%rsp), %rsi
+	addq	$0x28, %rsp
+	popq	%rbx
+	popq	%rbp
+	ret
+	.seh_endchained
+	.seh_endproc
+
+	.seh_proc f
+f:
+	subq	$0x1008, %rsp
+	.seh_stackalloc 0x1008
+	.seh_endprologue
+	xorl	%eax, %eax
+	addq	$0x1008, %rsp
+	ret
+	.seh_endproc
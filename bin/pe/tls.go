@@ -0,0 +1,38 @@
+package pe
+
+import (
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// parseTLS parses the TLS table of the given PE binary executable, recording
+// the TLS callbacks in file.InitFuncs. TLS callbacks are invoked by the loader
+// prior to the entry point, and are thus commonly used to hide code (e.g. by
+// malware and protectors).
+func parseTLS(file *bin.File, tlsRVA uint64) error {
+	// The TLS directory holds the start and end address of the TLS template,
+	// the address of the TLS index, and the address of the NULL-terminated
+	// array of TLS callbacks; each of pointer size.
+	tlsAddr := file.AddressSpace().VA(tlsRVA)
+	ptrSize := bin.Address(file.PtrSize())
+	callbacksAddr, err := file.ReadPointer(tlsAddr + 3*ptrSize)
+	if err != nil {
+		return errors.Wrapf(err, "invalid TLS directory at %v", tlsAddr)
+	}
+	dbg.Println("tls callbacks addr:", callbacksAddr)
+	if callbacksAddr == 0 {
+		return nil
+	}
+	for addr := callbacksAddr; ; addr += ptrSize {
+		callback, err := file.ReadPointer(addr)
+		if err != nil {
+			return errors.Wrapf(err, "invalid TLS callbacks at %v", callbacksAddr)
+		}
+		if callback == 0 {
+			break
+		}
+		dbg.Printf("tls callback at %v", callback)
+		file.InitFuncs = append(file.InitFuncs, callback)
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+package pe
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// Flag of unwind info specifying that the unwind info is chained to that of a
+// previous function entry.
+const unwFlagChainInfo = 0x4
+
+// Unwind operation codes.
+const (
+	uwopPushNonvol    = 0
+	uwopAllocLarge    = 1
+	uwopAllocSmall    = 2
+	uwopSetFPReg      = 3
+	uwopSaveNonvol    = 4
+	uwopSaveNonvolFar = 5
+	uwopEpilog        = 6
+	uwopSpareCode     = 7
+	uwopSaveXMM128    = 8
+	uwopSaveXMM128Far = 9
+	uwopPushMachFrame = 10
+)
+
+// Sizes in bytes.
+const (
+	// Size of RUNTIME_FUNCTION.
+	runtimeFuncSize = 12
+	// Size of UNWIND_INFO header, excluding unwind codes.
+	unwindInfoHdrSize = 4
+	// Size of UNWIND_CODE.
+	unwindCodeSize = 2
+	// Size of return address.
+	returnAddrSize = 8
+	// Size of machine frame pushed by interrupts, without and with error code.
+	machFrameSize      = 5 * 8
+	machFrameErrorSize = 6 * 8
+)
+
+// Maximum depth of chained unwind info.
+const maxChainDepth = 32
+
+// regNames maps from register number of unwind codes to register name.
+var regNames = [16]string{"rax", "rcx", "rdx", "rbx", "rsp", "rbp", "rsi", "rdi", "r8", "r9", "r10", "r11", "r12", "r13", "r14", "r15"}
+
+// parseUnwind parses the exception table (.pdata) of the given x64 PE binary
+// executable, recording the unwinding information (.xdata) of each function in
+// file.Unwind.
+//
+// The exception table is an array of RUNTIME_FUNCTION entries, each specifying
+// the start, end and unwind info RVA of a function.
+//
+//    struct RUNTIME_FUNCTION {
+//       uint32 BeginAddress;
+//       uint32 EndAddress;
+//       uint32 UnwindInfoAddress;
+//    };
+//
+// ref: https://docs.microsoft.com/en-us/cpp/build/exception-handling-x64
+func parseUnwind(file *bin.File, exRVA, exSize uint64) error {
+	as := file.AddressSpace()
+	exAddr := as.VA(exRVA)
+	data, ok := as.Bytes(exAddr, 0)
+	if !ok || uint64(len(data)) < exSize {
+		return errors.Errorf("unable to locate exception table at %v", exAddr)
+	}
+	data = data[:exSize]
+	for off := 0; off+runtimeFuncSize <= len(data); off += runtimeFuncSize {
+		start := binary.LittleEndian.Uint32(data[off:])
+		end := binary.LittleEndian.Uint32(data[off+4:])
+		infoRVA := binary.LittleEndian.Uint32(data[off+8:])
+		if start == 0 && end == 0 {
+			// skip zero padding.
+			continue
+		}
+		u := &bin.Unwind{
+			Start: as.VA(uint64(start)),
+			End:   as.VA(uint64(end)),
+		}
+		if err := parseUnwindInfo(file, u, uint64(infoRVA), 0); err != nil {
+			return errors.WithStack(err)
+		}
+		file.Unwind = append(file.Unwind, u)
+	}
+	file.SortUnwind()
+	dbg.Printf("parsed unwind info of %d functions", len(file.Unwind))
+	return nil
+}
+
+// parseUnwindInfo parses the UNWIND_INFO structure at the given RVA, recording
+// the prologue layout in u. Chained unwind info is followed to locate the
+// parent function.
+//
+//    struct UNWIND_INFO {
+//       uint8 Version:3;
+//       uint8 Flags:5;
+//       uint8 SizeOfProlog;
+//       uint8 CountOfCodes;
+//       uint8 FrameRegister:4;
+//       uint8 FrameOffset:4;
+//       UNWIND_CODE UnwindCode[CountOfCodes (aligned to even)];
+//       // RUNTIME_FUNCTION of parent if UNW_FLAG_CHAININFO is set.
+//    };
+func parseUnwindInfo(file *bin.File, u *bin.Unwind, infoRVA uint64, depth int) error {
+	as := file.AddressSpace()
+	infoAddr := as.VA(infoRVA)
+	data, ok := as.Bytes(infoAddr, 0)
+	if !ok || len(data) < unwindInfoHdrSize {
+		return errors.Errorf("unable to locate unwind info at %v of function at %v", infoAddr, u.Start)
+	}
+	flags := data[0] >> 3
+	ncodes := int(data[2])
+	frameReg := data[3] & 0x0F
+	codesEnd := unwindInfoHdrSize + unwindCodeSize*ncodes
+	if len(data) < codesEnd {
+		return errors.Errorf("truncated unwind info at %v of function at %v", infoAddr, u.Start)
+	}
+	if flags&unwFlagChainInfo != 0 {
+		// The parent RUNTIME_FUNCTION follows the unwind codes, aligned to a
+		// multiple of two unwind codes.
+		chainOff := unwindInfoHdrSize + unwindCodeSize*((ncodes+1)&^1)
+		if len(data) < chainOff+runtimeFuncSize {
+			return errors.Errorf("truncated chained unwind info at %v of function at %v", infoAddr, u.Start)
+		}
+		parent := binary.LittleEndian.Uint32(data[chainOff:])
+		parentInfoRVA := binary.LittleEndian.Uint32(data[chainOff+8:])
+		u.Parent = as.VA(uint64(parent))
+		// Follow the chain to the primary function.
+		if depth < maxChainDepth {
+			p := &bin.Unwind{Start: u.Parent}
+			if err := parseUnwindInfo(file, p, uint64(parentInfoRVA), depth+1); err != nil {
+				return errors.WithStack(err)
+			}
+			if p.Parent != 0 {
+				u.Parent = p.Parent
+			}
+		}
+	}
+	if depth > 0 {
+		// Prologue of parent function not needed.
+		return nil
+	}
+	u.PrologueSize = int(data[1])
+	if frameReg != 0 {
+		u.FrameReg = regNames[frameReg]
+	}
+	return parseUnwindCodes(u, data[unwindInfoHdrSize:codesEnd])
+}
+
+// parseUnwindCodes parses the given unwind codes, recording the stack frame size
+// and saved registers in u.
+//
+// Unwind codes are stored in reverse order of the prologue operations, and are
+// thus simulated from last to first, tracking the size of the stack frame.
+func parseUnwindCodes(u *bin.Unwind, codes []byte) error {
+	// Decode unwind codes; each operation occupies one or more slots.
+	type op struct {
+		code, info uint8
+		// Operand of the operation (e.g. allocation size or save offset).
+		x uint32
+	}
+	var ops []op
+	slot := func(i int) uint16 {
+		return binary.LittleEndian.Uint16(codes[i*unwindCodeSize:])
+	}
+	nslots := len(codes) / unwindCodeSize
+	for i := 0; i < nslots; {
+		o := op{code: codes[i*unwindCodeSize+1] & 0x0F, info: codes[i*unwindCodeSize+1] >> 4}
+		n := 1
+		switch o.code {
+		case uwopPushNonvol, uwopSetFPReg, uwopPushMachFrame:
+		case uwopAllocSmall:
+			o.x = uint32(o.info)*8 + 8
+		case uwopAllocLarge:
+			if o.info == 0 {
+				n = 2
+				if i+n <= nslots {
+					o.x = uint32(slot(i+1)) * 8
+				}
+			} else {
+				n = 3
+				if i+n <= nslots {
+					o.x = uint32(slot(i+1)) | uint32(slot(i+2))<<16
+				}
+			}
+		case uwopSaveNonvol:
+			n = 2
+			if i+n <= nslots {
+				o.x = uint32(slot(i+1)) * 8
+			}
+		case uwopSaveNonvolFar, uwopSaveXMM128Far:
+			n = 3
+			if i+n <= nslots {
+				o.x = uint32(slot(i+1)) | uint32(slot(i+2))<<16
+			}
+		case uwopSaveXMM128:
+			n = 2
+			if i+n <= nslots {
+				o.x = uint32(slot(i+1)) * 16
+			}
+		case uwopEpilog:
+			n = 2
+		case uwopSpareCode:
+			n = 3
+		default:
+			return errors.Errorf("invalid unwind operation code %d of function at %v", o.code, u.Start)
+		}
+		if i+n > nslots {
+			return errors.Errorf("truncated unwind code %d of function at %v", o.code, u.Start)
+		}
+		ops = append(ops, o)
+		i += n
+	}
+	// Simulate prologue; size is the offset from the CFA to the stack pointer,
+	// initially holding the return address.
+	size := int64(returnAddrSize)
+	type save struct {
+		reg string
+		off int64
+	}
+	var saves []save
+	for i := len(ops) - 1; i >= 0; i-- {
+		o := ops[i]
+		switch o.code {
+		case uwopPushNonvol:
+			size += 8
+			u.SavedRegs = append(u.SavedRegs, bin.SavedReg{Reg: regNames[o.info], Offset: -size})
+		case uwopAllocSmall, uwopAllocLarge:
+			size += int64(o.x)
+		case uwopSaveNonvol, uwopSaveNonvolFar:
+			saves = append(saves, save{reg: regNames[o.info], off: int64(o.x)})
+		case uwopSaveXMM128, uwopSaveXMM128Far:
+			saves = append(saves, save{reg: fmt.Sprintf("xmm%d", o.info), off: int64(o.x)})
+		case uwopPushMachFrame:
+			// The machine frame replaces the return address.
+			size -= returnAddrSize
+			if o.info == 0 {
+				size += machFrameSize
+			} else {
+				size += machFrameErrorSize
+			}
+		}
+	}
+	// Saves are relative to the stack pointer after the prologue.
+	for _, s := range saves {
+		u.SavedRegs = append(u.SavedRegs, bin.SavedReg{Reg: s.reg, Offset: s.off - size})
+	}
+	u.FrameSize = size - returnAddrSize
+	return nil
+}
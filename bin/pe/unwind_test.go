@@ -0,0 +1,114 @@
+package pe_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/bin/pe"
+)
+
+func TestParseUnwind(t *testing.T) {
+	golden := []struct {
+		path string
+		want []*bin.Unwind
+	}{
+		{
+			path: "testdata/unwind.exe",
+			want: []*bin.Unwind{
+				// Function which saves rbp, rbx and rsi, and establishes rbp as
+				// frame pointer.
+				//
+				//    _start:
+				//       push rbp
+				//       push rbx
+				//       sub  rsp, 0x28
+				//       lea  rbp, [rsp]
+				//       mov  [rsp+0x40], rsi
+				{
+					Start:        0x140001000,
+					End:          0x140001020,
+					PrologueSize: 15,
+					FrameSize:    56,
+					FrameReg:     "rbp",
+					SavedRegs: []bin.SavedReg{
+						{Reg: "rbp", Offset: -16},
+						{Reg: "rbx", Offset: -24},
+						// Saved in home space of caller.
+						{Reg: "rsi", Offset: 0},
+					},
+				},
+				// Function chunk with chained unwind info.
+				{
+					Start:  0x140001014,
+					End:    0x140001020,
+					Parent: 0x140001000,
+				},
+				// Function with large stack allocation (UWOP_ALLOC_LARGE).
+				//
+				//    f:
+				//       sub  rsp, 0x1008
+				{
+					Start:        0x140001020,
+					End:          0x140001031,
+					PrologueSize: 7,
+					FrameSize:    0x1008,
+				},
+			},
+		},
+	}
+	for _, g := range golden {
+		file, err := pe.ParseFile(g.path)
+		if err != nil {
+			t.Errorf("%q: unable to parse PE executable; %+v", g.path, err)
+			continue
+		}
+		if len(file.Unwind) != len(g.want) {
+			t.Errorf("%q: number of unwind info entries mismatch; expected %d, got %d", g.path, len(g.want), len(file.Unwind))
+			continue
+		}
+		for i, u := range file.Unwind {
+			if !reflect.DeepEqual(u, g.want[i]) {
+				t.Errorf("%q: unwind info %d: mismatch; expected %+v, got %+v", g.path, i, g.want[i], u)
+			}
+		}
+	}
+}
+
+func TestParseUnwindInvalid(t *testing.T) {
+	golden := []struct {
+		path string
+		desc string
+	}{
+		{path: "testdata/invalid/unwind_size.exe", desc: "exception table exceeding section"},
+		{path: "testdata/invalid/unwind_info.exe", desc: "unwind info outside of executable"},
+		{path: "testdata/invalid/unwind_op.exe", desc: "invalid unwind operation code"},
+		{path: "testdata/invalid/unwind_code.exe", desc: "truncated unwind code"},
+	}
+	for _, g := range golden {
+		if _, err := pe.ParseFile(g.path); err == nil {
+			t.Errorf("%q: %s; expected error, got nil", g.path, g.desc)
+		}
+	}
+}
+
+func TestParseUnwindTruncated(t *testing.T) {
+	buf, err := ioutil.ReadFile("testdata/unwind.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := []struct {
+		size int
+		desc string
+	}{
+		{size: 0x610, desc: "exception table"},
+		{size: 0x808, desc: "unwind info"},
+	}
+	for _, g := range golden {
+		if _, err := pe.Parse(bytes.NewReader(buf[:g.size])); err == nil {
+			t.Errorf("truncated within %s (0x%X bytes); expected error, got nil", g.desc, g.size)
+		}
+	}
+}
@@ -44,8 +44,10 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 		return nil, errors.WithStack(err)
 	}
 
-	// Parse machine architecture.
-	file := &bin.File{}
+	// Parse machine architecture; PEF executables are big-endian.
+	file := &bin.File{
+		BigEndian: true,
+	}
 	for _, container := range f.Containers {
 		var arch bin.Arch
 		switch container.Architecture {
@@ -76,6 +78,7 @@ func Parse(r io.ReaderAt) (*bin.File, error) {
 				FileSize: int(s.PackedSize),
 				MemSize:  int(s.TotalSize),
 				Perm:     perm,
+				Align:    1 << s.Alignment,
 			}
 			file.Sections = append(file.Sections, sect)
 		}
@@ -4,7 +4,6 @@ package raw
 import (
 	"io"
 	"io/ioutil"
-	"os"
 
 	"github.com/decomp/exp/bin"
 	"github.com/pkg/errors"
@@ -14,29 +13,41 @@ import (
 //
 // The entry point and base address are both 0 by default. To specify a custom
 // entry point, set file.Entry, and to specify a custom base address, set
-// file.Sections[0].Addr.
+// file.Base and file.Sections[0].Addr. Segmented addresses of 16-bit
+// executables use real mode addressing.
+//
+// The file is memory-mapped, and its contents are loaded lazily on first
+// access. Section data refers to the memory-mapped file, which is therefore
+// kept open until the returned file is closed.
 func ParseFile(path string, arch bin.Arch) (*bin.File, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, errors.WithStack(err)
+	parse := func(m *bin.Mapping) (*bin.File, error) {
+		return parseData(m.Bytes(), arch), nil
 	}
-	defer f.Close()
-	return Parse(f, arch)
+	return bin.ParseMapped(path, parse)
 }
 
 // Parse parses the given raw binary executable, reading from r.
 //
 // The entry point and base address are both 0 by default. To specify a custom
 // entry point, set file.Entry, and to specify a custom base address, set
-// file.Sections[0].Addr.
+// file.Base and file.Sections[0].Addr. Segmented addresses of 16-bit
+// executables use real mode addressing.
 func Parse(r io.Reader, arch bin.Arch) (*bin.File, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return parseData(data, arch), nil
+}
+
+// parseData parses the given raw binary executable contents.
+func parseData(data []byte, arch bin.Arch) *bin.File {
 	// Parse segments.
 	file := &bin.File{
 		Arch: arch,
 	}
-	data, err := ioutil.ReadAll(r)
-	if err != nil {
-		return nil, errors.WithStack(err)
+	if arch == bin.ArchX86_16 {
+		file.Segments = bin.SegmentReal
 	}
 	seg := &bin.Section{
 		Addr:     0,
@@ -46,5 +57,5 @@ func Parse(r io.Reader, arch bin.Arch) (*bin.File, error) {
 		Perm:     bin.PermR | bin.PermW | bin.PermX,
 	}
 	file.Sections = append(file.Sections, seg)
-	return file, nil
+	return file
 }
@@ -0,0 +1,139 @@
+package bin
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// ByteOrder returns the byte order of the binary executable.
+func (file *File) ByteOrder() binary.ByteOrder {
+	if file.BigEndian {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+// PtrSize returns the size in bytes of pointers of the binary executable.
+func (file *File) PtrSize() int {
+	return file.Arch.BitSize() / 8
+}
+
+// ReadUint8 reads an 8-bit unsigned integer at the specified address of the
+// binary executable.
+func (file *File) ReadUint8(addr Address) (uint8, error) {
+	buf, err := file.read(addr, 1)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return buf[0], nil
+}
+
+// ReadUint16 reads a 16-bit unsigned integer at the specified address of the
+// binary executable, in the byte order of the executable.
+func (file *File) ReadUint16(addr Address) (uint16, error) {
+	buf, err := file.read(addr, 2)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return file.ByteOrder().Uint16(buf), nil
+}
+
+// ReadUint32 reads a 32-bit unsigned integer at the specified address of the
+// binary executable, in the byte order of the executable.
+func (file *File) ReadUint32(addr Address) (uint32, error) {
+	buf, err := file.read(addr, 4)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return file.ByteOrder().Uint32(buf), nil
+}
+
+// ReadUint64 reads a 64-bit unsigned integer at the specified address of the
+// binary executable, in the byte order of the executable.
+func (file *File) ReadUint64(addr Address) (uint64, error) {
+	buf, err := file.read(addr, 8)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return file.ByteOrder().Uint64(buf), nil
+}
+
+// ReadPointer reads a pointer at the specified address of the binary
+// executable, in the byte order and pointer size of the executable.
+func (file *File) ReadPointer(addr Address) (Address, error) {
+	switch n := file.PtrSize(); n {
+	case 2:
+		v, err := file.ReadUint16(addr)
+		return Address(v), err
+	case 4:
+		v, err := file.ReadUint32(addr)
+		return Address(v), err
+	case 8:
+		v, err := file.ReadUint64(addr)
+		return Address(v), err
+	default:
+		return 0, errors.Errorf("support for pointer size %d not yet implemented", n)
+	}
+}
+
+// read returns n bytes at the specified address of the binary executable.
+func (file *File) read(addr Address, n int) ([]byte, error) {
+	buf, ok := file.AddressSpace().Bytes(addr, 0)
+	if !ok {
+		return nil, errors.Errorf("unable to locate data at address %v", addr)
+	}
+	if len(buf) < n {
+		return nil, errors.Errorf("unable to read %d bytes at address %v; exceeds section end by %d bytes", n, addr, n-len(buf))
+	}
+	return buf[:n], nil
+}
+
+// ReadUint8 reads an 8-bit unsigned integer at the specified address of the
+// section.
+func (sect *Section) ReadUint8(addr Address) (uint8, error) {
+	buf, err := sect.read(addr, 1)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return buf[0], nil
+}
+
+// ReadUint16 reads a 16-bit unsigned integer at the specified address of the
+// section, in the given byte order.
+func (sect *Section) ReadUint16(addr Address, order binary.ByteOrder) (uint16, error) {
+	buf, err := sect.read(addr, 2)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return order.Uint16(buf), nil
+}
+
+// ReadUint32 reads a 32-bit unsigned integer at the specified address of the
+// section, in the given byte order.
+func (sect *Section) ReadUint32(addr Address, order binary.ByteOrder) (uint32, error) {
+	buf, err := sect.read(addr, 4)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return order.Uint32(buf), nil
+}
+
+// ReadUint64 reads a 64-bit unsigned integer at the specified address of the
+// section, in the given byte order.
+func (sect *Section) ReadUint64(addr Address, order binary.ByteOrder) (uint64, error) {
+	buf, err := sect.read(addr, 8)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return order.Uint64(buf), nil
+}
+
+// read returns n bytes at the specified address of the section.
+func (sect *Section) read(addr Address, n int) ([]byte, error) {
+	if addr < sect.Addr || sect.End() < addr+Address(n) || addr+Address(n) < addr {
+		return nil, errors.Errorf("unable to read %d bytes at address %v; outside of section %q (%v-%v)", n, addr, sect.Name, sect.Addr, sect.End())
+	}
+	off := addr - sect.Addr
+	return sect.Data[off : off+Address(n)], nil
+}
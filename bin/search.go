@@ -0,0 +1,116 @@
+package bin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A Pattern is a byte pattern with wildcards, as used to locate known routines
+// in binary executables (e.g. "55 8B EC ?? ?? 6A ?F").
+type Pattern struct {
+	// Byte values.
+	Bytes []byte
+	// Bit masks of byte values; a set bit indicates a significant bit, a clear
+	// bit indicates a wildcard bit. Mask[i] is 0xFF for exact bytes, 0x00 for
+	// wildcard bytes, and 0xF0 or 0x0F for nibble wildcards.
+	Mask []byte
+}
+
+// ParsePattern parses the given byte pattern. Bytes are specified as pairs of
+// hexadecimal digits, optionally separated by whitespace; where "??" denotes a
+// wildcard byte, and "?" denotes a wildcard nibble (e.g. "55 8B EC ?? ?? 6A ?F").
+func ParsePattern(s string) (*Pattern, error) {
+	digits := strings.Join(strings.Fields(s), "")
+	if len(digits) == 0 {
+		return nil, errors.Errorf("invalid byte pattern %q; empty pattern", s)
+	}
+	if len(digits)%2 != 0 {
+		return nil, errors.Errorf("invalid byte pattern %q; odd number of hexadecimal digits", s)
+	}
+	p := &Pattern{}
+	for i := 0; i < len(digits); i += 2 {
+		var b, mask byte
+		for _, c := range digits[i : i+2] {
+			b <<= 4
+			mask <<= 4
+			if c == '?' {
+				continue
+			}
+			v, err := strconv.ParseUint(string(c), 16, 8)
+			if err != nil {
+				return nil, errors.Errorf("invalid byte pattern %q; invalid hexadecimal digit %q", s, c)
+			}
+			b |= byte(v)
+			mask |= 0xF
+		}
+		p.Bytes = append(p.Bytes, b)
+		p.Mask = append(p.Mask, mask)
+	}
+	return p, nil
+}
+
+// String returns the string representation of the byte pattern (e.g.
+// "55 8B EC ?? ?? 6A ?F").
+func (p *Pattern) String() string {
+	var ss []string
+	for i, b := range p.Bytes {
+		s := fmt.Sprintf("%02X", b)
+		if p.Mask[i]&0xF0 == 0 {
+			s = "?" + s[1:]
+		}
+		if p.Mask[i]&0x0F == 0 {
+			s = s[:1] + "?"
+		}
+		ss = append(ss, s)
+	}
+	return strings.Join(ss, " ")
+}
+
+// Len returns the length in bytes of the byte pattern.
+func (p *Pattern) Len() int {
+	return len(p.Bytes)
+}
+
+// Match reports whether the given data starts with the byte pattern.
+func (p *Pattern) Match(data []byte) bool {
+	if len(data) < len(p.Bytes) {
+		return false
+	}
+	for i, b := range p.Bytes {
+		if data[i]&p.Mask[i] != b&p.Mask[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Index returns the index of the first match of the byte pattern in data at or
+// after the given offset, or -1 if not present.
+func (p *Pattern) Index(data []byte, off int) int {
+	for i := off; i+len(p.Bytes) <= len(data); i++ {
+		if p.Match(data[i:]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Search returns the addresses of all matches of the byte pattern in the
+// section data of the binary executable, in section order. Only sections with
+// the given access permissions are searched (e.g. PermX for code); all
+// sections are searched if perm is zero.
+func (file *File) Search(p *Pattern, perm Perm) []Address {
+	var addrs []Address
+	for _, sect := range file.Sections {
+		if sect.Perm&perm != perm {
+			continue
+		}
+		for i := p.Index(sect.Data, 0); i != -1; i = p.Index(sect.Data, i+1) {
+			addrs = append(addrs, sect.Addr+Address(i))
+		}
+	}
+	return addrs
+}
@@ -0,0 +1,229 @@
+package bin
+
+import (
+	"fmt"
+)
+
+// An AddressSpace provides access to the contents of a binary executable
+// through multiple views; virtual addresses (VAs), relative virtual addresses
+// (RVAs), file offsets and 16-bit segment:offset addresses.
+//
+// Sections may overlap (e.g. overlays loaded at the same address, or segments
+// spanning sections); an address is resolved to the first section in address
+// order which contains it and has the requested access permissions.
+type AddressSpace struct {
+	// Base address of the image; RVAs are relative to the base address.
+	Base Address
+	// Segment model of 16-bit segment:offset addresses.
+	Segments SegmentModel
+	// Sections of the address space, sorted in ascending address order.
+	sects []*Section
+	// Interval tree of the sections, for fast containment queries.
+	index *IntervalTree
+}
+
+// AddressSpace returns the address space of the binary executable. The address
+// space is cached, and rebuilt when sections are added or replaced.
+//
+// pre-condition: file.Sections must be sorted in ascending order, and the
+// address ranges of sections must not change once the address space is in use.
+func (file *File) AddressSpace() *AddressSpace {
+	if as, ok := file.space.Load().(*AddressSpace); ok && as.Base == file.Base && as.Segments == file.Segments && sameSections(as.sects, file.Sections) {
+		return as
+	}
+	ivs := make([]Interval, 0, len(file.Sections))
+	for _, sect := range file.Sections {
+		ivs = append(ivs, Interval{Start: sect.Addr, End: sect.End(), Value: sect})
+	}
+	as := &AddressSpace{
+		Base:     file.Base,
+		Segments: file.Segments,
+		sects:    file.Sections,
+		index:    NewIntervalTree(ivs),
+	}
+	file.space.Store(as)
+	return as
+}
+
+// Sections returns the sections containing the given address, in ascending
+// address order.
+func (as *AddressSpace) Sections(addr Address) []*Section {
+	var sects []*Section
+	for _, iv := range as.index.Containing(addr) {
+		sects = append(sects, iv.Value.(*Section))
+	}
+	return sects
+}
+
+// Section returns the first section containing the given address which has
+// all of the specified access permissions. The boolean return value indicates
+// success.
+func (as *AddressSpace) Section(addr Address, perm Perm) (*Section, bool) {
+	hasPerm := func(iv Interval) bool {
+		return iv.Value.(*Section).Perm&perm == perm
+	}
+	if iv, ok := as.index.Find(addr, hasPerm); ok {
+		return iv.Value.(*Section), true
+	}
+	return nil, false
+}
+
+// Bytes returns the contents starting at the given address, up to the end of
+// the first section containing the address which has all of the specified
+// access permissions. The boolean return value indicates success.
+func (as *AddressSpace) Bytes(addr Address, perm Perm) ([]byte, bool) {
+	sect, ok := as.Section(addr, perm)
+	if !ok {
+		return nil, false
+	}
+	return sect.Data[addr-sect.Addr:], true
+}
+
+// Contains reports whether the given address is contained within a section
+// which has all of the specified access permissions.
+func (as *AddressSpace) Contains(addr Address, perm Perm) bool {
+	_, ok := as.Section(addr, perm)
+	return ok
+}
+
+// Range returns the start address of the first section and the end address of
+// the last section which have all of the specified access permissions (e.g.
+// the code range of the executable). The boolean return value indicates
+// success.
+func (as *AddressSpace) Range(perm Perm) (start, end Address, ok bool) {
+	for _, sect := range as.sects {
+		if sect.Perm&perm != perm {
+			continue
+		}
+		if !ok || sect.Addr < start {
+			start = sect.Addr
+		}
+		if !ok || end < sect.End() {
+			end = sect.End()
+		}
+		ok = true
+	}
+	return start, end, ok
+}
+
+// --- [ Relative virtual addresses ] ------------------------------------------
+
+// VA returns the virtual address of the given relative virtual address.
+func (as *AddressSpace) VA(rva uint64) Address {
+	return as.Base + Address(rva)
+}
+
+// RVA returns the relative virtual address of the given virtual address.
+func (as *AddressSpace) RVA(addr Address) uint64 {
+	return uint64(addr - as.Base)
+}
+
+// --- [ File offsets ] --------------------------------------------------------
+
+// FileOffset returns the file offset of the given address. The boolean return
+// value indicates success, and is false for addresses of uninitialized data not
+// part of the executable file.
+func (as *AddressSpace) FileOffset(addr Address) (uint64, bool) {
+	for _, sect := range as.Sections(addr) {
+		off := uint64(addr - sect.Addr)
+		if off >= uint64(sect.FileSize) {
+			// uninitialized data.
+			continue
+		}
+		return sect.Offset + off, true
+	}
+	return 0, false
+}
+
+// AddrOfFileOffset returns the address of the given file offset. The boolean
+// return value indicates success, and is false for file offsets not mapped
+// into memory (e.g. file headers and overlays appended to the executable).
+func (as *AddressSpace) AddrOfFileOffset(off uint64) (Address, bool) {
+	for _, sect := range as.sects {
+		size := uint64(len(sect.Data))
+		if uint64(sect.FileSize) < size {
+			size = uint64(sect.FileSize)
+		}
+		if sect.Offset <= off && off < sect.Offset+size {
+			return sect.Addr + Address(off-sect.Offset), true
+		}
+	}
+	return 0, false
+}
+
+// --- [ Segmented addresses ] -------------------------------------------------
+
+// SegmentModel specifies how 16-bit segment:offset addresses are mapped to
+// linear addresses.
+type SegmentModel uint8
+
+// Segment models.
+const (
+	// SegmentFlat specifies a flat memory model without segmented addresses.
+	SegmentFlat SegmentModel = iota
+	// SegmentReal specifies real mode addressing; the linear address of
+	// seg:off is seg*16 + off (e.g. DOS executables).
+	SegmentReal
+	// SegmentNumbered specifies that each segment is mapped to a distinct 64 KB
+	// region of the linear address space based on its segment number; the
+	// linear address of seg:off is seg*0x10000 + off (e.g. NE executables).
+	SegmentNumbered
+)
+
+// A FarAddr is a 16-bit segment:offset address.
+type FarAddr struct {
+	// Segment (selector, paragraph or segment number).
+	Seg uint16
+	// Offset within segment.
+	Off uint16
+}
+
+// String returns the string representation of the far address (e.g.
+// "0001:0010").
+func (a FarAddr) String() string {
+	return fmt.Sprintf("%04X:%04X", a.Seg, a.Off)
+}
+
+// Linear returns the linear address of the given far address.
+func (as *AddressSpace) Linear(a FarAddr) Address {
+	switch as.Segments {
+	case SegmentReal:
+		return Address(a.Seg)<<4 + Address(a.Off)
+	case SegmentNumbered:
+		return Address(a.Seg)<<16 | Address(a.Off)
+	default:
+		panic(fmt.Errorf("support for segmented addresses of segment model %d not yet implemented", as.Segments))
+	}
+}
+
+// Far returns the far address of the given linear address, relative to the
+// specified segment. The boolean return value indicates success, and is false
+// if the address is not addressable from the segment.
+func (as *AddressSpace) Far(addr Address, seg uint16) (FarAddr, bool) {
+	base := as.Linear(FarAddr{Seg: seg})
+	if addr < base || addr-base > 0xFFFF {
+		return FarAddr{}, false
+	}
+	return FarAddr{Seg: seg, Off: uint16(addr - base)}, true
+}
+
+// End returns the end address of the section contents.
+func (sect *Section) End() Address {
+	return sect.Addr + Address(len(sect.Data))
+}
+
+// ### [ Helper functions ] ####################################################
+
+// sameSections reports whether the given slices of sections share the same
+// sections, in the same order.
+func sameSections(a, b []*Section) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+package bin
+
+import "sort"
+
+// An Unwind records the stack unwinding information of a function, as specified
+// by the exception handling metadata of the binary executable; the .pdata and
+// .xdata sections of x64 PE executables, or the .eh_frame section of ELF
+// executables. Unwinding information is emitted by the compiler, and thus
+// provides precise function boundaries and prologue layouts.
+type Unwind struct {
+	// Start address of the function.
+	Start Address
+	// End address of the function (exclusive).
+	End Address
+	// Start address of the function containing the function chunk, if the
+	// unwinding information is chained to that of another function (e.g. cold
+	// code split from the function by the compiler); or 0 otherwise.
+	Parent Address
+	// Size in bytes of the prologue; or 0 if unknown.
+	PrologueSize int
+	// Size in bytes of the stack frame established by the prologue, excluding
+	// the return address; i.e. the offset of the return address from the stack
+	// pointer after the prologue.
+	FrameSize int64
+	// Frame pointer register established by the prologue (e.g. "rbp"); or
+	// empty if the stack pointer is used to address the stack frame.
+	FrameReg string
+	// Callee-saved registers stored by the prologue.
+	SavedRegs []SavedReg
+}
+
+// A SavedReg is a callee-saved register stored in the stack frame.
+type SavedReg struct {
+	// Register name (e.g. "rbx").
+	Reg string
+	// Offset of the saved register from the canonical frame address (CFA); the
+	// value of the stack pointer prior to the call instruction.
+	Offset int64
+}
+
+// UnwindAt returns the unwinding information of the function containing the
+// given address. The boolean return value indicates success.
+func (file *File) UnwindAt(addr Address) (*Unwind, bool) {
+	less := func(i int) bool {
+		return addr < file.Unwind[i].Start
+	}
+	index := sort.Search(len(file.Unwind), less) - 1
+	if index < 0 {
+		return nil, false
+	}
+	u := file.Unwind[index]
+	if addr >= u.End {
+		return nil, false
+	}
+	return u, true
+}
+
+// SortUnwind sorts the unwinding information of functions by start address.
+func (file *File) SortUnwind() {
+	less := func(i, j int) bool {
+		return file.Unwind[i].Start < file.Unwind[j].Start
+	}
+	sort.Slice(file.Unwind, less)
+}
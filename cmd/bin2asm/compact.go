@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+)
+
+// Compact Output
+//
+// With the -compact flag, runs of padding bytes (e.g. alignment padding between
+// functions and unused gaps of zero bytes in data sections) are emitted as a
+// single directive rather than one line per byte. To preserve the original
+// virtual layout, the padding extends up to the address following the run,
+// rather than being of fixed length; thus, code and data following the
+// padding remain at their original addresses when preceding code is edited
+// (provided that it fits).
+//
+//    ; padding (11 bytes)
+//    times (0x401030 - _text_vstart) - ($ - $$) db 0xCC
+
+// Minimum length in bytes of padding runs in executable sections (e.g. INT3 or
+// NOP alignment padding between functions).
+const minCodePadding = 2
+
+// Minimum length in bytes of padding runs in data sections (i.e. zero bytes).
+const minDataPadding = 16
+
+// paddingRun returns the length in bytes of the run of padding bytes at the
+// given address of the section. Padding runs end before addresses which are
+// labeled, referenced or part of decoded instructions.
+func paddingRun(sect *bin.Section, addr bin.Address, labels map[bin.Address][]string, prog *x86.Program, xrefs *x86.Xrefs) int {
+	fill, err := sect.ReadUint8(addr)
+	if err != nil {
+		return 0
+	}
+	minLen := minDataPadding
+	if sect.Perm&bin.PermX != 0 {
+		minLen = minCodePadding
+		switch fill {
+		case 0x00, 0x90, 0xCC:
+			// NOP, INT3 and zero padding.
+		default:
+			return 0
+		}
+	} else if fill != 0x00 {
+		return 0
+	}
+	n := 0
+	for a := addr; a < sect.End(); a++ {
+		if a != addr && (len(labels[a]) > 0 || len(xrefs.To(a)) > 0) {
+			break
+		}
+		if _, ok := prog.InstAt(a); ok {
+			break
+		}
+		if b, err := sect.ReadUint8(a); err != nil || b != fill {
+			break
+		}
+		n++
+	}
+	if n < minLen {
+		return 0
+	}
+	return n
+}
+
+// dumpPadding dumps a run of padding bytes of the given fill byte, extending up
+// to the specified end address of the section.
+//
+//    ; padding (11 bytes)
+//    times (0x401030 - _text_vstart) - ($ - $$) db 0xCC
+func dumpPadding(buf *bytes.Buffer, sectName string, end bin.Address, n int, fill byte) {
+	fmt.Fprintf(buf, "; padding (%d bytes)\n", n)
+	fmt.Fprintf(buf, "times (0x%06X - %s_vstart) - ($ - $$) db 0x%02X\n", uint64(end), sectName, fill)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/mewkiz/pkg/jsonutil"
+	"github.com/mewkiz/pkg/osutil"
+	"github.com/pkg/errors"
+)
+
+// Output Templates
+//
+// The scaffolding of the NASM output (e.g. main.asm, the PE header, and section
+// and function headers and footers) is generated from text/template templates,
+// which are located in the source directory of bin2asm. Users targeting other
+// assemblers or build systems may override templates using the bin2asm.json
+// config file, by mapping from template name to the path of a replacement
+// template.
+//
+//    {
+//       "templates": {
+//          "sect-header.asm.tmpl": "my-sect-header.tmpl",
+//          "sect-footer.asm.tmpl": "my-sect-footer.tmpl"
+//       }
+//    }
+//
+// Templates:
+//
+//    main.asm.tmpl          main file, including all other files
+//    pe-hdr.asm.tmpl        PE header
+//    sect-header.asm.tmpl   section header; of sectHeader data
+//    sect-footer.asm.tmpl   section footer; of sectFooter data
+//    func-header.asm.tmpl   function header; of funcHeader data
+
+// A bin2asmConfig is a bin2asm config file.
+type bin2asmConfig struct {
+	// Templates maps from template name to the path of the template overriding
+	// the built-in template.
+	Templates map[string]string `json:"templates"`
+}
+
+// templatePaths maps from template name to the path of the template overriding
+// the built-in template, as specified by the config file.
+var templatePaths = make(map[string]string)
+
+// loadConfig loads the given bin2asm config file, if present.
+func loadConfig(path string) error {
+	if !osutil.Exists(path) {
+		return nil
+	}
+	dbg.Printf("loading config file %q", path)
+	var c bin2asmConfig
+	if err := jsonutil.ParseFile(path, &c); err != nil {
+		return errors.WithStack(err)
+	}
+	for name, path := range c.Templates {
+		templatePaths[name] = path
+	}
+	return nil
+}
+
+// asmTemplates holds the parsed templates of section and function headers and
+// footers.
+type asmTemplates struct {
+	// Section header.
+	sectHeader *template.Template
+	// Section footer.
+	sectFooter *template.Template
+	// Function header.
+	funcHeader *template.Template
+}
+
+// parseAsmTemplates parses the templates of section and function headers and
+// footers.
+func parseAsmTemplates() (*asmTemplates, error) {
+	sectHeader, err := parseTemplate("sect-header.asm.tmpl")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	sectFooter, err := parseTemplate("sect-footer.asm.tmpl")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	funcHeader, err := parseTemplate("func-header.asm.tmpl")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &asmTemplates{sectHeader: sectHeader, sectFooter: sectFooter, funcHeader: funcHeader}, nil
+}
+
+// sectHeader is the data of section header templates.
+type sectHeader struct {
+	// Section name (e.g. ".text").
+	Name string
+	// Section name with dots replaced by underscores (e.g. "_text").
+	Sect string
+	// File offset of the section.
+	Offset uint64
+	// Virtual address of the section.
+	Addr uint64
+	// Access permissions of the section (e.g. "r-x").
+	Perm string
+	// Section flags; or empty if none.
+	Flags string
+	// Section alignment; or 0 if unspecified.
+	Align uint64
+}
+
+// sectFooter is the data of section footer templates.
+type sectFooter struct {
+	// Section name with dots replaced by underscores (e.g. "_text").
+	Sect string
+	// Section with uninitialized data; otherwise section with padding.
+	Uninit bool
+}
+
+// funcHeader is the data of function header templates.
+type funcHeader struct {
+	// Function address.
+	Addr uint64
+	// Name of the section containing the function, with dots replaced by
+	// underscores (e.g. "_text").
+	Sect string
+	// Function label.
+	Name string
+}
+
+// pad pads the given string with spaces to n characters, followed by at least
+// one space.
+func pad(s string, n int) string {
+	if len(s) >= n {
+		return s + " "
+	}
+	return s + strings.Repeat(" ", n-len(s))
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/decomp/exp/bin"
+	"github.com/mewrev/pe"
+)
+
+// A dataDir is a data directory of the PE optional header.
+//
+// The import table, resource table and import address table (IAT) are anchored
+// to labels in the NASM output, which are referenced by the data directory
+// entries of the PE header.
+//
+//    import_table:
+//       ...
+//       import_table_size     equ     $ - import_table
+//
+// Labels are only emitted for valid data directories; i.e. non-empty data
+// directories contained within the contents of a single section. Other data
+// directories (e.g. of resource-only DLLs without imports, or of unusual PE
+// files with truncated or out-of-section data directories) are stored
+// verbatim.
+type dataDir struct {
+	pe.DataDirectory
+	// Label of the data directory; or empty if not anchored to a label.
+	Label string
+	// Field name of the data directory in the PE header; or empty if not
+	// anchored to a label.
+	Field string
+	// Start and end address of the data directory.
+	Addr, End bin.Address
+}
+
+// dataDirNames maps from data directory index to the label and field name of
+// anchored data directories.
+var dataDirNames = map[int]struct{ label, field string }{
+	1:  {label: "import_table", field: "import_table"},
+	2:  {label: "resource_table", field: "resource_table"},
+	12: {label: "iat", field: "import_address_table"},
+}
+
+// dataDirs returns the data directories of the given optional header, with
+// labels of valid anchored data directories.
+func dataDirs(binFile *bin.File, optHdr *pe.OptHeader) []*dataDir {
+	as := binFile.AddressSpace()
+	var dirs []*dataDir
+	for i, d := range optHdr.DataDirs {
+		dir := &dataDir{DataDirectory: d}
+		dirs = append(dirs, dir)
+		names, ok := dataDirNames[i]
+		if !ok {
+			continue
+		}
+		if d.RelAddr == 0 || d.Size == 0 {
+			dbg.Printf("data directory %d (%s) not present", i, names.field)
+			continue
+		}
+		dir.Addr = as.VA(uint64(d.RelAddr))
+		dir.End = dir.Addr + bin.Address(d.Size)
+		if !inSection(binFile, dir.Addr, dir.End) {
+			warn.Printf("data directory %d (%s) at %v-%v not contained within a section; stored verbatim", i, names.field, dir.Addr, dir.End)
+			continue
+		}
+		dir.Label = names.label
+		dir.Field = names.field
+	}
+	return dirs
+}
+
+// hasEntry reports whether the entry point of the given binary executable is
+// anchored to the start label; i.e. whether the entry point is present and
+// located within the contents of a section. Resource-only DLLs have no entry
+// point.
+func hasEntry(binFile *bin.File, optHdr *pe.OptHeader) bool {
+	if optHdr.EntryRelAddr == 0 {
+		return false
+	}
+	entry := binFile.AddressSpace().VA(uint64(optHdr.EntryRelAddr))
+	return inSection(binFile, entry, entry)
+}
+
+// inSection reports whether the address range [start, end] is contained within
+// the contents of a single section of the binary executable.
+func inSection(binFile *bin.File, start, end bin.Address) bool {
+	for _, sect := range binFile.Sections {
+		if len(sect.Name) == 0 {
+			// Ignore segments, as they are not dumped.
+			continue
+		}
+		if sect.Addr <= start && end <= sect.End() {
+			return true
+		}
+	}
+	return false
+}
+
+// sectionLabels returns the labels of the entry point and valid data
+// directories, mapping from address to label definitions.
+func sectionLabels(binFile *bin.File, optHdr *pe.OptHeader) map[bin.Address][]string {
+	labels := make(map[bin.Address][]string)
+	for _, dir := range dataDirs(binFile, optHdr) {
+		if len(dir.Label) == 0 {
+			continue
+		}
+		labels[dir.Addr] = append(labels[dir.Addr], fmt.Sprintf("\n%s:\n", dir.Label))
+		size := dir.Label + "_size"
+		labels[dir.End] = append(labels[dir.End], fmt.Sprintf("\n   %-21sequ     $ - %s\n", size, dir.Label))
+	}
+	if hasEntry(binFile, optHdr) {
+		entry := binFile.AddressSpace().VA(uint64(optHdr.EntryRelAddr))
+		labels[entry] = append(labels[entry], "\nstart:\n")
+	}
+	return labels
+}
@@ -10,12 +10,14 @@ import (
 	"text/template"
 	"unicode"
 
+	"github.com/decomp/exp/bin"
 	"github.com/mewrev/pe"
 	"github.com/pkg/errors"
 )
 
-// dumpMainAsm dumps the main.asm file of the executable.
-func dumpMainAsm(file *pe.File, hasOverlay bool) error {
+// dumpMainAsm dumps the main.asm file of the executable; linkable against
+// import libraries if link is set.
+func dumpMainAsm(binFile *bin.File, file *pe.File, hasOverlay, link bool) error {
 	t, err := parseTemplate("main.asm.tmpl")
 	if err != nil {
 		return errors.WithStack(err)
@@ -24,13 +26,22 @@ func dumpMainAsm(file *pe.File, hasOverlay bool) error {
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	var data []string
+	optHdr, err := file.OptHeader()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	var sects []string
 	for _, sectHdr := range sectHdrs {
 		sectName := underline(sectHdr.Name)
-		data = append(data, sectName)
+		sects = append(sects, sectName)
 	}
 	if hasOverlay {
-		data = append(data, "overlay")
+		sects = append(sects, "overlay")
+	}
+	data := map[string]interface{}{
+		"Sects":    sects,
+		"Link":     link,
+		"HasEntry": hasEntry(binFile, optHdr),
 	}
 	// Store output.
 	if err := writeFile(t, "main.asm", data); err != nil {
@@ -40,7 +51,7 @@ func dumpMainAsm(file *pe.File, hasOverlay bool) error {
 }
 
 // dumpPEHeaderAsm dumps the pe-hdr.asm file of the executable.
-func dumpPEHeaderAsm(file *pe.File) error {
+func dumpPEHeaderAsm(binFile *bin.File, file *pe.File) error {
 	t, err := parseTemplate("pe-hdr.asm.tmpl")
 	if err != nil {
 		return errors.WithStack(err)
@@ -92,7 +103,8 @@ func dumpPEHeaderAsm(file *pe.File) error {
 		"SectAlignKB": sectAlignKB,
 		"SectHdrs":    sectHdrs,
 		"DataSizes":   strings.Join(dataSizes, " + "),
-		"DataDirs":    optHdr.DataDirs,
+		"DataDirs":    dataDirs(binFile, optHdr),
+		"HasEntry":    hasEntry(binFile, optHdr),
 	}
 	if err := writeFile(t, "pe-hdr.asm", data); err != nil {
 		return errors.WithStack(err)
@@ -121,17 +133,25 @@ func writeFile(t *template.Template, filename string, data interface{}) error {
 	return nil
 }
 
-// parseTemplate parses and returns the given template.
+// parseTemplate parses and returns the given template; as overridden by the
+// config file, or the built-in template otherwise.
 func parseTemplate(filename string) (*template.Template, error) {
 	funcMap := map[string]interface{}{
 		"isprint":   isPrint,
 		"underline": underline,
 		"nameArray": nameArray,
+		"pad":       pad,
 		"ui16":      ui16,
 		"ui32":      ui32,
 	}
 	path := filepath.Join(bin2asmDir, filename)
-	t, err := template.New(filename).Funcs(funcMap).ParseFiles(path)
+	if p, ok := templatePaths[filename]; ok {
+		dbg.Printf("using template %q for %q", p, filename)
+		path = p
+	}
+	// The template is named after the base name of its file, as required by
+	// ParseFiles.
+	t, err := template.New(filepath.Base(path)).Funcs(funcMap).ParseFiles(path)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -23,8 +23,10 @@ func init() {
 	}
 }
 
-// dumpCommon dumps a common include file of the executable.
-func dumpCommon(file *pe.File) error {
+// dumpCommon dumps a common include file of the executable; declaring the
+// sections of a COFF object file if link is set, and of a flat binary
+// otherwise.
+func dumpCommon(file *pe.File, link bool) error {
 	buf := &bytes.Buffer{}
 	const commonFormat = `
 %%ifndef __COMMON_INC__
@@ -73,21 +75,32 @@ func dumpCommon(file *pe.File) error {
 	}
 	buf.WriteString("\n")
 
-	const bitsHeader = `
+	if link {
+		buf.WriteString("BITS 32\n\n")
+		for _, sectHdr := range sectHdrs {
+			fmt.Fprintf(buf, "SECTION %s  %s\n", sectHdr.Name, coffSectKind(sectHdr))
+		}
+		buf.WriteString("\n")
+	} else {
+		const bitsHeader = `
 BITS 32
 
-SECTION hdr    vstart=hdr_vstart
+SECTION hdr  progbits  vstart=hdr_vstart
 `
-	buf.WriteString(bitsHeader[1:])
+		buf.WriteString(bitsHeader[1:])
 
-	prev := "hdr"
-	for _, sectHdr := range sectHdrs {
-		rawName := sectHdr.Name
-		sectName := strings.Replace(rawName, ".", "_", -1)
-		fmt.Fprintf(buf, "SECTION %s  vstart=%s_vstart  follows=%s\n", rawName, sectName, prev)
-		prev = rawName
+		prev := "hdr"
+		for _, sectHdr := range sectHdrs {
+			rawName := sectHdr.Name
+			sectName := strings.Replace(rawName, ".", "_", -1)
+			// Sections are progbits, as their raw data is part of the file;
+			// trailing uninitialized data is implied by the virtual size in the
+			// PE header.
+			fmt.Fprintf(buf, "SECTION %s  progbits  vstart=%s_vstart  follows=%s\n", rawName, sectName, prev)
+			prev = rawName
+		}
+		buf.WriteString("\n")
 	}
-	buf.WriteString("\n")
 	buf.WriteString("%endif ; %ifndef __COMMON_INC__\n")
 
 	// Store output.
@@ -98,3 +111,15 @@ SECTION hdr    vstart=hdr_vstart
 	}
 	return nil
 }
+
+// coffSectKind returns the NASM section type of the given section in COFF
+// object files.
+func coffSectKind(sectHdr *pe.SectHeader) string {
+	switch {
+	case sectHdr.Flags&pe.SectFlagCode != 0:
+		return "code"
+	case sectHdr.Flags&pe.SectFlagMemWrite != 0:
+		return "data"
+	}
+	return "rdata"
+}
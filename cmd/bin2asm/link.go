@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/bin/demangle"
+	"github.com/decomp/exp/disasm/x86"
+	x86lift "github.com/decomp/exp/lift/x86"
+	"github.com/pkg/errors"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// Symbolized Output
+//
+// With the -link flag, bin2asm emits NASM assembly which is assembled to a COFF
+// object file (nasm -f win32) and linked against the import libraries of the
+// executable, rather than reproducing the executable byte by byte (nasm -f
+// bin). The PE header and import tables are thus generated by the linker.
+//
+// Imported functions are declared as external symbols of their import
+// libraries (see imports.inc), named by the decorated names of the function
+// prototype database (see lift/x86).
+//
+//    extern __imp__CreateFileA@28
+//
+// References to import address table entries by instructions are fixed up to
+// refer to the external symbols, while preserving the original instruction
+// encoding.
+//
+//      addr_401000:          db      0xFF, 0x15                                      ; call   [CreateFileA]
+//                            dd      __imp__CreateFileA@28
+//
+// Other absolute addresses are not symbolized; the sections must therefore be
+// linked at their original addresses (e.g. using /BASE and /FIXED, with the
+// original section alignment), and the import tables of the original
+// executable are retained as data.
+
+// importSymbols returns the external symbol names of the imports of the given
+// binary executable, mapping from import address table entry to symbol name.
+func importSymbols(binFile *bin.File) map[bin.Address]string {
+	mode := binFile.Arch.BitSize()
+	protos, err := x86lift.LoadProtos(mode)
+	if err != nil {
+		warn.Printf("unable to load function prototype database; %v", err)
+	}
+	syms := make(map[bin.Address]string)
+	for addr, name := range binFile.Imports {
+		switch {
+		case strings.Contains(name, "_ordinal_"):
+			warn.Printf("unable to symbolize import %q at %v; imported by ordinal", name, addr)
+			continue
+		case demangle.IsMangled(name):
+			// Decorated C++ symbol.
+			syms[addr] = "__imp_" + name
+		default:
+			if p, ok := protos[name]; ok {
+				syms[addr] = "__imp_" + p.DecoratedName(mode)
+				continue
+			}
+			if mode == 32 {
+				warn.Printf("unable to locate prototype of import %q; assuming C calling convention", name)
+				syms[addr] = "__imp__" + name
+			} else {
+				syms[addr] = "__imp_" + name
+			}
+		}
+	}
+	return syms
+}
+
+// dumpImportsInc dumps the imports.inc file of the executable, declaring the
+// given external symbols of imports.
+func dumpImportsInc(syms map[bin.Address]string) error {
+	var names []string
+	for _, name := range syms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	buf := &bytes.Buffer{}
+	buf.WriteString("; Imported functions.\n\n")
+	prev := ""
+	for _, name := range names {
+		if name == prev {
+			continue
+		}
+		fmt.Fprintf(buf, "extern %s\n", name)
+		prev = name
+	}
+	outPath := filepath.Join(outDir, "imports.inc")
+	dbg.Printf("creating %q\n", outPath)
+	if err := ioutil.WriteFile(outPath, buf.Bytes(), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// instFixup returns the offset within the encoding of the given instruction of
+// the 32-bit absolute address of an import address table entry referenced by
+// the instruction (e.g. call [0x40A0B4]), and the external symbol name of the
+// import. The boolean return value indicates success.
+func instFixup(inst *x86.Inst, data []byte, fixups map[bin.Address]string) (int, string, bool) {
+	for _, arg := range inst.Args {
+		var addr uint32
+		switch arg := arg.(type) {
+		case x86asm.Mem:
+			if arg.Base != 0 || arg.Index != 0 {
+				continue
+			}
+			addr = uint32(arg.Disp)
+		case x86asm.Imm:
+			addr = uint32(arg)
+		default:
+			continue
+		}
+		sym, ok := fixups[bin.Address(addr)]
+		if !ok {
+			continue
+		}
+		enc := make([]byte, 4)
+		binary.LittleEndian.PutUint32(enc, addr)
+		if off := bytes.Index(data, enc); off > 0 {
+			return off, sym, true
+		}
+	}
+	return 0, "", false
+}
+
+// dumpFixup dumps the given instruction in NASM syntax, with the 32-bit address
+// at the given offset of the instruction encoding replaced by a reference to
+// the given external symbol.
+//
+//      addr_401000:          db      0xFF, 0x15                                      ; call   [CreateFileA]
+//                            dd      __imp__CreateFileA@28
+func dumpFixup(buf *bytes.Buffer, addr bin.Address, data []byte, off int, sym, asm string) {
+	const indent = "                        "
+	fmt.Fprintf(buf, "  addr_%06X:          db      %s", uint64(addr), byteList(data[:off]))
+	pad := " "
+	if n := 80 - (len("  addr_401000:          db      ") + len("0x00")*off + len(", ")*(off-1)); n > 0 {
+		pad = strings.Repeat(" ", n)
+	}
+	fmt.Fprintf(buf, "%s; %s\n", pad, asm)
+	fmt.Fprintf(buf, "%sdd      %s\n", indent, sym)
+	if rest := data[off+4:]; len(rest) > 0 {
+		fmt.Fprintf(buf, "%sdb      %s\n", indent, byteList(rest))
+	}
+}
+
+// byteList returns the given bytes as a comma-separated list in NASM syntax.
+func byteList(data []byte) string {
+	var ss []string
+	for _, b := range data {
+		ss = append(ss, fmt.Sprintf("0x%02X", b))
+	}
+	return strings.Join(ss, ", ")
+}
@@ -7,12 +7,20 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 
 	"github.com/decomp/exp/bin"
-	_ "github.com/decomp/exp/bin/elf" // register ELF decoder
-	_ "github.com/decomp/exp/bin/pe"  // register PE decoder
-	_ "github.com/decomp/exp/bin/pef" // register PEF decoder
+	_ "github.com/decomp/exp/bin/coff" // register COFF decoder
+	"github.com/decomp/exp/bin/demangle"
+	_ "github.com/decomp/exp/bin/elf"   // register ELF decoder
+	_ "github.com/decomp/exp/bin/le"    // register LE/LX decoder
+	"github.com/decomp/exp/bin/memdump" // register minidump decoder
+	_ "github.com/decomp/exp/bin/ne"    // register NE decoder
+	_ "github.com/decomp/exp/bin/pe"    // register PE decoder
+	_ "github.com/decomp/exp/bin/pef"   // register PEF decoder
 	"github.com/decomp/exp/bin/raw"
+	"github.com/decomp/exp/config"
+	"github.com/decomp/exp/disasm"
 	"github.com/decomp/exp/disasm/x86"
 	"github.com/mewkiz/pkg/term"
 	"github.com/mewrev/pe"
@@ -48,6 +56,10 @@ func main() {
 	var (
 		// blockAddr specifies a basic block address to disassemble.
 		blockAddr bin.Address
+		// compact specifies whether to compact runs of padding bytes.
+		compact bool
+		// configPath specifies the path of the bin2asm config file.
+		configPath string
 		// TODO: Remove -first flag and firstAddr.
 		// firstAddr specifies the first function address to disassemble.
 		firstAddr bin.Address
@@ -56,25 +68,44 @@ func main() {
 		// TODO: Remove -last flag and lastAddr.
 		// lastAddr specifies the last function address to disassemble.
 		lastAddr bin.Address
+		// link specifies whether to emit symbolized NASM assembly linkable
+		// against import libraries.
+		link bool
+		// naming specifies the naming scheme of function labels.
+		naming = disasm.NamingIDA
 		// quiet specifies whether to suppress non-error messages.
 		quiet bool
+		// modMap specifies the module map of a raw process memory dump.
+		modMap string
 		// rawArch specifies the machine architecture of a raw binary executable.
 		rawArch bin.Arch
 		// rawEntry specifies the entry point of a raw binary executable.
 		rawEntry bin.Address
 		// rawBase specifies the base address of a raw binary executable.
 		rawBase bin.Address
+		// march specifies the micro-architecture of instruction timings.
+		march x86.MicroArch
 	)
 	flag.Usage = usage
 	flag.Var(&blockAddr, "block", "basic block address to disassemble")
+	flag.BoolVar(&compact, "compact", false, "compact alignment padding and unused gaps, preserving the original layout")
+	flag.StringVar(&configPath, "config", "bin2asm.json", "config file overriding output templates (JSON)")
 	flag.Var(&firstAddr, "first", "first function address to disassemble")
 	flag.Var(&funcAddr, "func", "function address to disassemble")
 	flag.Var(&lastAddr, "last", "last function address to disassemble")
+	flag.BoolVar(&link, "link", false, "emit symbolized NASM assembly linkable against import libraries (nasm -f win32)")
+	flag.Var(&naming, "naming", "naming scheme of function labels (default, ida or ghidra)")
 	flag.BoolVar(&quiet, "q", false, "suppress non-error messages")
+	flag.StringVar(&modMap, "modmap", "", "module map of raw process memory dump (JSON)")
 	flag.Var(&rawArch, "raw", "machine architecture of raw binary executable (x86_32, x86_64, MIPS_32, PowerPC_32, ...)")
 	flag.Var(&rawEntry, "rawentry", "entry point of raw binary executable")
 	flag.Var(&rawBase, "rawbase", "base address of raw binary executable")
+	flag.Var(&march, "timing", "annotate instructions with approximate latency and micro-operations of micro-architecture (8086, 386, 486, p5, p6 or skylake)")
 	flag.Parse()
+	// Apply project configuration file.
+	if err := config.Setup("bin2asm", flag.CommandLine, true); err != nil {
+		log.Fatalf("%+v", err)
+	}
 	if flag.NArg() != 1 {
 		flag.Usage()
 		os.Exit(1)
@@ -86,11 +117,22 @@ func main() {
 		warn.SetOutput(ioutil.Discard)
 	}
 
+	// Load config file.
+	if err := loadConfig(configPath); err != nil {
+		log.Fatalf("%+v", err)
+	}
+	tmpls, err := parseAsmTemplates()
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+
 	// Prepare disassembler for the binary executable.
-	dis, err := newDisasm(binPath, rawArch, rawEntry, rawBase)
+	dis, err := newDisasm(binPath, modMap, rawArch, rawEntry, rawBase)
 	if err != nil {
 		log.Fatalf("%+v", err)
 	}
+	naming.Demangle = demangle.Name
+	dis.Naming = naming
 	// Disassemble basic block.
 	if blockAddr != 0 {
 		block, err := dis.DecodeBlock(blockAddr)
@@ -124,6 +166,7 @@ func main() {
 		}
 		fs = append(fs, f)
 	}
+	prog := x86.NewProgram(fs)
 
 	// Create output directory.
 	if err := os.MkdirAll(outDir, 0755); err != nil {
@@ -141,24 +184,46 @@ func main() {
 		log.Fatalf("%+v", err)
 	}
 	hasOverlay := len(overlay) > 0
+	if link && hasOverlay {
+		warn.Printf("overlay (%d bytes) not part of linked output", len(overlay))
+		hasOverlay = false
+	}
 
 	// Dump main file.
-	if err := dumpMainAsm(file, hasOverlay); err != nil {
+	if err := dumpMainAsm(dis.File, file, hasOverlay, link); err != nil {
 		log.Fatalf("%+v", err)
 	}
 
 	// Dump common include file.
-	if err := dumpCommon(file); err != nil {
+	if err := dumpCommon(file, link); err != nil {
 		log.Fatalf("%+v", err)
 	}
 
-	// Dump PE header in NASM syntax.
-	if err := dumpPEHeaderAsm(file); err != nil {
+	// Dump external symbols of imports, as referenced by symbolized output; or
+	// PE header in NASM syntax.
+	var fixups map[bin.Address]string
+	if link {
+		fixups = importSymbols(dis.File)
+		if err := dumpImportsInc(fixups); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	} else {
+		if err := dumpPEHeaderAsm(dis.File, file); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	}
+
+	// Dump issue report.
+	issuesPath := filepath.Join(outDir, "issues.json")
+	issues := dis.Issues(fs)
+	dbg.Printf("storing %d issues in %q", len(issues), issuesPath)
+	if err := x86.WriteIssues(issuesPath, issues); err != nil {
 		log.Fatalf("%+v", err)
 	}
 
 	// Dump sections in NASM syntax.
-	if err := dumpSections(dis.File.Sections, file, fs); err != nil {
+	xrefs := dis.Xrefs(fs)
+	if err := dumpSections(dis.File, file, prog, xrefs, fixups, dis.Naming, march, compact, tmpls); err != nil {
 		log.Fatalf("%+v", err)
 	}
 
@@ -174,7 +239,15 @@ func main() {
 const outDir = "_dump_"
 
 // newDisasm returns a new disassembler for the given binary executable.
-func newDisasm(binPath string, rawArch bin.Arch, rawEntry, rawBase bin.Address) (*x86.Disasm, error) {
+func newDisasm(binPath, modMap string, rawArch bin.Arch, rawEntry, rawBase bin.Address) (*x86.Disasm, error) {
+	// Parse raw process memory dump.
+	if len(modMap) > 0 {
+		file, err := memdump.ParseRaw(binPath, modMap)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return x86.NewDisasm(file)
+	}
 	// Parse raw binary executable.
 	if rawArch != 0 {
 		file, err := raw.ParseFile(binPath, rawArch)
@@ -182,6 +255,7 @@ func newDisasm(binPath string, rawArch bin.Arch, rawEntry, rawBase bin.Address)
 			return nil, errors.WithStack(err)
 		}
 		file.Entry = rawEntry
+		file.Base = rawBase
 		file.Sections[0].Addr = rawBase
 		return x86.NewDisasm(file)
 	}
@@ -8,50 +8,44 @@ import (
 	"strings"
 
 	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/bin/demangle"
+	"github.com/decomp/exp/disasm"
 	"github.com/decomp/exp/disasm/x86"
 	"github.com/mewrev/pe"
 	"github.com/pkg/errors"
-	"golang.org/x/arch/x86/x86asm"
 )
 
-// dumpSections dumps the given sections in NASM syntax.
-func dumpSections(sects []*bin.Section, file *pe.File, fs []*x86.Func) error {
-	// Index functions, basic blocks and instructions.
-	funcs := make(map[bin.Address]*x86.Func)
-	blocks := make(map[bin.Address]*x86.BasicBlock)
-	insts := make(map[bin.Address]*x86.Inst)
-	for _, f := range fs {
-		funcs[f.Addr] = f
-		for _, block := range f.Blocks {
-			blocks[block.Addr] = block
-			for _, inst := range block.Insts {
-				insts[inst.Addr] = inst
-			}
-			if !block.Term.IsDummyTerm() {
-				insts[block.Term.Addr] = block.Term
-			}
-		}
-	}
+// dumpSections dumps the sections of the given binary executable in NASM syntax.
+// Referenced addresses are annotated with their cross-references, labels are
+// named based on the given naming scheme, and instructions are annotated with
+// their approximate timing on the given micro-architecture (if any).
+// References to import address table entries are fixed up to refer to the
+// given external symbols of imports (if any), and runs of padding bytes are
+// compacted if compact is set. Section and function headers and footers are
+// generated from the given templates.
+func dumpSections(binFile *bin.File, file *pe.File, prog *x86.Program, xrefs *x86.Xrefs, fixups map[bin.Address]string, naming disasm.Naming, march x86.MicroArch, compact bool, tmpls *asmTemplates) error {
 	optHdr, err := file.OptHeader()
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	entry := bin.Address(optHdr.ImageBase + optHdr.EntryRelAddr)
-	imageBase := bin.Address(optHdr.ImageBase)
-	dataDirs := optHdr.DataDirs
-	for _, sect := range sects {
+	// Index symbol names of imports and exports; used in comments.
+	syms := make(map[bin.Address]string)
+	for addr, name := range binFile.Imports {
+		syms[addr] = name
+	}
+	for addr, name := range binFile.Exports {
+		syms[addr] = name
+	}
+	labels := sectionLabels(binFile, optHdr)
+	for _, sect := range binFile.Sections {
 		if len(sect.Name) == 0 {
 			// Ignore segments.
 			continue
 		}
-		data := func(addr bin.Address) (byte, bool) {
-			i := int(addr - sect.Addr)
-			if i < 0 || i >= len(sect.Data) {
-				return 0, false
-			}
-			return sect.Data[addr-sect.Addr], true
+		buf, err := dumpSection(sect, labels, prog, xrefs, syms, fixups, naming, march, compact, tmpls)
+		if err != nil {
+			return errors.WithStack(err)
 		}
-		buf := dumpSection(sect, entry, imageBase, dataDirs, funcs, blocks, insts, data)
 		filename := strings.Replace(sect.Name, ".", "_", -1) + ".asm"
 		outPath := filepath.Join(outDir, filename)
 		dbg.Printf("creating %q\n", outPath)
@@ -62,8 +56,9 @@ func dumpSections(sects []*bin.Section, file *pe.File, fs []*x86.Func) error {
 	return nil
 }
 
-// dumpSection dumps the given section in NASM syntax.
-func dumpSection(sect *bin.Section, entry, imageBase bin.Address, dataDirs []pe.DataDirectory, funcs map[bin.Address]*x86.Func, blocks map[bin.Address]*x86.BasicBlock, insts map[bin.Address]*x86.Inst, data func(addr bin.Address) (byte, bool)) []byte {
+// dumpSection dumps the given section in NASM syntax, defining the given labels
+// (e.g. of the entry point and data directories) at their addresses.
+func dumpSection(sect *bin.Section, labels map[bin.Address][]string, prog *x86.Program, xrefs *x86.Xrefs, syms, fixups map[bin.Address]string, naming disasm.Naming, march x86.MicroArch, compact bool, tmpls *asmTemplates) ([]byte, error) {
 	buf := &bytes.Buffer{}
 	sectName := strings.Replace(sect.Name, ".", "_", -1)
 	// Dump section header.
@@ -72,44 +67,35 @@ func dumpSection(sect *bin.Section, entry, imageBase bin.Address, dataDirs []pe.
 	//    ;
 	//    ;    file offset:    0x00000400
 	//    ;    virtual offset: 0x00401000
+	//    ;    permissions:    r-x
+	//    ;    alignment:      0x1000
 	//
 	//    SECTION .text
-	const sectHeader = `
-; <%s>
-;
-;    file offset:    0x%08X
-;    virtual offset: 0x%08X
-
-SECTION %s
-
-`
-	fmt.Fprintf(buf, sectHeader[1:], sect.Name, sect.Offset, uint64(sect.Addr), sect.Name)
-	end := sect.Addr + bin.Address(len(sect.Data))
-	// Import table.
-	itAddr := imageBase + bin.Address(dataDirs[1].RelAddr)
-	itEnd := itAddr + bin.Address(dataDirs[1].Size)
-	// Resource table.
-	rsrcTableAddr := imageBase + bin.Address(dataDirs[2].RelAddr)
-	rsrcTableEnd := rsrcTableAddr + bin.Address(dataDirs[2].Size)
-	// Import address table.
-	iatAddr := imageBase + bin.Address(dataDirs[12].RelAddr)
-	iatEnd := iatAddr + bin.Address(dataDirs[12].Size)
+	hdr := sectHeader{
+		Name:   sect.Name,
+		Sect:   sectName,
+		Offset: sect.Offset,
+		Addr:   uint64(sect.Addr),
+		Perm:   sect.Perm.String(),
+		Align:  uint64(sect.Align),
+	}
+	if sect.Flags != 0 {
+		hdr.Flags = sect.Flags.String()
+	}
+	if err := tmpls.sectHeader.Execute(buf, hdr); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// Resolve symbol names of instruction operands (e.g. call targets).
+	symname := func(addr uint64) (string, uint64) {
+		if name, ok := syms[bin.Address(addr)]; ok {
+			return naming.SymbolName(name), addr
+		}
+		return "", 0
+	}
+	end := sect.End()
 	for addr := sect.Addr; addr <= end; {
-		switch addr {
-		case entry:
-			buf.WriteString("\nstart:\n")
-		case itAddr:
-			buf.WriteString("\nimport_table:\n")
-		case itEnd:
-			buf.WriteString("\n   import_table_size    equ     $ - import_table\n")
-		case rsrcTableAddr:
-			buf.WriteString("\nresource_table:\n")
-		case rsrcTableEnd:
-			buf.WriteString("\n   resource_table_size  equ     $ - $$\n")
-		case iatAddr:
-			buf.WriteString("\niat:\n")
-		case iatEnd:
-			buf.WriteString("\niat_size             equ     $ - iat\n")
+		for _, label := range labels[addr] {
+			buf.WriteString(label)
 		}
 		a := uint64(addr)
 		if sect.Perm&bin.PermX != 0 {
@@ -117,50 +103,81 @@ SECTION %s
 			//
 			//    times (0x401000 - _text_vstart) - ($ - $$) db 0xCC
 			//    sub_401000:
-			if _, ok := funcs[addr]; ok {
+			if _, ok := prog.FuncAt(addr); ok {
 				if addr != sect.Addr {
 					buf.WriteString("\n")
 				}
-				const funcHeader = `
-times (0x%06X - %s_vstart) - ($ - $$) db 0xCC
-sub_%06X:
-`
-				fmt.Fprintf(buf, funcHeader[1:], a, sectName, a)
+				// Relative names (e.g. "DllMain+0x40") are not valid NASM labels;
+				// thus exports are not used as anchors.
+				hdr := funcHeader{Addr: a, Sect: sectName, Name: naming.FuncName(addr, nil)}
+				if err := tmpls.funcHeader.Execute(buf, hdr); err != nil {
+					return nil, errors.WithStack(err)
+				}
+				if name, ok := syms[addr]; ok {
+					// Dump symbol name; the prototype of C++ functions.
+					//
+					//    ; int __thiscall Foo::bar(char const*)
+					if sym, err := demangle.Demangle(name); err == nil {
+						name = sym.Proto()
+					}
+					fmt.Fprintf(buf, "; %s\n", name)
+				}
 			}
 			// Dump basic block header.
-			if _, ok := blocks[addr]; ok {
-				fmt.Fprintf(buf, "; block_%06X\n", a)
+			if _, ok := prog.BlockAt(addr); ok {
+				fmt.Fprintf(buf, "; %s\n", naming.BlockName(addr))
 			}
 			// Dump instruction.
 			//
+			//    ; xref from 0x4010AB (call)
 			//    addr_401000:          db      0x83, 0xEC, 0x08                                ; sub    esp,0x8
-			if inst, ok := insts[addr]; ok {
-				fmt.Fprintf(buf, "  addr_%06X:          db      ", a)
-				for i := 0; i < inst.Len; i++ {
-					if i != 0 {
-						fmt.Fprint(buf, ", ")
-					}
-					b, ok := data(addr + bin.Address(i))
-					if !ok {
-						panic(fmt.Errorf("unable to locate data at %v", addr+bin.Address(i)))
+			if inst, ok := prog.InstAt(addr); ok {
+				dumpXrefs(buf, xrefs, addr)
+				data := make([]byte, inst.Len)
+				for i := range data {
+					b, err := sect.ReadUint8(addr + bin.Address(i))
+					if err != nil {
+						panic(fmt.Errorf("unable to locate data at %v; %v", addr+bin.Address(i), err))
 					}
-					fmt.Fprintf(buf, "0x%02X", b)
-
+					data[i] = b
 				}
-				pad := " "
-				if n := 80 - (len("  addr_401000:          db      ") + len("0x00")*inst.Len + len(", ")*(inst.Len-1)); n > 0 {
-					pad = strings.Repeat(" ", n)
+				asm := x86.FormatInst(inst, symname)
+				// Dump instruction timing.
+				//
+				//    ; sub    esp,0x8                          ; 1c 1uop
+				if t, ok := x86.InstTiming(inst.Inst, march); ok {
+					asm = fmt.Sprintf("%-40s ; %v", asm, t)
+				}
+				if off, sym, ok := instFixup(inst, data, fixups); ok {
+					dumpFixup(buf, addr, data, off, sym, asm)
+				} else {
+					pad := " "
+					if n := 80 - (len("  addr_401000:          db      ") + len("0x00")*inst.Len + len(", ")*(inst.Len-1)); n > 0 {
+						pad = strings.Repeat(" ", n)
+					}
+					fmt.Fprintf(buf, "  addr_%06X:          db      %s%s; %s\n", a, byteList(data), pad, asm)
 				}
-				fmt.Fprintf(buf, "%s; %s\n", pad, x86asm.IntelSyntax(inst.Inst, uint64(addr), nil))
 				addr += bin.Address(inst.Len)
 				continue
 			}
 		}
 
+		// Dump padding.
+		if compact {
+			if n := paddingRun(sect, addr, labels, prog, xrefs); n > 0 {
+				fill, _ := sect.ReadUint8(addr)
+				dumpXrefs(buf, xrefs, addr)
+				dumpPadding(buf, sectName, addr+bin.Address(n), n, fill)
+				addr += bin.Address(n)
+				continue
+			}
+		}
+
 		// Dump data.
 		//
 		//    addr_48B054:          db      0x44 ; 'D'
-		if b, ok := data(addr); ok {
+		if b, err := sect.ReadUint8(addr); err == nil {
+			dumpXrefs(buf, xrefs, addr)
 			char := ""
 			if isPrint(b) {
 				char = fmt.Sprintf(" ; %q", b)
@@ -172,42 +189,32 @@ sub_%06X:
 
 	// The virtual size (sect.MemSize) is larger in unitialized sections, and the
 	// raw size (len(sect.Data)) is larger in sections with padding.
-	if sect.MemSize > len(sect.Data) {
-		// Section with uninitialized data.
-		//
-		//       _data_size           equ     $ - $$
-		//
-		//    ; Uninitialized data (allocated by the linker).
-		//    ;times _data_vsize - ($ - $$) resb 1
-		const sectFooter = `
-   %s_size%sequ     $ - $$
-
-; Uninitialized data (allocated by the linker).
-;times %s_vsize - ($ - $$) resb 1
-`
-		pad := " "
-		if n := 24 - (len("   ") + len(sectName) + len("_size")); n > 0 {
-			pad = strings.Repeat(" ", n)
-		}
-		fmt.Fprintf(buf, sectFooter, sectName, pad, sectName)
-	} else {
-		// Section with padding.
-		//
-		//       _text_vsize          equ     $ - $$
-		//
-		//    ; Section alignment.
-		//    times _text_size - ($ - $$) db 0x00
-		const sectFooter = `
-   %s_vsize%sequ     $ - $$
+	//
+	// Section with uninitialized data.
+	//
+	//       _data_size           equ     $ - $$
+	//
+	//    ; Uninitialized data (allocated by the linker).
+	//    ;times _data_vsize - ($ - $$) resb 1
+	//
+	// Section with padding.
+	//
+	//       _text_vsize          equ     $ - $$
+	//
+	//    ; Section alignment.
+	//    times _text_size - ($ - $$) db 0x00
+	ftr := sectFooter{Sect: sectName, Uninit: sect.MemSize > len(sect.Data)}
+	if err := tmpls.sectFooter.Execute(buf, ftr); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return buf.Bytes(), nil
+}
 
-; Section alignment.
-times %s_size - ($ - $$) db 0x00
-`
-		pad := " "
-		if n := 24 - (len("   ") + len(sectName) + len("_vsize")); n > 0 {
-			pad = strings.Repeat(" ", n)
-		}
-		fmt.Fprintf(buf, sectFooter, sectName, pad, sectName)
+// dumpXrefs dumps the cross-references to the given address as comments.
+//
+//    ; xref from 0x4010AB (call)
+func dumpXrefs(buf *bytes.Buffer, xrefs *x86.Xrefs, addr bin.Address) {
+	for _, xref := range xrefs.To(addr) {
+		fmt.Fprintf(buf, "; xref from 0x%06X (%v)\n", uint64(xref.From), xref.Kind)
 	}
-	return buf.Bytes()
 }
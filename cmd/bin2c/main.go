@@ -1,25 +1,40 @@
-// bin2c is a tool which converts binary executables to equivalent C source
+// bin2c is a tool which converts binary executables to equivalent Go source
 // code.
+//
+// Functions are decoded and lifted to LLVM IR by the x86 lifter (as used by
+// bin2ll), and the lifted LLVM IR is translated to Go source code.
 package main
 
 import (
-	"debug/pe"
 	"flag"
 	"fmt"
-	"go/printer"
-	"go/token"
+	"io/ioutil"
 	"log"
 	"os"
-	"strconv"
-	"strings"
 
-	"github.com/mewkiz/pkg/errutil"
+	"github.com/decomp/exp/bin"
+	_ "github.com/decomp/exp/bin/coff"    // register COFF decoder
+	_ "github.com/decomp/exp/bin/elf"     // register ELF decoder
+	_ "github.com/decomp/exp/bin/le"      // register LE/LX decoder
+	_ "github.com/decomp/exp/bin/memdump" // register minidump decoder
+	_ "github.com/decomp/exp/bin/ne"      // register NE decoder
+	_ "github.com/decomp/exp/bin/pe"      // register PE decoder
+	_ "github.com/decomp/exp/bin/pef"     // register PEF decoder
+	"github.com/decomp/exp/config"
+	"github.com/decomp/exp/emit/golang"
+	"github.com/decomp/exp/lift/x86"
+	"github.com/mewkiz/pkg/term"
+	"github.com/pkg/errors"
 )
 
+// dbg represents a logger with the "bin2c:" prefix, which logs debug messages
+// to standard error.
+var dbg = log.New(os.Stderr, term.MagentaBold("bin2c:")+" ", 0)
+
 func usage() {
 	const use = `
-Usage: bin2c -addr ADDRESS [OPTION]... FILE
-Convert binary executables to equivalent C source code.
+Usage: bin2c [OPTION]... FILE
+Convert binary executables to equivalent Go source code.
 
 Flags:
 `
@@ -27,97 +42,89 @@ Flags:
 	flag.PrintDefaults()
 }
 
-// Command line flags.
-var (
-	// flagVerbose specifies whether verbose output is enabled.
-	flagVerbose bool
-)
-
-// Base address of the ".text" section.
-const baseAddr = 0x00401000
-
 func main() {
 	// Parse command line arguments.
 	var (
-		// addr specifies the address to decompile.
-		addr address
+		// addr specifies the address of the function to decompile.
+		addr bin.Address
+		// output specifies the output path.
+		output string
+		// quiet specifies whether to suppress non-error messages.
+		quiet bool
 	)
-	flag.BoolVar(&flagVerbose, "v", false, "Enable verbose output.")
-	flag.Var(&addr, "addr", "Address of function to decompile.")
+	flag.Var(&addr, "addr", "address of function to decompile (default all functions)")
+	flag.StringVar(&output, "o", "", "output path")
+	flag.BoolVar(&quiet, "q", false, "suppress non-error messages")
 	flag.Usage = usage
 	flag.Parse()
+	// Apply project configuration file.
+	if err := config.Setup("bin2c", flag.CommandLine, true); err != nil {
+		log.Fatalf("%+v", err)
+	}
 	if flag.NArg() != 1 {
 		flag.Usage()
 		os.Exit(1)
 	}
-	path := flag.Arg(0)
-	if addr == 0 {
-		flag.Usage()
-		os.Exit(1)
+	binPath := flag.Arg(0)
+	// Mute debug messages if `-q` is set.
+	if quiet {
+		dbg.SetOutput(ioutil.Discard)
 	}
 
-	// Parse ".text" section.
-	text, err := parseText(path)
+	// Decompile functions.
+	var funcAddrs []bin.Address
+	if addr != 0 {
+		funcAddrs = []bin.Address{addr}
+	}
+	l, err := decompile(binPath, funcAddrs)
 	if err != nil {
-		log.Fatal(err)
+		log.Fatalf("%+v", err)
 	}
 
-	// Sanity check.
-	offset := int(addr - baseAddr)
-	if offset < 0 || offset >= len(text) {
-		log.Fatalf("invalid address; expected >= 0x%X and < 0x%X, got 0x%X", baseAddr, baseAddr+len(text), addr)
+	// Store Go output.
+	w := os.Stdout
+	if len(output) > 0 {
+		f, err := os.Create(output)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		w = f
 	}
-
-	// Parse basic blocks.
-	//blocks, err := parseBlocks(text, offset)
-	//if err != nil {
-	//	log.Fatal(err)
-	//}
-
-	// Convert the given function to C source code.
-	fn, err := parseFunc(text, offset)
-	if err != nil {
-		log.Fatal(err)
+	if err := golang.Write(w, l.Module()); err != nil {
+		log.Fatalf("%+v", err)
 	}
-	printer.Fprint(os.Stdout, token.NewFileSet(), fn)
 }
 
-// parseText parses and returns the ".text" section of the given binary
-// executable.
-func parseText(path string) (text []byte, err error) {
-	f, err := pe.Open(path)
+// decompile decodes and lifts the functions at the given addresses of the
+// binary executable, or all functions if funcAddrs is empty.
+func decompile(binPath string, funcAddrs []bin.Address) (*x86.Lifter, error) {
+	file, err := bin.ParseFile(binPath)
 	if err != nil {
-		return nil, errutil.Err(err)
+		return nil, errors.WithStack(err)
 	}
-	defer f.Close()
-
-	sect := f.Section(".text")
-	if sect == nil {
-		return nil, errutil.Newf(`unable to locate ".text" section in %q`, path)
+	l, err := x86.NewLifter(file)
+	if err != nil {
+		return nil, errors.WithStack(err)
 	}
-	return sect.Data()
-}
-
-// address implements the flag.Value interface and allows addresses to be
-// specified in hexadecimal format.
-type address uint64
-
-// String returns the hexadecimal string representation of v.
-func (v *address) String() string {
-	return fmt.Sprintf("0x%X", uint64(*v))
-}
-
-// Set sets v to the numberic value represented by s.
-func (v *address) Set(s string) error {
-	base := 10
-	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
-		s = s[2:]
-		base = 16
+	if len(funcAddrs) == 0 {
+		funcAddrs = l.FuncAddrs
 	}
-	x, err := strconv.ParseUint(s, base, 64)
-	if err != nil {
-		return errutil.Err(err)
+	for _, funcAddr := range funcAddrs {
+		asmFunc, err := l.DecodeFunc(funcAddr)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		l.Funcs[funcAddr] = l.NewFunc(asmFunc)
+	}
+	l.AliasThunks()
+	for _, funcAddr := range funcAddrs {
+		f, ok := l.Funcs[funcAddr]
+		if !ok || l.IsAlias(funcAddr) {
+			continue
+		}
+		dbg.Printf("decompiling function at %v", funcAddr)
+		f.Lift()
 	}
-	*v = address(x)
-	return nil
+	return l, nil
 }
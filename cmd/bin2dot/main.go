@@ -14,10 +14,16 @@ import (
 	"gonum.org/v1/gonum/graph/encoding/dot"
 
 	"github.com/decomp/exp/bin"
-	_ "github.com/decomp/exp/bin/elf" // register ELF decoder
-	_ "github.com/decomp/exp/bin/pe"  // register PE decoder
-	_ "github.com/decomp/exp/bin/pef" // register PEF decoder
+	_ "github.com/decomp/exp/bin/coff"    // register COFF decoder
+	_ "github.com/decomp/exp/bin/elf"     // register ELF decoder
+	_ "github.com/decomp/exp/bin/le"      // register LE/LX decoder
+	_ "github.com/decomp/exp/bin/memdump" // register minidump decoder
+	_ "github.com/decomp/exp/bin/ne"      // register NE decoder
+	_ "github.com/decomp/exp/bin/pe"      // register PE decoder
+	_ "github.com/decomp/exp/bin/pef"     // register PEF decoder
 	"github.com/decomp/exp/bin/raw"
+	"github.com/decomp/exp/config"
+	"github.com/decomp/exp/disasm/annot"
 	"github.com/decomp/exp/disasm/x86"
 	"github.com/mewkiz/pkg/term"
 	"github.com/mewrev/pe"
@@ -56,6 +62,8 @@ func main() {
 		// TODO: Remove -first flag and firstAddr.
 		// firstAddr specifies the first function address to disassemble.
 		firstAddr bin.Address
+		// importPath specifies a program annotation file to import.
+		importPath string
 		// funcAddr specifies a function address to disassemble.
 		funcAddr bin.Address
 		// TODO: Remove -last flag and lastAddr.
@@ -63,6 +71,9 @@ func main() {
 		lastAddr bin.Address
 		// quiet specifies whether to suppress non-error messages.
 		quiet bool
+		// superset specifies whether to locate functions using superset
+		// disassembly.
+		superset bool
 		// rawArch specifies the machine architecture of a raw binary executable.
 		rawArch bin.Arch
 		// rawEntry specifies the entry point of a raw binary executable.
@@ -73,13 +84,19 @@ func main() {
 	flag.Usage = usage
 	flag.Var(&blockAddr, "block", "basic block address to disassemble")
 	flag.Var(&firstAddr, "first", "first function address to disassemble")
+	flag.StringVar(&importPath, "import", "", "program annotations to import (Ghidra XML/SARIF or IDA JSON)")
 	flag.Var(&funcAddr, "func", "function address to disassemble")
 	flag.Var(&lastAddr, "last", "last function address to disassemble")
 	flag.BoolVar(&quiet, "q", false, "suppress non-error messages")
+	flag.BoolVar(&superset, "superset", false, "locate functions using superset disassembly")
 	flag.Var(&rawArch, "raw", "machine architecture of raw binary executable (x86_32, x86_64, MIPS_32, PowerPC_32, ...)")
 	flag.Var(&rawEntry, "rawentry", "entry point of raw binary executable")
 	flag.Var(&rawBase, "rawbase", "base address of raw binary executable")
 	flag.Parse()
+	// Apply project configuration file.
+	if err := config.Setup("bin2dot", flag.CommandLine, true); err != nil {
+		log.Fatalf("%+v", err)
+	}
 	if flag.NArg() != 1 {
 		flag.Usage()
 		os.Exit(1)
@@ -96,6 +113,22 @@ func main() {
 	if err != nil {
 		log.Fatalf("%+v", err)
 	}
+	// Import program annotations specified by `-import` flag.
+	if len(importPath) > 0 {
+		a, err := annot.ParseFile(importPath)
+		if err != nil {
+			log.Fatalf("%+v", err)
+		}
+		dis.Import(a)
+	}
+	// Locate functions using superset disassembly if `-superset` is set.
+	if superset {
+		a := annot.New()
+		a.FuncAddrs = dis.Superset()
+		a.BlockAddrs = a.FuncAddrs
+		dbg.Printf("located %d functions using superset disassembly", len(a.FuncAddrs))
+		dis.Import(a)
+	}
 	// Disassemble basic block.
 	if blockAddr != 0 {
 		block, err := dis.DecodeBlock(blockAddr)
@@ -176,6 +209,7 @@ func newDisasm(binPath string, rawArch bin.Arch, rawEntry, rawBase bin.Address)
 			return nil, errors.WithStack(err)
 		}
 		file.Entry = rawEntry
+		file.Base = rawBase
 		file.Sections[0].Addr = rawBase
 		return x86.NewDisasm(file)
 	}
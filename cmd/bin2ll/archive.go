@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/bin/ar"
+	x86dis "github.com/decomp/exp/disasm/x86"
+	"github.com/decomp/exp/lift/opt"
+	"github.com/decomp/exp/lift/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/pkg/errors"
+)
+
+// Static libraries.
+//
+// The object file members of static libraries (*.a and *.lib) are placed at
+// consecutive base addresses, so that the lifted members may be combined into a
+// single LLVM IR module without address collisions.
+
+// Base address of the first member of static libraries.
+const memberBase = 0x10000
+
+// Alignment of the base addresses of static library members.
+const memberAlign = 0x10000
+
+// isArchive reports whether the given file is a static library.
+func isArchive(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	return ar.IsArchive(f)
+}
+
+// liftArchive lifts the object file members of the given static library. If
+// split is set, each member is stored as a separate module (e.g. MEMBER.ll) in
+// the output directory; otherwise, the members are combined into a single
+// module stored to the output path, or standard output if empty.
+func liftArchive(libPath, output, emit string, split, optimize bool, shared x86dis.SharedCodePolicy, budget x86.Budget) error {
+	members, err := ar.ParseFile(libPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	var modules []*ir.Module
+	// Number of members with a given base name; used to disambiguate output
+	// paths.
+	names := make(map[string]int)
+	base := bin.Address(memberBase)
+	for _, member := range members {
+		file, err := member.Parse(base)
+		if err != nil {
+			warn.Printf("unable to parse member %q; %v", member.Name, err)
+			continue
+		}
+		dbg.Printf("lifting member %q at %v", member.Name, base)
+		if end := ar.End(file); end > base {
+			base = (end + memberAlign - 1) &^ (memberAlign - 1)
+		}
+		m, err := liftMember(file, shared, budget)
+		if err != nil {
+			return errors.Wrapf(err, "unable to lift member %q", member.Name)
+		}
+		if optimize {
+			opt.Module(m)
+		}
+		if !split {
+			modules = append(modules, m)
+			continue
+		}
+		name := member.BaseName()
+		if n := names[name]; n > 0 {
+			names[name]++
+			name = fmt.Sprintf("%s_%d", name, n)
+		} else {
+			names[name]++
+		}
+		outPath := filepath.Join(output, name+outputExts[emit])
+		dbg.Printf("creating %q", outPath)
+		if err := createModule(outPath, m, emit); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if split {
+		return nil
+	}
+	m := mergeModules(modules)
+	if len(output) == 0 {
+		return writeModule(os.Stdout, m, emit)
+	}
+	return createModule(output, m, emit)
+}
+
+// liftMember lifts the functions of the given static library member.
+func liftMember(file *bin.File, shared x86dis.SharedCodePolicy, budget x86.Budget) (*ir.Module, error) {
+	l, err := x86.NewLifter(file)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	l.Budget = budget
+	l.SplitSharedCode(shared)
+	for _, funcAddr := range l.FuncAddrs {
+		asmFunc, err := l.DecodeFunc(funcAddr)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		l.Funcs[funcAddr] = l.NewFunc(asmFunc)
+	}
+	l.AliasThunks()
+	for _, funcAddr := range l.FuncAddrs {
+		f, ok := l.Funcs[funcAddr]
+		if !ok || l.IsAlias(funcAddr) {
+			continue
+		}
+		f.Lift()
+	}
+	reportSkipped(l, l.FuncAddrs)
+	return l.Module(), nil
+}
+
+// mergeModules combines the given LLVM IR modules of static library members
+// into a single LLVM IR module. Function declarations (e.g. imports of one
+// member) are resolved by name to the function definitions of other members.
+//
+// Note, the type definitions of all members originate from the same info.ll,
+// and are thus taken from the first member.
+func mergeModules(modules []*ir.Module) *ir.Module {
+	m := &ir.Module{}
+	if len(modules) > 0 {
+		m.TypeDefs = modules[0].TypeDefs
+	}
+	globals := make(map[string]bool)
+	// Index of function in m.Funcs, by name.
+	funcs := make(map[string]int)
+	for _, module := range modules {
+		for _, g := range module.Globals {
+			if globals[g.Name()] {
+				warn.Printf("duplicate definition of global variable %q", g.Name())
+				continue
+			}
+			globals[g.Name()] = true
+			m.Globals = append(m.Globals, g)
+		}
+		for _, f := range module.Funcs {
+			i, ok := funcs[f.Name()]
+			switch {
+			case !ok:
+				funcs[f.Name()] = len(m.Funcs)
+				m.Funcs = append(m.Funcs, f)
+			case len(f.Blocks) == 0:
+				// skip declaration of function already declared or defined.
+			case len(m.Funcs[i].Blocks) == 0:
+				// replace declaration with definition.
+				m.Funcs[i] = f
+			default:
+				warn.Printf("duplicate definition of function %q", f.Name())
+			}
+		}
+	}
+	return m
+}
+
+// createModule stores the given LLVM IR module to the specified path in the
+// given output format.
+func createModule(outPath string, m *ir.Module, emit string) error {
+	f, err := os.Create(outPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+	return writeModule(f, m, emit)
+}
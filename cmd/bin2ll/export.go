@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/annot"
+	"github.com/decomp/exp/lift/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/types"
+)
+
+// exportAnnotations returns the program annotations discovered by the lifter
+// for the given functions; i.e. function and basic block addresses, function
+// names and signatures, and string literals of global variables.
+func exportAnnotations(l *x86.Lifter, funcAddrs []bin.Address) *annot.Annotations {
+	a := annot.New()
+	for _, funcAddr := range funcAddrs {
+		f, ok := l.Funcs[funcAddr]
+		if !ok || f.AsmFunc == nil {
+			continue
+		}
+		a.FuncAddrs = append(a.FuncAddrs, funcAddr)
+		for blockAddr := range f.AsmFunc.Blocks {
+			a.BlockAddrs = bin.InsertAddr(a.BlockAddrs, blockAddr)
+		}
+		a.Names[funcAddr] = f.Name()
+		a.Sigs[funcAddr] = cFuncSig(f.Function)
+	}
+	for globalAddr, g := range l.Globals {
+		a.Names[globalAddr] = g.Name()
+		if init, ok := g.Init.(*constant.CharArray); ok {
+			s := string(init.X)
+			if pos := strings.IndexByte(s, 0); pos != -1 {
+				s = s[:pos]
+			}
+			a.Strings[globalAddr] = s
+		}
+	}
+	return a
+}
+
+// cFuncSig returns the C function signature of the given function.
+func cFuncSig(f *ir.Function) string {
+	var params []string
+	for i, param := range f.Params {
+		name := param.Name()
+		if len(name) == 0 {
+			name = fmt.Sprintf("a%d", i+1)
+		}
+		params = append(params, fmt.Sprintf("%s %s", cType(param.Type()), name))
+	}
+	if f.Sig.Variadic {
+		params = append(params, "...")
+	}
+	if len(params) == 0 {
+		params = append(params, "void")
+	}
+	return fmt.Sprintf("%s %s(%s)", cType(f.Sig.RetType), f.Name(), strings.Join(params, ", "))
+}
+
+// cType returns the C type corresponding to the given LLVM IR type. Types
+// without a direct C counterpart are represented by an integer type of the same
+// size, or int.
+func cType(t types.Type) string {
+	switch t := t.(type) {
+	case *types.VoidType:
+		return "void"
+	case *types.IntType:
+		switch t.BitSize {
+		case 1, 8:
+			return "char"
+		case 16:
+			return "short"
+		case 32:
+			return "int"
+		case 64:
+			return "__int64"
+		}
+	case *types.FloatType:
+		switch t.Kind {
+		case types.FloatKindFloat:
+			return "float"
+		case types.FloatKindDouble:
+			return "double"
+		case types.FloatKindX86FP80:
+			return "long double"
+		}
+	case *types.PointerType:
+		if _, ok := t.ElemType.(*types.FuncType); ok {
+			return "void *"
+		}
+		return cType(t.ElemType) + " *"
+	}
+	return "int"
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// funcFilter is a list of function selectors, which may be specified multiple
+// times on the command line, each a comma-separated list of:
+//
+//    0x401000              function address
+//    0x401000-0x402000     function addresses in range [start, end)
+//    /^sub_/               regular expression over function names
+//    main                  function name
+type funcFilter []funcSelector
+
+// A funcSelector selects functions by address, address range, name or regular
+// expression over names.
+type funcSelector struct {
+	// Textual representation of the selector.
+	s string
+	// Function address range [start, end), where end is start+1 for single
+	// addresses; or zero if not an address selector.
+	start, end bin.Address
+	// Regular expression over function names; or nil if not a regular
+	// expression selector.
+	re *regexp.Regexp
+	// Function name; or empty if not a name selector.
+	name string
+}
+
+// String returns the string representation of the function filter.
+func (ff *funcFilter) String() string {
+	var ss []string
+	for _, sel := range *ff {
+		ss = append(ss, sel.s)
+	}
+	return strings.Join(ss, ",")
+}
+
+// Set adds the comma-separated function selectors represented by s.
+func (ff *funcFilter) Set(s string) error {
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if len(field) == 0 {
+			continue
+		}
+		sel, err := parseSelector(field)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		*ff = append(*ff, sel)
+	}
+	return nil
+}
+
+// parseSelector parses the given function selector.
+func parseSelector(s string) (funcSelector, error) {
+	sel := funcSelector{s: s}
+	// Regular expression.
+	if len(s) >= 2 && strings.HasPrefix(s, "/") && strings.HasSuffix(s, "/") {
+		re, err := regexp.Compile(s[1 : len(s)-1])
+		if err != nil {
+			return funcSelector{}, errors.WithStack(err)
+		}
+		sel.re = re
+		return sel, nil
+	}
+	// Address range.
+	if pos := strings.Index(s, "-"); pos > 0 {
+		if err := sel.start.Set(s[:pos]); err == nil {
+			if err := sel.end.Set(s[pos+1:]); err != nil {
+				return funcSelector{}, errors.Errorf("invalid end address of function range %q", s)
+			}
+			if sel.end <= sel.start {
+				return funcSelector{}, errors.Errorf("invalid function range %q; end address must be greater than start address", s)
+			}
+			return sel, nil
+		}
+	}
+	// Address.
+	if err := sel.start.Set(s); err == nil {
+		sel.end = sel.start + 1
+		return sel, nil
+	}
+	// Name.
+	sel.name = s
+	return sel, nil
+}
+
+// match reports whether the function of the given address and name is selected
+// by the function filter.
+func (ff funcFilter) match(addr bin.Address, name string) bool {
+	for _, sel := range ff {
+		switch {
+		case sel.re != nil:
+			if sel.re.MatchString(name) {
+				return true
+			}
+		case len(sel.name) > 0:
+			if sel.name == name {
+				return true
+			}
+		default:
+			if sel.start <= addr && addr < sel.end {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addrs returns the function addresses of the single address selectors of the
+// function filter, which are lifted even if not present among the functions
+// located by the disassembler.
+func (ff funcFilter) addrs() []bin.Address {
+	var addrs []bin.Address
+	for _, sel := range ff {
+		if sel.re == nil && len(sel.name) == 0 && sel.end == sel.start+1 {
+			addrs = append(addrs, sel.start)
+		}
+	}
+	return addrs
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	x86dis "github.com/decomp/exp/disasm/x86"
+	"github.com/decomp/exp/lift/opt"
+	"github.com/decomp/exp/lift/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/mewkiz/pkg/pathutil"
+	"github.com/pkg/errors"
+)
+
+// A linker lifts the libraries (e.g. DLLs and shared objects) imported by
+// binary executables into separate LLVM IR modules, and links the imported
+// functions to their lifted definitions; thus producing a whole-program set of
+// LLVM IR modules.
+//
+// Imported functions are declared with the name and signature of the lifted
+// definition, and an inter-module call map records the definition of each
+// imported function.
+type linker struct {
+	// Directories searched for libraries.
+	dirs []string
+	// Shared code policy of lifters.
+	shared x86dis.SharedCodePolicy
+	// Resource budget of lifters.
+	budget x86.Budget
+	// Libraries, by lower-case base name; or nil if not located.
+	libs map[string]*library
+	// Lifted libraries, in dependency order.
+	lifted []*library
+	// Inter-module call map.
+	links []*importLink
+}
+
+// A library is a lifted library (e.g. DLL or shared object).
+type library struct {
+	// Library path.
+	path string
+	// Module name (base name of library without extension).
+	name string
+	// x86 to LLVM IR lifter of the library.
+	l *x86.Lifter
+	// Map from export name to entry address.
+	exports map[string]bin.Address
+}
+
+// An importLink is an entry of the inter-module call map, which links an
+// imported function to its definition.
+type importLink struct {
+	// Module name of the importing executable.
+	Module string `json:"module"`
+	// Address of the import.
+	Addr string `json:"addr"`
+	// Import name.
+	Name string `json:"name"`
+	// Module name of the library defining the function.
+	Lib string `json:"lib"`
+	// Entry address of the function definition.
+	Target string `json:"target"`
+	// Name of the function definition.
+	Func string `json:"func"`
+}
+
+// newLinker returns a new linker, which searches for libraries in the given
+// directories.
+func newLinker(dirs []string, shared x86dis.SharedCodePolicy, budget x86.Budget) *linker {
+	return &linker{
+		dirs:   dirs,
+		shared: shared,
+		budget: budget,
+		libs:   make(map[string]*library),
+	}
+}
+
+// link links the imported functions of the given module to their definitions,
+// lifting the libraries defining them. link must be invoked before the
+// functions of the module are lifted, as call sites are lifted based on the
+// signature of the callee.
+func (lk *linker) link(module string, l *x86.Lifter) error {
+	var addrs bin.Addresses
+	for addr := range l.File.Imports {
+		addrs = append(addrs, addr)
+	}
+	sort.Sort(addrs)
+	for _, addr := range addrs {
+		name := l.File.Imports[addr]
+		libNames := l.File.Libs
+		if libName, ok := l.File.ImportLibs[addr]; ok {
+			libNames = []string{libName}
+		}
+		for _, libName := range libNames {
+			lib, err := lk.lift(libName)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if lib == nil {
+				continue
+			}
+			entry, ok := lib.lookup(name)
+			if !ok {
+				continue
+			}
+			def, ok := lib.l.Funcs[entry]
+			if !ok {
+				continue
+			}
+			decl, ok := l.Funcs[addr]
+			if !ok {
+				break
+			}
+			// Declare imported function with the name and signature of the
+			// definition.
+			decl.SetName(def.Name())
+			decl.Sig = def.Sig
+			decl.Typ = def.Typ
+			decl.CallingConv = def.CallingConv
+			decl.Params = nil
+			for _, param := range def.Params {
+				decl.Params = append(decl.Params, ir.NewParam(param.Name(), param.Typ))
+			}
+			link := &importLink{
+				Module: module,
+				Addr:   addr.String(),
+				Name:   name,
+				Lib:    lib.name,
+				Target: entry.String(),
+				Func:   def.Name(),
+			}
+			lk.links = append(lk.links, link)
+			break
+		}
+	}
+	return nil
+}
+
+// lift lifts the library of the given name, and the libraries it imports.
+// lift returns nil if the library was not located.
+func (lk *linker) lift(libName string) (*library, error) {
+	key := strings.ToLower(filepath.Base(libName))
+	if lib, ok := lk.libs[key]; ok {
+		// Library already lifted, being lifted (i.e. cyclic imports), or not
+		// located.
+		return lib, nil
+	}
+	lk.libs[key] = nil
+	libPath, ok := lk.locate(key)
+	if !ok {
+		warn.Printf("unable to locate library %q", libName)
+		return nil, nil
+	}
+	dbg.Printf("lifting library %q", libPath)
+	file, err := bin.ParseFile(libPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	l, err := x86.NewLifter(file)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	l.Budget = lk.budget
+	l.SplitSharedCode(lk.shared)
+	for _, funcAddr := range l.FuncAddrs {
+		asmFunc, err := l.DecodeFunc(funcAddr)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		l.Funcs[funcAddr] = l.NewFunc(asmFunc)
+	}
+	l.AliasThunks()
+	lib := &library{
+		path:    libPath,
+		name:    pathutil.TrimExt(filepath.Base(libPath)),
+		l:       l,
+		exports: make(map[string]bin.Address),
+	}
+	for addr, name := range file.Exports {
+		lib.exports[name] = addr
+	}
+	lk.libs[key] = lib
+	// Link imports of the library prior to lifting its functions.
+	if err := lk.link(lib.name, l); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, funcAddr := range l.FuncAddrs {
+		f, ok := l.Funcs[funcAddr]
+		if !ok || l.IsAlias(funcAddr) {
+			continue
+		}
+		f.Lift()
+	}
+	reportSkipped(l, l.FuncAddrs)
+	lk.lifted = append(lk.lifted, lib)
+	return lib, nil
+}
+
+// locate returns the path of the library with the given lower-case base name,
+// searching the directories of the linker. The boolean return value indicates
+// success.
+func (lk *linker) locate(key string) (string, bool) {
+	for _, dir := range lk.dirs {
+		fis, err := ioutil.ReadDir(dir)
+		if err != nil {
+			warn.Printf("unable to read library directory %q; %v", dir, err)
+			continue
+		}
+		for _, fi := range fis {
+			// Library names are case-insensitive on Windows.
+			if !fi.IsDir() && strings.ToLower(fi.Name()) == key {
+				return filepath.Join(dir, fi.Name()), true
+			}
+		}
+	}
+	return "", false
+}
+
+// lookup returns the entry address of the exported function of the given
+// name. The boolean return value indicates success.
+func (lib *library) lookup(name string) (bin.Address, bool) {
+	if addr, ok := lib.exports[name]; ok {
+		return addr, true
+	}
+	// Names of imports by ordinal (DLL_ordinal_N) are based on the DLL name of
+	// the importing executable, which may differ in case.
+	for exportName, addr := range lib.exports {
+		if strings.EqualFold(exportName, name) {
+			return addr, true
+		}
+	}
+	return 0, false
+}
+
+// store stores the LLVM IR modules of the lifted libraries to the given output
+// directory, in the specified output format, followed by the inter-module call
+// map (callmap.json).
+func (lk *linker) store(dir, emit string, optimize bool) error {
+	for _, lib := range lk.lifted {
+		m := lib.l.Module()
+		if optimize {
+			opt.Module(m)
+		}
+		outPath := filepath.Join(dir, lib.name+outputExts[emit])
+		dbg.Printf("creating %q", outPath)
+		if err := createModule(outPath, m, emit); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	buf, err := json.MarshalIndent(lk.links, "", "\t")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	buf = append(buf, '\n')
+	callMapPath := filepath.Join(dir, "callmap.json")
+	dbg.Printf("creating %q", callMapPath)
+	if err := ioutil.WriteFile(callMapPath, buf, 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
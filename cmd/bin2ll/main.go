@@ -5,20 +5,42 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"runtime"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/decomp/exp/bin"
-	_ "github.com/decomp/exp/bin/elf" // register ELF decoder
-	_ "github.com/decomp/exp/bin/pe"  // register PE decoder
-	_ "github.com/decomp/exp/bin/pef" // register PEF decoder
+	_ "github.com/decomp/exp/bin/coff" // register COFF decoder
+	"github.com/decomp/exp/bin/demangle"
+	_ "github.com/decomp/exp/bin/elf"   // register ELF decoder
+	_ "github.com/decomp/exp/bin/le"    // register LE/LX decoder
+	"github.com/decomp/exp/bin/memdump" // register minidump decoder
+	_ "github.com/decomp/exp/bin/ne"    // register NE decoder
+	"github.com/decomp/exp/bin/pe"      // register PE decoder
+	_ "github.com/decomp/exp/bin/pef"   // register PEF decoder
 	"github.com/decomp/exp/bin/raw"
+	"github.com/decomp/exp/config"
+	"github.com/decomp/exp/disasm"
+	"github.com/decomp/exp/disasm/annot"
+	x86dis "github.com/decomp/exp/disasm/x86"
+	"github.com/decomp/exp/emit/bitcode"
+	"github.com/decomp/exp/emit/c"
+	"github.com/decomp/exp/emit/wasm"
+	"github.com/decomp/exp/lift/opt"
+	"github.com/decomp/exp/lift/softfloat"
 	"github.com/decomp/exp/lift/x86"
+	"github.com/decomp/exp/project"
 	"github.com/llir/llvm/ir"
 	"github.com/llir/llvm/ir/constant"
 	"github.com/llir/llvm/ir/types"
+	"github.com/mewkiz/pkg/osutil"
+	"github.com/mewkiz/pkg/pathutil"
 	"github.com/mewkiz/pkg/term"
 	"github.com/pkg/errors"
 )
@@ -37,6 +59,14 @@ func usage() {
 	const use = `
 Lift binary executables to equivalent LLVM IR assembly (*.exe -> *.ll).
 
+Static libraries (*.a and *.lib) are lifted member by member, into a combined
+LLVM IR module or, if -split is set, into one LLVM IR module per member.
+
+If -split is set for other binary executables, each lifted function is stored
+as a separate LLVM IR module (e.g. f_401000.ll) in the output directory, along
+with an index module (index.ll) defining global variables and declaring all
+functions.
+
 Usage:
 
 	bin2ll [OPTION]... FILE
@@ -50,22 +80,97 @@ Flags:
 func main() {
 	// Parse command line arguments.
 	var (
+		// align specifies the alignment of lifted memory accesses.
+		align x86.AlignMode
 		// blockAddr specifies a basic block address to lift.
 		blockAddr bin.Address
 		// TODO: Remove -first flag and firstAddr.
 		// firstAddr specifies the first function address to lift.
 		firstAddr bin.Address
-		// funcAddr specifies a function address to lift.
-		funcAddr bin.Address
+		// dumps specifies memory dumps to lift instead of the static file image.
+		dumps memDumps
+		// emit specifies the output format.
+		emit string
+		// importPath specifies a program annotation file to import.
+		importPath string
+		// instrument specifies the instrumentation mode of the lifted code.
+		instrument string
+		// issuesPath specifies the output path of the issue report.
+		issuesPath string
+		// fromMain specifies whether to lift only the functions reachable from
+		// main, skipping the runtime startup code.
+		fromMain bool
+		// fixBounds specifies whether to validate and correct function
+		// boundaries.
+		fixBounds bool
+		// fpuPrecision specifies the precision model of x87 FPU values.
+		fpuPrecision x86.FPUPrecision
+		// funcs specifies the functions to lift.
+		funcs funcFilter
+		// jobs specifies the number of parallel instruction decoders.
+		jobs int
+		// exclude specifies the functions to exclude from lifting.
+		exclude funcFilter
 		// TODO: Remove -last flag and lastAddr.
 		// lastAddr specifies the last function address to disassemble.
 		lastAddr bin.Address
+		// lax specifies whether to replace functions which fail to lift by stubs.
+		lax bool
+		// libDirs specifies directories to search for imported libraries.
+		libDirs string
+		// maxInsts specifies the maximum number of instructions of each function
+		// lifted.
+		maxInsts int
+		// naming specifies the naming scheme of generated symbols.
+		naming = disasm.NamingDefault
 		// output specifies the output path.
 		output string
+		// optimize specifies whether to simplify the output LLVM IR.
+		optimize bool
+		// plugs specifies user analyses to load as Go plugins.
+		plugs plugins
+		// exportPath specifies a program annotation file to export.
+		exportPath string
+		// modelPath specifies a serialized disassembly model file.
+		modelPath string
+		// projectPath specifies a project database file.
+		projectPath string
 		// cfgonly specifies whether to output minimal LLVM IR needed for CFG generation.
 		cfgonly bool
+		// cpuProfile specifies the output path of a CPU profile.
+		cpuProfile string
+		// memProfile specifies the output path of a memory profile.
+		memProfile string
+		// prune specifies whether to remove functions unreachable from the roots
+		// of analysis.
+		prune bool
 		// quiet specifies whether to suppress non-error messages.
 		quiet bool
+		// roots specifies additional roots of analysis.
+		roots addrList
+		// structs specifies whether to recover struct layouts from memory access
+		// patterns.
+		structs bool
+		// split specifies whether to lift each function, or each member of a
+		// static library, into a separate LLVM IR module.
+		split bool
+		// softFloat specifies whether to lower floating-point operations to calls
+		// into a soft-float runtime.
+		softFloat bool
+		// stableNames specifies whether to name unnamed values by basic block
+		// address and instruction index.
+		stableNames bool
+		// superset specifies whether to locate functions using superset
+		// disassembly.
+		superset bool
+		// shared specifies how code shared between functions is modelled.
+		shared x86dis.SharedCodePolicy
+		// modMap specifies the module map of a raw process memory dump.
+		modMap string
+		// timeout specifies the maximum time spent lifting each function.
+		timeout time.Duration
+		// tracePath specifies an execution trace to import.
+		tracePath string
 		// rawArch specifies the machine architecture of a raw binary executable.
 		rawArch bin.Arch
 		// rawEntry specifies the entry point of a raw binary executable.
@@ -74,33 +179,290 @@ func main() {
 		rawBase bin.Address
 	)
 	flag.Usage = usage
+	flag.Var(&align, "align", "alignment of lifted memory accesses (natural or unaligned); unaligned marks loads and stores as align 1, for targets with strict alignment requirements")
 	flag.Var(&blockAddr, "block", "basic block address to lift")
+	flag.Var(&dumps, "dump", "memory dump to lift instead of static file image (PATH@ADDR); may be repeated")
+	flag.StringVar(&emit, "emit", "ll", "output format (ll, bc, wat or c); bc requires llvm-as")
 	flag.Var(&firstAddr, "first", "first function address to lift")
-	flag.Var(&funcAddr, "func", "function address to lift")
+	flag.StringVar(&importPath, "import", "", "program annotations to import (Ghidra XML/SARIF or IDA JSON)")
+	flag.StringVar(&instrument, "instrument", "", "instrumentation mode of lifted code (trace); trace inserts a call to @__trace(addr) at the start of each basic block")
+	flag.StringVar(&issuesPath, "issues", "", "output path of issue report (JSON); defaults to issues.json alongside the output if -o is set")
+	flag.BoolVar(&fromMain, "from-main", false, "lift only functions reachable from main (or WinMain), skipping runtime startup code")
+	flag.BoolVar(&fixBounds, "fix-bounds", false, "validate function boundaries (fall through into next function, undecodable gaps, no reachable return) and merge spurious functions; reported in issue report")
+	flag.Var(&fpuPrecision, "fpu-precision", "precision model of x87 FPU values (fp80 or double); double trades bit-exactness for portability to targets without x86_fp80 support")
+	flag.Var(&funcs, "func", "functions to lift; comma-separated list of addresses, address ranges (START-END), names or regular expressions over names (/REGEXP/)")
+	flag.IntVar(&jobs, "j", runtime.NumCPU(), "number of parallel instruction decoders")
+	flag.Var(&exclude, "exclude", "functions to exclude from lifting; same format as -func")
 	flag.Var(&lastAddr, "last", "last function address to lift")
+	flag.BoolVar(&lax, "lax", false, "replace functions which fail to lift (e.g. due to unsupported instructions) by stubs")
+	flag.StringVar(&libDirs, "libdir", "", "directories to search for imported libraries (DLLs and shared objects), which are lifted into separate LLVM IR modules and linked (comma-separated)")
+	flag.IntVar(&maxInsts, "max-insts", 0, "maximum number of instructions of each function; larger functions are replaced by stubs (0 is unlimited)")
+	flag.Var(&naming, "naming", "naming scheme of generated symbols (default, ida or ghidra); optionally followed by \",relative\" to name functions relative to the nearest preceding export")
 	flag.StringVar(&output, "o", "", "output path")
+	flag.BoolVar(&optimize, "opt", false, "simplify output LLVM IR (promote registers to SSA values, fold constants, eliminate dead code and merge basic blocks)")
+	flag.StringVar(&modelPath, "model", "", "serialized disassembly model; used instead of decoding functions if present, created otherwise")
+	flag.StringVar(&projectPath, "project", "", "project database; opened if present, created otherwise, and updated with analysis results")
+	flag.StringVar(&exportPath, "export", "", "program annotations to export (Ghidra XML or IDAPython)")
+	flag.Var(&plugs, "plugin", "user analysis to load as Go plugin (exporting Init and/or Module functions); may be repeated")
+	flag.BoolVar(&prune, "prune", false, "remove functions unreachable from roots of analysis (entry point, exports, initialization functions and -roots)")
 	flag.BoolVar(&quiet, "q", false, "suppress non-error messages")
+	flag.Var(&roots, "roots", "additional roots of analysis, from which reachable functions are located (comma-separated addresses); exports and initialization functions are roots by default for DLLs and shared objects")
+	flag.BoolVar(&structs, "structs", false, "recover struct layouts from memory access patterns")
+	flag.Var(&shared, "shared", "shared tails of functions; duplicate into each function or extract into artificial callees (duplicate or extract)")
+	flag.BoolVar(&split, "split", false, "lift each function (or each member of static library) into separate LLVM IR module (FUNC.ll or MEMBER.ll in output directory)")
+	flag.BoolVar(&softFloat, "soft-float", false, "lower floating-point operations to calls into a soft-float runtime (libgcc or compiler-rt), for targets without floating-point unit; implies -fpu-precision double")
+	flag.BoolVar(&stableNames, "stable-names", false, "name temporary values by basic block address and instruction index (e.g. %t_401000.7) rather than numbering them sequentially, keeping diffs between runs minimal")
+	flag.BoolVar(&superset, "superset", false, "locate functions using superset disassembly")
+	flag.DurationVar(&timeout, "timeout", 0, "maximum time spent lifting each function; slower functions are replaced by stubs (0 is unlimited)")
+	flag.StringVar(&tracePath, "trace", "", "execution trace to import (instruction addresses, one per line)")
 	flag.BoolVar(&cfgonly, "cfg-only", false, "output minimal LLVM IR needed for CFG generation")
+	flag.StringVar(&cpuProfile, "cpuprofile", "", "write CPU profile to file")
+	flag.StringVar(&memProfile, "memprofile", "", "write memory profile to file")
+	flag.StringVar(&modMap, "modmap", "", "module map of raw process memory dump (JSON)")
 	flag.Var(&rawArch, "raw", "machine architecture of raw binary executable (x86_32, x86_64, PowerPC_32, ...)")
 	flag.Var(&rawEntry, "rawentry", "entry point of raw binary executable")
 	flag.Var(&rawBase, "rawbase", "base address of raw binary executable")
 	flag.Parse()
+	// Apply project configuration file.
+	if err := config.Setup("bin2ll", flag.CommandLine, true); err != nil {
+		log.Fatalf("%+v", err)
+	}
 	if flag.NArg() != 1 {
 		flag.Usage()
 		os.Exit(1)
 	}
 	binPath := flag.Arg(0)
+	if _, ok := outputExts[emit]; !ok {
+		log.Fatalf("invalid output format %q; expected ll, bc, wat or c", emit)
+	}
+	switch instrument {
+	case "", "trace":
+		// valid instrumentation mode.
+	default:
+		log.Fatalf("invalid instrumentation mode %q; expected trace", instrument)
+	}
+	if emit == "bc" {
+		// Locate llvm-as prior to lifting.
+		if _, err := bitcode.LookPath(); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	}
 	// Mute debug and warning messages if `-q` is set.
 	if quiet {
 		dbg.SetOutput(ioutil.Discard)
 		warn.SetOutput(ioutil.Discard)
 	}
 
-	// Prepare x86 to LLVM IR lifter for the binary executable.
-	l, err := newLifter(binPath, rawArch, rawEntry, rawBase)
+	// Profile CPU usage if `-cpuprofile` is set.
+	if len(cpuProfile) > 0 {
+		stop, err := startCPUProfile(cpuProfile)
+		if err != nil {
+			log.Fatalf("%+v", err)
+		}
+		defer stop()
+	}
+	// Profile memory usage if `-memprofile` is set.
+	if len(memProfile) > 0 {
+		defer func() {
+			if err := writeMemProfile(memProfile); err != nil {
+				log.Fatalf("%+v", err)
+			}
+		}()
+	}
+
+	// Lift members of static library.
+	if isArchive(binPath) {
+		budget := x86.Budget{MaxInsts: maxInsts, Timeout: timeout}
+		if err := liftArchive(binPath, output, emit, split, optimize, shared, budget); err != nil {
+			log.Fatalf("%+v", err)
+		}
+		return
+	}
+
+	// Open project database specified by `-project` flag.
+	var p *project.Project
+	if len(projectPath) > 0 {
+		var err error
+		p, err = project.Open(projectPath)
+		if err != nil {
+			log.Fatalf("%+v", err)
+		}
+		stale, err := p.SetBinary(binPath)
+		if err != nil {
+			log.Fatalf("%+v", err)
+		}
+		if stale {
+			warn.Printf("contents of %q changed since last analysis; discarding analysis results of project %q", binPath, projectPath)
+		}
+	}
+
+	// Prepare x86 to LLVM IR lifter for the binary executable, or from the
+	// disassembly model of the project database or the disassembly model
+	// specified by `-model` flag.
+	var model []byte
+	switch {
+	case p != nil && len(p.Model) > 0:
+		model = p.Model
+	case len(modelPath) > 0 && osutil.Exists(modelPath):
+		dbg.Printf("loading disassembly model %q", modelPath)
+		var err error
+		model, err = ioutil.ReadFile(modelPath)
+		if err != nil {
+			log.Fatalf("%+v", errors.WithStack(err))
+		}
+	}
+	var l *x86.Lifter
+	var err error
+	if model != nil {
+		l, err = newLifterFromModel(model)
+	} else {
+		l, err = newLifter(binPath, modMap, rawArch, rawEntry, rawBase)
+	}
 	if err != nil {
 		log.Fatalf("%+v", err)
 	}
+	// Use naming scheme specified by `-naming` flag, with demangled C++ symbol
+	// names.
+	naming.Demangle = demangle.Name
+	l.Naming = naming
+	// Apply memory dumps specified by `-dump` flag.
+	if err := applyDumps(l.File, dumps); err != nil {
+		log.Fatalf("%+v", err)
+	}
+	if len(dumps) > 0 {
+		l.FlushCache()
+	}
+	// Import program annotations of project database.
+	if p != nil {
+		l.Import(p.Annotations())
+	}
+	// Import program annotations specified by `-import` flag.
+	if len(importPath) > 0 {
+		a, err := annot.ParseFile(importPath)
+		if err != nil {
+			log.Fatalf("%+v", err)
+		}
+		l.Import(a)
+	}
+	// Import string tables of PE resources; other file formats have no
+	// resources.
+	if rs, err := pe.ParseResourcesFile(binPath); err == nil {
+		a := annot.New()
+		a.ResStrings = pe.StringTable(rs)
+		l.Import(a)
+	}
+	// Import execution trace specified by `-trace` flag.
+	if len(tracePath) > 0 {
+		if err := l.ImportTrace(tracePath); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	}
+	// Locate functions using superset disassembly if `-superset` is set.
+	if superset {
+		a := annot.New()
+		a.FuncAddrs = l.Superset()
+		a.BlockAddrs = a.FuncAddrs
+		dbg.Printf("located %d functions using superset disassembly", len(a.FuncAddrs))
+		l.Import(a)
+	}
+	// Locate functions reachable from the roots of analysis for shared
+	// libraries, which have no meaningful entry point driven reachability, and
+	// if `-roots` is set.
+	if l.File.Shared || len(roots) > 0 {
+		a := annot.New()
+		a.FuncAddrs = l.Reachable(analysisRoots(l.File, roots)...)
+		a.BlockAddrs = a.FuncAddrs
+		dbg.Printf("located %d functions reachable from roots of analysis", len(a.FuncAddrs))
+		l.Import(a)
+	}
+	// Locate and label main past the runtime startup code.
+	startup := l.FindMain()
+	if startup.Main != 0 {
+		a := annot.New()
+		a.FuncAddrs = []bin.Address{startup.Main}
+		for addr, name := range startup.Names() {
+			_, named := l.Names[addr]
+			_, exported := l.File.Exports[addr]
+			if !named && !exported {
+				a.Names[addr] = name
+			}
+		}
+		l.Import(a)
+	}
+	// Locate and label handlers of MFC message maps and ATL COM maps.
+	if handlers := l.FindHandlers(); len(handlers.MsgMaps) > 0 || len(handlers.COMMaps) > 0 {
+		a := annot.New()
+		a.FuncAddrs = handlers.FuncAddrs()
+		a.BlockAddrs = a.FuncAddrs
+		for addr, name := range handlers.Names() {
+			_, named := l.Names[addr]
+			_, exported := l.File.Exports[addr]
+			if !named && !exported {
+				a.Names[addr] = name
+			}
+		}
+		l.Import(a)
+	}
+	// Locate and label COM interface vtables and GUIDs.
+	if com := l.FindCOM(); len(com.VTables) > 0 || len(com.GUIDs) > 0 {
+		a := annot.New()
+		a.FuncAddrs = com.FuncAddrs()
+		a.BlockAddrs = a.FuncAddrs
+		for addr, name := range com.Names() {
+			_, named := l.Names[addr]
+			_, exported := l.File.Exports[addr]
+			if !named && !exported {
+				a.Names[addr] = name
+			}
+		}
+		l.Import(a)
+	}
+
+	// Limit resources spent lifting each function if `-max-insts` or `-timeout`
+	// is set.
+	l.Budget = x86.Budget{MaxInsts: maxInsts, Timeout: timeout}
+	// Replace functions which fail to lift by stubs if `-lax` is set.
+	l.Lax = lax
+	// Instrument lifted basic blocks if `-instrument trace` is set.
+	l.Trace = instrument == "trace"
+	// Name temporary values deterministically if `-stable-names` is set.
+	l.StableNames = stableNames
+	// Mark memory accesses as unaligned if `-align unaligned` is set.
+	l.Align = align
+	// Model x87 FPU values as double if `-fpu-precision double` is set.
+	l.FPU = fpuPrecision
+	if softFloat {
+		// Few soft-float runtimes implement x86_fp80 arithmetic.
+		l.FPU = x86.FPUDouble
+	}
+
+	// Initialize plugins specified by `-plugin` flag.
+	if err := initPlugins(l, plugs); err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	// Validate and correct function boundaries if `-fix-bounds` is set.
+	var boundaryIssues []*x86dis.Issue
+	if fixBounds {
+		boundaryIssues = l.FixBoundaries()
+		for _, issue := range boundaryIssues {
+			warn.Printf("function at %v: %s", issue.Func, issue.Msg)
+		}
+	}
+
+	// Locate thunks and shared tails of functions.
+	l.SplitSharedCode(shared)
+
+	// Lift and link imported libraries located in directories specified by
+	// `-libdir` flag.
+	var lk *linker
+	if len(libDirs) > 0 {
+		lk = newLinker(strings.Split(libDirs, ","), shared, l.Budget)
+		module := pathutil.TrimExt(filepath.Base(binPath))
+		if err := lk.link(module, l); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	}
 
 	// Lift basic block.
 	if blockAddr != 0 {
@@ -112,87 +474,172 @@ func main() {
 		return
 	}
 
-	// Lift function specified by `-func` flag.
+	// Lift functions specified by `-func` flag, except those specified by
+	// `-exclude` flag.
+	selected := make(map[bin.Address]bool)
+	for _, funcAddr := range funcs.addrs() {
+		selected[funcAddr] = true
+	}
+	candidates := l.FuncAddrs
+	if fromMain {
+		// Lift functions reachable from main if `-from-main` is set.
+		if startup.Main == 0 {
+			log.Fatalf("unable to locate main function of %q", binPath)
+		}
+		candidates = l.Reachable(startup.Main)
+	}
+	for _, funcAddr := range candidates {
+		if firstAddr != 0 && funcAddr < firstAddr {
+			// skip functions before first address.
+			continue
+		}
+		if lastAddr != 0 && funcAddr >= lastAddr {
+			// skip functions after last address.
+			break
+		}
+		if len(funcs) > 0 && !funcs.match(funcAddr, l.FuncName(funcAddr)) {
+			continue
+		}
+		selected[funcAddr] = true
+	}
 	var funcAddrs bin.Addresses
-	if funcAddr != 0 {
-		funcAddrs = []bin.Address{funcAddr}
-	} else {
-		for _, funcAddr := range l.FuncAddrs {
-			if firstAddr != 0 && funcAddr < firstAddr {
-				// skip functions before first address.
-				continue
-			}
-			if lastAddr != 0 && funcAddr >= lastAddr {
-				// skip functions after last address.
-				break
-			}
-			funcAddrs = append(funcAddrs, funcAddr)
+	for funcAddr := range selected {
+		if exclude.match(funcAddr, l.FuncName(funcAddr)) {
+			continue
 		}
+		funcAddrs = append(funcAddrs, funcAddr)
+	}
+	sort.Sort(funcAddrs)
+	if len(funcs) > 0 || len(exclude) > 0 || fromMain {
+		dbg.Printf("lifting %d of %d functions", len(funcAddrs), len(l.FuncAddrs))
+	}
+
+	// Decode functions, using the number of parallel instruction decoders
+	// specified by `-j` flag.
+	prog, err := l.DecodeProgram(funcAddrs, jobs)
+	if err != nil {
+		log.Fatalf("%+v", err)
 	}
 
 	// Create function lifters.
-	for _, funcAddr := range funcAddrs {
-		asmFunc, err := l.DecodeFunc(funcAddr)
-		if err != nil {
-			log.Fatalf("%+v", err)
+	for _, asmFunc := range prog.Funcs() {
+		funcAddr := asmFunc.Addr
+		reportCodeWrites(l.Disasm, asmFunc)
+		if blockAddrs := l.Unexecuted(asmFunc); len(blockAddrs) > 0 {
+			dbg.Printf("function at %v: %d of %d basic blocks not executed in trace: %v", funcAddr, len(blockAddrs), len(asmFunc.Blocks), blockAddrs)
 		}
 		f := l.NewFunc(asmFunc)
 		l.Funcs[funcAddr] = f
 	}
 
+	// Alias thunks to their target functions.
+	l.AliasThunks()
+
+	// Recover struct layouts if `-structs` is set.
+	if structs {
+		l.RecoverStructs()
+	}
+
+	// Store disassembly model specified by `-model` flag.
+	if len(modelPath) > 0 && model == nil {
+		dbg.Printf("storing disassembly model %q", modelPath)
+		data := marshalModel(l, funcAddrs)
+		if err := ioutil.WriteFile(modelPath, data, 0644); err != nil {
+			log.Fatalf("%+v", errors.WithStack(err))
+		}
+	}
+
 	// Lift functions.
 	for i, funcAddr := range funcAddrs {
 		if i != 0 {
 			dbg.Println()
 		}
 		f, ok := l.Funcs[funcAddr]
-		if !ok {
+		if !ok || l.IsAlias(funcAddr) {
 			continue
 		}
 		f.Lift()
 		dbg.Println(f)
 	}
+	reportSkipped(l, funcAddrs)
 
-	// Store LLVM IR output.
-	w := os.Stdout
-	if len(output) > 0 {
-		f, err := os.Create(output)
-		if err != nil {
-			log.Fatal(err)
+	// Store issue report specified by `-issues` flag, or alongside the output
+	// specified by `-o` flag.
+	// Output directory of auxiliary output files; the output path itself if
+	// `-split` is set.
+	outDir := filepath.Dir(output)
+	if split {
+		outDir = output
+	}
+	if len(issuesPath) == 0 && len(output) > 0 {
+		issuesPath = filepath.Join(outDir, "issues.json")
+	}
+	if len(issuesPath) > 0 {
+		issues := append(liftIssues(l, funcAddrs), boundaryIssues...)
+		x86dis.SortIssues(issues)
+		dbg.Printf("storing %d issues in %q", len(issues), issuesPath)
+		if err := x86dis.WriteIssues(issuesPath, issues); err != nil {
+			log.Fatalf("%+v", err)
 		}
-		defer f.Close()
-		w = f
 	}
-	var funcs []*ir.Function
-	var allFuncAddrs bin.Addresses
-	for funcAddr := range l.Funcs {
-		allFuncAddrs = append(allFuncAddrs, funcAddr)
+
+	// Export program annotations specified by `-export` flag.
+	if len(exportPath) > 0 {
+		a := exportAnnotations(l, funcAddrs)
+		if err := a.WriteFile(exportPath); err != nil {
+			log.Fatalf("%+v", err)
+		}
 	}
-	sort.Sort(allFuncAddrs)
-	for _, funcAddr := range allFuncAddrs {
-		f := l.Funcs[funcAddr]
-		funcs = append(funcs, f.Function)
+
+	// Update project database specified by `-project` flag.
+	if p != nil {
+		p.SetFile(binPath, l.File)
+		if len(p.Model) == 0 {
+			p.Model = marshalModel(l, funcAddrs)
+		}
+		p.Update(exportAnnotations(l, funcAddrs))
+		if err := p.Save(); err != nil {
+			log.Fatalf("%+v", err)
+		}
 	}
-	var globals []*ir.Global
-	var globalAddrs bin.Addresses
-	for globalAddr := range l.Globals {
-		globalAddrs = append(globalAddrs, globalAddr)
+
+	// Store LLVM IR output.
+	m := l.Module()
+	if prune {
+		n := pruneUnreachable(m, l, analysisRoots(l.File, roots))
+		dbg.Printf("pruned %d unreachable functions", n)
 	}
-	sort.Sort(globalAddrs)
-	for _, globalAddr := range globalAddrs {
-		g := l.Globals[globalAddr]
-		globals = append(globals, g)
+	if optimize {
+		opt.Module(m)
 	}
-	m := &ir.Module{
-		TypeDefs: l.TypeDefs,
-		Globals:  globals,
-		Funcs:    funcs,
+	if softFloat {
+		softfloat.Module(m)
+	}
+	if err := modulePlugins(m, plugs); err != nil {
+		log.Fatalf("%+v", err)
 	}
 	if cfgonly {
 		pruneModule(m)
 	}
-	if _, err := fmt.Fprintln(w, m); err != nil {
-		log.Fatalf("%+v", err)
+	switch {
+	case split:
+		// Store each function in a separate module if `-split` is set.
+		if err := splitModule(output, m, emit); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	case len(output) > 0:
+		if err := createModule(output, m, emit); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	default:
+		if err := writeModule(os.Stdout, m, emit); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	}
+	if lk != nil {
+		if err := lk.store(outDir, emit, optimize); err != nil {
+			log.Fatalf("%+v", err)
+		}
 	}
 
 	// Create call graph.
@@ -203,7 +650,15 @@ func main() {
 
 // newLifter returns a new x86 to LLVM IR lifter for the given binary
 // executable.
-func newLifter(binPath string, rawArch bin.Arch, rawEntry, rawBase bin.Address) (*x86.Lifter, error) {
+func newLifter(binPath, modMap string, rawArch bin.Arch, rawEntry, rawBase bin.Address) (*x86.Lifter, error) {
+	// Parse raw process memory dump.
+	if len(modMap) > 0 {
+		file, err := memdump.ParseRaw(binPath, modMap)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return x86.NewLifter(file)
+	}
 	// Parse raw binary executable.
 	if rawArch != 0 {
 		file, err := raw.ParseFile(binPath, rawArch)
@@ -211,6 +666,7 @@ func newLifter(binPath string, rawArch bin.Arch, rawEntry, rawBase bin.Address)
 			return nil, errors.WithStack(err)
 		}
 		file.Entry = rawEntry
+		file.Base = rawBase
 		file.Sections[0].Addr = rawBase
 		return x86.NewLifter(file)
 	}
@@ -222,6 +678,87 @@ func newLifter(binPath string, rawArch bin.Arch, rawEntry, rawBase bin.Address)
 	return x86.NewLifter(file)
 }
 
+// reportSkipped reports the functions skipped for exceeding the lifting
+// budget.
+func reportSkipped(l *x86.Lifter, funcAddrs []bin.Address) {
+	var skipped []*x86.Func
+	for _, funcAddr := range funcAddrs {
+		if f, ok := l.Funcs[funcAddr]; ok && len(f.Skipped) > 0 && !l.IsAlias(funcAddr) {
+			skipped = append(skipped, f)
+		}
+	}
+	if len(skipped) == 0 {
+		return
+	}
+	warn.Printf("skipped %d functions exceeding the lifting budget:", len(skipped))
+	for _, f := range skipped {
+		warn.Printf("   %v %s; %s", f.AsmFunc.Addr, f.Name(), f.Skipped)
+	}
+}
+
+// liftIssues returns the issues of the given functions encountered during
+// disassembly and lifting.
+func liftIssues(l *x86.Lifter, funcAddrs []bin.Address) []*x86dis.Issue {
+	var fs []*x86dis.Func
+	for _, funcAddr := range funcAddrs {
+		if f, ok := l.Funcs[funcAddr]; ok && !l.IsAlias(funcAddr) {
+			fs = append(fs, f.AsmFunc)
+		}
+	}
+	issues := l.Issues(fs)
+	for _, funcAddr := range funcAddrs {
+		f, ok := l.Funcs[funcAddr]
+		if !ok || len(f.Skipped) == 0 || l.IsAlias(funcAddr) {
+			continue
+		}
+		addr := f.SkippedAt
+		if addr == 0 {
+			addr = funcAddr
+		}
+		issue := &x86dis.Issue{
+			Kind: x86dis.IssueUnlifted,
+			Addr: addr,
+			Func: funcAddr,
+			Msg:  f.Skipped,
+		}
+		issues = append(issues, issue)
+	}
+	x86dis.SortIssues(issues)
+	return issues
+}
+
+// outputExts maps from output format to file extension.
+var outputExts = map[string]string{
+	// LLVM IR assembly.
+	"ll": ".ll",
+	// LLVM bitcode.
+	"bc": ".bc",
+	// WebAssembly text format.
+	"wat": ".wat",
+	// C.
+	"c": ".c",
+}
+
+// writeModule writes the given LLVM IR module to w in the specified output
+// format.
+func writeModule(w io.Writer, m *ir.Module, emit string) error {
+	switch emit {
+	case "ll":
+		if _, err := fmt.Fprintln(w, m); err != nil {
+			return errors.WithStack(err)
+		}
+		return nil
+	case "bc":
+		return bitcode.Write(w, m)
+	case "wat":
+		return wasm.Write(w, m)
+	case "c":
+		return c.Write(w, m)
+	default:
+		panic(fmt.Errorf("support for output format %q not yet implemented", emit))
+	}
+}
+
 // pruneModule prunes the LLVM IR module to the minimal needed for CFG
 // generation.
 func pruneModule(m *ir.Module) {
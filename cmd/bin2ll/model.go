@@ -0,0 +1,35 @@
+package main
+
+import (
+	"github.com/decomp/exp/bin"
+	x86dis "github.com/decomp/exp/disasm/x86"
+	"github.com/decomp/exp/lift/x86"
+	"github.com/pkg/errors"
+)
+
+// newLifterFromModel returns a new x86 to LLVM IR lifter based on the given
+// serialized disassembly model, the functions of which are not decoded again.
+func newLifterFromModel(model []byte) (*x86.Lifter, error) {
+	file, fs, err := x86dis.Unmarshal(model)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	l, err := x86.NewLifter(file)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	l.AddFuncs(fs)
+	return l, nil
+}
+
+// marshalModel returns the serialized disassembly model of the decoded
+// functions at the given addresses.
+func marshalModel(l *x86.Lifter, funcAddrs []bin.Address) []byte {
+	var fs []*x86dis.Func
+	for _, funcAddr := range funcAddrs {
+		if f, ok := l.Funcs[funcAddr]; ok && f.AsmFunc != nil {
+			fs = append(fs, f.AsmFunc)
+		}
+	}
+	return x86dis.Marshal(l.File, fs)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"plugin"
+	"strings"
+
+	"github.com/decomp/exp/lift/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/pkg/errors"
+)
+
+// plugins is a list of user analyses loaded as Go plugins, which may be
+// specified multiple times on the command line.
+//
+// A plugin is a Go main package built with `go build -buildmode=plugin`, which
+// exports one or both of the following functions.
+//
+//    // Init is invoked after the lifter has been created and program
+//    // annotations have been imported, but before functions are lifted; e.g.
+//    // to name functions (l.Names), import annotations (l.Import) or register
+//    // lifting hooks (l.Hooks).
+//    func Init(l *x86.Lifter) error
+//
+//    // Module is invoked on the output LLVM IR module before it is stored.
+//    func Module(m *ir.Module) error
+//
+// Plugins must be built against the same version of this repository as bin2ll.
+type plugins []*userPlugin
+
+// A userPlugin is a user analysis loaded as a Go plugin.
+type userPlugin struct {
+	// Plugin path.
+	path string
+	// Init function of the plugin; or nil if not present.
+	init func(l *x86.Lifter) error
+	// Module function of the plugin; or nil if not present.
+	module func(m *ir.Module) error
+}
+
+// String returns the string representation of the plugins.
+func (ps *plugins) String() string {
+	var ss []string
+	for _, p := range *ps {
+		ss = append(ss, p.path)
+	}
+	return strings.Join(ss, ",")
+}
+
+// Set loads the plugin at the given path.
+func (ps *plugins) Set(path string) error {
+	p, err := loadPlugin(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	*ps = append(*ps, p)
+	return nil
+}
+
+// loadPlugin loads the Go plugin at the given path.
+func loadPlugin(path string) (*userPlugin, error) {
+	plug, err := plugin.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	p := &userPlugin{path: path}
+	if sym, err := plug.Lookup("Init"); err == nil {
+		fn, ok := sym.(func(l *x86.Lifter) error)
+		if !ok {
+			return nil, errors.Errorf("invalid Init function of plugin %q; expected func(*x86.Lifter) error, got %T", path, sym)
+		}
+		p.init = fn
+	}
+	if sym, err := plug.Lookup("Module"); err == nil {
+		fn, ok := sym.(func(m *ir.Module) error)
+		if !ok {
+			return nil, errors.Errorf("invalid Module function of plugin %q; expected func(*ir.Module) error, got %T", path, sym)
+		}
+		p.module = fn
+	}
+	if p.init == nil && p.module == nil {
+		return nil, errors.Errorf("invalid plugin %q; missing Init and Module functions", path)
+	}
+	return p, nil
+}
+
+// initPlugins invokes the Init functions of the given plugins.
+func initPlugins(l *x86.Lifter, ps plugins) error {
+	for _, p := range ps {
+		if p.init == nil {
+			continue
+		}
+		if err := p.init(l); err != nil {
+			return errors.Wrapf(err, "unable to initialize plugin %q", p.path)
+		}
+	}
+	return nil
+}
+
+// modulePlugins invokes the Module functions of the given plugins.
+func modulePlugins(m *ir.Module, ps plugins) error {
+	for _, p := range ps {
+		if p.module == nil {
+			continue
+		}
+		if err := p.module(m); err != nil {
+			return errors.Wrapf(err, "unable to process module with plugin %q", p.path)
+		}
+	}
+	return nil
+}
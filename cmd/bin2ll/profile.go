@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"runtime"
+	"runtime/pprof"
+
+	"github.com/pkg/errors"
+)
+
+// startCPUProfile starts CPU profiling, writing the profile to the given output
+// file. The returned function stops profiling and closes the output file.
+func startCPUProfile(path string) (stop func(), err error) {
+	dbg.Printf("creating CPU profile %q", path)
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		f.Close()
+		return nil, errors.WithStack(err)
+	}
+	stop = func() {
+		pprof.StopCPUProfile()
+		if err := f.Close(); err != nil {
+			warn.Printf("unable to close CPU profile %q; %v", path, err)
+		}
+	}
+	return stop, nil
+}
+
+// writeMemProfile writes a heap profile to the given output file.
+func writeMemProfile(path string) error {
+	dbg.Printf("creating memory profile %q", path)
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+	// Update statistics of allocations.
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/lift/irutil"
+	"github.com/decomp/exp/lift/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/value"
+)
+
+// pruneUnreachable removes the functions of the given LLVM IR module which are
+// not reachable from the given roots of analysis (e.g. the entry point and
+// exports of the binary executable), and returns the number of functions
+// removed.
+//
+// Reachability is determined by the call graph, where functions and global
+// variables referenced by a reachable function (e.g. callees and function
+// pointers), or by the initializer of a reachable global variable (e.g.
+// virtual method tables), are reachable. Integer constants which coincide with
+// the address of a function or global variable are considered references, as
+// addresses are not always resolved by the lifter.
+func pruneUnreachable(m *ir.Module, l *x86.Lifter, roots []bin.Address) int {
+	r := &reachability{
+		l:       l,
+		reached: make(map[value.Value]bool),
+	}
+	// Roots.
+	for _, addr := range roots {
+		r.refAddr(addr)
+	}
+	if len(r.queue) == 0 {
+		warn.Printf("unable to prune unreachable functions; roots of analysis (entry point, exports and initialization functions) not lifted")
+		return 0
+	}
+	for len(r.queue) > 0 {
+		v := r.queue[len(r.queue)-1]
+		r.queue = r.queue[:len(r.queue)-1]
+		switch v := v.(type) {
+		case *ir.Function:
+			for _, block := range v.Blocks {
+				for _, inst := range block.Insts {
+					for _, operand := range irutil.Operands(inst) {
+						r.ref(*operand)
+					}
+				}
+				for _, operand := range irutil.Operands(block.Term) {
+					r.ref(*operand)
+				}
+			}
+		case *ir.Global:
+			if v.Init != nil {
+				r.ref(v.Init)
+			}
+		}
+	}
+	// Remove unreachable functions.
+	n := 0
+	funcs := m.Funcs[:0]
+	for _, f := range m.Funcs {
+		if !r.reached[f] {
+			n++
+			continue
+		}
+		funcs = append(funcs, f)
+	}
+	m.Funcs = funcs
+	return n
+}
+
+// reachability tracks the functions and global variables reachable from the
+// entry point and exports of a binary executable.
+type reachability struct {
+	// x86 to LLVM IR lifter.
+	l *x86.Lifter
+	// Reached functions and global variables.
+	reached map[value.Value]bool
+	// Reached functions and global variables not yet visited.
+	queue []value.Value
+}
+
+// ref marks the functions and global variables referenced by the given value
+// as reachable.
+func (r *reachability) ref(v value.Value) {
+	switch v := v.(type) {
+	case *ir.Function, *ir.Global:
+		r.reach(v)
+	case *constant.Int:
+		if v.X.IsUint64() {
+			r.refAddr(bin.Address(v.X.Uint64()))
+		}
+	case *constant.Array:
+		for _, elem := range v.Elems {
+			r.ref(elem)
+		}
+	case *constant.Struct:
+		for _, field := range v.Fields {
+			r.ref(field)
+		}
+	case *constant.ExprBitCast:
+		r.ref(v.From)
+	case *constant.ExprPtrToInt:
+		r.ref(v.From)
+	case *constant.ExprIntToPtr:
+		r.ref(v.From)
+	case *constant.ExprGetElementPtr:
+		r.ref(v.Src)
+	}
+}
+
+// refAddr marks the function or global variable at the given address as
+// reachable.
+func (r *reachability) refAddr(addr bin.Address) {
+	if f, ok := r.l.Funcs[addr]; ok {
+		r.reach(f.Function)
+	}
+	if g, ok := r.l.Globals[addr]; ok {
+		r.reach(g)
+	}
+}
+
+// reach marks the given function or global variable as reachable.
+func (r *reachability) reach(v value.Value) {
+	if r.reached[v] {
+		return
+	}
+	r.reached[v] = true
+	r.queue = append(r.queue, v)
+}
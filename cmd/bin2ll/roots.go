@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// addrList is a list of addresses, which may be specified multiple times on
+// the command line, each a comma-separated list of addresses.
+type addrList []bin.Address
+
+// String returns the string representation of the address list.
+func (as *addrList) String() string {
+	var ss []string
+	for _, addr := range *as {
+		ss = append(ss, addr.String())
+	}
+	return strings.Join(ss, ",")
+}
+
+// Set adds the comma-separated addresses represented by s.
+func (as *addrList) Set(s string) error {
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if len(field) == 0 {
+			continue
+		}
+		var addr bin.Address
+		if err := addr.Set(field); err != nil {
+			return errors.Errorf("invalid address %q", field)
+		}
+		*as = append(*as, addr)
+	}
+	return nil
+}
+
+// analysisRoots returns the roots of analysis of the given binary executable;
+// i.e. the entry point (if any), exported functions and initialization
+// functions (e.g. TLS callbacks), and the given additional roots. Exported data
+// is excluded.
+func analysisRoots(file *bin.File, extra []bin.Address) []bin.Address {
+	as := file.AddressSpace()
+	var roots []bin.Address
+	add := func(addr bin.Address) {
+		if addr != 0 && as.Contains(addr, bin.PermX) {
+			roots = bin.InsertAddr(roots, addr)
+		}
+	}
+	add(file.Entry)
+	for addr := range file.Exports {
+		add(addr)
+	}
+	for _, addr := range file.InitFuncs {
+		add(addr)
+	}
+	for _, addr := range extra {
+		add(addr)
+	}
+	return roots
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"io/ioutil"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/pkg/errors"
+)
+
+// memDump is a memory dump of a region of the binary executable (e.g. code
+// decrypted at runtime), in PATH@ADDR format.
+type memDump struct {
+	// Path to memory dump.
+	path string
+	// Start address of memory dump.
+	addr bin.Address
+}
+
+// memDumps is a list of memory dumps, which may be specified multiple times
+// on the command line.
+type memDumps []*memDump
+
+// String returns the string representation of the memory dumps.
+func (ds *memDumps) String() string {
+	var ss []string
+	for _, d := range *ds {
+		ss = append(ss, d.path+"@"+d.addr.String())
+	}
+	return strings.Join(ss, ",")
+}
+
+// Set adds the memory dump represented by s, in PATH@ADDR format.
+func (ds *memDumps) Set(s string) error {
+	pos := strings.LastIndex(s, "@")
+	if pos == -1 {
+		return errors.Errorf("invalid memory dump %q; expected PATH@ADDR format", s)
+	}
+	d := &memDump{path: s[:pos]}
+	if err := d.addr.Set(s[pos+1:]); err != nil {
+		return errors.WithStack(err)
+	}
+	*ds = append(*ds, d)
+	return nil
+}
+
+// applyDumps overwrites the contents of the binary executable with the given
+// memory dumps.
+func applyDumps(file *bin.File, dumps memDumps) error {
+	for _, d := range dumps {
+		dbg.Printf("applying memory dump %q at %v", d.path, d.addr)
+		data, err := ioutil.ReadFile(d.path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if err := file.Patch(d.addr, data); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// reportCodeWrites reports writes into executable sections of the given
+// function; i.e. potential self-modifying code.
+func reportCodeWrites(dis *x86.Disasm, f *x86.Func) {
+	for _, w := range dis.CodeWrites(f) {
+		if w.Executed {
+			warn.Printf("self-modifying code in function at %v; instruction at %v writes %d bytes to code at %v (consider lifting from memory dump using -dump)", f.Addr, w.InstAddr, w.Size, w.Addr)
+		} else {
+			warn.Printf("write to executable section in function at %v; instruction at %v writes %d bytes to %v", f.Addr, w.InstAddr, w.Size, w.Addr)
+		}
+	}
+}
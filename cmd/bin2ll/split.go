@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/decomp/exp/lift/irutil"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/value"
+	"github.com/pkg/errors"
+)
+
+// indexName specifies the base name of the index module of split output.
+const indexName = "index"
+
+// splitModule stores each function definition of the given LLVM IR module as a
+// separate module (e.g. f_401000.ll) in the output directory, in the specified
+// output format. Each module declares the functions and global variables
+// referenced by its function definition. The index module (index.ll) defines
+// the global variables and declares every function of the module; linking the
+// index module with the function modules produces the complete module.
+//
+// Function modules are only rewritten when their contents change, so that the
+// modification time of unchanged functions is retained for incremental
+// recompilation.
+func splitModule(dir string, m *ir.Module, emit string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	// Function declarations, by function.
+	decls := make(map[*ir.Function]*ir.Function)
+	index := &ir.Module{
+		TypeDefs: m.TypeDefs,
+		Globals:  m.Globals,
+	}
+	for _, f := range m.Funcs {
+		if len(f.Blocks) == 0 {
+			decls[f] = f
+		} else {
+			decls[f] = declareFunc(f)
+		}
+		index.Funcs = append(index.Funcs, decls[f])
+	}
+	// Global variable declarations, by global variable.
+	globals := make(map[*ir.Global]*ir.Global)
+	for _, g := range m.Globals {
+		globals[g] = ir.NewGlobalDecl(g.Name(), g.ContentType)
+	}
+	// Number of functions with a given file name; used to disambiguate output
+	// paths.
+	names := make(map[string]int)
+	for _, f := range m.Funcs {
+		if len(f.Blocks) == 0 {
+			continue
+		}
+		fm := &ir.Module{
+			TypeDefs: m.TypeDefs,
+		}
+		seen := make(map[value.Value]bool)
+		add := func(v value.Value) {
+			if seen[v] || v == f {
+				return
+			}
+			seen[v] = true
+			switch v := v.(type) {
+			case *ir.Function:
+				if decl, ok := decls[v]; ok {
+					fm.Funcs = append(fm.Funcs, decl)
+				}
+			case *ir.Global:
+				if decl, ok := globals[v]; ok {
+					fm.Globals = append(fm.Globals, decl)
+				}
+			}
+		}
+		for _, block := range f.Blocks {
+			for _, inst := range block.Insts {
+				for _, operand := range irutil.Operands(inst) {
+					refs(*operand, add)
+				}
+			}
+			for _, operand := range irutil.Operands(block.Term) {
+				refs(*operand, add)
+			}
+		}
+		fm.Funcs = append(fm.Funcs, f)
+		name := fileName(f.Name())
+		if n := names[name]; n > 0 {
+			names[name]++
+			name = fmt.Sprintf("%s_%d", name, n)
+		} else {
+			names[name]++
+		}
+		outPath := filepath.Join(dir, name+outputExts[emit])
+		if err := updateModule(outPath, fm, emit); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	indexPath := filepath.Join(dir, indexName+outputExts[emit])
+	dbg.Printf("creating %q (%d functions)", indexPath, len(index.Funcs))
+	return updateModule(indexPath, index, emit)
+}
+
+// declareFunc returns a declaration of the given function.
+func declareFunc(f *ir.Function) *ir.Function {
+	var params []*ir.Param
+	for _, param := range f.Params {
+		params = append(params, ir.NewParam(param.Name(), param.Typ))
+	}
+	decl := ir.NewFunc(f.Name(), f.Sig.RetType, params...)
+	decl.Sig.Variadic = f.Sig.Variadic
+	decl.CallingConv = f.CallingConv
+	return decl
+}
+
+// refs invokes fn for each function and global variable referenced by the
+// given value.
+func refs(v value.Value, fn func(v value.Value)) {
+	switch v := v.(type) {
+	case *ir.Function, *ir.Global:
+		fn(v)
+	case *constant.Array:
+		for _, elem := range v.Elems {
+			refs(elem, fn)
+		}
+	case *constant.Struct:
+		for _, field := range v.Fields {
+			refs(field, fn)
+		}
+	case *constant.ExprBitCast:
+		refs(v.From, fn)
+	case *constant.ExprPtrToInt:
+		refs(v.From, fn)
+	case *constant.ExprIntToPtr:
+		refs(v.From, fn)
+	case *constant.ExprGetElementPtr:
+		refs(v.Src, fn)
+		for _, index := range v.Indices {
+			refs(index.Index, fn)
+		}
+	}
+}
+
+// updateModule stores the given LLVM IR module to the specified path in the
+// given output format, unless the file already has the same contents.
+func updateModule(outPath string, m *ir.Module, emit string) error {
+	buf := &bytes.Buffer{}
+	if err := writeModule(buf, m, emit); err != nil {
+		return errors.WithStack(err)
+	}
+	if old, err := ioutil.ReadFile(outPath); err == nil && bytes.Equal(old, buf.Bytes()) {
+		// skip unchanged module.
+		return nil
+	}
+	if err := ioutil.WriteFile(outPath, buf.Bytes(), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// ### [ Helper functions ] ####################################################
+
+// fileName returns a file name based on the given function name, with
+// characters not valid in file names (e.g. "?@<>:") replaced by underscores.
+func fileName(funcName string) string {
+	f := func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', r == '_', r == '-', r == '.':
+			return r
+		}
+		return '_'
+	}
+	name := strings.Map(f, funcName)
+	if name == indexName || strings.HasPrefix(name, ".") {
+		// avoid conflict with index module and hidden files.
+		name = "_" + name
+	}
+	return name
+}
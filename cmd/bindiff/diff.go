@@ -0,0 +1,221 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+)
+
+// A Diff records the function-level differences between two binary
+// executables.
+type Diff struct {
+	// Path of the old binary executable.
+	Old string `json:"old"`
+	// Path of the new binary executable.
+	New string `json:"new"`
+	// Functions only present in the new binary executable.
+	Added []bin.Address `json:"added"`
+	// Functions only present in the old binary executable.
+	Removed []bin.Address `json:"removed"`
+	// Matched functions with differing contents.
+	Changed []*FuncDiff `json:"changed"`
+	// Matched functions with identical contents.
+	Unchanged []*FuncDiff `json:"unchanged"`
+}
+
+// A FuncDiff records the basic block-level differences between a pair of
+// matched functions.
+type FuncDiff struct {
+	// Address of the function in the old binary executable.
+	OldAddr bin.Address `json:"old_addr"`
+	// Address of the function in the new binary executable.
+	NewAddr bin.Address `json:"new_addr"`
+	// Fingerprint used to match the functions; "hash", "cfg", "similar" or
+	// "addr".
+	Match string `json:"match"`
+	// Similarity of the functions, between 0 and 1; only set for functions
+	// matched by similarity.
+	Similarity float64 `json:"similarity,omitempty"`
+	// Basic blocks only present in the new function.
+	AddedBlocks []bin.Address `json:"added_blocks,omitempty"`
+	// Basic blocks only present in the old function.
+	RemovedBlocks []bin.Address `json:"removed_blocks,omitempty"`
+}
+
+// diffFuncs matches the functions of two binary executables and returns their
+// differences.
+//
+// Functions are matched in four passes, each considering only functions left
+// unmatched by the previous passes. First, functions with a unique instruction
+// hash are matched. Secondly, functions with a unique control flow graph
+// fingerprint are matched. Thirdly, functions which are each other's most
+// similar function (with a similarity of at least minSimilarity) are matched.
+// Lastly, functions located at the same address are matched.
+func diffFuncs(oldFuncs, newFuncs []*Func) *Diff {
+	diff := &Diff{}
+	oldLeft := make(map[bin.Address]*Func)
+	for _, f := range oldFuncs {
+		oldLeft[f.Addr] = f
+	}
+	newLeft := make(map[bin.Address]*Func)
+	for _, f := range newFuncs {
+		newLeft[f.Addr] = f
+	}
+	var fdiff *FuncDiff
+	record := func(oldFunc, newFunc *Func, match string) {
+		delete(oldLeft, oldFunc.Addr)
+		delete(newLeft, newFunc.Addr)
+		fdiff = diffBlocks(oldFunc, newFunc)
+		fdiff.Match = match
+		if oldFunc.Hash == newFunc.Hash {
+			diff.Unchanged = append(diff.Unchanged, fdiff)
+		} else {
+			diff.Changed = append(diff.Changed, fdiff)
+		}
+	}
+	// Match by instruction hash.
+	for _, pair := range uniquePairs(oldFuncs, newFuncs, oldLeft, newLeft, func(f *Func) string { return f.Hash }) {
+		record(pair[0], pair[1], "hash")
+	}
+	// Match by control flow graph fingerprint.
+	for _, pair := range uniquePairs(oldFuncs, newFuncs, oldLeft, newLeft, func(f *Func) string { return f.CFG }) {
+		record(pair[0], pair[1], "cfg")
+	}
+	// Match by similarity.
+	for _, pair := range similarPairs(oldFuncs, newFuncs, oldLeft, newLeft) {
+		record(pair.old, pair.new, "similar")
+		fdiff.Similarity = pair.score
+	}
+	// Match by address.
+	for _, oldFunc := range oldFuncs {
+		if _, ok := oldLeft[oldFunc.Addr]; !ok {
+			continue
+		}
+		if newFunc, ok := newLeft[oldFunc.Addr]; ok {
+			record(oldFunc, newFunc, "addr")
+		}
+	}
+	for addr := range oldLeft {
+		diff.Removed = append(diff.Removed, addr)
+	}
+	for addr := range newLeft {
+		diff.Added = append(diff.Added, addr)
+	}
+	sort.Sort(bin.Addresses(diff.Removed))
+	sort.Sort(bin.Addresses(diff.Added))
+	sortFuncDiffs(diff.Changed)
+	sortFuncDiffs(diff.Unchanged)
+	return diff
+}
+
+// uniquePairs returns the pairs of unmatched functions which share a key that
+// is unique within both binary executables.
+func uniquePairs(oldFuncs, newFuncs []*Func, oldLeft, newLeft map[bin.Address]*Func, key func(f *Func) string) [][2]*Func {
+	index := func(fs []*Func, left map[bin.Address]*Func) map[string][]*Func {
+		m := make(map[string][]*Func)
+		for _, f := range fs {
+			if _, ok := left[f.Addr]; ok {
+				k := key(f)
+				m[k] = append(m[k], f)
+			}
+		}
+		return m
+	}
+	oldIndex := index(oldFuncs, oldLeft)
+	newIndex := index(newFuncs, newLeft)
+	var pairs [][2]*Func
+	for _, oldFunc := range oldFuncs {
+		if _, ok := oldLeft[oldFunc.Addr]; !ok {
+			continue
+		}
+		k := key(oldFunc)
+		if len(oldIndex[k]) == 1 && len(newIndex[k]) == 1 {
+			pairs = append(pairs, [2]*Func{oldFunc, newIndex[k][0]})
+		}
+	}
+	return pairs
+}
+
+// Minimum similarity of functions matched by similarity.
+const minSimilarity = 0.8
+
+// A similarPair is a pair of similar functions.
+type similarPair struct {
+	old, new *Func
+	score    float64
+}
+
+// similarPairs returns the pairs of unmatched functions which are each other's
+// most similar unmatched function, with a similarity of at least minSimilarity.
+func similarPairs(oldFuncs, newFuncs []*Func, oldLeft, newLeft map[bin.Address]*Func) []similarPair {
+	prints := func(fs []*Func, left map[bin.Address]*Func) []*x86.FuncPrint {
+		var ps []*x86.FuncPrint
+		for _, f := range fs {
+			if _, ok := left[f.Addr]; ok {
+				ps = append(ps, f.Print)
+			}
+		}
+		return ps
+	}
+	// Similar functions are sorted by descending similarity, thus the first
+	// occurrence of each function is its most similar function.
+	oldBest := make(map[bin.Address]bin.Address)
+	newBest := make(map[bin.Address]bin.Address)
+	sims := x86.Similar(prints(oldFuncs, oldLeft), prints(newFuncs, newLeft), minSimilarity)
+	for _, sim := range sims {
+		if _, ok := oldBest[sim.A.Addr]; !ok {
+			oldBest[sim.A.Addr] = sim.B.Addr
+		}
+		if _, ok := newBest[sim.B.Addr]; !ok {
+			newBest[sim.B.Addr] = sim.A.Addr
+		}
+	}
+	var pairs []similarPair
+	for _, sim := range sims {
+		if oldBest[sim.A.Addr] != sim.B.Addr || newBest[sim.B.Addr] != sim.A.Addr {
+			continue
+		}
+		// Prevent duplicate pairs of functions with equal similarity.
+		delete(oldBest, sim.A.Addr)
+		pair := similarPair{old: oldLeft[sim.A.Addr], new: newLeft[sim.B.Addr], score: sim.Score}
+		pairs = append(pairs, pair)
+	}
+	return pairs
+}
+
+// diffBlocks returns the basic block-level differences between the given pair
+// of matched functions. Basic blocks are matched by instruction hash, in
+// address order.
+func diffBlocks(oldFunc, newFunc *Func) *FuncDiff {
+	fdiff := &FuncDiff{
+		OldAddr: oldFunc.Addr,
+		NewAddr: newFunc.Addr,
+	}
+	newBlocks := make(map[string][]*Block)
+	for _, block := range newFunc.Blocks {
+		newBlocks[block.Hash] = append(newBlocks[block.Hash], block)
+	}
+	for _, block := range oldFunc.Blocks {
+		if bs := newBlocks[block.Hash]; len(bs) > 0 {
+			newBlocks[block.Hash] = bs[1:]
+			continue
+		}
+		fdiff.RemovedBlocks = append(fdiff.RemovedBlocks, block.Addr)
+	}
+	for _, bs := range newBlocks {
+		for _, block := range bs {
+			fdiff.AddedBlocks = append(fdiff.AddedBlocks, block.Addr)
+		}
+	}
+	sort.Sort(bin.Addresses(fdiff.AddedBlocks))
+	return fdiff
+}
+
+// sortFuncDiffs sorts the given function differences by old address.
+func sortFuncDiffs(fdiffs []*FuncDiff) {
+	less := func(i, j int) bool {
+		return fdiffs[i].OldAddr < fdiffs[j].OldAddr
+	}
+	sort.Slice(fdiffs, less)
+}
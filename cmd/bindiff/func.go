@@ -0,0 +1,84 @@
+package main
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+)
+
+// A Func is a disassembled function together with its fingerprints.
+type Func struct {
+	// Address of the function.
+	Addr bin.Address
+	// Hash of the normalized instructions of the function.
+	Hash string
+	// Control flow graph fingerprint of the function.
+	CFG string
+	// Basic blocks of the function, sorted by address.
+	Blocks []*Block
+	// Similarity fingerprint of the function.
+	Print *x86.FuncPrint
+}
+
+// A Block is a basic block together with its fingerprint.
+type Block struct {
+	// Address of the basic block.
+	Addr bin.Address
+	// Hash of the normalized instructions of the basic block.
+	Hash string
+	// Number of instructions of the basic block, including the terminator.
+	NInsts int
+	// Number of successors of the basic block.
+	NSuccs int
+}
+
+// newFunc returns the fingerprinted function corresponding to the given
+// disassembled function.
+func newFunc(dis *x86.Disasm, asmFunc *x86.Func) *Func {
+	f := &Func{
+		Addr:  asmFunc.Addr,
+		Print: dis.FuncPrint(asmFunc),
+	}
+	var blockAddrs bin.Addresses
+	for blockAddr := range asmFunc.Blocks {
+		blockAddrs = append(blockAddrs, blockAddr)
+	}
+	sort.Sort(blockAddrs)
+	funcHash := sha1.New()
+	nedges := 0
+	var shape []string
+	for _, blockAddr := range blockAddrs {
+		asmBlock := asmFunc.Blocks[blockAddr]
+		blockHash := sha1.New()
+		ninsts := 0
+		for _, inst := range asmBlock.Insts {
+			io.WriteString(blockHash, x86.NormInst(inst))
+			ninsts++
+		}
+		if !asmBlock.Term.IsDummyTerm() {
+			io.WriteString(blockHash, x86.NormInst(asmBlock.Term))
+			ninsts++
+		}
+		block := &Block{
+			Addr:   blockAddr,
+			Hash:   fmt.Sprintf("%x", blockHash.Sum(nil)),
+			NInsts: ninsts,
+			NSuccs: len(dis.Targets(asmBlock.Term, asmFunc.Addr)),
+		}
+		io.WriteString(funcHash, block.Hash)
+		nedges += block.NSuccs
+		shape = append(shape, fmt.Sprintf("%d/%d", block.NInsts, block.NSuccs))
+		f.Blocks = append(f.Blocks, block)
+	}
+	f.Hash = fmt.Sprintf("%x", funcHash.Sum(nil))
+	// The CFG fingerprint is independent of block addresses and instruction
+	// operands, and thus survives relocation and minor code changes.
+	sort.Strings(shape)
+	f.CFG = fmt.Sprintf("%d:%d:%s", len(f.Blocks), nedges, strings.Join(shape, ","))
+	return f
+}
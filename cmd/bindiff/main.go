@@ -0,0 +1,167 @@
+// The bindiff tool reports function-level differences between two binary
+// executables (old.exe, new.exe -> diff.json, diff.html).
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/decomp/exp/bin"
+	_ "github.com/decomp/exp/bin/coff"    // register COFF decoder
+	_ "github.com/decomp/exp/bin/elf"     // register ELF decoder
+	_ "github.com/decomp/exp/bin/le"      // register LE/LX decoder
+	_ "github.com/decomp/exp/bin/memdump" // register minidump decoder
+	_ "github.com/decomp/exp/bin/ne"      // register NE decoder
+	_ "github.com/decomp/exp/bin/pe"      // register PE decoder
+	_ "github.com/decomp/exp/bin/pef"     // register PEF decoder
+	"github.com/decomp/exp/bin/raw"
+	"github.com/decomp/exp/config"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/mewkiz/pkg/term"
+	"github.com/pkg/errors"
+)
+
+// Loggers.
+var (
+	// dbg represents a logger with the "bindiff:" prefix, which logs debug
+	// messages to standard error.
+	dbg = log.New(os.Stderr, term.CyanBold("bindiff:")+" ", 0)
+	// warn represents a logger with the "warning:" prefix, which logs warning
+	// messages to standard error.
+	warn = log.New(os.Stderr, term.RedBold("warning:")+" ", 0)
+)
+
+func usage() {
+	const use = `
+Report function-level differences between two binary executables.
+
+Usage:
+
+	bindiff [OPTION]... OLD NEW
+
+Flags:
+`
+	fmt.Fprint(os.Stderr, use[1:])
+	flag.PrintDefaults()
+}
+
+func main() {
+	// Parse command line arguments.
+	var (
+		// format specifies the output format (json or html).
+		format string
+		// output specifies the output path.
+		output string
+		// quiet specifies whether to suppress non-error messages.
+		quiet bool
+		// rawArch specifies the machine architecture of raw binary executables.
+		rawArch bin.Arch
+		// rawEntry specifies the entry point of raw binary executables.
+		rawEntry bin.Address
+		// rawBase specifies the base address of raw binary executables.
+		rawBase bin.Address
+	)
+	flag.Usage = usage
+	flag.StringVar(&format, "format", "json", "output format (json or html)")
+	flag.StringVar(&output, "o", "", "output path")
+	flag.BoolVar(&quiet, "q", false, "suppress non-error messages")
+	flag.Var(&rawArch, "raw", "machine architecture of raw binary executables (x86_32, x86_64, ...)")
+	flag.Var(&rawEntry, "rawentry", "entry point of raw binary executables")
+	flag.Var(&rawBase, "rawbase", "base address of raw binary executables")
+	flag.Parse()
+	// Apply project configuration file.
+	if err := config.Setup("bindiff", flag.CommandLine, false); err != nil {
+		log.Fatalf("%+v", err)
+	}
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	oldPath, newPath := flag.Arg(0), flag.Arg(1)
+	// Mute debug and warning messages if `-q` is set.
+	if quiet {
+		dbg.SetOutput(ioutil.Discard)
+		warn.SetOutput(ioutil.Discard)
+	}
+
+	// Disassemble functions of both binary executables.
+	oldFuncs, err := decodeFuncs(oldPath, rawArch, rawEntry, rawBase)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+	newFuncs, err := decodeFuncs(newPath, rawArch, rawEntry, rawBase)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	// Match functions and compute differences.
+	diff := diffFuncs(oldFuncs, newFuncs)
+	diff.Old = oldPath
+	diff.New = newPath
+
+	// Write report.
+	w := os.Stdout
+	if len(output) > 0 {
+		f, err := os.Create(output)
+		if err != nil {
+			log.Fatalf("%+v", errors.WithStack(err))
+		}
+		defer f.Close()
+		w = f
+	}
+	switch format {
+	case "json":
+		if err := writeJSON(w, diff); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	case "html":
+		if err := writeHTML(w, diff); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	default:
+		log.Fatalf("support for output format %q not yet implemented", format)
+	}
+}
+
+// decodeFuncs disassembles the functions of the given binary executable.
+func decodeFuncs(binPath string, rawArch bin.Arch, rawEntry, rawBase bin.Address) ([]*Func, error) {
+	dis, err := newDisasm(binPath, rawArch, rawEntry, rawBase)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	dbg.Printf("disassembling %d functions of %q", len(dis.FuncAddrs), binPath)
+	var fs []*Func
+	for _, funcAddr := range dis.FuncAddrs {
+		asmFunc, err := dis.DecodeFunc(funcAddr)
+		if err != nil {
+			warn.Printf("unable to decode function at %v; %v", funcAddr, err)
+			continue
+		}
+		fs = append(fs, newFunc(dis, asmFunc))
+	}
+	return fs, nil
+}
+
+// newDisasm returns a new disassembler for the given binary executable.
+func newDisasm(binPath string, rawArch bin.Arch, rawEntry, rawBase bin.Address) (*x86.Disasm, error) {
+	// Parse raw binary executable.
+	if rawArch != 0 {
+		file, err := raw.ParseFile(binPath, rawArch)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		file.Entry = rawEntry
+		file.Base = rawBase
+		file.Sections[0].Addr = rawBase
+		return x86.NewDisasm(file)
+	}
+	// Parse binary executable.
+	file, err := bin.ParseFile(binPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return x86.NewDisasm(file)
+}
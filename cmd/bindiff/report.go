@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"html/template"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// writeJSON writes the given differences in JSON format to w.
+func writeJSON(w io.Writer, diff *Diff) error {
+	buf, err := json.MarshalIndent(diff, "", "\t")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	buf = append(buf, '\n')
+	if _, err := w.Write(buf); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// writeHTML writes the given differences in HTML format to w.
+func writeHTML(w io.Writer, diff *Diff) error {
+	t, err := template.New("bindiff").Parse(htmlTmpl[1:])
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := t.Execute(w, diff); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// htmlTmpl is the template of HTML reports.
+const htmlTmpl = `
+<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<title>bindiff: {{ .Old }} vs. {{ .New }}</title>
+	<style>
+		body { font-family: monospace; }
+		table { border-collapse: collapse; }
+		th, td { border: 1px solid #ccc; padding: 2px 8px; text-align: left; vertical-align: top; }
+		.added { color: #080; }
+		.removed { color: #c00; }
+	</style>
+</head>
+<body>
+	<h1>{{ .Old }} vs. {{ .New }}</h1>
+	<p>
+		{{ len .Unchanged }} unchanged,
+		{{ len .Changed }} changed,
+		<span class="added">{{ len .Added }} added</span>,
+		<span class="removed">{{ len .Removed }} removed</span> functions.
+	</p>
+	<h2>Changed functions</h2>
+	<table>
+		<tr><th>Old address</th><th>New address</th><th>Match</th><th>Added blocks</th><th>Removed blocks</th></tr>
+{{- range .Changed }}
+		<tr>
+			<td>{{ .OldAddr }}</td>
+			<td>{{ .NewAddr }}</td>
+			<td>{{ .Match }}{{ if .Similarity }} ({{ printf "%.2f" .Similarity }}){{ end }}</td>
+			<td class="added">{{ range .AddedBlocks }}{{ . }}<br>{{ end }}</td>
+			<td class="removed">{{ range .RemovedBlocks }}{{ . }}<br>{{ end }}</td>
+		</tr>
+{{- end }}
+	</table>
+	<h2>Added functions</h2>
+	<ul class="added">
+{{- range .Added }}
+		<li>{{ . }}</li>
+{{- end }}
+	</ul>
+	<h2>Removed functions</h2>
+	<ul class="removed">
+{{- range .Removed }}
+		<li>{{ . }}</li>
+{{- end }}
+	</ul>
+</body>
+</html>
+`
@@ -0,0 +1,199 @@
+// binpatch is a tool which splices patches back into binary executables.
+//
+// Patches are specified as NASM assembly (*.asm), e.g. edited snippets of
+// bin2asm output, or as raw machine code, e.g. of lifted and recompiled
+// functions. Functions may be detoured to replacement functions, with the
+// original function available through a trampoline.
+//
+// Usage:
+//
+//    binpatch -o patched.exe -patch 0x401000+12=fix.asm -patch 0x402000=f.bin foo.exe
+//    binpatch -o patched.exe -detour 0x403000=f.asm foo.exe
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	_ "github.com/decomp/exp/bin/coff" // register COFF decoder
+	_ "github.com/decomp/exp/bin/elf"  // register ELF decoder
+	_ "github.com/decomp/exp/bin/le"   // register LE/LX decoder
+	_ "github.com/decomp/exp/bin/ne"   // register NE decoder
+	_ "github.com/decomp/exp/bin/pe"   // register PE decoder
+	_ "github.com/decomp/exp/bin/pef"  // register PEF decoder
+	"github.com/decomp/exp/config"
+	"github.com/decomp/exp/patch"
+	"github.com/mewkiz/pkg/term"
+	"github.com/pkg/errors"
+)
+
+// dbg represents a logger with the "binpatch:" prefix, which logs debug
+// messages to standard error.
+var dbg = log.New(os.Stderr, term.MagentaBold("binpatch:")+" ", 0)
+
+func usage() {
+	const use = `
+Usage: binpatch [OPTION]... FILE
+Splice patches back into binary executables.
+
+Patches exceeding the size of the original code are relocated to a new section,
+and the original code is replaced by a jump to the relocated patch.
+
+Flags:
+`
+	fmt.Fprint(os.Stderr, use[1:])
+	flag.PrintDefaults()
+}
+
+func main() {
+	// Parse command line arguments.
+	var (
+		// detours specifies the functions to detour to replacement functions.
+		detours patchFlags
+		// output specifies the output path.
+		output string
+		// patches specifies the patches to apply.
+		patches patchFlags
+		// quiet specifies whether to suppress non-error messages.
+		quiet bool
+	)
+	flag.Var(&detours, "detour", "detour function to replacement in ADDR=PATH format; NASM assembly if PATH has .asm extension, raw machine code otherwise (may be repeated)")
+	flag.StringVar(&output, "o", "", "output path (required)")
+	flag.Var(&patches, "patch", "patch in ADDR[+SIZE]=PATH format; NASM assembly if PATH has .asm extension, raw machine code otherwise (may be repeated)")
+	flag.BoolVar(&quiet, "q", false, "suppress non-error messages")
+	flag.Usage = usage
+	flag.Parse()
+	// Apply project configuration file.
+	if err := config.Setup("binpatch", flag.CommandLine, true); err != nil {
+		log.Fatalf("%+v", err)
+	}
+	if flag.NArg() != 1 || len(output) == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	binPath := flag.Arg(0)
+	// Mute debug messages if `-q` is set.
+	if quiet {
+		dbg.SetOutput(ioutil.Discard)
+	}
+
+	// Apply patches.
+	buf, err := applyPatches(binPath, patches, detours)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	// Store patched executable.
+	if err := ioutil.WriteFile(output, buf, 0755); err != nil {
+		log.Fatalf("%+v", err)
+	}
+}
+
+// applyPatches applies the given patches and detours to the binary executable,
+// returning the contents of the patched executable.
+func applyPatches(binPath string, patchFs, detourFs patchFlags) ([]byte, error) {
+	data, err := ioutil.ReadFile(binPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	file, err := bin.ParseFile(binPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer file.Close()
+	var patches []*patch.Patch
+	for _, pf := range patchFs {
+		p, err := readPatch(pf)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		patches = append(patches, p)
+	}
+	for _, pf := range detourFs {
+		if pf.size != 0 {
+			return nil, errors.Errorf("invalid detour %q; size not supported", pf.path)
+		}
+		p, err := readPatch(pf)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		p.Detour = true
+		patches = append(patches, p)
+	}
+	return patch.Apply(data, file, patches)
+}
+
+// readPatch reads the NASM assembly or machine code of the given patch.
+func readPatch(pf *patchFlag) (*patch.Patch, error) {
+	dbg.Printf("reading patch %q at %v", pf.path, pf.addr)
+	buf, err := ioutil.ReadFile(pf.path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	p := &patch.Patch{Addr: pf.addr, Size: pf.size}
+	if filepath.Ext(pf.path) == ".asm" {
+		p.Src = string(buf)
+	} else {
+		p.Data = buf
+	}
+	return p, nil
+}
+
+// patchFlag is a patch specified on the command line, in ADDR[+SIZE]=PATH
+// format.
+type patchFlag struct {
+	// Address of the patch.
+	addr bin.Address
+	// Size in bytes of the original code replaced by the patch; or 0 to replace
+	// the length of the patch.
+	size int
+	// Path to NASM assembly or machine code of the patch.
+	path string
+}
+
+// patchFlags is a list of patches, which may be specified multiple times on
+// the command line.
+type patchFlags []*patchFlag
+
+// String returns the string representation of the patches.
+func (ps *patchFlags) String() string {
+	var ss []string
+	for _, p := range *ps {
+		s := p.addr.String()
+		if p.size != 0 {
+			s += "+" + strconv.Itoa(p.size)
+		}
+		ss = append(ss, s+"="+p.path)
+	}
+	return strings.Join(ss, ",")
+}
+
+// Set adds the patch represented by s, in ADDR[+SIZE]=PATH format.
+func (ps *patchFlags) Set(s string) error {
+	pos := strings.Index(s, "=")
+	if pos == -1 {
+		return errors.Errorf("invalid patch %q; expected ADDR[+SIZE]=PATH format", s)
+	}
+	p := &patchFlag{path: s[pos+1:]}
+	addr := s[:pos]
+	if i := strings.Index(addr, "+"); i != -1 {
+		size, err := strconv.ParseInt(addr[i+1:], 0, 64)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		p.size = int(size)
+		addr = addr[:i]
+	}
+	if err := p.addr.Set(addr); err != nil {
+		return errors.WithStack(err)
+	}
+	*ps = append(*ps, p)
+	return nil
+}
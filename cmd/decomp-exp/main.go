@@ -0,0 +1,85 @@
+// The decomp-exp tool provides interactive access to the disassembler and
+// lifter.
+//
+// Commands:
+//
+//    repl      explore binary executables interactively
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/decomp/exp/config"
+	"github.com/mewkiz/pkg/term"
+)
+
+// Loggers.
+var (
+	// dbg represents a logger with the "decomp-exp:" prefix, which logs debug
+	// messages to standard error.
+	dbg = log.New(os.Stderr, term.CyanBold("decomp-exp:")+" ", 0)
+	// warn represents a logger with the "warning:" prefix, which logs warning
+	// messages to standard error.
+	warn = log.New(os.Stderr, term.RedBold("warning:")+" ", 0)
+)
+
+func usage() {
+	const use = `
+Explore binary executables using the disassembler and lifter.
+
+Usage:
+
+	decomp-exp COMMAND [OPTION]... FILE
+
+Commands:
+
+	repl      explore binary executable interactively
+
+Flags:
+`
+	fmt.Fprint(os.Stderr, use[1:])
+	flag.PrintDefaults()
+}
+
+func main() {
+	// Parse command line arguments.
+	var (
+		// quiet specifies whether to suppress non-error messages.
+		quiet bool
+	)
+	flag.Usage = usage
+	flag.BoolVar(&quiet, "q", false, "suppress non-error messages")
+	if len(os.Args) < 2 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	cmd := os.Args[1]
+	flag.CommandLine.Parse(os.Args[2:])
+	// Apply project configuration file.
+	if err := config.Setup("decomp-exp", flag.CommandLine, true); err != nil {
+		log.Fatalf("%+v", err)
+	}
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	binPath := flag.Arg(0)
+	// Mute debug and warning messages if `-q` is set.
+	if quiet {
+		dbg.SetOutput(ioutil.Discard)
+		warn.SetOutput(ioutil.Discard)
+	}
+
+	switch cmd {
+	case "repl":
+		if err := repl(binPath, os.Stdin, os.Stdout); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	default:
+		log.Fatalf("invalid command %q; expected repl", cmd)
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	_ "github.com/decomp/exp/bin/coff" // register COFF decoder
+	_ "github.com/decomp/exp/bin/elf"  // register ELF decoder
+	_ "github.com/decomp/exp/bin/le"   // register LE/LX decoder
+	_ "github.com/decomp/exp/bin/ne"   // register NE decoder
+	_ "github.com/decomp/exp/bin/pe"   // register PE decoder
+	_ "github.com/decomp/exp/bin/pef"  // register PEF decoder
+	x86dis "github.com/decomp/exp/disasm/x86"
+	"github.com/decomp/exp/lift/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/pkg/errors"
+)
+
+// replHelp is the help text of the REPL.
+const replHelp = `
+Commands:
+
+	funcs                 list functions
+	fn ADDR               show function at address
+	disasm ADDR [N]       disassemble N instructions (default 10) at address
+	xrefs ADDR            list cross-references to address
+	strings [sjis]        list referenced strings (optionally detecting Shift-JIS)
+	lift ADDR             lift function at address to LLVM IR
+	help                  show this help
+	quit                  exit the REPL
+`
+
+// session is an interactive exploration session of a binary executable.
+type session struct {
+	// x86 to LLVM IR lifter of the binary executable.
+	l *x86.Lifter
+	// Cross-references of decoded functions; computed on first use.
+	xrefs *x86dis.Xrefs
+	// Lifted functions.
+	lifted map[bin.Address]bool
+	// Output writer.
+	w io.Writer
+}
+
+// repl runs an interactive read-eval-print loop exploring the given binary
+// executable, reading commands from r and writing output to w.
+func repl(binPath string, r io.Reader, w io.Writer) error {
+	file, err := bin.ParseFile(binPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer file.Close()
+	l, err := x86.NewLifter(file)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// Create function lifters; all functions are decoded up front, as call
+	// instructions are resolved through the function lifters of the callees.
+	for _, funcAddr := range l.FuncAddrs {
+		asmFunc, err := l.DecodeFunc(funcAddr)
+		if err != nil {
+			warn.Printf("unable to decode function at %v; %v", funcAddr, err)
+			continue
+		}
+		l.Funcs[funcAddr] = l.NewFunc(asmFunc)
+	}
+	s := &session{
+		l:      l,
+		lifted: make(map[bin.Address]bool),
+		w:      w,
+	}
+	fmt.Fprintf(w, "loaded %q; %d functions. Type help for a list of commands.\n", binPath, len(l.FuncAddrs))
+	scanner := bufio.NewScanner(r)
+	for {
+		fmt.Fprint(w, "> ")
+		if !scanner.Scan() {
+			break
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "quit" || fields[0] == "exit" {
+			return nil
+		}
+		if err := s.eval(fields[0], fields[1:]); err != nil {
+			fmt.Fprintf(w, "error: %v\n", err)
+		}
+	}
+	fmt.Fprintln(w)
+	return errors.WithStack(scanner.Err())
+}
+
+// eval evaluates the given REPL command.
+func (s *session) eval(cmd string, args []string) error {
+	switch cmd {
+	case "help":
+		fmt.Fprint(s.w, replHelp[1:])
+		return nil
+	case "funcs":
+		for _, funcAddr := range s.l.FuncAddrs {
+			fmt.Fprintf(s.w, "%v\t%s\n", funcAddr, s.l.FuncName(funcAddr))
+		}
+		return nil
+	case "strings":
+		opts := x86dis.StringOptions{}
+		if len(args) >= 1 {
+			if args[0] != "sjis" {
+				return errors.Errorf("invalid argument %q of %q command; expected sjis", args[0], cmd)
+			}
+			opts.ShiftJIS = true
+		}
+		return s.listStrings(opts)
+	}
+	if len(args) < 1 {
+		return errors.Errorf("missing address argument of %q command", cmd)
+	}
+	var addr bin.Address
+	if err := addr.Set(args[0]); err != nil {
+		return errors.WithStack(err)
+	}
+	switch cmd {
+	case "fn":
+		return s.fn(addr)
+	case "disasm":
+		n := 10
+		if len(args) >= 2 {
+			var err error
+			if n, err = strconv.Atoi(args[1]); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		return s.disasm(addr, n)
+	case "xrefs":
+		return s.listXrefs(addr)
+	case "lift":
+		return s.lift(addr)
+	default:
+		return errors.Errorf("invalid command %q; type help for a list of commands", cmd)
+	}
+}
+
+// fn shows the function at the given address.
+func (s *session) fn(funcAddr bin.Address) error {
+	f, err := s.fnc(funcAddr)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	asmFunc := f.AsmFunc
+	fmt.Fprintf(s.w, "%v\t%s\t(%d basic blocks)\n", funcAddr, s.l.FuncName(funcAddr), len(asmFunc.Blocks))
+	for _, blockAddr := range blockAddrs(asmFunc) {
+		block := asmFunc.Blocks[blockAddr]
+		fmt.Fprintf(s.w, "block_%06X:\n", uint64(blockAddr))
+		for _, inst := range block.Insts {
+			fmt.Fprintf(s.w, "\t%v: %v\n", inst.Addr, inst)
+		}
+		if !block.Term.IsDummyTerm() {
+			fmt.Fprintf(s.w, "\t%v: %v\n", block.Term.Addr, block.Term)
+		}
+	}
+	return nil
+}
+
+// disasm disassembles n instructions starting at the given address.
+func (s *session) disasm(addr bin.Address, n int) error {
+	for i := 0; i < n; i++ {
+		inst, err := s.l.DecodeInst(addr)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		fmt.Fprintf(s.w, "%v: %v\n", inst.Addr, inst)
+		addr += bin.Address(inst.Len)
+	}
+	return nil
+}
+
+// listXrefs lists the cross-references to the given address.
+func (s *session) listXrefs(addr bin.Address) error {
+	xrefs := s.index().To(addr)
+	if len(xrefs) == 0 {
+		fmt.Fprintf(s.w, "no cross-references to %v\n", addr)
+		return nil
+	}
+	for _, xref := range xrefs {
+		fmt.Fprintf(s.w, "%v\t%v\n", xref.From, xref.Kind)
+	}
+	return nil
+}
+
+// listStrings lists the string literals referenced by decoded functions, their
+// character encodings and number of references.
+func (s *session) listStrings(opts x86dis.StringOptions) error {
+	for _, str := range s.l.Strings(s.index(), opts) {
+		fmt.Fprintf(s.w, "%v\t%v\t%d\t%q\n", str.Addr, str.Enc, len(str.Refs), str.Text())
+	}
+	return nil
+}
+
+// lift lifts the function at the given address to LLVM IR.
+func (s *session) lift(funcAddr bin.Address) (err error) {
+	f, err := s.fnc(funcAddr)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !s.lifted[funcAddr] {
+		// The lifter panics on unsupported instructions; report as error to keep
+		// the session running.
+		defer func() {
+			if e := recover(); e != nil {
+				err = errors.Errorf("unable to lift function at %v; %v", funcAddr, e)
+			}
+		}()
+		f.Lift()
+		s.lifted[funcAddr] = true
+	}
+	m := &ir.Module{
+		TypeDefs: s.l.TypeDefs,
+		Funcs:    []*ir.Function{f.Function},
+	}
+	fmt.Fprintln(s.w, m)
+	return nil
+}
+
+// ### [ Helper functions ] ####################################################
+
+// index returns the cross-reference index of decoded functions, computing it on
+// first use.
+func (s *session) index() *x86dis.Xrefs {
+	if s.xrefs == nil {
+		var fs []*x86dis.Func
+		for _, funcAddr := range s.l.FuncAddrs {
+			if f, ok := s.l.Funcs[funcAddr]; ok {
+				fs = append(fs, f.AsmFunc)
+			}
+		}
+		s.xrefs = s.l.Xrefs(fs)
+	}
+	return s.xrefs
+}
+
+// fnc returns the function lifter of the function at the given address.
+func (s *session) fnc(funcAddr bin.Address) (*x86.Func, error) {
+	f, ok := s.l.Funcs[funcAddr]
+	if !ok || f.AsmFunc == nil {
+		return nil, errors.Errorf("unable to locate function at %v", funcAddr)
+	}
+	return f, nil
+}
+
+// blockAddrs returns the basic block addresses of the given function in
+// ascending order.
+func blockAddrs(f *x86dis.Func) []bin.Address {
+	var addrs bin.Addresses
+	for addr := range f.Blocks {
+		addrs = append(addrs, addr)
+	}
+	sort.Sort(addrs)
+	return addrs
+}
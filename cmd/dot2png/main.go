@@ -16,6 +16,7 @@ import (
 	"os"
 	"os/exec"
 
+	"github.com/decomp/exp/config"
 	"github.com/mewkiz/pkg/errutil"
 	"github.com/mewkiz/pkg/pathutil"
 )
@@ -40,6 +41,10 @@ func main() {
 	flag.BoolVar(&force, "f", false, "force overwrite existing images")
 	flag.Usage = usage
 	flag.Parse()
+	// Apply project configuration file.
+	if err := config.Setup("dot2png", flag.CommandLine, false); err != nil {
+		log.Fatalf("%+v", err)
+	}
 
 	// Convert DOT files to PNG images.
 	for _, dotPath := range flag.Args() {
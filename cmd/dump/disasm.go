@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	_ "github.com/decomp/exp/bin/coff" // register COFF decoder
+	"github.com/decomp/exp/bin/demangle"
+	_ "github.com/decomp/exp/bin/elf" // register ELF decoder
+	_ "github.com/decomp/exp/bin/le"  // register LE/LX decoder
+	_ "github.com/decomp/exp/bin/ne"  // register NE decoder
+	_ "github.com/decomp/exp/bin/pe"  // register PE decoder
+	_ "github.com/decomp/exp/bin/pef" // register PEF decoder
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/mewkiz/pkg/term"
+	"github.com/pkg/errors"
+)
+
+// dumpDisasm dumps a disassembly listing of the functions of the given binary
+// executable to w, in the style of `objdump -d`. Only the function at funcAddr
+// is dumped if non-zero. The listing is colored and annotated if color is set.
+//
+//    00401000 <f_401000>:
+//      401000:   55                      push ebp
+//      401001:   8b ec                   mov ebp, esp
+//      401003:   e8 f8 0f 00 00          call f_402000
+func dumpDisasm(w io.Writer, binPath string, funcAddr bin.Address, color bool) error {
+	file, err := bin.ParseFile(binPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer file.Close()
+	switch file.Arch {
+	case bin.ArchX86_16, bin.ArchX86_32, bin.ArchX86_64:
+		// supported.
+	default:
+		return errors.Errorf("support for disassembling machine architecture %v not yet implemented", file.Arch)
+	}
+	dis, err := x86.NewDisasm(file)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	dis.Naming.Demangle = demangle.Name
+	// Index symbol names; imports, exports and imported program annotations.
+	syms := make(map[bin.Address]string)
+	for addr, name := range file.Imports {
+		syms[addr] = name
+	}
+	for addr, name := range file.Exports {
+		syms[addr] = name
+	}
+	for addr, name := range dis.Names {
+		syms[addr] = name
+	}
+	symName := func(addr bin.Address) string {
+		if name, ok := syms[addr]; ok {
+			return dis.Naming.SymbolName(name)
+		}
+		return dis.Naming.FuncName(addr, nil)
+	}
+	// Resolve symbol names of instruction operands; only function addresses and
+	// named addresses are resolved, to avoid misinterpreting constants.
+	symname := func(addr uint64) (string, uint64) {
+		a := bin.Address(addr)
+		if _, ok := syms[a]; ok || dis.IsFunc(a) {
+			if color {
+				return term.Green(symName(a)), addr
+			}
+			return symName(a), addr
+		}
+		return "", 0
+	}
+	funcAddrs := dis.FuncAddrs
+	if funcAddr != 0 {
+		funcAddrs = []bin.Address{funcAddr}
+	}
+	as := file.AddressSpace()
+	for i, funcAddr := range funcAddrs {
+		f, err := dis.DecodeFunc(funcAddr)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if i != 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%08x <%s>:\n", uint64(f.Addr), symName(f.Addr))
+		insts := f.Insts()
+		var gutters []string
+		if color {
+			gutters = gutter(insts)
+		}
+		for j, inst := range insts {
+			data, ok := as.Bytes(inst.Addr, 0)
+			if !ok || len(data) < inst.Len {
+				return errors.Errorf("unable to locate instruction bytes at %v", inst.Addr)
+			}
+			var hex []string
+			for _, b := range data[:inst.Len] {
+				hex = append(hex, fmt.Sprintf("%02x", b))
+			}
+			asm := x86.FormatInst(inst, symname)
+			if !color {
+				fmt.Fprintf(w, "%8x:\t%-21s\t%s\n", uint64(inst.Addr), strings.Join(hex, " "), asm)
+				continue
+			}
+			asm = colorMnemonic(inst, asm)
+			if s, ok := stringPreview(as, inst); ok {
+				asm += "\t" + term.Blue("; "+s)
+			}
+			fmt.Fprintf(w, "%s%6x:\t%-21s\t%s\n", gutters[j], uint64(inst.Addr), strings.Join(hex, " "), asm)
+		}
+	}
+	return nil
+}
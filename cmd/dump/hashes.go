@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/bin/fingerprint"
+	"github.com/pkg/errors"
+)
+
+// dumpHashes dumps the fingerprint of the given binary executable to w; file
+// hashes, import hash, fuzzy hashes and section hashes.
+//
+//    md5      3f5a8e1b0c7d4e2f9a6b1c8d7e0f2a4b
+//    sha256   9b2c...
+//    imphash  f34d5f2d4577ed6d9ceec516c1f5a744
+//    ssdeep   1536:3Hk9...:3Hk9...
+//    tlsh     T1A4C3...
+//    section  .text    00401000  24576 bytes  md5 0b1c...  entropy 6.42
+func dumpHashes(w io.Writer, binPath string) error {
+	data, err := ioutil.ReadFile(binPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	file, err := bin.ParseFile(binPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer file.Close()
+	fp := fingerprint.Compute(data, file)
+	fmt.Fprintf(w, "md5      %s\n", fp.MD5)
+	fmt.Fprintf(w, "sha256   %s\n", fp.SHA256)
+	if len(fp.ImpHash) > 0 {
+		fmt.Fprintf(w, "imphash  %s\n", fp.ImpHash)
+	}
+	fmt.Fprintf(w, "ssdeep   %s\n", fp.SSDeep)
+	if len(fp.TLSH) > 0 {
+		fmt.Fprintf(w, "tlsh     %s\n", fp.TLSH)
+	}
+	for _, sect := range fp.Sections {
+		fmt.Fprintf(w, "section  %-8s %08X  %d bytes  md5 %s  entropy %.2f\n", sect.Name, uint64(sect.Addr), sect.Size, sect.MD5, sect.Entropy)
+	}
+	return nil
+}
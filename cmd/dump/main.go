@@ -0,0 +1,263 @@
+// The dump tool dumps information about binary executables (e.g. resources,
+// disassembly listings and byte pattern matches).
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/bin/pe"
+	"github.com/decomp/exp/config"
+	"github.com/mewkiz/pkg/term"
+	"github.com/pkg/errors"
+)
+
+// Loggers.
+var (
+	// dbg represents a logger with the "dump:" prefix, which logs debug
+	// messages to standard error.
+	dbg = log.New(os.Stderr, term.MagentaBold("dump:")+" ", 0)
+	// warn represents a logger with the "dump:" prefix, which logs warning
+	// messages to standard error.
+	warn = log.New(os.Stderr, term.RedBold("dump:")+" ", 0)
+)
+
+func usage() {
+	const use = `
+Dump information about binary executables.
+
+Usage:
+
+	dump [OPTION]... FILE
+
+Flags:
+`
+	fmt.Fprint(os.Stderr, use[1:])
+	flag.PrintDefaults()
+}
+
+func main() {
+	// Parse command line arguments.
+	var (
+		// color specifies whether to dump a colored and annotated disassembly
+		// listing.
+		color bool
+		// disasm specifies whether to dump a disassembly listing.
+		disasm bool
+		// extractDir specifies the output directory of extracted resources.
+		extractDir string
+		// funcAddr specifies a function address to disassemble.
+		funcAddr bin.Address
+		// hashes specifies whether to dump the fingerprint of the executable.
+		hashes bool
+		// quiet specifies whether to suppress non-error messages.
+		quiet bool
+		// rsrc specifies whether to dump the resources of PE executables.
+		rsrc bool
+		// search specifies a byte pattern to search for.
+		search string
+		// sig specifies whether to dump byte patterns of functions.
+		sig bool
+		// sigLen specifies the maximum length in bytes of function byte patterns.
+		sigLen int
+	)
+	flag.Usage = usage
+	flag.BoolVar(&color, "color", false, "colored and annotated disassembly listing (requires -d)")
+	flag.BoolVar(&disasm, "d", false, "dump disassembly listing of functions")
+	flag.StringVar(&extractDir, "extract", "", "output directory of extracted resources (requires -rsrc)")
+	flag.Var(&funcAddr, "func", "function address to disassemble (requires -d or -sig)")
+	flag.BoolVar(&hashes, "hashes", false, "dump hashes of executable (MD5, SHA-256, imphash, ssdeep, TLSH and section hashes)")
+	flag.BoolVar(&quiet, "q", false, "suppress non-error messages")
+	flag.BoolVar(&rsrc, "rsrc", false, "dump resources (dialogs, string tables, icons and version information) of PE executable")
+	flag.StringVar(&search, "search", "", `search for byte pattern with wildcards (e.g. "55 8B EC ?? ??")`)
+	flag.BoolVar(&sig, "sig", false, "dump byte patterns of functions, with operands masked")
+	flag.IntVar(&sigLen, "siglen", 32, "maximum length in bytes of function byte patterns; 0 for no limit (requires -sig)")
+	flag.Parse()
+	// Apply project configuration file.
+	if err := config.Setup("dump", flag.CommandLine, true); err != nil {
+		log.Fatalf("%+v", err)
+	}
+	if flag.NArg() != 1 || !(disasm || hashes || rsrc || sig || len(search) > 0) {
+		flag.Usage()
+		os.Exit(1)
+	}
+	binPath := flag.Arg(0)
+	// Mute debug and warning messages if `-q` is set.
+	if quiet {
+		dbg.SetOutput(ioutil.Discard)
+		warn.SetOutput(ioutil.Discard)
+	}
+
+	// Dump hashes if `-hashes` is set.
+	if hashes {
+		if err := dumpHashes(os.Stdout, binPath); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	}
+
+	// Dump disassembly listing if `-d` is set.
+	if disasm {
+		if err := dumpDisasm(os.Stdout, binPath, funcAddr, color); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	}
+
+	// Search for byte pattern if `-search` is set.
+	if len(search) > 0 {
+		if err := dumpSearch(os.Stdout, binPath, search); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	}
+
+	// Dump byte patterns of functions if `-sig` is set.
+	if sig {
+		if err := dumpSignatures(os.Stdout, binPath, funcAddr, sigLen); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	}
+
+	// Dump resources if `-rsrc` is set.
+	if rsrc {
+		if err := dumpResources(os.Stdout, binPath, extractDir); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	}
+}
+
+// dumpResources dumps the resources of the given PE executable to w. Resources
+// are extracted to extractDir if non-empty.
+func dumpResources(w io.Writer, binPath, extractDir string) error {
+	dbg.Printf("parsing resources of %q", binPath)
+	rs, err := pe.ParseResourcesFile(binPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, res := range rs {
+		fmt.Fprintf(w, "%s %v (lang 0x%04X) at %v: %d bytes\n", res.TypeName(), res.Name, res.Lang, res.Addr, len(res.Data))
+		switch res.Type.ID {
+		case pe.RTString:
+			if len(res.Type.Name) > 0 {
+				break
+			}
+			strs, err := res.Strings()
+			if err != nil {
+				warn.Printf("unable to parse string table; %v", err)
+				break
+			}
+			var ids []uint32
+			for id := range strs {
+				ids = append(ids, id)
+			}
+			sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+			for _, id := range ids {
+				fmt.Fprintf(w, "\t%d: %q\n", id, strs[id])
+			}
+		case pe.RTDialog:
+			if len(res.Type.Name) > 0 {
+				break
+			}
+			dlg, err := res.Dialog()
+			if err != nil {
+				warn.Printf("unable to parse dialog; %v", err)
+				break
+			}
+			fmt.Fprintf(w, "\ttitle: %q\n", dlg.Title)
+			fmt.Fprintf(w, "\tstyle: 0x%08X\n", dlg.Style)
+			fmt.Fprintf(w, "\trect: %d, %d, %d, %d\n", dlg.X, dlg.Y, dlg.CX, dlg.CY)
+			if len(dlg.Font) > 0 {
+				fmt.Fprintf(w, "\tfont: %d, %q\n", dlg.PointSize, dlg.Font)
+			}
+			for _, ctrl := range dlg.Controls {
+				fmt.Fprintf(w, "\tcontrol %d: class %v, text %q, rect %d, %d, %d, %d, style 0x%08X\n", ctrl.ID, controlClass(ctrl.Class), ctrl.Text.String(), ctrl.X, ctrl.Y, ctrl.CX, ctrl.CY, ctrl.Style)
+			}
+		case pe.RTVersion:
+			if len(res.Type.Name) > 0 {
+				break
+			}
+			info, err := res.Version()
+			if err != nil {
+				warn.Printf("unable to parse version information; %v", err)
+				break
+			}
+			fmt.Fprintf(w, "\tfile version: %s\n", info.FileVersion)
+			fmt.Fprintf(w, "\tproduct version: %s\n", info.ProductVersion)
+			var keys []string
+			for key := range info.Strings {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				fmt.Fprintf(w, "\t%s: %q\n", key, info.Strings[key])
+			}
+		}
+	}
+	if len(extractDir) > 0 {
+		if err := extractResources(extractDir, rs); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// extractResources extracts the given resources to the output directory. The
+// raw data of each resource is stored as TYPE_NAME_LANG.bin, and icon groups
+// are stored as icon files (NAME.ico).
+func extractResources(dir string, rs []*pe.Resource) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, res := range rs {
+		name := fmt.Sprintf("%s_%v_%04X.bin", res.TypeName(), res.Name, res.Lang)
+		if err := writeFile(filepath.Join(dir, name), res.Data); err != nil {
+			return errors.WithStack(err)
+		}
+		if res.Type.ID == pe.RTGroupIcon && len(res.Type.Name) == 0 {
+			buf, err := pe.Icon(rs, res)
+			if err != nil {
+				warn.Printf("unable to extract icon; %v", err)
+				continue
+			}
+			if err := writeFile(filepath.Join(dir, fmt.Sprintf("%v.ico", res.Name)), buf); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+	return nil
+}
+
+// ### [ Helper functions ] ####################################################
+
+// writeFile writes the given data to the output file.
+func writeFile(path string, data []byte) error {
+	dbg.Printf("creating %q", path)
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// controlClass returns the name of the given dialog control class.
+func controlClass(class pe.ResourceID) string {
+	// Predefined control classes.
+	names := map[uint16]string{
+		0x80: "BUTTON",
+		0x81: "EDIT",
+		0x82: "STATIC",
+		0x83: "LISTBOX",
+		0x84: "SCROLLBAR",
+		0x85: "COMBOBOX",
+	}
+	if len(class.Name) == 0 {
+		if name, ok := names[class.ID]; ok {
+			return name
+		}
+	}
+	return class.String()
+}
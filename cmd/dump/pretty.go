@@ -0,0 +1,241 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/mewkiz/pkg/term"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// Colored disassembly listings.
+//
+// With `-color`, mnemonics are colored by instruction class, resolved symbols
+// are highlighted, references to strings are annotated with a preview of the
+// string, and intra-function jumps are drawn as arrows in the gutter.
+//
+//       401000:   55                      push ebp
+//       401001:   8b ec                   mov ebp, esp
+//       401003:   85 c0                   test eax, eax
+//    +--401005:   74 05                   je 0x40100c
+//    |  401007:   68 00 20 40 00          push 0x402000   ; "hello world"
+//    +->40100c:   c3                      ret
+
+// maxPreviewLen specifies the maximum length in bytes of string previews.
+const maxPreviewLen = 48
+
+// colorMnemonic returns the disassembly of the given instruction, with its
+// mnemonic colored by instruction class.
+func colorMnemonic(inst *x86.Inst, asm string) string {
+	mnemonic := strings.ToLower(inst.Op.String())
+	pos := strings.Index(asm, mnemonic)
+	if pos == -1 {
+		return asm
+	}
+	var color func(s string) string
+	switch inst.Op {
+	case x86asm.CALL:
+		color = term.CyanBold
+	case x86asm.RET, x86asm.LRET, x86asm.IRET, x86asm.IRETD, x86asm.IRETQ:
+		color = term.RedBold
+	case x86asm.JMP, x86asm.LJMP, x86asm.LOOP, x86asm.LOOPE, x86asm.LOOPNE:
+		color = term.YellowBold
+	case x86asm.PUSH, x86asm.POP, x86asm.PUSHA, x86asm.PUSHAD, x86asm.POPA, x86asm.POPAD:
+		color = term.Magenta
+	case x86asm.NOP, x86asm.INT:
+		color = term.Blue
+	default:
+		if isJcc(inst.Op) {
+			color = term.Yellow
+		}
+	}
+	if color == nil {
+		return asm
+	}
+	return asm[:pos] + color(mnemonic) + asm[pos+len(mnemonic):]
+}
+
+// stringPreview returns a preview of the string referenced by an operand of the
+// given instruction. The boolean return value indicates success.
+func stringPreview(as *bin.AddressSpace, inst *x86.Inst) (string, bool) {
+	next := inst.Addr + bin.Address(inst.Len)
+	for _, arg := range inst.Args {
+		var addr bin.Address
+		switch arg := arg.(type) {
+		case x86asm.Imm:
+			addr = bin.Address(arg)
+		case x86asm.Mem:
+			switch {
+			case arg.Base == x86asm.RIP && arg.Index == 0:
+				addr = next + bin.Address(arg.Disp)
+			case arg.Base == 0 && arg.Index == 0:
+				addr = bin.Address(arg.Disp)
+			default:
+				continue
+			}
+		default:
+			continue
+		}
+		if s, ok := cstring(as, addr); ok {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// cstring returns the NULL-terminated string of printable ASCII characters at
+// the given address, of at least 4 characters. Long strings are truncated. The
+// boolean return value indicates success.
+func cstring(as *bin.AddressSpace, addr bin.Address) (string, bool) {
+	if sect, ok := as.Section(addr, 0); !ok || sect.Perm&bin.PermX != 0 {
+		// Skip addresses outside of data sections.
+		return "", false
+	}
+	data, ok := as.Bytes(addr, 0)
+	if !ok {
+		return "", false
+	}
+	end := 0
+	for end < len(data) && data[end] != 0 {
+		if b := data[end]; (b < ' ' || b > '~') && b != '\t' && b != '\n' && b != '\r' {
+			return "", false
+		}
+		end++
+	}
+	if end == len(data) || end < 4 {
+		// Missing NULL-terminator or too short.
+		return "", false
+	}
+	s := string(data[:end])
+	if len(s) > maxPreviewLen {
+		return strconv.Quote(s[:maxPreviewLen]) + "...", true
+	}
+	return strconv.Quote(s), true
+}
+
+// arrow is an intra-function jump, drawn as an arrow in the gutter of the
+// disassembly listing.
+type arrow struct {
+	// Instruction indices of the jump and the jump target.
+	from, to int
+	// Gutter column of the arrow.
+	col int
+}
+
+// lo returns the lowest instruction index spanned by the arrow.
+func (a *arrow) lo() int {
+	if a.from < a.to {
+		return a.from
+	}
+	return a.to
+}
+
+// hi returns the highest instruction index spanned by the arrow.
+func (a *arrow) hi() int {
+	if a.from > a.to {
+		return a.from
+	}
+	return a.to
+}
+
+// gutter returns the gutter of each instruction of the given disassembly
+// listing, with arrows drawn from jumps to their targets within the listing.
+// Shorter jumps are drawn closer to the instructions.
+func gutter(insts []*x86.Inst) []string {
+	// Locate intra-function jumps.
+	index := make(map[bin.Address]int)
+	for i, inst := range insts {
+		index[inst.Addr] = i
+	}
+	var arrows []*arrow
+	for i, inst := range insts {
+		if inst.Op != x86asm.JMP && !isJcc(inst.Op) && inst.Op != x86asm.LOOP && inst.Op != x86asm.LOOPE && inst.Op != x86asm.LOOPNE {
+			continue
+		}
+		rel, ok := inst.Args[0].(x86asm.Rel)
+		if !ok {
+			continue
+		}
+		target := inst.Addr + bin.Address(inst.Len) + bin.Address(rel)
+		if j, ok := index[target]; ok {
+			arrows = append(arrows, &arrow{from: i, to: j})
+		}
+	}
+	less := func(i, j int) bool {
+		return arrows[i].hi()-arrows[i].lo() < arrows[j].hi()-arrows[j].lo()
+	}
+	sort.SliceStable(arrows, less)
+	// Assign lanes, starting from the instructions; lane 0 is closest to the
+	// instructions.
+	var lanes [][]*arrow
+	for _, a := range arrows {
+		lane := 0
+		for ; lane < len(lanes); lane++ {
+			overlap := false
+			for _, b := range lanes[lane] {
+				if a.lo() <= b.hi() && b.lo() <= a.hi() {
+					overlap = true
+					break
+				}
+			}
+			if !overlap {
+				break
+			}
+		}
+		if lane == len(lanes) {
+			lanes = append(lanes, nil)
+		}
+		lanes[lane] = append(lanes[lane], a)
+		a.col = lane
+	}
+	// Draw gutter; two characters per lane followed by the arrow head.
+	width := 2 * len(lanes)
+	gutters := make([]string, len(insts))
+	for i := range insts {
+		line := []byte(strings.Repeat(" ", width+1))
+		left := width
+		head := false
+		for _, a := range arrows {
+			col := width - 2*(a.col+1)
+			switch {
+			case i == a.from || i == a.to:
+				line[col] = '+'
+				if col < left {
+					left = col
+				}
+				if i == a.to {
+					head = true
+				}
+			case a.lo() < i && i < a.hi():
+				line[col] = '|'
+			}
+		}
+		for col := left + 1; col < width; col++ {
+			if line[col] == ' ' {
+				line[col] = '-'
+			}
+		}
+		switch {
+		case head:
+			line[width] = '>'
+		case left < width:
+			line[width] = '-'
+		}
+		gutters[i] = string(line)
+	}
+	return gutters
+}
+
+// ### [ Helper functions ] ####################################################
+
+// isJcc reports whether the given instruction is a conditional jump.
+func isJcc(op x86asm.Op) bool {
+	switch op {
+	case x86asm.JA, x86asm.JAE, x86asm.JB, x86asm.JBE, x86asm.JCXZ, x86asm.JE, x86asm.JECXZ, x86asm.JG, x86asm.JGE, x86asm.JL, x86asm.JLE, x86asm.JNE, x86asm.JNO, x86asm.JNP, x86asm.JNS, x86asm.JO, x86asm.JP, x86asm.JRCXZ, x86asm.JS:
+		return true
+	}
+	return false
+}
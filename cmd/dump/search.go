@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/pkg/errors"
+)
+
+// dumpSearch dumps the addresses of all matches of the given byte pattern in
+// the section data of the binary executable to w.
+//
+//    00401000 .text
+//    004052A0 .text
+func dumpSearch(w io.Writer, binPath, pattern string) error {
+	p, err := bin.ParsePattern(pattern)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	file, err := bin.ParseFile(binPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer file.Close()
+	dbg.Printf("searching for %q in %q", p, binPath)
+	as := file.AddressSpace()
+	for _, addr := range file.Search(p, 0) {
+		name := ""
+		if sect, ok := as.Section(addr, 0); ok {
+			name = sect.Name
+		}
+		fmt.Fprintf(w, "%08X %s\n", uint64(addr), name)
+	}
+	return nil
+}
+
+// dumpSignatures dumps the byte patterns of the functions of the given binary
+// executable to w, with branch targets, immediates and displacements masked as
+// wildcards; suitable for building signature files. Only the function at
+// funcAddr is dumped if non-zero, and patterns are limited to at most n bytes
+// if n is non-zero.
+//
+//    00401000 f_401000: 55 8B EC 83 EC ?? E8 ?? ?? ?? ??
+func dumpSignatures(w io.Writer, binPath string, funcAddr bin.Address, n int) error {
+	file, err := bin.ParseFile(binPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer file.Close()
+	switch file.Arch {
+	case bin.ArchX86_16, bin.ArchX86_32, bin.ArchX86_64:
+		// supported.
+	default:
+		return errors.Errorf("support for byte patterns of machine architecture %v not yet implemented", file.Arch)
+	}
+	dis, err := x86.NewDisasm(file)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	funcAddrs := dis.FuncAddrs
+	if funcAddr != 0 {
+		funcAddrs = []bin.Address{funcAddr}
+	}
+	for _, funcAddr := range funcAddrs {
+		f, err := dis.DecodeFunc(funcAddr)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		p, err := dis.FuncPattern(f, x86.MaskAll, n)
+		if err != nil {
+			warn.Printf("unable to create byte pattern; %v", err)
+			continue
+		}
+		fmt.Fprintf(w, "%08X %s: %v\n", uint64(f.Addr), dis.Naming.FuncName(f.Addr, file.Exports), p)
+	}
+	return nil
+}
@@ -14,6 +14,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/decomp/exp/config"
 	"github.com/kr/pretty"
 	"github.com/mewkiz/pkg/pathutil"
 	"github.com/mewrev/pe"
@@ -22,6 +23,10 @@ import (
 
 func main() {
 	flag.Parse()
+	// Apply project configuration file.
+	if err := config.Setup("dump_imports", flag.CommandLine, true); err != nil {
+		log.Fatalf("%+v", err)
+	}
 	for _, path := range flag.Args() {
 		if err := dumpImports(path); err != nil {
 			log.Fatalf("%+v", err)
@@ -13,6 +13,7 @@ import (
 	"sort"
 
 	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/config"
 	"github.com/llir/llvm/ir"
 	"github.com/pkg/errors"
 )
@@ -44,6 +45,10 @@ func main() {
 	flag.Parse()
 	flag.Usage = usage
 	flag.Parse()
+	// Apply project configuration file.
+	if err := config.Setup("h2ll", flag.CommandLine, false); err != nil {
+		log.Fatalf("%+v", err)
+	}
 	if flag.NArg() != 1 {
 		flag.Usage()
 		os.Exit(1)
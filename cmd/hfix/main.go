@@ -14,6 +14,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/decomp/exp/config"
 	"github.com/mewkiz/pkg/term"
 	"github.com/pkg/errors"
 )
@@ -55,6 +56,10 @@ func main() {
 	flag.Parse()
 	flag.Usage = usage
 	flag.Parse()
+	// Apply project configuration file.
+	if err := config.Setup("hfix", flag.CommandLine, false); err != nil {
+		log.Fatalf("%+v", err)
+	}
 	if flag.NArg() != 1 {
 		flag.Usage()
 		os.Exit(1)
@@ -0,0 +1,97 @@
+// The liftd tool is a lifting server, which exposes the disassembler and
+// lifter through a REST API.
+//
+// Binary executables are parsed once when loaded, and functions are lifted on
+// demand; thus clients such as IDE plugins and web UIs may inspect individual
+// functions without re-parsing the binary executable for each request.
+//
+// Endpoints:
+//
+//    POST   /binaries?path=FILE               load binary executable from path
+//    POST   /binaries                         upload binary executable (request body)
+//    GET    /binaries                         list loaded binary executables
+//    DELETE /binaries/ID                      unload binary executable
+//    GET    /binaries/ID/funcs                list function addresses
+//    GET    /binaries/ID/funcs/ADDR/asm       disassembly of function
+//    GET    /binaries/ID/funcs/ADDR/cfg       control flow graph of function (JSON)
+//    GET    /binaries/ID/funcs/ADDR/ll        LLVM IR of function
+//
+// The LLVM IR of a function is a self-contained module, which declares the
+// functions and defines the global variables referenced by the function.
+//
+// Raw binary executables are loaded by specifying the raw, rawentry and rawbase
+// query parameters (e.g. POST /binaries?path=foo.bin&raw=x86_32&rawbase=0x100).
+//
+// Associated files (e.g. funcs.json, info.ll) are located relative to the
+// working directory of the server, as with bin2ll.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/decomp/exp/config"
+	"github.com/mewkiz/pkg/term"
+)
+
+// Loggers.
+var (
+	// dbg represents a logger with the "liftd:" prefix, which logs debug
+	// messages to standard error.
+	dbg = log.New(os.Stderr, term.GreenBold("liftd:")+" ", 0)
+	// warn represents a logger with the "warning:" prefix, which logs warning
+	// messages to standard error.
+	warn = log.New(os.Stderr, term.RedBold("warning:")+" ", 0)
+)
+
+func usage() {
+	const use = `
+Serve lifting requests of binary executables through a REST API.
+
+Usage:
+
+	liftd [OPTION]...
+
+Flags:
+`
+	fmt.Fprint(os.Stderr, use[1:])
+	flag.PrintDefaults()
+}
+
+func main() {
+	// Parse command line arguments.
+	var (
+		// httpAddr specifies the HTTP service address.
+		httpAddr string
+		// quiet specifies whether to suppress non-error messages.
+		quiet bool
+	)
+	flag.Usage = usage
+	flag.StringVar(&httpAddr, "http", "localhost:8080", "HTTP service address")
+	flag.BoolVar(&quiet, "q", false, "suppress non-error messages")
+	flag.Parse()
+	// Apply project configuration file.
+	if err := config.Setup("liftd", flag.CommandLine, false); err != nil {
+		log.Fatalf("%+v", err)
+	}
+	if flag.NArg() != 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	// Mute debug and warning messages if `-q` is set.
+	if quiet {
+		dbg.SetOutput(ioutil.Discard)
+		warn.SetOutput(ioutil.Discard)
+	}
+
+	// Serve lifting requests.
+	srv := newServer()
+	dbg.Printf("listening on %q", httpAddr)
+	if err := http.ListenAndServe(httpAddr, srv); err != nil {
+		log.Fatalf("%+v", err)
+	}
+}
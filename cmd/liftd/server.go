@@ -0,0 +1,479 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/decomp/exp/bin"
+	_ "github.com/decomp/exp/bin/elf"     // register ELF decoder
+	_ "github.com/decomp/exp/bin/memdump" // register minidump decoder
+	_ "github.com/decomp/exp/bin/pe"      // register PE decoder
+	_ "github.com/decomp/exp/bin/pef"     // register PEF decoder
+	"github.com/decomp/exp/bin/raw"
+	"github.com/decomp/exp/lift/irutil"
+	"github.com/decomp/exp/lift/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/metadata"
+	"github.com/llir/llvm/ir/value"
+	"github.com/pkg/errors"
+)
+
+// server is a lifting server of binary executables.
+type server struct {
+	// Mutex guarding access to bins and nextID.
+	mu sync.Mutex
+	// Loaded binary executables, mapped from ID.
+	bins map[string]*binary
+	// ID of the next loaded binary executable.
+	nextID int
+}
+
+// newServer returns a new lifting server.
+func newServer() *server {
+	return &server{
+		bins: make(map[string]*binary),
+	}
+}
+
+// binary is a loaded binary executable.
+type binary struct {
+	// ID of the binary executable.
+	ID string `json:"id"`
+	// Path of the binary executable; empty if uploaded.
+	Path string `json:"path,omitempty"`
+	// Mutex guarding access to the lifter, which is not safe for concurrent
+	// use, and closed.
+	mu sync.Mutex
+	// x86 to LLVM IR lifter of the binary executable.
+	l *x86.Lifter
+	// The binary executable has been unloaded, and its memory-mapped file
+	// unmapped.
+	closed bool
+	// Lifted functions.
+	lifted map[bin.Address]bool
+}
+
+// ServeHTTP serves the given HTTP request.
+func (srv *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	dbg.Printf("%s %s", r.Method, r.URL)
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if parts[0] != "binaries" {
+		http.NotFound(w, r)
+		return
+	}
+	// /binaries
+	if len(parts) == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			srv.listBinaries(w)
+		case http.MethodPost:
+			srv.loadBinary(w, r)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	// /binaries/ID
+	if len(parts) == 2 && r.Method == http.MethodDelete {
+		srv.unloadBinary(w, parts[1])
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	srv.mu.Lock()
+	b, ok := srv.bins[parts[1]]
+	srv.mu.Unlock()
+	if !ok {
+		http.Error(w, fmt.Sprintf("unable to locate binary executable with ID %q", parts[1]), http.StatusNotFound)
+		return
+	}
+	// The binary executable may have been unloaded by a concurrent request
+	// since it was located.
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		http.Error(w, fmt.Sprintf("unable to locate binary executable with ID %q", parts[1]), http.StatusNotFound)
+		return
+	}
+	switch {
+	// /binaries/ID/funcs
+	case len(parts) == 3 && parts[2] == "funcs":
+		writeJSON(w, b.l.FuncAddrs)
+	// /binaries/ID/funcs/ADDR/KIND
+	case len(parts) == 5 && parts[2] == "funcs":
+		var funcAddr bin.Address
+		if err := funcAddr.Set(parts[3]); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f, ok := b.l.Funcs[funcAddr]
+		if !ok || f.AsmFunc == nil {
+			http.Error(w, fmt.Sprintf("unable to locate function at %v", funcAddr), http.StatusNotFound)
+			return
+		}
+		switch parts[4] {
+		case "asm":
+			writeAsm(w, f)
+		case "cfg":
+			writeJSON(w, b.cfg(f))
+		case "ll":
+			if err := b.lift(f); err != nil {
+				http.Error(w, fmt.Sprintf("%+v", err), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			fmt.Fprintln(w, b.module(f))
+		default:
+			http.NotFound(w, r)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// listBinaries writes the list of loaded binary executables to w.
+func (srv *server) listBinaries(w http.ResponseWriter) {
+	srv.mu.Lock()
+	var bins []*binary
+	for _, b := range srv.bins {
+		bins = append(bins, b)
+	}
+	srv.mu.Unlock()
+	less := func(i, j int) bool {
+		return bins[i].ID < bins[j].ID
+	}
+	sort.Slice(bins, less)
+	writeJSON(w, bins)
+}
+
+// loadBinary loads the binary executable specified by the given request, either
+// through the path query parameter or as the request body, and writes the
+// loaded binary executable to w.
+func (srv *server) loadBinary(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	binPath := query.Get("path")
+	b := &binary{
+		Path:   binPath,
+		lifted: make(map[bin.Address]bool),
+	}
+	if len(binPath) == 0 {
+		// Store uploaded binary executable in temporary file.
+		f, err := ioutil.TempFile("", "liftd_")
+		if err != nil {
+			http.Error(w, fmt.Sprintf("%+v", errors.WithStack(err)), http.StatusInternalServerError)
+			return
+		}
+		defer os.Remove(f.Name())
+		_, err = io.Copy(f, r.Body)
+		f.Close()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("%+v", errors.WithStack(err)), http.StatusInternalServerError)
+			return
+		}
+		binPath = f.Name()
+	}
+	var (
+		rawArch  bin.Arch
+		rawEntry bin.Address
+		rawBase  bin.Address
+	)
+	for _, p := range []struct {
+		key string
+		v   flagValue
+	}{{"raw", &rawArch}, {"rawentry", &rawEntry}, {"rawbase", &rawBase}} {
+		if s := query.Get(p.key); len(s) > 0 {
+			if err := p.v.Set(s); err != nil {
+				http.Error(w, fmt.Sprintf("invalid %s query parameter; %v", p.key, err), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	l, err := newLifter(binPath, rawArch, rawEntry, rawBase)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("%+v", err), http.StatusBadRequest)
+		return
+	}
+	b.l = l
+	// Create function lifters; all functions are decoded up front, as call
+	// instructions are resolved through the function lifters of the callees.
+	for _, funcAddr := range l.FuncAddrs {
+		asmFunc, err := l.DecodeFunc(funcAddr)
+		if err != nil {
+			warn.Printf("unable to decode function at %v; %v", funcAddr, err)
+			continue
+		}
+		l.Funcs[funcAddr] = l.NewFunc(asmFunc)
+	}
+	srv.mu.Lock()
+	b.ID = strconv.Itoa(srv.nextID)
+	srv.nextID++
+	srv.bins[b.ID] = b
+	srv.mu.Unlock()
+	writeJSON(w, b)
+}
+
+// unloadBinary unloads the binary executable with the given ID, unmapping its
+// memory-mapped file.
+func (srv *server) unloadBinary(w http.ResponseWriter, id string) {
+	srv.mu.Lock()
+	b, ok := srv.bins[id]
+	delete(srv.bins, id)
+	srv.mu.Unlock()
+	if !ok {
+		http.Error(w, fmt.Sprintf("unable to locate binary executable with ID %q", id), http.StatusNotFound)
+		return
+	}
+	// Wait for pending requests of the binary executable before unmapping, and
+	// mark it as closed for requests which located it before it was unloaded.
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	if err := b.l.File.Close(); err != nil {
+		http.Error(w, fmt.Sprintf("%+v", errors.WithStack(err)), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// lift lifts the given function to LLVM IR, unless already lifted. The caller
+// must hold b.mu.
+func (b *binary) lift(f *x86.Func) (err error) {
+	entry := f.AsmFunc.Addr
+	if b.lifted[entry] {
+		return nil
+	}
+	// The lifter panics on unsupported instructions; report as error to keep
+	// the server running, and discard the partially lifted function so that
+	// lifting is retried by the next request.
+	md := f.Metadata
+	defer func() {
+		if e := recover(); e != nil {
+			b.discard(f, md)
+			if ee, ok := e.(error); ok {
+				err = errors.Wrapf(ee, "unable to lift function at %v", entry)
+				return
+			}
+			err = errors.Errorf("unable to lift function at %v; %v", entry, e)
+		}
+	}()
+	f.Lift()
+	b.lifted[entry] = true
+	return nil
+}
+
+// discard discards the partially lifted LLVM IR of the given function, and
+// resets its function lifter. The LLVM IR function is retained, as referenced by
+// the lifted callers of the function.
+func (b *binary) discard(f *x86.Func, md []*metadata.Attachment) {
+	fn := f.Function
+	asmFunc := f.AsmFunc
+	fn.Blocks = nil
+	*f = x86.Func{Function: fn}
+	b.l.NewFunc(asmFunc)
+	// Restore metadata, as user comments are attached again by NewFunc.
+	fn.Metadata = md
+}
+
+// module returns an LLVM IR module containing the given lifted function, the
+// declarations of the functions it references and the definitions of the
+// global variables it references, either directly or through the initializers
+// of global variables.
+func (b *binary) module(f *x86.Func) *ir.Module {
+	m := &ir.Module{
+		TypeDefs: b.l.TypeDefs,
+	}
+	// Function declarations, by function.
+	decls := make(map[*ir.Function]*ir.Function)
+	seen := make(map[value.Value]bool)
+	var add func(v value.Value)
+	add = func(v value.Value) {
+		if seen[v] || v == f.Function {
+			return
+		}
+		seen[v] = true
+		switch v := v.(type) {
+		case *ir.Function:
+			decl, ok := decls[v]
+			if !ok {
+				decl = v
+				if len(v.Blocks) > 0 {
+					decl = declareFunc(v)
+				}
+				decls[v] = decl
+			}
+			m.Funcs = append(m.Funcs, decl)
+		case *ir.Global:
+			m.Globals = append(m.Globals, v)
+			if v.Init != nil {
+				refs(v.Init, add)
+			}
+		}
+	}
+	for _, block := range f.Blocks {
+		for _, inst := range block.Insts {
+			for _, operand := range irutil.Operands(inst) {
+				refs(*operand, add)
+			}
+		}
+		for _, operand := range irutil.Operands(block.Term) {
+			refs(*operand, add)
+		}
+	}
+	m.Funcs = append(m.Funcs, f.Function)
+	return m
+}
+
+// cfgFunc is the control flow graph of a function.
+type cfgFunc struct {
+	// Address of the function.
+	Addr bin.Address `json:"addr"`
+	// Basic blocks of the function, sorted by address.
+	Blocks []*cfgBlock `json:"blocks"`
+}
+
+// cfgBlock is a basic block of a control flow graph.
+type cfgBlock struct {
+	// Address of the basic block.
+	Addr bin.Address `json:"addr"`
+	// Successors of the basic block.
+	Succs []bin.Address `json:"succs"`
+}
+
+// cfg returns the control flow graph of the given function.
+func (b *binary) cfg(f *x86.Func) *cfgFunc {
+	asmFunc := f.AsmFunc
+	g := &cfgFunc{
+		Addr: asmFunc.Addr,
+	}
+	for _, blockAddr := range blockAddrs(f) {
+		block := asmFunc.Blocks[blockAddr]
+		succs := b.l.Targets(block.Term, asmFunc.Addr)
+		g.Blocks = append(g.Blocks, &cfgBlock{Addr: blockAddr, Succs: succs})
+	}
+	return g
+}
+
+// writeAsm writes the disassembly of the given function to w.
+func writeAsm(w http.ResponseWriter, f *x86.Func) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	asmFunc := f.AsmFunc
+	for _, blockAddr := range blockAddrs(f) {
+		block := asmFunc.Blocks[blockAddr]
+		fmt.Fprintf(w, "block_%06X:\n", uint64(blockAddr))
+		for _, inst := range block.Insts {
+			fmt.Fprintf(w, "\t%v: %v\n", inst.Addr, inst)
+		}
+		fmt.Fprintf(w, "\t%v: %v\n", block.Term.Addr, block.Term)
+	}
+}
+
+// ### [ Helper functions ] ####################################################
+
+// flagValue is the value of a command line flag or query parameter.
+type flagValue interface {
+	// Set sets the value based on the given string representation.
+	Set(s string) error
+}
+
+// newLifter returns a new x86 to LLVM IR lifter for the given binary
+// executable.
+func newLifter(binPath string, rawArch bin.Arch, rawEntry, rawBase bin.Address) (*x86.Lifter, error) {
+	file, err := parseFile(binPath, rawArch, rawEntry, rawBase)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	l, err := x86.NewLifter(file)
+	if err != nil {
+		file.Close()
+		return nil, errors.WithStack(err)
+	}
+	return l, nil
+}
+
+// parseFile parses the given binary executable.
+func parseFile(binPath string, rawArch bin.Arch, rawEntry, rawBase bin.Address) (*bin.File, error) {
+	// Parse raw binary executable.
+	if rawArch != 0 {
+		file, err := raw.ParseFile(binPath, rawArch)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		file.Entry = rawEntry
+		file.Base = rawBase
+		file.Sections[0].Addr = rawBase
+		return file, nil
+	}
+	// Parse binary executable.
+	return bin.ParseFile(binPath)
+}
+
+// blockAddrs returns the basic block addresses of the given function in
+// ascending order.
+func blockAddrs(f *x86.Func) []bin.Address {
+	var addrs bin.Addresses
+	for addr := range f.AsmFunc.Blocks {
+		addrs = append(addrs, addr)
+	}
+	sort.Sort(addrs)
+	return addrs
+}
+
+// writeJSON writes the JSON encoding of v to w.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	buf, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		http.Error(w, fmt.Sprintf("%+v", errors.WithStack(err)), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(buf)
+	w.Write([]byte("\n"))
+}
+
+// declareFunc returns a declaration of the given function.
+func declareFunc(f *ir.Function) *ir.Function {
+	var params []*ir.Param
+	for _, param := range f.Params {
+		params = append(params, ir.NewParam(param.Name(), param.Typ))
+	}
+	decl := ir.NewFunc(f.Name(), f.Sig.RetType, params...)
+	decl.Sig.Variadic = f.Sig.Variadic
+	decl.CallingConv = f.CallingConv
+	return decl
+}
+
+// refs invokes fn for each function and global variable referenced by the
+// given value.
+func refs(v value.Value, fn func(v value.Value)) {
+	switch v := v.(type) {
+	case *ir.Function, *ir.Global:
+		fn(v)
+	case *constant.Array:
+		for _, elem := range v.Elems {
+			refs(elem, fn)
+		}
+	case *constant.Struct:
+		for _, field := range v.Fields {
+			refs(field, fn)
+		}
+	case *constant.ExprBitCast:
+		refs(v.From, fn)
+	case *constant.ExprPtrToInt:
+		refs(v.From, fn)
+	case *constant.ExprIntToPtr:
+		refs(v.From, fn)
+	case *constant.ExprGetElementPtr:
+		refs(v.Src, fn)
+	}
+}
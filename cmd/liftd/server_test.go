@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// code is the machine code of a raw 32-bit x86 binary executable, loaded at
+// 0x1000.
+var code = []byte{
+	// 0x1000: push ebp
+	0x55,
+	// 0x1001: mov ebp, esp
+	0x89, 0xE5,
+	// 0x1003: xor eax, eax
+	0x31, 0xC0,
+	// 0x1005: pop ebp
+	0x5D,
+	// 0x1006: ret
+	0xC3,
+}
+
+func TestLift(t *testing.T) {
+	srv := newServer()
+	id := upload(t, srv)
+	resp := serve(srv, http.MethodGet, fmt.Sprintf("/binaries/%s/funcs/0x1000/ll", id), nil)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status code mismatch; expected %d, got %d (%s)", http.StatusOK, resp.Code, resp.Body)
+	}
+	if !bytes.Contains(resp.Body.Bytes(), []byte("define void @f_001000()")) {
+		t.Errorf("missing function definition in LLVM IR of lifted function; got %s", resp.Body)
+	}
+	resp = serve(srv, http.MethodDelete, "/binaries/"+id, nil)
+	if resp.Code != http.StatusNoContent {
+		t.Fatalf("status code mismatch; expected %d, got %d (%s)", http.StatusNoContent, resp.Code, resp.Body)
+	}
+	resp = serve(srv, http.MethodGet, fmt.Sprintf("/binaries/%s/funcs/0x1000/ll", id), nil)
+	if resp.Code != http.StatusNotFound {
+		t.Errorf("status code mismatch; expected %d, got %d (%s)", http.StatusNotFound, resp.Code, resp.Body)
+	}
+}
+
+// TestUnloadWhileLifting unloads a binary executable while its functions are
+// being lifted by concurrent requests. Requests either complete before the
+// memory-mapped file is unmapped, or report that the binary executable has been
+// unloaded. Run with -race to detect data races.
+func TestUnloadWhileLifting(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		srv := newServer()
+		id := upload(t, srv)
+		const nworkers = 4
+		var wg sync.WaitGroup
+		for worker := 0; worker < nworkers; worker++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for _, kind := range []string{"asm", "cfg", "ll"} {
+					resp := serve(srv, http.MethodGet, fmt.Sprintf("/binaries/%s/funcs/0x1000/%s", id, kind), nil)
+					if resp.Code != http.StatusOK && resp.Code != http.StatusNotFound {
+						t.Errorf("%s: status code mismatch; expected %d or %d, got %d (%s)", kind, http.StatusOK, http.StatusNotFound, resp.Code, resp.Body)
+					}
+				}
+			}()
+		}
+		resp := serve(srv, http.MethodDelete, "/binaries/"+id, nil)
+		if resp.Code != http.StatusNoContent {
+			t.Errorf("status code mismatch; expected %d, got %d (%s)", http.StatusNoContent, resp.Code, resp.Body)
+		}
+		wg.Wait()
+	}
+}
+
+// upload uploads the raw binary executable to the given server, and returns
+// its ID.
+func upload(t *testing.T, srv *server) string {
+	resp := serve(srv, http.MethodPost, "/binaries?raw=x86_32&rawentry=0x1000&rawbase=0x1000", code)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("unable to upload binary executable; status code %d (%s)", resp.Code, resp.Body)
+	}
+	var b binary
+	if err := json.Unmarshal(resp.Body.Bytes(), &b); err != nil {
+		t.Fatalf("unable to decode loaded binary executable; %v", err)
+	}
+	return b.ID
+}
+
+// serve serves the HTTP request of the given method, target and body, and
+// returns the recorded response.
+func serve(srv *server, method, target string, body []byte) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+	return w
+}
@@ -14,6 +14,7 @@ import (
 	"strconv"
 
 	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/config"
 	"github.com/mewkiz/pkg/term"
 	"github.com/pkg/errors"
 )
@@ -41,6 +42,10 @@ func main() {
 	flag.Parse()
 	flag.Usage = usage
 	flag.Parse()
+	// Apply project configuration file.
+	if err := config.Setup("lst2json", flag.CommandLine, false); err != nil {
+		log.Fatalf("%+v", err)
+	}
 	if flag.NArg() != 1 {
 		flag.Usage()
 		os.Exit(1)
@@ -0,0 +1,148 @@
+// The projedit tool inspects and edits project databases; e.g. to assign user
+// names which survive re-analysis.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/config"
+	"github.com/decomp/exp/project"
+	"github.com/pkg/errors"
+)
+
+func usage() {
+	const use = `
+Inspect and edit project databases.
+
+Usage:
+
+	projedit [OPTION]... PROJECT
+
+Examples:
+
+	projedit -rename 0x401000=main foo.db
+	projedit -comment "0x401000=entry point" foo.db
+
+Flags:
+`
+	fmt.Fprint(os.Stderr, use[1:])
+	flag.PrintDefaults()
+}
+
+func main() {
+	// Parse command line arguments.
+	var (
+		// comment specifies a comment to assign, in ADDR=TEXT format.
+		comment string
+		// list specifies whether to list the functions of the project.
+		list bool
+		// rename specifies a user name to assign, in ADDR=NAME format.
+		rename string
+	)
+	flag.Usage = usage
+	flag.StringVar(&comment, "comment", "", "assign comment (ADDR=TEXT); empty TEXT removes comment")
+	flag.BoolVar(&list, "list", false, "list functions of project")
+	flag.StringVar(&rename, "rename", "", "assign user name (ADDR=NAME); empty NAME removes user name")
+	flag.Parse()
+	// Apply project configuration file.
+	if err := config.Setup("projedit", flag.CommandLine, false); err != nil {
+		log.Fatalf("%+v", err)
+	}
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	projectPath := flag.Arg(0)
+
+	p, err := project.Open(projectPath)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+	modified := false
+	if len(rename) > 0 {
+		addr, name, err := parseAssign(rename)
+		if err != nil {
+			log.Fatalf("%+v", err)
+		}
+		p.Rename(addr, name)
+		modified = true
+	}
+	if len(comment) > 0 {
+		addr, text, err := parseAssign(comment)
+		if err != nil {
+			log.Fatalf("%+v", err)
+		}
+		p.SetComment(addr, text)
+		modified = true
+	}
+	if modified {
+		if err := p.Save(); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	}
+	if list {
+		listFuncs(p)
+	}
+}
+
+// listFuncs prints the functions of the given project to standard output.
+func listFuncs(p *project.Project) {
+	for _, funcAddr := range p.FuncAddrs {
+		line := funcAddr.String()
+		if name, ok := p.Name(funcAddr); ok {
+			line += "\t" + name
+		}
+		if _, ok := p.UserNames[funcAddr]; ok {
+			line += " (user)"
+		}
+		if sig, ok := p.Sigs[funcAddr]; ok {
+			line += "\t" + sig
+		}
+		if text, ok := p.Comments[funcAddr]; ok {
+			line += "\t; " + text
+		}
+		fmt.Println(line)
+	}
+	// List user names of non-function addresses.
+	var addrs bin.Addresses
+	for addr := range p.UserNames {
+		addrs = append(addrs, addr)
+	}
+	sort.Sort(addrs)
+	for _, addr := range addrs {
+		if !isFunc(p, addr) {
+			fmt.Printf("%v\t%s (user)\n", addr, p.UserNames[addr])
+		}
+	}
+}
+
+// ### [ Helper functions ] ####################################################
+
+// parseAssign parses the given assignment in ADDR=VALUE format.
+func parseAssign(s string) (bin.Address, string, error) {
+	pos := strings.Index(s, "=")
+	if pos == -1 {
+		return 0, "", errors.Errorf("invalid assignment %q; expected ADDR=VALUE format", s)
+	}
+	var addr bin.Address
+	if err := addr.Set(s[:pos]); err != nil {
+		return 0, "", errors.WithStack(err)
+	}
+	return addr, s[pos+1:], nil
+}
+
+// isFunc reports whether the given address is a function address of the
+// project.
+func isFunc(p *project.Project, addr bin.Address) bool {
+	less := func(i int) bool {
+		return addr <= p.FuncAddrs[i]
+	}
+	index := sort.Search(len(p.FuncAddrs), less)
+	return index < len(p.FuncAddrs) && p.FuncAddrs[index] == addr
+}
@@ -13,6 +13,7 @@ import (
 	"text/template"
 
 	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/config"
 	"github.com/mewkiz/pkg/errutil"
 	"github.com/pkg/errors"
 )
@@ -41,6 +42,10 @@ func main() {
 	flag.Parse()
 	flag.Usage = usage
 	flag.Parse()
+	// Apply project configuration file.
+	if err := config.Setup("sigs2h", flag.CommandLine, false); err != nil {
+		log.Fatalf("%+v", err)
+	}
 	if flag.NArg() != 1 {
 		flag.Usage()
 		os.Exit(1)
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/pkg/errors"
+)
+
+// A Report records the control flow divergences between the traces of a binary
+// executable and its recompiled lifted code.
+type Report struct {
+	// Path of the binary executable.
+	Binary string `json:"binary"`
+	// Path of the drcov coverage trace of the binary executable.
+	Orig string `json:"orig"`
+	// Path of the basic block trace of the recompiled lifted code.
+	Lifted string `json:"lifted"`
+	// Executed functions with divergences (or all executed functions if -all
+	// is set), sorted by address.
+	Funcs []*FuncReport `json:"funcs"`
+	// Traced addresses of the lifted code which are not basic block addresses
+	// of any disassembled function.
+	Unknown []bin.Address `json:"unknown,omitempty"`
+}
+
+// A FuncReport records the basic block-level divergences of a function.
+type FuncReport struct {
+	// Address of the function.
+	Addr bin.Address `json:"addr"`
+	// Number of basic blocks executed by the binary executable.
+	OrigBlocks int `json:"orig_blocks"`
+	// Number of basic blocks executed by the lifted code.
+	LiftedBlocks int `json:"lifted_blocks"`
+	// Basic blocks executed by the binary executable but not by the lifted
+	// code.
+	Missing []bin.Address `json:"missing,omitempty"`
+	// Basic blocks executed by the lifted code but not by the binary
+	// executable.
+	Extra []bin.Address `json:"extra,omitempty"`
+	// Basic block preceding the first extra basic block in the lifted trace;
+	// i.e. the basic block whose lifted terminator first branched differently
+	// than the original.
+	DivergedAt bin.Address `json:"diverged_at,omitempty"`
+}
+
+// A comparer compares traces of a binary executable and its lifted code.
+type comparer struct {
+	// Extents of the basic blocks of the disassembled functions, sorted by
+	// start address.
+	extents []*extent
+	// Map from basic block address to extent.
+	blocks map[bin.Address]*extent
+}
+
+// An extent is the address range of a basic block.
+type extent struct {
+	// Start and end address of the basic block.
+	start, end bin.Address
+	// Function containing the basic block.
+	funcAddr bin.Address
+}
+
+// newComparer returns a new comparer for the functions of the given
+// disassembler.
+func newComparer(dis *x86.Disasm) *comparer {
+	c := &comparer{
+		blocks: make(map[bin.Address]*extent),
+	}
+	dbg.Printf("disassembling %d functions", len(dis.FuncAddrs))
+	for _, funcAddr := range dis.FuncAddrs {
+		f, err := dis.DecodeFunc(funcAddr)
+		if err != nil {
+			warn.Printf("unable to decode function at %v; %v", funcAddr, err)
+			continue
+		}
+		for blockAddr, block := range f.Blocks {
+			if _, ok := c.blocks[blockAddr]; ok {
+				// basic block shared between functions; attribute to first.
+				continue
+			}
+			e := &extent{
+				start:    blockAddr,
+				end:      block.Term.Addr + bin.Address(block.Term.Len),
+				funcAddr: funcAddr,
+			}
+			c.extents = append(c.extents, e)
+			c.blocks[blockAddr] = e
+		}
+	}
+	less := func(i, j int) bool {
+		return c.extents[i].start < c.extents[j].start
+	}
+	sort.Slice(c.extents, less)
+	return c
+}
+
+// compare compares the given traces of the binary executable and the lifted
+// code. Functions without divergences are only reported if all is set.
+//
+// As the basic blocks of DynamoRIO may span several basic blocks of the
+// disassembler (e.g. across calls), each basic block overlapping an executed
+// basic block of the drcov trace is considered executed.
+func (c *comparer) compare(origBlocks []x86.CovBlock, liftedAddrs []bin.Address, all bool) *Report {
+	report := &Report{}
+	orig := make(map[bin.Address]bool)
+	for _, block := range origBlocks {
+		end := block.Addr + bin.Address(block.Size)
+		i := sort.Search(len(c.extents), func(i int) bool { return c.extents[i].end > block.Addr })
+		for ; i < len(c.extents) && c.extents[i].start < end; i++ {
+			orig[c.extents[i].start] = true
+		}
+	}
+	lifted := make(map[bin.Address]bool)
+	for _, addr := range liftedAddrs {
+		if _, ok := c.blocks[addr]; !ok {
+			report.Unknown = bin.InsertAddr(report.Unknown, addr)
+			continue
+		}
+		lifted[addr] = true
+	}
+	if len(report.Unknown) > 0 {
+		warn.Printf("%d traced addresses of lifted code are not basic block addresses", len(report.Unknown))
+	}
+	// Compare executed basic blocks of each function.
+	funcs := make(map[bin.Address]*FuncReport)
+	for _, e := range c.extents {
+		if !orig[e.start] && !lifted[e.start] {
+			continue
+		}
+		f, ok := funcs[e.funcAddr]
+		if !ok {
+			f = &FuncReport{Addr: e.funcAddr}
+			funcs[e.funcAddr] = f
+		}
+		if orig[e.start] {
+			f.OrigBlocks++
+		}
+		if lifted[e.start] {
+			f.LiftedBlocks++
+		}
+		switch {
+		case orig[e.start] && !lifted[e.start]:
+			f.Missing = append(f.Missing, e.start)
+		case !orig[e.start] && lifted[e.start]:
+			f.Extra = append(f.Extra, e.start)
+		}
+	}
+	// Locate the first divergence of each function in lifted trace order.
+	var prev bin.Address
+	for _, addr := range liftedAddrs {
+		e, ok := c.blocks[addr]
+		if !ok {
+			continue
+		}
+		if !orig[addr] {
+			f := funcs[e.funcAddr]
+			if f.DivergedAt == 0 && prev != 0 && c.blocks[prev].funcAddr == e.funcAddr {
+				f.DivergedAt = prev
+			}
+		}
+		prev = addr
+	}
+	for _, f := range funcs {
+		if !all && len(f.Missing) == 0 && len(f.Extra) == 0 {
+			continue
+		}
+		report.Funcs = append(report.Funcs, f)
+	}
+	less := func(i, j int) bool {
+		return report.Funcs[i].Addr < report.Funcs[j].Addr
+	}
+	sort.Slice(report.Funcs, less)
+	dbg.Printf("reporting %d functions", len(report.Funcs))
+	return report
+}
+
+// writeJSON writes the given report in JSON format to w.
+func writeJSON(w io.Writer, report *Report) error {
+	buf, err := json.MarshalIndent(report, "", "\t")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	buf = append(buf, '\n')
+	if _, err := w.Write(buf); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+// The tracediff tool reports control flow divergences between an original
+// binary executable and its recompiled lifted code, based on coverage traces of
+// both (foo.exe, foo.drcov, foo_lifted.trace -> divergences.json).
+//
+// The coverage trace of the original binary executable is recorded by the
+// drcov tool of DynamoRIO, and the trace of the recompiled lifted code by the
+// __trace runtime support function, called at the start of each basic block
+// of code lifted with `bin2ll -instrument trace`. As __trace is passed the
+// address of the basic block in the original binary executable, the traces are
+// aligned without further mapping.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/decomp/exp/bin"
+	_ "github.com/decomp/exp/bin/coff"    // register COFF decoder
+	_ "github.com/decomp/exp/bin/elf"     // register ELF decoder
+	_ "github.com/decomp/exp/bin/le"      // register LE/LX decoder
+	_ "github.com/decomp/exp/bin/memdump" // register minidump decoder
+	_ "github.com/decomp/exp/bin/ne"      // register NE decoder
+	_ "github.com/decomp/exp/bin/pe"      // register PE decoder
+	_ "github.com/decomp/exp/bin/pef"     // register PEF decoder
+	"github.com/decomp/exp/config"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/mewkiz/pkg/term"
+	"github.com/pkg/errors"
+)
+
+// Loggers.
+var (
+	// dbg represents a logger with the "tracediff:" prefix, which logs debug
+	// messages to standard error.
+	dbg = log.New(os.Stderr, term.CyanBold("tracediff:")+" ", 0)
+	// warn represents a logger with the "warning:" prefix, which logs warning
+	// messages to standard error.
+	warn = log.New(os.Stderr, term.RedBold("warning:")+" ", 0)
+)
+
+func usage() {
+	const use = `
+Report control flow divergences between a binary executable and its recompiled
+lifted code, based on a drcov coverage trace of the binary executable and a
+basic block trace of the lifted code (as produced by bin2ll -instrument trace).
+
+Usage:
+
+	tracediff [OPTION]... BIN ORIG.drcov LIFTED.trace
+
+Flags:
+`
+	fmt.Fprint(os.Stderr, use[1:])
+	flag.PrintDefaults()
+}
+
+func main() {
+	// Parse command line arguments.
+	var (
+		// all specifies whether to report functions without divergences.
+		all bool
+		// module specifies the module name of the binary executable in the drcov
+		// module table.
+		module string
+		// output specifies the output path.
+		output string
+		// quiet specifies whether to suppress non-error messages.
+		quiet bool
+	)
+	flag.Usage = usage
+	flag.BoolVar(&all, "all", false, "report executed functions without divergences")
+	flag.StringVar(&module, "module", "", "module name of binary executable in drcov module table (default base name of BIN)")
+	flag.StringVar(&output, "o", "", "output path")
+	flag.BoolVar(&quiet, "q", false, "suppress non-error messages")
+	flag.Parse()
+	// Apply project configuration file.
+	if err := config.Setup("tracediff", flag.CommandLine, false); err != nil {
+		log.Fatalf("%+v", err)
+	}
+	if flag.NArg() != 3 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	binPath, origPath, liftedPath := flag.Arg(0), flag.Arg(1), flag.Arg(2)
+	if len(module) == 0 {
+		module = filepath.Base(binPath)
+	}
+	// Mute debug and warning messages if `-q` is set.
+	if quiet {
+		dbg.SetOutput(ioutil.Discard)
+		warn.SetOutput(ioutil.Discard)
+	}
+
+	// Parse binary executable and traces.
+	file, err := bin.ParseFile(binPath)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+	defer file.Close()
+	dis, err := x86.NewDisasm(file)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+	origBlocks, err := x86.ParseDrcov(origPath, module, file.AddressSpace())
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+	liftedAddrs, err := x86.ParseTrace(liftedPath)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+	dbg.Printf("comparing %d basic blocks of %q with %d traced basic blocks of %q", len(origBlocks), origPath, len(liftedAddrs), liftedPath)
+
+	// Compare traces.
+	c := newComparer(dis)
+	report := c.compare(origBlocks, liftedAddrs, all)
+	report.Binary = binPath
+	report.Orig = origPath
+	report.Lifted = liftedPath
+
+	// Write report.
+	w := os.Stdout
+	if len(output) > 0 {
+		f, err := os.Create(output)
+		if err != nil {
+			log.Fatalf("%+v", errors.WithStack(err))
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := writeJSON(w, report); err != nil {
+		log.Fatalf("%+v", err)
+	}
+}
@@ -0,0 +1,326 @@
+// Package config implements project configuration files shared by the command
+// line tools.
+//
+// A project configuration file (decomp.yaml) describes the input binary
+// executable and the command line flags of tools, so that complex invocations
+// are reproducible and shareable.
+//
+//    # Input binary executable.
+//    input: foo.exe
+//    # Architecture overrides of raw binary executables.
+//    arch: x86_32
+//    base: 0x400000
+//    entry: 0x401000
+//    # Directory of annotation files (e.g. funcs.json and contexts.json).
+//    annotations: decomp
+//    # Output path.
+//    output: foo.ll
+//    # Naming scheme of functions and basic blocks.
+//    naming: ida
+//    # Lifter options.
+//    lifter:
+//       align: unaligned
+//       fpu-precision: extended
+//    # Flags of all tools.
+//    flags:
+//       q: true
+//    # Flags of specific tools; take precedence over flags of all tools.
+//    tools:
+//       bin2asm:
+//          compact: true
+//
+// The well-known keys (arch, base, entry, output and naming) correspond to the
+// -raw, -rawbase, -rawentry, -o and -naming flags, respectively, and lifter
+// options to flags of the same name. Config values only apply to flags not
+// specified on the command line, and to flags defined by the tool; except for
+// flags of specific tools, which must be defined by the tool.
+//
+// Relative paths of the config file are relative to the directory of the config
+// file. When an annotation directory is specified, tools are run with the
+// annotation directory as working directory, from which annotation files are
+// read; command line arguments and output paths are resolved before changing
+// the working directory.
+package config
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/mewkiz/pkg/osutil"
+	"github.com/mewkiz/pkg/term"
+	"github.com/pkg/errors"
+)
+
+// dbg represents a logger with the "config:" prefix, which logs debug messages
+// to standard error.
+var dbg = log.New(os.Stderr, term.YellowBold("config:")+" ", 0)
+
+// DefaultPath is the default path of project configuration files, as located in
+// the working directory. The path may be overridden by the DECOMP_CONFIG
+// environment variable.
+const DefaultPath = "decomp.yaml"
+
+// wellKnown maps from well-known config key to flag name.
+var wellKnown = map[string]string{
+	"arch":   "raw",
+	"base":   "rawbase",
+	"entry":  "rawentry",
+	"output": "o",
+	"naming": "naming",
+}
+
+// A Config is a project configuration file.
+type Config struct {
+	// Path of the config file.
+	Path string
+	// Path of the input binary executable; or empty if not specified.
+	Input string
+	// Directory of annotation files; or empty if not specified.
+	Annotations string
+	// Flags of all tools, mapping from flag name to value.
+	Flags map[string]string
+	// Flags of specific tools, mapping from tool name to flag name to value.
+	Tools map[string]map[string]string
+}
+
+// Load loads the given project configuration file.
+func Load(path string) (*Config, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	c, err := Parse(string(buf))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to parse config file %q", path)
+	}
+	c.Path = path
+	// Resolve relative paths.
+	dir := filepath.Dir(path)
+	c.Input = resolve(dir, c.Input)
+	c.Annotations = resolve(dir, c.Annotations)
+	if o, ok := c.Flags["o"]; ok {
+		c.Flags["o"] = resolve(dir, o)
+	}
+	for _, flags := range c.Tools {
+		if o, ok := flags["o"]; ok {
+			flags["o"] = resolve(dir, o)
+		}
+	}
+	return c, nil
+}
+
+// Parse parses the given project configuration file contents.
+func Parse(src string) (*Config, error) {
+	root, err := parseYAML(src)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	c := &Config{
+		Flags: make(map[string]string),
+		Tools: make(map[string]map[string]string),
+	}
+	for key, v := range root {
+		switch key {
+		case "input":
+			if c.Input, err = scalar(key, v); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		case "annotations":
+			if c.Annotations, err = scalar(key, v); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		case "flags", "lifter":
+			flags, err := mapping(key, v)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			for name, val := range flags {
+				c.Flags[name] = val
+			}
+		case "tools":
+			tools, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, errors.Errorf("invalid value of %q; expected mapping from tool name to flags", key)
+			}
+			for tool, v := range tools {
+				flags, err := mapping(key+"."+tool, v)
+				if err != nil {
+					return nil, errors.WithStack(err)
+				}
+				c.Tools[tool] = wellKnownFlags(flags)
+			}
+		default:
+			name, ok := wellKnown[key]
+			if !ok {
+				return nil, errors.Errorf("unknown config key %q", key)
+			}
+			if c.Flags[name], err = scalar(key, v); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+	}
+	return c, nil
+}
+
+// Setup loads the project configuration file, if present, and applies it to
+// the given tool; where fs is the parsed command line flag set of the tool, and
+// input specifies whether the tool takes a binary executable as command line
+// argument. The config file is located using the DECOMP_CONFIG environment
+// variable if set, and DefaultPath otherwise.
+func Setup(tool string, fs *flag.FlagSet, input bool) error {
+	path := os.Getenv("DECOMP_CONFIG")
+	if len(path) == 0 {
+		path = DefaultPath
+		if !osutil.Exists(path) {
+			return nil
+		}
+	}
+	c, err := Load(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	dbg.Printf("loaded config file %q", path)
+	return c.Apply(tool, fs, input)
+}
+
+// Apply applies the config file to the given tool; where fs is the parsed
+// command line flag set of the tool, and input specifies whether the tool takes
+// a binary executable as command line argument.
+//
+// Flags not specified on the command line are set to the values of the config
+// file, and the input binary executable is used as the command line argument of
+// the tool if no arguments were specified. Lastly, the working directory is
+// changed to the annotation directory, if specified.
+func (c *Config) Apply(tool string, fs *flag.FlagSet, input bool) error {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	// Flags of all tools.
+	for _, name := range sortedKeys(c.Flags) {
+		if set[name] || fs.Lookup(name) == nil {
+			continue
+		}
+		if err := fs.Set(name, c.Flags[name]); err != nil {
+			return errors.Wrapf(err, "invalid value of flag %q in config file %q", name, c.Path)
+		}
+	}
+	// Flags of the specific tool.
+	flags := c.Tools[tool]
+	for _, name := range sortedKeys(flags) {
+		if set[name] {
+			continue
+		}
+		if fs.Lookup(name) == nil {
+			return errors.Errorf("invalid flag %q of tool %q in config file %q; no such flag", name, tool, c.Path)
+		}
+		if err := fs.Set(name, flags[name]); err != nil {
+			return errors.Wrapf(err, "invalid value of flag %q of tool %q in config file %q", name, tool, c.Path)
+		}
+	}
+	// Input binary executable.
+	args := fs.Args()
+	if input && len(args) == 0 && len(c.Input) > 0 {
+		args = []string{c.Input}
+	}
+	if len(c.Annotations) == 0 {
+		return fs.Parse(append([]string{"--"}, args...))
+	}
+	// Resolve command line arguments and output path relative to the original
+	// working directory.
+	for i, arg := range args {
+		args[i] = absPath(arg)
+	}
+	if f := fs.Lookup("o"); f != nil && len(f.Value.String()) > 0 {
+		if err := fs.Set("o", absPath(f.Value.String())); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if err := fs.Parse(append([]string{"--"}, args...)); err != nil {
+		return errors.WithStack(err)
+	}
+	dbg.Printf("using annotation directory %q", c.Annotations)
+	if err := os.Chdir(c.Annotations); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// ### [ Helper functions ] ####################################################
+
+// scalar returns the scalar value of the given config key.
+func scalar(key string, v interface{}) (string, error) {
+	s, ok := v.(string)
+	if !ok {
+		return "", errors.Errorf("invalid value of %q; expected scalar, got mapping", key)
+	}
+	return s, nil
+}
+
+// mapping returns the mapping from flag name to scalar value of the given
+// config key.
+func mapping(key string, v interface{}) (map[string]string, error) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		if s, ok := v.(string); ok && len(s) == 0 {
+			// Empty mapping.
+			return nil, nil
+		}
+		return nil, errors.Errorf("invalid value of %q; expected mapping, got scalar", key)
+	}
+	flags := make(map[string]string)
+	for name, v := range m {
+		s, err := scalar(key+"."+name, v)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		flags[name] = s
+	}
+	return flags, nil
+}
+
+// wellKnownFlags returns the given flags, with well-known config keys replaced
+// by their flag names.
+func wellKnownFlags(flags map[string]string) map[string]string {
+	m := make(map[string]string)
+	for name, val := range flags {
+		if n, ok := wellKnown[name]; ok {
+			name = n
+		}
+		m[name] = val
+	}
+	return m
+}
+
+// resolve returns the given path resolved relative to dir, if relative and
+// non-empty.
+func resolve(dir, path string) string {
+	if len(path) == 0 || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// absPath returns the absolute representation of the given path, or the path
+// itself if unable to resolve.
+func absPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	return abs
+}
+
+// sortedKeys returns the keys of the given map in sorted order.
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	const src = `
+# Project configuration.
+input: foo.exe
+arch: x86_32   # raw architecture
+base: 0x400000
+naming: "ida"
+lifter:
+   align: unaligned
+flags:
+   q: true
+tools:
+   bin2asm:
+      compact: true
+   bin2ll:
+      output: 'foo.ll'
+`
+	c, err := Parse(src)
+	if err != nil {
+		t.Fatalf("unable to parse config; %v", err)
+	}
+	want := &Config{
+		Input: "foo.exe",
+		Flags: map[string]string{
+			"raw":     "x86_32",
+			"rawbase": "0x400000",
+			"naming":  "ida",
+			"align":   "unaligned",
+			"q":       "true",
+		},
+		Tools: map[string]map[string]string{
+			"bin2asm": {"compact": "true"},
+			"bin2ll":  {"o": "foo.ll"},
+		},
+	}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("config mismatch; expected %#v, got %#v", want, c)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	golden := []string{
+		// Unknown key.
+		"foo: bar\n",
+		// Invalid indentation.
+		"lifter:\n   align: unaligned\n  q: true\n",
+		// Sequence.
+		"flags:\n   - q\n",
+		// Duplicate key.
+		"input: a\ninput: b\n",
+		// Unterminated quoted scalar.
+		"input: \"foo\n",
+	}
+	for _, src := range golden {
+		if _, err := Parse(src); err == nil {
+			t.Errorf("%q: expected error, got nil", src)
+		}
+	}
+}
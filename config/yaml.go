@@ -0,0 +1,142 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// parseYAML parses the given YAML source, returning the top-level mapping.
+// Values of the mapping are either strings (scalars) or nested mappings of type
+// map[string]interface{}.
+//
+// Only the subset of YAML used by config files is supported; i.e. block
+// mappings of plain or quoted scalars, nested by indentation, and comments.
+//
+//    # comment
+//    key: value
+//    nested:
+//       key: "quoted value"
+func parseYAML(src string) (map[string]interface{}, error) {
+	// A frame is a mapping under construction, and its indentation.
+	type frame struct {
+		m      map[string]interface{}
+		indent int
+	}
+	root := make(map[string]interface{})
+	stack := []frame{{m: root, indent: -1}}
+	// pending is the key of a mapping entry without value, whose nested mapping
+	// starts on the next line of greater indentation.
+	var pending string
+	var pendingIndent int
+	pendingParent := root
+	for i, line := range strings.Split(src, "\n") {
+		lineNum := i + 1
+		line = stripComment(strings.TrimRight(line, " \t\r"))
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		if strings.HasPrefix(line, "\t") {
+			return nil, errors.Errorf("line %d: tab characters not allowed in indentation", lineNum)
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		line = strings.TrimSpace(line)
+		if len(pending) > 0 {
+			if indent > pendingIndent {
+				// Nested mapping.
+				m := make(map[string]interface{})
+				pendingParent[pending] = m
+				stack = append(stack, frame{m: m, indent: indent})
+			} else {
+				// Empty value.
+				pendingParent[pending] = ""
+			}
+			pending = ""
+		}
+		// Pop mappings of greater indentation.
+		for indent < stack[len(stack)-1].indent {
+			stack = stack[:len(stack)-1]
+		}
+		top := stack[len(stack)-1]
+		if indent != top.indent && top.indent != -1 {
+			return nil, errors.Errorf("line %d: invalid indentation", lineNum)
+		}
+		if top.indent == -1 {
+			if indent != 0 {
+				return nil, errors.Errorf("line %d: invalid indentation", lineNum)
+			}
+			stack[len(stack)-1].indent = 0
+		}
+		if strings.HasPrefix(line, "- ") || line == "-" {
+			return nil, errors.Errorf("line %d: support for sequences not yet implemented", lineNum)
+		}
+		pos := strings.Index(line, ":")
+		if pos == -1 || (pos+1 < len(line) && line[pos+1] != ' ') {
+			return nil, errors.Errorf("line %d: expected mapping entry in KEY: VALUE format, got %q", lineNum, line)
+		}
+		key, err := unquote(strings.TrimSpace(line[:pos]))
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", lineNum)
+		}
+		if _, ok := top.m[key]; ok {
+			return nil, errors.Errorf("line %d: duplicate key %q", lineNum, key)
+		}
+		val := strings.TrimSpace(line[pos+1:])
+		if len(val) == 0 {
+			pending, pendingIndent, pendingParent = key, indent, top.m
+			continue
+		}
+		v, err := unquote(val)
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", lineNum)
+		}
+		top.m[key] = v
+	}
+	if len(pending) > 0 {
+		pendingParent[pending] = ""
+	}
+	return root, nil
+}
+
+// stripComment strips the trailing comment of the given line, if any. Comments
+// start with a '#' character at the start of the line or following whitespace,
+// outside of quoted strings.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return strings.TrimRight(line[:i], " \t")
+		}
+	}
+	return line
+}
+
+// unquote returns the value of the given plain, single-quoted or double-quoted
+// scalar.
+func unquote(s string) (string, error) {
+	switch {
+	case len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"':
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return "", errors.Errorf("invalid double-quoted scalar %s", s)
+		}
+		return v, nil
+	case len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'':
+		// Single quotes are escaped by doubling.
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1), nil
+	case strings.HasPrefix(s, "\"") || strings.HasPrefix(s, "'"):
+		return "", errors.Errorf("unterminated quoted scalar %s", s)
+	}
+	return s, nil
+}
@@ -0,0 +1,111 @@
+// Package annot imports and exports program annotations (function, basic block
+// and data addresses, symbol names, strings and function signatures) in the
+// formats of third-party disassemblers.
+//
+// Supported import formats, identified by file extension.
+//
+//    *.xml      Ghidra XML program export
+//    *.sarif    Ghidra SARIF program export
+//    *.json     IDA JSON dump
+//    *.idc      IDA IDC script
+//
+// The IDA JSON dump has the following structure, where addresses are
+// hexadecimal strings.
+//
+//    {
+//       "funcs": [{"addr": "0x401000", "name": "main", "blocks": ["0x401010"]}],
+//       "data": ["0x403000"],
+//       "names": {"0x403000": "g_count"}
+//    }
+//
+// Supported export formats, identified by file extension.
+//
+//    *.xml      Ghidra XML program import
+//    *.py       IDAPython script
+package annot
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// Annotations holds program annotations of a binary executable.
+type Annotations struct {
+	// Function addresses.
+	FuncAddrs []bin.Address
+	// Basic block addresses.
+	BlockAddrs []bin.Address
+	// Data addresses.
+	DataAddrs []bin.Address
+	// Map from address to symbol name.
+	Names map[bin.Address]string
+	// Map from address to string literal.
+	Strings map[bin.Address]string
+	// Map from function address to C function signature (e.g. "int f(int a)").
+	Sigs map[bin.Address]string
+	// Map from string resource ID to string (e.g. PE string tables).
+	ResStrings map[uint32]string
+}
+
+// New returns a new empty set of program annotations.
+func New() *Annotations {
+	return &Annotations{
+		Names:      make(map[bin.Address]string),
+		Strings:    make(map[bin.Address]string),
+		Sigs:       make(map[bin.Address]string),
+		ResStrings: make(map[uint32]string),
+	}
+}
+
+// ParseFile parses the given annotation file, the format of which is determined
+// by file extension.
+func ParseFile(path string) (*Annotations, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	a := New()
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".xml":
+		err = parseGhidraXML(f, a)
+	case ".sarif":
+		err = parseGhidraSARIF(f, a)
+	case ".json":
+		err = parseIDAJSON(f, a)
+	case ".idc":
+		err = parseIDC(f, a)
+	default:
+		return nil, errors.Errorf("support for annotation file format %q not yet implemented", ext)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to parse annotation file %q", path)
+	}
+	sort.Sort(bin.Addresses(a.FuncAddrs))
+	sort.Sort(bin.Addresses(a.BlockAddrs))
+	sort.Sort(bin.Addresses(a.DataAddrs))
+	return a, nil
+}
+
+// ### [ Helper functions ] ####################################################
+
+// parseAddr parses the given address. Ghidra addresses are hexadecimal without
+// "0x" prefix, optionally qualified by address space (e.g. "ram:00401000").
+func parseAddr(s string) (bin.Address, error) {
+	if pos := strings.LastIndex(s, ":"); pos != -1 {
+		s = s[pos+1:]
+	}
+	if !strings.HasPrefix(s, "0x") && !strings.HasPrefix(s, "0X") {
+		s = "0x" + s
+	}
+	var addr bin.Address
+	if err := addr.Set(s); err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return addr, nil
+}
@@ -0,0 +1,130 @@
+package annot_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/annot"
+)
+
+func TestParseFile(t *testing.T) {
+	golden := []struct {
+		path       string
+		funcAddrs  []bin.Address
+		blockAddrs []bin.Address
+		dataAddrs  []bin.Address
+		names      map[bin.Address]string
+		sigs       map[bin.Address]string
+	}{
+		{
+			path:       "testdata/ghidra.xml",
+			funcAddrs:  []bin.Address{0x401000, 0x401020},
+			blockAddrs: []bin.Address{0x401000, 0x401020},
+			dataAddrs:  []bin.Address{0x403000, 0x403004},
+			names: map[bin.Address]string{
+				0x401000: "main",
+				0x403000: "g_count",
+				0x403004: "s_hello",
+			},
+			sigs: map[bin.Address]string{},
+		},
+		{
+			path:       "testdata/ghidra.sarif",
+			funcAddrs:  []bin.Address{0x401000, 0x401020},
+			blockAddrs: []bin.Address{0x401000, 0x401020},
+			dataAddrs:  []bin.Address{0x403000},
+			names: map[bin.Address]string{
+				0x401000: "main",
+				0x403000: "g_count",
+			},
+			sigs: map[bin.Address]string{},
+		},
+		{
+			path:       "testdata/ida.json",
+			funcAddrs:  []bin.Address{0x401000, 0x401020},
+			blockAddrs: []bin.Address{0x401000, 0x401010, 0x401020},
+			dataAddrs:  []bin.Address{0x403000, 0x403004},
+			names: map[bin.Address]string{
+				0x401000: "main",
+				0x403000: "g_count",
+			},
+			sigs: map[bin.Address]string{},
+		},
+		{
+			path:       "testdata/ida.idc",
+			funcAddrs:  []bin.Address{0x401000, 0x401020},
+			blockAddrs: []bin.Address{0x401000, 0x401000, 0x401010, 0x401020, 0x401020},
+			dataAddrs:  []bin.Address{0x403000, 0x403004, 0x40300C},
+			names: map[bin.Address]string{
+				0x401000: "main",
+				0x403000: "g_count",
+				0x403004: "s_hello",
+			},
+			sigs: map[bin.Address]string{
+				0x401000: "int __cdecl main(int argc, char **argv)",
+			},
+		},
+	}
+	for _, g := range golden {
+		a, err := annot.ParseFile(g.path)
+		if err != nil {
+			t.Errorf("%q: unable to parse annotation file; %+v", g.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(a.FuncAddrs, g.funcAddrs) {
+			t.Errorf("%q: function addresses mismatch; expected %v, got %v", g.path, g.funcAddrs, a.FuncAddrs)
+		}
+		if !reflect.DeepEqual(a.BlockAddrs, g.blockAddrs) {
+			t.Errorf("%q: basic block addresses mismatch; expected %v, got %v", g.path, g.blockAddrs, a.BlockAddrs)
+		}
+		if !reflect.DeepEqual(a.DataAddrs, g.dataAddrs) {
+			t.Errorf("%q: data addresses mismatch; expected %v, got %v", g.path, g.dataAddrs, a.DataAddrs)
+		}
+		if !reflect.DeepEqual(a.Names, g.names) {
+			t.Errorf("%q: names mismatch; expected %v, got %v", g.path, g.names, a.Names)
+		}
+		if !reflect.DeepEqual(a.Sigs, g.sigs) {
+			t.Errorf("%q: function signatures mismatch; expected %v, got %v", g.path, g.sigs, a.Sigs)
+		}
+	}
+}
+
+func TestParseFileInvalid(t *testing.T) {
+	golden := []struct {
+		name string
+		src  string
+	}{
+		// Malformed XML.
+		{name: "a.xml", src: `<PROGRAM><FUNCTIONS>`},
+		// Invalid Ghidra address.
+		{name: "b.xml", src: `<PROGRAM><FUNCTIONS><FUNCTION ENTRY_POINT="ram:zz" /></FUNCTIONS></PROGRAM>`},
+		// Malformed SARIF.
+		{name: "c.sarif", src: `{"runs": [`},
+		// Invalid IDA JSON address.
+		{name: "d.json", src: `{"data": ["foo"]}`},
+		// Unterminated IDC argument list.
+		{name: "e.idc", src: `set_name(0X401000, "main"`},
+		// Unterminated IDC string literal.
+		{name: "f.idc", src: "set_name(0X401000, \"main);\n"},
+		// Unknown file extension.
+		{name: "g.map", src: ``},
+	}
+	dir, err := ioutil.TempDir("", "annot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, g := range golden {
+		path := filepath.Join(dir, g.name)
+		if err := ioutil.WriteFile(path, []byte(g.src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := annot.ParseFile(path); err == nil {
+			t.Errorf("%q: expected error, got nil", g.name)
+		}
+	}
+}
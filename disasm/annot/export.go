@@ -0,0 +1,160 @@
+package annot
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// WriteFile writes the program annotations to the given file, the format of
+// which is determined by file extension.
+func (a *Annotations) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+	bw := bufio.NewWriter(f)
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".xml":
+		err = a.WriteGhidraXML(bw)
+	case ".py":
+		err = a.WriteIDAPython(bw)
+	default:
+		return errors.Errorf("support for annotation file format %q not yet implemented", ext)
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := bw.Flush(); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// --- [ Ghidra XML ] ----------------------------------------------------------
+
+// WriteGhidraXML writes the program annotations to w as a Ghidra XML program
+// import file. Function signatures are stored as function comments, as Ghidra
+// XML requires fully resolved data types. Basic block addresses are omitted, as
+// Ghidra recovers basic blocks from function entry points.
+func (a *Annotations) WriteGhidraXML(w io.Writer) error {
+	prog := &ghidraProgram{}
+	for _, addr := range sortedKeys(a.Names) {
+		sym := ghidraSymbol{
+			Addr: ghidraAddr(addr),
+			Name: a.Names[addr],
+		}
+		prog.Symbols = append(prog.Symbols, sym)
+	}
+	for _, funcAddr := range a.FuncAddrs {
+		f := ghidraFunc{
+			Entry:   ghidraAddr(funcAddr),
+			Name:    a.Names[funcAddr],
+			Comment: a.Sigs[funcAddr],
+		}
+		prog.Funcs = append(prog.Funcs, f)
+	}
+	for _, addr := range sortedKeys(a.Strings) {
+		data := ghidraDefData{
+			Addr:     ghidraAddr(addr),
+			DataType: "string",
+			Size:     fmt.Sprintf("0x%x", len(a.Strings[addr])+1),
+		}
+		prog.Data = append(prog.Data, data)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return errors.WithStack(err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	if err := enc.Encode(prog); err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// --- [ IDAPython ] -----------------------------------------------------------
+
+// WriteIDAPython writes the program annotations to w as an IDAPython script,
+// which applies the annotations when run from within IDA (File -> Script
+// file).
+func (a *Annotations) WriteIDAPython(w io.Writer) error {
+	var lines []string
+	add := func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+	add("# Program annotations exported by decomp.")
+	add("import ida_bytes")
+	add("import ida_funcs")
+	add("import ida_nalt")
+	add("import ida_ua")
+	add("import idc")
+	add("")
+	add("# Basic blocks.")
+	for _, blockAddr := range a.BlockAddrs {
+		add("ida_ua.create_insn(0x%X)", uint64(blockAddr))
+	}
+	add("")
+	add("# Functions.")
+	for _, funcAddr := range a.FuncAddrs {
+		add("ida_funcs.add_func(0x%X)", uint64(funcAddr))
+	}
+	add("")
+	add("# Strings.")
+	for _, addr := range sortedKeys(a.Strings) {
+		add("ida_bytes.create_strlit(0x%X, %d, ida_nalt.STRTYPE_C)", uint64(addr), len(a.Strings[addr])+1)
+	}
+	add("")
+	add("# Names.")
+	for _, addr := range sortedKeys(a.Names) {
+		add("idc.set_name(0x%X, %s, idc.SN_NOWARN)", uint64(addr), pyString(a.Names[addr]))
+	}
+	add("")
+	add("# Function signatures.")
+	for _, funcAddr := range sortedKeys(a.Sigs) {
+		add("idc.SetType(0x%X, %s)", uint64(funcAddr), pyString(a.Sigs[funcAddr]+";"))
+	}
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// ### [ Helper functions ] ####################################################
+
+// ghidraAddr returns the Ghidra representation of the given address.
+func ghidraAddr(addr bin.Address) string {
+	return fmt.Sprintf("%08x", uint64(addr))
+}
+
+// pyString returns a Python string literal of s.
+func pyString(s string) string {
+	// Go quoted strings of printable ASCII characters are valid Python string
+	// literals.
+	return strconv.QuoteToASCII(s)
+}
+
+// sortedKeys returns the addresses of the given map in ascending order.
+func sortedKeys(m map[bin.Address]string) []bin.Address {
+	var addrs bin.Addresses
+	for addr := range m {
+		addrs = append(addrs, addr)
+	}
+	sort.Sort(addrs)
+	return addrs
+}
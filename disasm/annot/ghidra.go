@@ -0,0 +1,134 @@
+package annot
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// --- [ Ghidra XML ] ----------------------------------------------------------
+
+// ghidraProgram is the root element of a Ghidra XML program export.
+type ghidraProgram struct {
+	XMLName xml.Name        `xml:"PROGRAM"`
+	Symbols []ghidraSymbol  `xml:"SYMBOL_TABLE>SYMBOL"`
+	Funcs   []ghidraFunc    `xml:"FUNCTIONS>FUNCTION"`
+	Data    []ghidraDefData `xml:"DATA>DEFINED_DATA"`
+}
+
+// ghidraSymbol is a symbol of a Ghidra XML program export.
+type ghidraSymbol struct {
+	Addr string `xml:"ADDRESS,attr"`
+	Name string `xml:"NAME,attr"`
+}
+
+// ghidraFunc is a function of a Ghidra XML program export.
+type ghidraFunc struct {
+	Entry   string `xml:"ENTRY_POINT,attr"`
+	Name    string `xml:"NAME,attr,omitempty"`
+	Comment string `xml:"REGULAR_CMT,omitempty"`
+}
+
+// ghidraDefData is a defined data item of a Ghidra XML program export.
+type ghidraDefData struct {
+	Addr     string `xml:"ADDRESS,attr"`
+	DataType string `xml:"DATATYPE,attr,omitempty"`
+	Size     string `xml:"SIZE,attr,omitempty"`
+}
+
+// parseGhidraXML parses the Ghidra XML program export read from r into a.
+func parseGhidraXML(r io.Reader, a *Annotations) error {
+	var prog ghidraProgram
+	if err := xml.NewDecoder(r).Decode(&prog); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, sym := range prog.Symbols {
+		addr, err := parseAddr(sym.Addr)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		a.Names[addr] = sym.Name
+	}
+	for _, f := range prog.Funcs {
+		entry, err := parseAddr(f.Entry)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		a.FuncAddrs = append(a.FuncAddrs, entry)
+		a.BlockAddrs = append(a.BlockAddrs, entry)
+		if len(f.Name) > 0 {
+			a.Names[entry] = f.Name
+		}
+	}
+	for _, data := range prog.Data {
+		addr, err := parseAddr(data.Addr)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		a.DataAddrs = append(a.DataAddrs, addr)
+	}
+	return nil
+}
+
+// --- [ Ghidra SARIF ] --------------------------------------------------------
+
+// sarifLog is the root object of a Ghidra SARIF program export.
+type sarifLog struct {
+	Runs []struct {
+		Results []struct {
+			// Kind of result; e.g. "FUNCTIONS", "DATA" or "SYMBOLS".
+			RuleID    string `json:"ruleId"`
+			Locations []struct {
+				PhysicalLocation struct {
+					Address struct {
+						AbsoluteAddress uint64 `json:"absoluteAddress"`
+					} `json:"address"`
+				} `json:"physicalLocation"`
+				LogicalLocations []struct {
+					Name string `json:"name"`
+				} `json:"logicalLocations"`
+			} `json:"locations"`
+		} `json:"results"`
+	} `json:"runs"`
+}
+
+// parseGhidraSARIF parses the Ghidra SARIF program export read from r into a.
+// Only function, data and symbol results are considered.
+func parseGhidraSARIF(r io.Reader, a *Annotations) error {
+	var log sarifLog
+	if err := json.NewDecoder(r).Decode(&log); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, run := range log.Runs {
+		for _, result := range run.Results {
+			if len(result.Locations) == 0 {
+				continue
+			}
+			loc := result.Locations[0]
+			addr := bin.Address(loc.PhysicalLocation.Address.AbsoluteAddress)
+			var name string
+			if len(loc.LogicalLocations) > 0 {
+				name = loc.LogicalLocations[0].Name
+			}
+			switch result.RuleID {
+			case "FUNCTIONS":
+				a.FuncAddrs = append(a.FuncAddrs, addr)
+				a.BlockAddrs = append(a.BlockAddrs, addr)
+			case "DATA":
+				a.DataAddrs = append(a.DataAddrs, addr)
+			case "SYMBOLS":
+				// nothing to do.
+			default:
+				// skip unrelated results (e.g. comments, bookmarks).
+				continue
+			}
+			if len(name) > 0 {
+				a.Names[addr] = name
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package annot
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// idaDump is the root object of an IDA JSON dump.
+type idaDump struct {
+	Funcs []struct {
+		Addr   bin.Address   `json:"addr"`
+		Name   string        `json:"name"`
+		Blocks []bin.Address `json:"blocks"`
+	} `json:"funcs"`
+	Data  []bin.Address          `json:"data"`
+	Names map[bin.Address]string `json:"names"`
+}
+
+// parseIDAJSON parses the IDA JSON dump read from r into a.
+func parseIDAJSON(r io.Reader, a *Annotations) error {
+	var dump idaDump
+	if err := json.NewDecoder(r).Decode(&dump); err != nil {
+		return errors.WithStack(err)
+	}
+	for addr, name := range dump.Names {
+		a.Names[addr] = name
+	}
+	for _, f := range dump.Funcs {
+		a.FuncAddrs = append(a.FuncAddrs, f.Addr)
+		a.BlockAddrs = append(a.BlockAddrs, f.Addr)
+		a.BlockAddrs = append(a.BlockAddrs, f.Blocks...)
+		if len(f.Name) > 0 {
+			a.Names[f.Addr] = f.Name
+		}
+	}
+	a.DataAddrs = append(a.DataAddrs, dump.Data...)
+	return nil
+}
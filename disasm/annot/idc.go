@@ -0,0 +1,176 @@
+package annot
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"text/scanner"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// --- [ IDA IDC ] -------------------------------------------------------------
+
+// parseIDC parses the IDA IDC script read from r (e.g. as produced by "Dump
+// database to IDC file") into a. The script is not executed; rather, calls to
+// the following functions are recognized, with both the current and the legacy
+// (IDA 6.x) names. Instruction addresses are treated as basic block addresses,
+// as with code overrides.
+//
+//    set_name(ea, name)            MakeName(ea, name)
+//    add_func(start, end)          MakeFunction(start, end)
+//    create_insn(ea)               MakeCode(ea)
+//    create_data(ea, ...)          MakeData(ea, ...)
+//    create_dword(ea)              MakeDword(ea)
+//    create_strlit(ea, end)        MakeStr(ea, end)
+//    SetType(ea, type)
+//
+// Arguments are integer and string literals, and variables assigned within the
+// argument list (e.g. "create_insn(x=0X401000); op_hex(x, 1);"). Calls with
+// other arguments are ignored.
+func parseIDC(r io.Reader, a *Annotations) error {
+	var s scanner.Scanner
+	s.Init(r)
+	s.Mode = scanner.ScanIdents | scanner.ScanInts | scanner.ScanFloats | scanner.ScanStrings | scanner.ScanRawStrings | scanner.ScanChars | scanner.ScanComments | scanner.SkipComments
+	var serr error
+	s.Error = func(s *scanner.Scanner, msg string) {
+		if serr == nil {
+			serr = errors.Errorf("%v: %s", s.Position, msg)
+		}
+	}
+	// Map from variable name to integer value.
+	vars := make(map[string]uint64)
+	prev := ""
+	for tok := s.Scan(); tok != scanner.EOF; tok = s.Scan() {
+		if serr != nil {
+			return serr
+		}
+		if tok == scanner.Ident {
+			prev = s.TokenText()
+			continue
+		}
+		if tok != '(' || len(prev) == 0 {
+			prev = ""
+			continue
+		}
+		name := prev
+		prev = ""
+		args, err := parseIDCArgs(&s, vars)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		addIDCCall(a, name, args)
+	}
+	return serr
+}
+
+// parseIDCArgs parses the argument list of an IDC function call, up to and
+// including the closing parenthesis. Arguments are integers (uint64), strings
+// (string), or nil if not literals. Variable assignments within the argument
+// list are recorded in vars.
+func parseIDCArgs(s *scanner.Scanner, vars map[string]uint64) ([]interface{}, error) {
+	var args []interface{}
+	// Tokens of the current argument.
+	var toks []string
+	var kinds []rune
+	depth := 0
+	for {
+		tok := s.Scan()
+		switch {
+		case tok == scanner.EOF:
+			return nil, errors.Errorf("%v: unexpected end of file in argument list", s.Position)
+		case tok == '(':
+			depth++
+		case tok == ')' && depth > 0:
+			depth--
+		case (tok == ',' || tok == ')') && depth == 0:
+			if len(toks) > 0 {
+				args = append(args, evalIDCArg(toks, kinds, vars))
+			}
+			if tok == ')' {
+				return args, nil
+			}
+			toks, kinds = nil, nil
+			continue
+		}
+		toks = append(toks, s.TokenText())
+		kinds = append(kinds, tok)
+	}
+}
+
+// evalIDCArg evaluates the IDC function argument of the given tokens, recording
+// variable assignments in vars. The value is an integer (uint64) or a string
+// (string), or nil if not a literal.
+func evalIDCArg(toks []string, kinds []rune, vars map[string]uint64) interface{} {
+	// Variable assignment; e.g. x=0X401000
+	if len(toks) > 2 && kinds[0] == scanner.Ident && toks[1] == "=" && toks[2] != "=" {
+		v := evalIDCArg(toks[2:], kinds[2:], vars)
+		if x, ok := v.(uint64); ok {
+			vars[toks[0]] = x
+		}
+		return v
+	}
+	if len(toks) != 1 {
+		return nil
+	}
+	switch kinds[0] {
+	case scanner.Int:
+		x, err := strconv.ParseUint(toks[0], 0, 64)
+		if err != nil {
+			return nil
+		}
+		return x
+	case scanner.String, scanner.RawString:
+		s, err := strconv.Unquote(toks[0])
+		if err != nil {
+			return nil
+		}
+		return s
+	case scanner.Ident:
+		if x, ok := vars[toks[0]]; ok {
+			return x
+		}
+	}
+	return nil
+}
+
+// addIDCCall records the program annotations of the given IDC function call
+// in a.
+func addIDCCall(a *Annotations, name string, args []interface{}) {
+	if len(args) == 0 {
+		return
+	}
+	x, ok := args[0].(uint64)
+	if !ok {
+		return
+	}
+	addr := bin.Address(x)
+	str := func(i int) string {
+		if i < len(args) {
+			if s, ok := args[i].(string); ok {
+				return s
+			}
+		}
+		return ""
+	}
+	switch name {
+	case "set_name", "MakeName", "MakeNameEx":
+		if name := str(1); len(name) > 0 {
+			a.Names[addr] = name
+		}
+	case "add_func", "MakeFunction":
+		a.FuncAddrs = append(a.FuncAddrs, addr)
+		a.BlockAddrs = append(a.BlockAddrs, addr)
+	case "create_insn", "MakeCode":
+		a.BlockAddrs = append(a.BlockAddrs, addr)
+	case "create_data", "create_byte", "create_word", "create_dword", "create_qword", "create_float", "create_double", "create_strlit",
+		"MakeData", "MakeByte", "MakeWord", "MakeDword", "MakeQword", "MakeFloat", "MakeDouble", "MakeStr":
+		a.DataAddrs = append(a.DataAddrs, addr)
+	case "SetType":
+		// Only function types are recorded as function signatures.
+		if typ := str(1); strings.Contains(typ, "(") {
+			a.Sigs[addr] = typ
+		}
+	}
+}
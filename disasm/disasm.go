@@ -7,6 +7,7 @@ import (
 	"sort"
 
 	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/annot"
 	"github.com/mewkiz/pkg/jsonutil"
 	"github.com/mewkiz/pkg/osutil"
 	"github.com/mewkiz/pkg/term"
@@ -44,6 +45,15 @@ type Disasm struct {
 	Chunks map[bin.Address]map[bin.Address]bool
 	// Fragments; sequences of bytes.
 	Frags []*Fragment
+	// Map from address to symbol name.
+	Names map[bin.Address]string
+	// Map from string resource ID to string (e.g. PE string tables); used to
+	// name and annotate references to string resources.
+	ResStrings map[uint32]string
+	// User overrides.
+	Overrides *Overrides
+	// Naming scheme of generated symbols.
+	Naming Naming
 }
 
 // New creates a new Disasm for accessing the assembly instructions of the given
@@ -57,12 +67,16 @@ type Disasm struct {
 //    tables.json
 //    chunks.json
 //    data.json
+//    overrides.json
 func New(file *bin.File) (*Disasm, error) {
 	// Prepare generic disassembler.
 	dis := &Disasm{
-		File:   file,
-		Tables: make(map[bin.Address][]bin.Address),
-		Chunks: make(map[bin.Address]map[bin.Address]bool),
+		File:       file,
+		Tables:     make(map[bin.Address][]bin.Address),
+		Chunks:     make(map[bin.Address]map[bin.Address]bool),
+		Names:      make(map[bin.Address]string),
+		ResStrings: make(map[uint32]string),
+		Naming:     NamingDefault,
 	}
 
 	// Parse function addresses.
@@ -77,9 +91,13 @@ func New(file *bin.File) (*Disasm, error) {
 	}
 	sort.Sort(bin.Addresses(dis.BlockAddrs))
 
-	// Add entry point function to function and basic block addresses.
-	dis.FuncAddrs = bin.InsertAddr(dis.FuncAddrs, dis.File.Entry)
-	dis.BlockAddrs = bin.InsertAddr(dis.BlockAddrs, dis.File.Entry)
+	// Add entry point function to function and basic block addresses. The entry
+	// point is omitted by binaries without one (e.g. DLLs), in which case it is
+	// not contained within an executable section.
+	if dis.File.AddressSpace().Contains(dis.File.Entry, bin.PermX) {
+		dis.FuncAddrs = bin.InsertAddr(dis.FuncAddrs, dis.File.Entry)
+		dis.BlockAddrs = bin.InsertAddr(dis.BlockAddrs, dis.File.Entry)
+	}
 
 	// Add export functions to function and basic block addresses.
 	for addr := range dis.File.Exports {
@@ -87,16 +105,50 @@ func New(file *bin.File) (*Disasm, error) {
 		dis.BlockAddrs = bin.InsertAddr(dis.BlockAddrs, addr)
 	}
 
-	// Parse jump table targets.
-	if err := parseJSON("tables.json", &dis.Tables); err != nil {
+	// Add initialization functions (e.g. TLS callbacks) to function and basic
+	// block addresses.
+	for _, addr := range dis.File.InitFuncs {
+		dis.FuncAddrs = bin.InsertAddr(dis.FuncAddrs, addr)
+		dis.BlockAddrs = bin.InsertAddr(dis.BlockAddrs, addr)
+	}
+
+	// Parse jump tables, and add their targets to basic block addresses.
+	tableAddrs, err := dis.parseTables()
+	if err != nil {
 		return nil, errors.WithStack(err)
 	}
+	for _, targets := range dis.Tables {
+		for _, target := range targets {
+			dis.BlockAddrs = bin.InsertAddr(dis.BlockAddrs, target)
+		}
+	}
 
 	// Parse function chunks.
 	if err := parseJSON("chunks.json", &dis.Chunks); err != nil {
 		return nil, errors.WithStack(err)
 	}
 
+	// Add functions of stack unwinding information (e.g. .pdata and .eh_frame)
+	// to function and basic block addresses. Fragments with chained unwinding
+	// information are function chunks of their parent function.
+	for _, u := range dis.File.Unwind {
+		dis.BlockAddrs = bin.InsertAddr(dis.BlockAddrs, u.Start)
+		if u.Parent == 0 {
+			dis.FuncAddrs = bin.InsertAddr(dis.FuncAddrs, u.Start)
+			continue
+		}
+		if dis.Chunks[u.Start] == nil {
+			dis.Chunks[u.Start] = make(map[bin.Address]bool)
+		}
+		dis.Chunks[u.Start][u.Parent] = true
+	}
+
+	// Ignore function and basic block addresses outside of executable sections
+	// (e.g. exported data); code is not decoded in non-executable sections
+	// unless overridden by the user.
+	dis.FuncAddrs = dis.execAddrs(dis.FuncAddrs)
+	dis.BlockAddrs = dis.execAddrs(dis.BlockAddrs)
+
 	// Compute fragments of the binary; distinct byte sequences of either code or
 	// data.
 	//
@@ -105,6 +157,11 @@ func New(file *bin.File) (*Disasm, error) {
 	if err := parseJSON("data.json", &dataAddrs); err != nil {
 		return nil, errors.WithStack(err)
 	}
+	sort.Sort(bin.Addresses(dataAddrs))
+	// Jump tables are data.
+	for _, tableAddr := range tableAddrs {
+		dataAddrs = bin.InsertAddr(dataAddrs, tableAddr)
+	}
 	// Append basic block addresses to fragments.
 	for _, blockAddr := range dis.BlockAddrs {
 		frag := &Fragment{
@@ -121,15 +178,56 @@ func New(file *bin.File) (*Disasm, error) {
 		}
 		dis.Frags = append(dis.Frags, frag)
 	}
-	// Sort fragments based on address.
-	less := func(i, j int) bool {
-		return dis.Frags[i].Addr < dis.Frags[j].Addr
+	dis.sortFrags()
+
+	// Parse user overrides, which take precedence over the above.
+	if err := dis.parseOverrides(); err != nil {
+		return nil, errors.WithStack(err)
 	}
-	sort.Slice(dis.Frags, less)
 
 	return dis, nil
 }
 
+// Import adds the given program annotations (e.g. exported by Ghidra or IDA) to
+// the function, basic block and data addresses of the disassembler. User
+// overrides take precedence over imported program annotations. Function and
+// basic block addresses outside of executable sections are ignored.
+func (dis *Disasm) Import(a *annot.Annotations) {
+	isData := make(map[bin.Address]bool)
+	for _, dataAddr := range dis.Overrides.Data {
+		isData[dataAddr] = true
+	}
+	for _, funcAddr := range dis.execAddrs(a.FuncAddrs) {
+		if isData[funcAddr] {
+			continue
+		}
+		dis.FuncAddrs = bin.InsertAddr(dis.FuncAddrs, funcAddr)
+	}
+	for _, blockAddr := range dis.execAddrs(a.BlockAddrs) {
+		if isData[blockAddr] {
+			continue
+		}
+		dis.addBlock(blockAddr)
+	}
+	for _, dataAddr := range a.DataAddrs {
+		frag := &Fragment{
+			Addr: dataAddr,
+			Kind: KindData,
+		}
+		dis.Frags = append(dis.Frags, frag)
+	}
+	dis.sortFrags()
+	for addr, name := range a.Names {
+		if dis.Overrides.IsOverridden(addr) {
+			continue
+		}
+		dis.Names[addr] = name
+	}
+	for id, s := range a.ResStrings {
+		dis.ResStrings[id] = s
+	}
+}
+
 // IsFunc reports whether the given address is the entry address of a function.
 func (dis *Disasm) IsFunc(addr bin.Address) bool {
 	less := func(i int) bool {
@@ -166,6 +264,28 @@ const (
 
 // ### [ Helper functions ] ####################################################
 
+// sortFrags sorts the fragments of the disassembler based on address.
+func (dis *Disasm) sortFrags() {
+	less := func(i, j int) bool {
+		return dis.Frags[i].Addr < dis.Frags[j].Addr
+	}
+	sort.Slice(dis.Frags, less)
+}
+
+// execAddrs returns the given addresses located in executable sections.
+func (dis *Disasm) execAddrs(addrs []bin.Address) []bin.Address {
+	as := dis.File.AddressSpace()
+	var execs []bin.Address
+	for _, addr := range addrs {
+		if !as.Contains(addr, bin.PermX) {
+			dbg.Printf("ignoring code address %v outside of executable sections", addr)
+			continue
+		}
+		execs = append(execs, addr)
+	}
+	return execs
+}
+
 // parseJSON parses the given JSON file and stores the result into v.
 func parseJSON(jsonPath string, v interface{}) error {
 	if !osutil.Exists(jsonPath) {
@@ -1,7 +1,6 @@
 package mips
 
 import (
-	"encoding/binary"
 	"sort"
 
 	"github.com/decomp/exp/bin"
@@ -114,7 +113,7 @@ func (dis *Disasm) DecodeBlock(entry bin.Address) (*BasicBlock, error) {
 // DecodeInst decodes and returns the instruction at the given address.
 func (dis *Disasm) DecodeInst(addr bin.Address) (*Inst, error) {
 	code := dis.File.Code(addr)
-	word := binary.LittleEndian.Uint32(code)
+	word := dis.File.ByteOrder().Uint32(code)
 	i := mips32.DecodeInstruction(word)
 	inst := &Inst{
 		Addr:        addr,
@@ -137,34 +136,18 @@ func (dis *Disasm) maxBlockLen(blockAddr bin.Address) int64 {
 
 // codeStart returns the start address of the first code section.
 func (dis *Disasm) codeStart() bin.Address {
-	var min bin.Address
-	for _, sect := range dis.File.Sections {
-		if sect.Perm&bin.PermX != 0 {
-			start := sect.Addr
-			if min == 0 || min > start {
-				min = start
-			}
-		}
-	}
-	if min == 0 {
+	start, _, ok := dis.File.AddressSpace().Range(bin.PermX)
+	if !ok {
 		panic("unable to locate start address of first code section")
 	}
-	return min
+	return start
 }
 
 // codeEnd returns the end address of the last code section.
 func (dis *Disasm) codeEnd() bin.Address {
-	var max bin.Address
-	for _, sect := range dis.File.Sections {
-		if sect.Perm&bin.PermX != 0 {
-			end := sect.Addr + bin.Address(len(sect.Data))
-			if max < end {
-				max = end
-			}
-		}
-	}
-	if max == 0 {
+	_, end, ok := dis.File.AddressSpace().Range(bin.PermX)
+	if !ok {
 		panic("unable to locate end address of last code section")
 	}
-	return max
+	return end
 }
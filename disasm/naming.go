@@ -0,0 +1,172 @@
+package disasm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// A Naming specifies the naming scheme of generated symbols; i.e. the names of
+// functions, basic blocks and global variables without a symbol name. Naming
+// schemes are used to match the labels of other tools (e.g. IDA or Ghidra), so
+// that output of different tools may be compared.
+type Naming struct {
+	// Function name prefix (e.g. "sub_").
+	Func string
+	// Basic block name prefix (e.g. "loc_").
+	Block string
+	// Data name prefix (e.g. "unk_"); used for data of unknown size when Sized
+	// is set.
+	Data string
+	// Name data based on access size (byte_, word_, dword_ and qword_).
+	Sized bool
+	// Minimum number of hexadecimal digits of addresses.
+	Digits int
+	// Name unnamed functions relative to the nearest preceding export (e.g.
+	// "DllMain+0x40").
+	Relative bool
+	// Demangle returns the demangled name of the given symbol name. The
+	// boolean return value indicates success. Symbol names are left unaltered
+	// if nil.
+	Demangle func(name string) (string, bool)
+}
+
+// Naming schemes.
+var (
+	// NamingDefault specifies the default naming scheme (e.g. f_401000,
+	// block_401010 and g_403000).
+	NamingDefault = Naming{Func: "f_", Block: "block_", Data: "g_", Digits: 6}
+	// NamingIDA specifies the naming scheme of IDA (e.g. sub_401000,
+	// loc_401010 and dword_403000).
+	NamingIDA = Naming{Func: "sub_", Block: "loc_", Data: "unk_", Sized: true, Digits: 6}
+	// NamingGhidra specifies the naming scheme of Ghidra (e.g. FUN_00401000,
+	// LAB_00401010 and DAT_00403000).
+	NamingGhidra = Naming{Func: "FUN_", Block: "LAB_", Data: "DAT_", Digits: 8}
+)
+
+// String returns the string representation of the naming scheme.
+func (n Naming) String() string {
+	var s string
+	switch {
+	case n.equal(NamingIDA):
+		s = "ida"
+	case n.equal(NamingGhidra):
+		s = "ghidra"
+	default:
+		s = "default"
+	}
+	if n.Relative {
+		s += ",relative"
+	}
+	return s
+}
+
+// Set sets the naming scheme to the given string; either "default", "ida" or
+// "ghidra", optionally followed by ",relative" to name unnamed functions
+// relative to the nearest preceding export.
+func (n *Naming) Set(s string) error {
+	parts := strings.Split(s, ",")
+	switch parts[0] {
+	case "default":
+		*n = NamingDefault
+	case "ida":
+		*n = NamingIDA
+	case "ghidra":
+		*n = NamingGhidra
+	default:
+		return errors.Errorf("invalid naming scheme %q; expected default, ida or ghidra", parts[0])
+	}
+	for _, opt := range parts[1:] {
+		switch opt {
+		case "relative":
+			n.Relative = true
+		default:
+			return errors.Errorf("invalid naming scheme option %q; expected relative", opt)
+		}
+	}
+	return nil
+}
+
+// FuncName returns the generated name of the function at the given address.
+// Exports specifies the exported functions of the binary executable, which are
+// used as anchors of relative names.
+func (n Naming) FuncName(addr bin.Address, exports map[bin.Address]string) string {
+	if n.Relative {
+		if name, anchor, ok := nearest(addr, exports); ok {
+			return fmt.Sprintf("%s+0x%X", n.SymbolName(name), uint64(addr-anchor))
+		}
+	}
+	return n.Func + n.hex(addr)
+}
+
+// BlockName returns the generated name of the basic block at the given
+// address.
+func (n Naming) BlockName(addr bin.Address) string {
+	return n.Block + n.hex(addr)
+}
+
+// DataName returns the generated name of the global variable at the given
+// address. Size specifies the size in bytes of the data, or 0 if unknown.
+func (n Naming) DataName(addr bin.Address, size int) string {
+	prefix := n.Data
+	if n.Sized {
+		switch size {
+		case 1:
+			prefix = "byte_"
+		case 2:
+			prefix = "word_"
+		case 4:
+			prefix = "dword_"
+		case 8:
+			prefix = "qword_"
+		}
+	}
+	return prefix + n.hex(addr)
+}
+
+// SymbolName returns the name of the given symbol, demangled if a demangler is
+// present.
+func (n Naming) SymbolName(name string) string {
+	if n.Demangle != nil {
+		if s, ok := n.Demangle(name); ok {
+			return s
+		}
+	}
+	return name
+}
+
+// ### [ Helper functions ] ####################################################
+
+// hex returns the hexadecimal representation of the given address, padded to
+// the number of digits of the naming scheme.
+func (n Naming) hex(addr bin.Address) string {
+	return fmt.Sprintf("%0*X", n.Digits, uint64(addr))
+}
+
+// equal reports whether the naming schemes n and m have the same prefixes and
+// number of digits.
+func (n Naming) equal(m Naming) bool {
+	return n.Func == m.Func && n.Block == m.Block && n.Data == m.Data && n.Sized == m.Sized && n.Digits == m.Digits
+}
+
+// nearest returns the name and address of the nearest symbol preceding or at
+// the given address. The boolean return value indicates success.
+func nearest(addr bin.Address, syms map[bin.Address]string) (string, bin.Address, bool) {
+	var addrs []bin.Address
+	for symAddr := range syms {
+		addrs = append(addrs, symAddr)
+	}
+	sort.Sort(bin.Addresses(addrs))
+	less := func(i int) bool {
+		return addr < addrs[i]
+	}
+	index := sort.Search(len(addrs), less)
+	if index == 0 {
+		return "", 0, false
+	}
+	anchor := addrs[index-1]
+	return syms[anchor], anchor, true
+}
@@ -0,0 +1,140 @@
+package disasm
+
+import (
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// Overrides holds user overrides, which take precedence over automatic
+// analysis and imported program annotations. Sections containing code
+// overrides are treated as executable.
+//
+// Overrides are parsed from the associated overrides.json file, which has the
+// following structure, where addresses are hexadecimal strings.
+//
+//    {
+//       "code": ["0x401000"],
+//       "data": ["0x403000"],
+//       "funcs": {"0x401000": {"name": "main", "nargs": 2}},
+//       "tables": {"0x402000": ["0x401010", "0x401020"]},
+//       "comments": {"0x401000": "entry point"}
+//    }
+type Overrides struct {
+	// Addresses forced to be code; i.e. basic block addresses.
+	Code []bin.Address `json:"code"`
+	// Addresses forced to be data.
+	Data []bin.Address `json:"data"`
+	// Map from function address to function overrides; the addresses are
+	// forced to be function addresses.
+	Funcs map[bin.Address]*FuncOverride `json:"funcs"`
+	// Map from jump table address to target addresses; the regions are forced
+	// to be jump tables.
+	Tables map[bin.Address][]bin.Address `json:"tables"`
+	// Map from address to user comment.
+	Comments map[bin.Address]string `json:"comments"`
+}
+
+// FuncOverride holds user overrides of a function.
+type FuncOverride struct {
+	// Function name; or empty if not overridden.
+	Name string `json:"name,omitempty"`
+	// Number of arguments; or nil if not overridden.
+	NArgs *int `json:"nargs,omitempty"`
+}
+
+// parseOverrides parses the associated overrides.json file and applies the
+// user overrides to the disassembler.
+func (dis *Disasm) parseOverrides() error {
+	dis.Overrides = &Overrides{
+		Funcs:    make(map[bin.Address]*FuncOverride),
+		Tables:   make(map[bin.Address][]bin.Address),
+		Comments: make(map[bin.Address]string),
+	}
+	if err := parseJSON("overrides.json", dis.Overrides); err != nil {
+		return errors.WithStack(err)
+	}
+	o := dis.Overrides
+	for _, addr := range o.Code {
+		dis.forceExec(addr)
+		dis.addBlock(addr)
+	}
+	for funcAddr, fo := range o.Funcs {
+		dis.forceExec(funcAddr)
+		dis.FuncAddrs = bin.InsertAddr(dis.FuncAddrs, funcAddr)
+		dis.addBlock(funcAddr)
+		if len(fo.Name) > 0 {
+			dis.Names[funcAddr] = fo.Name
+		}
+	}
+	for tableAddr, targets := range o.Tables {
+		dis.Tables[tableAddr] = targets
+		for _, target := range targets {
+			dis.forceExec(target)
+			dis.addBlock(target)
+		}
+		o.Data = append(o.Data, tableAddr)
+	}
+	for _, addr := range o.Data {
+		// Data overrides take precedence over code discovered by analysis.
+		dis.FuncAddrs = removeAddr(dis.FuncAddrs, addr)
+		dis.BlockAddrs = removeAddr(dis.BlockAddrs, addr)
+		found := false
+		for _, frag := range dis.Frags {
+			if frag.Addr == addr {
+				frag.Kind = KindData
+				found = true
+			}
+		}
+		if !found {
+			frag := &Fragment{
+				Addr: addr,
+				Kind: KindData,
+			}
+			dis.Frags = append(dis.Frags, frag)
+		}
+	}
+	dis.sortFrags()
+	return nil
+}
+
+// IsOverridden reports whether the symbol name of the given address is
+// overridden by the user.
+func (o *Overrides) IsOverridden(addr bin.Address) bool {
+	fo, ok := o.Funcs[addr]
+	return ok && len(fo.Name) > 0
+}
+
+// addBlock adds the given basic block address to the disassembler, unless
+// already present.
+func (dis *Disasm) addBlock(addr bin.Address) {
+	n := len(dis.BlockAddrs)
+	dis.BlockAddrs = bin.InsertAddr(dis.BlockAddrs, addr)
+	if len(dis.BlockAddrs) != n {
+		frag := &Fragment{
+			Addr: addr,
+			Kind: KindCode,
+		}
+		dis.Frags = append(dis.Frags, frag)
+	}
+}
+
+// forceExec marks the sections containing the given code address as executable
+// (e.g. code copied to a data section at runtime), unless already executable.
+func (dis *Disasm) forceExec(addr bin.Address) {
+	for _, sect := range dis.File.AddressSpace().Sections(addr) {
+		if sect.Perm&bin.PermX == 0 {
+			dbg.Printf("marking section %q at %v executable; code overridden at %v", sect.Name, sect.Addr, addr)
+			sect.Perm |= bin.PermX
+		}
+	}
+}
+
+// removeAddr removes the given address from the sorted slice of addresses.
+func removeAddr(addrs []bin.Address, addr bin.Address) []bin.Address {
+	for i, a := range addrs {
+		if a == addr {
+			return append(addrs[:i], addrs[i+1:]...)
+		}
+	}
+	return addrs
+}
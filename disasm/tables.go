@@ -0,0 +1,293 @@
+package disasm
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/decomp/exp/bin"
+	"github.com/mewkiz/pkg/osutil"
+	"github.com/pkg/errors"
+)
+
+// Maximum number of entries of jump tables without an explicit count.
+const maxTableEntries = 1024
+
+// A TableSpec specifies the layout of a jump table, from which the targets of
+// the jump table are read from the binary executable.
+//
+// Jump tables are parsed from the associated tables.json file, which holds a
+// list of jump table specifications, where addresses are hexadecimal strings.
+//
+//    [
+//       {"addr": "0x402000", "kind": "code", "count": 4},
+//       {"addr": "0x402010", "kind": "offset", "entry_size": 4, "base": "0x402010", "count": 8},
+//       {"addr": "0x402030", "kind": "index", "base": "0x402000", "count": 12}
+//    ]
+//
+// For compatibility, tables.json may also hold a map from jump table address
+// to target addresses (as generated by lst2json).
+//
+//    {"0x402000": ["0x401010", "0x401020"]}
+type TableSpec struct {
+	// Address of the jump table.
+	Addr bin.Address `json:"addr"`
+	// Kind of jump table entries.
+	Kind TableKind `json:"kind"`
+	// Size in bytes of each entry; defaults to the pointer size for code
+	// tables, 4 for offset tables, and 1 for index tables.
+	EntrySize int `json:"entry_size,omitempty"`
+	// Number of entries; or 0 to read entries up to (but not including) the
+	// first entry with an invalid target.
+	Count int `json:"count,omitempty"`
+	// Base address of entries; for offset tables, the base address added to
+	// each entry (defaults to the jump table address), and for index tables,
+	// the address of the code table indexed by each entry.
+	Base bin.Address `json:"base,omitempty"`
+}
+
+// TableKind specifies the kind of jump table entries.
+type TableKind uint8
+
+// Jump table kinds.
+const (
+	// Entries are absolute addresses of code (e.g. `jmp [table+eax*4]`).
+	TableCode TableKind = iota + 1
+	// Entries are signed offsets relative to a base address (e.g.
+	// `movsxd rax, [table+rax*4]; add rax, base; jmp rax`).
+	TableOffset
+	// Entries are unsigned indices into a code table (e.g. `movzx eax, byte
+	// [table+ecx]; jmp [base+eax*4]`).
+	TableIndex
+)
+
+// String returns the string representation of the jump table kind.
+func (kind TableKind) String() string {
+	switch kind {
+	case TableCode:
+		return "code"
+	case TableOffset:
+		return "offset"
+	case TableIndex:
+		return "index"
+	}
+	return "unknown"
+}
+
+// MarshalText encodes the jump table kind as text.
+func (kind TableKind) MarshalText() ([]byte, error) {
+	return []byte(kind.String()), nil
+}
+
+// UnmarshalText decodes the jump table kind from text; either "code", "offset"
+// or "index".
+func (kind *TableKind) UnmarshalText(text []byte) error {
+	switch s := string(text); s {
+	case "code":
+		*kind = TableCode
+	case "offset":
+		*kind = TableOffset
+	case "index":
+		*kind = TableIndex
+	default:
+		return errors.Errorf("invalid jump table kind %q; expected code, offset or index", s)
+	}
+	return nil
+}
+
+// parseTables parses the associated tables.json file, and records the targets
+// of each jump table in dis.Tables. Jump table addresses are returned, as the
+// jump tables are data.
+func (dis *Disasm) parseTables() ([]bin.Address, error) {
+	const jsonPath = "tables.json"
+	if !osutil.Exists(jsonPath) {
+		warn.Printf("unable to locate JSON file %q", jsonPath)
+		return nil, nil
+	}
+	dbg.Printf("parsing: %q", jsonPath)
+	buf, err := ioutil.ReadFile(jsonPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(buf), []byte("{")) {
+		// Map from jump table address to target addresses.
+		if err := json.Unmarshal(buf, &dis.Tables); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		var tableAddrs []bin.Address
+		for tableAddr := range dis.Tables {
+			tableAddrs = bin.InsertAddr(tableAddrs, tableAddr)
+		}
+		return tableAddrs, nil
+	}
+	var specs []*TableSpec
+	if err := json.Unmarshal(buf, &specs); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var tableAddrs []bin.Address
+	for _, spec := range specs {
+		if spec.Kind == TableIndex {
+			// The indexed code table is data and a jump table in its own right,
+			// as referenced by the indirect jump.
+			code, targets, err := dis.readIndexTable(spec)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			dis.Tables[spec.Addr] = targets
+			if _, ok := dis.Tables[spec.Base]; !ok {
+				dis.Tables[spec.Base] = code
+			}
+			tableAddrs = bin.InsertAddr(tableAddrs, spec.Addr)
+			tableAddrs = bin.InsertAddr(tableAddrs, spec.Base)
+			continue
+		}
+		targets, err := dis.ReadTable(spec)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		dis.Tables[spec.Addr] = targets
+		tableAddrs = bin.InsertAddr(tableAddrs, spec.Addr)
+	}
+	return tableAddrs, nil
+}
+
+// ReadTable reads the targets of the given jump table from the binary
+// executable. Entries are bounds checked against the section containing the
+// jump table, and targets must be located in executable sections.
+func (dis *Disasm) ReadTable(spec *TableSpec) ([]bin.Address, error) {
+	if spec.Kind == TableIndex {
+		_, targets, err := dis.readIndexTable(spec)
+		return targets, err
+	}
+	var entrySize int
+	switch spec.Kind {
+	case TableCode:
+		entrySize = dis.File.PtrSize()
+	case TableOffset:
+		entrySize = 4
+	default:
+		return nil, errors.Errorf("invalid kind of jump table at %v", spec.Addr)
+	}
+	entries, entrySize, err := dis.readEntries(spec, entrySize)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	base := spec.Base
+	if base == 0 {
+		base = spec.Addr
+	}
+	as := dis.File.AddressSpace()
+	var targets []bin.Address
+	for i, x := range entries {
+		target := bin.Address(x)
+		if spec.Kind == TableOffset {
+			// sign extend.
+			shift := uint(64 - 8*entrySize)
+			target = base + bin.Address(int64(x<<shift)>>shift)
+		}
+		if !as.Contains(target, bin.PermX) {
+			if spec.Count == 0 {
+				// end of jump table.
+				break
+			}
+			return nil, errors.Errorf("target %v of entry %d of jump table at %v not in executable section", target, i, spec.Addr)
+		}
+		targets = append(targets, target)
+	}
+	if len(targets) == 0 {
+		return nil, errors.Errorf("jump table at %v has no valid entries", spec.Addr)
+	}
+	return targets, nil
+}
+
+// readIndexTable reads the given index table from the binary executable, and
+// returns the indexed code table (up to the largest index) and the targets of
+// the index table.
+func (dis *Disasm) readIndexTable(spec *TableSpec) (code, targets []bin.Address, err error) {
+	if spec.Count == 0 {
+		return nil, nil, errors.Errorf("missing entry count of index table at %v", spec.Addr)
+	}
+	if spec.Base == 0 {
+		return nil, nil, errors.Errorf("missing code table address of index table at %v", spec.Addr)
+	}
+	indices, _, err := dis.readEntries(spec, 1)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	var max uint64
+	for _, index := range indices {
+		if index > max {
+			max = index
+		}
+	}
+	if max >= maxTableEntries {
+		return nil, nil, errors.Errorf("index %d of index table at %v out of bounds", max, spec.Addr)
+	}
+	codeSpec := &TableSpec{
+		Addr:  spec.Base,
+		Kind:  TableCode,
+		Count: int(max) + 1,
+	}
+	code, err = dis.ReadTable(codeSpec)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	for _, index := range indices {
+		targets = append(targets, code[index])
+	}
+	return code, targets, nil
+}
+
+// readEntries reads the raw entries of the given jump table from the binary
+// executable, using the default entry size if not specified, and returns the
+// entries and entry size. All entries of the section are read (up to
+// maxTableEntries) if the count is not specified.
+func (dis *Disasm) readEntries(spec *TableSpec, defaultSize int) ([]uint64, int, error) {
+	entrySize := spec.EntrySize
+	if entrySize == 0 {
+		entrySize = defaultSize
+	}
+	switch entrySize {
+	case 1, 2, 4, 8:
+		// valid entry size.
+	default:
+		return nil, 0, errors.Errorf("invalid entry size %d of jump table at %v", entrySize, spec.Addr)
+	}
+	data, ok := dis.File.AddressSpace().Bytes(spec.Addr, bin.PermR)
+	if !ok {
+		return nil, 0, errors.Errorf("unable to locate jump table at %v", spec.Addr)
+	}
+	n := spec.Count
+	switch {
+	case n < 0:
+		return nil, 0, errors.Errorf("invalid entry count %d of jump table at %v", n, spec.Addr)
+	case n == 0:
+		n = len(data) / entrySize
+		if n > maxTableEntries {
+			n = maxTableEntries
+		}
+	case n*entrySize > len(data):
+		return nil, 0, errors.Errorf("jump table at %v with %d entries of %d bytes extends past end of section", spec.Addr, n, entrySize)
+	}
+	entries := make([]uint64, n)
+	for i := range entries {
+		entries[i] = readEntry(dis.File, data[i*entrySize:], entrySize)
+	}
+	return entries, entrySize, nil
+}
+
+// readEntry reads an unsigned integer of the given size in bytes from buf,
+// using the byte order of the binary executable.
+func readEntry(file *bin.File, buf []byte, size int) uint64 {
+	order := file.ByteOrder()
+	switch size {
+	case 1:
+		return uint64(buf[0])
+	case 2:
+		return uint64(order.Uint16(buf))
+	case 4:
+		return uint64(order.Uint32(buf))
+	default:
+		return order.Uint64(buf)
+	}
+}
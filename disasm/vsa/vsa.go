@@ -0,0 +1,449 @@
+// Package vsa implements the abstract domain of value-set analysis; value sets
+// of explicit values and strided intervals.
+//
+// Value-set analysis over-approximates the set of values each register and
+// memory location may hold at each program point. The abstract domain is
+// architecture independent; the analysis itself (i.e. the transfer functions of
+// instructions) is implemented by the disassembler of each architecture.
+package vsa
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+	"strings"
+)
+
+// Maximum number of values of explicit value sets; larger value sets are
+// approximated by strided intervals.
+const maxSetSize = 512
+
+// --- [ Strided interval ] ----------------------------------------------------
+
+// A StridedInterval is a set of values s[lo, hi], containing every value lo +
+// n*s where lo + n*s <= hi. A stride of 0 denotes the singleton set {lo}.
+type StridedInterval struct {
+	// Stride.
+	Stride uint64
+	// Lower bound (inclusive).
+	Lo uint64
+	// Upper bound (inclusive).
+	Hi uint64
+}
+
+// String returns the string representation of the strided interval.
+func (si StridedInterval) String() string {
+	return fmt.Sprintf("%d[0x%X, 0x%X]", si.Stride, si.Lo, si.Hi)
+}
+
+// Len returns the number of values in the strided interval.
+func (si StridedInterval) Len() uint64 {
+	if si.Stride == 0 {
+		return 1
+	}
+	n := (si.Hi-si.Lo)/si.Stride + 1
+	if n == 0 {
+		// overflow.
+		return math.MaxUint64
+	}
+	return n
+}
+
+// Contains reports whether the strided interval contains the given value.
+func (si StridedInterval) Contains(v uint64) bool {
+	if v < si.Lo || v > si.Hi {
+		return false
+	}
+	if si.Stride == 0 {
+		return v == si.Lo
+	}
+	return (v-si.Lo)%si.Stride == 0
+}
+
+// join returns the smallest strided interval containing both a and b.
+func (a StridedInterval) join(b StridedInterval) StridedInterval {
+	lo, hi := a.Lo, a.Hi
+	if b.Lo < lo {
+		lo = b.Lo
+	}
+	if b.Hi > hi {
+		hi = b.Hi
+	}
+	diff := a.Lo - b.Lo
+	if b.Lo > a.Lo {
+		diff = b.Lo - a.Lo
+	}
+	stride := gcd(gcd(a.Stride, b.Stride), diff)
+	if lo == hi {
+		stride = 0
+	}
+	return StridedInterval{Stride: stride, Lo: lo, Hi: hi}
+}
+
+// --- [ Value set ] -----------------------------------------------------------
+
+// A ValueSet is an abstract set of values, as computed by value-set analysis.
+// Small value sets are represented explicitly, and larger value sets are
+// approximated by strided intervals. The zero value is the unknown value set,
+// containing every value.
+type ValueSet struct {
+	// Value set kind.
+	kind valueSetKind
+	// Explicit values in ascending order; used by explicit value sets.
+	vals []uint64
+	// Strided interval; used by strided interval value sets.
+	si StridedInterval
+}
+
+// valueSetKind specifies the kind of a value set.
+type valueSetKind uint8
+
+// Value set kinds.
+const (
+	// Unknown value set; contains every value.
+	kindTop valueSetKind = iota
+	// Explicit value set.
+	kindSet
+	// Strided interval value set.
+	kindInterval
+)
+
+// Top returns the unknown value set, containing every value.
+func Top() ValueSet {
+	return ValueSet{}
+}
+
+// Const returns the singleton value set containing the given value.
+func Const(v uint64) ValueSet {
+	return ValueSet{kind: kindSet, vals: []uint64{v}}
+}
+
+// Values returns the value set containing the given values.
+func Values(vals ...uint64) ValueSet {
+	vs := ValueSet{kind: kindSet}
+	for _, v := range vals {
+		vs.vals = insertValue(vs.vals, v)
+	}
+	return vs.normalize()
+}
+
+// Interval returns the value set of the given strided interval.
+func Interval(stride, lo, hi uint64) ValueSet {
+	if lo > hi {
+		panic(fmt.Errorf("invalid strided interval; lower bound 0x%X larger than upper bound 0x%X", lo, hi))
+	}
+	if lo == hi {
+		stride = 0
+	} else if stride == 0 {
+		stride = 1
+	}
+	vs := ValueSet{kind: kindInterval, si: StridedInterval{Stride: stride, Lo: lo, Hi: hi}}
+	return vs.normalize()
+}
+
+// String returns the string representation of the value set.
+func (vs ValueSet) String() string {
+	switch vs.kind {
+	case kindSet:
+		var ss []string
+		for _, v := range vs.vals {
+			ss = append(ss, fmt.Sprintf("0x%X", v))
+		}
+		return "{" + strings.Join(ss, ", ") + "}"
+	case kindInterval:
+		return vs.si.String()
+	}
+	return "⊤"
+}
+
+// IsTop reports whether the value set is unknown; i.e. contains every value.
+func (vs ValueSet) IsTop() bool {
+	return vs.kind == kindTop
+}
+
+// Const returns the value of a singleton value set. The boolean return value
+// indicates success.
+func (vs ValueSet) Const() (uint64, bool) {
+	if vs.kind == kindSet && len(vs.vals) == 1 {
+		return vs.vals[0], true
+	}
+	return 0, false
+}
+
+// Values returns the values of the value set, if the value set contains at most
+// max values. The boolean return value indicates success.
+func (vs ValueSet) Values(max int) ([]uint64, bool) {
+	switch vs.kind {
+	case kindSet:
+		if len(vs.vals) > max {
+			return nil, false
+		}
+		return vs.vals, true
+	case kindInterval:
+		if vs.si.Len() > uint64(max) {
+			return nil, false
+		}
+		var vals []uint64
+		for v := vs.si.Lo; ; v += vs.si.Stride {
+			vals = append(vals, v)
+			if v >= vs.si.Hi || vs.si.Stride == 0 {
+				break
+			}
+		}
+		return vals, true
+	}
+	return nil, false
+}
+
+// Interval returns the strided interval approximating the value set. The
+// boolean return value indicates success; i.e. that the value set is bounded.
+func (vs ValueSet) Interval() (StridedInterval, bool) {
+	switch vs.kind {
+	case kindSet:
+		si := StridedInterval{Lo: vs.vals[0], Hi: vs.vals[0]}
+		for _, v := range vs.vals[1:] {
+			si = si.join(StridedInterval{Lo: v, Hi: v})
+		}
+		return si, true
+	case kindInterval:
+		return vs.si, true
+	}
+	return StridedInterval{}, false
+}
+
+// Equal reports whether the value sets are identical.
+func (vs ValueSet) Equal(other ValueSet) bool {
+	if vs.kind != other.kind {
+		return false
+	}
+	switch vs.kind {
+	case kindSet:
+		if len(vs.vals) != len(other.vals) {
+			return false
+		}
+		for i := range vs.vals {
+			if vs.vals[i] != other.vals[i] {
+				return false
+			}
+		}
+		return true
+	case kindInterval:
+		return vs.si == other.si
+	}
+	return true
+}
+
+// Join returns the union of the value sets.
+func (vs ValueSet) Join(other ValueSet) ValueSet {
+	if vs.IsTop() || other.IsTop() {
+		return Top()
+	}
+	if vs.kind == kindSet && other.kind == kindSet {
+		vals := append([]uint64(nil), vs.vals...)
+		for _, v := range other.vals {
+			vals = insertValue(vals, v)
+		}
+		return ValueSet{kind: kindSet, vals: vals}.normalize()
+	}
+	a, _ := vs.Interval()
+	b, _ := other.Interval()
+	return ValueSet{kind: kindInterval, si: a.join(b)}.normalize()
+}
+
+// Add returns the value set of pairwise sums.
+func (vs ValueSet) Add(other ValueSet) ValueSet {
+	return vs.binop(other, func(x, y uint64) uint64 { return x + y }, func(a, b StridedInterval) (StridedInterval, bool) {
+		lo, c1 := bits.Add64(a.Lo, b.Lo, 0)
+		hi, c2 := bits.Add64(a.Hi, b.Hi, 0)
+		if c1 != 0 || c2 != 0 {
+			return StridedInterval{}, false
+		}
+		return StridedInterval{Stride: gcd(a.Stride, b.Stride), Lo: lo, Hi: hi}, true
+	})
+}
+
+// Sub returns the value set of pairwise differences.
+func (vs ValueSet) Sub(other ValueSet) ValueSet {
+	return vs.binop(other, func(x, y uint64) uint64 { return x - y }, func(a, b StridedInterval) (StridedInterval, bool) {
+		if b.Stride != 0 || a.Lo < b.Lo {
+			return StridedInterval{}, false
+		}
+		return StridedInterval{Stride: a.Stride, Lo: a.Lo - b.Lo, Hi: a.Hi - b.Lo}, true
+	})
+}
+
+// Mul returns the value set of pairwise products.
+func (vs ValueSet) Mul(other ValueSet) ValueSet {
+	return vs.binop(other, func(x, y uint64) uint64 { return x * y }, func(a, b StridedInterval) (StridedInterval, bool) {
+		if b.Stride != 0 {
+			return StridedInterval{}, false
+		}
+		c := b.Lo
+		hiHi, hi := bits.Mul64(a.Hi, c)
+		if hiHi != 0 {
+			return StridedInterval{}, false
+		}
+		return StridedInterval{Stride: a.Stride * c, Lo: a.Lo * c, Hi: hi}, true
+	})
+}
+
+// And returns the value set of pairwise bitwise AND.
+func (vs ValueSet) And(other ValueSet) ValueSet {
+	result := vs.binop(other, func(x, y uint64) uint64 { return x & y }, func(a, b StridedInterval) (StridedInterval, bool) {
+		return StridedInterval{}, false
+	})
+	if result.IsTop() {
+		// x & mask is within [0, mask].
+		if mask, ok := other.Const(); ok {
+			return Interval(1, 0, mask)
+		}
+		if mask, ok := vs.Const(); ok {
+			return Interval(1, 0, mask)
+		}
+	}
+	return result
+}
+
+// Truncate returns the value set truncated to the given bit size.
+func (vs ValueSet) Truncate(size int) ValueSet {
+	if size >= 64 {
+		return vs
+	}
+	mask := uint64(1)<<uint(size) - 1
+	if si, ok := vs.Interval(); ok && si.Hi <= mask {
+		return vs
+	}
+	return vs.binop(Const(mask), func(x, y uint64) uint64 { return x & y }, func(a, b StridedInterval) (StridedInterval, bool) {
+		return StridedInterval{}, false
+	})
+}
+
+// SignExtend returns the value set sign-extended from the given bit size to 64
+// bits.
+func (vs ValueSet) SignExtend(size int) ValueSet {
+	if size >= 64 {
+		return vs
+	}
+	signBit := uint64(1) << uint(size-1)
+	if si, ok := vs.Interval(); ok && si.Hi < signBit {
+		// non-negative values.
+		return vs
+	}
+	ext := func(x uint64) uint64 {
+		if x&signBit != 0 {
+			return x | ^(signBit<<1 - 1)
+		}
+		return x
+	}
+	return vs.binop(Const(0), func(x, _ uint64) uint64 { return ext(x) }, func(a, b StridedInterval) (StridedInterval, bool) {
+		return StridedInterval{}, false
+	})
+}
+
+// Narrow returns the intersection of the value set and the interval [lo, hi].
+func (vs ValueSet) Narrow(lo, hi uint64) ValueSet {
+	switch vs.kind {
+	case kindSet:
+		var vals []uint64
+		for _, v := range vs.vals {
+			if lo <= v && v <= hi {
+				vals = append(vals, v)
+			}
+		}
+		if len(vals) == 0 {
+			// infeasible; keep unknown.
+			return Top()
+		}
+		return ValueSet{kind: kindSet, vals: vals}
+	case kindInterval:
+		si := vs.si
+		if si.Lo < lo {
+			if si.Stride == 0 {
+				return Top()
+			}
+			// Round up to the next value of the strided interval.
+			n := (lo - si.Lo + si.Stride - 1) / si.Stride
+			si.Lo += n * si.Stride
+		}
+		if si.Hi > hi {
+			si.Hi = hi
+		}
+		if si.Lo > si.Hi {
+			return Top()
+		}
+		if si.Stride != 0 {
+			si.Hi -= (si.Hi - si.Lo) % si.Stride
+		}
+		return Interval(si.Stride, si.Lo, si.Hi)
+	}
+	return Interval(1, lo, hi)
+}
+
+// binop returns the value set resulting from applying the given operation
+// pairwise to the values of the value sets, or to their strided interval
+// approximations if too large.
+func (vs ValueSet) binop(other ValueSet, op func(x, y uint64) uint64, siOp func(a, b StridedInterval) (StridedInterval, bool)) ValueSet {
+	if vs.IsTop() || other.IsTop() {
+		return Top()
+	}
+	if vs.kind == kindSet && other.kind == kindSet && len(vs.vals)*len(other.vals) <= 4*maxSetSize {
+		var vals []uint64
+		for _, x := range vs.vals {
+			for _, y := range other.vals {
+				vals = insertValue(vals, op(x, y))
+			}
+		}
+		return ValueSet{kind: kindSet, vals: vals}.normalize()
+	}
+	a, _ := vs.Interval()
+	b, _ := other.Interval()
+	si, ok := siOp(a, b)
+	if !ok {
+		return Top()
+	}
+	return ValueSet{kind: kindInterval, si: si}.normalize()
+}
+
+// normalize returns the canonical representation of the value set; explicit
+// value sets larger than maxSetSize are approximated by strided intervals, and
+// small strided intervals are represented explicitly.
+func (vs ValueSet) normalize() ValueSet {
+	switch vs.kind {
+	case kindSet:
+		if len(vs.vals) > maxSetSize {
+			si, _ := vs.Interval()
+			return ValueSet{kind: kindInterval, si: si}
+		}
+	case kindInterval:
+		if vals, ok := vs.Values(maxSetSize); ok {
+			return ValueSet{kind: kindSet, vals: vals}
+		}
+	}
+	return vs
+}
+
+// ### [ Helper functions ] ####################################################
+
+// insertValue inserts the given value within the sorted slice of values.
+func insertValue(vals []uint64, v uint64) []uint64 {
+	less := func(i int) bool {
+		return v <= vals[i]
+	}
+	index := sort.Search(len(vals), less)
+	if index < len(vals) && vals[index] == v {
+		return vals
+	}
+	vals = append(vals, 0)
+	copy(vals[index+1:], vals[index:])
+	vals[index] = v
+	return vals
+}
+
+// gcd returns the greatest common divisor of a and b.
+func gcd(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
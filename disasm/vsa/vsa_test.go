@@ -0,0 +1,24 @@
+package vsa
+
+import "testing"
+
+func TestValueSet(t *testing.T) {
+	golden := []struct {
+		vs   ValueSet
+		want string
+	}{
+		{vs: Const(1).Join(Const(3)), want: "{0x1, 0x3}"},
+		{vs: Interval(1, 0, 5).Mul(Const(8)).Add(Const(0x402000)), want: "{0x402000, 0x402008, 0x402010, 0x402018, 0x402020, 0x402028}"},
+		{vs: Interval(4, 0, 0x10000).Add(Const(2)), want: "4[0x2, 0x10002]"},
+		{vs: Top().Narrow(0, 2), want: "{0x0, 0x1, 0x2}"},
+		{vs: Interval(4, 0, 0x10000).Narrow(3, 0x1000), want: "4[0x4, 0x1000]"},
+		{vs: Top().And(Const(3)), want: "{0x0, 0x1, 0x2, 0x3}"},
+		{vs: Const(0xFFFFFFFF).SignExtend(32), want: "{0xFFFFFFFFFFFFFFFF}"},
+		{vs: Const(1).Join(Top()), want: "⊤"},
+	}
+	for _, g := range golden {
+		if got := g.vs.String(); got != g.want {
+			t.Errorf("value set mismatch; expected %q, got %q", g.want, got)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package x86
+
+import (
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// Anti-disassembly pattern neutralization.
+//
+// The following classic anti-disassembly tricks are detected and normalized
+// during decoding, so that the control flow graph contains the intended code.
+//
+//    call $+5                  ; call to next instruction (get PC)
+//       -> push next
+//
+//    jz X                      ; opaque predicate, complementary conditional
+//    jnz X                     ; jumps to the same target; followed by junk
+//       -> jmp X
+//
+//    xor eax, eax              ; opaque predicate, constant condition
+//    jz X
+//       -> jmp X
+//
+// Rewritten instructions replace the decoded instructions of the basic block,
+// which are retained in BasicBlock.Rewritten.
+//
+// Jumps into the middle of instructions (overlapping instructions) are
+// supported by decoding each basic block independently, and are reported by
+// DecodeFunc.
+
+// neutralizeInst returns the normalized form of the given instruction if part
+// of an anti-disassembly pattern, and nil otherwise.
+func (dis *Disasm) neutralizeInst(inst *Inst) *Inst {
+	// Call to next instruction; replace with push of return address.
+	if inst.Op == x86asm.CALL {
+		if rel, ok := inst.Args[0].(x86asm.Rel); ok && rel == 0 {
+			dbg.Printf("anti-disassembly: call to next instruction at %v", inst.Addr)
+			return rewriteInst(inst)
+		}
+	}
+	return nil
+}
+
+// neutralizeTerm returns the normalized form of the terminator of the given
+// basic block if part of an anti-disassembly pattern, and nil otherwise.
+func (dis *Disasm) neutralizeTerm(block *BasicBlock) *Inst {
+	term := block.Term
+	if term.IsDummyTerm() {
+		return nil
+	}
+	rel, ok := term.Args[0].(x86asm.Rel)
+	if !ok {
+		return nil
+	}
+	inv, ok := inverseJcc[term.Op]
+	if !ok {
+		return nil
+	}
+	next := term.Addr + bin.Address(term.Len)
+	target := next + bin.Address(rel)
+	// Complementary conditional jumps to the same target.
+	if succ, err := dis.DecodeInst(next); err == nil && succ.Op == inv {
+		if succRel, ok := succ.Args[0].(x86asm.Rel); ok {
+			succNext := next + bin.Address(succ.Len)
+			if succNext+bin.Address(succRel) == target {
+				dbg.Printf("anti-disassembly: opaque predicate at %v", term.Addr)
+				return rewriteInst(term)
+			}
+		}
+	}
+	// Constant condition; zero flag set by XOR of register with itself.
+	if len(block.Insts) > 0 && term.Op == x86asm.JE {
+		last := block.Insts[len(block.Insts)-1]
+		if last.Op == x86asm.XOR && last.Args[0] != nil && last.Args[0] == last.Args[1] {
+			if _, ok := last.Args[0].(x86asm.Reg); ok {
+				dbg.Printf("anti-disassembly: constant condition at %v", term.Addr)
+				return rewriteInst(term)
+			}
+		}
+	}
+	return nil
+}
+
+// rewriteInst returns the normalized form of the given instruction of an
+// anti-disassembly pattern; a push of the return address for calls to the next
+// instruction, and an unconditional jump for conditional jumps. The given
+// instruction is left unmodified, as the normalized form is determined by the
+// instruction alone once the pattern has been detected.
+func rewriteInst(inst *Inst) *Inst {
+	i := inst.Inst
+	if i.Op == x86asm.CALL {
+		next := inst.Addr + bin.Address(inst.Len)
+		i.Op = x86asm.PUSH
+		i.Args = x86asm.Args{x86asm.Imm(next)}
+	} else {
+		i.Op = x86asm.JMP
+	}
+	return &Inst{Addr: inst.Addr, Inst: i}
+}
+
+// inverseJcc maps from conditional jump instructions to their inverse.
+var inverseJcc = map[x86asm.Op]x86asm.Op{
+	x86asm.JA:  x86asm.JBE,
+	x86asm.JAE: x86asm.JB,
+	x86asm.JB:  x86asm.JAE,
+	x86asm.JBE: x86asm.JA,
+	x86asm.JE:  x86asm.JNE,
+	x86asm.JG:  x86asm.JLE,
+	x86asm.JGE: x86asm.JL,
+	x86asm.JL:  x86asm.JGE,
+	x86asm.JLE: x86asm.JG,
+	x86asm.JNE: x86asm.JE,
+	x86asm.JNO: x86asm.JO,
+	x86asm.JNP: x86asm.JP,
+	x86asm.JNS: x86asm.JS,
+	x86asm.JO:  x86asm.JNO,
+	x86asm.JP:  x86asm.JNP,
+	x86asm.JS:  x86asm.JNS,
+}
+
+// reportOverlaps reports basic blocks of the given function which start in the
+// middle of instructions of other basic blocks.
+func (dis *Disasm) reportOverlaps(f *Func) {
+	var insts []*Inst
+	var blockAddrs bin.Addresses
+	for blockAddr, block := range f.Blocks {
+		insts = append(insts, block.Insts...)
+		if !block.Term.IsDummyTerm() {
+			insts = append(insts, block.Term)
+		}
+		blockAddrs = append(blockAddrs, blockAddr)
+	}
+	less := func(i, j int) bool {
+		return insts[i].Addr < insts[j].Addr
+	}
+	sort.Slice(insts, less)
+	sort.Sort(blockAddrs)
+	// Sweep basic block addresses in ascending order, tracking the instruction
+	// extending the furthest among the instructions preceding each basic block.
+	var (
+		// Instruction extending the furthest; or nil if none precedes.
+		last *Inst
+		// End address of last.
+		end bin.Address
+	)
+	i := 0
+	for _, blockAddr := range blockAddrs {
+		for ; i < len(insts) && insts[i].Addr < blockAddr; i++ {
+			if instEnd := insts[i].Addr + bin.Address(insts[i].Len); instEnd > end {
+				last, end = insts[i], instEnd
+			}
+		}
+		if last != nil && blockAddr < end {
+			warn.Printf("anti-disassembly: basic block at %v starts in the middle of instruction at %v", blockAddr, last.Addr)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package x86
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// Function boundary validation.
+//
+// Function addresses from associated files, symbols, program annotations or
+// heuristics (e.g. superset disassembly) may be incorrect. A spurious function
+// address inside the body of another function truncates the body of that
+// function, which then falls through into the spurious function.
+//
+//    f:                         ; function
+//       ...
+//       jz    g
+//    g:                         ; spurious function; basic block of f
+//       ...
+//       ret
+//
+// The recovered body of each function is validated by the following sanity
+// checks of its terminators.
+//
+//    fallthrough    body falls through into the next function
+//    gap            undecodable bytes within the body
+//    noreturn       no reachable return (ret or tail call)
+//
+// Spurious functions into which other functions fall through are corrected
+// automatically, by merging them into the preceding function, unless
+// referenced as functions (e.g. called, exported or overridden by the user).
+// Other violations are reported, as the correct boundaries are ambiguous.
+
+// Maximum number of rounds of boundary correction.
+const maxBoundaryRounds = 16
+
+// FixBoundaries validates the function boundaries of the disassembler, merges
+// spurious functions into the function falling through into them, and returns
+// the violations and corrections as issues of kind IssueBoundary.
+//
+// FixBoundaries should be invoked during initialization, after all function
+// addresses are known and before functions are decoded.
+func (dis *Disasm) FixBoundaries() []*Issue {
+	var issues []*Issue
+	for round := 0; round < maxBoundaryRounds; round++ {
+		fs, decodeIssues := dis.decodeChecked(dis.FuncAddrs)
+		// Cross-references of all functions, to locate functions which are
+		// referenced from outside of the function falling through into them.
+		xrefs := dis.Xrefs(fs)
+		merged := make(map[bin.Address]bin.Address)
+		var roundIssues []*Issue
+		for _, f := range fs {
+			for _, next := range dis.fallthroughFuncs(f) {
+				if _, ok := merged[next]; ok {
+					continue
+				}
+				if reason, ok := dis.isReferencedFunc(next, f, xrefs); ok {
+					msg := fmt.Sprintf("function falls through into function at %v; not merged as %s", next, reason)
+					roundIssues = append(roundIssues, &Issue{Kind: IssueBoundary, Addr: next, Func: f.Addr, Msg: msg})
+					continue
+				}
+				merged[next] = f.Addr
+			}
+		}
+		if len(merged) == 0 {
+			// Boundaries stable; report remaining violations.
+			issues = append(issues, decodeIssues...)
+			issues = append(issues, roundIssues...)
+			for _, f := range fs {
+				issues = append(issues, dis.gapIssues(f)...)
+				if issue, ok := dis.noReturnIssue(f); ok {
+					issues = append(issues, issue)
+				}
+			}
+			break
+		}
+		for next, funcAddr := range merged {
+			dbg.Printf("merging spurious function at %v into function at %v", next, funcAddr)
+			dis.FuncAddrs = removeAddr(dis.FuncAddrs, next)
+			msg := fmt.Sprintf("merged spurious function at %v into function at %v, which falls through into it", next, funcAddr)
+			issues = append(issues, &Issue{Kind: IssueBoundary, Addr: next, Func: funcAddr, Msg: msg})
+		}
+	}
+	SortIssues(issues)
+	return issues
+}
+
+// decodeChecked decodes the functions at the given addresses, and returns the
+// decoded functions and the issues of functions which failed to decode (e.g.
+// undecodable instructions, or jumps to non-function addresses).
+func (dis *Disasm) decodeChecked(funcAddrs []bin.Address) ([]*Func, []*Issue) {
+	var fs []*Func
+	var issues []*Issue
+	for _, funcAddr := range funcAddrs {
+		if _, ok := dis.Thunks[funcAddr]; ok {
+			continue
+		}
+		f, err := dis.tryDecodeFunc(funcAddr)
+		if err != nil {
+			addr := funcAddr
+			if e, ok := bin.AsPosError(err); ok {
+				addr = e.Addr
+			}
+			msg := fmt.Sprintf("undecodable function body; %v", errors.Cause(err))
+			issues = append(issues, &Issue{Kind: IssueBoundary, Addr: addr, Func: funcAddr, Msg: msg})
+			continue
+		}
+		fs = append(fs, f)
+	}
+	return fs, issues
+}
+
+// tryDecodeFunc decodes the function at the given address, recovering from
+// panics of unsupported or inconsistent control flow.
+func (dis *Disasm) tryDecodeFunc(funcAddr bin.Address) (f *Func, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = errors.Errorf("%v", e)
+		}
+	}()
+	return dis.DecodeFunc(funcAddr)
+}
+
+// fallthroughFuncs returns the addresses of the functions into which the given
+// function falls through; i.e. the fall-through successors of its basic blocks
+// which are function entry addresses.
+func (dis *Disasm) fallthroughFuncs(f *Func) []bin.Address {
+	var funcAddrs []bin.Address
+	for _, block := range f.Blocks {
+		term := block.Term
+		var next bin.Address
+		switch {
+		case term.IsDummyTerm():
+			next = term.Addr
+		case term.Op == x86asm.JMP || term.Op == x86asm.LJMP || term.Op == x86asm.RET:
+			continue
+		default:
+			// conditional branch or loop.
+			next = term.Addr + bin.Address(term.Len)
+		}
+		if next != f.Addr && dis.IsFunc(next) {
+			if _, ok := dis.File.Imports[next]; !ok {
+				funcAddrs = bin.InsertAddr(funcAddrs, next)
+			}
+		}
+	}
+	return funcAddrs
+}
+
+// isReferencedFunc reports whether the given function address is referenced as
+// a function, other than by fall through or jumps from the given function, and
+// returns the reason.
+func (dis *Disasm) isReferencedFunc(funcAddr bin.Address, from *Func, xrefs *Xrefs) (string, bool) {
+	switch {
+	case funcAddr == dis.File.Entry:
+		return "entry point", true
+	case dis.Overrides != nil && dis.Overrides.Funcs[funcAddr] != nil:
+		return "overridden by user", true
+	case dis.Extracted[funcAddr]:
+		return "extracted shared tail", true
+	}
+	if _, ok := dis.File.Exports[funcAddr]; ok {
+		return "exported", true
+	}
+	for _, addr := range dis.File.InitFuncs {
+		if addr == funcAddr {
+			return "initialization function", true
+		}
+	}
+	for _, xref := range xrefs.To(funcAddr) {
+		if xref.Kind == XrefCall {
+			return fmt.Sprintf("called from %v", xref.From), true
+		}
+		if !from.contains(xref.From) {
+			return fmt.Sprintf("referenced from %v", xref.From), true
+		}
+	}
+	return "", false
+}
+
+// gapIssues returns the issues of undecodable bytes between the basic blocks of
+// the given function. Alignment padding (int3, nop and zero bytes) is ignored.
+func (dis *Disasm) gapIssues(f *Func) []*Issue {
+	var blocks []*BasicBlock
+	for _, block := range f.Blocks {
+		blocks = append(blocks, block)
+	}
+	less := func(i, j int) bool {
+		return blocks[i].Addr < blocks[j].Addr
+	}
+	sort.Slice(blocks, less)
+	var issues []*Issue
+	for i := 0; i+1 < len(blocks); i++ {
+		start := blocks[i].Term.Addr + bin.Address(blocks[i].Term.Len)
+		end := blocks[i+1].Addr
+		if end <= start || dis.containsFunc(start, end) {
+			// no gap, overlapping basic blocks or function chunk.
+			continue
+		}
+		code, ok := dis.File.AddressSpace().Bytes(start, bin.PermX)
+		if !ok || len(code) < int(end-start) {
+			continue
+		}
+		code = code[:end-start]
+		for off := 0; off < len(code); {
+			switch code[off] {
+			case 0xCC, 0x90, 0x00:
+				// alignment padding.
+				off++
+				continue
+			}
+			addr := start + bin.Address(off)
+			inst, err := dis.DecodeInst(addr)
+			if err != nil {
+				msg := fmt.Sprintf("undecodable bytes in gap between basic blocks at %v and %v", blocks[i].Addr, blocks[i+1].Addr)
+				issues = append(issues, &Issue{Kind: IssueBoundary, Addr: addr, Func: f.Addr, Msg: msg})
+				break
+			}
+			off += inst.Len
+		}
+	}
+	return issues
+}
+
+// noReturnIssue returns an issue if the given function has no reachable return;
+// i.e. no ret instruction, no tail call and no unresolved indirect jump. The
+// boolean return value indicates success.
+func (dis *Disasm) noReturnIssue(f *Func) (*Issue, bool) {
+	for _, block := range f.Blocks {
+		term := block.Term
+		switch {
+		case term.IsDummyTerm():
+			if !f.contains(term.Addr) {
+				// fall through out of function body.
+				return nil, false
+			}
+		case term.Op == x86asm.RET, term.Op == x86asm.LJMP:
+			// return or far jump (possibly switching processor mode).
+			return nil, false
+		case term.Op == x86asm.JMP:
+			if !dis.isResolved(term) {
+				return nil, false
+			}
+			targets, ok := dis.Indirect[term.Addr]
+			if !ok {
+				targets = dis.Addrs(term.Args[0], term.Addr, term.Addr+bin.Address(term.Len))
+			}
+			for _, target := range targets {
+				if _, ok := f.Blocks[target]; !ok {
+					// tail call.
+					return nil, false
+				}
+			}
+		}
+	}
+	msg := "no reachable return (ret or tail call); function is non-returning or its body is truncated"
+	return &Issue{Kind: IssueBoundary, Addr: f.Addr, Func: f.Addr, Msg: msg}, true
+}
+
+// ### [ Helper functions ] ####################################################
+
+// contains reports whether the given address is the address of an instruction
+// of the function.
+func (f *Func) contains(addr bin.Address) bool {
+	for _, block := range f.Blocks {
+		if block.Addr <= addr && addr <= block.Term.Addr {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFunc reports whether a function entry address is within [start,
+// end).
+func (dis *Disasm) containsFunc(start, end bin.Address) bool {
+	less := func(i int) bool {
+		return start <= dis.FuncAddrs[i]
+	}
+	index := sort.Search(len(dis.FuncAddrs), less)
+	return index < len(dis.FuncAddrs) && dis.FuncAddrs[index] < end
+}
+
+// removeAddr removes the given address from the sorted slice of addresses.
+func removeAddr(addrs []bin.Address, addr bin.Address) []bin.Address {
+	for i, a := range addrs {
+		if a == addr {
+			return append(addrs[:i], addrs[i+1:]...)
+		}
+	}
+	return addrs
+}
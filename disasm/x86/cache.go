@@ -0,0 +1,80 @@
+package x86
+
+import (
+	"sync"
+
+	"github.com/decomp/exp/bin"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// nshards specifies the number of shards of the instruction cache.
+const nshards = 64
+
+// instCache is a concurrency-safe cache of decoded instructions, mapped from
+// instruction address. The cache is sharded by address to reduce lock
+// contention between decoders running in parallel.
+type instCache struct {
+	// Cache shards.
+	shards [nshards]instShard
+}
+
+// instShard is a shard of the instruction cache.
+type instShard struct {
+	sync.RWMutex
+	// Decoded instructions, mapped from instruction address.
+	insts map[bin.Address]x86asm.Inst
+}
+
+// newInstCache returns a new instruction cache.
+func newInstCache() *instCache {
+	c := &instCache{}
+	for i := range c.shards {
+		c.shards[i].insts = make(map[bin.Address]x86asm.Inst)
+	}
+	return c
+}
+
+// get returns the cached instruction at the given address. The boolean return
+// value indicates success.
+func (c *instCache) get(addr bin.Address) (x86asm.Inst, bool) {
+	shard := c.shard(addr)
+	shard.RLock()
+	inst, ok := shard.insts[addr]
+	shard.RUnlock()
+	return inst, ok
+}
+
+// put caches the instruction at the given address.
+func (c *instCache) put(addr bin.Address, inst x86asm.Inst) {
+	shard := c.shard(addr)
+	shard.Lock()
+	shard.insts[addr] = inst
+	shard.Unlock()
+}
+
+// len returns the number of cached instructions.
+func (c *instCache) len() int {
+	n := 0
+	for i := range c.shards {
+		shard := &c.shards[i]
+		shard.RLock()
+		n += len(shard.insts)
+		shard.RUnlock()
+	}
+	return n
+}
+
+// shard returns the cache shard of the given address.
+func (c *instCache) shard(addr bin.Address) *instShard {
+	// Mix the address bits, as instruction addresses are not uniformly
+	// distributed in the low bits.
+	h := uint64(addr) * 0x9E3779B97F4A7C15
+	return &c.shards[h>>58%nshards]
+}
+
+// FlushCache flushes the cache of decoded instructions; which must be invoked
+// after patching the contents of the binary executable (e.g. applying memory
+// dumps).
+func (dis *Disasm) FlushCache() {
+	dis.cache = newInstCache()
+}
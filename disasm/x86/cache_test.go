@@ -0,0 +1,75 @@
+package x86_test
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm"
+	"github.com/decomp/exp/disasm/x86"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// TestDecodeInstParallel decodes instructions from concurrent decoders sharing
+// the instruction cache. Run with -race to detect data races.
+func TestDecodeInstParallel(t *testing.T) {
+	t.Parallel()
+	const base = 0x401000
+	// push ebp
+	// mov ebp, esp
+	// sub esp, 0x10
+	// xor eax, eax
+	// ret
+	pattern := []byte{0x55, 0x8B, 0xEC, 0x83, 0xEC, 0x10, 0x33, 0xC0, 0xC3}
+	var code []byte
+	for i := 0; i < 256; i++ {
+		code = append(code, pattern...)
+	}
+	file := &bin.File{
+		Arch: bin.ArchX86_32,
+		Sections: []*bin.Section{
+			{Name: ".text", Addr: base, Data: code, FileSize: len(code), MemSize: len(code), Perm: bin.PermR | bin.PermX},
+		},
+	}
+	dis := &x86.Disasm{Disasm: &disasm.Disasm{File: file}, Mode: 32}
+	dis.FlushCache()
+	// Expected instructions, mapped from instruction address.
+	want := make(map[bin.Address]x86asm.Inst)
+	var addrs []bin.Address
+	for offset := 0; offset < len(code); {
+		i, err := x86asm.Decode(code[offset:], 32)
+		if err != nil {
+			t.Fatalf("unable to decode instruction at offset %d; %v", offset, err)
+		}
+		addr := bin.Address(base + offset)
+		want[addr] = i
+		addrs = append(addrs, addr)
+		offset += i.Len
+	}
+	const nworkers = 8
+	var wg sync.WaitGroup
+	for worker := 0; worker < nworkers; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			// Decode each instruction a few times, starting at a different
+			// offset in each worker to interleave cache misses and hits.
+			for j := 0; j < 4*len(addrs); j++ {
+				addr := addrs[(worker*len(addrs)/nworkers+j)%len(addrs)]
+				inst, err := dis.DecodeInst(addr)
+				if err != nil {
+					t.Errorf("worker %d: unable to decode instruction at %v; %v", worker, addr, err)
+					return
+				}
+				if inst.Addr != addr {
+					t.Errorf("worker %d: instruction address mismatch; expected %v, got %v", worker, addr, inst.Addr)
+				}
+				if !reflect.DeepEqual(inst.Inst, want[addr]) {
+					t.Errorf("worker %d: instruction at %v mismatch; expected %v, got %v", worker, addr, want[addr], inst.Inst)
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,404 @@
+package x86
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// COM is the COM interface information of a binary executable, recovered from
+// interface vtables and GUIDs in data sections.
+type COM struct {
+	// COM interface vtables.
+	VTables []*VTable
+	// Map from address to name of GUIDs in data (e.g. IID_IClassFactory or
+	// CLSID_0002DF01_0000_0000_C000_000000000046).
+	GUIDs map[bin.Address]string
+}
+
+// A VTable is the vtable of a COM interface; a table of method pointers, the
+// first three of which are the methods of IUnknown (QueryInterface, AddRef and
+// Release).
+type VTable struct {
+	// Address of the vtable.
+	Addr bin.Address
+	// Interface ID (e.g. "{00000001-0000-0000-C000-000000000046}"); or empty
+	// if not identified.
+	IID string
+	// Interface name (e.g. "IClassFactory"); or empty if not identified.
+	Iface string
+	// Method addresses.
+	Methods []bin.Address
+}
+
+// MethodName returns the name of the i:th method of the vtable (e.g.
+// "IClassFactory_CreateInstance").
+func (vt *VTable) MethodName(i int) string {
+	var methods []string
+	if iface, ok := comIfaces[vt.IID]; ok {
+		methods = iface.allMethods()
+	} else {
+		methods = comIfaces[iidIUnknown].allMethods()
+	}
+	iface := vt.Iface
+	switch {
+	case i < 3 && len(iface) == 0:
+		iface = "IUnknown"
+	case len(iface) == 0 && len(vt.IID) > 0:
+		// e.g. Iface_6D5140C1
+		iface = "Iface_" + vt.IID[1:9]
+	case len(iface) == 0:
+		iface = "Iface"
+	}
+	if i < len(methods) {
+		return iface + "_" + methods[i]
+	}
+	return fmt.Sprintf("%s_Method%d", iface, i)
+}
+
+// FuncAddrs returns the addresses of the interface methods, in ascending order.
+func (c *COM) FuncAddrs() []bin.Address {
+	var funcAddrs []bin.Address
+	for _, vt := range c.VTables {
+		for _, method := range vt.Methods {
+			funcAddrs = bin.InsertAddr(funcAddrs, method)
+		}
+	}
+	return funcAddrs
+}
+
+// Names returns the names of the interface methods (e.g.
+// IClassFactory_CreateInstance_401230) and GUIDs in data, by address. Methods
+// shared by several vtables are named after the first identified interface.
+func (c *COM) Names() map[bin.Address]string {
+	names := make(map[bin.Address]string)
+	for addr, name := range c.GUIDs {
+		names[addr] = name
+	}
+	// Name methods of identified interfaces first.
+	vts := append([]*VTable(nil), c.VTables...)
+	less := func(i, j int) bool {
+		return len(vts[i].Iface) > 0 && len(vts[j].Iface) == 0
+	}
+	sort.SliceStable(vts, less)
+	for _, vt := range vts {
+		for i, method := range vt.Methods {
+			if _, ok := names[method]; !ok {
+				names[method] = fmt.Sprintf("%s_%06X", vt.MethodName(i), uint64(method))
+			}
+		}
+	}
+	return names
+}
+
+// Maximum number of methods of COM interface vtables.
+const maxVTableLen = 1024
+
+// FindCOM locates the COM interface vtables and GUIDs in the data sections of
+// the binary executable.
+//
+// GUIDs are located by value; both well-known interface IDs and GUIDs of
+// registry-style strings (e.g. "CLSID\{...}" or "Interface\{...}") are
+// recognized. Vtables are recognized as runs of pointers to executable code,
+// the first of which (QueryInterface) references a GUID. The interface of a
+// vtable is identified by the interface IDs referenced by QueryInterface, and
+// the number of methods of the vtable.
+func (dis *Disasm) FindCOM() *COM {
+	c := &COM{
+		GUIDs: make(map[bin.Address]string),
+	}
+	switch dis.File.Arch {
+	case bin.ArchX86_32, bin.ArchX86_64:
+		// supported.
+	default:
+		// COM targets 32- and 64-bit Windows.
+		return c
+	}
+	// Map from GUID value to name of GUID.
+	known := make(map[[16]byte]string)
+	for iid, iface := range comIfaces {
+		v, _ := parseGUID(iid)
+		known[v] = "IID_" + iface.name
+	}
+	for v, name := range dis.guidStrings() {
+		if _, ok := known[v]; !ok {
+			known[v] = name
+		}
+	}
+	// Locate GUIDs by value.
+	iids := make(map[bin.Address]string)
+	for _, sect := range dis.File.Sections {
+		if sect.Perm&bin.PermX != 0 {
+			// skip code sections.
+			continue
+		}
+		var v [16]byte
+		for off := 0; off+len(v) <= len(sect.Data); off += 4 {
+			copy(v[:], sect.Data[off:])
+			if name, ok := known[v]; ok {
+				addr := sect.Addr + bin.Address(off)
+				c.GUIDs[addr] = name
+				iids[addr] = formatGUID(v[:])
+			}
+		}
+	}
+	// Locate vtables.
+	order := dis.File.ByteOrder()
+	ptrSize := dis.File.PtrSize()
+	for _, sect := range dis.File.Sections {
+		if sect.Perm&bin.PermX != 0 {
+			// skip code sections.
+			continue
+		}
+		for off := 0; off+3*ptrSize <= len(sect.Data); off += ptrSize {
+			var methods []bin.Address
+			for end := off; end+ptrSize <= len(sect.Data) && len(methods) < maxVTableLen; end += ptrSize {
+				method := readPtr(sect.Data[end:], ptrSize, order)
+				if !dis.isExec(method) {
+					break
+				}
+				methods = append(methods, method)
+			}
+			if len(methods) < 3 {
+				continue
+			}
+			vt := &VTable{
+				Addr:    sect.Addr + bin.Address(off),
+				Methods: methods,
+			}
+			// skip past vtable.
+			off += (len(methods) - 1) * ptrSize
+			refs, ok := dis.guidRefs(methods[0], iids)
+			if !ok {
+				continue
+			}
+			vt.IID = identifyIface(refs, len(methods))
+			if iface, ok := comIfaces[vt.IID]; ok {
+				vt.Iface = iface.name
+			}
+			c.VTables = append(c.VTables, vt)
+		}
+	}
+	if len(c.VTables) > 0 || len(c.GUIDs) > 0 {
+		dbg.Printf("located %d COM interface vtables and %d GUIDs", len(c.VTables), len(c.GUIDs))
+	}
+	return c
+}
+
+// guidRefs returns the interface IDs referenced by the given QueryInterface
+// candidate, in order of reference. The boolean return value indicates whether
+// the function references any GUID.
+func (dis *Disasm) guidRefs(funcAddr bin.Address, iids map[bin.Address]string) ([]string, bool) {
+	if len(iids) == 0 {
+		return nil, false
+	}
+	f, err := dis.DecodeFunc(dis.FinalTarget(funcAddr))
+	if err != nil {
+		return nil, false
+	}
+	var refs []string
+	found := false
+	for _, inst := range f.Insts() {
+		next := inst.Addr + bin.Address(inst.Len)
+		for _, arg := range inst.Args {
+			var addr bin.Address
+			switch arg := arg.(type) {
+			case x86asm.Imm:
+				addr = bin.Address(arg)
+			case x86asm.Mem:
+				a, ok := staticAddr(arg, next)
+				if !ok {
+					continue
+				}
+				addr = a
+			default:
+				continue
+			}
+			iid, ok := iids[addr]
+			if !ok {
+				continue
+			}
+			found = true
+			if iid != iidIUnknown {
+				refs = append(refs, iid)
+			}
+		}
+	}
+	return refs, found
+}
+
+// identifyIface returns the interface ID of a vtable with n methods, based on
+// the interface IDs referenced by its QueryInterface method; or empty if not
+// identified. Interfaces with n methods are preferred, as QueryInterface of
+// classes implementing several interfaces is shared by the vtables of each
+// interface.
+func identifyIface(refs []string, n int) string {
+	for _, iid := range refs {
+		if iface, ok := comIfaces[iid]; ok && len(iface.allMethods()) == n {
+			return iid
+		}
+	}
+	if len(refs) == 1 {
+		return refs[0]
+	}
+	return ""
+}
+
+// guidStrings returns the GUIDs of registry-style strings in the data sections
+// of the binary executable (e.g. "CLSID\{...}"), in ASCII or UTF-16 encoding,
+// mapped to the name of the GUID (e.g. CLSID_0002DF01_0000_0000_C000_000000000046).
+func (dis *Disasm) guidStrings() map[[16]byte]string {
+	guids := make(map[[16]byte]string)
+	for _, sect := range dis.File.Sections {
+		if sect.Perm&bin.PermX != 0 {
+			// skip code sections.
+			continue
+		}
+		data := sect.Data
+		for off := bytes.IndexByte(data, '{'); off != -1; {
+			for _, stride := range []int{1, 2} {
+				s, ok := decodeString(data[off:], guidStrLen, stride)
+				if !ok {
+					continue
+				}
+				v, ok := parseGUID(s)
+				if !ok {
+					continue
+				}
+				prefix := "GUID_"
+				switch {
+				case hasKeyPrefix(data[:off], "CLSID\\", stride):
+					prefix = "CLSID_"
+				case hasKeyPrefix(data[:off], "Interface\\", stride):
+					prefix = "IID_"
+				}
+				name := prefix + strings.NewReplacer("{", "", "}", "", "-", "_").Replace(s)
+				if _, ok := guids[v]; !ok || prefix != "GUID_" {
+					guids[v] = name
+				}
+			}
+			next := bytes.IndexByte(data[off+1:], '{')
+			if next == -1 {
+				break
+			}
+			off += 1 + next
+		}
+	}
+	return guids
+}
+
+// ### [ Helper functions ] ####################################################
+
+// Length in characters of GUID strings (e.g.
+// "{00000000-0000-0000-C000-000000000046}").
+const guidStrLen = 38
+
+// iidIUnknown is the interface ID of IUnknown.
+const iidIUnknown = "{00000000-0000-0000-C000-000000000046}"
+
+// A comIface is a well-known COM interface.
+type comIface struct {
+	// Interface name.
+	name string
+	// Interface ID of the parent interface; or empty for IUnknown.
+	parent string
+	// Methods of the interface, excluding methods of the parent interface.
+	methods []string
+}
+
+// allMethods returns the methods of the interface, including the methods of
+// its parent interfaces.
+func (iface *comIface) allMethods() []string {
+	if parent, ok := comIfaces[iface.parent]; ok {
+		return append(parent.allMethods(), iface.methods...)
+	}
+	return iface.methods
+}
+
+// comIfaces maps from interface ID to well-known COM interface.
+var comIfaces = map[string]*comIface{
+	iidIUnknown:                              {name: "IUnknown", methods: []string{"QueryInterface", "AddRef", "Release"}},
+	"{00000001-0000-0000-C000-000000000046}": {name: "IClassFactory", parent: iidIUnknown, methods: []string{"CreateInstance", "LockServer"}},
+	"{00000002-0000-0000-C000-000000000046}": {name: "IMalloc", parent: iidIUnknown, methods: []string{"Alloc", "Realloc", "Free", "GetSize", "DidAlloc", "HeapMinimize"}},
+	"{0000000C-0000-0000-C000-000000000046}": {name: "IStream", parent: "{0C733A30-2A1C-11CE-ADE5-00AA0044773D}", methods: []string{"Seek", "SetSize", "CopyTo", "Commit", "Revert", "LockRegion", "UnlockRegion", "Stat", "Clone"}},
+	"{0C733A30-2A1C-11CE-ADE5-00AA0044773D}": {name: "ISequentialStream", parent: iidIUnknown, methods: []string{"Read", "Write"}},
+	"{0000010C-0000-0000-C000-000000000046}": {name: "IPersist", parent: iidIUnknown, methods: []string{"GetClassID"}},
+	"{00000109-0000-0000-C000-000000000046}": {name: "IPersistStream", parent: "{0000010C-0000-0000-C000-000000000046}", methods: []string{"IsDirty", "Load", "Save", "GetSizeMax"}},
+	"{0000010B-0000-0000-C000-000000000046}": {name: "IPersistFile", parent: "{0000010C-0000-0000-C000-000000000046}", methods: []string{"IsDirty", "Load", "Save", "SaveCompleted", "GetCurFile"}},
+	"{0000010E-0000-0000-C000-000000000046}": {name: "IDataObject", parent: iidIUnknown, methods: []string{"GetData", "GetDataHere", "QueryGetData", "GetCanonicalFormatEtc", "SetData", "EnumFormatEtc", "DAdvise", "DUnadvise", "EnumDAdvise"}},
+	"{00000114-0000-0000-C000-000000000046}": {name: "IOleWindow", parent: iidIUnknown, methods: []string{"GetWindow", "ContextSensitiveHelp"}},
+	"{00000121-0000-0000-C000-000000000046}": {name: "IDropSource", parent: iidIUnknown, methods: []string{"QueryContinueDrag", "GiveFeedback"}},
+	"{00000122-0000-0000-C000-000000000046}": {name: "IDropTarget", parent: iidIUnknown, methods: []string{"DragEnter", "DragOver", "DragLeave", "Drop"}},
+	"{00020400-0000-0000-C000-000000000046}": {name: "IDispatch", parent: iidIUnknown, methods: []string{"GetTypeInfoCount", "GetTypeInfo", "GetIDsOfNames", "Invoke"}},
+	"{00020404-0000-0000-C000-000000000046}": {name: "IEnumVARIANT", parent: iidIUnknown, methods: []string{"Next", "Skip", "Reset", "Clone"}},
+	"{B196B283-BAB4-101A-B69C-00AA00341D07}": {name: "IProvideClassInfo", parent: iidIUnknown, methods: []string{"GetClassInfo"}},
+	"{B196B284-BAB4-101A-B69C-00AA00341D07}": {name: "IConnectionPointContainer", parent: iidIUnknown, methods: []string{"EnumConnectionPoints", "FindConnectionPoint"}},
+	"{DF0B3D60-548F-101B-8E65-08002B2BD119}": {name: "ISupportErrorInfo", parent: iidIUnknown, methods: []string{"InterfaceSupportsErrorInfo"}},
+	"{FC4801A3-2BA9-11CF-A229-00AA003D7352}": {name: "IObjectWithSite", parent: iidIUnknown, methods: []string{"SetSite", "GetSite"}},
+	"{6D5140C1-7436-11CE-8034-00AA006009FA}": {name: "IServiceProvider", parent: iidIUnknown, methods: []string{"QueryService"}},
+}
+
+// formatGUID returns the string representation of the GUID stored in the
+// first 16 bytes of data (e.g. "{00000000-0000-0000-C000-000000000046}").
+func formatGUID(data []byte) string {
+	order := binary.LittleEndian
+	return fmt.Sprintf("{%08X-%04X-%04X-%04X-%012X}", order.Uint32(data[0:4]), order.Uint16(data[4:6]), order.Uint16(data[6:8]), data[8:10], data[10:16])
+}
+
+// parseGUID parses the given GUID string (e.g.
+// "{00000000-0000-0000-C000-000000000046}") into its binary representation.
+// The boolean return value indicates success.
+func parseGUID(s string) ([16]byte, bool) {
+	var v [16]byte
+	if len(s) != guidStrLen || s[0] != '{' || s[37] != '}' || s[9] != '-' || s[14] != '-' || s[19] != '-' || s[24] != '-' {
+		return v, false
+	}
+	hex := s[1:9] + s[10:14] + s[15:19] + s[20:24] + s[25:37]
+	for i := range v {
+		b, err := strconv.ParseUint(hex[2*i:2*i+2], 16, 8)
+		if err != nil {
+			return v, false
+		}
+		v[i] = byte(b)
+	}
+	// Data1, Data2 and Data3 are stored in little-endian byte order.
+	v[0], v[1], v[2], v[3] = v[3], v[2], v[1], v[0]
+	v[4], v[5] = v[5], v[4]
+	v[6], v[7] = v[7], v[6]
+	return v, true
+}
+
+// decodeString decodes n ASCII characters of the given data, encoded in ASCII
+// (stride 1) or UTF-16 (stride 2). The boolean return value indicates success.
+func decodeString(data []byte, n, stride int) (string, bool) {
+	if len(data) < n*stride {
+		return "", false
+	}
+	buf := make([]byte, n)
+	for i := range buf {
+		c := data[i*stride]
+		if c < ' ' || c > '~' || (stride == 2 && data[i*stride+1] != 0) {
+			return "", false
+		}
+		buf[i] = c
+	}
+	return string(buf), true
+}
+
+// hasKeyPrefix reports whether the given data ends with the registry key prefix
+// (e.g. "CLSID\"), encoded in ASCII (stride 1) or UTF-16 (stride 2). The prefix
+// is matched case-insensitively.
+func hasKeyPrefix(data []byte, prefix string, stride int) bool {
+	n := len(prefix) * stride
+	if len(data) < n {
+		return false
+	}
+	s, ok := decodeString(data[len(data)-n:], len(prefix), stride)
+	return ok && strings.EqualFold(s, prefix)
+}
@@ -21,10 +21,25 @@ type Func struct {
 type BasicBlock struct {
 	// Address of the basic block.
 	Addr bin.Address
+	// Processor mode (16, 32 or 64) in which the basic block is decoded.
+	Mode int
 	// Sequence of non-branching instructions.
 	Insts []*Inst
 	// Terminating instruction.
 	Term *Inst
+	// Original decoded instructions of the basic block which have been
+	// rewritten by anti-disassembly neutralization, mapped from instruction
+	// address; or nil if none.
+	Rewritten map[bin.Address]*Inst
+}
+
+// addRewritten records the original decoded instruction of an instruction of
+// the basic block rewritten by anti-disassembly neutralization.
+func (block *BasicBlock) addRewritten(orig *Inst) {
+	if block.Rewritten == nil {
+		block.Rewritten = make(map[bin.Address]*Inst)
+	}
+	block.Rewritten[orig.Addr] = orig
 }
 
 // An Inst is a single instruction.
@@ -35,13 +50,41 @@ type Inst struct {
 	x86asm.Inst
 }
 
+// Insts returns the instructions of the function in address order, including
+// terminators.
+func (f *Func) Insts() []*Inst {
+	var blockAddrs bin.Addresses
+	for blockAddr := range f.Blocks {
+		blockAddrs = append(blockAddrs, blockAddr)
+	}
+	sort.Sort(blockAddrs)
+	var insts []*Inst
+	for _, blockAddr := range blockAddrs {
+		block := f.Blocks[blockAddr]
+		insts = append(insts, block.Insts...)
+		if !block.Term.IsDummyTerm() {
+			insts = append(insts, block.Term)
+		}
+	}
+	return insts
+}
+
 // DecodeFunc decodes and returns the function at the given address.
 func (dis *Disasm) DecodeFunc(entry bin.Address) (*Func, error) {
+	if f, ok := dis.decoded[entry]; ok {
+		// Function pre-decoded.
+		return f, nil
+	}
 	dbg.Printf("decoding function at %v", entry)
 	f := &Func{
 		Addr:   entry,
 		Blocks: make(map[bin.Address]*BasicBlock),
 	}
+	// Processor mode of basic blocks; inherited from the first predecessor
+	// unless overridden by the user.
+	modes := map[bin.Address]int{
+		entry: dis.blockMode(entry, dis.Mode),
+	}
 	queue := newQueue()
 	queue.push(entry)
 	for !queue.empty() {
@@ -50,23 +93,39 @@ func (dis *Disasm) DecodeFunc(entry bin.Address) (*Func, error) {
 			// skip basic block if already decoded.
 			continue
 		}
-		block, err := dis.DecodeBlock(blockAddr)
+		block, err := dis.decodeBlock(blockAddr, modes[blockAddr])
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
 		f.Blocks[blockAddr] = block
+		// Resolve indirect jump targets using value-set analysis.
+		if dis.isUnresolvedJump(block.Term) {
+			dis.resolveJump(f, block.Term)
+		}
 		// Add block targets to queue.
 		targets := dis.Targets(block.Term, entry)
 		for _, target := range targets {
 			dbg.Printf("adding basic block address %v to queue", target)
+			if _, ok := modes[target]; !ok {
+				modes[target] = dis.blockMode(target, block.Mode)
+			}
 			queue.push(target)
 		}
 	}
+	dis.resolveCalls(f)
+	dis.reportOverlaps(f)
 	return f, nil
 }
 
-// DecodeBlock decodes and returns the basic block at the given address.
+// DecodeBlock decodes and returns the basic block at the given address, in the
+// processor mode of the disassembler unless overridden by the user.
 func (dis *Disasm) DecodeBlock(entry bin.Address) (*BasicBlock, error) {
+	return dis.decodeBlock(entry, dis.blockMode(entry, dis.Mode))
+}
+
+// decodeBlock decodes and returns the basic block at the given address, in the
+// specified processor mode.
+func (dis *Disasm) decodeBlock(entry bin.Address, mode int) (*BasicBlock, error) {
 	dbg.Printf("decoding basic block at %v", entry)
 	// Compute end address of the basic block.
 	maxLen := dis.maxBlockLen(entry)
@@ -75,16 +134,25 @@ func (dis *Disasm) DecodeBlock(entry bin.Address) (*BasicBlock, error) {
 	// Decode instructions.
 	block := &BasicBlock{
 		Addr: entry,
+		Mode: mode,
 	}
 	for addr < end {
-		inst, err := dis.DecodeInst(addr)
+		inst, err := dis.DecodeInstMode(addr, mode)
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
+		if rewrite := dis.neutralizeInst(inst); rewrite != nil {
+			block.addRewritten(inst)
+			inst = rewrite
+		}
 		dbg.Printf("   instruction at %v: %v", addr, inst)
 		addr += bin.Address(inst.Len)
 		if inst.isTerm() {
 			block.Term = inst
+			if rewrite := dis.neutralizeTerm(block); rewrite != nil {
+				block.addRewritten(inst)
+				block.Term = rewrite
+			}
 			break
 		}
 		block.Insts = append(block.Insts, inst)
@@ -103,11 +171,40 @@ func (dis *Disasm) DecodeBlock(entry bin.Address) (*BasicBlock, error) {
 }
 
 // DecodeInst decodes and returns the instruction at the given address.
+// Decoded instructions are cached, and DecodeInst is safe for concurrent use.
 func (dis *Disasm) DecodeInst(addr bin.Address) (*Inst, error) {
+	if dis.cache != nil {
+		if i, ok := dis.cache.get(addr); ok {
+			return &Inst{Addr: addr, Inst: i}, nil
+		}
+	}
+	inst, err := dis.decodeInst(addr, dis.Mode)
+	if err != nil {
+		return nil, err
+	}
+	if dis.cache != nil {
+		dis.cache.put(addr, inst.Inst)
+	}
+	return inst, nil
+}
+
+// DecodeInstMode decodes and returns the instruction at the given address, in
+// the specified processor mode. Only instructions decoded in the processor mode
+// of the disassembler are cached.
+func (dis *Disasm) DecodeInstMode(addr bin.Address, mode int) (*Inst, error) {
+	if mode == dis.Mode {
+		return dis.DecodeInst(addr)
+	}
+	return dis.decodeInst(addr, mode)
+}
+
+// decodeInst decodes and returns the instruction at the given address, in the
+// specified processor mode.
+func (dis *Disasm) decodeInst(addr bin.Address, mode int) (*Inst, error) {
 	code := dis.File.Code(addr)
-	i, err := x86asm.Decode(code, dis.Mode)
+	i, err := x86asm.Decode(code, mode)
 	if err != nil {
-		return nil, errors.WithStack(err)
+		return nil, dis.File.WrapAt(addr, "decode", errors.WithStack(err))
 	}
 	inst := &Inst{
 		Addr: addr,
@@ -130,34 +227,18 @@ func (dis *Disasm) maxBlockLen(blockAddr bin.Address) int64 {
 
 // codeStart returns the start address of the first code section.
 func (dis *Disasm) codeStart() bin.Address {
-	var min bin.Address
-	for _, sect := range dis.File.Sections {
-		if sect.Perm&bin.PermX != 0 {
-			start := sect.Addr
-			if min == 0 || min > start {
-				min = start
-			}
-		}
-	}
-	if min == 0 {
+	start, _, ok := dis.File.AddressSpace().Range(bin.PermX)
+	if !ok {
 		panic("unable to locate start address of first code section")
 	}
-	return min
+	return start
 }
 
 // codeEnd returns the end address of the last code section.
 func (dis *Disasm) codeEnd() bin.Address {
-	var max bin.Address
-	for _, sect := range dis.File.Sections {
-		if sect.Perm&bin.PermX != 0 {
-			end := sect.Addr + bin.Address(len(sect.Data))
-			if max < end {
-				max = end
-			}
-		}
-	}
-	if max == 0 {
+	_, end, ok := dis.File.AddressSpace().Range(bin.PermX)
+	if !ok {
 		panic("unable to locate end address of last code section")
 	}
-	return max
+	return end
 }
@@ -37,6 +37,25 @@ type Disasm struct {
 	Mode int
 	// CPU contexts.
 	Contexts Contexts
+	// Map from basic block address to processor mode (16, 32 or 64), for basic
+	// blocks decoded in a processor mode other than Mode (e.g. protected mode
+	// code entered through a far jump from real mode code).
+	Modes map[bin.Address]int
+	// Map from indirect branch instruction address to target addresses observed
+	// at runtime (e.g. from execution traces), or resolved by static analysis.
+	Indirect map[bin.Address][]bin.Address
+	// Executed instruction addresses of imported execution traces; or nil if no
+	// execution trace has been imported.
+	Executed map[bin.Address]bool
+	// Map from thunk address to target function address.
+	Thunks map[bin.Address]bin.Address
+	// Addresses of shared tails extracted into artificial callees.
+	Extracted map[bin.Address]bool
+	// Pre-decoded functions (e.g. from a serialized disassembly model), mapped
+	// from function address.
+	decoded map[bin.Address]*Func
+	// Decoded instructions, shared by concurrent decoders.
+	cache *instCache
 }
 
 // NewDisasm creates a new Disasm for accessing the assembly instructions of the
@@ -49,10 +68,12 @@ type Disasm struct {
 //    tables.json
 //    chunks.json
 //    data.json
+//    overrides.json
 //
 // Associated files of the x86 disassembler.
 //
 //    contexts.json
+//    modes.json
 func NewDisasm(file *bin.File) (*Disasm, error) {
 	// Prepare x86 disassembler.
 	d, err := disasm.New(file)
@@ -62,10 +83,14 @@ func NewDisasm(file *bin.File) (*Disasm, error) {
 	dis := &Disasm{
 		Disasm:   d,
 		Contexts: make(Contexts),
+		Modes:    make(map[bin.Address]int),
+		cache:    newInstCache(),
 	}
 
 	// Parse processor mode.
 	switch dis.File.Arch {
+	case bin.ArchX86_16:
+		dis.Mode = 16
 	case bin.ArchX86_32:
 		dis.Mode = 32
 	case bin.ArchX86_64:
@@ -79,9 +104,34 @@ func NewDisasm(file *bin.File) (*Disasm, error) {
 		return nil, errors.WithStack(err)
 	}
 
+	// Parse processor modes of basic blocks.
+	if err := parseJSON("modes.json", &dis.Modes); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for addr, mode := range dis.Modes {
+		switch mode {
+		case 16, 32, 64:
+			// valid processor mode.
+		default:
+			return nil, errors.Errorf("invalid processor mode %d of basic block at %v; expected 16, 32 or 64", mode, addr)
+		}
+	}
+
 	return dis, nil
 }
 
+// AddFuncs adds the given pre-decoded functions (e.g. from a serialized
+// disassembly model) to the disassembler; which are subsequently returned by
+// DecodeFunc rather than being decoded again.
+func (dis *Disasm) AddFuncs(fs []*Func) {
+	if dis.decoded == nil {
+		dis.decoded = make(map[bin.Address]*Func)
+	}
+	for _, f := range fs {
+		dis.decoded[f.Addr] = f
+	}
+}
+
 // ### [ Helper functions ] ####################################################
 
 // parseJSON parses the given JSON file and stores the result into v.
@@ -0,0 +1,161 @@
+package x86
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// A CovBlock is a basic block executed according to a coverage trace.
+type CovBlock struct {
+	// Address of the basic block.
+	Addr bin.Address
+	// Size in bytes of the basic block.
+	Size int
+}
+
+// ParseDrcov parses the DynamoRIO drcov coverage trace read from the given
+// path, and returns the executed basic blocks of the module with the given file
+// name (e.g. "foo.exe"), relocated to the address space of the binary
+// executable.
+//
+// The drcov format consists of a textual header and module table, followed by
+// a binary table of executed basic blocks, each recorded as the offset from the
+// start of its module, its size and module ID.
+//
+//    DRCOV VERSION: 2
+//    DRCOV FLAVOR: drcov
+//    Module Table: version 2, count 2
+//    Columns: id, base, end, entry, path
+//      0, 0x400000, 0x452000, 0x401234, /home/u/foo.exe
+//      1, 0x7ff000000000, 0x7ff000020000, 0x0, /lib/ld-linux.so.2
+//    BB Table: 2 bbs
+//    <start uint32, size uint16, id uint16>...
+func ParseDrcov(tracePath, module string, as *bin.AddressSpace) ([]CovBlock, error) {
+	buf, err := ioutil.ReadFile(tracePath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// Parse header.
+	var (
+		// Column names of module table.
+		columns = []string{"id", "size", "path"}
+		// Number of module table entries.
+		nmods int
+		// Number of basic block table entries.
+		nbbs int
+	)
+	const bbTablePrefix = "BB Table:"
+	var lines []string
+	for {
+		pos := bytes.IndexByte(buf, '\n')
+		if pos == -1 {
+			return nil, errors.Errorf("unable to locate basic block table of drcov trace %q", tracePath)
+		}
+		line := strings.TrimSpace(string(buf[:pos]))
+		buf = buf[pos+1:]
+		if strings.HasPrefix(line, bbTablePrefix) {
+			// BB Table: 1234 bbs
+			s := strings.TrimSuffix(strings.TrimPrefix(line, bbTablePrefix), "bbs")
+			n, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil {
+				return nil, errors.Errorf("invalid basic block table header %q of drcov trace %q", line, tracePath)
+			}
+			nbbs = n
+			break
+		}
+		lines = append(lines, line)
+	}
+	// Parse module table.
+	//
+	// Base address of each module segment, by module ID, of the given module.
+	segBases := make(map[uint16]bin.Address)
+	modBase := bin.Address(0)
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "DRCOV "), len(line) == 0:
+			// skip version and flavor.
+		case strings.HasPrefix(line, "Module Table:"):
+			// Module Table: 3
+			// Module Table: version 2, count 3
+			fields := strings.Fields(strings.TrimPrefix(line, "Module Table:"))
+			if len(fields) == 0 {
+				return nil, errors.Errorf("invalid module table header %q of drcov trace %q", line, tracePath)
+			}
+			if n, err := strconv.Atoi(fields[len(fields)-1]); err == nil {
+				nmods = n
+			}
+		case strings.HasPrefix(line, "Columns:"):
+			columns = nil
+			for _, column := range strings.Split(strings.TrimPrefix(line, "Columns:"), ",") {
+				columns = append(columns, strings.TrimSpace(column))
+			}
+		default:
+			// The path is the last column, and may contain commas.
+			fields := strings.SplitN(line, ",", len(columns))
+			if len(fields) != len(columns) {
+				return nil, errors.Errorf("invalid module table entry %q of drcov trace %q", line, tracePath)
+			}
+			entry := make(map[string]string)
+			for i, column := range columns {
+				entry[column] = strings.TrimSpace(fields[i])
+			}
+			modPath := strings.Replace(entry["path"], `\`, "/", -1)
+			if !strings.EqualFold(path.Base(modPath), module) {
+				continue
+			}
+			id, err := strconv.ParseUint(entry["id"], 10, 16)
+			if err != nil {
+				return nil, errors.Errorf("invalid module ID %q of drcov trace %q", entry["id"], tracePath)
+			}
+			// Columns of version 2 and version 3 module tables respectively.
+			base := entry["base"]
+			if len(base) == 0 {
+				base = entry["start"]
+			}
+			var segBase bin.Address
+			if len(base) > 0 {
+				if err := segBase.Set(base); err != nil {
+					return nil, errors.Errorf("invalid base address %q of module %q in drcov trace %q", base, module, tracePath)
+				}
+			}
+			if len(segBases) == 0 || segBase < modBase {
+				modBase = segBase
+			}
+			segBases[uint16(id)] = segBase
+		}
+	}
+	if len(segBases) == 0 {
+		return nil, errors.Errorf("unable to locate module %q in module table (%d entries) of drcov trace %q", module, nmods, tracePath)
+	}
+	// Parse basic block table.
+	const bbEntrySize = 8
+	if len(buf) < nbbs*bbEntrySize {
+		return nil, errors.Errorf("truncated basic block table of drcov trace %q; expected %d entries, got %d", tracePath, nbbs, len(buf)/bbEntrySize)
+	}
+	var blocks []CovBlock
+	for i := 0; i < nbbs; i++ {
+		entry := buf[i*bbEntrySize:]
+		start := binary.LittleEndian.Uint32(entry[0:])
+		size := binary.LittleEndian.Uint16(entry[4:])
+		id := binary.LittleEndian.Uint16(entry[6:])
+		segBase, ok := segBases[id]
+		if !ok {
+			// skip basic blocks of other modules.
+			continue
+		}
+		rva := uint64(segBase-modBase) + uint64(start)
+		block := CovBlock{
+			Addr: as.VA(rva),
+			Size: int(size),
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
@@ -0,0 +1,44 @@
+// +build gofuzz
+
+package x86
+
+import (
+	"bytes"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/bin/raw"
+)
+
+// Fuzz is the go-fuzz entry point of the x86 decoder; which decodes the
+// function at the start of the given data, interpreted as a raw 32-bit x86
+// binary executable.
+//
+// Usage:
+//
+//    go-fuzz-build github.com/decomp/exp/disasm/x86
+//    go-fuzz -bin=x86-fuzz.zip -workdir=testdata/fuzz
+func Fuzz(data []byte) int {
+	if len(data) == 0 {
+		return 0
+	}
+	file, err := raw.Parse(bytes.NewReader(data), bin.ArchX86_32)
+	if err != nil {
+		return 0
+	}
+	dis, err := NewDisasm(file)
+	if err != nil {
+		return 0
+	}
+	f, err := dis.DecodeFunc(0)
+	if err != nil {
+		return 0
+	}
+	// Exercise analyses of decoded functions.
+	dis.Xrefs([]*Func{f})
+	for _, inst := range f.Insts() {
+		if _, err := dis.InstPattern(inst, MaskAll); err != nil {
+			panic(err)
+		}
+	}
+	return 1
+}
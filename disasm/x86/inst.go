@@ -28,6 +28,9 @@ func (term *Inst) isTerm() bool {
 	// Unconditional jump terminators.
 	case x86asm.JMP:
 		return true
+	// Far jump terminators; possibly switching processor mode.
+	case x86asm.LJMP:
+		return true
 	// Return terminators.
 	case x86asm.RET:
 		return true
@@ -65,7 +68,10 @@ func (dis *Disasm) Targets(term *Inst, funcEntry bin.Address) []bin.Address {
 		return append(targets, next)
 	// Unconditional jump terminators.
 	case x86asm.JMP:
-		preTargets := dis.Addrs(term.Args[0], term.Addr, next)
+		preTargets, ok := dis.Indirect[term.Addr]
+		if !ok {
+			preTargets = dis.Addrs(term.Args[0], term.Addr, next)
+		}
 		var targets []bin.Address
 		for _, target := range preTargets {
 			if dis.isTailCall(funcEntry, target) {
@@ -76,6 +82,18 @@ func (dis *Disasm) Targets(term *Inst, funcEntry bin.Address) []bin.Address {
 			}
 		}
 		return targets
+	// Far jump terminators.
+	case x86asm.LJMP:
+		target, ok := dis.FarTarget(term)
+		if !ok {
+			warn.Printf("ignoring targets of indirect far jump at %v", term.Addr)
+			return nil
+		}
+		if dis.IsFunc(target) && target != funcEntry {
+			dbg.Printf("far tail call at %v", term.Addr)
+			return nil
+		}
+		return []bin.Address{target}
 	// Return terminators.
 	case x86asm.RET:
 		// no targets.
@@ -87,6 +105,10 @@ func (dis *Disasm) Targets(term *Inst, funcEntry bin.Address) []bin.Address {
 // isTailCall reports whether the given JMP instruction is a tail call
 // instruction.
 func (dis *Disasm) isTailCall(funcEntry bin.Address, target bin.Address) bool {
+	if dis.Extracted[target] && target != funcEntry {
+		// Target is an extracted shared tail.
+		return true
+	}
 	funcEnd := dis.funcEnd(funcEntry)
 	if funcEntry <= target && target < funcEnd {
 		// Target inside function body.
@@ -112,6 +134,11 @@ func (dis *Disasm) funcEnd(funcEntry bin.Address) bin.Address {
 		return funcEntry < dis.FuncAddrs[i]
 	}
 	index := sort.Search(len(dis.FuncAddrs), less)
+	// Skip extracted shared tails, which may be located within the function
+	// body.
+	for index < len(dis.FuncAddrs) && dis.Extracted[dis.FuncAddrs[index]] {
+		index++
+	}
 	if 0 <= index && index < len(dis.FuncAddrs) {
 		return dis.FuncAddrs[index]
 	}
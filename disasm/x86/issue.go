@@ -0,0 +1,246 @@
+package x86
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm"
+	"github.com/pkg/errors"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// IssueKind specifies the kind of an issue.
+type IssueKind uint8
+
+// Issue kinds.
+const (
+	// IssueIndirect specifies an indirect branch with unresolved targets (e.g.
+	// `jmp eax` or `call [ebx+8]`); add jump tables to tables.json, or import
+	// an execution trace.
+	IssueIndirect IssueKind = iota + 1
+	// IssueUnlifted specifies an instruction which failed to lift, or a function
+	// skipped for exceeding the lifting budget.
+	IssueUnlifted
+	// IssueOverlap specifies an instruction which overlaps another instruction
+	// (e.g. jumps into the middle of instructions, as used by anti-disassembly
+	// techniques, or functions with incorrect boundaries).
+	IssueOverlap
+	// IssueDataInCode specifies decoded instructions which are likely data
+	// (e.g. inside a data fragment of data.json, runs of zero bytes, or
+	// privileged I/O instructions in user-mode code).
+	IssueDataInCode
+	// IssueBoundary specifies an incorrect function boundary (e.g. a function
+	// body which falls through into the next function, contains undecodable
+	// gaps or lacks a reachable return), or an automatic correction thereof.
+	IssueBoundary
+)
+
+// String returns the string representation of the issue kind.
+func (kind IssueKind) String() string {
+	m := map[IssueKind]string{
+		IssueIndirect:   "indirect",
+		IssueUnlifted:   "unlifted",
+		IssueOverlap:    "overlap",
+		IssueDataInCode: "data-in-code",
+		IssueBoundary:   "boundary",
+	}
+	if s, ok := m[kind]; ok {
+		return s
+	}
+	return fmt.Sprintf("IssueKind(%d)", uint8(kind))
+}
+
+// MarshalText encodes the issue kind into UTF-8-encoded text.
+func (kind IssueKind) MarshalText() ([]byte, error) {
+	return []byte(kind.String()), nil
+}
+
+// An Issue is a problem encountered during analysis, which may be resolved by
+// refining the annotation files of the binary executable.
+type Issue struct {
+	// Kind of issue.
+	Kind IssueKind `json:"kind"`
+	// Address of the issue.
+	Addr bin.Address `json:"addr"`
+	// Address of the function containing the issue.
+	Func bin.Address `json:"func"`
+	// Description of the issue.
+	Msg string `json:"msg"`
+}
+
+// Issues returns the issues of the given decoded functions, sorted by address.
+func (dis *Disasm) Issues(fs []*Func) []*Issue {
+	var issues []*Issue
+	// Map from instruction address to instruction and parent function; shared
+	// instructions are recorded once.
+	insts := make(map[bin.Address]*Inst)
+	parents := make(map[bin.Address]bin.Address)
+	for _, f := range fs {
+		for _, block := range f.Blocks {
+			for _, inst := range block.Insts {
+				insts[inst.Addr] = inst
+				parents[inst.Addr] = f.Addr
+				if inst.Op == x86asm.CALL && !dis.isResolved(inst) {
+					issue := &Issue{Kind: IssueIndirect, Addr: inst.Addr, Func: f.Addr, Msg: fmt.Sprintf("unresolved targets of indirect call `%v`", inst)}
+					issues = append(issues, issue)
+				}
+			}
+			if block.Term.IsDummyTerm() {
+				continue
+			}
+			insts[block.Term.Addr] = block.Term
+			parents[block.Term.Addr] = f.Addr
+			if block.Term.Op == x86asm.JMP && !dis.isResolved(block.Term) {
+				issue := &Issue{Kind: IssueIndirect, Addr: block.Term.Addr, Func: f.Addr, Msg: fmt.Sprintf("unresolved targets of indirect jump `%v`", block.Term)}
+				issues = append(issues, issue)
+			}
+		}
+		if issue, ok := dis.dataInCode(f); ok {
+			issues = append(issues, issue)
+		}
+	}
+	// Overlapping instructions.
+	var addrs []bin.Address
+	for addr := range insts {
+		addrs = append(addrs, addr)
+	}
+	sort.Sort(bin.Addresses(addrs))
+	for i := 0; i+1 < len(addrs); i++ {
+		inst := insts[addrs[i]]
+		end := inst.Addr + bin.Address(inst.Len)
+		if next := addrs[i+1]; next < end {
+			issue := &Issue{Kind: IssueOverlap, Addr: next, Func: parents[next], Msg: fmt.Sprintf("instruction starts in the middle of instruction `%v` at %v", inst, inst.Addr)}
+			issues = append(issues, issue)
+		}
+	}
+	SortIssues(issues)
+	return issues
+}
+
+// SortIssues sorts the given issues by address and kind.
+func SortIssues(issues []*Issue) {
+	less := func(i, j int) bool {
+		if issues[i].Addr != issues[j].Addr {
+			return issues[i].Addr < issues[j].Addr
+		}
+		return issues[i].Kind < issues[j].Kind
+	}
+	sort.SliceStable(issues, less)
+}
+
+// WriteIssues writes the given issues to the specified JSON file.
+func WriteIssues(path string, issues []*Issue) error {
+	if issues == nil {
+		// Output an empty JSON array rather than null.
+		issues = []*Issue{}
+	}
+	buf, err := json.MarshalIndent(issues, "", "\t")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	buf = append(buf, '\n')
+	if err := ioutil.WriteFile(path, buf, 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// ### [ Helper functions ] ####################################################
+
+// isResolved reports whether the targets of the given branch instruction are
+// known; either direct branches, branches through static memory addresses
+// (e.g. `call [__imp_ExitProcess]`), jump tables or branches with targets
+// observed at runtime.
+func (dis *Disasm) isResolved(inst *Inst) bool {
+	if _, ok := dis.Indirect[inst.Addr]; ok {
+		return true
+	}
+	switch arg := inst.Args[0].(type) {
+	case x86asm.Rel:
+		return true
+	case x86asm.Mem:
+		if arg.Base == 0 && arg.Index == 0 {
+			return true
+		}
+		_, ok := dis.Tables[bin.Address(arg.Disp)]
+		return ok
+	}
+	return false
+}
+
+// suspiciousOps specifies instructions which are rarely used by user-mode code,
+// and are likely the result of decoding data as code.
+var suspiciousOps = map[x86asm.Op]bool{
+	x86asm.ARPL:  true,
+	x86asm.BOUND: true,
+	x86asm.HLT:   true,
+	x86asm.IN:    true,
+	x86asm.INSB:  true,
+	x86asm.INSD:  true,
+	x86asm.INSW:  true,
+	x86asm.INTO:  true,
+	x86asm.OUT:   true,
+	x86asm.OUTSB: true,
+	x86asm.OUTSD: true,
+	x86asm.OUTSW: true,
+}
+
+// dataInCode returns the first instruction of the given function which is
+// likely data decoded as code. The boolean return value indicates success.
+func (dis *Disasm) dataInCode(f *Func) (*Issue, bool) {
+	var blockAddrs []bin.Address
+	for blockAddr := range f.Blocks {
+		blockAddrs = append(blockAddrs, blockAddr)
+	}
+	sort.Sort(bin.Addresses(blockAddrs))
+	for _, blockAddr := range blockAddrs {
+		block := f.Blocks[blockAddr]
+		zeros := 0
+		for _, inst := range block.Insts {
+			var msg string
+			switch {
+			case dis.isData(inst.Addr):
+				msg = fmt.Sprintf("instruction `%v` inside data fragment", inst)
+			case suspiciousOps[inst.Op]:
+				msg = fmt.Sprintf("unexpected %v instruction in user-mode code", inst.Op)
+			case dis.isZeroInst(inst):
+				// `add [eax], al`; encoded as 00 00.
+				zeros++
+				if zeros == 2 {
+					msg = "run of zero bytes decoded as instructions"
+				}
+			default:
+				zeros = 0
+			}
+			if len(msg) > 0 {
+				return &Issue{Kind: IssueDataInCode, Addr: inst.Addr, Func: f.Addr, Msg: msg}, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// isData reports whether the given address is part of a data fragment.
+func (dis *Disasm) isData(addr bin.Address) bool {
+	less := func(i int) bool {
+		return addr < dis.Frags[i].Addr
+	}
+	index := sort.Search(len(dis.Frags), less)
+	if index == 0 {
+		return false
+	}
+	return dis.Frags[index-1].Kind == disasm.KindData
+}
+
+// isZeroInst reports whether the given instruction is encoded as two zero
+// bytes (i.e. `add [eax], al`).
+func (dis *Disasm) isZeroInst(inst *Inst) bool {
+	if inst.Len != 2 {
+		return false
+	}
+	code, ok := dis.File.AddressSpace().Bytes(inst.Addr, 0)
+	return ok && len(code) >= 2 && code[0] == 0 && code[1] == 0
+}
@@ -0,0 +1,400 @@
+package x86
+
+import (
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// Maximum number of iterations simulated to derive the trip count of a loop.
+const maxTripCount = 1 << 20
+
+// A Loop is a natural loop of a function; i.e. a strongly connected set of
+// basic blocks with a single entry, the loop header, which dominates the
+// sources of all back edges, the loop latches.
+type Loop struct {
+	// Address of the loop header.
+	Header bin.Address
+	// Addresses of the loop latches, in ascending order.
+	Latches []bin.Address
+	// Addresses of the basic blocks of the loop body (including the header),
+	// in ascending order.
+	Blocks []bin.Address
+	// Addresses of the loop exits; i.e. the basic blocks outside of the loop
+	// targeted from within the loop, in ascending order.
+	Exits []bin.Address
+	// Number of iterations of the loop body; or 0 if unknown.
+	TripCount uint64
+	// Innermost enclosing loop; or nil if outermost loop.
+	Parent *Loop
+}
+
+// Contains reports whether the given basic block is part of the loop body.
+func (loop *Loop) Contains(blockAddr bin.Address) bool {
+	i := sort.Search(len(loop.Blocks), func(i int) bool {
+		return loop.Blocks[i] >= blockAddr
+	})
+	return i < len(loop.Blocks) && loop.Blocks[i] == blockAddr
+}
+
+// Loops returns the natural loops of the given function, ordered by header
+// address. Loops sharing a header are merged.
+//
+// The trip count is derived for counted loops with a single exiting basic
+// block, which compares an induction variable against a constant bound. The
+// induction variable (register or memory location) is initialized to a
+// constant before the loop, and updated by a constant step exactly once within
+// the loop body.
+//
+//    mov dword [ebp-4], 0
+//    jmp cond
+//    body:
+//       ...
+//       add dword [ebp-4], 1
+//    cond:
+//       cmp dword [ebp-4], 9
+//       jle body              ; trip count 10
+func (dis *Disasm) Loops(f *Func) []*Loop {
+	f = splitBlocks(f)
+	succs := make(map[bin.Address][]bin.Address)
+	preds := make(map[bin.Address][]bin.Address)
+	for blockAddr, block := range f.Blocks {
+		for _, target := range dis.Targets(block.Term, f.Addr) {
+			if _, ok := f.Blocks[target]; !ok {
+				continue
+			}
+			succs[blockAddr] = bin.InsertAddr(succs[blockAddr], target)
+			preds[target] = bin.InsertAddr(preds[target], blockAddr)
+		}
+	}
+	idom := dominators(f.Addr, succs, preds)
+	// Locate back edges, mapping from loop header to loop latches.
+	latches := make(map[bin.Address][]bin.Address)
+	for src, targets := range succs {
+		for _, target := range targets {
+			if dominates(idom, target, src) {
+				latches[target] = bin.InsertAddr(latches[target], src)
+			}
+		}
+	}
+	var loops []*Loop
+	for header, ls := range latches {
+		loop := &Loop{
+			Header:  header,
+			Latches: ls,
+		}
+		// Collect loop body by walking backwards from the latches to the
+		// header.
+		body := map[bin.Address]bool{header: true}
+		stack := append([]bin.Address(nil), ls...)
+		for len(stack) > 0 {
+			blockAddr := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if body[blockAddr] {
+				continue
+			}
+			body[blockAddr] = true
+			stack = append(stack, preds[blockAddr]...)
+		}
+		for blockAddr := range body {
+			loop.Blocks = bin.InsertAddr(loop.Blocks, blockAddr)
+			for _, target := range succs[blockAddr] {
+				if !body[target] {
+					loop.Exits = bin.InsertAddr(loop.Exits, target)
+				}
+			}
+		}
+		loop.TripCount = dis.tripCount(f, loop, preds[header])
+		loops = append(loops, loop)
+	}
+	sort.Slice(loops, func(i, j int) bool {
+		return loops[i].Header < loops[j].Header
+	})
+	// Locate innermost enclosing loops.
+	for _, loop := range loops {
+		for _, outer := range loops {
+			if outer == loop || !outer.Contains(loop.Header) || len(outer.Blocks) <= len(loop.Blocks) {
+				continue
+			}
+			if loop.Parent == nil || len(outer.Blocks) < len(loop.Parent.Blocks) {
+				loop.Parent = outer
+			}
+		}
+	}
+	return loops
+}
+
+// tripCount returns the number of iterations of the given counted loop; or 0
+// if unknown. Preds specifies the predecessors of the loop header.
+func (dis *Disasm) tripCount(f *Func, loop *Loop, preds []bin.Address) uint64 {
+	// Locate single exiting basic block and compare instruction.
+	var exiting *BasicBlock
+	for _, blockAddr := range loop.Blocks {
+		block := f.Blocks[blockAddr]
+		for _, target := range dis.Targets(block.Term, f.Addr) {
+			if !loop.Contains(target) {
+				if exiting != nil && exiting != block {
+					return 0
+				}
+				exiting = block
+			}
+		}
+	}
+	if exiting == nil || len(exiting.Insts) == 0 || !isJcc(exiting.Term.Op) {
+		return 0
+	}
+	cmp := exiting.Insts[len(exiting.Insts)-1]
+	if cmp.Op != x86asm.CMP {
+		return 0
+	}
+	bound, ok := cmp.Args[1].(x86asm.Imm)
+	if !ok {
+		return 0
+	}
+	iv := cmp.Args[0]
+	// Locate single update of induction variable within the loop.
+	var (
+		step        int64
+		updateBlock *BasicBlock
+		updateIndex int
+	)
+	for _, blockAddr := range loop.Blocks {
+		block := f.Blocks[blockAddr]
+		for i, inst := range block.Insts {
+			switch inst.Op {
+			case x86asm.CMP, x86asm.TEST, x86asm.PUSH:
+				// induction variable not modified.
+				continue
+			}
+			if inst.Args[0] != iv {
+				continue
+			}
+			if updateBlock != nil {
+				return 0
+			}
+			switch inst.Op {
+			case x86asm.INC:
+				step = 1
+			case x86asm.DEC:
+				step = -1
+			case x86asm.ADD, x86asm.SUB:
+				imm, ok := inst.Args[1].(x86asm.Imm)
+				if !ok {
+					return 0
+				}
+				step = int64(imm)
+				if inst.Op == x86asm.SUB {
+					step = -step
+				}
+			default:
+				return 0
+			}
+			updateBlock, updateIndex = block, i
+		}
+	}
+	if updateBlock == nil || step == 0 {
+		return 0
+	}
+	// Locate initialization of induction variable in the single predecessor of
+	// the loop header outside of the loop.
+	var init *Inst
+	for _, pred := range preds {
+		if loop.Contains(pred) {
+			continue
+		}
+		if init != nil {
+			return 0
+		}
+		block := f.Blocks[pred]
+		for _, inst := range block.Insts {
+			if inst.Args[0] == iv && inst.Op != x86asm.CMP && inst.Op != x86asm.PUSH {
+				init = inst
+			}
+		}
+		if init == nil {
+			return 0
+		}
+	}
+	if init == nil || init.Op != x86asm.MOV {
+		return 0
+	}
+	start, ok := init.Args[1].(x86asm.Imm)
+	if !ok {
+		return 0
+	}
+	// Simulate loop iterations.
+	next := exiting.Term.Addr + bin.Address(exiting.Term.Len)
+	jumpStays := true
+	if targets := dis.Addrs(exiting.Term.Args[0], exiting.Term.Addr, next); len(targets) != 1 || !loop.Contains(targets[0]) {
+		jumpStays = false
+	}
+	updateFirst := updateBlock != exiting && exiting.Addr != loop.Header
+	if updateBlock == exiting {
+		updateFirst = updateIndex < len(exiting.Insts)-1
+	}
+	size := argSize(iv, cmp)
+	v := int64(start)
+	for n := uint64(0); n < maxTripCount; n++ {
+		if updateFirst {
+			v += step
+		}
+		if jccTaken(exiting.Term.Op, v, int64(bound), size) != jumpStays {
+			if updateFirst {
+				return n + 1
+			}
+			return n
+		}
+		if !updateFirst {
+			v += step
+		}
+	}
+	return 0
+}
+
+// splitBlocks returns a copy of the given function, where basic blocks
+// overlapping the start of succeeding basic blocks (e.g. the target of a jump
+// into the middle of a previously decoded basic block) are truncated to fall
+// through into the succeeding basic block.
+func splitBlocks(f *Func) *Func {
+	g := &Func{
+		Addr:   f.Addr,
+		Blocks: make(map[bin.Address]*BasicBlock),
+	}
+	for blockAddr, block := range f.Blocks {
+		g.Blocks[blockAddr] = block
+		insts := block.Insts
+		if !block.Term.IsDummyTerm() {
+			insts = append(insts[:len(insts):len(insts)], block.Term)
+		}
+		for i, inst := range insts {
+			if _, ok := f.Blocks[inst.Addr]; !ok || inst.Addr == blockAddr {
+				continue
+			}
+			g.Blocks[blockAddr] = &BasicBlock{
+				Addr:  blockAddr,
+				Insts: block.Insts[:i],
+				Term:  &Inst{Addr: inst.Addr},
+			}
+			break
+		}
+	}
+	return g
+}
+
+// jccTaken reports whether the given conditional jump is taken after comparing
+// x against y, where size specifies the operand size in bits.
+func jccTaken(op x86asm.Op, x, y int64, size int) bool {
+	// Sign-extended and zero-extended operands.
+	shift := uint(64 - size)
+	sx, sy := x<<shift>>shift, y<<shift>>shift
+	ux, uy := uint64(x)<<shift>>shift, uint64(y)<<shift>>shift
+	switch op {
+	case x86asm.JE:
+		return ux == uy
+	case x86asm.JNE:
+		return ux != uy
+	case x86asm.JL:
+		return sx < sy
+	case x86asm.JLE:
+		return sx <= sy
+	case x86asm.JG:
+		return sx > sy
+	case x86asm.JGE:
+		return sx >= sy
+	case x86asm.JB:
+		return ux < uy
+	case x86asm.JBE:
+		return ux <= uy
+	case x86asm.JA:
+		return ux > uy
+	case x86asm.JAE:
+		return ux >= uy
+	}
+	return false
+}
+
+// isJcc reports whether the given instruction operation is a conditional jump
+// used for comparisons.
+func isJcc(op x86asm.Op) bool {
+	switch op {
+	case x86asm.JE, x86asm.JNE, x86asm.JL, x86asm.JLE, x86asm.JG, x86asm.JGE, x86asm.JB, x86asm.JBE, x86asm.JA, x86asm.JAE:
+		return true
+	}
+	return false
+}
+
+// dominators returns the immediate dominators of the basic blocks reachable
+// from the given entry, based on the algorithm of Cooper, Harvey and Kennedy.
+//
+// ref: https://www.cs.rice.edu/~keith/EMBED/dom.pdf
+func dominators(entry bin.Address, succs, preds map[bin.Address][]bin.Address) map[bin.Address]bin.Address {
+	// Compute reverse postorder.
+	var order []bin.Address
+	visited := make(map[bin.Address]bool)
+	var visit func(blockAddr bin.Address)
+	visit = func(blockAddr bin.Address) {
+		visited[blockAddr] = true
+		for _, succ := range succs[blockAddr] {
+			if !visited[succ] {
+				visit(succ)
+			}
+		}
+		order = append(order, blockAddr)
+	}
+	visit(entry)
+	rpo := make(map[bin.Address]int)
+	for i := range order {
+		blockAddr := order[len(order)-1-i]
+		rpo[blockAddr] = i
+	}
+	idom := map[bin.Address]bin.Address{entry: entry}
+	intersect := func(a, b bin.Address) bin.Address {
+		for a != b {
+			for rpo[a] > rpo[b] {
+				a = idom[a]
+			}
+			for rpo[b] > rpo[a] {
+				b = idom[b]
+			}
+		}
+		return a
+	}
+	for changed := true; changed; {
+		changed = false
+		for i := len(order) - 2; i >= 0; i-- {
+			blockAddr := order[i]
+			var newIdom bin.Address
+			found := false
+			for _, pred := range preds[blockAddr] {
+				if _, ok := idom[pred]; !ok {
+					continue
+				}
+				if !found {
+					newIdom, found = pred, true
+					continue
+				}
+				newIdom = intersect(pred, newIdom)
+			}
+			if found && idom[blockAddr] != newIdom {
+				idom[blockAddr] = newIdom
+				changed = true
+			}
+		}
+	}
+	return idom
+}
+
+// dominates reports whether the basic block a dominates the basic block b.
+func dominates(idom map[bin.Address]bin.Address, a, b bin.Address) bool {
+	for {
+		if a == b {
+			return true
+		}
+		parent, ok := idom[b]
+		if !ok || parent == b {
+			return false
+		}
+		b = parent
+	}
+}
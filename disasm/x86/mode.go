@@ -0,0 +1,58 @@
+package x86
+
+import (
+	"github.com/decomp/exp/bin"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// Mode switching.
+//
+// Code of some binary executables is executed in more than one processor mode;
+// e.g. boot loaders switching from real mode to protected mode through a far
+// jump, or 32-bit code thunking into 16-bit code. The same bytes may thus be
+// decoded as overlapping views in different processor modes.
+//
+// The processor mode of each basic block is recorded in BasicBlock.Mode. Basic
+// blocks inherit the processor mode of their first predecessor, unless
+// overridden by the associated modes.json file, which maps basic block
+// addresses to processor modes.
+//
+//    {"0x7C50": 32}
+//
+// Direct far jumps (e.g. `jmp 0x8:0x7c50`) are mode-switch terminators; the far
+// target is decoded in the processor mode specified by modes.json.
+
+// blockMode returns the processor mode of the basic block at the given
+// address; as overridden by the user, or the given processor mode of its
+// predecessor otherwise.
+func (dis *Disasm) blockMode(addr bin.Address, pred int) int {
+	if mode, ok := dis.Modes[addr]; ok {
+		return mode
+	}
+	return pred
+}
+
+// FarTarget returns the linear address of the target of the given direct far
+// jump or call (e.g. `jmp 0x8:0x7c50`). Segment selectors of flat memory models
+// and of 32-bit far pointers (ptr16:32; e.g. the switch to protected mode of
+// boot loaders) are assumed to have a base address of 0. The boolean return
+// value indicates success.
+func (dis *Disasm) FarTarget(inst *Inst) (bin.Address, bool) {
+	if inst.Op != x86asm.LJMP && inst.Op != x86asm.LCALL {
+		return 0, false
+	}
+	seg, ok := inst.Args[0].(x86asm.Imm)
+	if !ok {
+		// indirect far jump or call (e.g. `jmp far [eax]`).
+		return 0, false
+	}
+	off, ok := inst.Args[1].(x86asm.Imm)
+	if !ok {
+		return 0, false
+	}
+	as := dis.File.AddressSpace()
+	if as.Segments == bin.SegmentFlat || inst.DataSize == 32 {
+		return bin.Address(off), true
+	}
+	return as.Linear(bin.FarAddr{Seg: uint16(seg), Off: uint16(off)}), true
+}
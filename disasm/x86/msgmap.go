@@ -0,0 +1,419 @@
+package x86
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+)
+
+// Handlers are the window message handlers and COM interface functions of a
+// binary executable, recovered from MFC message maps and ATL COM maps in data
+// sections. Handlers are only referenced through these tables, and are
+// otherwise left undiscovered.
+type Handlers struct {
+	// MFC message maps.
+	MsgMaps []*MsgMap
+	// ATL COM maps.
+	COMMaps []*COMMap
+}
+
+// A MsgMap is an MFC message map (AFX_MSGMAP), mapping window messages of a
+// class to handler functions.
+//
+//	struct AFX_MSGMAP {
+//	   const AFX_MSGMAP* (PASCAL* pfnGetBaseMap)();
+//	   const AFX_MSGMAP_ENTRY* lpEntries;
+//	};
+type MsgMap struct {
+	// Address of the message map.
+	Addr bin.Address
+	// Address of the function returning the message map of the base class.
+	GetBaseMap bin.Address
+	// Message map entries, excluding the terminating entry.
+	Entries []*MsgMapEntry
+}
+
+// A MsgMapEntry is an entry of an MFC message map (AFX_MSGMAP_ENTRY).
+//
+//	struct AFX_MSGMAP_ENTRY {
+//	   UINT nMessage;
+//	   UINT nCode;
+//	   UINT nID;
+//	   UINT nLastID;
+//	   UINT_PTR nSig;
+//	   AFX_PMSG pfn;
+//	};
+type MsgMapEntry struct {
+	// Address of the entry.
+	Addr bin.Address
+	// Window message (e.g. WM_PAINT).
+	Msg uint32
+	// Control code or WM_NOTIFY code.
+	Code uint32
+	// Control ID; or 0 for window messages.
+	ID uint32
+	// Last control ID of control ID ranges.
+	LastID uint32
+	// Signature of the handler function (AfxSig).
+	Sig uint64
+	// Address of the handler function.
+	Handler bin.Address
+}
+
+// A COMMap is an ATL COM map (array of _ATL_INTMAP_ENTRY), mapping interface
+// IDs of a class to interface pointers; either by offset into the object or
+// through a function.
+type COMMap struct {
+	// Address of the COM map.
+	Addr bin.Address
+	// COM map entries, excluding the terminating entry.
+	Entries []*COMMapEntry
+}
+
+// A COMMapEntry is an entry of an ATL COM map.
+//
+//	struct _ATL_INTMAP_ENTRY {
+//	   const IID* piid;
+//	   DWORD_PTR dw;
+//	   _ATL_CREATORARGFUNC* pFunc;
+//	};
+type COMMapEntry struct {
+	// Address of the entry.
+	Addr bin.Address
+	// Interface ID (e.g. "{00000000-0000-0000-C000-000000000046}"); or empty
+	// if not present (e.g. COM_INTERFACE_ENTRY_CHAIN).
+	IID string
+	// Offset of the interface into the object, or data passed to Func.
+	Offset uint64
+	// Address of the function returning the interface pointer; or 0 for
+	// interfaces at an offset of the object (_ATL_SIMPLEMAPENTRY).
+	Func bin.Address
+}
+
+// FuncAddrs returns the addresses of the handler functions, in ascending order.
+func (h *Handlers) FuncAddrs() []bin.Address {
+	var funcAddrs []bin.Address
+	for funcAddr := range h.Names() {
+		funcAddrs = append(funcAddrs, funcAddr)
+	}
+	sort.Sort(bin.Addresses(funcAddrs))
+	return funcAddrs
+}
+
+// Names returns the names of the handler functions, by address. Handlers of
+// window messages are named after the corresponding MFC handler (e.g.
+// OnPaint_401230), and handlers of commands after the control ID (e.g.
+// OnCommand_57600_401230).
+func (h *Handlers) Names() map[bin.Address]string {
+	names := make(map[bin.Address]string)
+	add := func(addr bin.Address, name string) {
+		if _, ok := names[addr]; !ok {
+			names[addr] = fmt.Sprintf("%s_%06X", name, uint64(addr))
+		}
+	}
+	for _, m := range h.MsgMaps {
+		for _, entry := range m.Entries {
+			add(entry.Handler, entry.handlerName())
+		}
+		add(m.GetBaseMap, "GetThisMessageMap")
+	}
+	for _, m := range h.COMMaps {
+		for _, entry := range m.Entries {
+			if entry.Func != 0 {
+				add(entry.Func, "ComMapFunc")
+			}
+		}
+	}
+	return names
+}
+
+// Maximum number of entries of message maps and COM maps.
+const maxMapEntries = 4096
+
+// FindHandlers locates the MFC message maps and ATL COM maps in the data
+// sections of the binary executable.
+//
+// Message maps are recognized as a pointer to executable code (pfnGetBaseMap)
+// followed by a pointer to a table of entries, terminated by a zero entry, the
+// handlers of which are executable code. COM maps are recognized as a table of
+// entries, the first of which is a simple map entry (as required by ATL),
+// terminated by a zero entry.
+func (dis *Disasm) FindHandlers() *Handlers {
+	h := &Handlers{}
+	switch dis.File.Arch {
+	case bin.ArchX86_32, bin.ArchX86_64:
+		// supported.
+	default:
+		// MFC and ATL target 32- and 64-bit Windows.
+		return h
+	}
+	order := dis.File.ByteOrder()
+	ptrSize := dis.File.PtrSize()
+	for _, sect := range dis.File.Sections {
+		if sect.Perm&bin.PermX != 0 {
+			// skip code sections.
+			continue
+		}
+		for off := 0; off+2*ptrSize <= len(sect.Data); off += ptrSize {
+			addr := sect.Addr + bin.Address(off)
+			x := readPtr(sect.Data[off:], ptrSize, order)
+			if !dis.isExec(x) {
+				if x == 1 && off >= 2*ptrSize {
+					// The first entry of COM maps is a simple map entry; pFunc is
+					// preceded by the interface ID and offset.
+					if m, ok := dis.comMap(addr - 2*bin.Address(ptrSize)); ok {
+						h.COMMaps = append(h.COMMaps, m)
+						// skip past terminating entry, as the remaining simple map
+						// entries are also valid COM maps.
+						off += 3 * len(m.Entries) * ptrSize
+					}
+				}
+				continue
+			}
+			if m, ok := dis.msgMap(addr); ok {
+				h.MsgMaps = append(h.MsgMaps, m)
+			}
+		}
+	}
+	if len(h.MsgMaps) > 0 || len(h.COMMaps) > 0 {
+		dbg.Printf("located %d MFC message maps and %d ATL COM maps", len(h.MsgMaps), len(h.COMMaps))
+	}
+	return h
+}
+
+// msgMap parses the MFC message map at the given address. The boolean return
+// value indicates success.
+func (dis *Disasm) msgMap(addr bin.Address) (*MsgMap, bool) {
+	ptrSize := bin.Address(dis.File.PtrSize())
+	getBaseMap, err := dis.File.ReadPointer(addr)
+	if err != nil {
+		return nil, false
+	}
+	entriesAddr, err := dis.File.ReadPointer(addr + ptrSize)
+	if err != nil || entriesAddr == 0 || dis.isExec(entriesAddr) {
+		return nil, false
+	}
+	m := &MsgMap{
+		Addr:       addr,
+		GetBaseMap: getBaseMap,
+	}
+	// AFX_MSGMAP_ENTRY; four 32-bit integers followed by two pointers.
+	entrySize := 16 + 2*ptrSize
+	for i := 0; i < maxMapEntries; i++ {
+		entryAddr := entriesAddr + bin.Address(i)*entrySize
+		entry, ok := dis.msgMapEntry(entryAddr)
+		if !ok {
+			return nil, false
+		}
+		if entry.Msg == 0 && entry.Sig == 0 && entry.Handler == 0 {
+			// terminating entry.
+			return m, len(m.Entries) > 0
+		}
+		if entry.Msg == 0 || entry.ID > entry.LastID || entry.Sig > maxAfxSig || !dis.isExec(entry.Handler) {
+			return nil, false
+		}
+		m.Entries = append(m.Entries, entry)
+	}
+	return nil, false
+}
+
+// Maximum value of AfxSig signatures of message map handlers.
+const maxAfxSig = 0x400
+
+// msgMapEntry parses the MFC message map entry at the given address. The
+// boolean return value indicates success.
+func (dis *Disasm) msgMapEntry(addr bin.Address) (*MsgMapEntry, bool) {
+	ptrSize := bin.Address(dis.File.PtrSize())
+	var fields [4]uint32
+	for i := range fields {
+		v, err := dis.File.ReadUint32(addr + bin.Address(4*i))
+		if err != nil {
+			return nil, false
+		}
+		fields[i] = v
+	}
+	sig, err := dis.File.ReadPointer(addr + 16)
+	if err != nil {
+		return nil, false
+	}
+	handler, err := dis.File.ReadPointer(addr + 16 + ptrSize)
+	if err != nil {
+		return nil, false
+	}
+	entry := &MsgMapEntry{
+		Addr:    addr,
+		Msg:     fields[0],
+		Code:    fields[1],
+		ID:      fields[2],
+		LastID:  fields[3],
+		Sig:     uint64(sig),
+		Handler: handler,
+	}
+	return entry, true
+}
+
+// comMap parses the ATL COM map at the given address. The boolean return value
+// indicates success.
+func (dis *Disasm) comMap(addr bin.Address) (*COMMap, bool) {
+	ptrSize := bin.Address(dis.File.PtrSize())
+	m := &COMMap{
+		Addr: addr,
+	}
+	for i := 0; i < maxMapEntries; i++ {
+		entryAddr := m.Addr + bin.Address(i)*3*ptrSize
+		var fields [3]bin.Address
+		for j := range fields {
+			v, err := dis.File.ReadPointer(entryAddr + bin.Address(j)*ptrSize)
+			if err != nil {
+				return nil, false
+			}
+			fields[j] = v
+		}
+		piid, dw, pFunc := fields[0], fields[1], fields[2]
+		if piid == 0 && dw == 0 && pFunc == 0 {
+			// terminating entry.
+			return m, len(m.Entries) > 0
+		}
+		entry := &COMMapEntry{
+			Addr:   entryAddr,
+			Offset: uint64(dw),
+		}
+		switch {
+		case pFunc == 1:
+			// _ATL_SIMPLEMAPENTRY; interface at offset of object.
+			if dw >= 0x10000 {
+				return nil, false
+			}
+		case dis.isExec(pFunc):
+			entry.Func = pFunc
+		default:
+			return nil, false
+		}
+		if piid != 0 {
+			iid, ok := dis.guid(piid)
+			if !ok {
+				return nil, false
+			}
+			entry.IID = iid
+		} else if i == 0 || entry.Func == 0 {
+			// Only COM map entries with functions lack interface IDs (e.g.
+			// COM_INTERFACE_ENTRY_CHAIN).
+			return nil, false
+		}
+		m.Entries = append(m.Entries, entry)
+	}
+	return nil, false
+}
+
+// guid returns the string representation of the GUID at the given address. The
+// boolean return value indicates success.
+func (dis *Disasm) guid(addr bin.Address) (string, bool) {
+	if dis.isExec(addr) {
+		return "", false
+	}
+	data, ok := dis.File.AddressSpace().Bytes(addr, 0)
+	if !ok || len(data) < 16 {
+		return "", false
+	}
+	return formatGUID(data), true
+}
+
+// handlerName returns the name of the handler of the given message map entry,
+// based on the window message and control ID.
+func (entry *MsgMapEntry) handlerName() string {
+	switch entry.Msg {
+	case wmCommand:
+		if entry.Code == cnUpdateCommandUI {
+			return fmt.Sprintf("OnUpdate_%d", entry.ID)
+		}
+		return fmt.Sprintf("OnCommand_%d", entry.ID)
+	case wmNotify:
+		return fmt.Sprintf("OnNotify_%d", entry.ID)
+	}
+	if name, ok := msgHandlerNames[entry.Msg]; ok {
+		return name
+	}
+	if entry.Msg >= 0xC000 {
+		// Registered window messages (RegisterWindowMessage) are only known at
+		// runtime.
+		return "OnRegisteredMessage"
+	}
+	return fmt.Sprintf("OnMessage_%X", entry.Msg)
+}
+
+// ### [ Helper functions ] ####################################################
+
+// Window messages and control codes.
+const (
+	// WM_NOTIFY.
+	wmNotify = 0x004E
+	// WM_COMMAND.
+	wmCommand = 0x0111
+	// CN_UPDATE_COMMAND_UI.
+	cnUpdateCommandUI = 0xFFFFFFFF
+)
+
+// msgHandlerNames maps from window message to the name of the corresponding
+// MFC handler function.
+var msgHandlerNames = map[uint32]string{
+	0x0001: "OnCreate",            // WM_CREATE
+	0x0002: "OnDestroy",           // WM_DESTROY
+	0x0003: "OnMove",              // WM_MOVE
+	0x0005: "OnSize",              // WM_SIZE
+	0x0006: "OnActivate",          // WM_ACTIVATE
+	0x0007: "OnSetFocus",          // WM_SETFOCUS
+	0x0008: "OnKillFocus",         // WM_KILLFOCUS
+	0x000A: "OnEnable",            // WM_ENABLE
+	0x000F: "OnPaint",             // WM_PAINT
+	0x0010: "OnClose",             // WM_CLOSE
+	0x0011: "OnQueryEndSession",   // WM_QUERYENDSESSION
+	0x0014: "OnEraseBkgnd",        // WM_ERASEBKGND
+	0x0016: "OnEndSession",        // WM_ENDSESSION
+	0x0018: "OnShowWindow",        // WM_SHOWWINDOW
+	0x001C: "OnActivateApp",       // WM_ACTIVATEAPP
+	0x0020: "OnSetCursor",         // WM_SETCURSOR
+	0x0021: "OnMouseActivate",     // WM_MOUSEACTIVATE
+	0x0024: "OnGetMinMaxInfo",     // WM_GETMINMAXINFO
+	0x002B: "OnDrawItem",          // WM_DRAWITEM
+	0x002C: "OnMeasureItem",       // WM_MEASUREITEM
+	0x0046: "OnWindowPosChanging", // WM_WINDOWPOSCHANGING
+	0x0047: "OnWindowPosChanged",  // WM_WINDOWPOSCHANGED
+	0x007B: "OnContextMenu",       // WM_CONTEXTMENU
+	0x0082: "OnNcDestroy",         // WM_NCDESTROY
+	0x0084: "OnNcHitTest",         // WM_NCHITTEST
+	0x0100: "OnKeyDown",           // WM_KEYDOWN
+	0x0101: "OnKeyUp",             // WM_KEYUP
+	0x0102: "OnChar",              // WM_CHAR
+	0x0104: "OnSysKeyDown",        // WM_SYSKEYDOWN
+	0x0110: "OnInitDialog",        // WM_INITDIALOG
+	0x0112: "OnSysCommand",        // WM_SYSCOMMAND
+	0x0113: "OnTimer",             // WM_TIMER
+	0x0114: "OnHScroll",           // WM_HSCROLL
+	0x0115: "OnVScroll",           // WM_VSCROLL
+	0x0117: "OnInitMenuPopup",     // WM_INITMENUPOPUP
+	0x0133: "OnCtlColor",          // WM_CTLCOLOREDIT
+	0x0134: "OnCtlColor",          // WM_CTLCOLORLISTBOX
+	0x0135: "OnCtlColor",          // WM_CTLCOLORBTN
+	0x0136: "OnCtlColor",          // WM_CTLCOLORDLG
+	0x0137: "OnCtlColor",          // WM_CTLCOLORSCROLLBAR
+	0x0138: "OnCtlColor",          // WM_CTLCOLORSTATIC
+	0x0200: "OnMouseMove",         // WM_MOUSEMOVE
+	0x0201: "OnLButtonDown",       // WM_LBUTTONDOWN
+	0x0202: "OnLButtonUp",         // WM_LBUTTONUP
+	0x0203: "OnLButtonDblClk",     // WM_LBUTTONDBLCLK
+	0x0204: "OnRButtonDown",       // WM_RBUTTONDOWN
+	0x0205: "OnRButtonUp",         // WM_RBUTTONUP
+	0x020A: "OnMouseWheel",        // WM_MOUSEWHEEL
+	0x0214: "OnSizing",            // WM_SIZING
+	0x0233: "OnDropFiles",         // WM_DROPFILES
+}
+
+// readPtr reads a pointer of the given size in bytes from buf.
+func readPtr(buf []byte, ptrSize int, order binary.ByteOrder) bin.Address {
+	if ptrSize == 8 {
+		return bin.Address(order.Uint64(buf))
+	}
+	return bin.Address(order.Uint32(buf))
+}
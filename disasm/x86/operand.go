@@ -0,0 +1,142 @@
+package x86
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// Symbolic operands.
+//
+// x86asm.IntelSyntax only resolves the symbols of absolute and RIP-relative
+// memory references, immediates of MOV and PUSH, and branch targets. The
+// displacement of indexed memory references (e.g. arrays and jump tables) is
+// printed numerically.
+//
+//    mov eax, dword ptr [4*ecx+0x403000]
+//
+// Symbolic operands resolve the displacement of memory references using the
+// given symbol lookup (e.g. of the names of imports, exports and imported
+// program annotations).
+//
+//    mov eax, dword ptr [4*ecx+g_403000]
+
+// A MemExpr is the symbolic address expression of a memory reference;
+// Segment:[Base + Scale*Index + Sym + Off].
+type MemExpr struct {
+	// Segment register; or 0 if none.
+	Segment x86asm.Reg
+	// Base register; or 0 if none.
+	Base x86asm.Reg
+	// Index register; or 0 if none.
+	Index x86asm.Reg
+	// Scale of the index register.
+	Scale uint8
+	// Symbol name of the displacement; or empty if unresolved.
+	Sym string
+	// Offset from the symbol; or the displacement if Sym is empty.
+	Off int64
+}
+
+// Expr returns the symbolic address expression of the memory reference,
+// resolving the displacement using the given symbol lookup. The displacement
+// of RIP-relative memory references is resolved as an absolute address.
+func (mem *Mem) Expr(symname x86asm.SymLookup) MemExpr {
+	expr := MemExpr{
+		Segment: mem.Mem.Segment,
+		Base:    mem.Mem.Base,
+		Index:   mem.Mem.Index,
+		Scale:   mem.Mem.Scale,
+		Off:     mem.Disp,
+	}
+	if mem.Disp == 0 || symname == nil {
+		return expr
+	}
+	addr := uint64(mem.Disp)
+	if isIP(expr.Base) && mem.Parent != nil {
+		// RIP-relative memory reference.
+		addr = uint64(mem.Parent.Addr) + uint64(mem.Parent.Len) + uint64(mem.Disp)
+		expr.Base = 0
+	}
+	if sym, base := symname(addr); len(sym) > 0 {
+		expr.Sym = sym
+		expr.Off = int64(addr - base)
+	}
+	return expr
+}
+
+// String returns the string representation of the address expression in Intel
+// syntax (e.g. "[4*ecx+g_403000+0x4]").
+func (expr MemExpr) String() string {
+	var terms []string
+	if expr.Base != 0 {
+		terms = append(terms, strings.ToLower(expr.Base.String()))
+	}
+	if expr.Index != 0 {
+		index := strings.ToLower(expr.Index.String())
+		if expr.Scale > 1 {
+			index = fmt.Sprintf("%d*%s", expr.Scale, index)
+		}
+		terms = append(terms, index)
+	}
+	if len(expr.Sym) > 0 {
+		terms = append(terms, expr.Sym)
+	}
+	s := "[" + strings.Join(terms, "+")
+	switch {
+	case len(terms) == 0:
+		s += fmt.Sprintf("%#x", uint64(expr.Off))
+	case expr.Off != 0:
+		s += fmt.Sprintf("%+#x", expr.Off)
+	}
+	s += "]"
+	if expr.Segment != 0 {
+		s = strings.ToLower(expr.Segment.String()) + ":" + s
+	}
+	return s
+}
+
+// FormatInst returns the Intel syntax of the given instruction, resolving the
+// addresses of operands symbolically using the given symbol lookup, which
+// returns the symbol name and symbol address of a given address (as used by
+// x86asm.IntelSyntax). The displacement of indexed memory references is
+// resolved in addition to the operands resolved by x86asm.IntelSyntax.
+func FormatInst(inst *Inst, symname x86asm.SymLookup) string {
+	asm := x86asm.IntelSyntax(inst.Inst, uint64(inst.Addr), symname)
+	for i, arg := range inst.Args {
+		if arg == nil {
+			break
+		}
+		a, ok := arg.(x86asm.Mem)
+		if !ok || a.Disp == 0 || (a.Base == 0 && a.Index == 0) || isIP(a.Base) {
+			// Absolute and RIP-relative memory references are resolved by
+			// x86asm.IntelSyntax.
+			continue
+		}
+		expr := inst.Mem(i).Expr(symname)
+		if len(expr.Sym) == 0 {
+			continue
+		}
+		// The displacement of memory references with a base or index register
+		// is printed as a signed hexadecimal offset by x86asm.IntelSyntax.
+		disp := fmt.Sprintf("%+#x]", a.Disp)
+		sym := "+" + expr.Sym
+		if expr.Off != 0 {
+			sym += fmt.Sprintf("%+#x", expr.Off)
+		}
+		asm = strings.Replace(asm, disp, sym+"]", 1)
+	}
+	return asm
+}
+
+// ### [ Helper functions ] ####################################################
+
+// isIP reports whether the given register is an instruction pointer register.
+func isIP(reg x86asm.Reg) bool {
+	switch reg {
+	case x86asm.IP, x86asm.EIP, x86asm.RIP:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,202 @@
+package x86
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// A Program is a decoded program; an immutable snapshot of the decoded
+// functions of a binary executable, which may be shared by concurrent
+// consumers (e.g. lifters and analyses). The functions, basic blocks and
+// instructions of a program must not be modified once created.
+type Program struct {
+	// Function addresses, in ascending order.
+	FuncAddrs []bin.Address
+	// Decoded functions, mapped from function address.
+	funcs map[bin.Address]*Func
+	// Basic block addresses, in ascending order.
+	blockAddrs []bin.Address
+	// Basic blocks, mapped from basic block address. Basic blocks shared by
+	// functions (e.g. function chunks) are indexed once, from the function with
+	// the lowest address.
+	blocks map[bin.Address]*BasicBlock
+	// Interval tree of the address ranges of basic blocks.
+	blockIndex *bin.IntervalTree
+	// Instructions, including terminators, mapped from instruction address.
+	insts map[bin.Address]*Inst
+}
+
+// NewProgram returns a new program of the given decoded functions, indexing
+// their basic blocks and instructions.
+func NewProgram(fs []*Func) *Program {
+	prog := &Program{
+		funcs:  make(map[bin.Address]*Func),
+		blocks: make(map[bin.Address]*BasicBlock),
+		insts:  make(map[bin.Address]*Inst),
+	}
+	for _, f := range fs {
+		if _, ok := prog.funcs[f.Addr]; ok {
+			continue
+		}
+		prog.FuncAddrs = append(prog.FuncAddrs, f.Addr)
+		prog.funcs[f.Addr] = f
+	}
+	sort.Sort(bin.Addresses(prog.FuncAddrs))
+	for _, f := range prog.Funcs() {
+		for _, inst := range f.Insts() {
+			if _, ok := prog.insts[inst.Addr]; !ok {
+				prog.insts[inst.Addr] = inst
+			}
+		}
+		for blockAddr, block := range f.Blocks {
+			if _, ok := prog.blocks[blockAddr]; ok {
+				continue
+			}
+			prog.blockAddrs = append(prog.blockAddrs, blockAddr)
+			prog.blocks[blockAddr] = block
+		}
+	}
+	sort.Sort(bin.Addresses(prog.blockAddrs))
+	ivs := make([]bin.Interval, 0, len(prog.blockAddrs))
+	for _, blockAddr := range prog.blockAddrs {
+		block := prog.blocks[blockAddr]
+		end := block.Term.Addr + bin.Address(block.Term.Len)
+		ivs = append(ivs, bin.Interval{Start: blockAddr, End: end, Value: block})
+	}
+	prog.blockIndex = bin.NewIntervalTree(ivs)
+	return prog
+}
+
+// FuncAt returns the decoded function at the given address. The boolean return
+// value indicates success.
+func (prog *Program) FuncAt(addr bin.Address) (*Func, bool) {
+	f, ok := prog.funcs[addr]
+	return f, ok
+}
+
+// BlockAt returns the basic block at the given address. The boolean return
+// value indicates success.
+func (prog *Program) BlockAt(addr bin.Address) (*BasicBlock, bool) {
+	block, ok := prog.blocks[addr]
+	return block, ok
+}
+
+// BlockContaining returns the basic block containing the given address; i.e.
+// the basic block with the highest address of those with an instruction
+// overlapping the given address. The boolean return value indicates success.
+func (prog *Program) BlockContaining(addr bin.Address) (*BasicBlock, bool) {
+	// Basic blocks may overlap (e.g. jumps into the middle of instructions).
+	if iv, ok := prog.blockIndex.Last(addr); ok {
+		return iv.Value.(*BasicBlock), true
+	}
+	return nil, false
+}
+
+// InstAt returns the instruction at the given address. The boolean return value
+// indicates success.
+func (prog *Program) InstAt(addr bin.Address) (*Inst, bool) {
+	inst, ok := prog.insts[addr]
+	return inst, ok
+}
+
+// Funcs returns the functions of the program in address order.
+func (prog *Program) Funcs() []*Func {
+	fs := make([]*Func, 0, len(prog.FuncAddrs))
+	for _, funcAddr := range prog.FuncAddrs {
+		fs = append(fs, prog.funcs[funcAddr])
+	}
+	return fs
+}
+
+// Blocks returns the basic blocks of the program in address order.
+func (prog *Program) Blocks() []*BasicBlock {
+	blocks := make([]*BasicBlock, 0, len(prog.blockAddrs))
+	for _, blockAddr := range prog.blockAddrs {
+		blocks = append(blocks, prog.blocks[blockAddr])
+	}
+	return blocks
+}
+
+// Insts returns the instructions of the program in address order, including
+// terminators.
+func (prog *Program) Insts() []*Inst {
+	var instAddrs bin.Addresses
+	for instAddr := range prog.insts {
+		instAddrs = append(instAddrs, instAddr)
+	}
+	sort.Sort(instAddrs)
+	insts := make([]*Inst, 0, len(instAddrs))
+	for _, instAddr := range instAddrs {
+		insts = append(insts, prog.insts[instAddr])
+	}
+	return insts
+}
+
+// DecodeProgram decodes the functions at the given addresses, and returns the
+// decoded program. The instructions of known basic blocks are decoded in
+// parallel by the given number of workers, after which the control flow of
+// each function is recovered using the shared instruction cache.
+func (dis *Disasm) DecodeProgram(funcAddrs []bin.Address, workers int) (*Program, error) {
+	// Decode instructions of basic blocks in parallel.
+	blockAddrs := append(append([]bin.Address{}, funcAddrs...), dis.BlockAddrs...)
+	dis.predecode(blockAddrs, workers)
+	// Recover control flow of functions. Control flow recovery updates the
+	// state of the disassembler (e.g. resolved jump tables and thunks), and is
+	// therefore sequential.
+	var fs []*Func
+	done := make(map[bin.Address]bool)
+	for _, funcAddr := range funcAddrs {
+		if done[funcAddr] {
+			continue
+		}
+		done[funcAddr] = true
+		f, err := dis.DecodeFunc(funcAddr)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		fs = append(fs, f)
+	}
+	return NewProgram(fs), nil
+}
+
+// predecode decodes the instructions of the basic blocks at the given
+// addresses in parallel, using the given number of workers, and stores the
+// decoded instructions in the instruction cache. Decoding errors are ignored,
+// and later reported by DecodeFunc.
+func (dis *Disasm) predecode(blockAddrs []bin.Address, workers int) {
+	if dis.cache == nil {
+		return
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	dbg.Printf("decoding %d basic blocks using %d workers", len(blockAddrs), workers)
+	as := dis.File.AddressSpace()
+	addrs := make(chan bin.Address)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for blockAddr := range addrs {
+				end := blockAddr + bin.Address(dis.maxBlockLen(blockAddr))
+				for addr := blockAddr; addr < end && as.Contains(addr, bin.PermX); {
+					inst, err := dis.DecodeInst(addr)
+					if err != nil || inst.isTerm() {
+						break
+					}
+					addr += bin.Address(inst.Len)
+				}
+			}
+		}()
+	}
+	for _, blockAddr := range blockAddrs {
+		addrs <- blockAddr
+	}
+	close(addrs)
+	wg.Wait()
+	dbg.Printf("decoded %d instructions", dis.cache.len())
+}
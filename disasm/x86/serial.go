@@ -0,0 +1,507 @@
+package x86
+
+import (
+	"encoding/binary"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// Marshal returns the serialized disassembly model of the given binary
+// executable and decoded functions, encoded in Protocol Buffers wire format as
+// specified by the Model message of disasm.proto.
+//
+// Instructions are stored as machine code, which is independent of the
+// x86asm version; the costly control flow recovery is not repeated when
+// unmarshalling.
+func Marshal(file *bin.File, fs []*Func) []byte {
+	e := &encoder{}
+	e.message(1, func(e *encoder) {
+		encodeFile(e, file)
+	})
+	fs = append([]*Func(nil), fs...)
+	less := func(i, j int) bool {
+		return fs[i].Addr < fs[j].Addr
+	}
+	sort.Slice(fs, less)
+	for _, f := range fs {
+		e.message(2, func(e *encoder) {
+			encodeFunc(e, file, f)
+		})
+	}
+	return e.buf
+}
+
+// Unmarshal parses the given serialized disassembly model (see Marshal), and
+// returns the binary executable and decoded functions.
+func Unmarshal(data []byte) (*bin.File, []*Func, error) {
+	var (
+		file *bin.File
+		fs   []*Func
+		// Function messages are parsed after the file message, as the processor
+		// mode is required to decode instructions.
+		funcsData [][]byte
+	)
+	err := parseFields(data, func(field int, v value) error {
+		switch field {
+		case 1:
+			var err error
+			if file, err = decodeFile(v.b); err != nil {
+				return errors.WithStack(err)
+			}
+		case 2:
+			funcsData = append(funcsData, v.b)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	if file == nil {
+		return nil, nil, errors.New("invalid disassembly model; missing binary executable")
+	}
+	var mode int
+	switch file.Arch {
+	case bin.ArchX86_16:
+		mode = 16
+	case bin.ArchX86_32:
+		mode = 32
+	case bin.ArchX86_64:
+		mode = 64
+	default:
+		return nil, nil, errors.Errorf("support for machine architecture %v not yet implemented", file.Arch)
+	}
+	for _, funcData := range funcsData {
+		f, err := decodeFunc(funcData, mode)
+		if err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+		fs = append(fs, f)
+	}
+	return file, fs, nil
+}
+
+// --- [ Encoding ] ------------------------------------------------------------
+
+// encodeFile encodes the given binary executable as a File message.
+func encodeFile(e *encoder, file *bin.File) {
+	e.str(1, file.Arch.String())
+	e.uvarint(2, uint64(file.Entry))
+	for _, sect := range file.Sections {
+		e.message(3, func(e *encoder) {
+			e.str(1, sect.Name)
+			e.uvarint(2, uint64(sect.Addr))
+			e.uvarint(3, sect.Offset)
+			e.bytes(4, sect.Data)
+			e.uvarint(5, uint64(sect.FileSize))
+			e.uvarint(6, uint64(sect.MemSize))
+			e.uvarint(7, uint64(sect.Perm))
+			e.uvarint(8, uint64(sect.Flags))
+			e.uvarint(9, uint64(sect.Align))
+		})
+	}
+	encodeSymbols := func(field int, m map[bin.Address]string) {
+		var addrs bin.Addresses
+		for addr := range m {
+			addrs = append(addrs, addr)
+		}
+		sort.Sort(addrs)
+		for _, addr := range addrs {
+			e.message(field, func(e *encoder) {
+				e.uvarint(1, uint64(addr))
+				e.str(2, m[addr])
+			})
+		}
+	}
+	encodeSymbols(4, file.Imports)
+	encodeSymbols(5, file.Exports)
+	for _, lib := range file.Libs {
+		e.str(6, lib)
+	}
+	encodeSymbols(7, file.ImportLibs)
+	for _, addr := range file.InitFuncs {
+		e.uvarint(8, uint64(addr))
+	}
+	e.uvarint(9, uint64(file.Base))
+	e.uvarint(10, uint64(file.Segments))
+	if file.BigEndian {
+		e.uvarint(11, 1)
+	}
+	if file.Shared {
+		e.uvarint(12, 1)
+	}
+	e.uvarint(13, uint64(file.Flags))
+}
+
+// encodeFunc encodes the given function as a Func message.
+func encodeFunc(e *encoder, file *bin.File, f *Func) {
+	e.uvarint(1, uint64(f.Addr))
+	var blockAddrs bin.Addresses
+	for blockAddr := range f.Blocks {
+		blockAddrs = append(blockAddrs, blockAddr)
+	}
+	sort.Sort(blockAddrs)
+	for _, blockAddr := range blockAddrs {
+		block := f.Blocks[blockAddr]
+		e.message(2, func(e *encoder) {
+			e.uvarint(1, uint64(block.Addr))
+			e.uvarint(4, uint64(block.Mode))
+			for _, inst := range block.Insts {
+				e.message(2, func(e *encoder) {
+					encodeInst(e, file, block, inst)
+				})
+			}
+			e.message(3, func(e *encoder) {
+				encodeInst(e, file, block, block.Term)
+			})
+		})
+	}
+}
+
+// encodeInst encodes the given instruction of the basic block as an Inst
+// message.
+func encodeInst(e *encoder, file *bin.File, block *BasicBlock, inst *Inst) {
+	e.uvarint(1, uint64(inst.Addr))
+	if !inst.IsDummyTerm() {
+		e.bytes(2, file.Code(inst.Addr)[:inst.Len])
+	}
+	if _, ok := block.Rewritten[inst.Addr]; ok {
+		e.uvarint(3, 1)
+	}
+}
+
+// --- [ Decoding ] ------------------------------------------------------------
+
+// decodeFile decodes the given File message.
+func decodeFile(data []byte) (*bin.File, error) {
+	file := &bin.File{
+		Imports:    make(map[bin.Address]string),
+		Exports:    make(map[bin.Address]string),
+		ImportLibs: make(map[bin.Address]string),
+	}
+	decodeSymbol := func(data []byte, m map[bin.Address]string) error {
+		var (
+			addr bin.Address
+			name string
+		)
+		err := parseFields(data, func(field int, v value) error {
+			switch field {
+			case 1:
+				addr = bin.Address(v.x)
+			case 2:
+				name = string(v.b)
+			}
+			return nil
+		})
+		m[addr] = name
+		return err
+	}
+	err := parseFields(data, func(field int, v value) error {
+		switch field {
+		case 1:
+			if err := file.Arch.Set(string(v.b)); err != nil {
+				return errors.WithStack(err)
+			}
+		case 2:
+			file.Entry = bin.Address(v.x)
+		case 3:
+			sect, err := decodeSection(v.b)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			file.Sections = append(file.Sections, sect)
+		case 4:
+			return decodeSymbol(v.b, file.Imports)
+		case 5:
+			return decodeSymbol(v.b, file.Exports)
+		case 6:
+			file.Libs = append(file.Libs, string(v.b))
+		case 7:
+			return decodeSymbol(v.b, file.ImportLibs)
+		case 8:
+			file.InitFuncs = append(file.InitFuncs, bin.Address(v.x))
+		case 9:
+			file.Base = bin.Address(v.x)
+		case 10:
+			file.Segments = bin.SegmentModel(v.x)
+		case 11:
+			file.BigEndian = v.x != 0
+		case 12:
+			file.Shared = v.x != 0
+		case 13:
+			file.Flags = bin.ImageFlag(v.x)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return file, nil
+}
+
+// decodeSection decodes the given Section message.
+func decodeSection(data []byte) (*bin.Section, error) {
+	sect := &bin.Section{}
+	err := parseFields(data, func(field int, v value) error {
+		switch field {
+		case 1:
+			sect.Name = string(v.b)
+		case 2:
+			sect.Addr = bin.Address(v.x)
+		case 3:
+			sect.Offset = v.x
+		case 4:
+			sect.Data = v.b
+		case 5:
+			sect.FileSize = int(v.x)
+		case 6:
+			sect.MemSize = int(v.x)
+		case 7:
+			sect.Perm = bin.Perm(v.x)
+		case 8:
+			sect.Flags = bin.SectFlag(v.x)
+		case 9:
+			sect.Align = int(v.x)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return sect, nil
+}
+
+// decodeFunc decodes the given Func message, decoding instructions in the
+// specified processor mode.
+func decodeFunc(data []byte, mode int) (*Func, error) {
+	f := &Func{
+		Blocks: make(map[bin.Address]*BasicBlock),
+	}
+	err := parseFields(data, func(field int, v value) error {
+		switch field {
+		case 1:
+			f.Addr = bin.Address(v.x)
+		case 2:
+			block, err := decodeBlock(v.b, mode)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			f.Blocks[block.Addr] = block
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return f, nil
+}
+
+// decodeBlock decodes the given BasicBlock message, decoding instructions in
+// the processor mode of the basic block if present, and in the specified
+// processor mode otherwise.
+func decodeBlock(data []byte, mode int) (*BasicBlock, error) {
+	block := &BasicBlock{
+		Mode: mode,
+	}
+	// Instructions are decoded once the processor mode of the basic block is
+	// known.
+	var instsData [][]byte
+	var termData []byte
+	err := parseFields(data, func(field int, v value) error {
+		switch field {
+		case 1:
+			block.Addr = bin.Address(v.x)
+		case 2:
+			instsData = append(instsData, v.b)
+		case 3:
+			termData = v.b
+		case 4:
+			block.Mode = int(v.x)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if termData == nil {
+		return nil, errors.Errorf("invalid basic block at %v; missing terminator", block.Addr)
+	}
+	for _, instData := range instsData {
+		inst, err := decodeInst(block, instData)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		block.Insts = append(block.Insts, inst)
+	}
+	term, err := decodeInst(block, termData)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	block.Term = term
+	return block, nil
+}
+
+// decodeInst decodes the given Inst message of the basic block, decoding the
+// machine code in the processor mode of the basic block. Instructions rewritten
+// by anti-disassembly neutralization are rewritten again, and their original
+// decoded instructions are recorded in the basic block.
+func decodeInst(block *BasicBlock, data []byte) (*Inst, error) {
+	inst := &Inst{}
+	var (
+		code      []byte
+		rewritten bool
+	)
+	err := parseFields(data, func(field int, v value) error {
+		switch field {
+		case 1:
+			inst.Addr = bin.Address(v.x)
+		case 2:
+			code = v.b
+		case 3:
+			rewritten = v.x != 0
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(code) == 0 {
+		// Dummy terminator.
+		return inst, nil
+	}
+	i, err := x86asm.Decode(code, block.Mode)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if i.Len != len(code) {
+		return nil, errors.Errorf("invalid instruction at %v; expected length %d, got %d", inst.Addr, len(code), i.Len)
+	}
+	inst.Inst = i
+	if rewritten {
+		block.addRewritten(inst)
+		return rewriteInst(inst), nil
+	}
+	return inst, nil
+}
+
+// ### [ Helper functions ] ####################################################
+
+// Protocol Buffers wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// encoder encodes messages in Protocol Buffers wire format. Fields with default
+// values are omitted, as in proto3.
+type encoder struct {
+	// Encoded message.
+	buf []byte
+}
+
+// tag encodes the key of the given field.
+func (e *encoder) tag(field, wireType int) {
+	e.buf = appendUvarint(e.buf, uint64(field)<<3|uint64(wireType))
+}
+
+// uvarint encodes the given integer field.
+func (e *encoder) uvarint(field int, x uint64) {
+	if x == 0 {
+		return
+	}
+	e.tag(field, wireVarint)
+	e.buf = appendUvarint(e.buf, x)
+}
+
+// bytes encodes the given byte sequence field.
+func (e *encoder) bytes(field int, b []byte) {
+	if len(b) == 0 {
+		return
+	}
+	e.tag(field, wireBytes)
+	e.buf = appendUvarint(e.buf, uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+// str encodes the given string field.
+func (e *encoder) str(field int, s string) {
+	e.bytes(field, []byte(s))
+}
+
+// message encodes the given embedded message field, the contents of which are
+// encoded by fn.
+func (e *encoder) message(field int, fn func(e *encoder)) {
+	sub := &encoder{}
+	fn(sub)
+	e.tag(field, wireBytes)
+	e.buf = appendUvarint(e.buf, uint64(len(sub.buf)))
+	e.buf = append(e.buf, sub.buf...)
+}
+
+// appendUvarint appends the varint encoding of x to buf.
+func appendUvarint(buf []byte, x uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], x)
+	return append(buf, tmp[:n]...)
+}
+
+// value is the value of a decoded field.
+type value struct {
+	// Integer value of varint and fixed-size fields.
+	x uint64
+	// Byte sequence of length-delimited fields (strings, bytes and messages).
+	b []byte
+}
+
+// parseFields parses the fields of the given message in Protocol Buffers wire
+// format, invoking fn for each field. Unknown fields are passed to fn, which
+// should ignore them.
+func parseFields(data []byte, fn func(field int, v value) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errors.New("invalid field key")
+		}
+		data = data[n:]
+		field, wireType := int(key>>3), int(key&0x7)
+		var v value
+		switch wireType {
+		case wireVarint:
+			x, n := binary.Uvarint(data)
+			if n <= 0 {
+				return errors.Errorf("invalid varint of field %d", field)
+			}
+			v.x = x
+			data = data[n:]
+		case wireFixed64:
+			if len(data) < 8 {
+				return errors.Errorf("invalid fixed64 of field %d", field)
+			}
+			v.x = binary.LittleEndian.Uint64(data)
+			data = data[8:]
+		case wireBytes:
+			size, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < size {
+				return errors.Errorf("invalid length-delimited value of field %d", field)
+			}
+			v.b = data[n : n+int(size)]
+			data = data[n+int(size):]
+		case wireFixed32:
+			if len(data) < 4 {
+				return errors.Errorf("invalid fixed32 of field %d", field)
+			}
+			v.x = uint64(binary.LittleEndian.Uint32(data))
+			data = data[4:]
+		default:
+			return errors.Errorf("invalid wire type %d of field %d", wireType, field)
+		}
+		if err := fn(field, v); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
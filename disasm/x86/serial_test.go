@@ -0,0 +1,250 @@
+package x86_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+func TestMarshal(t *testing.T) {
+	t.Parallel()
+	code := []byte{
+		// 0x401000: push ebp
+		0x55,
+		// 0x401001: call 0x401006 (rewritten as push 0x401006)
+		0xE8, 0x00, 0x00, 0x00, 0x00,
+		// 0x401006: pop eax
+		0x58,
+		// 0x401007: ret
+		0xC3,
+		// 0x401008: ret (16-bit)
+		0xC3,
+	}
+	file := &bin.File{
+		Arch:     bin.ArchX86_32,
+		Entry:    0x401000,
+		Shared:   true,
+		Base:     0x400000,
+		Segments: 1,
+		Sections: []*bin.Section{
+			{Name: ".text", Addr: 0x401000, Offset: 0x400, Data: code, FileSize: len(code), MemSize: 0x1000, Perm: bin.PermR | bin.PermX, Align: 0x1000},
+			{Name: ".bss", Addr: 0x402000, MemSize: 0x100, Perm: bin.PermR | bin.PermW},
+		},
+		Imports:    map[bin.Address]string{0x403000: "ExitProcess", 0x403004: "GetTickCount"},
+		Exports:    map[bin.Address]string{0x401000: "start"},
+		Libs:       []string{"kernel32.dll"},
+		ImportLibs: map[bin.Address]string{0x403000: "kernel32.dll", 0x403004: "kernel32.dll"},
+		InitFuncs:  []bin.Address{0x401008, 0x401007},
+		Flags:      1,
+	}
+	call := decodeInst(t, code[1:], 0x401001, 32)
+	push := &x86.Inst{Addr: call.Addr, Inst: call.Inst}
+	push.Op = x86asm.PUSH
+	push.Args = x86asm.Args{x86asm.Imm(0x401006)}
+	f1 := &x86.Func{
+		Addr: 0x401000,
+		Blocks: map[bin.Address]*x86.BasicBlock{
+			0x401000: {
+				Addr:      0x401000,
+				Mode:      32,
+				Insts:     []*x86.Inst{decodeInst(t, code[0:], 0x401000, 32), push},
+				Term:      &x86.Inst{Addr: 0x401006},
+				Rewritten: map[bin.Address]*x86.Inst{0x401001: call},
+			},
+			0x401006: {
+				Addr:  0x401006,
+				Mode:  32,
+				Insts: []*x86.Inst{decodeInst(t, code[6:], 0x401006, 32)},
+				Term:  decodeInst(t, code[7:], 0x401007, 32),
+			},
+		},
+	}
+	f2 := &x86.Func{
+		Addr: 0x401008,
+		Blocks: map[bin.Address]*x86.BasicBlock{
+			0x401008: {
+				Addr: 0x401008,
+				Mode: 16,
+				Term: decodeInst(t, code[8:], 0x401008, 16),
+			},
+		},
+	}
+	data := x86.Marshal(file, []*x86.Func{f2, f1})
+	gotFile, gotFuncs, err := x86.Unmarshal(data)
+	if err != nil {
+		t.Fatalf("unable to unmarshal disassembly model; %+v", err)
+	}
+	// Compare binary executables.
+	if gotFile.Arch != file.Arch {
+		t.Errorf("arch mismatch; expected %v, got %v", file.Arch, gotFile.Arch)
+	}
+	if gotFile.Entry != file.Entry {
+		t.Errorf("entry point mismatch; expected %v, got %v", file.Entry, gotFile.Entry)
+	}
+	if gotFile.Shared != file.Shared {
+		t.Errorf("shared mismatch; expected %v, got %v", file.Shared, gotFile.Shared)
+	}
+	if gotFile.Base != file.Base {
+		t.Errorf("base address mismatch; expected %v, got %v", file.Base, gotFile.Base)
+	}
+	if gotFile.Segments != file.Segments {
+		t.Errorf("segment model mismatch; expected %v, got %v", file.Segments, gotFile.Segments)
+	}
+	if gotFile.Flags != file.Flags {
+		t.Errorf("image flags mismatch; expected %v, got %v", file.Flags, gotFile.Flags)
+	}
+	if !reflect.DeepEqual(gotFile.Sections, file.Sections) {
+		t.Errorf("sections mismatch; expected %#v, got %#v", file.Sections, gotFile.Sections)
+	}
+	if !reflect.DeepEqual(gotFile.Imports, file.Imports) {
+		t.Errorf("imports mismatch; expected %v, got %v", file.Imports, gotFile.Imports)
+	}
+	if !reflect.DeepEqual(gotFile.Exports, file.Exports) {
+		t.Errorf("exports mismatch; expected %v, got %v", file.Exports, gotFile.Exports)
+	}
+	if !reflect.DeepEqual(gotFile.Libs, file.Libs) {
+		t.Errorf("libraries mismatch; expected %v, got %v", file.Libs, gotFile.Libs)
+	}
+	if !reflect.DeepEqual(gotFile.ImportLibs, file.ImportLibs) {
+		t.Errorf("import libraries mismatch; expected %v, got %v", file.ImportLibs, gotFile.ImportLibs)
+	}
+	if !reflect.DeepEqual(gotFile.InitFuncs, file.InitFuncs) {
+		t.Errorf("initialization functions mismatch; expected %v, got %v", file.InitFuncs, gotFile.InitFuncs)
+	}
+	// Compare functions; sorted by address.
+	want := []*x86.Func{f1, f2}
+	if len(gotFuncs) != len(want) {
+		t.Fatalf("number of functions mismatch; expected %d, got %d", len(want), len(gotFuncs))
+	}
+	for i, f := range want {
+		if !reflect.DeepEqual(gotFuncs[i], f) {
+			t.Errorf("function %d mismatch; expected %#v, got %#v", i, f, gotFuncs[i])
+		}
+	}
+	// Marshalling is deterministic.
+	if got := x86.Marshal(gotFile, gotFuncs); !bytes.Equal(got, data) {
+		t.Errorf("re-marshalled disassembly model mismatch; expected % X, got % X", data, got)
+	}
+}
+
+// TestMarshalSchema pins the wire format of the disassembly model, as
+// specified by disasm.proto. Existing fields must never be renumbered.
+func TestMarshalSchema(t *testing.T) {
+	t.Parallel()
+	code := []byte{
+		// 0x1000: call 0x1005 (rewritten as push 0x1005)
+		0xE8, 0x00, 0x00, 0x00, 0x00,
+		// 0x1005: ret
+		0xC3,
+	}
+	file := &bin.File{
+		Arch:  bin.ArchX86_32,
+		Entry: 0x1000,
+		Sections: []*bin.Section{
+			{Name: ".text", Addr: 0x1000, Data: code, FileSize: len(code), MemSize: len(code), Perm: bin.PermR | bin.PermX},
+		},
+		Imports:    map[bin.Address]string{0x2000: "f"},
+		Exports:    map[bin.Address]string{},
+		ImportLibs: map[bin.Address]string{},
+	}
+	call := decodeInst(t, code[0:], 0x1000, 32)
+	push := &x86.Inst{Addr: call.Addr, Inst: call.Inst}
+	push.Op = x86asm.PUSH
+	push.Args = x86asm.Args{x86asm.Imm(0x1005)}
+	f := &x86.Func{
+		Addr: 0x1000,
+		Blocks: map[bin.Address]*x86.BasicBlock{
+			0x1000: {
+				Addr:      0x1000,
+				Mode:      32,
+				Insts:     []*x86.Inst{push},
+				Term:      decodeInst(t, code[5:], 0x1005, 32),
+				Rewritten: map[bin.Address]*x86.Inst{0x1000: call},
+			},
+		},
+	}
+	want := []byte{
+		// Model.file (1)
+		0x0A, 0x2D,
+		// File.arch (1): "x86_32"
+		0x0A, 0x06, 'x', '8', '6', '_', '3', '2',
+		// File.entry (2): 0x1000
+		0x10, 0x80, 0x20,
+		// File.sections (3)
+		0x1A, 0x18,
+		// Section.name (1): ".text"
+		0x0A, 0x05, '.', 't', 'e', 'x', 't',
+		// Section.addr (2): 0x1000
+		0x10, 0x80, 0x20,
+		// Section.data (4)
+		0x22, 0x06, 0xE8, 0x00, 0x00, 0x00, 0x00, 0xC3,
+		// Section.file_size (5): 6
+		0x28, 0x06,
+		// Section.mem_size (6): 6
+		0x30, 0x06,
+		// Section.perm (7): PermR | PermX
+		0x38, byte(bin.PermR | bin.PermX),
+		// File.imports (4)
+		0x22, 0x06,
+		// key (1): 0x2000
+		0x08, 0x80, 0x40,
+		// value (2): "f"
+		0x12, 0x01, 'f',
+		// Model.funcs (2)
+		0x12, 0x20,
+		// Func.addr (1): 0x1000
+		0x08, 0x80, 0x20,
+		// Func.blocks (2)
+		0x12, 0x1B,
+		// BasicBlock.addr (1): 0x1000
+		0x08, 0x80, 0x20,
+		// BasicBlock.mode (4): 32
+		0x20, 0x20,
+		// BasicBlock.insts (2)
+		0x12, 0x0C,
+		// Inst.addr (1): 0x1000
+		0x08, 0x80, 0x20,
+		// Inst.code (2): call 0x1005
+		0x12, 0x05, 0xE8, 0x00, 0x00, 0x00, 0x00,
+		// Inst.rewritten (3): true
+		0x18, 0x01,
+		// BasicBlock.term (3)
+		0x1A, 0x06,
+		// Inst.addr (1): 0x1005
+		0x08, 0x85, 0x20,
+		// Inst.code (2): ret
+		0x12, 0x01, 0xC3,
+	}
+	got := x86.Marshal(file, []*x86.Func{f})
+	if !bytes.Equal(got, want) {
+		t.Errorf("serialized disassembly model mismatch; expected\n% X\ngot\n% X", want, got)
+	}
+	// The pinned wire format is unmarshalled into the same model.
+	gotFile, gotFuncs, err := x86.Unmarshal(want)
+	if err != nil {
+		t.Fatalf("unable to unmarshal disassembly model; %+v", err)
+	}
+	if !reflect.DeepEqual(gotFile.Sections, file.Sections) {
+		t.Errorf("sections mismatch; expected %#v, got %#v", file.Sections, gotFile.Sections)
+	}
+	if !reflect.DeepEqual(gotFile.Imports, file.Imports) {
+		t.Errorf("imports mismatch; expected %v, got %v", file.Imports, gotFile.Imports)
+	}
+	if len(gotFuncs) != 1 || !reflect.DeepEqual(gotFuncs[0], f) {
+		t.Errorf("functions mismatch; expected [%#v], got %#v", f, gotFuncs)
+	}
+}
+
+// decodeInst decodes the given machine code in the specified processor mode,
+// and returns the instruction at the given address.
+func decodeInst(t *testing.T, code []byte, addr bin.Address, mode int) *x86.Inst {
+	i, err := x86asm.Decode(code, mode)
+	if err != nil {
+		t.Fatalf("unable to decode instruction at %v; %v", addr, err)
+	}
+	return &x86.Inst{Addr: addr, Inst: i}
+}
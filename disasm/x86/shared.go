@@ -0,0 +1,226 @@
+package x86
+
+import (
+	"fmt"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// Shared code between functions.
+//
+// Thunks are functions consisting of a single jump to another function (or
+// imported function). Calls to thunks are resolved to the final target of the
+// thunk, and thunks are aliased to their targets in the lifted LLVM IR.
+//
+//    thunk:
+//       jmp [__imp_ExitProcess]
+//
+// Shared tails are sequences of code outside of the function body, targeted by
+// unconditional jumps from more than one function (or from a function with
+// multiple entry points), without being known function entry points. Shared
+// tails are either duplicated into each function jumping to them, or extracted
+// into artificial callees which are tail called by each function.
+//
+//    f:                         g:
+//       ...                        ...
+//       jmp tail                   jmp tail
+//    tail:
+//       pop ebp
+//       ret
+
+// Maximum length of thunk chains.
+const maxThunkDepth = 16
+
+// SharedCodePolicy specifies how shared tails of functions are modelled.
+type SharedCodePolicy uint
+
+// Shared code policies.
+const (
+	// Duplicate shared tails into each function jumping to them; i.e. treat
+	// shared tails as function chunks of each function.
+	SharedDuplicate SharedCodePolicy = iota
+	// Extract shared tails into artificial callees, tail called by each
+	// function.
+	SharedExtract
+)
+
+// String returns the string representation of the shared code policy.
+func (policy SharedCodePolicy) String() string {
+	switch policy {
+	case SharedDuplicate:
+		return "duplicate"
+	case SharedExtract:
+		return "extract"
+	}
+	return fmt.Sprintf("SharedCodePolicy(%d)", uint(policy))
+}
+
+// Set sets the shared code policy to the given string; either "duplicate" or
+// "extract".
+func (policy *SharedCodePolicy) Set(s string) error {
+	switch s {
+	case "duplicate":
+		*policy = SharedDuplicate
+	case "extract":
+		*policy = SharedExtract
+	default:
+		return errors.Errorf("invalid shared code policy %q; expected duplicate or extract", s)
+	}
+	return nil
+}
+
+// SplitSharedCode locates thunks and shared tails of the functions of the
+// disassembler, and models shared tails based on the given policy. Thunks are
+// recorded in dis.Thunks, and extracted shared tails in dis.Extracted.
+//
+// SplitSharedCode should be invoked during initialization, after all function
+// addresses are known and before functions are decoded.
+func (dis *Disasm) SplitSharedCode(policy SharedCodePolicy) {
+	dis.Thunks = make(map[bin.Address]bin.Address)
+	for _, funcAddr := range dis.FuncAddrs {
+		if target, ok := dis.thunkTarget(funcAddr); ok {
+			dbg.Printf("thunk at %v to %v", funcAddr, target)
+			dis.Thunks[funcAddr] = target
+		}
+	}
+	if dis.Extracted == nil {
+		dis.Extracted = make(map[bin.Address]bool)
+	}
+	// Repeat until no more shared tails are located, as shared tails may
+	// themselves jump to other shared tails.
+	for {
+		// Map from shared tail address to functions jumping to it.
+		shared := make(map[bin.Address][]bin.Address)
+		for _, funcAddr := range dis.FuncAddrs {
+			if _, ok := dis.Thunks[funcAddr]; ok {
+				continue
+			}
+			for _, target := range dis.sharedTails(funcAddr) {
+				shared[target] = bin.InsertAddr(shared[target], funcAddr)
+			}
+		}
+		if len(shared) == 0 {
+			return
+		}
+		for target, funcAddrs := range shared {
+			switch policy {
+			case SharedDuplicate:
+				dbg.Printf("duplicating shared tail at %v into functions %v", target, funcAddrs)
+				if dis.Chunks[target] == nil {
+					dis.Chunks[target] = make(map[bin.Address]bool)
+				}
+				for _, funcAddr := range funcAddrs {
+					dis.Chunks[target][funcAddr] = true
+				}
+			case SharedExtract:
+				dbg.Printf("extracting shared tail at %v of functions %v", target, funcAddrs)
+				dis.Extracted[target] = true
+				dis.FuncAddrs = bin.InsertAddr(dis.FuncAddrs, target)
+			default:
+				panic(fmt.Errorf("support for shared code policy %v not yet implemented", policy))
+			}
+		}
+	}
+}
+
+// FinalTarget returns the final target of the given function address, after
+// following thunks.
+func (dis *Disasm) FinalTarget(addr bin.Address) bin.Address {
+	for i := 0; i < maxThunkDepth; i++ {
+		target, ok := dis.Thunks[addr]
+		if !ok {
+			break
+		}
+		addr = target
+	}
+	return addr
+}
+
+// thunkTarget returns the target of the given function if it is a thunk; i.e.
+// a single jump to another function or imported function. The boolean return
+// value indicates success.
+func (dis *Disasm) thunkTarget(funcAddr bin.Address) (bin.Address, bool) {
+	inst, err := dis.DecodeInst(funcAddr)
+	if err != nil || inst.Op != x86asm.JMP {
+		return 0, false
+	}
+	next := funcAddr + bin.Address(inst.Len)
+	var target bin.Address
+	switch arg := inst.Args[0].(type) {
+	case x86asm.Rel:
+		target = next + bin.Address(arg)
+	case x86asm.Mem:
+		if arg.Segment != 0 || arg.Index != 0 {
+			return 0, false
+		}
+		switch arg.Base {
+		case 0:
+			target = bin.Address(arg.Disp)
+		case x86asm.RIP:
+			target = next + bin.Address(arg.Disp)
+		default:
+			return 0, false
+		}
+		if _, ok := dis.File.Imports[target]; !ok {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	if target == funcAddr || !dis.IsFunc(target) {
+		return 0, false
+	}
+	return target, true
+}
+
+// sharedTails returns the shared tails targeted by unconditional jumps of the
+// given function; i.e. jump targets outside of the function body which are
+// neither function entry points nor function chunks of the function.
+func (dis *Disasm) sharedTails(funcAddr bin.Address) []bin.Address {
+	var tails []bin.Address
+	visited := make(map[bin.Address]bool)
+	queue := newQueue()
+	queue.push(funcAddr)
+	funcEnd := dis.funcEnd(funcAddr)
+	for !queue.empty() {
+		blockAddr := queue.pop()
+		if visited[blockAddr] {
+			continue
+		}
+		visited[blockAddr] = true
+		block, err := dis.DecodeBlock(blockAddr)
+		if err != nil {
+			warn.Printf("unable to decode basic block at %v of function at %v; %v", blockAddr, funcAddr, err)
+			continue
+		}
+		term := block.Term
+		if term.IsDummyTerm() || term.Op != x86asm.JMP {
+			for _, target := range dis.Targets(term, funcAddr) {
+				queue.push(target)
+			}
+			continue
+		}
+		targets, ok := dis.Indirect[term.Addr]
+		if !ok {
+			targets = dis.Addrs(term.Args[0], term.Addr, term.Addr+bin.Address(term.Len))
+		}
+		for _, target := range targets {
+			switch {
+			case funcAddr <= target && target < funcEnd && !dis.Extracted[target]:
+				// target inside function body.
+			case dis.Chunks[target][funcAddr]:
+				// target part of function chunk.
+			case dis.IsFunc(target):
+				// tail call.
+				continue
+			default:
+				tails = bin.InsertAddr(tails, target)
+				continue
+			}
+			queue.push(target)
+		}
+	}
+	return tails
+}
@@ -0,0 +1,148 @@
+package x86
+
+import (
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// Mask specifies the operand encodings masked as wildcards in byte patterns of
+// instructions; as they typically vary between builds of the same routine
+// (e.g. relocated addresses).
+type Mask uint8
+
+// Operand encoding masks.
+const (
+	// PC-relative branch targets.
+	MaskRel Mask = 1 << iota
+	// Immediates.
+	MaskImm
+	// Memory displacements.
+	MaskDisp
+	// All operand encodings.
+	MaskAll = MaskRel | MaskImm | MaskDisp
+)
+
+// InstPattern returns the byte pattern of the given instruction, with the
+// specified operand encodings masked as wildcards.
+func (dis *Disasm) InstPattern(inst *Inst, mask Mask) (*bin.Pattern, error) {
+	code := dis.File.Code(inst.Addr)
+	if len(code) < inst.Len {
+		return nil, errors.Errorf("unable to locate instruction bytes at %v", inst.Addr)
+	}
+	p := &bin.Pattern{
+		Bytes: append([]byte{}, code[:inst.Len]...),
+		Mask:  make([]byte, inst.Len),
+	}
+	for i := range p.Mask {
+		p.Mask[i] = 0xFF
+	}
+	wildcard := func(start, end int) {
+		for i := start; i < end; i++ {
+			p.Mask[i] = 0x00
+		}
+	}
+	if mask&MaskRel != 0 && inst.PCRel > 0 {
+		wildcard(inst.PCRelOff, inst.PCRelOff+inst.PCRel)
+	}
+	// Immediates are encoded last, preceded by memory displacements. Locate the
+	// encodings from the end of the instruction, in reverse operand order (e.g.
+	// ENTER imm16, imm8).
+	end := inst.Len
+	if inst.PCRel > 0 {
+		end = inst.PCRelOff
+	}
+	for i := len(inst.Args) - 1; i >= 0; i-- {
+		arg, ok := inst.Args[i].(x86asm.Imm)
+		if !ok {
+			continue
+		}
+		n := encodingSize(p.Bytes[:end], uint64(arg), inst.DataSize)
+		if n == 0 {
+			continue
+		}
+		if mask&MaskImm != 0 {
+			wildcard(end-n, end)
+		}
+		end -= n
+	}
+	for _, arg := range inst.Args {
+		mem, ok := arg.(x86asm.Mem)
+		if !ok || mem.Disp == 0 {
+			continue
+		}
+		n := encodingSize(p.Bytes[:end], uint64(mem.Disp), inst.AddrSize)
+		if n == 0 {
+			continue
+		}
+		if mask&MaskDisp != 0 {
+			wildcard(end-n, end)
+		}
+		end -= n
+	}
+	return p, nil
+}
+
+// FuncPattern returns the byte pattern of the given function, with the
+// specified operand encodings masked as wildcards. The pattern covers the
+// contiguous instructions at the function entry, limited to at most n bytes if
+// n is non-zero.
+func (dis *Disasm) FuncPattern(f *Func, mask Mask, n int) (*bin.Pattern, error) {
+	p := &bin.Pattern{}
+	addr := f.Addr
+	for _, inst := range f.Insts() {
+		if inst.Addr != addr {
+			// Non-contiguous function chunk.
+			break
+		}
+		ip, err := dis.InstPattern(inst, mask)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		p.Bytes = append(p.Bytes, ip.Bytes...)
+		p.Mask = append(p.Mask, ip.Mask...)
+		addr += bin.Address(inst.Len)
+	}
+	if n != 0 && len(p.Bytes) > n {
+		p.Bytes, p.Mask = p.Bytes[:n], p.Mask[:n]
+	}
+	if len(p.Bytes) == 0 {
+		return nil, errors.Errorf("unable to create byte pattern of function at %v; no instructions at function entry", f.Addr)
+	}
+	return p, nil
+}
+
+// ### [ Helper functions ] ####################################################
+
+// encodingSize returns the size in bytes of the encoding of the given operand
+// value at the end of the instruction bytes, or 0 if not located. Encodings of
+// the operand size are preferred to 8-bit sign-extended encodings.
+func encodingSize(code []byte, v uint64, bits int) int {
+	if bits == 0 {
+		bits = 64
+	}
+	for _, n := range []int{8, 4, 2, 1} {
+		if n*8 > bits && n != 1 {
+			continue
+		}
+		// Leave room for the opcode.
+		if n >= len(code) {
+			continue
+		}
+		var x uint64
+		for i := n - 1; i >= 0; i-- {
+			x = x<<8 | uint64(code[len(code)-n+i])
+		}
+		// Sign-extend.
+		shift := uint(64 - n*8)
+		sx := uint64(int64(x<<shift) >> shift)
+		mask := uint64(1)<<uint(bits) - 1
+		if bits >= 64 {
+			mask = ^uint64(0)
+		}
+		if x == v&mask || sx&mask == v&mask {
+			return n
+		}
+	}
+	return 0
+}
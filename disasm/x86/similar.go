@@ -0,0 +1,249 @@
+package x86
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// A FuncPrint is the similarity fingerprint of a function, which is independent
+// of the addresses and operands of instructions, and thus survives relocation,
+// register allocation changes and minor code changes. Fingerprints may be
+// stored (e.g. as JSON) to identify library functions in other binary
+// executables.
+type FuncPrint struct {
+	// Address of the function.
+	Addr bin.Address `json:"addr"`
+	// Number of basic blocks.
+	NBlocks int `json:"nblocks"`
+	// Number of control flow edges between basic blocks of the function.
+	NEdges int `json:"nedges"`
+	// Number of instructions, including terminators.
+	NInsts int `json:"ninsts"`
+	// Hash of the normalized instructions of the function; equal for
+	// functions which only differ in immediates and displacements.
+	Hash string `json:"hash"`
+	// Hash of the control flow graph structure; the in- and out-degree of each
+	// basic block.
+	CFG string `json:"cfg"`
+	// Number of occurrences of each mnemonic n-gram, by hash of the n-gram.
+	Grams map[uint64]int `json:"grams"`
+}
+
+// Length of mnemonic n-grams.
+const gramLen = 3
+
+// FuncPrint returns the similarity fingerprint of the given function.
+func (dis *Disasm) FuncPrint(f *Func) *FuncPrint {
+	p := &FuncPrint{
+		Addr:    f.Addr,
+		NBlocks: len(f.Blocks),
+		Grams:   make(map[uint64]int),
+	}
+	var blockAddrs []bin.Address
+	for blockAddr := range f.Blocks {
+		blockAddrs = append(blockAddrs, blockAddr)
+	}
+	sort.Sort(bin.Addresses(blockAddrs))
+	preds := make(map[bin.Address]int)
+	succs := make(map[bin.Address]int)
+	funcHash := sha1.New()
+	for _, blockAddr := range blockAddrs {
+		block := f.Blocks[blockAddr]
+		insts := block.Insts
+		if !block.Term.IsDummyTerm() {
+			insts = append(insts[:len(insts):len(insts)], block.Term)
+		}
+		var mnemonics []string
+		for _, inst := range insts {
+			io.WriteString(funcHash, NormInst(inst))
+			mnemonics = append(mnemonics, inst.Op.String())
+		}
+		p.NInsts += len(insts)
+		p.addGrams(mnemonics)
+		for _, target := range dis.Targets(block.Term, f.Addr) {
+			if _, ok := f.Blocks[target]; !ok {
+				// skip tail calls.
+				continue
+			}
+			succs[blockAddr]++
+			preds[target]++
+			p.NEdges++
+		}
+	}
+	p.Hash = fmt.Sprintf("%x", funcHash.Sum(nil))
+	var degrees []string
+	for _, blockAddr := range blockAddrs {
+		degrees = append(degrees, fmt.Sprintf("%d/%d", preds[blockAddr], succs[blockAddr]))
+	}
+	sort.Strings(degrees)
+	cfgHash := sha1.Sum([]byte(strings.Join(degrees, ",")))
+	p.CFG = fmt.Sprintf("%d:%d:%x", p.NBlocks, p.NEdges, cfgHash[:8])
+	return p
+}
+
+// addGrams adds the mnemonic n-grams of the given basic block to the
+// fingerprint. Basic blocks shorter than the n-gram length contribute a single
+// shorter n-gram.
+func (p *FuncPrint) addGrams(mnemonics []string) {
+	n := gramLen
+	if len(mnemonics) < n {
+		n = len(mnemonics)
+	}
+	for i := 0; n > 0 && i+n <= len(mnemonics); i++ {
+		h := fnv.New64a()
+		io.WriteString(h, strings.Join(mnemonics[i:i+n], " "))
+		p.Grams[h.Sum64()]++
+	}
+}
+
+// Similarity returns the similarity of the fingerprinted functions, between 0
+// (unrelated) and 1 (identical). The similarity is the weighted Jaccard index
+// of the mnemonic n-grams of the functions, adjusted by the ratio of their
+// basic block counts and whether their control flow graphs have the same
+// structure.
+func (p *FuncPrint) Similarity(q *FuncPrint) float64 {
+	if p.Hash == q.Hash {
+		return 1
+	}
+	grams := jaccard(p.Grams, q.Grams)
+	blocks := ratio(p.NBlocks, q.NBlocks)
+	cfg := 0.0
+	if p.CFG == q.CFG {
+		cfg = 1
+	}
+	return 0.7*grams + 0.2*blocks + 0.1*cfg
+}
+
+// A SimilarFunc is a pair of similar functions.
+type SimilarFunc struct {
+	// Fingerprints of the pair of functions.
+	A, B *FuncPrint
+	// Similarity of the functions, between 0 and 1.
+	Score float64
+}
+
+// Similar returns the pairs of similar functions of ps and qs (e.g. functions
+// of two binary executables, or library functions and functions of a binary
+// executable), with a similarity of at least threshold, sorted by descending
+// similarity.
+func Similar(ps, qs []*FuncPrint, threshold float64) []*SimilarFunc {
+	return similar(ps, qs, threshold, false)
+}
+
+// Duplicates returns the pairs of near-duplicate functions of ps (e.g.
+// functions of a single binary executable), with a similarity of at least
+// threshold, sorted by descending similarity.
+func Duplicates(ps []*FuncPrint, threshold float64) []*SimilarFunc {
+	return similar(ps, ps, threshold, true)
+}
+
+// similar returns the pairs of similar functions of ps and qs, with a
+// similarity of at least threshold. If same is set, ps and qs are the same set
+// of functions, and each pair is only reported once.
+//
+// As the similarity is bounded by the ratio of the instruction counts of the
+// functions, only functions of comparable size are compared.
+func similar(ps, qs []*FuncPrint, threshold float64, same bool) []*SimilarFunc {
+	sorted := append([]*FuncPrint(nil), qs...)
+	less := func(i, j int) bool {
+		return sorted[i].NInsts < sorted[j].NInsts
+	}
+	sort.SliceStable(sorted, less)
+	var pairs []*SimilarFunc
+	for _, p := range ps {
+		min := int(float64(p.NInsts) * threshold)
+		start := sort.Search(len(sorted), func(i int) bool { return sorted[i].NInsts >= min })
+		for _, q := range sorted[start:] {
+			if threshold > 0 && float64(q.NInsts)*threshold > float64(p.NInsts) {
+				break
+			}
+			if same && q.Addr <= p.Addr {
+				continue
+			}
+			if score := p.Similarity(q); score >= threshold {
+				pairs = append(pairs, &SimilarFunc{A: p, B: q, Score: score})
+			}
+		}
+	}
+	less = func(i, j int) bool {
+		if pairs[i].Score != pairs[j].Score {
+			return pairs[i].Score > pairs[j].Score
+		}
+		if pairs[i].A.Addr != pairs[j].A.Addr {
+			return pairs[i].A.Addr < pairs[j].A.Addr
+		}
+		return pairs[i].B.Addr < pairs[j].B.Addr
+	}
+	sort.SliceStable(pairs, less)
+	return pairs
+}
+
+// NormInst returns a normalized string representation of the given
+// instruction, which is independent of the address of the instruction.
+// Immediates and memory displacements are omitted, as they frequently change
+// between builds due to relocation.
+func NormInst(inst *Inst) string {
+	var args []string
+	for _, arg := range inst.Args {
+		if arg == nil {
+			break
+		}
+		switch arg := arg.(type) {
+		case x86asm.Reg:
+			args = append(args, arg.String())
+		case x86asm.Mem:
+			args = append(args, fmt.Sprintf("[%v+%v*%d]", arg.Base, arg.Index, arg.Scale))
+		case x86asm.Imm:
+			args = append(args, "imm")
+		case x86asm.Rel:
+			args = append(args, "rel")
+		default:
+			panic(fmt.Errorf("support for argument type %T not yet implemented", arg))
+		}
+	}
+	return fmt.Sprintf("%v %s;", inst.Op, strings.Join(args, ","))
+}
+
+// ### [ Helper functions ] ####################################################
+
+// jaccard returns the weighted Jaccard index of the given multisets.
+func jaccard(a, b map[uint64]int) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	inter, union := 0, 0
+	for k, x := range a {
+		y := b[k]
+		if x < y {
+			inter += x
+			union += y
+		} else {
+			inter += y
+			union += x
+		}
+	}
+	for k, y := range b {
+		if _, ok := a[k]; !ok {
+			union += y
+		}
+	}
+	return float64(inter) / float64(union)
+}
+
+// ratio returns the ratio of the smaller and larger of the given counts.
+func ratio(x, y int) float64 {
+	if x > y {
+		x, y = y, x
+	}
+	if y == 0 {
+		return 1
+	}
+	return float64(x) / float64(y)
+}
@@ -0,0 +1,123 @@
+package x86
+
+import (
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// A CodeWrite is a write into an executable section of the binary executable;
+// i.e. potential self-modifying code or runtime decryption of code.
+type CodeWrite struct {
+	// Address of the writing instruction.
+	InstAddr bin.Address
+	// Address written to.
+	Addr bin.Address
+	// Size in bytes of the write.
+	Size int
+	// Specifies whether the written region is executed; i.e. whether its
+	// content is written before being executed.
+	Executed bool
+}
+
+// CodeWrites returns the writes into executable sections performed by the
+// instructions of the given function.
+//
+// Only writes to static addresses (absolute or RIP-relative memory references)
+// are tracked.
+func (dis *Disasm) CodeWrites(f *Func) []*CodeWrite {
+	var writes []*CodeWrite
+	for _, block := range f.Blocks {
+		insts := append([]*Inst{}, block.Insts...)
+		if !block.Term.IsDummyTerm() {
+			insts = append(insts, block.Term)
+		}
+		for _, inst := range insts {
+			if !writesFirstArg[inst.Op] {
+				continue
+			}
+			mem, ok := inst.Args[0].(x86asm.Mem)
+			if !ok {
+				continue
+			}
+			next := inst.Addr + bin.Address(inst.Len)
+			addr, ok := staticAddr(mem, next)
+			if !ok || !dis.isExec(addr) {
+				continue
+			}
+			write := &CodeWrite{
+				InstAddr: inst.Addr,
+				Addr:     addr,
+				Size:     inst.MemBytes,
+				Executed: dis.isCode(addr),
+			}
+			writes = append(writes, write)
+		}
+	}
+	less := func(i, j int) bool {
+		return writes[i].InstAddr < writes[j].InstAddr
+	}
+	sort.Slice(writes, less)
+	return writes
+}
+
+// writesFirstArg specifies the instructions which write to their first
+// argument.
+var writesFirstArg = map[x86asm.Op]bool{
+	x86asm.ADC:   true,
+	x86asm.ADD:   true,
+	x86asm.AND:   true,
+	x86asm.DEC:   true,
+	x86asm.INC:   true,
+	x86asm.MOV:   true,
+	x86asm.MOVSD: true,
+	x86asm.MOVSS: true,
+	x86asm.NEG:   true,
+	x86asm.NOT:   true,
+	x86asm.OR:    true,
+	x86asm.POP:   true,
+	x86asm.RCL:   true,
+	x86asm.RCR:   true,
+	x86asm.ROL:   true,
+	x86asm.ROR:   true,
+	x86asm.SAR:   true,
+	x86asm.SBB:   true,
+	x86asm.SHL:   true,
+	x86asm.SHR:   true,
+	x86asm.SUB:   true,
+	x86asm.XCHG:  true,
+	x86asm.XOR:   true,
+}
+
+// staticAddr returns the static address of the given memory reference. Next
+// specifies the address of the next instruction. The boolean return value
+// indicates success.
+func staticAddr(mem x86asm.Mem, next bin.Address) (bin.Address, bool) {
+	switch {
+	case mem.Segment == 0 && mem.Base == 0 && mem.Index == 0:
+		return bin.Address(mem.Disp), true
+	case mem.Base == x86asm.RIP && mem.Index == 0:
+		return next + bin.Address(mem.Disp), true
+	}
+	return 0, false
+}
+
+// isExec reports whether the given address is within an executable section.
+func (dis *Disasm) isExec(addr bin.Address) bool {
+	return dis.File.AddressSpace().Contains(addr, bin.PermX)
+}
+
+// isCode reports whether the given address is within a code fragment of the
+// disassembler.
+func (dis *Disasm) isCode(addr bin.Address) bool {
+	less := func(i int) bool {
+		return addr < dis.Frags[i].Addr
+	}
+	index := sort.Search(len(dis.Frags), less)
+	if index == 0 {
+		return false
+	}
+	return dis.Frags[index-1].Kind == disasm.KindCode
+}
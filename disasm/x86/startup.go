@@ -0,0 +1,394 @@
+package x86
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// StartupKind specifies the runtime startup code (CRT) of a binary executable.
+type StartupKind uint8
+
+// Runtime startup code kinds.
+const (
+	// Unknown runtime startup code.
+	StartupUnknown StartupKind = iota
+	// Microsoft Visual C++ (mainCRTStartup, __scrt_common_main_seh).
+	StartupMSVC
+	// Borland C++ (__startup).
+	StartupBorland
+	// Watcom C/C++ (_cstart_, __CMain).
+	StartupWatcom
+)
+
+// String returns the string representation of the runtime startup code kind.
+func (kind StartupKind) String() string {
+	switch kind {
+	case StartupMSVC:
+		return "msvc"
+	case StartupBorland:
+		return "borland"
+	case StartupWatcom:
+		return "watcom"
+	}
+	return "unknown"
+}
+
+// Startup is the runtime startup code of a binary executable, which invokes the
+// main function of the user code.
+type Startup struct {
+	// Runtime startup code kind.
+	Kind StartupKind
+	// Startup functions, from the entry point to the function invoking main.
+	Stubs []bin.Address
+	// Entry address of main or WinMain; or 0 if not located.
+	Main bin.Address
+	// Main is WinMain (i.e. invoked with four arguments).
+	WinMain bool
+}
+
+// Names returns the names of the startup functions and main, by address.
+func (s *Startup) Names() map[bin.Address]string {
+	names := make(map[bin.Address]string)
+	if s.Main == 0 || len(s.Stubs) == 0 {
+		return names
+	}
+	if s.WinMain {
+		names[s.Main] = "WinMain"
+	} else {
+		names[s.Main] = "main"
+	}
+	entry, caller := s.Stubs[0], s.Stubs[len(s.Stubs)-1]
+	switch s.Kind {
+	case StartupMSVC:
+		if s.WinMain {
+			names[entry] = "WinMainCRTStartup"
+		} else {
+			names[entry] = "mainCRTStartup"
+		}
+		if caller != entry {
+			names[caller] = "__scrt_common_main_seh"
+		}
+	case StartupBorland:
+		if caller != entry {
+			names[caller] = "__startup"
+		}
+	case StartupWatcom:
+		names[entry] = "_cstart_"
+		if caller != entry {
+			names[caller] = "__CMain"
+		}
+	}
+	return names
+}
+
+// Maximum call depth from the entry point to the function invoking main.
+const maxStartupDepth = 3
+
+// FindMain locates the main function (main or WinMain) of the binary
+// executable, by recognizing the runtime startup code invoked at the entry
+// point.
+//
+// The call to main is recognized as the direct call from a startup function
+// which is passed three (main) or four (WinMain) arguments, and the return
+// value of which is passed to the succeeding call (e.g. exit). Watcom passes
+// arguments in registers, and only the latter condition applies. Borland
+// invokes main through a module table, which is not yet supported.
+func (dis *Disasm) FindMain() *Startup {
+	entry := dis.File.Entry
+	if entry == 0 || !dis.isExec(entry) {
+		// e.g. object files and raw memory dumps.
+		return &Startup{}
+	}
+	s := &Startup{
+		Kind: dis.startupKind(),
+	}
+	parent := map[bin.Address]bin.Address{entry: entry}
+	queue := []bin.Address{entry}
+	for depth := 0; depth <= maxStartupDepth && len(queue) > 0; depth++ {
+		var next []bin.Address
+		for _, funcAddr := range queue {
+			f, err := dis.DecodeFunc(funcAddr)
+			if err != nil {
+				warn.Printf("unable to decode startup function at %v; %v", funcAddr, err)
+				continue
+			}
+			insts := f.Insts()
+			for i, inst := range insts {
+				target, ok := dis.directTarget(inst)
+				if !ok {
+					continue
+				}
+				if inst.Op == x86asm.CALL && dis.isMainCall(s.Kind, insts, i) {
+					s.Main = target
+					s.WinMain = dis.callArgs(insts, i) == 4
+					for addr := funcAddr; ; addr = parent[addr] {
+						s.Stubs = append([]bin.Address{addr}, s.Stubs...)
+						if addr == entry {
+							break
+						}
+					}
+					dbg.Printf("located main at %v (%v runtime startup code)", s.Main, s.Kind)
+					return s
+				}
+				if _, ok := parent[target]; ok {
+					continue
+				}
+				if inst.Op == x86asm.JMP && f.Blocks[target] != nil {
+					// skip jump within function.
+					continue
+				}
+				parent[target] = funcAddr
+				next = append(next, target)
+			}
+		}
+		queue = next
+	}
+	warn.Printf("unable to locate main function (%v runtime startup code)", s.Kind)
+	return s
+}
+
+// Reachable returns the entry addresses of the functions reachable from the
+// given root functions through direct calls and tail calls, in ascending order.
+// Imported functions are excluded.
+func (dis *Disasm) Reachable(roots ...bin.Address) []bin.Address {
+	reached := make(map[bin.Address]bool)
+	queue := append([]bin.Address(nil), roots...)
+	var funcAddrs bin.Addresses
+	for len(queue) > 0 {
+		funcAddr := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		if reached[funcAddr] {
+			continue
+		}
+		reached[funcAddr] = true
+		funcAddrs = append(funcAddrs, funcAddr)
+		f, err := dis.DecodeFunc(funcAddr)
+		if err != nil {
+			warn.Printf("unable to decode function at %v; %v", funcAddr, err)
+			continue
+		}
+		for _, inst := range f.Insts() {
+			target, ok := dis.directTarget(inst)
+			if !ok || reached[target] {
+				continue
+			}
+			if inst.Op == x86asm.JMP && (f.Blocks[target] != nil || !dis.IsFunc(target)) {
+				// skip jump within function.
+				continue
+			}
+			queue = append(queue, target)
+		}
+	}
+	sort.Sort(funcAddrs)
+	return funcAddrs
+}
+
+// ### [ Helper functions ] ####################################################
+
+// startupKind returns the runtime startup code kind of the binary executable,
+// based on the copyright strings of the startup code and the imported runtime
+// functions.
+func (dis *Disasm) startupKind() StartupKind {
+	// Borland and Watcom jump over a copyright string at the entry point.
+	const maxStringLen = 256
+	code := dis.File.Code(dis.File.Entry)
+	if len(code) > maxStringLen {
+		code = code[:maxStringLen]
+	}
+	switch {
+	case bytes.Contains(code, []byte("WATCOM")):
+		return StartupWatcom
+	case bytes.Contains(code, []byte("Borland")), bytes.Contains(code, []byte("fb:C++HOOK")):
+		return StartupBorland
+	}
+	for _, name := range dis.File.Imports {
+		switch name {
+		case "__p___argv", "__p___argc", "_get_initial_narrow_environment", "_get_initial_wide_environment", "__getmainargs", "__wgetmainargs", "_initterm", "_initterm_e", "__set_app_type", "_set_app_type":
+			return StartupMSVC
+		}
+	}
+	return StartupUnknown
+}
+
+// isMainCall reports whether the call instruction at index i invokes main.
+func (dis *Disasm) isMainCall(kind StartupKind, insts []*Inst, i int) bool {
+	if _, ok := dis.importName(insts[i]); ok {
+		return false
+	}
+	if kind != StartupWatcom {
+		if n := dis.callArgs(insts, i); n != 3 && n != 4 {
+			return false
+		}
+	}
+	return dis.passesResult(insts, i)
+}
+
+// callArgs returns the number of arguments passed to the call instruction at
+// index i; pushed onto the stack, stored to the stack or stored to argument
+// registers (x86-64) since the preceding call.
+func (dis *Disasm) callArgs(insts []*Inst, i int) int {
+	n := 0
+	stackArgs := make(map[int64]bool)
+	regArgs := make(map[int]bool)
+	for j := i - 1; j >= 0; j-- {
+		inst := insts[j]
+		switch inst.Op {
+		case x86asm.CALL:
+			// GetModuleHandle(NULL) is commonly invoked while pushing the
+			// arguments of WinMain.
+			if name, ok := dis.importName(inst); ok && (name == "GetModuleHandleA" || name == "GetModuleHandleW") {
+				n--
+				continue
+			}
+			return n + len(stackArgs) + len(regArgs)
+		case x86asm.PUSH:
+			n++
+		case x86asm.MOV, x86asm.LEA, x86asm.XOR, x86asm.MOVZX, x86asm.MOVSXD:
+			switch dst := inst.Args[0].(type) {
+			case x86asm.Mem:
+				if (dst.Base == x86asm.ESP || dst.Base == x86asm.RSP) && dst.Index == 0 && dis.Mode != 64 {
+					stackArgs[dst.Disp] = true
+				}
+			case x86asm.Reg:
+				if dis.Mode == 64 {
+					switch fam, _ := RegFamily(dst); fam {
+					case 1, 2, 8, 9: // rcx, rdx, r8, r9
+						regArgs[fam] = true
+					}
+				}
+			}
+		}
+	}
+	return n + len(stackArgs) + len(regArgs)
+}
+
+// passesResult reports whether the return value of the call instruction at
+// index i is passed as the first argument of the succeeding call (e.g.
+// exit(main(argc, argv, envp))).
+func (dis *Disasm) passesResult(insts []*Inst, i int) bool {
+	// Maximum number of instructions between the call and the succeeding call.
+	const maxDist = 16
+	// Register families holding the return value.
+	result := map[int]bool{0: true} // eax
+	for j := i + 1; j < len(insts) && j <= i+maxDist; j++ {
+		inst := insts[j]
+		switch inst.Op {
+		case x86asm.CALL:
+			if name, ok := dis.importName(inst); ok {
+				switch name {
+				case "exit", "_exit", "ExitProcess":
+					return true
+				}
+			}
+			if j == i+1 && result[0] {
+				// Register calling convention (e.g. Watcom); return value
+				// passed in eax.
+				return true
+			}
+			delete(result, 0)
+		case x86asm.JMP:
+			if j == i+1 {
+				// Tail call; return value passed in eax.
+				_, ok := dis.directTarget(inst)
+				return ok
+			}
+			return false
+		case x86asm.RET:
+			return false
+		case x86asm.PUSH:
+			if isResult(inst.Args[0], result) && j+1 < len(insts) && insts[j+1].Op == x86asm.CALL {
+				return true
+			}
+		case x86asm.MOV:
+			if !isResult(inst.Args[1], result) {
+				if reg, ok := inst.Args[0].(x86asm.Reg); ok {
+					fam, _ := RegFamily(reg)
+					delete(result, fam)
+				}
+				continue
+			}
+			next := j + 1
+			switch dst := inst.Args[0].(type) {
+			case x86asm.Reg:
+				fam, _ := RegFamily(dst)
+				result[fam] = true
+				// First argument register of x86-64 (rcx).
+				if dis.Mode == 64 && fam == 1 && next < len(insts) && insts[next].Op == x86asm.CALL {
+					return true
+				}
+			case x86asm.Mem:
+				// First stack argument.
+				if (dst.Base == x86asm.ESP || dst.Base == x86asm.RSP) && dst.Index == 0 && dst.Disp == 0 && next < len(insts) && insts[next].Op == x86asm.CALL {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// isResult reports whether the given argument is a register of the given
+// register families.
+func isResult(arg x86asm.Arg, result map[int]bool) bool {
+	reg, ok := arg.(x86asm.Reg)
+	if !ok {
+		return false
+	}
+	fam, _ := RegFamily(reg)
+	return fam != -1 && result[fam]
+}
+
+// directTarget returns the target of the given direct call or jump
+// instruction, after following thunks. The boolean return value indicates
+// success, and is false for calls and jumps to imported functions.
+func (dis *Disasm) directTarget(inst *Inst) (bin.Address, bool) {
+	if inst.Op != x86asm.CALL && inst.Op != x86asm.JMP {
+		return 0, false
+	}
+	rel, ok := inst.Args[0].(x86asm.Rel)
+	if !ok {
+		return 0, false
+	}
+	target := inst.Addr + bin.Address(inst.Len) + bin.Address(rel)
+	if _, ok := dis.importName(inst); ok {
+		return 0, false
+	}
+	return dis.FinalTarget(target), true
+}
+
+// importName returns the name of the imported function called or jumped to by
+// the given instruction, either directly or through a thunk. The boolean
+// return value indicates success.
+func (dis *Disasm) importName(inst *Inst) (string, bool) {
+	if inst.Op != x86asm.CALL && inst.Op != x86asm.JMP {
+		return "", false
+	}
+	next := inst.Addr + bin.Address(inst.Len)
+	var target bin.Address
+	switch arg := inst.Args[0].(type) {
+	case x86asm.Rel:
+		target = next + bin.Address(arg)
+		if t, ok := dis.thunkTarget(target); ok {
+			target = t
+		}
+	case x86asm.Mem:
+		if arg.Segment != 0 || arg.Index != 0 {
+			return "", false
+		}
+		switch arg.Base {
+		case 0:
+			target = bin.Address(arg.Disp)
+		case x86asm.RIP:
+			target = next + bin.Address(arg.Disp)
+		default:
+			return "", false
+		}
+	default:
+		return "", false
+	}
+	name, ok := dis.File.Imports[target]
+	return name, ok
+}
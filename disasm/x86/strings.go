@@ -0,0 +1,338 @@
+package x86
+
+import (
+	"bytes"
+	"fmt"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/decomp/exp/bin"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// Encoding specifies the character encoding of a string literal.
+type Encoding uint8
+
+// Character encodings.
+const (
+	// EncASCII specifies 7-bit ASCII.
+	EncASCII Encoding = iota + 1
+	// EncLatin1 specifies ISO 8859-1 (or code pages compatible in the printable
+	// range, such as Windows-1252).
+	EncLatin1
+	// EncUTF8 specifies UTF-8.
+	EncUTF8
+	// EncUTF16LE specifies little-endian UTF-16 (e.g. wide strings of Windows).
+	EncUTF16LE
+	// EncShiftJIS specifies Shift-JIS (e.g. Japanese code page 932).
+	EncShiftJIS
+)
+
+// String returns the string representation of the character encoding.
+func (enc Encoding) String() string {
+	m := map[Encoding]string{
+		EncASCII:    "ascii",
+		EncLatin1:   "latin1",
+		EncUTF8:     "utf8",
+		EncUTF16LE:  "utf16le",
+		EncShiftJIS: "sjis",
+	}
+	if s, ok := m[enc]; ok {
+		return s
+	}
+	return fmt.Sprintf("Encoding(%d)", uint8(enc))
+}
+
+// A String is a string literal of the binary executable, referenced by
+// instructions.
+type String struct {
+	// Start address of the string.
+	Addr bin.Address
+	// Size in bytes of the string; including the NULL terminator.
+	Size int
+	// Contents of the string; excluding the NULL terminator.
+	Data []byte
+	// Character encoding of the string.
+	Enc Encoding
+	// Cross-references to the string, sorted by referenced address and source
+	// address. References within the string (e.g. to the tail of a string
+	// shared by suffix merging of the linker) are included.
+	Refs []*Xref
+}
+
+// End returns the end address of the string; including the NULL terminator.
+func (s *String) End() bin.Address {
+	return s.Addr + bin.Address(s.Size)
+}
+
+// Text returns the contents of the string, decoded as UTF-8.
+func (s *String) Text() string {
+	switch s.Enc {
+	case EncLatin1:
+		rs := make([]rune, len(s.Data))
+		for i, b := range s.Data {
+			rs[i] = rune(b)
+		}
+		return string(rs)
+	case EncUTF16LE:
+		return string(utf16.Decode(utf16Units(s.Data)))
+	case EncShiftJIS:
+		return decodeShiftJIS(s.Data)
+	default:
+		return string(s.Data)
+	}
+}
+
+// StringOptions specifies options of string recovery.
+type StringOptions struct {
+	// Minimum length in characters of string literals; or 0 for the default
+	// (4 characters).
+	MinLen int
+	// Detect Shift-JIS encoded strings. Disabled by default, as Latin-1 strings
+	// are frequently valid Shift-JIS.
+	ShiftJIS bool
+}
+
+// maxStringLen is the maximum length in bytes of string literals.
+const maxStringLen = 64 * 1024
+
+// Strings recovers the string literals referenced (read or address taken) by
+// the instructions of the given cross-reference index, sorted by address.
+//
+// The character encoding of each string is detected in order of UTF-16LE (if
+// the second byte is zero), ASCII, UTF-8, Shift-JIS (if enabled), UTF-16LE and
+// lastly Latin-1. References to addresses within a string (e.g. the tail of a
+// string shared by suffix merging, or string offsets computed by the compiler)
+// are merged into the references of the enclosing string, rather than
+// recovered as separate strings.
+func (dis *Disasm) Strings(xrefs *Xrefs, opts StringOptions) []*String {
+	if opts.MinLen == 0 {
+		opts.MinLen = 4
+	}
+	var strs []*String
+	for _, addr := range xrefs.Targets() {
+		refs := stringRefs(xrefs.To(addr))
+		if len(refs) == 0 {
+			continue
+		}
+		// References within the preceding string.
+		if n := len(strs); n > 0 && addr < strs[n-1].End() {
+			strs[n-1].Refs = append(strs[n-1].Refs, refs...)
+			continue
+		}
+		data, ok := dis.File.AddressSpace().Bytes(addr, bin.PermR)
+		if !ok {
+			continue
+		}
+		s, ok := detectString(data, opts)
+		if !ok {
+			continue
+		}
+		s.Addr = addr
+		s.Refs = refs
+		strs = append(strs, s)
+	}
+	return strs
+}
+
+// ### [ Helper functions ] ####################################################
+
+// stringRefs returns the cross-references which may refer to string literals;
+// i.e. memory loads and address taken.
+func stringRefs(xrefs []*Xref) []*Xref {
+	var refs []*Xref
+	for _, xref := range xrefs {
+		switch xref.Kind {
+		case XrefRead, XrefOffset:
+			refs = append(refs, xref)
+		}
+	}
+	return refs
+}
+
+// detectString detects the string literal at the start of the given data, and
+// its character encoding. The boolean return value indicates success.
+func detectString(data []byte, opts StringOptions) (*String, bool) {
+	if len(data) >= 2 && data[0] != 0 && data[1] == 0 {
+		if s, ok := detectUTF16LE(data, opts.MinLen); ok {
+			return s, true
+		}
+	}
+	end := bytes.IndexByte(data, 0)
+	if end == -1 || end > maxStringLen {
+		return nil, false
+	}
+	buf := data[:end]
+	newString := func(enc Encoding) *String {
+		return &String{Size: end + 1, Data: buf, Enc: enc}
+	}
+	switch {
+	case isASCII(buf):
+		if len(buf) >= opts.MinLen {
+			return newString(EncASCII), true
+		}
+		return nil, false
+	case isUTF8(buf):
+		if utf8.RuneCount(buf) >= opts.MinLen {
+			return newString(EncUTF8), true
+		}
+		return nil, false
+	case opts.ShiftJIS && isShiftJIS(buf):
+		if len(buf) >= opts.MinLen {
+			return newString(EncShiftJIS), true
+		}
+		return nil, false
+	}
+	if s, ok := detectUTF16LE(data, opts.MinLen); ok {
+		return s, true
+	}
+	if isLatin1(buf) && len(buf) >= opts.MinLen {
+		return newString(EncLatin1), true
+	}
+	return nil, false
+}
+
+// detectUTF16LE detects the little-endian UTF-16 string literal at the start of
+// the given data. The boolean return value indicates success.
+func detectUTF16LE(data []byte, minLen int) (*String, bool) {
+	end := -1
+	for i := 0; i+1 < len(data) && i <= maxStringLen; i += 2 {
+		if data[i] == 0 && data[i+1] == 0 {
+			end = i
+			break
+		}
+	}
+	if end == -1 {
+		return nil, false
+	}
+	buf := data[:end]
+	rs := utf16.Decode(utf16Units(buf))
+	if len(rs) < minLen {
+		return nil, false
+	}
+	for _, r := range rs {
+		if !isPrintRune(r) {
+			return nil, false
+		}
+	}
+	return &String{Size: end + 2, Data: buf, Enc: EncUTF16LE}, true
+}
+
+// utf16Units returns the little-endian UTF-16 code units of the given data.
+func utf16Units(data []byte) []uint16 {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = uint16(data[2*i]) | uint16(data[2*i+1])<<8
+	}
+	return units
+}
+
+// isASCII reports whether the given data consists of printable ASCII
+// characters.
+func isASCII(buf []byte) bool {
+	for _, b := range buf {
+		if !isPrintASCII(b) {
+			return false
+		}
+	}
+	return true
+}
+
+// isLatin1 reports whether the given data consists of printable Latin-1
+// characters.
+func isLatin1(buf []byte) bool {
+	for _, b := range buf {
+		if !isPrintASCII(b) && b < 0xA0 {
+			return false
+		}
+	}
+	return true
+}
+
+// isUTF8 reports whether the given data is valid UTF-8 consisting of printable
+// characters.
+func isUTF8(buf []byte) bool {
+	if !utf8.Valid(buf) {
+		return false
+	}
+	for _, r := range string(buf) {
+		if !isPrintRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// isShiftJIS reports whether the given data is valid Shift-JIS, consisting of
+// printable characters and containing at least one non-ASCII character.
+func isShiftJIS(buf []byte) bool {
+	if !isShiftJISBytes(buf) {
+		return false
+	}
+	// Double-byte characters unassigned in the code page are decoded as
+	// U+FFFD.
+	for _, r := range decodeShiftJIS(buf) {
+		if !isPrintRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeShiftJIS returns the given Shift-JIS data decoded as UTF-8. Invalid
+// byte sequences are decoded as U+FFFD.
+func decodeShiftJIS(buf []byte) string {
+	// The decoder replaces invalid byte sequences rather than reporting errors.
+	s, _ := japanese.ShiftJIS.NewDecoder().Bytes(buf)
+	return string(s)
+}
+
+// isShiftJISBytes reports whether the given data consists of valid Shift-JIS
+// byte sequences of printable ASCII, half-width katakana and double-byte
+// characters, containing at least one non-ASCII character.
+func isShiftJISBytes(buf []byte) bool {
+	nonASCII := false
+	for i := 0; i < len(buf); i++ {
+		b := buf[i]
+		switch {
+		case isPrintASCII(b):
+		case 0xA1 <= b && b <= 0xDF:
+			// Half-width katakana.
+			nonASCII = true
+		case (0x81 <= b && b <= 0x9F) || (0xE0 <= b && b <= 0xEF):
+			// Lead byte of double-byte character.
+			if i+1 >= len(buf) {
+				return false
+			}
+			trail := buf[i+1]
+			if trail < 0x40 || trail == 0x7F || trail > 0xFC {
+				return false
+			}
+			nonASCII = true
+			i++
+		default:
+			return false
+		}
+	}
+	return nonASCII
+}
+
+// isPrintASCII reports whether the given byte is a printable ASCII character or
+// whitespace.
+func isPrintASCII(b byte) bool {
+	switch b {
+	case '\t', '\n', '\r':
+		return true
+	}
+	return 0x20 <= b && b <= 0x7E
+}
+
+// isPrintRune reports whether the given rune is printable or whitespace.
+func isPrintRune(r rune) bool {
+	switch r {
+	case '\t', '\n', '\r':
+		return true
+	}
+	return r != utf8.RuneError && unicode.IsPrint(r)
+}
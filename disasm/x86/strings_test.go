@@ -0,0 +1,148 @@
+package x86_test
+
+import (
+	"testing"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm"
+	"github.com/decomp/exp/disasm/x86"
+)
+
+func TestStrings(t *testing.T) {
+	t.Parallel()
+	golden := []struct {
+		data     string
+		shiftJIS bool
+		// Expected encoding; or 0 if no string is recovered.
+		enc  x86.Encoding
+		text string
+		size int
+	}{
+		{data: "hello\x00", enc: x86.EncASCII, text: "hello", size: 6},
+		{data: "tab\there\r\n\x00", enc: x86.EncASCII, text: "tab\there\r\n", size: 11},
+		{data: "h\x00e\x00l\x00l\x00o\x00\x00\x00", enc: x86.EncUTF16LE, text: "hello", size: 12},
+		{data: "h\xC3\xA9llo\x00", enc: x86.EncUTF8, text: "héllo", size: 7},
+		{data: "caf\xE9s\x00", enc: x86.EncLatin1, text: "cafés", size: 6},
+		// 日本語です
+		{data: "\x93\xFA\x96\x7B\x8C\xEA\x82\xC5\x82\xB7\x00", shiftJIS: true, enc: x86.EncShiftJIS, text: "日本語です", size: 11},
+		// Half-width katakana (ｶﾀｶﾅ).
+		{data: "\xB6\xC0\xB6\xC5\x00", shiftJIS: true, enc: x86.EncShiftJIS, text: "ｶﾀｶﾅ", size: 5},
+		// Shift-JIS detection disabled; valid Latin-1.
+		{data: "\xB6\xC0\xB6\xC5\x00", enc: x86.EncLatin1, text: "¶À¶Å", size: 5},
+		// Truncated double-byte character.
+		{data: "abc\x93\x00", shiftJIS: true},
+		// Too short.
+		{data: "abc\x00"},
+		// Control characters.
+		{data: "ab\x01cd\x00"},
+		// Missing NULL terminator.
+		{data: "hello"},
+	}
+	for _, g := range golden {
+		dis := newDisasm([]byte(g.data))
+		xrefs := x86.NewXrefs()
+		xrefs.Add(&x86.Xref{From: 0x401000, To: 0x402000, Kind: x86.XrefRead})
+		strs := dis.Strings(xrefs, x86.StringOptions{ShiftJIS: g.shiftJIS})
+		if g.enc == 0 {
+			if len(strs) != 0 {
+				t.Errorf("%q: expected no string, got %q (%v)", g.data, strs[0].Text(), strs[0].Enc)
+			}
+			continue
+		}
+		if len(strs) != 1 {
+			t.Errorf("%q: number of strings mismatch; expected 1, got %d", g.data, len(strs))
+			continue
+		}
+		s := strs[0]
+		if s.Enc != g.enc {
+			t.Errorf("%q: encoding mismatch; expected %v, got %v", g.data, g.enc, s.Enc)
+		}
+		if got := s.Text(); got != g.text {
+			t.Errorf("%q: text mismatch; expected %q, got %q", g.data, g.text, got)
+		}
+		if s.Size != g.size {
+			t.Errorf("%q: size mismatch; expected %d, got %d", g.data, g.size, s.Size)
+		}
+	}
+}
+
+func TestStringsMergeRefs(t *testing.T) {
+	t.Parallel()
+	// Suffix merged string "world" within "hello, world", followed by "foo bar".
+	dis := newDisasm([]byte("hello, world\x00foo bar\x00"))
+	golden := []struct {
+		refs []*x86.Xref
+		// Expected start addresses of strings, and number of references of each
+		// string.
+		addrs []bin.Address
+		nrefs []int
+	}{
+		// References to the start of each string.
+		{
+			refs: []*x86.Xref{
+				{From: 0x401000, To: 0x402000, Kind: x86.XrefOffset},
+				{From: 0x401010, To: 0x40200D, Kind: x86.XrefOffset},
+			},
+			addrs: []bin.Address{0x402000, 0x40200D},
+			nrefs: []int{1, 1},
+		},
+		// Reference to the tail of the first string.
+		{
+			refs: []*x86.Xref{
+				{From: 0x401000, To: 0x402000, Kind: x86.XrefOffset},
+				{From: 0x401008, To: 0x402007, Kind: x86.XrefRead},
+				{From: 0x401010, To: 0x40200D, Kind: x86.XrefOffset},
+			},
+			addrs: []bin.Address{0x402000, 0x40200D},
+			nrefs: []int{2, 1},
+		},
+		// Reference to the NULL terminator of the first string.
+		{
+			refs: []*x86.Xref{
+				{From: 0x401000, To: 0x402000, Kind: x86.XrefOffset},
+				{From: 0x401008, To: 0x40200C, Kind: x86.XrefOffset},
+			},
+			addrs: []bin.Address{0x402000},
+			nrefs: []int{2},
+		},
+		// Memory stores are not references to string literals.
+		{
+			refs: []*x86.Xref{
+				{From: 0x401000, To: 0x402000, Kind: x86.XrefWrite},
+				{From: 0x401008, To: 0x402007, Kind: x86.XrefRead},
+			},
+			addrs: []bin.Address{0x402007},
+			nrefs: []int{1},
+		},
+	}
+	for i, g := range golden {
+		xrefs := x86.NewXrefs()
+		for _, ref := range g.refs {
+			xrefs.Add(ref)
+		}
+		strs := dis.Strings(xrefs, x86.StringOptions{})
+		if len(strs) != len(g.addrs) {
+			t.Errorf("test %d: number of strings mismatch; expected %d, got %d", i, len(g.addrs), len(strs))
+			continue
+		}
+		for j, s := range strs {
+			if s.Addr != g.addrs[j] {
+				t.Errorf("test %d: address of string %d mismatch; expected %v, got %v", i, j, g.addrs[j], s.Addr)
+			}
+			if len(s.Refs) != g.nrefs[j] {
+				t.Errorf("test %d: number of references of string %d mismatch; expected %d, got %d", i, j, g.nrefs[j], len(s.Refs))
+			}
+		}
+	}
+}
+
+// newDisasm returns a disassembler of a binary executable with a single
+// read-only data section at 0x402000 containing data.
+func newDisasm(data []byte) *x86.Disasm {
+	file := &bin.File{
+		Sections: []*bin.Section{
+			{Name: ".rdata", Addr: 0x402000, Data: data, FileSize: len(data), MemSize: len(data), Perm: bin.PermR},
+		},
+	}
+	return &x86.Disasm{Disasm: &disasm.Disasm{File: file}, Mode: 32}
+}
@@ -0,0 +1,266 @@
+package x86
+
+import (
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// Superset disassembly heuristics.
+const (
+	// Maximum number of instructions of a candidate instruction chain.
+	maxChainLen = 512
+	// Minimum score of candidate functions.
+	minScore = 5
+	// Score of candidate functions being the target of call instructions.
+	scoreCallTarget = 5
+	// Score of candidate functions starting with a function prologue.
+	scorePrologue = 5
+	// Score of candidate functions preceded by padding or a return instruction.
+	scorePadding = 2
+	// Score of candidate functions aligned to 16 bytes.
+	scoreAlign = 1
+)
+
+// Superset locates function addresses using speculative disassembly, and
+// returns the addresses of candidate functions not already known to the
+// disassembler.
+//
+// Instructions are decoded at every offset of the executable sections (i.e. the
+// superset of all possible instructions), after which candidate functions are
+// scored by heuristics (call targets, function prologues, preceding padding and
+// alignment). Candidates are accepted in order of decreasing score, and
+// candidates whose instructions overlap those of previously accepted functions
+// are rejected. Known function addresses are accepted first.
+//
+// Superset disassembly improves recovery on binaries with misaligned or
+// obfuscated code, where recursive descent stalls.
+func (dis *Disasm) Superset() []bin.Address {
+	var funcAddrs []bin.Address
+	seen := make(map[bin.Address]bool)
+	for _, sect := range dis.File.Sections {
+		if sect.Perm&bin.PermX == 0 || len(sect.Data) == 0 {
+			continue
+		}
+		if seen[sect.Addr] {
+			// skip segments overlapping already disassembled sections.
+			continue
+		}
+		seen[sect.Addr] = true
+		s := dis.newSuperset(sect)
+		funcAddrs = append(funcAddrs, s.resolve(dis.FuncAddrs)...)
+	}
+	sort.Sort(bin.Addresses(funcAddrs))
+	return funcAddrs
+}
+
+// superset is the superset of all possible instructions of a section.
+type superset struct {
+	// Start address of the section.
+	addr bin.Address
+	// Section contents.
+	data []byte
+	// Instructions decoded at each offset of the section; or nil if invalid.
+	insts []*x86asm.Inst
+	// Number of call instructions targeting each offset of the section.
+	callers []int
+}
+
+// newSuperset decodes instructions at every offset of the given section.
+func (dis *Disasm) newSuperset(sect *bin.Section) *superset {
+	s := &superset{
+		addr:    sect.Addr,
+		data:    sect.Data,
+		insts:   make([]*x86asm.Inst, len(sect.Data)),
+		callers: make([]int, len(sect.Data)),
+	}
+	for off := range s.data {
+		inst, err := x86asm.Decode(s.data[off:], dis.Mode)
+		if err != nil {
+			continue
+		}
+		s.insts[off] = &inst
+	}
+	// Locate call targets.
+	for off, inst := range s.insts {
+		if inst == nil || inst.Op != x86asm.CALL {
+			continue
+		}
+		if rel, ok := inst.Args[0].(x86asm.Rel); ok {
+			target := off + inst.Len + int(rel)
+			if 0 <= target && target < len(s.data) {
+				s.callers[target]++
+			}
+		}
+	}
+	return s
+}
+
+// chain returns the offsets of the instruction chain starting at the given
+// offset, following fallthrough until a return or unconditional jump. The
+// boolean return value reports whether the chain is valid; i.e. terminated
+// without reaching an invalid instruction.
+func (s *superset) chain(off int) ([]int, bool) {
+	var offs []int
+	for len(offs) < maxChainLen {
+		if off >= len(s.insts) || s.insts[off] == nil {
+			return nil, false
+		}
+		inst := s.insts[off]
+		offs = append(offs, off)
+		switch inst.Op {
+		case x86asm.RET, x86asm.JMP, x86asm.HLT:
+			return offs, true
+		case x86asm.INT, x86asm.UD2:
+			// Trap instructions are valid padding after calls to non-returning
+			// functions, but are otherwise considered decoded from data.
+			if n := len(offs); n > 1 && s.insts[offs[n-2]].Op == x86asm.CALL {
+				return offs[:n-1], true
+			}
+			return nil, false
+		}
+		off += inst.Len
+	}
+	return offs, true
+}
+
+// score returns the heuristic score of a candidate function at the given
+// offset.
+func (s *superset) score(off int) int {
+	score := 0
+	if s.callers[off] > 0 {
+		score += scoreCallTarget
+	}
+	if s.isPrologue(off) {
+		score += scorePrologue
+	}
+	if off == 0 {
+		score += scorePadding
+	} else {
+		switch s.data[off-1] {
+		// INT3, NOP and RET.
+		case 0xCC, 0x90, 0xC3:
+			score += scorePadding
+		}
+	}
+	if (uint64(s.addr)+uint64(off))%16 == 0 {
+		score += scoreAlign
+	}
+	return score
+}
+
+// isPrologue reports whether the instructions at the given offset constitute a
+// function prologue (e.g. "push ebp; mov ebp, esp").
+func (s *superset) isPrologue(off int) bool {
+	first := s.insts[off]
+	if first == nil || first.Op != x86asm.PUSH {
+		return false
+	}
+	if reg, ok := first.Args[0].(x86asm.Reg); !ok || (reg != x86asm.EBP && reg != x86asm.RBP) {
+		return false
+	}
+	next := off + first.Len
+	if next >= len(s.insts) || s.insts[next] == nil {
+		return false
+	}
+	second := s.insts[next]
+	if second.Op != x86asm.MOV {
+		return false
+	}
+	dst, ok1 := second.Args[0].(x86asm.Reg)
+	src, ok2 := second.Args[1].(x86asm.Reg)
+	return ok1 && ok2 && (dst == x86asm.EBP || dst == x86asm.RBP) && (src == x86asm.ESP || src == x86asm.RSP)
+}
+
+// resolve scores candidate functions and resolves overlapping candidates, and
+// returns the addresses of accepted candidates which are not among the given
+// known function addresses.
+func (s *superset) resolve(known []bin.Address) []bin.Address {
+	type candidate struct {
+		off   int
+		score int
+		offs  []int
+	}
+	isKnown := make(map[int]bool)
+	var cands []*candidate
+	for _, funcAddr := range known {
+		if funcAddr < s.addr || funcAddr >= s.addr+bin.Address(len(s.data)) {
+			continue
+		}
+		off := int(funcAddr - s.addr)
+		isKnown[off] = true
+		offs, _ := s.chain(off)
+		// Known functions take precedence over all candidates.
+		cands = append(cands, &candidate{off: off, score: int(^uint(0) >> 1), offs: offs})
+	}
+	for off := range s.insts {
+		if isKnown[off] {
+			continue
+		}
+		score := s.score(off)
+		if score < minScore {
+			continue
+		}
+		offs, ok := s.chain(off)
+		if !ok {
+			continue
+		}
+		cands = append(cands, &candidate{off: off, score: score, offs: offs})
+	}
+	less := func(i, j int) bool {
+		if cands[i].score != cands[j].score {
+			return cands[i].score > cands[j].score
+		}
+		return cands[i].off < cands[j].off
+	}
+	sort.Slice(cands, less)
+
+	// Byte occupancy of accepted instructions.
+	const (
+		free = iota
+		start
+		body
+	)
+	occ := make([]uint8, len(s.data))
+	var funcAddrs []bin.Address
+	for _, cand := range cands {
+		// Check for conflicts with previously accepted instructions. A chain may
+		// merge into a previously accepted chain (e.g. shared function tails).
+		if occ[cand.off] != free && !isKnown[cand.off] && s.callers[cand.off] == 0 {
+			// skip candidates within previously accepted instructions, unless
+			// targeted by call instructions.
+			continue
+		}
+		var accepted []int
+		conflict := false
+		for _, off := range cand.offs {
+			if occ[off] == start {
+				break
+			}
+			for i := off; i < off+s.insts[off].Len && i < len(occ); i++ {
+				if occ[i] != free {
+					conflict = true
+					break
+				}
+			}
+			if conflict {
+				break
+			}
+			accepted = append(accepted, off)
+		}
+		if conflict && !isKnown[cand.off] {
+			continue
+		}
+		for _, off := range accepted {
+			occ[off] = start
+			for i := off + 1; i < off+s.insts[off].Len && i < len(occ); i++ {
+				occ[i] = body
+			}
+		}
+		if !isKnown[cand.off] {
+			funcAddrs = append(funcAddrs, s.addr+bin.Address(cand.off))
+		}
+	}
+	return funcAddrs
+}
@@ -0,0 +1,358 @@
+package x86
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// MicroArch specifies a micro-architecture of the x86 architecture; used to
+// approximate instruction timings.
+type MicroArch uint8
+
+// Micro-architectures.
+const (
+	// No micro-architecture; instruction timings are disabled.
+	MicroArchNone MicroArch = iota
+	// Intel 8086/8088.
+	MicroArch8086
+	// Intel 80386.
+	MicroArch386
+	// Intel 80486.
+	MicroArch486
+	// Intel Pentium (P5).
+	MicroArchP5
+	// Intel Pentium Pro, Pentium II and Pentium III (P6).
+	MicroArchP6
+	// Intel Skylake.
+	MicroArchSkylake
+)
+
+// microArchNames maps from micro-architecture to name.
+var microArchNames = map[MicroArch]string{
+	MicroArch8086:    "8086",
+	MicroArch386:     "386",
+	MicroArch486:     "486",
+	MicroArchP5:      "p5",
+	MicroArchP6:      "p6",
+	MicroArchSkylake: "skylake",
+}
+
+// String returns the string representation of the micro-architecture.
+func (march MicroArch) String() string {
+	if march == MicroArchNone {
+		return "none"
+	}
+	if s, ok := microArchNames[march]; ok {
+		return s
+	}
+	return fmt.Sprintf("MicroArch(%d)", uint8(march))
+}
+
+// Set sets the micro-architecture to the given string; either "8086", "386",
+// "486", "p5", "p6" or "skylake".
+func (march *MicroArch) Set(s string) error {
+	for m, name := range microArchNames {
+		if name == s {
+			*march = m
+			return nil
+		}
+	}
+	return errors.Errorf("invalid micro-architecture %q; expected 8086, 386, 486, p5, p6 or skylake", s)
+}
+
+// A Timing is the approximate timing of an instruction on a given
+// micro-architecture.
+type Timing struct {
+	// Latency in clock cycles.
+	Latency int
+	// Number of micro-operations; or 0 if not applicable (e.g. micro-
+	// architectures predating the P6).
+	Uops int
+}
+
+// String returns the string representation of the instruction timing (e.g.
+// "3c 2uops").
+func (t Timing) String() string {
+	if t.Uops == 0 {
+		return fmt.Sprintf("%dc", t.Latency)
+	}
+	if t.Uops == 1 {
+		return fmt.Sprintf("%dc 1uop", t.Latency)
+	}
+	return fmt.Sprintf("%dc %duops", t.Latency, t.Uops)
+}
+
+// InstTiming returns the approximate timing of the given instruction on the
+// specified micro-architecture. The boolean return value indicates success.
+//
+// Timings are approximations based on published instruction tables (e.g. the
+// Intel programmer's reference manuals, and Agner Fog's instruction tables), and
+// do not account for pipelining, pairing, cache misses or operand-dependent
+// latencies (e.g. MUL and DIV on early micro-architectures, for which the
+// average is used).
+func InstTiming(inst x86asm.Inst, march MicroArch) (Timing, bool) {
+	table, ok := timingTables[march]
+	if !ok {
+		return Timing{}, false
+	}
+	class, ok := classOf(inst)
+	if !ok {
+		return Timing{}, false
+	}
+	t, ok := table.classes[class]
+	if !ok {
+		return Timing{}, false
+	}
+	if hasMemArg(inst) && class != classLEA && class != classString {
+		// Memory operand.
+		t.Latency += table.mem
+		if t.Uops > 0 {
+			t.Uops++
+		}
+	}
+	return t, true
+}
+
+// timingClass specifies a class of instructions with similar timing.
+type timingClass uint8
+
+// Instruction timing classes.
+const (
+	classALU timingClass = iota + 1
+	classMov
+	classLEA
+	classShift
+	classMul
+	classDiv
+	classBranch
+	classCall
+	classRet
+	classPush
+	classPop
+	classString
+	classFAdd
+	classFMul
+	classFDiv
+	classFSqrt
+	classFMov
+	classNop
+)
+
+// timingTable is an instruction timing table of a micro-architecture.
+type timingTable struct {
+	// Additional latency of memory operands.
+	mem int
+	// Map from instruction class to timing of register operands.
+	classes map[timingClass]Timing
+}
+
+// timingTables maps from micro-architecture to instruction timing table.
+var timingTables = map[MicroArch]timingTable{
+	MicroArch8086: {
+		mem: 9,
+		classes: map[timingClass]Timing{
+			classALU:    {Latency: 3},
+			classMov:    {Latency: 2},
+			classLEA:    {Latency: 2},
+			classShift:  {Latency: 2},
+			classMul:    {Latency: 118},
+			classDiv:    {Latency: 150},
+			classBranch: {Latency: 15},
+			classCall:   {Latency: 19},
+			classRet:    {Latency: 16},
+			classPush:   {Latency: 11},
+			classPop:    {Latency: 8},
+			classString: {Latency: 18},
+			classFAdd:   {Latency: 85},
+			classFMul:   {Latency: 130},
+			classFDiv:   {Latency: 200},
+			classFSqrt:  {Latency: 180},
+			classFMov:   {Latency: 17},
+			classNop:    {Latency: 3},
+		},
+	},
+	MicroArch386: {
+		mem: 4,
+		classes: map[timingClass]Timing{
+			classALU:    {Latency: 2},
+			classMov:    {Latency: 2},
+			classLEA:    {Latency: 2},
+			classShift:  {Latency: 3},
+			classMul:    {Latency: 22},
+			classDiv:    {Latency: 38},
+			classBranch: {Latency: 7},
+			classCall:   {Latency: 7},
+			classRet:    {Latency: 10},
+			classPush:   {Latency: 2},
+			classPop:    {Latency: 4},
+			classString: {Latency: 7},
+			classFAdd:   {Latency: 23},
+			classFMul:   {Latency: 29},
+			classFDiv:   {Latency: 88},
+			classFSqrt:  {Latency: 122},
+			classFMov:   {Latency: 14},
+			classNop:    {Latency: 3},
+		},
+	},
+	MicroArch486: {
+		mem: 2,
+		classes: map[timingClass]Timing{
+			classALU:    {Latency: 1},
+			classMov:    {Latency: 1},
+			classLEA:    {Latency: 1},
+			classShift:  {Latency: 2},
+			classMul:    {Latency: 26},
+			classDiv:    {Latency: 40},
+			classBranch: {Latency: 3},
+			classCall:   {Latency: 3},
+			classRet:    {Latency: 5},
+			classPush:   {Latency: 1},
+			classPop:    {Latency: 4},
+			classString: {Latency: 5},
+			classFAdd:   {Latency: 8},
+			classFMul:   {Latency: 16},
+			classFDiv:   {Latency: 73},
+			classFSqrt:  {Latency: 83},
+			classFMov:   {Latency: 3},
+			classNop:    {Latency: 1},
+		},
+	},
+	MicroArchP5: {
+		mem: 1,
+		classes: map[timingClass]Timing{
+			classALU:    {Latency: 1},
+			classMov:    {Latency: 1},
+			classLEA:    {Latency: 1},
+			classShift:  {Latency: 1},
+			classMul:    {Latency: 10},
+			classDiv:    {Latency: 41},
+			classBranch: {Latency: 1},
+			classCall:   {Latency: 1},
+			classRet:    {Latency: 2},
+			classPush:   {Latency: 1},
+			classPop:    {Latency: 1},
+			classString: {Latency: 3},
+			classFAdd:   {Latency: 3},
+			classFMul:   {Latency: 3},
+			classFDiv:   {Latency: 39},
+			classFSqrt:  {Latency: 70},
+			classFMov:   {Latency: 1},
+			classNop:    {Latency: 1},
+		},
+	},
+	MicroArchP6: {
+		mem: 3,
+		classes: map[timingClass]Timing{
+			classALU:    {Latency: 1, Uops: 1},
+			classMov:    {Latency: 1, Uops: 1},
+			classLEA:    {Latency: 1, Uops: 1},
+			classShift:  {Latency: 1, Uops: 1},
+			classMul:    {Latency: 4, Uops: 1},
+			classDiv:    {Latency: 39, Uops: 4},
+			classBranch: {Latency: 1, Uops: 1},
+			classCall:   {Latency: 1, Uops: 4},
+			classRet:    {Latency: 1, Uops: 4},
+			classPush:   {Latency: 1, Uops: 3},
+			classPop:    {Latency: 1, Uops: 2},
+			classString: {Latency: 3, Uops: 3},
+			classFAdd:   {Latency: 3, Uops: 1},
+			classFMul:   {Latency: 5, Uops: 1},
+			classFDiv:   {Latency: 38, Uops: 1},
+			classFSqrt:  {Latency: 69, Uops: 1},
+			classFMov:   {Latency: 1, Uops: 1},
+			classNop:    {Latency: 1, Uops: 1},
+		},
+	},
+	MicroArchSkylake: {
+		mem: 5,
+		classes: map[timingClass]Timing{
+			classALU:    {Latency: 1, Uops: 1},
+			classMov:    {Latency: 1, Uops: 1},
+			classLEA:    {Latency: 1, Uops: 1},
+			classShift:  {Latency: 1, Uops: 1},
+			classMul:    {Latency: 3, Uops: 1},
+			classDiv:    {Latency: 26, Uops: 10},
+			classBranch: {Latency: 1, Uops: 1},
+			classCall:   {Latency: 2, Uops: 2},
+			classRet:    {Latency: 2, Uops: 2},
+			classPush:   {Latency: 1, Uops: 1},
+			classPop:    {Latency: 1, Uops: 1},
+			classString: {Latency: 4, Uops: 4},
+			classFAdd:   {Latency: 3, Uops: 1},
+			classFMul:   {Latency: 5, Uops: 1},
+			classFDiv:   {Latency: 15, Uops: 1},
+			classFSqrt:  {Latency: 15, Uops: 1},
+			classFMov:   {Latency: 1, Uops: 1},
+			classNop:    {Latency: 1, Uops: 1},
+		},
+	},
+}
+
+// classOf returns the timing class of the given instruction. The boolean
+// return value indicates success.
+func classOf(inst x86asm.Inst) (timingClass, bool) {
+	switch inst.Op {
+	case x86asm.ADC, x86asm.ADD, x86asm.AND, x86asm.CMP, x86asm.DEC, x86asm.INC, x86asm.NEG, x86asm.NOT, x86asm.OR, x86asm.SBB, x86asm.SUB, x86asm.TEST, x86asm.XOR, x86asm.CBW, x86asm.CWDE, x86asm.CDQ, x86asm.CWD, x86asm.CLC, x86asm.STC, x86asm.CMC, x86asm.CLD, x86asm.STD, x86asm.SETA, x86asm.SETAE, x86asm.SETB, x86asm.SETBE, x86asm.SETE, x86asm.SETG, x86asm.SETGE, x86asm.SETL, x86asm.SETLE, x86asm.SETNE, x86asm.SETNO, x86asm.SETNP, x86asm.SETNS, x86asm.SETO, x86asm.SETP, x86asm.SETS:
+		return classALU, true
+	case x86asm.MOV, x86asm.MOVSX, x86asm.MOVZX, x86asm.MOVSXD, x86asm.XCHG, x86asm.CMOVA, x86asm.CMOVAE, x86asm.CMOVB, x86asm.CMOVBE, x86asm.CMOVE, x86asm.CMOVG, x86asm.CMOVGE, x86asm.CMOVL, x86asm.CMOVLE, x86asm.CMOVNE, x86asm.CMOVNO, x86asm.CMOVNP, x86asm.CMOVNS, x86asm.CMOVO, x86asm.CMOVP, x86asm.CMOVS:
+		return classMov, true
+	case x86asm.LEA:
+		return classLEA, true
+	case x86asm.SHL, x86asm.SHR, x86asm.SAR, x86asm.ROL, x86asm.ROR, x86asm.RCL, x86asm.RCR, x86asm.SHLD, x86asm.SHRD:
+		return classShift, true
+	case x86asm.MUL, x86asm.IMUL:
+		return classMul, true
+	case x86asm.DIV, x86asm.IDIV:
+		return classDiv, true
+	case x86asm.JA, x86asm.JAE, x86asm.JB, x86asm.JBE, x86asm.JCXZ, x86asm.JE, x86asm.JECXZ, x86asm.JG, x86asm.JGE, x86asm.JL, x86asm.JLE, x86asm.JMP, x86asm.JNE, x86asm.JNO, x86asm.JNP, x86asm.JNS, x86asm.JO, x86asm.JP, x86asm.JRCXZ, x86asm.JS, x86asm.LOOP, x86asm.LOOPE, x86asm.LOOPNE:
+		return classBranch, true
+	case x86asm.CALL:
+		return classCall, true
+	case x86asm.RET, x86asm.LRET, x86asm.IRET, x86asm.IRETD:
+		return classRet, true
+	case x86asm.PUSH, x86asm.PUSHF, x86asm.PUSHFD:
+		return classPush, true
+	case x86asm.POP, x86asm.POPF, x86asm.POPFD:
+		return classPop, true
+	case x86asm.MOVSB, x86asm.MOVSW, x86asm.MOVSD, x86asm.STOSB, x86asm.STOSW, x86asm.STOSD, x86asm.LODSB, x86asm.LODSW, x86asm.LODSD, x86asm.SCASB, x86asm.SCASW, x86asm.SCASD, x86asm.CMPSB, x86asm.CMPSW, x86asm.CMPSD:
+		if inst.Op == x86asm.MOVSD && (isXMM(inst.Args[0]) || isXMM(inst.Args[1])) {
+			// SSE2 MOVSD.
+			return classFMov, true
+		}
+		return classString, true
+	case x86asm.FADD, x86asm.FADDP, x86asm.FIADD, x86asm.FSUB, x86asm.FSUBP, x86asm.FSUBR, x86asm.FSUBRP, x86asm.FISUB, x86asm.FISUBR, x86asm.FCOM, x86asm.FCOMP, x86asm.FCOMPP, x86asm.FUCOM, x86asm.FUCOMP, x86asm.FUCOMPP, x86asm.FCOMI, x86asm.FCOMIP, x86asm.FUCOMI, x86asm.FUCOMIP, x86asm.ADDSS, x86asm.ADDSD, x86asm.SUBSS, x86asm.SUBSD:
+		return classFAdd, true
+	case x86asm.FMUL, x86asm.FMULP, x86asm.FIMUL, x86asm.MULSS, x86asm.MULSD:
+		return classFMul, true
+	case x86asm.FDIV, x86asm.FDIVP, x86asm.FDIVR, x86asm.FDIVRP, x86asm.FIDIV, x86asm.FIDIVR, x86asm.DIVSS, x86asm.DIVSD:
+		return classFDiv, true
+	case x86asm.FSQRT, x86asm.SQRTSS, x86asm.SQRTSD:
+		return classFSqrt, true
+	case x86asm.FLD, x86asm.FST, x86asm.FSTP, x86asm.FILD, x86asm.FIST, x86asm.FISTP, x86asm.FXCH, x86asm.FCHS, x86asm.FABS, x86asm.FLDZ, x86asm.FLD1, x86asm.MOVSS, x86asm.MOVAPS, x86asm.MOVUPS:
+		return classFMov, true
+	case x86asm.NOP:
+		return classNop, true
+	}
+	return 0, false
+}
+
+// hasMemArg reports whether the given instruction has a memory operand.
+func hasMemArg(inst x86asm.Inst) bool {
+	for _, arg := range inst.Args {
+		if arg == nil {
+			break
+		}
+		if _, ok := arg.(x86asm.Mem); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isXMM reports whether the given argument is an XMM register.
+func isXMM(arg x86asm.Arg) bool {
+	reg, ok := arg.(x86asm.Reg)
+	return ok && x86asm.X0 <= reg && reg <= x86asm.X15
+}
@@ -0,0 +1,123 @@
+package x86
+
+import (
+	"bufio"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/annot"
+	"github.com/pkg/errors"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// ImportTrace imports the execution trace read from the given path, to seed
+// function and basic block discovery, and to resolve the targets of indirect
+// branches observed at runtime.
+//
+// The execution trace lists the addresses of executed instructions in order,
+// one address per line, as produced by tracing tools such as Pin, DynamoRIO or
+// perf. Addresses are hexadecimal, with or without 0x prefix, and only the
+// first field of each line is considered; empty lines and lines starting with
+// '#' are ignored.
+func (dis *Disasm) ImportTrace(path string) error {
+	addrs, err := ParseTrace(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	dbg.Printf("importing execution trace %q (%d instructions)", path, len(addrs))
+	if dis.Executed == nil {
+		dis.Executed = make(map[bin.Address]bool)
+	}
+	if dis.Indirect == nil {
+		dis.Indirect = make(map[bin.Address][]bin.Address)
+	}
+	a := annot.New()
+	for i, addr := range addrs {
+		if !dis.isExec(addr) {
+			// skip instructions outside of the binary executable (e.g. shared
+			// libraries).
+			continue
+		}
+		dis.Executed[addr] = true
+		if i == 0 {
+			a.BlockAddrs = append(a.BlockAddrs, addr)
+		}
+		if i+1 >= len(addrs) {
+			break
+		}
+		next := addrs[i+1]
+		inst, err := dis.DecodeInst(addr)
+		if err != nil {
+			warn.Printf("unable to decode traced instruction at %v; %v", addr, err)
+			continue
+		}
+		if next == addr+bin.Address(inst.Len) || !dis.isExec(next) {
+			// skip fallthrough and branches out of the binary executable.
+			continue
+		}
+		switch {
+		case inst.Op == x86asm.CALL:
+			a.FuncAddrs = append(a.FuncAddrs, next)
+			a.BlockAddrs = append(a.BlockAddrs, next)
+		case inst.isTerm() && inst.Op != x86asm.RET:
+			a.BlockAddrs = append(a.BlockAddrs, next)
+		default:
+			continue
+		}
+		if _, ok := inst.Args[0].(x86asm.Rel); !ok {
+			dis.Indirect[addr] = bin.InsertAddr(dis.Indirect[addr], next)
+		}
+	}
+	dis.Import(a)
+	return nil
+}
+
+// Unexecuted returns the addresses of the basic blocks of the given function
+// which were not executed in the imported execution traces. Unexecuted is nil
+// if no execution trace has been imported.
+func (dis *Disasm) Unexecuted(f *Func) []bin.Address {
+	if dis.Executed == nil {
+		return nil
+	}
+	var blockAddrs bin.Addresses
+	for blockAddr := range f.Blocks {
+		if !dis.Executed[blockAddr] {
+			blockAddrs = append(blockAddrs, blockAddr)
+		}
+	}
+	sort.Sort(blockAddrs)
+	return blockAddrs
+}
+
+// ParseTrace parses the execution trace read from the given path, and returns
+// the traced addresses in order. The format of execution traces is described
+// by ImportTrace.
+func ParseTrace(path string) ([]bin.Address, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	var addrs []bin.Address
+	s := bufio.NewScanner(f)
+	for lineNum := 1; s.Scan(); lineNum++ {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		field := strings.TrimSuffix(fields[0], ":")
+		field = strings.TrimPrefix(strings.ToLower(field), "0x")
+		x, err := strconv.ParseUint(field, 16, 64)
+		if err != nil {
+			return nil, errors.Errorf("invalid instruction address %q at line %d of execution trace %q", fields[0], lineNum, path)
+		}
+		addrs = append(addrs, bin.Address(x))
+	}
+	if err := s.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return addrs, nil
+}
@@ -0,0 +1,853 @@
+package x86
+
+import (
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/vsa"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// Value-set analysis heuristics.
+const (
+	// Maximum number of values enumerated by memory loads and indirect branch
+	// targets.
+	maxVSAValues = 512
+	// Number of visits of a basic block before widening.
+	maxVSAVisits = 8
+)
+
+// A VSA holds the results of value-set analysis of a function; i.e. the value
+// sets of registers and memory locations at each instruction.
+//
+// Registers are tracked by register family (e.g. AL, AX, EAX and RAX), and
+// memory locations at static addresses or at constant offsets from registers
+// (e.g. stack variables relative to the frame pointer).
+type VSA struct {
+	// Disassembler.
+	dis *Disasm
+	// Analyzed function.
+	f *Func
+	// Map from basic block address to value sets at the entry of the basic
+	// block; or nil if unreachable.
+	in map[bin.Address]*vsaState
+}
+
+// ValueSets performs value-set analysis of the given function.
+//
+// The analysis iterates to a fixed point over the control flow graph of the
+// function, narrowing value sets on the edges of conditional branches guarded
+// by comparisons against constants (e.g. bounds checks of jump tables).
+func (dis *Disasm) ValueSets(f *Func) *VSA {
+	v := &VSA{
+		dis: dis,
+		f:   f,
+		in:  make(map[bin.Address]*vsaState),
+	}
+	if _, ok := f.Blocks[f.Addr]; !ok {
+		return v
+	}
+	v.in[f.Addr] = newVSAState()
+	visits := make(map[bin.Address]int)
+	queue := newQueue()
+	queue.push(f.Addr)
+	for !queue.empty() {
+		blockAddr := queue.pop()
+		block := f.Blocks[blockAddr]
+		st := v.in[blockAddr].clone()
+		for _, inst := range block.Insts {
+			dis.transfer(inst, st)
+		}
+		if !block.Term.IsDummyTerm() {
+			dis.transfer(block.Term, st)
+		}
+		for _, edge := range dis.vsaEdges(block) {
+			if _, ok := f.Blocks[edge.target]; !ok {
+				continue
+			}
+			out := st.clone()
+			if edge.narrow != nil {
+				edge.narrow(out)
+			}
+			old := v.in[edge.target]
+			visits[edge.target]++
+			var joined *vsaState
+			if visits[edge.target] > maxVSAVisits {
+				joined = old.widen(out)
+			} else {
+				joined = old.join(out)
+			}
+			if old == nil || !old.equal(joined) {
+				v.in[edge.target] = joined
+				queue.push(edge.target)
+			}
+		}
+	}
+	return v
+}
+
+// Reg returns the value set of the given register before the execution of the
+// instruction at the specified address.
+func (v *VSA) Reg(instAddr bin.Address, reg x86asm.Reg) vsa.ValueSet {
+	st, inst, ok := v.state(instAddr)
+	if !ok {
+		return vsa.Top()
+	}
+	return v.dis.evalArg(reg, st, inst)
+}
+
+// Arg returns the value set of the i:th argument of the instruction at the
+// specified address, before its execution. The value set of memory arguments is
+// the set of values loaded from memory.
+func (v *VSA) Arg(instAddr bin.Address, i int) vsa.ValueSet {
+	st, inst, ok := v.state(instAddr)
+	if !ok {
+		return vsa.Top()
+	}
+	return v.dis.evalArg(inst.Args[i], st, inst)
+}
+
+// MemAddr returns the value set of the addresses accessed by the i:th argument
+// of the instruction at the specified address, which must be a memory
+// argument.
+func (v *VSA) MemAddr(instAddr bin.Address, i int) vsa.ValueSet {
+	st, inst, ok := v.state(instAddr)
+	if !ok {
+		return vsa.Top()
+	}
+	mem, ok := inst.Args[i].(x86asm.Mem)
+	if !ok {
+		return vsa.Top()
+	}
+	return st.memAddr(mem, inst)
+}
+
+// state returns the value sets before the execution of the instruction at the
+// specified address. The boolean return value indicates success.
+func (v *VSA) state(instAddr bin.Address) (*vsaState, *Inst, bool) {
+	for blockAddr, block := range v.f.Blocks {
+		in, ok := v.in[blockAddr]
+		if !ok {
+			continue
+		}
+		st := in.clone()
+		for _, inst := range block.Insts {
+			if inst.Addr == instAddr {
+				return st, inst, true
+			}
+			v.dis.transfer(inst, st)
+		}
+		if block.Term.Addr == instAddr && !block.Term.IsDummyTerm() {
+			return st, block.Term, true
+		}
+	}
+	return nil, nil, false
+}
+
+// --- [ Abstract state ] ------------------------------------------------------
+
+// vsaState holds the value sets of registers and memory locations at a program
+// point. Registers and memory locations not present are unknown.
+type vsaState struct {
+	// Map from register family to value set.
+	regs map[int]vsa.ValueSet
+	// Map from memory location to value set.
+	mems map[memLoc]vsa.ValueSet
+}
+
+// memLoc is a memory location, at a constant offset from a register or at a
+// static address.
+type memLoc struct {
+	// Register family of base register; or -1 for static addresses.
+	base int
+	// Offset from base register, or static address.
+	disp int64
+	// Size in bytes of the memory location.
+	size int
+}
+
+// overlaps reports whether the memory locations may overlap. Memory locations
+// relative to different base registers (or static addresses) may alias.
+func (loc memLoc) overlaps(other memLoc) bool {
+	if loc.base != other.base {
+		return true
+	}
+	return loc.disp < other.disp+int64(other.size) && other.disp < loc.disp+int64(loc.size)
+}
+
+// newVSAState returns a new state with unknown registers and memory.
+func newVSAState() *vsaState {
+	return &vsaState{
+		regs: make(map[int]vsa.ValueSet),
+		mems: make(map[memLoc]vsa.ValueSet),
+	}
+}
+
+// clone returns a copy of the state.
+func (st *vsaState) clone() *vsaState {
+	c := newVSAState()
+	for fam, vs := range st.regs {
+		c.regs[fam] = vs
+	}
+	for loc, vs := range st.mems {
+		c.mems[loc] = vs
+	}
+	return c
+}
+
+// join returns the union of the states. A nil state denotes an unreachable
+// program point.
+func (st *vsaState) join(other *vsaState) *vsaState {
+	if st == nil {
+		return other.clone()
+	}
+	c := newVSAState()
+	for fam, vs := range st.regs {
+		if o, ok := other.regs[fam]; ok {
+			if j := vs.Join(o); !j.IsTop() {
+				c.regs[fam] = j
+			}
+		}
+	}
+	for loc, vs := range st.mems {
+		if o, ok := other.mems[loc]; ok {
+			if j := vs.Join(o); !j.IsTop() {
+				c.mems[loc] = j
+			}
+		}
+	}
+	return c
+}
+
+// widen returns the union of the states, where value sets which have changed
+// are considered unknown, to ensure termination.
+func (st *vsaState) widen(other *vsaState) *vsaState {
+	c := st.join(other)
+	if st == nil {
+		return c
+	}
+	for fam, vs := range c.regs {
+		if !vs.Equal(st.regs[fam]) {
+			delete(c.regs, fam)
+		}
+	}
+	for loc, vs := range c.mems {
+		if !vs.Equal(st.mems[loc]) {
+			delete(c.mems, loc)
+		}
+	}
+	return c
+}
+
+// equal reports whether the states are identical.
+func (st *vsaState) equal(other *vsaState) bool {
+	if len(st.regs) != len(other.regs) || len(st.mems) != len(other.mems) {
+		return false
+	}
+	for fam, vs := range st.regs {
+		if o, ok := other.regs[fam]; !ok || !vs.Equal(o) {
+			return false
+		}
+	}
+	for loc, vs := range st.mems {
+		if o, ok := other.mems[loc]; !ok || !vs.Equal(o) {
+			return false
+		}
+	}
+	return true
+}
+
+// setReg sets the value set of the given register family, and invalidates
+// memory locations relative to the register.
+func (st *vsaState) setReg(fam int, vs vsa.ValueSet) {
+	if vs.IsTop() {
+		delete(st.regs, fam)
+	} else {
+		st.regs[fam] = vs
+	}
+	for loc := range st.mems {
+		if loc.base == fam {
+			delete(st.mems, loc)
+		}
+	}
+}
+
+// memAddr returns the value set of the addresses accessed by the given memory
+// reference of the instruction.
+func (st *vsaState) memAddr(mem x86asm.Mem, inst *Inst) vsa.ValueSet {
+	if mem.Segment != 0 {
+		return vsa.Top()
+	}
+	next := inst.Addr + bin.Address(inst.Len)
+	addr := vsa.Const(uint64(mem.Disp))
+	switch mem.Base {
+	case 0:
+	case x86asm.RIP, x86asm.EIP:
+		addr = addr.Add(vsa.Const(uint64(next)))
+	default:
+		addr = addr.Add(st.reg(mem.Base))
+	}
+	if mem.Index != 0 {
+		index := st.reg(mem.Index).Mul(vsa.Const(uint64(mem.Scale)))
+		addr = addr.Add(index)
+	}
+	return addr
+}
+
+// memLoc returns the memory location of the given memory reference of the
+// instruction. The boolean return value indicates success.
+func (st *vsaState) memLoc(mem x86asm.Mem, inst *Inst) (memLoc, bool) {
+	if mem.Segment != 0 || mem.Index != 0 {
+		return memLoc{}, false
+	}
+	size := argSize(mem, inst) / 8
+	switch mem.Base {
+	case 0:
+		return memLoc{base: -1, disp: mem.Disp, size: size}, true
+	case x86asm.RIP, x86asm.EIP:
+		next := inst.Addr + bin.Address(inst.Len)
+		return memLoc{base: -1, disp: int64(next) + mem.Disp, size: size}, true
+	}
+	fam, _ := RegFamily(mem.Base)
+	if fam == -1 {
+		return memLoc{}, false
+	}
+	return memLoc{base: fam, disp: mem.Disp, size: size}, true
+}
+
+// reg returns the value set of the given register.
+func (st *vsaState) reg(reg x86asm.Reg) vsa.ValueSet {
+	fam, isWide := RegFamily(reg)
+	if fam == -1 {
+		return vsa.Top()
+	}
+	vs, ok := st.regs[fam]
+	if !ok {
+		return vsa.Top()
+	}
+	switch {
+	case x86asm.AH <= reg && reg < x86asm.SPB:
+		// high byte registers.
+		return vsa.Top()
+	case !isWide || (x86asm.EAX <= reg && reg <= x86asm.R15L):
+		return vs.Truncate(regSize(reg))
+	}
+	return vs
+}
+
+// --- [ Transfer functions ] --------------------------------------------------
+
+// transfer updates the state based on the execution of the given instruction.
+//
+// Registers written implicitly by the instruction (e.g. EDX of CDQ) are
+// considered unknown after its execution, as are registers and memory locations
+// written explicitly by instructions without modelled semantics.
+func (dis *Disasm) transfer(inst *Inst, st *vsaState) {
+	dis.transferArgs(inst, st)
+	for _, fam := range implicitDefs(inst) {
+		st.setReg(fam, vsa.Top())
+	}
+}
+
+// transferArgs updates the state based on the writes to the explicit arguments
+// and memory of the given instruction.
+func (dis *Disasm) transferArgs(inst *Inst, st *vsaState) {
+	switch inst.Op {
+	case x86asm.CALL, x86asm.INT, x86asm.INTO, x86asm.SYSCALL, x86asm.SYSENTER:
+		// Caller-saved registers are not preserved across calls, and the callee
+		// may update global variables and local variables of the caller through
+		// pointers.
+		callerSaved := []int{0, 1, 2, 4}
+		if dis.Mode == 64 {
+			callerSaved = append(callerSaved, 6, 7, 8, 9, 10, 11)
+		}
+		for _, fam := range callerSaved {
+			st.setReg(fam, vsa.Top())
+		}
+		for loc := range st.mems {
+			delete(st.mems, loc)
+		}
+		return
+	case x86asm.PUSH, x86asm.POP, x86asm.RET:
+		// Stack pointer updated.
+		const spFam = 4
+		if inst.Op == x86asm.PUSH {
+			// The pushed value may alias stack memory relative to other
+			// registers (e.g. the frame pointer).
+			for loc := range st.mems {
+				if loc.base != -1 {
+					delete(st.mems, loc)
+				}
+			}
+		}
+		st.setReg(spFam, vsa.Top())
+		if inst.Op == x86asm.POP {
+			dis.write(inst.Args[0], vsa.Top(), st, inst)
+		}
+		return
+	case x86asm.CMP, x86asm.TEST, x86asm.NOP, x86asm.JMP:
+		// no side effects tracked.
+		return
+	}
+	if inst.isTerm() {
+		return
+	}
+	var vs vsa.ValueSet
+	switch inst.Op {
+	case x86asm.MOV:
+		vs = dis.evalArg(inst.Args[1], st, inst)
+	case x86asm.MOVZX:
+		vs = dis.evalArg(inst.Args[1], st, inst)
+	case x86asm.MOVSX, x86asm.MOVSXD:
+		vs = dis.evalArg(inst.Args[1], st, inst).SignExtend(argSize(inst.Args[1], inst))
+	case x86asm.LEA:
+		if mem, ok := inst.Args[1].(x86asm.Mem); ok {
+			vs = st.memAddr(mem, inst)
+		}
+	case x86asm.ADD:
+		vs = dis.evalArg(inst.Args[0], st, inst).Add(dis.evalArg(inst.Args[1], st, inst))
+	case x86asm.SUB:
+		vs = dis.evalArg(inst.Args[0], st, inst).Sub(dis.evalArg(inst.Args[1], st, inst))
+	case x86asm.AND:
+		vs = dis.evalArg(inst.Args[0], st, inst).And(dis.evalArg(inst.Args[1], st, inst))
+	case x86asm.INC:
+		vs = dis.evalArg(inst.Args[0], st, inst).Add(vsa.Const(1))
+	case x86asm.DEC:
+		vs = dis.evalArg(inst.Args[0], st, inst).Sub(vsa.Const(1))
+	case x86asm.SHL:
+		if n, ok := dis.evalArg(inst.Args[1], st, inst).Const(); ok && n < 64 {
+			vs = dis.evalArg(inst.Args[0], st, inst).Mul(vsa.Const(1 << n))
+		}
+	case x86asm.XOR:
+		if inst.Args[0] == inst.Args[1] {
+			vs = vsa.Const(0)
+		}
+	}
+	if inst.Args[0] != nil {
+		dis.write(inst.Args[0], vs, st, inst)
+	}
+}
+
+// implicitDefs returns the register families written by the given instruction,
+// other than through its first argument; e.g. EDX of CDQ, or the second
+// argument of XCHG. All register families are returned for instructions with
+// unknown effects on registers (e.g. system calls).
+func implicitDefs(inst *Inst) []int {
+	// Register families.
+	const (
+		ax = 0
+		cx = 1
+		dx = 2
+		bx = 3
+		sp = 4
+		bp = 5
+		si = 6
+		di = 7
+	)
+	switch inst.Op {
+	case x86asm.CBW, x86asm.CWDE, x86asm.CDQE, x86asm.LAHF, x86asm.XLATB:
+		return []int{ax}
+	case x86asm.CWD, x86asm.CDQ, x86asm.CQO:
+		return []int{dx}
+	case x86asm.MUL, x86asm.DIV, x86asm.IDIV, x86asm.RDTSC, x86asm.RDMSR, x86asm.XGETBV, x86asm.CMPXCHG8B, x86asm.CMPXCHG16B:
+		return []int{ax, dx}
+	case x86asm.IMUL:
+		if inst.Args[1] == nil {
+			// One-operand form; EDX:EAX = EAX * r/m32.
+			return []int{ax, dx}
+		}
+	case x86asm.RDTSCP:
+		return []int{ax, cx, dx}
+	case x86asm.CPUID:
+		return []int{ax, bx, cx, dx}
+	case x86asm.CMPXCHG:
+		return []int{ax}
+	case x86asm.XCHG, x86asm.XADD:
+		if reg, ok := inst.Args[1].(x86asm.Reg); ok {
+			if fam, _ := RegFamily(reg); fam != -1 {
+				return []int{fam}
+			}
+		}
+	case x86asm.LODSB, x86asm.LODSW, x86asm.LODSD, x86asm.LODSQ, x86asm.OUTSB, x86asm.OUTSW, x86asm.OUTSD:
+		return []int{ax, cx, si}
+	case x86asm.STOSB, x86asm.STOSW, x86asm.STOSD, x86asm.STOSQ, x86asm.SCASB, x86asm.SCASW, x86asm.SCASD, x86asm.SCASQ, x86asm.INSB, x86asm.INSW, x86asm.INSD:
+		// ECX is updated by REP prefixed string instructions.
+		return []int{cx, di}
+	case x86asm.MOVSB, x86asm.MOVSW, x86asm.MOVSD, x86asm.MOVSQ, x86asm.CMPSB, x86asm.CMPSW, x86asm.CMPSD, x86asm.CMPSQ:
+		return []int{cx, si, di}
+	case x86asm.LOOP, x86asm.LOOPE, x86asm.LOOPNE:
+		return []int{cx}
+	case x86asm.ENTER, x86asm.LEAVE, x86asm.PUSHA, x86asm.PUSHAD:
+		return []int{sp, bp}
+	case x86asm.POPA, x86asm.POPAD, x86asm.INT, x86asm.INTO, x86asm.SYSCALL, x86asm.SYSENTER:
+		// All general purpose registers.
+		fams := make([]int, 16)
+		for fam := range fams {
+			fams[fam] = fam
+		}
+		return fams
+	}
+	return nil
+}
+
+// write updates the state based on a write of the given value set to the
+// argument of the instruction.
+func (dis *Disasm) write(arg x86asm.Arg, vs vsa.ValueSet, st *vsaState, inst *Inst) {
+	switch arg := arg.(type) {
+	case x86asm.Reg:
+		fam, isWide := RegFamily(arg)
+		if fam == -1 {
+			return
+		}
+		if !isWide {
+			// partial register write.
+			vs = vsa.Top()
+		}
+		st.setReg(fam, vs.Truncate(regSize(arg)))
+	case x86asm.Mem:
+		if loc, ok := st.memLoc(arg, inst); ok {
+			for other := range st.mems {
+				if loc.overlaps(other) {
+					delete(st.mems, other)
+				}
+			}
+			if !vs.IsTop() {
+				st.mems[loc] = vs.Truncate(argSize(arg, inst))
+			}
+			return
+		}
+		// Write to unknown memory location; may alias any memory location.
+		for loc := range st.mems {
+			delete(st.mems, loc)
+		}
+	}
+}
+
+// evalArg returns the value set of the given argument of the instruction.
+func (dis *Disasm) evalArg(arg x86asm.Arg, st *vsaState, inst *Inst) vsa.ValueSet {
+	switch arg := arg.(type) {
+	case x86asm.Imm:
+		return vsa.Const(uint64(arg))
+	case x86asm.Reg:
+		return st.reg(arg)
+	case x86asm.Mem:
+		if loc, ok := st.memLoc(arg, inst); ok {
+			if vs, ok := st.mems[loc]; ok {
+				return vs
+			}
+		}
+		// Load from read-only memory.
+		addrs, ok := st.memAddr(arg, inst).Values(maxVSAValues)
+		if !ok {
+			return vsa.Top()
+		}
+		size := argSize(arg, inst) / 8
+		var vals []uint64
+		for _, addr := range addrs {
+			v, ok := dis.readConst(bin.Address(addr), size)
+			if !ok {
+				return vsa.Top()
+			}
+			vals = append(vals, v)
+		}
+		return vsa.Values(vals...)
+	}
+	return vsa.Top()
+}
+
+// --- [ Control flow edges ] --------------------------------------------------
+
+// vsaEdge is a control flow edge of value-set analysis.
+type vsaEdge struct {
+	// Target basic block address.
+	target bin.Address
+	// Narrows the value sets of the state based on the branch condition; or nil
+	// if unconditional.
+	narrow func(st *vsaState)
+}
+
+// vsaEdges returns the outgoing control flow edges of the given basic block.
+// Unresolved indirect branches have no edges.
+func (dis *Disasm) vsaEdges(block *BasicBlock) []vsaEdge {
+	term := block.Term
+	if term.IsDummyTerm() {
+		return []vsaEdge{{target: term.Addr}}
+	}
+	next := term.Addr + bin.Address(term.Len)
+	if term.Op == x86asm.RET {
+		return nil
+	}
+	if term.Op == x86asm.LJMP {
+		if target, ok := dis.FarTarget(term); ok {
+			return []vsaEdge{{target: target}}
+		}
+		return nil
+	}
+	var targets []bin.Address
+	if rel, ok := term.Args[0].(x86asm.Rel); ok {
+		targets = append(targets, next+bin.Address(rel))
+	} else if ts, ok := dis.Indirect[term.Addr]; ok {
+		targets = ts
+	} else if mem, ok := term.Args[0].(x86asm.Mem); ok {
+		targets = dis.Tables[bin.Address(mem.Disp)]
+	}
+	if term.Op == x86asm.JMP {
+		var edges []vsaEdge
+		for _, target := range targets {
+			edges = append(edges, vsaEdge{target: target})
+		}
+		return edges
+	}
+	// Conditional branch.
+	var edges []vsaEdge
+	taken, fall := dis.branchNarrow(block)
+	for _, target := range targets {
+		edges = append(edges, vsaEdge{target: target, narrow: taken})
+	}
+	return append(edges, vsaEdge{target: next, narrow: fall})
+}
+
+// branchNarrow returns functions narrowing value sets on the taken and
+// fallthrough edges of the conditional branch terminating the given basic
+// block, if guarded by an unsigned comparison against a constant (e.g. "cmp
+// eax, 5; ja default").
+func (dis *Disasm) branchNarrow(block *BasicBlock) (taken, fall func(st *vsaState)) {
+	if len(block.Insts) == 0 {
+		return nil, nil
+	}
+	cmp := block.Insts[len(block.Insts)-1]
+	if cmp.Op != x86asm.CMP {
+		return nil, nil
+	}
+	imm, ok := cmp.Args[1].(x86asm.Imm)
+	if !ok {
+		return nil, nil
+	}
+	size := argSize(cmp.Args[0], cmp)
+	max := uint64(1)<<uint(size) - 1
+	if size >= 64 {
+		max = ^uint64(0)
+	}
+	n := uint64(imm) & max
+	narrow := func(lo, hi uint64) func(st *vsaState) {
+		if lo > hi {
+			return nil
+		}
+		return func(st *vsaState) {
+			vs := dis.evalArg(cmp.Args[0], st, cmp).Narrow(lo, hi)
+			dis.write(cmp.Args[0], vs, st, cmp)
+		}
+	}
+	switch block.Term.Op {
+	case x86asm.JA:
+		return narrow(n+1, max), narrow(0, n)
+	case x86asm.JAE:
+		if n == 0 {
+			return nil, nil
+		}
+		return narrow(n, max), narrow(0, n-1)
+	case x86asm.JB:
+		if n == 0 {
+			return nil, nil
+		}
+		return narrow(0, n-1), narrow(n, max)
+	case x86asm.JBE:
+		return narrow(0, n), narrow(n+1, max)
+	case x86asm.JE:
+		return narrow(n, n), nil
+	case x86asm.JNE:
+		return nil, narrow(n, n)
+	}
+	return nil, nil
+}
+
+// --- [ Indirect branch resolution ] ------------------------------------------
+
+// The targets of indirect jumps and calls are resolved using value-set
+// analysis, and recorded in dis.Indirect, from which they are used both by the
+// control flow graph and the lifted LLVM IR; the decoded instructions are left
+// unmodified. Registers are assigned values by immediates, address computations
+// (LEA) and loads from read-only memory (e.g. constant globals and function
+// pointer tables).
+//
+//    mov eax, [0x402000]       ; 0x402000 in read-only section
+//    call eax                  ; target: [0x402000]
+//
+//    cmp eax, 3
+//    ja default
+//    jmp [0x402010+eax*4]      ; targets: [0x402010], ..., [0x40201C]
+
+// isUnresolvedJump reports whether the given terminator is an indirect jump
+// with unknown targets.
+func (dis *Disasm) isUnresolvedJump(term *Inst) bool {
+	if term.IsDummyTerm() || term.Op != x86asm.JMP {
+		return false
+	}
+	if _, ok := dis.Indirect[term.Addr]; ok {
+		return false
+	}
+	switch arg := term.Args[0].(type) {
+	case x86asm.Rel:
+		return false
+	case x86asm.Mem:
+		if arg.Base == 0 && arg.Index == 0 {
+			// static target.
+			return false
+		}
+		if _, ok := dis.Tables[bin.Address(arg.Disp)]; ok {
+			// jump table.
+			return false
+		}
+	}
+	return true
+}
+
+// resolveJump resolves the targets of the given indirect jump of the (partially
+// decoded) function using value-set analysis. The resolved targets are
+// recorded in dis.Indirect.
+func (dis *Disasm) resolveJump(f *Func, term *Inst) {
+	v := dis.ValueSets(f)
+	vals, ok := v.Arg(term.Addr, 0).Values(maxVSAValues)
+	if !ok {
+		return
+	}
+	var targets []bin.Address
+	for _, val := range vals {
+		target := bin.Address(val)
+		if !dis.isExec(target) {
+			warn.Printf("invalid target %v of indirect jump at %v resolved by value-set analysis", target, term.Addr)
+			return
+		}
+		targets = bin.InsertAddr(targets, target)
+	}
+	dbg.Printf("resolved %d targets of indirect jump at %v using value-set analysis", len(targets), term.Addr)
+	if dis.Indirect == nil {
+		dis.Indirect = make(map[bin.Address][]bin.Address)
+	}
+	dis.Indirect[term.Addr] = targets
+}
+
+// resolveCalls resolves the targets of indirect calls of the given function
+// using value-set analysis. The target of calls with a single target function
+// is recorded in dis.Indirect.
+func (dis *Disasm) resolveCalls(f *Func) {
+	var v *VSA
+	var blockAddrs bin.Addresses
+	for blockAddr := range f.Blocks {
+		blockAddrs = append(blockAddrs, blockAddr)
+	}
+	sort.Sort(blockAddrs)
+	for _, blockAddr := range blockAddrs {
+		for _, inst := range f.Blocks[blockAddr].Insts {
+			if inst.Op != x86asm.CALL {
+				continue
+			}
+			if _, ok := inst.Args[0].(x86asm.Rel); ok {
+				continue
+			}
+			if _, ok := dis.Indirect[inst.Addr]; ok {
+				// targets observed at runtime.
+				continue
+			}
+			if v == nil {
+				v = dis.ValueSets(f)
+			}
+			if _, ok := inst.Args[0].(x86asm.Mem); ok {
+				if addr, ok := v.MemAddr(inst.Addr, 0).Const(); ok {
+					if _, ok := dis.File.Imports[bin.Address(addr)]; ok {
+						// skip calls to imported functions.
+						continue
+					}
+				}
+			}
+			val, ok := v.Arg(inst.Addr, 0).Const()
+			if !ok || !dis.IsFunc(bin.Address(val)) {
+				continue
+			}
+			dbg.Printf("resolved indirect call at %v to %v using value-set analysis", inst.Addr, bin.Address(val))
+			if dis.Indirect == nil {
+				dis.Indirect = make(map[bin.Address][]bin.Address)
+			}
+			dis.Indirect[inst.Addr] = []bin.Address{bin.Address(val)}
+		}
+	}
+}
+
+// ### [ Helper functions ] ####################################################
+
+// regSize returns the size in bits of the given register.
+func regSize(reg x86asm.Reg) int {
+	switch {
+	case x86asm.AL <= reg && reg <= x86asm.R15B:
+		return 8
+	case x86asm.AX <= reg && reg <= x86asm.R15W:
+		return 16
+	case x86asm.EAX <= reg && reg <= x86asm.R15L:
+		return 32
+	}
+	return 64
+}
+
+// argSize returns the size in bits of the given argument of the instruction.
+func argSize(arg x86asm.Arg, inst *Inst) int {
+	switch arg := arg.(type) {
+	case x86asm.Reg:
+		return regSize(arg)
+	case x86asm.Mem:
+		if inst.MemBytes != 0 {
+			return inst.MemBytes * 8
+		}
+	}
+	if inst.DataSize != 0 {
+		return inst.DataSize
+	}
+	return 64
+}
+
+// readConst reads the value of the given size in bytes at the specified
+// address of a read-only section, in the byte order of the executable. The
+// boolean return value indicates success.
+func (dis *Disasm) readConst(addr bin.Address, size int) (uint64, bool) {
+	order := dis.File.ByteOrder()
+	for _, sect := range dis.File.AddressSpace().Sections(addr) {
+		if sect.Perm&bin.PermW != 0 {
+			// skip writeable section; contents not constant.
+			continue
+		}
+		switch size {
+		case 4:
+			if v, err := sect.ReadUint32(addr, order); err == nil {
+				return uint64(v), true
+			}
+		case 8:
+			if v, err := sect.ReadUint64(addr, order); err == nil {
+				return v, true
+			}
+		default:
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// RegFamily returns the register family of the given general purpose
+// register (e.g. 0 for AL, AH, AX, EAX and RAX), and reports whether the
+// register is 32- or 64-bit wide. The register family is -1 for non-general
+// purpose registers.
+func RegFamily(reg x86asm.Reg) (int, bool) {
+	switch {
+	case x86asm.AL <= reg && reg < x86asm.AH:
+		return int(reg - x86asm.AL), false
+	case x86asm.AH <= reg && reg < x86asm.SPB:
+		return int(reg - x86asm.AH), false
+	case x86asm.SPB <= reg && reg <= x86asm.R15B:
+		return int(reg-x86asm.SPB) + 4, false
+	case x86asm.AX <= reg && reg <= x86asm.R15W:
+		return int(reg - x86asm.AX), false
+	case x86asm.EAX <= reg && reg <= x86asm.R15L:
+		return int(reg - x86asm.EAX), true
+	case x86asm.RAX <= reg && reg <= x86asm.R15:
+		return int(reg - x86asm.RAX), true
+	}
+	return -1, false
+}
@@ -0,0 +1,207 @@
+package x86
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// XrefKind specifies the kind of a cross-reference.
+type XrefKind uint8
+
+// Cross-reference kinds.
+const (
+	// XrefCall specifies a call to a function (e.g. `call sub_401000` or
+	// `call [__imp_ExitProcess]`).
+	XrefCall XrefKind = iota + 1
+	// XrefJump specifies a jump to a basic block (e.g. `jmp loc_401010`), or a
+	// jump table target.
+	XrefJump
+	// XrefRead specifies a memory load (e.g. `mov eax, [0x402000]`).
+	XrefRead
+	// XrefWrite specifies a memory store (e.g. `mov [0x402000], eax`).
+	XrefWrite
+	// XrefOffset specifies that the address is taken, without being
+	// dereferenced (e.g. `push 0x402000` or `lea eax, [0x402000]`).
+	XrefOffset
+)
+
+// String returns the string representation of the cross-reference kind.
+func (kind XrefKind) String() string {
+	m := map[XrefKind]string{
+		XrefCall:   "call",
+		XrefJump:   "jump",
+		XrefRead:   "read",
+		XrefWrite:  "write",
+		XrefOffset: "offset",
+	}
+	if s, ok := m[kind]; ok {
+		return s
+	}
+	return fmt.Sprintf("XrefKind(%d)", uint8(kind))
+}
+
+// An Xref is a cross-reference from an instruction to an address.
+type Xref struct {
+	// Address of the referencing instruction.
+	From bin.Address
+	// Referenced address.
+	To bin.Address
+	// Kind of cross-reference.
+	Kind XrefKind
+}
+
+// Xrefs is a cross-reference index, providing lookup of the references to and
+// from any given address.
+type Xrefs struct {
+	// Map from referenced address to cross-references, sorted by source
+	// address.
+	to map[bin.Address][]*Xref
+	// Map from instruction address to cross-references, sorted by target
+	// address.
+	from map[bin.Address][]*Xref
+}
+
+// NewXrefs returns a new, empty cross-reference index.
+func NewXrefs() *Xrefs {
+	return &Xrefs{
+		to:   make(map[bin.Address][]*Xref),
+		from: make(map[bin.Address][]*Xref),
+	}
+}
+
+// Add adds the given cross-reference to the index. Duplicate cross-references
+// are ignored.
+func (xrefs *Xrefs) Add(xref *Xref) {
+	for _, x := range xrefs.from[xref.From] {
+		if *x == *xref {
+			return
+		}
+	}
+	xrefs.to[xref.To] = append(xrefs.to[xref.To], xref)
+	xrefs.from[xref.From] = append(xrefs.from[xref.From], xref)
+}
+
+// To returns the cross-references to the given address, sorted by source
+// address.
+func (xrefs *Xrefs) To(addr bin.Address) []*Xref {
+	refs := xrefs.to[addr]
+	sort.SliceStable(refs, func(i, j int) bool {
+		return refs[i].From < refs[j].From
+	})
+	return refs
+}
+
+// From returns the cross-references from the instruction at the given address,
+// sorted by target address.
+func (xrefs *Xrefs) From(addr bin.Address) []*Xref {
+	refs := xrefs.from[addr]
+	sort.SliceStable(refs, func(i, j int) bool {
+		return refs[i].To < refs[j].To
+	})
+	return refs
+}
+
+// Targets returns the referenced addresses of the index, sorted in ascending
+// order.
+func (xrefs *Xrefs) Targets() []bin.Address {
+	var addrs []bin.Address
+	for addr := range xrefs.to {
+		addrs = append(addrs, addr)
+	}
+	sort.Sort(bin.Addresses(addrs))
+	return addrs
+}
+
+// Xrefs returns the cross-reference index of the instructions of the given
+// functions.
+//
+// Only references to static addresses are tracked; i.e. relative branch
+// targets, absolute and RIP-relative memory references, immediates within the
+// sections of the binary executable, and the targets of known jump tables.
+func (dis *Disasm) Xrefs(fs []*Func) *Xrefs {
+	xrefs := NewXrefs()
+	for _, f := range fs {
+		for _, block := range f.Blocks {
+			for _, inst := range block.Insts {
+				dis.instXrefs(xrefs, inst)
+			}
+			if !block.Term.IsDummyTerm() {
+				dis.instXrefs(xrefs, block.Term)
+			}
+		}
+	}
+	return xrefs
+}
+
+// instXrefs adds the cross-references of the given instruction to the index.
+func (dis *Disasm) instXrefs(xrefs *Xrefs, inst *Inst) {
+	next := inst.Addr + bin.Address(inst.Len)
+	add := func(to bin.Address, kind XrefKind) {
+		xref := &Xref{From: inst.Addr, To: to, Kind: kind}
+		xrefs.Add(xref)
+	}
+	// Direct far jumps and calls.
+	if target, ok := dis.FarTarget(inst); ok {
+		kind := XrefJump
+		if inst.Op == x86asm.LCALL {
+			kind = XrefCall
+		}
+		add(target, kind)
+		return
+	}
+	for i, arg := range inst.Args {
+		if arg == nil {
+			break
+		}
+		switch arg := arg.(type) {
+		case x86asm.Rel:
+			target := next + bin.Address(arg)
+			switch {
+			case inst.Op == x86asm.CALL:
+				add(target, XrefCall)
+			case inst.isTerm():
+				add(target, XrefJump)
+			}
+		case x86asm.Mem:
+			// Jump table.
+			if inst.Op == x86asm.JMP && arg.Segment == 0 && arg.Base == 0 && arg.Index != 0 {
+				table := bin.Address(arg.Disp)
+				if targets, ok := dis.Tables[table]; ok {
+					add(table, XrefRead)
+					for _, target := range targets {
+						add(target, XrefJump)
+					}
+				}
+				continue
+			}
+			addr, ok := staticAddr(arg, next)
+			if !ok {
+				continue
+			}
+			switch {
+			case inst.Op == x86asm.LEA:
+				add(addr, XrefOffset)
+			case inst.Op == x86asm.CALL:
+				// Indirect call through function pointer (e.g. import address
+				// table).
+				if _, ok := dis.File.Imports[addr]; ok {
+					add(addr, XrefCall)
+				} else {
+					add(addr, XrefRead)
+				}
+			case i == 0 && writesFirstArg[inst.Op]:
+				add(addr, XrefWrite)
+			default:
+				add(addr, XrefRead)
+			}
+		case x86asm.Imm:
+			addr := bin.Address(arg)
+			if addr != 0 && dis.File.AddressSpace().Contains(addr, 0) {
+				add(addr, XrefOffset)
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Package bitcode translates lifted LLVM IR modules to LLVM bitcode (*.bc).
+//
+// As llir/llvm lacks a bitcode writer, the textual LLVM IR assembly of the
+// module is assembled by the llvm-as tool of the LLVM distribution, which is
+// located using the LLVM_AS environment variable if set, and PATH otherwise.
+//
+// Versioned names of llvm-as (e.g. llvm-as-14, as installed by Debian) are
+// located if llvm-as is not present.
+package bitcode
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/llir/llvm/ir"
+	"github.com/pkg/errors"
+)
+
+// Oldest and newest major version of versioned llvm-as names searched for in
+// PATH.
+const (
+	minVersion = 7
+	maxVersion = 20
+)
+
+// Write writes the LLVM bitcode translation of the given LLVM IR module to w.
+func Write(w io.Writer, m *ir.Module) error {
+	llvmAs, err := LookPath()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	stderr := &bytes.Buffer{}
+	cmd := exec.Command(llvmAs, "-o", "-", "-")
+	cmd.Stdin = strings.NewReader(m.String())
+	cmd.Stdout = w
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "unable to assemble LLVM IR module using %q; %s", llvmAs, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// LookPath returns the path of the llvm-as tool; as specified by the LLVM_AS
+// environment variable, or located in PATH.
+func LookPath() (string, error) {
+	if llvmAs := os.Getenv("LLVM_AS"); len(llvmAs) > 0 {
+		return exec.LookPath(llvmAs)
+	}
+	if llvmAs, err := exec.LookPath("llvm-as"); err == nil {
+		return llvmAs, nil
+	}
+	for version := maxVersion; version >= minVersion; version-- {
+		if llvmAs, err := exec.LookPath(fmt.Sprintf("llvm-as-%d", version)); err == nil {
+			return llvmAs, nil
+		}
+	}
+	return "", errors.New("unable to locate llvm-as in PATH; install LLVM or set the LLVM_AS environment variable")
+}
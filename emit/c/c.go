@@ -0,0 +1,70 @@
+// Package c translates lifted LLVM IR modules to readable pseudo-C.
+//
+// Control flow is recovered by a simple structuring pass over the basic blocks
+// of each function, in layout order; conditional branches over a contiguous
+// range of basic blocks are translated to if and if-else statements, and
+// backward branches to do-while loops. Any remaining control flow is translated
+// to goto statements, which is always valid as local variables are declared at
+// the start of each function.
+//
+// Expressions are folded into their use if the value is used once, by a later
+// instruction of the same basic block, without intervening side effects (store
+// and call instructions). Registers and other local variables allocated on the
+// stack (alloca) are translated to C variables if only loaded from and stored
+// to.
+//
+//    %1 = load i32, i32* %eax
+//    %2 = add i32 %1, 4
+//    store i32 %2, i32* %eax
+//
+//    eax = eax + 4;
+package c
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/llir/llvm/ir"
+	"github.com/pkg/errors"
+)
+
+// Write writes the pseudo-C translation of the given LLVM IR module to w.
+func Write(w io.Writer, m *ir.Module) error {
+	buf := &strings.Builder{}
+	buf.WriteString("#include <stdbool.h>\n")
+	buf.WriteString("#include <stdint.h>\n")
+	// Type definitions.
+	for _, t := range m.TypeDefs {
+		if def := typeDef(t); len(def) > 0 {
+			buf.WriteString("\n")
+			buf.WriteString(def)
+		}
+	}
+	// Function declarations.
+	if len(m.Funcs) > 0 {
+		buf.WriteString("\n")
+	}
+	for _, f := range m.Funcs {
+		fmt.Fprintf(buf, "%s;\n", funcHeader(f))
+	}
+	// Global variables.
+	if len(m.Globals) > 0 {
+		buf.WriteString("\n")
+	}
+	for _, g := range m.Globals {
+		buf.WriteString(globalDef(g))
+	}
+	// Function definitions.
+	for _, f := range m.Funcs {
+		if len(f.Blocks) == 0 {
+			continue
+		}
+		buf.WriteString("\n")
+		buf.WriteString(newFuncEmitter(f).emit())
+	}
+	if _, err := io.WriteString(w, buf.String()); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
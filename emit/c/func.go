@@ -0,0 +1,554 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/decomp/exp/lift/irutil"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+)
+
+// A funcEmitter translates LLVM IR function definitions to pseudo-C.
+type funcEmitter struct {
+	// LLVM IR function.
+	f *ir.Function
+	// Variable names of LLVM IR values.
+	names map[value.Value]string
+	// Set of variable names in use.
+	used map[string]bool
+	// Allocas translated to C variables, the address of which is &name.
+	vars map[*ir.InstAlloca]bool
+	// Values folded into their use.
+	folded map[value.Value]bool
+	// Local variable declarations.
+	decls []string
+	// Index of basic blocks in layout order.
+	blockIndices map[*ir.BasicBlock]int
+	// Index of the last basic block of the loop of each loop header, as
+	// identified by backward branches.
+	loopTails map[int]int
+	// Loop headers of the do-while loops currently being emitted.
+	open map[int]bool
+	// Basic blocks targeted by goto statements.
+	targets map[int]bool
+	// Output lines.
+	lines []line
+	// Current indentation level.
+	indent int
+}
+
+// A line is a line of C source code.
+type line struct {
+	// Indentation level.
+	indent int
+	// C source code of the line.
+	s string
+	// Index of basic block labelled by the line; or -1 if not a label.
+	label int
+}
+
+// newFuncEmitter returns a new emitter for the given LLVM IR function
+// definition.
+func newFuncEmitter(f *ir.Function) *funcEmitter {
+	fe := &funcEmitter{
+		f:            f,
+		names:        make(map[value.Value]string),
+		used:         make(map[string]bool),
+		vars:         make(map[*ir.InstAlloca]bool),
+		folded:       make(map[value.Value]bool),
+		blockIndices: make(map[*ir.BasicBlock]int),
+		loopTails:    make(map[int]int),
+		open:         make(map[int]bool),
+		targets:      make(map[int]bool),
+	}
+	for i, param := range f.Params {
+		fe.names[param] = fe.newName(paramName(param, i))
+	}
+	for i, block := range f.Blocks {
+		fe.blockIndices[block] = i
+	}
+	fe.foldExprs()
+	fe.declareLocals()
+	fe.findLoops()
+	return fe
+}
+
+// emit returns the C function definition of the function.
+func (fe *funcEmitter) emit() string {
+	fe.indent = 1
+	fe.emitRange(0, len(fe.f.Blocks), -1)
+	buf := &strings.Builder{}
+	fmt.Fprintf(buf, "%s {\n", funcHeader(fe.f))
+	for _, decl := range fe.decls {
+		fmt.Fprintf(buf, "\t%s;\n", decl)
+	}
+	if len(fe.decls) > 0 {
+		buf.WriteString("\n")
+	}
+	for _, l := range fe.lines {
+		if l.label != -1 {
+			if !fe.targets[l.label] {
+				continue
+			}
+			// Labels are outdented by one level.
+			fmt.Fprintf(buf, "%s%s:\n", strings.Repeat("\t", l.indent-1), l.s)
+			continue
+		}
+		fmt.Fprintf(buf, "%s%s\n", strings.Repeat("\t", l.indent), l.s)
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+// ### [ Analysis ] ############################################################
+
+// foldExprs locates the values to fold into their use.
+func (fe *funcEmitter) foldExprs() {
+	// Number of uses of each value.
+	uses := make(map[value.Value]int)
+	for _, block := range fe.f.Blocks {
+		for _, inst := range block.Insts {
+			for _, v := range irutil.Operands(inst) {
+				uses[*v]++
+			}
+		}
+		for _, v := range irutil.Operands(block.Term) {
+			uses[*v]++
+		}
+	}
+	for _, block := range fe.f.Blocks {
+		for i, inst := range block.Insts {
+			v, ok := inst.(value.Value)
+			if !ok || types.Equal(v.Type(), types.Void) || uses[v] != 1 {
+				continue
+			}
+			switch inst.(type) {
+			case *ir.InstAlloca, *ir.InstPhi:
+				continue
+			}
+			// Locate use in the same basic block.
+			use := -1
+			for j := i + 1; j < len(block.Insts); j++ {
+				if usesValue(block.Insts[j], v) {
+					use = j
+					break
+				}
+			}
+			if use == -1 {
+				if !usesValue(block.Term, v) {
+					// used by another basic block.
+					continue
+				}
+				use = len(block.Insts)
+			}
+			// Check for intervening side effects.
+			fold := true
+			for j := i + 1; j < use; j++ {
+				if hasSideEffects(block.Insts[j]) {
+					fold = false
+					break
+				}
+			}
+			if fold {
+				fe.folded[v] = true
+			}
+		}
+	}
+}
+
+// declareLocals declares the local variables of the function; allocas and
+// values not folded into their use.
+func (fe *funcEmitter) declareLocals() {
+	for _, block := range fe.f.Blocks {
+		for _, inst := range block.Insts {
+			v, ok := inst.(value.Value)
+			if !ok || types.Equal(v.Type(), types.Void) || fe.folded[v] {
+				continue
+			}
+			if alloca, ok := inst.(*ir.InstAlloca); ok && isStaticAlloca(alloca) {
+				name := fe.newName(localName(alloca, "v"))
+				fe.names[alloca] = name
+				fe.vars[alloca] = true
+				typ := alloca.ElemType
+				if alloca.NElems != nil {
+					typ = types.NewArray(uint64(indexValue(alloca.NElems)), typ)
+				}
+				fe.decls = append(fe.decls, decl(typ, name))
+				continue
+			}
+			name := fe.newName(localName(v, "t"))
+			fe.names[v] = name
+			fe.decls = append(fe.decls, decl(v.Type(), name))
+		}
+	}
+}
+
+// findLoops locates the loops of the function, as identified by backward
+// branches.
+func (fe *funcEmitter) findLoops() {
+	for i, block := range fe.f.Blocks {
+		var succs []*ir.BasicBlock
+		switch term := block.Term.(type) {
+		case *ir.TermBr:
+			succs = append(succs, term.Target)
+		case *ir.TermCondBr:
+			succs = append(succs, term.TargetTrue, term.TargetFalse)
+		}
+		for _, succ := range succs {
+			if head := fe.blockIndices[succ]; head <= i {
+				if tail, ok := fe.loopTails[head]; !ok || tail < i {
+					fe.loopTails[head] = i
+				}
+			}
+		}
+	}
+}
+
+// ### [ Structuring ] #########################################################
+
+// emitRange emits the basic blocks in the range [lo, hi) in layout order. Upon
+// falling off the end of the range, execution continues at the basic block
+// with index follow (or -1 if none).
+func (fe *funcEmitter) emitRange(lo, hi, follow int) {
+	for i := lo; i < hi; {
+		// next returns the basic block executed after falling off the end of
+		// block j.
+		next := func(j int) int {
+			if j+1 < hi {
+				return j + 1
+			}
+			return follow
+		}
+		// Do-while loop.
+		if tail, ok := fe.loopTails[i]; ok && tail < hi && !fe.open[i] {
+			fe.emitLoop(i, tail, next(tail))
+			i = tail + 1
+			continue
+		}
+		block := fe.f.Blocks[i]
+		fe.emitBlock(i)
+		switch term := block.Term.(type) {
+		case *ir.TermCondBr:
+			t, f := fe.blockIndices[term.TargetTrue], fe.blockIndices[term.TargetFalse]
+			if !hasPhis(term.TargetTrue) && !hasPhis(term.TargetFalse) {
+				// if-then-else.
+				if j, ok := fe.ifElse(i, t, f, hi); ok {
+					fe.emitIf(fe.value(term.Cond), i+1, f, j)
+					fe.line("} else {")
+					fe.indent++
+					fe.emitRange(f, j, j)
+					fe.indent--
+					fe.line("}")
+					i = j
+					continue
+				}
+				// if-then.
+				if t == i+1 && f > t && f < hi {
+					fe.emitIf(fe.value(term.Cond), t, f, f)
+					fe.line("}")
+					i = f
+					continue
+				}
+				if f == i+1 && t > f && t < hi {
+					fe.emitIf(not(fe.value(term.Cond)), f, t, t)
+					fe.line("}")
+					i = t
+					continue
+				}
+			}
+			fe.emitTerm(block, next(i))
+		default:
+			fe.emitTerm(block, next(i))
+		}
+		i++
+	}
+}
+
+// ifElse reports whether the conditional branch of basic block i to t and f is
+// an if-then-else statement within [i, hi), and if so, returns the index of the
+// basic block following the if-else statement.
+func (fe *funcEmitter) ifElse(i, t, f, hi int) (int, bool) {
+	if t != i+1 || f <= t || f >= hi {
+		return 0, false
+	}
+	br, ok := fe.f.Blocks[f-1].Term.(*ir.TermBr)
+	if !ok {
+		return 0, false
+	}
+	j := fe.blockIndices[br.Target]
+	if j <= f || j >= hi {
+		return 0, false
+	}
+	return j, true
+}
+
+// emitIf emits an if statement with the given condition, and the basic blocks
+// [lo, hi) as body, which continues at follow. The closing brace is emitted by
+// the caller.
+func (fe *funcEmitter) emitIf(cond expr, lo, hi, follow int) {
+	fe.line("if (%s) {", cond.s)
+	fe.indent++
+	fe.emitRange(lo, hi, follow)
+	fe.indent--
+}
+
+// emitLoop emits a do-while loop of the basic blocks [head, tail], which
+// continues at follow when exiting the loop through the tail.
+func (fe *funcEmitter) emitLoop(head, tail, follow int) {
+	fe.open[head] = true
+	defer delete(fe.open, head)
+	fe.lines = append(fe.lines, line{indent: fe.indent, s: blockLabel(head), label: head})
+	fe.line("do {")
+	fe.indent++
+	fe.emitRange(head, tail, tail)
+	// The loop condition is given by the terminator of the tail.
+	block := fe.f.Blocks[tail]
+	fe.emitBlock(tail)
+	switch term := block.Term.(type) {
+	case *ir.TermBr:
+		fe.indent--
+		fe.line("} while (true);")
+		return
+	case *ir.TermCondBr:
+		t, f := fe.blockIndices[term.TargetTrue], fe.blockIndices[term.TargetFalse]
+		if hasPhis(term.TargetTrue) || hasPhis(term.TargetFalse) {
+			break
+		}
+		var cond expr
+		exit := f
+		switch head {
+		case t:
+			cond = fe.value(term.Cond)
+		case f:
+			cond, exit = not(fe.value(term.Cond)), t
+		}
+		fe.indent--
+		fe.line("} while (%s);", cond.s)
+		if exit != follow {
+			fe.gotoBlock(exit)
+		}
+		return
+	}
+	fe.emitTerm(block, -1)
+	fe.indent--
+	fe.line("} while (false);")
+}
+
+// emitBlock emits the label and instructions of the given basic block.
+func (fe *funcEmitter) emitBlock(i int) {
+	// The label of loop headers is emitted by emitLoop.
+	if !fe.open[i] {
+		fe.lines = append(fe.lines, line{indent: fe.indent, s: blockLabel(i), label: i})
+	}
+	for _, inst := range fe.f.Blocks[i].Insts {
+		fe.emitInst(inst)
+	}
+}
+
+// emitTerm emits the given terminator; next specifies the basic block executed
+// after falling off the end of the basic block.
+func (fe *funcEmitter) emitTerm(block *ir.BasicBlock, next int) {
+	switch term := block.Term.(type) {
+	case *ir.TermRet:
+		if term.X != nil {
+			fe.line("return %s;", fe.value(term.X).s)
+		} else {
+			fe.line("return;")
+		}
+	case *ir.TermBr:
+		fe.branch(block, term.Target, next)
+	case *ir.TermCondBr:
+		cond := fe.value(term.Cond)
+		fe.line("if (%s) {", cond.s)
+		fe.indent++
+		fe.branch(block, term.TargetTrue, -1)
+		fe.indent--
+		fe.line("}")
+		fe.branch(block, term.TargetFalse, next)
+	case *ir.TermSwitch:
+		fe.line("switch (%s) {", fe.value(term.X).s)
+		for _, c := range term.Cases {
+			fe.line("case %s:", fe.constExpr(c.X).s)
+			fe.indent++
+			fe.branch(block, c.Target, -1)
+			fe.indent--
+		}
+		fe.line("default:")
+		fe.indent++
+		fe.branch(block, term.TargetDefault, -1)
+		fe.indent--
+		fe.line("}")
+	case *ir.TermUnreachable:
+		fe.line("__builtin_unreachable();")
+	default:
+		panic(fmt.Errorf("support for terminator %T not yet implemented", term))
+	}
+}
+
+// branch emits a branch from the given basic block to target, assigning the
+// incoming values of the phi instructions of the target; the goto statement is
+// omitted if target is the next basic block.
+func (fe *funcEmitter) branch(from, target *ir.BasicBlock, next int) {
+	for _, inst := range target.Insts {
+		phi, ok := inst.(*ir.InstPhi)
+		if !ok {
+			break
+		}
+		for _, inc := range phi.Incs {
+			if inc.Pred == from {
+				fe.line("%s = %s;", fe.names[phi], fe.value(inc.X).s)
+			}
+		}
+	}
+	if t := fe.blockIndices[target]; t != next {
+		fe.gotoBlock(t)
+	}
+}
+
+// gotoBlock emits a goto statement to the given basic block.
+func (fe *funcEmitter) gotoBlock(i int) {
+	fe.targets[i] = true
+	fe.line("goto %s;", blockLabel(i))
+}
+
+// ### [ Instructions ] ########################################################
+
+// emitInst emits the given instruction, unless folded into its use.
+func (fe *funcEmitter) emitInst(inst ir.Instruction) {
+	switch inst := inst.(type) {
+	case *ir.InstStore:
+		fe.line("%s = %s;", fe.lvalue(inst.Dst).s, fe.value(inst.Src).s)
+		return
+	case *ir.InstPhi:
+		// assigned by the branches of predecessor basic blocks.
+		return
+	case *ir.InstAlloca:
+		if fe.vars[inst] {
+			// declared as local variable.
+			return
+		}
+		size := fmt.Sprintf("sizeof(%s)", typeName(inst.ElemType))
+		if inst.NElems != nil {
+			size = fmt.Sprintf("%s * %s", fe.operand(inst.NElems), size)
+		}
+		fe.line("%s = alloca(%s);", fe.names[inst], size)
+		return
+	}
+	v, ok := inst.(value.Value)
+	if !ok {
+		panic(fmt.Errorf("support for instruction %T not yet implemented", inst))
+	}
+	if fe.folded[v] {
+		return
+	}
+	x := fe.instExpr(inst)
+	if types.Equal(v.Type(), types.Void) {
+		fe.line("%s;", x.s)
+		return
+	}
+	fe.line("%s = %s;", fe.names[v], x.s)
+}
+
+// ### [ Helper functions ] ####################################################
+
+// line emits a line of C source code at the current indentation level.
+func (fe *funcEmitter) line(format string, args ...interface{}) {
+	fe.lines = append(fe.lines, line{indent: fe.indent, s: fmt.Sprintf(format, args...), label: -1})
+}
+
+// newName returns a unique variable name based on the given name.
+func (fe *funcEmitter) newName(name string) string {
+	name = sanitize(name)
+	unique := name
+	for i := 2; fe.used[unique]; i++ {
+		unique = fmt.Sprintf("%s_%d", name, i)
+	}
+	fe.used[unique] = true
+	return unique
+}
+
+// blockLabel returns the label of the basic block with the given index.
+func blockLabel(i int) string {
+	return fmt.Sprintf("block_%d", i)
+}
+
+// paramName returns the name of the given function parameter.
+func paramName(param *ir.Param, i int) string {
+	if name := param.Name(); len(name) > 0 && !isNumeric(name) {
+		return sanitize(name)
+	}
+	return fmt.Sprintf("a%d", i)
+}
+
+// localName returns the name of the given local variable; or a name with the
+// given prefix if unnamed.
+func localName(v value.Value, prefix string) string {
+	if named, ok := v.(value.Named); ok {
+		if name := named.Name(); len(name) > 0 && !isNumeric(name) {
+			return name
+		}
+	}
+	return prefix
+}
+
+// isNumeric reports whether the given name is numeric (i.e. an unnamed local
+// variable ID).
+func isNumeric(name string) bool {
+	for _, r := range name {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// not returns the negation of the given expression.
+func not(x expr) expr {
+	return compound("!%s", x.operand())
+}
+
+// hasPhis reports whether the given basic block contains phi instructions.
+func hasPhis(block *ir.BasicBlock) bool {
+	if len(block.Insts) == 0 {
+		return false
+	}
+	_, ok := block.Insts[0].(*ir.InstPhi)
+	return ok
+}
+
+// hasSideEffects reports whether the given instruction has side effects that
+// prevent earlier values from being folded past it.
+func hasSideEffects(inst ir.Instruction) bool {
+	switch inst.(type) {
+	case *ir.InstStore, *ir.InstCall:
+		return true
+	}
+	return false
+}
+
+// isStaticAlloca reports whether the given alloca allocates a constant number of
+// elements.
+func isStaticAlloca(alloca *ir.InstAlloca) bool {
+	if alloca.NElems == nil {
+		return true
+	}
+	switch alloca.NElems.(type) {
+	case constant.Constant:
+		return true
+	}
+	return false
+}
+
+// usesValue reports whether the given instruction or terminator uses v as
+// operand.
+func usesValue(inst interface{}, v value.Value) bool {
+	for _, operand := range irutil.Operands(inst) {
+		if *operand == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/types"
+)
+
+// typeName returns the C type name of the given LLVM IR type, as used in casts.
+func typeName(t types.Type) string {
+	return strings.TrimSpace(decl(t, ""))
+}
+
+// decl returns the C declaration of a variable with the given type and name.
+func decl(t types.Type, name string) string {
+	switch t := t.(type) {
+	case *types.VoidType:
+		return "void " + name
+	case *types.IntType:
+		switch t.BitSize {
+		case 1:
+			return "bool " + name
+		case 8, 16, 32, 64:
+			return fmt.Sprintf("int%d_t %s", t.BitSize, name)
+		case 128:
+			return "__int128 " + name
+		default:
+			return fmt.Sprintf("_BitInt(%d) %s", t.BitSize, name)
+		}
+	case *types.FloatType:
+		switch t.Kind {
+		case types.FloatKindFloat:
+			return "float " + name
+		case types.FloatKindDouble:
+			return "double " + name
+		case types.FloatKindX86FP80:
+			return "long double " + name
+		default:
+			return "__float128 " + name
+		}
+	case *types.PointerType:
+		switch elem := t.ElemType.(type) {
+		case *types.FuncType:
+			return funcDecl(elem, "(*"+name+")")
+		case *types.ArrayType:
+			return decl(elem, "(*"+name+")")
+		default:
+			return decl(elem, "*"+name)
+		}
+	case *types.ArrayType:
+		return decl(t.ElemType, fmt.Sprintf("%s[%d]", name, t.Len))
+	case *types.VectorType:
+		return decl(t.ElemType, fmt.Sprintf("%s[%d]", name, t.Len))
+	case *types.StructType:
+		if len(t.TypeName) > 0 {
+			return fmt.Sprintf("struct %s %s", sanitize(t.TypeName), name)
+		}
+		return fmt.Sprintf("struct { %s} %s", fieldDecls(t), name)
+	case *types.FuncType:
+		return funcDecl(t, name)
+	default:
+		panic(fmt.Errorf("support for type %T not yet implemented", t))
+	}
+}
+
+// funcDecl returns the C declaration of a function with the given signature and
+// name.
+func funcDecl(sig *types.FuncType, name string) string {
+	var params []string
+	for _, param := range sig.Params {
+		params = append(params, typeName(param))
+	}
+	if sig.Variadic {
+		params = append(params, "...")
+	}
+	if len(params) == 0 {
+		params = append(params, "void")
+	}
+	return decl(sig.RetType, fmt.Sprintf("%s(%s)", name, strings.Join(params, ", ")))
+}
+
+// funcHeader returns the C function header of the given function.
+func funcHeader(f *ir.Function) string {
+	var params []string
+	for i, param := range f.Params {
+		params = append(params, decl(param.Type(), paramName(param, i)))
+	}
+	if f.Sig.Variadic {
+		params = append(params, "...")
+	}
+	if len(params) == 0 {
+		params = append(params, "void")
+	}
+	return decl(f.Sig.RetType, fmt.Sprintf("%s(%s)", sanitize(f.Name()), strings.Join(params, ", ")))
+}
+
+// typeDef returns the C type definition of the given LLVM IR type definition,
+// or an empty string if not a struct type.
+func typeDef(t types.Type) string {
+	st, ok := t.(*types.StructType)
+	if !ok || len(st.TypeName) == 0 {
+		return ""
+	}
+	if st.Opaque {
+		return fmt.Sprintf("struct %s;\n", sanitize(st.TypeName))
+	}
+	buf := &strings.Builder{}
+	fmt.Fprintf(buf, "struct %s {\n", sanitize(st.TypeName))
+	for i, field := range st.Fields {
+		fmt.Fprintf(buf, "\t%s;\n", decl(field, fieldName(i)))
+	}
+	buf.WriteString("};\n")
+	return buf.String()
+}
+
+// fieldDecls returns the field declarations of the given literal struct type.
+func fieldDecls(t *types.StructType) string {
+	buf := &strings.Builder{}
+	for i, field := range t.Fields {
+		fmt.Fprintf(buf, "%s; ", decl(field, fieldName(i)))
+	}
+	return buf.String()
+}
+
+// fieldName returns the name of the given struct field.
+func fieldName(i int) string {
+	return fmt.Sprintf("field_%d", i)
+}
+
+// unsignedName returns the unsigned C type name of the given integer type.
+func unsignedName(t types.Type) string {
+	if t, ok := t.(*types.IntType); ok {
+		switch t.BitSize {
+		case 1:
+			return "bool"
+		case 8, 16, 32, 64:
+			return fmt.Sprintf("uint%d_t", t.BitSize)
+		case 128:
+			return "unsigned __int128"
+		default:
+			return fmt.Sprintf("unsigned _BitInt(%d)", t.BitSize)
+		}
+	}
+	return typeName(t)
+}
@@ -0,0 +1,558 @@
+package c
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/enum"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+)
+
+// An expr is a C expression.
+type expr struct {
+	// C source code of the expression.
+	s string
+	// Specifies whether the expression is atomic (e.g. identifier, literal or
+	// postfix expression), and may thus be used as operand without parentheses.
+	atomic bool
+}
+
+// atom returns an atomic expression.
+func atom(format string, args ...interface{}) expr {
+	return expr{s: fmt.Sprintf(format, args...), atomic: true}
+}
+
+// compound returns a non-atomic expression.
+func compound(format string, args ...interface{}) expr {
+	return expr{s: fmt.Sprintf(format, args...)}
+}
+
+// operand returns the C source code of the expression used as operand.
+func (x expr) operand() string {
+	if x.atomic {
+		return x.s
+	}
+	return "(" + x.s + ")"
+}
+
+// ### [ Values ] ##############################################################
+
+// operand returns the C source code of the given value used as operand.
+func (fe *funcEmitter) operand(v value.Value) string {
+	return fe.value(v).operand()
+}
+
+// value returns the C expression of the given value.
+func (fe *funcEmitter) value(v value.Value) expr {
+	if alloca, ok := v.(*ir.InstAlloca); ok && fe.vars[alloca] {
+		return compound("&%s", fe.names[v])
+	}
+	if name, ok := fe.names[v]; ok {
+		return atom("%s", name)
+	}
+	if inst, ok := v.(ir.Instruction); ok && fe.folded[v] {
+		return fe.instExpr(inst)
+	}
+	if c, ok := v.(constant.Constant); ok {
+		return fe.constExpr(c)
+	}
+	panic(fmt.Errorf("unable to locate variable of value %v", v.Ident()))
+}
+
+// lvalue returns the C lvalue designated by the given pointer value (i.e. *v).
+func (fe *funcEmitter) lvalue(v value.Value) expr {
+	if alloca, ok := v.(*ir.InstAlloca); ok && fe.vars[alloca] {
+		return atom("%s", fe.names[v])
+	}
+	switch v := v.(type) {
+	case *ir.Global:
+		return atom("%s", sanitize(v.Name()))
+	case *ir.InstGetElementPtr:
+		if fe.folded[v] && len(v.Indices) > 1 {
+			return fe.gepLvalue(v.Src, v.Indices)
+		}
+	case *constant.ExprGetElementPtr:
+		if len(v.Indices) > 1 {
+			return fe.gepLvalue(v.Src, constIndices(v.Indices))
+		}
+	}
+	return compound("*%s", fe.operand(v))
+}
+
+// gepLvalue returns the C lvalue designated by the getelementptr of the given
+// source pointer and indices; where len(indices) > 1.
+func (fe *funcEmitter) gepLvalue(src value.Value, indices []value.Value) expr {
+	// cur is the lvalue of the current index, unless pending is set, in which
+	// case ptr points to the current element.
+	var cur expr
+	var ptr string
+	pending := false
+	if isZeroIndex(indices[0]) {
+		switch src.(type) {
+		case *ir.Global, *ir.InstAlloca, *ir.InstGetElementPtr, *constant.ExprGetElementPtr:
+			cur = fe.lvalue(src)
+			if !cur.atomic {
+				ptr = fe.operand(src)
+				pending = true
+			}
+		default:
+			ptr = fe.operand(src)
+			pending = true
+		}
+	} else {
+		cur = atom("%s[%s]", fe.operand(src), fe.value(indices[0]).s)
+	}
+	elem := src.Type().(*types.PointerType).ElemType
+	for _, index := range indices[1:] {
+		switch t := elem.(type) {
+		case *types.StructType:
+			field := int(indexValue(index))
+			if pending {
+				cur = atom("%s->%s", ptr, fieldName(field))
+			} else {
+				cur = atom("%s.%s", cur.s, fieldName(field))
+			}
+			elem = t.Fields[field]
+		case *types.ArrayType:
+			if pending {
+				cur = atom("(*%s)[%s]", ptr, fe.value(index).s)
+			} else {
+				cur = atom("%s[%s]", cur.s, fe.value(index).s)
+			}
+			elem = t.ElemType
+		case *types.VectorType:
+			if pending {
+				cur = atom("(*%s)[%s]", ptr, fe.value(index).s)
+			} else {
+				cur = atom("%s[%s]", cur.s, fe.value(index).s)
+			}
+			elem = t.ElemType
+		default:
+			panic(fmt.Errorf("support for getelementptr index into type %T not yet implemented", t))
+		}
+		pending = false
+	}
+	return cur
+}
+
+// gepExpr returns the C expression of the getelementptr of the given source
+// pointer and indices.
+func (fe *funcEmitter) gepExpr(src value.Value, indices []value.Value) expr {
+	if len(indices) == 1 {
+		// pointer arithmetic.
+		if isZeroIndex(indices[0]) {
+			return fe.value(src)
+		}
+		return compound("%s + %s", fe.operand(src), fe.operand(indices[0]))
+	}
+	return compound("&%s", fe.gepLvalue(src, indices).s)
+}
+
+// ### [ Constants ] ###########################################################
+
+// constExpr returns the C expression of the given constant.
+func (fe *funcEmitter) constExpr(c constant.Constant) expr {
+	switch c := c.(type) {
+	case *constant.Int:
+		return atom("%s", formatInt(c))
+	case *constant.Float:
+		return atom("%s", formatFloat(c))
+	case *constant.Null:
+		return atom("NULL")
+	case *constant.ZeroInitializer:
+		if isAggregate(c.Typ) {
+			return atom("{0}")
+		}
+		return atom("0")
+	case *constant.Undef:
+		if isAggregate(c.Typ) {
+			return atom("{0}")
+		}
+		return atom("0")
+	case *constant.CharArray:
+		return atom("%s", quote(c.X))
+	case *constant.Array:
+		var elems []string
+		for _, elem := range c.Elems {
+			elems = append(elems, fe.constExpr(elem).s)
+		}
+		return atom("{%s}", strings.Join(elems, ", "))
+	case *constant.Struct:
+		var fields []string
+		for _, field := range c.Fields {
+			fields = append(fields, fe.constExpr(field).s)
+		}
+		return atom("{%s}", strings.Join(fields, ", "))
+	case *ir.Global:
+		return compound("&%s", sanitize(c.Name()))
+	case *ir.Function:
+		return atom("%s", sanitize(c.Name()))
+	case *constant.ExprBitCast:
+		return compound("(%s)%s", typeName(c.To), fe.operand(c.From))
+	case *constant.ExprIntToPtr:
+		return compound("(%s)%s", typeName(c.To), fe.operand(c.From))
+	case *constant.ExprPtrToInt:
+		return compound("(%s)%s", typeName(c.To), fe.operand(c.From))
+	case *constant.ExprGetElementPtr:
+		return fe.gepExpr(c.Src, constIndices(c.Indices))
+	default:
+		panic(fmt.Errorf("support for constant %T not yet implemented", c))
+	}
+}
+
+// globalDef returns the C definition (or declaration) of the given global
+// variable.
+func globalDef(g *ir.Global) string {
+	name := decl(g.ContentType, sanitize(g.Name()))
+	if g.Immutable {
+		name = "const " + name
+	}
+	if g.Init == nil {
+		return fmt.Sprintf("extern %s;\n", name)
+	}
+	fe := &funcEmitter{}
+	return fmt.Sprintf("%s = %s;\n", name, fe.constExpr(g.Init).s)
+}
+
+// ### [ Instructions ] ########################################################
+
+// instExpr returns the C expression of the given value instruction.
+func (fe *funcEmitter) instExpr(inst ir.Instruction) expr {
+	switch inst := inst.(type) {
+	// Binary instructions.
+	case *ir.InstAdd:
+		return fe.binary(inst.X, inst.Y, "+", false)
+	case *ir.InstFAdd:
+		return fe.binary(inst.X, inst.Y, "+", false)
+	case *ir.InstSub:
+		return fe.binary(inst.X, inst.Y, "-", false)
+	case *ir.InstFSub:
+		return fe.binary(inst.X, inst.Y, "-", false)
+	case *ir.InstMul:
+		return fe.binary(inst.X, inst.Y, "*", false)
+	case *ir.InstFMul:
+		return fe.binary(inst.X, inst.Y, "*", false)
+	case *ir.InstUDiv:
+		return fe.binary(inst.X, inst.Y, "/", true)
+	case *ir.InstSDiv:
+		return fe.binary(inst.X, inst.Y, "/", false)
+	case *ir.InstFDiv:
+		return fe.binary(inst.X, inst.Y, "/", false)
+	case *ir.InstURem:
+		return fe.binary(inst.X, inst.Y, "%", true)
+	case *ir.InstSRem:
+		return fe.binary(inst.X, inst.Y, "%", false)
+	case *ir.InstFRem:
+		return atom("fmod(%s, %s)", fe.value(inst.X).s, fe.value(inst.Y).s)
+	// Bitwise instructions.
+	case *ir.InstShl:
+		return fe.binary(inst.X, inst.Y, "<<", false)
+	case *ir.InstLShr:
+		return compound("(%s)%s >> %s", unsignedName(inst.X.Type()), fe.operand(inst.X), fe.operand(inst.Y))
+	case *ir.InstAShr:
+		return fe.binary(inst.X, inst.Y, ">>", false)
+	case *ir.InstAnd:
+		return fe.binary(inst.X, inst.Y, "&", false)
+	case *ir.InstOr:
+		return fe.binary(inst.X, inst.Y, "|", false)
+	case *ir.InstXor:
+		return fe.binary(inst.X, inst.Y, "^", false)
+	// Vector and aggregate instructions.
+	case *ir.InstExtractValue:
+		x := fe.value(inst.X)
+		s := x.operand()
+		for _, index := range inst.Indices {
+			s = fmt.Sprintf("%s.%s", s, fieldName(int(index)))
+		}
+		return atom("%s", s)
+	// Memory instructions.
+	case *ir.InstLoad:
+		return fe.lvalue(inst.Src)
+	case *ir.InstGetElementPtr:
+		return fe.gepExpr(inst.Src, inst.Indices)
+	// Conversion instructions.
+	case *ir.InstTrunc:
+		return fe.cast(inst.From, inst.To)
+	case *ir.InstZExt:
+		return compound("(%s)(%s)%s", typeName(inst.To), unsignedName(inst.From.Type()), fe.operand(inst.From))
+	case *ir.InstSExt:
+		return fe.cast(inst.From, inst.To)
+	case *ir.InstFPTrunc:
+		return fe.cast(inst.From, inst.To)
+	case *ir.InstFPExt:
+		return fe.cast(inst.From, inst.To)
+	case *ir.InstFPToUI:
+		return compound("(%s)%s", unsignedName(inst.To), fe.operand(inst.From))
+	case *ir.InstFPToSI:
+		return fe.cast(inst.From, inst.To)
+	case *ir.InstUIToFP:
+		return compound("(%s)(%s)%s", typeName(inst.To), unsignedName(inst.From.Type()), fe.operand(inst.From))
+	case *ir.InstSIToFP:
+		return fe.cast(inst.From, inst.To)
+	case *ir.InstPtrToInt:
+		return fe.cast(inst.From, inst.To)
+	case *ir.InstIntToPtr:
+		return fe.cast(inst.From, inst.To)
+	case *ir.InstBitCast:
+		if types.IsPointer(inst.From.Type()) && types.IsPointer(inst.To) {
+			return fe.cast(inst.From, inst.To)
+		}
+		// reinterpret bits.
+		return compound("*(%s *)&%s", typeName(inst.To), fe.operand(inst.From))
+	case *ir.InstAddrSpaceCast:
+		return fe.cast(inst.From, inst.To)
+	// Other instructions.
+	case *ir.InstICmp:
+		return fe.icmp(inst)
+	case *ir.InstFCmp:
+		return fe.fcmp(inst)
+	case *ir.InstSelect:
+		return compound("%s ? %s : %s", fe.operand(inst.Cond), fe.operand(inst.X), fe.operand(inst.Y))
+	case *ir.InstCall:
+		return fe.callExpr(inst)
+	default:
+		panic(fmt.Errorf("support for instruction %T not yet implemented", inst))
+	}
+}
+
+// binary returns the C expression of the binary operation op on x and y. The
+// operands are converted to unsigned integers if unsigned is set.
+func (fe *funcEmitter) binary(x, y value.Value, op string, unsigned bool) expr {
+	if unsigned {
+		return compound("(%s)%s %s (%s)%s", unsignedName(x.Type()), fe.operand(x), op, unsignedName(y.Type()), fe.operand(y))
+	}
+	return compound("%s %s %s", fe.operand(x), op, fe.operand(y))
+}
+
+// cast returns the C expression of from converted to the given type.
+func (fe *funcEmitter) cast(from value.Value, to types.Type) expr {
+	return compound("(%s)%s", typeName(to), fe.operand(from))
+}
+
+// icmp returns the C expression of the given integer comparison.
+func (fe *funcEmitter) icmp(inst *ir.InstICmp) expr {
+	switch inst.Pred {
+	case enum.IPredEQ:
+		return fe.binary(inst.X, inst.Y, "==", false)
+	case enum.IPredNE:
+		return fe.binary(inst.X, inst.Y, "!=", false)
+	case enum.IPredSGE:
+		return fe.binary(inst.X, inst.Y, ">=", false)
+	case enum.IPredSGT:
+		return fe.binary(inst.X, inst.Y, ">", false)
+	case enum.IPredSLE:
+		return fe.binary(inst.X, inst.Y, "<=", false)
+	case enum.IPredSLT:
+		return fe.binary(inst.X, inst.Y, "<", false)
+	case enum.IPredUGE:
+		return fe.binary(inst.X, inst.Y, ">=", types.IsInt(inst.X.Type()))
+	case enum.IPredUGT:
+		return fe.binary(inst.X, inst.Y, ">", types.IsInt(inst.X.Type()))
+	case enum.IPredULE:
+		return fe.binary(inst.X, inst.Y, "<=", types.IsInt(inst.X.Type()))
+	case enum.IPredULT:
+		return fe.binary(inst.X, inst.Y, "<", types.IsInt(inst.X.Type()))
+	default:
+		panic(fmt.Errorf("support for integer comparison predicate %v not yet implemented", inst.Pred))
+	}
+}
+
+// fcmp returns the C expression of the given floating-point comparison.
+//
+// Note, unordered comparisons are expressed as the negation of the inverse
+// ordered comparison.
+func (fe *funcEmitter) fcmp(inst *ir.InstFCmp) expr {
+	x, y := fe.operand(inst.X), fe.operand(inst.Y)
+	switch inst.Pred {
+	case enum.FPredFalse:
+		return atom("false")
+	case enum.FPredTrue:
+		return atom("true")
+	case enum.FPredOEQ:
+		return compound("%s == %s", x, y)
+	case enum.FPredOGT:
+		return compound("%s > %s", x, y)
+	case enum.FPredOGE:
+		return compound("%s >= %s", x, y)
+	case enum.FPredOLT:
+		return compound("%s < %s", x, y)
+	case enum.FPredOLE:
+		return compound("%s <= %s", x, y)
+	case enum.FPredONE:
+		return compound("%s < %s || %s > %s", x, y, x, y)
+	case enum.FPredORD:
+		return compound("!isnan(%s) && !isnan(%s)", x, y)
+	case enum.FPredUEQ:
+		return compound("!(%s < %s || %s > %s)", x, y, x, y)
+	case enum.FPredUGT:
+		return compound("!(%s <= %s)", x, y)
+	case enum.FPredUGE:
+		return compound("!(%s < %s)", x, y)
+	case enum.FPredULT:
+		return compound("!(%s >= %s)", x, y)
+	case enum.FPredULE:
+		return compound("!(%s > %s)", x, y)
+	case enum.FPredUNE:
+		return compound("%s != %s", x, y)
+	case enum.FPredUNO:
+		return compound("isnan(%s) || isnan(%s)", x, y)
+	default:
+		panic(fmt.Errorf("support for floating-point comparison predicate %v not yet implemented", inst.Pred))
+	}
+}
+
+// callExpr returns the C expression of the given call instruction.
+func (fe *funcEmitter) callExpr(inst *ir.InstCall) expr {
+	var args []string
+	for _, arg := range inst.Args {
+		args = append(args, fe.value(arg).s)
+	}
+	var callee string
+	if f, ok := inst.Callee.(*ir.Function); ok {
+		callee = sanitize(f.Name())
+	} else {
+		callee = fe.operand(inst.Callee)
+	}
+	return atom("%s(%s)", callee, strings.Join(args, ", "))
+}
+
+// ### [ Helper functions ] ####################################################
+
+// constIndices returns the given constant indices as values.
+func constIndices(indices []*constant.Index) []value.Value {
+	var vs []value.Value
+	for _, index := range indices {
+		vs = append(vs, index.Index)
+	}
+	return vs
+}
+
+// isZeroIndex reports whether the given getelementptr index is constant zero.
+func isZeroIndex(index value.Value) bool {
+	switch index := index.(type) {
+	case *constant.Int:
+		return index.X.Sign() == 0
+	case *constant.ZeroInitializer:
+		return true
+	}
+	return false
+}
+
+// indexValue returns the value of the given constant getelementptr struct
+// index.
+func indexValue(index value.Value) int64 {
+	switch index := index.(type) {
+	case *constant.Int:
+		return index.X.Int64()
+	case *constant.ZeroInitializer:
+		return 0
+	}
+	panic(fmt.Errorf("invalid non-constant struct index %v", index.Ident()))
+}
+
+// isAggregate reports whether the given type is an aggregate type.
+func isAggregate(t types.Type) bool {
+	switch t.(type) {
+	case *types.ArrayType, *types.StructType, *types.VectorType:
+		return true
+	}
+	return false
+}
+
+// formatInt returns the C literal of the given integer constant; large values
+// are formatted in hexadecimal, as they are likely addresses or bit masks.
+func formatInt(c *constant.Int) string {
+	if c.Typ.BitSize == 1 {
+		if c.X.Sign() == 0 {
+			return "false"
+		}
+		return "true"
+	}
+	if c.X.CmpAbs(big.NewInt(0x10000)) >= 0 && c.X.Sign() > 0 {
+		return fmt.Sprintf("0x%X", c.X)
+	}
+	return c.X.String()
+}
+
+// formatFloat returns the C literal of the given floating-point constant.
+func formatFloat(c *constant.Float) string {
+	if c.NaN {
+		return "NAN"
+	}
+	if c.X.IsInf() {
+		if c.X.Sign() < 0 {
+			return "-INFINITY"
+		}
+		return "INFINITY"
+	}
+	x, _ := c.X.Float64()
+	s := strconv.FormatFloat(x, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eE") {
+		s += ".0"
+	}
+	return s
+}
+
+// quote returns the C string literal of the given character array.
+func quote(data []byte) string {
+	s := &strings.Builder{}
+	s.WriteByte('"')
+	// Trailing NULL byte is implicit.
+	if n := len(data); n > 0 && data[n-1] == 0 {
+		data = data[:n-1]
+	}
+	for _, b := range data {
+		switch {
+		case b == '"' || b == '\\':
+			s.WriteByte('\\')
+			s.WriteByte(b)
+		case b == '\n':
+			s.WriteString(`\n`)
+		case b == '\t':
+			s.WriteString(`\t`)
+		case b >= 0x20 && b < 0x7F:
+			s.WriteByte(b)
+		default:
+			// three-digit octal escapes are not continued by following digits.
+			fmt.Fprintf(s, "\\%03o", b)
+		}
+	}
+	s.WriteByte('"')
+	return s.String()
+}
+
+// sanitize returns a valid C identifier of the given name, replacing invalid
+// characters with underscores.
+func sanitize(name string) string {
+	s := strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', r == '_':
+			return r
+		}
+		return '_'
+	}, name)
+	if len(s) == 0 || ('0' <= s[0] && s[0] <= '9') {
+		s = "_" + s
+	}
+	if keywords[s] {
+		s += "_"
+	}
+	return s
+}
+
+// keywords is the set of C keywords.
+var keywords = map[string]bool{
+	"auto": true, "break": true, "case": true, "char": true, "const": true,
+	"continue": true, "default": true, "do": true, "double": true, "else": true,
+	"enum": true, "extern": true, "float": true, "for": true, "goto": true,
+	"if": true, "inline": true, "int": true, "long": true, "register": true,
+	"restrict": true, "return": true, "short": true, "signed": true,
+	"sizeof": true, "static": true, "struct": true, "switch": true,
+	"typedef": true, "union": true, "unsigned": true, "void": true,
+	"volatile": true, "while": true, "bool": true, "true": true, "false": true,
+}
@@ -0,0 +1,406 @@
+package golang
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+
+	"github.com/decomp/exp/lift/irutil"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+)
+
+// A funcEmitter translates LLVM IR function definitions to Go.
+type funcEmitter struct {
+	// Generator of the Go source file.
+	gen *generator
+	// LLVM IR function.
+	f *ir.Function
+	// Variable names of LLVM IR values.
+	names map[value.Value]string
+	// Set of variable names in use.
+	used map[string]bool
+	// Allocas translated to Go variables, the address of which is &name.
+	vars map[*ir.InstAlloca]bool
+	// Values folded into their use.
+	folded map[value.Value]bool
+	// Index of basic blocks in layout order.
+	blockIndices map[*ir.BasicBlock]int
+	// Basic blocks targeted by goto statements.
+	targets map[int]bool
+}
+
+// funcDecl returns the Go function declaration of the given LLVM IR function;
+// function declarations (e.g. imports) have no body.
+func (gen *generator) funcDecl(f *ir.Function) *ast.FuncDecl {
+	fe := &funcEmitter{
+		gen:          gen,
+		f:            f,
+		names:        make(map[value.Value]string),
+		used:         make(map[string]bool),
+		vars:         make(map[*ir.InstAlloca]bool),
+		folded:       make(map[value.Value]bool),
+		blockIndices: make(map[*ir.BasicBlock]int),
+		targets:      make(map[int]bool),
+	}
+	var paramNames []string
+	for i, param := range f.Params {
+		name := fe.newName(paramName(param, i))
+		fe.names[param] = name
+		paramNames = append(paramNames, name)
+	}
+	decl := &ast.FuncDecl{
+		Name: ast.NewIdent(sanitize(f.Name())),
+		Type: funcType(f.Sig, paramNames),
+	}
+	if len(f.Blocks) == 0 {
+		return decl
+	}
+	for i, block := range f.Blocks {
+		fe.blockIndices[block] = i
+	}
+	fe.foldExprs()
+	decl.Body = &ast.BlockStmt{List: fe.declareLocals()}
+	decl.Body.List = append(decl.Body.List, fe.emitBlocks()...)
+	return decl
+}
+
+// ### [ Analysis ] ############################################################
+
+// foldExprs locates the values to fold into their use.
+func (fe *funcEmitter) foldExprs() {
+	// Number of uses of each value.
+	uses := make(map[value.Value]int)
+	for _, block := range fe.f.Blocks {
+		for _, inst := range block.Insts {
+			for _, v := range irutil.Operands(inst) {
+				uses[*v]++
+			}
+		}
+		for _, v := range irutil.Operands(block.Term) {
+			uses[*v]++
+		}
+	}
+	for _, block := range fe.f.Blocks {
+		for i, inst := range block.Insts {
+			v, ok := inst.(value.Value)
+			if !ok || types.Equal(v.Type(), types.Void) || uses[v] != 1 {
+				continue
+			}
+			switch inst.(type) {
+			case *ir.InstAlloca, *ir.InstPhi:
+				continue
+			}
+			// Locate use in the same basic block.
+			use := -1
+			for j := i + 1; j < len(block.Insts); j++ {
+				if usesValue(block.Insts[j], v) {
+					use = j
+					break
+				}
+			}
+			if use == -1 {
+				if !usesValue(block.Term, v) {
+					// used by another basic block.
+					continue
+				}
+				use = len(block.Insts)
+			}
+			// Check for intervening side effects.
+			fold := true
+			for j := i + 1; j < use; j++ {
+				if hasSideEffects(block.Insts[j]) {
+					fold = false
+					break
+				}
+			}
+			if fold {
+				fe.folded[v] = true
+			}
+		}
+	}
+}
+
+// declareLocals returns the declarations of the local variables of the
+// function; allocas and values not folded into their use.
+func (fe *funcEmitter) declareLocals() []ast.Stmt {
+	var stmts []ast.Stmt
+	declare := func(name string, typ types.Type) {
+		spec := &ast.ValueSpec{
+			Names: []*ast.Ident{ast.NewIdent(name)},
+			Type:  goType(typ),
+		}
+		stmts = append(stmts, &ast.DeclStmt{Decl: &ast.GenDecl{Tok: token.VAR, Specs: []ast.Spec{spec}}})
+	}
+	for _, block := range fe.f.Blocks {
+		for _, inst := range block.Insts {
+			v, ok := inst.(value.Value)
+			if !ok || types.Equal(v.Type(), types.Void) || fe.folded[v] {
+				continue
+			}
+			if alloca, ok := inst.(*ir.InstAlloca); ok && isStaticAlloca(alloca) {
+				name := fe.newName(localName(alloca, "v"))
+				fe.names[alloca] = name
+				fe.vars[alloca] = true
+				typ := alloca.ElemType
+				if alloca.NElems != nil {
+					typ = types.NewArray(uint64(indexValue(alloca.NElems)), typ)
+				}
+				declare(name, typ)
+				continue
+			}
+			name := fe.newName(localName(v, "t"))
+			fe.names[v] = name
+			declare(name, v.Type())
+		}
+	}
+	return stmts
+}
+
+// ### [ Basic blocks ] ########################################################
+
+// emitBlocks returns the statements of the basic blocks of the function, in
+// layout order.
+func (fe *funcEmitter) emitBlocks() []ast.Stmt {
+	// Index of the first statement of each basic block.
+	starts := make([]int, len(fe.f.Blocks))
+	var stmts []ast.Stmt
+	for i, block := range fe.f.Blocks {
+		starts[i] = len(stmts)
+		for _, inst := range block.Insts {
+			stmts = append(stmts, fe.emitInst(inst)...)
+		}
+		next := -1
+		if i+1 < len(fe.f.Blocks) {
+			next = i + 1
+		}
+		stmts = append(stmts, fe.emitTerm(block, next)...)
+	}
+	// Label basic blocks targeted by goto statements; going backwards, as
+	// labelled statements are inserted.
+	for i := len(fe.f.Blocks) - 1; i >= 0; i-- {
+		if !fe.targets[i] {
+			continue
+		}
+		// Note, the last basic block always ends with a statement, as there is
+		// no next basic block to fall through to.
+		start := starts[i]
+		stmts[start] = &ast.LabeledStmt{Label: ast.NewIdent(blockLabel(i)), Stmt: stmts[start]}
+	}
+	return stmts
+}
+
+// emitTerm returns the statements of the given terminator; next specifies the
+// basic block executed after falling off the end of the basic block.
+func (fe *funcEmitter) emitTerm(block *ir.BasicBlock, next int) []ast.Stmt {
+	switch term := block.Term.(type) {
+	case *ir.TermRet:
+		stmt := &ast.ReturnStmt{}
+		if term.X != nil {
+			stmt.Results = []ast.Expr{fe.value(term.X)}
+		}
+		return []ast.Stmt{stmt}
+	case *ir.TermBr:
+		return fe.branch(block, term.Target, next)
+	case *ir.TermCondBr:
+		cond := fe.value(term.Cond)
+		t, f := fe.blockIndices[term.TargetTrue], fe.blockIndices[term.TargetFalse]
+		if t == next && !hasPhis(term.TargetTrue) {
+			// if !cond { goto F }
+			stmt := &ast.IfStmt{
+				Cond: not(cond),
+				Body: &ast.BlockStmt{List: fe.branch(block, term.TargetFalse, -1)},
+			}
+			return []ast.Stmt{stmt}
+		}
+		stmt := &ast.IfStmt{
+			Cond: cond,
+			Body: &ast.BlockStmt{List: fe.branch(block, term.TargetTrue, -1)},
+		}
+		stmts := []ast.Stmt{stmt}
+		if f != next || hasPhis(term.TargetFalse) {
+			stmts = append(stmts, fe.branch(block, term.TargetFalse, next)...)
+		}
+		return stmts
+	case *ir.TermSwitch:
+		body := &ast.BlockStmt{}
+		for _, c := range term.Cases {
+			clause := &ast.CaseClause{
+				List: []ast.Expr{fe.constExpr(c.X)},
+				Body: fe.branch(block, c.Target, -1),
+			}
+			body.List = append(body.List, clause)
+		}
+		clause := &ast.CaseClause{Body: fe.branch(block, term.TargetDefault, -1)}
+		body.List = append(body.List, clause)
+		stmt := &ast.SwitchStmt{Tag: fe.value(term.X), Body: body}
+		return []ast.Stmt{stmt}
+	case *ir.TermUnreachable:
+		stmt := &ast.ExprStmt{X: &ast.CallExpr{
+			Fun:  ast.NewIdent("panic"),
+			Args: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: `"unreachable"`}},
+		}}
+		return []ast.Stmt{stmt}
+	default:
+		panic(fmt.Errorf("support for terminator %T not yet implemented", term))
+	}
+}
+
+// branch returns the statements of a branch from the given basic block to
+// target, assigning the incoming values of the phi instructions of the target;
+// the goto statement is omitted if target is the next basic block.
+func (fe *funcEmitter) branch(from, target *ir.BasicBlock, next int) []ast.Stmt {
+	var stmts []ast.Stmt
+	for _, inst := range target.Insts {
+		phi, ok := inst.(*ir.InstPhi)
+		if !ok {
+			break
+		}
+		for _, inc := range phi.Incs {
+			if inc.Pred == from {
+				stmts = append(stmts, assign(ast.NewIdent(fe.names[phi]), fe.value(inc.X)))
+			}
+		}
+	}
+	if t := fe.blockIndices[target]; t != next {
+		fe.targets[t] = true
+		stmts = append(stmts, &ast.BranchStmt{Tok: token.GOTO, Label: ast.NewIdent(blockLabel(t))})
+	}
+	return stmts
+}
+
+// ### [ Instructions ] ########################################################
+
+// emitInst returns the statements of the given instruction; or nil if folded
+// into its use.
+func (fe *funcEmitter) emitInst(inst ir.Instruction) []ast.Stmt {
+	switch inst := inst.(type) {
+	case *ir.InstStore:
+		return []ast.Stmt{assign(fe.lvalue(inst.Dst), fe.value(inst.Src))}
+	case *ir.InstPhi:
+		// assigned by the branches of predecessor basic blocks.
+		return nil
+	case *ir.InstAlloca:
+		if fe.vars[inst] {
+			// declared as local variable.
+			return nil
+		}
+		// Dynamically sized allocas are allocated on the heap.
+		//
+		//    &make([]T, n)[0]
+		n := convert(ast.NewIdent("int"), fe.value(inst.NElems))
+		slice := &ast.CallExpr{
+			Fun:  ast.NewIdent("make"),
+			Args: []ast.Expr{&ast.ArrayType{Elt: goType(inst.ElemType)}, n},
+		}
+		addr := &ast.UnaryExpr{Op: token.AND, X: &ast.IndexExpr{X: slice, Index: intLit(0)}}
+		return []ast.Stmt{assign(ast.NewIdent(fe.names[inst]), addr)}
+	}
+	v, ok := inst.(value.Value)
+	if !ok {
+		panic(fmt.Errorf("support for instruction %T not yet implemented", inst))
+	}
+	if fe.folded[v] {
+		return nil
+	}
+	x := fe.instExpr(inst)
+	if types.Equal(v.Type(), types.Void) {
+		return []ast.Stmt{&ast.ExprStmt{X: x}}
+	}
+	return []ast.Stmt{assign(ast.NewIdent(fe.names[v]), x)}
+}
+
+// ### [ Helper functions ] ####################################################
+
+// newName returns a unique variable name based on the given name.
+func (fe *funcEmitter) newName(name string) string {
+	name = sanitize(name)
+	unique := name
+	for i := 2; fe.used[unique]; i++ {
+		unique = fmt.Sprintf("%s_%d", name, i)
+	}
+	fe.used[unique] = true
+	return unique
+}
+
+// assign returns the assignment statement lhs = rhs.
+func assign(lhs, rhs ast.Expr) ast.Stmt {
+	return &ast.AssignStmt{Lhs: []ast.Expr{lhs}, Tok: token.ASSIGN, Rhs: []ast.Expr{rhs}}
+}
+
+// blockLabel returns the label of the basic block with the given index.
+func blockLabel(i int) string {
+	return fmt.Sprintf("block_%d", i)
+}
+
+// paramName returns the name of the given function parameter.
+func paramName(param *ir.Param, i int) string {
+	if name := param.Name(); len(name) > 0 && !isNumeric(name) {
+		return name
+	}
+	return fmt.Sprintf("a%d", i)
+}
+
+// localName returns the name of the given local variable; or a name with the
+// given prefix if unnamed.
+func localName(v value.Value, prefix string) string {
+	if named, ok := v.(value.Named); ok {
+		if name := named.Name(); len(name) > 0 && !isNumeric(name) {
+			return name
+		}
+	}
+	return prefix
+}
+
+// isNumeric reports whether the given name is numeric (i.e. an unnamed local
+// variable ID).
+func isNumeric(name string) bool {
+	for _, r := range name {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// hasPhis reports whether the given basic block contains phi instructions.
+func hasPhis(block *ir.BasicBlock) bool {
+	if len(block.Insts) == 0 {
+		return false
+	}
+	_, ok := block.Insts[0].(*ir.InstPhi)
+	return ok
+}
+
+// hasSideEffects reports whether the given instruction has side effects that
+// prevent earlier values from being folded past it.
+func hasSideEffects(inst ir.Instruction) bool {
+	switch inst.(type) {
+	case *ir.InstStore, *ir.InstCall:
+		return true
+	}
+	return false
+}
+
+// isStaticAlloca reports whether the given alloca allocates a constant number of
+// elements.
+func isStaticAlloca(alloca *ir.InstAlloca) bool {
+	if alloca.NElems == nil {
+		return true
+	}
+	_, ok := alloca.NElems.(constant.Constant)
+	return ok
+}
+
+// usesValue reports whether the given instruction or terminator uses v as
+// operand.
+func usesValue(inst interface{}, v value.Value) bool {
+	for _, operand := range irutil.Operands(inst) {
+		if *operand == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+// Package golang translates lifted LLVM IR modules to Go source code.
+//
+// The basic blocks of each function are translated to labelled statements in
+// layout order, connected by goto statements; branches to the next basic block
+// fall through. Local variables are declared at the start of each function, so
+// that goto statements never jump over variable declarations.
+//
+// Expressions are folded into their use if the value is used once, by a later
+// instruction of the same basic block, without intervening side effects (store
+// and call instructions). Registers and other local variables allocated on the
+// stack (alloca) are translated to Go variables.
+//
+//    %1 = load i32, i32* %eax
+//    %2 = add i32 %1, 4
+//    store i32 %2, i32* %eax
+//
+//    eax = eax + 4
+//
+// Pointer arithmetic and conversions between pointer types are translated
+// using package unsafe. x86_fp80 values are represented as float64, and thus
+// lose precision; 128-bit integers (e.g. XMM registers) are represented by the
+// int128 placeholder type.
+package golang
+
+import (
+	"go/ast"
+	"go/format"
+	"go/token"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/llir/llvm/ir"
+	"github.com/pkg/errors"
+)
+
+// Write writes the Go translation of the given LLVM IR module to w.
+func Write(w io.Writer, m *ir.Module) error {
+	if err := format.Node(w, token.NewFileSet(), NewFile(m)); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// NewFile returns the Go source file of the given LLVM IR module.
+func NewFile(m *ir.Module) *ast.File {
+	gen := newGenerator()
+	file := &ast.File{
+		Name: ast.NewIdent("main"),
+	}
+	// Type definitions.
+	for _, t := range m.TypeDefs {
+		if decl := gen.typeDecl(t); decl != nil {
+			file.Decls = append(file.Decls, decl)
+		}
+	}
+	// Global variables.
+	for _, g := range m.Globals {
+		file.Decls = append(file.Decls, gen.globalDecl(g))
+	}
+	// Functions.
+	for _, f := range m.Funcs {
+		file.Decls = append(file.Decls, gen.funcDecl(f))
+	}
+	// Helper functions.
+	if gen.useB2I {
+		file.Decls = append(file.Decls, b2iDecl())
+	}
+	// Imports.
+	if len(gen.imports) > 0 {
+		var paths []string
+		for path := range gen.imports {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		decl := &ast.GenDecl{Tok: token.IMPORT}
+		if len(paths) > 1 {
+			decl.Lparen = 1
+		}
+		for _, path := range paths {
+			spec := &ast.ImportSpec{
+				Path: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(path)},
+			}
+			decl.Specs = append(decl.Specs, spec)
+		}
+		file.Decls = append([]ast.Decl{decl}, file.Decls...)
+	}
+	return file
+}
+
+// A generator keeps track of the packages and helper functions used by the Go
+// translation of an LLVM IR module.
+type generator struct {
+	// Import paths of packages used.
+	imports map[string]bool
+	// Specifies whether the b2i helper function is used.
+	useB2I bool
+}
+
+// newGenerator returns a new generator.
+func newGenerator() *generator {
+	return &generator{
+		imports: make(map[string]bool),
+	}
+}
+
+// pkg returns the selector expression of the given member of the package with
+// the given import path.
+func (gen *generator) pkg(path, member string) ast.Expr {
+	gen.imports[path] = true
+	return &ast.SelectorExpr{X: ast.NewIdent(path), Sel: ast.NewIdent(member)}
+}
+
+// b2i returns a call to the b2i helper function, which converts the given
+// boolean expression to an integer (0 or 1).
+func (gen *generator) b2i(x ast.Expr) ast.Expr {
+	gen.useB2I = true
+	return &ast.CallExpr{Fun: ast.NewIdent("b2i"), Args: []ast.Expr{x}}
+}
+
+// b2iDecl returns the declaration of the b2i helper function.
+//
+//    func b2i(x bool) uint8 {
+//       if x {
+//          return 1
+//       }
+//       return 0
+//    }
+func b2iDecl() *ast.FuncDecl {
+	x := ast.NewIdent("x")
+	return &ast.FuncDecl{
+		Name: ast.NewIdent("b2i"),
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{List: []*ast.Field{
+				{Names: []*ast.Ident{x}, Type: ast.NewIdent("bool")},
+			}},
+			Results: &ast.FieldList{List: []*ast.Field{
+				{Type: ast.NewIdent("uint8")},
+			}},
+		},
+		Body: &ast.BlockStmt{List: []ast.Stmt{
+			&ast.IfStmt{
+				Cond: x,
+				Body: &ast.BlockStmt{List: []ast.Stmt{
+					&ast.ReturnStmt{Results: []ast.Expr{intLit(1)}},
+				}},
+			},
+			&ast.ReturnStmt{Results: []ast.Expr{intLit(0)}},
+		}},
+	}
+}
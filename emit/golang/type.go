@@ -0,0 +1,154 @@
+package golang
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+
+	"github.com/llir/llvm/ir/types"
+)
+
+// goType returns the Go type of the given LLVM IR type.
+func goType(t types.Type) ast.Expr {
+	switch t := t.(type) {
+	case *types.IntType:
+		switch {
+		case t.BitSize == 1:
+			return ast.NewIdent("bool")
+		case t.BitSize <= 8:
+			return ast.NewIdent("int8")
+		case t.BitSize <= 16:
+			return ast.NewIdent("int16")
+		case t.BitSize <= 32:
+			return ast.NewIdent("int32")
+		case t.BitSize <= 64:
+			return ast.NewIdent("int64")
+		case t.BitSize <= 128:
+			return ast.NewIdent("int128")
+		}
+	case *types.FloatType:
+		switch t.Kind {
+		case types.FloatKindHalf, types.FloatKindFloat:
+			return ast.NewIdent("float32")
+		default:
+			return ast.NewIdent("float64")
+		}
+	case *types.PointerType:
+		// Function pointers are represented as Go function values.
+		if sig, ok := t.ElemType.(*types.FuncType); ok {
+			return funcType(sig, nil)
+		}
+		return &ast.StarExpr{X: goType(t.ElemType)}
+	case *types.ArrayType:
+		return &ast.ArrayType{Len: intLit(int64(t.Len)), Elt: goType(t.ElemType)}
+	case *types.VectorType:
+		return &ast.ArrayType{Len: intLit(int64(t.Len)), Elt: goType(t.ElemType)}
+	case *types.StructType:
+		if len(t.TypeName) > 0 {
+			return ast.NewIdent(sanitize(t.TypeName))
+		}
+		return structType(t)
+	case *types.FuncType:
+		return funcType(t, nil)
+	}
+	panic(fmt.Errorf("support for type %v not yet implemented", t))
+}
+
+// unsignedType returns the unsigned Go type of the given integer or pointer
+// type.
+func unsignedType(t types.Type) ast.Expr {
+	switch t := t.(type) {
+	case *types.IntType:
+		switch {
+		case t.BitSize == 1:
+			return ast.NewIdent("bool")
+		case t.BitSize <= 8:
+			return ast.NewIdent("uint8")
+		case t.BitSize <= 16:
+			return ast.NewIdent("uint16")
+		case t.BitSize <= 32:
+			return ast.NewIdent("uint32")
+		case t.BitSize <= 64:
+			return ast.NewIdent("uint64")
+		case t.BitSize <= 128:
+			return ast.NewIdent("uint128")
+		}
+	case *types.PointerType:
+		return ast.NewIdent("uintptr")
+	}
+	return goType(t)
+}
+
+// funcType returns the Go function type of the given LLVM IR function
+// signature, with optional parameter names.
+func funcType(sig *types.FuncType, names []string) *ast.FuncType {
+	params := &ast.FieldList{}
+	for i, param := range sig.Params {
+		field := &ast.Field{Type: goType(param)}
+		if i < len(names) {
+			field.Names = []*ast.Ident{ast.NewIdent(names[i])}
+		}
+		params.List = append(params.List, field)
+	}
+	if sig.Variadic {
+		field := &ast.Field{Type: &ast.Ellipsis{Elt: &ast.InterfaceType{Methods: &ast.FieldList{}}}}
+		if len(names) > 0 {
+			field.Names = []*ast.Ident{ast.NewIdent("args")}
+		}
+		params.List = append(params.List, field)
+	}
+	typ := &ast.FuncType{Params: params}
+	if !types.Equal(sig.RetType, types.Void) {
+		typ.Results = &ast.FieldList{List: []*ast.Field{{Type: goType(sig.RetType)}}}
+	}
+	return typ
+}
+
+// structType returns the Go struct type of the given LLVM IR struct type.
+func structType(t *types.StructType) *ast.StructType {
+	fields := &ast.FieldList{}
+	for i, field := range t.Fields {
+		f := &ast.Field{
+			Names: []*ast.Ident{ast.NewIdent(fieldName(i))},
+			Type:  goType(field),
+		}
+		fields.List = append(fields.List, f)
+	}
+	return &ast.StructType{Fields: fields}
+}
+
+// typeDecl returns the Go type declaration of the given LLVM IR type
+// definition, or nil if not a struct type.
+func (gen *generator) typeDecl(t types.Type) ast.Decl {
+	st, ok := t.(*types.StructType)
+	if !ok || len(st.TypeName) == 0 {
+		return nil
+	}
+	spec := &ast.TypeSpec{
+		Name: ast.NewIdent(sanitize(st.TypeName)),
+		Type: structType(st),
+	}
+	return &ast.GenDecl{Tok: token.TYPE, Specs: []ast.Spec{spec}}
+}
+
+// fieldName returns the name of the given struct field.
+func fieldName(i int) string {
+	return fmt.Sprintf("field%d", i)
+}
+
+// isAggregate reports whether the given type is an aggregate type.
+func isAggregate(t types.Type) bool {
+	switch t.(type) {
+	case *types.ArrayType, *types.StructType, *types.VectorType:
+		return true
+	}
+	return false
+}
+
+// isBool reports whether the given type is the boolean type i1.
+func isBool(t types.Type) bool {
+	if t, ok := t.(*types.IntType); ok {
+		return t.BitSize == 1
+	}
+	return false
+}
@@ -0,0 +1,687 @@
+package golang
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/enum"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+)
+
+// ### [ Values ] ##############################################################
+
+// value returns the Go expression of the given value.
+func (fe *funcEmitter) value(v value.Value) ast.Expr {
+	if alloca, ok := v.(*ir.InstAlloca); ok && fe.vars[alloca] {
+		return &ast.UnaryExpr{Op: token.AND, X: ast.NewIdent(fe.names[v])}
+	}
+	if name, ok := fe.names[v]; ok {
+		return ast.NewIdent(name)
+	}
+	if inst, ok := v.(ir.Instruction); ok && fe.folded[v] {
+		return fe.instExpr(inst)
+	}
+	if c, ok := v.(constant.Constant); ok {
+		return fe.constExpr(c)
+	}
+	panic(fmt.Errorf("unable to locate variable of value %v", v.Ident()))
+}
+
+// lvalue returns the Go expression designating the variable pointed to by the
+// given pointer value (i.e. *v).
+func (fe *funcEmitter) lvalue(v value.Value) ast.Expr {
+	if alloca, ok := v.(*ir.InstAlloca); ok && fe.vars[alloca] {
+		return ast.NewIdent(fe.names[v])
+	}
+	switch v := v.(type) {
+	case *ir.Global:
+		return ast.NewIdent(sanitize(v.Name()))
+	case *ir.InstGetElementPtr:
+		if fe.folded[v] && len(v.Indices) > 1 {
+			return fe.gepLvalue(v.Src, v.Indices)
+		}
+	case *constant.ExprGetElementPtr:
+		if len(v.Indices) > 1 {
+			return fe.gepLvalue(v.Src, constIndices(v.Indices))
+		}
+	}
+	return &ast.StarExpr{X: fe.value(v)}
+}
+
+// gepLvalue returns the Go expression designating the element addressed by the
+// getelementptr of the given source pointer and indices; where
+// len(indices) > 1.
+//
+// Note, Go implicitly dereferences pointers to structs and arrays in selector
+// and index expressions.
+func (fe *funcEmitter) gepLvalue(src value.Value, indices []value.Value) ast.Expr {
+	var cur ast.Expr
+	if isZeroIndex(indices[0]) {
+		switch src.(type) {
+		case *ir.Global, *ir.InstAlloca, *ir.InstGetElementPtr, *constant.ExprGetElementPtr:
+			cur = fe.lvalue(src)
+			if star, ok := cur.(*ast.StarExpr); ok {
+				cur = star.X
+			}
+		default:
+			cur = fe.value(src)
+		}
+	} else {
+		cur = fe.ptrAdd(src, indices[0])
+	}
+	elem := src.Type().(*types.PointerType).ElemType
+	for _, index := range indices[1:] {
+		switch t := elem.(type) {
+		case *types.StructType:
+			field := int(indexValue(index))
+			cur = &ast.SelectorExpr{X: paren(cur), Sel: ast.NewIdent(fieldName(field))}
+			elem = t.Fields[field]
+		case *types.ArrayType:
+			cur = &ast.IndexExpr{X: paren(cur), Index: fe.value(index)}
+			elem = t.ElemType
+		case *types.VectorType:
+			cur = &ast.IndexExpr{X: paren(cur), Index: fe.value(index)}
+			elem = t.ElemType
+		default:
+			panic(fmt.Errorf("support for getelementptr index into type %T not yet implemented", t))
+		}
+	}
+	return cur
+}
+
+// gepExpr returns the Go expression of the getelementptr of the given source
+// pointer and indices.
+func (fe *funcEmitter) gepExpr(src value.Value, indices []value.Value) ast.Expr {
+	if len(indices) == 1 {
+		if isZeroIndex(indices[0]) {
+			return fe.value(src)
+		}
+		return fe.ptrAdd(src, indices[0])
+	}
+	return &ast.UnaryExpr{Op: token.AND, X: fe.gepLvalue(src, indices)}
+}
+
+// ptrAdd returns the Go expression of the given pointer advanced by index
+// elements.
+//
+//    (*T)(unsafe.Pointer(uintptr(unsafe.Pointer(p)) + uintptr(i)*unsafe.Sizeof(*p)))
+func (fe *funcEmitter) ptrAdd(src value.Value, index value.Value) ast.Expr {
+	p := fe.value(src)
+	addr := convert(ast.NewIdent("uintptr"), convert(fe.gen.pkg("unsafe", "Pointer"), p))
+	size := &ast.CallExpr{Fun: fe.gen.pkg("unsafe", "Sizeof"), Args: []ast.Expr{&ast.StarExpr{X: p}}}
+	offset := binary(convert(ast.NewIdent("uintptr"), fe.value(index)), token.MUL, size)
+	sum := binary(addr, token.ADD, offset)
+	return convert(goType(src.Type()), convert(fe.gen.pkg("unsafe", "Pointer"), sum))
+}
+
+// ### [ Constants ] ###########################################################
+
+// constExpr returns the Go expression of the given constant.
+func (fe *funcEmitter) constExpr(c constant.Constant) ast.Expr {
+	switch c := c.(type) {
+	case *constant.Int:
+		return intConst(c)
+	case *constant.Float:
+		return fe.floatConst(c)
+	case *constant.Null:
+		return ast.NewIdent("nil")
+	case *constant.ZeroInitializer:
+		return zeroValue(c.Typ)
+	case *constant.Undef:
+		return zeroValue(c.Typ)
+	case *constant.CharArray:
+		lit := &ast.CompositeLit{Type: goType(c.Typ)}
+		for _, b := range c.X {
+			lit.Elts = append(lit.Elts, intLit(int64(int8(b))))
+		}
+		return lit
+	case *constant.Array:
+		lit := &ast.CompositeLit{Type: goType(c.Typ)}
+		for _, elem := range c.Elems {
+			lit.Elts = append(lit.Elts, fe.constExpr(elem))
+		}
+		return lit
+	case *constant.Struct:
+		lit := &ast.CompositeLit{Type: goType(c.Typ)}
+		for _, field := range c.Fields {
+			lit.Elts = append(lit.Elts, fe.constExpr(field))
+		}
+		return lit
+	case *ir.Global:
+		return &ast.UnaryExpr{Op: token.AND, X: ast.NewIdent(sanitize(c.Name()))}
+	case *ir.Function:
+		return ast.NewIdent(sanitize(c.Name()))
+	case *constant.ExprBitCast:
+		return fe.bitCast(c.From, c.To)
+	case *constant.ExprIntToPtr:
+		return fe.intToPtr(c.From, c.To)
+	case *constant.ExprPtrToInt:
+		return fe.ptrToInt(c.From, c.To)
+	case *constant.ExprGetElementPtr:
+		return fe.gepExpr(c.Src, constIndices(c.Indices))
+	default:
+		panic(fmt.Errorf("support for constant %T not yet implemented", c))
+	}
+}
+
+// globalDecl returns the Go variable declaration of the given global variable.
+func (gen *generator) globalDecl(g *ir.Global) ast.Decl {
+	spec := &ast.ValueSpec{
+		Names: []*ast.Ident{ast.NewIdent(sanitize(g.Name()))},
+		Type:  goType(g.ContentType),
+	}
+	switch g.Init.(type) {
+	case nil, *constant.ZeroInitializer, *constant.Undef:
+		// zero value.
+	default:
+		fe := &funcEmitter{gen: gen}
+		spec.Values = []ast.Expr{fe.constExpr(g.Init)}
+	}
+	return &ast.GenDecl{Tok: token.VAR, Specs: []ast.Spec{spec}}
+}
+
+// zeroValue returns the Go zero value of the given type.
+func zeroValue(t types.Type) ast.Expr {
+	switch t := t.(type) {
+	case *types.IntType:
+		if t.BitSize == 1 {
+			return ast.NewIdent("false")
+		}
+		return intLit(0)
+	case *types.FloatType:
+		return intLit(0)
+	case *types.PointerType:
+		return ast.NewIdent("nil")
+	}
+	return &ast.CompositeLit{Type: goType(t)}
+}
+
+// ### [ Instructions ] ########################################################
+
+// instExpr returns the Go expression of the given value instruction.
+func (fe *funcEmitter) instExpr(inst ir.Instruction) ast.Expr {
+	switch inst := inst.(type) {
+	// Binary instructions.
+	case *ir.InstAdd:
+		return fe.binary(inst.X, inst.Y, token.ADD, false)
+	case *ir.InstFAdd:
+		return fe.binary(inst.X, inst.Y, token.ADD, false)
+	case *ir.InstSub:
+		return fe.binary(inst.X, inst.Y, token.SUB, false)
+	case *ir.InstFSub:
+		return fe.binary(inst.X, inst.Y, token.SUB, false)
+	case *ir.InstMul:
+		return fe.binary(inst.X, inst.Y, token.MUL, false)
+	case *ir.InstFMul:
+		return fe.binary(inst.X, inst.Y, token.MUL, false)
+	case *ir.InstUDiv:
+		return fe.binary(inst.X, inst.Y, token.QUO, true)
+	case *ir.InstSDiv:
+		return fe.binary(inst.X, inst.Y, token.QUO, false)
+	case *ir.InstFDiv:
+		return fe.binary(inst.X, inst.Y, token.QUO, false)
+	case *ir.InstURem:
+		return fe.binary(inst.X, inst.Y, token.REM, true)
+	case *ir.InstSRem:
+		return fe.binary(inst.X, inst.Y, token.REM, false)
+	case *ir.InstFRem:
+		return &ast.CallExpr{Fun: fe.gen.pkg("math", "Mod"), Args: []ast.Expr{fe.value(inst.X), fe.value(inst.Y)}}
+	// Bitwise instructions.
+	case *ir.InstShl:
+		return fe.shift(inst.X, inst.Y, token.SHL, false)
+	case *ir.InstLShr:
+		return fe.shift(inst.X, inst.Y, token.SHR, true)
+	case *ir.InstAShr:
+		return fe.shift(inst.X, inst.Y, token.SHR, false)
+	case *ir.InstAnd:
+		if isBool(inst.X.Type()) {
+			return fe.binary(inst.X, inst.Y, token.LAND, false)
+		}
+		return fe.binary(inst.X, inst.Y, token.AND, false)
+	case *ir.InstOr:
+		if isBool(inst.X.Type()) {
+			return fe.binary(inst.X, inst.Y, token.LOR, false)
+		}
+		return fe.binary(inst.X, inst.Y, token.OR, false)
+	case *ir.InstXor:
+		if isBool(inst.X.Type()) {
+			return fe.binary(inst.X, inst.Y, token.NEQ, false)
+		}
+		return fe.binary(inst.X, inst.Y, token.XOR, false)
+	// Vector and aggregate instructions.
+	case *ir.InstExtractValue:
+		x := fe.value(inst.X)
+		t := inst.X.Type()
+		for _, index := range inst.Indices {
+			switch tt := t.(type) {
+			case *types.StructType:
+				x = &ast.SelectorExpr{X: paren(x), Sel: ast.NewIdent(fieldName(int(index)))}
+				t = tt.Fields[index]
+			case *types.ArrayType:
+				x = &ast.IndexExpr{X: paren(x), Index: intLit(int64(index))}
+				t = tt.ElemType
+			default:
+				panic(fmt.Errorf("support for extractvalue index into type %T not yet implemented", tt))
+			}
+		}
+		return x
+	// Memory instructions.
+	case *ir.InstLoad:
+		return fe.lvalue(inst.Src)
+	case *ir.InstGetElementPtr:
+		return fe.gepExpr(inst.Src, inst.Indices)
+	// Conversion instructions.
+	case *ir.InstTrunc:
+		if isBool(inst.To) {
+			// x&1 != 0
+			return binary(binary(fe.value(inst.From), token.AND, intLit(1)), token.NEQ, intLit(0))
+		}
+		return convert(goType(inst.To), fe.value(inst.From))
+	case *ir.InstZExt:
+		if isBool(inst.From.Type()) {
+			return convert(goType(inst.To), fe.gen.b2i(fe.value(inst.From)))
+		}
+		return convert(goType(inst.To), convert(unsignedType(inst.From.Type()), fe.value(inst.From)))
+	case *ir.InstSExt:
+		if isBool(inst.From.Type()) {
+			return &ast.UnaryExpr{Op: token.SUB, X: convert(goType(inst.To), fe.gen.b2i(fe.value(inst.From)))}
+		}
+		return convert(goType(inst.To), fe.value(inst.From))
+	case *ir.InstFPTrunc:
+		return convert(goType(inst.To), fe.value(inst.From))
+	case *ir.InstFPExt:
+		return convert(goType(inst.To), fe.value(inst.From))
+	case *ir.InstFPToUI:
+		return convert(goType(inst.To), convert(unsignedType(inst.To), fe.value(inst.From)))
+	case *ir.InstFPToSI:
+		return convert(goType(inst.To), fe.value(inst.From))
+	case *ir.InstUIToFP:
+		return convert(goType(inst.To), convert(unsignedType(inst.From.Type()), fe.value(inst.From)))
+	case *ir.InstSIToFP:
+		return convert(goType(inst.To), fe.value(inst.From))
+	case *ir.InstPtrToInt:
+		return fe.ptrToInt(inst.From, inst.To)
+	case *ir.InstIntToPtr:
+		return fe.intToPtr(inst.From, inst.To)
+	case *ir.InstBitCast:
+		return fe.bitCast(inst.From, inst.To)
+	case *ir.InstAddrSpaceCast:
+		return fe.bitCast(inst.From, inst.To)
+	// Other instructions.
+	case *ir.InstICmp:
+		return fe.icmp(inst)
+	case *ir.InstFCmp:
+		return fe.fcmp(inst)
+	case *ir.InstSelect:
+		return fe.selectExpr(inst)
+	case *ir.InstCall:
+		return fe.callExpr(inst)
+	default:
+		panic(fmt.Errorf("support for instruction %T not yet implemented", inst))
+	}
+}
+
+// binary returns the Go expression of the binary operation op on x and y. The
+// operands are converted to unsigned integers if unsigned is set.
+func (fe *funcEmitter) binary(x, y value.Value, op token.Token, unsigned bool) ast.Expr {
+	if unsigned {
+		expr := binary(convert(unsignedType(x.Type()), fe.value(x)), op, convert(unsignedType(y.Type()), fe.value(y)))
+		return convert(goType(x.Type()), expr)
+	}
+	return binary(fe.value(x), op, fe.value(y))
+}
+
+// shift returns the Go expression of the shift operation op on x by y. The
+// shifted operand is converted to an unsigned integer if unsigned is set.
+//
+// Note, the shift count is converted to an unsigned integer, as required by
+// Go 1.12.
+func (fe *funcEmitter) shift(x, y value.Value, op token.Token, unsigned bool) ast.Expr {
+	count := convert(ast.NewIdent("uint64"), fe.value(y))
+	if unsigned {
+		expr := binary(convert(unsignedType(x.Type()), fe.value(x)), op, count)
+		return convert(goType(x.Type()), expr)
+	}
+	return binary(fe.value(x), op, count)
+}
+
+// ptrToInt returns the Go expression of the conversion from the given pointer
+// to an integer of the given type.
+//
+//    T(uintptr(unsafe.Pointer(p)))
+func (fe *funcEmitter) ptrToInt(from value.Value, to types.Type) ast.Expr {
+	addr := convert(ast.NewIdent("uintptr"), convert(fe.gen.pkg("unsafe", "Pointer"), fe.value(from)))
+	return convert(goType(to), addr)
+}
+
+// intToPtr returns the Go expression of the conversion from the given integer
+// to a pointer of the given type.
+//
+//    (*T)(unsafe.Pointer(uintptr(x)))
+func (fe *funcEmitter) intToPtr(from value.Value, to types.Type) ast.Expr {
+	addr := convert(ast.NewIdent("uintptr"), fe.value(from))
+	return convert(goType(to), convert(fe.gen.pkg("unsafe", "Pointer"), addr))
+}
+
+// bitCast returns the Go expression of the reinterpretation of the bits of the
+// given value as the given type.
+func (fe *funcEmitter) bitCast(from value.Value, to types.Type) ast.Expr {
+	x := fe.value(from)
+	fromType := from.Type()
+	switch {
+	case types.IsPointer(fromType) && types.IsPointer(to):
+		//    (*T)(unsafe.Pointer(p))
+		return convert(goType(to), convert(fe.gen.pkg("unsafe", "Pointer"), x))
+	case types.IsInt(fromType) && types.IsFloat(to):
+		//    math.Float64frombits(uint64(x))
+		name := fmt.Sprintf("Float%sfrombits", bitSize(to))
+		return &ast.CallExpr{Fun: fe.gen.pkg("math", name), Args: []ast.Expr{convert(unsignedType(fromType), x)}}
+	case types.IsFloat(fromType) && types.IsInt(to):
+		//    int64(math.Float64bits(x))
+		name := fmt.Sprintf("Float%sbits", bitSize(fromType))
+		return convert(goType(to), &ast.CallExpr{Fun: fe.gen.pkg("math", name), Args: []ast.Expr{x}})
+	}
+	//    *(*T)(unsafe.Pointer(&x))
+	addr := &ast.UnaryExpr{Op: token.AND, X: x}
+	return &ast.StarExpr{X: convert(&ast.StarExpr{X: goType(to)}, convert(fe.gen.pkg("unsafe", "Pointer"), addr))}
+}
+
+// icmp returns the Go expression of the given integer comparison instruction.
+func (fe *funcEmitter) icmp(inst *ir.InstICmp) ast.Expr {
+	switch inst.Pred {
+	case enum.IPredEQ:
+		return fe.binary(inst.X, inst.Y, token.EQL, false)
+	case enum.IPredNE:
+		return fe.binary(inst.X, inst.Y, token.NEQ, false)
+	case enum.IPredSGT:
+		return fe.binary(inst.X, inst.Y, token.GTR, false)
+	case enum.IPredSGE:
+		return fe.binary(inst.X, inst.Y, token.GEQ, false)
+	case enum.IPredSLT:
+		return fe.binary(inst.X, inst.Y, token.LSS, false)
+	case enum.IPredSLE:
+		return fe.binary(inst.X, inst.Y, token.LEQ, false)
+	case enum.IPredUGT:
+		return binary(fe.unsigned(inst.X), token.GTR, fe.unsigned(inst.Y))
+	case enum.IPredUGE:
+		return binary(fe.unsigned(inst.X), token.GEQ, fe.unsigned(inst.Y))
+	case enum.IPredULT:
+		return binary(fe.unsigned(inst.X), token.LSS, fe.unsigned(inst.Y))
+	case enum.IPredULE:
+		return binary(fe.unsigned(inst.X), token.LEQ, fe.unsigned(inst.Y))
+	default:
+		panic(fmt.Errorf("support for integer comparison predicate %v not yet implemented", inst.Pred))
+	}
+}
+
+// unsigned returns the Go expression of the given integer or pointer value
+// converted to an unsigned integer.
+func (fe *funcEmitter) unsigned(v value.Value) ast.Expr {
+	x := fe.value(v)
+	if types.IsPointer(v.Type()) {
+		x = convert(fe.gen.pkg("unsafe", "Pointer"), x)
+	}
+	return convert(unsignedType(v.Type()), x)
+}
+
+// fcmp returns the Go expression of the given floating-point comparison
+// instruction. Unordered comparisons are negations of the inverse ordered
+// comparison, as Go comparisons involving NaN are false (except !=).
+func (fe *funcEmitter) fcmp(inst *ir.InstFCmp) ast.Expr {
+	switch inst.Pred {
+	case enum.FPredFalse:
+		return ast.NewIdent("false")
+	case enum.FPredOEQ:
+		return fe.binary(inst.X, inst.Y, token.EQL, false)
+	case enum.FPredOGT:
+		return fe.binary(inst.X, inst.Y, token.GTR, false)
+	case enum.FPredOGE:
+		return fe.binary(inst.X, inst.Y, token.GEQ, false)
+	case enum.FPredOLT:
+		return fe.binary(inst.X, inst.Y, token.LSS, false)
+	case enum.FPredOLE:
+		return fe.binary(inst.X, inst.Y, token.LEQ, false)
+	case enum.FPredONE:
+		return binary(fe.binary(inst.X, inst.Y, token.LSS, false), token.LOR, fe.binary(inst.X, inst.Y, token.GTR, false))
+	case enum.FPredORD:
+		x, y := fe.value(inst.X), fe.value(inst.Y)
+		return binary(binary(x, token.EQL, x), token.LAND, binary(y, token.EQL, y))
+	case enum.FPredUEQ:
+		return not(fe.binary(inst.X, inst.Y, token.NEQ, false))
+	case enum.FPredUGT:
+		return not(fe.binary(inst.X, inst.Y, token.LEQ, false))
+	case enum.FPredUGE:
+		return not(fe.binary(inst.X, inst.Y, token.LSS, false))
+	case enum.FPredULT:
+		return not(fe.binary(inst.X, inst.Y, token.GEQ, false))
+	case enum.FPredULE:
+		return not(fe.binary(inst.X, inst.Y, token.GTR, false))
+	case enum.FPredUNE:
+		return fe.binary(inst.X, inst.Y, token.NEQ, false)
+	case enum.FPredUNO:
+		x, y := fe.value(inst.X), fe.value(inst.Y)
+		return binary(binary(x, token.NEQ, x), token.LOR, binary(y, token.NEQ, y))
+	case enum.FPredTrue:
+		return ast.NewIdent("true")
+	default:
+		panic(fmt.Errorf("support for floating-point comparison predicate %v not yet implemented", inst.Pred))
+	}
+}
+
+// selectExpr returns the Go expression of the given select instruction, as Go
+// lacks a conditional operator.
+//
+//    func() T {
+//       if cond {
+//          return x
+//       }
+//       return y
+//    }()
+func (fe *funcEmitter) selectExpr(inst *ir.InstSelect) ast.Expr {
+	lit := &ast.FuncLit{
+		Type: &ast.FuncType{
+			Params:  &ast.FieldList{},
+			Results: &ast.FieldList{List: []*ast.Field{{Type: goType(inst.X.Type())}}},
+		},
+		Body: &ast.BlockStmt{List: []ast.Stmt{
+			&ast.IfStmt{
+				Cond: fe.value(inst.Cond),
+				Body: &ast.BlockStmt{List: []ast.Stmt{
+					&ast.ReturnStmt{Results: []ast.Expr{fe.value(inst.X)}},
+				}},
+			},
+			&ast.ReturnStmt{Results: []ast.Expr{fe.value(inst.Y)}},
+		}},
+	}
+	return &ast.CallExpr{Fun: lit}
+}
+
+// callExpr returns the Go expression of the given call instruction.
+func (fe *funcEmitter) callExpr(inst *ir.InstCall) ast.Expr {
+	var args []ast.Expr
+	for _, arg := range inst.Args {
+		args = append(args, fe.value(arg))
+	}
+	var callee ast.Expr
+	if f, ok := inst.Callee.(*ir.Function); ok {
+		callee = ast.NewIdent(sanitize(f.Name()))
+	} else {
+		callee = paren(fe.value(inst.Callee))
+	}
+	return &ast.CallExpr{Fun: callee, Args: args}
+}
+
+// ### [ Helper functions ] ####################################################
+
+// binary returns the binary expression x op y, parenthesizing operands as
+// needed.
+func binary(x ast.Expr, op token.Token, y ast.Expr) ast.Expr {
+	return &ast.BinaryExpr{X: parenBinary(x, op), Op: op, Y: parenBinary(y, op)}
+}
+
+// parenBinary parenthesizes the given binary expression operand of op, unless
+// of higher precedence than op.
+func parenBinary(x ast.Expr, op token.Token) ast.Expr {
+	if bin, ok := x.(*ast.BinaryExpr); ok && bin.Op.Precedence() <= op.Precedence() {
+		return &ast.ParenExpr{X: x}
+	}
+	return x
+}
+
+// convert returns the Go conversion of x to the given type.
+func convert(typ, x ast.Expr) ast.Expr {
+	switch typ.(type) {
+	case *ast.StarExpr, *ast.FuncType:
+		typ = &ast.ParenExpr{X: typ}
+	}
+	return &ast.CallExpr{Fun: typ, Args: []ast.Expr{x}}
+}
+
+// not returns the negation of the given boolean expression.
+func not(x ast.Expr) ast.Expr {
+	return &ast.UnaryExpr{Op: token.NOT, X: paren(x)}
+}
+
+// paren parenthesizes the given expression if used as the operand of a unary,
+// selector, index or call expression.
+func paren(x ast.Expr) ast.Expr {
+	switch x.(type) {
+	case *ast.BinaryExpr, *ast.UnaryExpr, *ast.StarExpr, *ast.FuncLit:
+		return &ast.ParenExpr{X: x}
+	}
+	return x
+}
+
+// intLit returns the Go integer literal of the given value.
+func intLit(x int64) ast.Expr {
+	if x < 0 {
+		return &ast.UnaryExpr{Op: token.SUB, X: intLit(-x)}
+	}
+	return &ast.BasicLit{Kind: token.INT, Value: strconv.FormatInt(x, 10)}
+}
+
+// intConst returns the Go expression of the given integer constant; large
+// values are formatted in hexadecimal, as they are likely addresses or bit
+// masks.
+func intConst(c *constant.Int) ast.Expr {
+	if c.Typ.BitSize == 1 {
+		if c.X.Sign() == 0 {
+			return ast.NewIdent("false")
+		}
+		return ast.NewIdent("true")
+	}
+	if c.X.Sign() < 0 {
+		abs := &constant.Int{Typ: c.Typ, X: new(big.Int).Neg(c.X)}
+		return &ast.UnaryExpr{Op: token.SUB, X: intConst(abs)}
+	}
+	if c.X.Cmp(big.NewInt(0x10000)) >= 0 {
+		return &ast.BasicLit{Kind: token.INT, Value: fmt.Sprintf("0x%X", c.X)}
+	}
+	return &ast.BasicLit{Kind: token.INT, Value: c.X.String()}
+}
+
+// floatConst returns the Go expression of the given floating-point constant.
+func (fe *funcEmitter) floatConst(c *constant.Float) ast.Expr {
+	if c.NaN {
+		return &ast.CallExpr{Fun: fe.gen.pkg("math", "NaN")}
+	}
+	if c.X.IsInf() {
+		return &ast.CallExpr{Fun: fe.gen.pkg("math", "Inf"), Args: []ast.Expr{intLit(int64(c.X.Sign()))}}
+	}
+	x, _ := c.X.Float64()
+	s := strconv.FormatFloat(x, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eE") {
+		s += ".0"
+	}
+	if strings.HasPrefix(s, "-") {
+		return &ast.UnaryExpr{Op: token.SUB, X: &ast.BasicLit{Kind: token.FLOAT, Value: s[1:]}}
+	}
+	return &ast.BasicLit{Kind: token.FLOAT, Value: s}
+}
+
+// bitSize returns the size in bits of the given floating-point type, as used
+// in the names of the math.Float{32,64}{bits,frombits} functions.
+func bitSize(t types.Type) string {
+	if t, ok := t.(*types.FloatType); ok {
+		switch t.Kind {
+		case types.FloatKindHalf, types.FloatKindFloat:
+			return "32"
+		}
+		return "64"
+	}
+	if t, ok := t.(*types.IntType); ok && t.BitSize <= 32 {
+		return "32"
+	}
+	return "64"
+}
+
+// constIndices returns the given constant indices as values.
+func constIndices(indices []*constant.Index) []value.Value {
+	var vs []value.Value
+	for _, index := range indices {
+		vs = append(vs, index.Index)
+	}
+	return vs
+}
+
+// isZeroIndex reports whether the given getelementptr index is constant zero.
+func isZeroIndex(index value.Value) bool {
+	switch index := index.(type) {
+	case *constant.Int:
+		return index.X.Sign() == 0
+	case *constant.ZeroInitializer:
+		return true
+	}
+	return false
+}
+
+// indexValue returns the value of the given constant getelementptr struct
+// index.
+func indexValue(index value.Value) int64 {
+	switch index := index.(type) {
+	case *constant.Int:
+		return index.X.Int64()
+	case *constant.ZeroInitializer:
+		return 0
+	}
+	panic(fmt.Errorf("invalid non-constant struct index %v", index.Ident()))
+}
+
+// sanitize returns a valid Go identifier of the given name, replacing invalid
+// characters with underscores.
+func sanitize(name string) string {
+	s := strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', r == '_':
+			return r
+		}
+		return '_'
+	}, name)
+	if len(s) == 0 || ('0' <= s[0] && s[0] <= '9') {
+		s = "_" + s
+	}
+	if keywords[s] {
+		s += "_"
+	}
+	return s
+}
+
+// keywords is the set of Go keywords and predeclared identifiers used by the
+// Go translation.
+var keywords = map[string]bool{
+	"break": true, "case": true, "chan": true, "const": true, "continue": true,
+	"default": true, "defer": true, "else": true, "fallthrough": true,
+	"for": true, "func": true, "go": true, "goto": true, "if": true,
+	"import": true, "interface": true, "map": true, "package": true,
+	"range": true, "return": true, "select": true, "struct": true,
+	"switch": true, "type": true, "var": true,
+	"b2i": true, "false": true, "math": true, "nil": true, "true": true,
+	"uintptr": true, "unsafe": true,
+}
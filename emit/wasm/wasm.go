@@ -0,0 +1,1206 @@
+// Package wasm translates lifted LLVM IR modules to WebAssembly text format
+// (*.wat).
+//
+// Global variables are placed in linear memory, followed by the stack; pointers
+// are represented as i32 offsets into linear memory and function pointers as
+// indices into the function table. Function declarations (e.g. imports of the
+// binary executable) are imported from the "env" module.
+//
+// As WebAssembly lacks unstructured control flow, the basic blocks of each
+// function are translated to the cases of a dispatch loop, which selects the
+// basic block to execute based on the $label local variable.
+//
+//    loop $dispatch
+//      block $block_2
+//        block $block_1
+//          block $block_0
+//            local.get $label
+//            br_table $block_0 $block_1 $block_2
+//          end
+//          ;; basic block 0
+//          i32.const 2
+//          local.set $label
+//          br $dispatch
+//        end
+//        ;; basic block 1
+//      end
+//      ;; basic block 2
+//    end
+//
+// Integer values narrower than 32 bits are kept zero-extended in i32 locals, and
+// are sign-extended on demand (e.g. for sdiv and signed comparisons).
+// x86_fp80 values are represented as f64, and thus lose precision.
+//
+// Variadic functions follow the WebAssembly C ABI of Clang; variadic arguments
+// are stored in a buffer on the stack, which is passed as an additional i32
+// argument.
+package wasm
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/enum"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+	"github.com/pkg/errors"
+)
+
+// Memory layout.
+const (
+	// Offset in linear memory of the first global variable; the first kilobyte
+	// is left unused, so that null pointer dereferences do not alias global
+	// variables.
+	globalsStart = 0x400
+	// Size in bytes of the stack, which follows the global variables.
+	stackSize = 0x100000
+	// Size in bytes of WebAssembly memory pages.
+	pageSize = 0x10000
+)
+
+// Write writes the WebAssembly text format translation of the given LLVM IR
+// module to w.
+func Write(w io.Writer, m *ir.Module) error {
+	e := newEmitter(m)
+	for _, f := range m.Funcs {
+		if len(f.Blocks) == 0 {
+			continue
+		}
+		e.emitFunc(f)
+	}
+	if _, err := w.Write(e.module()); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// An emitter translates LLVM IR modules to WebAssembly text format.
+type emitter struct {
+	// LLVM IR module.
+	m *ir.Module
+	// Offset in linear memory of global variables.
+	globalAddrs map[*ir.Global]uint32
+	// End offset of global variables in linear memory.
+	globalsEnd uint32
+	// Index in function table of functions, by name; index 0 is reserved for
+	// null function pointers.
+	funcIndices map[string]uint32
+	// Type names of function signatures used by indirect calls, by WebAssembly
+	// signature.
+	sigNames map[string]string
+	// WebAssembly signatures of indirect calls, in order of use.
+	sigs []string
+	// Translated function definitions.
+	funcs bytes.Buffer
+
+	// Per function state.
+
+	// Output buffer of the current function.
+	buf *bytes.Buffer
+	// Indentation level of the current function.
+	indent int
+	// Local variable names of LLVM IR values.
+	locals map[value.Value]string
+	// Local variable declarations of the current function.
+	localDecls []string
+	// Index of basic blocks of the current function.
+	blockIndices map[*ir.BasicBlock]int
+}
+
+// newEmitter returns a new emitter for the given LLVM IR module, with global
+// variables laid out in linear memory.
+func newEmitter(m *ir.Module) *emitter {
+	e := &emitter{
+		m:           m,
+		globalAddrs: make(map[*ir.Global]uint32),
+		funcIndices: make(map[string]uint32),
+		sigNames:    make(map[string]string),
+	}
+	off := uint32(globalsStart)
+	for _, g := range m.Globals {
+		off = align(off, alignOf(g.ContentType))
+		e.globalAddrs[g] = off
+		off += sizeOf(g.ContentType)
+	}
+	e.globalsEnd = off
+	for i, f := range m.Funcs {
+		e.funcIndices[f.Name()] = uint32(i + 1)
+	}
+	return e
+}
+
+// module returns the WebAssembly text format of the module.
+func (e *emitter) module() []byte {
+	out := &bytes.Buffer{}
+	out.WriteString("(module\n")
+	// Function signatures of indirect calls.
+	for _, sig := range e.sigs {
+		fmt.Fprintf(out, "  (type %s (func%s))\n", e.sigNames[sig], sig)
+	}
+	// Imports.
+	for _, f := range e.m.Funcs {
+		if len(f.Blocks) != 0 {
+			continue
+		}
+		fmt.Fprintf(out, "  (import \"env\" %s (func %s%s))\n", quote([]byte(f.Name())), funcIdent(f), funcSig(f.Sig))
+	}
+	// Linear memory and stack pointer.
+	stackTop := align(e.globalsEnd, 16) + stackSize
+	pages := (stackTop + pageSize - 1) / pageSize
+	fmt.Fprintf(out, "  (memory $memory %d)\n", pages)
+	fmt.Fprintf(out, "  (global $__stack_pointer (mut i32) (i32.const %d))\n", stackTop)
+	// Function table.
+	fmt.Fprintf(out, "  (table $table %d funcref)\n", len(e.m.Funcs)+1)
+	if len(e.m.Funcs) > 0 {
+		out.WriteString("  (elem (i32.const 1)")
+		for _, f := range e.m.Funcs {
+			fmt.Fprintf(out, " %s", funcIdent(f))
+		}
+		out.WriteString(")\n")
+	}
+	// Initial contents of global variables.
+	for _, g := range e.m.Globals {
+		if g.Init == nil {
+			continue
+		}
+		data := make([]byte, sizeOf(g.ContentType))
+		e.encodeConst(data, g.Init)
+		if isZero(data) {
+			continue
+		}
+		fmt.Fprintf(out, "  (data (i32.const %d) %s) ;; %s\n", e.globalAddrs[g], quote(data), g.Ident())
+	}
+	// Function definitions.
+	out.Write(e.funcs.Bytes())
+	// Exports.
+	out.WriteString("  (export \"memory\" (memory $memory))\n")
+	for _, f := range e.m.Funcs {
+		if len(f.Blocks) == 0 {
+			continue
+		}
+		fmt.Fprintf(out, "  (export %s (func %s))\n", quote([]byte(f.Name())), funcIdent(f))
+	}
+	out.WriteString(")\n")
+	return out.Bytes()
+}
+
+// ### [ Functions ] ###########################################################
+
+// emitFunc translates the given LLVM IR function definition to WebAssembly.
+func (e *emitter) emitFunc(f *ir.Function) {
+	e.buf = &bytes.Buffer{}
+	e.indent = 2
+	e.locals = make(map[value.Value]string)
+	e.localDecls = nil
+	e.blockIndices = make(map[*ir.BasicBlock]int)
+	for i, param := range f.Params {
+		e.locals[param] = fmt.Sprintf("$p%d", i)
+	}
+	for i, block := range f.Blocks {
+		e.blockIndices[block] = i
+		for _, inst := range block.Insts {
+			if v, ok := inst.(value.Value); ok && !types.Equal(v.Type(), types.Void) {
+				name := fmt.Sprintf("$v%d", len(e.localDecls))
+				e.locals[v] = name
+				e.localDecls = append(e.localDecls, fmt.Sprintf("(local %s %s)", name, valType(v.Type())))
+			}
+		}
+	}
+
+	// Prologue; save stack pointer, which is restored on return.
+	e.emit("global.get $__stack_pointer")
+	e.emit("local.set $sp")
+	// Dispatch loop.
+	e.emit("loop $dispatch")
+	e.indent++
+	for i := len(f.Blocks) - 1; i >= 0; i-- {
+		e.emit("block $block_%d", i)
+		e.indent++
+	}
+	e.emit("local.get $label")
+	labels := make([]string, len(f.Blocks))
+	for i := range f.Blocks {
+		labels[i] = fmt.Sprintf("$block_%d", i)
+	}
+	e.emit("br_table %s", strings.Join(labels, " "))
+	for i, block := range f.Blocks {
+		e.indent--
+		e.emit("end")
+		e.emit(";; %s", blockName(block, i))
+		for _, inst := range block.Insts {
+			e.emitInst(inst)
+		}
+		e.emitTerm(block)
+	}
+	e.indent--
+	e.emit("end")
+	e.emit("unreachable")
+
+	// Function header.
+	out := &e.funcs
+	fmt.Fprintf(out, "  (func %s", funcIdent(f))
+	for i, param := range f.Params {
+		fmt.Fprintf(out, " (param $p%d %s)", i, valType(param.Type()))
+	}
+	if f.Sig.Variadic {
+		out.WriteString(" (param $va i32)")
+	}
+	if !types.Equal(f.Sig.RetType, types.Void) {
+		fmt.Fprintf(out, " (result %s)", valType(f.Sig.RetType))
+	}
+	out.WriteString("\n")
+	out.WriteString("    (local $label i32) (local $sp i32)\n")
+	for _, decl := range e.localDecls {
+		fmt.Fprintf(out, "    %s\n", decl)
+	}
+	out.Write(e.buf.Bytes())
+	out.WriteString("  )\n")
+}
+
+// emitTerm translates the terminator of the given basic block to WebAssembly.
+func (e *emitter) emitTerm(block *ir.BasicBlock) {
+	switch term := block.Term.(type) {
+	case *ir.TermRet:
+		e.emit("local.get $sp")
+		e.emit("global.set $__stack_pointer")
+		if term.X != nil {
+			e.push(term.X)
+		}
+		e.emit("return")
+	case *ir.TermBr:
+		e.jump(block, term.Target)
+	case *ir.TermCondBr:
+		e.push(term.Cond)
+		e.emit("if")
+		e.indent++
+		e.jump(block, term.TargetTrue)
+		e.indent--
+		e.emit("else")
+		e.indent++
+		e.jump(block, term.TargetFalse)
+		e.indent--
+		e.emit("end")
+		e.emit("unreachable")
+	case *ir.TermSwitch:
+		typ := valType(term.X.Type())
+		for _, c := range term.Cases {
+			e.push(term.X)
+			e.push(c.X)
+			e.emit("%s.eq", typ)
+			e.emit("if")
+			e.indent++
+			e.jump(block, c.Target)
+			e.indent--
+			e.emit("end")
+		}
+		e.jump(block, term.TargetDefault)
+	case *ir.TermUnreachable:
+		e.emit("unreachable")
+	default:
+		panic(fmt.Errorf("support for terminator %T not yet implemented", term))
+	}
+}
+
+// jump emits a branch from the given basic block to the target basic block,
+// assigning the incoming values of the phi instructions of the target.
+func (e *emitter) jump(from, to *ir.BasicBlock) {
+	// Push all incoming values before assigning any phi instruction, as phi
+	// instructions are evaluated in parallel.
+	var phis []*ir.InstPhi
+	for _, inst := range to.Insts {
+		phi, ok := inst.(*ir.InstPhi)
+		if !ok {
+			break
+		}
+		found := false
+		for _, inc := range phi.Incs {
+			if inc.Pred == from {
+				e.push(inc.X)
+				found = true
+				break
+			}
+		}
+		if !found {
+			panic(fmt.Errorf("unable to locate incoming value of %s for predecessor %s", phi.Ident(), from.Ident()))
+		}
+		phis = append(phis, phi)
+	}
+	for i := len(phis) - 1; i >= 0; i-- {
+		e.emit("local.set %s", e.locals[phis[i]])
+	}
+	e.emit("i32.const %d", e.blockIndices[to])
+	e.emit("local.set $label")
+	e.emit("br $dispatch")
+}
+
+// ### [ Instructions ] ########################################################
+
+// emitInst translates the given LLVM IR instruction to WebAssembly.
+func (e *emitter) emitInst(inst ir.Instruction) {
+	switch inst := inst.(type) {
+	// Binary instructions.
+	case *ir.InstAdd:
+		e.binary(inst, inst.X, inst.Y, "add", false)
+	case *ir.InstSub:
+		e.binary(inst, inst.X, inst.Y, "sub", false)
+	case *ir.InstMul:
+		e.binary(inst, inst.X, inst.Y, "mul", false)
+	case *ir.InstUDiv:
+		e.binary(inst, inst.X, inst.Y, "div_u", false)
+	case *ir.InstSDiv:
+		e.binary(inst, inst.X, inst.Y, "div_s", true)
+	case *ir.InstURem:
+		e.binary(inst, inst.X, inst.Y, "rem_u", false)
+	case *ir.InstSRem:
+		e.binary(inst, inst.X, inst.Y, "rem_s", true)
+	case *ir.InstFAdd:
+		e.binary(inst, inst.X, inst.Y, "add", false)
+	case *ir.InstFSub:
+		e.binary(inst, inst.X, inst.Y, "sub", false)
+	case *ir.InstFMul:
+		e.binary(inst, inst.X, inst.Y, "mul", false)
+	case *ir.InstFDiv:
+		e.binary(inst, inst.X, inst.Y, "div", false)
+	// Bitwise instructions.
+	case *ir.InstShl:
+		e.binary(inst, inst.X, inst.Y, "shl", false)
+	case *ir.InstLShr:
+		e.binary(inst, inst.X, inst.Y, "shr_u", false)
+	case *ir.InstAShr:
+		// Only the shifted operand is sign-extended.
+		e.pushSigned(inst.X)
+		e.push(inst.Y)
+		e.emit("%s.shr_s", valType(inst.Type()))
+		e.mask(inst.Type())
+		e.def(inst)
+	case *ir.InstAnd:
+		e.binary(inst, inst.X, inst.Y, "and", false)
+	case *ir.InstOr:
+		e.binary(inst, inst.X, inst.Y, "or", false)
+	case *ir.InstXor:
+		e.binary(inst, inst.X, inst.Y, "xor", false)
+	// Memory instructions.
+	case *ir.InstAlloca:
+		e.emit("global.get $__stack_pointer")
+		e.emit("i32.const %d", sizeOf(inst.ElemType))
+		if inst.NElems != nil {
+			e.pushI32(inst.NElems)
+			e.emit("i32.mul")
+		}
+		e.emit("i32.sub")
+		// Stack allocations are 16-byte aligned, as by the C ABI.
+		e.emit("i32.const %d", -int32(max(alignOf(inst.ElemType), 16)))
+		e.emit("i32.and")
+		e.emit("local.tee %s", e.locals[inst])
+		e.emit("global.set $__stack_pointer")
+	case *ir.InstLoad:
+		e.push(inst.Src)
+		e.emit(loadOp(inst.Type()))
+		e.def(inst)
+	case *ir.InstStore:
+		e.push(inst.Dst)
+		e.push(inst.Src)
+		e.emit(storeOp(inst.Src.Type()))
+	case *ir.InstGetElementPtr:
+		e.push(inst.Src)
+		e.gep(inst.Src.Type(), inst.Indices)
+		e.def(inst)
+	// Conversion instructions.
+	case *ir.InstTrunc:
+		e.convert(inst, inst.From, inst.To, false)
+	case *ir.InstZExt:
+		e.convert(inst, inst.From, inst.To, false)
+	case *ir.InstSExt:
+		e.convert(inst, inst.From, inst.To, true)
+	case *ir.InstFPTrunc:
+		e.convert(inst, inst.From, inst.To, false)
+	case *ir.InstFPExt:
+		e.convert(inst, inst.From, inst.To, false)
+	case *ir.InstFPToUI:
+		e.convert(inst, inst.From, inst.To, false)
+	case *ir.InstFPToSI:
+		e.convert(inst, inst.From, inst.To, true)
+	case *ir.InstUIToFP:
+		e.convert(inst, inst.From, inst.To, false)
+	case *ir.InstSIToFP:
+		e.convert(inst, inst.From, inst.To, true)
+	case *ir.InstPtrToInt:
+		e.convert(inst, inst.From, inst.To, false)
+	case *ir.InstIntToPtr:
+		e.convert(inst, inst.From, inst.To, false)
+	case *ir.InstBitCast:
+		e.convert(inst, inst.From, inst.To, false)
+	case *ir.InstAddrSpaceCast:
+		e.convert(inst, inst.From, inst.To, false)
+	// Other instructions.
+	case *ir.InstICmp:
+		e.icmp(inst)
+	case *ir.InstFCmp:
+		e.fcmp(inst)
+	case *ir.InstPhi:
+		// assigned by the branches of predecessor basic blocks.
+	case *ir.InstSelect:
+		e.push(inst.X)
+		e.push(inst.Y)
+		e.push(inst.Cond)
+		e.emit("select")
+		e.def(inst)
+	case *ir.InstCall:
+		e.call(inst)
+	default:
+		panic(fmt.Errorf("support for instruction %T not yet implemented", inst))
+	}
+}
+
+// binary emits the binary operation op on x and y, and assigns the result to
+// the given instruction. Operands narrower than 32 bits are sign-extended if
+// signed is set.
+func (e *emitter) binary(inst value.Value, x, y value.Value, op string, signed bool) {
+	if signed {
+		e.pushSigned(x)
+		e.pushSigned(y)
+	} else {
+		e.push(x)
+		e.push(y)
+	}
+	e.emit("%s.%s", valType(inst.Type()), op)
+	switch op {
+	case "add", "sub", "mul", "shl", "div_s", "rem_s":
+		// truncate result to bit size.
+		e.mask(inst.Type())
+	}
+	e.def(inst)
+}
+
+// icmp emits the given integer comparison.
+func (e *emitter) icmp(inst *ir.InstICmp) {
+	var op string
+	signed := false
+	switch inst.Pred {
+	case enum.IPredEQ:
+		op = "eq"
+	case enum.IPredNE:
+		op = "ne"
+	case enum.IPredSGE:
+		op, signed = "ge_s", true
+	case enum.IPredSGT:
+		op, signed = "gt_s", true
+	case enum.IPredSLE:
+		op, signed = "le_s", true
+	case enum.IPredSLT:
+		op, signed = "lt_s", true
+	case enum.IPredUGE:
+		op = "ge_u"
+	case enum.IPredUGT:
+		op = "gt_u"
+	case enum.IPredULE:
+		op = "le_u"
+	case enum.IPredULT:
+		op = "lt_u"
+	default:
+		panic(fmt.Errorf("support for integer comparison predicate %v not yet implemented", inst.Pred))
+	}
+	if signed {
+		e.pushSigned(inst.X)
+		e.pushSigned(inst.Y)
+	} else {
+		e.push(inst.X)
+		e.push(inst.Y)
+	}
+	e.emit("%s.%s", valType(inst.X.Type()), op)
+	e.def(inst)
+}
+
+// fcmp emits the given floating-point comparison.
+func (e *emitter) fcmp(inst *ir.InstFCmp) {
+	typ := valType(inst.X.Type())
+	// cmp emits the comparison op of x and y.
+	cmp := func(op string) {
+		e.push(inst.X)
+		e.push(inst.Y)
+		e.emit("%s.%s", typ, op)
+	}
+	// ord emits whether neither x nor y is NaN.
+	ord := func() {
+		e.push(inst.X)
+		e.push(inst.X)
+		e.emit("%s.eq", typ)
+		e.push(inst.Y)
+		e.push(inst.Y)
+		e.emit("%s.eq", typ)
+		e.emit("i32.and")
+	}
+	switch inst.Pred {
+	case enum.FPredFalse:
+		e.emit("i32.const 0")
+	case enum.FPredTrue:
+		e.emit("i32.const 1")
+	// Ordered comparisons are false if either operand is NaN, which is the
+	// semantics of WebAssembly comparisons (except ne).
+	case enum.FPredOEQ:
+		cmp("eq")
+	case enum.FPredOGT:
+		cmp("gt")
+	case enum.FPredOGE:
+		cmp("ge")
+	case enum.FPredOLT:
+		cmp("lt")
+	case enum.FPredOLE:
+		cmp("le")
+	case enum.FPredONE:
+		cmp("lt")
+		cmp("gt")
+		e.emit("i32.or")
+	case enum.FPredORD:
+		ord()
+	// Unordered comparisons are the negation of the inverse ordered
+	// comparison.
+	case enum.FPredUEQ:
+		cmp("lt")
+		cmp("gt")
+		e.emit("i32.or")
+		e.emit("i32.eqz")
+	case enum.FPredUGT:
+		cmp("le")
+		e.emit("i32.eqz")
+	case enum.FPredUGE:
+		cmp("lt")
+		e.emit("i32.eqz")
+	case enum.FPredULT:
+		cmp("ge")
+		e.emit("i32.eqz")
+	case enum.FPredULE:
+		cmp("gt")
+		e.emit("i32.eqz")
+	case enum.FPredUNE:
+		cmp("ne")
+	case enum.FPredUNO:
+		ord()
+		e.emit("i32.eqz")
+	default:
+		panic(fmt.Errorf("support for floating-point comparison predicate %v not yet implemented", inst.Pred))
+	}
+	e.def(inst)
+}
+
+// convert emits the conversion of from to the given type, and assigns the
+// result to the given instruction. The conversion is signed if signed is set.
+func (e *emitter) convert(inst value.Value, from value.Value, to types.Type, signed bool) {
+	fromType := from.Type()
+	switch {
+	case isIntLike(fromType) && isIntLike(to):
+		fromBits, toBits := bitSize(fromType), bitSize(to)
+		if signed && fromBits < toBits {
+			e.pushSigned(from)
+		} else {
+			e.push(from)
+		}
+		switch {
+		case fromBits <= 32 && toBits > 32:
+			if signed {
+				e.emit("i64.extend_i32_s")
+			} else {
+				e.emit("i64.extend_i32_u")
+			}
+		case fromBits > 32 && toBits <= 32:
+			e.emit("i32.wrap_i64")
+		}
+		if toBits < fromBits || signed {
+			e.mask(to)
+		}
+	case isIntLike(fromType) && types.IsFloat(to):
+		if bitSize(fromType) == bitSize(to) && !signed && isBitCast(inst) {
+			e.push(from)
+			e.emit("%s.reinterpret_%s", valType(to), valType(fromType))
+			break
+		}
+		if signed {
+			e.pushSigned(from)
+			e.emit("%s.convert_%s_s", valType(to), valType(fromType))
+		} else {
+			e.push(from)
+			e.emit("%s.convert_%s_u", valType(to), valType(fromType))
+		}
+	case types.IsFloat(fromType) && isIntLike(to):
+		e.push(from)
+		if bitSize(fromType) == bitSize(to) && !signed && isBitCast(inst) {
+			e.emit("%s.reinterpret_%s", valType(to), valType(fromType))
+			break
+		}
+		if signed {
+			e.emit("%s.trunc_%s_s", valType(to), valType(fromType))
+		} else {
+			e.emit("%s.trunc_%s_u", valType(to), valType(fromType))
+		}
+		e.mask(to)
+	case types.IsFloat(fromType) && types.IsFloat(to):
+		e.push(from)
+		switch f, t := valType(fromType), valType(to); {
+		case f == "f32" && t == "f64":
+			e.emit("f64.promote_f32")
+		case f == "f64" && t == "f32":
+			e.emit("f32.demote_f64")
+		}
+	default:
+		panic(fmt.Errorf("support for conversion from %v to %v not yet implemented", fromType, to))
+	}
+	e.def(inst)
+}
+
+// isBitCast reports whether the given value is a bitcast instruction.
+func isBitCast(v value.Value) bool {
+	_, ok := v.(*ir.InstBitCast)
+	return ok
+}
+
+// gep emits the address computation of a getelementptr with the given source
+// type and indices; the source address is on the stack.
+func (e *emitter) gep(srcType types.Type, indices []value.Value) {
+	elem := srcType
+	for i, index := range indices {
+		var size uint32
+		switch t := elem.(type) {
+		case *types.PointerType:
+			if i != 0 {
+				panic(fmt.Errorf("invalid getelementptr index into pointer type %v", t))
+			}
+			elem = t.ElemType
+			size = sizeOf(elem)
+		case *types.ArrayType:
+			elem = t.ElemType
+			size = sizeOf(elem)
+		case *types.VectorType:
+			elem = t.ElemType
+			size = sizeOf(elem)
+		case *types.StructType:
+			c, ok := index.(*constant.Int)
+			if !ok {
+				panic(fmt.Errorf("invalid non-constant getelementptr index into struct type %v", t))
+			}
+			field := int(c.X.Int64())
+			e.emit("i32.const %d", fieldOffset(t, field))
+			e.emit("i32.add")
+			elem = t.Fields[field]
+			continue
+		default:
+			panic(fmt.Errorf("support for getelementptr index into type %T not yet implemented", t))
+		}
+		if c, ok := index.(*constant.Int); ok {
+			if off := int32(c.X.Int64()) * int32(size); off != 0 {
+				e.emit("i32.const %d", off)
+				e.emit("i32.add")
+			}
+			continue
+		}
+		e.pushI32Signed(index)
+		e.emit("i32.const %d", size)
+		e.emit("i32.mul")
+		e.emit("i32.add")
+	}
+}
+
+// call emits the given call instruction.
+func (e *emitter) call(inst *ir.InstCall) {
+	sig := calleeSig(inst.Callee)
+	for i, arg := range inst.Args {
+		if i < len(sig.Params) {
+			e.push(arg)
+		}
+	}
+	if sig.Variadic {
+		e.varargs(inst.Args[len(sig.Params):])
+	}
+	if callee, ok := inst.Callee.(*ir.Function); ok {
+		e.emit("call %s", funcIdent(callee))
+	} else {
+		e.push(inst.Callee)
+		e.emit("call_indirect (type %s)", e.sigName(sig))
+	}
+	if !types.Equal(sig.RetType, types.Void) {
+		e.def(inst)
+	}
+}
+
+// varargs stores the given variadic arguments in a buffer on the stack, and
+// pushes its address.
+func (e *emitter) varargs(args []value.Value) {
+	var off uint32
+	var offs []uint32
+	for _, arg := range args {
+		off = align(off, alignOf(arg.Type()))
+		offs = append(offs, off)
+		off += sizeOf(arg.Type())
+	}
+	e.emit("global.get $__stack_pointer")
+	e.emit("i32.const %d", align(off, 16))
+	e.emit("i32.sub")
+	e.emit("global.set $__stack_pointer")
+	for i, arg := range args {
+		e.emit("global.get $__stack_pointer")
+		e.push(arg)
+		e.emit("%s offset=%d", storeOp(arg.Type()), offs[i])
+	}
+	e.emit("global.get $__stack_pointer")
+}
+
+// sigName returns the type name of the given function signature, as used by
+// indirect calls.
+func (e *emitter) sigName(sig *types.FuncType) string {
+	s := funcSig(sig)
+	name, ok := e.sigNames[s]
+	if !ok {
+		name = fmt.Sprintf("$sig%d", len(e.sigs))
+		e.sigNames[s] = name
+		e.sigs = append(e.sigs, s)
+	}
+	return name
+}
+
+// ### [ Values ] ##############################################################
+
+// push emits code to push the given value onto the stack.
+func (e *emitter) push(v value.Value) {
+	if name, ok := e.locals[v]; ok {
+		e.emit("local.get %s", name)
+		return
+	}
+	c, ok := v.(constant.Constant)
+	if !ok {
+		panic(fmt.Errorf("unable to locate local variable of value %v", v.Ident()))
+	}
+	typ := valType(c.Type())
+	switch c := c.(type) {
+	case *constant.Float:
+		e.emit("%s.const %s", typ, formatFloat(c))
+	case *constant.ZeroInitializer, *constant.Undef:
+		e.emit("%s.const 0", typ)
+	default:
+		x := e.eval(c)
+		if typ == "i64" {
+			e.emit("i64.const %d", int64(x))
+		} else {
+			e.emit("i32.const %d", uint32(x)&uint32(mask(bitSize(c.Type()))))
+		}
+	}
+}
+
+// pushSigned emits code to push the given integer value onto the stack,
+// sign-extended to the bit size of its WebAssembly value type.
+func (e *emitter) pushSigned(v value.Value) {
+	e.push(v)
+	if bits := bitSize(v.Type()); bits < 32 && types.IsInt(v.Type()) {
+		e.emit("i32.const %d", 32-bits)
+		e.emit("i32.shl")
+		e.emit("i32.const %d", 32-bits)
+		e.emit("i32.shr_s")
+	}
+}
+
+// pushI32 emits code to push the given unsigned integer value onto the stack,
+// converted to i32.
+func (e *emitter) pushI32(v value.Value) {
+	e.push(v)
+	if valType(v.Type()) == "i64" {
+		e.emit("i32.wrap_i64")
+	}
+}
+
+// pushI32Signed emits code to push the given signed integer value onto the
+// stack, converted to i32.
+func (e *emitter) pushI32Signed(v value.Value) {
+	e.pushSigned(v)
+	if valType(v.Type()) == "i64" {
+		e.emit("i32.wrap_i64")
+	}
+}
+
+// mask emits code to truncate the integer on top of the stack to the bit size
+// of the given type.
+func (e *emitter) mask(t types.Type) {
+	if !types.IsInt(t) {
+		return
+	}
+	if bits := bitSize(t); bits < 32 {
+		e.emit("i32.const %d", mask(bits))
+		e.emit("i32.and")
+	}
+}
+
+// def emits code to assign the value on top of the stack to the local variable
+// of the given value.
+func (e *emitter) def(v value.Value) {
+	e.emit("local.set %s", e.locals[v])
+}
+
+// eval returns the value of the given scalar constant, with addresses of global
+// variables and functions resolved to linear memory offsets and function table
+// indices respectively.
+func (e *emitter) eval(c constant.Constant) uint64 {
+	switch c := c.(type) {
+	case *constant.Int:
+		if c.X.Sign() < 0 {
+			return uint64(c.X.Int64())
+		}
+		return c.X.Uint64()
+	case *constant.Float:
+		if valType(c.Typ) == "f32" {
+			x, _ := c.X.Float32()
+			return uint64(math.Float32bits(x))
+		}
+		x, _ := c.X.Float64()
+		return math.Float64bits(x)
+	case *constant.Null, *constant.ZeroInitializer, *constant.Undef:
+		return 0
+	case *ir.Global:
+		addr, ok := e.globalAddrs[c]
+		if !ok {
+			panic(fmt.Errorf("unable to locate global variable %v", c.Ident()))
+		}
+		return uint64(addr)
+	case *ir.Function:
+		index, ok := e.funcIndices[c.Name()]
+		if !ok {
+			panic(fmt.Errorf("unable to locate function %v", c.Ident()))
+		}
+		return uint64(index)
+	case *constant.ExprBitCast:
+		return e.eval(c.From)
+	case *constant.ExprIntToPtr:
+		return e.eval(c.From)
+	case *constant.ExprPtrToInt:
+		return e.eval(c.From)
+	case *constant.ExprGetElementPtr:
+		addr := e.eval(c.Src)
+		elem := c.Src.Type()
+		for i, index := range c.Indices {
+			x := int64(e.eval(index.Index))
+			switch t := elem.(type) {
+			case *types.PointerType:
+				if i != 0 {
+					panic(fmt.Errorf("invalid getelementptr index into pointer type %v", t))
+				}
+				elem = t.ElemType
+				addr += uint64(x * int64(sizeOf(elem)))
+			case *types.ArrayType:
+				elem = t.ElemType
+				addr += uint64(x * int64(sizeOf(elem)))
+			case *types.StructType:
+				addr += uint64(fieldOffset(t, int(x)))
+				elem = t.Fields[x]
+			default:
+				panic(fmt.Errorf("support for getelementptr index into type %T not yet implemented", t))
+			}
+		}
+		return uint64(uint32(addr))
+	default:
+		panic(fmt.Errorf("support for constant %T not yet implemented", c))
+	}
+}
+
+// encodeConst stores the little-endian memory representation of the given
+// constant in buf.
+func (e *emitter) encodeConst(buf []byte, c constant.Constant) {
+	switch c := c.(type) {
+	case *constant.CharArray:
+		copy(buf, c.X)
+	case *constant.Array:
+		size := sizeOf(c.Typ.ElemType)
+		for i, elem := range c.Elems {
+			e.encodeConst(buf[uint32(i)*size:], elem)
+		}
+	case *constant.Struct:
+		for i, field := range c.Fields {
+			e.encodeConst(buf[fieldOffset(c.Typ, i):], field)
+		}
+	case *constant.ZeroInitializer, *constant.Undef:
+		// zero initialized.
+	default:
+		x := e.eval(c)
+		for i := uint32(0); i < sizeOf(c.Type()); i++ {
+			buf[i] = byte(x >> (8 * i))
+		}
+	}
+}
+
+// ### [ Types ] ###############################################################
+
+// valType returns the WebAssembly value type of the given LLVM IR type.
+func valType(t types.Type) string {
+	switch t := t.(type) {
+	case *types.IntType:
+		switch {
+		case t.BitSize <= 32:
+			return "i32"
+		case t.BitSize <= 64:
+			return "i64"
+		}
+	case *types.PointerType:
+		return "i32"
+	case *types.FloatType:
+		switch t.Kind {
+		case types.FloatKindFloat:
+			return "f32"
+		case types.FloatKindDouble, types.FloatKindX86FP80:
+			return "f64"
+		}
+	}
+	panic(fmt.Errorf("support for type %v not yet implemented", t))
+}
+
+// funcSig returns the WebAssembly parameter and result types of the given
+// function signature.
+func funcSig(sig *types.FuncType) string {
+	s := &strings.Builder{}
+	for _, param := range sig.Params {
+		fmt.Fprintf(s, " (param %s)", valType(param))
+	}
+	if sig.Variadic {
+		s.WriteString(" (param i32)")
+	}
+	if !types.Equal(sig.RetType, types.Void) {
+		fmt.Fprintf(s, " (result %s)", valType(sig.RetType))
+	}
+	return s.String()
+}
+
+// calleeSig returns the function signature of the given callee.
+func calleeSig(callee value.Value) *types.FuncType {
+	if t, ok := callee.Type().(*types.PointerType); ok {
+		if sig, ok := t.ElemType.(*types.FuncType); ok {
+			return sig
+		}
+	}
+	panic(fmt.Errorf("invalid callee type; expected pointer to function type, got %v", callee.Type()))
+}
+
+// loadOp returns the WebAssembly load instruction of the given type.
+func loadOp(t types.Type) string {
+	switch sizeOf(t) {
+	case 1:
+		if types.IsInt(t) {
+			return "i32.load8_u"
+		}
+	case 2:
+		if types.IsInt(t) {
+			return "i32.load16_u"
+		}
+	case 4, 8:
+		return valType(t) + ".load"
+	}
+	panic(fmt.Errorf("support for load of type %v not yet implemented", t))
+}
+
+// storeOp returns the WebAssembly store instruction of the given type.
+func storeOp(t types.Type) string {
+	switch sizeOf(t) {
+	case 1:
+		if types.IsInt(t) {
+			return "i32.store8"
+		}
+	case 2:
+		if types.IsInt(t) {
+			return "i32.store16"
+		}
+	case 4, 8:
+		return valType(t) + ".store"
+	}
+	panic(fmt.Errorf("support for store of type %v not yet implemented", t))
+}
+
+// sizeOf returns the size in bytes of the given type in linear memory.
+func sizeOf(t types.Type) uint32 {
+	switch t := t.(type) {
+	case *types.IntType:
+		switch {
+		case t.BitSize <= 8:
+			return 1
+		case t.BitSize <= 16:
+			return 2
+		case t.BitSize <= 32:
+			return 4
+		case t.BitSize <= 64:
+			return 8
+		}
+	case *types.PointerType:
+		return 4
+	case *types.FloatType:
+		if valType(t) == "f32" {
+			return 4
+		}
+		return 8
+	case *types.ArrayType:
+		return uint32(t.Len) * sizeOf(t.ElemType)
+	case *types.VectorType:
+		return uint32(t.Len) * sizeOf(t.ElemType)
+	case *types.StructType:
+		if len(t.Fields) == 0 {
+			return 0
+		}
+		last := len(t.Fields) - 1
+		return align(fieldOffset(t, last)+sizeOf(t.Fields[last]), alignOf(t))
+	}
+	panic(fmt.Errorf("support for type %v not yet implemented", t))
+}
+
+// alignOf returns the alignment in bytes of the given type in linear memory.
+func alignOf(t types.Type) uint32 {
+	switch t := t.(type) {
+	case *types.ArrayType:
+		return alignOf(t.ElemType)
+	case *types.VectorType:
+		return alignOf(t.ElemType)
+	case *types.StructType:
+		if t.Packed {
+			return 1
+		}
+		a := uint32(1)
+		for _, field := range t.Fields {
+			a = max(a, alignOf(field))
+		}
+		return a
+	default:
+		return sizeOf(t)
+	}
+}
+
+// fieldOffset returns the offset in bytes of the given field of the struct
+// type.
+func fieldOffset(t *types.StructType, field int) uint32 {
+	var off uint32
+	for i, f := range t.Fields {
+		if !t.Packed {
+			off = align(off, alignOf(f))
+		}
+		if i == field {
+			return off
+		}
+		off += sizeOf(f)
+	}
+	panic(fmt.Errorf("invalid field index %d of struct type %v", field, t))
+}
+
+// bitSize returns the size in bits of the given scalar type.
+func bitSize(t types.Type) uint64 {
+	if t, ok := t.(*types.IntType); ok {
+		return t.BitSize
+	}
+	return uint64(sizeOf(t)) * 8
+}
+
+// isIntLike reports whether the given type is represented as a WebAssembly
+// integer (i.e. integer or pointer type).
+func isIntLike(t types.Type) bool {
+	return types.IsInt(t) || types.IsPointer(t)
+}
+
+// ### [ Helper functions ] ####################################################
+
+// emit emits the given WebAssembly instruction of the current function.
+func (e *emitter) emit(format string, args ...interface{}) {
+	e.buf.WriteString(strings.Repeat("  ", e.indent))
+	fmt.Fprintf(e.buf, format, args...)
+	e.buf.WriteString("\n")
+}
+
+// funcIdent returns the WebAssembly identifier of the given function.
+func funcIdent(f *ir.Function) string {
+	return "$" + sanitize(f.Name())
+}
+
+// blockName returns a descriptive name of the given basic block with index i.
+func blockName(block *ir.BasicBlock, i int) string {
+	if name := block.Name(); len(name) > 0 {
+		return name
+	}
+	return fmt.Sprintf("block %d", i)
+}
+
+// sanitize returns a valid WebAssembly identifier of the given name, replacing
+// invalid characters with underscores.
+func sanitize(name string) string {
+	const valid = "!#$%&'*+-./:<=>?@\\^_`|~"
+	return strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+			return r
+		case r < 0x80 && strings.ContainsRune(valid, r):
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// quote returns the WebAssembly string literal of the given data.
+func quote(data []byte) string {
+	s := &strings.Builder{}
+	s.WriteByte('"')
+	for _, b := range data {
+		if b >= 0x20 && b < 0x7F && b != '"' && b != '\\' {
+			s.WriteByte(b)
+			continue
+		}
+		fmt.Fprintf(s, "\\%02x", b)
+	}
+	s.WriteByte('"')
+	return s.String()
+}
+
+// formatFloat returns the WebAssembly literal of the given floating-point
+// constant.
+func formatFloat(c *constant.Float) string {
+	if c.NaN {
+		return "nan"
+	}
+	if c.X.IsInf() {
+		if c.X.Sign() < 0 {
+			return "-inf"
+		}
+		return "inf"
+	}
+	prec := 64
+	if valType(c.Typ) == "f32" {
+		prec = 32
+	}
+	x, _ := c.X.Float64()
+	return strconv.FormatFloat(x, 'g', -1, prec)
+}
+
+// isZero reports whether the given data is zero-initialized.
+func isZero(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// mask returns the bit mask of the given bit size.
+func mask(bits uint64) uint64 {
+	if bits >= 64 {
+		return math.MaxUint64
+	}
+	return 1<<bits - 1
+}
+
+// align returns x rounded up to the given alignment.
+func align(x, a uint32) uint32 {
+	if a <= 1 {
+		return x
+	}
+	return (x + a - 1) / a * a
+}
+
+// max returns the maximum of x and y.
+func max(x, y uint32) uint32 {
+	if x > y {
+		return x
+	}
+	return y
+}
@@ -12,8 +12,10 @@ require (
 	github.com/mewrev/pe v0.0.0-20181024063030-8f6d1d7d219c
 	github.com/pkg/errors v0.8.0
 	github.com/unixpickle/mips32 v0.0.0-20160406235431-dee2e89bbdfd
+	go.etcd.io/bbolt v1.3.3
 	golang.org/x/arch v0.0.0-20181203225421-5a4828bb7045
 	golang.org/x/exp v0.0.0-20181206211736-68cc7b1f272e // indirect
+	golang.org/x/text v0.3.0
 	golang.org/x/tools v0.0.0-20181207222222-4c874b978acb // indirect
 	gonum.org/v1/gonum v0.0.0-20181208091643-b71a28080e0f
 	gonum.org/v1/netlib v0.0.0-20181029234149-ec6d1f5cefe6 // indirect
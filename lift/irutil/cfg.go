@@ -0,0 +1,68 @@
+package irutil
+
+import (
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/value"
+)
+
+// Succs returns the successor basic blocks of the given terminator.
+func Succs(term ir.Terminator) []*ir.BasicBlock {
+	switch term := term.(type) {
+	case *ir.TermBr:
+		return []*ir.BasicBlock{term.Target}
+	case *ir.TermCondBr:
+		return []*ir.BasicBlock{term.TargetTrue, term.TargetFalse}
+	case *ir.TermSwitch:
+		succs := []*ir.BasicBlock{term.TargetDefault}
+		for _, c := range term.Cases {
+			succs = append(succs, c.Target)
+		}
+		return succs
+	case *ir.TermIndirectBr:
+		return term.ValidTargets
+	}
+	return nil
+}
+
+// Preds returns the predecessor basic blocks of each basic block of the given
+// function. Predecessors are listed once per incoming edge.
+func Preds(f *ir.Function) map[*ir.BasicBlock][]*ir.BasicBlock {
+	preds := make(map[*ir.BasicBlock][]*ir.BasicBlock)
+	for _, block := range f.Blocks {
+		for _, succ := range Succs(block.Term) {
+			preds[succ] = append(preds[succ], block)
+		}
+	}
+	return preds
+}
+
+// ReplaceUses replaces the uses of values within the given function based on
+// the specified replacement map. Replacements are applied transitively; i.e.
+// if x is replaced by y and y by z, uses of x are replaced by z.
+func ReplaceUses(f *ir.Function, repl map[value.Value]value.Value) {
+	if len(repl) == 0 {
+		return
+	}
+	replace := func(operands []*value.Value) {
+		for _, operand := range operands {
+			v, ok := repl[*operand]
+			if !ok {
+				continue
+			}
+			for {
+				w, ok := repl[v]
+				if !ok {
+					break
+				}
+				v = w
+			}
+			*operand = v
+		}
+	}
+	for _, block := range f.Blocks {
+		for _, inst := range block.Insts {
+			replace(Operands(inst))
+		}
+		replace(Operands(block.Term))
+	}
+}
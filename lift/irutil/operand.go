@@ -0,0 +1,137 @@
+// Package irutil provides utility functions for the analysis and
+// transformation of lifted LLVM IR.
+package irutil
+
+import (
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/value"
+)
+
+// Operands returns pointers to the operands of the given instruction or
+// terminator; which may be used to replace operands.
+func Operands(inst interface{}) []*value.Value {
+	switch inst := inst.(type) {
+	// Binary instructions.
+	case *ir.InstAdd:
+		return []*value.Value{&inst.X, &inst.Y}
+	case *ir.InstFAdd:
+		return []*value.Value{&inst.X, &inst.Y}
+	case *ir.InstSub:
+		return []*value.Value{&inst.X, &inst.Y}
+	case *ir.InstFSub:
+		return []*value.Value{&inst.X, &inst.Y}
+	case *ir.InstMul:
+		return []*value.Value{&inst.X, &inst.Y}
+	case *ir.InstFMul:
+		return []*value.Value{&inst.X, &inst.Y}
+	case *ir.InstUDiv:
+		return []*value.Value{&inst.X, &inst.Y}
+	case *ir.InstSDiv:
+		return []*value.Value{&inst.X, &inst.Y}
+	case *ir.InstFDiv:
+		return []*value.Value{&inst.X, &inst.Y}
+	case *ir.InstURem:
+		return []*value.Value{&inst.X, &inst.Y}
+	case *ir.InstSRem:
+		return []*value.Value{&inst.X, &inst.Y}
+	case *ir.InstFRem:
+		return []*value.Value{&inst.X, &inst.Y}
+	// Bitwise instructions.
+	case *ir.InstShl:
+		return []*value.Value{&inst.X, &inst.Y}
+	case *ir.InstLShr:
+		return []*value.Value{&inst.X, &inst.Y}
+	case *ir.InstAShr:
+		return []*value.Value{&inst.X, &inst.Y}
+	case *ir.InstAnd:
+		return []*value.Value{&inst.X, &inst.Y}
+	case *ir.InstOr:
+		return []*value.Value{&inst.X, &inst.Y}
+	case *ir.InstXor:
+		return []*value.Value{&inst.X, &inst.Y}
+	// Vector and aggregate instructions.
+	case *ir.InstExtractElement:
+		return []*value.Value{&inst.X, &inst.Index}
+	case *ir.InstInsertElement:
+		return []*value.Value{&inst.X, &inst.Elem, &inst.Index}
+	case *ir.InstExtractValue:
+		return []*value.Value{&inst.X}
+	case *ir.InstInsertValue:
+		return []*value.Value{&inst.X, &inst.Elem}
+	// Memory instructions.
+	case *ir.InstAlloca:
+		if inst.NElems != nil {
+			return []*value.Value{&inst.NElems}
+		}
+		return nil
+	case *ir.InstLoad:
+		return []*value.Value{&inst.Src}
+	case *ir.InstStore:
+		return []*value.Value{&inst.Src, &inst.Dst}
+	case *ir.InstGetElementPtr:
+		ops := []*value.Value{&inst.Src}
+		for i := range inst.Indices {
+			ops = append(ops, &inst.Indices[i])
+		}
+		return ops
+	// Conversion instructions.
+	case *ir.InstTrunc:
+		return []*value.Value{&inst.From}
+	case *ir.InstZExt:
+		return []*value.Value{&inst.From}
+	case *ir.InstSExt:
+		return []*value.Value{&inst.From}
+	case *ir.InstFPTrunc:
+		return []*value.Value{&inst.From}
+	case *ir.InstFPExt:
+		return []*value.Value{&inst.From}
+	case *ir.InstFPToUI:
+		return []*value.Value{&inst.From}
+	case *ir.InstFPToSI:
+		return []*value.Value{&inst.From}
+	case *ir.InstUIToFP:
+		return []*value.Value{&inst.From}
+	case *ir.InstSIToFP:
+		return []*value.Value{&inst.From}
+	case *ir.InstPtrToInt:
+		return []*value.Value{&inst.From}
+	case *ir.InstIntToPtr:
+		return []*value.Value{&inst.From}
+	case *ir.InstBitCast:
+		return []*value.Value{&inst.From}
+	case *ir.InstAddrSpaceCast:
+		return []*value.Value{&inst.From}
+	// Other instructions.
+	case *ir.InstICmp:
+		return []*value.Value{&inst.X, &inst.Y}
+	case *ir.InstFCmp:
+		return []*value.Value{&inst.X, &inst.Y}
+	case *ir.InstPhi:
+		var ops []*value.Value
+		for _, inc := range inst.Incs {
+			ops = append(ops, &inc.X)
+		}
+		return ops
+	case *ir.InstSelect:
+		return []*value.Value{&inst.Cond, &inst.X, &inst.Y}
+	case *ir.InstCall:
+		ops := []*value.Value{&inst.Callee}
+		for i := range inst.Args {
+			ops = append(ops, &inst.Args[i])
+		}
+		return ops
+	// Terminators.
+	case *ir.TermRet:
+		if inst.X != nil {
+			return []*value.Value{&inst.X}
+		}
+		return nil
+	case *ir.TermCondBr:
+		return []*value.Value{&inst.Cond}
+	case *ir.TermSwitch:
+		return []*value.Value{&inst.X}
+	case *ir.TermIndirectBr:
+		return []*value.Value{&inst.Addr}
+	}
+	return nil
+}
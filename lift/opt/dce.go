@@ -0,0 +1,138 @@
+package opt
+
+import (
+	"github.com/decomp/exp/lift/irutil"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/value"
+)
+
+// deadCode removes the instructions of the given function without side
+// effects whose values are not used, including cycles of phi instructions only
+// used by each other. The boolean return value indicates whether the function
+// was changed.
+func deadCode(f *ir.Function) bool {
+	live := make(map[ir.Instruction]bool)
+	var work []ir.Instruction
+	mark := func(operands []*value.Value) {
+		for _, operand := range operands {
+			if inst, ok := (*operand).(ir.Instruction); ok && !live[inst] {
+				live[inst] = true
+				work = append(work, inst)
+			}
+		}
+	}
+	for _, block := range f.Blocks {
+		for _, inst := range block.Insts {
+			if !isPure(inst) {
+				live[inst] = true
+				work = append(work, inst)
+			}
+		}
+		mark(irutil.Operands(block.Term))
+	}
+	for len(work) > 0 {
+		inst := work[len(work)-1]
+		work = work[:len(work)-1]
+		mark(irutil.Operands(inst))
+	}
+	changed := false
+	for _, block := range f.Blocks {
+		insts := block.Insts[:0]
+		for _, inst := range block.Insts {
+			if !live[inst] {
+				changed = true
+				continue
+			}
+			insts = append(insts, inst)
+		}
+		block.Insts = insts
+	}
+	return changed
+}
+
+// deadStores removes the stores of the given function which are overwritten
+// within the same basic block before being read. The boolean return value
+// indicates whether the function was changed.
+//
+// Memory is read by loads and calls; and distinct local and global variables
+// are assumed not to alias, while any other pointer may alias any variable.
+func deadStores(f *ir.Function) bool {
+	changed := false
+	for _, block := range f.Blocks {
+		dead := make(map[ir.Instruction]bool)
+		// Pending stores not yet read, by destination.
+		pending := make(map[value.Value]*ir.InstStore)
+		for _, inst := range block.Insts {
+			switch inst := inst.(type) {
+			case *ir.InstStore:
+				if prev, ok := pending[inst.Dst]; ok {
+					dead[prev] = true
+				}
+				pending[inst.Dst] = inst
+			case *ir.InstLoad:
+				if !isVariable(inst.Src) {
+					pending = make(map[value.Value]*ir.InstStore)
+					break
+				}
+				for dst := range pending {
+					if dst == inst.Src || !isVariable(dst) {
+						delete(pending, dst)
+					}
+				}
+			default:
+				if !isPure(inst) {
+					pending = make(map[value.Value]*ir.InstStore)
+				}
+			}
+		}
+		if len(dead) == 0 {
+			continue
+		}
+		changed = true
+		insts := block.Insts[:0]
+		for _, inst := range block.Insts {
+			if !dead[inst] {
+				insts = append(insts, inst)
+			}
+		}
+		block.Insts = insts
+	}
+	return changed
+}
+
+// ### [ Helper functions ] ####################################################
+
+// isPure reports whether the given instruction is free of side effects, and
+// may thus be removed if unused.
+func isPure(inst ir.Instruction) bool {
+	switch inst.(type) {
+	// Binary and bitwise instructions.
+	case *ir.InstAdd, *ir.InstFAdd, *ir.InstSub, *ir.InstFSub, *ir.InstMul, *ir.InstFMul, *ir.InstUDiv, *ir.InstSDiv, *ir.InstFDiv, *ir.InstURem, *ir.InstSRem, *ir.InstFRem:
+		return true
+	case *ir.InstShl, *ir.InstLShr, *ir.InstAShr, *ir.InstAnd, *ir.InstOr, *ir.InstXor:
+		return true
+	// Vector and aggregate instructions.
+	case *ir.InstExtractElement, *ir.InstInsertElement, *ir.InstExtractValue, *ir.InstInsertValue:
+		return true
+	// Memory instructions.
+	case *ir.InstAlloca, *ir.InstLoad, *ir.InstGetElementPtr:
+		return true
+	// Conversion instructions.
+	case *ir.InstTrunc, *ir.InstZExt, *ir.InstSExt, *ir.InstFPTrunc, *ir.InstFPExt, *ir.InstFPToUI, *ir.InstFPToSI, *ir.InstUIToFP, *ir.InstSIToFP, *ir.InstPtrToInt, *ir.InstIntToPtr, *ir.InstBitCast, *ir.InstAddrSpaceCast:
+		return true
+	// Other instructions.
+	case *ir.InstICmp, *ir.InstFCmp, *ir.InstPhi, *ir.InstSelect:
+		return true
+	}
+	// Stores, calls, atomic instructions, fences, etc.
+	return false
+}
+
+// isVariable reports whether the given pointer is a local or global variable.
+func isVariable(ptr value.Value) bool {
+	switch ptr.(type) {
+	case *ir.InstAlloca, *ir.Global:
+		return true
+	}
+	return false
+}
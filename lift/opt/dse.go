@@ -0,0 +1,193 @@
+package opt
+
+import (
+	"github.com/decomp/exp/lift/irutil"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/value"
+)
+
+// deadVarStores removes the stores to local and global variables of the given
+// function (e.g. the registers and status flags of lifted functions) which are
+// overwritten before being read along every path of the function. The boolean
+// return value indicates whether the function was changed.
+//
+// Stores are removed based on a backward liveness analysis of variables, where
+// loads read the variable loaded from, and memory accesses through any other
+// pointer, calls and other instructions with side effects read every variable
+// which may be accessed outside of the function; i.e. global variables and
+// local variables whose address escape. Global variables are live at returns,
+// as callers may read the registers and status flags defined by the callee,
+// while local variables are dead once the function returns.
+func deadVarStores(f *ir.Function) bool {
+	escaping := escapingVars(f)
+	// Variables live at the start of each basic block.
+	liveIn := make(map[*ir.BasicBlock]map[value.Value]bool)
+	for _, block := range f.Blocks {
+		liveIn[block] = make(map[value.Value]bool)
+	}
+	// transfer propagates the variables live at the end of the given basic
+	// block to the start of the basic block, invoking dead for each store to a
+	// variable not live after the store.
+	transfer := func(block *ir.BasicBlock, dead func(store *ir.InstStore)) map[value.Value]bool {
+		live := liveOut(block, liveIn, escaping)
+		for i := len(block.Insts) - 1; i >= 0; i-- {
+			switch inst := block.Insts[i].(type) {
+			case *ir.InstStore:
+				v, ok := baseVar(inst.Dst)
+				if !ok {
+					// Stores through other pointers may overwrite part of any
+					// escaping variable, but do not read them.
+					break
+				}
+				if !live[v] {
+					if dead != nil {
+						dead(inst)
+					}
+					break
+				}
+				if v == inst.Dst {
+					// Overwrites the entire variable; stores through bitcasts of
+					// the variable may overwrite only part of it.
+					delete(live, v)
+				}
+			case *ir.InstLoad:
+				if v, ok := baseVar(inst.Src); ok {
+					live[v] = true
+					break
+				}
+				for v := range escaping {
+					live[v] = true
+				}
+			default:
+				if !isPure(inst) {
+					for v := range escaping {
+						live[v] = true
+					}
+				}
+			}
+		}
+		return live
+	}
+	// Iterate until fixed point; the sets of live variables only grow.
+	for changed := true; changed; {
+		changed = false
+		for i := len(f.Blocks) - 1; i >= 0; i-- {
+			block := f.Blocks[i]
+			live := transfer(block, nil)
+			if len(live) != len(liveIn[block]) {
+				liveIn[block] = live
+				changed = true
+			}
+		}
+	}
+	dead := make(map[ir.Instruction]bool)
+	for _, block := range f.Blocks {
+		transfer(block, func(store *ir.InstStore) {
+			dead[store] = true
+		})
+	}
+	if len(dead) == 0 {
+		return false
+	}
+	for _, block := range f.Blocks {
+		insts := block.Insts[:0]
+		for _, inst := range block.Insts {
+			if !dead[inst] {
+				insts = append(insts, inst)
+			}
+		}
+		block.Insts = insts
+	}
+	dbg.Printf("removed %d dead stores to variables of function %q", len(dead), f.Name())
+	return true
+}
+
+// ### [ Helper functions ] ####################################################
+
+// liveOut returns the variables live at the end of the given basic block, based
+// on the variables live at the start of its successors.
+func liveOut(block *ir.BasicBlock, liveIn map[*ir.BasicBlock]map[value.Value]bool, escaping map[value.Value]bool) map[value.Value]bool {
+	live := make(map[value.Value]bool)
+	switch block.Term.(type) {
+	case *ir.TermBr, *ir.TermCondBr, *ir.TermSwitch, *ir.TermIndirectBr:
+		for _, succ := range irutil.Succs(block.Term) {
+			for v := range liveIn[succ] {
+				live[v] = true
+			}
+		}
+	case *ir.TermRet, *ir.TermUnreachable:
+		// Global variables may be read by the caller.
+		for v := range escaping {
+			if _, ok := v.(*ir.Global); ok {
+				live[v] = true
+			}
+		}
+	default:
+		// Terminators with side effects (e.g. invoke).
+		for v := range escaping {
+			live[v] = true
+		}
+		for _, succ := range irutil.Succs(block.Term) {
+			for v := range liveIn[succ] {
+				live[v] = true
+			}
+		}
+	}
+	return live
+}
+
+// escapingVars returns the variables of the given function which may be
+// accessed outside of the function; i.e. global variables accessed by the
+// function, and local variables whose address escape (e.g. passed to calls).
+func escapingVars(f *ir.Function) map[value.Value]bool {
+	escaping := make(map[value.Value]bool)
+	check := func(inst interface{}) {
+		for _, operand := range irutil.Operands(inst) {
+			v, ok := baseVar(*operand)
+			if !ok {
+				continue
+			}
+			if _, ok := v.(*ir.Global); ok {
+				escaping[v] = true
+				continue
+			}
+			switch inst := inst.(type) {
+			case *ir.InstLoad:
+				// Source of load.
+				continue
+			case *ir.InstStore:
+				if operand == &inst.Dst {
+					// Destination of store.
+					continue
+				}
+			case *ir.InstBitCast:
+				// Uses of the bitcast are checked separately.
+				continue
+			}
+			escaping[v] = true
+		}
+	}
+	for _, block := range f.Blocks {
+		for _, inst := range block.Insts {
+			check(inst)
+		}
+		check(block.Term)
+	}
+	return escaping
+}
+
+// baseVar returns the local or global variable pointed to by the given pointer,
+// looking through bitcasts. The boolean return value indicates success.
+func baseVar(ptr value.Value) (value.Value, bool) {
+	for {
+		switch p := ptr.(type) {
+		case *ir.InstBitCast:
+			ptr = p.From
+		case *constant.ExprBitCast:
+			ptr = p.From
+		default:
+			return ptr, isVariable(ptr)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+package opt
+
+import (
+	"testing"
+
+	"github.com/llir/llvm/asm"
+)
+
+func TestDeadVarStores(t *testing.T) {
+	golden := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "overwritten local",
+			in: `
+define i32 @f() {
+entry:
+	%x = alloca i32
+	store i32 1, i32* %x
+	store i32 2, i32* %x
+	%v = load i32, i32* %x
+	ret i32 %v
+}`,
+			want: `
+define i32 @f() {
+entry:
+	%x = alloca i32
+	store i32 2, i32* %x
+	%v = load i32, i32* %x
+	ret i32 %v
+}`,
+		},
+		{
+			name: "local dead at return",
+			in: `
+define void @f() {
+entry:
+	%x = alloca i32
+	store i32 1, i32* %x
+	ret void
+}`,
+			want: `
+define void @f() {
+entry:
+	%x = alloca i32
+	ret void
+}`,
+		},
+		{
+			name: "global live at return",
+			in: `
+@g = global i32 0
+
+define void @f() {
+entry:
+	store i32 1, i32* @g
+	store i32 2, i32* @g
+	ret void
+}`,
+			want: `
+@g = global i32 0
+
+define void @f() {
+entry:
+	store i32 2, i32* @g
+	ret void
+}`,
+		},
+		{
+			name: "global overwritten along every path",
+			in: `
+@g = global i32 0
+
+define void @f(i1 %c) {
+entry:
+	store i32 1, i32* @g
+	br i1 %c, label %a, label %b
+
+a:
+	store i32 2, i32* @g
+	ret void
+
+b:
+	store i32 3, i32* @g
+	ret void
+}`,
+			want: `
+@g = global i32 0
+
+define void @f(i1 %c) {
+entry:
+	br i1 %c, label %a, label %b
+
+a:
+	store i32 2, i32* @g
+	ret void
+
+b:
+	store i32 3, i32* @g
+	ret void
+}`,
+		},
+		{
+			name: "global read along some path",
+			in: `
+@g = global i32 0
+
+define i32 @f(i1 %c) {
+entry:
+	store i32 1, i32* @g
+	br i1 %c, label %a, label %b
+
+a:
+	store i32 2, i32* @g
+	ret i32 0
+
+b:
+	%v = load i32, i32* @g
+	ret i32 %v
+}`,
+			want: `
+@g = global i32 0
+
+define i32 @f(i1 %c) {
+entry:
+	store i32 1, i32* @g
+	br i1 %c, label %a, label %b
+
+a:
+	store i32 2, i32* @g
+	ret i32 0
+
+b:
+	%v = load i32, i32* @g
+	ret i32 %v
+}`,
+		},
+		{
+			name: "global read through loop",
+			in: `
+@g = global i32 0
+
+define void @f(i1 %c) {
+entry:
+	store i32 1, i32* @g
+	br label %loop
+
+loop:
+	%v = load i32, i32* @g
+	store i32 %v, i32* @g
+	br i1 %c, label %loop, label %exit
+
+exit:
+	ret void
+}`,
+			want: `
+@g = global i32 0
+
+define void @f(i1 %c) {
+entry:
+	store i32 1, i32* @g
+	br label %loop
+
+loop:
+	%v = load i32, i32* @g
+	store i32 %v, i32* @g
+	br i1 %c, label %loop, label %exit
+
+exit:
+	ret void
+}`,
+		},
+		{
+			name: "global read by call",
+			in: `
+@g = global i32 0
+
+declare void @h()
+
+define void @f() {
+entry:
+	store i32 1, i32* @g
+	call void @h()
+	store i32 2, i32* @g
+	ret void
+}`,
+			want: `
+@g = global i32 0
+
+declare void @h()
+
+define void @f() {
+entry:
+	store i32 1, i32* @g
+	call void @h()
+	store i32 2, i32* @g
+	ret void
+}`,
+		},
+		{
+			name: "local not escaping across call",
+			in: `
+declare void @h()
+
+define i32 @f() {
+entry:
+	%x = alloca i32
+	store i32 1, i32* %x
+	call void @h()
+	store i32 2, i32* %x
+	%v = load i32, i32* %x
+	ret i32 %v
+}`,
+			want: `
+declare void @h()
+
+define i32 @f() {
+entry:
+	%x = alloca i32
+	call void @h()
+	store i32 2, i32* %x
+	%v = load i32, i32* %x
+	ret i32 %v
+}`,
+		},
+		{
+			name: "local escaping through call",
+			in: `
+declare void @h(i32*)
+
+define i32 @f() {
+entry:
+	%x = alloca i32
+	store i32 1, i32* %x
+	call void @h(i32* %x)
+	store i32 2, i32* %x
+	%v = load i32, i32* %x
+	ret i32 %v
+}`,
+			want: `
+declare void @h(i32*)
+
+define i32 @f() {
+entry:
+	%x = alloca i32
+	store i32 1, i32* %x
+	call void @h(i32* %x)
+	store i32 2, i32* %x
+	%v = load i32, i32* %x
+	ret i32 %v
+}`,
+		},
+		{
+			name: "local escaping through bitcast passed to call",
+			in: `
+declare void @h(i8*)
+
+define void @f() {
+entry:
+	%x = alloca i32
+	%p = bitcast i32* %x to i8*
+	store i32 1, i32* %x
+	call void @h(i8* %p)
+	store i32 2, i32* %x
+	ret void
+}`,
+			want: `
+declare void @h(i8*)
+
+define void @f() {
+entry:
+	%x = alloca i32
+	%p = bitcast i32* %x to i8*
+	store i32 1, i32* %x
+	call void @h(i8* %p)
+	ret void
+}`,
+		},
+		{
+			name: "local escaping through stored pointer",
+			in: `
+@p = global i32* null
+
+define i32 @f() {
+entry:
+	%x = alloca i32
+	store i32* %x, i32** @p
+	store i32 1, i32* %x
+	%q = load i32*, i32** @p
+	%v = load i32, i32* %q
+	store i32 2, i32* %x
+	ret i32 %v
+}`,
+			want: `
+@p = global i32* null
+
+define i32 @f() {
+entry:
+	%x = alloca i32
+	store i32* %x, i32** @p
+	store i32 1, i32* %x
+	%q = load i32*, i32** @p
+	%v = load i32, i32* %q
+	ret i32 %v
+}`,
+		},
+		{
+			name: "partial overwrite through bitcast",
+			in: `
+define i32 @f() {
+entry:
+	%x = alloca i32
+	store i32 1, i32* %x
+	%p = bitcast i32* %x to i8*
+	store i8 2, i8* %p
+	%v = load i32, i32* %x
+	ret i32 %v
+}`,
+			want: `
+define i32 @f() {
+entry:
+	%x = alloca i32
+	store i32 1, i32* %x
+	%p = bitcast i32* %x to i8*
+	store i8 2, i8* %p
+	%v = load i32, i32* %x
+	ret i32 %v
+}`,
+		},
+	}
+	for _, g := range golden {
+		m, err := asm.ParseString(g.name, g.in)
+		if err != nil {
+			t.Errorf("%s: unable to parse input LLVM IR; %v", g.name, err)
+			continue
+		}
+		want, err := asm.ParseString(g.name, g.want)
+		if err != nil {
+			t.Errorf("%s: unable to parse expected LLVM IR; %v", g.name, err)
+			continue
+		}
+		for _, f := range m.Funcs {
+			if len(f.Blocks) > 0 {
+				deadVarStores(f)
+			}
+		}
+		if got, want := m.String(), want.String(); got != want {
+			t.Errorf("%s: LLVM IR mismatch; expected\n%s\ngot\n%s", g.name, want, got)
+		}
+	}
+}
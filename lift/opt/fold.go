@@ -0,0 +1,293 @@
+package opt
+
+import (
+	"math/big"
+
+	"github.com/decomp/exp/lift/irutil"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/enum"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+)
+
+// fold folds the integer instructions of the given function with constant
+// operands, replaces phi instructions with identical incoming values by the
+// incoming value, and folds conditional branches with constant conditions into
+// unconditional branches. The boolean return value indicates whether the
+// function was changed.
+func fold(f *ir.Function) bool {
+	changed := false
+	repl := make(map[value.Value]value.Value)
+	for _, block := range f.Blocks {
+		insts := block.Insts[:0]
+		for _, inst := range block.Insts {
+			// Apply replacements of preceding instructions, to fold chains of
+			// constant instructions in a single pass.
+			for _, operand := range irutil.Operands(inst) {
+				if v, ok := repl[*operand]; ok {
+					*operand = v
+				}
+			}
+			if v, ok := foldInst(inst); ok {
+				repl[inst.(value.Value)] = v
+				changed = true
+				continue
+			}
+			insts = append(insts, inst)
+		}
+		block.Insts = insts
+	}
+	irutil.ReplaceUses(f, repl)
+	for _, block := range f.Blocks {
+		term, ok := block.Term.(*ir.TermCondBr)
+		if !ok {
+			continue
+		}
+		var target, other *ir.BasicBlock
+		switch {
+		case term.TargetTrue == term.TargetFalse:
+			target, other = term.TargetTrue, nil
+		case isTrue(term.Cond):
+			target, other = term.TargetTrue, term.TargetFalse
+		case isFalse(term.Cond):
+			target, other = term.TargetFalse, term.TargetTrue
+		default:
+			continue
+		}
+		if other != nil {
+			removeIncoming(other, block)
+		} else {
+			// Both edges lead to the same basic block; keep one incoming value
+			// of its phi instructions.
+			removeIncomingOnce(target, block)
+		}
+		br := &ir.TermBr{Target: target}
+		br.Metadata = term.Metadata
+		block.Term = br
+		changed = true
+	}
+	return changed
+}
+
+// foldInst returns the value of the given instruction, if it may be determined
+// without executing the instruction.
+func foldInst(inst ir.Instruction) (value.Value, bool) {
+	switch inst := inst.(type) {
+	// Binary and bitwise instructions.
+	case *ir.InstAdd:
+		return foldBinary(inst.X, inst.Y, func(z, x, y *big.Int) *big.Int { return z.Add(x, y) })
+	case *ir.InstSub:
+		return foldBinary(inst.X, inst.Y, func(z, x, y *big.Int) *big.Int { return z.Sub(x, y) })
+	case *ir.InstMul:
+		return foldBinary(inst.X, inst.Y, func(z, x, y *big.Int) *big.Int { return z.Mul(x, y) })
+	case *ir.InstAnd:
+		return foldBinary(inst.X, inst.Y, func(z, x, y *big.Int) *big.Int { return z.And(x, y) })
+	case *ir.InstOr:
+		return foldBinary(inst.X, inst.Y, func(z, x, y *big.Int) *big.Int { return z.Or(x, y) })
+	case *ir.InstXor:
+		return foldBinary(inst.X, inst.Y, func(z, x, y *big.Int) *big.Int { return z.Xor(x, y) })
+	case *ir.InstShl:
+		return foldShift(inst.X, inst.Y, false, func(z, x *big.Int, n uint) *big.Int { return z.Lsh(x, n) })
+	case *ir.InstLShr:
+		return foldShift(inst.X, inst.Y, false, func(z, x *big.Int, n uint) *big.Int { return z.Rsh(x, n) })
+	case *ir.InstAShr:
+		return foldShift(inst.X, inst.Y, true, func(z, x *big.Int, n uint) *big.Int { return z.Rsh(x, n) })
+	// Conversion instructions.
+	case *ir.InstTrunc:
+		if x, ok := inst.From.(*constant.Int); ok {
+			if to, ok := inst.To.(*types.IntType); ok {
+				return newInt(to, unsigned(x)), true
+			}
+		}
+	case *ir.InstZExt:
+		if x, ok := inst.From.(*constant.Int); ok {
+			if to, ok := inst.To.(*types.IntType); ok {
+				return newInt(to, unsigned(x)), true
+			}
+		}
+	case *ir.InstSExt:
+		if x, ok := inst.From.(*constant.Int); ok {
+			if to, ok := inst.To.(*types.IntType); ok {
+				return newInt(to, signed(x)), true
+			}
+		}
+	// Other instructions.
+	case *ir.InstICmp:
+		x, ok := inst.X.(*constant.Int)
+		if !ok {
+			break
+		}
+		y, ok := inst.Y.(*constant.Int)
+		if !ok {
+			break
+		}
+		var cond bool
+		switch inst.Pred {
+		case enum.IPredEQ:
+			cond = unsigned(x).Cmp(unsigned(y)) == 0
+		case enum.IPredNE:
+			cond = unsigned(x).Cmp(unsigned(y)) != 0
+		case enum.IPredUGT:
+			cond = unsigned(x).Cmp(unsigned(y)) > 0
+		case enum.IPredUGE:
+			cond = unsigned(x).Cmp(unsigned(y)) >= 0
+		case enum.IPredULT:
+			cond = unsigned(x).Cmp(unsigned(y)) < 0
+		case enum.IPredULE:
+			cond = unsigned(x).Cmp(unsigned(y)) <= 0
+		case enum.IPredSGT:
+			cond = signed(x).Cmp(signed(y)) > 0
+		case enum.IPredSGE:
+			cond = signed(x).Cmp(signed(y)) >= 0
+		case enum.IPredSLT:
+			cond = signed(x).Cmp(signed(y)) < 0
+		case enum.IPredSLE:
+			cond = signed(x).Cmp(signed(y)) <= 0
+		default:
+			return nil, false
+		}
+		return constant.NewBool(cond), true
+	case *ir.InstSelect:
+		switch {
+		case isTrue(inst.Cond):
+			return inst.X, true
+		case isFalse(inst.Cond):
+			return inst.Y, true
+		}
+	case *ir.InstPhi:
+		// Phi instruction with identical incoming values, ignoring incoming
+		// values of the phi instruction itself.
+		var v value.Value
+		for _, inc := range inst.Incs {
+			if inc.X == inst || inc.X == v {
+				continue
+			}
+			if v != nil {
+				return nil, false
+			}
+			v = inc.X
+		}
+		if v != nil {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// foldBinary folds the binary integer operation of the given operands, if
+// constant.
+func foldBinary(x, y value.Value, op func(z, x, y *big.Int) *big.Int) (value.Value, bool) {
+	a, ok := x.(*constant.Int)
+	if !ok {
+		return nil, false
+	}
+	b, ok := y.(*constant.Int)
+	if !ok {
+		return nil, false
+	}
+	return newInt(a.Typ, op(new(big.Int), unsigned(a), unsigned(b))), true
+}
+
+// foldShift folds the integer shift operation of the given operands, if
+// constant. Shift amounts exceeding the bit size of the operands produce
+// poison values in LLVM IR, and are therefore not folded.
+func foldShift(x, y value.Value, arithmetic bool, op func(z, x *big.Int, n uint) *big.Int) (value.Value, bool) {
+	a, ok := x.(*constant.Int)
+	if !ok {
+		return nil, false
+	}
+	b, ok := y.(*constant.Int)
+	if !ok {
+		return nil, false
+	}
+	n := unsigned(b)
+	if !n.IsUint64() || n.Uint64() >= a.Typ.BitSize {
+		return nil, false
+	}
+	v := unsigned(a)
+	if arithmetic {
+		v = signed(a)
+	}
+	return newInt(a.Typ, op(new(big.Int), v, uint(n.Uint64()))), true
+}
+
+// ### [ Helper functions ] ####################################################
+
+// newInt returns a new integer constant of the given type, truncating x to the
+// bit size of the type. Integers wider than 1 bit are represented in two's
+// complement, as used by the LLVM IR assembly.
+func newInt(typ *types.IntType, x *big.Int) *constant.Int {
+	c := &constant.Int{Typ: typ}
+	c.X = new(big.Int).Set(x)
+	c.X = unsigned(c)
+	if typ.BitSize > 1 {
+		c.X = signed(c)
+	}
+	return c
+}
+
+// unsigned returns the value of the given integer constant, interpreted as an
+// unsigned integer.
+func unsigned(c *constant.Int) *big.Int {
+	mask := new(big.Int).Lsh(big.NewInt(1), uint(c.Typ.BitSize))
+	mask.Sub(mask, big.NewInt(1))
+	return new(big.Int).And(c.X, mask)
+}
+
+// signed returns the value of the given integer constant, interpreted as a
+// signed integer in two's complement.
+func signed(c *constant.Int) *big.Int {
+	x := unsigned(c)
+	if x.Bit(int(c.Typ.BitSize)-1) == 1 {
+		x.Sub(x, new(big.Int).Lsh(big.NewInt(1), uint(c.Typ.BitSize)))
+	}
+	return x
+}
+
+// isTrue reports whether the given value is the boolean constant true.
+func isTrue(v value.Value) bool {
+	c, ok := v.(*constant.Int)
+	return ok && c.Typ.BitSize == 1 && c.X.Sign() != 0
+}
+
+// isFalse reports whether the given value is the boolean constant false.
+func isFalse(v value.Value) bool {
+	c, ok := v.(*constant.Int)
+	return ok && c.Typ.BitSize == 1 && c.X.Sign() == 0
+}
+
+// removeIncoming removes the incoming values from pred of the phi instructions
+// of the given basic block.
+func removeIncoming(block, pred *ir.BasicBlock) {
+	for _, inst := range block.Insts {
+		phi, ok := inst.(*ir.InstPhi)
+		if !ok {
+			continue
+		}
+		incs := phi.Incs[:0]
+		for _, inc := range phi.Incs {
+			if inc.Pred != value.Value(pred) {
+				incs = append(incs, inc)
+			}
+		}
+		phi.Incs = incs
+	}
+}
+
+// removeIncomingOnce removes the first incoming value from pred of the phi
+// instructions of the given basic block.
+func removeIncomingOnce(block, pred *ir.BasicBlock) {
+	for _, inst := range block.Insts {
+		phi, ok := inst.(*ir.InstPhi)
+		if !ok {
+			continue
+		}
+		for i, inc := range phi.Incs {
+			if inc.Pred == value.Value(pred) {
+				phi.Incs = append(phi.Incs[:i], phi.Incs[i+1:]...)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package opt
+
+import (
+	"github.com/decomp/exp/lift/irutil"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/value"
+)
+
+// mem2reg promotes the local variables of the given function to SSA values,
+// inserting phi instructions at the iterated dominance frontiers of stores.
+//
+// Local variables are promotable if allocated in the entry basic block and
+// only used as the source of loads or destination of stores; e.g. the
+// registers and status flags of lifted functions, but not local variables
+// whose address escape.
+func mem2reg(f *ir.Function) {
+	allocas := promotable(f)
+	if len(allocas) == 0 {
+		return
+	}
+	preds := irutil.Preds(f)
+	d := newDomTree(f, preds)
+	// Insert phi instructions.
+	phis := make(map[*ir.BasicBlock][]*ir.InstPhi)
+	phiAlloca := make(map[*ir.InstPhi]*ir.InstAlloca)
+	promoted := make(map[*ir.InstAlloca]bool)
+	for _, a := range allocas {
+		promoted[a] = true
+		var work []*ir.BasicBlock
+		for _, block := range f.Blocks {
+			for _, inst := range block.Insts {
+				if store, ok := inst.(*ir.InstStore); ok && store.Dst == a {
+					work = append(work, block)
+					break
+				}
+			}
+		}
+		done := make(map[*ir.BasicBlock]bool)
+		for len(work) > 0 {
+			block := work[len(work)-1]
+			work = work[:len(work)-1]
+			for _, y := range d.frontier[block] {
+				if done[y] {
+					continue
+				}
+				done[y] = true
+				phi := &ir.InstPhi{}
+				phi.Typ = a.ElemType
+				phis[y] = append(phis[y], phi)
+				phiAlloca[phi] = a
+				work = append(work, y)
+			}
+		}
+	}
+	for block, ps := range phis {
+		insts := make([]ir.Instruction, 0, len(ps)+len(block.Insts))
+		for _, phi := range ps {
+			insts = append(insts, phi)
+		}
+		block.Insts = append(insts, block.Insts...)
+	}
+	// Rename loads and stores, walking the dominator tree.
+	repl := make(map[value.Value]value.Value)
+	removed := make(map[ir.Instruction]bool)
+	current := func(vals map[*ir.InstAlloca]value.Value, a *ir.InstAlloca) value.Value {
+		if v, ok := vals[a]; ok {
+			return v
+		}
+		// Loaded before stored.
+		return constant.NewUndef(a.ElemType)
+	}
+	var rename func(block *ir.BasicBlock, vals map[*ir.InstAlloca]value.Value)
+	rename = func(block *ir.BasicBlock, vals map[*ir.InstAlloca]value.Value) {
+		for _, inst := range block.Insts {
+			switch inst := inst.(type) {
+			case *ir.InstPhi:
+				if a, ok := phiAlloca[inst]; ok {
+					vals[a] = inst
+				}
+			case *ir.InstAlloca:
+				if promoted[inst] {
+					removed[inst] = true
+				}
+			case *ir.InstLoad:
+				if a, ok := inst.Src.(*ir.InstAlloca); ok && promoted[a] {
+					repl[inst] = current(vals, a)
+					removed[inst] = true
+				}
+			case *ir.InstStore:
+				if a, ok := inst.Dst.(*ir.InstAlloca); ok && promoted[a] {
+					vals[a] = inst.Src
+					removed[inst] = true
+				}
+			}
+		}
+		for _, succ := range irutil.Succs(block.Term) {
+			for _, phi := range phis[succ] {
+				inc := &ir.Incoming{X: current(vals, phiAlloca[phi]), Pred: block}
+				phi.Incs = append(phi.Incs, inc)
+			}
+		}
+		for _, child := range d.children[block] {
+			childVals := make(map[*ir.InstAlloca]value.Value, len(vals))
+			for a, v := range vals {
+				childVals[a] = v
+			}
+			rename(child, childVals)
+		}
+	}
+	rename(f.Blocks[0], make(map[*ir.InstAlloca]value.Value))
+	for _, block := range f.Blocks {
+		insts := block.Insts[:0]
+		for _, inst := range block.Insts {
+			if !removed[inst] {
+				insts = append(insts, inst)
+			}
+		}
+		block.Insts = insts
+	}
+	irutil.ReplaceUses(f, repl)
+	dbg.Printf("promoted %d local variables of function %q", len(allocas), f.Name())
+}
+
+// promotable returns the promotable local variables of the given function, as
+// allocated in the entry basic block and only used as the source of loads or
+// destination of stores.
+func promotable(f *ir.Function) []*ir.InstAlloca {
+	candidates := make(map[*ir.InstAlloca]bool)
+	var allocas []*ir.InstAlloca
+	for _, inst := range f.Blocks[0].Insts {
+		if a, ok := inst.(*ir.InstAlloca); ok && a.NElems == nil {
+			candidates[a] = true
+			allocas = append(allocas, a)
+		}
+	}
+	if len(allocas) == 0 {
+		return nil
+	}
+	escape := func(inst interface{}) {
+		for _, operand := range irutil.Operands(inst) {
+			a, ok := (*operand).(*ir.InstAlloca)
+			if !ok || !candidates[a] {
+				continue
+			}
+			switch inst := inst.(type) {
+			case *ir.InstLoad:
+				// Source of load.
+				continue
+			case *ir.InstStore:
+				if operand == &inst.Dst {
+					// Destination of store.
+					continue
+				}
+			}
+			delete(candidates, a)
+		}
+	}
+	for _, block := range f.Blocks {
+		for _, inst := range block.Insts {
+			escape(inst)
+		}
+		escape(block.Term)
+	}
+	var as []*ir.InstAlloca
+	for _, a := range allocas {
+		if candidates[a] {
+			as = append(as, a)
+		}
+	}
+	return as
+}
+
+// ### [ Helper functions ] ####################################################
+
+// A domTree is the dominator tree of a function.
+type domTree struct {
+	// Immediate dominator of each basic block; the entry basic block is its
+	// own immediate dominator.
+	idom map[*ir.BasicBlock]*ir.BasicBlock
+	// Children of each basic block in the dominator tree, in layout order.
+	children map[*ir.BasicBlock][]*ir.BasicBlock
+	// Dominance frontier of each basic block, in layout order.
+	frontier map[*ir.BasicBlock][]*ir.BasicBlock
+}
+
+// newDomTree returns the dominator tree of the given function, as computed by
+// the algorithm of Cooper, Harvey and Kennedy in "A Simple, Fast Dominance
+// Algorithm". The basic blocks of the function must be reachable from the
+// entry basic block.
+func newDomTree(f *ir.Function, preds map[*ir.BasicBlock][]*ir.BasicBlock) *domTree {
+	entry := f.Blocks[0]
+	// Postorder numbering.
+	var order []*ir.BasicBlock
+	index := make(map[*ir.BasicBlock]int)
+	visited := make(map[*ir.BasicBlock]bool)
+	var walk func(block *ir.BasicBlock)
+	walk = func(block *ir.BasicBlock) {
+		visited[block] = true
+		for _, succ := range irutil.Succs(block.Term) {
+			if !visited[succ] {
+				walk(succ)
+			}
+		}
+		index[block] = len(order)
+		order = append(order, block)
+	}
+	walk(entry)
+	// Immediate dominators.
+	idom := map[*ir.BasicBlock]*ir.BasicBlock{entry: entry}
+	intersect := func(a, b *ir.BasicBlock) *ir.BasicBlock {
+		for a != b {
+			for index[a] < index[b] {
+				a = idom[a]
+			}
+			for index[b] < index[a] {
+				b = idom[b]
+			}
+		}
+		return a
+	}
+	for changed := true; changed; {
+		changed = false
+		// Reverse postorder.
+		for i := len(order) - 1; i >= 0; i-- {
+			block := order[i]
+			if block == entry {
+				continue
+			}
+			var newIdom *ir.BasicBlock
+			for _, pred := range preds[block] {
+				if _, ok := idom[pred]; !ok {
+					continue
+				}
+				if newIdom == nil {
+					newIdom = pred
+				} else {
+					newIdom = intersect(pred, newIdom)
+				}
+			}
+			if idom[block] != newIdom {
+				idom[block] = newIdom
+				changed = true
+			}
+		}
+	}
+	d := &domTree{
+		idom:     idom,
+		children: make(map[*ir.BasicBlock][]*ir.BasicBlock),
+		frontier: make(map[*ir.BasicBlock][]*ir.BasicBlock),
+	}
+	for _, block := range f.Blocks {
+		if block != entry {
+			parent := idom[block]
+			d.children[parent] = append(d.children[parent], block)
+		}
+	}
+	// Dominance frontiers.
+	inFrontier := make(map[*ir.BasicBlock]map[*ir.BasicBlock]bool)
+	for _, block := range f.Blocks {
+		if len(preds[block]) < 2 {
+			continue
+		}
+		for _, pred := range preds[block] {
+			for runner := pred; runner != idom[block]; runner = idom[runner] {
+				if inFrontier[runner] == nil {
+					inFrontier[runner] = make(map[*ir.BasicBlock]bool)
+				}
+				if !inFrontier[runner][block] {
+					inFrontier[runner][block] = true
+					d.frontier[runner] = append(d.frontier[runner], block)
+				}
+				if runner == entry {
+					break
+				}
+			}
+		}
+	}
+	return d
+}
@@ -0,0 +1,117 @@
+package opt
+
+import (
+	"github.com/decomp/exp/lift/irutil"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/value"
+)
+
+// removeUnreachable removes the basic blocks of the given function which are
+// not reachable from the entry basic block. The boolean return value indicates
+// whether the function was changed.
+func removeUnreachable(f *ir.Function) bool {
+	reachable := map[*ir.BasicBlock]bool{f.Blocks[0]: true}
+	work := []*ir.BasicBlock{f.Blocks[0]}
+	for len(work) > 0 {
+		block := work[len(work)-1]
+		work = work[:len(work)-1]
+		for _, succ := range irutil.Succs(block.Term) {
+			if !reachable[succ] {
+				reachable[succ] = true
+				work = append(work, succ)
+			}
+		}
+	}
+	if len(reachable) == len(f.Blocks) {
+		return false
+	}
+	blocks := f.Blocks[:0]
+	var unreachable []*ir.BasicBlock
+	for _, block := range f.Blocks {
+		if reachable[block] {
+			blocks = append(blocks, block)
+		} else {
+			unreachable = append(unreachable, block)
+		}
+	}
+	f.Blocks = blocks
+	// Remove incoming values of phi instructions from unreachable basic blocks.
+	for _, block := range unreachable {
+		for _, succ := range irutil.Succs(block.Term) {
+			if reachable[succ] {
+				removeIncoming(succ, block)
+			}
+		}
+	}
+	dbg.Printf("removed %d unreachable basic blocks of function %q", len(unreachable), f.Name())
+	return true
+}
+
+// mergeBlocks merges the basic blocks of the given function which are only
+// reached by an unconditional branch from their predecessor into the
+// predecessor. The boolean return value indicates whether the function was
+// changed.
+//
+// Branches with metadata attachments (e.g. loop metadata) are preserved.
+func mergeBlocks(f *ir.Function) bool {
+	preds := irutil.Preds(f)
+	merged := make(map[*ir.BasicBlock]bool)
+	for _, block := range f.Blocks {
+		if merged[block] {
+			continue
+		}
+		for {
+			br, ok := block.Term.(*ir.TermBr)
+			if !ok || len(br.Metadata) > 0 {
+				break
+			}
+			succ := br.Target
+			if succ == block || succ == f.Blocks[0] || len(preds[succ]) != 1 {
+				break
+			}
+			// Replace phi instructions of the successor by their incoming
+			// value from the predecessor.
+			repl := make(map[value.Value]value.Value)
+			for _, inst := range succ.Insts {
+				if phi, ok := inst.(*ir.InstPhi); ok {
+					repl[phi] = phi.Incs[0].X
+					continue
+				}
+				block.Insts = append(block.Insts, inst)
+			}
+			block.Term = succ.Term
+			// Update incoming basic blocks of phi instructions in successors.
+			for _, s := range irutil.Succs(succ.Term) {
+				for _, inst := range s.Insts {
+					phi, ok := inst.(*ir.InstPhi)
+					if !ok {
+						continue
+					}
+					for _, inc := range phi.Incs {
+						if inc.Pred == value.Value(succ) {
+							inc.Pred = block
+						}
+					}
+				}
+				for i, pred := range preds[s] {
+					if pred == succ {
+						preds[s][i] = block
+					}
+				}
+			}
+			irutil.ReplaceUses(f, repl)
+			merged[succ] = true
+		}
+	}
+	if len(merged) == 0 {
+		return false
+	}
+	blocks := f.Blocks[:0]
+	for _, block := range f.Blocks {
+		if !merged[block] {
+			blocks = append(blocks, block)
+		}
+	}
+	f.Blocks = blocks
+	return true
+}
@@ -0,0 +1,72 @@
+// Package opt implements a cleanup pipeline for lifted LLVM IR, which produces
+// compact LLVM IR without the need to round-trip through opt of the LLVM
+// toolchain.
+//
+// The pipeline consists of the following passes.
+//
+//    * promotion of local variables (e.g. registers and status flags) to SSA
+//      values (mem2reg)
+//    * constant folding of integer instructions and conditional branches
+//    * dead code elimination of unused instructions without side effects
+//    * dead store elimination of stores overwritten within the same basic block,
+//      and of stores to local and global variables overwritten before being
+//      read along every path of the function
+//    * merging of basic blocks connected by unconditional branches, and removal
+//      of unreachable basic blocks
+package opt
+
+import (
+	"log"
+	"os"
+
+	"github.com/llir/llvm/ir"
+	"github.com/mewkiz/pkg/term"
+)
+
+// TODO: Remove loggers once the library matures.
+
+// dbg represents a logger with the "opt:" prefix, which logs debug messages to
+// standard error.
+var dbg = log.New(os.Stderr, term.BlueBold("opt:")+" ", 0)
+
+// Module simplifies the function definitions of the given LLVM IR module.
+func Module(m *ir.Module) {
+	for _, f := range m.Funcs {
+		Func(f)
+	}
+}
+
+// Func simplifies the given LLVM IR function definition. Function declarations
+// are left untouched.
+func Func(f *ir.Function) {
+	if len(f.Blocks) == 0 {
+		return
+	}
+	dbg.Printf("simplifying function %q", f.Name())
+	removeUnreachable(f)
+	mem2reg(f)
+	// Iterate until fixed point, as each pass may expose further opportunities
+	// to the others (e.g. folded branches expose unreachable basic blocks, and
+	// merged basic blocks expose dead stores).
+	for {
+		changed := fold(f)
+		if removeUnreachable(f) {
+			changed = true
+		}
+		if mergeBlocks(f) {
+			changed = true
+		}
+		if deadStores(f) {
+			changed = true
+		}
+		if deadVarStores(f) {
+			changed = true
+		}
+		if deadCode(f) {
+			changed = true
+		}
+		if !changed {
+			break
+		}
+	}
+}
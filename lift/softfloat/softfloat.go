@@ -0,0 +1,363 @@
+// Package softfloat lowers the floating-point operations of LLVM IR to calls
+// into a soft-float runtime, for recompiling lifted code to targets without a
+// floating-point unit (e.g. embedded platforms).
+//
+// The runtime functions follow the naming conventions of libgcc and
+// compiler-rt, and are thus provided by the compiler runtime of most embedded
+// toolchains. The mode suffix of each function specifies the floating-point
+// type of its operands; sf (float), df (double), xf (x86_fp80) or tf (fp128),
+// and the integer type of its operands or result; si (i32), di (i64) or ti
+// (i128).
+//
+//    fadd, fsub, fmul, fdiv    __addsf3, __subdf3, __muldf3, __divdf3, ...
+//    frem                      fmodf, fmod, fmodl
+//    fpext, fptrunc            __extendsfdf2, __truncdfsf2, ...
+//    sitofp, uitofp            __floatsidf, __floatundidf, ...
+//    fptosi, fptoui            __fixdfsi, __fixunsdfdi, ...
+//    fcmp                      __eqdf2, __nedf2, __ltdf2, __ledf2, __gtdf2,
+//                              __gedf2 and __unorddf2
+//
+// Integer operands narrower than 32 bits are extended to i32 before conversion,
+// and integer results narrower than 32 bits are truncated from i32.
+//
+// Calls to the rounding intrinsics (e.g. llvm.rint.f64) are lowered to calls to
+// the corresponding functions of the C math library (e.g. rint), which is
+// implemented in software on targets without a floating-point unit.
+//
+// Floating-point constants, loads, stores, bitcasts and phi and select
+// instructions are left as is, as these only move the bit pattern of values.
+//
+// Few soft-float runtimes implement x86_fp80 arithmetic; lift with double
+// precision x87 FPU values (`bin2ll -fpu-precision double`) to only use float
+// and double.
+package softfloat
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/decomp/exp/lift/irutil"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/enum"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+	"github.com/mewkiz/pkg/term"
+)
+
+// TODO: Remove loggers once the library matures.
+
+// dbg represents a logger with the "softfloat:" prefix, which logs debug
+// messages to standard error.
+var dbg = log.New(os.Stderr, term.BlueBold("softfloat:")+" ", 0)
+
+// Module lowers the floating-point operations of the function definitions of
+// the given LLVM IR module to calls into the soft-float runtime. Runtime
+// functions are declared in the module as needed.
+func Module(m *ir.Module) {
+	l := &lowerer{m: m, funcs: make(map[string]*ir.Function)}
+	for _, f := range m.Funcs {
+		l.funcs[f.Name()] = f
+	}
+	// Iterate over a copy, as runtime functions are appended to m.Funcs.
+	funcs := append([]*ir.Function(nil), m.Funcs...)
+	for _, f := range funcs {
+		if len(f.Blocks) == 0 {
+			continue
+		}
+		dbg.Printf("lowering floating-point operations of function %q", f.Name())
+		l.lowerFunc(f)
+	}
+}
+
+// lowerer lowers floating-point operations to calls into the soft-float
+// runtime.
+type lowerer struct {
+	// LLVM IR module.
+	m *ir.Module
+	// Functions of the module, mapping from function name to function.
+	funcs map[string]*ir.Function
+}
+
+// lowerFunc lowers the floating-point operations of the given function.
+func (l *lowerer) lowerFunc(f *ir.Function) {
+	repl := make(map[value.Value]value.Value)
+	for _, block := range f.Blocks {
+		var insts []ir.Instruction
+		for _, inst := range block.Insts {
+			for _, operand := range irutil.Operands(inst) {
+				if v, ok := repl[*operand]; ok {
+					*operand = v
+				}
+			}
+			lowered, v, ok := l.lowerInst(inst)
+			if !ok {
+				insts = append(insts, inst)
+				continue
+			}
+			insts = append(insts, lowered...)
+			repl[inst.(value.Value)] = v
+		}
+		block.Insts = insts
+	}
+	irutil.ReplaceUses(f, repl)
+}
+
+// lowerInst lowers the given floating-point instruction, and returns the
+// instructions computing its value, and the value by which it is replaced. The
+// boolean return value indicates whether the instruction was lowered.
+func (l *lowerer) lowerInst(inst ir.Instruction) ([]ir.Instruction, value.Value, bool) {
+	switch inst := inst.(type) {
+	// Binary instructions.
+	case *ir.InstFAdd:
+		return l.binary("add", inst.X, inst.Y)
+	case *ir.InstFSub:
+		return l.binary("sub", inst.X, inst.Y)
+	case *ir.InstFMul:
+		return l.binary("mul", inst.X, inst.Y)
+	case *ir.InstFDiv:
+		return l.binary("div", inst.X, inst.Y)
+	case *ir.InstFRem:
+		typ := inst.X.Type()
+		return l.call(l.runtimeFunc(libmName("fmod", typ), typ, typ, typ), inst.X, inst.Y)
+	// Conversion instructions.
+	case *ir.InstFPExt:
+		name := fmt.Sprintf("__extend%s%s2", floatMode(inst.From.Type()), floatMode(inst.To))
+		return l.call(l.runtimeFunc(name, inst.To, inst.From.Type()), inst.From)
+	case *ir.InstFPTrunc:
+		name := fmt.Sprintf("__trunc%s%s2", floatMode(inst.From.Type()), floatMode(inst.To))
+		return l.call(l.runtimeFunc(name, inst.To, inst.From.Type()), inst.From)
+	case *ir.InstSIToFP:
+		return l.intToFloat("__float", inst.From, inst.To, true)
+	case *ir.InstUIToFP:
+		return l.intToFloat("__floatun", inst.From, inst.To, false)
+	case *ir.InstFPToSI:
+		return l.floatToInt("__fix", inst.From, inst.To)
+	case *ir.InstFPToUI:
+		return l.floatToInt("__fixuns", inst.From, inst.To)
+	// Other instructions.
+	case *ir.InstFCmp:
+		return l.fcmp(inst)
+	case *ir.InstCall:
+		return l.intrinsic(inst)
+	}
+	return nil, nil, false
+}
+
+// binary lowers a binary floating-point instruction with the given operation
+// name (e.g. "add") to a call to the corresponding runtime function (e.g.
+// __adddf3).
+func (l *lowerer) binary(op string, x, y value.Value) ([]ir.Instruction, value.Value, bool) {
+	typ := x.Type()
+	name := fmt.Sprintf("__%s%s3", op, floatMode(typ))
+	return l.call(l.runtimeFunc(name, typ, typ, typ), x, y)
+}
+
+// intToFloat lowers an integer to floating-point conversion to a call to the
+// runtime function with the given name prefix (e.g. __float), sign or zero
+// extending integer operands narrower than 32 bits.
+func (l *lowerer) intToFloat(prefix string, from value.Value, to types.Type, signed bool) ([]ir.Instruction, value.Value, bool) {
+	var insts []ir.Instruction
+	fromType := intType(from.Type())
+	if fromType.BitSize < 32 {
+		var ext ir.Instruction
+		if signed {
+			ext = ir.NewSExt(from, types.I32)
+		} else {
+			ext = ir.NewZExt(from, types.I32)
+		}
+		insts = append(insts, ext)
+		from = ext.(value.Value)
+		fromType = types.I32
+	}
+	name := fmt.Sprintf("%s%s%s", prefix, intMode(fromType), floatMode(to))
+	call := ir.NewCall(l.runtimeFunc(name, to, fromType), from)
+	insts = append(insts, call)
+	return insts, call, true
+}
+
+// floatToInt lowers a floating-point to integer conversion to a call to the
+// runtime function with the given name prefix (e.g. __fix), truncating integer
+// results narrower than 32 bits.
+func (l *lowerer) floatToInt(prefix string, from value.Value, to types.Type) ([]ir.Instruction, value.Value, bool) {
+	toType := intType(to)
+	retType := toType
+	if retType.BitSize < 32 {
+		retType = types.I32
+	}
+	name := fmt.Sprintf("%s%s%s", prefix, floatMode(from.Type()), intMode(retType))
+	call := ir.NewCall(l.runtimeFunc(name, retType, from.Type()), from)
+	if retType == toType {
+		return []ir.Instruction{call}, call, true
+	}
+	trunc := ir.NewTrunc(call, toType)
+	return []ir.Instruction{call, trunc}, trunc, true
+}
+
+// fcmpFuncs maps from floating-point predicate to the name of the runtime
+// comparison function, and the integer predicate of its result compared to 0.
+//
+// The comparison functions return a value which compares to 0 as the operands
+// compare to each other; the result for unordered operands (i.e. NaN) is chosen
+// such that the ordered predicates are false. Unordered predicates are thus
+// lowered to the negated ordered predicate, e.g. ugt to !ole.
+var fcmpFuncs = map[enum.FPred]struct {
+	name string
+	pred enum.IPred
+}{
+	enum.FPredOEQ: {"eq", enum.IPredEQ},
+	enum.FPredUNE: {"ne", enum.IPredNE},
+	enum.FPredOGE: {"ge", enum.IPredSGE},
+	enum.FPredOLT: {"lt", enum.IPredSLT},
+	enum.FPredOLE: {"le", enum.IPredSLE},
+	enum.FPredOGT: {"gt", enum.IPredSGT},
+	enum.FPredUGT: {"le", enum.IPredSGT},
+	enum.FPredUGE: {"lt", enum.IPredSGE},
+	enum.FPredULT: {"ge", enum.IPredSLT},
+	enum.FPredULE: {"gt", enum.IPredSLE},
+	enum.FPredUNO: {"unord", enum.IPredNE},
+	enum.FPredORD: {"unord", enum.IPredEQ},
+}
+
+// fcmp lowers the given floating-point comparison to calls to the runtime
+// comparison functions.
+func (l *lowerer) fcmp(inst *ir.InstFCmp) ([]ir.Instruction, value.Value, bool) {
+	switch inst.Pred {
+	case enum.FPredTrue:
+		return nil, constant.True, true
+	case enum.FPredFalse:
+		return nil, constant.False, true
+	case enum.FPredUEQ:
+		// ueq = uno || oeq
+		uno, unoInsts := l.cmp(enum.FPredUNO, inst.X, inst.Y)
+		oeq, oeqInsts := l.cmp(enum.FPredOEQ, inst.X, inst.Y)
+		or := ir.NewOr(uno, oeq)
+		insts := append(unoInsts, oeqInsts...)
+		return append(insts, or), or, true
+	case enum.FPredONE:
+		// one = ord && une
+		ord, ordInsts := l.cmp(enum.FPredORD, inst.X, inst.Y)
+		une, uneInsts := l.cmp(enum.FPredUNE, inst.X, inst.Y)
+		and := ir.NewAnd(ord, une)
+		insts := append(ordInsts, uneInsts...)
+		return append(insts, and), and, true
+	}
+	v, insts := l.cmp(inst.Pred, inst.X, inst.Y)
+	return insts, v, true
+}
+
+// cmp lowers a floating-point comparison with the given predicate to a call to
+// the runtime comparison function and an integer comparison of its result, and
+// returns the result and the instructions computing it.
+func (l *lowerer) cmp(pred enum.FPred, x, y value.Value) (value.Value, []ir.Instruction) {
+	fn, ok := fcmpFuncs[pred]
+	if !ok {
+		panic(fmt.Errorf("support for floating-point predicate %v not yet implemented", pred))
+	}
+	typ := x.Type()
+	name := fmt.Sprintf("__%s%s2", fn.name, floatMode(typ))
+	call := ir.NewCall(l.runtimeFunc(name, types.I32, typ, typ), x, y)
+	icmp := ir.NewICmp(fn.pred, call, constant.NewInt(types.I32, 0))
+	return icmp, []ir.Instruction{call, icmp}
+}
+
+// roundingIntrinsics specifies the names of the LLVM intrinsics lowered to
+// calls to the corresponding function of the C math library.
+var roundingIntrinsics = []string{"rint", "floor", "ceil", "trunc", "sqrt", "fabs"}
+
+// intrinsic lowers the given call to a floating-point intrinsic (e.g.
+// llvm.rint.f64) to a call to the corresponding function of the C math library
+// (e.g. rint).
+func (l *lowerer) intrinsic(inst *ir.InstCall) ([]ir.Instruction, value.Value, bool) {
+	callee, ok := inst.Callee.(*ir.Function)
+	if !ok || !strings.HasPrefix(callee.Name(), "llvm.") || len(inst.Args) != 1 {
+		return nil, nil, false
+	}
+	for _, base := range roundingIntrinsics {
+		if !strings.HasPrefix(callee.Name(), "llvm."+base+".") {
+			continue
+		}
+		typ := inst.Args[0].Type()
+		return l.call(l.runtimeFunc(libmName(base, typ), typ, typ), inst.Args[0])
+	}
+	return nil, nil, false
+}
+
+// ### [ Helper functions ] ####################################################
+
+// call returns a call to the given runtime function.
+func (l *lowerer) call(callee *ir.Function, args ...value.Value) ([]ir.Instruction, value.Value, bool) {
+	call := ir.NewCall(callee, args...)
+	return []ir.Instruction{call}, call, true
+}
+
+// runtimeFunc returns the runtime function of the given name and signature,
+// declaring the function in the module if not present.
+func (l *lowerer) runtimeFunc(name string, retType types.Type, paramTypes ...types.Type) *ir.Function {
+	if f, ok := l.funcs[name]; ok {
+		return f
+	}
+	var params []*ir.Param
+	for i, paramType := range paramTypes {
+		params = append(params, ir.NewParam(fmt.Sprintf("x%d", i), paramType))
+	}
+	f := l.m.NewFunc(name, retType, params...)
+	l.funcs[name] = f
+	return f
+}
+
+// floatMode returns the mode suffix of runtime functions with operands of the
+// given floating-point type.
+func floatMode(typ types.Type) string {
+	if t, ok := typ.(*types.FloatType); ok {
+		switch t.Kind {
+		case types.FloatKindFloat:
+			return "sf"
+		case types.FloatKindDouble:
+			return "df"
+		case types.FloatKindX86FP80:
+			return "xf"
+		case types.FloatKindFP128:
+			return "tf"
+		}
+	}
+	panic(fmt.Errorf("support for soft-float operands of type %v not yet implemented", typ))
+}
+
+// intMode returns the mode suffix of runtime functions with operands or
+// results of the given integer type.
+func intMode(typ *types.IntType) string {
+	switch typ.BitSize {
+	case 32:
+		return "si"
+	case 64:
+		return "di"
+	case 128:
+		return "ti"
+	}
+	panic(fmt.Errorf("support for soft-float conversion of integer type %v not yet implemented", typ))
+}
+
+// intType returns the given type as an integer type.
+func intType(typ types.Type) *types.IntType {
+	t, ok := typ.(*types.IntType)
+	if !ok {
+		panic(fmt.Errorf("support for soft-float conversion of type %v not yet implemented", typ))
+	}
+	return t
+}
+
+// libmName returns the name of the function of the C math library with the
+// given base name (e.g. "fmod") and operands of the given floating-point type.
+func libmName(base string, typ types.Type) string {
+	switch floatMode(typ) {
+	case "sf":
+		return base + "f"
+	case "df":
+		return base
+	default:
+		return base + "l"
+	}
+}
@@ -0,0 +1,243 @@
+package x86
+
+import (
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/enum"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// Calling Conventions
+//
+// When the signature of a callee is known, arguments are passed explicitly at
+// call sites, read from the registers and stack slots of the calling
+// convention, and the return value is bound to the return registers of the
+// calling convention; and vice versa at function entry and returns.
+//
+//    calling convention   register arguments                 return value
+//
+//    cdecl, stdcall       -                                  EAX, EDX:EAX, ST0
+//    fastcall             ECX, EDX                           EAX, EDX:EAX, ST0
+//    thiscall             ECX                                EAX, EDX:EAX, ST0
+//    win64                RCX, RDX, R8, R9 or XMM0-XMM3      RAX, XMM0
+//    x86-64 System V      RDI, RSI, RDX, RCX, R8, R9 or      RAX, XMM0
+//                         XMM0-XMM7
+//
+// Remaining arguments are passed on the stack, pushed from right to left. In
+// 32-bit mode, 64-bit integers are returned in EDX:EAX, and floating-point
+// values in ST0; i.e. pushed onto the FPU register stack by the callee.
+//
+// In 64-bit mode, the calling convention keywords of 32-bit mode (e.g.
+// __stdcall and __cdecl) are ignored, and functions use the calling convention
+// of the platform; win64 for PE and COFF files, and x86-64 System V otherwise.
+//
+// ref: https://en.wikipedia.org/wiki/X86_calling_conventions
+
+// Integer argument registers of the Microsoft x64 calling convention.
+var win64IntRegs = []*x86.Reg{x86.RCX, x86.RDX, x86.R8, x86.R9}
+
+// Floating-point argument registers of the Microsoft x64 calling convention.
+var win64FloatRegs = []*x86.Reg{x86.X0, x86.X1, x86.X2, x86.X3}
+
+// Integer argument registers of the System V AMD64 calling convention.
+var sysvIntRegs = []*x86.Reg{x86.RDI, x86.RSI, x86.RDX, x86.RCX, x86.R8, x86.R9}
+
+// Floating-point argument registers of the System V AMD64 calling convention.
+var sysvFloatRegs = []*x86.Reg{x86.X0, x86.X1, x86.X2, x86.X3, x86.X4, x86.X5, x86.X6, x86.X7}
+
+// callConv returns the calling convention used by functions of the given
+// declared calling convention.
+func (l *Lifter) callConv(callconv enum.CallingConv) enum.CallingConv {
+	if l.Mode != 64 {
+		return callconv
+	}
+	switch callconv {
+	case enum.CallingConvWin64, enum.CallingConvX86_64SysV:
+		return callconv
+	}
+	switch l.File.Format {
+	case "pe", "coff", "minidump":
+		return enum.CallingConvWin64
+	}
+	return enum.CallingConvX86_64SysV
+}
+
+// paramRegs returns the register of each parameter of the given types passed
+// in registers by the calling convention, or nil for parameters passed on the
+// stack.
+func paramRegs(callconv enum.CallingConv, params []types.Type) []*x86.Reg {
+	regs := make([]*x86.Reg, len(params))
+	switch callconv {
+	case enum.CallingConvX86FastCall:
+		// The first two parameters of at most 32 bits, from left to right.
+		free := []*x86.Reg{x86.ECX, x86.EDX}
+		for i, param := range params {
+			if len(free) > 0 && isDWord(param) {
+				regs[i], free = free[0], free[1:]
+			}
+		}
+	case enum.CallingConvX86ThisCall:
+		if len(params) > 0 {
+			regs[0] = x86.ECX
+		}
+	case enum.CallingConvWin64:
+		// Registers are assigned by parameter position.
+		for i := range params {
+			if i >= len(win64IntRegs) {
+				break
+			}
+			if types.IsFloat(params[i]) {
+				regs[i] = win64FloatRegs[i]
+			} else {
+				regs[i] = win64IntRegs[i]
+			}
+		}
+	case enum.CallingConvX86_64SysV:
+		// Registers are assigned separately to integer and floating-point
+		// parameters.
+		ints, floats := sysvIntRegs, sysvFloatRegs
+		for i, param := range params {
+			switch {
+			case types.IsFloat(param) && len(floats) > 0:
+				regs[i], floats = floats[0], floats[1:]
+			case !types.IsFloat(param) && len(ints) > 0:
+				regs[i], ints = ints[0], ints[1:]
+			}
+		}
+	}
+	return regs
+}
+
+// isDWord reports whether the given type is an integer or pointer type of at
+// most 32 bits.
+func isDWord(typ types.Type) bool {
+	switch typ := typ.(type) {
+	case *types.IntType:
+		return typ.BitSize <= 32
+	case *types.PointerType:
+		return true
+	}
+	return false
+}
+
+// stackParams returns the parameters of the given types passed on the stack by
+// the calling convention.
+func stackParams(callconv enum.CallingConv, params []types.Type) []types.Type {
+	var stack []types.Type
+	for i, reg := range paramRegs(callconv, params) {
+		if reg == nil {
+			stack = append(stack, params[i])
+		}
+	}
+	return stack
+}
+
+// useParamReg returns the value of the given type of the parameter passed in
+// the given register, emitting code to f.
+func (f *Func) useParamReg(reg *x86.Reg, typ types.Type) value.Value {
+	if types.IsFloat(typ) {
+		return f.useRegElem(reg, typ)
+	}
+	return f.convert(f.useReg(reg), typ)
+}
+
+// defParamReg stores the value of the parameter passed in the given register,
+// emitting code to f.
+func (f *Func) defParamReg(reg *x86.Reg, v value.Value) {
+	if types.IsFloat(v.Type()) {
+		f.defRegElem(reg, v, v.Type())
+		return
+	}
+	f.defReg(reg, f.convert(v, regType(reg.Reg)))
+}
+
+// retST0 reports whether return values of the given function type are
+// returned in ST0.
+func (l *Lifter) retST0(sig *types.FuncType) bool {
+	return l.Mode == 32 && types.IsFloat(sig.RetType)
+}
+
+// callsST0 reports whether the function calls functions returning values in
+// ST0.
+func (f *Func) callsST0() bool {
+	for _, bb := range f.AsmFunc.Blocks {
+		for _, inst := range bb.Insts {
+			if inst.Op == x86asm.CALL && f.callRetST0(inst) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// callRetST0 reports whether the callee of the given call instruction returns
+// its value in ST0.
+func (f *Func) callRetST0(inst *x86.Inst) bool {
+	// Locate the callee in a dummy basic block, as f.getFunc may emit load
+	// instructions.
+	cur := f.cur
+	f.cur = &ir.BasicBlock{}
+	_, sig, _, ok := f.getFunc(inst.Arg(0))
+	f.cur = cur
+	return ok && f.l.retST0(sig)
+}
+
+// useResult returns the return value of the given type of the function, as
+// passed by the calling convention, emitting code to f.
+func (f *Func) useResult(typ types.Type) value.Value {
+	switch {
+	case f.l.retST0(f.Sig):
+		return f.fconv(f.fload(), typ.(*types.FloatType))
+	case f.l.Mode == 64:
+		if types.IsFloat(typ) {
+			return f.useRegElem(x86.X0, typ)
+		}
+		return f.convert(f.useReg(x86.RAX), typ)
+	case f.l.sizeOfTypeInBits(typ) == 64:
+		// EDX:EAX.
+		lo := f.cur.NewZExt(f.useReg(x86.EAX), types.I64)
+		hi := f.cur.NewZExt(f.useReg(x86.EDX), types.I64)
+		v := f.cur.NewOr(lo, f.cur.NewShl(hi, f.constInt(types.I64, 32)))
+		return f.convert(v, typ)
+	}
+	return f.convert(f.useReg(x86.EAX), typ)
+}
+
+// defResult binds the return value of a call to a function of the given type
+// to the return registers of the calling convention, emitting code to f.
+func (f *Func) defResult(sig *types.FuncType, result value.Value) {
+	typ := sig.RetType
+	switch {
+	case types.Equal(typ, types.Void):
+		// nothing to do.
+	case f.l.retST0(sig):
+		f.fpush(f.fext(result))
+	case f.l.Mode == 64:
+		if types.IsFloat(typ) {
+			f.defRegElem(x86.X0, result, typ)
+			return
+		}
+		f.defReg(x86.RAX, f.convert(result, types.I64))
+	case f.l.sizeOfTypeInBits(typ) == 64:
+		// EDX:EAX.
+		v := f.convert(result, types.I64)
+		f.defReg(x86.EAX, f.cur.NewTrunc(v, types.I32))
+		hi := f.cur.NewLShr(v, f.constInt(types.I64, 32))
+		f.defReg(x86.EDX, f.cur.NewTrunc(hi, types.I32))
+	default:
+		f.defReg(x86.EAX, f.convert(result, types.I32))
+	}
+}
+
+// checkStackParams reports whether the stack parameters of the given calling
+// convention are supported by the stack model of the lifter. Stack parameters
+// are not yet supported in 64-bit mode, in which case a warning is emitted.
+func (f *Func) checkStackParams(callconv enum.CallingConv, params []types.Type) bool {
+	if f.l.Mode == 64 && len(stackParams(callconv, params)) > 0 {
+		warn.Printf("support for stack arguments of calling convention %v in 64-bit mode not yet implemented; ignoring stack arguments", callconv)
+		return false
+	}
+	return true
+}
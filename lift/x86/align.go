@@ -0,0 +1,97 @@
+package x86
+
+import (
+	"fmt"
+
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/value"
+	"github.com/pkg/errors"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// AlignMode specifies the alignment of lifted memory accesses.
+//
+// Most x86 instructions permit misaligned memory operands, whereas LLVM
+// assumes the natural alignment of the accessed type for loads and stores
+// without an explicit alignment; code recompiled for targets with strict
+// alignment requirements may thus fault, and optimizations may miscompile
+// misaligned accesses. Marking memory accesses as unaligned (align 1) preserves
+// the semantics of the original code, at the cost of performance on such
+// targets.
+//
+// Independent of the alignment mode, memory accesses of instructions with
+// explicit alignment semantics are lifted accordingly; e.g. align 16 for MOVAPS
+// and MOVDQA (which fault on misaligned operands) and align 1 for MOVUPS and
+// MOVDQU.
+type AlignMode uint8
+
+// Alignment modes.
+const (
+	// AlignNatural assumes natural alignment of memory accesses.
+	AlignNatural AlignMode = iota
+	// AlignUnaligned marks memory accesses as unaligned (align 1).
+	AlignUnaligned
+)
+
+// String returns the string representation of the alignment mode.
+func (mode AlignMode) String() string {
+	switch mode {
+	case AlignNatural:
+		return "natural"
+	case AlignUnaligned:
+		return "unaligned"
+	}
+	return fmt.Sprintf("AlignMode(%d)", uint8(mode))
+}
+
+// Set sets the alignment mode to the given string; either "natural" or
+// "unaligned".
+func (mode *AlignMode) Set(s string) error {
+	switch s {
+	case "natural":
+		*mode = AlignNatural
+	case "unaligned":
+		*mode = AlignUnaligned
+	default:
+		return errors.Errorf("invalid alignment mode %q; expected natural or unaligned", s)
+	}
+	return nil
+}
+
+// memAlign returns the alignment of the memory access of the given instruction
+// (or of pushes and pops if nil) in bytes, based on the alignment mode of the
+// lifter; or 0 for natural alignment.
+func (l *Lifter) memAlign(inst *x86.Inst) ir.Align {
+	if inst != nil {
+		switch inst.Op {
+		// Explicitly unaligned moves.
+		case x86asm.MOVUPS, x86asm.MOVUPD, x86asm.MOVDQU, x86asm.LDDQU:
+			return 1
+		// Explicitly aligned moves; fault on misaligned operands.
+		case x86asm.MOVAPS, x86asm.MOVAPD, x86asm.MOVDQA, x86asm.MOVNTPS,
+			x86asm.MOVNTPD, x86asm.MOVNTDQ, x86asm.MOVNTDQA:
+			return ir.Align(inst.MemBytes)
+		}
+		// Legacy SSE instructions with 128-bit memory operands (e.g. ADDPS and
+		// PXOR) fault on misaligned operands, as does CMPXCHG16B.
+		if inst.MemBytes == 16 {
+			return 16
+		}
+	}
+	if l.Align == AlignUnaligned {
+		return 1
+	}
+	return 0
+}
+
+// align returns the alignment in bytes of the memory access through the given
+// pointer (prior to casts) of the specified instruction; or 0 for natural
+// alignment.
+func (f *Func) align(ptr value.Value, inst *x86.Inst) ir.Align {
+	if _, ok := ptr.(*ir.InstAlloca); ok {
+		// Local variables (e.g. stack slots) are allocated by the lifter.
+		return 0
+	}
+	return f.l.memAlign(inst)
+}
@@ -29,7 +29,7 @@ func (f *Func) useArg(arg *x86.Arg) value.Value {
 		mem := x86.NewMem(a, arg.Parent)
 		return f.useMem(mem)
 	case x86asm.Imm:
-		return constant.NewInt(types.I32, int64(a))
+		return f.constInt(operandType(arg.Parent), int64(a))
 	case x86asm.Rel:
 		next := arg.Parent.Addr + bin.Address(arg.Parent.Len)
 		addr := next + bin.Address(a)
@@ -94,6 +94,13 @@ func (f *Func) defArgElem(arg *x86.Arg, v value.Value, elem types.Type) {
 // useReg loads and returns a value from the given x86 register, emitting code
 // to f.
 func (f *Func) useReg(reg *x86.Reg) value.Named {
+	if isFPUReg(reg.Reg) {
+		// ST(i) is relative to the FPU register stack top.
+		return f.fuse(int(reg.Reg - x86asm.F0))
+	}
+	if full, offset, ok := subReg(reg.Reg, f.mode); ok {
+		return f.useSubReg(reg.Reg, full, offset)
+	}
 	src := f.reg(reg.Reg)
 	return f.cur.NewLoad(src)
 }
@@ -101,6 +108,10 @@ func (f *Func) useReg(reg *x86.Reg) value.Named {
 // useRegElem loads and returns a value of the specified element type from the
 // given x86 register, emitting code to f.
 func (f *Func) useRegElem(reg *x86.Reg, elem types.Type) value.Value {
+	if _, ok := regType(reg.Reg).(*types.IntType); ok {
+		// General-purpose registers may alias other registers.
+		return f.convert(f.useReg(reg), elem)
+	}
 	src := f.reg(reg.Reg)
 	typ := types.NewPointer(elem)
 	if !typ.Equal(src.Type()) {
@@ -111,9 +122,20 @@ func (f *Func) useRegElem(reg *x86.Reg, elem types.Type) value.Value {
 
 // defReg stores the value to the given x86 register, emitting code to f.
 func (f *Func) defReg(reg *x86.Reg, v value.Value) {
-	dst := f.reg(reg.Reg)
-	f.cur.NewStore(v, dst)
-	switch reg.Reg {
+	if isFPUReg(reg.Reg) {
+		// ST(i) is relative to the FPU register stack top.
+		f.fdef(int(reg.Reg-x86asm.F0), v)
+		return
+	}
+	full := reg.Reg
+	if r, offset, ok := subReg(reg.Reg, f.mode); ok {
+		f.defSubReg(reg.Reg, r, offset, v)
+		full = r
+	} else {
+		dst := f.reg(reg.Reg)
+		f.cur.NewStore(v, dst)
+	}
+	switch full {
 	case x86asm.EAX, x86asm.EDX:
 		// Redefine the PSEUDO-register EDX:EAX based on change in EAX or EDX.
 		f.redefEDX_EAX()
@@ -123,6 +145,11 @@ func (f *Func) defReg(reg *x86.Reg, v value.Value) {
 // defRegElem stores the value of the specified element type to the given x86
 // register, emitting code to f.
 func (f *Func) defRegElem(reg *x86.Reg, v value.Value, elem types.Type) {
+	if typ, ok := regType(reg.Reg).(*types.IntType); ok {
+		// General-purpose registers may alias other registers.
+		f.defReg(reg, f.convert(v, typ))
+		return
+	}
 	dst := f.reg(reg.Reg)
 	typ := types.NewPointer(elem)
 	if !typ.Equal(dst.Type()) {
@@ -132,12 +159,16 @@ func (f *Func) defRegElem(reg *x86.Reg, v value.Value, elem types.Type) {
 }
 
 // reg returns a pointer to the LLVM IR value associated with the given x86
-// register.
+// register. Sub-registers of general-purpose registers have no associated
+// value of their own, and are accessed through useReg and defReg.
 func (f *Func) reg(reg x86asm.Reg) value.Value {
 	if v, ok := f.regs[reg]; ok {
 		return v
 	}
 	typ := regType(reg)
+	if isFPUReg(reg) {
+		typ = f.l.fpuType()
+	}
 	v := ir.NewAlloca(typ)
 	name := strings.ToLower(x86.Register(reg).String())
 	v.SetName(name)
@@ -145,6 +176,41 @@ func (f *Func) reg(reg x86asm.Reg) value.Value {
 	return v
 }
 
+// useArgAs returns the value of the given argument as a value of the specified
+// type, emitting code to f. Immediates are sign-extended to the size of integer
+// types.
+func (f *Func) useArgAs(arg *x86.Arg, typ types.Type) value.Value {
+	if t, ok := typ.(*types.IntType); ok {
+		if imm, ok := arg.Arg.(x86asm.Imm); ok {
+			return f.constInt(t, int64(imm))
+		}
+	}
+	return f.useArg(arg)
+}
+
+// operandType returns the integer type of the operand size of the given
+// instruction; e.g. i16 for `add ax, 5`, and for `push word 5` in 32-bit mode.
+// Immediate operands are of the operand size.
+func operandType(inst *x86.Inst) *types.IntType {
+	if inst == nil {
+		return types.I32
+	}
+	switch a := inst.Args[0].(type) {
+	case x86asm.Reg:
+		if typ, ok := regType(a).(*types.IntType); ok {
+			return typ
+		}
+	case x86asm.Mem:
+		if inst.MemBytes != 0 {
+			return types.NewInt(uint64(inst.MemBytes) * 8)
+		}
+	}
+	if inst.DataSize != 0 {
+		return types.NewInt(uint64(inst.DataSize))
+	}
+	return types.I32
+}
+
 // === [ memory reference ] ====================================================
 
 // useMem loads and returns the value of the given memory reference, emitting
@@ -152,6 +218,7 @@ func (f *Func) reg(reg x86asm.Reg) value.Value {
 func (f *Func) useMem(mem *x86.Mem) value.Named {
 	src := f.mem(mem)
 	v := f.cur.NewLoad(src)
+	v.Align = f.align(src, mem.Parent)
 	if mem.Parent != nil && mem.Parent.MemBytes != 0 {
 		if t, ok := src.Type().(*types.PointerType); ok {
 			var indices []uint64
@@ -180,46 +247,54 @@ func (f *Func) useMem(mem *x86.Mem) value.Named {
 // useMemElem loads and returns a value of the specified element type from the
 // given memory reference, emitting code to f.
 func (f *Func) useMemElem(mem *x86.Mem, elem types.Type) value.Value {
-	src := f.mem(mem)
+	ptr := f.mem(mem)
+	src := ptr
 	typ := types.NewPointer(elem)
 	if !typ.Equal(src.Type()) {
 		src = f.cur.NewBitCast(src, typ)
 	}
-	return f.cur.NewLoad(src)
+	v := f.cur.NewLoad(src)
+	v.Align = f.align(ptr, mem.Parent)
+	return v
 }
 
 // defMem stores the value to the given memory reference, emitting code to f.
 func (f *Func) defMem(mem *x86.Mem, v value.Value) {
-	dst := f.mem(mem)
+	ptr := f.mem(mem)
 	// Bitcast pointer to appropriate size.
-	dst = f.castToPtr(dst, mem.Parent)
-	f.cur.NewStore(v, dst)
+	dst := f.castToPtr(ptr, mem.Parent)
+	store := f.cur.NewStore(v, dst)
+	store.Align = f.align(ptr, mem.Parent)
 }
 
 // defMemElem stores the value of the specified element type to the given memory
 // reference, emitting code to f.
 func (f *Func) defMemElem(mem *x86.Mem, v value.Value, elem types.Type) {
-	dst := f.mem(mem)
+	ptr := f.mem(mem)
+	dst := ptr
 	typ := types.NewPointer(elem)
 	if !typ.Equal(dst.Type()) {
 		dst = f.cur.NewBitCast(dst, typ)
 	}
-	f.cur.NewStore(v, dst)
+	store := f.cur.NewStore(v, dst)
+	store.Align = f.align(ptr, mem.Parent)
 }
 
 // mem returns a pointer to the LLVM IR value associated with the given memory
 // argument, emitting code to f.
 func (f *Func) mem(mem *x86.Mem) value.Value {
 	// Segment:[Base+Scale*Index+Disp].
-	var (
-		segment value.Value
-		base    value.Value
-		index   value.Value
-		disp    value.Value
-	)
 	if mem.Mem.Segment != 0 {
-		segment = f.useReg(mem.Segment())
+		if segBase, ok := f.segmentBase(mem); ok {
+			return f.segmentMem(mem, segBase)
+		}
+		// Flat segment; ignore segment.
 	}
+	var (
+		base  value.Value
+		index value.Value
+		disp  value.Value
+	)
 
 	// Parse Base register.
 	var rel bin.Address
@@ -236,18 +311,31 @@ func (f *Func) mem(mem *x86.Mem) value.Value {
 		index = f.useReg(mem.Index())
 	}
 
+	// Handle floating-point constants.
+	if base == nil && index == nil && mem.Disp != 0 {
+		if g, ok := f.floatConst(mem, rel+bin.Address(mem.Disp)); ok {
+			return g
+		}
+	}
+
 	// TODO: Add proper support for memory references.
-	//    Segment Reg
 	//    Base    Reg
 	//    Scale   uint8
 	//    Index   Reg
 	//    Disp    int64
 
+	// Handle accesses of recovered structs.
+	if base != nil && index == nil {
+		if src, ok := f.structFieldPtr(mem, base); ok {
+			return f.castToPtr(src, mem.Parent)
+		}
+	}
+
 	// Handle local variables.
-	if segment == nil && index == nil {
+	if index == nil {
 		// Stack local memory access.
 		switch mem.Mem.Base {
-		case x86asm.ESP, x86asm.EBP:
+		case x86asm.ESP, x86asm.EBP, x86asm.SP, x86asm.BP:
 			name := fmt.Sprintf("%s_%d", strings.ToLower(x86.Register(mem.Mem.Base).String()), f.espDisp+mem.Disp)
 			if v, ok := f.locals[name]; ok {
 				return v
@@ -260,6 +348,14 @@ func (f *Func) mem(mem *x86.Mem) value.Value {
 		}
 	}
 
+	// Handle 16-bit addressing (e.g. [bx+si+8]), where the effective address is
+	// computed modulo 64 KiB.
+	if mem.Parent != nil && mem.Parent.AddrSize == 16 && (base != nil || index != nil) {
+		off := f.cur.NewZExt(f.offset16(mem), types.I32)
+		src := f.cur.NewIntToPtr(off, types.I8Ptr)
+		return f.castToPtr(src, mem.Parent)
+	}
+
 	// Handle disposition.
 	if mem.Disp != 0 {
 		if context, ok := f.l.Contexts[mem.Parent.Addr]; ok {
@@ -291,13 +387,13 @@ func (f *Func) mem(mem *x86.Mem) value.Value {
 	}
 
 	// Early return for direct memory access.
-	if segment == nil && base == nil && index == nil {
+	if base == nil && index == nil {
 		if disp == nil {
 			addr := rel + bin.Address(mem.Disp)
 			// TODO: Remove once the lift library matures a bit.
 			warn.Printf("unknown global variable type at address %v; guessing i32", addr)
-			name := fmt.Sprintf("g_%06X", uint64(addr))
 			contentType := types.I32
+			name := f.l.Naming.DataName(addr, 4)
 			typ := types.NewPointer(contentType)
 			g := &ir.Global{
 				Typ:         typ,
@@ -321,13 +417,7 @@ func (f *Func) mem(mem *x86.Mem) value.Value {
 		return disp
 	}
 
-	// TODO: Handle Segment.
 	src := disp
-	if segment != nil {
-		// Ignore segments for now, assume byte addressing.
-		//pretty.Println(mem)
-		//panic("support for memory reference segment not yet implemented")
-	}
 
 	// Handle Base.
 	if base != nil {
@@ -365,6 +455,25 @@ func (f *Func) mem(mem *x86.Mem) value.Value {
 	return f.castToPtr(src, mem.Parent)
 }
 
+// offset16 returns the 16-bit effective address Base+Scale*Index+Disp of the
+// given memory reference, emitting code to f. The address wraps around on
+// overflow, as the arithmetic is carried out on 16-bit integers.
+func (f *Func) offset16(mem *x86.Mem) value.Value {
+	var off value.Value = f.constInt(types.I16, mem.Disp)
+	if mem.Mem.Base != 0 {
+		base := f.convert(f.useReg(mem.Base()), types.I16)
+		off = f.cur.NewAdd(off, base)
+	}
+	if mem.Mem.Index != 0 {
+		index := f.convert(f.useReg(mem.Index()), types.I16)
+		if mem.Scale > 1 {
+			index = f.cur.NewMul(index, f.constInt(types.I16, int64(mem.Scale)))
+		}
+		off = f.cur.NewAdd(off, index)
+	}
+	return off
+}
+
 // castToPtr casts the given value into a pointer, where the element type is
 // derrived from src and instruction prefixes, with instruction prefix takes
 // precedence.
@@ -389,10 +498,14 @@ func (f *Func) castToPtr(src value.Value, parent *x86.Inst) value.Value {
 				break
 			}
 			switch prefix &^ x86asm.PrefixImplicit {
-			case x86asm.PrefixData16:
-				bitSize = 16
+			case x86asm.PrefixDataSize, x86asm.PrefixData16, x86asm.PrefixData32:
+				// operand size override; reflected by MemBytes.
+			case x86asm.PrefixAddrSize, x86asm.PrefixAddr16, x86asm.PrefixAddr32:
+				// address size override; handled by f.mem.
 			case x86asm.PrefixREP, x86asm.PrefixREPN:
 				// nothing to do.
+			case x86asm.PrefixCS, x86asm.PrefixDS, x86asm.PrefixES, x86asm.PrefixFS, x86asm.PrefixGS, x86asm.PrefixSS:
+				// segment override; handled by f.mem.
 			case x86asm.PrefixREX | x86asm.PrefixREXW:
 				// TODO: Implement support for REX.W
 			default:
@@ -459,14 +572,17 @@ func (status StatusFlag) String() string {
 // useStatus loads and returns the value of the given x86 status flag, emitting
 // code to f.
 func (f *Func) useStatus(status StatusFlag) value.Value {
+	if f.flags != nil && f.flags.defines(status) {
+		return f.flag(status)
+	}
 	src := f.status(status)
 	return f.cur.NewLoad(src)
 }
 
 // defStatus stores the value to the given x86 status flag, emitting code to f.
 func (f *Func) defStatus(status StatusFlag, v value.Value) {
-	dst := f.status(status)
-	f.cur.NewStore(v, dst)
+	f.flushFlags()
+	f.storeFlag(status, v)
 }
 
 // status returns a pointer to the LLVM IR value associated with the given x86
@@ -698,6 +814,14 @@ func (f *Func) getFunc(arg *x86.Arg) (value.Named, *types.FuncType, enum.Calling
 		}
 	}
 
+	// Check if a single target of the indirect call was observed at runtime.
+	if targets := f.l.Indirect[arg.Parent.Addr]; len(targets) == 1 {
+		if fn, ok := f.l.Funcs[targets[0]]; ok {
+			v := fn.Function
+			return v, v.Sig, v.CallingConv, true
+		}
+	}
+
 	if addr, ok := f.getAddr(arg); ok {
 		if fn, ok := f.l.Funcs[addr]; ok {
 			v := fn.Function
@@ -0,0 +1,68 @@
+package x86
+
+import (
+	"time"
+
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/metadata"
+	"github.com/llir/llvm/ir/types"
+)
+
+// A Budget limits the resources spent lifting each function, as very large or
+// pathological functions may otherwise stall the lifter. The body of functions
+// exceeding the budget is replaced by a stub, which returns the zero value of
+// the return type, and the reason is recorded in the "skipped" metadata
+// attachment of the function.
+type Budget struct {
+	// Maximum number of instructions of each function; or 0 if unlimited.
+	MaxInsts int
+	// Maximum wall-clock time spent lifting each function; or 0 if unlimited.
+	Timeout time.Duration
+}
+
+// numInsts returns the number of instructions of the input assembly of the
+// function, including terminators.
+func (f *Func) numInsts() int {
+	n := 0
+	for _, block := range f.AsmFunc.Blocks {
+		n += len(block.Insts) + 1
+	}
+	return n
+}
+
+// exceedsTimeout reports whether the time spent lifting the function exceeds
+// the time budget.
+func (f *Func) exceedsTimeout() bool {
+	timeout := f.l.Budget.Timeout
+	return timeout > 0 && time.Since(f.start) > timeout
+}
+
+// stub replaces the body of the function with a stub, and records the reason
+// the function was skipped. The stub calls the __unsupported runtime support
+// function before returning.
+func (f *Func) stub(reason string) {
+	warn.Printf("skipping function %q at %v; %s", f.Name(), f.AsmFunc.Addr, reason)
+	f.Skipped = reason
+	block := &ir.BasicBlock{}
+	// Report execution of the stub at runtime, with the address of the
+	// instruction which failed to lift if known.
+	addr := f.AsmFunc.Addr
+	if f.SkippedAt != 0 {
+		addr = f.SkippedAt
+	}
+	block.NewCall(f.l.intrinsics[unsupportedFunc], constant.NewInt(types.I64, int64(addr)))
+	if types.Equal(f.Sig.RetType, types.Void) {
+		block.NewRet(nil)
+	} else {
+		block.NewRet(constant.NewZeroInitializer(f.Sig.RetType))
+	}
+	f.Blocks = []*ir.BasicBlock{block}
+	md := &metadata.Attachment{
+		Name: "skipped",
+		Node: &metadata.Tuple{
+			Fields: []metadata.Field{&metadata.String{Value: reason}},
+		},
+	}
+	f.Metadata = append(f.Metadata, md)
+}
@@ -0,0 +1,428 @@
+package x86
+
+import (
+	"fmt"
+
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/enum"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+)
+
+// Status Flags
+//
+// The status flags are computed lazily. Instructions which define the status
+// flags record the class of the operation (e.g. sub) and its operands as the
+// pending flag definition of the current basic block, rather than computing
+// each status flag eagerly. Condition codes, as used by Jcc, SETcc, CMOVcc and
+// FCMOVcc, are computed directly from the operands of the pending flag
+// definition when possible.
+//
+//    cmp eax, ebx
+//    jl  foo
+//
+//    %1 = icmp slt i32 %eax, %ebx
+//    br i1 %1, label %foo, label %bar
+//
+// Individual status flags are computed on use from the pending flag definition,
+// and stored to their local variables when redefined directly (e.g. by MUL),
+// and at the end of each basic block, if live; i.e. if they may be used by
+// succeeding instructions or successor basic blocks. Computed status flags and
+// condition codes are reused for the lifetime of the pending flag definition.
+//
+// ref: $ 3.4.3.1 Status Flags, Intel 64 and IA-32 Architectures Software
+// Developer's Manual
+
+// flagOp specifies the class of an instruction which defines the status flags.
+type flagOp uint8
+
+// Classes of instructions which define the status flags.
+const (
+	// result = x + y (ADD, INC), or x + y + CF (ADC).
+	flagOpAdd flagOp = iota + 1
+	// result = x - y (SUB, CMP, DEC, NEG), or x - (y + CF) (SBB).
+	flagOpSub
+	// result = x op y, with CF and OF cleared (AND, OR, XOR, TEST).
+	flagOpLogic
+	// Floating-point compare of x and y, without result (FCOMI, FUCOMI).
+	flagOpFCmp
+)
+
+// flagDef is a pending definition of the status flags.
+type flagDef struct {
+	// Class of the flag-defining instruction.
+	op flagOp
+	// Operands and result of the flag-defining instruction.
+	x, y, result value.Value
+	// CF is left unaffected by the flag-defining instruction (INC, DEC).
+	keepCF bool
+	// Incoming CF of the flag-defining instruction (ADC, SBB); or nil if the
+	// carry is not used.
+	carry value.Value
+	// Computed status flags.
+	flags map[StatusFlag]value.Value
+	// Computed condition codes.
+	conds map[condCode]value.Value
+}
+
+// defines reports whether the given status flag is defined by the flag
+// definition. AF is undefined after logical operations.
+func (def *flagDef) defines(status StatusFlag) bool {
+	switch status {
+	case CF:
+		return !def.keepCF
+	case AF:
+		return def.op != flagOpLogic
+	}
+	return true
+}
+
+// condCode is an x86 condition code, in encoding order.
+type condCode uint8
+
+// Condition codes.
+const (
+	condO  condCode = iota // OF=1
+	condNO                 // OF=0
+	condB                  // CF=1
+	condAE                 // CF=0
+	condE                  // ZF=1
+	condNE                 // ZF=0
+	condBE                 // CF=1 or ZF=1
+	condA                  // CF=0 and ZF=0
+	condS                  // SF=1
+	condNS                 // SF=0
+	condP                  // PF=1
+	condNP                 // PF=0
+	condL                  // SF≠OF
+	condGE                 // SF=OF
+	condLE                 // ZF=1 or SF≠OF
+	condG                  // ZF=0 and SF=OF
+)
+
+// Name of LLVM intrinsic which counts the number of set bits of an 8-bit
+// integer; used to compute PF.
+const intrinsicCtpop = "llvm.ctpop.i8"
+
+// setFlags records the pending definition of the status flags by an
+// instruction of the given class, with operands x and y and the given result,
+// emitting code to f.
+func (f *Func) setFlags(op flagOp, x, y, result value.Value) {
+	f.setFlagsDef(&flagDef{op: op, x: x, y: y, result: result})
+}
+
+// setFlagsCarry records the pending definition of the status flags by an add or
+// subtract with carry (ADC, SBB), with operands x and y, incoming CF carry and
+// the given result, emitting code to f.
+func (f *Func) setFlagsCarry(op flagOp, x, y, carry, result value.Value) {
+	f.setFlagsDef(&flagDef{op: op, x: x, y: y, result: result, carry: carry})
+}
+
+// setFlagsKeepCF records the pending definition of the status flags by an
+// instruction which leaves CF unaffected (INC, DEC), emitting code to f.
+func (f *Func) setFlagsKeepCF(op flagOp, x, y, result value.Value) {
+	f.setFlagsDef(&flagDef{op: op, x: x, y: y, result: result, keepCF: true})
+}
+
+// setFlagsDef records the given pending definition of the status flags,
+// emitting code to f.
+func (f *Func) setFlagsDef(def *flagDef) {
+	// Floating-point compares have no result; their status flags are computed
+	// from the operands.
+	if def.op != flagOpFCmp {
+		if _, ok := def.result.Type().(*types.IntType); !ok {
+			// Status flags of non-integer operations (e.g. pointer arithmetic)
+			// are not yet modeled; leave status flags unaffected.
+			f.flushFlags()
+			return
+		}
+	}
+	if def.keepCF && f.flags != nil && f.flags.defines(CF) && f.live.has(CF) {
+		// Store CF of the previous flag definition, as it is left unaffected.
+		f.storeFlag(CF, f.flag(CF))
+	}
+	def.flags = make(map[StatusFlag]value.Value)
+	def.conds = make(map[condCode]value.Value)
+	f.flags = def
+}
+
+// flushFlags stores the status flags of the pending flag definition to their
+// local variables, and clears the pending flag definition, emitting code to f.
+func (f *Func) flushFlags() {
+	f.storeFlags()
+	f.flags = nil
+}
+
+// storeFlags stores the live status flags of the pending flag definition to
+// their local variables, emitting code to f. The pending flag definition is
+// kept, to allow computing condition codes directly from its operands (e.g. by
+// the terminator of the basic block).
+func (f *Func) storeFlags() {
+	if f.flags == nil {
+		return
+	}
+	for status := firstStatusFlag; status <= lastStatusFlag; status++ {
+		if f.flags.defines(status) && f.live.has(status) {
+			f.storeFlag(status, f.flag(status))
+		}
+	}
+}
+
+// flag returns the value of the given status flag as defined by the pending
+// flag definition, emitting code to f.
+func (f *Func) flag(status StatusFlag) value.Value {
+	def := f.flags
+	if v, ok := def.flags[status]; ok {
+		return v
+	}
+	if def.op == flagOpFCmp {
+		v := f.fcmpFlag(status)
+		def.flags[status] = v
+		return v
+	}
+	typ := def.result.Type().(*types.IntType)
+	zero := f.constInt(typ, 0)
+	var v value.Value
+	switch status {
+	// CF (bit 0) Carry flag - Set if an arithmetic operation generates a carry
+	// or a borrow out of the most-significant bit of the result; cleared
+	// otherwise.
+	case CF:
+		switch def.op {
+		case flagOpAdd:
+			v = f.cur.NewICmp(enum.IPredULT, def.result, def.x)
+			if def.carry != nil {
+				// The incoming carry also carries out if y is all ones; i.e. if
+				// the result equals x.
+				eq := f.cur.NewICmp(enum.IPredEQ, def.result, def.x)
+				v = f.cur.NewOr(v, f.cur.NewAnd(def.carry, eq))
+			}
+		case flagOpSub:
+			v = f.cur.NewICmp(enum.IPredULT, def.x, def.y)
+			if def.carry != nil {
+				// The incoming borrow also borrows out if x equals y.
+				eq := f.cur.NewICmp(enum.IPredEQ, def.x, def.y)
+				v = f.cur.NewOr(v, f.cur.NewAnd(def.carry, eq))
+			}
+		case flagOpLogic:
+			v = constant.False
+		}
+	// PF (bit 2) Parity flag - Set if the least-significant byte of the result
+	// contains an even number of 1 bits; cleared otherwise.
+	case PF:
+		low := value.Value(def.result)
+		if typ.BitSize != 8 {
+			low = f.cur.NewTrunc(low, types.I8)
+		}
+		n := f.cur.NewCall(f.l.intrinsics[intrinsicCtpop], low)
+		odd := f.cur.NewAnd(n, f.constInt(types.I8, 1))
+		v = f.cur.NewICmp(enum.IPredEQ, odd, f.constInt(types.I8, 0))
+	// AF (bit 4) Auxiliary Carry flag - Set if an arithmetic operation generates
+	// a carry or a borrow out of bit 3 of the result; cleared otherwise.
+	case AF:
+		tmp := f.cur.NewXor(f.cur.NewXor(def.x, def.y), def.result)
+		bit := f.cur.NewAnd(tmp, f.constInt(typ, 0x10))
+		v = f.cur.NewICmp(enum.IPredNE, bit, zero)
+	// ZF (bit 6) Zero flag - Set if the result is zero; cleared otherwise.
+	case ZF:
+		v = f.cur.NewICmp(enum.IPredEQ, def.result, zero)
+	// SF (bit 7) Sign flag - Set equal to the most-significant bit of the
+	// result, which is the sign bit of a signed integer.
+	case SF:
+		v = f.cur.NewICmp(enum.IPredSLT, def.result, zero)
+	// OF (bit 11) Overflow flag - Set if the integer result is too large a
+	// positive number or too small a negative number (excluding the sign-bit) to
+	// fit in the destination operand; cleared otherwise.
+	case OF:
+		switch def.op {
+		case flagOpAdd:
+			// Operands of equal sign, and result of different sign.
+			a := f.cur.NewXor(def.x, def.result)
+			b := f.cur.NewXor(def.y, def.result)
+			v = f.cur.NewICmp(enum.IPredSLT, f.cur.NewAnd(a, b), zero)
+		case flagOpSub:
+			// Operands of different sign, and result of different sign than x.
+			a := f.cur.NewXor(def.x, def.y)
+			b := f.cur.NewXor(def.x, def.result)
+			v = f.cur.NewICmp(enum.IPredSLT, f.cur.NewAnd(a, b), zero)
+		case flagOpLogic:
+			v = constant.False
+		}
+	default:
+		panic(fmt.Errorf("support for status flag %v not yet implemented", status))
+	}
+	def.flags[status] = v
+	return v
+}
+
+// storeFlag stores the value to the local variable of the given status flag,
+// emitting code to f.
+func (f *Func) storeFlag(status StatusFlag, v value.Value) {
+	dst := f.status(status)
+	f.cur.NewStore(v, dst)
+}
+
+// cond returns the value of the given condition code, emitting code to f. The
+// condition is computed directly from the operands of the pending flag
+// definition when possible; and from the status flags otherwise.
+func (f *Func) cond(cc condCode) value.Value {
+	def := f.flags
+	if def == nil {
+		return f.condFromFlags(cc)
+	}
+	if v, ok := def.conds[cc]; ok {
+		return v
+	}
+	v := f.condDirect(cc)
+	if v == nil {
+		v = f.condFromFlags(cc)
+	}
+	def.conds[cc] = v
+	return v
+}
+
+// condDirect returns the value of the given condition code computed directly
+// from the operands of the pending flag definition, or nil if not supported,
+// emitting code to f.
+func (f *Func) condDirect(cc condCode) value.Value {
+	def := f.flags
+	switch def.op {
+	case flagOpSub:
+		if def.carry != nil {
+			// sbb x, y; conditions depend on the incoming borrow.
+			return nil
+		}
+		// cmp x, y
+		preds := map[condCode]enum.IPred{
+			condE:  enum.IPredEQ,
+			condNE: enum.IPredNE,
+			condL:  enum.IPredSLT,
+			condGE: enum.IPredSGE,
+			condLE: enum.IPredSLE,
+			condG:  enum.IPredSGT,
+		}
+		if !def.keepCF {
+			// Unsigned conditions depend on CF.
+			preds[condB] = enum.IPredULT
+			preds[condAE] = enum.IPredUGE
+			preds[condBE] = enum.IPredULE
+			preds[condA] = enum.IPredUGT
+		}
+		if pred, ok := preds[cc]; ok {
+			return f.cur.NewICmp(pred, def.x, def.y)
+		}
+	case flagOpLogic:
+		// test x, y; CF and OF are cleared.
+		preds := map[condCode]enum.IPred{
+			condE:  enum.IPredEQ,
+			condNE: enum.IPredNE,
+			condBE: enum.IPredEQ,
+			condA:  enum.IPredNE,
+			condS:  enum.IPredSLT,
+			condNS: enum.IPredSGE,
+			condL:  enum.IPredSLT,
+			condGE: enum.IPredSGE,
+			condLE: enum.IPredSLE,
+			condG:  enum.IPredSGT,
+		}
+		if pred, ok := preds[cc]; ok {
+			zero := f.constInt(def.result.Type().(*types.IntType), 0)
+			return f.cur.NewICmp(pred, def.result, zero)
+		}
+		switch cc {
+		case condO, condB:
+			return constant.False
+		case condNO, condAE:
+			return constant.True
+		}
+	case flagOpFCmp:
+		// fcomi x, y
+		preds := map[condCode]enum.FPred{
+			condE:  enum.FPredUEQ,
+			condNE: enum.FPredONE,
+			condB:  enum.FPredULT,
+			condAE: enum.FPredOGE,
+			condBE: enum.FPredULE,
+			condA:  enum.FPredOGT,
+			condP:  enum.FPredUNO,
+			condNP: enum.FPredORD,
+		}
+		if pred, ok := preds[cc]; ok {
+			return f.cur.NewFCmp(pred, def.x, def.y)
+		}
+	}
+	return nil
+}
+
+// fcmpFlag returns the value of the given status flag as defined by the pending
+// floating-point compare, emitting code to f.
+//
+//    Comparison results   ZF PF CF
+//
+//    x > y                 0  0  0
+//    x < y                 0  0  1
+//    x = y                 1  0  0
+//    Unordered             1  1  1
+//
+// OF, SF and AF are cleared.
+func (f *Func) fcmpFlag(status StatusFlag) value.Value {
+	def := f.flags
+	switch status {
+	case CF:
+		return f.cur.NewFCmp(enum.FPredULT, def.x, def.y)
+	case PF:
+		return f.cur.NewFCmp(enum.FPredUNO, def.x, def.y)
+	case ZF:
+		return f.cur.NewFCmp(enum.FPredUEQ, def.x, def.y)
+	case AF, SF, OF:
+		return constant.False
+	default:
+		panic(fmt.Errorf("support for status flag %v not yet implemented", status))
+	}
+}
+
+// condFromFlags returns the value of the given condition code computed from the
+// status flags, emitting code to f.
+func (f *Func) condFromFlags(cc condCode) value.Value {
+	not := func(v value.Value) value.Value {
+		return f.cur.NewXor(v, constant.True)
+	}
+	switch cc {
+	case condO:
+		return f.useStatus(OF)
+	case condNO:
+		return not(f.useStatus(OF))
+	case condB:
+		return f.useStatus(CF)
+	case condAE:
+		return not(f.useStatus(CF))
+	case condE:
+		return f.useStatus(ZF)
+	case condNE:
+		return not(f.useStatus(ZF))
+	case condBE:
+		return f.cur.NewOr(f.useStatus(CF), f.useStatus(ZF))
+	case condA:
+		return not(f.cur.NewOr(f.useStatus(CF), f.useStatus(ZF)))
+	case condS:
+		return f.useStatus(SF)
+	case condNS:
+		return not(f.useStatus(SF))
+	case condP:
+		return f.useStatus(PF)
+	case condNP:
+		return not(f.useStatus(PF))
+	case condL:
+		return f.cur.NewICmp(enum.IPredNE, f.useStatus(SF), f.useStatus(OF))
+	case condGE:
+		return f.cur.NewICmp(enum.IPredEQ, f.useStatus(SF), f.useStatus(OF))
+	case condLE:
+		lt := f.cur.NewICmp(enum.IPredNE, f.useStatus(SF), f.useStatus(OF))
+		return f.cur.NewOr(f.useStatus(ZF), lt)
+	case condG:
+		ge := f.cur.NewICmp(enum.IPredEQ, f.useStatus(SF), f.useStatus(OF))
+		return f.cur.NewAnd(not(f.useStatus(ZF)), ge)
+	default:
+		panic(fmt.Errorf("support for condition code %d not yet implemented", cc))
+	}
+}
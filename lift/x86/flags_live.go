@@ -0,0 +1,211 @@
+package x86
+
+import (
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// Status Flag Liveness
+//
+// The status flags of the pending flag definition are only stored to their
+// local variables if live; i.e. if they may be used by succeeding instructions
+// of the basic block, or by successor basic blocks, before being redefined.
+// Liveness is computed at function level from the status flags used and
+// defined by each instruction, as modelled by the lifter.
+//
+//    block_1:               ; live-out: ZF
+//       dec  ecx
+//       jmp  block_2
+//    block_2:               ; live-in: ZF
+//       jnz  block_1
+//
+// Status flags are considered live at the end of basic blocks terminated by
+// indirect jumps with unresolved targets.
+
+// flagSet is a set of status flags, as a bitmask indexed by StatusFlag.
+type flagSet uint8
+
+// allStatusFlags is the set of all arithmetic status flags.
+const allStatusFlags flagSet = 1<<(lastStatusFlag+1) - 1
+
+// newFlagSet returns the set of the given status flags.
+func newFlagSet(statuses ...StatusFlag) flagSet {
+	var set flagSet
+	for _, status := range statuses {
+		set |= 1 << status
+	}
+	return set
+}
+
+// has reports whether the set contains the given status flag.
+func (set flagSet) has(status StatusFlag) bool {
+	return set&(1<<status) != 0
+}
+
+// condFlags specifies the status flags used by each condition code.
+var condFlags = map[condCode]flagSet{
+	condO:  newFlagSet(OF),
+	condNO: newFlagSet(OF),
+	condB:  newFlagSet(CF),
+	condAE: newFlagSet(CF),
+	condE:  newFlagSet(ZF),
+	condNE: newFlagSet(ZF),
+	condBE: newFlagSet(CF, ZF),
+	condA:  newFlagSet(CF, ZF),
+	condS:  newFlagSet(SF),
+	condNS: newFlagSet(SF),
+	condP:  newFlagSet(PF),
+	condNP: newFlagSet(PF),
+	condL:  newFlagSet(SF, OF),
+	condGE: newFlagSet(SF, OF),
+	condLE: newFlagSet(ZF, SF, OF),
+	condG:  newFlagSet(ZF, SF, OF),
+}
+
+// opConds specifies the condition code of each instruction opcode which uses
+// a condition code.
+var opConds = map[x86asm.Op]condCode{
+	// Jcc.
+	x86asm.JO: condO, x86asm.JNO: condNO, x86asm.JB: condB, x86asm.JAE: condAE,
+	x86asm.JE: condE, x86asm.JNE: condNE, x86asm.JBE: condBE, x86asm.JA: condA,
+	x86asm.JS: condS, x86asm.JNS: condNS, x86asm.JP: condP, x86asm.JNP: condNP,
+	x86asm.JL: condL, x86asm.JGE: condGE, x86asm.JLE: condLE, x86asm.JG: condG,
+	// SETcc.
+	x86asm.SETO: condO, x86asm.SETNO: condNO, x86asm.SETB: condB, x86asm.SETAE: condAE,
+	x86asm.SETE: condE, x86asm.SETNE: condNE, x86asm.SETBE: condBE, x86asm.SETA: condA,
+	x86asm.SETS: condS, x86asm.SETNS: condNS, x86asm.SETP: condP, x86asm.SETNP: condNP,
+	x86asm.SETL: condL, x86asm.SETGE: condGE, x86asm.SETLE: condLE, x86asm.SETG: condG,
+	// CMOVcc.
+	x86asm.CMOVO: condO, x86asm.CMOVNO: condNO, x86asm.CMOVB: condB, x86asm.CMOVAE: condAE,
+	x86asm.CMOVE: condE, x86asm.CMOVNE: condNE, x86asm.CMOVBE: condBE, x86asm.CMOVA: condA,
+	x86asm.CMOVS: condS, x86asm.CMOVNS: condNS, x86asm.CMOVP: condP, x86asm.CMOVNP: condNP,
+	x86asm.CMOVL: condL, x86asm.CMOVGE: condGE, x86asm.CMOVLE: condLE, x86asm.CMOVG: condG,
+	// FCMOVcc.
+	x86asm.FCMOVB: condB, x86asm.FCMOVNB: condAE, x86asm.FCMOVE: condE, x86asm.FCMOVNE: condNE,
+	x86asm.FCMOVBE: condBE, x86asm.FCMOVNBE: condA, x86asm.FCMOVU: condP, x86asm.FCMOVNU: condNP,
+	// LOOPcc.
+	x86asm.LOOPE: condE, x86asm.LOOPNE: condNE,
+}
+
+// statusUses returns the status flags which may be used by instructions of
+// the given opcode.
+func statusUses(op x86asm.Op) flagSet {
+	if cc, ok := opConds[op]; ok {
+		return condFlags[cc]
+	}
+	switch op {
+	case x86asm.ADC, x86asm.SBB, x86asm.CMC, x86asm.RCL, x86asm.RCR:
+		return newFlagSet(CF)
+	case x86asm.LAHF:
+		return newFlagSet(CF, PF, AF, ZF, SF)
+	case x86asm.PUSHF, x86asm.PUSHFD, x86asm.PUSHFQ:
+		return allStatusFlags
+	case x86asm.INTO:
+		return newFlagSet(OF)
+	}
+	return 0
+}
+
+// statusDefs returns the status flags defined, or left undefined, by
+// instructions of the given opcode regardless of their operands.
+func statusDefs(op x86asm.Op) flagSet {
+	switch op {
+	case x86asm.ADC, x86asm.ADD, x86asm.AND, x86asm.CMP, x86asm.IMUL, x86asm.MUL, x86asm.NEG, x86asm.OR, x86asm.SBB, x86asm.SUB, x86asm.TEST, x86asm.XOR:
+		return allStatusFlags
+	case x86asm.FCOMI, x86asm.FCOMIP, x86asm.FUCOMI, x86asm.FUCOMIP:
+		return allStatusFlags
+	case x86asm.INC, x86asm.DEC:
+		// CF is left unaffected.
+		return allStatusFlags &^ newFlagSet(CF)
+	case x86asm.RCL, x86asm.RCR:
+		return newFlagSet(CF)
+	case x86asm.SAHF:
+		// OF is left unaffected.
+		return allStatusFlags &^ newFlagSet(OF)
+	case x86asm.POPF, x86asm.POPFD, x86asm.POPFQ:
+		// Status flags are restored from the stack.
+		return allStatusFlags
+	case x86asm.CALL:
+		// Status flags are undefined after calls, as the callee may clobber
+		// them.
+		return allStatusFlags
+	}
+	return 0
+}
+
+// liveFlags returns the status flags live at the end of each basic block of
+// the function.
+func (f *Func) liveFlags() map[bin.Address]flagSet {
+	// Status flags used before being defined, and status flags defined, by each
+	// basic block.
+	uses := make(map[bin.Address]flagSet)
+	defs := make(map[bin.Address]flagSet)
+	for blockAddr, bb := range f.AsmFunc.Blocks {
+		var use, def flagSet
+		for _, inst := range append(normalize(bb), bb.Term) {
+			use |= statusUses(inst.Op) &^ def
+			def |= statusDefs(inst.Op)
+		}
+		uses[blockAddr] = use
+		defs[blockAddr] = def
+	}
+	// Propagate liveness backwards from successor basic blocks until a fixed
+	// point is reached.
+	succs := make(map[bin.Address][]bin.Address)
+	live := make(map[bin.Address]flagSet)
+	for blockAddr, bb := range f.AsmFunc.Blocks {
+		for _, target := range f.l.Targets(bb.Term, f.AsmFunc.Addr) {
+			if _, ok := f.AsmFunc.Blocks[target]; ok {
+				succs[blockAddr] = append(succs[blockAddr], target)
+			}
+		}
+		if f.unresolvedJump(bb.Term, succs[blockAddr]) {
+			live[blockAddr] = allStatusFlags
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for blockAddr := range f.AsmFunc.Blocks {
+			out := live[blockAddr]
+			for _, succ := range succs[blockAddr] {
+				out |= uses[succ] | live[succ]&^defs[succ]
+			}
+			if out != live[blockAddr] {
+				live[blockAddr] = out
+				changed = true
+			}
+		}
+	}
+	return live
+}
+
+// liveFlagsAfter returns the status flags live after each of the given
+// instructions of the basic block, based on the status flags live at the end
+// of the basic block.
+func liveFlagsAfter(insts []*x86.Inst, term *x86.Inst, liveOut flagSet) []flagSet {
+	after := make([]flagSet, len(insts))
+	live := liveOut | statusUses(term.Op)
+	for i := len(insts) - 1; i >= 0; i-- {
+		after[i] = live
+		live = live&^statusDefs(insts[i].Op) | statusUses(insts[i].Op)
+	}
+	return after
+}
+
+// unresolvedJump reports whether the given terminator is an indirect jump with
+// unresolved targets, based on the given successors within the function.
+func (f *Func) unresolvedJump(term *x86.Inst, succs []bin.Address) bool {
+	if len(succs) > 0 {
+		return false
+	}
+	switch term.Op {
+	case x86asm.JMP:
+		_, ok := term.Args[0].(x86asm.Rel)
+		return !ok
+	case x86asm.LJMP:
+		_, ok := f.l.FarTarget(term)
+		return !ok
+	}
+	return false
+}
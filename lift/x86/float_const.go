@@ -0,0 +1,175 @@
+package x86
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/metadata"
+	"github.com/llir/llvm/ir/types"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// Floating-point Constants
+//
+// Floating-point literals are commonly stored in read-only sections, and
+// loaded by x87 FPU and SSE instructions through absolute (or RIP-relative)
+// memory references.
+//
+//    fld     qword ptr [0x402008]
+//
+// The literals are decoded from the binary executable, and lifted to constant
+// global variables of floating-point type, annotated with the decoded value.
+//
+//    @g_402008 = constant double 2.5, !addr !0, !value !1
+//
+//    !0 = !{!"0x00402008"}
+//    !1 = !{!"2.5"}
+//
+// Literals of the 80-bit double extended-precision format are emitted in
+// hexadecimal form, as decoded from their bit representation.
+//
+//    @g_402010 = constant x86_fp80 0xK4000C90FDAA22168C235, !addr !2, !value !3
+
+// floatConst returns a constant global variable holding the floating-point
+// literal referenced by the given memory reference at the specified address.
+// The boolean return value indicates success; i.e. the memory reference is read
+// as a floating-point value by its instruction, and the address is located in a
+// read-only section.
+func (f *Func) floatConst(mem *x86.Mem, addr bin.Address) (*ir.Global, bool) {
+	if mem.Parent == nil {
+		return nil, false
+	}
+	typ, ok := floatMemType(mem.Parent)
+	if !ok {
+		return nil, false
+	}
+	if g, ok := f.l.Globals[addr]; ok {
+		// Reuse floating-point constants of other instructions.
+		if g.Immutable && typ.Equal(g.ContentType) {
+			return g, true
+		}
+		return nil, false
+	}
+	space := f.l.File.AddressSpace()
+	sects := space.Sections(addr)
+	if len(sects) == 0 {
+		return nil, false
+	}
+	for _, sect := range sects {
+		if sect.Perm&bin.PermW != 0 || sect.Flags&bin.SectUninit != 0 {
+			return nil, false
+		}
+	}
+	size := int(f.l.sizeOfTypeInBits(typ) / 8)
+	data, ok := space.Bytes(addr, 0)
+	if !ok || len(data) < size {
+		return nil, false
+	}
+	c, ok := decodeFloat(typ, data[:size])
+	if !ok {
+		return nil, false
+	}
+	g := &ir.Global{
+		Typ:         types.NewPointer(typ),
+		ContentType: typ,
+		Init:        c,
+		Immutable:   true,
+	}
+	g.SetName(f.l.Naming.DataName(addr, size))
+	g.Metadata = append(g.Metadata, &metadata.Attachment{
+		Name: "addr",
+		Node: &metadata.Tuple{
+			Fields: []metadata.Field{&metadata.String{Value: addr.String()}},
+		},
+	})
+	value := floatText(c)
+	g.Metadata = append(g.Metadata, &metadata.Attachment{
+		Name: "value",
+		Node: &metadata.Tuple{
+			Fields: []metadata.Field{&metadata.String{Value: value}},
+		},
+	})
+	dbg.Printf("floating-point constant %s at %v referenced from %v instruction at %v", value, addr, mem.Parent.Op, mem.Parent.Addr)
+	// TODO: don't write to f.l from here as it should be read-only to allow for
+	// concurrent execution.
+	f.l.Globals[addr] = g
+	return g, true
+}
+
+// floatMemType returns the floating-point type of the memory operand read by
+// the given instruction. The boolean return value indicates success.
+func floatMemType(inst *x86.Inst) (*types.FloatType, bool) {
+	switch inst.Op {
+	// x87 FPU instructions.
+	case x86asm.FLD, x86asm.FADD, x86asm.FSUB, x86asm.FSUBR, x86asm.FMUL,
+		x86asm.FDIV, x86asm.FDIVR, x86asm.FCOM, x86asm.FCOMP:
+		switch inst.MemBytes {
+		case 4:
+			return types.Float, true
+		case 8:
+			return types.Double, true
+		case 10:
+			return types.X86FP80, true
+		}
+	// Scalar single-precision SSE instructions.
+	case x86asm.MOVSS, x86asm.ADDSS, x86asm.SUBSS, x86asm.MULSS, x86asm.DIVSS,
+		x86asm.MINSS, x86asm.MAXSS, x86asm.SQRTSS, x86asm.COMISS,
+		x86asm.UCOMISS, x86asm.CVTSS2SD:
+		if inst.MemBytes == 4 {
+			return types.Float, true
+		}
+	// Scalar double-precision SSE instructions.
+	case x86asm.MOVSD_XMM, x86asm.ADDSD, x86asm.SUBSD, x86asm.MULSD,
+		x86asm.DIVSD, x86asm.MINSD, x86asm.MAXSD, x86asm.SQRTSD, x86asm.COMISD,
+		x86asm.UCOMISD, x86asm.CVTSD2SS:
+		if inst.MemBytes == 8 {
+			return types.Double, true
+		}
+	}
+	return nil, false
+}
+
+// decodeFloat decodes the little-endian floating-point constant of the given
+// type held by the given data. Values of the 80-bit double extended-precision
+// format are decoded from their bit representation, thus retaining the full
+// 64-bit significand. The boolean return value indicates success; NaNs of the
+// 80-bit format are not supported, as their payload cannot be represented by
+// constant.Float.
+func decodeFloat(typ *types.FloatType, data []byte) (*constant.Float, bool) {
+	switch typ.Kind {
+	case types.FloatKindFloat:
+		x := math.Float32frombits(binary.LittleEndian.Uint32(data))
+		return constant.NewFloat(typ, float64(x)), true
+	case types.FloatKindDouble:
+		x := math.Float64frombits(binary.LittleEndian.Uint64(data))
+		return constant.NewFloat(typ, x), true
+	case types.FloatKindX86FP80:
+		// 64-bit significand with explicit integer bit, followed by 15-bit
+		// exponent and sign bit.
+		m := binary.LittleEndian.Uint64(data)
+		se := binary.LittleEndian.Uint16(data[8:])
+		c, err := constant.NewFloatFromString(typ, fmt.Sprintf("0xK%04X%016X", se, m))
+		if err != nil {
+			panic(fmt.Errorf("unable to decode x86_fp80 constant; %v", err))
+		}
+		if c.NaN {
+			return nil, false
+		}
+		return c, true
+	}
+	panic(fmt.Errorf("support for floating-point type %v not yet implemented", typ))
+}
+
+// floatText returns the decimal representation of the given floating-point
+// constant.
+func floatText(c *constant.Float) string {
+	if c.NaN {
+		return "NaN"
+	}
+	return c.X.Text('g', -1)
+}
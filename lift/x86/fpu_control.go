@@ -0,0 +1,136 @@
+package x86
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/enum"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+)
+
+// x87 FPU Control Word
+//
+// The rounding control (RC) field of the FPU control word, bits 10 and 11,
+// determines how FIST, FISTP and FRNDINT round to integer.
+//
+//    RC   Rounding mode
+//
+//    00   Round to nearest (even)
+//    01   Round down (toward -∞)
+//    10   Round up (toward +∞)
+//    11   Round toward zero (truncate)
+//
+// The control word is held in a local variable of the function, initialized
+// to the default control word of 0x037F (round to nearest) on function entry,
+// and updated by FLDCW. The rounding mode is selected at runtime from the RC
+// field; a common pattern is the _ftol helper of MSVC, which switches to
+// truncation for the duration of a FISTP:
+//
+//    fnstcw [esp+2]
+//    mov    ax, [esp+2]
+//    or     ah, 0x0C
+//    mov    [esp], ax
+//    fldcw  [esp]
+//    fistp  qword [esp+4]
+//    fldcw  [esp+2]
+//
+// As the control word is a known constant at each FIST in such sequences, the
+// selects on the rounding mode are folded by the LLVM optimizer, leaving either
+// a plain fptosi (truncate) or an fptosi of llvm.rint (round to nearest).
+//
+// ref: $ 8.1.5 x87 FPU Control Word, Intel 64 and IA-32 Architectures Software
+// Developer's Manual
+
+// Default x87 FPU control word; all exceptions masked, double extended
+// precision, round to nearest.
+const defaultFPUControlWord = 0x037F
+
+// controlWord returns the local variable of the FPU control word, allocating it
+// on first use.
+func (f *Func) controlWord() *ir.InstAlloca {
+	if f.fcw == nil {
+		f.fcw = ir.NewAlloca(types.I16)
+		f.fcw.SetName("fcw")
+	}
+	return f.fcw
+}
+
+// Rounding modes of the RC field of the x87 FPU control word.
+const (
+	roundNearest  = 0
+	roundDown     = 1
+	roundUp       = 2
+	roundTruncate = 3
+)
+
+// Names of LLVM intrinsics used to round x86_fp80 values to integer; the
+// ".f80" suffix is replaced by ".f64" for double values (see FPUDouble).
+const (
+	intrinsicRint  = "llvm.rint.f80"
+	intrinsicFloor = "llvm.floor.f80"
+	intrinsicCeil  = "llvm.ceil.f80"
+	intrinsicTrunc = "llvm.trunc.f80"
+)
+
+// newRoundIntrinsics returns the declarations of the LLVM intrinsics used to
+// round x86_fp80 and double values to integer, mapping from function name to
+// function declaration.
+func newRoundIntrinsics() map[string]*ir.Function {
+	m := make(map[string]*ir.Function)
+	for _, name := range []string{intrinsicRint, intrinsicFloor, intrinsicCeil, intrinsicTrunc} {
+		fn := ir.NewFunc(name, types.X86FP80, ir.NewParam("x", types.X86FP80))
+		fn.FuncAttrs = append(fn.FuncAttrs, enum.FuncAttrReadNone)
+		m[name] = fn
+		name64 := strings.TrimSuffix(name, ".f80") + ".f64"
+		fn64 := ir.NewFunc(name64, types.Double, ir.NewParam("x", types.Double))
+		fn64.FuncAttrs = append(fn64.FuncAttrs, enum.FuncAttrReadNone)
+		m[name64] = fn64
+	}
+	return m
+}
+
+// fround rounds the given x87 FPU value to integer, using the rounding mode of
+// the FPU control word, emitting code to f.
+func (f *Func) fround(x value.Value) value.Value {
+	cw := f.cur.NewLoad(f.controlWord())
+	rc := f.cur.NewAnd(f.cur.NewLShr(cw, f.constInt(types.I16, 10)), f.constInt(types.I16, 3))
+	round := func(name string) value.Value {
+		if f.l.FPU == FPUDouble {
+			name = strings.TrimSuffix(name, ".f80") + ".f64"
+		}
+		return f.cur.NewCall(f.l.intrinsics[name], x)
+	}
+	is := func(mode int64) value.Value {
+		return f.cur.NewICmp(enum.IPredEQ, rc, f.constInt(types.I16, mode))
+	}
+	v := f.newSelect(is(roundTruncate), round(intrinsicTrunc), round(intrinsicRint))
+	v = f.newSelect(is(roundUp), round(intrinsicCeil), v)
+	return f.newSelect(is(roundDown), round(intrinsicFloor), v)
+}
+
+// fist converts the given x87 FPU value to a signed integer of the size of the
+// memory operand of the given FIST, FISTP or FISTTP instruction, emitting code
+// to f. The value is rounded using the rounding mode of the FPU control word,
+// or truncated if trunc is set.
+func (f *Func) fist(inst *x86.Inst, x value.Value, trunc bool) value.Value {
+	var typ *types.IntType
+	switch inst.MemBytes {
+	case 2:
+		typ = types.I16
+	case 4:
+		typ = types.I32
+	case 8:
+		typ = types.I64
+	default:
+		panic(fmt.Errorf("support for memory argument with byte size %d not yet implemented", inst.MemBytes))
+	}
+	if !trunc {
+		x = f.fround(x)
+	}
+	// TODO: Store the integer indefinite value (e.g. 0x80000000) for values out
+	// of range of the destination type.
+	return f.cur.NewFPToSI(x, typ)
+}
@@ -0,0 +1,79 @@
+package x86
+
+import (
+	"fmt"
+
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+	"github.com/pkg/errors"
+)
+
+// FPUPrecision specifies the precision model of x87 FPU values.
+//
+// The x87 FPU register stack holds double extended-precision values, which are
+// modelled as x86_fp80 by default; bit-exact, but only supported by LLVM
+// backends of x86 targets. Modelling the FPU registers as double instead
+// makes the lifted LLVM IR portable to other targets (e.g. WebAssembly), at the
+// cost of the precision of intermediate results; as if the precision control
+// field of the FPU control word was set to double precision.
+type FPUPrecision uint8
+
+// FPU precision models.
+const (
+	// FPUExtended models x87 FPU values as x86_fp80.
+	FPUExtended FPUPrecision = iota
+	// FPUDouble models x87 FPU values as double.
+	FPUDouble
+)
+
+// String returns the string representation of the FPU precision model.
+func (prec FPUPrecision) String() string {
+	switch prec {
+	case FPUExtended:
+		return "fp80"
+	case FPUDouble:
+		return "double"
+	}
+	return fmt.Sprintf("FPUPrecision(%d)", uint8(prec))
+}
+
+// Set sets the FPU precision model to the given string; either "fp80" or
+// "double".
+func (prec *FPUPrecision) Set(s string) error {
+	switch s {
+	case "fp80":
+		*prec = FPUExtended
+	case "double":
+		*prec = FPUDouble
+	default:
+		return errors.Errorf("invalid FPU precision model %q; expected fp80 or double", s)
+	}
+	return nil
+}
+
+// fpuType returns the LLVM IR type of x87 FPU values, based on the FPU
+// precision model of the lifter.
+func (l *Lifter) fpuType() *types.FloatType {
+	if l.FPU == FPUDouble {
+		return types.Double
+	}
+	return types.X86FP80
+}
+
+// fconv converts the given floating-point value to the specified
+// floating-point type, emitting code to f.
+func (f *Func) fconv(v value.Value, typ *types.FloatType) value.Value {
+	if types.Equal(v.Type(), typ) {
+		return v
+	}
+	if f.l.sizeOfTypeInBits(v.Type()) < f.l.sizeOfTypeInBits(typ) {
+		return f.cur.NewFPExt(v, typ)
+	}
+	return f.cur.NewFPTrunc(v, typ)
+}
+
+// fext converts the given floating-point value (e.g. a memory operand) to the
+// type of x87 FPU values, emitting code to f.
+func (f *Func) fext(v value.Value) value.Value {
+	return f.fconv(v, f.l.fpuType())
+}
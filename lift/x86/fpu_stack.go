@@ -0,0 +1,248 @@
+package x86
+
+import (
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// x87 FPU Register Stack
+//
+// The FPU data registers R0 through R7 are accessed as a register stack; ST(i)
+// refers to the register R((TOP+i) mod 8), where TOP is the stack top held in
+// the FPU status word. Pushes decrement TOP and pops increment TOP.
+//
+// In the common case, TOP is known statically at each instruction relative to
+// the function entry (i.e. the stack depth is balanced along all paths), and
+// ST(i) is resolved to a fixed register at lift time:
+//
+//    fld  dword [x]      ; TOP = 7; R7 = x
+//    fld  dword [y]      ; TOP = 6; R6 = y
+//    faddp               ; TOP = 7; R7 = R7 + R6
+//
+// Otherwise (e.g. stack depths which differ between predecessors of a basic
+// block, or FLDENV and FRSTOR), TOP is held in a local variable and ST(i) is
+// resolved at runtime through a switch on TOP.
+//
+// ref: $ 8.1.2 x87 FPU Data Registers, Intel 64 and IA-32 Architectures
+// Software Developer's Manual
+
+// fpuRegs specifies the FPU data registers R0 through R7, as represented by
+// x86asm.
+var fpuRegs = [8]x86asm.Reg{x86asm.F0, x86asm.F1, x86asm.F2, x86asm.F3, x86asm.F4, x86asm.F5, x86asm.F6, x86asm.F7}
+
+// fpuStackTops returns the static FPU register stack top at the entry of each
+// basic block of the function, or nil if the stack top is dynamic. The stack
+// top is 0 at function entry.
+func (f *Func) fpuStackTops() map[bin.Address]int {
+	tops := map[bin.Address]int{f.AsmFunc.Addr: 0}
+	queue := []bin.Address{f.AsmFunc.Addr}
+	for len(queue) > 0 {
+		blockAddr := queue[0]
+		queue = queue[1:]
+		bb, ok := f.AsmFunc.Blocks[blockAddr]
+		if !ok {
+			continue
+		}
+		top := tops[blockAddr]
+		for _, inst := range normalize(bb) {
+			delta, ok := fpuStackDelta(inst)
+			if !ok {
+				dbg.Printf("dynamic FPU stack top in %q; %v at %v", f.Name(), inst.Op, inst.Addr)
+				return nil
+			}
+			if inst.Op == x86asm.CALL && f.callRetST0(inst) {
+				// Return value pushed by callee.
+				delta = -1
+			}
+			switch inst.Op {
+			case x86asm.FNINIT, x86asm.FNSAVE:
+				top = 0
+			default:
+				top = (top + delta) & 7
+			}
+		}
+		for _, target := range f.l.Targets(bb.Term, f.AsmFunc.Addr) {
+			if _, ok := f.AsmFunc.Blocks[target]; !ok {
+				continue
+			}
+			prev, ok := tops[target]
+			if !ok {
+				tops[target] = top
+				queue = append(queue, target)
+				continue
+			}
+			if prev != top {
+				dbg.Printf("dynamic FPU stack top in %q; stack top of basic block at %v is %d or %d", f.Name(), target, prev, top)
+				return nil
+			}
+		}
+	}
+	// Basic blocks not reached from the function entry (e.g. targets of
+	// unresolved indirect jumps) are assumed to have a balanced stack.
+	for blockAddr := range f.AsmFunc.Blocks {
+		if _, ok := tops[blockAddr]; !ok {
+			tops[blockAddr] = 0
+		}
+	}
+	return tops
+}
+
+// fpuStackDelta returns the change to the FPU register stack top of the given
+// instruction; -1 for pushes and +1 for pops. The boolean return value
+// indicates whether the change is known statically.
+func fpuStackDelta(inst *x86.Inst) (int, bool) {
+	switch inst.Op {
+	// Push.
+	case x86asm.FLD, x86asm.FILD, x86asm.FBLD, x86asm.FLD1, x86asm.FLDZ,
+		x86asm.FLDPI, x86asm.FLDL2E, x86asm.FLDL2T, x86asm.FLDLG2,
+		x86asm.FLDLN2, x86asm.FXTRACT, x86asm.FPTAN, x86asm.FSINCOS,
+		x86asm.FDECSTP:
+		return -1, true
+	// Pop.
+	case x86asm.FSTP, x86asm.FISTP, x86asm.FISTTP, x86asm.FBSTP,
+		x86asm.FADDP, x86asm.FSUBP, x86asm.FSUBRP, x86asm.FMULP,
+		x86asm.FDIVP, x86asm.FDIVRP, x86asm.FCOMP, x86asm.FUCOMP,
+		x86asm.FCOMIP, x86asm.FUCOMIP, x86asm.FICOMP, x86asm.FFREEP,
+		x86asm.FPATAN, x86asm.FYL2X, x86asm.FYL2XP1, x86asm.FINCSTP:
+		return 1, true
+	// Pop twice.
+	case x86asm.FCOMPP, x86asm.FUCOMPP:
+		return 2, true
+	// Stack top restored from memory.
+	case x86asm.FLDENV, x86asm.FRSTOR, x86asm.FXRSTOR, x86asm.FXRSTOR64:
+		return 0, false
+	}
+	return 0, true
+}
+
+// ### [ Helper functions ] ####################################################
+
+// fpush pushes the given value to the top of the FPU register stack, emitting
+// code to f.
+func (f *Func) fpush(src value.Value) {
+	f.fsetTop(-1)
+	f.fstore(src)
+}
+
+// fpop pops and returns the top of the FPU register stack, emitting code to f.
+func (f *Func) fpop() value.Value {
+	v := f.fload()
+	f.fdrop()
+	return v
+}
+
+// fdrop pops the top of the FPU register stack without loading its value,
+// emitting code to f.
+func (f *Func) fdrop() {
+	// TODO: Mark st(0) as empty before incrementing st.
+	f.fsetTop(1)
+}
+
+// fload returns the value of the top FPU register, emitting code to f.
+func (f *Func) fload() value.Named {
+	return f.fuse(0)
+}
+
+// fstore stores the source value to the top FPU register, emitting code to f.
+func (f *Func) fstore(src value.Value) {
+	f.fdef(0, src)
+}
+
+// fuse returns the value of the FPU register ST(i), emitting code to f.
+func (f *Func) fuse(i int) value.Named {
+	if f.st == nil {
+		return f.cur.NewLoad(f.reg(f.fslot(i)))
+	}
+	end := &ir.BasicBlock{}
+	var incs []*ir.Incoming
+	f.fswitch(i, end, func(reg x86asm.Reg) {
+		v := f.cur.NewLoad(f.reg(reg))
+		incs = append(incs, &ir.Incoming{X: v, Pred: f.cur})
+	})
+	f.cur = end
+	f.Blocks = append(f.Blocks, end)
+	return f.cur.NewPhi(incs...)
+}
+
+// fdef stores the source value to the FPU register ST(i), emitting code to f.
+func (f *Func) fdef(i int, src value.Value) {
+	if f.st == nil {
+		f.cur.NewStore(src, f.reg(f.fslot(i)))
+		return
+	}
+	end := &ir.BasicBlock{}
+	f.fswitch(i, end, func(reg x86asm.Reg) {
+		f.cur.NewStore(src, f.reg(reg))
+	})
+	f.cur = end
+	f.Blocks = append(f.Blocks, end)
+}
+
+// fswitch emits a switch on the dynamic FPU register stack top, with one case
+// per FPU data register holding ST(i); the body of each case is emitted by the
+// given function, after which control is transferred to end.
+func (f *Func) fswitch(i int, end *ir.BasicBlock, body func(reg x86asm.Reg)) {
+	cur := f.cur
+	var cases []*ir.Case
+	for top := range fpuRegs {
+		block := &ir.BasicBlock{}
+		f.Blocks = append(f.Blocks, block)
+		f.cur = block
+		body(fpuRegs[(top+i)&7])
+		f.cur.NewBr(end)
+		c := ir.NewCase(f.constInt(types.I8, int64(top)), block)
+		cases = append(cases, c)
+	}
+	f.cur = cur
+	st := f.cur.NewLoad(f.st)
+	defaultTarget := &ir.BasicBlock{}
+	defaultTarget.NewUnreachable()
+	f.Blocks = append(f.Blocks, defaultTarget)
+	f.cur.NewSwitch(st, defaultTarget, cases...)
+}
+
+// fslot returns the FPU data register holding ST(i), based on the static FPU
+// register stack top.
+func (f *Func) fslot(i int) x86asm.Reg {
+	return fpuRegs[(f.ftop+i)&7]
+}
+
+// fsetTop adds the given delta to the FPU register stack top, emitting code to
+// f.
+func (f *Func) fsetTop(delta int) {
+	if f.st == nil {
+		f.ftop = (f.ftop + delta) & 7
+		return
+	}
+	st := f.cur.NewLoad(f.st)
+	tmp := f.cur.NewAdd(st, f.constInt(types.I8, int64(delta&7)))
+	f.cur.NewStore(f.cur.NewAnd(tmp, f.constInt(types.I8, 7)), f.st)
+}
+
+// fresetTop resets the FPU register stack top to its initial value, emitting
+// code to f.
+func (f *Func) fresetTop() {
+	if f.st == nil {
+		f.ftop = 0
+		return
+	}
+	f.cur.NewStore(f.constInt(types.I8, 0), f.st)
+}
+
+// fgetTop returns the FPU register stack top as an 8-bit integer value,
+// emitting code to f.
+func (f *Func) fgetTop() value.Value {
+	if f.st == nil {
+		return f.constInt(types.I8, int64(f.ftop))
+	}
+	return f.cur.NewLoad(f.st)
+}
+
+// isFPUReg reports whether the given register is an FPU register ST(i).
+func isFPUReg(reg x86asm.Reg) bool {
+	return x86asm.F0 <= reg && reg <= x86asm.F7
+}
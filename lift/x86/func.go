@@ -2,13 +2,14 @@ package x86
 
 import (
 	"fmt"
+	"runtime"
 	"sort"
+	"time"
 
 	"github.com/decomp/exp/bin"
 	"github.com/decomp/exp/disasm/x86"
 	"github.com/llir/llvm/ir"
 	"github.com/llir/llvm/ir/constant"
-	"github.com/llir/llvm/ir/enum"
 	"github.com/llir/llvm/ir/metadata"
 	"github.com/llir/llvm/ir/types"
 	"golang.org/x/arch/x86/x86asm"
@@ -44,13 +45,56 @@ type Func struct {
 	// ESP disposition; used for shadow stack.
 	espDisp int64
 
-	// FPU register stack top; integer value in range [0, 7].
+	// FPU register stack top; integer value in range [0, 7]. Only used if the
+	// stack top is dynamic.
 	st *ir.InstAlloca
+	// Static FPU register stack top at the entry of each basic block; or nil if
+	// the stack top is dynamic.
+	ftops map[bin.Address]int
+	// Static FPU register stack top at the current instruction.
+	ftop int
+	// Processor mode (16, 32 or 64) of the current basic block.
+	mode int
+	// Pending definition of the status flags within the current basic block;
+	// or nil if stored.
+	flags *flagDef
+	// Status flags live at the end of each basic block.
+	liveOut map[bin.Address]flagSet
+	// Status flags live after the current instruction.
+	live flagSet
+	// FPU control word; or nil if not used within the function.
+	fcw *ir.InstAlloca
+
+	// Interned integer constants; released once the function has been lifted.
+	consts map[constKey]*constant.Int
+	// Start time of lifting the function, as limited by the time budget.
+	start time.Time
+
+	// Reason for skipping the function, if the lifting budget was exceeded; or
+	// empty if lifted.
+	Skipped string
+	// Address of the instruction which failed to lift, if the function was
+	// skipped in lax mode; or 0 otherwise.
+	SkippedAt bin.Address
 
 	// Read-only global lifter state.
 	l *Lifter
 }
 
+// FuncName returns the name of the function at the given entry address; the
+// symbol name of imported program annotations, the name of the function
+// signature (info.ll) or import, or a name generated by the naming scheme of
+// the lifter (e.g. f_ADDR) if unnamed.
+func (l *Lifter) FuncName(entry bin.Address) string {
+	if name, ok := l.Names[entry]; ok {
+		return l.Naming.SymbolName(name)
+	}
+	if f, ok := l.Funcs[entry]; ok {
+		return f.Name()
+	}
+	return l.Naming.FuncName(entry, l.File.Exports)
+}
+
 // NewFunc returns a new function lifter based on the input assembly of the
 // function.
 func (l *Lifter) NewFunc(asmFunc *x86.Func) *Func {
@@ -59,7 +103,7 @@ func (l *Lifter) NewFunc(asmFunc *x86.Func) *Func {
 	if !ok {
 		// TODO: Add proper support for type signatures once type analysis has
 		// been conducted.
-		name := fmt.Sprintf("f_%06X", uint64(entry))
+		name := l.FuncName(entry)
 		sig := types.NewFunc(types.Void)
 		typ := types.NewPointer(sig)
 		f = &Func{
@@ -77,6 +121,37 @@ func (l *Lifter) NewFunc(asmFunc *x86.Func) *Func {
 		}
 		f.Metadata = append(f.Metadata, md)
 	}
+	if name, ok := l.Names[entry]; ok {
+		// Use symbol name of imported program annotations (e.g. user renames).
+		f.SetName(l.Naming.SymbolName(name))
+	}
+	if fo, ok := l.Overrides.Funcs[entry]; ok && fo.NArgs != nil {
+		// Use number of arguments of user overrides; arguments are of the word
+		// size of the processor mode.
+		paramType := types.I32
+		if l.Mode == 64 {
+			paramType = types.I64
+		}
+		var params []types.Type
+		f.Params = nil
+		for i := 0; i < *fo.NArgs; i++ {
+			param := ir.NewParam(fmt.Sprintf("a%d", i+1), paramType)
+			f.Params = append(f.Params, param)
+			params = append(params, param.Typ)
+		}
+		f.Sig = types.NewFunc(f.Sig.RetType, params...)
+		f.Typ = types.NewPointer(f.Sig)
+	}
+	if comment, ok := l.Overrides.Comments[entry]; ok {
+		// Attach user comment.
+		md := &metadata.Attachment{
+			Name: "comment",
+			Node: &metadata.Tuple{
+				Fields: []metadata.Field{&metadata.String{Value: comment}},
+			},
+		}
+		f.Metadata = append(f.Metadata, md)
+	}
 	f.AsmFunc = asmFunc
 	f.blocks = make(map[bin.Address]*ir.BasicBlock)
 	f.regs = make(map[x86asm.Reg]*ir.InstAlloca)
@@ -86,7 +161,7 @@ func (l *Lifter) NewFunc(asmFunc *x86.Func) *Func {
 	f.l = l
 	// Prepare output LLVM IR basic blocks.
 	for addr := range asmFunc.Blocks {
-		label := fmt.Sprintf("block_%06X", uint64(addr))
+		label := l.Naming.BlockName(addr)
 		block := ir.NewBlock(label)
 		f.blocks[addr] = block
 	}
@@ -130,12 +205,46 @@ func (l *Lifter) NewFunc(asmFunc *x86.Func) *Func {
 
 // Lift lifts the function from input assembly to LLVM IR.
 func (f *Func) Lift() {
+	for _, hook := range f.l.Hooks {
+		hook.BeforeFunc(f)
+	}
+	if f.l.Lax {
+		f.liftLax()
+	} else {
+		f.lift()
+	}
+	f.consts = nil
+	for _, hook := range f.l.Hooks {
+		hook.AfterFunc(f)
+	}
+	if f.l.StableNames {
+		f.nameValues()
+	}
+}
+
+// lift lifts the function from input assembly to LLVM IR.
+func (f *Func) lift() {
 	dbg.Printf("lifting function %q at %v", f.Ident(), f.AsmFunc.Addr)
+	// Skip function if exceeding the instruction budget.
+	if max := f.l.Budget.MaxInsts; max > 0 {
+		if n := f.numInsts(); n > max {
+			f.stub(fmt.Sprintf("exceeded instruction budget (%d > %d instructions)", n, max))
+			return
+		}
+	}
+	f.start = time.Now()
+	// Return values passed in ST0 make use of the FPU register stack.
+	if !f.usesFPU && (f.l.retST0(f.Sig) || f.callsST0()) {
+		f.usesFPU = true
+	}
 	// Allocate a local variable for the FPU stack top used within the function.
 	if f.usesFPU {
-		v := ir.NewAlloca(types.I8)
-		v.SetName("st")
-		f.st = v
+		f.ftops = f.fpuStackTops()
+		if f.ftops == nil {
+			v := ir.NewAlloca(types.I8)
+			v.SetName("st")
+			f.st = v
+		}
 	}
 	var blockAddrs bin.Addresses
 	for blockAddr := range f.AsmFunc.Blocks {
@@ -145,9 +254,18 @@ func (f *Func) Lift() {
 	if len(blockAddrs) == 0 {
 		panic(fmt.Errorf("invalid function definition at %v; missing function body", f.AsmFunc.Addr))
 	}
+	f.liveOut = f.liveFlags()
+	loopMDs := f.loopMetadata()
 	for _, blockAddr := range blockAddrs {
 		bb := f.AsmFunc.Blocks[blockAddr]
-		f.liftBlock(bb)
+		// Skip function if exceeding the time budget.
+		if !f.liftBlock(bb) {
+			f.stub(fmt.Sprintf("exceeded time budget (%v > %v)", time.Since(f.start), f.l.Budget.Timeout))
+			return
+		}
+		if md, ok := loopMDs[blockAddr]; ok {
+			f.attachLoopMetadata(md)
+		}
 	}
 	// Add new entry basic block to define registers, status flags, and local
 	// variables (allocated on the stack) used within the function.
@@ -161,10 +279,15 @@ func (f *Func) Lift() {
 		}
 		// Allocate local variables for the FPU register stack used within the
 		// function.
-		if f.usesFPU {
+		if f.st != nil {
 			entry.Insts = append(entry.Insts, f.st)
-			seven := constant.NewInt(types.I8, 7)
-			entry.NewStore(seven, f.st)
+			entry.NewStore(f.constInt(types.I8, 0), f.st)
+		}
+		// Allocate a local variable for the FPU control word, if used within the
+		// function.
+		if f.fcw != nil {
+			entry.Insts = append(entry.Insts, f.fcw)
+			entry.NewStore(f.constInt(types.I16, defaultFPUControlWord), f.fcw)
 		}
 		// Allocate local variables for each status flag used within the function.
 		for status := firstStatusFlag; status <= lastStatusFlag; status++ {
@@ -190,22 +313,19 @@ func (f *Func) Lift() {
 			inst := f.locals[name]
 			entry.Insts = append(entry.Insts, inst)
 		}
-		// Handle calling conventions.
+		// Handle calling conventions, as used in the processor mode of the
+		// executable.
 		f.cur = entry
+		f.mode = f.l.Mode
 
+		callconv := f.l.callConv(f.CallingConv)
+		stack := f.checkStackParams(callconv, f.Sig.Params)
+		regs := paramRegs(callconv, f.Sig.Params)
 		f.espDisp = 8
 		for i := range f.Params {
 			// Use parameter in register.
-			switch f.CallingConv {
-			case enum.CallingConvX86FastCall:
-				switch i {
-				case 0:
-					continue
-				case 1:
-					continue
-				}
-			default:
-				// TODO: Add support for more calling conventions.
+			if regs[i] != nil || !stack {
+				continue
 			}
 			name := fmt.Sprintf("esp_%d", f.espDisp)
 			if _, ok := f.locals[name]; !ok {
@@ -223,18 +343,12 @@ func (f *Func) Lift() {
 		disp := int64(8)
 		for i, param := range f.Params {
 			// Use parameter in register.
-			switch f.CallingConv {
-			case enum.CallingConvX86FastCall:
-				switch i {
-				case 0:
-					f.defReg(x86.ECX, param)
-					continue
-				case 1:
-					f.defReg(x86.EDX, param)
-					continue
-				}
-			default:
-				// TODO: Add support for more calling conventions.
+			if reg := regs[i]; reg != nil {
+				f.defParamReg(reg, param)
+				continue
+			}
+			if !stack {
+				continue
 			}
 			// Use parameter on stack.
 			m := x86asm.Mem{
@@ -251,13 +365,66 @@ func (f *Func) Lift() {
 	}
 }
 
-// liftBlock lifts the basic block from input assembly to LLVM IR.
-func (f *Func) liftBlock(bb *x86.BasicBlock) {
+// liftLax lifts the function from input assembly to LLVM IR, replacing the
+// function by a stub if lifting fails (e.g. due to unsupported instructions).
+// Runtime errors (e.g. nil pointer dereferences) are not recovered, as they
+// indicate bugs in the lifter.
+func (f *Func) liftLax() {
+	defer func() {
+		if e := recover(); e != nil {
+			if _, ok := e.(runtime.Error); ok {
+				panic(e)
+			}
+			f.cur = nil
+			if err, ok := e.(error); ok {
+				if pe, ok := bin.AsPosError(err); ok {
+					f.SkippedAt = pe.Addr
+				}
+			}
+			f.stub(fmt.Sprintf("unable to lift function; %v", e))
+		}
+	}()
+	f.lift()
+}
+
+// liftBlock lifts the basic block from input assembly to LLVM IR. The boolean
+// return value indicates success, and is false if the time budget of the
+// function was exceeded before the basic block was lifted.
+func (f *Func) liftBlock(bb *x86.BasicBlock) bool {
 	dbg.Printf("lifting basic block at %v", bb.Addr)
 	f.cur = f.blocks[bb.Addr]
 	f.Blocks = append(f.Blocks, f.cur)
-	for _, inst := range bb.Insts {
-		f.liftInst(inst)
+	f.mode = bb.Mode
+	if f.mode == 0 {
+		f.mode = f.l.Mode
+	}
+	if f.ftops != nil {
+		f.ftop = f.ftops[bb.Addr]
+	}
+	f.flags = nil
+	if f.l.Trace {
+		f.trace(bb.Addr)
+	}
+	insts := normalize(bb)
+	liveAfter := liveFlagsAfter(insts, bb.Term, f.liveOut[bb.Addr])
+	for i := 0; i < len(insts); i++ {
+		// Check the time budget before each instruction, as a single basic block
+		// may be arbitrarily large.
+		if f.exceedsTimeout() {
+			return false
+		}
+		f.live = liveAfter[i]
+		// Lift paired 32-bit shifts of 64-bit integers as i64 shifts.
+		if i+1 < len(insts) && f.liftShift64(insts[i], insts[i+1]) {
+			i++
+			continue
+		}
+		f.liftInstHooked(insts[i])
 	}
+	// Store status flags for use by successor basic blocks.
+	f.live = f.liveOut[bb.Addr]
+	f.storeFlags()
 	f.liftTerm(bb.Term)
+	f.flags = nil
+	return true
 }
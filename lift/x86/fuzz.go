@@ -0,0 +1,53 @@
+// +build gofuzz
+
+package x86
+
+import (
+	"bytes"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/bin/raw"
+	"github.com/llir/llvm/asm"
+	"github.com/pkg/errors"
+)
+
+// Fuzz is the go-fuzz entry point of the x86 to LLVM IR lifter; which lifts the
+// function at the start of the given data, interpreted as a raw 32-bit x86
+// binary executable. The lifter runs in lax mode, so that functions with
+// unsupported instructions are skipped, while runtime errors of partially
+// implemented instruction lifters (e.g. nil pointer dereferences) are reported
+// as crashes. The output LLVM IR is verified to parse.
+//
+// Usage:
+//
+//    go-fuzz-build github.com/decomp/exp/lift/x86
+//    go-fuzz -bin=x86-fuzz.zip -workdir=testdata/fuzz
+func Fuzz(data []byte) int {
+	if len(data) == 0 {
+		return 0
+	}
+	file, err := raw.Parse(bytes.NewReader(data), bin.ArchX86_32)
+	if err != nil {
+		return 0
+	}
+	l, err := NewLifter(file)
+	if err != nil {
+		return 0
+	}
+	l.Lax = true
+	asmFunc, err := l.DecodeFunc(0)
+	if err != nil {
+		return 0
+	}
+	f := l.NewFunc(asmFunc)
+	l.Funcs[asmFunc.Addr] = f
+	f.Lift()
+	if len(f.Skipped) > 0 {
+		return 0
+	}
+	module := l.Module()
+	if _, err := asm.ParseString("fuzz.ll", module.String()); err != nil {
+		panic(errors.Wrapf(err, "invalid LLVM IR of function at %v", asmFunc.Addr))
+	}
+	return 1
+}
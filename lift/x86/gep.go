@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/llir/llvm/ir"
-	"github.com/llir/llvm/ir/constant"
 	"github.com/llir/llvm/ir/types"
 	"github.com/llir/llvm/ir/value"
 )
@@ -35,7 +34,7 @@ loop:
 			// src.
 			//
 			// ref: http://llvm.org/docs/GetElementPtr.html#why-is-the-extra-0-index-required
-			index := constant.NewInt(types.I64, 0)
+			index := f.constInt(types.I64, 0)
 			indices = append(indices, index)
 			continue
 		}
@@ -55,7 +54,7 @@ loop:
 				}
 				total += elemSize
 			}
-			index := constant.NewInt(types.I64, j)
+			index := f.constInt(types.I64, j)
 			indices = append(indices, index)
 			e = t.ElemType
 		case *types.StructType:
@@ -67,7 +66,7 @@ loop:
 				}
 				total += fieldSize
 			}
-			index := constant.NewInt(types.I64, j)
+			index := f.constInt(types.I64, j)
 			indices = append(indices, index)
 			e = t.Fields[j]
 		case *types.IntType:
@@ -90,8 +89,8 @@ loop:
 		typ := types.NewPointer(types.NewArray(uint64(n), types.I8))
 		tmp1 := f.cur.NewBitCast(src, typ)
 		indices := []value.Value{
-			constant.NewInt(types.I64, 0),
-			constant.NewInt(types.I64, int64(offset)-int64(total)),
+			f.constInt(types.I64, 0),
+			f.constInt(types.I64, int64(offset)-int64(total)),
 		}
 		tmp2 := f.cur.NewGetElementPtr(tmp1, indices...)
 		return tmp2
@@ -0,0 +1,120 @@
+package x86
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+)
+
+// A Hook is notified by the lifter before and after lifting each function and
+// instruction, thus enabling plugins to override the lifting of specific
+// instructions (e.g. by opcode or address), insert instrumentation calls, or
+// record custom metadata.
+//
+// Hooks are registered by appending to the Hooks field of the lifter, and are
+// invoked in order of registration. The LLVM IR of the current basic block is
+// accessible through the Block method of the function lifter.
+type Hook interface {
+	// BeforeFunc is invoked before lifting the given function.
+	BeforeFunc(f *Func)
+	// AfterFunc is invoked after lifting the given function.
+	AfterFunc(f *Func)
+	// BeforeInst is invoked before lifting the given instruction. The boolean
+	// return value indicates that the hook has lifted the instruction, in
+	// which case the instruction is not lifted by the lifter nor passed to the
+	// BeforeInst method of succeeding hooks.
+	BeforeInst(f *Func, inst *x86.Inst) bool
+	// AfterInst is invoked after lifting the given instruction.
+	AfterInst(f *Func, inst *x86.Inst)
+}
+
+// NopHook implements the methods of the Hook interface as no-ops. It may be
+// embedded by hooks which only implement a subset of the methods.
+type NopHook struct{}
+
+// BeforeFunc is a no-op.
+func (NopHook) BeforeFunc(f *Func) {}
+
+// AfterFunc is a no-op.
+func (NopHook) AfterFunc(f *Func) {}
+
+// BeforeInst is a no-op, which leaves the lifting of the instruction to the
+// lifter.
+func (NopHook) BeforeInst(f *Func, inst *x86.Inst) bool { return false }
+
+// AfterInst is a no-op.
+func (NopHook) AfterInst(f *Func, inst *x86.Inst) {}
+
+// Block returns the current basic block being generated, to which hooks may
+// append instructions.
+func (f *Func) Block() *ir.BasicBlock {
+	return f.cur
+}
+
+// UseArg returns the value held by the given argument, emitting code to the
+// current basic block.
+func (f *Func) UseArg(arg *x86.Arg) value.Value {
+	return f.useArg(arg)
+}
+
+// DefArg stores the value to the given argument, emitting code to the current
+// basic block.
+func (f *Func) DefArg(arg *x86.Arg, v value.Value) {
+	f.defArg(arg, v)
+}
+
+// Declare declares an external function of the given name and signature (e.g.
+// an instrumentation function called by hooks), which is included in the
+// output module if used. Declare should only be invoked during initialization
+// of the lifter.
+func (l *Lifter) Declare(name string, retType types.Type, params ...*ir.Param) *ir.Function {
+	if f, ok := l.intrinsics[name]; ok {
+		return f
+	}
+	f := ir.NewFunc(name, retType, params...)
+	l.intrinsics[name] = f
+	return f
+}
+
+// ### [ Helper functions ] ####################################################
+
+// liftInstHooked lifts the given instruction to LLVM IR, emitting code to f,
+// unless overridden by the BeforeInst method of a hook.
+func (f *Func) liftInstHooked(inst *x86.Inst) {
+	defer f.annotateFailure(inst)
+	lifted := false
+	for _, hook := range f.l.Hooks {
+		if hook.BeforeInst(f, inst) {
+			lifted = true
+			break
+		}
+	}
+	if !lifted {
+		f.liftInst(inst)
+	}
+	for _, hook := range f.l.Hooks {
+		hook.AfterInst(f, inst)
+	}
+}
+
+// annotateFailure annotates lifter failures (e.g. unsupported instructions)
+// recovered while lifting the given instruction with the address, raw bytes and
+// section of the instruction. Runtime errors are propagated as is.
+//
+// annotateFailure must be called directly by a deferred function call.
+func (f *Func) annotateFailure(inst *x86.Inst) {
+	if e := recover(); e != nil {
+		if _, ok := e.(runtime.Error); ok {
+			panic(e)
+		}
+		err, ok := e.(error)
+		if !ok {
+			err = fmt.Errorf("%v", e)
+		}
+		panic(f.l.File.WrapAt(inst.Addr, "lift", err))
+	}
+}
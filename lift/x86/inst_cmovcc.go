@@ -0,0 +1,228 @@
+package x86
+
+import (
+	"fmt"
+
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir/value"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// Conditional Move
+//
+//    (CF=0 and ZF=0)     CMOVA     Move if above.
+//    (CF=0 and ZF=0)     CMOVNBE   Move if not below or equal.     PRESUDO-instruction
+//    (CF=0)              CMOVAE    Move if above or equal.
+//    (CF=0)              CMOVNB    Move if not below.              PRESUDO-instruction
+//    (CF=0)              CMOVNC    Move if not carry.              PRESUDO-instruction
+//    (CF=1 or ZF=1)      CMOVBE    Move if below or equal.
+//    (CF=1 or ZF=1)      CMOVNA    Move if not above.              PRESUDO-instruction
+//    (CF=1)              CMOVB     Move if below.
+//    (CF=1)              CMOVC     Move if carry.                  PRESUDO-instruction
+//    (CF=1)              CMOVNAE   Move if not above or equal.     PRESUDO-instruction
+//    (OF=0)              CMOVNO    Move if not overflow.
+//    (OF=1)              CMOVO     Move if overflow.
+//    (PF=0)              CMOVNP    Move if not parity.
+//    (PF=0)              CMOVPO    Move if parity odd.             PRESUDO-instruction
+//    (PF=1)              CMOVP     Move if parity.
+//    (PF=1)              CMOVPE    Move if parity even.            PRESUDO-instruction
+//    (SF=0)              CMOVNS    Move if not sign.
+//    (SF=1)              CMOVS     Move if sign.
+//    (SF=OF)             CMOVGE    Move if greater or equal.
+//    (SF=OF)             CMOVNL    Move if not less.               PRESUDO-instruction
+//    (SF≠OF)             CMOVL     Move if less.
+//    (SF≠OF)             CMOVNGE   Move if not greater or equal.   PRESUDO-instruction
+//    (ZF=0 and SF=OF)    CMOVG     Move if greater.
+//    (ZF=0 and SF=OF)    CMOVNLE   Move if not less or equal.      PRESUDO-instruction
+//    (ZF=0)              CMOVNE    Move if not equal.
+//    (ZF=0)              CMOVNZ    Move if not zero.               PRESUDO-instruction
+//    (ZF=1 or SF≠OF)     CMOVLE    Move if less or equal.
+//    (ZF=1 or SF≠OF)     CMOVNG    Move if not greater.            PRESUDO-instruction
+//    (ZF=1)              CMOVE     Move if equal.
+//    (ZF=1)              CMOVZ     Move if zero.                   PRESUDO-instruction
+//
+// ref: $ 3.2 CMOVcc - Conditional Move, Intel 64 and IA-32 Architectures
+// Software Developer's Manual
+
+// --- [ CMOVA ] ---------------------------------------------------------------
+
+// liftInstCMOVA lifts the given x86 CMOVA instruction to LLVM IR, emitting code
+// to f.
+func (f *Func) liftInstCMOVA(inst *x86.Inst) error {
+	// Move if above.
+	//    (CF=0 and ZF=0)
+	return f.liftInstCMOVcc(inst, f.cond(condA))
+}
+
+// --- [ CMOVAE ] --------------------------------------------------------------
+
+// liftInstCMOVAE lifts the given x86 CMOVAE instruction to LLVM IR, emitting
+// code to f.
+func (f *Func) liftInstCMOVAE(inst *x86.Inst) error {
+	// Move if above or equal.
+	//    (CF=0)
+	return f.liftInstCMOVcc(inst, f.cond(condAE))
+}
+
+// --- [ CMOVBE ] --------------------------------------------------------------
+
+// liftInstCMOVBE lifts the given x86 CMOVBE instruction to LLVM IR, emitting
+// code to f.
+func (f *Func) liftInstCMOVBE(inst *x86.Inst) error {
+	// Move if below or equal.
+	//    (CF=1 or ZF=1)
+	return f.liftInstCMOVcc(inst, f.cond(condBE))
+}
+
+// --- [ CMOVB ] ---------------------------------------------------------------
+
+// liftInstCMOVB lifts the given x86 CMOVB instruction to LLVM IR, emitting code
+// to f.
+func (f *Func) liftInstCMOVB(inst *x86.Inst) error {
+	// Move if below.
+	//    (CF=1)
+	return f.liftInstCMOVcc(inst, f.cond(condB))
+}
+
+// --- [ CMOVNO ] --------------------------------------------------------------
+
+// liftInstCMOVNO lifts the given x86 CMOVNO instruction to LLVM IR, emitting
+// code to f.
+func (f *Func) liftInstCMOVNO(inst *x86.Inst) error {
+	// Move if not overflow.
+	//    (OF=0)
+	return f.liftInstCMOVcc(inst, f.cond(condNO))
+}
+
+// --- [ CMOVO ] ---------------------------------------------------------------
+
+// liftInstCMOVO lifts the given x86 CMOVO instruction to LLVM IR, emitting code
+// to f.
+func (f *Func) liftInstCMOVO(inst *x86.Inst) error {
+	// Move if overflow.
+	//    (OF=1)
+	return f.liftInstCMOVcc(inst, f.cond(condO))
+}
+
+// --- [ CMOVNP ] --------------------------------------------------------------
+
+// liftInstCMOVNP lifts the given x86 CMOVNP instruction to LLVM IR, emitting
+// code to f.
+func (f *Func) liftInstCMOVNP(inst *x86.Inst) error {
+	// Move if not parity.
+	//    (PF=0)
+	return f.liftInstCMOVcc(inst, f.cond(condNP))
+}
+
+// --- [ CMOVP ] ---------------------------------------------------------------
+
+// liftInstCMOVP lifts the given x86 CMOVP instruction to LLVM IR, emitting code
+// to f.
+func (f *Func) liftInstCMOVP(inst *x86.Inst) error {
+	// Move if parity.
+	//    (PF=1)
+	return f.liftInstCMOVcc(inst, f.cond(condP))
+}
+
+// --- [ CMOVNS ] --------------------------------------------------------------
+
+// liftInstCMOVNS lifts the given x86 CMOVNS instruction to LLVM IR, emitting
+// code to f.
+func (f *Func) liftInstCMOVNS(inst *x86.Inst) error {
+	// Move if not sign.
+	//    (SF=0)
+	return f.liftInstCMOVcc(inst, f.cond(condNS))
+}
+
+// --- [ CMOVS ] ---------------------------------------------------------------
+
+// liftInstCMOVS lifts the given x86 CMOVS instruction to LLVM IR, emitting code
+// to f.
+func (f *Func) liftInstCMOVS(inst *x86.Inst) error {
+	// Move if sign.
+	//    (SF=1)
+	return f.liftInstCMOVcc(inst, f.cond(condS))
+}
+
+// --- [ CMOVGE ] --------------------------------------------------------------
+
+// liftInstCMOVGE lifts the given x86 CMOVGE instruction to LLVM IR, emitting
+// code to f.
+func (f *Func) liftInstCMOVGE(inst *x86.Inst) error {
+	// Move if greater or equal.
+	//    (SF=OF)
+	return f.liftInstCMOVcc(inst, f.cond(condGE))
+}
+
+// --- [ CMOVL ] ---------------------------------------------------------------
+
+// liftInstCMOVL lifts the given x86 CMOVL instruction to LLVM IR, emitting code
+// to f.
+func (f *Func) liftInstCMOVL(inst *x86.Inst) error {
+	// Move if less.
+	//    (SF≠OF)
+	return f.liftInstCMOVcc(inst, f.cond(condL))
+}
+
+// --- [ CMOVG ] ---------------------------------------------------------------
+
+// liftInstCMOVG lifts the given x86 CMOVG instruction to LLVM IR, emitting code
+// to f.
+func (f *Func) liftInstCMOVG(inst *x86.Inst) error {
+	// Move if greater.
+	//    (ZF=0 and SF=OF)
+	return f.liftInstCMOVcc(inst, f.cond(condG))
+}
+
+// --- [ CMOVNE ] --------------------------------------------------------------
+
+// liftInstCMOVNE lifts the given x86 CMOVNE instruction to LLVM IR, emitting
+// code to f.
+func (f *Func) liftInstCMOVNE(inst *x86.Inst) error {
+	// Move if not equal.
+	//    (ZF=0)
+	return f.liftInstCMOVcc(inst, f.cond(condNE))
+}
+
+// --- [ CMOVLE ] --------------------------------------------------------------
+
+// liftInstCMOVLE lifts the given x86 CMOVLE instruction to LLVM IR, emitting
+// code to f.
+func (f *Func) liftInstCMOVLE(inst *x86.Inst) error {
+	// Move if less or equal.
+	//    (ZF=1 or SF≠OF)
+	return f.liftInstCMOVcc(inst, f.cond(condLE))
+}
+
+// --- [ CMOVE ] ---------------------------------------------------------------
+
+// liftInstCMOVE lifts the given x86 CMOVE instruction to LLVM IR, emitting code
+// to f.
+func (f *Func) liftInstCMOVE(inst *x86.Inst) error {
+	// Move if equal.
+	//    (ZF=1)
+	return f.liftInstCMOVcc(inst, f.cond(condE))
+}
+
+// === [ Helper functions ] ====================================================
+
+// liftInstCMOVcc lifts the given x86 CMOVcc instruction to LLVM IR, emitting
+// code to f. The source operand is moved to the destination register if cond
+// holds; which is lifted as a select instruction, to keep the control flow of
+// the function intact.
+//
+// Operand sizes of 16, 32 and 64 bits are supported, as determined by the
+// destination register. Note, the source operand is read irrespective of the
+// condition, as is the case for memory operands of the x86 instruction.
+func (f *Func) liftInstCMOVcc(inst *x86.Inst, cond value.Value) error {
+	reg, ok := inst.Args[0].(x86asm.Reg)
+	if !ok {
+		panic(fmt.Errorf("invalid destination operand of %v instruction at %v; expected register, got %T", inst.Op, inst.Addr, inst.Args[0]))
+	}
+	typ := regType(reg)
+	x := f.useArg(inst.Arg(0))
+	y := f.useArgElem(inst.Arg(1), typ)
+	v := f.newSelect(cond, y, x)
+	f.defArg(inst.Arg(0), v)
+	return nil
+}
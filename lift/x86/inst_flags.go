@@ -0,0 +1,172 @@
+package x86
+
+import (
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir/enum"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+)
+
+// EFLAGS Register
+//
+// Instructions which transfer the status flags to and from an integer (e.g. to
+// spill the status flags across calls, or to test the x87 FPU condition codes
+// stored by FNSTSW) pack the modelled status flags into an integer image of the
+// EFLAGS register, and unpack the modelled status flags from it.
+//
+//    11    - OF, Overflow Flag
+//    10    - DF, Direction Flag
+//     9    - IF, Interrupt Enable Flag
+//     7    - SF, Sign Flag
+//     6    - ZF, Zero Flag
+//     4    - AF, Auxiliary Carry Flag
+//     2    - PF, Parity Flag
+//     1    - reserved, always set
+//     0    - CF, Carry Flag
+//
+// The system flags and DF are not modelled; packed images have IF and the
+// reserved bit 1 set, and DF cleared, as in user mode code conforming to the
+// calling conventions. Unmodelled bits of unpacked images are ignored.
+//
+// ref: $ 3.4.3 EFLAGS Register, Intel 64 and IA-32 Architectures Software
+// Developer's Manual
+
+// eflagsFields specifies the bit positions of the modelled status flags within
+// the EFLAGS register.
+var eflagsFields = []struct {
+	status StatusFlag
+	bit    uint64
+}{
+	{CF, 0}, {PF, 2}, {AF, 4}, {ZF, 6}, {SF, 7}, {OF, 11},
+}
+
+// Bits of the EFLAGS register set in packed images; the reserved bit 1 and IF.
+const eflagsFixed = 0x0202
+
+// packFlags returns an integer image of the given type of the EFLAGS register,
+// holding the modelled status flags, emitting code to f. Status flags outside
+// of the integer type are omitted; e.g. the 8-bit image of LAHF omits OF.
+func (f *Func) packFlags(typ *types.IntType) value.Value {
+	mask := uint64(1)<<typ.BitSize - 1
+	var result value.Value = f.constInt(typ, int64(eflagsFixed&mask))
+	for _, field := range eflagsFields {
+		if field.bit >= typ.BitSize {
+			continue
+		}
+		v := f.cur.NewZExt(f.useStatus(field.status), typ)
+		result = f.cur.NewOr(result, f.cur.NewShl(v, f.constInt(typ, int64(field.bit))))
+	}
+	return result
+}
+
+// unpackFlags stores the modelled status flags held by the given integer image
+// of the EFLAGS register, emitting code to f. Status flags outside of the
+// integer type are left unaffected; e.g. OF by SAHF.
+func (f *Func) unpackFlags(v value.Value) {
+	typ := v.Type().(*types.IntType)
+	zero := f.constInt(typ, 0)
+	for _, field := range eflagsFields {
+		if field.bit >= typ.BitSize {
+			continue
+		}
+		bit := f.cur.NewAnd(v, f.constInt(typ, int64(1)<<field.bit))
+		f.defStatus(field.status, f.cur.NewICmp(enum.IPredNE, bit, zero))
+	}
+}
+
+// --- [ LAHF ] ----------------------------------------------------------------
+
+// liftInstLAHF lifts the given x86 LAHF instruction to LLVM IR, emitting code
+// to f.
+func (f *Func) liftInstLAHF(inst *x86.Inst) error {
+	// LAHF - Load status flags into AH register.
+	//
+	//    AH = EFLAGS(SF:ZF:0:AF:0:PF:1:CF)
+	f.defReg(x86.AH, f.packFlags(types.I8))
+	return nil
+}
+
+// --- [ SAHF ] ----------------------------------------------------------------
+
+// liftInstSAHF lifts the given x86 SAHF instruction to LLVM IR, emitting code
+// to f.
+func (f *Func) liftInstSAHF(inst *x86.Inst) error {
+	// SAHF - Store AH into flags.
+	//
+	//    EFLAGS(SF:ZF:0:AF:0:PF:1:CF) = AH
+	//
+	// Used to test the x87 FPU condition codes stored by FNSTSW AX, as C0, C2
+	// and C3 are stored to CF, PF and ZF respectively.
+	//
+	//    fnstsw ax
+	//    sahf
+	//    jb     foo
+	f.unpackFlags(f.useReg(x86.AH))
+	return nil
+}
+
+// --- [ POPF ] ----------------------------------------------------------------
+
+// liftInstPOPF lifts the given x86 POPF instruction to LLVM IR, emitting code
+// to f.
+func (f *Func) liftInstPOPF(inst *x86.Inst) error {
+	// POPF - Pop top of stack into lower 16 bits of EFLAGS.
+	f.unpackFlags(f.popElem(types.I16))
+	return nil
+}
+
+// --- [ POPFD ] ---------------------------------------------------------------
+
+// liftInstPOPFD lifts the given x86 POPFD instruction to LLVM IR, emitting code
+// to f.
+func (f *Func) liftInstPOPFD(inst *x86.Inst) error {
+	// POPFD - Pop top of stack into EFLAGS.
+	f.unpackFlags(f.popElem(types.I32))
+	return nil
+}
+
+// --- [ POPFQ ] ---------------------------------------------------------------
+
+// liftInstPOPFQ lifts the given x86 POPFQ instruction to LLVM IR, emitting code
+// to f.
+func (f *Func) liftInstPOPFQ(inst *x86.Inst) error {
+	// POPFQ - Pop top of stack and zero-extend into RFLAGS.
+	//
+	// The upper 32 bits of RFLAGS are reserved; the stack slot is of the size
+	// modelled by push and pop.
+	f.unpackFlags(f.popElem(types.I32))
+	return nil
+}
+
+// --- [ PUSHF ] ---------------------------------------------------------------
+
+// liftInstPUSHF lifts the given x86 PUSHF instruction to LLVM IR, emitting code
+// to f.
+func (f *Func) liftInstPUSHF(inst *x86.Inst) error {
+	// PUSHF - Push lower 16 bits of EFLAGS.
+	f.push(f.packFlags(types.I16))
+	return nil
+}
+
+// --- [ PUSHFD ] --------------------------------------------------------------
+
+// liftInstPUSHFD lifts the given x86 PUSHFD instruction to LLVM IR, emitting
+// code to f.
+func (f *Func) liftInstPUSHFD(inst *x86.Inst) error {
+	// PUSHFD - Push EFLAGS.
+	f.push(f.packFlags(types.I32))
+	return nil
+}
+
+// --- [ PUSHFQ ] --------------------------------------------------------------
+
+// liftInstPUSHFQ lifts the given x86 PUSHFQ instruction to LLVM IR, emitting
+// code to f.
+func (f *Func) liftInstPUSHFQ(inst *x86.Inst) error {
+	// PUSHFQ - Push RFLAGS.
+	//
+	// The upper 32 bits of RFLAGS are reserved; the stack slot is of the size
+	// modelled by push and pop.
+	f.push(f.packFlags(types.I32))
+	return nil
+}
@@ -40,9 +40,7 @@ func (f *Func) liftInstFLD(inst *x86.Inst) error {
 	// point format before being pushed on the stack.
 	src := f.useArg(inst.Arg(0))
 	// TODO: Verify that FLD ST(i) is handled correctly.
-	if !types.Equal(src.Type(), types.X86FP80) {
-		src = f.cur.NewFPExt(src, types.X86FP80)
-	}
+	src = f.fext(src)
 	f.fpush(src)
 	return nil
 }
@@ -59,12 +57,12 @@ func (f *Func) liftInstFST(inst *x86.Inst) error {
 	//    FST ST(i)      Copy ST(0) to ST(i).
 	//
 	// Copies the value in the ST(0) register to the destination operand.
-	src := f.fload()
+	var src value.Value = f.fload()
 	switch arg := inst.Args[0].(type) {
 	case x86asm.Reg:
 		// no type conversion needed.
 	case x86asm.Mem:
-		var typ types.Type
+		var typ *types.FloatType
 		switch inst.MemBytes {
 		case 4:
 			typ = types.Float
@@ -73,7 +71,7 @@ func (f *Func) liftInstFST(inst *x86.Inst) error {
 		default:
 			panic(fmt.Errorf("support for memory argument with byte size %d not yet implemented", inst.MemBytes))
 		}
-		src = f.cur.NewFPTrunc(src, typ)
+		src = f.fconv(src, typ)
 	default:
 		panic(fmt.Errorf("support for operand type %T not yet implemented", arg))
 	}
@@ -94,18 +92,18 @@ func (f *Func) liftInstFSTP(inst *x86.Inst) error {
 	//    FSTP ST(i)          Copy ST(0) to ST(i) and pop register stack.
 	//
 	// Copies the value in the ST(0) register to the destination operand.
-	src := f.fload()
+	var src value.Value = f.fload()
 	switch arg := inst.Args[0].(type) {
 	case x86asm.Reg:
 		// no type conversion needed.
 	case x86asm.Mem:
 		switch inst.MemBytes {
 		case 4:
-			src = f.cur.NewFPTrunc(src, types.Float)
+			src = f.fconv(src, types.Float)
 		case 8:
-			src = f.cur.NewFPTrunc(src, types.Double)
+			src = f.fconv(src, types.Double)
 		case 10:
-			// no type conversion needed.
+			src = f.fconv(src, types.X86FP80)
 		default:
 			panic(fmt.Errorf("support for memory argument with byte size %d not yet implemented", inst.MemBytes))
 		}
@@ -113,7 +111,7 @@ func (f *Func) liftInstFSTP(inst *x86.Inst) error {
 		panic(fmt.Errorf("support for operand type %T not yet implemented", arg))
 	}
 	f.defArg(inst.Arg(0), src)
-	f.pop()
+	f.fdrop()
 	return nil
 }
 
@@ -131,7 +129,7 @@ func (f *Func) liftInstFILD(inst *x86.Inst) error {
 	// Converts the signed-integer source operand into double extended-precision
 	// floating-point format and pushes the value onto the FPU register stack.
 	arg := f.useArg(inst.Arg(0))
-	src := f.cur.NewSIToFP(arg, types.X86FP80)
+	src := f.cur.NewSIToFP(arg, f.l.fpuType())
 	f.fpush(src)
 	return nil
 }
@@ -142,8 +140,16 @@ func (f *Func) liftInstFILD(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFIST(inst *x86.Inst) error {
 	// FIST - Store integer.
-	pretty.Println("inst:", inst)
-	panic("liftInstFIST: not yet implemented")
+	//
+	//    FIST m16int         Store ST(0) in m16int.
+	//    FIST m32int         Store ST(0) in m32int.
+	//
+	// Converts the value in the ST(0) register to a signed integer, rounded
+	// according to the RC field of the FPU control word, and stores the result
+	// in the destination operand.
+	src := f.fload()
+	f.defArg(inst.Arg(0), f.fist(inst, src, false))
+	return nil
 }
 
 // --- [ FISTP ] ---------------------------------------------------------------
@@ -152,8 +158,17 @@ func (f *Func) liftInstFIST(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFISTP(inst *x86.Inst) error {
 	// FISTP - Store integer and pop.
-	pretty.Println("inst:", inst)
-	panic("liftInstFISTP: not yet implemented")
+	//
+	//    FISTP m16int        Store ST(0) in m16int and pop register stack.
+	//    FISTP m32int        Store ST(0) in m32int and pop register stack.
+	//    FISTP m64int        Store ST(0) in m64int and pop register stack.
+	//
+	// Converts the value in the ST(0) register to a signed integer, rounded
+	// according to the RC field of the FPU control word, and stores the result
+	// in the destination operand.
+	src := f.fpop()
+	f.defArg(inst.Arg(0), f.fist(inst, src, false))
+	return nil
 }
 
 // --- [ FBLD ] ----------------------------------------------------------------
@@ -209,8 +224,8 @@ func (f *Func) liftInstFXCH(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFCMOVE(inst *x86.Inst) error {
 	// FCMOVE - Floating-point conditional move if equal.
-	pretty.Println("inst:", inst)
-	panic("liftInstFCMOVE: not yet implemented")
+	//    (ZF=1)
+	return f.liftInstFCMOVcc(inst, f.cond(condE))
 }
 
 // --- [ FCMOVNE ] -------------------------------------------------------------
@@ -219,8 +234,8 @@ func (f *Func) liftInstFCMOVE(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFCMOVNE(inst *x86.Inst) error {
 	// FCMOVNE - Floating-point conditional move if not equal.
-	pretty.Println("inst:", inst)
-	panic("liftInstFCMOVNE: not yet implemented")
+	//    (ZF=0)
+	return f.liftInstFCMOVcc(inst, f.cond(condNE))
 }
 
 // --- [ FCMOVB ] --------------------------------------------------------------
@@ -229,8 +244,8 @@ func (f *Func) liftInstFCMOVNE(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFCMOVB(inst *x86.Inst) error {
 	// FCMOVB - Floating-point conditional move if below.
-	pretty.Println("inst:", inst)
-	panic("liftInstFCMOVB: not yet implemented")
+	//    (CF=1)
+	return f.liftInstFCMOVcc(inst, f.cond(condB))
 }
 
 // --- [ FCMOVBE ] -------------------------------------------------------------
@@ -239,8 +254,8 @@ func (f *Func) liftInstFCMOVB(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFCMOVBE(inst *x86.Inst) error {
 	// FCMOVBE - Floating-point conditional move if below or equal.
-	pretty.Println("inst:", inst)
-	panic("liftInstFCMOVBE: not yet implemented")
+	//    (CF=1 or ZF=1)
+	return f.liftInstFCMOVcc(inst, f.cond(condBE))
 }
 
 // --- [ FCMOVNB ] -------------------------------------------------------------
@@ -249,8 +264,8 @@ func (f *Func) liftInstFCMOVBE(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFCMOVNB(inst *x86.Inst) error {
 	// FCMOVNB - Floating-point conditional move if not below.
-	pretty.Println("inst:", inst)
-	panic("liftInstFCMOVNB: not yet implemented")
+	//    (CF=0)
+	return f.liftInstFCMOVcc(inst, f.cond(condAE))
 }
 
 // --- [ FCMOVNBE ] ------------------------------------------------------------
@@ -259,8 +274,8 @@ func (f *Func) liftInstFCMOVNB(inst *x86.Inst) error {
 // emitting code to f.
 func (f *Func) liftInstFCMOVNBE(inst *x86.Inst) error {
 	// FCMOVNBE - Floating-point conditional move if not below or equal.
-	pretty.Println("inst:", inst)
-	panic("liftInstFCMOVNBE: not yet implemented")
+	//    (CF=0 and ZF=0)
+	return f.liftInstFCMOVcc(inst, f.cond(condA))
 }
 
 // --- [ FCMOVU ] --------------------------------------------------------------
@@ -269,8 +284,8 @@ func (f *Func) liftInstFCMOVNBE(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFCMOVU(inst *x86.Inst) error {
 	// FCMOVU - Floating-point conditional move if unordered.
-	pretty.Println("inst:", inst)
-	panic("liftInstFCMOVU: not yet implemented")
+	//    (PF=1)
+	return f.liftInstFCMOVcc(inst, f.cond(condP))
 }
 
 // --- [ FCMOVNU ] -------------------------------------------------------------
@@ -279,8 +294,20 @@ func (f *Func) liftInstFCMOVU(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFCMOVNU(inst *x86.Inst) error {
 	// FCMOVNU - Floating-point conditional move if not unordered.
-	pretty.Println("inst:", inst)
-	panic("liftInstFCMOVNU: not yet implemented")
+	//    (PF=0)
+	return f.liftInstFCMOVcc(inst, f.cond(condNP))
+}
+
+// liftInstFCMOVcc lifts the given x87 FCMOVcc instruction to LLVM IR, emitting
+// code to f. ST(i) is moved to ST(0) if the condition holds.
+//
+//    FCMOVcc ST(0), ST(i)
+func (f *Func) liftInstFCMOVcc(inst *x86.Inst, cond value.Value) error {
+	x := f.useArg(inst.Arg(0))
+	y := f.useArg(inst.Arg(1))
+	v := f.newSelect(cond, y, x)
+	f.defArg(inst.Arg(0), v)
+	return nil
 }
 
 // === [ x87 FPU Basic Arithmetic Instructions ] ===============================
@@ -309,7 +336,7 @@ func (f *Func) liftInstFADD(inst *x86.Inst) error {
 	}
 	// One-operand form.
 	src := f.useArg(inst.Arg(0))
-	v := f.cur.NewFPExt(src, types.X86FP80)
+	v := f.fext(src)
 	st0 := f.fload()
 	result := f.cur.NewFAdd(st0, v)
 	f.fstore(result)
@@ -334,18 +361,16 @@ func (f *Func) liftInstFADDP(inst *x86.Inst) error {
 		src := f.useArg(inst.Arg(1))
 		result := f.cur.NewFAdd(dst, src)
 		f.defArg(inst.Arg(0), result)
+		f.fdrop()
 		return nil
 	}
 	// Zero-operand form.
-
-	// TODO: Figure out how to handle F1, directly or through abstraction since
-	// the underlying register of F1 changes as ST is updated.
 	st0 := f.useReg(x86.NewReg(x86asm.F0, inst))
 	st1 := f.useReg(x86.NewReg(x86asm.F1, inst))
 	result := f.cur.NewFAdd(st0, st1)
 	f.defReg(x86.NewReg(x86asm.F1, inst), result)
 
-	f.pop()
+	f.fdrop()
 	return nil
 }
 
@@ -412,7 +437,7 @@ func (f *Func) liftInstFISUB(inst *x86.Inst) error {
 	// Subtracts the source operand from the destination operand and stores the
 	// difference in the destination location.
 	arg := f.useArg(inst.Arg(0))
-	src := f.cur.NewSIToFP(arg, types.X86FP80)
+	src := f.cur.NewSIToFP(arg, f.l.fpuType())
 	st0 := f.fload()
 	result := f.cur.NewFSub(st0, src)
 	f.fstore(result)
@@ -473,7 +498,7 @@ func (f *Func) liftInstFMUL(inst *x86.Inst) error {
 	}
 	// One-operand form.
 	arg := f.useArg(inst.Arg(0))
-	src := f.cur.NewFPExt(arg, types.X86FP80)
+	src := f.fext(arg)
 	st0 := f.fload()
 	result := f.cur.NewFMul(st0, src)
 	f.fstore(result)
@@ -509,7 +534,7 @@ func (f *Func) liftInstFIMUL(inst *x86.Inst) error {
 	// Multiplies the destination and source operands and stores the product in
 	// the destination location.
 	arg := f.useArg(inst.Arg(0))
-	src := f.cur.NewSIToFP(arg, types.X86FP80)
+	src := f.cur.NewSIToFP(arg, f.l.fpuType())
 	st0 := f.fload()
 	result := f.cur.NewFMul(st0, src)
 	f.fstore(result)
@@ -540,7 +565,7 @@ func (f *Func) liftInstFDIV(inst *x86.Inst) error {
 	}
 	// One-operand form.
 	arg := f.useArg(inst.Arg(0))
-	src := f.cur.NewFPExt(arg, types.X86FP80)
+	src := f.fext(arg)
 	st0 := f.fload()
 	result := f.cur.NewFDiv(st0, src)
 	f.fstore(result)
@@ -565,7 +590,7 @@ func (f *Func) liftInstFDIVP(inst *x86.Inst) error {
 		src := f.useArg(inst.Arg(1))
 		result := f.cur.NewFDiv(dst, src)
 		f.defArg(inst.Arg(0), result)
-		f.fpop()
+		f.fdrop()
 		return nil
 	}
 	// Zero-operand form.
@@ -573,7 +598,7 @@ func (f *Func) liftInstFDIVP(inst *x86.Inst) error {
 	src := f.useReg(x86.NewReg(x86asm.F0, inst))
 	result := f.cur.NewFDiv(dst, src)
 	f.defReg(x86.NewReg(x86asm.F1, inst), result)
-	f.pop()
+	f.fdrop()
 	return nil
 }
 
@@ -590,7 +615,7 @@ func (f *Func) liftInstFIDIV(inst *x86.Inst) error {
 	// Convert an integer source operand to double extended-precision floating-
 	// point format before performing the division.
 	arg := f.useArg(inst.Arg(0))
-	src := f.cur.NewSIToFP(arg, types.X86FP80)
+	src := f.cur.NewSIToFP(arg, f.l.fpuType())
 	st0 := f.fload()
 	result := f.cur.NewFDiv(st0, src)
 	f.fstore(result)
@@ -634,7 +659,7 @@ func (f *Func) liftInstFDIVRP(inst *x86.Inst) error {
 		src := f.useArg(inst.Arg(1))
 		result := f.cur.NewFDiv(src, dst)
 		f.defArg(inst.Arg(0), result)
-		f.pop()
+		f.fdrop()
 		return nil
 	}
 	// Zero-operand form.
@@ -642,7 +667,7 @@ func (f *Func) liftInstFDIVRP(inst *x86.Inst) error {
 	src := f.useReg(x86.NewReg(x86asm.F0, inst))
 	result := f.cur.NewFDiv(src, dst)
 	f.defReg(x86.NewReg(x86asm.F1, inst), result)
-	f.pop()
+	f.fdrop()
 	return nil
 }
 
@@ -709,8 +734,12 @@ func (f *Func) liftInstFCHS(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFRNDINT(inst *x86.Inst) error {
 	// FRNDINT - Round to integer.
-	pretty.Println("inst:", inst)
-	panic("liftInstFRNDINT: not yet implemented")
+	//
+	// Rounds the value in the ST(0) register to an integral value, according to
+	// the RC field of the FPU control word.
+	src := f.fload()
+	f.fstore(f.fround(src))
+	return nil
 }
 
 // --- [ FSCALE ] --------------------------------------------------------------
@@ -771,9 +800,7 @@ func (f *Func) liftInstFCOM(inst *x86.Inst) error {
 		panic(fmt.Errorf("support for zero-operand FCOM not yet implemented; instruction %v at address %v", inst, inst.Addr))
 	}
 	src := f.useArg(inst.Arg(0))
-	if !types.Equal(src.Type(), types.X86FP80) {
-		src = f.cur.NewFPExt(src, types.X86FP80)
-	}
+	src = f.fext(src)
 	st0 := f.fload()
 	a := f.cur.NewFCmp(enum.FPredOGT, st0, src)
 	b := f.cur.NewFCmp(enum.FPredOLT, st0, src)
@@ -852,7 +879,7 @@ func (f *Func) liftInstFCOMP(inst *x86.Inst) error {
 	if err := f.liftInstFCOM(inst); err != nil {
 		return errors.WithStack(err)
 	}
-	f.pop()
+	f.fdrop()
 	return nil
 }
 
@@ -878,8 +905,8 @@ func (f *Func) liftInstFCOMPP(inst *x86.Inst) error {
 	if err := f.liftInstFCOM(inst); err != nil {
 		return errors.WithStack(err)
 	}
-	f.pop()
-	f.pop()
+	f.fdrop()
+	f.fdrop()
 	return nil
 }
 
@@ -939,8 +966,23 @@ func (f *Func) liftInstFICOMP(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFCOMI(inst *x86.Inst) error {
 	// FCOMI - Compare floating-point and set EFLAGS.
-	pretty.Println("inst:", inst)
-	panic("liftInstFCOMI: not yet implemented")
+	//
+	//    FCOMI ST(0), ST(i)     Compare ST(0) with ST(i) and set status flags.
+	//
+	// Compares the contents of register ST(0) and ST(i) and sets the status
+	// flags ZF, PF and CF in the EFLAGS register according to the results (see
+	// the table below). OF, SF and AF are cleared.
+	//
+	//    Comparison results   ZF PF CF
+	//
+	//    ST(0) > ST(i)         0  0  0
+	//    ST(0) < ST(i)         0  0  1
+	//    ST(0) = ST(i)         1  0  0
+	//    Unordered             1  1  1
+	x := f.useArg(inst.Arg(0))
+	y := f.useArg(inst.Arg(1))
+	f.setFlags(flagOpFCmp, x, y, nil)
+	return nil
 }
 
 // --- [ FUCOMI ] --------------------------------------------------------------
@@ -949,8 +991,13 @@ func (f *Func) liftInstFCOMI(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFUCOMI(inst *x86.Inst) error {
 	// FUCOMI - Unordered compare floating-point and set EFLAGS.
-	pretty.Println("inst:", inst)
-	panic("liftInstFUCOMI: not yet implemented")
+	//
+	//    FUCOMI ST(0), ST(i)    Compare ST(0) with ST(i), check for ordered values
+	//                           and set status flags.
+	//
+	// Identical to FCOMI, except for the invalid-arithmetic-operand exception
+	// raised for QNaN operands, which is not modeled.
+	return f.liftInstFCOMI(inst)
 }
 
 // --- [ FCOMIP ] --------------------------------------------------------------
@@ -959,8 +1006,14 @@ func (f *Func) liftInstFUCOMI(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFCOMIP(inst *x86.Inst) error {
 	// FCOMIP - Compare floating-point, set EFLAGS, and pop.
-	pretty.Println("inst:", inst)
-	panic("liftInstFCOMIP: not yet implemented")
+	//
+	//    FCOMIP ST(0), ST(i)    Compare ST(0) with ST(i), set status flags, and
+	//                           pop register stack.
+	if err := f.liftInstFCOMI(inst); err != nil {
+		return errors.WithStack(err)
+	}
+	f.fdrop()
+	return nil
 }
 
 // --- [ FUCOMIP ] -------------------------------------------------------------
@@ -969,8 +1022,14 @@ func (f *Func) liftInstFCOMIP(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFUCOMIP(inst *x86.Inst) error {
 	// FUCOMIP - Unordered compare floating-point, set EFLAGS, and pop.
-	pretty.Println("inst:", inst)
-	panic("liftInstFUCOMIP: not yet implemented")
+	//
+	//    FUCOMIP ST(0), ST(i)   Compare ST(0) with ST(i), check for ordered values,
+	//                           set status flags, and pop register stack.
+	if err := f.liftInstFUCOMI(inst); err != nil {
+		return errors.WithStack(err)
+	}
+	f.fdrop()
+	return nil
 }
 
 // --- [ FTST ] ----------------------------------------------------------------
@@ -1088,7 +1147,7 @@ func (f *Func) liftInstFLD1(inst *x86.Inst) error {
 	//
 	// Push one of seven commonly used constants (in double extended-precision
 	// floating-point format) onto the FPU register stack.
-	src := constant.NewFloat(types.X86FP80, 1)
+	src := constant.NewFloat(f.l.fpuType(), 1)
 	f.fpush(src)
 	return nil
 }
@@ -1104,7 +1163,7 @@ func (f *Func) liftInstFLDZ(inst *x86.Inst) error {
 	//
 	// Push one of seven commonly used constants (in double extended-precision
 	// floating-point format) onto the FPU register stack.
-	src := constant.NewFloat(types.X86FP80, 0)
+	src := constant.NewFloat(f.l.fpuType(), 0)
 	f.fpush(src)
 	return nil
 }
@@ -1120,7 +1179,7 @@ func (f *Func) liftInstFLDPI(inst *x86.Inst) error {
 	//
 	// Push one of seven commonly used constants (in double extended-precision
 	// floating-point format) onto the FPU register stack.
-	src := constant.NewFloat(types.X86FP80, math.Pi)
+	src := constant.NewFloat(f.l.fpuType(), math.Pi)
 	f.fpush(src)
 	return nil
 }
@@ -1136,7 +1195,7 @@ func (f *Func) liftInstFLDL2E(inst *x86.Inst) error {
 	//
 	// Push one of seven commonly used constants (in double extended-precision
 	// floating-point format) onto the FPU register stack.
-	src := constant.NewFloat(types.X86FP80, math.Log2E)
+	src := constant.NewFloat(f.l.fpuType(), math.Log2E)
 	f.fpush(src)
 	return nil
 }
@@ -1152,7 +1211,7 @@ func (f *Func) liftInstFLDLN2(inst *x86.Inst) error {
 	//
 	// Push one of seven commonly used constants (in double extended-precision
 	// floating-point format) onto the FPU register stack.
-	src := constant.NewFloat(types.X86FP80, math.Ln2)
+	src := constant.NewFloat(f.l.fpuType(), math.Ln2)
 	f.fpush(src)
 	return nil
 }
@@ -1168,7 +1227,7 @@ func (f *Func) liftInstFLDL2T(inst *x86.Inst) error {
 	//
 	// Push one of seven commonly used constants (in double extended-precision
 	// floating-point format) onto the FPU register stack.
-	src := constant.NewFloat(types.X86FP80, math.Log2(10))
+	src := constant.NewFloat(f.l.fpuType(), math.Log2(10))
 	f.fpush(src)
 	return nil
 }
@@ -1184,7 +1243,7 @@ func (f *Func) liftInstFLDLG2(inst *x86.Inst) error {
 	//
 	// Push one of seven commonly used constants (in double extended-precision
 	// floating-point format) onto the FPU register stack.
-	src := constant.NewFloat(types.X86FP80, math.Log10(2))
+	src := constant.NewFloat(f.l.fpuType(), math.Log10(2))
 	f.fpush(src)
 	return nil
 }
@@ -1197,8 +1256,8 @@ func (f *Func) liftInstFLDLG2(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFINCSTP(inst *x86.Inst) error {
 	// FINCSTP - Increment FPU register stack pointer.
-	pretty.Println("inst:", inst)
-	panic("liftInstFINCSTP: not yet implemented")
+	f.fsetTop(1)
+	return nil
 }
 
 // --- [ FDECSTP ] -------------------------------------------------------------
@@ -1207,8 +1266,8 @@ func (f *Func) liftInstFINCSTP(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFDECSTP(inst *x86.Inst) error {
 	// FDECSTP - Decrement FPU register stack pointer.
-	pretty.Println("inst:", inst)
-	panic("liftInstFDECSTP: not yet implemented")
+	f.fsetTop(-1)
+	return nil
 }
 
 // --- [ FFREE ] ---------------------------------------------------------------
@@ -1217,8 +1276,9 @@ func (f *Func) liftInstFDECSTP(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFFREE(inst *x86.Inst) error {
 	// FFREE - Free floating-point register.
-	pretty.Println("inst:", inst)
-	panic("liftInstFFREE: not yet implemented")
+	//
+	// The FPU tag word is not modeled; FFREE leaves the register stack as is.
+	return nil
 }
 
 // --- [ FINIT ] ---------------------------------------------------------------
@@ -1227,8 +1287,7 @@ func (f *Func) liftInstFFREE(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFINIT(inst *x86.Inst) error {
 	// FINIT - Initialize FPU after checking error conditions.
-	pretty.Println("inst:", inst)
-	panic("liftInstFINIT: not yet implemented")
+	return f.liftInstFNINIT(inst)
 }
 
 // --- [ FNINIT ] --------------------------------------------------------------
@@ -1237,8 +1296,12 @@ func (f *Func) liftInstFINIT(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFNINIT(inst *x86.Inst) error {
 	// FNINIT - Initialize FPU without checking error conditions.
-	pretty.Println("inst:", inst)
-	panic("liftInstFNINIT: not yet implemented")
+	//
+	// Sets the FPU control word to its default value, and empties the register
+	// stack.
+	f.cur.NewStore(f.constInt(types.I16, defaultFPUControlWord), f.controlWord())
+	f.fresetTop()
+	return nil
 }
 
 // --- [ FCLEX ] ---------------------------------------------------------------
@@ -1269,8 +1332,7 @@ func (f *Func) liftInstFNCLEX(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFSTCW(inst *x86.Inst) error {
 	// FSTCW - Store FPU control word after checking error conditions.
-	pretty.Println("inst:", inst)
-	panic("liftInstFSTCW: not yet implemented")
+	return f.liftInstFNSTCW(inst)
 }
 
 // --- [ FNSTCW ] --------------------------------------------------------------
@@ -1279,8 +1341,11 @@ func (f *Func) liftInstFSTCW(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFNSTCW(inst *x86.Inst) error {
 	// FNSTCW - Store FPU control word without checking error conditions.
-	pretty.Println("inst:", inst)
-	panic("liftInstFNSTCW: not yet implemented")
+	//
+	//    FNSTCW m2byte       Store FPU control word to m2byte.
+	cw := f.cur.NewLoad(f.controlWord())
+	f.defArgElem(inst.Arg(0), cw, types.I16)
+	return nil
 }
 
 // --- [ FLDCW ] ---------------------------------------------------------------
@@ -1289,8 +1354,11 @@ func (f *Func) liftInstFNSTCW(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstFLDCW(inst *x86.Inst) error {
 	// FLDCW - Load FPU control word.
-	pretty.Println("inst:", inst)
-	panic("liftInstFLDCW: not yet implemented")
+	//
+	//    FLDCW m2byte        Load FPU control word from m2byte.
+	cw := f.useArgElem(inst.Arg(0), types.I16)
+	f.cur.NewStore(cw, f.controlWord())
+	return nil
 }
 
 // --- [ FSTENV ] --------------------------------------------------------------
@@ -1299,8 +1367,7 @@ func (f *Func) liftInstFLDCW(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFSTENV(inst *x86.Inst) error {
 	// FSTENV - Store FPU environment after checking error conditions.
-	pretty.Println("inst:", inst)
-	panic("liftInstFSTENV: not yet implemented")
+	return f.liftInstFNSTENV(inst)
 }
 
 // --- [ FNSTENV ] -------------------------------------------------------------
@@ -1309,8 +1376,15 @@ func (f *Func) liftInstFSTENV(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFNSTENV(inst *x86.Inst) error {
 	// FNSTENV - Store FPU environment without checking error conditions.
-	pretty.Println("inst:", inst)
-	panic("liftInstFNSTENV: not yet implemented")
+	//
+	//    FNSTENV m14/28byte  Store FPU environment to m14byte or m28byte. Then
+	//                        mask all floating-point exceptions.
+	env, size := f.fpuEnv(inst)
+	cw := f.cur.NewLoad(f.controlWord())
+	f.cur.NewCall(f.l.intrinsics[fnstenvFunc], env, cw, f.constInt(types.I32, size))
+	masked := f.cur.NewOr(cw, f.constInt(types.I16, 0x3F))
+	f.cur.NewStore(masked, f.controlWord())
+	return nil
 }
 
 // --- [ FLDENV ] --------------------------------------------------------------
@@ -1319,8 +1393,12 @@ func (f *Func) liftInstFNSTENV(inst *x86.Inst) error {
 // code to f.
 func (f *Func) liftInstFLDENV(inst *x86.Inst) error {
 	// FLDENV - Load FPU environment.
-	pretty.Println("inst:", inst)
-	panic("liftInstFLDENV: not yet implemented")
+	//
+	//    FLDENV m14/28byte   Load FPU environment from m14byte or m28byte.
+	env, _ := f.fpuEnv(inst)
+	cw := f.cur.NewCall(f.l.intrinsics[fldenvFunc], env)
+	f.cur.NewStore(cw, f.controlWord())
+	return nil
 }
 
 // --- [ FSAVE ] ---------------------------------------------------------------
@@ -1385,38 +1463,6 @@ func (f *Func) liftInstFNSTSW(inst *x86.Inst) error {
 	// Stores the current value of the x87 FPU status word in the destination
 	// location.
 
-	// Load FPU status flags.
-	b := f.useFStatus(Busy)
-	c3 := f.useFStatus(C3)
-	st := f.fload()
-	c2 := f.useFStatus(C2)
-	c1 := f.useFStatus(C1)
-	c0 := f.useFStatus(C0)
-	es := f.useFStatus(ES)
-	sf := f.useFStatus(StackFault)
-	pe := f.useFStatus(PE)
-	ue := f.useFStatus(UE)
-	oe := f.useFStatus(OE)
-	ze := f.useFStatus(ZE)
-	de := f.useFStatus(DE)
-	ie := f.useFStatus(IE)
-
-	// Extend to 16-bit.
-	b = f.cur.NewZExt(b, types.I16)
-	c3 = f.cur.NewZExt(b, types.I16)
-	st = f.cur.NewZExt(b, types.I16)
-	c2 = f.cur.NewZExt(b, types.I16)
-	c1 = f.cur.NewZExt(b, types.I16)
-	c0 = f.cur.NewZExt(b, types.I16)
-	es = f.cur.NewZExt(b, types.I16)
-	sf = f.cur.NewZExt(b, types.I16)
-	pe = f.cur.NewZExt(b, types.I16)
-	ue = f.cur.NewZExt(b, types.I16)
-	oe = f.cur.NewZExt(b, types.I16)
-	ze = f.cur.NewZExt(b, types.I16)
-	de = f.cur.NewZExt(b, types.I16)
-	ie = f.cur.NewZExt(b, types.I16)
-
 	// x87 FPU Status Word
 	//
 	//    15    - B, FPU Busy
@@ -1436,38 +1482,22 @@ func (f *Func) liftInstFNSTSW(inst *x86.Inst) error {
 	//
 	// ref: 8.1.3 x87 FPU Status Register, Intel 64 and IA-32 architectures
 	// software developer's manual volume 1: Basic architecture.
-	b = f.cur.NewShl(b, constant.NewInt(types.I64, 15))
-	c3 = f.cur.NewShl(c3, constant.NewInt(types.I64, 14))
-	st = f.cur.NewShl(st, constant.NewInt(types.I64, 11))
-	c2 = f.cur.NewShl(c2, constant.NewInt(types.I64, 10))
-	c1 = f.cur.NewShl(c1, constant.NewInt(types.I64, 9))
-	c0 = f.cur.NewShl(c0, constant.NewInt(types.I64, 8))
-	es = f.cur.NewShl(es, constant.NewInt(types.I64, 7))
-	sf = f.cur.NewShl(sf, constant.NewInt(types.I64, 6))
-	pe = f.cur.NewShl(pe, constant.NewInt(types.I64, 5))
-	ue = f.cur.NewShl(ue, constant.NewInt(types.I64, 4))
-	oe = f.cur.NewShl(oe, constant.NewInt(types.I64, 3))
-	ze = f.cur.NewShl(ze, constant.NewInt(types.I64, 2))
-	de = f.cur.NewShl(de, constant.NewInt(types.I64, 1))
-	//ie = f.cur.NewShl(ie, constant.NewInt(0, types.I64))
-
-	tmp := f.cur.NewOr(b, c3)
-	tmp = f.cur.NewOr(tmp, st)
-	tmp = f.cur.NewOr(tmp, c2)
-	tmp = f.cur.NewOr(tmp, c1)
-	tmp = f.cur.NewOr(tmp, c0)
-	tmp = f.cur.NewOr(tmp, es)
-	tmp = f.cur.NewOr(tmp, sf)
-	tmp = f.cur.NewOr(tmp, pe)
-	tmp = f.cur.NewOr(tmp, ue)
-	tmp = f.cur.NewOr(tmp, oe)
-	tmp = f.cur.NewOr(tmp, ze)
-	tmp = f.cur.NewOr(tmp, de)
-	result := f.cur.NewOr(tmp, ie)
+	fields := []struct {
+		fstatus FStatusFlag
+		bit     int64
+	}{
+		{Busy, 15}, {C3, 14}, {C2, 10}, {C1, 9}, {C0, 8}, {ES, 7},
+		{StackFault, 6}, {PE, 5}, {UE, 4}, {OE, 3}, {ZE, 2}, {DE, 1}, {IE, 0},
+	}
+	top := f.cur.NewZExt(f.fgetTop(), types.I16)
+	var result value.Value = f.cur.NewShl(top, f.constInt(types.I16, 11))
+	for _, field := range fields {
+		v := f.cur.NewZExt(f.useFStatus(field.fstatus), types.I16)
+		result = f.cur.NewOr(result, f.cur.NewShl(v, f.constInt(types.I16, field.bit)))
+	}
 
 	// Store FPU status flags.
 	f.defArg(inst.Arg(0), result)
-
 	return nil
 }
 
@@ -1490,128 +1520,3 @@ func (f *Func) liftInstFNOP(inst *x86.Inst) error {
 	pretty.Println("inst:", inst)
 	panic("liftInstFNOP: not yet implemented")
 }
-
-// ### [ Helper functions ] ####################################################
-
-// fpush pushes the given value to the top of the FPU register stack, emitting
-// code to f.
-func (f *Func) fpush(src value.Value) {
-	// Decrement st.
-	tmp1 := f.cur.NewLoad(f.st)
-	targetTrue := &ir.BasicBlock{}
-	targetFalse := &ir.BasicBlock{}
-	follow := &ir.BasicBlock{}
-	targetTrue.NewBr(follow)
-	targetFalse.NewBr(follow)
-	zero := constant.NewInt(types.I8, 0)
-	cond := f.cur.NewICmp(enum.IPredEQ, tmp1, zero)
-	f.cur.NewCondBr(cond, targetTrue, targetFalse)
-	f.cur = targetTrue
-	f.Blocks = append(f.Blocks, targetTrue)
-	seven := constant.NewInt(types.I8, 7)
-	f.cur.NewStore(seven, f.st)
-	f.cur = targetFalse
-	f.Blocks = append(f.Blocks, targetFalse)
-	one := constant.NewInt(types.I8, 1)
-	tmp2 := f.cur.NewSub(tmp1, one)
-	f.cur.NewStore(tmp2, f.st)
-	f.cur = follow
-	f.Blocks = append(f.Blocks, follow)
-
-	// Store arg at st(0).
-	f.fstore(src)
-}
-
-// fpop pops and returns the top of the FPU register stack, emitting code to f.
-func (f *Func) fpop() value.Value {
-	// Load arg from st(0).
-	v := f.fload()
-
-	// TODO: Mark st(0) as empty before incrementing st.
-
-	// Increment st.
-	tmp1 := f.cur.NewLoad(f.st)
-	targetTrue := &ir.BasicBlock{}
-	targetFalse := &ir.BasicBlock{}
-	follow := &ir.BasicBlock{}
-	targetTrue.NewBr(follow)
-	targetFalse.NewBr(follow)
-	zero := constant.NewInt(types.I8, 7)
-	cond := f.cur.NewICmp(enum.IPredEQ, tmp1, zero)
-	f.cur.NewCondBr(cond, targetTrue, targetFalse)
-	f.cur = targetTrue
-	f.Blocks = append(f.Blocks, targetTrue)
-	seven := constant.NewInt(types.I8, 0)
-	f.cur.NewStore(seven, f.st)
-	f.cur = targetFalse
-	f.Blocks = append(f.Blocks, targetFalse)
-	one := constant.NewInt(types.I8, 1)
-	tmp2 := f.cur.NewAdd(tmp1, one)
-	f.cur.NewStore(tmp2, f.st)
-	f.cur = follow
-	f.Blocks = append(f.Blocks, follow)
-
-	// Return arg.
-	return v
-}
-
-// fstore stores the source value to the top FPU register, emitting code to f.
-func (f *Func) fstore(src value.Value) {
-	// Store arg at st(0).
-	end := &ir.BasicBlock{}
-	cur := f.cur
-	var cases []*ir.Case
-	regs := []x86asm.Reg{x86asm.F0, x86asm.F1, x86asm.F2, x86asm.F3, x86asm.F4, x86asm.F5, x86asm.F6, x86asm.F7}
-	for i, reg := range regs {
-		block := &ir.BasicBlock{}
-		block.NewBr(end)
-		f.cur = block
-		f.Blocks = append(f.Blocks, block)
-		dst := f.reg(reg)
-		f.cur.NewStore(src, dst)
-		c := ir.NewCase(constant.NewInt(types.I8, int64(i)), block)
-		cases = append(cases, c)
-	}
-	f.cur = cur
-	st := f.cur.NewLoad(f.st)
-	defaultTarget := &ir.BasicBlock{}
-	defaultTarget.NewUnreachable()
-	f.Blocks = append(f.Blocks, defaultTarget)
-	f.cur.NewSwitch(st, defaultTarget, cases...)
-	f.cur = end
-	f.Blocks = append(f.Blocks, end)
-}
-
-// fload returns the value of the top FPU register, emitting code to f.
-func (f *Func) fload() value.Value {
-	// Load value from st(0).
-	end := &ir.BasicBlock{}
-	cur := f.cur
-	var cases []*ir.Case
-	regs := []x86asm.Reg{x86asm.F0, x86asm.F1, x86asm.F2, x86asm.F3, x86asm.F4, x86asm.F5, x86asm.F6, x86asm.F7}
-	var incs []*ir.Incoming
-	for i, reg := range regs {
-		block := &ir.BasicBlock{}
-		block.NewBr(end)
-		f.cur = block
-		f.Blocks = append(f.Blocks, block)
-		src := f.reg(reg)
-		v := f.cur.NewLoad(src)
-		inc := &ir.Incoming{
-			X:    v,
-			Pred: block,
-		}
-		incs = append(incs, inc)
-		c := ir.NewCase(constant.NewInt(types.I8, int64(i)), block)
-		cases = append(cases, c)
-	}
-	f.cur = cur
-	st := f.cur.NewLoad(f.st)
-	defaultTarget := &ir.BasicBlock{}
-	defaultTarget.NewUnreachable()
-	f.Blocks = append(f.Blocks, defaultTarget)
-	f.cur.NewSwitch(st, defaultTarget, cases...)
-	f.cur = end
-	f.Blocks = append(f.Blocks, end)
-	return f.cur.NewPhi(incs...)
-}
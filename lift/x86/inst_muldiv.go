@@ -0,0 +1,183 @@
+package x86
+
+import (
+	"fmt"
+
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/enum"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// Full-width Multiply and Divide
+//
+//    MUL r/m       Unsigned multiply (AX = AL * r/m8, DX:AX = AX * r/m16, ...).
+//    IMUL r/m      Signed multiply (AX = AL * r/m8, DX:AX = AX * r/m16, ...).
+//    DIV r/m       Unsigned divide (AL, AH = AX / r/m8, AX, DX = DX:AX / r/m16, ...).
+//    IDIV r/m      Signed divide (AL, AH = AX / r/m8, AX, DX = DX:AX / r/m16, ...).
+//
+// The one-operand forms operate on an implicit operand of twice the operand
+// size, held in the register pair AH:AL, DX:AX, EDX:EAX or RDX:RAX. The wide
+// operand is composed from (and decomposed into) its halves, and the product,
+// quotient and remainder are computed on integers of twice the operand size.
+//
+// A divide error (#DE) is raised by DIV and IDIV if the divisor is zero, or if
+// the quotient is too large for the destination register. The divide error is
+// lifted as a call to llvm.trap.
+//
+// ref: $ 4.2 MUL, IMUL, DIV and IDIV, Intel 64 and IA-32 Architectures
+// Software Developer's Manual
+
+// Name of LLVM intrinsic which traps; used to model processor exceptions.
+const intrinsicTrap = "llvm.trap"
+
+// liftInstMulWide lifts the one-operand form of the given x86 MUL or IMUL
+// instruction to LLVM IR, emitting code to f. CF and OF are set if the high
+// half of the product is significant.
+func (f *Func) liftInstMulWide(inst *x86.Inst, signed bool) error {
+	y := f.useArg(inst.Arg(0))
+	typ, ok := y.Type().(*types.IntType)
+	if !ok {
+		panic(fmt.Errorf("support for operand type %v not yet implemented", y.Type()))
+	}
+	lo, hi := wideRegs(typ)
+	wide := types.NewInt(2 * typ.BitSize)
+	x := f.useReg(lo)
+	result := f.cur.NewMul(f.extend(x, wide, signed), f.extend(y, wide, signed))
+	low := f.cur.NewTrunc(result, typ)
+	if hi == nil {
+		// AX = AL * r/m8
+		f.defReg(x86.AX, result)
+	} else {
+		high := f.cur.NewLShr(result, f.constInt(wide, int64(typ.BitSize)))
+		f.defReg(lo, low)
+		f.defReg(hi, f.cur.NewTrunc(high, typ))
+	}
+	// CF = OF = product not representable in the low half.
+	overflow := f.cur.NewICmp(enum.IPredNE, result, f.extend(low, wide, signed))
+	f.defStatus(CF, overflow)
+	f.defStatus(OF, overflow)
+	return nil
+}
+
+// liftInstIMULTrunc lifts the two- and three-operand forms of the given x86
+// IMUL instruction to LLVM IR, emitting code to f. The product is truncated to
+// the operand size; CF and OF are set if significant bits were lost.
+//
+//    IMUL r, r/m           r = r * r/m
+//    IMUL r, r/m, imm      r = r/m * imm
+func (f *Func) liftInstIMULTrunc(inst *x86.Inst) error {
+	typ := regType(inst.Args[0].(x86asm.Reg)).(*types.IntType)
+	var x, y value.Value
+	if inst.Args[2] != nil {
+		x, y = f.useArg(inst.Arg(1)), f.useArgAs(inst.Arg(2), typ)
+	} else {
+		x, y = f.useArg(inst.Arg(0)), f.useArgAs(inst.Arg(1), typ)
+	}
+	wide := types.NewInt(2 * typ.BitSize)
+	product := f.cur.NewMul(f.cur.NewSExt(x, wide), f.cur.NewSExt(y, wide))
+	result := f.cur.NewTrunc(product, typ)
+	f.defArg(inst.Arg(0), result)
+	overflow := f.cur.NewICmp(enum.IPredNE, product, f.cur.NewSExt(result, wide))
+	f.defStatus(CF, overflow)
+	f.defStatus(OF, overflow)
+	return nil
+}
+
+// liftInstDivWide lifts the given x86 DIV or IDIV instruction to LLVM IR,
+// emitting code to f. The quotient is stored in the low half and the remainder
+// in the high half of the implicit operand.
+func (f *Func) liftInstDivWide(inst *x86.Inst, signed bool) error {
+	y := f.useArg(inst.Arg(0))
+	typ, ok := y.Type().(*types.IntType)
+	if !ok {
+		panic(fmt.Errorf("support for operand type %v not yet implemented", y.Type()))
+	}
+	lo, hi := wideRegs(typ)
+	wide := types.NewInt(2 * typ.BitSize)
+	bits := f.constInt(wide, int64(typ.BitSize))
+	// Dividend; AX, DX:AX, EDX:EAX or RDX:RAX.
+	var x value.Value
+	if hi == nil {
+		x = f.useReg(x86.AX)
+	} else {
+		high := f.cur.NewShl(f.cur.NewZExt(f.useReg(hi), wide), bits)
+		x = f.cur.NewOr(high, f.cur.NewZExt(f.useReg(lo), wide))
+	}
+	// #DE on division by zero. The signed division of the smallest wide integer
+	// by -1 overflows the wide integer, and is checked before dividing as it
+	// would otherwise be undefined behaviour in LLVM IR.
+	var divErr value.Value = f.cur.NewICmp(enum.IPredEQ, y, f.constInt(typ, 0))
+	if signed {
+		min := f.cur.NewShl(f.constInt(wide, 1), f.constInt(wide, int64(2*typ.BitSize-1)))
+		isMin := f.cur.NewICmp(enum.IPredEQ, x, min)
+		isNegOne := f.cur.NewICmp(enum.IPredEQ, y, f.constInt(typ, -1))
+		divErr = f.cur.NewOr(divErr, f.cur.NewAnd(isMin, isNegOne))
+	}
+	f.divideError(divErr)
+	d := f.extend(y, wide, signed)
+	var quo, rem value.Value
+	if signed {
+		quo, rem = f.cur.NewSDiv(x, d), f.cur.NewSRem(x, d)
+	} else {
+		quo, rem = f.cur.NewUDiv(x, d), f.cur.NewURem(x, d)
+	}
+	// #DE if the quotient is too large for the low half.
+	q := f.cur.NewTrunc(quo, typ)
+	f.divideError(f.cur.NewICmp(enum.IPredNE, quo, f.extend(q, wide, signed)))
+	r := f.cur.NewTrunc(rem, typ)
+	if hi == nil {
+		// AL = quotient, AH = remainder
+		f.defReg(x86.AL, q)
+		f.defReg(x86.AH, r)
+	} else {
+		f.defReg(lo, q)
+		f.defReg(hi, r)
+	}
+	return nil
+}
+
+// divideError emits a conditional branch to a basic block raising a divide
+// error (#DE) if cond is true, emitting code to f. Lifting continues in the
+// basic block of the false branch.
+func (f *Func) divideError(cond value.Value) {
+	trap := &ir.BasicBlock{}
+	trap.NewCall(f.l.intrinsics[intrinsicTrap])
+	trap.NewUnreachable()
+	next := &ir.BasicBlock{}
+	f.Blocks = append(f.Blocks, trap, next)
+	f.cur.NewCondBr(cond, trap, next)
+	f.cur = next
+}
+
+// ### [ Helper functions ] ####################################################
+
+// wideRegs returns the low and high halves of the implicit operand of twice the
+// size of the given operand type, as used by the one-operand forms of MUL,
+// IMUL, DIV and IDIV. The high half is nil for 8-bit operands, as the implicit
+// operand AX is accessed as a whole.
+func wideRegs(typ *types.IntType) (lo, hi *x86.Reg) {
+	switch typ.BitSize {
+	case 8:
+		return x86.AL, nil
+	case 16:
+		return x86.AX, x86.DX
+	case 32:
+		return x86.EAX, x86.EDX
+	case 64:
+		return x86.RAX, x86.RDX
+	default:
+		panic(fmt.Errorf("support for operand bit size %d not yet implemented", typ.BitSize))
+	}
+}
+
+// extend sign- or zero-extends the given integer value to the specified type,
+// emitting code to f.
+func (f *Func) extend(v value.Value, typ *types.IntType, signed bool) value.Value {
+	if signed {
+		return f.cur.NewSExt(v, typ)
+	}
+	return f.cur.NewZExt(v, typ)
+}
@@ -6,7 +6,6 @@ import (
 	"github.com/decomp/exp/disasm/x86"
 	"github.com/kr/pretty"
 	"github.com/llir/llvm/ir"
-	"github.com/llir/llvm/ir/constant"
 	"github.com/llir/llvm/ir/enum"
 	"github.com/llir/llvm/ir/types"
 	"github.com/pkg/errors"
@@ -37,7 +36,7 @@ func (f *Func) liftREPInst(inst *x86.Inst) error {
 	// Generate loop basic block.
 	f.cur = loop
 	ecx := f.useReg(x86.ECX)
-	zero := constant.NewInt(types.I32, 0)
+	zero := f.constInt(types.I32, 0)
 	cond := f.cur.NewICmp(enum.IPredNE, ecx, zero)
 	f.cur.NewCondBr(cond, body, exit)
 	// Generate body basic block.
@@ -67,7 +66,7 @@ func (f *Func) liftREPInst(inst *x86.Inst) error {
 		panic(fmt.Errorf("support for REP prefixed %v instruction not yet implemented", inst.Op))
 	}
 	ecx = f.useReg(x86.ECX)
-	one := constant.NewInt(types.I32, 1)
+	one := f.constInt(types.I32, 1)
 	tmp := f.cur.NewSub(ecx, one)
 	f.defReg(x86.ECX, tmp)
 	f.cur.NewBr(loop)
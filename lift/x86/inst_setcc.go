@@ -2,9 +2,6 @@ package x86
 
 import (
 	"github.com/decomp/exp/disasm/x86"
-	"github.com/llir/llvm/ir"
-	"github.com/llir/llvm/ir/constant"
-	"github.com/llir/llvm/ir/enum"
 	"github.com/llir/llvm/ir/types"
 	"github.com/llir/llvm/ir/value"
 )
@@ -52,12 +49,7 @@ import (
 func (f *Func) liftInstSETA(inst *x86.Inst) error {
 	// Set byte if above.
 	//    (CF=0 and ZF=0)
-	cf := f.useStatus(CF)
-	zf := f.useStatus(ZF)
-	cond1 := f.cur.NewICmp(enum.IPredEQ, cf, constant.False)
-	cond2 := f.cur.NewICmp(enum.IPredEQ, zf, constant.False)
-	cond := f.cur.NewAnd(cond1, cond2)
-	return f.liftInstSETcc(inst.Arg(0), cond)
+	return f.liftInstSETcc(inst.Arg(0), f.cond(condA))
 }
 
 // --- [ SETAE ] ---------------------------------------------------------------
@@ -67,9 +59,7 @@ func (f *Func) liftInstSETA(inst *x86.Inst) error {
 func (f *Func) liftInstSETAE(inst *x86.Inst) error {
 	// Set byte if above or equal.
 	//    (CF=0)
-	cf := f.useStatus(CF)
-	cond := f.cur.NewICmp(enum.IPredEQ, cf, constant.False)
-	return f.liftInstSETcc(inst.Arg(0), cond)
+	return f.liftInstSETcc(inst.Arg(0), f.cond(condAE))
 }
 
 // --- [ SETBE ] ---------------------------------------------------------------
@@ -79,12 +69,7 @@ func (f *Func) liftInstSETAE(inst *x86.Inst) error {
 func (f *Func) liftInstSETBE(inst *x86.Inst) error {
 	// Set byte if below or equal.
 	//    (CF=1 or ZF=1)
-	cf := f.useStatus(CF)
-	zf := f.useStatus(ZF)
-	cond1 := cf
-	cond2 := zf
-	cond := f.cur.NewOr(cond1, cond2)
-	return f.liftInstSETcc(inst.Arg(0), cond)
+	return f.liftInstSETcc(inst.Arg(0), f.cond(condBE))
 }
 
 // --- [ SETB ] ----------------------------------------------------------------
@@ -94,9 +79,7 @@ func (f *Func) liftInstSETBE(inst *x86.Inst) error {
 func (f *Func) liftInstSETB(inst *x86.Inst) error {
 	// Set byte if below.
 	//    (CF=1)
-	cf := f.useStatus(CF)
-	cond := cf
-	return f.liftInstSETcc(inst.Arg(0), cond)
+	return f.liftInstSETcc(inst.Arg(0), f.cond(condB))
 }
 
 // --- [ SETNO ] ---------------------------------------------------------------
@@ -106,9 +89,7 @@ func (f *Func) liftInstSETB(inst *x86.Inst) error {
 func (f *Func) liftInstSETNO(inst *x86.Inst) error {
 	// Set byte if not overflow.
 	//    (OF=0)
-	of := f.useStatus(OF)
-	cond := f.cur.NewICmp(enum.IPredEQ, of, constant.False)
-	return f.liftInstSETcc(inst.Arg(0), cond)
+	return f.liftInstSETcc(inst.Arg(0), f.cond(condNO))
 }
 
 // --- [ SETO ] ----------------------------------------------------------------
@@ -118,9 +99,7 @@ func (f *Func) liftInstSETNO(inst *x86.Inst) error {
 func (f *Func) liftInstSETO(inst *x86.Inst) error {
 	// Set byte if overflow.
 	//    (OF=1)
-	of := f.useStatus(OF)
-	cond := of
-	return f.liftInstSETcc(inst.Arg(0), cond)
+	return f.liftInstSETcc(inst.Arg(0), f.cond(condO))
 }
 
 // --- [ SETNP ] ---------------------------------------------------------------
@@ -130,9 +109,7 @@ func (f *Func) liftInstSETO(inst *x86.Inst) error {
 func (f *Func) liftInstSETNP(inst *x86.Inst) error {
 	// Set byte if not parity.
 	//    (PF=0)
-	pf := f.useStatus(PF)
-	cond := f.cur.NewICmp(enum.IPredEQ, pf, constant.False)
-	return f.liftInstSETcc(inst.Arg(0), cond)
+	return f.liftInstSETcc(inst.Arg(0), f.cond(condNP))
 }
 
 // --- [ SETP ] ----------------------------------------------------------------
@@ -142,9 +119,7 @@ func (f *Func) liftInstSETNP(inst *x86.Inst) error {
 func (f *Func) liftInstSETP(inst *x86.Inst) error {
 	// Set byte if parity.
 	//    (PF=1)
-	pf := f.useStatus(PF)
-	cond := pf
-	return f.liftInstSETcc(inst.Arg(0), cond)
+	return f.liftInstSETcc(inst.Arg(0), f.cond(condP))
 }
 
 // --- [ SETNS ] ---------------------------------------------------------------
@@ -154,9 +129,7 @@ func (f *Func) liftInstSETP(inst *x86.Inst) error {
 func (f *Func) liftInstSETNS(inst *x86.Inst) error {
 	// Set byte if not sign.
 	//    (SF=0)
-	sf := f.useStatus(SF)
-	cond := f.cur.NewICmp(enum.IPredEQ, sf, constant.False)
-	return f.liftInstSETcc(inst.Arg(0), cond)
+	return f.liftInstSETcc(inst.Arg(0), f.cond(condNS))
 }
 
 // --- [ SETS ] ----------------------------------------------------------------
@@ -166,9 +139,7 @@ func (f *Func) liftInstSETNS(inst *x86.Inst) error {
 func (f *Func) liftInstSETS(inst *x86.Inst) error {
 	// Set byte if sign.
 	//    (SF=1)
-	sf := f.useStatus(SF)
-	cond := sf
-	return f.liftInstSETcc(inst.Arg(0), cond)
+	return f.liftInstSETcc(inst.Arg(0), f.cond(condS))
 }
 
 // --- [ SETGE ] ---------------------------------------------------------------
@@ -178,10 +149,7 @@ func (f *Func) liftInstSETS(inst *x86.Inst) error {
 func (f *Func) liftInstSETGE(inst *x86.Inst) error {
 	// Set byte if greater or equal.
 	//    (SF=OF)
-	sf := f.useStatus(SF)
-	of := f.useStatus(OF)
-	cond := f.cur.NewICmp(enum.IPredEQ, sf, of)
-	return f.liftInstSETcc(inst.Arg(0), cond)
+	return f.liftInstSETcc(inst.Arg(0), f.cond(condGE))
 }
 
 // --- [ SETL ] ----------------------------------------------------------------
@@ -191,10 +159,7 @@ func (f *Func) liftInstSETGE(inst *x86.Inst) error {
 func (f *Func) liftInstSETL(inst *x86.Inst) error {
 	// Set byte if less.
 	//    (SF≠OF)
-	sf := f.useStatus(SF)
-	of := f.useStatus(OF)
-	cond := f.cur.NewICmp(enum.IPredNE, sf, of)
-	return f.liftInstSETcc(inst.Arg(0), cond)
+	return f.liftInstSETcc(inst.Arg(0), f.cond(condL))
 }
 
 // --- [ SETG ] ----------------------------------------------------------------
@@ -204,13 +169,7 @@ func (f *Func) liftInstSETL(inst *x86.Inst) error {
 func (f *Func) liftInstSETG(inst *x86.Inst) error {
 	// Set byte if greater.
 	//    (ZF=0 and SF=OF)
-	zf := f.useStatus(ZF)
-	sf := f.useStatus(SF)
-	of := f.useStatus(OF)
-	cond1 := f.cur.NewICmp(enum.IPredEQ, zf, constant.False)
-	cond2 := f.cur.NewICmp(enum.IPredEQ, sf, of)
-	cond := f.cur.NewAnd(cond1, cond2)
-	return f.liftInstSETcc(inst.Arg(0), cond)
+	return f.liftInstSETcc(inst.Arg(0), f.cond(condG))
 }
 
 // --- [ SETNE ] ---------------------------------------------------------------
@@ -220,9 +179,7 @@ func (f *Func) liftInstSETG(inst *x86.Inst) error {
 func (f *Func) liftInstSETNE(inst *x86.Inst) error {
 	// Set byte if not equal.
 	//    (ZF=0)
-	zf := f.useStatus(ZF)
-	cond := f.cur.NewICmp(enum.IPredEQ, zf, constant.False)
-	return f.liftInstSETcc(inst.Arg(0), cond)
+	return f.liftInstSETcc(inst.Arg(0), f.cond(condNE))
 }
 
 // --- [ SETLE ] ---------------------------------------------------------------
@@ -232,13 +189,7 @@ func (f *Func) liftInstSETNE(inst *x86.Inst) error {
 func (f *Func) liftInstSETLE(inst *x86.Inst) error {
 	// Set byte if less or equal.
 	//    (ZF=1 or SF≠OF)
-	zf := f.useStatus(ZF)
-	sf := f.useStatus(SF)
-	of := f.useStatus(OF)
-	cond1 := zf
-	cond2 := f.cur.NewICmp(enum.IPredNE, sf, of)
-	cond := f.cur.NewOr(cond1, cond2)
-	return f.liftInstSETcc(inst.Arg(0), cond)
+	return f.liftInstSETcc(inst.Arg(0), f.cond(condLE))
 }
 
 // --- [ SETE ] ----------------------------------------------------------------
@@ -248,25 +199,16 @@ func (f *Func) liftInstSETLE(inst *x86.Inst) error {
 func (f *Func) liftInstSETE(inst *x86.Inst) error {
 	// Set byte if equal.
 	//    (ZF=1)
-	zf := f.useStatus(ZF)
-	cond := zf
-	return f.liftInstSETcc(inst.Arg(0), cond)
+	return f.liftInstSETcc(inst.Arg(0), f.cond(condE))
 }
 
 // === [ Helper functions ] ====================================================
 
 // liftInstSETcc lifts the given x86 SETcc instruction to LLVM IR, emitting code
-// to f.
+// to f. The condition is materialized as a byte value (1 if cond holds, and 0
+// otherwise), without introducing control flow.
 func (f *Func) liftInstSETcc(arg *x86.Arg, cond value.Value) error {
-	targetTrue := &ir.BasicBlock{}
-	exit := &ir.BasicBlock{}
-	f.Blocks = append(f.Blocks, targetTrue)
-	f.Blocks = append(f.Blocks, exit)
-	f.cur.NewCondBr(cond, targetTrue, exit)
-	f.cur = targetTrue
-	one := constant.NewInt(types.I8, 1)
-	f.defArgElem(arg, one, types.I8)
-	targetTrue.NewBr(exit)
-	f.cur = exit
+	v := f.cur.NewZExt(cond, types.I8)
+	f.defArgElem(arg, v, types.I8)
 	return nil
 }
@@ -0,0 +1,159 @@
+package x86
+
+import (
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir/enum"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// Double Precision Shifts and Rotates Through Carry
+//
+//    SHLD r/m, r, imm8/CL    Shift r/m left, shifting in bits from r.
+//    SHRD r/m, r, imm8/CL    Shift r/m right, shifting in bits from r.
+//    RCL r/m, imm8/CL        Rotate r/m and CF left.
+//    RCR r/m, imm8/CL        Rotate r/m and CF right.
+//
+// The shift count is masked to 5 bits (or 6 bits for 64-bit operands).
+//
+// ref: $ 4.2 SHLD, SHRD and RCL/RCR/ROL/ROR, Intel 64 and IA-32 Architectures
+// Software Developer's Manual
+
+// liftInstShiftDouble lifts the given x86 SHLD or SHRD instruction to LLVM IR,
+// emitting code to f. The destination and source operands are concatenated
+// into an integer of twice the operand size, which is shifted and truncated.
+func (f *Func) liftInstShiftDouble(inst *x86.Inst, left bool) error {
+	dst := f.useArg(inst.Arg(0))
+	typ := dst.Type().(*types.IntType)
+	src := f.convert(f.useArg(inst.Arg(1)), typ)
+	n := f.shiftCount(inst.Arg(2), typ)
+	wide := types.NewInt(2 * typ.BitSize)
+	bits := f.constInt(wide, int64(typ.BitSize))
+	count := f.cur.NewZExt(n, wide)
+	var result value.Value
+	if left {
+		// dst:src << n
+		high := f.cur.NewShl(f.cur.NewZExt(dst, wide), bits)
+		v := f.cur.NewOr(high, f.cur.NewZExt(src, wide))
+		tmp := f.cur.NewLShr(f.cur.NewShl(v, count), bits)
+		result = f.cur.NewTrunc(tmp, typ)
+	} else {
+		// src:dst >> n
+		high := f.cur.NewShl(f.cur.NewZExt(src, wide), bits)
+		v := f.cur.NewOr(high, f.cur.NewZExt(dst, wide))
+		result = f.cur.NewTrunc(f.cur.NewLShr(v, count), typ)
+	}
+	f.defArg(inst.Arg(0), result)
+	return nil
+}
+
+// liftInstRotateCarry lifts the given x86 RCL or RCR instruction to LLVM IR,
+// emitting code to f. The destination operand and CF are concatenated into an
+// integer of one bit wider than the operand size, which is rotated; the most
+// significant bit of the rotated integer is stored in CF.
+func (f *Func) liftInstRotateCarry(inst *x86.Inst, left bool) error {
+	dst := f.useArg(inst.Arg(0))
+	typ := dst.Type().(*types.IntType)
+	wide := types.NewInt(typ.BitSize + 1)
+	bits := f.constInt(wide, int64(typ.BitSize))
+	width := f.constInt(wide, int64(typ.BitSize+1))
+	// CF:dst
+	cf := f.cur.NewShl(f.cur.NewZExt(f.useStatus(CF), wide), bits)
+	v := f.cur.NewOr(cf, f.cur.NewZExt(dst, wide))
+	// Rotate count modulo the width of CF:dst.
+	n := f.cur.NewURem(f.cur.NewZExt(f.shiftCount(inst.Arg(1), typ), wide), width)
+	m := f.cur.NewSub(width, n)
+	var rot value.Value
+	if left {
+		rot = f.cur.NewOr(f.cur.NewShl(v, n), f.cur.NewLShr(v, m))
+	} else {
+		rot = f.cur.NewOr(f.cur.NewLShr(v, n), f.cur.NewShl(v, m))
+	}
+	// Rotating by 0 leaves CF:dst unchanged; handled separately as shifting by
+	// the bit width yields a poison value.
+	zero := f.constInt(wide, 0)
+	isZero := f.cur.NewICmp(enum.IPredEQ, n, zero)
+	rot = f.newSelect(isZero, v, rot)
+	result := f.cur.NewTrunc(rot, typ)
+	f.defArg(inst.Arg(0), result)
+	f.defStatus(CF, f.cur.NewTrunc(f.cur.NewLShr(rot, bits), types.I1))
+	return nil
+}
+
+// liftShift64 lifts the paired 32-bit shift instructions emitted by compilers
+// for shifts of 64-bit integers held in two registers, as an i64 shift,
+// emitting code to f. The boolean return value indicates whether the given
+// instructions form such a pair.
+//
+//    shld hi, lo, n   ; shl lo, n    =>  hi:lo << (n & 31)
+//    shrd lo, hi, n   ; shr hi, n    =>  hi:lo >> (n & 31)  (logical)
+//    shrd lo, hi, n   ; sar hi, n    =>  hi:lo >> (n & 31)  (arithmetic)
+//
+// Shift counts of 32 and above are adjusted by compilers in a separate basic
+// block, which is lifted as is.
+//
+// The instructions are not paired if hooks are registered, as hooks observe
+// and may override the lifting of each instruction separately.
+func (f *Func) liftShift64(first, second *x86.Inst) bool {
+	if len(f.l.Hooks) > 0 || f.mode != 32 || first.Args[2] != second.Args[1] {
+		return false
+	}
+	a, ok := gpr32(first.Args[0])
+	if !ok {
+		return false
+	}
+	b, ok := gpr32(first.Args[1])
+	if !ok || a == b {
+		return false
+	}
+	if first.Args[2] == x86asm.CL && (a == x86asm.ECX || b == x86asm.ECX) {
+		// Count register redefined by first shift.
+		return false
+	}
+	var hi, lo x86asm.Reg
+	switch {
+	case first.Op == x86asm.SHLD && second.Op == x86asm.SHL && second.Args[0] == b:
+		hi, lo = a, b
+	case first.Op == x86asm.SHRD && (second.Op == x86asm.SHR || second.Op == x86asm.SAR) && second.Args[0] == b:
+		hi, lo = b, a
+	default:
+		return false
+	}
+	dbg.Printf("lifting 64-bit shift idiom at %v", first.Addr)
+	defer f.annotateFailure(first)
+	hiReg, loReg := x86.NewReg(hi, first), x86.NewReg(lo, first)
+	n32 := f.constInt(types.I64, 32)
+	high := f.cur.NewShl(f.cur.NewZExt(f.useReg(hiReg), types.I64), n32)
+	v := f.cur.NewOr(high, f.cur.NewZExt(f.useReg(loReg), types.I64))
+	n := f.cur.NewZExt(f.shiftCount(first.Arg(2), types.I32), types.I64)
+	var result value.Value
+	switch second.Op {
+	case x86asm.SHL:
+		result = f.cur.NewShl(v, n)
+	case x86asm.SHR:
+		result = f.cur.NewLShr(v, n)
+	case x86asm.SAR:
+		result = f.cur.NewAShr(v, n)
+	}
+	f.defReg(loReg, f.cur.NewTrunc(result, types.I32))
+	f.defReg(hiReg, f.cur.NewTrunc(f.cur.NewLShr(result, n32), types.I32))
+	return true
+}
+
+// === [ Helper functions ] ====================================================
+
+// shiftCount returns the shift count of the given argument as a value of the
+// specified operand type, masked to 5 bits (or 6 bits for 64-bit operands),
+// emitting code to f.
+func (f *Func) shiftCount(arg *x86.Arg, typ *types.IntType) value.Value {
+	mask := int64(31)
+	if typ.BitSize == 64 {
+		mask = 63
+	}
+	if imm, ok := arg.Arg.(x86asm.Imm); ok {
+		return f.constInt(typ, int64(imm)&mask)
+	}
+	n := f.convert(f.useArg(arg), typ)
+	return f.cur.NewAnd(n, f.constInt(typ, mask))
+}
@@ -10,7 +10,6 @@ import (
 	"github.com/llir/llvm/ir/enum"
 	"github.com/llir/llvm/ir/types"
 	"github.com/llir/llvm/ir/value"
-	"github.com/pkg/errors"
 	"golang.org/x/arch/x86/x86asm"
 )
 
@@ -29,12 +28,16 @@ func (f *Func) liftInst(inst *x86.Inst) error {
 			break
 		}
 		switch prefix &^ x86asm.PrefixImplicit {
-		case x86asm.PrefixData16:
-			// prefix already supported.
+		case x86asm.PrefixDataSize, x86asm.PrefixData16, x86asm.PrefixData32:
+			// operand size override; handled by operand types.
+		case x86asm.PrefixAddrSize, x86asm.PrefixAddr16, x86asm.PrefixAddr32:
+			// address size override; handled by memory operands.
 		case x86asm.PrefixREP:
 			hasREP = true
 		case x86asm.PrefixREPN:
 			hasREPN = true
+		case x86asm.PrefixCS, x86asm.PrefixDS, x86asm.PrefixES, x86asm.PrefixFS, x86asm.PrefixGS, x86asm.PrefixSS:
+			// segment override; handled by memory operands.
 		case x86asm.PrefixREX | x86asm.PrefixREXW:
 			// TODO: Implement support for REX.W
 		default:
@@ -541,8 +544,6 @@ func (f *Func) liftInst(inst *x86.Inst) error {
 		return f.liftInstLGS(inst)
 	case x86asm.LIDT:
 		return f.liftInstLIDT(inst)
-	case x86asm.LJMP:
-		return f.liftInstLJMP(inst)
 	case x86asm.LLDT:
 		return f.liftInstLLDT(inst)
 	case x86asm.LMSW:
@@ -1303,11 +1304,14 @@ func (f *Func) liftInstADC(inst *x86.Inst) error {
 	// operand), and the carry (CF) flag and stores the result in the destination
 	// operand.
 	dst := f.useArg(inst.Arg(0))
-	src := f.useArg(inst.Arg(1))
-	cf := f.useStatus(CF)
+	typ := dst.Type()
+	src := f.convert(f.useArg(inst.Arg(1)), typ)
+	carry := f.useStatus(CF)
+	cf := f.cur.NewZExt(carry, typ)
 	v := f.cur.NewAdd(src, cf)
 	result := f.cur.NewAdd(dst, v)
 	f.defArg(inst.Arg(0), result)
+	f.setFlagsCarry(flagOpAdd, dst, src, carry, result)
 	return nil
 }
 
@@ -1316,9 +1320,11 @@ func (f *Func) liftInstADC(inst *x86.Inst) error {
 // liftInstADD lifts the given x86 ADD instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstADD(inst *x86.Inst) error {
-	x, y := f.useArg(inst.Arg(0)), f.useArg(inst.Arg(1))
+	x := f.useArg(inst.Arg(0))
+	y := f.useArgAs(inst.Arg(1), x.Type())
 	result := f.cur.NewAdd(x, y)
 	f.defArg(inst.Arg(0), result)
+	f.setFlags(flagOpAdd, x, y, result)
 	return nil
 }
 
@@ -1435,9 +1441,11 @@ func (f *Func) liftInstAESKEYGENASSIST(inst *x86.Inst) error {
 // liftInstAND lifts the given x86 AND instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstAND(inst *x86.Inst) error {
-	x, y := f.useArg(inst.Arg(0)), f.useArg(inst.Arg(1))
+	x := f.useArg(inst.Arg(0))
+	y := f.useArgAs(inst.Arg(1), x.Type())
 	result := f.cur.NewAnd(x, y)
 	f.defArg(inst.Arg(0), result)
+	f.setFlags(flagOpLogic, x, y, result)
 	return nil
 }
 
@@ -1603,34 +1611,47 @@ func (f *Func) liftInstCALL(inst *x86.Inst) error {
 	if !ok {
 		panic(fmt.Errorf("unable to locate function for argument %v of instruction at address %v", inst.Arg(0), inst.Addr))
 	}
+	return f.liftCall(inst, callee, sig, callconv)
+}
+
+// liftCall lifts the given call instruction to LLVM IR, as a call to the given
+// callee of the specified function type and calling convention, emitting code
+// to f.
+func (f *Func) liftCall(inst *x86.Inst, callee value.Named, sig *types.FuncType, callconv enum.CallingConv) error {
+	// Handle setjmp, longjmp and __EH_prolog.
+	switch sjljKindOf(callee) {
+	case sjljSetjmp:
+		return f.liftSetjmp(inst)
+	case sjljLongjmp:
+		return f.liftLongjmp(inst)
+	case sjljEHProlog:
+		return f.liftEHProlog(inst)
+	}
 
 	// Handle function arguments.
+	callconv = f.l.callConv(callconv)
+	stack := f.checkStackParams(callconv, sig.Params)
 	var args []value.Value
 	purge := int64(0)
-	for i := range sig.Params {
+	regs := paramRegs(callconv, sig.Params)
+	for i, param := range sig.Params {
 		// Pass argument in register.
-		switch callconv {
-		case enum.CallingConvX86FastCall:
-			switch i {
-			case 0:
-				arg := f.useReg(x86.ECX)
-				args = append(args, arg)
-				continue
-			case 1:
-				arg := f.useReg(x86.EDX)
-				args = append(args, arg)
-				continue
-			}
-		default:
-			// TODO: Add support for more calling conventions.
+		if reg := regs[i]; reg != nil {
+			arg := f.useParamReg(reg, param)
+			args = append(args, arg)
+			continue
+		}
+		if !stack {
+			args = append(args, constant.NewUndef(param))
+			continue
 		}
 		// Pass argument on stack.
-		arg := f.pop()
+		arg := f.popArg(param)
 		args = append(args, arg)
 		switch callconv {
-		case enum.CallingConvX86FastCall, enum.CallingConvX86StdCall:
+		case enum.CallingConvX86FastCall, enum.CallingConvX86StdCall, enum.CallingConvX86ThisCall:
 			// callee purge.
-			purge += 4
+			purge += f.l.argSize([]types.Type{param})
 		case enum.CallingConvC:
 			// caller purge; nothing to do.
 		default:
@@ -1638,16 +1659,20 @@ func (f *Func) liftInstCALL(inst *x86.Inst) error {
 		}
 	}
 
+	// Handle variable arguments (caller purge).
+	if sig.Variadic && f.l.Mode == 32 {
+		args = append(args, f.popVarArgs(callee, args)...)
+	}
+
 	// Emit call instruction.
 	result := f.cur.NewCall(callee, args...)
 
 	// Handle purged arguments by callee.
 	f.espDisp += purge
+	f.checkPurge(inst, sig, callconv)
 
 	// Handle return value.
-	if !types.Equal(sig.RetType, types.Void) {
-		f.defReg(x86.EAX, result)
-	}
+	f.defResult(sig, result)
 	return nil
 }
 
@@ -1667,7 +1692,7 @@ func (f *Func) liftInstCBW(inst *x86.Inst) error {
 func (f *Func) liftInstCDQ(inst *x86.Inst) error {
 	// EDX:EAX = sign-extend of EAX.
 	eax := f.useReg(x86.EAX)
-	tmp := f.cur.NewLShr(eax, constant.NewInt(types.I32, 31))
+	tmp := f.cur.NewLShr(eax, f.constInt(types.I32, 31))
 	cond := f.cur.NewTrunc(tmp, types.I1)
 	targetTrue := &ir.BasicBlock{}
 	targetFalse := &ir.BasicBlock{}
@@ -1677,9 +1702,9 @@ func (f *Func) liftInstCDQ(inst *x86.Inst) error {
 	f.Blocks = append(f.Blocks, exit)
 	f.cur.NewCondBr(cond, targetTrue, targetFalse)
 	f.cur = targetTrue
-	f.defReg(x86.EDX, constant.NewInt(types.I32, 0xFFFFFFFF))
+	f.defReg(x86.EDX, f.constInt(types.I32, 0xFFFFFFFF))
 	f.cur = targetFalse
-	f.defReg(x86.EDX, constant.NewInt(types.I32, 0))
+	f.defReg(x86.EDX, f.constInt(types.I32, 0))
 	targetTrue.NewBr(exit)
 	targetFalse.NewBr(exit)
 	f.cur = exit
@@ -1749,195 +1774,16 @@ func (f *Func) liftInstCMC(inst *x86.Inst) error {
 	panic("emitInstCMC: not yet implemented")
 }
 
-// --- [ CMOVA ] ---------------------------------------------------------------
-
-// liftInstCMOVA lifts the given x86 CMOVA instruction to LLVM IR, emitting code
-// to f.
-func (f *Func) liftInstCMOVA(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstCMOVA: not yet implemented")
-}
-
-// --- [ CMOVAE ] --------------------------------------------------------------
-
-// liftInstCMOVAE lifts the given x86 CMOVAE instruction to LLVM IR, emitting
-// code to f.
-func (f *Func) liftInstCMOVAE(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstCMOVAE: not yet implemented")
-}
-
-// --- [ CMOVB ] ---------------------------------------------------------------
-
-// liftInstCMOVB lifts the given x86 CMOVB instruction to LLVM IR, emitting code
-// to f.
-func (f *Func) liftInstCMOVB(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstCMOVB: not yet implemented")
-}
-
-// --- [ CMOVBE ] --------------------------------------------------------------
-
-// liftInstCMOVBE lifts the given x86 CMOVBE instruction to LLVM IR, emitting
-// code to f.
-func (f *Func) liftInstCMOVBE(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstCMOVBE: not yet implemented")
-}
-
-// --- [ CMOVE ] ---------------------------------------------------------------
-
-// liftInstCMOVE lifts the given x86 CMOVE instruction to LLVM IR, emitting code
-// to f.
-func (f *Func) liftInstCMOVE(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstCMOVE: not yet implemented")
-}
-
-// --- [ CMOVG ] ---------------------------------------------------------------
-
-// liftInstCMOVG lifts the given x86 CMOVG instruction to LLVM IR, emitting code
-// to f.
-func (f *Func) liftInstCMOVG(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstCMOVG: not yet implemented")
-}
-
-// --- [ CMOVGE ] --------------------------------------------------------------
-
-// liftInstCMOVGE lifts the given x86 CMOVGE instruction to LLVM IR, emitting
-// code to f.
-func (f *Func) liftInstCMOVGE(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstCMOVGE: not yet implemented")
-}
-
-// --- [ CMOVL ] ---------------------------------------------------------------
-
-// liftInstCMOVL lifts the given x86 CMOVL instruction to LLVM IR, emitting code
-// to f.
-func (f *Func) liftInstCMOVL(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstCMOVL: not yet implemented")
-}
-
-// --- [ CMOVLE ] --------------------------------------------------------------
-
-// liftInstCMOVLE lifts the given x86 CMOVLE instruction to LLVM IR, emitting
-// code to f.
-func (f *Func) liftInstCMOVLE(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstCMOVLE: not yet implemented")
-}
-
-// --- [ CMOVNE ] --------------------------------------------------------------
-
-// liftInstCMOVNE lifts the given x86 CMOVNE instruction to LLVM IR, emitting
-// code to f.
-func (f *Func) liftInstCMOVNE(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstCMOVNE: not yet implemented")
-}
-
-// --- [ CMOVNO ] --------------------------------------------------------------
-
-// liftInstCMOVNO lifts the given x86 CMOVNO instruction to LLVM IR, emitting
-// code to f.
-func (f *Func) liftInstCMOVNO(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstCMOVNO: not yet implemented")
-}
-
-// --- [ CMOVNP ] --------------------------------------------------------------
-
-// liftInstCMOVNP lifts the given x86 CMOVNP instruction to LLVM IR, emitting
-// code to f.
-func (f *Func) liftInstCMOVNP(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstCMOVNP: not yet implemented")
-}
-
-// --- [ CMOVNS ] --------------------------------------------------------------
-
-// liftInstCMOVNS lifts the given x86 CMOVNS instruction to LLVM IR, emitting
-// code to f.
-func (f *Func) liftInstCMOVNS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstCMOVNS: not yet implemented")
-}
-
-// --- [ CMOVO ] ---------------------------------------------------------------
-
-// liftInstCMOVO lifts the given x86 CMOVO instruction to LLVM IR, emitting code
-// to f.
-func (f *Func) liftInstCMOVO(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstCMOVO: not yet implemented")
-}
-
-// --- [ CMOVP ] ---------------------------------------------------------------
-
-// liftInstCMOVP lifts the given x86 CMOVP instruction to LLVM IR, emitting code
-// to f.
-func (f *Func) liftInstCMOVP(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstCMOVP: not yet implemented")
-}
-
-// --- [ CMOVS ] ---------------------------------------------------------------
-
-// liftInstCMOVS lifts the given x86 CMOVS instruction to LLVM IR, emitting code
-// to f.
-func (f *Func) liftInstCMOVS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstCMOVS: not yet implemented")
-}
-
 // --- [ CMP ] -----------------------------------------------------------------
 
 // liftInstCMP lifts the given x86 CMP instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstCMP(inst *x86.Inst) error {
 	// result = x SUB y; set CF, PF, AF, ZF, SF, and OF according to result.
-	x, y := f.useArg(inst.Arg(0)), f.useArg(inst.Arg(1))
+	x := f.useArg(inst.Arg(0))
+	y := f.useArgAs(inst.Arg(1), x.Type())
 	result := f.cur.NewSub(x, y)
-
-	// CF (bit 0) Carry flag - Set if an arithmetic operation generates a carry
-	// or a borrow out of the most- significant bit of the result; cleared
-	// otherwise. This flag indicates an overflow condition for unsigned-integer
-	// arithmetic. It is also used in multiple-precision arithmetic.
-
-	// TODO: Add support for the CF status flag.
-
-	// PF (bit 2) Parity flag - Set if the least-significant byte of the result
-	// contains an even number of 1 bits; cleared otherwise.
-
-	// TODO: Add support for the PF status flag.
-
-	// AF (bit 4) Auxiliary Carry flag - Set if an arithmetic operation generates
-	// a carry or a borrow out of bit 3 of the result; cleared otherwise. This
-	// flag is used in binary-coded decimal (BCD) arithmetic.
-
-	// TODO: Add support for the AF status flag.
-
-	// ZF (bit 6) Zero flag - Set if the result is zero; cleared otherwise.
-	zero := constant.NewInt(types.I32, 0)
-	zf := f.cur.NewICmp(enum.IPredEQ, result, zero)
-	f.defStatus(ZF, zf)
-
-	// SF (bit 7) Sign flag - Set equal to the most-significant bit of the
-	// result, which is the sign bit of a signed integer. (0 indicates a positive
-	// value and 1 indicates a negative value.)
-
-	// TODO: Add support for SF flag.
-
-	// OF (bit 11) Overflow flag - Set if the integer result is too large a
-	// positive number or too small a negative number (excluding the sign-bit) to
-	// fit in the destination operand; cleared otherwise. This flag indicates an
-	// overflow condition for signed-integer (two's complement) arithmetic.
-
-	// TODO: Add support for the OF status flag.
-
+	f.setFlags(flagOpSub, x, y, result)
 	return nil
 }
 
@@ -2063,8 +1909,12 @@ func (f *Func) liftInstCOMISS(inst *x86.Inst) error {
 // liftInstCPUID lifts the given x86 CPUID instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstCPUID(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstCPUID: not yet implemented")
+	// CPUID - CPU identification.
+	//
+	//    CPUID               EAX, EBX, ECX, EDX = processor identification
+	//                        information of leaf EAX and subleaf ECX.
+	f.cpuid()
+	return nil
 }
 
 // --- [ CQO ] -----------------------------------------------------------------
@@ -2324,14 +2174,18 @@ func (f *Func) liftInstDAS(inst *x86.Inst) error {
 // liftInstDEC lifts the given x86 DEC instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstDEC(inst *x86.Inst) error {
-	f.dec(inst.Arg(0))
+	x := f.useArg(inst.Arg(0))
+	one := f.constInt(x.Type().(*types.IntType), 1)
+	result := f.cur.NewSub(x, one)
+	f.defArg(inst.Arg(0), result)
+	f.setFlagsKeepCF(flagOpSub, x, one, result)
 	return nil
 }
 
 // dec decrements the given argument by 1, stores and returns the result.
 func (f *Func) dec(arg *x86.Arg) value.Value {
 	x := f.useArg(arg)
-	one := constant.NewInt(x.Type().(*types.IntType), 1)
+	one := f.constInt(x.Type().(*types.IntType), 1)
 	result := f.cur.NewSub(x, one)
 	f.defArg(arg, result)
 	return result
@@ -2344,61 +2198,11 @@ func (f *Func) dec(arg *x86.Arg) value.Value {
 func (f *Func) liftInstDIV(inst *x86.Inst) error {
 	// DIV - Unsigned Divide
 	//
-	//    div arg
-	arg := f.useArg(inst.Arg(0))
-	typ, ok := arg.Type().(*types.IntType)
-	if !ok {
-		return errors.Errorf("invalid argument type in instruction %v; expected *types.IntType, got %T", inst, arg.Type())
-	}
-	switch typ.BitSize {
-	case 8:
-		// Unsigned divide AX by r/m8, with result stored in:
-		//
-		//    AL = quotient
-		//    AH = remainder
-		ax := f.useReg(x86.AX)
-		arg = f.cur.NewZExt(arg, types.I16)
-		quo := f.cur.NewUDiv(ax, arg)
-		rem := f.cur.NewURem(ax, arg)
-		f.defReg(x86.AL, quo)
-		f.defReg(x86.AH, rem)
-	case 16:
-		// Unsigned divide DX:AX by r/m16, with result stored in:
-		//
-		//    AX = quotient
-		//    DX = remainder
-		dx_ax := f.useReg(x86.DX_AX)
-		arg = f.cur.NewZExt(arg, types.I32)
-		quo := f.cur.NewUDiv(dx_ax, arg)
-		rem := f.cur.NewURem(dx_ax, arg)
-		f.defReg(x86.AX, quo)
-		f.defReg(x86.DX, rem)
-	case 32:
-		// Unsigned divide EDX:EAX by r/m32, with result stored in:
-		//
-		//    EAX = quotient
-		//    EDX = remainder
-		edx_eax := f.useReg(x86.EDX_EAX)
-		arg = f.cur.NewZExt(arg, types.I64)
-		quo := f.cur.NewUDiv(edx_eax, arg)
-		rem := f.cur.NewURem(edx_eax, arg)
-		f.defReg(x86.EAX, quo)
-		f.defReg(x86.EDX, rem)
-	case 64:
-		// Unsigned divide RDX:RAX by r/m64, with result stored in:
-		//
-		//    RAX = quotient
-		//    RDX = remainder
-		rdx_rax := f.useReg(x86.RDX_RAX)
-		arg = f.cur.NewZExt(arg, types.I128)
-		quo := f.cur.NewUDiv(rdx_rax, arg)
-		rem := f.cur.NewURem(rdx_rax, arg)
-		f.defReg(x86.RAX, quo)
-		f.defReg(x86.RDX, rem)
-	default:
-		panic(fmt.Errorf("support for argument bit size %d not yet implemented", typ.BitSize))
-	}
-	return nil
+	//    DIV r/m8      Unsigned divide AX by r/m8, with result stored in AL = quotient, AH = remainder.
+	//    DIV r/m16     Unsigned divide DX:AX by r/m16, with result stored in AX = quotient, DX = remainder.
+	//    DIV r/m32     Unsigned divide EDX:EAX by r/m32, with result stored in EAX = quotient, EDX = remainder.
+	//    DIV r/m64     Unsigned divide RDX:RAX by r/m64, with result stored in RAX = quotient, RDX = remainder.
+	return f.liftInstDivWide(inst, false)
 }
 
 // --- [ DIVPD ] ---------------------------------------------------------------
@@ -2487,8 +2291,9 @@ func (f *Func) liftInstEXTRACTPS(inst *x86.Inst) error {
 // liftInstFFREEP lifts the given x86 FFREEP instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstFFREEP(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstFFREEP: not yet implemented")
+	// FFREEP - Free floating-point register and pop.
+	f.fdrop()
+	return nil
 }
 
 // --- [ FISTTP ] --------------------------------------------------------------
@@ -2496,8 +2301,13 @@ func (f *Func) liftInstFFREEP(inst *x86.Inst) error {
 // liftInstFISTTP lifts the given x86 FISTTP instruction to LLVM IR, emitting
 // code to f.
 func (f *Func) liftInstFISTTP(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstFISTTP: not yet implemented")
+	// FISTTP - Store integer with truncation and pop.
+	//
+	// Converts the value in the ST(0) register to a signed integer using
+	// truncation, regardless of the RC field of the FPU control word.
+	src := f.fpop()
+	f.defArg(inst.Arg(0), f.fist(inst, src, true))
+	return nil
 }
 
 // --- [ FXRSTOR ] -------------------------------------------------------------
@@ -2596,21 +2406,12 @@ func (f *Func) liftInstICEBP(inst *x86.Inst) error {
 // to f.
 func (f *Func) liftInstIDIV(inst *x86.Inst) error {
 	// IDIV - Signed Divide
-
-	// Signed divide EDX:EAX by r/m32, with result stored in:
 	//
-	//    EAX = Quotient
-	//    EDX = Remainder
-	x := f.useArg(inst.Arg(0))
-	edx_eax := f.useReg(x86.EDX_EAX)
-	if !types.Equal(x.Type(), types.I64) {
-		x = f.cur.NewSExt(x, types.I64)
-	}
-	quo := f.cur.NewSDiv(edx_eax, x)
-	rem := f.cur.NewSRem(edx_eax, x)
-	f.defReg(x86.EAX, quo)
-	f.defReg(x86.EDX, rem)
-	return nil
+	//    IDIV r/m8     Signed divide AX by r/m8, with result stored in AL = quotient, AH = remainder.
+	//    IDIV r/m16    Signed divide DX:AX by r/m16, with result stored in AX = quotient, DX = remainder.
+	//    IDIV r/m32    Signed divide EDX:EAX by r/m32, with result stored in EAX = quotient, EDX = remainder.
+	//    IDIV r/m64    Signed divide RDX:RAX by r/m64, with result stored in RAX = quotient, RDX = remainder.
+	return f.liftInstDivWide(inst, true)
 }
 
 // --- [ IMUL ] ----------------------------------------------------------------
@@ -2635,42 +2436,12 @@ func (f *Func) liftInstIMUL(inst *x86.Inst) error {
 	//    IMUL r64, r/m64, imm32        Quadword register = r/m64 * immediate doubleword.
 	//
 	// Performs a signed multiplication of two operands.
-	var x, y value.Value
-	switch {
-	case inst.Args[2] != nil:
-		// Three-operand form.
-		x, y = f.useArg(inst.Arg(1)), f.useArg(inst.Arg(2))
-	case inst.Args[1] != nil:
-		// Two-operand form.
-		x, y = f.useArg(inst.Arg(0)), f.useArg(inst.Arg(1))
-	case inst.Args[0] != nil:
+	if inst.Args[1] == nil {
 		// One-operand form.
-		y := f.useArg(inst.Arg(0))
-		size := f.l.sizeOfType(y.Type())
-		var dst value.Value
-		switch size {
-		case 1:
-			x = f.useReg(x86.AL)
-			dst = f.reg(x86asm.AX)
-		case 2:
-			x = f.useReg(x86.AX)
-			dst = f.reg(x86.X86asm_DX_AX)
-		case 3:
-			x = f.useReg(x86.EAX)
-			dst = f.reg(x86.X86asm_EDX_EAX)
-		case 4:
-			x = f.useReg(x86.RAX)
-			dst = f.reg(x86.X86asm_RDX_RAX)
-		default:
-			panic(fmt.Errorf("support for operand type of byte size %d not yet implemented", size))
-		}
-		result := f.cur.NewMul(x, y)
-		f.cur.NewStore(result, dst)
-		return nil
+		return f.liftInstMulWide(inst, true)
 	}
-	result := f.cur.NewMul(x, y)
-	f.defArg(inst.Arg(0), result)
-	return nil
+	// Two- and three-operand forms.
+	return f.liftInstIMULTrunc(inst)
 }
 
 // --- [ IN ] ------------------------------------------------------------------
@@ -2687,9 +2458,10 @@ func (f *Func) liftInstIN(inst *x86.Inst) error {
 // f.
 func (f *Func) liftInstINC(inst *x86.Inst) error {
 	x := f.useArg(inst.Arg(0))
-	one := constant.NewInt(types.I32, 1)
+	one := f.constInt(x.Type().(*types.IntType), 1)
 	result := f.cur.NewAdd(x, one)
 	f.defArg(inst.Arg(0), result)
+	f.setFlagsKeepCF(flagOpAdd, x, one, result)
 	return nil
 }
 
@@ -2801,15 +2573,6 @@ func (f *Func) liftInstIRETQ(inst *x86.Inst) error {
 	panic("emitInstIRETQ: not yet implemented")
 }
 
-// --- [ LAHF ] ----------------------------------------------------------------
-
-// liftInstLAHF lifts the given x86 LAHF instruction to LLVM IR, emitting code
-// to f.
-func (f *Func) liftInstLAHF(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstLAHF: not yet implemented")
-}
-
 // --- [ LAR ] -----------------------------------------------------------------
 
 // liftInstLAR lifts the given x86 LAR instruction to LLVM IR, emitting code to
@@ -2851,8 +2614,7 @@ func (f *Func) liftInstLDMXCSR(inst *x86.Inst) error {
 // liftInstLDS lifts the given x86 LDS instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstLDS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstLDS: not yet implemented")
+	return f.liftFarPointer(inst, x86.DS)
 }
 
 // --- [ LEA ] -----------------------------------------------------------------
@@ -2895,8 +2657,7 @@ func (f *Func) liftInstLEAVE(inst *x86.Inst) error {
 // liftInstLES lifts the given x86 LES instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstLES(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstLES: not yet implemented")
+	return f.liftFarPointer(inst, x86.ES)
 }
 
 // --- [ LFENCE ] --------------------------------------------------------------
@@ -2913,8 +2674,7 @@ func (f *Func) liftInstLFENCE(inst *x86.Inst) error {
 // liftInstLFS lifts the given x86 LFS instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstLFS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstLFS: not yet implemented")
+	return f.liftFarPointer(inst, x86.FS)
 }
 
 // --- [ LGDT ] ----------------------------------------------------------------
@@ -2931,8 +2691,7 @@ func (f *Func) liftInstLGDT(inst *x86.Inst) error {
 // liftInstLGS lifts the given x86 LGS instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstLGS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstLGS: not yet implemented")
+	return f.liftFarPointer(inst, x86.GS)
 }
 
 // --- [ LIDT ] ----------------------------------------------------------------
@@ -2944,15 +2703,6 @@ func (f *Func) liftInstLIDT(inst *x86.Inst) error {
 	panic("emitInstLIDT: not yet implemented")
 }
 
-// --- [ LJMP ] ----------------------------------------------------------------
-
-// liftInstLJMP lifts the given x86 LJMP instruction to LLVM IR, emitting code
-// to f.
-func (f *Func) liftInstLJMP(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstLJMP: not yet implemented")
-}
-
 // --- [ LLDT ] ----------------------------------------------------------------
 
 // liftInstLLDT lifts the given x86 LLDT instruction to LLVM IR, emitting code
@@ -3034,8 +2784,7 @@ func (f *Func) liftInstLSL(inst *x86.Inst) error {
 // liftInstLSS lifts the given x86 LSS instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstLSS(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstLSS: not yet implemented")
+	return f.liftFarPointer(inst, x86.SS)
 }
 
 // --- [ LTR ] -----------------------------------------------------------------
@@ -3488,11 +3237,15 @@ func (f *Func) liftInstMOVSW(inst *x86.Inst) error {
 // liftInstMOVSX lifts the given x86 MOVSX instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstMOVSX(inst *x86.Inst) error {
-	size := uint64(inst.MemBytes) * 8
-	elem := types.NewInt(size)
-	src := f.useArgElem(inst.Arg(1), elem)
-	// TODO: Handle dst type dynamically.
-	src = f.cur.NewSExt(src, types.I32)
+	var src value.Value
+	if _, ok := inst.Args[1].(x86asm.Mem); ok {
+		elem := types.NewInt(uint64(inst.MemBytes) * 8)
+		src = f.useArgElem(inst.Arg(1), elem)
+	} else {
+		// Register source operand; e.g. `movzx eax, al`.
+		src = f.useArg(inst.Arg(1))
+	}
+	src = f.cur.NewSExt(src, operandType(inst))
 	f.defArg(inst.Arg(0), src)
 	return nil
 }
@@ -3529,11 +3282,15 @@ func (f *Func) liftInstMOVUPS(inst *x86.Inst) error {
 // liftInstMOVZX lifts the given x86 MOVZX instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstMOVZX(inst *x86.Inst) error {
-	size := uint64(inst.MemBytes) * 8
-	elem := types.NewInt(size)
-	src := f.useArgElem(inst.Arg(1), elem)
-	// TODO: Handle dst type dynamically.
-	src = f.cur.NewZExt(src, types.I32)
+	var src value.Value
+	if _, ok := inst.Args[1].(x86asm.Mem); ok {
+		elem := types.NewInt(uint64(inst.MemBytes) * 8)
+		src = f.useArgElem(inst.Arg(1), elem)
+	} else {
+		// Register source operand; e.g. `movzx eax, al`.
+		src = f.useArg(inst.Arg(1))
+	}
+	src = f.cur.NewZExt(src, operandType(inst))
 	f.defArg(inst.Arg(0), src)
 	return nil
 }
@@ -3555,7 +3312,6 @@ func (f *Func) liftInstMUL(inst *x86.Inst) error {
 	// MUL - Unsigned Multiply
 	//
 	//    MUL r/m8       Unsigned multiply (AX = AL ∗ r/m8).
-	//    MUL r/m8       Unsigned multiply (AX = AL ∗ r/m8).
 	//    MUL r/m16      Unsigned multiply (DX:AX = AX ∗ r/m16).
 	//    MUL r/m32      Unsigned multiply (EDX:EAX = EAX ∗ r/m32).
 	//    MUL r/m64      Unsigned multiply (RDX:RAX = RAX ∗ r/m64).
@@ -3563,30 +3319,7 @@ func (f *Func) liftInstMUL(inst *x86.Inst) error {
 	// Performs an unsigned multiplication of the first operand (destination
 	// operand) and the second operand (source operand) and stores the result in
 	// the destination operand.
-	// One-operand form.
-	y := f.useArg(inst.Arg(0))
-	size := f.l.sizeOfType(y.Type())
-	var x value.Value
-	var dst value.Value
-	switch size {
-	case 1:
-		x = f.useReg(x86.AL)
-		dst = f.reg(x86asm.AX)
-	case 2:
-		x = f.useReg(x86.AX)
-		dst = f.reg(x86.X86asm_DX_AX)
-	case 3:
-		x = f.useReg(x86.EAX)
-		dst = f.reg(x86.X86asm_EDX_EAX)
-	case 4:
-		x = f.useReg(x86.RAX)
-		dst = f.reg(x86.X86asm_RDX_RAX)
-	default:
-		panic(fmt.Errorf("support for operand type of byte size %d not yet implemented", size))
-	}
-	result := f.cur.NewMul(x, y)
-	f.cur.NewStore(result, dst)
-	return nil
+	return f.liftInstMulWide(inst, false)
 }
 
 // --- [ MULPD ] ---------------------------------------------------------------
@@ -3639,10 +3372,12 @@ func (f *Func) liftInstMWAIT(inst *x86.Inst) error {
 // liftInstNEG lifts the given x86 NEG instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstNEG(inst *x86.Inst) error {
+	// result = 0 SUB x
 	x := f.useArg(inst.Arg(0))
-	zero := constant.NewInt(x.Type().(*types.IntType), 0)
+	zero := f.constInt(x.Type().(*types.IntType), 0)
 	result := f.cur.NewSub(zero, x)
 	f.defArg(inst.Arg(0), result)
+	f.setFlags(flagOpSub, zero, x, result)
 	return nil
 }
 
@@ -3667,11 +3402,11 @@ func (f *Func) liftInstNOT(inst *x86.Inst) error {
 	}
 	switch typ.BitSize {
 	case 8:
-		mask = constant.NewInt(types.I8, 0xFF)
+		mask = f.constInt(types.I8, 0xFF)
 	case 16:
-		mask = constant.NewInt(types.I16, 0xFFFF)
+		mask = f.constInt(types.I16, 0xFFFF)
 	case 32:
-		mask = constant.NewInt(types.I32, 0xFFFFFFFF)
+		mask = f.constInt(types.I32, 0xFFFFFFFF)
 	case 64:
 		v, err := constant.NewIntFromString(types.I64, "0xFFFFFFFFFFFFFFFF")
 		if err != nil {
@@ -3690,9 +3425,11 @@ func (f *Func) liftInstNOT(inst *x86.Inst) error {
 
 // liftInstOR lifts the given x86 OR instruction to LLVM IR, emitting code to f.
 func (f *Func) liftInstOR(inst *x86.Inst) error {
-	x, y := f.useArg(inst.Arg(0)), f.useArg(inst.Arg(1))
+	x := f.useArg(inst.Arg(0))
+	y := f.useArgAs(inst.Arg(1), x.Type())
 	result := f.cur.NewOr(x, y)
 	f.defArg(inst.Arg(0), result)
+	f.setFlags(flagOpLogic, x, y, result)
 	return nil
 }
 
@@ -4520,6 +4257,12 @@ func (f *Func) liftInstPMULUDQ(inst *x86.Inst) error {
 // liftInstPOP lifts the given x86 POP instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstPOP(inst *x86.Inst) error {
+	if typ := operandType(inst); typ.BitSize == 16 {
+		// 16-bit operand size; e.g. pop ax.
+		v := f.popElem(typ)
+		f.defArg(inst.Arg(0), v)
+		return nil
+	}
 	v := f.pop()
 	f.defArg(inst.Arg(0), v)
 	return nil
@@ -4537,6 +4280,18 @@ func (f *Func) pop() value.Named {
 	return v
 }
 
+// popElem pops a value of the specified integer type from the top of the stack
+// of the function, emitting code to f.
+func (f *Func) popElem(typ *types.IntType) value.Value {
+	m := x86asm.Mem{
+		Base: x86asm.ESP,
+	}
+	mem := x86.NewMem(m, nil)
+	v := f.useMemElem(mem, typ)
+	f.espDisp += int64(typ.BitSize / 8)
+	return v
+}
+
 // --- [ POPA ] ----------------------------------------------------------------
 
 // liftInstPOPA lifts the given x86 POPA instruction to LLVM IR, emitting code
@@ -4564,33 +4319,6 @@ func (f *Func) liftInstPOPCNT(inst *x86.Inst) error {
 	panic("emitInstPOPCNT: not yet implemented")
 }
 
-// --- [ POPF ] ----------------------------------------------------------------
-
-// liftInstPOPF lifts the given x86 POPF instruction to LLVM IR, emitting code
-// to f.
-func (f *Func) liftInstPOPF(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstPOPF: not yet implemented")
-}
-
-// --- [ POPFD ] ---------------------------------------------------------------
-
-// liftInstPOPFD lifts the given x86 POPFD instruction to LLVM IR, emitting code
-// to f.
-func (f *Func) liftInstPOPFD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstPOPFD: not yet implemented")
-}
-
-// --- [ POPFQ ] ---------------------------------------------------------------
-
-// liftInstPOPFQ lifts the given x86 POPFQ instruction to LLVM IR, emitting code
-// to f.
-func (f *Func) liftInstPOPFQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstPOPFQ: not yet implemented")
-}
-
 // --- [ POR ] -----------------------------------------------------------------
 
 // liftInstPOR lifts the given x86 POR instruction to LLVM IR, emitting code to
@@ -4982,6 +4710,17 @@ func (f *Func) liftInstPUSH(inst *x86.Inst) error {
 // push pushes the given value onto the top of the stack of the function,
 // emitting code to f.
 func (f *Func) push(v value.Value) {
+	if typ, ok := v.Type().(*types.IntType); ok && typ.BitSize == 16 {
+		// 16-bit operand size; e.g. push ax.
+		m := x86asm.Mem{
+			Base: x86asm.ESP,
+			Disp: -2,
+		}
+		mem := x86.NewMem(m, nil)
+		f.defMemElem(mem, v, typ)
+		f.espDisp -= 2
+		return
+	}
 	m := x86asm.Mem{
 		Base: x86asm.ESP,
 		Disp: -4,
@@ -5009,33 +4748,6 @@ func (f *Func) liftInstPUSHAD(inst *x86.Inst) error {
 	panic("emitInstPUSHAD: not yet implemented")
 }
 
-// --- [ PUSHF ] ---------------------------------------------------------------
-
-// liftInstPUSHF lifts the given x86 PUSHF instruction to LLVM IR, emitting code
-// to f.
-func (f *Func) liftInstPUSHF(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstPUSHF: not yet implemented")
-}
-
-// --- [ PUSHFD ] --------------------------------------------------------------
-
-// liftInstPUSHFD lifts the given x86 PUSHFD instruction to LLVM IR, emitting
-// code to f.
-func (f *Func) liftInstPUSHFD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstPUSHFD: not yet implemented")
-}
-
-// --- [ PUSHFQ ] --------------------------------------------------------------
-
-// liftInstPUSHFQ lifts the given x86 PUSHFQ instruction to LLVM IR, emitting
-// code to f.
-func (f *Func) liftInstPUSHFQ(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstPUSHFQ: not yet implemented")
-}
-
 // --- [ PXOR ] ----------------------------------------------------------------
 
 // liftInstPXOR lifts the given x86 PXOR instruction to LLVM IR, emitting code
@@ -5050,8 +4762,12 @@ func (f *Func) liftInstPXOR(inst *x86.Inst) error {
 // liftInstRCL lifts the given x86 RCL instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstRCL(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstRCL: not yet implemented")
+	// RCL - Rotate through carry left
+	//
+	//    RCL a1, a2
+	//
+	// Rotate CF:a1 to left a2 places.
+	return f.liftInstRotateCarry(inst, true)
 }
 
 // --- [ RCPPS ] ---------------------------------------------------------------
@@ -5077,8 +4793,12 @@ func (f *Func) liftInstRCPSS(inst *x86.Inst) error {
 // liftInstRCR lifts the given x86 RCR instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstRCR(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstRCR: not yet implemented")
+	// RCR - Rotate through carry right
+	//
+	//    RCR a1, a2
+	//
+	// Rotate CF:a1 to right a2 places.
+	return f.liftInstRotateCarry(inst, false)
 }
 
 // --- [ RDFSBASE ] ------------------------------------------------------------
@@ -5156,7 +4876,7 @@ func (f *Func) liftInstROL(inst *x86.Inst) error {
 	if !ok {
 		panic(fmt.Errorf("invalid count operand type; expected *types.IntType, got %T", y.Type()))
 	}
-	bits := constant.NewInt(typ, int64(typ.BitSize))
+	bits := f.constInt(typ, int64(typ.BitSize))
 	shift := f.cur.NewSub(bits, y)
 	low := f.cur.NewLShr(x, shift)
 	result := f.cur.NewOr(low, high)
@@ -5176,7 +4896,7 @@ func (f *Func) liftInstROR(inst *x86.Inst) error {
 	if !ok {
 		panic(fmt.Errorf("invalid count operand type; expected *types.IntType, got %T", y.Type()))
 	}
-	bits := constant.NewInt(typ, int64(typ.BitSize))
+	bits := f.constInt(typ, int64(typ.BitSize))
 	shift := f.cur.NewSub(bits, y)
 	high := f.cur.NewShl(x, shift)
 	result := f.cur.NewOr(low, high)
@@ -5247,15 +4967,6 @@ func (f *Func) liftInstRSQRTSS(inst *x86.Inst) error {
 	panic("emitInstRSQRTSS: not yet implemented")
 }
 
-// --- [ SAHF ] ----------------------------------------------------------------
-
-// liftInstSAHF lifts the given x86 SAHF instruction to LLVM IR, emitting code
-// to f.
-func (f *Func) liftInstSAHF(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstSAHF: not yet implemented")
-}
-
 // --- [ SAR ] -----------------------------------------------------------------
 
 // liftInstSAR lifts the given x86 SAR instruction to LLVM IR, emitting code to
@@ -5301,12 +5012,30 @@ func (f *Func) liftInstSBB(inst *x86.Inst) error {
 	// Adds the source operand (second operand) and the carry (CF) flag, and
 	// subtracts the result from the destination operand (first operand). The
 	// result of the subtraction is stored in the destination operand.
+	if isSameReg(inst.Args[0], inst.Args[1]) {
+		// Materialize carry flag as boolean mask; e.g.
+		//
+		//    sbb eax, eax    ; eax = CF ? -1 : 0
+		//
+		// The status flags are those of 0 - (0 + CF), as the register operands
+		// cancel out.
+		typ := regType(inst.Args[0].(x86asm.Reg))
+		carry := f.useStatus(CF)
+		result := f.cur.NewSExt(carry, typ)
+		f.defArg(inst.Arg(0), result)
+		zero := f.constInt(typ.(*types.IntType), 0)
+		f.setFlagsCarry(flagOpSub, zero, zero, carry, result)
+		return nil
+	}
 	dst := f.useArg(inst.Arg(0))
-	src := f.useArg(inst.Arg(1))
-	cf := f.useStatus(CF)
+	typ := dst.Type()
+	src := f.convert(f.useArg(inst.Arg(1)), typ)
+	carry := f.useStatus(CF)
+	cf := f.cur.NewZExt(carry, typ)
 	v := f.cur.NewAdd(src, cf)
 	result := f.cur.NewSub(dst, v)
 	f.defArg(inst.Arg(0), result)
+	f.setFlagsCarry(flagOpSub, dst, src, carry, result)
 	return nil
 }
 
@@ -5386,17 +5115,7 @@ func (f *Func) liftInstSHLD(inst *x86.Inst) error {
 	//    SHLD a1, a2, a3
 	//
 	// Shift a1 to left a3 places while shifting bits from a2 in from the right.
-	a1, a2, a3 := f.useArg(inst.Arg(0)), f.useArg(inst.Arg(1)), f.useArg(inst.Arg(2))
-	tmp1 := f.cur.NewZExt(a1, types.I64)
-	n32 := constant.NewInt(types.I64, 32)
-	high := f.cur.NewShl(tmp1, n32)
-	low := f.cur.NewZExt(a2, types.I64)
-	tmp3 := f.cur.NewOr(high, low)
-	tmp4 := f.cur.NewShl(tmp3, a3)
-	tmp5 := f.cur.NewLShr(tmp4, n32)
-	result := f.cur.NewTrunc(tmp5, types.I32)
-	f.defArg(inst.Arg(0), result)
-	return nil
+	return f.liftInstShiftDouble(inst, true)
 }
 
 // --- [ SHR ] -----------------------------------------------------------------
@@ -5416,8 +5135,12 @@ func (f *Func) liftInstSHR(inst *x86.Inst) error {
 // liftInstSHRD lifts the given x86 SHRD instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstSHRD(inst *x86.Inst) error {
-	pretty.Println("inst:", inst)
-	panic("emitInstSHRD: not yet implemented")
+	// SHRD - Double Precision Shift Right
+	//
+	//    SHRD a1, a2, a3
+	//
+	// Shift a1 to right a3 places while shifting bits from a2 in from the left.
+	return f.liftInstShiftDouble(inst, false)
 }
 
 // --- [ SHUFPD ] --------------------------------------------------------------
@@ -5591,9 +5314,11 @@ func (f *Func) liftInstSTR(inst *x86.Inst) error {
 // liftInstSUB lifts the given x86 SUB instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstSUB(inst *x86.Inst) error {
-	x, y := f.useArg(inst.Arg(0)), f.useArg(inst.Arg(1))
+	x := f.useArg(inst.Arg(0))
+	y := f.useArgAs(inst.Arg(1), x.Type())
 	result := f.cur.NewSub(x, y)
 	f.defArg(inst.Arg(0), result)
+	f.setFlags(flagOpSub, x, y, result)
 	return nil
 }
 
@@ -5683,28 +5408,13 @@ func (f *Func) liftInstSYSRET(inst *x86.Inst) error {
 // liftInstTEST lifts the given x86 TEST instruction to LLVM IR, emitting code
 // to f.
 func (f *Func) liftInstTEST(inst *x86.Inst) error {
-	// result = x AND y; set PF, ZF, and SF according to result.
-	x, y := f.useArg(inst.Arg(0)), f.useArg(inst.Arg(1))
+	// result = x AND y; set PF, ZF, and SF according to result; clear CF and
+	// OF.
+	x := f.useArg(inst.Arg(0))
+	y := f.useArgAs(inst.Arg(1), x.Type())
 	result := f.cur.NewAnd(x, y)
-
-	// PF (bit 2) Parity flag - Set if the least-significant byte of the result
-	// contains an even number of 1 bits; cleared otherwise.
-
-	// TODO: Add support for the PF status flag.
-
-	// ZF (bit 6) Zero flag - Set if the result is zero; cleared otherwise.
-	zero := constant.NewInt(types.I32, 0)
-	zf := f.cur.NewICmp(enum.IPredEQ, result, zero)
-	f.defStatus(ZF, zf)
-
-	// SF (bit 7) Sign flag - Set equal to the most-significant bit of the
-	// result, which is the sign bit of a signed integer. (0 indicates a positive
-	// value and 1 indicates a negative value.)
-
-	// TODO: Add support for the SF flag.
-
+	f.setFlags(flagOpLogic, x, y, result)
 	return nil
-
 }
 
 // --- [ TZCNT ] ---------------------------------------------------------------
@@ -5963,9 +5673,11 @@ func (f *Func) liftInstXLATB(inst *x86.Inst) error {
 // liftInstXOR lifts the given x86 XOR instruction to LLVM IR, emitting code to
 // f.
 func (f *Func) liftInstXOR(inst *x86.Inst) error {
-	x, y := f.useArg(inst.Arg(0)), f.useArg(inst.Arg(1))
+	x := f.useArg(inst.Arg(0))
+	y := f.useArgAs(inst.Arg(1), x.Type())
 	result := f.cur.NewXor(x, y)
 	f.defArg(inst.Arg(0), result)
+	f.setFlags(flagOpLogic, x, y, result)
 	return nil
 }
 
@@ -6112,3 +5824,27 @@ func (f *Func) liftInstXTEST(inst *x86.Inst) error {
 	pretty.Println("inst:", inst)
 	panic("emitInstXTEST: not yet implemented")
 }
+
+// ### [ Helper functions ] ####################################################
+
+// newSelect appends a new select instruction to the current basic block of f,
+// selecting x if cond is true and y otherwise.
+//
+// Note, ir.BasicBlock.NewSelect of llir/llvm v0.3.0-pre4 uses x for both
+// operands, thus the instruction is created through ir.NewSelect.
+func (f *Func) newSelect(cond, x, y value.Value) *ir.InstSelect {
+	inst := ir.NewSelect(cond, x, y)
+	f.cur.Insts = append(f.cur.Insts, inst)
+	return inst
+}
+
+// isSameReg reports whether the given instruction operands refer to the same
+// register (e.g. the operands of `sbb eax, eax`).
+func isSameReg(a, b x86asm.Arg) bool {
+	x, ok := a.(x86asm.Reg)
+	if !ok {
+		return false
+	}
+	y, ok := b.(x86asm.Reg)
+	return ok && x == y
+}
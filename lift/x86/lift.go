@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sort"
 
 	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/bin/demangle"
 	"github.com/decomp/exp/disasm/x86"
 	"github.com/llir/llvm/asm"
 	"github.com/llir/llvm/ir"
@@ -46,6 +48,32 @@ type Lifter struct {
 	FuncByName map[string]*ir.Function
 	// Global variables.
 	Globals map[bin.Address]*ir.Global
+	// Map from function name to function prototype of imported functions.
+	Protos map[string]*Proto
+	// Resource budget of each function lifted.
+	Budget Budget
+	// Lax mode; replace functions which fail to lift (e.g. due to unsupported
+	// instructions) by stubs, rather than panicking. Runtime errors of the
+	// lifter are not recovered.
+	Lax bool
+	// Instruction trace instrumentation; insert a call to @__trace(addr) at
+	// the start of each lifted basic block.
+	Trace bool
+	// Precision model of x87 FPU values; x86_fp80 (default) or double.
+	FPU FPUPrecision
+	// Alignment of lifted memory accesses; natural (default) or unaligned.
+	Align AlignMode
+	// Stable value names; name unnamed local values by basic block address and
+	// instruction index (e.g. %t_401000.7), rather than numbering them
+	// sequentially throughout the function.
+	StableNames bool
+	// Hooks invoked while lifting functions, in order of registration.
+	Hooks []Hook
+	// Map from instruction address to accessed field of recovered struct.
+	structs map[bin.Address]*structField
+	// Map from name to declaration of LLVM intrinsics and runtime support
+	// functions used by the lifted code.
+	intrinsics map[string]*ir.Function
 }
 
 // NewLifter creates a new Lifter for accessing the assembly instructions of the
@@ -59,14 +87,17 @@ type Lifter struct {
 //    tables.json
 //    chunks.json
 //    data.json
+//    overrides.json
 //
 // Associated files of the x86 disassembler.
 //
 //    contexts.json
+//    modes.json
 //
 // Associated files of the x86 to LLVM IR lifter.
 //
 //    info.ll
+//    winapi.h
 func NewLifter(file *bin.File) (*Lifter, error) {
 	// Prepare x86 to LLVM IR lifter.
 	dis, err := x86.NewDisasm(file)
@@ -78,6 +109,7 @@ func NewLifter(file *bin.File) (*Lifter, error) {
 		Funcs:      make(map[bin.Address]*Func),
 		FuncByName: make(map[string]*ir.Function),
 		Globals:    make(map[bin.Address]*ir.Global),
+		intrinsics: newIntrinsics(dis.Mode),
 	}
 
 	// Parse associated LLVM IR information.
@@ -121,18 +153,27 @@ func NewLifter(file *bin.File) (*Lifter, error) {
 		l.Funcs[entry] = fn
 	}
 
+	// Load function prototypes of imported functions.
+	if err := l.loadProtos(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
 	// Parse imports.
 	addFunc := func(entry bin.Address, name string) {
-		// TODO: Mark function signature as unknown (using metadata), so that type
-		// analysis may replace it.
-		name = fmt.Sprintf("_imp_%s", name)
-		sig := types.NewFunc(types.Void)
-		typ := types.NewPointer(sig)
-		f := &ir.Function{
-			Typ: typ,
-			Sig: sig,
+		var f *ir.Function
+		if p, ok := l.lookupProto(name); ok {
+			f = p.NewFunc(fmt.Sprintf("_imp_%s", name))
+		} else {
+			// TODO: Mark function signature as unknown (using metadata), so that
+			// type analysis may replace it.
+			sig := types.NewFunc(types.Void)
+			typ := types.NewPointer(sig)
+			f = &ir.Function{
+				Typ: typ,
+				Sig: sig,
+			}
+			f.SetName(fmt.Sprintf("_imp_%s", name))
 		}
-		f.SetName(name)
 		md := &metadata.Attachment{
 			Name: "addr",
 			Node: &metadata.Tuple{
@@ -140,27 +181,38 @@ func NewLifter(file *bin.File) (*Lifter, error) {
 			},
 		}
 		f.Metadata = append(f.Metadata, md)
+		if sym, err := demangle.Demangle(name); err == nil {
+			// Record demangled prototype of C++ functions.
+			md := &metadata.Attachment{
+				Name: "demangled",
+				Node: &metadata.Tuple{
+					Fields: []metadata.Field{&metadata.String{Value: sym.Proto()}},
+				},
+			}
+			f.Metadata = append(f.Metadata, md)
+		}
 		fn := &Func{
 			Function: f,
 		}
 		l.Funcs[entry] = fn
 	}
-	for entry, fname := range l.File.Imports {
+	for _, entry := range sortedAddrs(l.File.Imports) {
 		if _, ok := l.Funcs[entry]; ok {
 			// Skip import if already specified through function signature.
 			continue
 		}
+		fname := l.File.Imports[entry]
 		dbg.Printf("function import at %v: %v\n", entry, fname)
 		addFunc(entry, fname)
 	}
 
 	// Parse exports.
-	for entry, fname := range dis.File.Exports {
+	for _, entry := range sortedAddrs(dis.File.Exports) {
 		if _, ok := l.Funcs[entry]; ok {
 			// Skip export if already specified through function signature.
 			continue
 		}
-		addFunc(entry, fname)
+		addFunc(entry, dis.File.Exports[entry])
 	}
 
 	return l, nil
@@ -168,6 +220,17 @@ func NewLifter(file *bin.File) (*Lifter, error) {
 
 // ### [ Helper functions ] ####################################################
 
+// sortedAddrs returns the addresses of the given symbol map in ascending order;
+// used to make the lifter independent of map iteration order.
+func sortedAddrs(m map[bin.Address]string) []bin.Address {
+	var addrs bin.Addresses
+	for addr := range m {
+		addrs = append(addrs, addr)
+	}
+	sort.Sort(addrs)
+	return addrs
+}
+
 // parseModule parses and returns the given LLVM IR module.
 func parseModule(llPath string) (*ir.Module, error) {
 	if !osutil.Exists(llPath) {
@@ -5,6 +5,8 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/decomp/exp/bin"
@@ -12,9 +14,15 @@ import (
 	_ "github.com/decomp/exp/bin/pe"  // register PE decoder
 	_ "github.com/decomp/exp/bin/pef" // register PEF decoder
 	"github.com/decomp/exp/bin/raw"
+	"github.com/decomp/exp/disasm"
+	"github.com/decomp/exp/disasm/x86"
 	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/enum"
+	"github.com/llir/llvm/ir/types"
 	"github.com/mewkiz/pkg/diffutil"
 	"github.com/pkg/errors"
+	"golang.org/x/arch/x86/x86asm"
 )
 
 func TestLift(t *testing.T) {
@@ -50,6 +58,23 @@ func TestLift(t *testing.T) {
 		{dir: "testdata/x86_32/arithmetic", in: "arithmetic.so", out: "arithmetic.ll"},
 		{dir: "testdata/x86_64/arithmetic", in: "arithmetic.so", out: "arithmetic.ll"},
 
+		// Status flags live across basic blocks.
+		{dir: "testdata/x86_32/flags", in: "flags.so", out: "flags.ll"},
+		{dir: "testdata/x86_64/flags", in: "flags.so", out: "flags.ll"},
+
+		// Conditional move instructions.
+		{dir: "testdata/x86_32/cmovcc", in: "cmovcc.so", out: "cmovcc.ll"},
+		{dir: "testdata/x86_64/cmovcc", in: "cmovcc.so", out: "cmovcc.ll"},
+
+		// Set byte on condition instructions and SBB flag idioms.
+		{dir: "testdata/x86_32/setcc", in: "setcc.so", out: "setcc.ll"},
+		{dir: "testdata/x86_64/setcc", in: "setcc.so", out: "setcc.ll"},
+
+		// Double precision shifts, rotates through carry and paired 64-bit shift
+		// idioms.
+		{dir: "testdata/x86_32/shift", in: "shift.so", out: "shift.ll"},
+		{dir: "testdata/x86_64/shift", in: "shift.so", out: "shift.ll"},
+
 		// Import functions from dynamic libraries.
 		{dir: "testdata/x86_32/import", in: "import.out", out: "import.ll"},
 		{dir: "testdata/x86_64/import", in: "import.out", out: "import.ll"},
@@ -82,6 +107,8 @@ func TestLift(t *testing.T) {
 		//    * FCMOVNE
 		//    * FCMOVNU
 		//    * FCMOVU
+		{dir: "testdata/x86_32/fpu/fcmovcc", in: "fcmovcc.so", out: "fcmovcc.ll"},
+		{dir: "testdata/x86_64/fpu/fcmovcc", in: "fcmovcc.so", out: "fcmovcc.ll"},
 		//
 		// --- [ x87 FPU Load Constants Instructions ] ---------------------------
 		//
@@ -106,11 +133,27 @@ func TestLift(t *testing.T) {
 		//    * FLDZ
 		{dir: "testdata/x86_32/fpu/fldz", in: "fldz.so", out: "fldz.ll"},
 		{dir: "testdata/x86_64/fpu/fldz", in: "fldz.so", out: "fldz.ll"},
+		//
+		// --- [ x87 FPU Comparison Instructions ] -------------------------------
+		//
+		//    * FCOMI
+		//    * FCOMIP
+		//    * FUCOMI
+		//    * FUCOMIP
+		{dir: "testdata/x86_32/fpu/fcomi", in: "fcomi.so", out: "fcomi.ll"},
+		{dir: "testdata/x86_64/fpu/fcomi", in: "fcomi.so", out: "fcomi.ll"},
+		//
+		// --- [ x87 FPU register stack ] ----------------------------------------
+		//
+		//    * static and dynamic stack top
+		{dir: "testdata/x86_32/fpu/fstack", in: "fstack.so", out: "fstack.ll"},
+		{dir: "testdata/x86_64/fpu/fstack", in: "fstack.so", out: "fstack.ll"},
 	}
 	wd, err := os.Getwd()
 	if err != nil {
 		t.Fatalf("unable to retrieve current working directory; %+v", err)
 	}
+	defer os.Chdir(wd)
 	for _, g := range golden {
 		in := filepath.Join(g.dir, g.in)
 		log.Printf("testing: %q", in)
@@ -164,6 +207,502 @@ func TestLift(t *testing.T) {
 	}
 }
 
+func TestLiftDeterministic(t *testing.T) {
+	golden := []struct {
+		// Base directory; which may contain decomp JSON files.
+		dir string
+		// Path to input binary executable or object file.
+		in string
+		// Raw machine architecture; or 0 if any format other than raw.
+		arch bin.Arch
+	}{
+		{dir: "testdata/x86_32/import", in: "import.out"},
+		{dir: "testdata/x86_64/import", in: "import.out"},
+		{dir: "testdata/x86_32/fpu/fild", in: "fild.so"},
+		{dir: "testdata/x86_32/fpu/fld", in: "fld.so"},
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("unable to retrieve current working directory; %+v", err)
+	}
+	defer os.Chdir(wd)
+	for _, g := range golden {
+		in := filepath.Join(g.dir, g.in)
+		if err := os.Chdir(filepath.Join(wd, g.dir)); err != nil {
+			t.Errorf("%q: unable to change working directory; %+v", in, err)
+			continue
+		}
+		// Lift the binary executable several times, and verify that the output
+		// is byte-identical across runs.
+		var want string
+		for i := 0; i < 3; i++ {
+			got, err := liftModule(g.in, g.arch)
+			if err != nil {
+				t.Errorf("%q: unable to lift module; %+v", in, err)
+				break
+			}
+			if i == 0 {
+				want = got
+				continue
+			}
+			if got != want {
+				diffutil.Diff(want, got, false, g.in)
+				t.Errorf("%q: non-deterministic output in run %d", in, i)
+				break
+			}
+		}
+	}
+}
+
+func TestHookShift64(t *testing.T) {
+	// Paired 64-bit shift idioms are lifted one instruction at a time when hooks
+	// are registered, so that hooks observe each instruction.
+	const in = "testdata/x86_32/shift/shift.so"
+	l, err := newLifter(in, 0)
+	if err != nil {
+		t.Fatalf("%q: unable to prepare lifter; %+v", in, err)
+	}
+	hook := &countHook{before: make(map[bin.Address]int), after: make(map[bin.Address]int)}
+	l.Hooks = append(l.Hooks, hook)
+	want := make(map[bin.Address]int)
+	for _, funcAddr := range l.FuncAddrs {
+		asmFunc, err := l.DecodeFunc(funcAddr)
+		if err != nil {
+			t.Fatalf("%q: unable to decode function; %+v", in, err)
+		}
+		for _, block := range asmFunc.Blocks {
+			for _, inst := range block.Insts {
+				want[inst.Addr]++
+			}
+		}
+		l.Funcs[funcAddr] = l.NewFunc(asmFunc)
+	}
+	for _, funcAddr := range l.FuncAddrs {
+		l.Funcs[funcAddr].Lift()
+	}
+	if !reflect.DeepEqual(hook.before, want) {
+		t.Errorf("%q: BeforeInst mismatch; expected %v, got %v", in, want, hook.before)
+	}
+	if !reflect.DeepEqual(hook.after, want) {
+		t.Errorf("%q: AfterInst mismatch; expected %v, got %v", in, want, hook.after)
+	}
+}
+
+// countHook counts the number of times each instruction is passed to the
+// BeforeInst and AfterInst methods of the hook.
+type countHook struct {
+	NopHook
+	// Number of BeforeInst calls, mapped from instruction address.
+	before map[bin.Address]int
+	// Number of AfterInst calls, mapped from instruction address.
+	after map[bin.Address]int
+}
+
+// BeforeInst counts the given instruction, leaving its lifting to the lifter.
+func (hook *countHook) BeforeInst(f *Func, inst *x86.Inst) bool {
+	hook.before[inst.Addr]++
+	return false
+}
+
+// AfterInst counts the given instruction.
+func (hook *countHook) AfterInst(f *Func, inst *x86.Inst) {
+	hook.after[inst.Addr]++
+}
+
+func TestSubReg(t *testing.T) {
+	golden := []struct {
+		reg    x86asm.Reg
+		mode   int
+		full   x86asm.Reg
+		offset uint64
+		ok     bool
+	}{
+		// 32-bit mode.
+		{reg: x86asm.AL, mode: 32, full: x86asm.EAX, offset: 0, ok: true},
+		{reg: x86asm.AH, mode: 32, full: x86asm.EAX, offset: 8, ok: true},
+		{reg: x86asm.BH, mode: 32, full: x86asm.EBX, offset: 8, ok: true},
+		{reg: x86asm.AX, mode: 32, full: x86asm.EAX, offset: 0, ok: true},
+		{reg: x86asm.DI, mode: 32, full: x86asm.EDI, offset: 0, ok: true},
+		{reg: x86asm.EAX, mode: 32, ok: false},
+		{reg: x86asm.ESP, mode: 32, ok: false},
+		// 16-bit mode; 32-bit registers are accessible through operand size
+		// prefixes.
+		{reg: x86asm.AX, mode: 16, full: x86asm.EAX, offset: 0, ok: true},
+		{reg: x86asm.CL, mode: 16, full: x86asm.ECX, offset: 0, ok: true},
+		// 64-bit mode.
+		{reg: x86asm.AL, mode: 64, full: x86asm.RAX, offset: 0, ok: true},
+		{reg: x86asm.AH, mode: 64, full: x86asm.RAX, offset: 8, ok: true},
+		{reg: x86asm.SIB, mode: 64, full: x86asm.RSI, offset: 0, ok: true},
+		{reg: x86asm.R8B, mode: 64, full: x86asm.R8, offset: 0, ok: true},
+		{reg: x86asm.R15W, mode: 64, full: x86asm.R15, offset: 0, ok: true},
+		{reg: x86asm.EAX, mode: 64, full: x86asm.RAX, offset: 0, ok: true},
+		{reg: x86asm.R9L, mode: 64, full: x86asm.R9, offset: 0, ok: true},
+		{reg: x86asm.RAX, mode: 64, ok: false},
+		// Other registers.
+		{reg: x86asm.EIP, mode: 32, ok: false},
+		{reg: x86asm.F0, mode: 32, ok: false},
+		{reg: x86asm.FS, mode: 64, ok: false},
+	}
+	for _, g := range golden {
+		full, offset, ok := subReg(g.reg, g.mode)
+		if ok != g.ok {
+			t.Errorf("%v (%d-bit mode): sub-register mismatch; expected %v, got %v", g.reg, g.mode, g.ok, ok)
+			continue
+		}
+		if full != g.full || offset != g.offset {
+			t.Errorf("%v (%d-bit mode): full register mismatch; expected %v at offset %d, got %v at offset %d", g.reg, g.mode, g.full, g.offset, full, offset)
+		}
+	}
+}
+
+func TestParamRegs(t *testing.T) {
+	i32, i64, f64 := types.Type(types.I32), types.Type(types.I64), types.Type(types.Double)
+	golden := []struct {
+		callconv enum.CallingConv
+		params   []types.Type
+		want     []x86asm.Reg
+	}{
+		{callconv: enum.CallingConvC, params: []types.Type{i32, i32}, want: []x86asm.Reg{0, 0}},
+		{callconv: enum.CallingConvX86StdCall, params: []types.Type{i32}, want: []x86asm.Reg{0}},
+		{callconv: enum.CallingConvX86ThisCall, params: []types.Type{i32, i32}, want: []x86asm.Reg{x86asm.ECX, 0}},
+		// The first two parameters of at most 32 bits are passed in registers.
+		{callconv: enum.CallingConvX86FastCall, params: []types.Type{i32, i32, i32}, want: []x86asm.Reg{x86asm.ECX, x86asm.EDX, 0}},
+		{callconv: enum.CallingConvX86FastCall, params: []types.Type{i64, f64, i32}, want: []x86asm.Reg{0, 0, x86asm.ECX}},
+		// Registers are assigned by position.
+		{callconv: enum.CallingConvWin64, params: []types.Type{i64, f64, i64, i64, i64}, want: []x86asm.Reg{x86asm.RCX, x86asm.X1, x86asm.R8, x86asm.R9, 0}},
+		// Registers are assigned by class.
+		{callconv: enum.CallingConvX86_64SysV, params: []types.Type{f64, i64, f64, i64}, want: []x86asm.Reg{x86asm.X0, x86asm.RDI, x86asm.X1, x86asm.RSI}},
+	}
+	for _, g := range golden {
+		regs := paramRegs(g.callconv, g.params)
+		if len(regs) != len(g.want) {
+			t.Errorf("%v: number of parameters mismatch; expected %d, got %d", g.callconv, len(g.want), len(regs))
+			continue
+		}
+		for i, reg := range regs {
+			got := x86asm.Reg(0)
+			if reg != nil {
+				got = reg.Reg
+			}
+			if got != g.want[i] {
+				t.Errorf("%v: register of parameter %d mismatch; expected %v, got %v", g.callconv, i, g.want[i], got)
+			}
+		}
+	}
+}
+
+func TestCallConv(t *testing.T) {
+	golden := []struct {
+		mode     int
+		format   string
+		callconv enum.CallingConv
+		want     enum.CallingConv
+	}{
+		// Calling conventions are used as declared in 32-bit mode.
+		{mode: 32, format: "pe", callconv: enum.CallingConvX86StdCall, want: enum.CallingConvX86StdCall},
+		{mode: 32, format: "elf", callconv: enum.CallingConvC, want: enum.CallingConvC},
+		// Calling conventions of 32-bit mode use the platform calling convention
+		// in 64-bit mode.
+		{mode: 64, format: "pe", callconv: enum.CallingConvX86StdCall, want: enum.CallingConvWin64},
+		{mode: 64, format: "coff", callconv: enum.CallingConvC, want: enum.CallingConvWin64},
+		{mode: 64, format: "elf", callconv: enum.CallingConvC, want: enum.CallingConvX86_64SysV},
+		{mode: 64, format: "", callconv: enum.CallingConvNone, want: enum.CallingConvX86_64SysV},
+		// Explicit 64-bit calling conventions are kept.
+		{mode: 64, format: "elf", callconv: enum.CallingConvWin64, want: enum.CallingConvWin64},
+	}
+	for _, g := range golden {
+		l := &Lifter{Disasm: &x86.Disasm{Mode: g.mode, Disasm: &disasm.Disasm{File: &bin.File{Format: g.format}}}}
+		if got := l.callConv(g.callconv); got != g.want {
+			t.Errorf("%v (%d-bit %q): calling convention mismatch; expected %v, got %v", g.callconv, g.mode, g.format, g.want, got)
+		}
+	}
+}
+
+func TestNArgsOverride(t *testing.T) {
+	golden := []struct {
+		mode int
+		want string
+	}{
+		{mode: 32, want: "void (i32, i32)"},
+		{mode: 64, want: "void (i64, i64)"},
+	}
+	for _, g := range golden {
+		nargs := 2
+		d := &disasm.Disasm{
+			File:   &bin.File{},
+			Naming: disasm.NamingDefault,
+			Overrides: &disasm.Overrides{
+				Funcs: map[bin.Address]*disasm.FuncOverride{
+					0x401000: {NArgs: &nargs},
+				},
+			},
+		}
+		l := &Lifter{Disasm: &x86.Disasm{Mode: g.mode, Disasm: d}, Funcs: make(map[bin.Address]*Func)}
+		f := l.NewFunc(&x86.Func{Addr: 0x401000, Blocks: make(map[bin.Address]*x86.BasicBlock)})
+		if got := f.Sig.String(); got != g.want {
+			t.Errorf("%d-bit mode: function signature mismatch; expected %q, got %q", g.mode, g.want, got)
+		}
+	}
+}
+
+func TestFormatParams(t *testing.T) {
+	golden := []struct {
+		format string
+		scanf  bool
+		want   string
+	}{
+		{format: "%d%%\n", want: "i32"},
+		{format: "%-*.*s %lld %p %5.2f", want: "i32 i32 i8* i64 i8* double"},
+		{format: "%ls %I64x %c", want: "i16* i64 i32"},
+		{format: "%d %*d %lf %s", scanf: true, want: "i32* double* i8*"},
+		{format: "%hhu %[^]a] %3c %n", scanf: true, want: "i8* i8* i8* i32*"},
+	}
+	for _, g := range golden {
+		var params []types.Type
+		if g.scanf {
+			params = scanfParams(g.format)
+		} else {
+			params = printfParams(g.format, 32)
+		}
+		var got []string
+		for _, param := range params {
+			got = append(got, param.String())
+		}
+		if s := strings.Join(got, " "); s != g.want {
+			t.Errorf("%q: parameter types mismatch; expected %q, got %q", g.format, g.want, s)
+		}
+	}
+}
+
+func TestNameValues(t *testing.T) {
+	// liftFunc returns a function with basic blocks at the given addresses; the
+	// basic block at 0x401000 contains n instructions, followed by a basic
+	// block created while lifting (e.g. a REP loop).
+	liftFunc := func(n int, blockAddrs ...bin.Address) *Func {
+		f := &Func{
+			Function: &ir.Function{},
+			blocks:   make(map[bin.Address]*ir.BasicBlock),
+		}
+		one := constant.NewInt(types.I32, 1)
+		for _, blockAddr := range blockAddrs {
+			block := ir.NewBlock("")
+			f.blocks[blockAddr] = block
+			f.Blocks = append(f.Blocks, block)
+			m := 2
+			if blockAddr == 0x401000 {
+				m = n
+			}
+			for i := 0; i < m; i++ {
+				block.NewAdd(one, one)
+			}
+			if blockAddr == 0x401000 {
+				loop := ir.NewBlock("")
+				loop.NewAdd(one, one)
+				f.Blocks = append(f.Blocks, loop)
+			}
+		}
+		return f
+	}
+	// names returns the names of the instructions of the basic block at the
+	// given address.
+	names := func(f *Func, blockAddr bin.Address) []string {
+		var names []string
+		for _, inst := range f.blocks[blockAddr].Insts {
+			names = append(names, inst.(*ir.InstAdd).Name())
+		}
+		return names
+	}
+	f := liftFunc(2, 0x401000, 0x401010)
+	f.nameValues()
+	want := []string{"t_401000.0", "t_401000.1"}
+	if got := names(f, 0x401000); !reflect.DeepEqual(got, want) {
+		t.Errorf("names mismatch; expected %q, got %q", want, got)
+	}
+	if got, want := f.Blocks[1].Insts[0].(*ir.InstAdd).Name(), "t_401000.2"; got != want {
+		t.Errorf("names of lifted basic block mismatch; expected %q, got %q", want, got)
+	}
+	// Adding instructions and basic blocks does not rename the values of
+	// succeeding basic blocks.
+	g := liftFunc(3, 0x401000, 0x401008, 0x401010)
+	g.nameValues()
+	if got, want := names(g, 0x401010), names(f, 0x401010); !reflect.DeepEqual(got, want) {
+		t.Errorf("names of succeeding basic block mismatch; expected %q, got %q", want, got)
+	}
+}
+
+func TestDecodeFloat(t *testing.T) {
+	golden := []struct {
+		typ  *types.FloatType
+		data []byte
+		// Expected LLVM IR literal; or empty if not supported.
+		want string
+	}{
+		{typ: types.Float, data: []byte{0x00, 0x00, 0x20, 0x40}, want: "2.5"},
+		{typ: types.Double, data: []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xF0, 0xBF}, want: "-1.0"},
+		// 80-bit double extended-precision.
+		{typ: types.X86FP80, data: []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x80, 0xFF, 0x3F}, want: "0xK3FFF8000000000000000"},
+		{typ: types.X86FP80, data: []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xC0, 0x00, 0xC0}, want: "0xKC000C000000000000000"},
+		// Pi, with a 64-bit significand not representable in double-precision.
+		{typ: types.X86FP80, data: []byte{0x35, 0xC2, 0x68, 0x21, 0xA2, 0xDA, 0x0F, 0xC9, 0x00, 0x40}, want: "0xK4000C90FDAA22168C235"},
+		// Denormal.
+		{typ: types.X86FP80, data: []byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, want: "0xK00000000000000000001"},
+		// Infinity.
+		{typ: types.X86FP80, data: []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x80, 0xFF, 0xFF}, want: "0xKFFFF8000000000000000"},
+		// NaN.
+		{typ: types.X86FP80, data: []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xC0, 0xFF, 0x7F}},
+	}
+	for _, g := range golden {
+		c, ok := decodeFloat(g.typ, g.data)
+		if !ok {
+			if len(g.want) > 0 {
+				t.Errorf("% X: unable to decode floating-point constant", g.data)
+			}
+			continue
+		}
+		if got := c.Ident(); got != g.want {
+			t.Errorf("% X: floating-point constant mismatch; expected %v, got %v", g.data, g.want, got)
+		}
+	}
+}
+
+func TestDecoratedName(t *testing.T) {
+	const src = `
+HANDLE WINAPI CreateFileA(LPCSTR lpFileName, DWORD dwDesiredAccess, DWORD dwShareMode, LPSECURITY_ATTRIBUTES lpSecurityAttributes, DWORD dwCreationDisposition, DWORD dwFlagsAndAttributes, HANDLE hTemplateFile);
+int __fastcall Foo(int a, double b);
+int printf(const char *format, ...);
+`
+	protos, err := parseProtos(src, 32)
+	if err != nil {
+		t.Fatalf("unable to parse prototypes; %v", err)
+	}
+	golden := []struct {
+		name string
+		mode int
+		want string
+	}{
+		{name: "CreateFileA", mode: 32, want: "_CreateFileA@28"},
+		{name: "Foo", mode: 32, want: "@Foo@12"},
+		{name: "printf", mode: 32, want: "_printf"},
+		{name: "CreateFileA", mode: 64, want: "CreateFileA"},
+	}
+	for _, g := range golden {
+		if got := protos[g.name].DecoratedName(g.mode); got != g.want {
+			t.Errorf("%q: decorated name mismatch; expected %q, got %q", g.name, g.want, got)
+		}
+	}
+}
+
+// benchCorpus is the corpus of binary executables used by benchmarks.
+var benchCorpus = []struct {
+	// Base directory; which may contain decomp JSON files.
+	dir string
+	// Path to input binary executable or object file.
+	in string
+	// Raw machine architecture; or 0 if any format other than raw.
+	arch bin.Arch
+}{
+	{dir: "testdata/x86_32/arithmetic", in: "arithmetic.so"},
+	{dir: "testdata/x86_64/arithmetic", in: "arithmetic.so"},
+	{dir: "testdata/x86_32/import", in: "import.out"},
+	{dir: "testdata/x86_32/fpu/fld", in: "fld.so"},
+	{dir: "testdata/x86_32/format", in: "format.bin", arch: bin.ArchX86_32},
+}
+
+// BenchmarkDecode benchmarks decoding the functions of the corpus.
+func BenchmarkDecode(b *testing.B) {
+	bench(b, func(b *testing.B, in string, arch bin.Arch) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			l, err := newLifter(in, arch)
+			if err != nil {
+				b.Fatalf("%q: unable to create lifter; %+v", in, err)
+			}
+			b.StartTimer()
+			if _, err := l.DecodeProgram(l.FuncAddrs, 1); err != nil {
+				b.Fatalf("%q: unable to decode functions; %+v", in, err)
+			}
+		}
+	})
+}
+
+// BenchmarkLift benchmarks lifting the pre-decoded functions of the corpus.
+func BenchmarkLift(b *testing.B) {
+	bench(b, func(b *testing.B, in string, arch bin.Arch) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			l, err := newLifter(in, arch)
+			if err != nil {
+				b.Fatalf("%q: unable to create lifter; %+v", in, err)
+			}
+			prog, err := l.DecodeProgram(l.FuncAddrs, 1)
+			if err != nil {
+				b.Fatalf("%q: unable to decode functions; %+v", in, err)
+			}
+			b.StartTimer()
+			for _, funcAddr := range prog.FuncAddrs {
+				asmFunc, _ := prog.FuncAt(funcAddr)
+				l.Funcs[funcAddr] = l.NewFunc(asmFunc)
+			}
+			for _, funcAddr := range prog.FuncAddrs {
+				l.Funcs[funcAddr].Lift()
+			}
+		}
+	})
+}
+
+// BenchmarkEndToEnd benchmarks parsing, decoding, lifting and printing the
+// LLVM IR assembly of the corpus.
+func BenchmarkEndToEnd(b *testing.B) {
+	bench(b, func(b *testing.B, in string, arch bin.Arch) {
+		for i := 0; i < b.N; i++ {
+			if _, err := liftModule(in, arch); err != nil {
+				b.Fatalf("%q: unable to lift module; %+v", in, err)
+			}
+		}
+	})
+}
+
+// bench runs the given benchmark as a sub-benchmark for each binary executable
+// of the corpus, from within the base directory of the binary executable.
+func bench(b *testing.B, f func(b *testing.B, in string, arch bin.Arch)) {
+	wd, err := os.Getwd()
+	if err != nil {
+		b.Fatalf("unable to retrieve current working directory; %+v", err)
+	}
+	defer os.Chdir(wd)
+	for _, c := range benchCorpus {
+		in := filepath.Join(c.dir, c.in)
+		b.Run(in, func(b *testing.B) {
+			if err := os.Chdir(filepath.Join(wd, c.dir)); err != nil {
+				b.Fatalf("%q: unable to change working directory; %+v", in, err)
+			}
+			// Report allocations, to track the GC pressure of lifted IR values.
+			b.ReportAllocs()
+			f(b, c.in, c.arch)
+		})
+	}
+}
+
+// liftModule lifts the functions of the given binary executable, and returns
+// the LLVM IR assembly of the resulting module.
+func liftModule(path string, arch bin.Arch) (string, error) {
+	l, err := newLifter(path, arch)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	for _, funcAddr := range l.FuncAddrs {
+		asmFunc, err := l.DecodeFunc(funcAddr)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		l.Funcs[funcAddr] = l.NewFunc(asmFunc)
+	}
+	for _, funcAddr := range l.FuncAddrs {
+		l.Funcs[funcAddr].Lift()
+	}
+	return l.Module().String(), nil
+}
+
 // newLifter returns a new x86 to LLVM IR lifter for the given binary
 // executable.
 func newLifter(path string, arch bin.Arch) (*Lifter, error) {
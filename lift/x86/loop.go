@@ -0,0 +1,67 @@
+package x86
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/decomp/exp/bin"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/metadata"
+)
+
+// loopMetadata returns the loop metadata attachments of the natural loops of
+// the function, mapping from loop latch address to the "llvm.loop" attachment
+// of its terminator.
+//
+// Loop properties are encoded as string tuples, in the same manner as the
+// "addr" metadata of functions and global variables.
+//
+//    br label %block_401010, !llvm.loop !{!{!"llvm.loop.header", !"0x401010"}, !{!"llvm.loop.latch", !"0x401020"}, !{!"llvm.loop.exit", !"0x40102C"}, !{!"llvm.loop.trip_count", !"10"}}
+func (f *Func) loopMetadata() map[bin.Address]*metadata.Attachment {
+	mds := make(map[bin.Address]*metadata.Attachment)
+	for _, loop := range f.l.Loops(f.AsmFunc) {
+		node := &metadata.Tuple{}
+		add := func(name, value string) {
+			field := &metadata.Tuple{
+				Fields: []metadata.Field{&metadata.String{Value: name}, &metadata.String{Value: value}},
+			}
+			node.Fields = append(node.Fields, field)
+		}
+		add("llvm.loop.header", loop.Header.String())
+		for _, latch := range loop.Latches {
+			add("llvm.loop.latch", latch.String())
+		}
+		for _, exit := range loop.Exits {
+			add("llvm.loop.exit", exit.String())
+		}
+		if loop.Parent != nil {
+			add("llvm.loop.parent", loop.Parent.Header.String())
+		}
+		if loop.TripCount != 0 {
+			add("llvm.loop.trip_count", strconv.FormatUint(loop.TripCount, 10))
+		}
+		dbg.Printf("loop at %v of %q; latches %v, exits %v, trip count %d", loop.Header, f.Name(), loop.Latches, loop.Exits, loop.TripCount)
+		for _, latch := range loop.Latches {
+			mds[latch] = &metadata.Attachment{
+				Name: "llvm.loop",
+				Node: node,
+			}
+		}
+	}
+	return mds
+}
+
+// attachLoopMetadata attaches the given loop metadata to the terminator of the
+// current basic block.
+func (f *Func) attachLoopMetadata(md *metadata.Attachment) {
+	switch term := f.cur.Term.(type) {
+	case *ir.TermBr:
+		term.Metadata = append(term.Metadata, md)
+	case *ir.TermCondBr:
+		term.Metadata = append(term.Metadata, md)
+	case *ir.TermSwitch:
+		term.Metadata = append(term.Metadata, md)
+	default:
+		panic(fmt.Errorf("support for loop metadata on terminator %T not yet implemented", term))
+	}
+}
@@ -0,0 +1,141 @@
+package x86
+
+import (
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/enum"
+	"github.com/llir/llvm/ir/types"
+)
+
+// Module returns an LLVM IR module containing the type definitions, global
+// variables and functions of the lifter. Initialization functions invoked prior
+// to the entry point (e.g. TLS callbacks) are registered in the
+// @llvm.global_ctors initializer list.
+//
+// The output is deterministic; functions and global variables are sorted by
+// address, and type definitions retain the order of info.ll. Thus, lifting the
+// same binary executable twice produces byte-identical LLVM IR assembly.
+func (l *Lifter) Module() *ir.Module {
+	m := &ir.Module{
+		TypeDefs: l.TypeDefs,
+	}
+	// Add global variables in ascending address order.
+	var globalAddrs bin.Addresses
+	for globalAddr := range l.Globals {
+		globalAddrs = append(globalAddrs, globalAddr)
+	}
+	sort.Sort(globalAddrs)
+	for _, globalAddr := range globalAddrs {
+		g := l.Globals[globalAddr]
+		m.Globals = append(m.Globals, g)
+	}
+	if ctors := l.initFuncsGlobal(); ctors != nil {
+		m.Globals = append(m.Globals, ctors)
+	}
+	// Add functions in ascending address order.
+	var funcAddrs bin.Addresses
+	for funcAddr := range l.Funcs {
+		funcAddrs = append(funcAddrs, funcAddr)
+	}
+	sort.Sort(funcAddrs)
+	added := make(map[*Func]bool)
+	for _, funcAddr := range funcAddrs {
+		f := l.Funcs[funcAddr]
+		if added[f] {
+			// skip thunks aliased to their target functions.
+			continue
+		}
+		added[f] = true
+		m.Funcs = append(m.Funcs, f.Function)
+	}
+	// Add declarations of used intrinsics in name order.
+	var names []string
+	for name := range l.intrinsics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if intrinsic := l.intrinsics[name]; usesCallee(m.Funcs, intrinsic) {
+			m.Funcs = append(m.Funcs, intrinsic)
+		}
+	}
+	return m
+}
+
+// AliasThunks aliases the thunks of the lifter to their final target functions,
+// so that calls to thunks are lifted as direct calls to the target functions.
+// AliasThunks should be invoked after the function lifters of the thunk targets
+// have been created.
+func (l *Lifter) AliasThunks() {
+	for _, thunk := range sortedThunks(l.Thunks) {
+		target := l.FinalTarget(thunk)
+		f, ok := l.Funcs[target]
+		if !ok {
+			warn.Printf("unable to locate target function at %v of thunk at %v", target, thunk)
+			continue
+		}
+		dbg.Printf("aliasing thunk at %v to %q", thunk, f.Name())
+		l.Funcs[thunk] = f
+	}
+}
+
+// IsAlias reports whether the function lifter at the given address is an alias
+// of a function located at another address (e.g. a thunk).
+func (l *Lifter) IsAlias(funcAddr bin.Address) bool {
+	f, ok := l.Funcs[funcAddr]
+	if !ok {
+		return false
+	}
+	if f.AsmFunc != nil {
+		return f.AsmFunc.Addr != funcAddr
+	}
+	_, ok = l.Thunks[funcAddr]
+	return ok
+}
+
+// initFuncsGlobal returns the @llvm.global_ctors initializer list of the
+// initialization functions of the binary executable, or nil if not present.
+//
+// Example:
+//
+//    @llvm.global_ctors = appending global [1 x { i32, void ()*, i8* }] [{ i32, void ()*, i8* } { i32 101, void ()* @f_401000, i8* null }]
+func (l *Lifter) initFuncsGlobal() *ir.Global {
+	ctorType := types.NewPointer(types.NewFunc(types.Void))
+	var elems []constant.Constant
+	for i, addr := range l.File.InitFuncs {
+		f, ok := l.Funcs[addr]
+		if !ok {
+			warn.Printf("unable to locate initialization function at %v", addr)
+			continue
+		}
+		var ctor constant.Constant = f.Function
+		if !f.Typ.Equal(ctorType) {
+			ctor = constant.NewBitCast(f.Function, ctorType)
+		}
+		// Initialization functions are invoked in ascending priority order,
+		// and priorities below 101 are reserved.
+		priority := constant.NewInt(types.I32, int64(101+i))
+		elem := constant.NewStruct(priority, ctor, constant.NewNull(types.I8Ptr))
+		elems = append(elems, elem)
+	}
+	if len(elems) == 0 {
+		return nil
+	}
+	g := ir.NewGlobalDef("llvm.global_ctors", constant.NewArray(elems...))
+	g.Linkage = enum.LinkageAppending
+	return g
+}
+
+// sortedThunks returns the thunk addresses of the given thunk map in ascending
+// order.
+func sortedThunks(m map[bin.Address]bin.Address) []bin.Address {
+	var addrs bin.Addresses
+	for addr := range m {
+		addrs = append(addrs, addr)
+	}
+	sort.Sort(addrs)
+	return addrs
+}
@@ -0,0 +1,51 @@
+package x86
+
+import (
+	"fmt"
+
+	"github.com/decomp/exp/bin"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+)
+
+// nameValues assigns deterministic names to the unnamed local values of the
+// function, based on the address of the input basic block and the index of the
+// instruction within the LLVM IR basic blocks lifted from the input basic block
+// (e.g. %t_401000.7 for the eighth instruction of the basic block at 0x401000).
+// Values of the entry basic block, which defines the registers and local
+// variables used within the function, are named by instruction index alone
+// (e.g. %t_entry.2).
+//
+// Unnamed values are otherwise numbered sequentially throughout the function
+// when the module is printed, and thus all succeeding values are renumbered
+// when instructions are added or removed (e.g. as a result of a new
+// annotation). With stable names, changes stay local to the affected basic
+// blocks, keeping diffs between runs minimal; as opposed to naming by the index
+// of the basic block, adding or removing basic blocks does not rename the
+// values of succeeding basic blocks.
+func (f *Func) nameValues() {
+	// Map from LLVM IR basic block to address of the input basic block.
+	addrs := make(map[*ir.BasicBlock]bin.Address)
+	for addr, block := range f.blocks {
+		addrs[block] = addr
+	}
+	// Basic blocks created while lifting an input basic block (e.g. loops of
+	// REP prefixed instructions) succeed the LLVM IR basic block of the input
+	// basic block, and continue its instruction index.
+	prefix := "t_entry"
+	j := 0
+	for _, block := range f.Blocks {
+		if addr, ok := addrs[block]; ok {
+			prefix = fmt.Sprintf("t_%06X", uint64(addr))
+			j = 0
+		}
+		for _, inst := range block.Insts {
+			v, ok := inst.(value.Named)
+			if ok && len(v.Name()) == 0 && !types.Equal(v.Type(), types.Void) {
+				v.SetName(fmt.Sprintf("%s.%d", prefix, j))
+			}
+			j++
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package x86
+
+import (
+	"github.com/decomp/exp/disasm/x86"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// normalize returns the instructions of the given basic block with common
+// instruction idioms rewritten into canonical form, thus reducing the number
+// of distinct instruction forms handled by the lifter and making the output
+// LLVM IR more uniform. The input assembly of the basic block is not modified.
+//
+// The following idioms are rewritten.
+//
+//    xor r32, r32            =>  mov r32, 0
+//    sub r32, r32            =>  mov r32, 0
+//    lea r32, [r32b]         =>  mov r32, r32b
+//    lea r32, [r32+disp]     =>  add r32, disp
+//    lea r32, [r32+r32]      =>  add r32, r32
+//    lea r32, [r32*2]        =>  add r32, r32
+//    lea r32, [r32*4]        =>  shl r32, 2
+//    lea r32, [r32*8]        =>  shl r32, 3
+//    lea r32, [r32+r32*N]    =>  imul r32, r32, N+1
+//    add esp, -N             =>  sub esp, N
+//    sub esp, -N             =>  add esp, N
+//
+// Rewrites which change the status flags defined by an instruction (e.g. xor
+// sets ZF, while mov leaves the status flags untouched; and lea leaves the
+// status flags untouched, while add sets them) are only applied when the
+// status flags are not used before being redefined. The stack pointer and
+// frame pointer are never rewritten by lea idioms, as the lifter tracks stack
+// slots through push and pop.
+func normalize(bb *x86.BasicBlock) []*x86.Inst {
+	insts := make([]*x86.Inst, len(bb.Insts))
+	for i, inst := range bb.Insts {
+		insts[i] = inst
+		if canon, ok := normalizeInst(inst); ok {
+			if definesStatus(inst.Op) != definesStatus(canon.Op) && statusLive(bb, i+1) {
+				continue
+			}
+			dbg.Printf("normalized %v to %v at %v", inst.Inst, canon.Inst, inst.Addr)
+			insts[i] = canon
+		}
+	}
+	return insts
+}
+
+// normalizeInst returns the canonical form of the given instruction, and a
+// boolean indicating whether the instruction was rewritten.
+func normalizeInst(inst *x86.Inst) (*x86.Inst, bool) {
+	switch inst.Op {
+	case x86asm.XOR, x86asm.SUB:
+		dst, ok := gpr32(inst.Args[0])
+		if !ok {
+			break
+		}
+		if src, ok := inst.Args[1].(x86asm.Reg); ok && src == dst {
+			return rewrite(inst, x86asm.MOV, dst, x86asm.Imm(0)), true
+		}
+		if imm, ok := inst.Args[1].(x86asm.Imm); ok && inst.Op == x86asm.SUB && dst == x86asm.ESP && imm < 0 {
+			return rewrite(inst, x86asm.ADD, dst, -imm), true
+		}
+	case x86asm.ADD:
+		if imm, ok := inst.Args[1].(x86asm.Imm); ok && inst.Args[0] == x86asm.ESP && imm < 0 {
+			return rewrite(inst, x86asm.SUB, x86asm.ESP, -imm), true
+		}
+	case x86asm.LEA:
+		dst, ok := gpr32(inst.Args[0])
+		if !ok || dst == x86asm.ESP || dst == x86asm.EBP {
+			break
+		}
+		mem, ok := inst.Args[1].(x86asm.Mem)
+		if !ok || mem.Segment != 0 || inst.AddrSize != 32 {
+			break
+		}
+		switch {
+		// lea r32, [r32b]
+		case mem.Index == 0 && mem.Disp == 0 && mem.Base != 0:
+			return rewrite(inst, x86asm.MOV, dst, mem.Base), true
+		// lea r32, [r32+disp]
+		case mem.Index == 0 && mem.Base == dst:
+			return rewrite(inst, x86asm.ADD, dst, x86asm.Imm(mem.Disp)), true
+		// lea r32, [r32+r32]
+		case mem.Disp == 0 && mem.Base == dst && mem.Index == dst && mem.Scale == 1:
+			return rewrite(inst, x86asm.ADD, dst, dst), true
+		// lea r32, [r32*2], lea r32, [r32*4] and lea r32, [r32*8]
+		case mem.Disp == 0 && mem.Base == 0 && mem.Index == dst:
+			switch mem.Scale {
+			case 2:
+				return rewrite(inst, x86asm.ADD, dst, dst), true
+			case 4:
+				return rewrite(inst, x86asm.SHL, dst, x86asm.Imm(2)), true
+			case 8:
+				return rewrite(inst, x86asm.SHL, dst, x86asm.Imm(3)), true
+			}
+		// lea r32, [r32+r32*N]
+		case mem.Disp == 0 && mem.Base == dst && mem.Index == dst:
+			canon := rewrite(inst, x86asm.IMUL, dst, dst)
+			canon.Args[2] = x86asm.Imm(mem.Scale + 1)
+			return canon, true
+		}
+	}
+	return nil, false
+}
+
+// rewrite returns a copy of the given instruction with the specified opcode and
+// operands.
+func rewrite(inst *x86.Inst, op x86asm.Op, dst, src x86asm.Arg) *x86.Inst {
+	canon := &x86.Inst{Addr: inst.Addr, Inst: inst.Inst}
+	canon.Op = op
+	canon.Args = x86asm.Args{dst, src}
+	canon.MemBytes = 0
+	return canon
+}
+
+// gpr32 returns the 32-bit general purpose register of the given argument, and
+// a boolean indicating whether the argument is such a register.
+func gpr32(arg x86asm.Arg) (x86asm.Reg, bool) {
+	reg, ok := arg.(x86asm.Reg)
+	if !ok || reg < x86asm.EAX || reg > x86asm.EDI {
+		return 0, false
+	}
+	return reg, true
+}
+
+// statusLive reports whether the status flags may be used by the instructions
+// of the basic block starting at index i before being redefined.
+func statusLive(bb *x86.BasicBlock, i int) bool {
+	for _, inst := range bb.Insts[i:] {
+		switch {
+		case usesStatus(inst.Op):
+			return true
+		case definesStatus(inst.Op):
+			return false
+		}
+	}
+	if bb.Term != nil {
+		switch bb.Term.Op {
+		case x86asm.RET, x86asm.LRET:
+			return false
+		}
+	}
+	// Conditional branches use the status flags, and other branches may
+	// continue into basic blocks which use them.
+	return true
+}
+
+// definesStatus reports whether the given instruction opcode defines all
+// arithmetic status flags (CF, PF, AF, ZF, SF and OF) regardless of its
+// operands, or leaves them undefined.
+func definesStatus(op x86asm.Op) bool {
+	return statusDefs(op) == allStatusFlags
+}
+
+// usesStatus reports whether the given instruction opcode may use the
+// arithmetic status flags.
+func usesStatus(op x86asm.Op) bool {
+	return statusUses(op) != 0
+}
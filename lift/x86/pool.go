@@ -0,0 +1,31 @@
+package x86
+
+import (
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/types"
+)
+
+// constKey is the key of an interned integer constant.
+type constKey struct {
+	// Integer type.
+	typ *types.IntType
+	// Integer value.
+	x int64
+}
+
+// constInt returns the integer constant of the given type and value. Integer
+// constants are immutable and interned per function; lifting large functions
+// would otherwise allocate a new constant for every immediate, displacement
+// and scale operand.
+func (f *Func) constInt(typ *types.IntType, x int64) *constant.Int {
+	key := constKey{typ: typ, x: x}
+	if c, ok := f.consts[key]; ok {
+		return c
+	}
+	if f.consts == nil {
+		f.consts = make(map[constKey]*constant.Int)
+	}
+	c := constant.NewInt(typ, x)
+	f.consts[key] = c
+	return c
+}
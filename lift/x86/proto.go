@@ -0,0 +1,601 @@
+package x86
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/bin/demangle"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/enum"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+	"github.com/mewkiz/pkg/osutil"
+	"github.com/pkg/errors"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// Function prototype database.
+//
+// The signatures of imported functions are located in a database of function
+// prototypes, which consists of the built-in Windows API prototypes (see
+// winapi.go) extended with the C prototype declarations of the user-provided
+// header bundle "winapi.h", if present. Prototypes of the header bundle take
+// precedence over built-in prototypes.
+//
+//    WINBASEAPI HANDLE WINAPI CreateFileA(
+//       _In_ LPCSTR lpFileName,
+//       _In_ DWORD dwDesiredAccess,
+//       ...
+//    );
+//
+// Preprocessor directives, type definitions and SAL annotations are ignored.
+// Declarations with the WINAPI, APIENTRY, CALLBACK, NTAPI, PASCAL or __stdcall
+// keywords use the stdcall calling convention; other declarations use the C
+// calling convention.
+
+// A Proto is a function prototype of the prototype database.
+type Proto struct {
+	// Function name.
+	Name string
+	// Return type.
+	RetType types.Type
+	// Function parameters.
+	Params []*ir.Param
+	// Variadic function.
+	Variadic bool
+	// Calling convention.
+	CallingConv enum.CallingConv
+}
+
+// NewFunc returns a new LLVM IR function declaration with the given name based
+// on the function prototype.
+func (p *Proto) NewFunc(name string) *ir.Function {
+	var params []*ir.Param
+	for _, param := range p.Params {
+		params = append(params, ir.NewParam(param.Name(), param.Typ))
+	}
+	f := ir.NewFunc(name, p.RetType, params...)
+	f.Sig.Variadic = p.Variadic
+	f.CallingConv = p.CallingConv
+	return f
+}
+
+// loadProtos loads the function prototype database, consisting of the built-in
+// Windows API prototypes and the prototypes of the user-provided header bundle.
+func (l *Lifter) loadProtos() error {
+	protos, err := LoadProtos(l.Mode)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	l.Protos = protos
+	return nil
+}
+
+// LoadProtos returns the function prototype database of the given CPU mode (32
+// or 64-bit execution), consisting of the built-in Windows API prototypes and
+// the prototypes of the user-provided header bundle "winapi.h", if present.
+func LoadProtos(mode int) (map[string]*Proto, error) {
+	protos, err := parseProtos(winapiProtos, mode)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse built-in Windows API prototypes")
+	}
+	hPath := "winapi.h"
+	if !osutil.Exists(hPath) {
+		return protos, nil
+	}
+	buf, err := ioutil.ReadFile(hPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	hProtos, err := parseProtos(string(buf), mode)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to parse header bundle %q", hPath)
+	}
+	dbg.Printf("loaded %d function prototypes from %q", len(hProtos), hPath)
+	for name, p := range hProtos {
+		protos[name] = p
+	}
+	return protos, nil
+}
+
+// DecoratedName returns the decorated symbol name of the function prototype in
+// the given CPU mode, as used by import libraries of 32-bit Windows; e.g.
+// "_CreateFileA@28" (stdcall), "@Foo@8" (fastcall) and "_printf" (C). Names are
+// not decorated in 64-bit mode.
+func (p *Proto) DecoratedName(mode int) string {
+	if mode != 32 {
+		return p.Name
+	}
+	var params []types.Type
+	for _, param := range p.Params {
+		params = append(params, param.Typ)
+	}
+	l := &Lifter{Disasm: &x86.Disasm{Mode: mode}}
+	switch p.CallingConv {
+	case enum.CallingConvX86StdCall:
+		return fmt.Sprintf("_%s@%d", p.Name, l.argSize(params))
+	case enum.CallingConvX86FastCall:
+		return fmt.Sprintf("@%s@%d", p.Name, l.argSize(params))
+	}
+	return "_" + p.Name
+}
+
+// lookupProto returns the function prototype of the given imported function.
+// Import names are undecorated (e.g. "__imp__ExitProcess@4" -> "ExitProcess")
+// before lookup, and the stack cleanup amount of stdcall decorations is
+// verified against the prototype. The prototypes of C++ functions not present
+// in the prototype database are recovered from their mangled names. The boolean
+// return value indicates success.
+func (l *Lifter) lookupProto(name string) (*Proto, bool) {
+	if demangle.IsMangled(name) {
+		if p, ok := l.Protos[name]; ok {
+			return p, true
+		}
+		sym, err := demangle.Demangle(name)
+		if err != nil {
+			warn.Printf("unable to recover prototype of %q; %v", name, err)
+			return nil, false
+		}
+		if !sym.Func {
+			return nil, false
+		}
+		return demangledProto(sym, l.Mode), true
+	}
+	undecorated := strings.TrimPrefix(name, "__imp_")
+	purge := int64(-1)
+	if pos := strings.LastIndex(undecorated, "@"); pos > 0 {
+		if n, err := strconv.ParseInt(undecorated[pos+1:], 10, 64); err == nil {
+			purge = n
+			undecorated = strings.TrimPrefix(undecorated[:pos], "_")
+		}
+	}
+	p, ok := l.Protos[undecorated]
+	if !ok {
+		return nil, false
+	}
+	if purge != -1 {
+		var params []types.Type
+		for _, param := range p.Params {
+			params = append(params, param.Typ)
+		}
+		if want := l.argSize(params); purge != want {
+			warn.Printf("stack cleanup amount of import %q (%d bytes) does not match prototype of %q (%d bytes)", name, purge, p.Name, want)
+		}
+	}
+	return p, true
+}
+
+// argSize returns the size in bytes of the given function parameter types when
+// passed on the stack.
+func (l *Lifter) argSize(params []types.Type) int64 {
+	slot := uint64(l.Mode / 8)
+	total := int64(0)
+	for _, param := range params {
+		size := l.sizeOfType(param)
+		total += int64((size + slot - 1) / slot * slot)
+	}
+	return total
+}
+
+// checkPurge verifies the stack cleanup of the caller following the given call
+// instruction against the calling convention of the callee.
+func (f *Func) checkPurge(inst *x86.Inst, sig *types.FuncType, callconv enum.CallingConv) {
+	if callconv != enum.CallingConvX86StdCall || len(sig.Params) == 0 {
+		return
+	}
+	next, err := f.l.DecodeInst(inst.Addr + bin.Address(inst.Len))
+	if err != nil {
+		return
+	}
+	if next.Op != x86asm.ADD || next.Args[0] != x86asm.ESP {
+		return
+	}
+	imm, ok := next.Args[1].(x86asm.Imm)
+	if !ok {
+		return
+	}
+	if int64(imm) == f.l.argSize(sig.Params) {
+		warn.Printf("caller cleans up %d bytes of stack after call at %v to stdcall function; calling convention mismatch", int64(imm), inst.Addr)
+	}
+}
+
+// checkRet verifies the stack cleanup of the given return instruction against
+// the calling convention of the function.
+func (f *Func) checkRet(term *x86.Inst) {
+	purge := int64(0)
+	if imm, ok := term.Args[0].(x86asm.Imm); ok {
+		purge = int64(imm)
+	}
+	want := int64(0)
+	callconv := f.l.callConv(f.CallingConv)
+	switch callconv {
+	case enum.CallingConvX86StdCall, enum.CallingConvX86FastCall, enum.CallingConvX86ThisCall:
+		want = f.l.argSize(stackParams(callconv, f.Sig.Params))
+	case enum.CallingConvC:
+	default:
+		return
+	}
+	if purge != want {
+		warn.Printf("return at %v of function %q cleans up %d bytes of stack; expected %d bytes based on calling convention %v", term.Addr, f.Name(), purge, want, callconv)
+	}
+}
+
+// popArg pops an argument of the given type from the stack, emitting code to f.
+func (f *Func) popArg(typ types.Type) value.Value {
+	v := value.Value(f.pop())
+	if f.l.sizeOfType(typ) > 4 {
+		// Arguments larger than 4 bytes occupy two stack slots; low dword first.
+		hi := f.pop()
+		lo := f.cur.NewZExt(v, types.I64)
+		x := f.cur.NewShl(f.cur.NewZExt(hi, types.I64), f.constInt(types.I64, 32))
+		v = f.cur.NewOr(lo, x)
+	}
+	return f.convert(v, typ)
+}
+
+// convert converts the given value to the specified type, emitting code to f.
+func (f *Func) convert(v value.Value, typ types.Type) value.Value {
+	from := v.Type()
+	if from.Equal(typ) {
+		return v
+	}
+	switch to := typ.(type) {
+	case *types.PointerType:
+		if types.IsPointer(from) {
+			return f.cur.NewBitCast(v, to)
+		}
+		return f.cur.NewIntToPtr(v, to)
+	case *types.IntType:
+		switch from := from.(type) {
+		case *types.PointerType:
+			return f.cur.NewPtrToInt(v, to)
+		case *types.IntType:
+			switch {
+			case from.BitSize > to.BitSize:
+				return f.cur.NewTrunc(v, to)
+			case from.BitSize < to.BitSize:
+				return f.cur.NewZExt(v, to)
+			}
+			return v
+		}
+	}
+	if f.l.sizeOfTypeInBits(from) == f.l.sizeOfTypeInBits(typ) {
+		return f.cur.NewBitCast(v, typ)
+	}
+	panic(fmt.Errorf("support for conversion from %v to %v not yet implemented", from, typ))
+}
+
+// ### [ Helper functions ] ####################################################
+
+var (
+	// reComment matches C comments.
+	reComment = regexp.MustCompile(`(?s)//[^\n]*|/\*.*?\*/`)
+	// rePreprocessor matches preprocessor directives.
+	rePreprocessor = regexp.MustCompile(`(?m)^[ \t]*#.*$`)
+	// reAttr matches declaration specifiers with arguments (e.g.
+	// __declspec(dllimport)) and SAL annotations with arguments (e.g.
+	// _In_reads_(n)).
+	reAttr = regexp.MustCompile(`\b(__declspec|__attribute__|_[A-Z][A-Za-z_]*_)\s*\((?:[^()]|\([^()]*\))*\)`)
+	// reIdent matches identifiers.
+	reIdent = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// Calling convention keywords.
+var callConvs = map[string]enum.CallingConv{
+	"WINAPI":     enum.CallingConvX86StdCall,
+	"APIENTRY":   enum.CallingConvX86StdCall,
+	"CALLBACK":   enum.CallingConvX86StdCall,
+	"NTAPI":      enum.CallingConvX86StdCall,
+	"PASCAL":     enum.CallingConvX86StdCall,
+	"__stdcall":  enum.CallingConvX86StdCall,
+	"_stdcall":   enum.CallingConvX86StdCall,
+	"WINAPIV":    enum.CallingConvC,
+	"CDECL":      enum.CallingConvC,
+	"__cdecl":    enum.CallingConvC,
+	"_cdecl":     enum.CallingConvC,
+	"__fastcall": enum.CallingConvX86FastCall,
+	"_fastcall":  enum.CallingConvX86FastCall,
+}
+
+// Keywords without bearing on the function signature.
+var ignoredKeywords = map[string]bool{
+	"extern":            true,
+	"static":            true,
+	"inline":            true,
+	"__inline":          true,
+	"const":             true,
+	"CONST":             true,
+	"volatile":          true,
+	"struct":            true,
+	"union":             true,
+	"enum":              true,
+	"signed":            true,
+	"unsigned":          true,
+	"WINBASEAPI":        true,
+	"WINUSERAPI":        true,
+	"WINGDIAPI":         true,
+	"WINADVAPI":         true,
+	"NTSYSAPI":          true,
+	"DECLSPEC_IMPORT":   true,
+	"DECLSPEC_NORETURN": true,
+	"_CRTIMP":           true,
+	"__out":             true,
+	"__in":              true,
+	"__inout":           true,
+	"__in_opt":          true,
+	"__out_opt":         true,
+	"__inout_opt":       true,
+	"IN":                true,
+	"OUT":               true,
+	"OPTIONAL":          true,
+}
+
+// demangledProto returns the function prototype of the given demangled C++
+// function, based on the CPU mode. Non-static member functions receive the this
+// pointer as their first parameter.
+func demangledProto(sym *demangle.Symbol, mode int) *Proto {
+	p := &Proto{
+		Name:        sym.String(),
+		RetType:     types.Void,
+		Variadic:    sym.Variadic,
+		CallingConv: enum.CallingConvC,
+	}
+	switch sym.CallConv {
+	case "__thiscall":
+		p.CallingConv = enum.CallingConvX86ThisCall
+	default:
+		if callconv, ok := callConvs[sym.CallConv]; ok {
+			p.CallingConv = callconv
+		}
+	}
+	if len(sym.RetType) > 0 {
+		p.RetType = cxxType(sym.RetType, mode)
+	}
+	if sym.Member {
+		p.Params = append(p.Params, ir.NewParam("this", types.NewPointer(types.I8)))
+	}
+	for _, param := range sym.Params {
+		p.Params = append(p.Params, ir.NewParam("", cxxType(param, mode)))
+	}
+	return p
+}
+
+// cxxType returns the LLVM IR type of the given demangled C++ type (e.g.
+// "char const*"), based on the CPU mode. References are passed as pointers.
+func cxxType(typ string, mode int) types.Type {
+	if strings.ContainsAny(typ, "*&(") {
+		// Pointers, references, function pointers and pointers to arrays.
+		typ = strings.Replace(typ, "&", "*", -1)
+		if strings.Contains(typ, "(") {
+			return types.NewPointer(types.I8)
+		}
+		return protoType(strings.Fields(strings.Replace(typ, "*", " * ", -1)), mode)
+	}
+	var fields []string
+	for _, field := range strings.Fields(typ) {
+		if field != "const" && field != "volatile" {
+			fields = append(fields, field)
+		}
+	}
+	switch name := strings.Join(fields, " "); name {
+	case "bool", "signed char", "unsigned char", "__int8", "unsigned __int8", "char8_t":
+		return types.I8
+	case "unsigned short", "__int16", "unsigned __int16", "char16_t":
+		return types.I16
+	case "long long", "unsigned long long", "unsigned __int64":
+		return types.I64
+	case "long double":
+		return types.X86FP80
+	default:
+		return baseType(name, mode)
+	}
+}
+
+// parseProtos parses the function prototype declarations of the given C source,
+// based on the CPU mode (32 or 64-bit execution).
+func parseProtos(src string, mode int) (map[string]*Proto, error) {
+	src = reComment.ReplaceAllString(src, " ")
+	src = rePreprocessor.ReplaceAllString(src, " ")
+	src = reAttr.ReplaceAllString(src, " ")
+	protos := make(map[string]*Proto)
+	for _, decl := range strings.Split(src, ";") {
+		decl = strings.TrimSpace(decl)
+		if strings.HasPrefix(decl, "typedef") || strings.ContainsAny(decl, "{}=") {
+			// skip type definitions, function definitions and variables.
+			continue
+		}
+		start := strings.Index(decl, "(")
+		end := strings.LastIndex(decl, ")")
+		if start == -1 || end < start {
+			continue
+		}
+		p, err := parseProto(decl[:start], decl[start+1:end], mode)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to parse prototype %q", decl)
+		}
+		protos[p.Name] = p
+	}
+	return protos, nil
+}
+
+// parseProto parses the function prototype with the given head (return type,
+// calling convention and name) and parameter list, based on the CPU mode.
+func parseProto(head, paramList string, mode int) (*Proto, error) {
+	p := &Proto{
+		CallingConv: enum.CallingConvC,
+	}
+	var fields []string
+	for _, field := range strings.Fields(strings.Replace(head, "*", " * ", -1)) {
+		if callconv, ok := callConvs[field]; ok {
+			p.CallingConv = callconv
+			continue
+		}
+		fields = append(fields, field)
+	}
+	if len(fields) < 2 || !reIdent.MatchString(fields[len(fields)-1]) {
+		return nil, errors.Errorf("unable to locate function name in %q", head)
+	}
+	p.Name = fields[len(fields)-1]
+	p.RetType = protoType(fields[:len(fields)-1], mode)
+	for _, param := range splitParams(paramList) {
+		switch param {
+		case "", "void", "VOID":
+			continue
+		case "...":
+			p.Variadic = true
+			p.CallingConv = enum.CallingConvC
+			continue
+		}
+		name, typ := parseParam(param, mode)
+		p.Params = append(p.Params, ir.NewParam(name, typ))
+	}
+	return p, nil
+}
+
+// parseParam parses the given parameter declaration, based on the CPU mode.
+func parseParam(param string, mode int) (name string, typ types.Type) {
+	if strings.Contains(param, "(") {
+		// function pointer parameter.
+		return "", types.NewPointer(types.I8)
+	}
+	array := false
+	if pos := strings.Index(param, "["); pos != -1 {
+		// array parameters decay to pointers.
+		param = param[:pos]
+		array = true
+	}
+	var tokens []string
+	for _, field := range strings.Fields(strings.Replace(param, "*", " * ", -1)) {
+		if !ignoredKeywords[field] && !isSAL(field) {
+			tokens = append(tokens, field)
+		}
+	}
+	if len(tokens) > 1 {
+		if last := tokens[len(tokens)-1]; last != "*" && !isBaseType(last) {
+			name = last
+			tokens = tokens[:len(tokens)-1]
+		}
+	}
+	if array {
+		tokens = append(tokens, "*")
+	}
+	return name, protoType(tokens, mode)
+}
+
+// protoType returns the LLVM IR type of the given C type tokens, based on the
+// CPU mode.
+func protoType(tokens []string, mode int) types.Type {
+	var base []string
+	ptrs := 0
+	for _, tok := range tokens {
+		switch {
+		case tok == "*":
+			ptrs++
+		case ignoredKeywords[tok] || isSAL(tok):
+			// skip.
+		default:
+			base = append(base, tok)
+		}
+	}
+	name := strings.Join(base, " ")
+	var typ types.Type
+	if ptrs > 0 {
+		switch name {
+		case "char", "CHAR", "":
+			typ = types.I8
+		case "wchar_t", "WCHAR":
+			typ = types.I16
+		default:
+			typ = types.I8
+		}
+		typ = types.NewPointer(typ)
+		for i := 1; i < ptrs; i++ {
+			typ = types.NewPointer(typ)
+		}
+		return typ
+	}
+	return baseType(name, mode)
+}
+
+// baseType returns the LLVM IR type of the given C type name, based on the CPU
+// mode.
+func baseType(name string, mode int) types.Type {
+	switch name {
+	case "void", "VOID":
+		return types.Void
+	case "char", "CHAR", "UCHAR", "BYTE", "BOOLEAN", "INT8", "UINT8":
+		return types.I8
+	case "short", "short int", "SHORT", "USHORT", "WORD", "WCHAR", "wchar_t", "ATOM", "LANGID", "INT16", "UINT16":
+		return types.I16
+	case "long long", "__int64", "LONGLONG", "ULONGLONG", "DWORD64", "INT64", "UINT64", "LARGE_INTEGER", "ULARGE_INTEGER":
+		return types.I64
+	case "HRESULT", "HFILE":
+		return types.I32
+	case "float", "FLOAT":
+		return types.Float
+	case "double", "DOUBLE":
+		return types.Double
+	case "size_t", "ssize_t", "ptrdiff_t", "intptr_t", "uintptr_t", "SIZE_T", "SSIZE_T", "ULONG_PTR", "LONG_PTR", "UINT_PTR", "INT_PTR", "DWORD_PTR", "WPARAM", "LPARAM", "LRESULT":
+		return types.NewInt(uint64(mode))
+	case "LPSTR", "LPCSTR", "PSTR", "PCSTR", "PCHAR", "LPCH", "PCH":
+		return types.NewPointer(types.I8)
+	case "LPWSTR", "LPCWSTR", "PWSTR", "PCWSTR", "PWCHAR", "LPWCH", "PWCH", "BSTR":
+		return types.NewPointer(types.I16)
+	}
+	if isTypeName(name) && (strings.HasPrefix(name, "H") || strings.HasPrefix(name, "LP") || strings.HasPrefix(name, "P")) {
+		// handle and pointer types (e.g. HANDLE, HWND, LPVOID, PDWORD).
+		return types.NewPointer(types.I8)
+	}
+	if strings.HasSuffix(name, "PROC") || strings.HasSuffix(name, "ROUTINE") {
+		// function pointer types (e.g. FARPROC, WNDPROC).
+		return types.NewPointer(types.I8)
+	}
+	// int, long, BOOL, DWORD, UINT, HRESULT and other 32-bit integer types.
+	return types.I32
+}
+
+// splitParams splits the given parameter list into parameter declarations.
+func splitParams(paramList string) []string {
+	var params []string
+	depth, start := 0, 0
+	for i, r := range paramList {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				params = append(params, strings.TrimSpace(paramList[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(params, strings.TrimSpace(paramList[start:]))
+}
+
+// isSAL reports whether the given token is a source-code annotation language
+// (SAL) annotation (e.g. _In_, _Out_opt_).
+func isSAL(tok string) bool {
+	return len(tok) > 2 && strings.HasPrefix(tok, "_") && strings.HasSuffix(tok, "_") && !strings.HasPrefix(tok, "__")
+}
+
+// isBaseType reports whether the given token is a C base type keyword.
+func isBaseType(tok string) bool {
+	switch tok {
+	case "void", "char", "short", "int", "long", "float", "double", "wchar_t", "size_t", "__int64":
+		return true
+	}
+	return false
+}
+
+// isTypeName reports whether the given identifier follows the naming convention
+// of Windows API type names (i.e. upper case letters, digits and underscores).
+func isTypeName(name string) bool {
+	return reIdent.MatchString(name) && strings.ToUpper(name) == name
+}
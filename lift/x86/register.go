@@ -5,6 +5,7 @@ import (
 
 	"github.com/decomp/exp/disasm/x86"
 	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
 	"golang.org/x/arch/x86/x86asm"
 )
 
@@ -74,3 +75,87 @@ func regType(reg x86asm.Reg) types.Type {
 		panic(fmt.Errorf("support for register %v not yet implemented", reg))
 	}
 }
+
+// Register aliasing.
+//
+// The sub-registers of each general-purpose register alias the bits of the
+// full register, which is modelled as a single local variable of the width of
+// the general-purpose registers of the machine mode; e.g. AL, AH, AX and EAX
+// alias EAX in 32-bit mode, and RAX in 64-bit mode.
+//
+//    RAX    bits 0-63
+//    EAX    bits 0-31
+//    AX     bits 0-15
+//    AH     bits 8-15
+//    AL     bits 0-7
+//
+// Reads of sub-registers extract their bits from the full register, and writes
+// insert their bits into the full register, leaving the remaining bits intact.
+// As on hardware, writes to 32-bit registers in 64-bit mode instead clear the
+// upper 32 bits of the full register.
+
+// subReg returns the full register aliased by the given general-purpose
+// register in the given machine mode, and the offset in bits of the register
+// within the full register. The boolean return value indicates whether reg is a
+// sub-register.
+func subReg(reg x86asm.Reg, mode int) (full x86asm.Reg, offset uint64, ok bool) {
+	// Index of full register; in the order AX, CX, DX, BX, SP, BP, SI, DI, R8,
+	// ..., R15.
+	var index x86asm.Reg
+	switch {
+	case x86asm.AL <= reg && reg <= x86asm.BL:
+		index = reg - x86asm.AL
+	case x86asm.AH <= reg && reg <= x86asm.BH:
+		index, offset = reg-x86asm.AH, 8
+	case x86asm.SPB <= reg && reg <= x86asm.R15B:
+		index = reg - x86asm.SPB + 4
+	case x86asm.AX <= reg && reg <= x86asm.R15W:
+		index = reg - x86asm.AX
+	case x86asm.EAX <= reg && reg <= x86asm.R15L:
+		index = reg - x86asm.EAX
+	case x86asm.RAX <= reg && reg <= x86asm.R15:
+		index = reg - x86asm.RAX
+	default:
+		return 0, 0, false
+	}
+	full = x86asm.EAX + index
+	if mode == 64 {
+		full = x86asm.RAX + index
+	}
+	if full == reg {
+		return 0, 0, false
+	}
+	return full, offset, true
+}
+
+// useSubReg loads and returns the value of the given sub-register from the
+// full register aliased by it, emitting code to f.
+func (f *Func) useSubReg(reg, full x86asm.Reg, offset uint64) value.Named {
+	var v value.Named = f.cur.NewLoad(f.reg(full))
+	if offset > 0 {
+		v = f.cur.NewLShr(v, f.constInt(regType(full).(*types.IntType), int64(offset)))
+	}
+	return f.cur.NewTrunc(v, regType(reg))
+}
+
+// defSubReg stores the value to the given sub-register, by inserting its bits
+// into the full register aliased by it, emitting code to f.
+func (f *Func) defSubReg(reg, full x86asm.Reg, offset uint64, v value.Value) {
+	dst := f.reg(full)
+	fullType := regType(full).(*types.IntType)
+	size := regType(reg).(*types.IntType).BitSize
+	if size == 32 && fullType.BitSize == 64 {
+		// Writes to 32-bit registers zero-extend into 64-bit registers.
+		f.cur.NewStore(f.cur.NewZExt(v, fullType), dst)
+		return
+	}
+	// full = (full &^ (mask << offset)) | (zext(v) << offset)
+	mask := ^(((uint64(1) << size) - 1) << offset)
+	old := f.cur.NewLoad(dst)
+	var bits value.Value = f.cur.NewZExt(v, fullType)
+	if offset > 0 {
+		bits = f.cur.NewShl(bits, f.constInt(fullType, int64(offset)))
+	}
+	rest := f.cur.NewAnd(old, f.constInt(fullType, int64(mask)))
+	f.cur.NewStore(f.cur.NewOr(rest, bits), dst)
+}
@@ -0,0 +1,91 @@
+package x86
+
+import (
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+)
+
+// Runtime support functions.
+//
+// Instructions which access processor state not modelled by the lifter are
+// lifted to calls to runtime support functions, as are functions replaced by
+// stubs. A C implementation of the runtime support functions is provided in
+// runtime/runtime.c, which is linked with the lifted code when recompiling.
+//
+//    clang -o foo foo.ll lift/x86/runtime/runtime.c
+//
+// The runtime support functions are:
+//
+//    segment_base      base address of segment (FS and GS); see segment.go
+//    __trace           record execution of basic block; see trace.go
+//    x86_cpuid         processor identification (CPUID)
+//    x86_fnstenv       store x87 FPU environment (FNSTENV)
+//    x86_fldenv        load x87 FPU environment (FLDENV)
+//    __unsupported     report execution of function not lifted
+
+// Names of runtime support functions.
+const (
+	// void x86_cpuid(i32 leaf, i32 subleaf, i32* regs)
+	cpuidFunc = "x86_cpuid"
+	// void x86_fnstenv(i8* env, i16 cw, i32 size)
+	fnstenvFunc = "x86_fnstenv"
+	// i16 x86_fldenv(i8* env)
+	fldenvFunc = "x86_fldenv"
+	// void __unsupported(i64 addr)
+	unsupportedFunc = "__unsupported"
+)
+
+// newRuntimeFuncs returns the declarations of the runtime support functions
+// used to lift processor identification and x87 FPU environment instructions,
+// and stubs, mapping from function name to function declaration.
+func newRuntimeFuncs() map[string]*ir.Function {
+	cpuid := ir.NewFunc(cpuidFunc, types.Void, ir.NewParam("leaf", types.I32), ir.NewParam("subleaf", types.I32), ir.NewParam("regs", types.NewPointer(types.I32)))
+	fnstenv := ir.NewFunc(fnstenvFunc, types.Void, ir.NewParam("env", types.I8Ptr), ir.NewParam("cw", types.I16), ir.NewParam("size", types.I32))
+	fldenv := ir.NewFunc(fldenvFunc, types.I16, ir.NewParam("env", types.I8Ptr))
+	unsupported := ir.NewFunc(unsupportedFunc, types.Void, ir.NewParam("addr", types.I64))
+	return map[string]*ir.Function{
+		cpuidFunc:       cpuid,
+		fnstenvFunc:     fnstenv,
+		fldenvFunc:      fldenv,
+		unsupportedFunc: unsupported,
+	}
+}
+
+// cpuid lifts an x86 CPUID instruction to a call to the x86_cpuid runtime
+// support function, emitting code to f. The leaf and subleaf are passed in EAX
+// and ECX, and the results are stored to EAX, EBX, ECX and EDX.
+func (f *Func) cpuid() {
+	const name = "cpuid"
+	regs, ok := f.locals[name]
+	if !ok {
+		regs = ir.NewAlloca(types.NewArray(4, types.I32))
+		regs.SetName(name)
+		f.locals[name] = regs
+	}
+	leaf := f.useRegElem(x86.EAX, types.I32)
+	subleaf := f.useRegElem(x86.ECX, types.I32)
+	zero := f.constInt(types.I32, 0)
+	ptr := f.cur.NewGetElementPtr(regs, zero, zero)
+	f.cur.NewCall(f.l.intrinsics[cpuidFunc], leaf, subleaf, ptr)
+	for i, reg := range []*x86.Reg{x86.EAX, x86.EBX, x86.ECX, x86.EDX} {
+		elem := f.cur.NewGetElementPtr(regs, zero, f.constInt(types.I32, int64(i)))
+		f.defRegElem(reg, f.cur.NewLoad(elem), types.I32)
+	}
+}
+
+// fpuEnv returns an i8* pointer to the x87 FPU environment of the given memory
+// argument, and the size in bytes of the environment, emitting code to f. The
+// environment is 14 bytes with 16-bit operand size, and 28 bytes otherwise.
+func (f *Func) fpuEnv(inst *x86.Inst) (value.Value, int64) {
+	env := f.mem(inst.Mem(0))
+	if !types.I8Ptr.Equal(env.Type()) {
+		env = f.cur.NewBitCast(env, types.I8Ptr)
+	}
+	size := int64(28)
+	if inst.DataSize == 16 {
+		size = 14
+	}
+	return env, size
+}
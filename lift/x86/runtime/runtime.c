@@ -0,0 +1,112 @@
+// Runtime support functions of lifted code.
+//
+// The x86 to LLVM IR lifter emits calls to the following functions for
+// processor state not modelled in LLVM IR. Link this file with the lifted code
+// when recompiling, e.g.
+//
+//    clang -o foo foo.ll runtime.c
+//
+// Compile for the same processor mode as the lifted binary executable (e.g.
+// -m32 for 32-bit executables), as segment_base returns a pointer-sized
+// address.
+
+#include <stdint.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+#if defined(_WIN32)
+#include <windows.h>
+#endif
+
+#if defined(__GNUC__) || defined(__clang__)
+#include <cpuid.h>
+#elif defined(_MSC_VER)
+#include <intrin.h>
+#endif
+
+// segment_base returns the base address of the segment referenced by the given
+// segment selector; i.e. the thread information block (FS on 32-bit and GS on
+// 64-bit Windows), or the thread control block (GS on 32-bit and FS on 64-bit
+// Linux). The selector is ignored, as lifted code only accesses the segment
+// used for thread-local data of the platform.
+uintptr_t segment_base(uint16_t selector) {
+	(void)selector;
+#if defined(_WIN32)
+	return (uintptr_t)NtCurrentTeb();
+#elif defined(__x86_64__)
+	uintptr_t base;
+	// The thread control block starts with a pointer to itself.
+	__asm__("movq %%fs:0, %0" : "=r"(base));
+	return base;
+#elif defined(__i386__)
+	uintptr_t base;
+	__asm__("movl %%gs:0, %0" : "=r"(base));
+	return base;
+#else
+#error "segment_base: unsupported platform"
+#endif
+}
+
+// __trace records the execution of the basic block at the given address of the
+// original binary executable (see bin2ll -instrument trace). Addresses are
+// written to the file specified by the LIFT_TRACE environment variable, or to
+// standard error, one hexadecimal address per line as read by tracediff.
+void __trace(uint64_t addr) {
+	static FILE *w;
+	if (w == NULL) {
+		const char *path = getenv("LIFT_TRACE");
+		if (path != NULL) {
+			w = fopen(path, "w");
+		}
+		if (w == NULL) {
+			w = stderr;
+		}
+	}
+	fprintf(w, "%llx\n", (unsigned long long)addr);
+}
+
+// x86_cpuid stores the processor identification information of the given leaf
+// and subleaf to regs, in order EAX, EBX, ECX and EDX.
+void x86_cpuid(uint32_t leaf, uint32_t subleaf, uint32_t regs[4]) {
+#if defined(__GNUC__) || defined(__clang__)
+	__cpuid_count(leaf, subleaf, regs[0], regs[1], regs[2], regs[3]);
+#elif defined(_MSC_VER)
+	__cpuidex((int *)regs, (int)leaf, (int)subleaf);
+#else
+	memset(regs, 0, 4 * sizeof(regs[0]));
+#endif
+}
+
+// x86_fnstenv stores the x87 FPU environment to env, in the 28-byte protected
+// mode format, or the 14-byte format if size is 14. As the FPU register stack
+// is held in LLVM IR values of the lifted code, only the control word is
+// preserved; the status word is clear and all registers are tagged as empty.
+void x86_fnstenv(uint8_t *env, uint16_t cw, uint32_t size) {
+	// Offsets of the control word, status word and tag word.
+	const int step = size == 14 ? 2 : 4;
+	memset(env, 0, size);
+	memcpy(&env[0*step], &cw, 2);
+	const uint16_t tw = 0xFFFF;
+	memcpy(&env[2*step], &tw, 2);
+}
+
+// x86_fldenv loads the x87 FPU environment from env, and returns its control
+// word.
+uint16_t x86_fldenv(const uint8_t *env) {
+	uint16_t cw;
+	memcpy(&cw, &env[0], 2);
+	return cw;
+}
+
+// __unsupported reports the execution of a function which was replaced by a
+// stub, as it failed to lift (e.g. due to an unsupported instruction at addr)
+// or exceeded the resource budget of the lifter. Execution is aborted, unless
+// the LIFT_CONTINUE environment variable is set, in which case the stub returns
+// the zero value of its return type.
+void __unsupported(uint64_t addr) {
+	fprintf(stderr, "lifted code: executed stub of function not lifted (at 0x%llX)\n", (unsigned long long)addr);
+	if (getenv("LIFT_CONTINUE") == NULL) {
+		abort();
+	}
+}
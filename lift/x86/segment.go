@@ -0,0 +1,113 @@
+package x86
+
+import (
+	"fmt"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// Segmented memory references.
+//
+// In 32- and 64-bit mode, a flat memory model is assumed; the base of the CS,
+// DS, ES and SS segments is zero, and references through these segments are
+// lifted as regular memory references. The base of the FS and GS segments is
+// retrieved from the descriptor table at runtime, through the segment_base
+// support function.
+//
+//    mov eax, [fs:0x18]
+//
+//    %fs = load i16, i16* %fs
+//    %1 = call i32 @segment_base(i16 %fs)
+//    %2 = add i32 %1, 24
+//    %3 = inttoptr i32 %2 to i8*
+//
+// In 16-bit mode, real mode addressing is used for segment overrides of all
+// segment registers; the linear address is selector*16 + offset.
+
+// Name of runtime support function which returns the base address of the
+// segment descriptor referenced by a given segment selector.
+const segmentBaseFunc = "segment_base"
+
+// segmentBase returns the base address of the segment of the given memory
+// reference, emitting code to f. The boolean return value indicates whether the
+// segment is non-flat.
+func (f *Func) segmentBase(mem *x86.Mem) (value.Value, bool) {
+	if f.mode == 16 {
+		// Real mode addressing.
+		sel := f.useReg(mem.Segment())
+		base := f.cur.NewZExt(sel, types.I32)
+		return f.cur.NewShl(base, f.constInt(types.I32, 4)), true
+	}
+	switch mem.Mem.Segment {
+	case x86asm.CS, x86asm.DS, x86asm.ES, x86asm.SS:
+		// Flat memory model.
+		return nil, false
+	case x86asm.FS, x86asm.GS:
+		sel := f.useReg(mem.Segment())
+		callee := f.l.intrinsics[segmentBaseFunc]
+		return f.cur.NewCall(callee, sel), true
+	default:
+		panic(fmt.Errorf("support for segment register %v not yet implemented", mem.Mem.Segment))
+	}
+}
+
+// segmentMem returns a pointer to the given memory reference relative to the
+// specified segment base address, emitting code to f.
+func (f *Func) segmentMem(mem *x86.Mem, segBase value.Value) value.Value {
+	// Segment:[Base+Scale*Index+Disp].
+	typ := segBase.Type()
+	addr := segBase
+	if mem.Parent != nil && mem.Parent.AddrSize == 16 {
+		// 16-bit offsets wrap around within the segment.
+		off := f.convert(f.offset16(mem), typ)
+		addr = f.cur.NewAdd(addr, off)
+		src := f.cur.NewIntToPtr(addr, types.I8Ptr)
+		return f.castToPtr(src, mem.Parent)
+	}
+	switch mem.Mem.Base {
+	case 0:
+		// no base register.
+	case x86asm.IP, x86asm.EIP, x86asm.RIP:
+		next := mem.Parent.Addr + bin.Address(mem.Parent.Len)
+		addr = f.cur.NewAdd(addr, f.constInt(typ.(*types.IntType), int64(next)))
+	default:
+		base := f.convert(f.useReg(mem.Base()), typ)
+		addr = f.cur.NewAdd(addr, base)
+	}
+	if mem.Mem.Index != 0 {
+		index := f.convert(f.useReg(mem.Index()), typ)
+		if mem.Scale > 1 {
+			index = f.cur.NewMul(index, f.constInt(typ.(*types.IntType), int64(mem.Scale)))
+		}
+		addr = f.cur.NewAdd(addr, index)
+	}
+	if mem.Disp != 0 {
+		addr = f.cur.NewAdd(addr, f.constInt(typ.(*types.IntType), mem.Disp))
+	}
+	src := f.cur.NewIntToPtr(addr, types.I8Ptr)
+	return f.castToPtr(src, mem.Parent)
+}
+
+// liftFarPointer lifts the given x86 LDS, LES, LFS, LGS or LSS instruction to
+// LLVM IR, emitting code to f. The far pointer (m16:16 or m16:32) of the second
+// argument is loaded into the first argument (offset) and the given segment
+// register (selector).
+func (f *Func) liftFarPointer(inst *x86.Inst, seg *x86.Reg) error {
+	reg, ok := inst.Args[0].(x86asm.Reg)
+	if !ok {
+		panic(fmt.Errorf("invalid first argument of %v instruction at address %v; expected x86asm.Reg, got %T", inst.Op, inst.Addr, inst.Args[0]))
+	}
+	offType := regType(reg).(*types.IntType)
+	m := inst.Mem(1)
+	off := f.useMemElem(m, offType)
+	selMem := m.Mem
+	selMem.Disp += int64(offType.BitSize / 8)
+	sel := f.useMemElem(x86.NewMem(selMem, inst), types.I16)
+	f.defArg(inst.Arg(0), off)
+	f.defReg(seg, sel)
+	return nil
+}
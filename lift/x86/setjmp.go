@@ -0,0 +1,189 @@
+package x86
+
+import (
+	"strings"
+
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/enum"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+)
+
+// Non-local control flow through setjmp and longjmp.
+//
+// Calls to setjmp and longjmp are lifted to the SjLj exception handling
+// intrinsics of LLVM, annotated with the returns_twice and noreturn function
+// attributes respectively, so that optimization passes do not assume that
+// control returns from setjmp only once, or returns from longjmp at all.
+//
+//    push eax                   ; jmp_buf
+//    call _setjmp3
+//
+//    %1 = call i32 @llvm.eh.sjlj.setjmp(i8* %buf) returns_twice
+//
+// Note, llvm.eh.sjlj.setjmp returns 1 when resumed through llvm.eh.sjlj.longjmp,
+// thus the value passed to longjmp is not preserved.
+//
+// Calls to MSVC's __EH_prolog helper are replaced by the frame setup performed
+// by the helper, which registers the exception handler of the function (passed
+// in EAX) in the SEH chain.
+//
+//    push ebp
+//    mov ebp, esp
+//    push -1
+//    push eax                   ; exception handler
+//    push dword [fs:0]          ; previous SEH record
+//    mov [fs:0], esp
+
+// sjljKind specifies the kind of a setjmp/longjmp related function.
+type sjljKind uint
+
+// setjmp/longjmp related functions.
+const (
+	// Not related to setjmp or longjmp.
+	sjljNone sjljKind = iota
+	// setjmp(jmp_buf env).
+	sjljSetjmp
+	// longjmp(jmp_buf env, int val).
+	sjljLongjmp
+	// MSVC's __EH_prolog helper.
+	sjljEHProlog
+)
+
+// sjljFuncs maps from undecorated function name to setjmp/longjmp related
+// function kind.
+var sjljFuncs = map[string]sjljKind{
+	"setjmp":             sjljSetjmp,
+	"_setjmp":            sjljSetjmp,
+	"_setjmp3":           sjljSetjmp,
+	"_setjmpex":          sjljSetjmp,
+	"__intrinsic_setjmp": sjljSetjmp,
+	"sigsetjmp":          sjljSetjmp,
+	"__sigsetjmp":        sjljSetjmp,
+	"longjmp":            sjljLongjmp,
+	"_longjmp":           sjljLongjmp,
+	"siglongjmp":         sjljLongjmp,
+	"__EH_prolog":        sjljEHProlog,
+	"_EH_prolog":         sjljEHProlog,
+}
+
+// Names of LLVM SjLj intrinsics.
+const (
+	intrinsicSetjmp  = "llvm.eh.sjlj.setjmp"
+	intrinsicLongjmp = "llvm.eh.sjlj.longjmp"
+)
+
+// newIntrinsics returns the declarations of the LLVM intrinsics and runtime
+// support functions used by the lifter in the given processor mode, mapping
+// from function name to function declaration.
+func newIntrinsics(mode int) map[string]*ir.Function {
+	setjmp := ir.NewFunc(intrinsicSetjmp, types.I32, ir.NewParam("buf", types.I8Ptr))
+	setjmp.FuncAttrs = append(setjmp.FuncAttrs, enum.FuncAttrReturnsTwice)
+	longjmp := ir.NewFunc(intrinsicLongjmp, types.Void, ir.NewParam("buf", types.I8Ptr))
+	longjmp.FuncAttrs = append(longjmp.FuncAttrs, enum.FuncAttrNoReturn)
+	segmentBase := ir.NewFunc(segmentBaseFunc, types.NewInt(uint64(mode)), ir.NewParam("selector", types.I16))
+	trap := ir.NewFunc(intrinsicTrap, types.Void)
+	trap.FuncAttrs = append(trap.FuncAttrs, enum.FuncAttrNoReturn)
+	ctpop := ir.NewFunc(intrinsicCtpop, types.I8, ir.NewParam("x", types.I8))
+	ctpop.FuncAttrs = append(ctpop.FuncAttrs, enum.FuncAttrReadNone)
+	trace := ir.NewFunc(traceFunc, types.Void, ir.NewParam("addr", types.I64))
+	intrinsics := map[string]*ir.Function{
+		intrinsicSetjmp:  setjmp,
+		intrinsicLongjmp: longjmp,
+		segmentBaseFunc:  segmentBase,
+		intrinsicTrap:    trap,
+		intrinsicCtpop:   ctpop,
+		traceFunc:        trace,
+	}
+	for name, fn := range newRoundIntrinsics() {
+		intrinsics[name] = fn
+	}
+	for name, fn := range newRuntimeFuncs() {
+		intrinsics[name] = fn
+	}
+	return intrinsics
+}
+
+// sjljKindOf returns the setjmp/longjmp related function kind of the given
+// callee, as identified by its import name or by the returns_twice attribute of
+// its function signature (e.g. as specified in info.ll).
+func sjljKindOf(callee value.Value) sjljKind {
+	fn, ok := callee.(*ir.Function)
+	if !ok {
+		return sjljNone
+	}
+	name := fn.Name()
+	for _, prefix := range []string{"__imp_", "_imp_"} {
+		name = strings.TrimPrefix(name, prefix)
+	}
+	if kind, ok := sjljFuncs[name]; ok {
+		return kind
+	}
+	for _, attr := range fn.FuncAttrs {
+		if attr == enum.FuncAttrReturnsTwice {
+			return sjljSetjmp
+		}
+	}
+	return sjljNone
+}
+
+// liftSetjmp lifts the given call to setjmp to LLVM IR, emitting code to f.
+func (f *Func) liftSetjmp(inst *x86.Inst) error {
+	// Additional arguments of _setjmp3 are left on the stack; cdecl caller
+	// purge.
+	buf := f.popArg(types.I8Ptr)
+	callee := f.l.intrinsics[intrinsicSetjmp]
+	result := f.cur.NewCall(callee, buf)
+	result.FuncAttrs = append(result.FuncAttrs, enum.FuncAttrReturnsTwice)
+	dbg.Printf("setjmp call at %v of %q", inst.Addr, f.Name())
+	f.defReg(x86.EAX, result)
+	return nil
+}
+
+// liftLongjmp lifts the given call to longjmp to LLVM IR, emitting code to f.
+func (f *Func) liftLongjmp(inst *x86.Inst) error {
+	buf := f.popArg(types.I8Ptr)
+	// The value passed to longjmp is not preserved by llvm.eh.sjlj.longjmp.
+	f.pop()
+	callee := f.l.intrinsics[intrinsicLongjmp]
+	result := f.cur.NewCall(callee, buf)
+	result.FuncAttrs = append(result.FuncAttrs, enum.FuncAttrNoReturn)
+	dbg.Printf("longjmp call at %v of %q", inst.Addr, f.Name())
+	// Control never returns from longjmp; lift any remaining instructions of the
+	// basic block into an unreachable basic block.
+	f.cur.NewUnreachable()
+	dead := &ir.BasicBlock{}
+	f.Blocks = append(f.Blocks, dead)
+	f.cur = dead
+	return nil
+}
+
+// liftEHProlog lifts the given call to __EH_prolog to LLVM IR, emitting code to
+// f.
+func (f *Func) liftEHProlog(inst *x86.Inst) error {
+	dbg.Printf("__EH_prolog call at %v of %q", inst.Addr, f.Name())
+	f.push(f.useReg(x86.EBP))
+	f.defReg(x86.EBP, f.useReg(x86.ESP))
+	f.push(f.constInt(types.I32, -1))
+	f.push(f.useReg(x86.EAX))
+	// TODO: Model the SEH chain (fs:[0]); push a null previous SEH record for
+	// now.
+	f.push(f.constInt(types.I32, 0))
+	return nil
+}
+
+// usesCallee reports whether any of the given functions calls the specified
+// callee.
+func usesCallee(funcs []*ir.Function, callee *ir.Function) bool {
+	for _, f := range funcs {
+		for _, block := range f.Blocks {
+			for _, inst := range block.Insts {
+				if call, ok := inst.(*ir.InstCall); ok && call.Callee == callee {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,355 @@
+package x86
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/x86"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// Struct layout recovery.
+//
+// Memory accesses at constant offsets from the same base pointer are clustered
+// into struct definitions. A base pointer is identified by the register
+// holding it and the instruction defining the register value; registers used
+// but never written within a function (e.g. the this pointer passed in ECX)
+// hold incoming values defined at the function entry. Incoming register values
+// of callees are unified with the register values of the caller at each call
+// site, so that accesses of a struct passed between functions contribute to the
+// same struct definition.
+//
+//    mov eax, [ecx+4]         ; this->field_4
+//    mov [ecx+8], eax         ; this->field_8 = this->field_4
+//       -> %struct_401000 = type { [4 x i8], i32, i32 }
+//
+// Accesses of recovered structs are lifted as getelementptr instructions into
+// the corresponding named struct types.
+
+// A structField is a field access of a recovered struct.
+type structField struct {
+	// Recovered struct type.
+	typ *types.StructType
+	// Byte offset of the field within the struct.
+	offset int64
+	// Field index within the struct.
+	index int64
+}
+
+// A baseValue identifies a base pointer value, as held by the given register
+// family after the instruction defining it.
+type baseValue struct {
+	// Address of the function containing the definition.
+	funcAddr bin.Address
+	// Register family of the base register.
+	fam int
+	// Address of the instruction defining the register value; or 0 for
+	// incoming register values.
+	defAddr bin.Address
+}
+
+// A fieldAccess is a memory access at a constant offset from a base pointer.
+type fieldAccess struct {
+	// Address of the accessing instruction.
+	instAddr bin.Address
+	// Byte offset from the base pointer.
+	offset int64
+	// Size in bytes of the access.
+	size int
+}
+
+// RecoverStructs recovers struct layouts from the memory access patterns of
+// the functions of the lifter, and records the recovered struct types as type
+// definitions of the lifter.
+func (l *Lifter) RecoverStructs() {
+	// Union-find of base pointer values.
+	parent := make(map[baseValue]baseValue)
+	var find func(v baseValue) baseValue
+	find = func(v baseValue) baseValue {
+		p, ok := parent[v]
+		if !ok || p == v {
+			return v
+		}
+		root := find(p)
+		parent[v] = root
+		return root
+	}
+	union := func(a, b baseValue) {
+		ra, rb := find(a), find(b)
+		if ra != rb {
+			parent[ra] = rb
+		}
+	}
+	accesses := make(map[baseValue][]fieldAccess)
+	var funcAddrs bin.Addresses
+	for funcAddr, f := range l.Funcs {
+		if f.AsmFunc != nil {
+			funcAddrs = append(funcAddrs, funcAddr)
+		}
+	}
+	sort.Sort(funcAddrs)
+	// Incoming register values used by each function.
+	incoming := make(map[bin.Address]map[int]bool)
+	for _, funcAddr := range funcAddrs {
+		incoming[funcAddr] = l.collectAccesses(l.Funcs[funcAddr].AsmFunc, accesses)
+	}
+	// Unify register values at call sites (including tail calls) with incoming
+	// register values of callees.
+	for _, funcAddr := range funcAddrs {
+		asmFunc := l.Funcs[funcAddr].AsmFunc
+		written := l.writtenRegs(asmFunc)
+		l.walkBlocks(asmFunc, func(inst *x86.Inst, defs map[int]bin.Address) {
+			if inst.Op != x86asm.CALL && inst.Op != x86asm.JMP {
+				return
+			}
+			rel, ok := inst.Args[0].(x86asm.Rel)
+			if !ok {
+				return
+			}
+			callee := inst.Addr + bin.Address(inst.Len) + bin.Address(rel)
+			if inst.Op == x86asm.JMP && !l.IsFunc(callee) {
+				// skip jumps other than tail calls.
+				return
+			}
+			for fam := range incoming[callee] {
+				v, ok := regValue(funcAddr, fam, defs, written)
+				if !ok {
+					continue
+				}
+				union(v, baseValue{funcAddr: callee, fam: fam})
+			}
+		})
+	}
+	// Cluster field accesses by unified base pointer value.
+	clusters := make(map[baseValue][]fieldAccess)
+	for v, as := range accesses {
+		root := find(v)
+		clusters[root] = append(clusters[root], as...)
+	}
+	var layouts [][]fieldAccess
+	for _, as := range clusters {
+		sort.Slice(as, func(i, j int) bool {
+			if as[i].offset != as[j].offset {
+				return as[i].offset < as[j].offset
+			}
+			return as[i].instAddr < as[j].instAddr
+		})
+		if as[0].offset < 0 || as[0].offset == as[len(as)-1].offset {
+			// skip negative offsets and base pointers accessed at a single offset.
+			continue
+		}
+		layouts = append(layouts, as)
+	}
+	sort.Slice(layouts, func(i, j int) bool {
+		return minInstAddr(layouts[i]) < minInstAddr(layouts[j])
+	})
+	if l.structs == nil {
+		l.structs = make(map[bin.Address]*structField)
+	}
+	for _, as := range layouts {
+		l.addStruct(as)
+	}
+}
+
+// addStruct adds a struct type definition based on the given field accesses,
+// sorted by offset.
+func (l *Lifter) addStruct(as []fieldAccess) {
+	var fields []types.Type
+	// Map from offset to field index.
+	indices := make(map[int64]int64)
+	end := int64(0)
+	for _, a := range as {
+		if _, ok := indices[a.offset]; ok || a.offset < end {
+			// skip overlapping field accesses.
+			continue
+		}
+		if a.offset > end {
+			// padding.
+			fields = append(fields, types.NewArray(uint64(a.offset-end), types.I8))
+		}
+		indices[a.offset] = int64(len(fields))
+		fields = append(fields, types.NewInt(uint64(a.size*8)))
+		end = a.offset + int64(a.size)
+	}
+	typ := types.NewStruct(fields...)
+	typ.SetName(fmt.Sprintf("struct_%06X", uint64(minInstAddr(as))))
+	dbg.Printf("recovered struct %v with %d fields", typ.Name(), len(indices))
+	l.TypeDefs = append(l.TypeDefs, typ)
+	for _, a := range as {
+		if index, ok := indices[a.offset]; ok {
+			l.structs[a.instAddr] = &structField{typ: typ, offset: a.offset, index: index}
+		}
+	}
+}
+
+// collectAccesses collects the memory accesses at constant offsets from base
+// pointers of the given function, and returns the register families of the
+// incoming register values used as base pointers.
+func (l *Lifter) collectAccesses(asmFunc *x86.Func, accesses map[baseValue][]fieldAccess) map[int]bool {
+	written := l.writtenRegs(asmFunc)
+	incoming := make(map[int]bool)
+	l.walkBlocks(asmFunc, func(inst *x86.Inst, defs map[int]bin.Address) {
+		if inst.Op == x86asm.LEA {
+			// address computation; not a memory access.
+			return
+		}
+		for _, arg := range inst.Args {
+			mem, ok := arg.(x86asm.Mem)
+			if !ok || mem.Segment != 0 || mem.Base == 0 || mem.Index != 0 {
+				continue
+			}
+			fam, _ := x86.RegFamily(mem.Base)
+			if fam == -1 || fam == famSP || fam == famBP {
+				// skip stack and frame pointers.
+				continue
+			}
+			v, ok := regValue(asmFunc.Addr, fam, defs, written)
+			if !ok {
+				continue
+			}
+			if v.defAddr == 0 {
+				incoming[fam] = true
+			}
+			size := inst.MemBytes
+			if size == 0 {
+				size = l.Mode / 8
+			}
+			a := fieldAccess{instAddr: inst.Addr, offset: mem.Disp, size: size}
+			accesses[v] = append(accesses[v], a)
+		}
+	})
+	return incoming
+}
+
+// walkBlocks invokes visit for each instruction of the given function, in
+// ascending address order, with the addresses of the instructions defining the
+// register families within the basic block prior to the instruction.
+func (l *Lifter) walkBlocks(asmFunc *x86.Func, visit func(inst *x86.Inst, defs map[int]bin.Address)) {
+	var blockAddrs bin.Addresses
+	for blockAddr := range asmFunc.Blocks {
+		blockAddrs = append(blockAddrs, blockAddr)
+	}
+	sort.Sort(blockAddrs)
+	for _, blockAddr := range blockAddrs {
+		block := asmFunc.Blocks[blockAddr]
+		defs := make(map[int]bin.Address)
+		insts := block.Insts
+		if !block.Term.IsDummyTerm() {
+			insts = append(insts[:len(insts):len(insts)], block.Term)
+		}
+		for _, inst := range insts {
+			visit(inst, defs)
+			for _, fam := range l.regWrites(inst) {
+				defs[fam] = inst.Addr
+			}
+		}
+	}
+}
+
+// writtenRegs returns the register families written within the given
+// function.
+func (l *Lifter) writtenRegs(asmFunc *x86.Func) map[int]bool {
+	written := make(map[int]bool)
+	l.walkBlocks(asmFunc, func(inst *x86.Inst, defs map[int]bin.Address) {
+		for _, fam := range l.regWrites(inst) {
+			written[fam] = true
+		}
+	})
+	return written
+}
+
+// Register families of the stack and frame pointers.
+const (
+	famSP = 4
+	famBP = 5
+)
+
+// regWrites returns the register families written by the given instruction.
+func (l *Lifter) regWrites(inst *x86.Inst) []int {
+	var fams []int
+	switch inst.Op {
+	case x86asm.CALL:
+		// caller-saved registers.
+		fams = []int{0, 1, 2}
+		if l.Mode == 64 {
+			fams = append(fams, 6, 7, 8, 9, 10, 11)
+		}
+		return fams
+	case x86asm.CMP, x86asm.TEST, x86asm.PUSH, x86asm.BT, x86asm.JMP:
+		// destination not written.
+		return nil
+	case x86asm.MUL, x86asm.DIV, x86asm.IDIV, x86asm.CDQ, x86asm.CWD, x86asm.CQO, x86asm.RDTSC:
+		fams = append(fams, 0, 2)
+	case x86asm.CPUID:
+		fams = append(fams, 0, 1, 2, 3)
+	case x86asm.MOVSB, x86asm.MOVSW, x86asm.MOVSD, x86asm.MOVSQ, x86asm.CMPSB, x86asm.CMPSW, x86asm.CMPSD, x86asm.CMPSQ:
+		fams = append(fams, 1, 6, 7)
+	case x86asm.STOSB, x86asm.STOSW, x86asm.STOSD, x86asm.STOSQ, x86asm.SCASB, x86asm.SCASW, x86asm.SCASD, x86asm.SCASQ:
+		fams = append(fams, 1, 7)
+	case x86asm.LODSB, x86asm.LODSW, x86asm.LODSD, x86asm.LODSQ:
+		fams = append(fams, 0, 1, 6)
+	case x86asm.POPA, x86asm.POPAD:
+		fams = append(fams, 0, 1, 2, 3, 5, 6, 7)
+	case x86asm.XCHG, x86asm.XADD:
+		if reg, ok := inst.Args[1].(x86asm.Reg); ok {
+			if fam, _ := x86.RegFamily(reg); fam != -1 {
+				fams = append(fams, fam)
+			}
+		}
+	}
+	if reg, ok := inst.Args[0].(x86asm.Reg); ok {
+		if fam, _ := x86.RegFamily(reg); fam != -1 {
+			fams = append(fams, fam)
+		}
+	}
+	return fams
+}
+
+// regValue returns the base pointer value held by the given register family,
+// based on the definitions of the current basic block and the register
+// families written within the function. The boolean return value indicates
+// success.
+func regValue(funcAddr bin.Address, fam int, defs map[int]bin.Address, written map[int]bool) (baseValue, bool) {
+	if defAddr, ok := defs[fam]; ok {
+		return baseValue{funcAddr: funcAddr, fam: fam, defAddr: defAddr}, true
+	}
+	if !written[fam] {
+		// incoming register value.
+		return baseValue{funcAddr: funcAddr, fam: fam}, true
+	}
+	// register value defined in other basic block.
+	return baseValue{}, false
+}
+
+// minInstAddr returns the lowest instruction address of the given field
+// accesses.
+func minInstAddr(as []fieldAccess) bin.Address {
+	min := as[0].instAddr
+	for _, a := range as[1:] {
+		if a.instAddr < min {
+			min = a.instAddr
+		}
+	}
+	return min
+}
+
+// structFieldPtr returns a pointer to the recovered struct field accessed by
+// the given memory argument, emitting code to f. The boolean return value
+// indicates success.
+func (f *Func) structFieldPtr(mem *x86.Mem, base value.Value) (value.Value, bool) {
+	if mem.Parent == nil {
+		// Synthetic memory argument; e.g. stack slot of push.
+		return nil, false
+	}
+	field, ok := f.l.structs[mem.Parent.Addr]
+	if !ok || field.offset != mem.Disp {
+		return nil, false
+	}
+	src := f.cur.NewIntToPtr(base, types.NewPointer(field.typ))
+	zero := f.constInt(types.I32, 0)
+	index := f.constInt(types.I32, field.index)
+	return f.cur.NewGetElementPtr(src, zero, index), true
+}
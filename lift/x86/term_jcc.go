@@ -4,7 +4,6 @@ import (
 	"github.com/decomp/exp/bin"
 	"github.com/decomp/exp/disasm/x86"
 	"github.com/kr/pretty"
-	"github.com/llir/llvm/ir/constant"
 	"github.com/llir/llvm/ir/enum"
 	"github.com/llir/llvm/ir/types"
 	"github.com/llir/llvm/ir/value"
@@ -56,12 +55,7 @@ import (
 func (f *Func) liftTermJA(term *x86.Inst) error {
 	// Jump if above.
 	//    (CF=0 and ZF=0)
-	cf := f.useStatus(CF)
-	zf := f.useStatus(ZF)
-	cond1 := f.cur.NewICmp(enum.IPredEQ, cf, constant.False)
-	cond2 := f.cur.NewICmp(enum.IPredEQ, zf, constant.False)
-	cond := f.cur.NewAnd(cond1, cond2)
-	return f.liftTermJcc(term.Arg(0), cond)
+	return f.liftTermJcc(term.Arg(0), f.cond(condA))
 }
 
 // --- [ JAE ] -----------------------------------------------------------------
@@ -70,9 +64,7 @@ func (f *Func) liftTermJA(term *x86.Inst) error {
 func (f *Func) liftTermJAE(term *x86.Inst) error {
 	// Jump if above or equal.
 	//    (CF=0)
-	cf := f.useStatus(CF)
-	cond := f.cur.NewICmp(enum.IPredEQ, cf, constant.False)
-	return f.liftTermJcc(term.Arg(0), cond)
+	return f.liftTermJcc(term.Arg(0), f.cond(condAE))
 }
 
 // --- [ JBE ] -----------------------------------------------------------------
@@ -81,12 +73,7 @@ func (f *Func) liftTermJAE(term *x86.Inst) error {
 func (f *Func) liftTermJBE(term *x86.Inst) error {
 	// Jump if below or equal.
 	//    (CF=1 or ZF=1)
-	cf := f.useStatus(CF)
-	zf := f.useStatus(ZF)
-	cond1 := cf
-	cond2 := zf
-	cond := f.cur.NewOr(cond1, cond2)
-	return f.liftTermJcc(term.Arg(0), cond)
+	return f.liftTermJcc(term.Arg(0), f.cond(condBE))
 }
 
 // --- [ JB ] ------------------------------------------------------------------
@@ -95,9 +82,7 @@ func (f *Func) liftTermJBE(term *x86.Inst) error {
 func (f *Func) liftTermJB(term *x86.Inst) error {
 	// Jump if below.
 	//    (CF=1)
-	cf := f.useStatus(CF)
-	cond := cf
-	return f.liftTermJcc(term.Arg(0), cond)
+	return f.liftTermJcc(term.Arg(0), f.cond(condB))
 }
 
 // --- [ JCXZ ] ----------------------------------------------------------------
@@ -119,7 +104,7 @@ func (f *Func) liftTermJECXZ(term *x86.Inst) error {
 	// Jump if ECX register is zero.
 	//    (ECX=0)
 	ecx := f.useReg(x86.ECX)
-	zero := constant.NewInt(types.I32, 0)
+	zero := f.constInt(types.I32, 0)
 	cond := f.cur.NewICmp(enum.IPredEQ, ecx, zero)
 	return f.liftTermJcc(term.Arg(0), cond)
 }
@@ -130,9 +115,7 @@ func (f *Func) liftTermJECXZ(term *x86.Inst) error {
 func (f *Func) liftTermJNO(term *x86.Inst) error {
 	// Jump if not overflow.
 	//    (OF=0)
-	of := f.useStatus(OF)
-	cond := f.cur.NewICmp(enum.IPredEQ, of, constant.False)
-	return f.liftTermJcc(term.Arg(0), cond)
+	return f.liftTermJcc(term.Arg(0), f.cond(condNO))
 }
 
 // --- [ JO ] ------------------------------------------------------------------
@@ -141,9 +124,7 @@ func (f *Func) liftTermJNO(term *x86.Inst) error {
 func (f *Func) liftTermJO(term *x86.Inst) error {
 	// Jump if overflow.
 	//    (OF=1)
-	of := f.useStatus(OF)
-	cond := of
-	return f.liftTermJcc(term.Arg(0), cond)
+	return f.liftTermJcc(term.Arg(0), f.cond(condO))
 }
 
 // --- [ JNP ] -----------------------------------------------------------------
@@ -152,9 +133,7 @@ func (f *Func) liftTermJO(term *x86.Inst) error {
 func (f *Func) liftTermJNP(term *x86.Inst) error {
 	// Jump if not parity.
 	//    (PF=0)
-	pf := f.useStatus(PF)
-	cond := f.cur.NewICmp(enum.IPredEQ, pf, constant.False)
-	return f.liftTermJcc(term.Arg(0), cond)
+	return f.liftTermJcc(term.Arg(0), f.cond(condNP))
 }
 
 // --- [ JP ] ------------------------------------------------------------------
@@ -163,9 +142,7 @@ func (f *Func) liftTermJNP(term *x86.Inst) error {
 func (f *Func) liftTermJP(term *x86.Inst) error {
 	// Jump if parity.
 	//    (PF=1)
-	pf := f.useStatus(PF)
-	cond := pf
-	return f.liftTermJcc(term.Arg(0), cond)
+	return f.liftTermJcc(term.Arg(0), f.cond(condP))
 }
 
 // --- [ JRCXZ ] ---------------------------------------------------------------
@@ -185,9 +162,7 @@ func (f *Func) liftTermJRCXZ(term *x86.Inst) error {
 func (f *Func) liftTermJNS(term *x86.Inst) error {
 	// Jump if not sign.
 	//    (SF=0)
-	sf := f.useStatus(SF)
-	cond := f.cur.NewICmp(enum.IPredEQ, sf, constant.False)
-	return f.liftTermJcc(term.Arg(0), cond)
+	return f.liftTermJcc(term.Arg(0), f.cond(condNS))
 }
 
 // --- [ JS ] ------------------------------------------------------------------
@@ -196,9 +171,7 @@ func (f *Func) liftTermJNS(term *x86.Inst) error {
 func (f *Func) liftTermJS(term *x86.Inst) error {
 	// Jump if sign.
 	//    (SF=1)
-	sf := f.useStatus(SF)
-	cond := sf
-	return f.liftTermJcc(term.Arg(0), cond)
+	return f.liftTermJcc(term.Arg(0), f.cond(condS))
 }
 
 // --- [ JGE ] -----------------------------------------------------------------
@@ -207,10 +180,7 @@ func (f *Func) liftTermJS(term *x86.Inst) error {
 func (f *Func) liftTermJGE(term *x86.Inst) error {
 	// Jump if greater or equal.
 	//    (SF=OF)
-	sf := f.useStatus(SF)
-	of := f.useStatus(OF)
-	cond := f.cur.NewICmp(enum.IPredEQ, sf, of)
-	return f.liftTermJcc(term.Arg(0), cond)
+	return f.liftTermJcc(term.Arg(0), f.cond(condGE))
 }
 
 // --- [ JL ] ------------------------------------------------------------------
@@ -219,10 +189,7 @@ func (f *Func) liftTermJGE(term *x86.Inst) error {
 func (f *Func) liftTermJL(term *x86.Inst) error {
 	// Jump if less.
 	//    (SF≠OF)
-	sf := f.useStatus(SF)
-	of := f.useStatus(OF)
-	cond := f.cur.NewICmp(enum.IPredNE, sf, of)
-	return f.liftTermJcc(term.Arg(0), cond)
+	return f.liftTermJcc(term.Arg(0), f.cond(condL))
 }
 
 // --- [ JG ] ------------------------------------------------------------------
@@ -231,13 +198,7 @@ func (f *Func) liftTermJL(term *x86.Inst) error {
 func (f *Func) liftTermJG(term *x86.Inst) error {
 	// Jump if greater.
 	//    (ZF=0 and SF=OF)
-	zf := f.useStatus(ZF)
-	sf := f.useStatus(SF)
-	of := f.useStatus(OF)
-	cond1 := f.cur.NewICmp(enum.IPredEQ, zf, constant.False)
-	cond2 := f.cur.NewICmp(enum.IPredEQ, sf, of)
-	cond := f.cur.NewAnd(cond1, cond2)
-	return f.liftTermJcc(term.Arg(0), cond)
+	return f.liftTermJcc(term.Arg(0), f.cond(condG))
 }
 
 // --- [ JNE ] -----------------------------------------------------------------
@@ -246,9 +207,7 @@ func (f *Func) liftTermJG(term *x86.Inst) error {
 func (f *Func) liftTermJNE(term *x86.Inst) error {
 	// Jump if not equal.
 	//    (ZF=0)
-	zf := f.useStatus(ZF)
-	cond := f.cur.NewICmp(enum.IPredEQ, zf, constant.False)
-	return f.liftTermJcc(term.Arg(0), cond)
+	return f.liftTermJcc(term.Arg(0), f.cond(condNE))
 }
 
 // --- [ JLE ] -----------------------------------------------------------------
@@ -257,13 +216,7 @@ func (f *Func) liftTermJNE(term *x86.Inst) error {
 func (f *Func) liftTermJLE(term *x86.Inst) error {
 	// Jump if less or equal.
 	//    (ZF=1 or SF≠OF)
-	zf := f.useStatus(ZF)
-	sf := f.useStatus(SF)
-	of := f.useStatus(OF)
-	cond1 := zf
-	cond2 := f.cur.NewICmp(enum.IPredNE, sf, of)
-	cond := f.cur.NewOr(cond1, cond2)
-	return f.liftTermJcc(term.Arg(0), cond)
+	return f.liftTermJcc(term.Arg(0), f.cond(condLE))
 }
 
 // --- [ JE ] ------------------------------------------------------------------
@@ -272,9 +225,7 @@ func (f *Func) liftTermJLE(term *x86.Inst) error {
 func (f *Func) liftTermJE(term *x86.Inst) error {
 	// Jump if equal.
 	//    (ZF=1)
-	zf := f.useStatus(ZF)
-	cond := zf
-	return f.liftTermJcc(term.Arg(0), cond)
+	return f.liftTermJcc(term.Arg(0), f.cond(condE))
 }
 
 // === [ Helper functions ] ====================================================
@@ -298,6 +249,5 @@ func (f *Func) liftTermJcc(arg *x86.Arg, cond value.Value) error {
 		return errors.Errorf("unable to locate fallthrough basic block at %v", nextAddr)
 	}
 	f.cur.NewCondBr(cond, target, next)
-	f.cur = next
 	return nil
 }
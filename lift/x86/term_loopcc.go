@@ -2,7 +2,6 @@ package x86
 
 import (
 	"github.com/decomp/exp/disasm/x86"
-	"github.com/llir/llvm/ir/constant"
 	"github.com/llir/llvm/ir/enum"
 	"github.com/llir/llvm/ir/types"
 	"golang.org/x/arch/x86/x86asm"
@@ -25,7 +24,7 @@ func (f *Func) liftTermLOOP(term *x86.Inst) error {
 	// Loop if ECX register is not zero.
 	//    (ECX≠0)
 	ecx := f.dec(x86.NewArg(x86asm.ECX, term))
-	zero := constant.NewInt(types.I32, 0)
+	zero := f.constInt(types.I32, 0)
 	cond := f.cur.NewICmp(enum.IPredNE, ecx, zero)
 	return f.liftTermJcc(term.Arg(0), cond)
 }
@@ -38,10 +37,9 @@ func (f *Func) liftTermLOOPE(term *x86.Inst) error {
 	// Loop if equal and ECX register is not zero.
 	//    (ECX≠0 and ZF=1)
 	ecx := f.dec(x86.NewArg(x86asm.ECX, term))
-	zero := constant.NewInt(types.I32, 0)
-	zf := f.useStatus(ZF)
+	zero := f.constInt(types.I32, 0)
 	cond1 := f.cur.NewICmp(enum.IPredNE, ecx, zero)
-	cond2 := zf
+	cond2 := f.cond(condE)
 	cond := f.cur.NewAnd(cond1, cond2)
 	return f.liftTermJcc(term.Arg(0), cond)
 }
@@ -54,10 +52,9 @@ func (f *Func) liftTermLOOPNE(term *x86.Inst) error {
 	// Loop if not equal and ECX register is not zero.
 	//    (ECX≠0 and ZF=0)
 	ecx := f.dec(x86.NewArg(x86asm.ECX, term))
-	zero := constant.NewInt(types.I32, 0)
-	zf := f.useStatus(ZF)
+	zero := f.constInt(types.I32, 0)
 	cond1 := f.cur.NewICmp(enum.IPredNE, ecx, zero)
-	cond2 := f.cur.NewICmp(enum.IPredEQ, zf, constant.False)
+	cond2 := f.cond(condNE)
 	cond := f.cur.NewAnd(cond1, cond2)
 	return f.liftTermJcc(term.Arg(0), cond)
 }
@@ -8,7 +8,6 @@ import (
 	"github.com/decomp/exp/disasm/x86"
 	"github.com/kr/pretty"
 	"github.com/llir/llvm/ir"
-	"github.com/llir/llvm/ir/constant"
 	"github.com/llir/llvm/ir/types"
 	"github.com/pkg/errors"
 	"golang.org/x/arch/x86/x86asm"
@@ -38,6 +37,8 @@ func (f *Func) liftTerm(term *x86.Inst) error {
 		switch prefix {
 		case x86asm.PrefixData16, x86asm.PrefixData16 | x86asm.PrefixImplicit:
 			// prefix already supported.
+		case x86asm.PrefixCS, x86asm.PrefixDS, x86asm.PrefixES, x86asm.PrefixFS, x86asm.PrefixGS, x86asm.PrefixSS:
+			// segment override (or branch hint); handled by memory operands.
 		default:
 			pretty.Println("terminator with prefix:", term)
 			panic(fmt.Errorf("support for %v terminator with prefix not yet implemented", term.Op))
@@ -95,6 +96,9 @@ func (f *Func) liftTerm(term *x86.Inst) error {
 	// Unconditional jump terminators.
 	case x86asm.JMP:
 		return f.liftTermJMP(term)
+	// Far jump terminators.
+	case x86asm.LJMP:
+		return f.liftTermLJMP(term)
 	// Return terminators.
 	case x86asm.RET:
 		return f.liftTermRET(term)
@@ -116,14 +120,7 @@ func (f *Func) liftTermJMP(term *x86.Inst) error {
 		if err := f.liftInstCALL(term); err != nil {
 			return errors.WithStack(err)
 		}
-		// Handle return values.
-		if !types.Equal(f.Sig.RetType, types.Void) {
-			// Non-void functions, pass return value in EAX.
-			result := f.useReg(x86.EAX)
-			f.cur.NewRet(result)
-			return nil
-		}
-		f.cur.NewRet(nil)
+		f.liftTailRet()
 		return nil
 	}
 
@@ -175,7 +172,7 @@ func (f *Func) liftTermJMP(term *x86.Inst) error {
 				if !ok {
 					return errors.Errorf("unable to locate basic block at %v", targetAddr)
 				}
-				ii := constant.NewInt(index.Type().(*types.IntType), int64(i))
+				ii := f.constInt(index.Type().(*types.IntType), int64(i))
 				c := ir.NewCase(ii, target)
 				cases = append(cases, c)
 			}
@@ -183,18 +180,81 @@ func (f *Func) liftTermJMP(term *x86.Inst) error {
 			return nil
 		}
 	}
+	// Handle indirect jumps with targets observed at runtime.
+	if targetAddrs, ok := f.l.Indirect[term.Addr]; ok {
+		if len(targetAddrs) == 1 {
+			target, ok := f.blocks[targetAddrs[0]]
+			if !ok {
+				return errors.Errorf("unable to locate target basic block at %v", targetAddrs[0])
+			}
+			f.cur.NewBr(target)
+			return nil
+		}
+		// The default branch is unreachable, under the assumption that the
+		// execution traces cover all targets of the indirect jump.
+		addr := f.useArg(arg)
+		unreachable := &ir.BasicBlock{}
+		unreachable.NewUnreachable()
+		f.Blocks = append(f.Blocks, unreachable)
+		var cases []*ir.Case
+		for _, targetAddr := range targetAddrs {
+			target, ok := f.blocks[targetAddr]
+			if !ok {
+				return errors.Errorf("unable to locate basic block at %v", targetAddr)
+			}
+			c := ir.NewCase(f.constInt(addr.Type().(*types.IntType), int64(targetAddr)), target)
+			cases = append(cases, c)
+		}
+		f.cur.NewSwitch(addr, unreachable, cases...)
+		return nil
+	}
 	pretty.Println("term:", term)
 	panic("emitTermJMP: not yet implemented")
 }
 
+// --- [ LJMP ] ----------------------------------------------------------------
+
+// liftTermLJMP lifts the given x86 LJMP terminator to LLVM IR, emitting code to
+// f.
+func (f *Func) liftTermLJMP(term *x86.Inst) error {
+	// LJMP - Far jump; possibly switching processor mode (e.g. from real mode to
+	// protected mode).
+	//
+	// The code segment is not modelled; the far jump is lifted as a branch to
+	// the basic block of the far target, which is decoded in the processor mode
+	// of the target, or as a tail call if the far target is outside of the
+	// function.
+	targetAddr, ok := f.l.FarTarget(term)
+	if !ok {
+		panic(fmt.Errorf("support for indirect far jump at %v not yet implemented", term.Addr))
+	}
+	if target, ok := f.blocks[targetAddr]; ok {
+		f.cur.NewBr(target)
+		return nil
+	}
+	// Handle far jumps to other functions as tail calls.
+	fn, ok := f.l.Funcs[targetAddr]
+	if !ok {
+		return errors.Errorf("unable to locate function at %v; target of far jump at %v", targetAddr, term.Addr)
+	}
+	callee := fn.Function
+	if err := f.liftCall(term, callee, callee.Sig, callee.CallingConv); err != nil {
+		return errors.WithStack(err)
+	}
+	f.liftTailRet()
+	return nil
+}
+
 // --- [ RET ] -----------------------------------------------------------------
 
 // liftTermRET lifts the given x86 RET terminator to LLVM IR, emitting code to
 // f.
 func (f *Func) liftTermRET(term *x86.Inst) error {
-	// Handle return values of non-void functions (passed through EAX).
+	f.checkRet(term)
+	// Handle return values of non-void functions (passed through EAX, EDX:EAX,
+	// or ST0 based on calling convention).
 	if !types.Equal(f.Sig.RetType, types.Void) {
-		result := f.useReg(x86.EAX)
+		result := f.useResult(f.Sig.RetType)
 		f.cur.NewRet(result)
 		return nil
 	}
@@ -204,6 +264,18 @@ func (f *Func) liftTermRET(term *x86.Inst) error {
 
 // === [ Helper functions ] ====================================================
 
+// liftTailRet lifts the return following a tail call, passing the return value
+// based on calling convention, emitting code to f.
+func (f *Func) liftTailRet() {
+	if !types.Equal(f.Sig.RetType, types.Void) {
+		// Non-void functions, pass return value based on calling convention.
+		result := f.useResult(f.Sig.RetType)
+		f.cur.NewRet(result)
+		return
+	}
+	f.cur.NewRet(nil)
+}
+
 // isTailCall reports whether the given instruction is a tail call instruction.
 func (f *Func) isTailCall(inst *x86.Inst) bool {
 	arg := inst.Arg(0)
@@ -236,6 +308,16 @@ func (f *Func) isTailCall(inst *x86.Inst) bool {
 		}
 	}
 
+	// Targets observed at runtime.
+	if targets, ok := f.l.Indirect[inst.Addr]; ok {
+		for _, target := range targets {
+			if !f.contains(target) {
+				return true
+			}
+		}
+		return false
+	}
+
 	// TODO: Find a prettier solution for handling indirect jumps to potential
 	// tail call functions at register relative memory locations; e.g.
 	//    JMP [EAX+0x8]
@@ -298,17 +380,9 @@ func (l *Lifter) getFuncEndAddr(entry bin.Address) bin.Address {
 
 // getCodeEnd returns the end address of the code section.
 func (l *Lifter) getCodeEnd() bin.Address {
-	var max bin.Address
-	for _, sect := range l.File.Sections {
-		if sect.Perm&bin.PermX != 0 {
-			end := sect.Addr + bin.Address(len(sect.Data))
-			if max < end {
-				max = end
-			}
-		}
-	}
-	if max == 0 {
+	_, end, ok := l.File.AddressSpace().Range(bin.PermX)
+	if !ok {
 		panic("unable to locate end of code segment")
 	}
-	return max
+	return end
 }
@@ -1,109 +1,258 @@
-define void @div_r8() !addr !{!"0x10000000"} {
+define void @_imp_div_r8() !addr !{!"0x10000000"} {
 ; <label>:0
-	%al = alloca i8
-	%bl = alloca i8
-	%ah = alloca i8
-	%ax = alloca i16
 	%eax = alloca i32
+	%ebx = alloca i32
 	br label %block_10000000
 
 block_10000000:
-	store i32 84, i16* %ax
-	store i32 2, i8* %bl
-	%1 = load i8, i8* %bl
-	%2 = load i16, i16* %ax
-	%3 = zext i8 %1 to i16
-	%4 = udiv i16 %2, %3
-	%5 = urem i16 %2, %3
-	store i16 %4, i8* %al
-	store i16 %5, i8* %ah
-	%6 = load i32, i32* %eax
-	%7 = and i32 %6, 255
-	store i32 %7, i32* %eax
+	%1 = load i32, i32* %eax
+	%2 = zext i16 84 to i32
+	%3 = and i32 %1, -65536
+	%4 = or i32 %3, %2
+	store i32 %4, i32* %eax
+	%5 = load i32, i32* %ebx
+	%6 = zext i8 2 to i32
+	%7 = and i32 %5, -256
+	%8 = or i32 %7, %6
+	store i32 %8, i32* %ebx
+	%9 = load i32, i32* %ebx
+	%10 = trunc i32 %9 to i8
+	%11 = load i32, i32* %eax
+	%12 = trunc i32 %11 to i16
+	%13 = icmp eq i8 %10, 0
+	br i1 %13, label %14, label %15
+
+; <label>:14
+	call void @llvm.trap()
+	unreachable
+
+; <label>:15
+	%16 = zext i8 %10 to i16
+	%17 = udiv i16 %12, %16
+	%18 = urem i16 %12, %16
+	%19 = trunc i16 %17 to i8
+	%20 = zext i8 %19 to i16
+	%21 = icmp ne i16 %17, %20
+	br i1 %21, label %22, label %23
+
+; <label>:22
+	call void @llvm.trap()
+	unreachable
+
+; <label>:23
+	%24 = trunc i16 %18 to i8
+	%25 = load i32, i32* %eax
+	%26 = zext i8 %19 to i32
+	%27 = and i32 %25, -256
+	%28 = or i32 %27, %26
+	store i32 %28, i32* %eax
+	%29 = load i32, i32* %eax
+	%30 = zext i8 %24 to i32
+	%31 = shl i32 %30, 8
+	%32 = and i32 %29, -65281
+	%33 = or i32 %32, %31
+	store i32 %33, i32* %eax
+	%34 = load i32, i32* %eax
+	%35 = and i32 %34, 255
+	store i32 %35, i32* %eax
 	ret void
 }
 
-define void @div_m8() !addr !{!"0x1000000E"} {
+define void @_imp_div_m8() !addr !{!"0x1000000E"} {
 ; <label>:0
-	%al = alloca i8
-	%ah = alloca i8
-	%ax = alloca i16
 	%eax = alloca i32
 	br label %block_1000000E
 
 block_1000000E:
-	store i32 84, i16* %ax
-	store i32 2, i8* @m8
-	%1 = load i8, i8* @m8
-	%2 = load i16, i16* %ax
-	%3 = zext i8 %1 to i16
-	%4 = udiv i16 %2, %3
-	%5 = urem i16 %2, %3
-	store i16 %4, i8* %al
-	store i16 %5, i8* %ah
+	%1 = load i32, i32* %eax
+	%2 = zext i16 84 to i32
+	%3 = and i32 %1, -65536
+	%4 = or i32 %3, %2
+	store i32 %4, i32* %eax
+	store i8 2, i8* @m8
+	%5 = load i8, i8* @m8
 	%6 = load i32, i32* %eax
-	%7 = and i32 %6, 255
-	store i32 %7, i32* %eax
+	%7 = trunc i32 %6 to i16
+	%8 = icmp eq i8 %5, 0
+	br i1 %8, label %9, label %10
+
+; <label>:9
+	call void @llvm.trap()
+	unreachable
+
+; <label>:10
+	%11 = zext i8 %5 to i16
+	%12 = udiv i16 %7, %11
+	%13 = urem i16 %7, %11
+	%14 = trunc i16 %12 to i8
+	%15 = zext i8 %14 to i16
+	%16 = icmp ne i16 %12, %15
+	br i1 %16, label %17, label %18
+
+; <label>:17
+	call void @llvm.trap()
+	unreachable
+
+; <label>:18
+	%19 = trunc i16 %13 to i8
+	%20 = load i32, i32* %eax
+	%21 = zext i8 %14 to i32
+	%22 = and i32 %20, -256
+	%23 = or i32 %22, %21
+	store i32 %23, i32* %eax
+	%24 = load i32, i32* %eax
+	%25 = zext i8 %19 to i32
+	%26 = shl i32 %25, 8
+	%27 = and i32 %24, -65281
+	%28 = or i32 %27, %26
+	store i32 %28, i32* %eax
+	%29 = load i32, i32* %eax
+	%30 = and i32 %29, 255
+	store i32 %30, i32* %eax
 	ret void
 }
 
-define void @div_r16() !addr !{!"0x10000025"} {
+define void @_imp_div_r16() !addr !{!"0x10000025"} {
 ; <label>:0
-	%ax = alloca i16
-	%dx = alloca i16
-	%bx = alloca i16
 	%eax = alloca i32
-	%"dx:ax" = alloca i32
+	%edx = alloca i32
+	%ebx = alloca i32
 	br label %block_10000025
 
 block_10000025:
-	store i32 0, i16* %dx
-	store i32 84, i16* %ax
-	store i32 2, i16* %bx
-	%1 = load i16, i16* %bx
-	%2 = load i32, i32* %"dx:ax"
-	%3 = zext i16 %1 to i32
-	%4 = udiv i32 %2, %3
-	%5 = urem i32 %2, %3
-	store i32 %4, i16* %ax
-	store i32 %5, i16* %dx
-	%6 = load i32, i32* %eax
-	%7 = and i32 %6, 65535
-	store i32 %7, i32* %eax
+	%1 = load i32, i32* %edx
+	%2 = zext i16 0 to i32
+	%3 = and i32 %1, -65536
+	%4 = or i32 %3, %2
+	store i32 %4, i32* %edx
+	%5 = load i32, i32* %eax
+	%6 = zext i16 84 to i32
+	%7 = and i32 %5, -65536
+	%8 = or i32 %7, %6
+	store i32 %8, i32* %eax
+	%9 = load i32, i32* %ebx
+	%10 = zext i16 2 to i32
+	%11 = and i32 %9, -65536
+	%12 = or i32 %11, %10
+	store i32 %12, i32* %ebx
+	%13 = load i32, i32* %ebx
+	%14 = trunc i32 %13 to i16
+	%15 = load i32, i32* %edx
+	%16 = trunc i32 %15 to i16
+	%17 = zext i16 %16 to i32
+	%18 = shl i32 %17, 16
+	%19 = load i32, i32* %eax
+	%20 = trunc i32 %19 to i16
+	%21 = zext i16 %20 to i32
+	%22 = or i32 %18, %21
+	%23 = icmp eq i16 %14, 0
+	br i1 %23, label %24, label %25
+
+; <label>:24
+	call void @llvm.trap()
+	unreachable
+
+; <label>:25
+	%26 = zext i16 %14 to i32
+	%27 = udiv i32 %22, %26
+	%28 = urem i32 %22, %26
+	%29 = trunc i32 %27 to i16
+	%30 = zext i16 %29 to i32
+	%31 = icmp ne i32 %27, %30
+	br i1 %31, label %32, label %33
+
+; <label>:32
+	call void @llvm.trap()
+	unreachable
+
+; <label>:33
+	%34 = trunc i32 %28 to i16
+	%35 = load i32, i32* %eax
+	%36 = zext i16 %29 to i32
+	%37 = and i32 %35, -65536
+	%38 = or i32 %37, %36
+	store i32 %38, i32* %eax
+	%39 = load i32, i32* %edx
+	%40 = zext i16 %34 to i32
+	%41 = and i32 %39, -65536
+	%42 = or i32 %41, %40
+	store i32 %42, i32* %edx
+	%43 = load i32, i32* %eax
+	%44 = and i32 %43, 65535
+	store i32 %44, i32* %eax
 	ret void
 }
 
-define void @div_m16() !addr !{!"0x1000003A"} {
+define void @_imp_div_m16() !addr !{!"0x1000003A"} {
 ; <label>:0
-	%ax = alloca i16
-	%dx = alloca i16
 	%eax = alloca i32
-	%"dx:ax" = alloca i32
+	%edx = alloca i32
 	br label %block_1000003A
 
 block_1000003A:
-	store i32 0, i16* %dx
-	store i32 84, i16* %ax
-	store i32 2, i16* @m16
-	%1 = load i16, i16* @m16
-	%2 = load i32, i32* %"dx:ax"
-	%3 = zext i16 %1 to i32
-	%4 = udiv i32 %2, %3
-	%5 = urem i32 %2, %3
-	store i32 %4, i16* %ax
-	store i32 %5, i16* %dx
-	%6 = load i32, i32* %eax
-	%7 = and i32 %6, 65535
-	store i32 %7, i32* %eax
+	%1 = load i32, i32* %edx
+	%2 = zext i16 0 to i32
+	%3 = and i32 %1, -65536
+	%4 = or i32 %3, %2
+	store i32 %4, i32* %edx
+	%5 = load i32, i32* %eax
+	%6 = zext i16 84 to i32
+	%7 = and i32 %5, -65536
+	%8 = or i32 %7, %6
+	store i32 %8, i32* %eax
+	store i16 2, i16* @m16
+	%9 = load i16, i16* @m16
+	%10 = load i32, i32* %edx
+	%11 = trunc i32 %10 to i16
+	%12 = zext i16 %11 to i32
+	%13 = shl i32 %12, 16
+	%14 = load i32, i32* %eax
+	%15 = trunc i32 %14 to i16
+	%16 = zext i16 %15 to i32
+	%17 = or i32 %13, %16
+	%18 = icmp eq i16 %9, 0
+	br i1 %18, label %19, label %20
+
+; <label>:19
+	call void @llvm.trap()
+	unreachable
+
+; <label>:20
+	%21 = zext i16 %9 to i32
+	%22 = udiv i32 %17, %21
+	%23 = urem i32 %17, %21
+	%24 = trunc i32 %22 to i16
+	%25 = zext i16 %24 to i32
+	%26 = icmp ne i32 %22, %25
+	br i1 %26, label %27, label %28
+
+; <label>:27
+	call void @llvm.trap()
+	unreachable
+
+; <label>:28
+	%29 = trunc i32 %23 to i16
+	%30 = load i32, i32* %eax
+	%31 = zext i16 %24 to i32
+	%32 = and i32 %30, -65536
+	%33 = or i32 %32, %31
+	store i32 %33, i32* %eax
+	%34 = load i32, i32* %edx
+	%35 = zext i16 %29 to i32
+	%36 = and i32 %34, -65536
+	%37 = or i32 %36, %35
+	store i32 %37, i32* %edx
+	%38 = load i32, i32* %eax
+	%39 = and i32 %38, 65535
+	store i32 %39, i32* %eax
 	ret void
 }
 
-define void @div_r32() !addr !{!"0x10000058"} {
+define void @_imp_div_r32() !addr !{!"0x10000058"} {
 ; <label>:0
 	%eax = alloca i32
 	%edx = alloca i32
 	%ebx = alloca i32
-	%"edx:eax" = alloca i64
 	br label %block_10000058
 
 block_10000058:
@@ -111,20 +260,43 @@ block_10000058:
 	store i32 84, i32* %eax
 	store i32 2, i32* %ebx
 	%1 = load i32, i32* %ebx
-	%2 = load i64, i64* %"edx:eax"
-	%3 = zext i32 %1 to i64
-	%4 = udiv i64 %2, %3
-	%5 = urem i64 %2, %3
-	store i64 %4, i32* %eax
-	store i64 %5, i32* %edx
+	%2 = load i32, i32* %edx
+	%3 = zext i32 %2 to i64
+	%4 = shl i64 %3, 32
+	%5 = load i32, i32* %eax
+	%6 = zext i32 %5 to i64
+	%7 = or i64 %4, %6
+	%8 = icmp eq i32 %1, 0
+	br i1 %8, label %9, label %10
+
+; <label>:9
+	call void @llvm.trap()
+	unreachable
+
+; <label>:10
+	%11 = zext i32 %1 to i64
+	%12 = udiv i64 %7, %11
+	%13 = urem i64 %7, %11
+	%14 = trunc i64 %12 to i32
+	%15 = zext i32 %14 to i64
+	%16 = icmp ne i64 %12, %15
+	br i1 %16, label %17, label %18
+
+; <label>:17
+	call void @llvm.trap()
+	unreachable
+
+; <label>:18
+	%19 = trunc i64 %13 to i32
+	store i32 %14, i32* %eax
+	store i32 %19, i32* %edx
 	ret void
 }
 
-define void @div_m32() !addr !{!"0x1000006A"} {
+define void @_imp_div_m32() !addr !{!"0x1000006A"} {
 ; <label>:0
 	%eax = alloca i32
 	%edx = alloca i32
-	%"edx:eax" = alloca i64
 	br label %block_1000006A
 
 block_1000006A:
@@ -132,11 +304,35 @@ block_1000006A:
 	store i32 84, i32* %eax
 	store i32 2, i32* @m32
 	%1 = load i32, i32* @m32
-	%2 = load i64, i64* %"edx:eax"
-	%3 = zext i32 %1 to i64
-	%4 = udiv i64 %2, %3
-	%5 = urem i64 %2, %3
-	store i64 %4, i32* %eax
-	store i64 %5, i32* %edx
+	%2 = load i32, i32* %edx
+	%3 = zext i32 %2 to i64
+	%4 = shl i64 %3, 32
+	%5 = load i32, i32* %eax
+	%6 = zext i32 %5 to i64
+	%7 = or i64 %4, %6
+	%8 = icmp eq i32 %1, 0
+	br i1 %8, label %9, label %10
+
+; <label>:9
+	call void @llvm.trap()
+	unreachable
+
+; <label>:10
+	%11 = zext i32 %1 to i64
+	%12 = udiv i64 %7, %11
+	%13 = urem i64 %7, %11
+	%14 = trunc i64 %12 to i32
+	%15 = zext i32 %14 to i64
+	%16 = icmp ne i64 %12, %15
+	br i1 %16, label %17, label %18
+
+; <label>:17
+	call void @llvm.trap()
+	unreachable
+
+; <label>:18
+	%19 = trunc i64 %13 to i32
+	store i32 %14, i32* %eax
+	store i32 %19, i32* %edx
 	ret void
 }
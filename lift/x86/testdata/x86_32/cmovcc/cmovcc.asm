@@ -0,0 +1,125 @@
+[BITS 32]
+
+global cmove:function
+global cmovne:function
+global cmovl:function
+global cmovg:function
+global cmovb:function
+global cmova:function
+global cmovs:function
+global cmovo:function
+global cmovp:function
+global cmov_m32:function
+global cmov_r16:function
+
+section .text
+
+; === [ CMOVcc - Conditional Move ] ============================================
+
+; --- [ CMOVE ] ----------------------------------------------------------------
+
+; ZF; 42 if equal, 0 otherwise.
+cmove:
+	xor     eax, eax
+	mov     ecx, 42
+	cmp     edx, ebx
+	cmove   eax, ecx
+	ret
+
+; --- [ CMOVNE ] ---------------------------------------------------------------
+
+; !ZF
+cmovne:
+	xor     eax, eax
+	mov     ecx, 42
+	test    edx, edx
+	cmovne  eax, ecx
+	ret
+
+; --- [ CMOVL ] ----------------------------------------------------------------
+
+; SF != OF; minimum of signed integers.
+cmovl:
+	mov     eax, edx
+	cmp     ebx, edx
+	cmovl   eax, ebx
+	ret
+
+; --- [ CMOVG ] ----------------------------------------------------------------
+
+; !ZF && SF == OF; maximum of signed integers.
+cmovg:
+	mov     eax, edx
+	cmp     ebx, edx
+	cmovg   eax, ebx
+	ret
+
+; --- [ CMOVB ] ----------------------------------------------------------------
+
+; CF; minimum of unsigned integers.
+cmovb:
+	mov     eax, edx
+	cmp     ebx, edx
+	cmovb   eax, ebx
+	ret
+
+; --- [ CMOVA ] ----------------------------------------------------------------
+
+; !CF && !ZF; maximum of unsigned integers.
+cmova:
+	mov     eax, edx
+	cmp     ebx, edx
+	cmova   eax, ebx
+	ret
+
+; --- [ CMOVS ] ----------------------------------------------------------------
+
+; SF; absolute value.
+cmovs:
+	mov     eax, edx
+	neg     eax
+	cmovs   eax, edx
+	ret
+
+; --- [ CMOVO ] ----------------------------------------------------------------
+
+; OF; saturating addition.
+cmovo:
+	mov     eax, edx
+	mov     ecx, 0x7FFFFFFF
+	add     eax, ebx
+	cmovo   eax, ecx
+	ret
+
+; --- [ CMOVP ] ----------------------------------------------------------------
+
+; PF
+cmovp:
+	xor     eax, eax
+	mov     ecx, 42
+	test    edx, 0x3
+	cmovp   eax, ecx
+	ret
+
+; --- [ CMOVcc r32, m32 ] ------------------------------------------------------
+
+; The memory operand is loaded regardless of the condition.
+cmov_m32:
+	xor     eax, eax
+	test    edx, edx
+	cmovz   eax, dword [m32]
+	ret
+
+; --- [ CMOVcc r16, r16 ] ------------------------------------------------------
+
+cmov_r16:
+	xor     eax, eax
+	mov     cx, 42
+	cmp     dx, bx
+	cmove   ax, cx
+	ret
+
+section .data
+
+; 32-bit memory variable.
+m32: dd 42
@@ -0,0 +1,249 @@
+define void @_imp_cmove() !addr !{!"0x10000000"} {
+; <label>:0
+	%eax = alloca i32
+	%ecx = alloca i32
+	%edx = alloca i32
+	%ebx = alloca i32
+	br label %block_10000000
+
+block_10000000:
+	store i32 0, i32* %eax
+	store i32 42, i32* %ecx
+	%1 = load i32, i32* %edx
+	%2 = load i32, i32* %ebx
+	%3 = sub i32 %1, %2
+	%4 = icmp eq i32 %1, %2
+	%5 = load i32, i32* %eax
+	%6 = load i32, i32* %ecx
+	%7 = select i1 %4, i32 %6, i32 %5
+	store i32 %7, i32* %eax
+	ret void
+}
+
+define void @_imp_cmovne() !addr !{!"0x1000000D"} {
+; <label>:0
+	%eax = alloca i32
+	%ecx = alloca i32
+	%edx = alloca i32
+	br label %block_1000000D
+
+block_1000000D:
+	store i32 0, i32* %eax
+	store i32 42, i32* %ecx
+	%1 = load i32, i32* %edx
+	%2 = load i32, i32* %edx
+	%3 = and i32 %1, %2
+	%4 = icmp ne i32 %3, 0
+	%5 = load i32, i32* %eax
+	%6 = load i32, i32* %ecx
+	%7 = select i1 %4, i32 %6, i32 %5
+	store i32 %7, i32* %eax
+	ret void
+}
+
+define void @_imp_cmovl() !addr !{!"0x1000001A"} {
+; <label>:0
+	%eax = alloca i32
+	%edx = alloca i32
+	%ebx = alloca i32
+	br label %block_1000001A
+
+block_1000001A:
+	%1 = load i32, i32* %edx
+	store i32 %1, i32* %eax
+	%2 = load i32, i32* %ebx
+	%3 = load i32, i32* %edx
+	%4 = sub i32 %2, %3
+	%5 = icmp slt i32 %2, %3
+	%6 = load i32, i32* %eax
+	%7 = load i32, i32* %ebx
+	%8 = select i1 %5, i32 %7, i32 %6
+	store i32 %8, i32* %eax
+	ret void
+}
+
+define void @_imp_cmovg() !addr !{!"0x10000022"} {
+; <label>:0
+	%eax = alloca i32
+	%edx = alloca i32
+	%ebx = alloca i32
+	br label %block_10000022
+
+block_10000022:
+	%1 = load i32, i32* %edx
+	store i32 %1, i32* %eax
+	%2 = load i32, i32* %ebx
+	%3 = load i32, i32* %edx
+	%4 = sub i32 %2, %3
+	%5 = icmp sgt i32 %2, %3
+	%6 = load i32, i32* %eax
+	%7 = load i32, i32* %ebx
+	%8 = select i1 %5, i32 %7, i32 %6
+	store i32 %8, i32* %eax
+	ret void
+}
+
+define void @_imp_cmovb() !addr !{!"0x1000002A"} {
+; <label>:0
+	%eax = alloca i32
+	%edx = alloca i32
+	%ebx = alloca i32
+	br label %block_1000002A
+
+block_1000002A:
+	%1 = load i32, i32* %edx
+	store i32 %1, i32* %eax
+	%2 = load i32, i32* %ebx
+	%3 = load i32, i32* %edx
+	%4 = sub i32 %2, %3
+	%5 = icmp ult i32 %2, %3
+	%6 = load i32, i32* %eax
+	%7 = load i32, i32* %ebx
+	%8 = select i1 %5, i32 %7, i32 %6
+	store i32 %8, i32* %eax
+	ret void
+}
+
+define void @_imp_cmova() !addr !{!"0x10000032"} {
+; <label>:0
+	%eax = alloca i32
+	%edx = alloca i32
+	%ebx = alloca i32
+	br label %block_10000032
+
+block_10000032:
+	%1 = load i32, i32* %edx
+	store i32 %1, i32* %eax
+	%2 = load i32, i32* %ebx
+	%3 = load i32, i32* %edx
+	%4 = sub i32 %2, %3
+	%5 = icmp ugt i32 %2, %3
+	%6 = load i32, i32* %eax
+	%7 = load i32, i32* %ebx
+	%8 = select i1 %5, i32 %7, i32 %6
+	store i32 %8, i32* %eax
+	ret void
+}
+
+define void @_imp_cmovs() !addr !{!"0x1000003A"} {
+; <label>:0
+	%eax = alloca i32
+	%edx = alloca i32
+	br label %block_1000003A
+
+block_1000003A:
+	%1 = load i32, i32* %edx
+	store i32 %1, i32* %eax
+	%2 = load i32, i32* %eax
+	%3 = sub i32 0, %2
+	store i32 %3, i32* %eax
+	%4 = icmp slt i32 %3, 0
+	%5 = load i32, i32* %eax
+	%6 = load i32, i32* %edx
+	%7 = select i1 %4, i32 %6, i32 %5
+	store i32 %7, i32* %eax
+	ret void
+}
+
+define void @_imp_cmovo() !addr !{!"0x10000042"} {
+; <label>:0
+	%eax = alloca i32
+	%ecx = alloca i32
+	%edx = alloca i32
+	%ebx = alloca i32
+	br label %block_10000042
+
+block_10000042:
+	%1 = load i32, i32* %edx
+	store i32 %1, i32* %eax
+	store i32 2147483647, i32* %ecx
+	%2 = load i32, i32* %eax
+	%3 = load i32, i32* %ebx
+	%4 = add i32 %2, %3
+	store i32 %4, i32* %eax
+	%5 = xor i32 %2, %4
+	%6 = xor i32 %3, %4
+	%7 = and i32 %5, %6
+	%8 = icmp slt i32 %7, 0
+	%9 = load i32, i32* %eax
+	%10 = load i32, i32* %ecx
+	%11 = select i1 %8, i32 %10, i32 %9
+	store i32 %11, i32* %eax
+	ret void
+}
+
+define void @_imp_cmovp() !addr !{!"0x1000004F"} {
+; <label>:0
+	%eax = alloca i32
+	%ecx = alloca i32
+	%edx = alloca i32
+	br label %block_1000004F
+
+block_1000004F:
+	store i32 0, i32* %eax
+	store i32 42, i32* %ecx
+	%1 = load i32, i32* %edx
+	%2 = and i32 %1, 3
+	%3 = trunc i32 %2 to i8
+	%4 = call i8 @llvm.ctpop.i8(i8 %3)
+	%5 = and i8 %4, 1
+	%6 = icmp eq i8 %5, 0
+	%7 = load i32, i32* %eax
+	%8 = load i32, i32* %ecx
+	%9 = select i1 %6, i32 %8, i32 %7
+	store i32 %9, i32* %eax
+	ret void
+}
+
+define void @_imp_cmov_m32() !addr !{!"0x10000060"} {
+; <label>:0
+	%eax = alloca i32
+	%edx = alloca i32
+	br label %block_10000060
+
+block_10000060:
+	store i32 0, i32* %eax
+	%1 = load i32, i32* %edx
+	%2 = load i32, i32* %edx
+	%3 = and i32 %1, %2
+	%4 = icmp eq i32 %3, 0
+	%5 = load i32, i32* %eax
+	%6 = load i32, i32* @g_20000000
+	%7 = select i1 %4, i32 %6, i32 %5
+	store i32 %7, i32* %eax
+	ret void
+}
+
+define void @_imp_cmov_r16() !addr !{!"0x1000006C"} {
+; <label>:0
+	%eax = alloca i32
+	%ecx = alloca i32
+	%edx = alloca i32
+	%ebx = alloca i32
+	br label %block_1000006C
+
+block_1000006C:
+	store i32 0, i32* %eax
+	%1 = load i32, i32* %ecx
+	%2 = zext i16 42 to i32
+	%3 = and i32 %1, -65536
+	%4 = or i32 %3, %2
+	store i32 %4, i32* %ecx
+	%5 = load i32, i32* %edx
+	%6 = trunc i32 %5 to i16
+	%7 = load i32, i32* %ebx
+	%8 = trunc i32 %7 to i16
+	%9 = sub i16 %6, %8
+	%10 = icmp eq i16 %6, %8
+	%11 = load i32, i32* %eax
+	%12 = trunc i32 %11 to i16
+	%13 = load i32, i32* %ecx
+	%14 = trunc i32 %13 to i16
+	%15 = select i1 %10, i16 %14, i16 %12
+	%16 = load i32, i32* %eax
+	%17 = zext i16 %15 to i32
+	%18 = and i32 %16, -65536
+	%19 = or i32 %18, %17
+	store i32 %19, i32* %eax
+	ret void
+}
@@ -0,0 +1,76 @@
+[BITS 32]
+
+global flags_loop:function
+global flags_carry:function
+global flags_redef:function
+global flags_lt64:function
+global flags_add64:function
+
+section .text
+
+; === [ Status flags live across basic blocks ] ================================
+
+; ZF of DEC is used by the JNZ of the succeeding basic block.
+flags_loop:
+	; 42 = 21 * 2
+	xor     eax, eax
+	mov     ecx, 21
+	jmp     flags_loop_check
+flags_loop_body:
+	add     eax, 2
+	dec     ecx
+flags_loop_check:
+	jnz     flags_loop_body
+	ret
+
+; CF of ADD is left unaffected by INC, and used by the SETC of the succeeding
+; basic block.
+flags_carry:
+	mov     eax, 0xFFFFFFFF
+	mov     ebx, 1
+	add     eax, ebx
+	inc     ecx
+	jmp     flags_carry_set
+flags_carry_set:
+	setc    al
+	ret
+
+; The status flags of CMP are redefined by TEST before being used by the JE of
+; the succeeding basic block, and are thus never stored.
+flags_redef:
+	mov     eax, 42
+	cmp     eax, 1
+	test    eax, eax
+	jmp     flags_redef_check
+flags_redef_check:
+	je      flags_redef_zero
+	ret
+flags_redef_zero:
+	xor     eax, eax
+	ret
+
+; === [ Status flags of add and subtract with carry ] ==========================
+
+; 64-bit signed compare of EDX:EAX and ECX:EBX; SF and OF of SBB, computed from
+; the borrow of CMP, are used by JL.
+flags_lt64:
+	cmp     eax, ebx
+	sbb     edx, ecx
+	jl      flags_lt64_less
+	xor     eax, eax
+	ret
+flags_lt64_less:
+	mov     eax, 1
+	ret
+
+; 64-bit add of ECX:EBX to EDX:EAX; CF of ADC, computed from the carry of ADD,
+; is used by JC.
+flags_add64:
+	add     eax, ebx
+	adc     edx, ecx
+	jc      flags_add64_carry
+	xor     eax, eax
+	ret
+flags_add64_carry:
+	mov     eax, 1
+	ret
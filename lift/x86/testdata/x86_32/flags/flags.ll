@@ -0,0 +1,168 @@
+define void @_imp_flags_loop() !addr !{!"0x10000000"} {
+; <label>:0
+	%eax = alloca i32
+	%ecx = alloca i32
+	%zf = alloca i1
+	br label %block_10000000
+
+block_10000000:
+	%1 = load i32, i32* %eax
+	%2 = load i32, i32* %eax
+	%3 = xor i32 %1, %2
+	store i32 %3, i32* %eax
+	store i32 21, i32* %ecx
+	%4 = icmp eq i32 %3, 0
+	store i1 %4, i1* %zf
+	br label %block_1000000D
+
+block_10000009:
+	%5 = load i32, i32* %eax
+	%6 = add i32 %5, 2
+	store i32 %6, i32* %eax
+	%7 = load i32, i32* %ecx
+	%8 = sub i32 %7, 1
+	store i32 %8, i32* %ecx
+	%9 = icmp ne i32 %7, 1
+	br i1 %9, label %block_10000009, label %block_1000000F, !llvm.loop !{!{!"llvm.loop.header", !"0x1000000D"}, !{!"llvm.loop.latch", !"0x10000009"}, !{!"llvm.loop.exit", !"0x1000000F"}}
+
+block_1000000D:
+	%10 = load i1, i1* %zf
+	%11 = xor i1 %10, true
+	br i1 %11, label %block_10000009, label %block_1000000F
+
+block_1000000F:
+	ret void
+}
+
+define void @_imp_flags_carry() !addr !{!"0x10000010"} {
+; <label>:0
+	%eax = alloca i32
+	%ecx = alloca i32
+	%ebx = alloca i32
+	%cf = alloca i1
+	br label %block_10000010
+
+block_10000010:
+	store i32 -1, i32* %eax
+	store i32 1, i32* %ebx
+	%1 = load i32, i32* %eax
+	%2 = load i32, i32* %ebx
+	%3 = add i32 %1, %2
+	store i32 %3, i32* %eax
+	%4 = load i32, i32* %ecx
+	%5 = add i32 %4, 1
+	store i32 %5, i32* %ecx
+	%6 = icmp ult i32 %3, %1
+	store i1 %6, i1* %cf
+	br label %block_1000001F
+
+block_1000001F:
+	%7 = load i1, i1* %cf
+	%8 = zext i1 %7 to i8
+	%9 = load i32, i32* %eax
+	%10 = zext i8 %8 to i32
+	%11 = and i32 %9, -256
+	%12 = or i32 %11, %10
+	store i32 %12, i32* %eax
+	ret void
+}
+
+define void @_imp_flags_redef() !addr !{!"0x10000023"} {
+; <label>:0
+	%eax = alloca i32
+	%zf = alloca i1
+	br label %block_10000023
+
+block_10000023:
+	store i32 42, i32* %eax
+	%1 = load i32, i32* %eax
+	%2 = sub i32 %1, 1
+	%3 = load i32, i32* %eax
+	%4 = load i32, i32* %eax
+	%5 = and i32 %3, %4
+	%6 = icmp eq i32 %5, 0
+	store i1 %6, i1* %zf
+	br label %block_1000002F
+
+block_1000002F:
+	%7 = load i1, i1* %zf
+	br i1 %7, label %block_10000032, label %block_10000031
+
+block_10000031:
+	ret void
+
+block_10000032:
+	store i32 0, i32* %eax
+	ret void
+}
+
+define void @_imp_flags_lt64() !addr !{!"0x10000035"} {
+; <label>:0
+	%eax = alloca i32
+	%ecx = alloca i32
+	%edx = alloca i32
+	%ebx = alloca i32
+	br label %block_10000035
+
+block_10000035:
+	%1 = load i32, i32* %eax
+	%2 = load i32, i32* %ebx
+	%3 = sub i32 %1, %2
+	%4 = load i32, i32* %edx
+	%5 = load i32, i32* %ecx
+	%6 = icmp ult i32 %1, %2
+	%7 = zext i1 %6 to i32
+	%8 = add i32 %5, %7
+	%9 = sub i32 %4, %8
+	store i32 %9, i32* %edx
+	%10 = icmp slt i32 %9, 0
+	%11 = xor i32 %4, %5
+	%12 = xor i32 %4, %9
+	%13 = and i32 %11, %12
+	%14 = icmp slt i32 %13, 0
+	%15 = icmp ne i1 %10, %14
+	br i1 %15, label %block_1000003E, label %block_1000003B
+
+block_1000003B:
+	store i32 0, i32* %eax
+	ret void
+
+block_1000003E:
+	store i32 1, i32* %eax
+	ret void
+}
+
+define void @_imp_flags_add64() !addr !{!"0x10000044"} {
+; <label>:0
+	%eax = alloca i32
+	%ecx = alloca i32
+	%edx = alloca i32
+	%ebx = alloca i32
+	br label %block_10000044
+
+block_10000044:
+	%1 = load i32, i32* %eax
+	%2 = load i32, i32* %ebx
+	%3 = add i32 %1, %2
+	store i32 %3, i32* %eax
+	%4 = load i32, i32* %edx
+	%5 = load i32, i32* %ecx
+	%6 = icmp ult i32 %3, %1
+	%7 = zext i1 %6 to i32
+	%8 = add i32 %5, %7
+	%9 = add i32 %4, %8
+	store i32 %9, i32* %edx
+	%10 = icmp ult i32 %9, %4
+	%11 = icmp eq i32 %9, %4
+	%12 = and i1 %6, %11
+	%13 = or i1 %10, %12
+	br i1 %13, label %block_1000004D, label %block_1000004A
+
+block_1000004A:
+	store i32 0, i32* %eax
+	ret void
+
+block_1000004D:
+	store i32 1, i32* %eax
+	ret void
+}
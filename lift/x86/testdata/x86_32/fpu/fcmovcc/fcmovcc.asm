@@ -0,0 +1,94 @@
+[BITS 32]
+
+global fcmovb:function
+global fcmove:function
+global fcmovbe:function
+global fcmovu:function
+global fcmovnb:function
+global fcmovne:function
+global fcmovnbe:function
+global fcmovnu:function
+
+section .text
+
+; === [ FCMOVcc - Floating-Point Conditional Move ] ============================
+
+; --- [ FCMOVB ] ---------------------------------------------------------------
+
+fcmovb:
+	fldz
+	fld1
+	cmp     edx, ebx
+	fcmovb  ST0, ST1
+	fstp    ST1
+	ret
+
+; --- [ FCMOVE ] ---------------------------------------------------------------
+
+fcmove:
+	fldz
+	fld1
+	cmp     edx, ebx
+	fcmove  ST0, ST1
+	fstp    ST1
+	ret
+
+; --- [ FCMOVBE ] --------------------------------------------------------------
+
+fcmovbe:
+	fldz
+	fld1
+	cmp     edx, ebx
+	fcmovbe ST0, ST1
+	fstp    ST1
+	ret
+
+; --- [ FCMOVU ] ---------------------------------------------------------------
+
+fcmovu:
+	fldz
+	fld1
+	test    edx, edx
+	fcmovu  ST0, ST1
+	fstp    ST1
+	ret
+
+; --- [ FCMOVNB ] --------------------------------------------------------------
+
+fcmovnb:
+	fldz
+	fld1
+	cmp     edx, ebx
+	fcmovnb ST0, ST1
+	fstp    ST1
+	ret
+
+; --- [ FCMOVNE ] --------------------------------------------------------------
+
+fcmovne:
+	fldz
+	fld1
+	cmp     edx, ebx
+	fcmovne ST0, ST1
+	fstp    ST1
+	ret
+
+; --- [ FCMOVNBE ] -------------------------------------------------------------
+
+fcmovnbe:
+	fldz
+	fld1
+	cmp     edx, ebx
+	fcmovnbe ST0, ST1
+	fstp    ST1
+	ret
+
+; --- [ FCMOVNU ] --------------------------------------------------------------
+
+fcmovnu:
+	fldz
+	fld1
+	test    edx, edx
+	fcmovnu ST0, ST1
+	fstp    ST1
+	ret
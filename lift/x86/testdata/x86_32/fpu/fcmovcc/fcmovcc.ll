@@ -0,0 +1,196 @@
+define void @_imp_fcmovb() !addr !{!"0x10000000"} {
+; <label>:0
+	%edx = alloca i32
+	%ebx = alloca i32
+	%f6 = alloca x86_fp80
+	%f7 = alloca x86_fp80
+	br label %block_10000000
+
+block_10000000:
+	store x86_fp80 0xK00000000000000000000, x86_fp80* %f7
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f6
+	%1 = load i32, i32* %edx
+	%2 = load i32, i32* %ebx
+	%3 = sub i32 %1, %2
+	%4 = icmp ult i32 %1, %2
+	%5 = load x86_fp80, x86_fp80* %f6
+	%6 = load x86_fp80, x86_fp80* %f7
+	%7 = select i1 %4, x86_fp80 %6, x86_fp80 %5
+	store x86_fp80 %7, x86_fp80* %f6
+	%8 = load x86_fp80, x86_fp80* %f6
+	store x86_fp80 %8, x86_fp80* %f7
+	ret void
+}
+
+define void @_imp_fcmove() !addr !{!"0x1000000B"} {
+; <label>:0
+	%edx = alloca i32
+	%ebx = alloca i32
+	%f6 = alloca x86_fp80
+	%f7 = alloca x86_fp80
+	br label %block_1000000B
+
+block_1000000B:
+	store x86_fp80 0xK00000000000000000000, x86_fp80* %f7
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f6
+	%1 = load i32, i32* %edx
+	%2 = load i32, i32* %ebx
+	%3 = sub i32 %1, %2
+	%4 = icmp eq i32 %1, %2
+	%5 = load x86_fp80, x86_fp80* %f6
+	%6 = load x86_fp80, x86_fp80* %f7
+	%7 = select i1 %4, x86_fp80 %6, x86_fp80 %5
+	store x86_fp80 %7, x86_fp80* %f6
+	%8 = load x86_fp80, x86_fp80* %f6
+	store x86_fp80 %8, x86_fp80* %f7
+	ret void
+}
+
+define void @_imp_fcmovbe() !addr !{!"0x10000016"} {
+; <label>:0
+	%edx = alloca i32
+	%ebx = alloca i32
+	%f6 = alloca x86_fp80
+	%f7 = alloca x86_fp80
+	br label %block_10000016
+
+block_10000016:
+	store x86_fp80 0xK00000000000000000000, x86_fp80* %f7
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f6
+	%1 = load i32, i32* %edx
+	%2 = load i32, i32* %ebx
+	%3 = sub i32 %1, %2
+	%4 = icmp ule i32 %1, %2
+	%5 = load x86_fp80, x86_fp80* %f6
+	%6 = load x86_fp80, x86_fp80* %f7
+	%7 = select i1 %4, x86_fp80 %6, x86_fp80 %5
+	store x86_fp80 %7, x86_fp80* %f6
+	%8 = load x86_fp80, x86_fp80* %f6
+	store x86_fp80 %8, x86_fp80* %f7
+	ret void
+}
+
+define void @_imp_fcmovu() !addr !{!"0x10000021"} {
+; <label>:0
+	%edx = alloca i32
+	%f6 = alloca x86_fp80
+	%f7 = alloca x86_fp80
+	br label %block_10000021
+
+block_10000021:
+	store x86_fp80 0xK00000000000000000000, x86_fp80* %f7
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f6
+	%1 = load i32, i32* %edx
+	%2 = load i32, i32* %edx
+	%3 = and i32 %1, %2
+	%4 = trunc i32 %3 to i8
+	%5 = call i8 @llvm.ctpop.i8(i8 %4)
+	%6 = and i8 %5, 1
+	%7 = icmp eq i8 %6, 0
+	%8 = load x86_fp80, x86_fp80* %f6
+	%9 = load x86_fp80, x86_fp80* %f7
+	%10 = select i1 %7, x86_fp80 %9, x86_fp80 %8
+	store x86_fp80 %10, x86_fp80* %f6
+	%11 = load x86_fp80, x86_fp80* %f6
+	store x86_fp80 %11, x86_fp80* %f7
+	ret void
+}
+
+define void @_imp_fcmovnb() !addr !{!"0x1000002C"} {
+; <label>:0
+	%edx = alloca i32
+	%ebx = alloca i32
+	%f6 = alloca x86_fp80
+	%f7 = alloca x86_fp80
+	br label %block_1000002C
+
+block_1000002C:
+	store x86_fp80 0xK00000000000000000000, x86_fp80* %f7
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f6
+	%1 = load i32, i32* %edx
+	%2 = load i32, i32* %ebx
+	%3 = sub i32 %1, %2
+	%4 = icmp uge i32 %1, %2
+	%5 = load x86_fp80, x86_fp80* %f6
+	%6 = load x86_fp80, x86_fp80* %f7
+	%7 = select i1 %4, x86_fp80 %6, x86_fp80 %5
+	store x86_fp80 %7, x86_fp80* %f6
+	%8 = load x86_fp80, x86_fp80* %f6
+	store x86_fp80 %8, x86_fp80* %f7
+	ret void
+}
+
+define void @_imp_fcmovne() !addr !{!"0x10000037"} {
+; <label>:0
+	%edx = alloca i32
+	%ebx = alloca i32
+	%f6 = alloca x86_fp80
+	%f7 = alloca x86_fp80
+	br label %block_10000037
+
+block_10000037:
+	store x86_fp80 0xK00000000000000000000, x86_fp80* %f7
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f6
+	%1 = load i32, i32* %edx
+	%2 = load i32, i32* %ebx
+	%3 = sub i32 %1, %2
+	%4 = icmp ne i32 %1, %2
+	%5 = load x86_fp80, x86_fp80* %f6
+	%6 = load x86_fp80, x86_fp80* %f7
+	%7 = select i1 %4, x86_fp80 %6, x86_fp80 %5
+	store x86_fp80 %7, x86_fp80* %f6
+	%8 = load x86_fp80, x86_fp80* %f6
+	store x86_fp80 %8, x86_fp80* %f7
+	ret void
+}
+
+define void @_imp_fcmovnbe() !addr !{!"0x10000042"} {
+; <label>:0
+	%edx = alloca i32
+	%ebx = alloca i32
+	%f6 = alloca x86_fp80
+	%f7 = alloca x86_fp80
+	br label %block_10000042
+
+block_10000042:
+	store x86_fp80 0xK00000000000000000000, x86_fp80* %f7
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f6
+	%1 = load i32, i32* %edx
+	%2 = load i32, i32* %ebx
+	%3 = sub i32 %1, %2
+	%4 = icmp ugt i32 %1, %2
+	%5 = load x86_fp80, x86_fp80* %f6
+	%6 = load x86_fp80, x86_fp80* %f7
+	%7 = select i1 %4, x86_fp80 %6, x86_fp80 %5
+	store x86_fp80 %7, x86_fp80* %f6
+	%8 = load x86_fp80, x86_fp80* %f6
+	store x86_fp80 %8, x86_fp80* %f7
+	ret void
+}
+
+define void @_imp_fcmovnu() !addr !{!"0x1000004D"} {
+; <label>:0
+	%edx = alloca i32
+	%f6 = alloca x86_fp80
+	%f7 = alloca x86_fp80
+	br label %block_1000004D
+
+block_1000004D:
+	store x86_fp80 0xK00000000000000000000, x86_fp80* %f7
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f6
+	%1 = load i32, i32* %edx
+	%2 = load i32, i32* %edx
+	%3 = and i32 %1, %2
+	%4 = trunc i32 %3 to i8
+	%5 = call i8 @llvm.ctpop.i8(i8 %4)
+	%6 = and i8 %5, 1
+	%7 = icmp eq i8 %6, 0
+	%8 = xor i1 %7, true
+	%9 = load x86_fp80, x86_fp80* %f6
+	%10 = load x86_fp80, x86_fp80* %f7
+	%11 = select i1 %8, x86_fp80 %10, x86_fp80 %9
+	store x86_fp80 %11, x86_fp80* %f6
+	%12 = load x86_fp80, x86_fp80* %f6
+	store x86_fp80 %12, x86_fp80* %f7
+	ret void
+}
@@ -0,0 +1,126 @@
+define void @_imp_fcomi() !addr !{!"0x10000000"} {
+; <label>:0
+	%eax = alloca i32
+	%f6 = alloca x86_fp80
+	%f7 = alloca x86_fp80
+	br label %block_10000000
+
+block_10000000:
+	store i32 0, i32* %eax
+	store x86_fp80 0xK4000C90FDAA22168C000, x86_fp80* %f7
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f6
+	%1 = load x86_fp80, x86_fp80* %f6
+	%2 = load x86_fp80, x86_fp80* %f7
+	%3 = fcmp ult x86_fp80 %1, %2
+	%4 = zext i1 %3 to i8
+	%5 = load i32, i32* %eax
+	%6 = zext i8 %4 to i32
+	%7 = and i32 %5, -256
+	%8 = or i32 %7, %6
+	store i32 %8, i32* %eax
+	%9 = load x86_fp80, x86_fp80* %f6
+	store x86_fp80 %9, x86_fp80* %f6
+	%10 = load x86_fp80, x86_fp80* %f7
+	store x86_fp80 %10, x86_fp80* %f7
+	ret void
+}
+
+define void @_imp_fcomip() !addr !{!"0x10000010"} {
+; <label>:0
+	%eax = alloca i32
+	%f6 = alloca x86_fp80
+	%f7 = alloca x86_fp80
+	br label %block_10000010
+
+block_10000010:
+	store i32 0, i32* %eax
+	store x86_fp80 0xK4000C90FDAA22168C000, x86_fp80* %f7
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f6
+	%1 = load x86_fp80, x86_fp80* %f6
+	%2 = load x86_fp80, x86_fp80* %f7
+	%3 = fcmp ueq x86_fp80 %1, %2
+	%4 = zext i1 %3 to i8
+	%5 = load i32, i32* %eax
+	%6 = zext i8 %4 to i32
+	%7 = and i32 %5, -256
+	%8 = or i32 %7, %6
+	store i32 %8, i32* %eax
+	%9 = load x86_fp80, x86_fp80* %f7
+	store x86_fp80 %9, x86_fp80* %f7
+	ret void
+}
+
+define void @_imp_fucomi() !addr !{!"0x1000001E"} {
+; <label>:0
+	%eax = alloca i32
+	%f6 = alloca x86_fp80
+	%f7 = alloca x86_fp80
+	br label %block_1000001E
+
+block_1000001E:
+	store i32 0, i32* %eax
+	store x86_fp80 0xK00000000000000000000, x86_fp80* %f7
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f6
+	%1 = load x86_fp80, x86_fp80* %f6
+	%2 = load x86_fp80, x86_fp80* %f7
+	%3 = fcmp uno x86_fp80 %1, %2
+	%4 = zext i1 %3 to i8
+	%5 = load i32, i32* %eax
+	%6 = zext i8 %4 to i32
+	%7 = and i32 %5, -256
+	%8 = or i32 %7, %6
+	store i32 %8, i32* %eax
+	%9 = load x86_fp80, x86_fp80* %f6
+	store x86_fp80 %9, x86_fp80* %f6
+	%10 = load x86_fp80, x86_fp80* %f7
+	store x86_fp80 %10, x86_fp80* %f7
+	ret void
+}
+
+define void @_imp_fucomip() !addr !{!"0x1000002E"} {
+; <label>:0
+	%eax = alloca i32
+	%f6 = alloca x86_fp80
+	%f7 = alloca x86_fp80
+	br label %block_1000002E
+
+block_1000002E:
+	store i32 0, i32* %eax
+	store x86_fp80 0xK00000000000000000000, x86_fp80* %f7
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f6
+	%1 = load x86_fp80, x86_fp80* %f6
+	%2 = load x86_fp80, x86_fp80* %f7
+	%3 = fcmp ogt x86_fp80 %1, %2
+	%4 = zext i1 %3 to i8
+	%5 = load i32, i32* %eax
+	%6 = zext i8 %4 to i32
+	%7 = and i32 %5, -256
+	%8 = or i32 %7, %6
+	store i32 %8, i32* %eax
+	%9 = load x86_fp80, x86_fp80* %f7
+	store x86_fp80 %9, x86_fp80* %f7
+	ret void
+}
+
+define void @_imp_fcomi_max() !addr !{!"0x1000003C"} {
+; <label>:0
+	%f6 = alloca x86_fp80
+	%f7 = alloca x86_fp80
+	br label %block_1000003C
+
+block_1000003C:
+	store x86_fp80 0xK4000C90FDAA22168C000, x86_fp80* %f7
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f6
+	%1 = load x86_fp80, x86_fp80* %f6
+	%2 = load x86_fp80, x86_fp80* %f7
+	%3 = fcmp ult x86_fp80 %1, %2
+	%4 = load x86_fp80, x86_fp80* %f6
+	%5 = load x86_fp80, x86_fp80* %f7
+	%6 = select i1 %3, x86_fp80 %5, x86_fp80 %4
+	store x86_fp80 %6, x86_fp80* %f6
+	%7 = load x86_fp80, x86_fp80* %f6
+	store x86_fp80 %7, x86_fp80* %f7
+	%8 = load x86_fp80, x86_fp80* %f7
+	store x86_fp80 %8, x86_fp80* %f7
+	ret void
+}
@@ -0,0 +1,194 @@
+define void @_imp_fstack_balanced() !addr !{!"0x10000000"} {
+; <label>:0
+	%f6 = alloca x86_fp80
+	%f7 = alloca x86_fp80
+	br label %block_10000000
+
+block_10000000:
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f7
+	store x86_fp80 0xK00000000000000000000, x86_fp80* %f6
+	%1 = load x86_fp80, x86_fp80* %f7
+	%2 = load x86_fp80, x86_fp80* %f6
+	%3 = fadd x86_fp80 %1, %2
+	store x86_fp80 %3, x86_fp80* %f7
+	%4 = load x86_fp80, x86_fp80* %f7
+	store x86_fp80 %4, x86_fp80* %f7
+	ret void
+}
+
+define void @_imp_fstack_branch() !addr !{!"0x10000009"} {
+; <label>:0
+	%eax = alloca i32
+	%f6 = alloca x86_fp80
+	%f7 = alloca x86_fp80
+	br label %block_10000009
+
+block_10000009:
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f7
+	%1 = load i32, i32* %eax
+	%2 = load i32, i32* %eax
+	%3 = and i32 %1, %2
+	%4 = icmp eq i32 %3, 0
+	br i1 %4, label %block_10000015, label %block_1000000F
+
+block_1000000F:
+	store x86_fp80 0xK4000C90FDAA22168C000, x86_fp80* %f6
+	%5 = load x86_fp80, x86_fp80* %f7
+	%6 = load x86_fp80, x86_fp80* %f6
+	%7 = fadd x86_fp80 %5, %6
+	store x86_fp80 %7, x86_fp80* %f7
+	br label %block_10000019
+
+block_10000015:
+	store x86_fp80 0xK00000000000000000000, x86_fp80* %f6
+	%8 = load x86_fp80, x86_fp80* %f7
+	%9 = load x86_fp80, x86_fp80* %f6
+	%10 = fadd x86_fp80 %8, %9
+	store x86_fp80 %10, x86_fp80* %f7
+	%11 = load x86_fp80, x86_fp80* %f7
+	store x86_fp80 %11, x86_fp80* %f7
+	ret void
+
+block_10000019:
+	%12 = load x86_fp80, x86_fp80* %f7
+	store x86_fp80 %12, x86_fp80* %f7
+	ret void
+}
+
+define void @_imp_fstack_dynamic() !addr !{!"0x1000001C"} {
+; <label>:0
+	%ecx = alloca i32
+	%f0 = alloca x86_fp80
+	%f1 = alloca x86_fp80
+	%f2 = alloca x86_fp80
+	%f3 = alloca x86_fp80
+	%f4 = alloca x86_fp80
+	%f5 = alloca x86_fp80
+	%f6 = alloca x86_fp80
+	%f7 = alloca x86_fp80
+	%st = alloca i8
+	store i8 0, i8* %st
+	br label %block_1000001C
+
+block_1000001C:
+	store i32 3, i32* %ecx
+	%1 = load i8, i8* %st
+	%2 = add i8 %1, 7
+	%3 = and i8 %2, 7
+	store i8 %3, i8* %st
+	%4 = load i8, i8* %st
+	switch i8 %4, label %13 [
+		i8 0, label %5
+		i8 1, label %6
+		i8 2, label %7
+		i8 3, label %8
+		i8 4, label %9
+		i8 5, label %10
+		i8 6, label %11
+		i8 7, label %12
+	]
+
+; <label>:5
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f0
+	br label %14
+
+; <label>:6
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f1
+	br label %14
+
+; <label>:7
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f2
+	br label %14
+
+; <label>:8
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f3
+	br label %14
+
+; <label>:9
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f4
+	br label %14
+
+; <label>:10
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f5
+	br label %14
+
+; <label>:11
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f6
+	br label %14
+
+; <label>:12
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f7
+	br label %14
+
+; <label>:13
+	unreachable
+
+; <label>:14
+	%15 = load i32, i32* %ecx
+	%16 = sub i32 %15, 1
+	store i32 %16, i32* %ecx
+	%17 = icmp ne i32 %15, 1
+	br i1 %17, label %block_10000021, label %block_10000026
+
+block_10000021:
+	%18 = load i8, i8* %st
+	%19 = add i8 %18, 7
+	%20 = and i8 %19, 7
+	store i8 %20, i8* %st
+	%21 = load i8, i8* %st
+	switch i8 %21, label %30 [
+		i8 0, label %22
+		i8 1, label %23
+		i8 2, label %24
+		i8 3, label %25
+		i8 4, label %26
+		i8 5, label %27
+		i8 6, label %28
+		i8 7, label %29
+	]
+
+; <label>:22
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f0
+	br label %31
+
+; <label>:23
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f1
+	br label %31
+
+; <label>:24
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f2
+	br label %31
+
+; <label>:25
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f3
+	br label %31
+
+; <label>:26
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f4
+	br label %31
+
+; <label>:27
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f5
+	br label %31
+
+; <label>:28
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f6
+	br label %31
+
+; <label>:29
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f7
+	br label %31
+
+; <label>:30
+	unreachable
+
+; <label>:31
+	%32 = load i32, i32* %ecx
+	%33 = sub i32 %32, 1
+	store i32 %33, i32* %ecx
+	%34 = icmp ne i32 %32, 1
+	br i1 %34, label %block_10000021, label %block_10000026, !llvm.loop !{!{!"llvm.loop.header", !"0x10000021"}, !{!"llvm.loop.latch", !"0x10000021"}, !{!"llvm.loop.exit", !"0x10000026"}}
+
+block_10000026:
+	ret void
+}
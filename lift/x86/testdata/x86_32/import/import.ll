@@ -1,4 +1,4 @@
-define void @_start() !addr !{!"0x400000"} {
+define void @_imp__start() !addr !{!"0x400000"} {
 ; <label>:0
 	%esp = alloca i32
 	%esp_-4 = alloca i32
@@ -7,6 +7,8 @@ define void @_start() !addr !{!"0x400000"} {
 block_400000:
 	%1 = load i32, i32* %esp
 	store i32 42, i32* %esp_-4
-	call void @exit()
+	%2 = load i32, i32* %esp
+	%3 = load i32, i32* %esp_-4
+	call void @_imp_exit(i32 %3)
 	ret void
 }
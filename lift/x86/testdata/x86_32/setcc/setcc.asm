@@ -0,0 +1,76 @@
+[BITS 32]
+
+global setz:function
+global setl:function
+global setbe:function
+global setc_zx:function
+global sbb_mask:function
+global sbb_neg:function
+global sbb_carry:function
+
+section .text
+
+; === [ SETcc - Set Byte on Condition ] ========================================
+
+; --- [ SETZ ] -----------------------------------------------------------------
+
+; Boolean of integer comparison; x == y.
+setz:
+	xor     eax, eax
+	cmp     edx, ebx
+	setz    al
+	ret
+
+; --- [ SETL ] -----------------------------------------------------------------
+
+; Boolean of signed integer comparison; x < y.
+setl:
+	xor     eax, eax
+	cmp     edx, ebx
+	setl    al
+	ret
+
+; --- [ SETBE ] ----------------------------------------------------------------
+
+; Boolean of unsigned integer comparison; x <= y.
+setbe:
+	xor     eax, eax
+	cmp     edx, ebx
+	setbe   al
+	ret
+
+; --- [ SETC ] -----------------------------------------------------------------
+
+; Boolean of carry, zero-extended by MOVZX.
+setc_zx:
+	add     edx, ebx
+	setc    cl
+	movzx   eax, cl
+	ret
+
+; === [ SBB flag idioms ] ======================================================
+
+; --- [ SBB r, r ] -------------------------------------------------------------
+
+; All-ones mask if x < y (unsigned); 0 otherwise.
+sbb_mask:
+	cmp     edx, ebx
+	sbb     eax, eax
+	ret
+
+; --- [ NEG; SBB r, r ] --------------------------------------------------------
+
+; All-ones mask if x != 0; 0 otherwise.
+sbb_neg:
+	neg     edx
+	sbb     eax, eax
+	ret
+
+; --- [ SBB r, imm ] -----------------------------------------------------------
+
+; Subtraction with borrow of a preceding comparison.
+sbb_carry:
+	mov     eax, 10
+	cmp     edx, ebx
+	sbb     eax, 1
+	ret
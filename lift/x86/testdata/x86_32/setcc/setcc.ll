@@ -0,0 +1,146 @@
+define void @_imp_setz() !addr !{!"0x10000000"} {
+; <label>:0
+	%eax = alloca i32
+	%edx = alloca i32
+	%ebx = alloca i32
+	br label %block_10000000
+
+block_10000000:
+	store i32 0, i32* %eax
+	%1 = load i32, i32* %edx
+	%2 = load i32, i32* %ebx
+	%3 = sub i32 %1, %2
+	%4 = icmp eq i32 %1, %2
+	%5 = zext i1 %4 to i8
+	%6 = load i32, i32* %eax
+	%7 = zext i8 %5 to i32
+	%8 = and i32 %6, -256
+	%9 = or i32 %8, %7
+	store i32 %9, i32* %eax
+	ret void
+}
+
+define void @_imp_setl() !addr !{!"0x10000008"} {
+; <label>:0
+	%eax = alloca i32
+	%edx = alloca i32
+	%ebx = alloca i32
+	br label %block_10000008
+
+block_10000008:
+	store i32 0, i32* %eax
+	%1 = load i32, i32* %edx
+	%2 = load i32, i32* %ebx
+	%3 = sub i32 %1, %2
+	%4 = icmp slt i32 %1, %2
+	%5 = zext i1 %4 to i8
+	%6 = load i32, i32* %eax
+	%7 = zext i8 %5 to i32
+	%8 = and i32 %6, -256
+	%9 = or i32 %8, %7
+	store i32 %9, i32* %eax
+	ret void
+}
+
+define void @_imp_setbe() !addr !{!"0x10000010"} {
+; <label>:0
+	%eax = alloca i32
+	%edx = alloca i32
+	%ebx = alloca i32
+	br label %block_10000010
+
+block_10000010:
+	store i32 0, i32* %eax
+	%1 = load i32, i32* %edx
+	%2 = load i32, i32* %ebx
+	%3 = sub i32 %1, %2
+	%4 = icmp ule i32 %1, %2
+	%5 = zext i1 %4 to i8
+	%6 = load i32, i32* %eax
+	%7 = zext i8 %5 to i32
+	%8 = and i32 %6, -256
+	%9 = or i32 %8, %7
+	store i32 %9, i32* %eax
+	ret void
+}
+
+define void @_imp_setc_zx() !addr !{!"0x10000018"} {
+; <label>:0
+	%eax = alloca i32
+	%ecx = alloca i32
+	%edx = alloca i32
+	%ebx = alloca i32
+	br label %block_10000018
+
+block_10000018:
+	%1 = load i32, i32* %edx
+	%2 = load i32, i32* %ebx
+	%3 = add i32 %1, %2
+	store i32 %3, i32* %edx
+	%4 = icmp ult i32 %3, %1
+	%5 = zext i1 %4 to i8
+	%6 = load i32, i32* %ecx
+	%7 = zext i8 %5 to i32
+	%8 = and i32 %6, -256
+	%9 = or i32 %8, %7
+	store i32 %9, i32* %ecx
+	%10 = load i32, i32* %ecx
+	%11 = trunc i32 %10 to i8
+	%12 = zext i8 %11 to i32
+	store i32 %12, i32* %eax
+	ret void
+}
+
+define void @_imp_sbb_mask() !addr !{!"0x10000021"} {
+; <label>:0
+	%eax = alloca i32
+	%edx = alloca i32
+	%ebx = alloca i32
+	br label %block_10000021
+
+block_10000021:
+	%1 = load i32, i32* %edx
+	%2 = load i32, i32* %ebx
+	%3 = sub i32 %1, %2
+	%4 = icmp ult i32 %1, %2
+	%5 = sext i1 %4 to i32
+	store i32 %5, i32* %eax
+	ret void
+}
+
+define void @_imp_sbb_neg() !addr !{!"0x10000026"} {
+; <label>:0
+	%eax = alloca i32
+	%edx = alloca i32
+	br label %block_10000026
+
+block_10000026:
+	%1 = load i32, i32* %edx
+	%2 = sub i32 0, %1
+	store i32 %2, i32* %edx
+	%3 = icmp ult i32 0, %1
+	%4 = sext i1 %3 to i32
+	store i32 %4, i32* %eax
+	ret void
+}
+
+define void @_imp_sbb_carry() !addr !{!"0x1000002B"} {
+; <label>:0
+	%eax = alloca i32
+	%edx = alloca i32
+	%ebx = alloca i32
+	br label %block_1000002B
+
+block_1000002B:
+	store i32 10, i32* %eax
+	%1 = load i32, i32* %edx
+	%2 = load i32, i32* %ebx
+	%3 = sub i32 %1, %2
+	%4 = load i32, i32* %eax
+	%5 = icmp ult i32 %1, %2
+	%6 = zext i1 %5 to i32
+	%7 = add i32 1, %6
+	%8 = sub i32 %4, %7
+	store i32 %8, i32* %eax
+	ret void
+}
@@ -0,0 +1,87 @@
+[BITS 32]
+
+global shld_imm:function
+global shld_cl:function
+global shrd_imm:function
+global shrd_cl:function
+global rcl_1:function
+global rcr_cl:function
+global shl64:function
+global shr64:function
+global sar64:function
+
+section .text
+
+; === [ SHLD/SHRD - Double Precision Shift ] ===================================
+
+; --- [ SHLD r/m32, r32, imm8 ] ------------------------------------------------
+
+shld_imm:
+	mov     eax, edx
+	shld    eax, ebx, 4
+	ret
+
+; --- [ SHLD r/m32, r32, CL ] --------------------------------------------------
+
+shld_cl:
+	mov     eax, edx
+	shld    eax, ebx, cl
+	ret
+
+; --- [ SHRD r/m32, r32, imm8 ] ------------------------------------------------
+
+shrd_imm:
+	mov     eax, edx
+	shrd    eax, ebx, 4
+	ret
+
+; --- [ SHRD r/m32, r32, CL ] --------------------------------------------------
+
+shrd_cl:
+	mov     eax, edx
+	shrd    eax, ebx, cl
+	ret
+
+; === [ RCL/RCR - Rotate Through Carry ] =======================================
+
+; --- [ RCL r/m32, 1 ] ---------------------------------------------------------
+
+rcl_1:
+	mov     eax, edx
+	cmp     edx, ebx
+	rcl     eax, 1
+	ret
+
+; --- [ RCR r/m32, CL ] --------------------------------------------------------
+
+rcr_cl:
+	mov     eax, edx
+	cmp     edx, ebx
+	rcr     eax, cl
+	ret
+
+; === [ Paired 64-bit shift idioms ] ===========================================
+
+; --- [ SHLD; SHL ] ------------------------------------------------------------
+
+; EDX:EAX << CL
+shl64:
+	shld    edx, eax, cl
+	shl     eax, cl
+	ret
+
+; --- [ SHRD; SHR ] ------------------------------------------------------------
+
+; EDX:EAX >> CL (logical)
+shr64:
+	shrd    eax, edx, cl
+	shr     edx, cl
+	ret
+
+; --- [ SHRD; SAR ] ------------------------------------------------------------
+
+; EDX:EAX >> CL (arithmetic)
+sar64:
+	shrd    eax, edx, cl
+	sar     edx, cl
+	ret
@@ -0,0 +1,264 @@
+define void @_imp_shld_imm() !addr !{!"0x10000000"} {
+; <label>:0
+	%eax = alloca i32
+	%edx = alloca i32
+	%ebx = alloca i32
+	br label %block_10000000
+
+block_10000000:
+	%1 = load i32, i32* %edx
+	store i32 %1, i32* %eax
+	%2 = load i32, i32* %eax
+	%3 = load i32, i32* %ebx
+	%4 = zext i32 4 to i64
+	%5 = zext i32 %2 to i64
+	%6 = shl i64 %5, 32
+	%7 = zext i32 %3 to i64
+	%8 = or i64 %6, %7
+	%9 = shl i64 %8, %4
+	%10 = lshr i64 %9, 32
+	%11 = trunc i64 %10 to i32
+	store i32 %11, i32* %eax
+	ret void
+}
+
+define void @_imp_shld_cl() !addr !{!"0x10000007"} {
+; <label>:0
+	%eax = alloca i32
+	%ecx = alloca i32
+	%edx = alloca i32
+	%ebx = alloca i32
+	br label %block_10000007
+
+block_10000007:
+	%1 = load i32, i32* %edx
+	store i32 %1, i32* %eax
+	%2 = load i32, i32* %eax
+	%3 = load i32, i32* %ebx
+	%4 = load i32, i32* %ecx
+	%5 = trunc i32 %4 to i8
+	%6 = zext i8 %5 to i32
+	%7 = and i32 %6, 31
+	%8 = zext i32 %7 to i64
+	%9 = zext i32 %2 to i64
+	%10 = shl i64 %9, 32
+	%11 = zext i32 %3 to i64
+	%12 = or i64 %10, %11
+	%13 = shl i64 %12, %8
+	%14 = lshr i64 %13, 32
+	%15 = trunc i64 %14 to i32
+	store i32 %15, i32* %eax
+	ret void
+}
+
+define void @_imp_shrd_imm() !addr !{!"0x1000000D"} {
+; <label>:0
+	%eax = alloca i32
+	%edx = alloca i32
+	%ebx = alloca i32
+	br label %block_1000000D
+
+block_1000000D:
+	%1 = load i32, i32* %edx
+	store i32 %1, i32* %eax
+	%2 = load i32, i32* %eax
+	%3 = load i32, i32* %ebx
+	%4 = zext i32 4 to i64
+	%5 = zext i32 %3 to i64
+	%6 = shl i64 %5, 32
+	%7 = zext i32 %2 to i64
+	%8 = or i64 %6, %7
+	%9 = lshr i64 %8, %4
+	%10 = trunc i64 %9 to i32
+	store i32 %10, i32* %eax
+	ret void
+}
+
+define void @_imp_shrd_cl() !addr !{!"0x10000014"} {
+; <label>:0
+	%eax = alloca i32
+	%ecx = alloca i32
+	%edx = alloca i32
+	%ebx = alloca i32
+	br label %block_10000014
+
+block_10000014:
+	%1 = load i32, i32* %edx
+	store i32 %1, i32* %eax
+	%2 = load i32, i32* %eax
+	%3 = load i32, i32* %ebx
+	%4 = load i32, i32* %ecx
+	%5 = trunc i32 %4 to i8
+	%6 = zext i8 %5 to i32
+	%7 = and i32 %6, 31
+	%8 = zext i32 %7 to i64
+	%9 = zext i32 %3 to i64
+	%10 = shl i64 %9, 32
+	%11 = zext i32 %2 to i64
+	%12 = or i64 %10, %11
+	%13 = lshr i64 %12, %8
+	%14 = trunc i64 %13 to i32
+	store i32 %14, i32* %eax
+	ret void
+}
+
+define void @_imp_rcl_1() !addr !{!"0x1000001A"} {
+; <label>:0
+	%eax = alloca i32
+	%edx = alloca i32
+	%ebx = alloca i32
+	%cf = alloca i1
+	br label %block_1000001A
+
+block_1000001A:
+	%1 = load i32, i32* %edx
+	store i32 %1, i32* %eax
+	%2 = load i32, i32* %edx
+	%3 = load i32, i32* %ebx
+	%4 = sub i32 %2, %3
+	%5 = load i32, i32* %eax
+	%6 = icmp ult i32 %2, %3
+	%7 = zext i1 %6 to i33
+	%8 = shl i33 %7, 32
+	%9 = zext i32 %5 to i33
+	%10 = or i33 %8, %9
+	%11 = zext i32 1 to i33
+	%12 = urem i33 %11, 33
+	%13 = sub i33 33, %12
+	%14 = shl i33 %10, %12
+	%15 = lshr i33 %10, %13
+	%16 = or i33 %14, %15
+	%17 = icmp eq i33 %12, 0
+	%18 = select i1 %17, i33 %10, i33 %16
+	%19 = trunc i33 %18 to i32
+	store i32 %19, i32* %eax
+	%20 = lshr i33 %18, 32
+	%21 = trunc i33 %20 to i1
+	store i1 %21, i1* %cf
+	ret void
+}
+
+define void @_imp_rcr_cl() !addr !{!"0x10000021"} {
+; <label>:0
+	%eax = alloca i32
+	%ecx = alloca i32
+	%edx = alloca i32
+	%ebx = alloca i32
+	%cf = alloca i1
+	br label %block_10000021
+
+block_10000021:
+	%1 = load i32, i32* %edx
+	store i32 %1, i32* %eax
+	%2 = load i32, i32* %edx
+	%3 = load i32, i32* %ebx
+	%4 = sub i32 %2, %3
+	%5 = load i32, i32* %eax
+	%6 = icmp ult i32 %2, %3
+	%7 = zext i1 %6 to i33
+	%8 = shl i33 %7, 32
+	%9 = zext i32 %5 to i33
+	%10 = or i33 %8, %9
+	%11 = load i32, i32* %ecx
+	%12 = trunc i32 %11 to i8
+	%13 = zext i8 %12 to i32
+	%14 = and i32 %13, 31
+	%15 = zext i32 %14 to i33
+	%16 = urem i33 %15, 33
+	%17 = sub i33 33, %16
+	%18 = lshr i33 %10, %16
+	%19 = shl i33 %10, %17
+	%20 = or i33 %18, %19
+	%21 = icmp eq i33 %16, 0
+	%22 = select i1 %21, i33 %10, i33 %20
+	%23 = trunc i33 %22 to i32
+	store i32 %23, i32* %eax
+	%24 = lshr i33 %22, 32
+	%25 = trunc i33 %24 to i1
+	store i1 %25, i1* %cf
+	ret void
+}
+
+define void @_imp_shl64() !addr !{!"0x10000028"} {
+; <label>:0
+	%eax = alloca i32
+	%ecx = alloca i32
+	%edx = alloca i32
+	br label %block_10000028
+
+block_10000028:
+	%1 = load i32, i32* %edx
+	%2 = zext i32 %1 to i64
+	%3 = shl i64 %2, 32
+	%4 = load i32, i32* %eax
+	%5 = zext i32 %4 to i64
+	%6 = or i64 %3, %5
+	%7 = load i32, i32* %ecx
+	%8 = trunc i32 %7 to i8
+	%9 = zext i8 %8 to i32
+	%10 = and i32 %9, 31
+	%11 = zext i32 %10 to i64
+	%12 = shl i64 %6, %11
+	%13 = trunc i64 %12 to i32
+	store i32 %13, i32* %eax
+	%14 = lshr i64 %12, 32
+	%15 = trunc i64 %14 to i32
+	store i32 %15, i32* %edx
+	ret void
+}
+
+define void @_imp_shr64() !addr !{!"0x1000002E"} {
+; <label>:0
+	%eax = alloca i32
+	%ecx = alloca i32
+	%edx = alloca i32
+	br label %block_1000002E
+
+block_1000002E:
+	%1 = load i32, i32* %edx
+	%2 = zext i32 %1 to i64
+	%3 = shl i64 %2, 32
+	%4 = load i32, i32* %eax
+	%5 = zext i32 %4 to i64
+	%6 = or i64 %3, %5
+	%7 = load i32, i32* %ecx
+	%8 = trunc i32 %7 to i8
+	%9 = zext i8 %8 to i32
+	%10 = and i32 %9, 31
+	%11 = zext i32 %10 to i64
+	%12 = lshr i64 %6, %11
+	%13 = trunc i64 %12 to i32
+	store i32 %13, i32* %eax
+	%14 = lshr i64 %12, 32
+	%15 = trunc i64 %14 to i32
+	store i32 %15, i32* %edx
+	ret void
+}
+
+define void @_imp_sar64() !addr !{!"0x10000034"} {
+; <label>:0
+	%eax = alloca i32
+	%ecx = alloca i32
+	%edx = alloca i32
+	br label %block_10000034
+
+block_10000034:
+	%1 = load i32, i32* %edx
+	%2 = zext i32 %1 to i64
+	%3 = shl i64 %2, 32
+	%4 = load i32, i32* %eax
+	%5 = zext i32 %4 to i64
+	%6 = or i64 %3, %5
+	%7 = load i32, i32* %ecx
+	%8 = trunc i32 %7 to i8
+	%9 = zext i8 %8 to i32
+	%10 = and i32 %9, 31
+	%11 = zext i32 %10 to i64
+	%12 = ashr i64 %6, %11
+	%13 = trunc i64 %12 to i32
+	store i32 %13, i32* %eax
+	%14 = lshr i64 %12, 32
+	%15 = trunc i64 %14 to i32
+	store i32 %15, i32* %edx
+	ret void
+}
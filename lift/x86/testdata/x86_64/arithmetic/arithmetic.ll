@@ -1,205 +1,459 @@
-define void @div_r8() !addr !{!"0x10000000"} {
+define void @_imp_div_r8() !addr !{!"0x10000000"} {
 ; <label>:0
-	%al = alloca i8
-	%bl = alloca i8
-	%ah = alloca i8
-	%ax = alloca i16
 	%rax = alloca i64
+	%rbx = alloca i64
 	br label %block_10000000
 
 block_10000000:
-	store i32 84, i16* %ax
-	store i32 2, i8* %bl
-	%1 = load i8, i8* %bl
-	%2 = load i16, i16* %ax
-	%3 = zext i8 %1 to i16
-	%4 = udiv i16 %2, %3
-	%5 = urem i16 %2, %3
-	store i16 %4, i8* %al
-	store i16 %5, i8* %ah
-	%6 = load i64, i64* %rax
-	%7 = and i64 %6, 255
-	store i64 %7, i64* %rax
+	%1 = load i64, i64* %rax
+	%2 = zext i16 84 to i64
+	%3 = and i64 %1, -65536
+	%4 = or i64 %3, %2
+	store i64 %4, i64* %rax
+	%5 = load i64, i64* %rbx
+	%6 = zext i8 2 to i64
+	%7 = and i64 %5, -256
+	%8 = or i64 %7, %6
+	store i64 %8, i64* %rbx
+	%9 = load i64, i64* %rbx
+	%10 = trunc i64 %9 to i8
+	%11 = load i64, i64* %rax
+	%12 = trunc i64 %11 to i16
+	%13 = icmp eq i8 %10, 0
+	br i1 %13, label %14, label %15
+
+; <label>:14
+	call void @llvm.trap()
+	unreachable
+
+; <label>:15
+	%16 = zext i8 %10 to i16
+	%17 = udiv i16 %12, %16
+	%18 = urem i16 %12, %16
+	%19 = trunc i16 %17 to i8
+	%20 = zext i8 %19 to i16
+	%21 = icmp ne i16 %17, %20
+	br i1 %21, label %22, label %23
+
+; <label>:22
+	call void @llvm.trap()
+	unreachable
+
+; <label>:23
+	%24 = trunc i16 %18 to i8
+	%25 = load i64, i64* %rax
+	%26 = zext i8 %19 to i64
+	%27 = and i64 %25, -256
+	%28 = or i64 %27, %26
+	store i64 %28, i64* %rax
+	%29 = load i64, i64* %rax
+	%30 = zext i8 %24 to i64
+	%31 = shl i64 %30, 8
+	%32 = and i64 %29, -65281
+	%33 = or i64 %32, %31
+	store i64 %33, i64* %rax
+	%34 = load i64, i64* %rax
+	%35 = and i64 %34, 255
+	store i64 %35, i64* %rax
 	ret void
 }
 
-define void @div_m8() !addr !{!"0x1000000F"} {
+define void @_imp_div_m8() !addr !{!"0x1000000F"} {
 ; <label>:0
-	%al = alloca i8
-	%ah = alloca i8
-	%ax = alloca i16
 	%rax = alloca i64
 	br label %block_1000000F
 
 block_1000000F:
-	store i32 84, i16* %ax
-	store i32 2, i8* @m8
-	%1 = load i8, i8* @m8
-	%2 = load i16, i16* %ax
-	%3 = zext i8 %1 to i16
-	%4 = udiv i16 %2, %3
-	%5 = urem i16 %2, %3
-	store i16 %4, i8* %al
-	store i16 %5, i8* %ah
+	%1 = load i64, i64* %rax
+	%2 = zext i16 84 to i64
+	%3 = and i64 %1, -65536
+	%4 = or i64 %3, %2
+	store i64 %4, i64* %rax
+	store i8 2, i8* @m8
+	%5 = load i8, i8* @m8
 	%6 = load i64, i64* %rax
-	%7 = and i64 %6, 255
-	store i64 %7, i64* %rax
+	%7 = trunc i64 %6 to i16
+	%8 = icmp eq i8 %5, 0
+	br i1 %8, label %9, label %10
+
+; <label>:9
+	call void @llvm.trap()
+	unreachable
+
+; <label>:10
+	%11 = zext i8 %5 to i16
+	%12 = udiv i16 %7, %11
+	%13 = urem i16 %7, %11
+	%14 = trunc i16 %12 to i8
+	%15 = zext i8 %14 to i16
+	%16 = icmp ne i16 %12, %15
+	br i1 %16, label %17, label %18
+
+; <label>:17
+	call void @llvm.trap()
+	unreachable
+
+; <label>:18
+	%19 = trunc i16 %13 to i8
+	%20 = load i64, i64* %rax
+	%21 = zext i8 %14 to i64
+	%22 = and i64 %20, -256
+	%23 = or i64 %22, %21
+	store i64 %23, i64* %rax
+	%24 = load i64, i64* %rax
+	%25 = zext i8 %19 to i64
+	%26 = shl i64 %25, 8
+	%27 = and i64 %24, -65281
+	%28 = or i64 %27, %26
+	store i64 %28, i64* %rax
+	%29 = load i64, i64* %rax
+	%30 = and i64 %29, 255
+	store i64 %30, i64* %rax
 	ret void
 }
 
-define void @div_r16() !addr !{!"0x10000027"} {
+define void @_imp_div_r16() !addr !{!"0x10000027"} {
 ; <label>:0
-	%ax = alloca i16
-	%dx = alloca i16
-	%bx = alloca i16
 	%rax = alloca i64
-	%"dx:ax" = alloca i32
+	%rdx = alloca i64
+	%rbx = alloca i64
 	br label %block_10000027
 
 block_10000027:
-	store i32 0, i16* %dx
-	store i32 84, i16* %ax
-	store i32 2, i16* %bx
-	%1 = load i16, i16* %bx
-	%2 = load i32, i32* %"dx:ax"
-	%3 = zext i16 %1 to i32
-	%4 = udiv i32 %2, %3
-	%5 = urem i32 %2, %3
-	store i32 %4, i16* %ax
-	store i32 %5, i16* %dx
-	%6 = load i64, i64* %rax
-	%7 = and i64 %6, 65535
-	store i64 %7, i64* %rax
+	%1 = load i64, i64* %rdx
+	%2 = zext i16 0 to i64
+	%3 = and i64 %1, -65536
+	%4 = or i64 %3, %2
+	store i64 %4, i64* %rdx
+	%5 = load i64, i64* %rax
+	%6 = zext i16 84 to i64
+	%7 = and i64 %5, -65536
+	%8 = or i64 %7, %6
+	store i64 %8, i64* %rax
+	%9 = load i64, i64* %rbx
+	%10 = zext i16 2 to i64
+	%11 = and i64 %9, -65536
+	%12 = or i64 %11, %10
+	store i64 %12, i64* %rbx
+	%13 = load i64, i64* %rbx
+	%14 = trunc i64 %13 to i16
+	%15 = load i64, i64* %rdx
+	%16 = trunc i64 %15 to i16
+	%17 = zext i16 %16 to i32
+	%18 = shl i32 %17, 16
+	%19 = load i64, i64* %rax
+	%20 = trunc i64 %19 to i16
+	%21 = zext i16 %20 to i32
+	%22 = or i32 %18, %21
+	%23 = icmp eq i16 %14, 0
+	br i1 %23, label %24, label %25
+
+; <label>:24
+	call void @llvm.trap()
+	unreachable
+
+; <label>:25
+	%26 = zext i16 %14 to i32
+	%27 = udiv i32 %22, %26
+	%28 = urem i32 %22, %26
+	%29 = trunc i32 %27 to i16
+	%30 = zext i16 %29 to i32
+	%31 = icmp ne i32 %27, %30
+	br i1 %31, label %32, label %33
+
+; <label>:32
+	call void @llvm.trap()
+	unreachable
+
+; <label>:33
+	%34 = trunc i32 %28 to i16
+	%35 = load i64, i64* %rax
+	%36 = zext i16 %29 to i64
+	%37 = and i64 %35, -65536
+	%38 = or i64 %37, %36
+	store i64 %38, i64* %rax
+	%39 = load i64, i64* %rdx
+	%40 = zext i16 %34 to i64
+	%41 = and i64 %39, -65536
+	%42 = or i64 %41, %40
+	store i64 %42, i64* %rdx
+	%43 = load i64, i64* %rax
+	%44 = and i64 %43, 65535
+	store i64 %44, i64* %rax
 	ret void
 }
 
-define void @div_m16() !addr !{!"0x1000003D"} {
+define void @_imp_div_m16() !addr !{!"0x1000003D"} {
 ; <label>:0
-	%ax = alloca i16
-	%dx = alloca i16
 	%rax = alloca i64
-	%"dx:ax" = alloca i32
+	%rdx = alloca i64
 	br label %block_1000003D
 
 block_1000003D:
-	store i32 0, i16* %dx
-	store i32 84, i16* %ax
-	store i32 2, i16* @m16
-	%1 = load i16, i16* @m16
-	%2 = load i32, i32* %"dx:ax"
-	%3 = zext i16 %1 to i32
-	%4 = udiv i32 %2, %3
-	%5 = urem i32 %2, %3
-	store i32 %4, i16* %ax
-	store i32 %5, i16* %dx
-	%6 = load i64, i64* %rax
-	%7 = and i64 %6, 65535
-	store i64 %7, i64* %rax
+	%1 = load i64, i64* %rdx
+	%2 = zext i16 0 to i64
+	%3 = and i64 %1, -65536
+	%4 = or i64 %3, %2
+	store i64 %4, i64* %rdx
+	%5 = load i64, i64* %rax
+	%6 = zext i16 84 to i64
+	%7 = and i64 %5, -65536
+	%8 = or i64 %7, %6
+	store i64 %8, i64* %rax
+	store i16 2, i16* @m16
+	%9 = load i16, i16* @m16
+	%10 = load i64, i64* %rdx
+	%11 = trunc i64 %10 to i16
+	%12 = zext i16 %11 to i32
+	%13 = shl i32 %12, 16
+	%14 = load i64, i64* %rax
+	%15 = trunc i64 %14 to i16
+	%16 = zext i16 %15 to i32
+	%17 = or i32 %13, %16
+	%18 = icmp eq i16 %9, 0
+	br i1 %18, label %19, label %20
+
+; <label>:19
+	call void @llvm.trap()
+	unreachable
+
+; <label>:20
+	%21 = zext i16 %9 to i32
+	%22 = udiv i32 %17, %21
+	%23 = urem i32 %17, %21
+	%24 = trunc i32 %22 to i16
+	%25 = zext i16 %24 to i32
+	%26 = icmp ne i32 %22, %25
+	br i1 %26, label %27, label %28
+
+; <label>:27
+	call void @llvm.trap()
+	unreachable
+
+; <label>:28
+	%29 = trunc i32 %23 to i16
+	%30 = load i64, i64* %rax
+	%31 = zext i16 %24 to i64
+	%32 = and i64 %30, -65536
+	%33 = or i64 %32, %31
+	store i64 %33, i64* %rax
+	%34 = load i64, i64* %rdx
+	%35 = zext i16 %29 to i64
+	%36 = and i64 %34, -65536
+	%37 = or i64 %36, %35
+	store i64 %37, i64* %rdx
+	%38 = load i64, i64* %rax
+	%39 = and i64 %38, 65535
+	store i64 %39, i64* %rax
 	ret void
 }
 
-define void @div_r32() !addr !{!"0x1000005C"} {
+define void @_imp_div_r32() !addr !{!"0x1000005C"} {
 ; <label>:0
-	%eax = alloca i32
-	%edx = alloca i32
-	%ebx = alloca i32
 	%rax = alloca i64
+	%rdx = alloca i64
 	%rbx = alloca i64
-	%"edx:eax" = alloca i64
 	br label %block_1000005C
 
 block_1000005C:
-	store i32 0, i32* %edx
-	store i32 84, i32* %eax
-	store i32 2, i32* %ebx
-	%1 = load i32, i32* %ebx
-	%2 = load i64, i64* %"edx:eax"
-	%3 = zext i32 %1 to i64
-	%4 = udiv i64 %2, %3
-	%5 = urem i64 %2, %3
-	store i64 %4, i32* %eax
-	store i64 %5, i32* %edx
-	store i32 -1, i32* %ebx
-	%6 = load i64, i64* %rax
-	%7 = load i64, i64* %rbx
-	%8 = and i64 %6, %7
-	store i64 %8, i64* %rax
+	%1 = zext i32 0 to i64
+	store i64 %1, i64* %rdx
+	%2 = zext i32 84 to i64
+	store i64 %2, i64* %rax
+	%3 = zext i32 2 to i64
+	store i64 %3, i64* %rbx
+	%4 = load i64, i64* %rbx
+	%5 = trunc i64 %4 to i32
+	%6 = load i64, i64* %rdx
+	%7 = trunc i64 %6 to i32
+	%8 = zext i32 %7 to i64
+	%9 = shl i64 %8, 32
+	%10 = load i64, i64* %rax
+	%11 = trunc i64 %10 to i32
+	%12 = zext i32 %11 to i64
+	%13 = or i64 %9, %12
+	%14 = icmp eq i32 %5, 0
+	br i1 %14, label %15, label %16
+
+; <label>:15
+	call void @llvm.trap()
+	unreachable
+
+; <label>:16
+	%17 = zext i32 %5 to i64
+	%18 = udiv i64 %13, %17
+	%19 = urem i64 %13, %17
+	%20 = trunc i64 %18 to i32
+	%21 = zext i32 %20 to i64
+	%22 = icmp ne i64 %18, %21
+	br i1 %22, label %23, label %24
+
+; <label>:23
+	call void @llvm.trap()
+	unreachable
+
+; <label>:24
+	%25 = trunc i64 %19 to i32
+	%26 = zext i32 %20 to i64
+	store i64 %26, i64* %rax
+	%27 = zext i32 %25 to i64
+	store i64 %27, i64* %rdx
+	%28 = zext i32 -1 to i64
+	store i64 %28, i64* %rbx
+	%29 = load i64, i64* %rax
+	%30 = load i64, i64* %rbx
+	%31 = and i64 %29, %30
+	store i64 %31, i64* %rax
 	ret void
 }
 
-define void @div_m32() !addr !{!"0x10000076"} {
+define void @_imp_div_m32() !addr !{!"0x10000076"} {
 ; <label>:0
-	%eax = alloca i32
-	%edx = alloca i32
-	%ebx = alloca i32
 	%rax = alloca i64
+	%rdx = alloca i64
 	%rbx = alloca i64
-	%"edx:eax" = alloca i64
 	br label %block_10000076
 
 block_10000076:
-	store i32 0, i32* %edx
-	store i32 84, i32* %eax
+	%1 = zext i32 0 to i64
+	store i64 %1, i64* %rdx
+	%2 = zext i32 84 to i64
+	store i64 %2, i64* %rax
 	store i32 2, i32* @m32
-	%1 = load i32, i32* @m32
-	%2 = load i64, i64* %"edx:eax"
-	%3 = zext i32 %1 to i64
-	%4 = udiv i64 %2, %3
-	%5 = urem i64 %2, %3
-	store i64 %4, i32* %eax
-	store i64 %5, i32* %edx
-	store i32 -1, i32* %ebx
-	%6 = load i64, i64* %rax
-	%7 = load i64, i64* %rbx
-	%8 = and i64 %6, %7
-	store i64 %8, i64* %rax
+	%3 = load i32, i32* @m32
+	%4 = load i64, i64* %rdx
+	%5 = trunc i64 %4 to i32
+	%6 = zext i32 %5 to i64
+	%7 = shl i64 %6, 32
+	%8 = load i64, i64* %rax
+	%9 = trunc i64 %8 to i32
+	%10 = zext i32 %9 to i64
+	%11 = or i64 %7, %10
+	%12 = icmp eq i32 %3, 0
+	br i1 %12, label %13, label %14
+
+; <label>:13
+	call void @llvm.trap()
+	unreachable
+
+; <label>:14
+	%15 = zext i32 %3 to i64
+	%16 = udiv i64 %11, %15
+	%17 = urem i64 %11, %15
+	%18 = trunc i64 %16 to i32
+	%19 = zext i32 %18 to i64
+	%20 = icmp ne i64 %16, %19
+	br i1 %20, label %21, label %22
+
+; <label>:21
+	call void @llvm.trap()
+	unreachable
+
+; <label>:22
+	%23 = trunc i64 %17 to i32
+	%24 = zext i32 %18 to i64
+	store i64 %24, i64* %rax
+	%25 = zext i32 %23 to i64
+	store i64 %25, i64* %rdx
+	%26 = zext i32 -1 to i64
+	store i64 %26, i64* %rbx
+	%27 = load i64, i64* %rax
+	%28 = load i64, i64* %rbx
+	%29 = and i64 %27, %28
+	store i64 %29, i64* %rax
 	ret void
 }
 
-define void @div_r64() !addr !{!"0x10000099"} {
+define void @_imp_div_r64() !addr !{!"0x10000099"} {
 ; <label>:0
-	%eax = alloca i32
-	%edx = alloca i32
-	%ebx = alloca i32
 	%rax = alloca i64
 	%rdx = alloca i64
 	%rbx = alloca i64
-	%"rdx:rax" = alloca i128
 	br label %block_10000099
 
 block_10000099:
-	store i32 0, i32* %edx
-	store i32 84, i32* %eax
-	store i32 2, i32* %ebx
-	%1 = load i64, i64* %rbx
-	%2 = load i128, i128* %"rdx:rax"
-	%3 = zext i64 %1 to i128
-	%4 = udiv i128 %2, %3
-	%5 = urem i128 %2, %3
-	store i128 %4, i64* %rax
-	store i128 %5, i64* %rdx
+	%1 = zext i32 0 to i64
+	store i64 %1, i64* %rdx
+	%2 = zext i32 84 to i64
+	store i64 %2, i64* %rax
+	%3 = zext i32 2 to i64
+	store i64 %3, i64* %rbx
+	%4 = load i64, i64* %rbx
+	%5 = load i64, i64* %rdx
+	%6 = zext i64 %5 to i128
+	%7 = shl i128 %6, 64
+	%8 = load i64, i64* %rax
+	%9 = zext i64 %8 to i128
+	%10 = or i128 %7, %9
+	%11 = icmp eq i64 %4, 0
+	br i1 %11, label %12, label %13
+
+; <label>:12
+	call void @llvm.trap()
+	unreachable
+
+; <label>:13
+	%14 = zext i64 %4 to i128
+	%15 = udiv i128 %10, %14
+	%16 = urem i128 %10, %14
+	%17 = trunc i128 %15 to i64
+	%18 = zext i64 %17 to i128
+	%19 = icmp ne i128 %15, %18
+	br i1 %19, label %20, label %21
+
+; <label>:20
+	call void @llvm.trap()
+	unreachable
+
+; <label>:21
+	%22 = trunc i128 %16 to i64
+	store i64 %17, i64* %rax
+	store i64 %22, i64* %rdx
 	ret void
 }
 
-define void @div_m64() !addr !{!"0x100000AC"} {
+define void @_imp_div_m64() !addr !{!"0x100000AC"} {
 ; <label>:0
-	%eax = alloca i32
-	%edx = alloca i32
 	%rax = alloca i64
 	%rdx = alloca i64
-	%"rdx:rax" = alloca i128
 	br label %block_100000AC
 
 block_100000AC:
-	store i32 0, i32* %edx
-	store i32 84, i32* %eax
-	store i32 2, i64* @m64
-	%1 = load i64, i64* @m64
-	%2 = load i128, i128* %"rdx:rax"
-	%3 = zext i64 %1 to i128
-	%4 = udiv i128 %2, %3
-	%5 = urem i128 %2, %3
-	store i128 %4, i64* %rax
-	store i128 %5, i64* %rdx
+	%1 = zext i32 0 to i64
+	store i64 %1, i64* %rdx
+	%2 = zext i32 84 to i64
+	store i64 %2, i64* %rax
+	store i64 2, i64* @m64
+	%3 = load i64, i64* @m64
+	%4 = load i64, i64* %rdx
+	%5 = zext i64 %4 to i128
+	%6 = shl i128 %5, 64
+	%7 = load i64, i64* %rax
+	%8 = zext i64 %7 to i128
+	%9 = or i128 %6, %8
+	%10 = icmp eq i64 %3, 0
+	br i1 %10, label %11, label %12
+
+; <label>:11
+	call void @llvm.trap()
+	unreachable
+
+; <label>:12
+	%13 = zext i64 %3 to i128
+	%14 = udiv i128 %9, %13
+	%15 = urem i128 %9, %13
+	%16 = trunc i128 %14 to i64
+	%17 = zext i64 %16 to i128
+	%18 = icmp ne i128 %14, %17
+	br i1 %18, label %19, label %20
+
+; <label>:19
+	call void @llvm.trap()
+	unreachable
+
+; <label>:20
+	%21 = trunc i128 %15 to i64
+	store i64 %16, i64* %rax
+	store i64 %21, i64* %rdx
 	ret void
 }
@@ -0,0 +1,125 @@
+[BITS 64]
+
+global cmove:function
+global cmovne:function
+global cmovl:function
+global cmovg:function
+global cmovb:function
+global cmova:function
+global cmovs:function
+global cmovo:function
+global cmovp:function
+global cmov_m32:function
+global cmov_r16:function
+
+section .text
+
+; === [ CMOVcc - Conditional Move ] ============================================
+
+; --- [ CMOVE ] ----------------------------------------------------------------
+
+; ZF; 42 if equal, 0 otherwise.
+cmove:
+	xor     rax, rax
+	mov     rcx, 42
+	cmp     rdx, rbx
+	cmove   rax, rcx
+	ret
+
+; --- [ CMOVNE ] ---------------------------------------------------------------
+
+; !ZF
+cmovne:
+	xor     rax, rax
+	mov     rcx, 42
+	test    rdx, rdx
+	cmovne  rax, rcx
+	ret
+
+; --- [ CMOVL ] ----------------------------------------------------------------
+
+; SF != OF; minimum of signed integers.
+cmovl:
+	mov     rax, rdx
+	cmp     rbx, rdx
+	cmovl   rax, rbx
+	ret
+
+; --- [ CMOVG ] ----------------------------------------------------------------
+
+; !ZF && SF == OF; maximum of signed integers.
+cmovg:
+	mov     rax, rdx
+	cmp     rbx, rdx
+	cmovg   rax, rbx
+	ret
+
+; --- [ CMOVB ] ----------------------------------------------------------------
+
+; CF; minimum of unsigned integers.
+cmovb:
+	mov     rax, rdx
+	cmp     rbx, rdx
+	cmovb   rax, rbx
+	ret
+
+; --- [ CMOVA ] ----------------------------------------------------------------
+
+; !CF && !ZF; maximum of unsigned integers.
+cmova:
+	mov     rax, rdx
+	cmp     rbx, rdx
+	cmova   rax, rbx
+	ret
+
+; --- [ CMOVS ] ----------------------------------------------------------------
+
+; SF; absolute value.
+cmovs:
+	mov     rax, rdx
+	neg     rax
+	cmovs   rax, rdx
+	ret
+
+; --- [ CMOVO ] ----------------------------------------------------------------
+
+; OF; saturating addition.
+cmovo:
+	mov     rax, rdx
+	mov     rcx, 0x7FFFFFFF
+	add     rax, rbx
+	cmovo   rax, rcx
+	ret
+
+; --- [ CMOVP ] ----------------------------------------------------------------
+
+; PF
+cmovp:
+	xor     rax, rax
+	mov     rcx, 42
+	test    rdx, 0x3
+	cmovp   rax, rcx
+	ret
+
+; --- [ CMOVcc r32, m32 ] ------------------------------------------------------
+
+; The memory operand is loaded regardless of the condition.
+cmov_m32:
+	xor     rax, rax
+	test    rdx, rdx
+	cmovz   eax, dword [rel m32]
+	ret
+
+; --- [ CMOVcc r16, r16 ] ------------------------------------------------------
+
+cmov_r16:
+	xor     rax, rax
+	mov     cx, 42
+	cmp     dx, bx
+	cmove   ax, cx
+	ret
+
+section .data
+
+; 32-bit memory variable.
+m32: dd 42
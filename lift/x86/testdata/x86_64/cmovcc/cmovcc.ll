@@ -0,0 +1,270 @@
+define void @_imp_cmove() !addr !{!"0x10000000"} {
+; <label>:0
+	%rax = alloca i64
+	%rcx = alloca i64
+	%rdx = alloca i64
+	%rbx = alloca i64
+	br label %block_10000000
+
+block_10000000:
+	%1 = load i64, i64* %rax
+	%2 = load i64, i64* %rax
+	%3 = xor i64 %1, %2
+	store i64 %3, i64* %rax
+	%4 = zext i32 42 to i64
+	store i64 %4, i64* %rcx
+	%5 = load i64, i64* %rdx
+	%6 = load i64, i64* %rbx
+	%7 = sub i64 %5, %6
+	%8 = icmp eq i64 %5, %6
+	%9 = load i64, i64* %rax
+	%10 = load i64, i64* %rcx
+	%11 = select i1 %8, i64 %10, i64 %9
+	store i64 %11, i64* %rax
+	ret void
+}
+
+define void @_imp_cmovne() !addr !{!"0x10000010"} {
+; <label>:0
+	%rax = alloca i64
+	%rcx = alloca i64
+	%rdx = alloca i64
+	br label %block_10000010
+
+block_10000010:
+	%1 = load i64, i64* %rax
+	%2 = load i64, i64* %rax
+	%3 = xor i64 %1, %2
+	store i64 %3, i64* %rax
+	%4 = zext i32 42 to i64
+	store i64 %4, i64* %rcx
+	%5 = load i64, i64* %rdx
+	%6 = load i64, i64* %rdx
+	%7 = and i64 %5, %6
+	%8 = icmp ne i64 %7, 0
+	%9 = load i64, i64* %rax
+	%10 = load i64, i64* %rcx
+	%11 = select i1 %8, i64 %10, i64 %9
+	store i64 %11, i64* %rax
+	ret void
+}
+
+define void @_imp_cmovl() !addr !{!"0x10000020"} {
+; <label>:0
+	%rax = alloca i64
+	%rdx = alloca i64
+	%rbx = alloca i64
+	br label %block_10000020
+
+block_10000020:
+	%1 = load i64, i64* %rdx
+	store i64 %1, i64* %rax
+	%2 = load i64, i64* %rbx
+	%3 = load i64, i64* %rdx
+	%4 = sub i64 %2, %3
+	%5 = icmp slt i64 %2, %3
+	%6 = load i64, i64* %rax
+	%7 = load i64, i64* %rbx
+	%8 = select i1 %5, i64 %7, i64 %6
+	store i64 %8, i64* %rax
+	ret void
+}
+
+define void @_imp_cmovg() !addr !{!"0x1000002B"} {
+; <label>:0
+	%rax = alloca i64
+	%rdx = alloca i64
+	%rbx = alloca i64
+	br label %block_1000002B
+
+block_1000002B:
+	%1 = load i64, i64* %rdx
+	store i64 %1, i64* %rax
+	%2 = load i64, i64* %rbx
+	%3 = load i64, i64* %rdx
+	%4 = sub i64 %2, %3
+	%5 = icmp sgt i64 %2, %3
+	%6 = load i64, i64* %rax
+	%7 = load i64, i64* %rbx
+	%8 = select i1 %5, i64 %7, i64 %6
+	store i64 %8, i64* %rax
+	ret void
+}
+
+define void @_imp_cmovb() !addr !{!"0x10000036"} {
+; <label>:0
+	%rax = alloca i64
+	%rdx = alloca i64
+	%rbx = alloca i64
+	br label %block_10000036
+
+block_10000036:
+	%1 = load i64, i64* %rdx
+	store i64 %1, i64* %rax
+	%2 = load i64, i64* %rbx
+	%3 = load i64, i64* %rdx
+	%4 = sub i64 %2, %3
+	%5 = icmp ult i64 %2, %3
+	%6 = load i64, i64* %rax
+	%7 = load i64, i64* %rbx
+	%8 = select i1 %5, i64 %7, i64 %6
+	store i64 %8, i64* %rax
+	ret void
+}
+
+define void @_imp_cmova() !addr !{!"0x10000041"} {
+; <label>:0
+	%rax = alloca i64
+	%rdx = alloca i64
+	%rbx = alloca i64
+	br label %block_10000041
+
+block_10000041:
+	%1 = load i64, i64* %rdx
+	store i64 %1, i64* %rax
+	%2 = load i64, i64* %rbx
+	%3 = load i64, i64* %rdx
+	%4 = sub i64 %2, %3
+	%5 = icmp ugt i64 %2, %3
+	%6 = load i64, i64* %rax
+	%7 = load i64, i64* %rbx
+	%8 = select i1 %5, i64 %7, i64 %6
+	store i64 %8, i64* %rax
+	ret void
+}
+
+define void @_imp_cmovs() !addr !{!"0x1000004C"} {
+; <label>:0
+	%rax = alloca i64
+	%rdx = alloca i64
+	br label %block_1000004C
+
+block_1000004C:
+	%1 = load i64, i64* %rdx
+	store i64 %1, i64* %rax
+	%2 = load i64, i64* %rax
+	%3 = sub i64 0, %2
+	store i64 %3, i64* %rax
+	%4 = icmp slt i64 %3, 0
+	%5 = load i64, i64* %rax
+	%6 = load i64, i64* %rdx
+	%7 = select i1 %4, i64 %6, i64 %5
+	store i64 %7, i64* %rax
+	ret void
+}
+
+define void @_imp_cmovo() !addr !{!"0x10000057"} {
+; <label>:0
+	%rax = alloca i64
+	%rcx = alloca i64
+	%rdx = alloca i64
+	%rbx = alloca i64
+	br label %block_10000057
+
+block_10000057:
+	%1 = load i64, i64* %rdx
+	store i64 %1, i64* %rax
+	%2 = zext i32 2147483647 to i64
+	store i64 %2, i64* %rcx
+	%3 = load i64, i64* %rax
+	%4 = load i64, i64* %rbx
+	%5 = add i64 %3, %4
+	store i64 %5, i64* %rax
+	%6 = xor i64 %3, %5
+	%7 = xor i64 %4, %5
+	%8 = and i64 %6, %7
+	%9 = icmp slt i64 %8, 0
+	%10 = load i64, i64* %rax
+	%11 = load i64, i64* %rcx
+	%12 = select i1 %9, i64 %11, i64 %10
+	store i64 %12, i64* %rax
+	ret void
+}
+
+define void @_imp_cmovp() !addr !{!"0x10000067"} {
+; <label>:0
+	%rax = alloca i64
+	%rcx = alloca i64
+	%rdx = alloca i64
+	br label %block_10000067
+
+block_10000067:
+	%1 = load i64, i64* %rax
+	%2 = load i64, i64* %rax
+	%3 = xor i64 %1, %2
+	store i64 %3, i64* %rax
+	%4 = zext i32 42 to i64
+	store i64 %4, i64* %rcx
+	%5 = load i64, i64* %rdx
+	%6 = and i64 %5, 3
+	%7 = trunc i64 %6 to i8
+	%8 = call i8 @llvm.ctpop.i8(i8 %7)
+	%9 = and i8 %8, 1
+	%10 = icmp eq i8 %9, 0
+	%11 = load i64, i64* %rax
+	%12 = load i64, i64* %rcx
+	%13 = select i1 %10, i64 %12, i64 %11
+	store i64 %13, i64* %rax
+	ret void
+}
+
+define void @_imp_cmov_m32() !addr !{!"0x1000007B"} {
+; <label>:0
+	%rax = alloca i64
+	%rdx = alloca i64
+	br label %block_1000007B
+
+block_1000007B:
+	%1 = load i64, i64* %rax
+	%2 = load i64, i64* %rax
+	%3 = xor i64 %1, %2
+	store i64 %3, i64* %rax
+	%4 = load i64, i64* %rdx
+	%5 = load i64, i64* %rdx
+	%6 = and i64 %4, %5
+	%7 = icmp eq i64 %6, 0
+	%8 = load i64, i64* %rax
+	%9 = trunc i64 %8 to i32
+	%10 = load i32, i32* @g_20000000
+	%11 = select i1 %7, i32 %10, i32 %9
+	%12 = zext i32 %11 to i64
+	store i64 %12, i64* %rax
+	ret void
+}
+
+define void @_imp_cmov_r16() !addr !{!"0x10000089"} {
+; <label>:0
+	%rax = alloca i64
+	%rcx = alloca i64
+	%rdx = alloca i64
+	%rbx = alloca i64
+	br label %block_10000089
+
+block_10000089:
+	%1 = load i64, i64* %rax
+	%2 = load i64, i64* %rax
+	%3 = xor i64 %1, %2
+	store i64 %3, i64* %rax
+	%4 = load i64, i64* %rcx
+	%5 = zext i16 42 to i64
+	%6 = and i64 %4, -65536
+	%7 = or i64 %6, %5
+	store i64 %7, i64* %rcx
+	%8 = load i64, i64* %rdx
+	%9 = trunc i64 %8 to i16
+	%10 = load i64, i64* %rbx
+	%11 = trunc i64 %10 to i16
+	%12 = sub i16 %9, %11
+	%13 = icmp eq i16 %9, %11
+	%14 = load i64, i64* %rax
+	%15 = trunc i64 %14 to i16
+	%16 = load i64, i64* %rcx
+	%17 = trunc i64 %16 to i16
+	%18 = select i1 %13, i16 %17, i16 %15
+	%19 = load i64, i64* %rax
+	%20 = zext i16 %18 to i64
+	%21 = and i64 %19, -65536
+	%22 = or i64 %21, %20
+	store i64 %22, i64* %rax
+	ret void
+}
@@ -0,0 +1,199 @@
+define void @_imp_flags_loop() !addr !{!"0x10000000"} {
+; <label>:0
+	%rax = alloca i64
+	%rcx = alloca i64
+	%zf = alloca i1
+	br label %block_10000000
+
+block_10000000:
+	%1 = load i64, i64* %rax
+	%2 = trunc i64 %1 to i32
+	%3 = load i64, i64* %rax
+	%4 = trunc i64 %3 to i32
+	%5 = xor i32 %2, %4
+	%6 = zext i32 %5 to i64
+	store i64 %6, i64* %rax
+	%7 = zext i32 21 to i64
+	store i64 %7, i64* %rcx
+	%8 = icmp eq i32 %5, 0
+	store i1 %8, i1* %zf
+	br label %block_1000000F
+
+block_10000009:
+	%9 = load i64, i64* %rax
+	%10 = trunc i64 %9 to i32
+	%11 = add i32 %10, 2
+	%12 = zext i32 %11 to i64
+	store i64 %12, i64* %rax
+	%13 = load i64, i64* %rcx
+	%14 = sub i64 %13, 1
+	store i64 %14, i64* %rcx
+	%15 = icmp ne i64 %13, 1
+	br i1 %15, label %block_10000009, label %block_10000011, !llvm.loop !{!{!"llvm.loop.header", !"0x1000000F"}, !{!"llvm.loop.latch", !"0x10000009"}, !{!"llvm.loop.exit", !"0x10000011"}}
+
+block_1000000F:
+	%16 = load i1, i1* %zf
+	%17 = xor i1 %16, true
+	br i1 %17, label %block_10000009, label %block_10000011
+
+block_10000011:
+	ret void
+}
+
+define void @_imp_flags_carry() !addr !{!"0x10000012"} {
+; <label>:0
+	%rax = alloca i64
+	%rcx = alloca i64
+	%rbx = alloca i64
+	%cf = alloca i1
+	br label %block_10000012
+
+block_10000012:
+	%1 = zext i32 -1 to i64
+	store i64 %1, i64* %rax
+	%2 = zext i32 1 to i64
+	store i64 %2, i64* %rbx
+	%3 = load i64, i64* %rax
+	%4 = trunc i64 %3 to i32
+	%5 = load i64, i64* %rbx
+	%6 = trunc i64 %5 to i32
+	%7 = add i32 %4, %6
+	%8 = zext i32 %7 to i64
+	store i64 %8, i64* %rax
+	%9 = load i64, i64* %rcx
+	%10 = add i64 %9, 1
+	store i64 %10, i64* %rcx
+	%11 = icmp ult i32 %7, %4
+	store i1 %11, i1* %cf
+	br label %block_10000023
+
+block_10000023:
+	%12 = load i1, i1* %cf
+	%13 = zext i1 %12 to i8
+	%14 = load i64, i64* %rax
+	%15 = zext i8 %13 to i64
+	%16 = and i64 %14, -256
+	%17 = or i64 %16, %15
+	store i64 %17, i64* %rax
+	ret void
+}
+
+define void @_imp_flags_redef() !addr !{!"0x10000027"} {
+; <label>:0
+	%rax = alloca i64
+	%zf = alloca i1
+	br label %block_10000027
+
+block_10000027:
+	%1 = zext i32 42 to i64
+	store i64 %1, i64* %rax
+	%2 = load i64, i64* %rax
+	%3 = trunc i64 %2 to i32
+	%4 = sub i32 %3, 1
+	%5 = load i64, i64* %rax
+	%6 = trunc i64 %5 to i32
+	%7 = load i64, i64* %rax
+	%8 = trunc i64 %7 to i32
+	%9 = and i32 %6, %8
+	%10 = icmp eq i32 %9, 0
+	store i1 %10, i1* %zf
+	br label %block_10000033
+
+block_10000033:
+	%11 = load i1, i1* %zf
+	br i1 %11, label %block_10000036, label %block_10000035
+
+block_10000035:
+	ret void
+
+block_10000036:
+	%12 = zext i32 0 to i64
+	store i64 %12, i64* %rax
+	ret void
+}
+
+define void @_imp_flags_lt64() !addr !{!"0x10000039"} {
+; <label>:0
+	%rax = alloca i64
+	%rcx = alloca i64
+	%rdx = alloca i64
+	%rbx = alloca i64
+	br label %block_10000039
+
+block_10000039:
+	%1 = load i64, i64* %rax
+	%2 = trunc i64 %1 to i32
+	%3 = load i64, i64* %rbx
+	%4 = trunc i64 %3 to i32
+	%5 = sub i32 %2, %4
+	%6 = load i64, i64* %rdx
+	%7 = trunc i64 %6 to i32
+	%8 = load i64, i64* %rcx
+	%9 = trunc i64 %8 to i32
+	%10 = icmp ult i32 %2, %4
+	%11 = zext i1 %10 to i32
+	%12 = add i32 %9, %11
+	%13 = sub i32 %7, %12
+	%14 = zext i32 %13 to i64
+	store i64 %14, i64* %rdx
+	%15 = icmp slt i32 %13, 0
+	%16 = xor i32 %7, %9
+	%17 = xor i32 %7, %13
+	%18 = and i32 %16, %17
+	%19 = icmp slt i32 %18, 0
+	%20 = icmp ne i1 %15, %19
+	br i1 %20, label %block_10000042, label %block_1000003F
+
+block_1000003F:
+	%21 = zext i32 0 to i64
+	store i64 %21, i64* %rax
+	ret void
+
+block_10000042:
+	%22 = zext i32 1 to i64
+	store i64 %22, i64* %rax
+	ret void
+}
+
+define void @_imp_flags_add64() !addr !{!"0x10000048"} {
+; <label>:0
+	%rax = alloca i64
+	%rcx = alloca i64
+	%rdx = alloca i64
+	%rbx = alloca i64
+	br label %block_10000048
+
+block_10000048:
+	%1 = load i64, i64* %rax
+	%2 = trunc i64 %1 to i32
+	%3 = load i64, i64* %rbx
+	%4 = trunc i64 %3 to i32
+	%5 = add i32 %2, %4
+	%6 = zext i32 %5 to i64
+	store i64 %6, i64* %rax
+	%7 = load i64, i64* %rdx
+	%8 = trunc i64 %7 to i32
+	%9 = load i64, i64* %rcx
+	%10 = trunc i64 %9 to i32
+	%11 = icmp ult i32 %5, %2
+	%12 = zext i1 %11 to i32
+	%13 = add i32 %10, %12
+	%14 = add i32 %8, %13
+	%15 = zext i32 %14 to i64
+	store i64 %15, i64* %rdx
+	%16 = icmp ult i32 %14, %8
+	%17 = icmp eq i32 %14, %8
+	%18 = and i1 %11, %17
+	%19 = or i1 %16, %18
+	br i1 %19, label %block_10000051, label %block_1000004E
+
+block_1000004E:
+	%20 = zext i32 0 to i64
+	store i64 %20, i64* %rax
+	ret void
+
+block_10000051:
+	%21 = zext i32 1 to i64
+	store i64 %21, i64* %rax
+	ret void
+}
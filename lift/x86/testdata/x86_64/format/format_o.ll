@@ -1,4 +1,4 @@
-define void @_start() !addr !{!"0x0"} {
-block_000000:
+define void @_imp__start() !addr !{!"0x10000"} {
+block_010000:
 	ret void
 }
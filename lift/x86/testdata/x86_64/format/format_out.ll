@@ -1,4 +1,4 @@
-define void @_start() !addr !{!"0x400000"} {
+define void @_imp__start() !addr !{!"0x400000"} {
 block_400000:
 	ret void
 }
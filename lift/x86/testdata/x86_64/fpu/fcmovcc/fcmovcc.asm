@@ -0,0 +1,94 @@
+[BITS 64]
+
+global fcmovb:function
+global fcmove:function
+global fcmovbe:function
+global fcmovu:function
+global fcmovnb:function
+global fcmovne:function
+global fcmovnbe:function
+global fcmovnu:function
+
+section .text
+
+; === [ FCMOVcc - Floating-Point Conditional Move ] ============================
+
+; --- [ FCMOVB ] ---------------------------------------------------------------
+
+fcmovb:
+	fldz
+	fld1
+	cmp     rdx, rbx
+	fcmovb  ST0, ST1
+	fstp    ST1
+	ret
+
+; --- [ FCMOVE ] ---------------------------------------------------------------
+
+fcmove:
+	fldz
+	fld1
+	cmp     rdx, rbx
+	fcmove  ST0, ST1
+	fstp    ST1
+	ret
+
+; --- [ FCMOVBE ] --------------------------------------------------------------
+
+fcmovbe:
+	fldz
+	fld1
+	cmp     rdx, rbx
+	fcmovbe ST0, ST1
+	fstp    ST1
+	ret
+
+; --- [ FCMOVU ] ---------------------------------------------------------------
+
+fcmovu:
+	fldz
+	fld1
+	test    rdx, rdx
+	fcmovu  ST0, ST1
+	fstp    ST1
+	ret
+
+; --- [ FCMOVNB ] --------------------------------------------------------------
+
+fcmovnb:
+	fldz
+	fld1
+	cmp     rdx, rbx
+	fcmovnb ST0, ST1
+	fstp    ST1
+	ret
+
+; --- [ FCMOVNE ] --------------------------------------------------------------
+
+fcmovne:
+	fldz
+	fld1
+	cmp     rdx, rbx
+	fcmovne ST0, ST1
+	fstp    ST1
+	ret
+
+; --- [ FCMOVNBE ] -------------------------------------------------------------
+
+fcmovnbe:
+	fldz
+	fld1
+	cmp     rdx, rbx
+	fcmovnbe ST0, ST1
+	fstp    ST1
+	ret
+
+; --- [ FCMOVNU ] --------------------------------------------------------------
+
+fcmovnu:
+	fldz
+	fld1
+	test    rdx, rdx
+	fcmovnu ST0, ST1
+	fstp    ST1
+	ret
@@ -0,0 +1,73 @@
+[BITS 64]
+
+global fcomi:function
+global fcomip:function
+global fucomi:function
+global fucomip:function
+global fcomi_max:function
+
+section .text
+
+; === [ FCOMI/FUCOMI - Compare Floating-Point Values and Set EFLAGS ] ==========
+
+; --- [ FCOMI ] ----------------------------------------------------------------
+
+; 1 if ST(0) < ST(1); 0 otherwise.
+fcomi:
+	xor     rax, rax
+	fldpi
+	fld1
+	fcomi   ST0, ST1
+	setb    al
+	fstp    ST0
+	fstp    ST0
+	ret
+
+; --- [ FCOMIP ] ---------------------------------------------------------------
+
+; 1 if ST(0) == ST(1); 0 otherwise.
+fcomip:
+	xor     rax, rax
+	fldpi
+	fld1
+	fcomip  ST0, ST1
+	sete    al
+	fstp    ST0
+	ret
+
+; --- [ FUCOMI ] ---------------------------------------------------------------
+
+; 1 if unordered; 0 otherwise.
+fucomi:
+	xor     rax, rax
+	fldz
+	fld1
+	fucomi  ST0, ST1
+	setp    al
+	fstp    ST0
+	fstp    ST0
+	ret
+
+; --- [ FUCOMIP ] --------------------------------------------------------------
+
+; 1 if ST(0) > ST(1); 0 otherwise.
+fucomip:
+	xor     rax, rax
+	fldz
+	fld1
+	fucomip ST0, ST1
+	seta    al
+	fstp    ST0
+	ret
+
+; --- [ FCOMI; FCMOVcc ] -------------------------------------------------------
+
+; Maximum of ST(0) and ST(1).
+fcomi_max:
+	fldpi
+	fld1
+	fcomi   ST0, ST1
+	fcmovb  ST0, ST1
+	fstp    ST1
+	fstp    ST0
+	ret
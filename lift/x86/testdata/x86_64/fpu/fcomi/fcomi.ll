@@ -0,0 +1,138 @@
+define void @_imp_fcomi() !addr !{!"0x10000000"} {
+; <label>:0
+	%rax = alloca i64
+	%f6 = alloca x86_fp80
+	%f7 = alloca x86_fp80
+	br label %block_10000000
+
+block_10000000:
+	%1 = load i64, i64* %rax
+	%2 = load i64, i64* %rax
+	%3 = xor i64 %1, %2
+	store i64 %3, i64* %rax
+	store x86_fp80 0xK4000C90FDAA22168C000, x86_fp80* %f7
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f6
+	%4 = load x86_fp80, x86_fp80* %f6
+	%5 = load x86_fp80, x86_fp80* %f7
+	%6 = fcmp ult x86_fp80 %4, %5
+	%7 = zext i1 %6 to i8
+	%8 = load i64, i64* %rax
+	%9 = zext i8 %7 to i64
+	%10 = and i64 %8, -256
+	%11 = or i64 %10, %9
+	store i64 %11, i64* %rax
+	%12 = load x86_fp80, x86_fp80* %f6
+	store x86_fp80 %12, x86_fp80* %f6
+	%13 = load x86_fp80, x86_fp80* %f7
+	store x86_fp80 %13, x86_fp80* %f7
+	ret void
+}
+
+define void @_imp_fcomip() !addr !{!"0x10000011"} {
+; <label>:0
+	%rax = alloca i64
+	%f6 = alloca x86_fp80
+	%f7 = alloca x86_fp80
+	br label %block_10000011
+
+block_10000011:
+	%1 = load i64, i64* %rax
+	%2 = load i64, i64* %rax
+	%3 = xor i64 %1, %2
+	store i64 %3, i64* %rax
+	store x86_fp80 0xK4000C90FDAA22168C000, x86_fp80* %f7
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f6
+	%4 = load x86_fp80, x86_fp80* %f6
+	%5 = load x86_fp80, x86_fp80* %f7
+	%6 = fcmp ueq x86_fp80 %4, %5
+	%7 = zext i1 %6 to i8
+	%8 = load i64, i64* %rax
+	%9 = zext i8 %7 to i64
+	%10 = and i64 %8, -256
+	%11 = or i64 %10, %9
+	store i64 %11, i64* %rax
+	%12 = load x86_fp80, x86_fp80* %f7
+	store x86_fp80 %12, x86_fp80* %f7
+	ret void
+}
+
+define void @_imp_fucomi() !addr !{!"0x10000020"} {
+; <label>:0
+	%rax = alloca i64
+	%f6 = alloca x86_fp80
+	%f7 = alloca x86_fp80
+	br label %block_10000020
+
+block_10000020:
+	%1 = load i64, i64* %rax
+	%2 = load i64, i64* %rax
+	%3 = xor i64 %1, %2
+	store i64 %3, i64* %rax
+	store x86_fp80 0xK00000000000000000000, x86_fp80* %f7
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f6
+	%4 = load x86_fp80, x86_fp80* %f6
+	%5 = load x86_fp80, x86_fp80* %f7
+	%6 = fcmp uno x86_fp80 %4, %5
+	%7 = zext i1 %6 to i8
+	%8 = load i64, i64* %rax
+	%9 = zext i8 %7 to i64
+	%10 = and i64 %8, -256
+	%11 = or i64 %10, %9
+	store i64 %11, i64* %rax
+	%12 = load x86_fp80, x86_fp80* %f6
+	store x86_fp80 %12, x86_fp80* %f6
+	%13 = load x86_fp80, x86_fp80* %f7
+	store x86_fp80 %13, x86_fp80* %f7
+	ret void
+}
+
+define void @_imp_fucomip() !addr !{!"0x10000031"} {
+; <label>:0
+	%rax = alloca i64
+	%f6 = alloca x86_fp80
+	%f7 = alloca x86_fp80
+	br label %block_10000031
+
+block_10000031:
+	%1 = load i64, i64* %rax
+	%2 = load i64, i64* %rax
+	%3 = xor i64 %1, %2
+	store i64 %3, i64* %rax
+	store x86_fp80 0xK00000000000000000000, x86_fp80* %f7
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f6
+	%4 = load x86_fp80, x86_fp80* %f6
+	%5 = load x86_fp80, x86_fp80* %f7
+	%6 = fcmp ogt x86_fp80 %4, %5
+	%7 = zext i1 %6 to i8
+	%8 = load i64, i64* %rax
+	%9 = zext i8 %7 to i64
+	%10 = and i64 %8, -256
+	%11 = or i64 %10, %9
+	store i64 %11, i64* %rax
+	%12 = load x86_fp80, x86_fp80* %f7
+	store x86_fp80 %12, x86_fp80* %f7
+	ret void
+}
+
+define void @_imp_fcomi_max() !addr !{!"0x10000040"} {
+; <label>:0
+	%f6 = alloca x86_fp80
+	%f7 = alloca x86_fp80
+	br label %block_10000040
+
+block_10000040:
+	store x86_fp80 0xK4000C90FDAA22168C000, x86_fp80* %f7
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f6
+	%1 = load x86_fp80, x86_fp80* %f6
+	%2 = load x86_fp80, x86_fp80* %f7
+	%3 = fcmp ult x86_fp80 %1, %2
+	%4 = load x86_fp80, x86_fp80* %f6
+	%5 = load x86_fp80, x86_fp80* %f7
+	%6 = select i1 %3, x86_fp80 %5, x86_fp80 %4
+	store x86_fp80 %6, x86_fp80* %f6
+	%7 = load x86_fp80, x86_fp80* %f6
+	store x86_fp80 %7, x86_fp80* %f7
+	%8 = load x86_fp80, x86_fp80* %f7
+	store x86_fp80 %8, x86_fp80* %f7
+	ret void
+}
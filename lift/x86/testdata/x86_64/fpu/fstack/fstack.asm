@@ -0,0 +1,49 @@
+[BITS 64]
+
+global fstack_balanced:function
+global fstack_branch:function
+global fstack_dynamic:function
+
+section .text
+
+; === [ x87 FPU register stack top ] ===========================================
+
+; --- [ Static stack top ] -----------------------------------------------------
+
+; The stack top is known at each instruction; ST(i) is resolved to fixed
+; registers.
+fstack_balanced:
+	fld1
+	fldz
+	faddp   ST1, ST0
+	fstp    ST0
+	ret
+
+; --- [ Static stack top across basic blocks ] ---------------------------------
+
+; The stack depth is balanced along both paths.
+fstack_branch:
+	fld1
+	test    rax, rax
+	jz      fstack_branch_zero
+	fldpi
+	faddp   ST1, ST0
+	jmp     fstack_branch_done
+fstack_branch_zero:
+	fldz
+	faddp   ST1, ST0
+fstack_branch_done:
+	fstp    ST0
+	ret
+
+; --- [ Dynamic stack top ] ----------------------------------------------------
+
+; The stack depth at the loop header differs between its predecessors; the stack
+; top is held in a local variable.
+fstack_dynamic:
+	mov     rcx, 3
+fstack_dynamic_loop:
+	fld1
+	dec     rcx
+	jnz     fstack_dynamic_loop
+	ret
@@ -0,0 +1,195 @@
+define void @_imp_fstack_balanced() !addr !{!"0x10000000"} {
+; <label>:0
+	%f6 = alloca x86_fp80
+	%f7 = alloca x86_fp80
+	br label %block_10000000
+
+block_10000000:
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f7
+	store x86_fp80 0xK00000000000000000000, x86_fp80* %f6
+	%1 = load x86_fp80, x86_fp80* %f7
+	%2 = load x86_fp80, x86_fp80* %f6
+	%3 = fadd x86_fp80 %1, %2
+	store x86_fp80 %3, x86_fp80* %f7
+	%4 = load x86_fp80, x86_fp80* %f7
+	store x86_fp80 %4, x86_fp80* %f7
+	ret void
+}
+
+define void @_imp_fstack_branch() !addr !{!"0x10000009"} {
+; <label>:0
+	%rax = alloca i64
+	%f6 = alloca x86_fp80
+	%f7 = alloca x86_fp80
+	br label %block_10000009
+
+block_10000009:
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f7
+	%1 = load i64, i64* %rax
+	%2 = load i64, i64* %rax
+	%3 = and i64 %1, %2
+	%4 = icmp eq i64 %3, 0
+	br i1 %4, label %block_10000016, label %block_10000010
+
+block_10000010:
+	store x86_fp80 0xK4000C90FDAA22168C000, x86_fp80* %f6
+	%5 = load x86_fp80, x86_fp80* %f7
+	%6 = load x86_fp80, x86_fp80* %f6
+	%7 = fadd x86_fp80 %5, %6
+	store x86_fp80 %7, x86_fp80* %f7
+	br label %block_1000001A
+
+block_10000016:
+	store x86_fp80 0xK00000000000000000000, x86_fp80* %f6
+	%8 = load x86_fp80, x86_fp80* %f7
+	%9 = load x86_fp80, x86_fp80* %f6
+	%10 = fadd x86_fp80 %8, %9
+	store x86_fp80 %10, x86_fp80* %f7
+	%11 = load x86_fp80, x86_fp80* %f7
+	store x86_fp80 %11, x86_fp80* %f7
+	ret void
+
+block_1000001A:
+	%12 = load x86_fp80, x86_fp80* %f7
+	store x86_fp80 %12, x86_fp80* %f7
+	ret void
+}
+
+define void @_imp_fstack_dynamic() !addr !{!"0x1000001D"} {
+; <label>:0
+	%rcx = alloca i64
+	%f0 = alloca x86_fp80
+	%f1 = alloca x86_fp80
+	%f2 = alloca x86_fp80
+	%f3 = alloca x86_fp80
+	%f4 = alloca x86_fp80
+	%f5 = alloca x86_fp80
+	%f6 = alloca x86_fp80
+	%f7 = alloca x86_fp80
+	%st = alloca i8
+	store i8 0, i8* %st
+	br label %block_1000001D
+
+block_1000001D:
+	%1 = zext i32 3 to i64
+	store i64 %1, i64* %rcx
+	%2 = load i8, i8* %st
+	%3 = add i8 %2, 7
+	%4 = and i8 %3, 7
+	store i8 %4, i8* %st
+	%5 = load i8, i8* %st
+	switch i8 %5, label %14 [
+		i8 0, label %6
+		i8 1, label %7
+		i8 2, label %8
+		i8 3, label %9
+		i8 4, label %10
+		i8 5, label %11
+		i8 6, label %12
+		i8 7, label %13
+	]
+
+; <label>:6
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f0
+	br label %15
+
+; <label>:7
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f1
+	br label %15
+
+; <label>:8
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f2
+	br label %15
+
+; <label>:9
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f3
+	br label %15
+
+; <label>:10
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f4
+	br label %15
+
+; <label>:11
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f5
+	br label %15
+
+; <label>:12
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f6
+	br label %15
+
+; <label>:13
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f7
+	br label %15
+
+; <label>:14
+	unreachable
+
+; <label>:15
+	%16 = load i64, i64* %rcx
+	%17 = sub i64 %16, 1
+	store i64 %17, i64* %rcx
+	%18 = icmp ne i64 %16, 1
+	br i1 %18, label %block_10000022, label %block_10000029
+
+block_10000022:
+	%19 = load i8, i8* %st
+	%20 = add i8 %19, 7
+	%21 = and i8 %20, 7
+	store i8 %21, i8* %st
+	%22 = load i8, i8* %st
+	switch i8 %22, label %31 [
+		i8 0, label %23
+		i8 1, label %24
+		i8 2, label %25
+		i8 3, label %26
+		i8 4, label %27
+		i8 5, label %28
+		i8 6, label %29
+		i8 7, label %30
+	]
+
+; <label>:23
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f0
+	br label %32
+
+; <label>:24
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f1
+	br label %32
+
+; <label>:25
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f2
+	br label %32
+
+; <label>:26
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f3
+	br label %32
+
+; <label>:27
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f4
+	br label %32
+
+; <label>:28
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f5
+	br label %32
+
+; <label>:29
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f6
+	br label %32
+
+; <label>:30
+	store x86_fp80 0xK3FFF8000000000000000, x86_fp80* %f7
+	br label %32
+
+; <label>:31
+	unreachable
+
+; <label>:32
+	%33 = load i64, i64* %rcx
+	%34 = sub i64 %33, 1
+	store i64 %34, i64* %rcx
+	%35 = icmp ne i64 %33, 1
+	br i1 %35, label %block_10000022, label %block_10000029, !llvm.loop !{!{!"llvm.loop.header", !"0x10000022"}, !{!"llvm.loop.latch", !"0x10000022"}, !{!"llvm.loop.exit", !"0x10000029"}}
+
+block_10000029:
+	ret void
+}
@@ -1,10 +1,13 @@
-define void @_start() !addr !{!"0x400000"} {
+define void @_imp__start() !addr !{!"0x400000"} {
 ; <label>:0
-	%edi = alloca i32
+	%rdi = alloca i64
 	br label %block_400000
 
 block_400000:
-	store i32 42, i32* %edi
-	call void @exit()
+	%1 = zext i32 42 to i64
+	store i64 %1, i64* %rdi
+	%2 = load i64, i64* %rdi
+	%3 = trunc i64 %2 to i32
+	call void @_imp_exit(i32 %3)
 	ret void
 }
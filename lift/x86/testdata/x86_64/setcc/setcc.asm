@@ -0,0 +1,76 @@
+[BITS 64]
+
+global setz:function
+global setl:function
+global setbe:function
+global setc_zx:function
+global sbb_mask:function
+global sbb_neg:function
+global sbb_carry:function
+
+section .text
+
+; === [ SETcc - Set Byte on Condition ] ========================================
+
+; --- [ SETZ ] -----------------------------------------------------------------
+
+; Boolean of integer comparison; x == y.
+setz:
+	xor     rax, rax
+	cmp     rdx, rbx
+	setz    al
+	ret
+
+; --- [ SETL ] -----------------------------------------------------------------
+
+; Boolean of signed integer comparison; x < y.
+setl:
+	xor     rax, rax
+	cmp     rdx, rbx
+	setl    al
+	ret
+
+; --- [ SETBE ] ----------------------------------------------------------------
+
+; Boolean of unsigned integer comparison; x <= y.
+setbe:
+	xor     rax, rax
+	cmp     rdx, rbx
+	setbe   al
+	ret
+
+; --- [ SETC ] -----------------------------------------------------------------
+
+; Boolean of carry, zero-extended by MOVZX.
+setc_zx:
+	add     rdx, rbx
+	setc    cl
+	movzx   rax, cl
+	ret
+
+; === [ SBB flag idioms ] ======================================================
+
+; --- [ SBB r, r ] -------------------------------------------------------------
+
+; All-ones mask if x < y (unsigned); 0 otherwise.
+sbb_mask:
+	cmp     rdx, rbx
+	sbb     rax, rax
+	ret
+
+; --- [ NEG; SBB r, r ] --------------------------------------------------------
+
+; All-ones mask if x != 0; 0 otherwise.
+sbb_neg:
+	neg     rdx
+	sbb     rax, rax
+	ret
+
+; --- [ SBB r, imm ] -----------------------------------------------------------
+
+; Subtraction with borrow of a preceding comparison.
+sbb_carry:
+	mov     rax, 10
+	cmp     rdx, rbx
+	sbb     rax, 1
+	ret
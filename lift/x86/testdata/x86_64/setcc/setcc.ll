@@ -0,0 +1,156 @@
+define void @_imp_setz() !addr !{!"0x10000000"} {
+; <label>:0
+	%rax = alloca i64
+	%rdx = alloca i64
+	%rbx = alloca i64
+	br label %block_10000000
+
+block_10000000:
+	%1 = load i64, i64* %rax
+	%2 = load i64, i64* %rax
+	%3 = xor i64 %1, %2
+	store i64 %3, i64* %rax
+	%4 = load i64, i64* %rdx
+	%5 = load i64, i64* %rbx
+	%6 = sub i64 %4, %5
+	%7 = icmp eq i64 %4, %5
+	%8 = zext i1 %7 to i8
+	%9 = load i64, i64* %rax
+	%10 = zext i8 %8 to i64
+	%11 = and i64 %9, -256
+	%12 = or i64 %11, %10
+	store i64 %12, i64* %rax
+	ret void
+}
+
+define void @_imp_setl() !addr !{!"0x1000000A"} {
+; <label>:0
+	%rax = alloca i64
+	%rdx = alloca i64
+	%rbx = alloca i64
+	br label %block_1000000A
+
+block_1000000A:
+	%1 = load i64, i64* %rax
+	%2 = load i64, i64* %rax
+	%3 = xor i64 %1, %2
+	store i64 %3, i64* %rax
+	%4 = load i64, i64* %rdx
+	%5 = load i64, i64* %rbx
+	%6 = sub i64 %4, %5
+	%7 = icmp slt i64 %4, %5
+	%8 = zext i1 %7 to i8
+	%9 = load i64, i64* %rax
+	%10 = zext i8 %8 to i64
+	%11 = and i64 %9, -256
+	%12 = or i64 %11, %10
+	store i64 %12, i64* %rax
+	ret void
+}
+
+define void @_imp_setbe() !addr !{!"0x10000014"} {
+; <label>:0
+	%rax = alloca i64
+	%rdx = alloca i64
+	%rbx = alloca i64
+	br label %block_10000014
+
+block_10000014:
+	%1 = load i64, i64* %rax
+	%2 = load i64, i64* %rax
+	%3 = xor i64 %1, %2
+	store i64 %3, i64* %rax
+	%4 = load i64, i64* %rdx
+	%5 = load i64, i64* %rbx
+	%6 = sub i64 %4, %5
+	%7 = icmp ule i64 %4, %5
+	%8 = zext i1 %7 to i8
+	%9 = load i64, i64* %rax
+	%10 = zext i8 %8 to i64
+	%11 = and i64 %9, -256
+	%12 = or i64 %11, %10
+	store i64 %12, i64* %rax
+	ret void
+}
+
+define void @_imp_setc_zx() !addr !{!"0x1000001E"} {
+; <label>:0
+	%rax = alloca i64
+	%rcx = alloca i64
+	%rdx = alloca i64
+	%rbx = alloca i64
+	br label %block_1000001E
+
+block_1000001E:
+	%1 = load i64, i64* %rdx
+	%2 = load i64, i64* %rbx
+	%3 = add i64 %1, %2
+	store i64 %3, i64* %rdx
+	%4 = icmp ult i64 %3, %1
+	%5 = zext i1 %4 to i8
+	%6 = load i64, i64* %rcx
+	%7 = zext i8 %5 to i64
+	%8 = and i64 %6, -256
+	%9 = or i64 %8, %7
+	store i64 %9, i64* %rcx
+	%10 = load i64, i64* %rcx
+	%11 = trunc i64 %10 to i8
+	%12 = zext i8 %11 to i64
+	store i64 %12, i64* %rax
+	ret void
+}
+
+define void @_imp_sbb_mask() !addr !{!"0x10000029"} {
+; <label>:0
+	%rax = alloca i64
+	%rdx = alloca i64
+	%rbx = alloca i64
+	br label %block_10000029
+
+block_10000029:
+	%1 = load i64, i64* %rdx
+	%2 = load i64, i64* %rbx
+	%3 = sub i64 %1, %2
+	%4 = icmp ult i64 %1, %2
+	%5 = sext i1 %4 to i64
+	store i64 %5, i64* %rax
+	ret void
+}
+
+define void @_imp_sbb_neg() !addr !{!"0x10000030"} {
+; <label>:0
+	%rax = alloca i64
+	%rdx = alloca i64
+	br label %block_10000030
+
+block_10000030:
+	%1 = load i64, i64* %rdx
+	%2 = sub i64 0, %1
+	store i64 %2, i64* %rdx
+	%3 = icmp ult i64 0, %1
+	%4 = sext i1 %3 to i64
+	store i64 %4, i64* %rax
+	ret void
+}
+
+define void @_imp_sbb_carry() !addr !{!"0x10000037"} {
+; <label>:0
+	%rax = alloca i64
+	%rdx = alloca i64
+	%rbx = alloca i64
+	br label %block_10000037
+
+block_10000037:
+	%1 = zext i32 10 to i64
+	store i64 %1, i64* %rax
+	%2 = load i64, i64* %rdx
+	%3 = load i64, i64* %rbx
+	%4 = sub i64 %2, %3
+	%5 = load i64, i64* %rax
+	%6 = icmp ult i64 %2, %3
+	%7 = zext i1 %6 to i64
+	%8 = add i64 1, %7
+	%9 = sub i64 %5, %8
+	store i64 %9, i64* %rax
+	ret void
+}
@@ -0,0 +1,87 @@
+[BITS 64]
+
+global shld_imm:function
+global shld_cl:function
+global shrd_imm:function
+global shrd_cl:function
+global rcl_1:function
+global rcr_cl:function
+global shl64:function
+global shr64:function
+global sar64:function
+
+section .text
+
+; === [ SHLD/SHRD - Double Precision Shift ] ===================================
+
+; --- [ SHLD r/m64, r64, imm8 ] ------------------------------------------------
+
+shld_imm:
+	mov     rax, rdx
+	shld    rax, rbx, 4
+	ret
+
+; --- [ SHLD r/m64, r64, CL ] --------------------------------------------------
+
+shld_cl:
+	mov     rax, rdx
+	shld    rax, rbx, cl
+	ret
+
+; --- [ SHRD r/m64, r64, imm8 ] ------------------------------------------------
+
+shrd_imm:
+	mov     rax, rdx
+	shrd    rax, rbx, 4
+	ret
+
+; --- [ SHRD r/m64, r64, CL ] --------------------------------------------------
+
+shrd_cl:
+	mov     rax, rdx
+	shrd    rax, rbx, cl
+	ret
+
+; === [ RCL/RCR - Rotate Through Carry ] =======================================
+
+; --- [ RCL r/m64, 1 ] ---------------------------------------------------------
+
+rcl_1:
+	mov     rax, rdx
+	cmp     rdx, rbx
+	rcl     rax, 1
+	ret
+
+; --- [ RCR r/m64, CL ] --------------------------------------------------------
+
+rcr_cl:
+	mov     rax, rdx
+	cmp     rdx, rbx
+	rcr     rax, cl
+	ret
+
+; === [ Paired 64-bit shift idioms ] ===========================================
+
+; --- [ SHLD; SHL ] ------------------------------------------------------------
+
+; RDX:RAX << CL
+shl64:
+	shld    rdx, rax, cl
+	shl     rax, cl
+	ret
+
+; --- [ SHRD; SHR ] ------------------------------------------------------------
+
+; RDX:RAX >> CL (logical)
+shr64:
+	shrd    rax, rdx, cl
+	shr     rdx, cl
+	ret
+
+; --- [ SHRD; SAR ] ------------------------------------------------------------
+
+; RDX:RAX >> CL (arithmetic)
+sar64:
+	shrd    rax, rdx, cl
+	sar     rdx, cl
+	ret
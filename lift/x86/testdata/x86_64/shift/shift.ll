@@ -0,0 +1,271 @@
+define void @_imp_shld_imm() !addr !{!"0x10000000"} {
+; <label>:0
+	%rax = alloca i64
+	%rdx = alloca i64
+	%rbx = alloca i64
+	br label %block_10000000
+
+block_10000000:
+	%1 = load i64, i64* %rdx
+	store i64 %1, i64* %rax
+	%2 = load i64, i64* %rax
+	%3 = load i64, i64* %rbx
+	%4 = zext i64 4 to i128
+	%5 = zext i64 %2 to i128
+	%6 = shl i128 %5, 64
+	%7 = zext i64 %3 to i128
+	%8 = or i128 %6, %7
+	%9 = shl i128 %8, %4
+	%10 = lshr i128 %9, 64
+	%11 = trunc i128 %10 to i64
+	store i64 %11, i64* %rax
+	ret void
+}
+
+define void @_imp_shld_cl() !addr !{!"0x10000009"} {
+; <label>:0
+	%rax = alloca i64
+	%rcx = alloca i64
+	%rdx = alloca i64
+	%rbx = alloca i64
+	br label %block_10000009
+
+block_10000009:
+	%1 = load i64, i64* %rdx
+	store i64 %1, i64* %rax
+	%2 = load i64, i64* %rax
+	%3 = load i64, i64* %rbx
+	%4 = load i64, i64* %rcx
+	%5 = trunc i64 %4 to i8
+	%6 = zext i8 %5 to i64
+	%7 = and i64 %6, 63
+	%8 = zext i64 %7 to i128
+	%9 = zext i64 %2 to i128
+	%10 = shl i128 %9, 64
+	%11 = zext i64 %3 to i128
+	%12 = or i128 %10, %11
+	%13 = shl i128 %12, %8
+	%14 = lshr i128 %13, 64
+	%15 = trunc i128 %14 to i64
+	store i64 %15, i64* %rax
+	ret void
+}
+
+define void @_imp_shrd_imm() !addr !{!"0x10000011"} {
+; <label>:0
+	%rax = alloca i64
+	%rdx = alloca i64
+	%rbx = alloca i64
+	br label %block_10000011
+
+block_10000011:
+	%1 = load i64, i64* %rdx
+	store i64 %1, i64* %rax
+	%2 = load i64, i64* %rax
+	%3 = load i64, i64* %rbx
+	%4 = zext i64 4 to i128
+	%5 = zext i64 %3 to i128
+	%6 = shl i128 %5, 64
+	%7 = zext i64 %2 to i128
+	%8 = or i128 %6, %7
+	%9 = lshr i128 %8, %4
+	%10 = trunc i128 %9 to i64
+	store i64 %10, i64* %rax
+	ret void
+}
+
+define void @_imp_shrd_cl() !addr !{!"0x1000001A"} {
+; <label>:0
+	%rax = alloca i64
+	%rcx = alloca i64
+	%rdx = alloca i64
+	%rbx = alloca i64
+	br label %block_1000001A
+
+block_1000001A:
+	%1 = load i64, i64* %rdx
+	store i64 %1, i64* %rax
+	%2 = load i64, i64* %rax
+	%3 = load i64, i64* %rbx
+	%4 = load i64, i64* %rcx
+	%5 = trunc i64 %4 to i8
+	%6 = zext i8 %5 to i64
+	%7 = and i64 %6, 63
+	%8 = zext i64 %7 to i128
+	%9 = zext i64 %3 to i128
+	%10 = shl i128 %9, 64
+	%11 = zext i64 %2 to i128
+	%12 = or i128 %10, %11
+	%13 = lshr i128 %12, %8
+	%14 = trunc i128 %13 to i64
+	store i64 %14, i64* %rax
+	ret void
+}
+
+define void @_imp_rcl_1() !addr !{!"0x10000022"} {
+; <label>:0
+	%rax = alloca i64
+	%rdx = alloca i64
+	%rbx = alloca i64
+	%cf = alloca i1
+	br label %block_10000022
+
+block_10000022:
+	%1 = load i64, i64* %rdx
+	store i64 %1, i64* %rax
+	%2 = load i64, i64* %rdx
+	%3 = load i64, i64* %rbx
+	%4 = sub i64 %2, %3
+	%5 = load i64, i64* %rax
+	%6 = icmp ult i64 %2, %3
+	%7 = zext i1 %6 to i65
+	%8 = shl i65 %7, 64
+	%9 = zext i64 %5 to i65
+	%10 = or i65 %8, %9
+	%11 = zext i64 1 to i65
+	%12 = urem i65 %11, 65
+	%13 = sub i65 65, %12
+	%14 = shl i65 %10, %12
+	%15 = lshr i65 %10, %13
+	%16 = or i65 %14, %15
+	%17 = icmp eq i65 %12, 0
+	%18 = select i1 %17, i65 %10, i65 %16
+	%19 = trunc i65 %18 to i64
+	store i64 %19, i64* %rax
+	%20 = lshr i65 %18, 64
+	%21 = trunc i65 %20 to i1
+	store i1 %21, i1* %cf
+	ret void
+}
+
+define void @_imp_rcr_cl() !addr !{!"0x1000002C"} {
+; <label>:0
+	%rax = alloca i64
+	%rcx = alloca i64
+	%rdx = alloca i64
+	%rbx = alloca i64
+	%cf = alloca i1
+	br label %block_1000002C
+
+block_1000002C:
+	%1 = load i64, i64* %rdx
+	store i64 %1, i64* %rax
+	%2 = load i64, i64* %rdx
+	%3 = load i64, i64* %rbx
+	%4 = sub i64 %2, %3
+	%5 = load i64, i64* %rax
+	%6 = icmp ult i64 %2, %3
+	%7 = zext i1 %6 to i65
+	%8 = shl i65 %7, 64
+	%9 = zext i64 %5 to i65
+	%10 = or i65 %8, %9
+	%11 = load i64, i64* %rcx
+	%12 = trunc i64 %11 to i8
+	%13 = zext i8 %12 to i64
+	%14 = and i64 %13, 63
+	%15 = zext i64 %14 to i65
+	%16 = urem i65 %15, 65
+	%17 = sub i65 65, %16
+	%18 = lshr i65 %10, %16
+	%19 = shl i65 %10, %17
+	%20 = or i65 %18, %19
+	%21 = icmp eq i65 %16, 0
+	%22 = select i1 %21, i65 %10, i65 %20
+	%23 = trunc i65 %22 to i64
+	store i64 %23, i64* %rax
+	%24 = lshr i65 %22, 64
+	%25 = trunc i65 %24 to i1
+	store i1 %25, i1* %cf
+	ret void
+}
+
+define void @_imp_shl64() !addr !{!"0x10000036"} {
+; <label>:0
+	%rax = alloca i64
+	%rcx = alloca i64
+	%rdx = alloca i64
+	br label %block_10000036
+
+block_10000036:
+	%1 = load i64, i64* %rdx
+	%2 = load i64, i64* %rax
+	%3 = load i64, i64* %rcx
+	%4 = trunc i64 %3 to i8
+	%5 = zext i8 %4 to i64
+	%6 = and i64 %5, 63
+	%7 = zext i64 %6 to i128
+	%8 = zext i64 %1 to i128
+	%9 = shl i128 %8, 64
+	%10 = zext i64 %2 to i128
+	%11 = or i128 %9, %10
+	%12 = shl i128 %11, %7
+	%13 = lshr i128 %12, 64
+	%14 = trunc i128 %13 to i64
+	store i64 %14, i64* %rdx
+	%15 = load i64, i64* %rax
+	%16 = load i64, i64* %rcx
+	%17 = trunc i64 %16 to i8
+	%18 = shl i64 %15, %17
+	store i64 %18, i64* %rax
+	ret void
+}
+
+define void @_imp_shr64() !addr !{!"0x1000003E"} {
+; <label>:0
+	%rax = alloca i64
+	%rcx = alloca i64
+	%rdx = alloca i64
+	br label %block_1000003E
+
+block_1000003E:
+	%1 = load i64, i64* %rax
+	%2 = load i64, i64* %rdx
+	%3 = load i64, i64* %rcx
+	%4 = trunc i64 %3 to i8
+	%5 = zext i8 %4 to i64
+	%6 = and i64 %5, 63
+	%7 = zext i64 %6 to i128
+	%8 = zext i64 %2 to i128
+	%9 = shl i128 %8, 64
+	%10 = zext i64 %1 to i128
+	%11 = or i128 %9, %10
+	%12 = lshr i128 %11, %7
+	%13 = trunc i128 %12 to i64
+	store i64 %13, i64* %rax
+	%14 = load i64, i64* %rdx
+	%15 = load i64, i64* %rcx
+	%16 = trunc i64 %15 to i8
+	%17 = lshr i64 %14, %16
+	store i64 %17, i64* %rdx
+	ret void
+}
+
+define void @_imp_sar64() !addr !{!"0x10000046"} {
+; <label>:0
+	%rax = alloca i64
+	%rcx = alloca i64
+	%rdx = alloca i64
+	br label %block_10000046
+
+block_10000046:
+	%1 = load i64, i64* %rax
+	%2 = load i64, i64* %rdx
+	%3 = load i64, i64* %rcx
+	%4 = trunc i64 %3 to i8
+	%5 = zext i8 %4 to i64
+	%6 = and i64 %5, 63
+	%7 = zext i64 %6 to i128
+	%8 = zext i64 %2 to i128
+	%9 = shl i128 %8, 64
+	%10 = zext i64 %1 to i128
+	%11 = or i128 %9, %10
+	%12 = lshr i128 %11, %7
+	%13 = trunc i128 %12 to i64
+	store i64 %13, i64* %rax
+	%14 = load i64, i64* %rdx
+	%15 = load i64, i64* %rcx
+	%16 = trunc i64 %15 to i8
+	%17 = ashr i64 %14, %16
+	store i64 %17, i64* %rdx
+	ret void
+}
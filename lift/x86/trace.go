@@ -0,0 +1,33 @@
+package x86
+
+import (
+	"github.com/decomp/exp/bin"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/types"
+)
+
+// Instruction trace instrumentation.
+//
+// If the Trace field of the lifter is set, a call to the __trace runtime
+// support function is inserted at the start of each lifted basic block, passing
+// the address of the basic block in the original binary executable. Recompiled
+// lifted code linked with a __trace implementation thus produces a basic block
+// trace (or coverage), which may be compared to a trace of the original program
+// to validate the lifter.
+//
+//    declare void @__trace(i64 %addr)
+//
+//    block_401000:
+//       call void @__trace(i64 4198400)
+//       ...
+
+// Name of runtime support function which records the execution of a basic
+// block.
+const traceFunc = "__trace"
+
+// trace emits a call to the __trace runtime support function to record the
+// execution of the basic block at the given address, emitting code to f.
+func (f *Func) trace(addr bin.Address) {
+	callee := f.l.intrinsics[traceFunc]
+	f.cur.NewCall(callee, constant.NewInt(types.I64, int64(addr)))
+}
@@ -0,0 +1,338 @@
+package x86
+
+import (
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+)
+
+// Variadic Functions
+//
+// Calls to variadic functions pass the fixed parameters of the callee
+// signature, followed by the variable arguments of the call site. The number
+// and types of variable arguments passed to format string functions (e.g. the
+// printf and scanf families) are derived from the conversion specifications of
+// constant format strings.
+//
+//    push    3
+//    push    offset aD          ; "%d\n"
+//    call    printf
+//    add     esp, 8
+//
+// The above assembly is lifted to the following LLVM IR.
+//
+//    %1 = call i32 (i8*, ...) @printf(i8* %fmt, i32 3)
+//
+// Variable arguments of calls to other variadic functions, of calls with
+// non-constant format strings, and of calls in 64-bit mode are omitted.
+
+// formatKind specifies the kind of format string of a format string function.
+type formatKind uint8
+
+// Kinds of format strings.
+const (
+	// printf-style format string, with variable arguments passed by value.
+	formatPrintf formatKind = iota + 1
+	// scanf-style format string, with variable arguments passed by reference.
+	formatScanf
+)
+
+// A formatFunc specifies the format string parameter of a format string
+// function.
+type formatFunc struct {
+	// Kind of format string.
+	kind formatKind
+	// Index of the format string parameter.
+	index int
+	// Wide character format string.
+	wide bool
+}
+
+// formatFuncs maps from function name to format string functions.
+var formatFuncs = map[string]formatFunc{
+	// printf family.
+	"printf":     {kind: formatPrintf, index: 0},
+	"fprintf":    {kind: formatPrintf, index: 1},
+	"sprintf":    {kind: formatPrintf, index: 1},
+	"snprintf":   {kind: formatPrintf, index: 2},
+	"_snprintf":  {kind: formatPrintf, index: 2},
+	"asprintf":   {kind: formatPrintf, index: 1},
+	"dprintf":    {kind: formatPrintf, index: 1},
+	"syslog":     {kind: formatPrintf, index: 1},
+	"err":        {kind: formatPrintf, index: 1},
+	"warn":       {kind: formatPrintf, index: 0},
+	"errx":       {kind: formatPrintf, index: 1},
+	"warnx":      {kind: formatPrintf, index: 0},
+	"wsprintfA":  {kind: formatPrintf, index: 1},
+	"wprintf":    {kind: formatPrintf, index: 0, wide: true},
+	"fwprintf":   {kind: formatPrintf, index: 1, wide: true},
+	"swprintf":   {kind: formatPrintf, index: 2, wide: true},
+	"_snwprintf": {kind: formatPrintf, index: 2, wide: true},
+	"wsprintfW":  {kind: formatPrintf, index: 1, wide: true},
+	// scanf family.
+	"scanf":   {kind: formatScanf, index: 0},
+	"fscanf":  {kind: formatScanf, index: 1},
+	"sscanf":  {kind: formatScanf, index: 1},
+	"wscanf":  {kind: formatScanf, index: 0, wide: true},
+	"fwscanf": {kind: formatScanf, index: 1, wide: true},
+	"swscanf": {kind: formatScanf, index: 1, wide: true},
+}
+
+// popVarArgs pops the variable arguments of a call to the given variadic
+// function, based on the fixed arguments of the call, emitting code to f.
+func (f *Func) popVarArgs(callee value.Named, args []value.Value) []value.Value {
+	name := callee.Name()
+	ff, ok := formatFuncs[name]
+	if !ok {
+		// Leading underscore of C symbols (e.g. _printf).
+		ff, ok = formatFuncs[strings.TrimPrefix(name, "_")]
+	}
+	if !ok || ff.index >= len(args) {
+		dbg.Printf("unable to determine variable arguments of call to %q", name)
+		return nil
+	}
+	addr, ok := f.constAddr(args[ff.index])
+	if !ok {
+		dbg.Printf("unable to determine variable arguments of call to %q; non-constant format string", name)
+		return nil
+	}
+	format, ok := f.l.readString(addr, ff.wide)
+	if !ok {
+		warn.Printf("unable to read format string at %v of call to %q", addr, name)
+		return nil
+	}
+	var params []types.Type
+	switch ff.kind {
+	case formatPrintf:
+		params = printfParams(format, f.l.Mode)
+	case formatScanf:
+		params = scanfParams(format)
+	}
+	var varargs []value.Value
+	for _, param := range params {
+		varargs = append(varargs, f.popArg(param))
+	}
+	return varargs
+}
+
+// printfParams returns the types of the variable arguments of the given
+// printf-style format string, as passed after default argument promotion.
+func printfParams(format string, mode int) []types.Type {
+	intptr := types.NewInt(uint64(mode))
+	var params []types.Type
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		// Flags.
+		for i < len(format) && strings.IndexByte("-+ #0'", format[i]) != -1 {
+			i++
+		}
+		// Width and precision.
+		for i < len(format) && strings.IndexByte("0123456789.*$", format[i]) != -1 {
+			if format[i] == '*' {
+				params = append(params, types.I32)
+			}
+			i++
+		}
+		length, conv, n := formatConv(format[i:])
+		i += n - 1
+		switch conv {
+		case 'd', 'i', 'o', 'u', 'x', 'X', 'c', 'C':
+			switch length {
+			case "ll", "L", "q", "j", "I64":
+				params = append(params, types.I64)
+			case "z", "t", "I":
+				params = append(params, intptr)
+			default:
+				params = append(params, types.I32)
+			}
+		case 'e', 'E', 'f', 'F', 'g', 'G', 'a', 'A':
+			params = append(params, types.Double)
+		case 's', 'S', 'p', 'n':
+			params = append(params, types.NewPointer(stringElem(length, conv)))
+		}
+	}
+	return params
+}
+
+// scanfParams returns the types of the variable arguments of the given
+// scanf-style format string.
+func scanfParams(format string) []types.Type {
+	var params []types.Type
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		// Assignment suppression.
+		suppress := i < len(format) && format[i] == '*'
+		if suppress {
+			i++
+		}
+		// Width.
+		for i < len(format) && '0' <= format[i] && format[i] <= '9' {
+			i++
+		}
+		length, conv, n := formatConv(format[i:])
+		i += n - 1
+		if conv == '[' {
+			// Skip scanset; a leading ']' is part of the set.
+			i++
+			if i < len(format) && format[i] == '^' {
+				i++
+			}
+			if i < len(format) && format[i] == ']' {
+				i++
+			}
+			for i < len(format) && format[i] != ']' {
+				i++
+			}
+		}
+		if suppress || conv == 0 || conv == '%' {
+			continue
+		}
+		var elem types.Type
+		switch conv {
+		case 'd', 'i', 'o', 'u', 'x', 'X', 'n':
+			switch length {
+			case "hh":
+				elem = types.I8
+			case "h":
+				elem = types.I16
+			case "ll", "L", "q", "j", "I64":
+				elem = types.I64
+			default:
+				elem = types.I32
+			}
+		case 'e', 'E', 'f', 'F', 'g', 'G', 'a', 'A':
+			switch length {
+			case "l", "L":
+				elem = types.Double
+			default:
+				elem = types.Float
+			}
+		case 'p':
+			elem = types.NewPointer(types.I8)
+		default:
+			// s, S, c, C and [.
+			elem = stringElem(length, conv)
+		}
+		params = append(params, types.NewPointer(elem))
+	}
+	return params
+}
+
+// ### [ Helper functions ] ####################################################
+
+// formatConv returns the length modifier and conversion specifier at the start
+// of the given conversion specification (following the flags, width and
+// precision), and the number of bytes consumed. The conversion specifier is 0
+// if not present.
+func formatConv(s string) (length string, conv byte, n int) {
+	for _, l := range []string{"hh", "h", "ll", "l", "L", "q", "j", "z", "t", "I64", "I32", "I", "w"} {
+		if strings.HasPrefix(s, l) {
+			length = l
+			break
+		}
+	}
+	if len(length) < len(s) {
+		conv = s[len(length)]
+		return length, conv, len(length) + 1
+	}
+	return length, 0, len(s)
+}
+
+// stringElem returns the character type of the given string conversion.
+func stringElem(length string, conv byte) types.Type {
+	switch {
+	case conv == 'n':
+		return types.I32
+	case conv == 'S' || conv == 'C' || length == "l" || length == "w":
+		// Wide characters.
+		return types.I16
+	}
+	return types.I8
+}
+
+// constAddr returns the constant address held by the given value; e.g. an
+// immediate pushed onto the stack earlier in the current basic block. The
+// boolean return value indicates success.
+func (f *Func) constAddr(v value.Value) (bin.Address, bool) {
+	for {
+		switch x := v.(type) {
+		case *constant.Int:
+			return bin.Address(x.X.Uint64()), true
+		case *ir.InstIntToPtr:
+			v = x.From
+		case *ir.InstBitCast:
+			v = x.From
+		case *ir.InstZExt:
+			v = x.From
+		case *ir.InstLoad:
+			// Locate the last store to the source of the load preceding the load
+			// within the current basic block.
+			store, ok := f.lastStore(x)
+			if !ok {
+				return 0, false
+			}
+			v = store.Src
+		default:
+			return 0, false
+		}
+	}
+}
+
+// lastStore returns the last store to the source of the given load preceding
+// the load within the current basic block. The boolean return value indicates
+// success.
+func (f *Func) lastStore(load *ir.InstLoad) (*ir.InstStore, bool) {
+	insts := f.cur.Insts
+	i := len(insts) - 1
+	for ; i >= 0 && insts[i] != load; i-- {
+	}
+	for i--; i >= 0; i-- {
+		switch inst := insts[i].(type) {
+		case *ir.InstStore:
+			if inst.Dst == load.Src {
+				return inst, true
+			}
+		case *ir.InstCall:
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// readString returns the NULL-terminated string at the given address of the
+// binary executable; wide character strings are converted to UTF-8. The
+// boolean return value indicates success.
+func (l *Lifter) readString(addr bin.Address, wide bool) (string, bool) {
+	data, ok := l.File.AddressSpace().Bytes(addr, 0)
+	if !ok {
+		return "", false
+	}
+	var s []rune
+	if wide {
+		for i := 0; i+1 < len(data); i += 2 {
+			c := rune(data[i]) | rune(data[i+1])<<8
+			if c == 0 {
+				return string(s), true
+			}
+			s = append(s, c)
+		}
+		return "", false
+	}
+	for _, b := range data {
+		if b == 0 {
+			return string(s), true
+		}
+		s = append(s, rune(b))
+	}
+	return "", false
+}
@@ -0,0 +1,108 @@
+package patch
+
+import (
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// Detours
+//
+// A detour replaces an original function with a replacement function (e.g. a
+// recompiled re-implementation), which is relocated to the new section. The
+// prologue of the original function is replaced by a jump to the replacement,
+// and as the replacement is jumped to rather than called, it is entered with
+// the return address and stack arguments of the original call; i.e. the
+// replacement must follow the calling convention of the original function.
+//
+// The instructions of the overwritten prologue are copied to a trampoline,
+// which continues execution in the original function after the prologue. The
+// replacement may thus call the original function through the trampoline,
+// which is available to NASM assembly replacements as the original symbol.
+//
+//    original function     replacement            trampoline
+//
+//    401000: jmp 40a010    40a010: ...            40a000: push ebp
+//    401005: sub esp, 8            call original          mov ebp, esp
+//    401008: ...                   ...                    push esi
+//                                  ret                    jmp 401005
+//
+// Prologues containing relative branches or RIP-relative memory references are
+// not relocated, and thus not supported.
+
+// Name of the trampoline symbol defined for NASM assembly replacements.
+const origSym = "original"
+
+// Size in bytes of the jump to the replacement function.
+const jumpSize = 5
+
+// detourBlocks returns the blocks of the trampoline and replacement function
+// of the given detour.
+func detourBlocks(space *bin.AddressSpace, mode int, p *Patch) ([]*block, error) {
+	prologue, err := prologue(space, p.Addr, mode)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	n := bin.Address(len(prologue))
+	tramp := &block{
+		size: len(prologue) + jumpSize,
+		code: func(addr bin.Address) ([]byte, error) {
+			jmp, err := Jump(addr+n, p.Addr+n)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			code := make([]byte, 0, len(prologue)+len(jmp))
+			code = append(code, prologue...)
+			return append(code, jmp...), nil
+		},
+	}
+	// Size of the replacement function as assembled at the original address.
+	code, err := p.code(p.Addr, mode, p.Addr)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	repl := &block{
+		from:     p.Addr,
+		fromSize: len(prologue),
+		size:     len(code),
+		code: func(addr bin.Address) ([]byte, error) {
+			dbg.Printf("detouring function at %v to %v (trampoline at %v)", p.Addr, addr, tramp.addr)
+			return p.code(addr, mode, tramp.addr)
+		},
+	}
+	return []*block{tramp, repl}, nil
+}
+
+// prologue returns the machine code of the instructions at the start of the
+// function at the given address overwritten by a jump, as decoded in the
+// specified processor mode.
+func prologue(space *bin.AddressSpace, addr bin.Address, mode int) ([]byte, error) {
+	data, ok := space.Bytes(addr, bin.PermX)
+	if !ok {
+		return nil, errors.Errorf("unable to locate function at %v in executable section", addr)
+	}
+	n := 0
+	for n < jumpSize {
+		inst, err := x86asm.Decode(data[n:], mode)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to decode instruction at %v in prologue of function at %v", addr+bin.Address(n), addr)
+		}
+		instAddr := addr + bin.Address(n)
+		switch inst.Op {
+		case x86asm.RET, x86asm.LRET, x86asm.JMP, x86asm.INT, x86asm.UD2:
+			return nil, errors.Errorf("unable to detour function at %v; function too short for jump (%v instruction at %v)", addr, inst.Op, instAddr)
+		}
+		for _, arg := range inst.Args {
+			switch arg := arg.(type) {
+			case x86asm.Rel:
+				return nil, errors.Errorf("support for relative branch at %v in prologue of detoured function at %v not yet implemented", instAddr, addr)
+			case x86asm.Mem:
+				if arg.Base == x86asm.RIP {
+					return nil, errors.Errorf("support for RIP-relative memory reference at %v in prologue of detoured function at %v not yet implemented", instAddr, addr)
+				}
+			}
+		}
+		n += inst.Len
+	}
+	return data[:n], nil
+}
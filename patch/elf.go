@@ -0,0 +1,117 @@
+package patch
+
+import (
+	"encoding/binary"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// ELF program header types and segment flags.
+const (
+	ptLoad = 1
+	ptNote = 4
+	pfX    = 0x1
+	pfR    = 0x4
+)
+
+// Page alignment of the new segment.
+const elfPageSize = 0x1000
+
+// addSectionELF appends a new executable section of the given size to the ELF
+// executable, returning the updated contents of the executable, and the address
+// and file offset of the new section.
+//
+// The PT_NOTE program header (which is not required at run time) is converted
+// to a PT_LOAD segment mapping the new section, located after the last loadable
+// segment.
+func addSectionELF(buf []byte, size int) ([]byte, bin.Address, uint64, error) {
+	if len(buf) < 0x34 {
+		return nil, 0, 0, errors.New("invalid ELF executable; truncated ELF header")
+	}
+	var order binary.ByteOrder
+	switch data := buf[5]; data {
+	case 1:
+		order = binary.LittleEndian
+	case 2:
+		order = binary.BigEndian
+	default:
+		return nil, 0, 0, errors.Errorf("invalid ELF executable; unknown data encoding %d", data)
+	}
+	// Program header layout.
+	var (
+		is64                    bool
+		phoff, phentsize, phnum int
+	)
+	switch class := buf[4]; class {
+	case 1:
+		// ELF32.
+		phoff = int(order.Uint32(buf[0x1C:]))
+		phentsize = int(order.Uint16(buf[0x2A:]))
+		phnum = int(order.Uint16(buf[0x2C:]))
+	case 2:
+		// ELF64.
+		if len(buf) < 0x40 {
+			return nil, 0, 0, errors.New("invalid ELF executable; truncated ELF header")
+		}
+		is64 = true
+		phoff = int(order.Uint64(buf[0x20:]))
+		phentsize = int(order.Uint16(buf[0x36:]))
+		phnum = int(order.Uint16(buf[0x38:]))
+	default:
+		return nil, 0, 0, errors.Errorf("invalid ELF executable; unknown class %d", class)
+	}
+	if phoff+phnum*phentsize > len(buf) {
+		return nil, 0, 0, errors.New("invalid ELF executable; truncated program header table")
+	}
+	// Locate the PT_NOTE program header and the end of the last loadable
+	// segment.
+	note := -1
+	var vend uint64
+	for i := 0; i < phnum; i++ {
+		ph := buf[phoff+i*phentsize:]
+		var vaddr, memsz uint64
+		if is64 {
+			vaddr, memsz = order.Uint64(ph[16:]), order.Uint64(ph[40:])
+		} else {
+			vaddr, memsz = uint64(order.Uint32(ph[8:])), uint64(order.Uint32(ph[20:]))
+		}
+		switch order.Uint32(ph) {
+		case ptLoad:
+			if vaddr+memsz > vend {
+				vend = vaddr + memsz
+			}
+		case ptNote:
+			if note == -1 {
+				note = i
+			}
+		}
+	}
+	if note == -1 {
+		return nil, 0, 0, errors.New("unable to add segment to ELF executable; no PT_NOTE program header to convert")
+	}
+	// Append section contents to the end of the file.
+	off := uint64(align(len(buf), elfPageSize))
+	addr := uint64(align(int(vend), elfPageSize))
+	buf = append(buf, make([]byte, int(off)+size-len(buf))...)
+	ph := buf[phoff+note*phentsize:]
+	order.PutUint32(ph, ptLoad)
+	if is64 {
+		order.PutUint32(ph[4:], pfR|pfX)
+		order.PutUint64(ph[8:], off)
+		order.PutUint64(ph[16:], addr)
+		order.PutUint64(ph[24:], addr)
+		order.PutUint64(ph[32:], uint64(size))
+		order.PutUint64(ph[40:], uint64(size))
+		order.PutUint64(ph[48:], elfPageSize)
+	} else {
+		order.PutUint32(ph[4:], uint32(off))
+		order.PutUint32(ph[8:], uint32(addr))
+		order.PutUint32(ph[12:], uint32(addr))
+		order.PutUint32(ph[16:], uint32(size))
+		order.PutUint32(ph[20:], uint32(size))
+		order.PutUint32(ph[24:], pfR|pfX)
+		order.PutUint32(ph[28:], elfPageSize)
+	}
+	return buf, bin.Address(addr), off, nil
+}
@@ -0,0 +1,63 @@
+package patch
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// Assemble assembles the given NASM assembly source located at the specified
+// address in the given processor mode (16, 32 or 64), returning the machine
+// code as a flat binary.
+//
+// The NASM assembler is located using the NASM environment variable if set, and
+// PATH otherwise.
+func Assemble(src string, addr bin.Address, mode int) ([]byte, error) {
+	nasm, err := LookPath()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	dir, err := ioutil.TempDir("", "patch")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer os.RemoveAll(dir)
+	srcPath := filepath.Join(dir, "patch.asm")
+	binPath := filepath.Join(dir, "patch.bin")
+	// Assemble at the address of the patch, so that relative branches and
+	// references to absolute addresses are encoded correctly.
+	header := fmt.Sprintf("BITS %d\nORG 0x%X\n\n", mode, uint64(addr))
+	if err := ioutil.WriteFile(srcPath, []byte(header+src), 0644); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	stderr := &bytes.Buffer{}
+	cmd := exec.Command(nasm, "-f", "bin", "-o", binPath, srcPath)
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "unable to assemble patch using %q; %s", nasm, strings.TrimSpace(stderr.String()))
+	}
+	code, err := ioutil.ReadFile(binPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return code, nil
+}
+
+// LookPath returns the path of the NASM assembler; as specified by the NASM
+// environment variable, or located in PATH.
+func LookPath() (string, error) {
+	if nasm := os.Getenv("NASM"); len(nasm) > 0 {
+		return exec.LookPath(nasm)
+	}
+	if nasm, err := exec.LookPath("nasm"); err == nil {
+		return nasm, nil
+	}
+	return "", errors.New("unable to locate nasm in PATH; install NASM or set the NASM environment variable")
+}
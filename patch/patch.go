@@ -0,0 +1,267 @@
+// Package patch splices modified code and data back into binary executables.
+//
+// Patches are specified as NASM assembly (e.g. edited snippets of bin2asm
+// output) or as machine code (e.g. of lifted and recompiled functions), and
+// are written to the file offsets of the patched addresses. Patches which
+// exceed the size of the original code are relocated to a new section appended
+// to the executable, and the original code is replaced by a jump to the
+// relocated patch.
+//
+//    original code         patched code
+//
+//    401000: push ebp      401000: jmp 0x40a000
+//    401001: mov ebp, esp  401005: nop
+//    401003: ...           ...
+//                          40a000: <relocated patch>
+//
+// Functions may furthermore be detoured to replacement functions (e.g. for
+// incremental re-implementation of a binary executable), with trampolines to
+// the original functions.
+//
+// New sections are supported for PE and ELF executables. For ELF executables,
+// the PT_NOTE program header is converted to a PT_LOAD segment mapping the new
+// section, as is common practice; the section header table is left unaltered.
+package patch
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/decomp/exp/bin"
+	"github.com/mewkiz/pkg/term"
+	"github.com/pkg/errors"
+)
+
+// dbg represents a logger with the "patch:" prefix, which logs debug messages
+// to standard error.
+var dbg = log.New(os.Stderr, term.YellowBold("patch:")+" ", 0)
+
+// A Patch is a modification of the code or data of a binary executable.
+type Patch struct {
+	// Address of the patch.
+	Addr bin.Address
+	// Size in bytes of the original code or data replaced by the patch; or 0 to
+	// replace the length of the patch. Patches of executable sections shorter
+	// than Size are padded with NOP instructions. Patches longer than Size are
+	// relocated to a new section, and the original code is replaced by a jump
+	// to the relocated patch.
+	Size int
+	// NASM assembly source of the patch, assembled at the (possibly relocated)
+	// address of the patch; or empty if Data is used.
+	Src string
+	// Machine code or data of the patch (e.g. of a recompiled function); must
+	// be position-independent if relocated.
+	Data []byte
+	// Detour specifies whether the patch is a replacement of the function at
+	// Addr, in which case Size is ignored; see Detours.
+	Detour bool
+}
+
+// Alignment in bytes of relocated patches within the new section.
+const patchAlign = 16
+
+// A block is a block of code relocated to the new section.
+type block struct {
+	// Original address of the block, which is replaced by a jump to the
+	// relocated block; or 0 if not jumped to.
+	from bin.Address
+	// Size in bytes of the original code replaced by the jump.
+	fromSize int
+	// Relocated address of the block.
+	addr bin.Address
+	// Size in bytes of the block, as assembled at its original address.
+	size int
+	// code returns the machine code of the block located at the given address.
+	code func(addr bin.Address) ([]byte, error)
+}
+
+// Apply applies the given patches to the binary executable, returning the
+// contents of the patched executable. The original contents of the executable
+// are given by data, and the parsed executable by file.
+func Apply(data []byte, file *bin.File, patches []*Patch) ([]byte, error) {
+	buf := make([]byte, len(data))
+	copy(buf, data)
+	space := file.AddressSpace()
+	mode := file.Arch.BitSize()
+	// Patch in place.
+	var blocks []*block
+	for _, p := range patches {
+		if p.Detour {
+			bs, err := detourBlocks(space, mode, p)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			blocks = append(blocks, bs...)
+			continue
+		}
+		code, err := p.code(p.Addr, mode, 0)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		size := p.Size
+		if size == 0 {
+			size = len(code)
+		}
+		if len(code) > size {
+			p := p
+			b := &block{
+				from:     p.Addr,
+				fromSize: p.Size,
+				size:     len(code),
+				code: func(addr bin.Address) ([]byte, error) {
+					return p.code(addr, mode, 0)
+				},
+			}
+			blocks = append(blocks, b)
+			continue
+		}
+		if err := write(buf, space, p.Addr, pad(space, p.Addr, code, size)); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	if len(blocks) == 0 {
+		return buf, nil
+	}
+	// Relocate patches exceeding the size of the original code to a new
+	// section.
+	switch file.Arch {
+	case bin.ArchX86_32, bin.ArchX86_64:
+		// supported.
+	default:
+		return nil, errors.Errorf("support for relocated patches of machine architecture %v not yet implemented", file.Arch)
+	}
+	sectSize := 0
+	for _, b := range blocks {
+		sectSize = align(sectSize, patchAlign) + b.size
+	}
+	buf, sectAddr, sectOff, err := addSection(buf, sectSize)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	dbg.Printf("relocating %d blocks to new section at %v (%d bytes)", len(blocks), sectAddr, sectSize)
+	// Lay out blocks before assembling, as blocks may refer to the addresses of
+	// other blocks.
+	off := 0
+	for _, b := range blocks {
+		off = align(off, patchAlign)
+		b.addr = sectAddr + bin.Address(off)
+		off += b.size
+	}
+	for _, b := range blocks {
+		code, err := b.code(b.addr)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if len(code) > b.size {
+			return nil, errors.Errorf("size of patch at %v changed from %d to %d bytes when relocated to %v", b.from, b.size, len(code), b.addr)
+		}
+		copy(buf[sectOff+uint64(b.addr-sectAddr):], code)
+		if b.from == 0 {
+			continue
+		}
+		jmp, err := Jump(b.from, b.addr)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if b.fromSize < len(jmp) {
+			return nil, errors.Errorf("unable to relocate patch at %v; size of original code (%d bytes) too small for jump (%d bytes)", b.from, b.fromSize, len(jmp))
+		}
+		if err := write(buf, space, b.from, pad(space, b.from, jmp, b.fromSize)); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	return buf, nil
+}
+
+// code returns the machine code of the patch, as located at the given address
+// in the specified processor mode. The address of the trampoline to the
+// original function is given by orig for detours.
+func (p *Patch) code(addr bin.Address, mode int, orig bin.Address) ([]byte, error) {
+	if len(p.Src) == 0 {
+		return p.Data, nil
+	}
+	src := p.Src
+	if p.Detour {
+		src = fmt.Sprintf("%s equ 0x%X\n", origSym, uint64(orig)) + src
+	}
+	code, err := Assemble(src, addr, mode)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to assemble patch at %v", p.Addr)
+	}
+	return code, nil
+}
+
+// Jump returns the machine code of a near jump (JMP rel32) located at the
+// given address to the specified target address.
+func Jump(addr, target bin.Address) ([]byte, error) {
+	const size = 5
+	rel := int64(target) - int64(addr+size)
+	if rel < -1<<31 || rel >= 1<<31 {
+		return nil, errors.Errorf("jump target %v out of range of jump at %v", target, addr)
+	}
+	jmp := make([]byte, size)
+	jmp[0] = 0xE9
+	binary.LittleEndian.PutUint32(jmp[1:], uint32(int32(rel)))
+	return jmp, nil
+}
+
+// ### [ Helper functions ] ####################################################
+
+// write writes the given data to the file offset of the specified address.
+func write(buf []byte, space *bin.AddressSpace, addr bin.Address, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	start, ok := space.FileOffset(addr)
+	if !ok {
+		return errors.Errorf("unable to locate file offset of address %v", addr)
+	}
+	last, ok := space.FileOffset(addr + bin.Address(len(data)-1))
+	if !ok || last-start != uint64(len(data)-1) {
+		return errors.Errorf("patch at %v (%d bytes) exceeds file-backed contents of section", addr, len(data))
+	}
+	if last >= uint64(len(buf)) {
+		return errors.Errorf("patch at %v (%d bytes) exceeds end of file", addr, len(data))
+	}
+	dbg.Printf("patching %d bytes at %v (file offset 0x%X)", len(data), addr, start)
+	copy(buf[start:], data)
+	return nil
+}
+
+// pad pads the given code located at the specified address to size bytes, with
+// NOP instructions if located in an executable section.
+func pad(space *bin.AddressSpace, addr bin.Address, code []byte, size int) []byte {
+	if len(code) >= size {
+		return code
+	}
+	if _, ok := space.Section(addr, bin.PermX); !ok {
+		return code
+	}
+	buf := make([]byte, size)
+	copy(buf, code)
+	for i := len(code); i < size; i++ {
+		// NOP
+		buf[i] = 0x90
+	}
+	return buf
+}
+
+// align returns x rounded up to the nearest multiple of n.
+func align(x, n int) int {
+	return (x + n - 1) / n * n
+}
+
+// addSection appends a new executable section of the given size to the binary
+// executable, returning the updated contents of the executable, and the
+// address and file offset of the new section.
+func addSection(buf []byte, size int) ([]byte, bin.Address, uint64, error) {
+	switch {
+	case len(buf) >= 4 && string(buf[:4]) == "\x7FELF":
+		return addSectionELF(buf, size)
+	case len(buf) >= 2 && string(buf[:2]) == "MZ":
+		return addSectionPE(buf, size)
+	}
+	return nil, 0, 0, errors.New("support for new sections of binary executable format not yet implemented")
+}
@@ -0,0 +1,31 @@
+package patch
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+)
+
+func TestJump(t *testing.T) {
+	golden := []struct {
+		addr, target bin.Address
+		want         []byte
+	}{
+		{addr: 0x401000, target: 0x40A000, want: []byte{0xE9, 0xFB, 0x8F, 0x00, 0x00}},
+		{addr: 0x401000, target: 0x401000, want: []byte{0xE9, 0xFB, 0xFF, 0xFF, 0xFF}},
+	}
+	for _, g := range golden {
+		got, err := Jump(g.addr, g.target)
+		if err != nil {
+			t.Errorf("%v -> %v: unexpected error; %v", g.addr, g.target, err)
+			continue
+		}
+		if !bytes.Equal(got, g.want) {
+			t.Errorf("%v -> %v: jump mismatch; expected % X, got % X", g.addr, g.target, g.want, got)
+		}
+	}
+	if _, err := Jump(0x1000, 0x200000000); err == nil {
+		t.Errorf("expected error for out of range jump target")
+	}
+}
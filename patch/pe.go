@@ -0,0 +1,104 @@
+package patch
+
+import (
+	"encoding/binary"
+
+	"github.com/decomp/exp/bin"
+	"github.com/pkg/errors"
+)
+
+// Name of sections added to binary executables.
+const sectName = ".patch"
+
+// PE section characteristics of the new section; IMAGE_SCN_CNT_CODE,
+// IMAGE_SCN_MEM_EXECUTE and IMAGE_SCN_MEM_READ.
+const peSectFlags = 0x00000020 | 0x20000000 | 0x40000000
+
+// addSectionPE appends a new executable section of the given size to the PE
+// executable, returning the updated contents of the executable, and the address
+// and file offset of the new section.
+//
+// The section header is added to the free space following the section table,
+// which is commonly present due to file alignment of the headers.
+func addSectionPE(buf []byte, size int) ([]byte, bin.Address, uint64, error) {
+	le := binary.LittleEndian
+	if len(buf) < 0x40 {
+		return nil, 0, 0, errors.New("invalid PE executable; truncated DOS header")
+	}
+	peOff := int(le.Uint32(buf[0x3C:]))
+	if peOff+24 > len(buf) || string(buf[peOff:peOff+4]) != "PE\x00\x00" {
+		return nil, 0, 0, errors.New("invalid PE executable; missing PE signature")
+	}
+	// COFF file header.
+	coffOff := peOff + 4
+	nsects := int(le.Uint16(buf[coffOff+2:]))
+	optSize := int(le.Uint16(buf[coffOff+16:]))
+	// Optional header.
+	optOff := coffOff + 20
+	if optOff+optSize > len(buf) || optSize < 68 {
+		return nil, 0, 0, errors.New("invalid PE executable; truncated optional header")
+	}
+	var imageBase uint64
+	switch magic := le.Uint16(buf[optOff:]); magic {
+	case 0x10B:
+		// PE32.
+		imageBase = uint64(le.Uint32(buf[optOff+28:]))
+	case 0x20B:
+		// PE32+.
+		imageBase = le.Uint64(buf[optOff+24:])
+	default:
+		return nil, 0, 0, errors.Errorf("support for PE optional header magic 0x%04X not yet implemented", magic)
+	}
+	sectAlign := int(le.Uint32(buf[optOff+32:]))
+	fileAlign := int(le.Uint32(buf[optOff+36:]))
+	headersSize := int(le.Uint32(buf[optOff+60:]))
+	// Section table.
+	tableOff := optOff + optSize
+	hdrOff := tableOff + nsects*40
+	end := headersSize
+	var vend int
+	for i := 0; i < nsects; i++ {
+		off := tableOff + i*40
+		vsize := int(le.Uint32(buf[off+8:]))
+		rva := int(le.Uint32(buf[off+12:]))
+		rawSize := int(le.Uint32(buf[off+16:]))
+		rawOff := int(le.Uint32(buf[off+20:]))
+		if rawSize > vsize {
+			vsize = rawSize
+		}
+		if rva+vsize > vend {
+			vend = rva + vsize
+		}
+		if rawOff != 0 && rawOff < end {
+			end = rawOff
+		}
+	}
+	if hdrOff+40 > end || hdrOff+40 > len(buf) {
+		return nil, 0, 0, errors.Errorf("unable to add section header to PE executable; no free space following section table (offset 0x%X)", hdrOff)
+	}
+	for _, b := range buf[hdrOff : hdrOff+40] {
+		if b != 0 {
+			return nil, 0, 0, errors.Errorf("unable to add section header to PE executable; space following section table (offset 0x%X) in use", hdrOff)
+		}
+	}
+	// Append section contents to the end of the file (after any overlay).
+	rva := align(vend, sectAlign)
+	rawOff := align(len(buf), fileAlign)
+	rawSize := align(size, fileAlign)
+	buf = append(buf, make([]byte, rawOff+rawSize-len(buf))...)
+	// Section header.
+	hdr := buf[hdrOff : hdrOff+40]
+	copy(hdr[0:8], sectName)
+	le.PutUint32(hdr[8:], uint32(size))
+	le.PutUint32(hdr[12:], uint32(rva))
+	le.PutUint32(hdr[16:], uint32(rawSize))
+	le.PutUint32(hdr[20:], uint32(rawOff))
+	le.PutUint32(hdr[36:], peSectFlags)
+	le.PutUint16(buf[coffOff+2:], uint16(nsects+1))
+	// SizeOfImage.
+	le.PutUint32(buf[optOff+56:], uint32(align(rva+size, sectAlign)))
+	// Clear CheckSum, as it is invalidated by the patch; only verified for
+	// drivers and system DLLs.
+	le.PutUint32(buf[optOff+64:], 0)
+	return buf, bin.Address(imageBase + uint64(rva)), uint64(rawOff), nil
+}
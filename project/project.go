@@ -0,0 +1,529 @@
+// Package project implements a persistent analysis database of a binary
+// executable.
+//
+// A project stores the sections, functions, basic blocks, names, comments,
+// user overrides and analysis results of a binary executable. The cmd tools
+// update the project incrementally, and subsequent runs open the project
+// instead of redoing discovery. Names assigned by the user are stored
+// separately from names assigned by analysis; they take precedence, and thus
+// survive re-analysis, and removing a user name restores the analysis name.
+//
+// The project records the content hash of the binary executable, and the
+// results of analysis are discarded when opened for a modified binary
+// executable.
+//
+// Projects are stored as bolt databases (see go.etcd.io/bbolt), with one
+// bucket per kind of information. Addresses are stored as 64-bit big-endian
+// keys, to iterate in ascending address order.
+//
+//    meta         version, bin_path, bin_hash and model
+//    sections     index → JSON encoded section
+//    funcs        address → (empty)
+//    blocks       address → (empty)
+//    data         address → (empty)
+//    names        address → name assigned by analysis
+//    user_names   address → name assigned by the user
+//    comments     address → comment
+//    strings      address → string literal
+//    sigs         address → C function signature
+//    results      analysis name → JSON encoded analysis result
+package project
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/annot"
+	"github.com/mewkiz/pkg/osutil"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// version specifies the version of the database schema.
+const version = "1"
+
+// Bucket names.
+var (
+	bucketMeta      = []byte("meta")
+	bucketSections  = []byte("sections")
+	bucketFuncs     = []byte("funcs")
+	bucketBlocks    = []byte("blocks")
+	bucketData      = []byte("data")
+	bucketNames     = []byte("names")
+	bucketUserNames = []byte("user_names")
+	bucketComments  = []byte("comments")
+	bucketStrings   = []byte("strings")
+	bucketSigs      = []byte("sigs")
+	bucketResults   = []byte("results")
+)
+
+// Keys of the meta bucket.
+var (
+	keyVersion = []byte("version")
+	keyBinPath = []byte("bin_path")
+	keyBinHash = []byte("bin_hash")
+	keyModel   = []byte("model")
+)
+
+// A Project is a persistent analysis database of a binary executable.
+type Project struct {
+	// Path of the project database.
+	path string
+	// Path of the binary executable.
+	BinPath string
+	// SHA-256 hash of the contents of the binary executable, in hexadecimal
+	// notation; or empty if not yet recorded.
+	BinHash string
+	// Sections of the binary executable.
+	Sections []*Section
+	// Function addresses.
+	FuncAddrs []bin.Address
+	// Basic block addresses.
+	BlockAddrs []bin.Address
+	// Data addresses.
+	DataAddrs []bin.Address
+	// Map from address to symbol name assigned by analysis.
+	Names map[bin.Address]string
+	// Map from address to symbol name assigned by the user; takes precedence
+	// over Names.
+	UserNames map[bin.Address]string
+	// Map from address to comment.
+	Comments map[bin.Address]string
+	// Map from address to string literal.
+	Strings map[bin.Address]string
+	// Map from function address to C function signature.
+	Sigs map[bin.Address]string
+	// Map from analysis name to analysis result.
+	Results map[string]json.RawMessage
+	// Serialized disassembly model (see x86.Marshal); or nil if not yet
+	// disassembled.
+	Model []byte
+}
+
+// A Section is a section of a binary executable.
+type Section struct {
+	// Section name.
+	Name string `json:"name"`
+	// Start address of the section.
+	Addr bin.Address `json:"addr"`
+	// Size in bytes of the section in memory.
+	Size int `json:"size"`
+	// Access permissions of the section in memory.
+	Perm bin.Perm `json:"perm"`
+}
+
+// New returns a new empty project, which is stored at the given path.
+func New(path string) *Project {
+	return &Project{
+		path:      path,
+		Names:     make(map[bin.Address]string),
+		UserNames: make(map[bin.Address]string),
+		Comments:  make(map[bin.Address]string),
+		Strings:   make(map[bin.Address]string),
+		Sigs:      make(map[bin.Address]string),
+		Results:   make(map[string]json.RawMessage),
+	}
+}
+
+// Open opens the project stored at the given path. A new empty project is
+// returned if no project exists at the path.
+func Open(path string) (*Project, error) {
+	p := New(path)
+	if !osutil.Exists(path) {
+		return p, nil
+	}
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: time.Second, ReadOnly: true})
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to open project %q", path)
+	}
+	defer db.Close()
+	if err := db.View(p.load); err != nil {
+		return nil, errors.Wrapf(err, "unable to load project %q", path)
+	}
+	return p, nil
+}
+
+// load loads the project from the given read transaction.
+func (p *Project) load(tx *bolt.Tx) error {
+	meta := tx.Bucket(bucketMeta)
+	if meta == nil {
+		return errors.New("missing meta bucket")
+	}
+	if v := string(meta.Get(keyVersion)); v != version {
+		return errors.Errorf("support for project version %q not yet implemented", v)
+	}
+	p.BinPath = string(meta.Get(keyBinPath))
+	p.BinHash = string(meta.Get(keyBinHash))
+	if model := meta.Get(keyModel); model != nil {
+		// Values are only valid for the lifetime of the transaction.
+		p.Model = append([]byte(nil), model...)
+	}
+	err := forEach(tx, bucketSections, func(k, v []byte) error {
+		sect := &Section{}
+		if err := json.Unmarshal(v, sect); err != nil {
+			return errors.WithStack(err)
+		}
+		p.Sections = append(p.Sections, sect)
+		return nil
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	addrLists := []struct {
+		bucket []byte
+		addrs  *[]bin.Address
+	}{
+		{bucket: bucketFuncs, addrs: &p.FuncAddrs},
+		{bucket: bucketBlocks, addrs: &p.BlockAddrs},
+		{bucket: bucketData, addrs: &p.DataAddrs},
+	}
+	for _, l := range addrLists {
+		addrs := l.addrs
+		err := forEach(tx, l.bucket, func(k, v []byte) error {
+			addr, err := parseKey(k)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			*addrs = append(*addrs, addr)
+			return nil
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	for bucket, m := range p.stringMaps() {
+		err := forEach(tx, []byte(bucket), func(k, v []byte) error {
+			addr, err := parseKey(k)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			m[addr] = string(v)
+			return nil
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return forEach(tx, bucketResults, func(k, v []byte) error {
+		p.Results[string(k)] = append(json.RawMessage(nil), v...)
+		return nil
+	})
+}
+
+// Save stores the project at its path. The project database is updated in a
+// single transaction, to prevent corruption if interrupted.
+func (p *Project) Save() error {
+	db, err := bolt.Open(p.path, 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return errors.Wrapf(err, "unable to open project %q", p.path)
+	}
+	if err := db.Update(p.store); err != nil {
+		db.Close()
+		return errors.Wrapf(err, "unable to store project %q", p.path)
+	}
+	if err := db.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// store stores the project in the given write transaction, replacing the
+// contents of each bucket.
+func (p *Project) store(tx *bolt.Tx) error {
+	meta, err := resetBucket(tx, bucketMeta)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := meta.Put(keyVersion, []byte(version)); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := meta.Put(keyBinPath, []byte(p.BinPath)); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := meta.Put(keyBinHash, []byte(p.BinHash)); err != nil {
+		return errors.WithStack(err)
+	}
+	if p.Model != nil {
+		if err := meta.Put(keyModel, p.Model); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	sects, err := resetBucket(tx, bucketSections)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for i, sect := range p.Sections {
+		buf, err := json.Marshal(sect)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		// Sections are keyed by index, as sections and segments may share
+		// start addresses.
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, uint64(i))
+		if err := sects.Put(key, buf); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	addrLists := map[string][]bin.Address{
+		string(bucketFuncs):  p.FuncAddrs,
+		string(bucketBlocks): p.BlockAddrs,
+		string(bucketData):   p.DataAddrs,
+	}
+	for name, addrs := range addrLists {
+		b, err := resetBucket(tx, []byte(name))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for _, addr := range addrs {
+			if err := b.Put(addrKey(addr), nil); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+	for name, m := range p.stringMaps() {
+		b, err := resetBucket(tx, []byte(name))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for addr, s := range m {
+			if err := b.Put(addrKey(addr), []byte(s)); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+	results, err := resetBucket(tx, bucketResults)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for analysis, buf := range p.Results {
+		if err := results.Put([]byte(analysis), buf); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// SetBinary records the path and content hash of the given binary executable.
+// If the project was created for a binary executable with different contents,
+// the results of analysis are discarded as stale; names assigned by the user
+// and comments are kept. The boolean return value reports whether the results
+// of analysis were discarded.
+func (p *Project) SetBinary(binPath string) (bool, error) {
+	hash, err := hashFile(binPath)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	stale := len(p.BinHash) > 0 && p.BinHash != hash
+	if stale {
+		p.invalidate()
+	}
+	p.BinPath = binPath
+	p.BinHash = hash
+	return stale, nil
+}
+
+// invalidate discards the results of analysis of the project.
+func (p *Project) invalidate() {
+	p.Sections = nil
+	p.FuncAddrs = nil
+	p.BlockAddrs = nil
+	p.DataAddrs = nil
+	p.Names = make(map[bin.Address]string)
+	p.Strings = make(map[bin.Address]string)
+	p.Sigs = make(map[bin.Address]string)
+	p.Results = make(map[string]json.RawMessage)
+	p.Model = nil
+}
+
+// SetFile records the path and sections of the given binary executable.
+func (p *Project) SetFile(binPath string, file *bin.File) {
+	p.BinPath = binPath
+	p.Sections = p.Sections[:0]
+	for _, sect := range file.Sections {
+		s := &Section{
+			Name: sect.Name,
+			Addr: sect.Addr,
+			Size: sect.MemSize,
+			Perm: sect.Perm,
+		}
+		p.Sections = append(p.Sections, s)
+	}
+}
+
+// Name returns the symbol name of the given address, giving precedence to
+// names assigned by the user.
+func (p *Project) Name(addr bin.Address) (string, bool) {
+	if name, ok := p.UserNames[addr]; ok {
+		return name, true
+	}
+	name, ok := p.Names[addr]
+	return name, ok
+}
+
+// Rename assigns a user-defined symbol name to the given address. An empty
+// name removes the user-defined name, restoring the name assigned by analysis.
+func (p *Project) Rename(addr bin.Address, name string) {
+	if len(name) == 0 {
+		delete(p.UserNames, addr)
+		return
+	}
+	p.UserNames[addr] = name
+}
+
+// SetComment assigns a comment to the given address. An empty comment removes
+// the comment.
+func (p *Project) SetComment(addr bin.Address, comment string) {
+	if len(comment) == 0 {
+		delete(p.Comments, addr)
+		return
+	}
+	p.Comments[addr] = comment
+}
+
+// SetResult stores the result of the given analysis.
+func (p *Project) SetResult(analysis string, v interface{}) error {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	p.Results[analysis] = buf
+	return nil
+}
+
+// Result loads the result of the given analysis into v, and reports whether the
+// result was present.
+func (p *Project) Result(analysis string, v interface{}) (bool, error) {
+	buf, ok := p.Results[analysis]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(buf, v); err != nil {
+		return false, errors.WithStack(err)
+	}
+	return true, nil
+}
+
+// Update merges the given analysis results into the project. Names assigned by
+// the user are left intact, and are not recorded as names assigned by analysis;
+// the annotations of the project, as imported by the disassembler, include
+// names assigned by the user.
+func (p *Project) Update(a *annot.Annotations) {
+	for _, funcAddr := range a.FuncAddrs {
+		p.FuncAddrs = bin.InsertAddr(p.FuncAddrs, funcAddr)
+	}
+	for _, blockAddr := range a.BlockAddrs {
+		p.BlockAddrs = bin.InsertAddr(p.BlockAddrs, blockAddr)
+	}
+	for _, dataAddr := range a.DataAddrs {
+		p.DataAddrs = bin.InsertAddr(p.DataAddrs, dataAddr)
+	}
+	for addr, name := range a.Names {
+		if userName, ok := p.UserNames[addr]; ok && userName == name {
+			continue
+		}
+		p.Names[addr] = name
+	}
+	for addr, s := range a.Strings {
+		p.Strings[addr] = s
+	}
+	for addr, sig := range a.Sigs {
+		p.Sigs[addr] = sig
+	}
+}
+
+// Annotations returns the program annotations of the project, for import into
+// the disassembler. Names assigned by the user take precedence.
+func (p *Project) Annotations() *annot.Annotations {
+	a := annot.New()
+	a.FuncAddrs = append(a.FuncAddrs, p.FuncAddrs...)
+	a.BlockAddrs = append(a.BlockAddrs, p.BlockAddrs...)
+	a.DataAddrs = append(a.DataAddrs, p.DataAddrs...)
+	for addr, name := range p.Names {
+		a.Names[addr] = name
+	}
+	for addr, name := range p.UserNames {
+		a.Names[addr] = name
+	}
+	for addr, s := range p.Strings {
+		a.Strings[addr] = s
+	}
+	for addr, sig := range p.Sigs {
+		a.Sigs[addr] = sig
+	}
+	return a
+}
+
+// ### [ Helper functions ] ####################################################
+
+// stringMaps returns the maps from address to string of the project, by bucket
+// name.
+func (p *Project) stringMaps() map[string]map[bin.Address]string {
+	return map[string]map[bin.Address]string{
+		string(bucketNames):     p.Names,
+		string(bucketUserNames): p.UserNames,
+		string(bucketComments):  p.Comments,
+		string(bucketStrings):   p.Strings,
+		string(bucketSigs):      p.Sigs,
+	}
+}
+
+// forEach invokes f for each key-value pair of the given bucket, in ascending
+// key order. Missing buckets are treated as empty.
+func forEach(tx *bolt.Tx, bucket []byte, f func(k, v []byte) error) error {
+	b := tx.Bucket(bucket)
+	if b == nil {
+		return nil
+	}
+	return b.ForEach(f)
+}
+
+// resetBucket returns the given bucket, emptied.
+func resetBucket(tx *bolt.Tx, bucket []byte) (*bolt.Bucket, error) {
+	if tx.Bucket(bucket) != nil {
+		if err := tx.DeleteBucket(bucket); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	b, err := tx.CreateBucket(bucket)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return b, nil
+}
+
+// addrKey returns the key of the given address.
+func addrKey(addr bin.Address) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(addr))
+	return key
+}
+
+// parseKey parses the given address key.
+func parseKey(key []byte) (bin.Address, error) {
+	if len(key) != 8 {
+		return 0, errors.Errorf("invalid address key % X; expected 8 bytes, got %d", key, len(key))
+	}
+	return bin.Address(binary.BigEndian.Uint64(key)), nil
+}
+
+// hashFile returns the SHA-256 hash of the contents of the given file, in
+// hexadecimal notation.
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
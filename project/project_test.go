@@ -0,0 +1,155 @@
+package project_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/decomp/exp/bin"
+	"github.com/decomp/exp/disasm/annot"
+	"github.com/decomp/exp/project"
+)
+
+func TestSaveOpen(t *testing.T) {
+	t.Parallel()
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "foo.db")
+	p := project.New(path)
+	p.BinPath = "foo.exe"
+	p.BinHash = "abc"
+	p.Sections = []*project.Section{
+		{Name: ".text", Addr: 0x401000, Size: 0x1000, Perm: bin.PermR | bin.PermX},
+		// Segment sharing the start address of a section.
+		{Name: "", Addr: 0x401000, Size: 0x2000, Perm: bin.PermR | bin.PermX},
+	}
+	p.FuncAddrs = []bin.Address{0x401000, 0x401010}
+	p.BlockAddrs = []bin.Address{0x401000, 0x401005, 0x401010}
+	p.DataAddrs = []bin.Address{0x402000}
+	p.Names[0x401010] = "f_401010"
+	p.Rename(0x401000, "main")
+	p.SetComment(0x401000, "entry point")
+	p.Strings[0x402000] = "hello"
+	p.Sigs[0x401000] = "int main(void)"
+	p.Model = []byte{0x00, 0x01, 0xFF}
+	if err := p.SetResult("stack", map[string]int{"f_401010": 16}); err != nil {
+		t.Fatalf("unable to set analysis result; %+v", err)
+	}
+	if err := p.Save(); err != nil {
+		t.Fatalf("unable to save project; %+v", err)
+	}
+	got, err := project.Open(path)
+	if err != nil {
+		t.Fatalf("unable to open project; %+v", err)
+	}
+	if !reflect.DeepEqual(got, p) {
+		t.Errorf("project mismatch; expected %#v, got %#v", p, got)
+	}
+	var stack map[string]int
+	if ok, err := got.Result("stack", &stack); !ok || err != nil {
+		t.Errorf("unable to load analysis result; present %v, error %v", ok, err)
+	}
+	if stack["f_401010"] != 16 {
+		t.Errorf("analysis result mismatch; expected 16, got %d", stack["f_401010"])
+	}
+	// Saving again replaces the contents of the project.
+	got.Rename(0x401000, "")
+	got.FuncAddrs = got.FuncAddrs[:1]
+	if err := got.Save(); err != nil {
+		t.Fatalf("unable to save project; %+v", err)
+	}
+	again, err := project.Open(path)
+	if err != nil {
+		t.Fatalf("unable to open project; %+v", err)
+	}
+	if !reflect.DeepEqual(again, got) {
+		t.Errorf("project mismatch; expected %#v, got %#v", got, again)
+	}
+}
+
+func TestOpenMissing(t *testing.T) {
+	t.Parallel()
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	p, err := project.Open(filepath.Join(dir, "missing.db"))
+	if err != nil {
+		t.Fatalf("unable to open missing project; %+v", err)
+	}
+	if len(p.FuncAddrs) != 0 || len(p.Names) != 0 {
+		t.Errorf("expected empty project, got %d functions and %d names", len(p.FuncAddrs), len(p.Names))
+	}
+}
+
+func TestUserNames(t *testing.T) {
+	t.Parallel()
+	p := project.New("foo.db")
+	a := annot.New()
+	a.Names[0x401000] = "f_401000"
+	p.Update(a)
+	p.Rename(0x401000, "main")
+	// Re-analysis imports the annotations of the project, including user names,
+	// and exports them again.
+	p.Update(p.Annotations())
+	if name, _ := p.Name(0x401000); name != "main" {
+		t.Errorf("name mismatch; expected %q, got %q", "main", name)
+	}
+	// Removing the user name restores the name assigned by analysis.
+	p.Rename(0x401000, "")
+	if name, _ := p.Name(0x401000); name != "f_401000" {
+		t.Errorf("name mismatch after removing user name; expected %q, got %q", "f_401000", name)
+	}
+}
+
+func TestSetBinary(t *testing.T) {
+	t.Parallel()
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	binPath := filepath.Join(dir, "foo.bin")
+	if err := ioutil.WriteFile(binPath, []byte{0x90, 0xC3}, 0644); err != nil {
+		t.Fatalf("unable to create binary; %+v", err)
+	}
+	p := project.New(filepath.Join(dir, "foo.db"))
+	if stale, err := p.SetBinary(binPath); stale || err != nil {
+		t.Fatalf("unable to record binary; stale %v, error %v", stale, err)
+	}
+	p.FuncAddrs = []bin.Address{0x401000}
+	p.Names[0x401000] = "f_401000"
+	p.Model = []byte{0x01}
+	p.Rename(0x401000, "main")
+	p.SetComment(0x401000, "entry point")
+	// Same contents.
+	if stale, err := p.SetBinary(binPath); stale || err != nil {
+		t.Fatalf("unable to record binary; stale %v, error %v", stale, err)
+	}
+	if len(p.FuncAddrs) != 1 {
+		t.Errorf("number of functions mismatch; expected 1, got %d", len(p.FuncAddrs))
+	}
+	// Modified contents.
+	if err := ioutil.WriteFile(binPath, []byte{0xCC, 0xC3}, 0644); err != nil {
+		t.Fatalf("unable to modify binary; %+v", err)
+	}
+	stale, err := p.SetBinary(binPath)
+	if err != nil {
+		t.Fatalf("unable to record binary; %+v", err)
+	}
+	if !stale {
+		t.Errorf("expected stale analysis results of modified binary")
+	}
+	if len(p.FuncAddrs) != 0 || len(p.Names) != 0 || p.Model != nil {
+		t.Errorf("expected discarded analysis results, got %d functions, %d names and model %v", len(p.FuncAddrs), len(p.Names), p.Model)
+	}
+	if p.UserNames[0x401000] != "main" || p.Comments[0x401000] != "entry point" {
+		t.Errorf("expected user names and comments to be kept, got %v and %v", p.UserNames, p.Comments)
+	}
+}
+
+// tempDir returns a new temporary directory.
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "project_test_")
+	if err != nil {
+		t.Fatalf("unable to create temporary directory; %+v", err)
+	}
+	return dir
+}